@@ -0,0 +1,171 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jlrickert/tapper/pkg/tapper"
+	"github.com/spf13/cobra"
+)
+
+// expandCommandAlias replaces the command word in args with the argument list
+// of the matching alias from the user config. Built-in commands always win
+// over an alias of the same name, and an expansion is not expanded again.
+// Flags before the command word, such as --keg work, are kept in place.
+func expandCommandAlias(root *cobra.Command, deps *Deps, args []string) ([]string, error) {
+	idx := commandWordIndex(root, args)
+	if idx < 0 {
+		return args, nil
+	}
+	name := args[idx]
+	if name == "help" || name == "__complete" || name == "__completeNoDesc" {
+		return args, nil
+	}
+	for _, c := range root.Commands() {
+		if c.Name() == name || c.HasAlias(name) {
+			return args, nil
+		}
+	}
+
+	aliases, err := loadCommandAliases(deps, args)
+	if err != nil || len(aliases) == 0 {
+		return args, err
+	}
+	command, ok := aliases[name]
+	if !ok {
+		return args, nil
+	}
+	expansion, err := splitAliasArgs(command)
+	if err != nil {
+		return nil, fmt.Errorf("invalid alias %q: %w", name, err)
+	}
+	out := make([]string, 0, len(args)+len(expansion))
+	out = append(out, args[:idx]...)
+	out = append(out, expansion...)
+	return append(out, args[idx+1:]...), nil
+}
+
+// commandWordIndex returns the index of the first positional argument, which
+// is the command name, skipping root flags and their values.
+func commandWordIndex(root *cobra.Command, args []string) int {
+	flags := root.PersistentFlags()
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			return -1
+		}
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			return i
+		}
+		if strings.Contains(arg, "=") {
+			continue
+		}
+		var takesValue bool
+		if strings.HasPrefix(arg, "--") {
+			f := flags.Lookup(strings.TrimPrefix(arg, "--"))
+			takesValue = f != nil && f.NoOptDefVal == ""
+		} else if len(arg) == 2 {
+			f := flags.ShorthandLookup(arg[1:])
+			takesValue = f != nil && f.NoOptDefVal == ""
+		}
+		if takesValue {
+			i++
+		}
+	}
+	return -1
+}
+
+// loadCommandAliases reads the aliases of the merged user and project config,
+// or of the file named by --config when args contain it.
+func loadCommandAliases(deps *Deps, args []string) (map[string]string, error) {
+	wd, err := deps.Runtime.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	svc, err := tapper.NewConfigService(wd, deps.Runtime)
+	if err != nil {
+		return nil, err
+	}
+	svc.ConfigPath = configFlagValue(args)
+	return svc.Config(false).Aliases(), nil
+}
+
+// configFlagValue returns the value of --config or -c in args, if any.
+func configFlagValue(args []string) string {
+	for i, arg := range args {
+		switch {
+		case arg == "--":
+			return ""
+		case (arg == "--config" || arg == "-c") && i+1 < len(args):
+			return args[i+1]
+		case strings.HasPrefix(arg, "--config="):
+			return strings.TrimPrefix(arg, "--config=")
+		}
+	}
+	return ""
+}
+
+// splitAliasArgs splits an alias command into arguments like a POSIX shell
+// would: on whitespace, honoring single quotes, double quotes and backslash
+// escapes.
+func splitAliasArgs(s string) ([]string, error) {
+	var (
+		args    []string
+		cur     strings.Builder
+		inWord  bool
+		quote   rune
+		escaped bool
+	)
+	for _, r := range s {
+		switch {
+		case escaped:
+			cur.WriteRune(r)
+			escaped = false
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else if r == '\\' && quote == '"' {
+				escaped = true
+			} else {
+				cur.WriteRune(r)
+			}
+		case r == '\\':
+			escaped, inWord = true, true
+		case r == '\'' || r == '"':
+			quote, inWord = r, true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inWord {
+				args = append(args, cur.String())
+				cur.Reset()
+				inWord = false
+			}
+		default:
+			cur.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+	}
+	if escaped {
+		return nil, fmt.Errorf("trailing backslash")
+	}
+	if inWord {
+		args = append(args, cur.String())
+	}
+	return args, nil
+}
+
+// joinAliasArgs is the inverse of splitAliasArgs, quoting arguments that
+// contain whitespace or quotes.
+func joinAliasArgs(args []string) string {
+	parts := make([]string, 0, len(args))
+	for _, arg := range args {
+		if arg != "" && !strings.ContainsAny(arg, " \t\n'\"\\") {
+			parts = append(parts, arg)
+			continue
+		}
+		parts = append(parts, "'"+strings.ReplaceAll(arg, "'", `'\''`)+"'")
+	}
+	return strings.Join(parts, " ")
+}
@@ -3,11 +3,14 @@ package cli
 import (
 	"context"
 	"errors"
-	"fmt"
+	"io"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/jlrickert/cli-toolkit/toolkit"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 func Run(ctx context.Context, rt *toolkit.Runtime, args []string) (int, error) {
@@ -40,23 +43,55 @@ func RunWithProfile(ctx context.Context, rt *toolkit.Runtime, args []string, pro
 		Profile:  profile,
 	}
 	cmd := NewRootCmd(deps)
+	if profile.withDefaults().IncludeConfigCommand {
+		expanded, err := expandCommandAlias(cmd, deps, args)
+		if err != nil {
+			writeUserError(streams.Err, err, nil, deps)
+			return exitCode(err), err
+		}
+		args = expanded
+	}
 	cmd.SetArgs(args)
 	cmd.SetIn(streams.In)
 	cmd.SetOut(streams.Out)
 	cmd.SetErr(streams.Err)
 
-	if err := cmd.ExecuteContext(ctx); err != nil {
-		_, _ = fmt.Fprintf(streams.Err, "Error: %s\n", renderUserError(err, deps))
-
-		if errors.Is(err, context.Canceled) ||
-			errors.Is(err, context.DeadlineExceeded) {
-			return 130, err
-		}
-		return 1, err
+	start := time.Now()
+	executed, err := cmd.ExecuteContextC(ctx)
+	if err != nil && executed != nil && !executed.Flags().Parsed() {
+		// Cobra reports an unknown command before it parses any flags.
+		err = unknownCommandError(cmd, args, err)
+	}
+	if pageErr := flushPager(ctx, deps); err == nil {
+		err = pageErr
+	}
+	logCommand(ctx, deps, executed, start, err)
+	closeTelemetry(ctx, deps, err)
+	closeLogging(deps)
+	if err != nil {
+		writeUserError(streams.Err, err, executed, deps)
+		return exitCode(err), err
 	}
 	return 0, nil
 }
 
+// unknownCommandError marks err, returned before root parsed any flags, as a
+// usage error. The root's persistent flags are read from args first so the
+// failure is still reported in the requested --error-format.
+func unknownCommandError(root *cobra.Command, args []string, err error) error {
+	flags := pflag.NewFlagSet(root.Name(), pflag.ContinueOnError)
+	flags.ParseErrorsWhitelist.UnknownFlags = true
+	flags.SetOutput(io.Discard)
+	flags.AddFlagSet(root.PersistentFlags())
+	_ = flags.Parse(args)
+
+	var usage *usageError
+	if errors.As(err, &usage) {
+		return err
+	}
+	return &usageError{err}
+}
+
 func RunCompletion(ctx context.Context, rt *toolkit.Runtime, args []string) (int, error) {
 	return Run(ctx, rt, append([]string{"__complete"}, args...))
 }
@@ -0,0 +1,116 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/jlrickert/tapper/pkg/tapper"
+	"github.com/spf13/cobra"
+)
+
+// NewAliasCmd returns the `alias` cobra command for managing user-defined
+// command aliases.
+//
+// Usage examples:
+//
+//	tap alias list
+//	tap alias add wls ls --keg work --sort updated
+//	tap alias rm wls
+func NewAliasCmd(deps *Deps) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "alias",
+		Short: "manage command aliases",
+		Long: `Manage command aliases stored under aliases: in the user config.
+
+An alias maps a short name to an argument list. When the first command word
+is an alias, it is replaced by that list before the command line is parsed,
+so "tap wls 3" with the alias "wls: ls --keg work" runs "tap ls --keg work 3".
+Built-in commands cannot be shadowed.`,
+	}
+	cmd.AddCommand(
+		newAliasListCmd(deps),
+		newAliasAddCmd(deps),
+		newAliasRmCmd(deps),
+	)
+	return cmd
+}
+
+func newAliasListCmd(deps *Deps) *cobra.Command {
+	return &cobra.Command{
+		Use:     "list",
+		Short:   "list command aliases",
+		Aliases: []string{"ls"},
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			for _, alias := range deps.Tap.ListAliases() {
+				if _, err := fmt.Fprintf(cmd.OutOrStdout(), "%s\t%s\n", alias.Name, alias.Command); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+}
+
+func newAliasAddCmd(deps *Deps) *cobra.Command {
+	var opts tapper.AddAliasOptions
+
+	cmd := &cobra.Command{
+		Use:   "add NAME COMMAND [ARG...]",
+		Short: "define a command alias",
+		Long: `Define NAME as an alias for COMMAND and its arguments.
+
+Everything after NAME is stored as the expansion, including flags, so
+"tap alias add wls ls --keg work --sort updated" needs no quoting. A single
+quoted argument is stored as written.`,
+		Args: cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.Name = args[0]
+			if c, _, err := cmd.Root().Find([]string{opts.Name}); err == nil && c != cmd.Root() {
+				return fmt.Errorf("alias %q would shadow the built-in %q command", opts.Name, c.Name())
+			}
+			opts.Command = args[1]
+			if len(args) > 2 {
+				opts.Command = joinAliasArgs(args[1:])
+			}
+			if _, err := splitAliasArgs(opts.Command); err != nil {
+				return fmt.Errorf("invalid alias %q: %w", opts.Name, err)
+			}
+			if err := deps.Tap.AddAlias(cmd.Context(), opts); err != nil {
+				return err
+			}
+			_, err := fmt.Fprintf(cmd.OutOrStdout(), "added alias %s = %s\n", opts.Name, opts.Command)
+			return err
+		},
+	}
+	// Flags after NAME belong to the expansion, not to alias add.
+	cmd.Flags().SetInterspersed(false)
+	cmd.Flags().BoolVar(&opts.Force, "force", false, "replace an existing alias")
+	return cmd
+}
+
+func newAliasRmCmd(deps *Deps) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "rm NAME",
+		Short:   "remove a command alias",
+		Aliases: []string{"remove"},
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := deps.Tap.RemoveAlias(cmd.Context(), args[0]); err != nil {
+				return err
+			}
+			_, err := fmt.Fprintf(cmd.OutOrStdout(), "removed alias %s\n", args[0])
+			return err
+		},
+	}
+	cmd.ValidArgsFunction = func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 || deps.Tap == nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		var names []string
+		for _, alias := range deps.Tap.ListAliases() {
+			names = append(names, alias.Name)
+		}
+		return names, cobra.ShellCompDirectiveNoFileComp
+	}
+	return cmd
+}
@@ -0,0 +1,72 @@
+package cli_test
+
+import (
+	"testing"
+
+	testutils "github.com/jlrickert/cli-toolkit/sandbox"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAliasCommand_AddListRunRemove(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("joe", "~"))
+
+	res := NewProcess(t, false, "alias", "add", "ids", "ls", "--keg", "personal", "--format", "%i: %t", "--sort", "id").
+		Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	require.Contains(t, string(res.Stdout), "added alias ids = ls --keg personal --format '%i: %t' --sort id")
+
+	cfg := string(sb.MustReadFile("~/.config/tapper/config.yaml"))
+	require.Contains(t, cfg, "aliases:")
+
+	res = NewProcess(t, false, "alias", "list").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	require.Equal(t, "ids\tls --keg personal --format '%i: %t' --sort id\n", string(res.Stdout))
+
+	res = NewProcess(t, false, "ids", "--reverse").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	require.Equal(t, "3: Meeting Notes\n2: Project Alpha\n1: Personal Overview\n0: Sorry, planned but not yet available\n", string(res.Stdout))
+
+	res = NewProcess(t, false, "--no-pager", "ids").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	require.Contains(t, string(res.Stdout), "1: Personal Overview\n")
+
+	res = NewProcess(t, false, "alias", "rm", "ids").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+
+	res = NewProcess(t, false, "ids").Run(sb.Context(), sb.Runtime())
+	require.Error(t, res.Err)
+}
+
+func TestAliasCommand_ExpandsConfiguredAlias(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("joe", "~"))
+	cfg := string(sb.MustReadFile("~/.config/tapper/config.yaml"))
+	sb.MustWriteFile("~/.config/tapper/config.yaml", []byte(cfg+"\naliases:\n  title: cat --meta-only\n"), 0o644)
+
+	res := NewProcess(t, false, "-k", "personal", "title", "2").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	require.Contains(t, string(res.Stdout), "entity: concept")
+	require.NotContains(t, string(res.Stdout), "# Project Alpha")
+}
+
+func TestAliasCommand_RejectsInvalidAliases(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("joe", "~"))
+
+	res := NewProcess(t, false, "alias", "add", "ls", "cat").Run(sb.Context(), sb.Runtime())
+	require.Error(t, res.Err)
+	require.Contains(t, res.Err.Error(), `would shadow the built-in "list" command`)
+
+	res = NewProcess(t, false, "alias", "add", "12", "cat").Run(sb.Context(), sb.Runtime())
+	require.Error(t, res.Err)
+	require.Contains(t, res.Err.Error(), "numeric names are node IDs")
+
+	res = NewProcess(t, false, "alias", "add", "x", "cat").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	res = NewProcess(t, false, "alias", "add", "x", "ls").Run(sb.Context(), sb.Runtime())
+	require.Error(t, res.Err)
+	require.Contains(t, res.Err.Error(), "use --force to replace it")
+	res = NewProcess(t, false, "alias", "add", "--force", "x", "ls").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+}
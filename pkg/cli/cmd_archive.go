@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
@@ -9,23 +10,96 @@ import (
 )
 
 func NewArchiveCmd(deps *Deps) *cobra.Command {
+	var opts tapper.ArchiveNodeOptions
+
 	cmd := &cobra.Command{
-		Use:   "archive",
-		Short: "import and export keg archives",
-		Long:  `Export nodes to a tar archive or import nodes from one.`,
+		Use:   "archive [NODE_ID...]",
+		Short: "archive nodes or import and export keg archives",
+		Long: `Archive nodes, or export nodes to a tar archive and import nodes from one.
+
+With node IDs, each node is moved under the keg's archive/ area. Archived
+nodes drop out of nodes.tsv and changes.md but stay readable with
+"tap cat --archived" and can be restored with "tap unarchive". A NODE_ID of
+"-" reads node IDs from stdin.`,
+		ValidArgsFunction: nodeIDCompletionFunc(deps, 0),
+		Annotations:       nodeArgAnnotations(0),
+		Args:              cobra.ArbitraryArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return cmd.Help()
+			}
+			ids, err := nodeArgs(cmd, deps, args)
+			if err != nil {
+				return err
+			}
+			opts.NodeIDs = ids
+			applyKegTargetProfile(deps, &opts.KegTargetOptions)
+			return deps.Tap.ArchiveNodes(cmd.Context(), opts)
+		},
 	}
 
 	cmd.AddCommand(
 		NewArchiveExportCmd(deps),
 		NewArchiveImportCmd(deps),
+		newArchiveListCmd(deps),
 	)
 	return cmd
 }
 
+func newArchiveListCmd(deps *Deps) *cobra.Command {
+	var opts tapper.KegTargetOptions
+
+	return &cobra.Command{
+		Use:   "list",
+		Short: "list archived nodes",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			applyKegTargetProfile(deps, &opts)
+			lines, err := deps.Tap.ListArchived(cmd.Context(), opts)
+			if err != nil {
+				return err
+			}
+			for _, line := range lines {
+				if _, err := fmt.Fprintln(cmd.OutOrStdout(), line); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// NewUnarchiveCmd returns the `unarchive` cobra command, which restores nodes
+// archived with `tap archive NODE_ID`.
+func NewUnarchiveCmd(deps *Deps) *cobra.Command {
+	var opts tapper.ArchiveNodeOptions
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "unarchive NODE_ID...",
+		Short: "restore archived nodes",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ids, err := nodeArgs(cmd, deps, args)
+			if err != nil {
+				return err
+			}
+			opts.NodeIDs = ids
+			applyKegTargetProfile(deps, &opts.KegTargetOptions)
+			return runWithDryRun(cmd, deps, dryRun, func(ctx context.Context) error {
+				return deps.Tap.UnarchiveNodes(ctx, opts)
+			})
+		},
+	}
+	addDryRunFlag(cmd, &dryRun)
+	return cmd
+}
+
 func NewArchiveExportCmd(deps *Deps) *cobra.Command {
 	var opts tapper.ExportOptions
 	var rawNodes string
 	var noHistory bool
+	var backlinks bool
 
 	opts.WithHistory = true
 
@@ -36,6 +110,9 @@ func NewArchiveExportCmd(deps *Deps) *cobra.Command {
 			applyKegTargetProfile(deps, &opts.KegTargetOptions)
 			opts.NodeIDs = splitArchiveNodeList(rawNodes)
 			opts.WithHistory = !noHistory
+			if cmd.Flags().Changed("backlinks") {
+				opts.Backlinks = &backlinks
+			}
 			path, err := deps.Tap.Export(cmd.Context(), opts)
 			if err != nil {
 				return err
@@ -47,14 +124,17 @@ func NewArchiveExportCmd(deps *Deps) *cobra.Command {
 
 	cmd.Flags().StringVar(&rawNodes, "nodes", "", "comma-separated node IDs to export (default all nodes)")
 	cmd.Flags().BoolVar(&noHistory, "no-history", false, "omit snapshot history from the archive")
-	cmd.Flags().StringVarP(&opts.OutputPath, "output", "o", "", "archive output path")
-	_ = cmd.MarkFlagRequired("output")
-	_ = cmd.MarkFlagFilename("output", "tar", "tar.gz", "tgz", "gz")
+	cmd.Flags().BoolVar(&backlinks, "backlinks", false, "append a generated backlinks section to each node (default from keg export.backlinks)")
+	cmd.Flags().BoolVar(&opts.Unlock, "unlock", false, "export nodes with encrypted content as plaintext instead of encrypted")
+	cmd.Flags().StringVarP(&opts.OutputPath, "out-file", "o", "", "archive output path")
+	_ = cmd.MarkFlagRequired("out-file")
+	_ = cmd.MarkFlagFilename("out-file", "tar", "tar.gz", "tgz", "gz")
 	return cmd
 }
 
 func NewArchiveImportCmd(deps *Deps) *cobra.Command {
 	var opts tapper.ImportOptions
+	var dryRun bool
 
 	cmd := &cobra.Command{
 		Use:   "import ARCHIVE",
@@ -69,18 +149,21 @@ func NewArchiveImportCmd(deps *Deps) *cobra.Command {
 		RunE: func(cmd *cobra.Command, args []string) error {
 			applyKegTargetProfile(deps, &opts.KegTargetOptions)
 			opts.Input = args[0]
-			imported, err := deps.Tap.Import(cmd.Context(), opts)
-			if err != nil {
-				return err
-			}
-			for _, id := range imported {
-				if _, err := fmt.Fprintln(cmd.OutOrStdout(), id.Path()); err != nil {
+			return runWithDryRun(cmd, deps, dryRun, func(ctx context.Context) error {
+				imported, err := deps.Tap.Import(ctx, opts)
+				if err != nil {
 					return err
 				}
-			}
-			return nil
+				for _, id := range imported {
+					if _, err := fmt.Fprintln(cmd.OutOrStdout(), id.Path()); err != nil {
+						return err
+					}
+				}
+				return nil
+			})
 		},
 	}
+	addDryRunFlag(cmd, &dryRun)
 	return cmd
 }
 
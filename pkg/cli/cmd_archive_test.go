@@ -0,0 +1,82 @@
+package cli_test
+
+import (
+	"testing"
+
+	testutils "github.com/jlrickert/cli-toolkit/sandbox"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArchiveCommand_ArchivesAndUnarchivesNode(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("joe", "~"))
+
+	res := NewProcess(t, false, "archive", "3").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+
+	list := NewProcess(t, false, "list", "--id-only").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, list.Err)
+	require.NotContains(t, string(list.Stdout), "3")
+	nodes := NewProcess(t, false, "index", "get", "nodes.tsv").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, nodes.Err)
+	require.NotContains(t, string(nodes.Stdout), "Meeting Notes")
+
+	cat := NewProcess(t, false, "cat", "3").Run(sb.Context(), sb.Runtime())
+	require.Error(t, cat.Err)
+
+	cat = NewProcess(t, false, "cat", "--archived", "--content-only", "3").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, cat.Err)
+	require.Contains(t, string(cat.Stdout), "# Meeting Notes")
+
+	archived := NewProcess(t, false, "archive", "list").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, archived.Err)
+	require.Equal(t, "3\tMeeting Notes\n", string(archived.Stdout))
+
+	res = NewProcess(t, false, "unarchive", "3").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+
+	cat = NewProcess(t, false, "cat", "--content-only", "3").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, cat.Err)
+	require.Contains(t, string(cat.Stdout), "# Meeting Notes")
+
+	res = NewProcess(t, false, "unarchive", "3").Run(sb.Context(), sb.Runtime())
+	require.Error(t, res.Err)
+	require.Contains(t, res.Err.Error(), "archived node 3 not found")
+}
+
+func TestArchiveExport_KeepsQueryBlocks(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("testuser", "~"))
+
+	for _, args := range [][]string{
+		{"--title", "Channels", "--tags", "go"},
+		{"--title", "Index", "--body", "# Index\n\n```keg-query\ntags: go\n```\n"},
+	} {
+		res := NewProcess(t, false, append([]string{"create"}, args...)...).Run(sb.Context(), sb.Runtime())
+		require.NoError(t, res.Err)
+	}
+
+	readIndex := func(path string) string {
+		return readArchiveFile(t, sb.MustReadFile(path), "keg-archive/nodes/2/README.md")
+	}
+
+	res := NewProcess(t, false, "archive", "export", "--nodes", "2", "-o", "~/plain.keg.tar.gz").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	require.Equal(t, "# Index\n\n```keg-query\ntags: go\n```\n", readIndex("~/plain.keg.tar.gz"))
+
+	res = NewProcess(t, false, "archive", "export", "--nodes", "2", "--query-blocks", "-o", "~/expanded.keg.tar.gz").Run(sb.Context(), sb.Runtime())
+	require.Error(t, res.Err, "archives are never expanded")
+}
+
+func TestArchiveExport_OutFileLeavesGlobalOutputFlag(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("joe", "~"))
+
+	res := NewProcess(t, false, "archive", "export", "--keg", "personal", "--nodes", "1", "--out-file", "~/long.keg.tar.gz", "--output", "json").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err, string(res.Stderr))
+	readArchiveFile(t, sb.MustReadFile("~/long.keg.tar.gz"), "keg-archive/nodes/1/README.md")
+
+	res = NewProcess(t, false, "archive", "export", "--keg", "personal", "--nodes", "1", "--output", "json").Run(sb.Context(), sb.Runtime())
+	require.Error(t, res.Err, "--output is the format flag, not the archive path")
+	require.Contains(t, res.Err.Error(), "out-file")
+}
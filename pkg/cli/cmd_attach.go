@@ -0,0 +1,176 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/jlrickert/tapper/pkg/keg"
+	"github.com/jlrickert/tapper/pkg/tapper"
+	"github.com/spf13/cobra"
+)
+
+func NewAttachCmd(deps *Deps) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "attach",
+		Short: "attach content to a node",
+	}
+
+	cmd.AddCommand(
+		newAttachCatCmd(deps),
+		newAttachFetchCmd(deps),
+		newAttachLsCmd(deps),
+		newAttachPasteCmd(deps),
+	)
+
+	return cmd
+}
+
+func newAttachCatCmd(deps *Deps) *cobra.Command {
+	var opts tapper.CatAttachmentOptions
+
+	cmd := &cobra.Command{
+		Use:   "cat NODE_ID NAME",
+		Short: "stream an image or file attachment to stdout",
+		Long: `Write the raw bytes of an image or file attachment of NODE_ID to stdout, or
+to --out when given, so binary items can be piped to other tools.
+
+NAME may be a path as printed by "attach ls" (images/x.png, assets/x.pdf) or a
+bare filename, which is looked up among file attachments first and images
+second.`,
+		Args:              cobra.ExactArgs(2),
+		ValidArgsFunction: nodeIDCompletionFunc(deps, 1),
+		Annotations:       nodeArgAnnotations(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.NodeID = args[0]
+			opts.Name = args[1]
+			applyKegTargetProfile(deps, &opts.KegTargetOptions)
+			_, err := deps.Tap.CatAttachment(cmd.Context(), opts, cmd.OutOrStdout())
+			return err
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.Out, "out", "o", "", "write to this file instead of stdout")
+	return cmd
+}
+
+func newAttachFetchCmd(deps *Deps) *cobra.Command {
+	var opts tapper.FetchAttachmentOptions
+	var maxSize string
+
+	cmd := &cobra.Command{
+		Use:   "fetch NODE_ID URL",
+		Short: "download a remote file onto a node",
+		Long: `Download URL and store it on NODE_ID. Responses with an image content type
+are stored as images, anything else as a file attachment. The source URL is
+recorded in the item metadata and the local path is printed.
+
+HTML pages are refused unless --accept allows them. With --rewrite every
+occurrence of URL in the node content is replaced by the local path.`,
+		Args:              cobra.ExactArgs(2),
+		ValidArgsFunction: nodeIDCompletionFunc(deps, 1),
+		Annotations:       nodeArgAnnotations(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.NodeID = args[0]
+			opts.URL = args[1]
+			if maxSize != "" {
+				n, err := keg.ParseByteSize(maxSize)
+				if err != nil {
+					return err
+				}
+				opts.MaxSize = n
+			}
+			applyKegTargetProfile(deps, &opts.KegTargetOptions)
+			res, err := deps.Tap.FetchAttachment(cmd.Context(), opts)
+			if err != nil {
+				return err
+			}
+			if opts.Rewrite {
+				fmt.Fprintf(cmd.ErrOrStderr(), "rewrote %d link(s) in node %s\n", res.Rewritten, opts.NodeID)
+			}
+			_, err = fmt.Fprintln(cmd.OutOrStdout(), res.Path)
+			return err
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.Name, "name", "", "stored filename (default: last URL path segment)")
+	cmd.Flags().StringVar(&opts.As, "as", "", "store as image or file (default: detect from content type)")
+	cmd.Flags().StringVar(&opts.Alt, "alt", "", "alternative text recorded for images")
+	cmd.Flags().StringVar(&maxSize, "max-size", "", "maximum download size, e.g. 10MB (default 25MB)")
+	cmd.Flags().StringSliceVar(&opts.Accept, "accept", nil, "allowed content types, e.g. image/*,application/pdf")
+	cmd.Flags().BoolVar(&opts.Rewrite, "rewrite", false, "replace URL in the node content with the local path")
+	return cmd
+}
+
+func newAttachLsCmd(deps *Deps) *cobra.Command {
+	var opts tapper.ListAttachmentsOptions
+
+	cmd := &cobra.Command{
+		Use:     "ls NODE_ID",
+		Short:   "list images and file attachments of a node",
+		Aliases: []string{"list"},
+		Long: `List the images and file attachments of NODE_ID by their path relative to
+the node directory.
+
+With --long each line is tab separated: path, size in bytes, MIME type,
+checksum and alt text.`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: nodeIDCompletionFunc(deps, 1),
+		Annotations:       nodeArgAnnotations(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.NodeID = args[0]
+			applyKegTargetProfile(deps, &opts.KegTargetOptions)
+			items, err := deps.Tap.ListAttachments(cmd.Context(), opts)
+			if err != nil {
+				return err
+			}
+			out := cmd.OutOrStdout()
+			for _, item := range items {
+				if !opts.Long {
+					_, err = fmt.Fprintln(out, tapper.AttachmentPath(item))
+				} else {
+					_, err = fmt.Fprintf(out, "%s\t%d\t%s\t%s\t%s\n", tapper.AttachmentPath(item),
+						item.Meta.Size, item.Meta.MimeType, item.Meta.Checksum, item.Meta.Alt)
+				}
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVarP(&opts.Long, "long", "l", false, "include size, MIME type, checksum and alt text")
+	return cmd
+}
+
+func newAttachPasteCmd(deps *Deps) *cobra.Command {
+	var opts tapper.PasteImageOptions
+
+	cmd := &cobra.Command{
+		Use:   "paste NODE_ID",
+		Short: "attach the clipboard image to a node",
+		Long: `Read an image from the system clipboard, store it on NODE_ID and print a
+Markdown image reference for the node content.
+
+The clipboard is read with pngpaste on macOS, wl-paste or xclip on Linux and
+PowerShell on Windows. Set ` + tapper.ClipboardCmdEnvKey + ` to use a different command that
+writes the image to stdout.`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: nodeIDCompletionFunc(deps, 1),
+		Annotations:       nodeArgAnnotations(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.NodeID = args[0]
+			applyKegTargetProfile(deps, &opts.KegTargetOptions)
+			res, err := deps.Tap.PasteImage(cmd.Context(), opts)
+			if err != nil {
+				return err
+			}
+			_, err = fmt.Fprintln(cmd.OutOrStdout(), res.Markdown)
+			return err
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.Name, "name", "", "stored filename (default: paste-YYYYMMDD-HHMMSS with a detected extension)")
+	cmd.Flags().StringVar(&opts.Alt, "alt", "", "alternative text for the image (default: stored filename)")
+	cmd.Flags().BoolVar(&opts.KeepMetadata, "keep-metadata", false, "keep EXIF/XMP metadata even when the keg strips it on upload")
+	return cmd
+}
@@ -0,0 +1,227 @@
+package cli_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// clipboardWith writes data to a real file and returns a clipboard command
+// that prints it.
+func clipboardWith(t *testing.T, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "clipboard")
+	require.NoError(t, os.WriteFile(path, data, 0o644))
+	return "/bin/cat " + path
+}
+
+func TestAttachPaste_StoresClipboardImage(t *testing.T) {
+	t.Parallel()
+	sb := imageFixture(t)
+	png := sb.MustReadFile("~/test-images/default.png")
+	sb.Runtime().Set("TAP_CLIPBOARD_CMD", clipboardWith(t, png))
+
+	res := NewProcess(t, false, "attach", "paste", "0").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err, string(res.Stderr))
+
+	name := "paste-" + sb.Now().Format("20060102-150405") + ".png"
+	require.Equal(t, "!["+name+"](images/"+name+")", strings.TrimSpace(string(res.Stdout)))
+	require.Equal(t, png, sb.MustReadFile("~/kegs/example/0/images/"+name))
+}
+
+func TestAttachPaste_CustomName(t *testing.T) {
+	t.Parallel()
+	sb := imageFixture(t)
+	sb.Runtime().Set("TAP_CLIPBOARD_CMD", clipboardWith(t, sb.MustReadFile("~/test-images/default.png")))
+
+	res := NewProcess(t, false, "attach", "paste", "0", "--name", "diagram.png").
+		Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	require.Equal(t, "![diagram.png](images/diagram.png)", strings.TrimSpace(string(res.Stdout)))
+}
+
+func TestAttachPaste_ClipboardWithoutImage(t *testing.T) {
+	t.Parallel()
+	sb := imageFixture(t)
+	sb.Runtime().Set("TAP_CLIPBOARD_CMD", clipboardWith(t, []byte("just some text")))
+
+	res := NewProcess(t, false, "attach", "paste", "0").Run(sb.Context(), sb.Runtime())
+	require.Error(t, res.Err)
+	require.Contains(t, res.Err.Error(), "clipboard does not contain an image")
+
+	res = NewProcess(t, false, "image", "ls", "0").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	require.Empty(t, strings.TrimSpace(string(res.Stdout)))
+}
+
+func TestAttachLs_LongShowsRecordedMetadata(t *testing.T) {
+	t.Parallel()
+	sb := imageFixture(t)
+	sb.MustWriteFile("~/notes.txt", []byte("hello\n"), 0o644)
+
+	res := NewProcess(t, false, "image", "upload", "0", "~/test-images/default.png", "--alt", "Tapper logo").
+		Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	res = NewProcess(t, false, "file", "upload", "0", "~/notes.txt").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+
+	res = NewProcess(t, false, "attach", "ls", "0").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	require.Equal(t, "images/default.png\nassets/notes.txt\n", string(res.Stdout))
+
+	res = NewProcess(t, false, "attach", "ls", "0", "--long").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	lines := strings.Split(strings.TrimSuffix(string(res.Stdout), "\n"), "\n")
+	require.Len(t, lines, 2)
+
+	image := strings.Split(lines[0], "\t")
+	require.Len(t, image, 5)
+	require.Equal(t, "images/default.png", image[0])
+	require.Equal(t, "image/png", image[2])
+	require.True(t, strings.HasPrefix(image[3], "sha256:"))
+	require.Equal(t, "Tapper logo", image[4])
+
+	require.Equal(t,
+		"assets/notes.txt\t6\ttext/plain\tsha256:5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03\t",
+		lines[1])
+
+	meta := sb.MustReadFile("~/kegs/example/0/images/.meta/default.png.json")
+	require.Contains(t, string(meta), `"alt": "Tapper logo"`)
+}
+
+func fetchServer(t *testing.T, png []byte) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/img/logo":
+			w.Header().Set("Content-Type", "image/png")
+			_, _ = w.Write(png)
+		case "/docs/spec.pdf":
+			w.Header().Set("Content-Type", "application/pdf")
+			_, _ = w.Write([]byte("%PDF-1.4 spec"))
+		default:
+			w.Header().Set("Content-Type", "text/html")
+			_, _ = w.Write([]byte("<html>page</html>"))
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestAttachFetch_StoresImageAndRewritesContent(t *testing.T) {
+	t.Parallel()
+	sb := imageFixture(t)
+	srv := fetchServer(t, sb.MustReadFile("~/test-images/default.png"))
+	url := srv.URL + "/img/logo"
+	sb.MustWriteFile("~/kegs/example/0/README.md", []byte("# Sorry\n\n![logo]("+url+")\n"), 0o644)
+
+	res := NewProcess(t, false, "attach", "fetch", "0", url, "--rewrite").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err, string(res.Stderr))
+	require.Equal(t, "images/logo.png\n", string(res.Stdout))
+	require.Contains(t, string(res.Stderr), "rewrote 1 link(s)")
+
+	require.Equal(t, "# Sorry\n\n![logo](images/logo.png)\n", string(sb.MustReadFile("~/kegs/example/0/README.md")))
+	meta := sb.MustReadFile("~/kegs/example/0/images/.meta/logo.png.json")
+	require.Contains(t, string(meta), `"source_url": "`+url+`"`)
+}
+
+func TestAttachFetch_StoresFileAttachment(t *testing.T) {
+	t.Parallel()
+	sb := imageFixture(t)
+	srv := fetchServer(t, nil)
+
+	res := NewProcess(t, false, "attach", "fetch", "0", srv.URL+"/docs/spec.pdf").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	require.Equal(t, "assets/spec.pdf\n", string(res.Stdout))
+	require.Equal(t, "%PDF-1.4 spec", string(sb.MustReadFile("~/kegs/example/0/assets/spec.pdf")))
+}
+
+func TestAttachFetch_RejectsOversizedAndHTML(t *testing.T) {
+	t.Parallel()
+	sb := imageFixture(t)
+	srv := fetchServer(t, sb.MustReadFile("~/test-images/default.png"))
+
+	res := NewProcess(t, false, "attach", "fetch", "0", srv.URL+"/img/logo", "--max-size", "10B").
+		Run(sb.Context(), sb.Runtime())
+	require.Error(t, res.Err)
+	require.Contains(t, res.Err.Error(), "exceeds limit of 10 bytes")
+
+	res = NewProcess(t, false, "attach", "fetch", "0", srv.URL+"/index").Run(sb.Context(), sb.Runtime())
+	require.Error(t, res.Err)
+	require.Contains(t, res.Err.Error(), `content type "text/html" is not accepted`)
+
+	res = NewProcess(t, false, "attach", "ls", "0").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	require.Empty(t, string(res.Stdout))
+}
+
+func TestAttachFetch_MissingNodeSkipsDownload(t *testing.T) {
+	t.Parallel()
+	sb := imageFixture(t)
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		_, _ = w.Write([]byte("data"))
+	}))
+	t.Cleanup(srv.Close)
+
+	res := NewProcess(t, false, "attach", "fetch", "99", srv.URL+"/file.txt").Run(sb.Context(), sb.Runtime())
+	require.Error(t, res.Err)
+	require.Contains(t, res.Err.Error(), "99")
+	require.Zero(t, hits.Load(), "nothing is downloaded for a missing node")
+}
+
+func TestAttachCat_StreamsImageAndFile(t *testing.T) {
+	t.Parallel()
+	sb := imageFixture(t)
+	png := sb.MustReadFile("~/test-images/default.png")
+
+	res := NewProcess(t, false, "image", "upload", "0", "~/test-images/default.png").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	res = NewProcess(t, false, "attach", "cat", "0", "images/default.png").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err, string(res.Stderr))
+	require.Equal(t, png, res.Stdout)
+
+	sb.MustWriteFile("~/notes.txt", []byte("hello\n"), 0o644)
+	res = NewProcess(t, false, "file", "upload", "0", "~/notes.txt").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	res = NewProcess(t, false, "attach", "cat", "0", "notes.txt").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	require.Equal(t, "hello\n", string(res.Stdout))
+
+	// Bare names fall back to images.
+	res = NewProcess(t, false, "attach", "cat", "0", "default.png", "--out", "~/copy.png").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	require.Empty(t, res.Stdout)
+	require.Equal(t, png, sb.MustReadFile("~/copy.png"))
+}
+
+func TestAttachCat_FollowsBlobPointers(t *testing.T) {
+	t.Parallel()
+	sb := imageFixture(t)
+	cfg := sb.MustReadFile("~/kegs/example/keg")
+	sb.MustWriteFile("~/kegs/example/keg", append(cfg, "files:\n    blobStore: true\n"...), 0o644)
+
+	res := NewProcess(t, false, "file", "upload", "0", "~/test-images/default.png").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	require.True(t, strings.HasPrefix(string(sb.MustReadFile("~/kegs/example/0/assets/default.png")), "keg-blob:"))
+
+	res = NewProcess(t, false, "attach", "cat", "0", "assets/default.png").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err, string(res.Stderr))
+	require.Equal(t, sb.MustReadFile("~/test-images/default.png"), res.Stdout)
+}
+
+func TestAttachCat_MissingItem(t *testing.T) {
+	t.Parallel()
+	sb := imageFixture(t)
+
+	res := NewProcess(t, false, "attach", "cat", "0", "nope.pdf").Run(sb.Context(), sb.Runtime())
+	require.Error(t, res.Err)
+	require.Contains(t, res.Err.Error(), `unable to open "nope.pdf" on node 0`)
+}
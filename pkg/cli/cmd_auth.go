@@ -0,0 +1,200 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/jlrickert/tapper/pkg/registry"
+	"github.com/jlrickert/tapper/pkg/tapper"
+	"github.com/spf13/cobra"
+)
+
+// authStatusRecord is the structured form of one `auth status` row.
+type authStatusRecord struct {
+	Registry      string `json:"registry" yaml:"registry"`
+	Url           string `json:"url" yaml:"url"`
+	Authenticated bool   `json:"authenticated" yaml:"authenticated"`
+	Source        string `json:"source,omitempty" yaml:"source,omitempty"`
+	Expires       string `json:"expires,omitempty" yaml:"expires,omitempty"`
+	Expired       bool   `json:"expired,omitempty" yaml:"expired,omitempty"`
+}
+
+func NewAuthCmd(deps *Deps) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "auth",
+		Short: "log in to keg registries",
+		Long: `Log in to the registries configured under "registries" in the user config.
+Tokens are saved in the system keyring (macOS keychain or the secret service
+via secret-tool) and otherwise in a file only the user can read. Set
+TAP_CREDENTIAL_STORE to "keyring" or "file" to choose.
+
+A token or tokenEnv configured for a registry takes precedence over a saved
+login.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+	cmd.AddCommand(
+		newAuthLoginCmd(deps),
+		newAuthLogoutCmd(deps),
+		newAuthStatusCmd(deps),
+		newAuthKeyCmd(deps),
+	)
+	return cmd
+}
+
+func newAuthLoginCmd(deps *Deps) *cobra.Command {
+	var withToken bool
+
+	cmd := &cobra.Command{
+		Use:   "login [REGISTRY]",
+		Short: "log in to a registry (default defaultRegistry)",
+		Long: `Log in to a registry with the OAuth device-code flow: open the shown URL,
+enter the code, and tapper saves the issued token.
+
+With --with-token the token is read from stdin instead, for example:
+
+  echo "$TOKEN" | tap auth login knut --with-token`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var opts tapper.AuthLoginOptions
+			if len(args) == 1 {
+				opts.Registry = args[0]
+			}
+			if withToken {
+				data, err := io.ReadAll(cmd.InOrStdin())
+				if err != nil {
+					return fmt.Errorf("unable to read token: %w", err)
+				}
+				opts.Token = strings.TrimSpace(string(data))
+				if opts.Token == "" {
+					return fmt.Errorf("no token on stdin")
+				}
+			} else {
+				opts.Prompt = func(auth *registry.DeviceAuth) error {
+					out := cmd.ErrOrStderr()
+					if auth.VerificationURIComplete != "" {
+						_, err := fmt.Fprintf(out, "Open %s and confirm the code %s\n", auth.VerificationURIComplete, auth.UserCode)
+						return err
+					}
+					_, err := fmt.Fprintf(out, "Open %s and enter the code %s\n", auth.VerificationURI, auth.UserCode)
+					return err
+				}
+			}
+
+			cred, err := deps.Tap.AuthLogin(cmd.Context(), opts)
+			if err != nil {
+				return err
+			}
+			msg := fmt.Sprintf("logged in to %s", cred.Registry)
+			if !cred.Expires.IsZero() {
+				msg += fmt.Sprintf(" (token expires %s)", formatStatusTime(cred.Expires))
+			}
+			_, err = fmt.Fprintln(cmd.ErrOrStderr(), msg)
+			return err
+		},
+	}
+	cmd.Flags().BoolVar(&withToken, "with-token", false, "read the token from stdin instead of the device-code flow")
+	return cmd
+}
+
+func newAuthKeyCmd(deps *Deps) *cobra.Command {
+	var generate bool
+
+	cmd := &cobra.Command{
+		Use:   "key",
+		Short: "save the age identity that decrypts encrypted nodes",
+		Long: `Save the age identity ("AGE-SECRET-KEY-1...") that decrypts nodes whose
+content is encrypted, read from stdin, in the credential store. The matching
+recipient is printed; list it under encryption.recipients in the keg config.
+
+With --generate a new identity is created instead. TAP_AGE_IDENTITY, the path
+of an age identity file, takes precedence over the saved identity.
+
+  age-keygen | tap auth key
+  tap auth key --generate`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts := tapper.AuthKeyOptions{Generate: generate}
+			if !generate {
+				data, err := io.ReadAll(cmd.InOrStdin())
+				if err != nil {
+					return fmt.Errorf("unable to read identity: %w", err)
+				}
+				opts.Identity = string(data)
+				if strings.TrimSpace(opts.Identity) == "" {
+					return fmt.Errorf("no identity on stdin")
+				}
+			}
+			recipient, err := deps.Tap.AuthKey(cmd.Context(), opts)
+			if err != nil {
+				return err
+			}
+			_, err = fmt.Fprintln(cmd.OutOrStdout(), recipient)
+			return err
+		},
+	}
+	cmd.Flags().BoolVar(&generate, "generate", false, "generate a new identity instead of reading one from stdin")
+	return cmd
+}
+
+func newAuthLogoutCmd(deps *Deps) *cobra.Command {
+	return &cobra.Command{
+		Use:   "logout [REGISTRY]",
+		Short: "remove the saved login of a registry",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := ""
+			if len(args) == 1 {
+				name = args[0]
+			}
+			return deps.Tap.AuthLogout(cmd.Context(), name)
+		},
+	}
+}
+
+func newAuthStatusCmd(deps *Deps) *cobra.Command {
+	return &cobra.Command{
+		Use:   "status",
+		Short: "show which registries have a token and when it expires",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			statuses := deps.Tap.AuthStatus(cmd.Context())
+			if deps.Output != OutputHuman {
+				records := make([]authStatusRecord, 0, len(statuses))
+				for _, s := range statuses {
+					records = append(records, authStatusRecord{
+						Registry:      s.Registry,
+						Url:           s.Url,
+						Authenticated: s.Authenticated(),
+						Source:        s.Source,
+						Expires:       formatRecordTime(s.Expires),
+						Expired:       s.Expired,
+					})
+				}
+				return writeOutput(cmd.OutOrStdout(), deps.Output, records)
+			}
+
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "REGISTRY\tSTATUS\tSOURCE\tEXPIRES")
+			for _, s := range statuses {
+				status := "not logged in"
+				switch {
+				case s.Expired:
+					status = "expired"
+				case s.Authenticated():
+					status = "logged in"
+				}
+				source := s.Source
+				if source == "" {
+					source = "-"
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", s.Registry, status, source, formatStatusTime(s.Expires))
+			}
+			return w.Flush()
+		},
+	}
+}
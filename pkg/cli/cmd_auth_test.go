@@ -0,0 +1,121 @@
+package cli_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	testutils "github.com/jlrickert/cli-toolkit/sandbox"
+	"github.com/jlrickert/tapper/pkg/tapper"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuth_TokenLoginStatusAndLogout(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("joe", "~"))
+	withTestRegistry(t, sb, "https://registry.invalid")
+	require.NoError(t, sb.Runtime().Set(tapper.CredentialStoreEnvKey, "file"))
+
+	res := NewProcess(t, false, "auth", "login", "--with-token").RunWithIO(sb.Context(), sb.Runtime(), strings.NewReader("s3cret\n"))
+	require.NoError(t, res.Err, string(res.Stderr))
+	require.Contains(t, string(res.Stderr), "logged in to test")
+
+	res = NewProcess(t, false, "auth", "status").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err, string(res.Stderr))
+	require.Regexp(t, `test\s+logged in\s+file`, string(res.Stdout))
+
+	res = NewProcess(t, false, "registry", "list").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err, string(res.Stderr))
+	require.Regexp(t, `test \*\s+https://registry.invalid\s+set`, string(res.Stdout))
+
+	res = NewProcess(t, false, "auth", "logout", "test").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err, string(res.Stderr))
+
+	res = NewProcess(t, false, "auth", "status").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err, string(res.Stderr))
+	require.Regexp(t, `test\s+not logged in`, string(res.Stdout))
+
+	res = NewProcess(t, false, "auth", "logout").Run(sb.Context(), sb.Runtime())
+	require.Error(t, res.Err)
+	require.Contains(t, string(res.Stderr), "not logged in to test")
+}
+
+func TestAuth_DeviceCodeLogin(t *testing.T) {
+	t.Parallel()
+	polls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		require.Equal(t, "tapper", r.PostForm.Get("client_id"))
+		switch r.URL.Path {
+		case "/oauth/device/code":
+			_, _ = w.Write([]byte(`{"device_code":"dev-1","user_code":"ABCD-1234","verification_uri":"https://example.com/device","expires_in":60,"interval":1}`))
+		case "/oauth/token":
+			require.Equal(t, "dev-1", r.PostForm.Get("device_code"))
+			polls++
+			if polls == 1 {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"error":"authorization_pending"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"access_token":"tok-1","expires_in":3600}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	sb := NewSandbox(t, testutils.WithFixture("joe", "~"))
+	withTestRegistry(t, sb, srv.URL)
+	require.NoError(t, sb.Runtime().Set(tapper.CredentialStoreEnvKey, "file"))
+
+	res := NewProcess(t, false, "auth", "login", "test").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err, string(res.Stderr))
+	require.Contains(t, string(res.Stderr), "enter the code ABCD-1234")
+	require.Contains(t, string(res.Stderr), "token expires")
+	require.Equal(t, 2, polls)
+
+	res = NewProcess(t, false, "auth", "status").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err, string(res.Stderr))
+	require.Regexp(t, `test\s+logged in\s+file\s+\d{4}-`, string(res.Stdout))
+}
+
+func TestAuth_KeyEncryptsPrivateNodes(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("testuser", "~"))
+	require.NoError(t, sb.Runtime().Set(tapper.CredentialStoreEnvKey, "file"))
+
+	res := NewProcess(t, false, "auth", "key", "--generate").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err, string(res.Stderr))
+	recipient := strings.TrimSpace(string(res.Stdout))
+	require.True(t, strings.HasPrefix(recipient, "age1"), recipient)
+
+	cfg := sb.MustReadFile("~/kegs/example/keg")
+	sb.MustWriteFile("~/kegs/example/keg", append(cfg, "encryption:\n    recipients:\n        - "+recipient+"\n"...), 0o644)
+
+	res = NewProcess(t, false, "create", "--tags", "private", "--body", "# Diary\n\nA secret entry.\n").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err, string(res.Stderr))
+	require.Equal(t, "1", strings.TrimSpace(string(res.Stdout)))
+	require.NotContains(t, string(sb.MustReadFile("~/kegs/example/1/README.md")), "secret")
+
+	res = NewProcess(t, false, "cat", "1", "--content-only").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err, string(res.Stderr))
+	require.Contains(t, string(res.Stdout), "A secret entry.")
+
+	res = NewProcess(t, false, "ls").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err, string(res.Stderr))
+	require.Contains(t, string(res.Stdout), "Diary")
+
+	res = NewProcess(t, false, "archive", "export", "--nodes", "1", "-o", "~/locked.keg.tar.gz").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err, string(res.Stderr))
+	locked := sb.MustReadFile("~/locked.keg.tar.gz")
+	require.Contains(t, readArchiveFile(t, locked, "keg-archive/manifest.json"), `"source_id": "1"`)
+	require.Equal(t, string(sb.MustReadFile("~/kegs/example/1/README.md")), readArchiveFile(t, locked, "keg-archive/nodes/1/README.md"))
+	require.NotContains(t, readArchiveFile(t, locked, "keg-archive/nodes/1/stats.json"), "secret")
+
+	res = NewProcess(t, false, "archive", "export", "--nodes", "1", "--unlock", "-o", "~/unlocked.keg.tar.gz").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err, string(res.Stderr))
+	unlocked := sb.MustReadFile("~/unlocked.keg.tar.gz")
+	require.Contains(t, readArchiveFile(t, unlocked, "keg-archive/manifest.json"), `"source_id": "1"`)
+	require.Contains(t, readArchiveFile(t, unlocked, "keg-archive/nodes/1/README.md"), "A secret entry.")
+}
@@ -19,6 +19,7 @@ Format placeholders: %i (node id), %d (date), %t (title), %% (literal %).
 Default format: "%i %d %t".`,
 		Args:              cobra.ExactArgs(1),
 		ValidArgsFunction: nodeIDCompletionFunc(deps, 1),
+		Annotations:       nodeArgAnnotations(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			opts.NodeID = args[0]
 			applyKegTargetProfile(deps, &opts.KegTargetOptions)
@@ -37,6 +38,7 @@ Default format: "%i %d %t".`,
 	cmd.Flags().BoolVarP(&opts.IdOnly, "id-only", "", false, "show only ids")
 	cmd.Flags().BoolVar(&opts.Reverse, "reverse", false, "list nodes in reverse order")
 	cmd.Flags().StringVarP(&opts.Format, "format", "f", "", "output format")
+	cmd.Flags().BoolVar(&opts.Exact, "exact", false, "disable fuzzy title matching for node arguments")
 
 	return cmd
 }
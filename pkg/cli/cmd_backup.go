@@ -0,0 +1,138 @@
+package cli
+
+import (
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/jlrickert/tapper/pkg/tapper"
+	"github.com/spf13/cobra"
+)
+
+// backupRecord is the structured form of one `backup list` row.
+type backupRecord struct {
+	Name    string `json:"name" yaml:"name"`
+	Path    string `json:"path" yaml:"path"`
+	Created string `json:"created" yaml:"created"`
+	Size    int64  `json:"size" yaml:"size"`
+}
+
+func NewBackupCmd(deps *Deps) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "backup",
+		Short: "write, list and verify keg backups",
+		Long: `Write keg backups to the directory configured under "backup" in the user
+config, list them, and verify them against their manifest.
+
+  backup:
+    destination: ~/backups/kegs
+    schedule: daily
+    keep: 7`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+	cmd.AddCommand(
+		newBackupRunCmd(deps),
+		newBackupListCmd(deps),
+		newBackupVerifyCmd(deps),
+	)
+	return cmd
+}
+
+func newBackupRunCmd(deps *Deps) *cobra.Command {
+	var opts tapper.BackupRunOptions
+
+	cmd := &cobra.Command{
+		Use:   "run",
+		Short: "back up the keg if a backup is due",
+		Long: `Export every node of the keg into a timestamped archive in the backup
+destination, verify it, and prune archives beyond backup.keep.
+
+When backup.schedule is set and the latest backup is newer than the
+schedule interval, nothing is written unless --force is passed. This makes
+"tap backup run" safe to call from cron or a login hook.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			applyKegTargetProfile(deps, &opts.KegTargetOptions)
+			result, err := deps.Tap.BackupRun(cmd.Context(), opts)
+			if err != nil {
+				return err
+			}
+			out := cmd.OutOrStdout()
+			if result.Skipped {
+				_, err := fmt.Fprintln(cmd.ErrOrStderr(), "backup not due; use --force to write one anyway")
+				return err
+			}
+			if _, err := fmt.Fprintln(out, result.Backup.Path); err != nil {
+				return err
+			}
+			for _, path := range result.Pruned {
+				if _, err := fmt.Fprintf(cmd.ErrOrStderr(), "pruned %s\n", path); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&opts.Force, "force", false, "write a backup even if the schedule says none is due")
+	return cmd
+}
+
+func newBackupListCmd(deps *Deps) *cobra.Command {
+	var opts tapper.KegTargetOptions
+
+	return &cobra.Command{
+		Use:   "list",
+		Short: "list backups of the keg, oldest first",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			applyKegTargetProfile(deps, &opts)
+			backups, err := deps.Tap.BackupList(cmd.Context(), opts)
+			if err != nil {
+				return err
+			}
+			if deps.Output != OutputHuman {
+				records := make([]backupRecord, 0, len(backups))
+				for _, b := range backups {
+					records = append(records, backupRecord{
+						Name:    b.Name,
+						Path:    b.Path,
+						Created: formatRecordTime(b.Created),
+						Size:    b.Size,
+					})
+				}
+				return writeOutput(cmd.OutOrStdout(), deps.Output, records)
+			}
+
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "CREATED\tSIZE\tPATH")
+			for _, b := range backups {
+				fmt.Fprintf(w, "%s\t%d\t%s\n", formatStatusTime(b.Created), b.Size, b.Path)
+			}
+			return w.Flush()
+		},
+	}
+}
+
+func newBackupVerifyCmd(deps *Deps) *cobra.Command {
+	var opts tapper.BackupVerifyOptions
+
+	return &cobra.Command{
+		Use:   "verify [ARCHIVE]",
+		Short: "check a backup against its manifest (default latest)",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			applyKegTargetProfile(deps, &opts.KegTargetOptions)
+			if len(args) == 1 {
+				opts.Path = args[0]
+			}
+			backup, err := deps.Tap.BackupVerify(cmd.Context(), opts)
+			if err != nil {
+				return err
+			}
+			_, err = fmt.Fprintf(cmd.OutOrStdout(), "ok %s (%d nodes)\n", backup.Path, backup.Nodes)
+			return err
+		},
+	}
+}
@@ -0,0 +1,165 @@
+package cli_test
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	testutils "github.com/jlrickert/cli-toolkit/sandbox"
+	"github.com/jlrickert/tapper/pkg/keg"
+	"github.com/jlrickert/tapper/pkg/tapper"
+	"github.com/stretchr/testify/require"
+)
+
+func withBackupConfig(t *testing.T, sb *testutils.Sandbox, section string) {
+	t.Helper()
+	cfg := string(sb.MustReadFile("~/.config/tapper/config.yaml"))
+	sb.MustWriteFile("~/.config/tapper/config.yaml", []byte(cfg+"\n"+section), 0o644)
+}
+
+func TestBackup_RunListVerify(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("joe", "~"))
+	withBackupConfig(t, sb, "backup:\n  destination: ~/backups\n  schedule: daily\n  keep: 3\n")
+
+	res := NewProcess(t, false, "backup", "run").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err, string(res.Stderr))
+	path := strings.TrimSpace(string(res.Stdout))
+	require.Contains(t, path, "personal-")
+	require.True(t, strings.HasSuffix(path, ".tar.gz"))
+
+	res = NewProcess(t, false, "backup", "run").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err, string(res.Stderr))
+	require.Empty(t, res.Stdout)
+	require.Contains(t, string(res.Stderr), "not due")
+
+	res = NewProcess(t, false, "backup", "list", "--output", "json").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err, string(res.Stderr))
+	var records []struct {
+		Path string `json:"path"`
+	}
+	require.NoError(t, json.Unmarshal(res.Stdout, &records))
+	require.Len(t, records, 1)
+	require.Equal(t, path, records[0].Path)
+
+	res = NewProcess(t, false, "backup", "verify").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err, string(res.Stderr))
+	require.Contains(t, string(res.Stdout), "ok "+path+" (4 nodes)")
+}
+
+func TestBackup_RoundTripsContentAsStored(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("joe", "~"))
+	withBackupConfig(t, sb, "backup:\n  destination: ~/backups\n")
+	require.NoError(t, sb.Runtime().Set(tapper.CredentialStoreEnvKey, "file"))
+
+	res := NewProcess(t, false, "auth", "key", "--generate").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err, string(res.Stderr))
+	recipient := strings.TrimSpace(string(res.Stdout))
+	cfg := sb.MustReadFile("~/kegs/personal/keg")
+	sb.MustWriteFile("~/kegs/personal/keg", append(cfg, "export:\n  backlinks: true\nencryption:\n  recipients:\n    - "+recipient+"\n"...), 0o644)
+
+	res = NewProcess(t, false, "create", "--keg", "personal", "--tags", "private", "--body", "# Diary\n\nA secret entry.\n").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err, string(res.Stderr))
+	require.Equal(t, "4", strings.TrimSpace(string(res.Stdout)))
+	require.True(t, keg.IsEncryptedContent(sb.MustReadFile("~/kegs/personal/4/README.md")))
+
+	res = NewProcess(t, false, "backup", "run", "--keg", "personal").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err, string(res.Stderr))
+	path := strings.TrimSpace(string(res.Stdout))
+	archive := sb.MustReadFile(path)
+
+	target := keg.NewKeg(keg.NewFsRepo("~/restored", sb.Runtime()), sb.Runtime())
+	require.NoError(t, target.Init(sb.Context()))
+	res = NewProcess(t, false, "archive", "import", path, "--path", "~/restored").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err, string(res.Stderr))
+
+	for _, id := range []string{"1", "2", "3", "4"} {
+		want := string(sb.MustReadFile("~/kegs/personal/" + id + "/README.md"))
+		require.Equal(t, want, readArchiveFile(t, archive, "keg-archive/nodes/"+id+"/README.md"), "node %s", id)
+		require.Equal(t, want, string(sb.MustReadFile("~/restored/"+id+"/README.md")), "node %s", id)
+	}
+}
+
+func TestBackup_RequiresDestination(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("joe", "~"))
+
+	res := NewProcess(t, false, "backup", "run").Run(sb.Context(), sb.Runtime())
+	require.Error(t, res.Err)
+	require.Contains(t, res.Err.Error(), "backup.destination")
+}
+
+func TestBackup_RejectsS3Destination(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("joe", "~"))
+	withBackupConfig(t, sb, "backup:\n  destination: s3://bucket/kegs\n")
+
+	res := NewProcess(t, false, "backup", "run").Run(sb.Context(), sb.Runtime())
+	require.Error(t, res.Err)
+	require.Contains(t, res.Err.Error(), "not supported")
+}
+
+func TestBackup_CarriesItemsArchivedNodesAndConfig(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("joe", "~"))
+	withBackupConfig(t, sb, "backup:\n  destination: ~/backups\n")
+	ctx := sb.Context()
+
+	repo := keg.NewFsRepo("~/kegs/personal", sb.Runtime())
+	k := keg.NewKeg(repo, sb.Runtime())
+	one, three := keg.NodeId{ID: 1}, keg.NodeId{ID: 3}
+	require.NoError(t, repo.WriteFile(ctx, one, "report.txt", []byte("report")))
+	require.NoError(t, repo.WriteItemMeta(ctx, one, keg.AssetKindItem, "report.txt", &keg.ItemMeta{Alt: "Quarterly report"}))
+	require.NoError(t, repo.WriteImage(ctx, one, "cover.png", []byte("png")))
+	require.NoError(t, k.SetContent(ctx, one, []byte("# Rewritten\n\nNew text.\n")))
+	require.NoError(t, repo.WriteFile(ctx, three, "notes.txt", []byte("notes")))
+	res := NewProcess(t, false, "archive", "3", "--keg", "personal").Run(ctx, sb.Runtime())
+	require.NoError(t, res.Err, string(res.Stderr))
+
+	res = NewProcess(t, false, "backup", "run", "--keg", "personal").Run(ctx, sb.Runtime())
+	require.NoError(t, res.Err, string(res.Stderr))
+	path := strings.TrimSpace(string(res.Stdout))
+	archive := sb.MustReadFile(path)
+
+	items := []string{
+		"nodes/1/assets/report.txt",
+		"nodes/1/assets/.meta/report.txt.json",
+		"nodes/1/assets/.versions/index.tsv",
+		"nodes/1/images/cover.png",
+		"archive/3/stats.json",
+		"archive/3/assets/notes.txt",
+	}
+	for _, item := range items {
+		readArchiveFile(t, archive, "keg-archive/"+item)
+	}
+	readArchiveFile(t, archive, "keg-archive/keg")
+
+	target := keg.NewKeg(keg.NewFsRepo("~/restored", sb.Runtime()), sb.Runtime())
+	require.NoError(t, target.Init(ctx))
+	res = NewProcess(t, false, "archive", "import", path, "--path", "~/restored").Run(ctx, sb.Runtime())
+	require.NoError(t, res.Err, string(res.Stderr))
+	for _, item := range items {
+		restored := strings.TrimPrefix(item, "nodes/")
+		require.Equal(t,
+			string(sb.MustReadFile("~/kegs/personal/"+restored)),
+			string(sb.MustReadFile("~/restored/"+restored)), restored)
+	}
+	versions, err := target.ListVersions(ctx, one)
+	require.NoError(t, err)
+	require.Len(t, versions, 1)
+	cfg, err := target.Config(ctx)
+	require.NoError(t, err)
+	src, err := k.Config(ctx)
+	require.NoError(t, err)
+	cfg.Updated, src.Updated = "", ""
+	require.Equal(t, src, cfg, "keg config restored")
+
+	// Verify notices a missing item.
+	broken := filepath.Join(filepath.Dir(path), "broken.tar.gz")
+	sb.MustWriteFile(broken, dropArchivePath(t, archive, "keg-archive/nodes/1/assets/report.txt"), 0o644)
+	res = NewProcess(t, false, "backup", "verify", broken).Run(ctx, sb.Runtime())
+	require.Error(t, res.Err)
+	require.Contains(t, res.Err.Error(), "assets/report.txt")
+}
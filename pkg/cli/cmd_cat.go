@@ -15,14 +15,30 @@ import (
 //	tap cat 0 1 2
 //	tap cat --tag "fire and not archived"
 //	tap cat 0 --keg myalias
+//	tap cat "project alpha"
+//	tap ls --format %i | tap cat -
 func NewCatCmd(deps *Deps) *cobra.Command {
 	var opts tapper.CatOptions
 
 	cmd := &cobra.Command{
-		Use:               "cat [NODE_ID...]",
-		Short:             "display node(s) content with metadata as frontmatter",
+		Use:   "cat [NODE_ID...]",
+		Short: "display node(s) content with metadata as frontmatter",
+		Long: `Display node content with its metadata as YAML frontmatter. Several nodes
+are printed as a YAML document stream with an injected id field. A NODE_ID
+of "-" reads node IDs from stdin, one per line or as JSONL.
+
+With --render, the content is rendered for the terminal instead: headings,
+emphasis and code are styled, code blocks are highlighted and links to other
+nodes show the linked node's title. keg-query blocks are replaced with lists
+of the nodes they match (see "tap docs query-expressions"). Set NO_COLOR to
+keep the layout without colors.
+
+With --output json|yaml|tsv, each node is emitted as a record with the fields
+id, meta, stats and content regardless of --content-only, --meta-only and
+--stats-only.`,
 		Aliases:           []string{"show"},
 		ValidArgsFunction: nodeIDCompletionFunc(deps, 0),
+		Annotations:       nodeArgAnnotations(0),
 		Args: func(cmd *cobra.Command, args []string) error {
 			if opts.Tag != "" {
 				return nil
@@ -33,9 +49,20 @@ func NewCatCmd(deps *Deps) *cobra.Command {
 			return nil
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			opts.NodeIDs = args
+			ids, err := nodeArgs(cmd, deps, args)
+			if err != nil {
+				return err
+			}
+			opts.NodeIDs = ids
 			opts.Stream = deps.Runtime.Stream()
 			applyKegTargetProfile(deps, &opts.KegTargetOptions)
+			if deps.Output != OutputHuman {
+				docs, err := deps.Tap.CatDocuments(cmd.Context(), opts)
+				if err != nil {
+					return err
+				}
+				return writeOutput(cmd.OutOrStdout(), deps.Output, documentRecords(docs))
+			}
 
 			output, err := deps.Tap.Cat(cmd.Context(), opts)
 			if err != nil {
@@ -49,9 +76,12 @@ func NewCatCmd(deps *Deps) *cobra.Command {
 	cmd.Flags().BoolVar(&opts.ContentOnly, "content-only", false, "display node content only")
 	cmd.Flags().BoolVar(&opts.StatsOnly, "stats-only", false, "display node stats only")
 	cmd.Flags().BoolVar(&opts.MetaOnly, "meta-only", false, "display node metadata only")
+	cmd.Flags().BoolVar(&opts.Render, "render", false, "render content as styled Markdown for the terminal")
 	cmd.Flags().BoolVar(&opts.Edit, "edit", false, "edit node in a temporary file")
 	cmd.Flags().StringVar(&opts.Tag, "tag", "", `tag expression to select nodes (e.g., "fire", "fire and not archived")`)
 	cmd.Flags().StringVar(&opts.Tag, "query", "", `boolean expression (see "tap docs query-expressions" for syntax)`)
+	cmd.Flags().BoolVar(&opts.Archived, "archived", false, "read archived nodes (see tap archive)")
+	cmd.Flags().BoolVar(&opts.Exact, "exact", false, "disable fuzzy title matching for node arguments")
 
 	return cmd
 }
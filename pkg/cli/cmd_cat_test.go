@@ -511,3 +511,97 @@ func TestCatCommand_SingleNode_NoIDField(t *testing.T) {
 	out := string(res.Stdout)
 	require.NotContains(t, out, `id: "0"`, "single-node output should not have injected id field")
 }
+
+func TestCatCommand_FuzzyTitleArgument(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("joe", "~"))
+
+	res := NewProcess(t, false, "cat", "alpha", "--content-only").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	require.Contains(t, string(res.Stdout), "Project Alpha")
+
+	ambiguous := NewProcess(t, false, "cat", "p").Run(sb.Context(), sb.Runtime())
+	require.Error(t, ambiguous.Err)
+	require.Contains(t, string(ambiguous.Stderr), "ambiguous")
+	require.Contains(t, string(ambiguous.Stderr), "Personal Overview")
+	require.Contains(t, string(ambiguous.Stderr), "Project Alpha")
+
+	exact := NewProcess(t, false, "cat", "alpha", "--exact").Run(sb.Context(), sb.Runtime())
+	require.Error(t, exact.Err)
+	require.Contains(t, string(exact.Stderr), "invalid node ID")
+}
+
+func TestCatCommand_RenderResolvesLinkTitles(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("joe", "~"))
+	require.NoError(t, sb.Runtime().Set("NO_COLOR", "1"))
+
+	res := NewProcess(t, false, "cat", "1", "--render").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	out := string(res.Stdout)
+	require.True(t, strings.HasPrefix(out, "# Personal Overview\n"), out)
+	require.Contains(t, out, "• Project Alpha → 2\n")
+	require.NotContains(t, out, "](../2)")
+	require.NotContains(t, out, "\x1b[")
+
+	res = NewProcess(t, false, "cat", "1", "--render", "--content-only").Run(sb.Context(), sb.Runtime())
+	require.Error(t, res.Err)
+	require.Contains(t, res.Err.Error(), "only one output mode")
+}
+
+func TestCatCommand_RenderExpandsQueryBlocks(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("testuser", "~"))
+	require.NoError(t, sb.Runtime().Set("NO_COLOR", "1"))
+
+	for _, args := range [][]string{
+		{"--title", "Channels", "--tags", "go"},
+		{"--title", "Generics", "--tags", "go,draft"},
+		{"--title", "Borrowing", "--tags", "rust"},
+		{"--title", "Go Index", "--tags", "go", "--body", "# Go Index\n\n```keg-query\ntags: go && !draft\nsort: title\n```\n\n````md\n```keg-query\ntags: rust\n```\n````\n"},
+	} {
+		res := NewProcess(t, false, append([]string{"create"}, args...)...).Run(sb.Context(), sb.Runtime())
+		require.NoError(t, res.Err)
+	}
+
+	res := NewProcess(t, false, "cat", "4", "--render").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	out := string(res.Stdout)
+	require.Contains(t, out, "• Channels → 1\n")
+	require.NotContains(t, out, "Generics")
+	require.NotContains(t, out, "Go Index →")
+	require.Contains(t, out, "tags: rust")
+	require.NotContains(t, out, "Borrowing")
+
+	// The node itself keeps the block.
+	require.Contains(t, string(sb.MustReadFile("~/kegs/example/4/README.md")), "tags: go && !draft")
+
+	sb.MustWriteFile("~/kegs/example/4/README.md", []byte("# Go Index\n\n```keg-query\nsort: size\n```\n"), 0o644)
+	res = NewProcess(t, false, "cat", "4", "--render").Run(sb.Context(), sb.Runtime())
+	require.Error(t, res.Err)
+	require.Contains(t, res.Err.Error(), `unknown sort type "size"`)
+}
+
+func TestCatCommand_ReadsNodeIDsFromStdin(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("joe", "~"))
+
+	res := NewProcess(t, false, "cat", "-", "--content-only").
+		RunWithIO(sb.Context(), sb.Runtime(), strings.NewReader("2\tProject Alpha\n\n3\n"))
+	require.NoError(t, res.Err)
+	stdout := string(res.Stdout)
+	require.Contains(t, stdout, "Project Alpha")
+	require.Contains(t, stdout, "Meeting Notes")
+	require.NotContains(t, stdout, "# Personal Overview")
+
+	res = NewProcess(t, false, "cat", "-", "--content-only").
+		RunWithIO(sb.Context(), sb.Runtime(), strings.NewReader(`{"id":"1","title":"Personal Overview"}`+"\n"+`{"id":3}`+"\n"))
+	require.NoError(t, res.Err)
+	stdout = string(res.Stdout)
+	require.Contains(t, stdout, "Personal Overview")
+	require.Contains(t, stdout, "Meeting Notes")
+
+	res = NewProcess(t, false, "cat", "-").RunWithIO(sb.Context(), sb.Runtime(), strings.NewReader(""))
+	require.Error(t, res.Err)
+	require.Contains(t, res.Err.Error(), "no node ids read from stdin")
+}
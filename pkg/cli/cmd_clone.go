@@ -0,0 +1,48 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/jlrickert/tapper/pkg/tapper"
+	"github.com/spf13/cobra"
+)
+
+// NewCloneCmd returns the `clone` cobra command.
+//
+// Usage examples:
+//
+//	tap clone 12
+//	tap clone 12 --suffix "(2026-10)" --tags monthly
+func NewCloneCmd(deps *Deps) *cobra.Command {
+	var opts tapper.CloneOptions
+
+	cmd := &cobra.Command{
+		Use:   "clone NODE_ID",
+		Short: "duplicate a node into a new node",
+		Long: `Copy NODE_ID into a freshly allocated node.
+
+Content, metadata, images and attachments are copied. Programmatic fields
+such as the hash and timestamps are regenerated for the clone. Use --suffix
+to append text to the clone's title and --tags to add tags, which is handy
+for recurring documents built from a template node. The new node id is
+printed.`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: nodeIDCompletionFunc(deps, 1),
+		Annotations:       nodeArgAnnotations(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.NodeID = args[0]
+			applyKegTargetProfile(deps, &opts.KegTargetOptions)
+
+			id, err := deps.Tap.Clone(cmd.Context(), opts)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "%s\n", id.Path())
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.TitleSuffix, "suffix", "", "text appended to the clone's title")
+	cmd.Flags().StringSliceVar(&opts.Tags, "tags", nil, "tags to add to the clone (repeatable)")
+	return cmd
+}
@@ -0,0 +1,56 @@
+package cli_test
+
+import (
+	"strings"
+	"testing"
+
+	testutils "github.com/jlrickert/cli-toolkit/sandbox"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCloneCommand_CopiesNodeWithSuffixAndTags(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t,
+		testutils.WithFixture("testuser", "~"),
+		testutils.WithFixture("images", "~/test-images"),
+	)
+
+	res := NewProcess(t, false, "create", "--title", "Monthly Review", "--lead", "template", "--tags", "review", "--attrs", "owner=joe").
+		Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	src := strings.TrimSpace(string(res.Stdout))
+
+	res = NewProcess(t, false, "file", "upload", src, "~/test-images/default.png").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+
+	res = NewProcess(t, false, "clone", src, "--suffix", "(October)", "--tags", "monthly").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	clone := strings.TrimSpace(string(res.Stdout))
+	require.NotEqual(t, src, clone)
+
+	readme := string(sb.MustReadFile("~/kegs/example/" + clone + "/README.md"))
+	require.True(t, strings.HasPrefix(readme, "# Monthly Review (October)\n"), readme)
+	require.Contains(t, readme, "template")
+
+	meta := string(sb.MustReadFile("~/kegs/example/" + clone + "/meta.yaml"))
+	require.Contains(t, meta, "owner: joe")
+	require.Contains(t, meta, "monthly")
+	require.Contains(t, meta, "review")
+	require.NotContains(t, meta, "hash:")
+	require.NotContains(t, meta, "created:")
+
+	require.NotEmpty(t, sb.MustReadFile("~/kegs/example/"+clone+"/assets/default.png"))
+	require.Contains(t, string(sb.MustReadFile("~/kegs/example/dex/nodes.tsv")), "Monthly Review (October)")
+
+	srcReadme := string(sb.MustReadFile("~/kegs/example/" + src + "/README.md"))
+	require.True(t, strings.HasPrefix(srcReadme, "# Monthly Review\n"), srcReadme)
+}
+
+func TestCloneCommand_MissingNode(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("joe", "~"))
+
+	res := NewProcess(t, false, "clone", "99").Run(sb.Context(), sb.Runtime())
+	require.Error(t, res.Err)
+	require.Contains(t, res.Err.Error(), "not found")
+}
@@ -0,0 +1,41 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/jlrickert/tapper/pkg/tapper"
+	"github.com/spf13/cobra"
+)
+
+// NewClustersCmd returns the `clusters` cobra command.
+func NewClustersCmd(deps *Deps) *cobra.Command {
+	var opts tapper.ClustersOptions
+
+	cmd := &cobra.Command{
+		Use:   "clusters",
+		Short: "list topic clusters detected from the link graph",
+		Long: `List groups of densely linked nodes, largest first.
+
+Clusters are computed by label propagation over the link graph when running
+"tap index rebuild --analyze" and stored in dex/clusters.tsv. Each cluster
+lists its most central members by PageRank.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			applyKegTargetProfile(deps, &opts.KegTargetOptions)
+
+			lines, err := deps.Tap.Clusters(cmd.Context(), opts)
+			if err != nil {
+				return err
+			}
+			for _, line := range lines {
+				fmt.Fprintln(cmd.OutOrStdout(), line)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().IntVar(&opts.Top, "top", 5, "nodes to show per cluster (0 for all)")
+	cmd.Flags().IntVar(&opts.MinSize, "min-size", 1, "hide clusters with fewer members")
+
+	return cmd
+}
@@ -0,0 +1,34 @@
+package cli_test
+
+import (
+	"strings"
+	"testing"
+
+	testutils "github.com/jlrickert/cli-toolkit/sandbox"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClustersCommand_ListsAnalyzedClusters(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("joe", "~"))
+
+	missing := NewProcess(t, false, "clusters").Run(sb.Context(), sb.Runtime())
+	require.Error(t, missing.Err)
+	require.Contains(t, missing.Err.Error(), "--analyze")
+
+	rebuild := NewProcess(t, false, "index", "rebuild", "--full", "--analyze").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, rebuild.Err)
+
+	res := NewProcess(t, false, "clusters", "--top", "1").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	lines := strings.Split(strings.TrimSpace(string(res.Stdout)), "\n")
+	require.Equal(t, "cluster 1 (3 nodes)", lines[0])
+	require.Len(t, lines, 5)
+	require.Equal(t, "cluster 2 (1 node)", lines[3])
+	require.Equal(t, "  0\tSorry, planned but not yet available", lines[4])
+
+	big := NewProcess(t, false, "clusters", "--min-size", "2", "--top", "0").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, big.Err)
+	require.NotContains(t, string(big.Stdout), "cluster 2")
+	require.Equal(t, 4, len(strings.Split(strings.TrimSpace(string(big.Stdout)), "\n")))
+}
@@ -0,0 +1,72 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jlrickert/tapper/pkg/tapper"
+	"github.com/spf13/cobra"
+)
+
+// NewCommitCmd returns the `commit` cobra command.
+//
+// Usage examples:
+//
+//	tap commit 4821
+//	tap commit 12-4821 13-0917
+//	tap commit --list
+func NewCommitCmd(deps *Deps) *cobra.Command {
+	var (
+		opts   tapper.CommitOptions
+		list   bool
+		dryRun bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "commit [CODE...]",
+		Short: "promote draft nodes to permanent ids",
+		Long: `Promote draft nodes created with "tap create --draft".
+
+Each draft is moved to the next available numeric id, links to it are
+rewritten, and it is added to the indexes. Drafts may be named by their code
+or their full N-CODE id. Use --list to show pending drafts.`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if list {
+				return cobra.NoArgs(cmd, args)
+			}
+			return cobra.MinimumNArgs(1)(cmd, args)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			applyKegTargetProfile(deps, &opts.KegTargetOptions)
+			out := cmd.OutOrStdout()
+
+			if list {
+				lines, err := deps.Tap.Drafts(cmd.Context(), opts.KegTargetOptions)
+				if err != nil {
+					return err
+				}
+				for _, line := range lines {
+					if _, err := fmt.Fprintln(out, line); err != nil {
+						return err
+					}
+				}
+				return nil
+			}
+
+			opts.Drafts = args
+			return runWithDryRun(cmd, deps, dryRun, func(ctx context.Context) error {
+				results, err := deps.Tap.Commit(ctx, opts)
+				for _, r := range results {
+					if _, werr := fmt.Fprintf(out, "%s -> %s\n", r.Draft.Path(), r.Node.Path()); werr != nil {
+						return werr
+					}
+				}
+				return err
+			})
+		},
+	}
+
+	addDryRunFlag(cmd, &dryRun)
+	cmd.Flags().BoolVar(&list, "list", false, "list pending drafts")
+	return cmd
+}
@@ -0,0 +1,47 @@
+package cli_test
+
+import (
+	"strings"
+	"testing"
+
+	testutils "github.com/jlrickert/cli-toolkit/sandbox"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommitCommand_PromotesDraft(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("joe", "~"))
+
+	res := NewProcess(t, false, "create", "--draft", "--title", "Rough Idea").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	draft := strings.TrimSpace(string(res.Stdout))
+	require.True(t, strings.HasPrefix(draft, "4-"), "draft id %q", draft)
+	code := strings.TrimPrefix(draft, "4-")
+
+	list := NewProcess(t, false, "list", "--id-only").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, list.Err)
+	require.NotContains(t, string(list.Stdout), draft)
+	require.NotContains(t, string(sb.MustReadFile("~/kegs/personal/dex/nodes.tsv")), "Rough Idea")
+
+	drafts := NewProcess(t, false, "commit", "--list").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, drafts.Err)
+	require.Equal(t, draft+"\tRough Idea\n", string(drafts.Stdout))
+
+	res = NewProcess(t, false, "commit", code).Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	require.Equal(t, draft+" -> 4\n", string(res.Stdout))
+
+	cat := NewProcess(t, false, "cat", "--content-only", "4").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, cat.Err)
+	require.Contains(t, string(cat.Stdout), "# Rough Idea")
+	require.Contains(t, string(sb.MustReadFile("~/kegs/personal/dex/nodes.tsv")), "Rough Idea")
+}
+
+func TestCommitCommand_UnknownDraft(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("joe", "~"))
+
+	res := NewProcess(t, false, "commit", "nope").Run(sb.Context(), sb.Runtime())
+	require.Error(t, res.Err)
+	require.Contains(t, res.Err.Error(), `draft "nope" not found`)
+}
@@ -13,8 +13,13 @@ import (
 //
 //	Tap create --title "My note" --lead "one-line summary"
 //	Tap create --title "Note" --tags tag1 --tags tag2 --attrs foo=bar --attrs x=1
+//	Tap create --title "Standup" --body "# {{.Title}}\n\n{{.Date}} by {{.User}}"
+//	Tap create --batch notes.jsonl
 func NewCreateCmd(deps *Deps) *cobra.Command {
-	var opts tapper.CreateOptions
+	var (
+		opts      tapper.CreateOptions
+		batchOpts tapper.CreateBatchOptions
+	)
 
 	cmd := &cobra.Command{
 		Use:     "create",
@@ -24,14 +29,42 @@ func NewCreateCmd(deps *Deps) *cobra.Command {
 
 If stdin is piped with non-empty content, it is used as the node body and no
 editor is launched. The content may optionally include YAML frontmatter; if
-present, the frontmatter is written to meta.yaml.
+present, the frontmatter is written to meta.yaml. With --keep-frontmatter, or
+keepFrontmatter in the keg config, it stays in README.md instead and its
+tags and attributes are synced into meta.yaml.
 
 If no stdin and no flags are provided on a TTY, an editor is opened with a
 pre-populated template.
 
 If flags are provided without stdin, the node is created immediately from the
-flag values without opening an editor.`,
+flag values without opening an editor.
+
+--body sets the node content instead of stdin. It and the keg's default
+template expand {{.Date}}, {{.Title}}, {{.KegAlias}} and {{.User}}, and
+{{snippet "name"}} includes a snippet from the snippets section of the user
+config.
+
+With --draft, the node is created under a temporary N-CODE id and kept out of
+the indexes until it is promoted with "tap commit CODE".
+
+With --batch FILE, one node is created per record in a JSONL or CSV file
+("-" reads stdin). JSONL records may set title, lead, tags, body and attrs;
+CSV files name their columns in a header row and any column other than
+title, lead, tags and body becomes an attribute. The dex is written once at
+the end, and each created node is printed as "ROW<TAB>ID".`,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if batchOpts.File != "" {
+				batchOpts.Stream = deps.Runtime.Stream()
+				applyKegTargetProfile(deps, &batchOpts.KegTargetOptions)
+				results, err := deps.Tap.CreateBatch(cmd.Context(), batchOpts)
+				for _, r := range results {
+					if _, werr := fmt.Fprintf(cmd.OutOrStdout(), "%d\t%s\n", r.Row, r.Node.Path()); werr != nil {
+						return werr
+					}
+				}
+				return err
+			}
+
 			opts.Stream = deps.Runtime.Stream()
 			applyKegTargetProfile(deps, &opts.KegTargetOptions)
 
@@ -46,7 +79,15 @@ flag values without opening an editor.`,
 	}
 	cmd.Flags().StringVar(&opts.Title, "title", "", "title for the new node")
 	cmd.Flags().StringVar(&opts.Lead, "lead", "", "lead/short summary for the new node")
+	cmd.Flags().StringVar(&opts.Body, "body", "", "content for the new node; template variables and snippets are expanded")
 	cmd.Flags().StringSliceVar(&opts.Tags, "tags", nil, "tags to apply to the node (repeatable)")
+	cmd.Flags().BoolVar(&opts.Draft, "draft", false, "create a draft node with a temporary id (promote with tap commit)")
+	cmd.Flags().BoolVar(&opts.KeepFrontmatter, "keep-frontmatter", false, "keep YAML frontmatter from piped content in README.md instead of moving it to meta.yaml")
+	cmd.Flags().StringVar(&batchOpts.File, "batch", "", "create one node per record in a JSONL or CSV file (- for stdin)")
+	cmd.Flags().StringVar(&batchOpts.Format, "batch-format", "", "batch input format: jsonl or csv (default from file extension)")
+	cmd.MarkFlagsMutuallyExclusive("batch", "title")
+	cmd.MarkFlagsMutuallyExclusive("batch", "draft")
+	cmd.MarkFlagsMutuallyExclusive("batch", "body")
 	cmd.Flags().StringToStringVar(
 		&opts.Attrs, "attrs", nil,
 		"attributes as key=value pairs (repeatable)",
@@ -146,3 +146,87 @@ func TestCreate_FromStdin(t *testing.T) {
 	content := fx.MustReadFile(readmePath)
 	require.Contains(t, string(content), "This content came from stdin.")
 }
+
+func TestCreate_KeepFrontmatter(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("testuser", "~"))
+
+	stdin := "---\ntags: [imported]\nsource: web\n---\n# Imported\n"
+	res := NewProcess(t, false, "create", "--keep-frontmatter").RunWithIO(sb.Context(), sb.Runtime(), strings.NewReader(stdin))
+	require.NoError(t, res.Err)
+	require.Equal(t, stdin, string(sb.MustReadFile("~/kegs/example/1/README.md")))
+	meta := string(sb.MustReadFile("~/kegs/example/1/meta.yaml"))
+	require.Contains(t, meta, "imported")
+	require.Contains(t, meta, "source: web")
+}
+
+func TestCreate_BodyExpandsVariables(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("testuser", "~"))
+	require.NoError(t, sb.Runtime().Set("USER", "ana"))
+	cfg := string(sb.MustReadFile("~/.config/tapper/config.yaml"))
+	sb.MustWriteFile("~/.config/tapper/config.yaml", []byte(cfg+"snippets:\n  sig: \"-- {{.User}} in {{.KegAlias}}\"\n"), 0o644)
+	today := sb.Runtime().Clock().Now().Format(time.DateOnly)
+
+	res := NewProcess(t, false, "create", "--title", "Standup", "--body", "# {{.Title}}\n\n{{.Date}}\n\n{{snippet \"sig\"}}\n").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	require.Equal(t, "1", strings.TrimSpace(string(res.Stdout)))
+	require.Equal(t, "# Standup\n\n"+today+"\n\n-- ana in example\n", string(sb.MustReadFile("~/kegs/example/1/README.md")))
+
+	res = NewProcess(t, false, "create", "--body", "{{snippet \"nope\"}}").Run(sb.Context(), sb.Runtime())
+	require.Error(t, res.Err)
+	require.Contains(t, res.Err.Error(), `unknown snippet "nope"`)
+}
+
+func TestCreate_BatchJSONL(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("testuser", "~"))
+
+	input := `{"title":"First","lead":"first lead","tags":["alpha"]}
+
+{"title":"Second","body":"Body text for second.","attrs":{"source":"import"}}
+`
+	res := NewProcess(t, false, "create", "--batch", "-").RunWithIO(sb.Context(), sb.Runtime(), strings.NewReader(input))
+	require.NoError(t, res.Err)
+	require.Equal(t, "1\t1\n2\t2\n", string(res.Stdout))
+
+	first := string(sb.MustReadFile("~/kegs/example/1/README.md"))
+	require.Contains(t, first, "# First")
+	require.Contains(t, first, "first lead")
+	require.Contains(t, string(sb.MustReadFile("~/kegs/example/1/meta.yaml")), "alpha")
+
+	second := string(sb.MustReadFile("~/kegs/example/2/README.md"))
+	require.Equal(t, "# Second\n\nBody text for second.\n", second)
+	require.Contains(t, string(sb.MustReadFile("~/kegs/example/2/meta.yaml")), "source: import")
+
+	nodes := string(sb.MustReadFile("~/kegs/example/dex/nodes.tsv"))
+	require.Contains(t, nodes, "First")
+	require.Contains(t, nodes, "Second")
+}
+
+func TestCreate_BatchCSV(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("testuser", "~"))
+
+	sb.MustWriteFile("~/notes.csv", []byte("title,tags,status\nCSV Node,\"one, two\",draft\n"), 0o644)
+	res := NewProcess(t, false, "create", "--batch", "~/notes.csv").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	require.Equal(t, "1\t1\n", string(res.Stdout))
+
+	meta := string(sb.MustReadFile("~/kegs/example/1/meta.yaml"))
+	require.Contains(t, meta, "one")
+	require.Contains(t, meta, "two")
+	require.Contains(t, meta, "status: draft")
+}
+
+func TestCreate_BatchInvalidRecordCreatesNothing(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("testuser", "~"))
+
+	input := "{\"title\":\"Good\"}\n{not json}\n"
+	res := NewProcess(t, false, "create", "--batch", "-").RunWithIO(sb.Context(), sb.Runtime(), strings.NewReader(input))
+	require.Error(t, res.Err)
+	require.Contains(t, res.Err.Error(), "line 2")
+	require.Empty(t, string(res.Stdout))
+	require.NotContains(t, string(sb.MustReadFile("~/kegs/example/dex/nodes.tsv")), "Good")
+}
@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/jlrickert/tapper/pkg/tapper"
+	"github.com/spf13/cobra"
+)
+
+// NewCronCmd returns the `cron` cobra command group.
+//
+// Usage examples:
+//
+//	tap cron run
+//	tap cron run --date 2026-10-12 weekly-review
+//	tap cron run --dry-run
+func NewCronCmd(deps *Deps) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cron",
+		Short: "create scheduled recurring nodes",
+		Long: `Create nodes from the recurring rules in the keg config.
+
+Rules live under "recurring" in keg config. Point a system cron job or a
+systemd timer at "tap cron run"; it is safe to run repeatedly.`,
+	}
+
+	cmd.AddCommand(newCronRunCmd(deps))
+	return cmd
+}
+
+// newCronRunCmd returns the `cron run` subcommand.
+func newCronRunCmd(deps *Deps) *cobra.Command {
+	var opts tapper.CronRunOptions
+
+	cmd := &cobra.Command{
+		Use:   "run [RULE...]",
+		Short: "create the current occurrence of each recurring node",
+		Long: `Create the most recent due occurrence of each recurring rule.
+
+Each created node gets a date-stamped title. Occurrences whose title already
+exists are left alone, so repeated runs create nothing new. One line is
+printed per rule: the status (created, exists, or pending with --dry-run),
+the node id, and the title.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.Rules = args
+			applyKegTargetProfile(deps, &opts.KegTargetOptions)
+
+			results, err := deps.Tap.CronRun(cmd.Context(), opts)
+			out := cmd.OutOrStdout()
+			for _, r := range results {
+				status, id := "pending", "-"
+				switch {
+				case r.Created:
+					status, id = "created", r.Node.Path()
+				case r.Exists:
+					status, id = "exists", r.Node.Path()
+				}
+				if _, werr := fmt.Fprintf(out, "%s\t%s\t%s\n", status, id, r.Title); werr != nil {
+					return werr
+				}
+			}
+			return err
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.Date, "date", "", "evaluate rules as of this date (YYYY-MM-DD) instead of today")
+	cmd.Flags().BoolVar(&opts.DryRun, "dry-run", false, "show what would be created without writing")
+	return cmd
+}
@@ -0,0 +1,64 @@
+package cli_test
+
+import (
+	"strings"
+	"testing"
+
+	testutils "github.com/jlrickert/cli-toolkit/sandbox"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCronRun_CreatesRecurringNodesIdempotently(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("joe", "~"))
+
+	cfg := `kegv: 2025-07
+title: Personal
+recurring:
+  - name: weekly-review
+    every: weekly
+    day: monday
+    title: Weekly Review {date}
+    tags: [review]
+  - name: monthly-notes
+    every: monthly
+    day: "31"
+    template: "3"
+`
+	res := NewProcess(t, false, "config", "edit", "--keg", "personal").RunWithIO(sb.Context(), sb.Runtime(), strings.NewReader(cfg))
+	require.NoError(t, res.Err)
+
+	res = NewProcess(t, false, "cron", "run", "--keg", "personal", "--date", "2026-10-15").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	require.Equal(t,
+		"created\t4\tWeekly Review 2026-10-12\ncreated\t5\tMeeting Notes 2026-09-30\n",
+		string(res.Stdout))
+
+	require.Contains(t, string(sb.MustReadFile("~/kegs/personal/4/meta.yaml")), "review")
+	clone := string(sb.MustReadFile("~/kegs/personal/5/README.md"))
+	require.True(t, strings.HasPrefix(clone, "# Meeting Notes 2026-09-30\n"), clone)
+	require.Contains(t, clone, "[Project Alpha](../2)")
+
+	res = NewProcess(t, false, "cron", "run", "--keg", "personal", "--date", "2026-10-18").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	require.Equal(t,
+		"exists\t4\tWeekly Review 2026-10-12\nexists\t5\tMeeting Notes 2026-09-30\n",
+		string(res.Stdout))
+
+	res = NewProcess(t, false, "cron", "run", "--keg", "personal", "--date", "2026-10-19", "--dry-run", "weekly-review").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	require.Equal(t, "pending\t-\tWeekly Review 2026-10-19\n", string(res.Stdout))
+
+	list := NewProcess(t, false, "list", "--keg", "personal", "--id-only").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, list.Err)
+	require.NotContains(t, strings.Fields(string(list.Stdout)), "6")
+}
+
+func TestCronRun_UnknownRule(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("joe", "~"))
+
+	res := NewProcess(t, false, "cron", "run", "--keg", "personal", "missing").Run(sb.Context(), sb.Runtime())
+	require.Error(t, res.Err)
+	require.Contains(t, res.Err.Error(), `recurring rule "missing" not found`)
+}
@@ -11,8 +11,9 @@ func NewPwdCmd(deps *Deps) *cobra.Command {
 	var opts tapper.DirOptions
 
 	cmd := &cobra.Command{
-		Use:   "dir [NODE_ID]",
-		Short: "print keg directory or node directory path",
+		Use:         "dir [NODE_ID]",
+		Annotations: nodeArgAnnotations(1),
+		Short:       "print keg directory or node directory path",
 		Long: `Print a filesystem path for the resolved keg.
 
 With no NODE_ID, prints the keg root directory.
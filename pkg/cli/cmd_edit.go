@@ -14,12 +14,16 @@ func NewEditCmd(deps *Deps) *cobra.Command {
 		Aliases:           []string{"e"},
 		Short:             "edit a node using a temporary markdown file",
 		ValidArgsFunction: nodeIDCompletionFunc(deps, 1),
+		Annotations:       nodeArgAnnotations(1),
 		Long: `Edit a node in a temporary markdown file.
 
 If the file includes YAML frontmatter, it is written to meta.yaml.
 The remaining markdown body is written to the node content file.
 If stdin is piped with non-empty content, it is applied directly and no editor
-is launched.`,
+is launched.
+
+NODE_ID may also be part of a node title; it is matched fuzzily against the
+index unless --exact is given.`,
 		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			opts.NodeID = args[0]
@@ -29,5 +33,7 @@ is launched.`,
 		},
 	}
 
+	cmd.Flags().BoolVar(&opts.Exact, "exact", false, "disable fuzzy title matching for node arguments")
+
 	return cmd
 }
@@ -19,6 +19,7 @@ func NewFileCmd(deps *Deps) *cobra.Command {
 		newFileUploadCmd(deps),
 		newFileDownloadCmd(deps),
 		newFileRmCmd(deps),
+		newFileGCCmd(deps),
 	)
 
 	return cmd
@@ -28,10 +29,11 @@ func newFileLsCmd(deps *Deps) *cobra.Command {
 	var opts tapper.ListFilesOptions
 
 	cmd := &cobra.Command{
-		Use:     "ls NODE_ID",
-		Short:   "list file attachments for a node",
-		Aliases: []string{"list"},
-		Args:    cobra.ExactArgs(1),
+		Use:         "ls NODE_ID",
+		Annotations: nodeArgAnnotations(1),
+		Short:       "list file attachments for a node",
+		Aliases:     []string{"list"},
+		Args:        cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			opts.NodeID = args[0]
 			applyKegTargetProfile(deps, &opts.KegTargetOptions)
@@ -52,9 +54,10 @@ func newFileUploadCmd(deps *Deps) *cobra.Command {
 	var opts tapper.UploadFileOptions
 
 	cmd := &cobra.Command{
-		Use:   "upload NODE_ID LOCAL_PATH",
-		Short: "upload a file attachment to a node",
-		Args:  cobra.ExactArgs(2),
+		Use:         "upload NODE_ID LOCAL_PATH",
+		Annotations: nodeArgAnnotations(1),
+		Short:       "upload a file attachment to a node",
+		Args:        cobra.ExactArgs(2),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			opts.NodeID = args[0]
 			opts.FilePath = args[1]
@@ -76,9 +79,10 @@ func newFileDownloadCmd(deps *Deps) *cobra.Command {
 	var opts tapper.DownloadFileOptions
 
 	cmd := &cobra.Command{
-		Use:   "download NODE_ID NAME",
-		Short: "download a file attachment from a node",
-		Args:  cobra.ExactArgs(2),
+		Use:         "download NODE_ID NAME",
+		Annotations: nodeArgAnnotations(1),
+		Short:       "download a file attachment from a node",
+		Args:        cobra.ExactArgs(2),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			opts.NodeID = args[0]
 			opts.Name = args[1]
@@ -100,10 +104,11 @@ func newFileRmCmd(deps *Deps) *cobra.Command {
 	var opts tapper.DeleteFileOptions
 
 	cmd := &cobra.Command{
-		Use:     "rm NODE_ID NAME",
-		Short:   "remove a file attachment from a node",
-		Aliases: []string{"remove"},
-		Args:    cobra.ExactArgs(2),
+		Use:         "rm NODE_ID NAME",
+		Annotations: nodeArgAnnotations(1),
+		Short:       "remove a file attachment from a node",
+		Aliases:     []string{"remove"},
+		Args:        cobra.ExactArgs(2),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			opts.NodeID = args[0]
 			opts.Name = args[1]
@@ -113,3 +118,40 @@ func newFileRmCmd(deps *Deps) *cobra.Command {
 	}
 	return cmd
 }
+
+func newFileGCCmd(deps *Deps) *cobra.Command {
+	var opts tapper.FileGCOptions
+
+	cmd := &cobra.Command{
+		Use:   "gc",
+		Short: "remove unreferenced attachment blobs",
+		Long: `Remove blobs from the keg blob store that no attachment points to.
+
+The blob store is enabled with files.blobStore in the keg config. Attachments
+then live once under blobs/ keyed by their SHA-256 hash, and node assets hold
+small pointer files. Deleting or replacing attachments leaves their blobs
+behind until this command runs. Each removed hash is printed, followed by a
+summary.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			applyKegTargetProfile(deps, &opts.KegTargetOptions)
+			report, err := deps.Tap.FileGC(cmd.Context(), opts)
+			if err != nil {
+				return err
+			}
+			out := cmd.OutOrStdout()
+			for _, hash := range report.Removed {
+				fmt.Fprintln(out, hash)
+			}
+			verb := "removed"
+			if opts.DryRun {
+				verb = "would remove"
+			}
+			_, err = fmt.Fprintf(out, "%s %d blob(s), %d bytes; %d kept\n", verb, len(report.Removed), report.Freed, report.Kept)
+			return err
+		},
+	}
+
+	cmd.Flags().BoolVar(&opts.DryRun, "dry-run", false, "report unreferenced blobs without removing them")
+	return cmd
+}
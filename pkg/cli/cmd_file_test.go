@@ -186,3 +186,48 @@ func TestFile_ErrorCases(t *testing.T) {
 		})
 	}
 }
+
+func TestFile_BlobStoreDeduplicatesAndCollects(t *testing.T) {
+	t.Parallel()
+	sb := fileFixture(t)
+
+	cfg := sb.MustReadFile("~/kegs/example/keg")
+	sb.MustWriteFile("~/kegs/example/keg", append(cfg, "files:\n    blobStore: true\n"...), 0o644)
+	original := sb.MustReadFile("~/test-images/default.png")
+
+	res := NewProcess(t, false, "create", "--title", "Second").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	second := strings.TrimSpace(string(res.Stdout))
+
+	for _, node := range []string{"0", second} {
+		res = NewProcess(t, false, "file", "upload", node, "~/test-images/default.png").Run(sb.Context(), sb.Runtime())
+		require.NoError(t, res.Err)
+		pointer := string(sb.MustReadFile("~/kegs/example/" + node + "/assets/default.png"))
+		require.True(t, strings.HasPrefix(pointer, "keg-blob: sha256:"), pointer)
+	}
+	hash := strings.TrimSpace(strings.TrimPrefix(string(sb.MustReadFile("~/kegs/example/0/assets/default.png")), "keg-blob: sha256:"))
+	require.Equal(t, original, sb.MustReadFile("~/kegs/example/blobs/"+hash[:2]+"/"+hash))
+
+	res = NewProcess(t, false, "file", "download", second, "default.png", "--dest", "~/out.png").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	require.Equal(t, original, sb.MustReadFile("~/out.png"))
+
+	res = NewProcess(t, false, "file", "rm", "0", "default.png").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	res = NewProcess(t, false, "file", "gc").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	require.Equal(t, "removed 0 blob(s), 0 bytes; 1 kept\n", string(res.Stdout))
+
+	res = NewProcess(t, false, "file", "rm", second, "default.png").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	res = NewProcess(t, false, "file", "gc", "--dry-run").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	require.True(t, strings.HasPrefix(string(res.Stdout), hash+"\nwould remove 1 blob(s)"), string(res.Stdout))
+	require.NotEmpty(t, sb.MustReadFile("~/kegs/example/blobs/"+hash[:2]+"/"+hash))
+
+	res = NewProcess(t, false, "file", "gc").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	require.Contains(t, string(res.Stdout), "removed 1 blob(s)")
+	_, err := sb.ReadFile("~/kegs/example/blobs/" + hash[:2] + "/" + hash)
+	require.Error(t, err)
+}
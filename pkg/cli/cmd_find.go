@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/jlrickert/tapper/pkg/tapper"
+	"github.com/spf13/cobra"
+)
+
+// NewFindCmd returns the `find` cobra command.
+//
+// Usage examples:
+//
+//	tap find
+//	tap find golang --edit
+func NewFindCmd(deps *Deps) *cobra.Command {
+	var (
+		opts tapper.FindOptions
+		edit bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "find [QUERY]",
+		Short: "interactively pick a node by title, tags, or lead",
+		Long: `Open an interactive picker that filters nodes by title, tags, and lead as
+you type, with a preview of the highlighted node.
+
+Keys: type to filter, Up/Down or Ctrl-P/Ctrl-N to move, Enter to select,
+Ctrl-U to clear the query, Esc or Ctrl-C to cancel.
+
+The selected node ID is printed to stdout, or opened in the editor with
+--edit. When stdout is not a terminal, the nodes matching QUERY are printed
+instead, one "ID<TAB>TITLE" per line.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			applyKegTargetProfile(deps, &opts.KegTargetOptions)
+			ctx := cmd.Context()
+
+			query := ""
+			if len(args) > 0 {
+				query = args[0]
+			}
+
+			cands, err := deps.Tap.FindCandidates(ctx, opts)
+			if err != nil {
+				return err
+			}
+
+			if !deps.Runtime.Stream().IsTTY {
+				for _, cand := range tapper.FilterFindCandidates(cands, query) {
+					fmt.Fprintf(cmd.OutOrStdout(), "%s\t%s\n", cand.ID, cand.Title)
+				}
+				return nil
+			}
+
+			picker := newFindPicker(cands, query, func(id string) string {
+				content, previewErr := deps.Tap.FindPreview(ctx, opts, id)
+				if previewErr != nil {
+					return previewErr.Error()
+				}
+				return content
+			})
+			choice, err := runFindPicker(picker, cmd.InOrStdin(), cmd.ErrOrStderr())
+			fmt.Fprintln(cmd.ErrOrStderr())
+			if err != nil {
+				return err
+			}
+
+			if edit {
+				return deps.Tap.Edit(ctx, tapper.EditOptions{
+					NodeID:           choice.ID,
+					KegTargetOptions: opts.KegTargetOptions,
+					Exact:            true,
+				})
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), choice.ID)
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVarP(&edit, "edit", "e", false, "open the selected node in the editor")
+
+	return cmd
+}
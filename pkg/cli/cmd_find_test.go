@@ -0,0 +1,41 @@
+package cli_test
+
+import (
+	"strings"
+	"testing"
+
+	testutils "github.com/jlrickert/cli-toolkit/sandbox"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindCommand_NonInteractiveListsMatches(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("joe", "~"))
+
+	res := NewProcess(t, false, "find", "alpha").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	lines := strings.Split(strings.TrimSpace(string(res.Stdout)), "\n")
+	require.Equal(t, "2\tProject Alpha", lines[0], "title matches rank first")
+}
+
+func TestFindCommand_InteractivePickerSelectsNode(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("joe", "~"))
+
+	// Type "p", move down once, and accept the highlighted candidate.
+	keys := "p\x1b[B\r"
+	res := NewProcess(t, true, "find").RunWithIO(sb.Context(), sb.Runtime(), strings.NewReader(keys))
+	require.NoError(t, res.Err)
+	require.Equal(t, "2", strings.TrimSpace(string(res.Stdout)))
+	require.Contains(t, string(res.Stderr), "> 2 Project Alpha")
+}
+
+func TestFindCommand_InteractivePickerCanceled(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("joe", "~"))
+
+	res := NewProcess(t, true, "find").RunWithIO(sb.Context(), sb.Runtime(), strings.NewReader("\x03"))
+	require.Error(t, res.Err)
+	require.Contains(t, res.Err.Error(), "find canceled")
+	require.Empty(t, strings.TrimSpace(string(res.Stdout)))
+}
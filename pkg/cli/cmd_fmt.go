@@ -0,0 +1,74 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jlrickert/tapper/pkg/tapper"
+	"github.com/spf13/cobra"
+)
+
+// NewFmtCmd returns the `fmt` cobra command.
+//
+// Usage examples:
+//
+//	tap fmt
+//	tap fmt 42 --width 80
+//	tap fmt --check
+func NewFmtCmd(deps *Deps) *cobra.Command {
+	var opts tapper.FmtOptions
+	var width int
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "fmt [NODE_ID...]",
+		Short: "normalize the Markdown of nodes",
+		Long: `Rewrite node content in a canonical Markdown form: ATX headings that go
+at most one level deeper than the heading before them, "-" bullets and
+sequential numbers, fenced code blocks, one blank line between blocks, no
+trailing whitespace, backslash hard breaks and canonical ../N node links.
+With a width, from --width or fmt.width in the keg config, paragraphs are
+rewrapped to that many columns. Frontmatter, code and HTML are kept as
+written. Without NODE_IDs every node is formatted; nodes that are not
+Markdown are skipped.
+
+The nodes that changed are printed. With --check nothing is written: the
+nodes that are not formatted are printed and the command fails when there
+are any, for use in CI. With --dry-run the files that would be written are
+listed instead.`,
+		ValidArgsFunction: nodeIDCompletionFunc(deps, 0),
+		Annotations:       nodeArgAnnotations(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.NodeIDs = args
+			if cmd.Flags().Changed("width") {
+				opts.Width = &width
+			}
+			applyKegTargetProfile(deps, &opts.KegTargetOptions)
+			return runWithDryRun(cmd, deps, dryRun, func(ctx context.Context) error {
+				changed, err := deps.Tap.Fmt(ctx, opts)
+				if dryRun && err == nil {
+					return nil
+				}
+				for _, id := range changed {
+					if _, werr := fmt.Fprintln(cmd.OutOrStdout(), id.Path()); werr != nil {
+						return werr
+					}
+				}
+				if err != nil {
+					return err
+				}
+				if opts.Check && len(changed) > 0 {
+					return fmt.Errorf("%d node(s) need formatting; run `%s fmt` to fix them", len(changed), deps.Profile.Use)
+				}
+				return nil
+			})
+		},
+	}
+
+	addDryRunFlag(cmd, &dryRun)
+	cmd.Flags().BoolVar(&opts.Check, "check", false, "list nodes that are not formatted and fail if there are any, without changing them")
+	cmd.Flags().IntVar(&width, "width", 0, "wrap paragraphs to this many columns; 0 keeps line breaks (default fmt.width from the keg config)")
+	cmd.Flags().BoolVar(&opts.Exact, "exact", false, "disable fuzzy title matching for node arguments")
+
+	return cmd
+}
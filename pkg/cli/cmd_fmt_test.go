@@ -0,0 +1,35 @@
+package cli_test
+
+import (
+	"strings"
+	"testing"
+
+	testutils "github.com/jlrickert/cli-toolkit/sandbox"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFmt_CheckAndFormat(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("testuser", "~"))
+
+	create := NewProcess(t, true, "create").RunWithIO(
+		sb.Context(),
+		sb.Runtime(),
+		strings.NewReader("# Messy   \n\n* one\n* two\n\nSee [it]( ../ 0 ) for more words here.\n"),
+	)
+	require.NoError(t, create.Err)
+	require.Equal(t, "1", strings.TrimSpace(string(create.Stdout)))
+
+	check := NewProcess(t, false, "fmt", "--check").Run(sb.Context(), sb.Runtime())
+	require.Error(t, check.Err)
+	require.Equal(t, "1", strings.TrimSpace(string(check.Stdout)))
+	require.Contains(t, string(check.Stderr), "1 node(s) need formatting")
+
+	res := NewProcess(t, false, "fmt", "1", "--width", "24").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	require.Equal(t, "1", strings.TrimSpace(string(res.Stdout)))
+	require.Equal(t, "# Messy\n\n- one\n- two\n\nSee [it](../0) for more\nwords here.\n",
+		string(sb.MustReadFile("~/kegs/example/1/README.md")))
+
+	require.NoError(t, NewProcess(t, false, "fmt", "1", "--check", "--width", "24").Run(sb.Context(), sb.Runtime()).Err)
+}
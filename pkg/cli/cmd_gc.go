@@ -0,0 +1,72 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/jlrickert/tapper/pkg/tapper"
+	"github.com/spf13/cobra"
+)
+
+func NewGCCmd(deps *Deps) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "gc",
+		Short: "clean up unused keg data",
+	}
+
+	cmd.AddCommand(
+		newGCMediaCmd(deps),
+	)
+
+	return cmd
+}
+
+func newGCMediaCmd(deps *Deps) *cobra.Command {
+	var opts tapper.MediaGCOptions
+
+	cmd := &cobra.Command{
+		Use:   "media",
+		Short: "remove images and attachments no node links to",
+		Long: `Cross-reference the image and attachment links in every node's content
+against the files stored with each node and list the unreferenced ones as
+NODE<TAB>PATH. Links may point into another node, as in ../42/images/x.png.
+
+Nothing is deleted unless --apply is given, and on a TTY the deletion is
+confirmed first; --yes skips the prompt. tap doctor reports the same findings
+as unreferenced-media warnings.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			applyKegTargetProfile(deps, &opts.KegTargetOptions)
+			if opts.Apply {
+				scan := opts
+				scan.Apply = false
+				items, err := deps.Tap.MediaGC(cmd.Context(), scan)
+				if err != nil {
+					return err
+				}
+				if len(items) > 0 {
+					question := fmt.Sprintf("Delete %d unreferenced item(s)?", len(items))
+					if err := confirm(cmd, deps, "gc media", false, question); err != nil {
+						return err
+					}
+				}
+			}
+			items, err := deps.Tap.MediaGC(cmd.Context(), opts)
+			if err != nil {
+				return err
+			}
+			out := cmd.OutOrStdout()
+			for _, item := range items {
+				fmt.Fprintf(out, "%s\t%s\n", item.Node.Path(), tapper.AttachmentPath(item.Attachment))
+			}
+			if opts.Apply {
+				_, err = fmt.Fprintf(out, "removed %d unreferenced item(s)\n", len(items))
+			} else {
+				_, err = fmt.Fprintf(out, "would remove %d unreferenced item(s); rerun with --apply\n", len(items))
+			}
+			return err
+		},
+	}
+
+	cmd.Flags().BoolVar(&opts.Apply, "apply", false, "delete the unreferenced media")
+	return cmd
+}
@@ -0,0 +1,48 @@
+package cli_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGCMedia_ReportsAndRemovesUnreferenced(t *testing.T) {
+	t.Parallel()
+	sb := imageFixture(t)
+	sb.MustWriteFile("~/spec.txt", []byte("spec"), 0o644)
+
+	for _, args := range [][]string{
+		{"image", "upload", "0", "~/test-images/default.png", "--name", "used.png"},
+		{"image", "upload", "0", "~/test-images/default.png", "--name", "stale.png"},
+		{"file", "upload", "0", "~/spec.txt"},
+		{"file", "upload", "0", "~/spec.txt", "--name", "old.txt"},
+	} {
+		res := NewProcess(t, false, args...).Run(sb.Context(), sb.Runtime())
+		require.NoError(t, res.Err, args)
+	}
+	sb.MustWriteFile("~/kegs/example/0/README.md", []byte("# Overview\n\n![chart](images/used.png)\n"), 0o644)
+
+	res := NewProcess(t, false, "create", "--title", "Spec").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	id := strings.TrimSpace(string(res.Stdout))
+	sb.MustWriteFile("~/kegs/example/"+id+"/README.md",
+		[]byte("# Spec\n\nSee <a href=\"../0/assets/spec.txt\">the spec</a>.\n"), 0o644)
+
+	res = NewProcess(t, false, "gc", "media").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err, string(res.Stderr))
+	require.Equal(t,
+		"0\timages/stale.png\n0\tassets/old.txt\nwould remove 2 unreferenced item(s); rerun with --apply\n",
+		string(res.Stdout))
+
+	res = NewProcess(t, false, "doctor").Run(sb.Context(), sb.Runtime())
+	require.Contains(t, string(res.Stdout)+string(res.Stderr), "images/stale.png is not referenced by any node")
+
+	res = NewProcess(t, false, "gc", "media", "--apply").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	require.Contains(t, string(res.Stdout), "removed 2 unreferenced item(s)")
+
+	res = NewProcess(t, false, "attach", "ls", "0").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	require.Equal(t, "images/used.png\nassets/spec.txt\n", string(res.Stdout))
+}
@@ -15,7 +15,10 @@ import (
 // Usage examples:
 //
 //	tap graph
-//	tap graph --keg pub --output graph.html
+//	tap graph --keg pub --out-file graph.html
+//	tap graph --format json > graph.json
+//	tap graph path 3 12
+//	tap graph extract --seed 42 --depth 2 --to project
 func NewGraphCmd(deps *Deps) *cobra.Command {
 	var (
 		opts       tapper.GraphOptions
@@ -28,18 +31,21 @@ func NewGraphCmd(deps *Deps) *cobra.Command {
 		Long: `Render KEG nodes and relationships as a standalone HTML page.
 
 The output includes both forward links and backlinks, and can be sent to stdout
-or written to a file with --output.`,
+or written to a file with --out-file.
+
+Use --format json to emit a nodes/edges document (id, title, tags, degree) for
+custom visualizations instead of the HTML page.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			applyKegTargetProfile(deps, &opts.KegTargetOptions)
 			opts.BundleJS = graphBundle
 
-			html, err := deps.Tap.Graph(cmd.Context(), opts)
+			out, err := deps.Tap.Graph(cmd.Context(), opts)
 			if err != nil {
 				return err
 			}
 
 			if strings.TrimSpace(outputPath) == "" {
-				_, err = fmt.Fprint(cmd.OutOrStdout(), html)
+				_, err = fmt.Fprint(cmd.OutOrStdout(), out)
 				return err
 			}
 
@@ -52,7 +58,7 @@ or written to a file with --output.`,
 			if err := deps.Runtime.Mkdir(dir, 0o755, true); err != nil {
 				return fmt.Errorf("unable to create output directory %q: %w", dir, err)
 			}
-			if err := deps.Runtime.AtomicWriteFile(path, []byte(html), 0o644); err != nil {
+			if err := deps.Runtime.AtomicWriteFile(path, []byte(out), 0o644); err != nil {
 				return fmt.Errorf("unable to write output file %q: %w", path, err)
 			}
 
@@ -61,7 +67,109 @@ or written to a file with --output.`,
 		},
 	}
 
-	cmd.Flags().StringVarP(&outputPath, "output", "o", "", "write graph HTML to file (default: stdout)")
+	cmd.Flags().StringVarP(&outputPath, "out-file", "o", "", "write graph to file (default: stdout)")
+	cmd.Flags().StringVar(&opts.Format, "format", "html", `output format: "html" or "json"`)
+	_ = cmd.RegisterFlagCompletionFunc("format", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{"html", "json"}, cobra.ShellCompDirectiveNoFileComp
+	})
+
+	cmd.AddCommand(
+		newGraphPathCmd(deps),
+		newGraphExtractCmd(deps),
+	)
+
+	return cmd
+}
+
+// newGraphPathCmd returns the `graph path` subcommand.
+func newGraphPathCmd(deps *Deps) *cobra.Command {
+	var opts tapper.GraphPathOptions
+
+	cmd := &cobra.Command{
+		Use:   "path FROM TO",
+		Short: "show how two nodes are connected",
+		Long: `Print the shortest chain of nodes linking FROM to TO, one node per line.
+
+Links are followed in either direction, so a path may pass through a
+backlink. Format placeholders: %i (node id), %d (date), %t (title),
+%% (literal %). Default format: "%i\t%t".`,
+		Args:              cobra.ExactArgs(2),
+		ValidArgsFunction: nodeIDCompletionFunc(deps, 2),
+		Annotations:       nodeArgAnnotations(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.From, opts.To = args[0], args[1]
+			applyKegTargetProfile(deps, &opts.KegTargetOptions)
+
+			lines, err := deps.Tap.GraphPath(cmd.Context(), opts)
+			if err != nil {
+				return err
+			}
+			for _, line := range lines {
+				fmt.Fprintln(cmd.OutOrStdout(), line)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVarP(&opts.IdOnly, "id-only", "", false, "show only ids")
+	cmd.Flags().StringVarP(&opts.Format, "format", "f", "", "output format")
+	cmd.Flags().BoolVar(&opts.Exact, "exact", false, "disable fuzzy title matching for node arguments")
+
+	return cmd
+}
+
+// newGraphExtractCmd returns the `graph extract` subcommand.
+func newGraphExtractCmd(deps *Deps) *cobra.Command {
+	var opts tapper.GraphExtractOptions
+
+	cmd := &cobra.Command{
+		Use:   "extract (--to ALIAS | --to-path DIR) (--seed NODE_ID [--depth N] | --filter EXPR)",
+		Short: "copy a subgraph into a new keg",
+		Long: `Copy a subgraph of the current keg into a new keg.
+
+The subgraph is the --seed node plus every node within --depth link hops
+(following links in either direction), the nodes matching --filter, or both.
+Copied nodes get fresh IDs. Links between copied nodes stay relative and links
+leaving the subgraph become keg:SOURCE/N references, as with "tap import".
+
+--to names the new keg's alias; an alias that is not configured yet is created
+under the first kegSearchPaths entry. --to-path creates the keg in DIR instead.
+An existing target keg must not hold any nodes besides node 0.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.Target.Keg == "" && opts.Target.Path == "" {
+				return fmt.Errorf("--to ALIAS or --to-path DIR is required")
+			}
+			applyKegTargetProfile(deps, &opts.KegTargetOptions)
+
+			extracted, err := deps.Tap.GraphExtract(cmd.Context(), opts)
+			if err != nil {
+				return err
+			}
+			for _, node := range extracted {
+				if _, err := fmt.Fprintf(cmd.OutOrStdout(), "%s -> %s\n",
+					node.SourceID.Path(), node.TargetID.Path()); err != nil {
+					return err
+				}
+			}
+			_, err = fmt.Fprintf(cmd.OutOrStdout(), "\nextracted %d node(s)\n", len(extracted))
+			return err
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.Target.Keg, "to", "", "alias of the new keg")
+	cmd.Flags().StringVar(&opts.Target.Path, "to-path", "", "directory of the new keg")
+	cmd.Flags().StringVar(&opts.Seed, "seed", "", "seed node ID or title")
+	cmd.Flags().IntVar(&opts.Depth, "depth", 1, "link hops around the seed to include")
+	cmd.Flags().StringVar(&opts.Filter, "filter", "", `boolean expression (see "tap docs query-expressions" for syntax)`)
+	cmd.Flags().BoolVar(&opts.Exact, "exact", false, "disable fuzzy title matching for the seed")
+	_ = cmd.RegisterFlagCompletionFunc("to", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if deps.Tap == nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		kegs, _ := deps.Tap.ListKegs(true)
+		return kegs, cobra.ShellCompDirectiveNoFileComp
+	})
 
 	return cmd
 }
@@ -105,10 +105,15 @@ func TestGraphCommand_WritesOutputFile(t *testing.T) {
 	t.Parallel()
 
 	sb := NewSandbox(t, testutils.WithFixture("joe", "~"))
-	res := NewProcess(t, false, "graph", "--keg", "personal", "--output", "~/graph.html").Run(sb.Context(), sb.Runtime())
+	res := NewProcess(t, false, "graph", "--keg", "personal", "--out-file", "~/graph.html").Run(sb.Context(), sb.Runtime())
 	require.NoError(t, res.Err)
 	require.Contains(t, string(res.Stdout), "graph written to")
 
+	// -o names the file; the global --output format flag is left alone.
+	res = NewProcess(t, false, "graph", "--keg", "personal", "-o", "~/short.html", "--output", "json").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err, string(res.Stderr))
+	require.Contains(t, string(sb.MustReadFile("~/short.html")), "<!DOCTYPE html>")
+
 	raw := sb.MustReadFile("~/graph.html")
 	out := string(raw)
 	require.Contains(t, out, "<!DOCTYPE html>")
@@ -168,3 +173,103 @@ func containsGraphEdge(edges []graphEdge, want graphEdge) bool {
 	}
 	return false
 }
+
+func TestGraphPathCommand(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("joe", "~"))
+
+	// 3 has no link to 1, but 1 links to 3.
+	res := NewProcess(t, false, "graph", "path", "3", "1").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	require.Equal(t, "3\tMeeting Notes\n1\tPersonal Overview", strings.TrimSpace(string(res.Stdout)))
+
+	res = NewProcess(t, false, "graph", "path", "1", "meeting", "--id-only").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	require.Equal(t, "1\n3", strings.TrimSpace(string(res.Stdout)))
+
+	res = NewProcess(t, false, "graph", "path", "0", "1").Run(sb.Context(), sb.Runtime())
+	require.Error(t, res.Err)
+	require.Contains(t, res.Err.Error(), "no path between 0 and 1")
+}
+
+func TestGraphCommand_JSONFormat(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("joe", "~"))
+
+	res := NewProcess(t, false, "graph", "--format", "json").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+
+	var doc struct {
+		Nodes []struct {
+			ID     string   `json:"id"`
+			Title  string   `json:"title"`
+			Tags   []string `json:"tags"`
+			Degree int      `json:"degree"`
+		} `json:"nodes"`
+		Edges []struct {
+			Source string `json:"source"`
+			Target string `json:"target"`
+		} `json:"edges"`
+	}
+	require.NoError(t, json.Unmarshal(res.Stdout, &doc))
+	require.Len(t, doc.Nodes, 4)
+	require.Equal(t, "0", doc.Nodes[0].ID)
+	require.Equal(t, []string{"planned"}, doc.Nodes[0].Tags)
+	require.Zero(t, doc.Nodes[0].Degree)
+	require.Equal(t, "Project Alpha", doc.Nodes[2].Title)
+	require.Equal(t, 4, doc.Nodes[2].Degree)
+	require.Len(t, doc.Edges, 5)
+
+	bad := NewProcess(t, false, "graph", "--format", "svg").Run(sb.Context(), sb.Runtime())
+	require.Error(t, bad.Err)
+}
+
+func TestGraphExtractCommand_SeedAndDepth(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("joe", "~"))
+
+	// personal: 3 links to 2; with depth 1 the neighborhood of 3 is {1, 2}.
+	res := NewProcess(t, false, "graph", "extract", "--keg", "personal", "--seed", "3", "--depth", "1", "--to", "work").
+		Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	out := string(res.Stdout)
+	require.Contains(t, out, "1 -> 1")
+	require.Contains(t, out, "2 -> 2")
+	require.Contains(t, out, "3 -> 3")
+	require.Contains(t, out, "extracted 3 node(s)")
+
+	node3 := string(sb.MustReadFile("~/kegs/work/3/README.md"))
+	require.Contains(t, node3, "[Project Alpha](../2)")
+
+	res = NewProcess(t, false, "graph", "extract", "--keg", "personal", "--seed", "3", "--depth", "0", "--to", "work").
+		Run(sb.Context(), sb.Runtime())
+	require.Error(t, res.Err, "an existing keg with nodes is refused")
+	require.Contains(t, res.Err.Error(), "already holds nodes")
+
+	res = NewProcess(t, false, "graph", "extract", "--keg", "personal", "--seed", "3", "--depth", "0", "--to", "split").
+		Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	require.Contains(t, string(res.Stdout), "3 -> 1")
+	node1 := string(sb.MustReadFile("~/.local/share/tapper/kegs/split/1/README.md"))
+	require.Contains(t, node1, "keg:personal/2", "links leaving the subgraph become cross-keg references")
+	require.Contains(t, string(sb.MustReadFile("~/.config/tapper/config.yaml")), "split:")
+
+	res = NewProcess(t, false, "graph", "extract", "--keg", "personal", "--seed", "2", "--depth", "0", "--to-path", "~/extracted").
+		Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	require.Contains(t, string(res.Stdout), "2 -> 1")
+	require.Contains(t, string(sb.MustReadFile("~/extracted/1/README.md")), "Project Alpha")
+}
+
+func TestGraphExtractCommand_RequiresSelection(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("joe", "~"))
+
+	res := NewProcess(t, false, "graph", "extract", "--keg", "personal", "--to", "work").Run(sb.Context(), sb.Runtime())
+	require.Error(t, res.Err)
+	require.Contains(t, res.Err.Error(), "seed node or filter")
+
+	res = NewProcess(t, false, "graph", "extract", "--keg", "personal", "--filter", "nosuchtag", "--to", "work").Run(sb.Context(), sb.Runtime())
+	require.Error(t, res.Err)
+	require.Contains(t, res.Err.Error(), "no nodes matched")
+}
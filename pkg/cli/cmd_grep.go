@@ -13,8 +13,16 @@ func NewGrepCmd(deps *Deps) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "grep QUERY",
 		Short: "search node content by query",
-		Long:  "Search node content with a regex and print matching lines grouped by node.",
-		Args:  cobra.ExactArgs(1),
+		Long: `Search node content with a regex and print matching lines grouped by node.
+
+With --rank, nodes are ordered by relevance: a blend of how often the query
+matches, how recently the node was updated, and how often it has been
+accessed. Weights are configured per keg under the "search" config key.
+
+With --format or --id-only, one line per matching node is printed instead.
+Format placeholders: %i (node id), %d (date), %t (title), %% (literal %); a
+format containing {{ is a Go template run per node.`,
+		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			opts.Query = args[0]
 			applyKegTargetProfile(deps, &opts.KegTargetOptions)
@@ -34,6 +42,8 @@ func NewGrepCmd(deps *Deps) *cobra.Command {
 	cmd.Flags().BoolVar(&opts.Reverse, "reverse", false, "list nodes in reverse order")
 	cmd.Flags().StringVarP(&opts.Format, "format", "f", "", "output format")
 	cmd.Flags().BoolVarP(&opts.IgnoreCase, "ignore-case", "i", false, "perform case-insensitive matching")
+	addDateRangeFlags(cmd, &opts.DateRangeOptions)
+	cmd.Flags().BoolVar(&opts.Rank, "rank", false, "order results by relevance instead of node id")
 
 	return cmd
 }
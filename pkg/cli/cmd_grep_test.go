@@ -103,6 +103,22 @@ func TestGrepCommand_IgnoreCaseIdOnlyReverseAndFormat(t *testing.T) {
 	require.Equal(t, "1|Alpha\n2|Beta", strings.TrimSpace(string(formatted.Stdout)))
 }
 
+func TestGrepCommand_RankOrdersByRelevance(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("testuser", "~"))
+
+	createNodeWithBodyFromStdin(t, sb, "# Alpha\n\nfire once\n")
+	createNodeWithBodyFromStdin(t, sb, "# Beta\n\nfire fire fire\nmore fire\n")
+
+	plain := NewProcess(t, false, "grep", "fire", "--id-only").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, plain.Err)
+	require.Equal(t, "1\n2", strings.TrimSpace(string(plain.Stdout)))
+
+	ranked := NewProcess(t, false, "grep", "fire", "--id-only", "--rank").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, ranked.Err)
+	require.Equal(t, "2\n1", strings.TrimSpace(string(ranked.Stdout)))
+}
+
 func TestGrepCommand_NoMatchesReturnsEmptyOutput(t *testing.T) {
 	t.Parallel()
 	sb := NewSandbox(t, testutils.WithFixture("joe", "~"))
@@ -28,10 +28,11 @@ func newImageLsCmd(deps *Deps) *cobra.Command {
 	var opts tapper.ListImagesOptions
 
 	cmd := &cobra.Command{
-		Use:     "ls NODE_ID",
-		Short:   "list images for a node",
-		Aliases: []string{"list"},
-		Args:    cobra.ExactArgs(1),
+		Use:         "ls NODE_ID",
+		Annotations: nodeArgAnnotations(1),
+		Short:       "list images for a node",
+		Aliases:     []string{"list"},
+		Args:        cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			opts.NodeID = args[0]
 			applyKegTargetProfile(deps, &opts.KegTargetOptions)
@@ -52,9 +53,19 @@ func newImageUploadCmd(deps *Deps) *cobra.Command {
 	var opts tapper.UploadImageOptions
 
 	cmd := &cobra.Command{
-		Use:   "upload NODE_ID LOCAL_PATH",
-		Short: "upload an image to a node",
-		Args:  cobra.ExactArgs(2),
+		Use:         "upload NODE_ID LOCAL_PATH",
+		Annotations: nodeArgAnnotations(1),
+		Short:       "upload an image to a node",
+		Long: `Upload LOCAL_PATH as an image on NODE_ID.
+
+EXIF (including GPS coordinates) and XMP metadata is stripped from JPEG, PNG
+and HEIC images unless the keg sets images.stripMetadata to false or
+--keep-metadata is given.
+
+When the keg configures images.convert, HEIC/TIFF uploads are converted to
+JPEG and large PNGs to WebP before they are stored, and the printed name
+reflects the new extension. Pass --no-convert to store the file as is.`,
+		Args: cobra.ExactArgs(2),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			opts.NodeID = args[0]
 			opts.FilePath = args[1]
@@ -69,6 +80,9 @@ func newImageUploadCmd(deps *Deps) *cobra.Command {
 	}
 
 	cmd.Flags().StringVar(&opts.Name, "name", "", "stored filename (default: basename of LOCAL_PATH)")
+	cmd.Flags().StringVar(&opts.Alt, "alt", "", "alternative text recorded in the image metadata")
+	cmd.Flags().BoolVar(&opts.KeepMetadata, "keep-metadata", false, "keep EXIF/XMP metadata even when the keg strips it on upload")
+	cmd.Flags().BoolVar(&opts.NoConvert, "no-convert", false, "skip the format conversion configured in the keg")
 	return cmd
 }
 
@@ -76,9 +90,10 @@ func newImageDownloadCmd(deps *Deps) *cobra.Command {
 	var opts tapper.DownloadImageOptions
 
 	cmd := &cobra.Command{
-		Use:   "download NODE_ID NAME",
-		Short: "download an image from a node",
-		Args:  cobra.ExactArgs(2),
+		Use:         "download NODE_ID NAME",
+		Annotations: nodeArgAnnotations(1),
+		Short:       "download an image from a node",
+		Args:        cobra.ExactArgs(2),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			opts.NodeID = args[0]
 			opts.Name = args[1]
@@ -100,10 +115,11 @@ func newImageRmCmd(deps *Deps) *cobra.Command {
 	var opts tapper.DeleteImageOptions
 
 	cmd := &cobra.Command{
-		Use:     "rm NODE_ID NAME",
-		Short:   "remove an image from a node",
-		Aliases: []string{"remove"},
-		Args:    cobra.ExactArgs(2),
+		Use:         "rm NODE_ID NAME",
+		Annotations: nodeArgAnnotations(1),
+		Short:       "remove an image from a node",
+		Aliases:     []string{"remove"},
+		Args:        cobra.ExactArgs(2),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			opts.NodeID = args[0]
 			opts.Name = args[1]
@@ -1,6 +1,9 @@
 package cli_test
 
 import (
+	"bytes"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -66,6 +69,42 @@ func TestImageUpload_ContentsPreserved(t *testing.T) {
 	require.Equal(t, original, stored)
 }
 
+// exifJPEG is a minimal JPEG carrying an APP1 EXIF segment with a GPS tag.
+var exifJPEG = []byte("\xFF\xD8" +
+	"\xFF\xE1\x00\x18Exif\x00\x00GPSLatitude=47.6" +
+	"\xFF\xDA\x00\x08\x01\x01\x00\x00\x3F\x00" +
+	"\x12\x34\xFF\xD9")
+
+func TestImageUpload_StripsExifByDefault(t *testing.T) {
+	t.Parallel()
+	sb := imageFixture(t)
+	sb.MustWriteFile("~/photo.jpg", exifJPEG, 0o644)
+
+	res := NewProcess(t, false, "image", "upload", "0", "~/photo.jpg").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+
+	stored := sb.MustReadFile("~/kegs/example/0/images/photo.jpg")
+	require.NotContains(t, string(stored), "GPSLatitude")
+	require.True(t, bytes.HasSuffix(stored, []byte("\x12\x34\xFF\xD9")))
+
+	res = NewProcess(t, false, "image", "upload", "0", "~/photo.jpg", "--name", "raw.jpg", "--keep-metadata").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	require.Equal(t, exifJPEG, sb.MustReadFile("~/kegs/example/0/images/raw.jpg"))
+}
+
+func TestImageUpload_StripDisabledInKegConfig(t *testing.T) {
+	t.Parallel()
+	sb := imageFixture(t)
+	sb.MustWriteFile("~/photo.jpg", exifJPEG, 0o644)
+
+	cfg := sb.MustReadFile("~/kegs/example/keg")
+	sb.MustWriteFile("~/kegs/example/keg", append(cfg, "images:\n    stripMetadata: false\n"...), 0o644)
+
+	res := NewProcess(t, false, "image", "upload", "0", "~/photo.jpg").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	require.Equal(t, exifJPEG, sb.MustReadFile("~/kegs/example/0/images/photo.jpg"))
+}
+
 func TestImageList_ShowsUploadedImages(t *testing.T) {
 	t.Parallel()
 	sb := imageFixture(t)
@@ -184,3 +223,30 @@ func TestImage_ErrorCases(t *testing.T) {
 		})
 	}
 }
+
+func TestImageUpload_ConvertsLargePNGToWebP(t *testing.T) {
+	t.Parallel()
+	sb := imageFixture(t)
+	png := sb.MustReadFile("~/test-images/default.png")
+
+	// The stand-in converter records its arguments in a fake WebP file.
+	script := filepath.Join(t.TempDir(), "convert.sh")
+	require.NoError(t, os.WriteFile(script, []byte("printf 'RIFF0000WEBPq=%s' \"$3\" > \"$4\"\n"), 0o755))
+	cfg := sb.MustReadFile("~/kegs/example/keg")
+	sb.MustWriteFile("~/kegs/example/keg", append(cfg,
+		"images:\n    convert:\n        pngToWebp: 1KB\n        quality: 70\n        keepOriginal: true\n        command: /bin/sh "+script+"\n"...), 0o644)
+	res := NewProcess(t, false, "repo", "trust", "--keg", "example").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err, string(res.Stderr))
+
+	res = NewProcess(t, false, "image", "upload", "0", "~/test-images/default.png").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err, string(res.Stderr))
+	require.Equal(t, "default.webp", strings.TrimSpace(string(res.Stdout)))
+	require.Equal(t, "RIFF0000WEBPq=70", string(sb.MustReadFile("~/kegs/example/0/images/default.webp")))
+	require.Equal(t, png, sb.MustReadFile("~/kegs/example/0/assets/default.png"), "original kept as attachment")
+
+	res = NewProcess(t, false, "image", "upload", "0", "~/test-images/default.png", "--no-convert").
+		Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	require.Equal(t, "default.png", strings.TrimSpace(string(res.Stdout)))
+	require.Equal(t, png, sb.MustReadFile("~/kegs/example/0/images/default.png"))
+}
@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"context"
 	"fmt"
 	"regexp"
 
@@ -14,6 +15,7 @@ var kegImportArgRefRE = regexp.MustCompile(`^keg:([a-zA-Z0-9][a-zA-Z0-9_-]*)/([0
 func NewImportCmd(deps *Deps) *cobra.Command {
 	var opts tapper.ImportFromKegOptions
 	var fromKeg string
+	var dryRun bool
 
 	opts.SkipZeroNode = true
 
@@ -33,7 +35,9 @@ rewritten:
   keg:OTHER/N              -> unchanged
 
 Nodes may be specified as bare IDs with --from SOURCE, or as keg:ALIAS/NODE_ID
-references. All must come from the same source keg.`,
+references. All must come from the same source keg. With --dry-run the ID
+mapping and the files that would be written in either keg are listed, but
+nothing is imported.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			// Extract source alias from keg:ALIAS/N args when --from is absent.
 			if fromKeg == "" {
@@ -54,25 +58,17 @@ references. All must come from the same source keg.`,
 			opts.NodeIDs = args
 			applyKegTargetProfile(deps, &opts.Target)
 
-			imported, err := deps.Tap.ImportFromKeg(cmd.Context(), opts)
-			if err != nil {
-				return err
-			}
-			for _, node := range imported {
-				if _, err := fmt.Fprintf(cmd.OutOrStdout(), "%s -> %s\n",
-					node.SourceID.Path(), node.TargetID.Path()); err != nil {
-					return err
-				}
-			}
-			if len(imported) > 0 {
-				if _, err := fmt.Fprintf(cmd.OutOrStdout(), "\nimported %d node(s)\n", len(imported)); err != nil {
+			return runWithDryRun(cmd, deps, dryRun, func(ctx context.Context) error {
+				imported, err := deps.Tap.ImportFromKeg(ctx, opts)
+				if err != nil {
 					return err
 				}
-			}
-			return nil
+				return printImportedNodes(cmd, imported)
+			})
 		},
 	}
 
+	addDryRunFlag(cmd, &dryRun)
 	cmd.Flags().StringVar(&fromKeg, "from", "", "source keg alias; required when using bare node IDs")
 	cmd.Flags().StringVar(&opts.TagQuery, "query", "", `boolean expression (see "tap docs query-expressions" for syntax)`)
 	cmd.Flags().BoolVar(&opts.LeaveStubs, "leave-stubs", false, "write forwarding stubs at source node locations after import")
@@ -103,3 +99,18 @@ references. All must come from the same source keg.`,
 
 	return cmd
 }
+
+func printImportedNodes(cmd *cobra.Command, imported []tapper.ImportedNode) error {
+	for _, node := range imported {
+		if _, err := fmt.Fprintf(cmd.OutOrStdout(), "%s -> %s\n",
+			node.SourceID.Path(), node.TargetID.Path()); err != nil {
+			return err
+		}
+	}
+	if len(imported) > 0 {
+		if _, err := fmt.Fprintf(cmd.OutOrStdout(), "\nimported %d node(s)\n", len(imported)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
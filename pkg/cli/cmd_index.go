@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/jlrickert/tapper/pkg/tapper"
@@ -102,6 +103,7 @@ INDEX is the index file name, e.g. "changes.md", "nodes.tsv", or "tags".`,
 // newIndexRebuildCmd returns the `index rebuild` subcommand.
 func newIndexRebuildCmd(deps *Deps) *cobra.Command {
 	var opts tapper.IndexOptions
+	var dryRun bool
 
 	cmd := &cobra.Command{
 		Use:   "rebuild",
@@ -109,19 +111,26 @@ func newIndexRebuildCmd(deps *Deps) *cobra.Command {
 		Long: `Rebuild indices for a keg (nodes.tsv, tags, links, backlinks, changes.md).
 
 By default this runs incremental indexing using the keg config timestamp.
-Use --full to scan all nodes and regenerate the full dex.`,
+Use --full to scan all nodes and regenerate the full dex.
+Use --analyze to also detect topic clusters (see "tap clusters").
+Use --dry-run to list the dex files that would change without writing them.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			applyKegTargetProfile(deps, &opts.KegTargetOptions)
-			ctx := cmd.Context()
-			output, err := deps.Tap.Index(ctx, opts)
-			if err != nil {
-				return err
-			}
-			fmt.Fprint(cmd.OutOrStdout(), output)
-			return nil
+			return runWithDryRun(cmd, deps, dryRun, func(ctx context.Context) error {
+				output, err := deps.Tap.Index(ctx, opts)
+				if err != nil {
+					return err
+				}
+				if !dryRun {
+					fmt.Fprint(cmd.OutOrStdout(), output)
+				}
+				return nil
+			})
 		},
 	}
+	addDryRunFlag(cmd, &dryRun)
 	cmd.Flags().BoolVarP(&opts.Rebuild, "full", "f", false, "full rebuild from scratch (scan all nodes and regenerate dex)")
+	cmd.Flags().BoolVar(&opts.Analyze, "analyze", false, "detect link clusters into dex/clusters.tsv")
 
 	return cmd
 }
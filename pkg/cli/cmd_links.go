@@ -16,12 +16,27 @@ func NewLinksCmd(deps *Deps) *cobra.Command {
 		Long: `List nodes that NODE_ID links to.
 
 Format placeholders: %i (node id), %d (date), %t (title), %% (literal %).
-Default format: "%i %d %t".`,
+Default format: "%i %d %t".
+
+With --output json|yaml|tsv, each linked node is emitted as a record with the
+fields id, title, created, updated and accessed.
+
+Use "links retitle" to give bare node links their target's title, "links
+unfurl" to cache previews of external URLs and "links external" to list
+them.`,
 		Args:              cobra.ExactArgs(1),
 		ValidArgsFunction: nodeIDCompletionFunc(deps, 1),
+		Annotations:       nodeArgAnnotations(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			opts.NodeID = args[0]
 			applyKegTargetProfile(deps, &opts.KegTargetOptions)
+			if deps.Output != OutputHuman {
+				entries, err := deps.Tap.LinkEntries(cmd.Context(), opts)
+				if err != nil {
+					return err
+				}
+				return writeOutput(cmd.OutOrStdout(), deps.Output, nodeEntryRecords(entries))
+			}
 
 			nodes, err := deps.Tap.Links(cmd.Context(), opts)
 			if err != nil {
@@ -37,6 +52,10 @@ Default format: "%i %d %t".`,
 	cmd.Flags().BoolVarP(&opts.IdOnly, "id-only", "", false, "show only ids")
 	cmd.Flags().BoolVar(&opts.Reverse, "reverse", false, "list nodes in reverse order")
 	cmd.Flags().StringVarP(&opts.Format, "format", "f", "", "output format")
+	cmd.Flags().BoolVar(&opts.Exact, "exact", false, "disable fuzzy title matching for node arguments")
+	cmd.AddCommand(NewLinksRetitleCmd(deps))
+	cmd.AddCommand(NewLinksUnfurlCmd(deps))
+	cmd.AddCommand(NewLinksExternalCmd(deps))
 
 	return cmd
 }
@@ -0,0 +1,66 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jlrickert/tapper/pkg/tapper"
+	"github.com/spf13/cobra"
+)
+
+// NewLinksRetitleCmd returns the `links retitle` cobra subcommand.
+//
+// Usage examples:
+//
+//	tap links retitle 42
+//	tap links retitle --all
+func NewLinksRetitleCmd(deps *Deps) *cobra.Command {
+	var opts tapper.RetitleLinksOptions
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "retitle [NODE_ID...]",
+		Short: "give bare node links their target's title",
+		Long: `Rewrite bare links to other nodes, such as [](../42), [42](../42) or
+[../42](../42), to use the target node's current title from the dex:
+[Target title](../42). Links with text of their own and image links are left
+alone.
+
+Each node with links rewritten is printed with the number of links changed.
+Set retitleLinks in the keg config to retitle links whenever node content is
+saved. With --dry-run the files that would be written are listed instead.`,
+		ValidArgsFunction: nodeIDCompletionFunc(deps, 0),
+		Annotations:       nodeArgAnnotations(0),
+		Args: func(cmd *cobra.Command, args []string) error {
+			if opts.All && len(args) > 0 {
+				return fmt.Errorf("node ids cannot be combined with --all")
+			}
+			if !opts.All && len(args) == 0 {
+				return fmt.Errorf("accepts at least 1 arg(s) or --all, received 0")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.NodeIDs = args
+			applyKegTargetProfile(deps, &opts.KegTargetOptions)
+			return runWithDryRun(cmd, deps, dryRun, func(ctx context.Context) error {
+				nodes, err := deps.Tap.RetitleLinks(ctx, opts)
+				if err != nil || dryRun {
+					return err
+				}
+				for _, n := range nodes {
+					if _, err := fmt.Fprintf(cmd.OutOrStdout(), "%s\t%d\n", n.Node.Path(), n.Rewrites); err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+		},
+	}
+
+	addDryRunFlag(cmd, &dryRun)
+	cmd.Flags().BoolVar(&opts.All, "all", false, "retitle links in every node")
+	cmd.Flags().BoolVar(&opts.Exact, "exact", false, "disable fuzzy title matching for node arguments")
+
+	return cmd
+}
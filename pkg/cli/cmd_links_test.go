@@ -1,6 +1,9 @@
 package cli_test
 
 import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
 
@@ -106,3 +109,88 @@ func TestLinksCommand_NoLinksReturnsEmptyOutput(t *testing.T) {
 	require.NoError(t, res.Err)
 	require.Equal(t, "", strings.TrimSpace(string(res.Stdout)))
 }
+
+func TestLinksRetitleCommand(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("testuser", "~"))
+
+	target := NewProcess(t, false, "create", "--title", "Target A").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, target.Err)
+	source := NewProcess(t, true, "create").RunWithIO(
+		sb.Context(),
+		sb.Runtime(),
+		strings.NewReader("# Source\n\nSee [](../1), [../1](../1) and [mine](../1).\n"),
+	)
+	require.NoError(t, source.Err)
+	require.Equal(t, "2", strings.TrimSpace(string(source.Stdout)))
+
+	missing := NewProcess(t, false, "links", "retitle").Run(sb.Context(), sb.Runtime())
+	require.Error(t, missing.Err)
+	require.Contains(t, string(missing.Stderr), "--all")
+
+	res := NewProcess(t, false, "links", "retitle", "--all").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	require.Equal(t, "2\t2", strings.TrimSpace(string(res.Stdout)))
+	require.Contains(t, string(sb.MustReadFile("~/kegs/example/2/README.md")),
+		"See [Target A](../1), [Target A](../1) and [mine](../1).")
+
+	again := NewProcess(t, false, "links", "retitle", "2").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, again.Err)
+	require.Empty(t, strings.TrimSpace(string(again.Stdout)))
+}
+
+func TestLinksUnfurlCommand(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			_, _ = w.Write([]byte("User-agent: *\nDisallow: /private\n"))
+		case "/article":
+			w.Header().Set("Content-Type", "text/html")
+			_, _ = w.Write([]byte(`<html><head><title>An Article</title>` +
+				`<meta property="og:description" content="Worth reading."></head></html>`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	sb := NewSandbox(t, testutils.WithFixture("testuser", "~"))
+	created := NewProcess(t, true, "create").RunWithIO(
+		sb.Context(),
+		sb.Runtime(),
+		strings.NewReader("# Reading\n\nRead "+srv.URL+"/article and "+srv.URL+"/private/notes.\n"),
+	)
+	require.NoError(t, created.Err)
+	id := strings.TrimSpace(string(created.Stdout))
+
+	disabled := NewProcess(t, false, "links", "unfurl").Run(sb.Context(), sb.Runtime())
+	require.Error(t, disabled.Err)
+	require.Contains(t, string(disabled.Stderr), "unfurl section")
+
+	cfg := sb.MustReadFile("~/kegs/example/keg")
+	sb.MustWriteFile("~/kegs/example/keg", append(cfg, []byte("unfurl:\n  ttl: 24h\n")...), 0o644)
+
+	res := NewProcess(t, false, "links", "unfurl").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	require.Equal(t, "2 urls, 2 fetched, 0 failed, 1 blocked by robots.txt", strings.TrimSpace(string(res.Stdout)))
+	require.Contains(t, string(sb.MustReadFile("~/kegs/example/dex/unfurl.jsonl")), `"title":"An Article"`)
+
+	again := NewProcess(t, false, "links", "unfurl", id).Run(sb.Context(), sb.Runtime())
+	require.NoError(t, again.Err)
+	require.Contains(t, string(again.Stdout), "0 fetched")
+
+	list := NewProcess(t, false, "links", "external", id).Run(sb.Context(), sb.Runtime())
+	require.NoError(t, list.Err)
+	require.Contains(t, string(list.Stdout), "An Article — Worth reading.")
+	require.Contains(t, string(list.Stdout), "(blocked by robots.txt)")
+
+	out := NewProcess(t, false, "links", "external", id, "--output", "json").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, out.Err)
+	var records []map[string]any
+	require.NoError(t, json.Unmarshal(out.Stdout, &records))
+	require.Len(t, records, 2)
+	require.Equal(t, srv.URL+"/article", records[0]["url"])
+	require.Equal(t, srv.URL+"/favicon.ico", records[0]["favicon"])
+	require.Equal(t, true, records[1]["blocked"])
+}
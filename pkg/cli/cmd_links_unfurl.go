@@ -0,0 +1,130 @@
+package cli
+
+import (
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/jlrickert/tapper/pkg/tapper"
+	"github.com/spf13/cobra"
+)
+
+// linkPreviewRecord is the structured form of one `links external` row.
+type linkPreviewRecord struct {
+	URL         string `json:"url" yaml:"url"`
+	Title       string `json:"title,omitempty" yaml:"title,omitempty"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	Favicon     string `json:"favicon,omitempty" yaml:"favicon,omitempty"`
+	Fetched     string `json:"fetched,omitempty" yaml:"fetched,omitempty"`
+	Blocked     bool   `json:"blocked,omitempty" yaml:"blocked,omitempty"`
+	Error       string `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// NewLinksUnfurlCmd returns the `links unfurl` cobra subcommand.
+//
+// Usage examples:
+//
+//	tap links unfurl
+//	tap links unfurl 42 --force
+func NewLinksUnfurlCmd(deps *Deps) *cobra.Command {
+	var opts tapper.UnfurlOptions
+
+	cmd := &cobra.Command{
+		Use:   "unfurl [NODE_ID...]",
+		Short: "cache previews of external links",
+		Long: `Fetch the title, description and favicon of external http(s) URLs in node
+content into the keg's dex/unfurl.jsonl cache. Without node ids every node is
+scanned and previews of URLs no longer linked are dropped.
+
+Unfurling is enabled by an unfurl section in the keg config. Cached previews
+are refetched once they are older than unfurl.ttl (default 720h), or always
+with --force. Pages disallowed by robots.txt for the "tapper" user agent are
+not fetched, and encrypted nodes are skipped.
+
+Use "links external" to show the cached previews of a node.`,
+		ValidArgsFunction: nodeIDCompletionFunc(deps, 0),
+		Annotations:       nodeArgAnnotations(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.NodeIDs = args
+			applyKegTargetProfile(deps, &opts.KegTargetOptions)
+			res, err := deps.Tap.Unfurl(cmd.Context(), opts)
+			if res != nil {
+				fmt.Fprintf(cmd.OutOrStdout(), "%d urls, %d fetched, %d failed, %d blocked by robots.txt\n",
+					res.URLs, res.Fetched, res.Failed, res.Blocked)
+			}
+			return err
+		},
+	}
+
+	cmd.Flags().BoolVar(&opts.Force, "force", false, "refetch previews that are still fresh")
+	cmd.Flags().BoolVar(&opts.Exact, "exact", false, "disable fuzzy title matching for node arguments")
+
+	return cmd
+}
+
+// NewLinksExternalCmd returns the `links external` cobra subcommand.
+//
+// Usage examples:
+//
+//	tap links external 42
+//	tap links external 42 --output json
+func NewLinksExternalCmd(deps *Deps) *cobra.Command {
+	var opts tapper.LinkPreviewsOptions
+
+	cmd := &cobra.Command{
+		Use:   "external NODE_ID",
+		Short: "list external links of a node with their previews",
+		Long: `List the external http(s) URLs in a node with the title and description
+cached by "links unfurl". No network requests are made; URLs not unfurled yet
+are listed without a preview.
+
+With --output json|yaml|tsv, each URL is emitted as a record with the fields
+url, title, description, favicon, fetched, blocked and error.`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: nodeIDCompletionFunc(deps, 1),
+		Annotations:       nodeArgAnnotations(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.NodeID = args[0]
+			applyKegTargetProfile(deps, &opts.KegTargetOptions)
+			previews, err := deps.Tap.LinkPreviews(cmd.Context(), opts)
+			if err != nil {
+				return err
+			}
+			if deps.Output != OutputHuman {
+				records := make([]linkPreviewRecord, 0, len(previews))
+				for _, p := range previews {
+					records = append(records, linkPreviewRecord{
+						URL:         p.URL,
+						Title:       p.Title,
+						Description: p.Description,
+						Favicon:     p.Favicon,
+						Fetched:     formatRecordTime(p.Fetched),
+						Blocked:     p.Blocked,
+						Error:       p.Error,
+					})
+				}
+				return writeOutput(cmd.OutOrStdout(), deps.Output, records)
+			}
+
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+			for _, p := range previews {
+				preview := p.String()
+				switch {
+				case p.Fetched.IsZero():
+					preview = "-"
+				case p.Blocked:
+					preview = "(blocked by robots.txt)"
+				case p.Error != "":
+					preview = "(" + p.Error + ")"
+				case preview == p.URL:
+					preview = "-"
+				}
+				fmt.Fprintf(w, "%s\t%s\n", p.URL, preview)
+			}
+			return w.Flush()
+		},
+	}
+
+	cmd.Flags().BoolVar(&opts.Exact, "exact", false, "disable fuzzy title matching for node arguments")
+
+	return cmd
+}
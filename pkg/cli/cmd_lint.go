@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/jlrickert/tapper/pkg/tapper"
+	"github.com/spf13/cobra"
+)
+
+// lintRecord is the structured form of a lint finding.
+type lintRecord struct {
+	Node    string `json:"node" yaml:"node"`
+	Line    int    `json:"line" yaml:"line"`
+	Column  int    `json:"column" yaml:"column"`
+	Level   string `json:"level" yaml:"level"`
+	Rule    string `json:"rule" yaml:"rule"`
+	Message string `json:"message" yaml:"message"`
+}
+
+// NewLintCmd returns the `lint` cobra command.
+//
+// Usage examples:
+//
+//	tap lint --prose
+//	tap lint --prose 42 --rules ./styles/house
+//	tap lint --prose --query "published && !draft"
+func NewLintCmd(deps *Deps) *cobra.Command {
+	var opts tapper.LintOptions
+
+	cmd := &cobra.Command{
+		Use:   "lint --prose [NODE_ID...]",
+		Short: "check node content for problems",
+		Long: `Check node content and report each finding as NODE:LINE:COL with its level,
+message and rule.
+
+--prose runs prose rules over the text of Markdown nodes, skipping code,
+HTML, link targets and frontmatter:
+
+  PassiveVoice     phrases such as "was written"
+  SentenceLength   sentences longer than lint.prose.maxSentenceWords (30)
+
+and Vale-style existence and substitution rule files listed under
+lint.prose.rules in the keg config or given with --rules. Rules named in
+lint.prose.disable are skipped.
+
+Without NODE_IDs every node is linted; --query limits the nodes to a tag
+expression. The command fails when any finding has the error level.`,
+		ValidArgsFunction: nodeIDCompletionFunc(deps, 0),
+		Annotations:       nodeArgAnnotations(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !opts.Prose {
+				return &usageError{fmt.Errorf("nothing to lint; choose a check such as --prose")}
+			}
+			opts.NodeIDs = args
+			applyKegTargetProfile(deps, &opts.KegTargetOptions)
+			findings, err := deps.Tap.Lint(cmd.Context(), opts)
+			if err != nil {
+				return err
+			}
+
+			errs := 0
+			records := make([]lintRecord, 0, len(findings))
+			for _, f := range findings {
+				if f.Level == tapper.LintError {
+					errs++
+				}
+				records = append(records, lintRecord{
+					Node:    f.Node.Path(),
+					Line:    f.Line,
+					Column:  f.Column,
+					Level:   f.Level,
+					Rule:    f.Rule,
+					Message: f.Message,
+				})
+			}
+			if deps.Output != OutputHuman {
+				if err := writeOutput(cmd.OutOrStdout(), deps.Output, records); err != nil {
+					return err
+				}
+			} else {
+				for _, r := range records {
+					if _, err := fmt.Fprintf(cmd.OutOrStdout(), "%s:%d:%d: %s: %s [%s]\n", r.Node, r.Line, r.Column, r.Level, r.Message, r.Rule); err != nil {
+						return err
+					}
+				}
+			}
+			if errs > 0 {
+				return fmt.Errorf("%d lint error(s)", errs)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&opts.Prose, "prose", false, "run prose rules over node text")
+	cmd.Flags().StringVar(&opts.Query, "query", "", `only lint nodes matching a boolean expression (see "tap docs query-expressions")`)
+	cmd.Flags().StringSliceVar(&opts.Rules, "rules", nil, "extra Vale-style rule file or directory (repeatable)")
+	cmd.Flags().BoolVar(&opts.Exact, "exact", false, "disable fuzzy title matching for node arguments")
+
+	return cmd
+}
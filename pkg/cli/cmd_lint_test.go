@@ -0,0 +1,41 @@
+package cli_test
+
+import (
+	"strings"
+	"testing"
+
+	testutils "github.com/jlrickert/cli-toolkit/sandbox"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLint_Prose(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("testuser", "~"))
+	sb.MustWriteFile("~/styles/house/Weasel.yml",
+		[]byte("extends: existence\nmessage: \"Avoid '%s'.\"\nlevel: error\ntokens:\n  - very\n"), 0o644)
+
+	create := NewProcess(t, true, "create", "--tags", "draft").RunWithIO(
+		sb.Context(),
+		sb.Runtime(),
+		strings.NewReader("# Notes\n\nThe plan was made in a very short time.\n"),
+	)
+	require.NoError(t, create.Err)
+	require.Equal(t, "1", strings.TrimSpace(string(create.Stdout)))
+
+	usage := NewProcess(t, false, "lint").Run(sb.Context(), sb.Runtime())
+	require.Error(t, usage.Err)
+	require.Equal(t, 2, usage.ExitCode)
+
+	warn := NewProcess(t, false, "lint", "--prose", "1").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, warn.Err)
+	require.Equal(t, `1:3:10: warning: "was made" may be passive voice [PassiveVoice]`, strings.TrimSpace(string(warn.Stdout)))
+
+	res := NewProcess(t, false, "lint", "--prose", "--rules", "~/styles/house", "--query", "draft").Run(sb.Context(), sb.Runtime())
+	require.Error(t, res.Err)
+	require.Contains(t, string(res.Stderr), "1 lint error(s)")
+	require.Contains(t, string(res.Stdout), "1:3:24: error: Avoid 'very'. [house.Weasel]")
+
+	none := NewProcess(t, false, "lint", "--prose", "--rules", "~/styles/house", "--query", "published").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, none.Err)
+	require.Empty(t, strings.TrimSpace(string(none.Stdout)))
+}
@@ -16,15 +16,34 @@ func NewListCmd(deps *Deps) *cobra.Command {
 		Long: `List indexed nodes for the resolved keg.
 
 Format placeholders: %i (node id), %d (date), %t (title), %% (literal %).
-Default format: "%i\t%d\t%t".
+Default format: "%i\t%d\t%t". A format containing {{ is a Go template run
+per node, e.g. '{{.ID}}\t{{.Title}} ({{.Tags}})'; see "tap docs format-templates".
 
 Use --query to filter by boolean tag/attribute expressions.
+Use --where to filter by metadata fields, for example:
+  --where "created > 2025-01-01 and tags has go and attrs.status = active"
+Use --since, --until, and --created-since to restrict by date; each accepts
+RFC3339, a date, or a duration such as "7d" or "2w".
+Use --saved to replay a saved search declared under "savedSearches" in the
+keg config.
 Use --limit (-n) to cap output (default 50, 0 for no limit).
-Use --sort to order by "id", "updated", "created", or "accessed".`,
+Use --sort to order by "id", "updated", "created", "accessed", or "rank"
+(link-based PageRank; the most central notes are listed last).
+With --output json|yaml|tsv, each node is emitted as a record with the fields
+id, title, created, updated and accessed; --format and --id-only are ignored.
+Use --all to list every configured keg; node IDs are then printed as
+alias:id, which every node command accepts, and --limit applies per keg.`,
 
 		Aliases: []string{"ls"},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			applyKegTargetProfile(deps, &opts.KegTargetOptions)
+			if deps.Output != OutputHuman {
+				entries, err := deps.Tap.ListEntries(cmd.Context(), opts)
+				if err != nil {
+					return err
+				}
+				return writeOutput(cmd.OutOrStdout(), deps.Output, nodeEntryRecords(entries))
+			}
 			nodes, err := deps.Tap.List(cmd.Context(), opts)
 			if err != nil {
 				return err
@@ -42,12 +61,16 @@ Use --sort to order by "id", "updated", "created", or "accessed".`,
 
 	cmd.Flags().BoolVarP(&opts.IdOnly, "id-only", "", false, "show only ids")
 	cmd.Flags().BoolVar(&opts.Reverse, "reverse", false, "list nodes in reverse order")
+	cmd.Flags().BoolVar(&opts.AllKegs, "all", false, "list every configured keg, printing ids as alias:id")
 	cmd.Flags().IntVarP(&opts.Limit, "limit", "n", 50, "maximum number of results (0 for no limit)")
 	cmd.Flags().StringVarP(&opts.Format, "format", "f", "", "output format")
 	cmd.Flags().StringVar(&opts.Query, "query", "", `boolean expression (see "tap docs query-expressions" for syntax)`)
-	cmd.Flags().StringVar((*string)(&opts.Sort), "sort", "", `sort order: "id", "updated", "created", or "accessed"`)
+	cmd.Flags().StringVar(&opts.Where, "where", "", `metadata filter (see "tap docs query-expressions" for syntax)`)
+	addDateRangeFlags(cmd, &opts.DateRangeOptions)
+	cmd.Flags().StringVar(&opts.Saved, "saved", "", "replay a saved search from the keg config")
+	cmd.Flags().StringVar((*string)(&opts.Sort), "sort", "", `sort order: "id", "updated", "created", "accessed", or "rank"`)
 	_ = cmd.RegisterFlagCompletionFunc("sort", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
-		return []string{"id", "updated", "created", "accessed"}, cobra.ShellCompDirectiveNoFileComp
+		return []string{"id", "updated", "created", "accessed", "rank"}, cobra.ShellCompDirectiveNoFileComp
 	})
 
 	return cmd
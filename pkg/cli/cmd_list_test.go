@@ -186,3 +186,127 @@ func TestListCommand_SortFlagCompletion(t *testing.T) {
 	require.Contains(t, suggestions, "created")
 	require.Contains(t, suggestions, "accessed")
 }
+
+func TestListCommand_WhereFiltersByMetadata(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("testuser", "~"))
+
+	res := NewProcess(t, false, "create", "--title", "Go Notes", "--tags", "go", "--attrs", "status=active").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	res = NewProcess(t, false, "create", "--title", "Rust Notes", "--tags", "rust", "--attrs", "status=active").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	res = NewProcess(t, false, "create", "--title", "Old Go Notes", "--tags", "go", "--attrs", "status=done").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+
+	where := NewProcess(t, false, "list", "--where", "tags has go and attrs.status = active", "--format", "%t").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, where.Err)
+	require.Equal(t, "Go Notes", strings.TrimSpace(string(where.Stdout)))
+
+	invalid := NewProcess(t, false, "list", "--where", "tags has").Run(sb.Context(), sb.Runtime())
+	require.Error(t, invalid.Err)
+	require.Contains(t, invalid.Err.Error(), "invalid where expression")
+}
+
+func TestListCommand_SavedSearchReplaysAndMaterializes(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("testuser", "~"))
+
+	res := NewProcess(t, false, "create", "--title", "Go Notes", "--tags", "go", "--attrs", "status=active").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	res = NewProcess(t, false, "create", "--title", "Go Archive", "--tags", "go", "--attrs", "status=done").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+
+	cfg := `kegv: 2025-07
+title: Saved Searches
+savedSearches:
+  - name: active-go
+    summary: active go notes
+    tags: go
+    where: attrs.status = active
+`
+	res = NewProcess(t, false, "config", "edit").RunWithIO(sb.Context(), sb.Runtime(), strings.NewReader(cfg))
+	require.NoError(t, res.Err)
+
+	saved := NewProcess(t, false, "list", "--saved", "active-go", "--format", "%t").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, saved.Err)
+	require.Equal(t, "Go Notes", strings.TrimSpace(string(saved.Stdout)))
+
+	rebuild := NewProcess(t, false, "index", "rebuild", "--full").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, rebuild.Err)
+
+	index := NewProcess(t, false, "index", "get", "search-active-go.md").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, index.Err)
+	require.Contains(t, string(index.Stdout), "[Go Notes]")
+	require.NotContains(t, string(index.Stdout), "Go Archive")
+
+	missing := NewProcess(t, false, "list", "--saved", "nope").Run(sb.Context(), sb.Runtime())
+	require.Error(t, missing.Err)
+	require.Contains(t, missing.Err.Error(), `saved search "nope" not found`)
+}
+
+func TestListCommand_SinceAndUntilFilterByUpdated(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("testuser", "~"))
+
+	sb.Advance(30 * 24 * time.Hour)
+	res := NewProcess(t, false, "create", "--title", "Old").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+
+	sb.Advance(10 * 24 * time.Hour)
+	res = NewProcess(t, false, "create", "--title", "Recent").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+
+	since := NewProcess(t, false, "list", "--since", "1w", "--format", "%t").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, since.Err)
+	require.Equal(t, "Recent", strings.TrimSpace(string(since.Stdout)))
+
+	until := NewProcess(t, false, "list", "--since", "6w", "--until", "7d", "--format", "%t").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, until.Err)
+	require.Equal(t, "Old", strings.TrimSpace(string(until.Stdout)))
+
+	invalid := NewProcess(t, false, "list", "--since", "7x").Run(sb.Context(), sb.Runtime())
+	require.Error(t, invalid.Err)
+	require.Contains(t, invalid.Err.Error(), "invalid since")
+}
+
+func TestListCommand_SortByRank(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("joe", "~"))
+
+	rebuild := NewProcess(t, false, "index", "rebuild", "--full").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, rebuild.Err)
+
+	res := NewProcess(t, false, "list", "--sort", "rank", "--id-only").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	ids := strings.Fields(string(res.Stdout))
+	require.Len(t, ids, 4)
+	require.Equal(t, "0", ids[0], "unlinked nodes rank lowest")
+
+	index := NewProcess(t, false, "index", "get", "rank.tsv").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, index.Err)
+	require.Contains(t, string(index.Stdout), "2\t")
+}
+
+func TestListCommand_FormatTemplate(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("joe", "~"))
+
+	res := NewProcess(t, false, "list", "--format", `{{.ID}}\t{{.Title}} ({{.Tags}})`).Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	out := string(res.Stdout)
+	require.Contains(t, out, "1\tPersonal Overview (planned)\n")
+	require.Contains(t, out, "2\tProject Alpha ()\n")
+
+	res = NewProcess(t, false, "list", "--format", `{{.ID}}={{.Attr "entity"}} links:{{join .Links ","}}`).Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	require.Contains(t, string(res.Stdout), "1=trick links:2,3\n")
+	require.Contains(t, string(res.Stdout), "2=concept links:")
+
+	res = NewProcess(t, false, "list", "--format", "{{.ID").Run(sb.Context(), sb.Runtime())
+	require.Error(t, res.Err)
+	require.Contains(t, res.Err.Error(), "invalid format template")
+
+	res = NewProcess(t, false, "list", "--format", "{{.Nope}}").Run(sb.Context(), sb.Runtime())
+	require.Error(t, res.Err)
+	require.Contains(t, res.Err.Error(), "unable to format node")
+}
@@ -0,0 +1,120 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/jlrickert/tapper/pkg/tapper"
+	"github.com/spf13/cobra"
+)
+
+// NewLockCmd returns the `lock` cobra command.
+//
+// Usage examples:
+//
+//	tap lock
+//	tap lock 12
+//	tap lock 12 --holder alice --force
+func NewLockCmd(deps *Deps) *cobra.Command {
+	var opts tapper.LockOptions
+
+	cmd := &cobra.Command{
+		Use:   "lock [NODE_ID...]",
+		Short: "mark nodes as checked out for editing",
+		Long: `Record a visible checkout lock on nodes in a shared keg.
+
+The lock holder and timestamp are written to the node's meta.yaml as
+locked_by and locked_at, so collaborators syncing the keg over git can see
+who is editing a node. "tap edit" warns before editing a node locked by
+someone else. The holder defaults to $USER. A node locked by someone else
+can only be taken over with --force.
+
+With no NODE_ID, list locked nodes as ID, holder, time and title. A NODE_ID
+of "-" reads node IDs from stdin.`,
+		ValidArgsFunction: nodeIDCompletionFunc(deps, 0),
+		Annotations:       nodeArgAnnotations(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			applyKegTargetProfile(deps, &opts.KegTargetOptions)
+			if len(args) == 0 {
+				lines, err := deps.Tap.ListLocks(cmd.Context(), opts.KegTargetOptions)
+				if err != nil {
+					return err
+				}
+				for _, line := range lines {
+					fmt.Fprintln(cmd.OutOrStdout(), line)
+				}
+				return nil
+			}
+			ids, err := nodeArgs(cmd, deps, args)
+			if err != nil {
+				return err
+			}
+			opts.NodeIDs = ids
+			return deps.Tap.Lock(cmd.Context(), opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.Holder, "holder", "", "lock holder name (default $USER)")
+	cmd.Flags().BoolVar(&opts.Force, "force", false, "take over a lock held by someone else")
+	return cmd
+}
+
+// NewUnlockCmd returns the `unlock` cobra command.
+//
+// Usage examples:
+//
+//	tap unlock 12
+//	tap unlock 12 --force
+//	tap unlock --keg-lock
+func NewUnlockCmd(deps *Deps) *cobra.Command {
+	var opts tapper.LockOptions
+	var kegLock bool
+
+	cmd := &cobra.Command{
+		Use:   "unlock NODE_ID...",
+		Short: "release checkout locks on nodes",
+		Long: `Remove the checkout lock recorded by "tap lock".
+
+Only the holder can release a lock unless --force is given. Unlocking a node
+that is not locked does nothing.
+
+With --keg-lock, remove the keg-wide .keg-lock taken by "tap index" and
+imports instead. Locks left by crashed processes are cleared automatically;
+use this when a run on another host died holding the lock. A lock whose
+process is still running needs --force.`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if kegLock {
+				return cobra.NoArgs(cmd, args)
+			}
+			return cobra.MinimumNArgs(1)(cmd, args)
+		},
+		ValidArgsFunction: nodeIDCompletionFunc(deps, 0),
+		Annotations:       nodeArgAnnotations(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if kegLock {
+				applyKegTargetProfile(deps, &opts.KegTargetOptions)
+				owner, err := deps.Tap.UnlockKeg(cmd.Context(), opts)
+				if err != nil {
+					return err
+				}
+				if owner == nil {
+					_, err = fmt.Fprintln(cmd.OutOrStdout(), "keg is not locked")
+					return err
+				}
+				_, err = fmt.Fprintf(cmd.OutOrStdout(), "removed keg lock held by %s\n", owner)
+				return err
+			}
+			ids, err := nodeArgs(cmd, deps, args)
+			if err != nil {
+				return err
+			}
+			opts.NodeIDs = ids
+			applyKegTargetProfile(deps, &opts.KegTargetOptions)
+			return deps.Tap.Unlock(cmd.Context(), opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.Holder, "holder", "", "lock holder name (default $USER)")
+	cmd.Flags().BoolVar(&opts.Force, "force", false, "remove a lock held by someone else")
+	cmd.Flags().BoolVar(&kegLock, "keg-lock", false, "remove the keg-wide index lock instead of node locks")
+	return cmd
+}
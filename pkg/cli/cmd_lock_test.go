@@ -0,0 +1,94 @@
+package cli_test
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	testutils "github.com/jlrickert/cli-toolkit/sandbox"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLockCommand_LockListUnlock(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("joe", "~"))
+	require.NoError(t, sb.Runtime().Set("USER", "joe"))
+
+	res := NewProcess(t, false, "lock", "--keg", "personal", "2").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+
+	meta := string(sb.MustReadFile("~/kegs/personal/2/meta.yaml"))
+	require.Contains(t, meta, "locked_by: joe")
+	require.Contains(t, meta, "locked_at:")
+
+	res = NewProcess(t, false, "lock", "--keg", "personal").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	require.True(t, strings.HasPrefix(string(res.Stdout), "2\tjoe\t"), string(res.Stdout))
+	require.Contains(t, string(res.Stdout), "\tProject Alpha\n")
+
+	res = NewProcess(t, false, "lock", "--keg", "personal", "2", "--holder", "alice").Run(sb.Context(), sb.Runtime())
+	require.Error(t, res.Err)
+	require.Contains(t, res.Err.Error(), "node 2 is locked by joe")
+
+	res = NewProcess(t, false, "unlock", "--keg", "personal", "2", "--holder", "alice").Run(sb.Context(), sb.Runtime())
+	require.Error(t, res.Err)
+
+	res = NewProcess(t, false, "unlock", "--keg", "personal", "2").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	require.NotContains(t, string(sb.MustReadFile("~/kegs/personal/2/meta.yaml")), "locked_by")
+
+	res = NewProcess(t, false, "lock", "--keg", "personal").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	require.Empty(t, string(res.Stdout))
+}
+
+func TestLockCommand_ForceTakesOver(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("joe", "~"))
+
+	res := NewProcess(t, false, "lock", "--keg", "personal", "3", "--holder", "alice").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	res = NewProcess(t, false, "lock", "--keg", "personal", "3", "--holder", "bob", "--force").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	require.Contains(t, string(sb.MustReadFile("~/kegs/personal/3/meta.yaml")), "locked_by: bob")
+}
+
+func TestEdit_WarnsOnNodeLockedByOthers(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("joe", "~"))
+	require.NoError(t, sb.Runtime().Set("USER", "joe"))
+	require.NoError(t, sb.Runtime().Set("EDITOR", "/bin/false"))
+	sb.Runtime().Unset("VISUAL")
+
+	res := NewProcess(t, false, "lock", "--keg", "personal", "3", "--holder", "alice").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+
+	res = NewProcess(t, false, "edit", "3", "--keg", "personal").RunWithIO(sb.Context(), sb.Runtime(), strings.NewReader("# Meeting Notes\n\nupdated\n"))
+	require.NoError(t, res.Err)
+	require.Contains(t, string(res.Stderr), "warning: node 3 is locked by alice")
+	require.Contains(t, string(sb.MustReadFile("~/kegs/personal/3/README.md")), "updated")
+}
+
+func TestUnlockCommand_KegLock(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("joe", "~"))
+
+	res := NewProcess(t, false, "unlock", "--keg-lock").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	require.Equal(t, "keg is not locked\n", string(res.Stdout))
+
+	owner := fmt.Sprintf(`{"pid":%d,"hostname":"elsewhere"}`, os.Getpid())
+	sb.MustWriteFile("~/kegs/personal/.keg-lock/owner.json", []byte(owner), 0o644)
+
+	res = NewProcess(t, false, "unlock", "--keg-lock").Run(sb.Context(), sb.Runtime())
+	require.Error(t, res.Err)
+	require.Contains(t, res.Err.Error(), "use --force")
+
+	res = NewProcess(t, false, "unlock", "--keg-lock", "--force").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	require.Contains(t, string(res.Stdout), "removed keg lock held by pid")
+
+	res = NewProcess(t, false, "unlock", "--keg-lock", "2").Run(sb.Context(), sb.Runtime())
+	require.Error(t, res.Err)
+}
@@ -0,0 +1,48 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jlrickert/tapper/pkg/tapper"
+	"github.com/spf13/cobra"
+)
+
+func NewMergeCmd(deps *Deps) *cobra.Command {
+	var opts tapper.MergeOptions
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "merge SRC_NODE_ID DST_NODE_ID",
+		Short: "merge one node into another and rewrite inbound links",
+		Long: `Fold SRC_NODE_ID into DST_NODE_ID.
+
+The source content is appended to the destination after a horizontal rule
+(or under a level-two heading with --heading), tags are unioned, and every
+../SRC reference in the keg is rewritten to ../DST. The source node is then
+archived and stays readable with "tap cat --archived". Node 0 cannot be
+merged. On a TTY the merge is confirmed first; --yes skips the prompt. With
+--dry-run the files that would be written or removed are listed instead.`,
+		Args:              cobra.ExactArgs(2),
+		ValidArgsFunction: nodeIDCompletionFunc(deps, 2),
+		Annotations:       nodeArgAnnotations(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.SourceID = args[0]
+			opts.DestID = args[1]
+			applyKegTargetProfile(deps, &opts.KegTargetOptions)
+			if !dryRun {
+				question := fmt.Sprintf("Merge node %s into %s and archive %s?", opts.SourceID, opts.DestID, opts.SourceID)
+				if err := confirm(cmd, deps, "merge", false, question); err != nil {
+					return err
+				}
+			}
+			return runWithDryRun(cmd, deps, dryRun, func(ctx context.Context) error {
+				return deps.Tap.Merge(ctx, opts)
+			})
+		},
+	}
+
+	addDryRunFlag(cmd, &dryRun)
+	cmd.Flags().BoolVar(&opts.Heading, "heading", false, "append the source under its title as a level-two heading instead of a horizontal rule")
+	return cmd
+}
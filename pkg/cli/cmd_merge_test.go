@@ -0,0 +1,64 @@
+package cli_test
+
+import (
+	"strings"
+	"testing"
+
+	testutils "github.com/jlrickert/cli-toolkit/sandbox"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeCommand_FoldsSourceIntoDestination(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("joe", "~"))
+
+	res := NewProcess(t, false, "merge", "3", "2").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+
+	dst := string(sb.MustReadFile("~/kegs/personal/2/README.md"))
+	require.True(t, strings.HasPrefix(dst, "# Project Alpha"))
+	require.Contains(t, dst, "\n\n---\n\n# Meeting Notes\n")
+	require.NotContains(t, dst, "../3")
+
+	overview := string(sb.MustReadFile("~/kegs/personal/1/README.md"))
+	require.Contains(t, overview, "- [Meeting Notes](../2)")
+
+	list := NewProcess(t, false, "list", "--id-only").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, list.Err)
+	require.NotContains(t, strings.Fields(string(list.Stdout)), "3")
+
+	archived := NewProcess(t, false, "cat", "--archived", "--content-only", "3").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, archived.Err)
+	require.Contains(t, string(archived.Stdout), "# Meeting Notes")
+
+	backlinks := NewProcess(t, false, "backlinks", "2").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, backlinks.Err)
+	require.Contains(t, string(backlinks.Stdout), "Personal Overview")
+}
+
+func TestMergeCommand_HeadingUnionsTags(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("testuser", "~"))
+
+	res := NewProcess(t, false, "create", "--title", "Keeper", "--tags", "alpha").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	keeper := strings.TrimSpace(string(res.Stdout))
+	res = NewProcess(t, false, "create", "--title", "Duplicate", "--tags", "beta").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	duplicate := strings.TrimSpace(string(res.Stdout))
+
+	res = NewProcess(t, false, "merge", duplicate, keeper, "--heading").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+
+	cat := NewProcess(t, false, "cat", keeper).Run(sb.Context(), sb.Runtime())
+	require.NoError(t, cat.Err)
+	out := string(cat.Stdout)
+	require.Contains(t, out, "\n## Duplicate\n")
+	require.NotContains(t, out, "---\n\n# Duplicate")
+	require.Contains(t, out, "- alpha")
+	require.Contains(t, out, "- beta")
+
+	res = NewProcess(t, false, "merge", keeper, keeper).Run(sb.Context(), sb.Runtime())
+	require.Error(t, res.Err)
+	require.Contains(t, res.Err.Error(), "into itself")
+}
@@ -14,6 +14,7 @@ func NewMetaCmd(deps *Deps) *cobra.Command {
 		Use:               "meta NODE_ID",
 		Short:             "print or edit node metadata",
 		ValidArgsFunction: nodeIDCompletionFunc(deps, 1),
+		Annotations:       nodeArgAnnotations(1),
 		Long: `Print node metadata (meta.yaml) for NODE_ID.
 
 If stdin is piped, the piped yaml replaces metadata after validation.
@@ -37,6 +38,7 @@ Use --edit to edit metadata in a temporary file with your editor.`,
 	}
 
 	cmd.Flags().BoolVar(&opts.Edit, "edit", false, "edit node metadata in a temporary file")
+	cmd.Flags().BoolVar(&opts.Exact, "exact", false, "disable fuzzy title matching for node arguments")
 
 	return cmd
 }
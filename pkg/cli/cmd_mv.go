@@ -1,12 +1,17 @@
 package cli
 
 import (
+	"context"
+	"fmt"
+
+	"github.com/jlrickert/tapper/pkg/keg"
 	"github.com/jlrickert/tapper/pkg/tapper"
 	"github.com/spf13/cobra"
 )
 
 func NewMoveCmd(deps *Deps) *cobra.Command {
 	var opts tapper.MoveOptions
+	var dryRun bool
 
 	cmd := &cobra.Command{
 		Use:   "mv SRC_NODE_ID DST_NODE_ID",
@@ -14,16 +19,41 @@ func NewMoveCmd(deps *Deps) *cobra.Command {
 		Long: `Rename a node from SRC_NODE_ID to DST_NODE_ID.
 
 All ../SRC references in other nodes are rewritten to ../DST. The
-destination must not already exist. Node 0 cannot be moved.`,
+destination must not already exist. Node 0 cannot be moved. With --verbose
+each rewritten file is reported on stderr. With --dry-run the files that
+would be written or removed are listed instead.`,
 		Aliases: []string{"move"},
 		Args:              cobra.ExactArgs(2),
 		ValidArgsFunction: nodeIDCompletionFunc(deps, 2),
+		Annotations:       nodeArgAnnotations(2),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			opts.SourceID = args[0]
 			opts.DestID = args[1]
 			applyKegTargetProfile(deps, &opts.KegTargetOptions)
-			return deps.Tap.Move(cmd.Context(), opts)
+			return runWithDryRun(cmd, deps, dryRun, func(ctx context.Context) error {
+				report, err := deps.Tap.Move(ctx, opts)
+				if perr := printLinkRepairs(cmd, deps, report); err == nil {
+					err = perr
+				}
+				return err
+			})
 		},
 	}
+	addDryRunFlag(cmd, &dryRun)
 	return cmd
 }
+
+// printLinkRepairs lists the files whose node links a move or removal
+// rewrote. It prints only with --verbose, on stderr, so the command's output
+// stays unchanged.
+func printLinkRepairs(cmd *cobra.Command, deps *Deps, report *keg.LinkRepairReport) error {
+	if !deps.Verbose || report == nil {
+		return nil
+	}
+	for _, f := range report.Files {
+		if _, err := fmt.Fprintf(cmd.ErrOrStderr(), "rewrote %d link(s) in node %s %s\n", f.Rewrites, f.Node.Path(), f.File); err != nil {
+			return err
+		}
+	}
+	return nil
+}
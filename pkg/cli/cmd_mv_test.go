@@ -21,6 +21,7 @@ func TestMoveCommand_RewritesLinks(t *testing.T) {
 
 	res = NewProcess(t, false, "mv", "2", "3").Run(sb.Context(), sb.Runtime())
 	require.NoError(t, res.Err)
+	require.NotContains(t, string(res.Stderr), "rewrote", "link repairs are only reported with --verbose")
 
 	content := string(sb.MustReadFile("~/kegs/example/1/README.md"))
 	require.Contains(t, content, "[two](../3)")
@@ -33,6 +34,27 @@ func TestMoveCommand_RewritesLinks(t *testing.T) {
 	require.NoError(t, err, "destination node directory should exist")
 }
 
+func TestMoveCommand_VerboseReportsLinkRepairs(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("testuser", "~"))
+
+	res := NewProcess(t, false, "create", "--title", "One").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	res = NewProcess(t, false, "create", "--title", "Two").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	sb.MustWriteFile("~/kegs/example/1/README.md", []byte("# One\n\nSee [two](../2).\nAlso ../2.\n"), 0o644)
+
+	res = NewProcess(t, false, "mv", "2", "3", "--verbose", "--dry-run").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	require.Contains(t, string(res.Stderr), "rewrote 2 link(s) in node 1 content")
+	require.Contains(t, string(sb.MustReadFile("~/kegs/example/1/README.md")), "../2", "dry run leaves the keg untouched")
+
+	res = NewProcess(t, false, "rm", "2", "--force", "--verbose").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	require.Contains(t, string(res.Stderr), "rewrote 2 link(s) in node 1 content")
+	require.Contains(t, string(sb.MustReadFile("~/kegs/example/1/README.md")), "[two](../0)")
+}
+
 func TestMoveCommand_ErrorCases(t *testing.T) {
 	t.Parallel()
 	sb := NewSandbox(t, testutils.WithFixture("testuser", "~"))
@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jlrickert/tapper/pkg/keg"
+	"github.com/jlrickert/tapper/pkg/tapper"
+	"github.com/spf13/cobra"
+)
+
+// NewPruneCmd returns the `prune` cobra command.
+//
+// Usage examples:
+//
+//	tap prune --dry-run
+//	tap prune --older-than 1y
+//	tap prune --delete --yes
+func NewPruneCmd(deps *Deps) *cobra.Command {
+	var (
+		opts   tapper.PruneOptions
+		dryRun bool
+		del    bool
+		force  bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "prune",
+		Short: "find and retire dead nodes",
+		Long: `Report nodes that look dead and optionally archive or delete them.
+
+Candidates are nodes with no content beyond their title (empty), drafts not
+touched within --older-than (stale-draft), and nodes not touched within
+--older-than that have no links in or out (stale-orphan). Node 0 is never a
+candidate. Each candidate is printed as ID, reasons and title.
+
+Without --dry-run, the candidates are archived after confirmation, or
+deleted with --delete. A delete is refused while other nodes link to a
+candidate unless --force is given. Pass --yes to skip the prompt when not on
+a TTY.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			applyKegTargetProfile(deps, &opts.KegTargetOptions)
+
+			candidates, err := deps.Tap.PruneCandidates(cmd.Context(), opts)
+			if err != nil {
+				return err
+			}
+			out := cmd.OutOrStdout()
+			for _, c := range candidates {
+				fmt.Fprintf(out, "%s\t%s\t%s\n", c.ID.Path(), strings.Join(c.Reasons, ","), c.Title)
+			}
+			if dryRun || len(candidates) == 0 {
+				return nil
+			}
+
+			action := "Archive"
+			if del {
+				action = "Delete"
+			}
+			question := fmt.Sprintf("%s %d node(s)?", action, len(candidates))
+			if err := confirm(cmd, deps, "prune", true, question); err != nil {
+				return err
+			}
+
+			nodes := make([]keg.NodeId, len(candidates))
+			for i, c := range candidates {
+				nodes[i] = c.ID
+			}
+			return deps.Tap.PruneApply(cmd.Context(), tapper.PruneApplyOptions{
+				KegTargetOptions: opts.KegTargetOptions,
+				Nodes:            nodes,
+				Delete:           del,
+				Force:            force,
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.OlderThan, "older-than", tapper.DefaultPruneOlderThan, "treat nodes untouched since this bound as stale (e.g. 90d, 1y, 2025-01-01)")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "only report candidates")
+	cmd.Flags().BoolVar(&del, "delete", false, "delete candidates instead of archiving them")
+	cmd.Flags().BoolVar(&force, "force", false, "with --delete, delete candidates even when other nodes link to them")
+	return cmd
+}
@@ -0,0 +1,85 @@
+package cli_test
+
+import (
+	"strings"
+	"testing"
+
+	testutils "github.com/jlrickert/cli-toolkit/sandbox"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPruneCommand_DryRunReportsCandidates(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("joe", "~"))
+
+	res := NewProcess(t, false, "create", "--keg", "personal", "--title", "Empty Stub").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	res = NewProcess(t, false, "create", "--keg", "personal").
+		RunWithIO(sb.Context(), sb.Runtime(), strings.NewReader("# Lonely\n\nNothing links here.\n"))
+	require.NoError(t, res.Err)
+	res = NewProcess(t, false, "create", "--keg", "personal", "--draft", "--title", "Half Thought").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	draft := strings.TrimSpace(string(res.Stdout))
+
+	res = NewProcess(t, false, "prune", "--keg", "personal", "--dry-run").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	out := string(res.Stdout)
+	require.Contains(t, out, "4\tempty\tEmpty Stub\n")
+	require.Contains(t, out, draft+"\tempty\tHalf Thought\n")
+	require.NotContains(t, out, "Lonely")
+
+	res = NewProcess(t, false, "prune", "--keg", "personal", "--dry-run", "--older-than", "2999-01-01").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	out = string(res.Stdout)
+	require.Contains(t, out, "4\tempty,stale-orphan\tEmpty Stub\n")
+	require.Contains(t, out, "5\tstale-orphan\tLonely\n")
+	require.Contains(t, out, draft+"\tempty,stale-draft\tHalf Thought\n")
+	require.NotContains(t, out, "Project Alpha")
+	require.NotContains(t, out, "Meeting Notes")
+}
+
+func TestPruneCommand_ArchivesWithYes(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("joe", "~"))
+
+	res := NewProcess(t, false, "create", "--keg", "personal", "--title", "Empty Stub").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+
+	res = NewProcess(t, false, "prune", "--keg", "personal").Run(sb.Context(), sb.Runtime())
+	require.Error(t, res.Err)
+	require.Contains(t, res.Err.Error(), "requires confirmation")
+
+	res = NewProcess(t, false, "prune", "--keg", "personal", "--yes").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+
+	list := NewProcess(t, false, "list", "--keg", "personal", "--id-only").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, list.Err)
+	require.NotContains(t, strings.Fields(string(list.Stdout)), "4")
+
+	archived := NewProcess(t, false, "archive", "list", "--keg", "personal").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, archived.Err)
+	require.Contains(t, string(archived.Stdout), "Empty Stub")
+}
+
+func TestPruneCommand_DeleteRefusesLinkedNodesWithoutForce(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("joe", "~"))
+
+	res := NewProcess(t, false, "create", "--keg", "personal", "--title", "Empty Stub").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	res = NewProcess(t, false, "create", "--keg", "personal").
+		RunWithIO(sb.Context(), sb.Runtime(), strings.NewReader("# Linker\n\nSee [stub](../4).\n"))
+	require.NoError(t, res.Err)
+
+	res = NewProcess(t, false, "prune", "--keg", "personal", "--delete", "--yes").Run(sb.Context(), sb.Runtime())
+	require.Error(t, res.Err)
+	require.Contains(t, res.Err.Error(), "node 4 is linked from 5")
+	_, err := sb.ReadFile("~/kegs/personal/4/README.md")
+	require.NoError(t, err, "a refused delete leaves the keg untouched")
+
+	res = NewProcess(t, false, "prune", "--keg", "personal", "--delete", "--force", "--yes").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err, string(res.Stderr))
+	_, err = sb.ReadFile("~/kegs/personal/4/README.md")
+	require.Error(t, err)
+	require.Contains(t, string(sb.MustReadFile("~/kegs/personal/5/README.md")), "../0")
+}
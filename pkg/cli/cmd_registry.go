@@ -0,0 +1,417 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/jlrickert/tapper/pkg/registry"
+	"github.com/jlrickert/tapper/pkg/tapper"
+	"github.com/spf13/cobra"
+)
+
+// registryRecord is the structured form of one `registry list` row.
+type registryRecord struct {
+	Name     string `json:"name" yaml:"name"`
+	Url      string `json:"url" yaml:"url"`
+	Default  bool   `json:"default" yaml:"default"`
+	HasToken bool   `json:"hasToken" yaml:"hasToken"`
+}
+
+// registryKegRecord is the structured form of a registry keg in `registry
+// kegs`, `registry search` and `registry show`.
+type registryKegRecord struct {
+	User        string `json:"user" yaml:"user"`
+	Name        string `json:"name" yaml:"name"`
+	Title       string `json:"title,omitempty" yaml:"title,omitempty"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	Visibility  string `json:"visibility,omitempty" yaml:"visibility,omitempty"`
+	Nodes       int    `json:"nodes" yaml:"nodes"`
+	Updated     string `json:"updated,omitempty" yaml:"updated,omitempty"`
+}
+
+// registryGrantRecord is the structured form of one `registry share` row.
+type registryGrantRecord struct {
+	User string `json:"user" yaml:"user"`
+	Role string `json:"role" yaml:"role"`
+}
+
+func newRegistryKegRecord(k registry.KegInfo) registryKegRecord {
+	return registryKegRecord{
+		User:        k.User,
+		Name:        k.Name,
+		Title:       k.Title,
+		Description: k.Description,
+		Visibility:  k.Visibility,
+		Nodes:       k.Nodes,
+		Updated:     formatRecordTime(k.Updated),
+	}
+}
+
+func NewRegistryCmd(deps *Deps) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "registry",
+		Short: "work with kegs hosted on a keg registry",
+		Long: `List, find, create, publish and share kegs on the registries configured
+under "registries" in the user config. Tokens come from token or tokenEnv.
+
+  defaultRegistry: knut
+  registries:
+    - name: knut
+      url: keg.jlrickert.me
+      tokenEnv: KNUT_API_KEY
+
+Registry kegs added under "kegs" (for example "knut:jlrickert/notes") work
+with every other command like a local keg.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+	cmd.AddCommand(
+		newRegistryListCmd(deps),
+		newRegistryKegsCmd(deps),
+		newRegistryCreateCmd(deps),
+		newRegistrySearchCmd(deps),
+		newRegistryShowCmd(deps),
+		newRegistryPublishCmd(deps),
+		newRegistryVisibilityCmd(deps),
+		newRegistryShareCmd(deps),
+	)
+	return cmd
+}
+
+func newRegistryListCmd(deps *Deps) *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "list configured registries",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			regs := deps.Tap.Registries(cmd.Context())
+			if deps.Output != OutputHuman {
+				records := make([]registryRecord, 0, len(regs))
+				for _, reg := range regs {
+					records = append(records, registryRecord(reg))
+				}
+				return writeOutput(cmd.OutOrStdout(), deps.Output, records)
+			}
+
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "NAME\tURL\tTOKEN")
+			for _, reg := range regs {
+				name := reg.Name
+				if reg.Default {
+					name += " *"
+				}
+				token := "-"
+				if reg.HasToken {
+					token = "set"
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\n", name, reg.Url, token)
+			}
+			return w.Flush()
+		},
+	}
+}
+
+func newRegistryKegsCmd(deps *Deps) *cobra.Command {
+	var opts tapper.RegistryKegsOptions
+
+	cmd := &cobra.Command{
+		Use:   "kegs",
+		Short: "list a user's kegs on a registry",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			kegs, err := deps.Tap.RegistryKegs(cmd.Context(), opts)
+			if err != nil {
+				return err
+			}
+			if deps.Output != OutputHuman {
+				records := make([]registryKegRecord, 0, len(kegs))
+				for _, k := range kegs {
+					records = append(records, newRegistryKegRecord(k))
+				}
+				return writeOutput(cmd.OutOrStdout(), deps.Output, records)
+			}
+
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "KEG\tNODES\tUPDATED\tTITLE")
+			for _, k := range kegs {
+				fmt.Fprintf(w, "%s/%s\t%d\t%s\t%s\n", k.User, k.Name, k.Nodes, formatStatusTime(k.Updated), k.Title)
+			}
+			return w.Flush()
+		},
+	}
+	addRegistryFlags(cmd, &opts.Registry, &opts.User)
+	return cmd
+}
+
+func newRegistryCreateCmd(deps *Deps) *cobra.Command {
+	var opts tapper.RegistryCreateOptions
+
+	cmd := &cobra.Command{
+		Use:   "create NAME",
+		Short: "create a keg on a registry",
+		Long: `Create an empty keg on the registry. With --alias the keg is also added
+to the user config so it can be used with --keg right away.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.Keg = args[0]
+			info, err := deps.Tap.RegistryCreate(cmd.Context(), opts)
+			if err != nil {
+				return err
+			}
+			_, err = fmt.Fprintf(cmd.OutOrStdout(), "%s/%s\n", info.User, info.Name)
+			return err
+		},
+	}
+	addRegistryFlags(cmd, &opts.Registry, &opts.User)
+	cmd.Flags().StringVar(&opts.Title, "title", "", "title of the new keg")
+	cmd.Flags().StringVar(&opts.Alias, "alias", "", "add the keg to the user config under this alias")
+	return cmd
+}
+
+func newRegistrySearchCmd(deps *Deps) *cobra.Command {
+	var (
+		opts tapper.RegistrySearchOptions
+		in   string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "search QUERY",
+		Short: "find kegs on a registry",
+		Long: `Find kegs on the registry whose name, owner, title or description match
+QUERY. Use "tap registry show USER/KEG --alias ALIAS" to add one.
+
+With --in the nodes of one registry keg are searched instead.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if in == "" {
+				return runRegistryDiscover(cmd, deps, tapper.RegistryDiscoverOptions{
+					Registry: opts.Registry,
+					Query:    args[0],
+				})
+			}
+			opts.Keg = in
+			if user, name, ok := strings.Cut(in, "/"); ok {
+				opts.User, opts.Keg = user, name
+			}
+			opts.Query = args[0]
+			hits, err := deps.Tap.RegistrySearch(cmd.Context(), opts)
+			if err != nil {
+				return err
+			}
+			if deps.Output != OutputHuman {
+				return writeOutput(cmd.OutOrStdout(), deps.Output, hits)
+			}
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+			for _, hit := range hits {
+				fmt.Fprintf(w, "%s\t%s\n", hit.ID, hit.Title)
+			}
+			return w.Flush()
+		},
+	}
+	addRegistryFlags(cmd, &opts.Registry, &opts.User)
+	cmd.Flags().StringVar(&in, "in", "", "search the nodes of this registry keg ([USER/]KEG)")
+	return cmd
+}
+
+func runRegistryDiscover(cmd *cobra.Command, deps *Deps, opts tapper.RegistryDiscoverOptions) error {
+	kegs, err := deps.Tap.RegistryDiscover(cmd.Context(), opts)
+	if err != nil {
+		return err
+	}
+	if deps.Output != OutputHuman {
+		records := make([]registryKegRecord, 0, len(kegs))
+		for _, k := range kegs {
+			records = append(records, newRegistryKegRecord(k))
+		}
+		return writeOutput(cmd.OutOrStdout(), deps.Output, records)
+	}
+
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tOWNER\tUPDATED\tDESCRIPTION")
+	for _, k := range kegs {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", k.Name, k.User, formatStatusTime(k.Updated), registryKegSummary(k))
+	}
+	return w.Flush()
+}
+
+func newRegistryShowCmd(deps *Deps) *cobra.Command {
+	var opts tapper.RegistryShowOptions
+
+	cmd := &cobra.Command{
+		Use:   "show [USER/]KEG",
+		Short: "show the details of a registry keg",
+		Long: `Show the details of a registry keg. With --alias the keg is also added to
+the user config so it can be used with --keg right away.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.Keg = args[0]
+			if user, name, ok := strings.Cut(args[0], "/"); ok {
+				opts.User, opts.Keg = user, name
+			}
+			info, err := deps.Tap.RegistryShow(cmd.Context(), opts)
+			if err != nil {
+				return err
+			}
+			if opts.Alias != "" {
+				fmt.Fprintf(cmd.ErrOrStderr(), "added keg alias %s\n", opts.Alias)
+			}
+			if deps.Output != OutputHuman {
+				return writeOutput(cmd.OutOrStdout(), deps.Output, newRegistryKegRecord(*info))
+			}
+
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+			fmt.Fprintf(w, "keg:\t%s/%s\n", info.User, info.Name)
+			fmt.Fprintf(w, "owner:\t%s\n", info.User)
+			if info.Title != "" {
+				fmt.Fprintf(w, "title:\t%s\n", info.Title)
+			}
+			if info.Description != "" {
+				fmt.Fprintf(w, "description:\t%s\n", info.Description)
+			}
+			if info.Visibility != "" {
+				fmt.Fprintf(w, "visibility:\t%s\n", info.Visibility)
+			}
+			fmt.Fprintf(w, "nodes:\t%d\n", info.Nodes)
+			fmt.Fprintf(w, "updated:\t%s\n", formatStatusTime(info.Updated))
+			return w.Flush()
+		},
+	}
+	addRegistryFlags(cmd, &opts.Registry, &opts.User)
+	cmd.Flags().StringVar(&opts.Alias, "alias", "", "add the keg to the user config under this alias")
+	return cmd
+}
+
+// registryKegSummary is the description of a registry keg, or its title
+// when it has none.
+func registryKegSummary(k registry.KegInfo) string {
+	if k.Description != "" {
+		return k.Description
+	}
+	return k.Title
+}
+
+func newRegistryPublishCmd(deps *Deps) *cobra.Command {
+	var opts tapper.RegistryPublishOptions
+
+	cmd := &cobra.Command{
+		Use:   "publish",
+		Short: "upload a local keg to a registry",
+		Long: `Upload a local keg to your namespace on a registry. The registry keg is
+created on first publish and updated afterwards: nodes, attachments, the keg
+config and the dex indexes are uploaded, and registry nodes that no longer
+exist locally are removed. Nodes whose content is encrypted are skipped, and
+removed from the registry, unless --unlock is given.
+
+The registry keg is named after the local alias unless --name is given. New
+kegs are private unless --visibility public is given.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			applyKegTargetProfile(deps, &opts.KegTargetOptions)
+			result, err := deps.Tap.RegistryPublish(cmd.Context(), opts)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.ErrOrStderr(), "published %d nodes, %d attachments and %d indexes to %s/%s\n",
+				result.Nodes, result.Assets, result.Indexes, result.User, result.Name)
+			if result.Removed > 0 {
+				fmt.Fprintf(cmd.ErrOrStderr(), "removed %d nodes missing locally\n", result.Removed)
+			}
+			if result.Skipped > 0 {
+				fmt.Fprintf(cmd.ErrOrStderr(), "skipped %d encrypted nodes (use --unlock to publish them)\n", result.Skipped)
+			}
+			_, err = fmt.Fprintln(cmd.OutOrStdout(), result.URL)
+			return err
+		},
+	}
+	addRegistryFlags(cmd, &opts.Registry, &opts.User)
+	cmd.Flags().StringVar(&opts.Name, "name", "", "keg name on the registry (default local alias)")
+	cmd.Flags().StringVar(&opts.Title, "title", "", "title of the registry keg")
+	cmd.Flags().StringVar(&opts.Visibility, "visibility", "", "public or private (default private for a new keg)")
+	cmd.Flags().BoolVar(&opts.Unlock, "unlock", false, "publish nodes with encrypted content as plaintext instead of skipping them")
+	return cmd
+}
+
+func newRegistryVisibilityCmd(deps *Deps) *cobra.Command {
+	var opts tapper.RegistryVisibilityOptions
+
+	cmd := &cobra.Command{
+		Use:   "visibility [USER/]KEG public|private",
+		Short: "make a registry keg public or private",
+		Long: `Make a registry keg public, so anyone can find and read it, or private,
+so only its owner and the users it is shared with can.`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.Keg, opts.Visibility = args[0], args[1]
+			if user, name, ok := strings.Cut(args[0], "/"); ok {
+				opts.User, opts.Keg = user, name
+			}
+			info, err := deps.Tap.RegistryVisibility(cmd.Context(), opts)
+			if err != nil {
+				return err
+			}
+			if deps.Output != OutputHuman {
+				return writeOutput(cmd.OutOrStdout(), deps.Output, newRegistryKegRecord(*info))
+			}
+			fmt.Fprintf(cmd.ErrOrStderr(), "%s/%s is now %s\n", info.User, info.Name, opts.Visibility)
+			return nil
+		},
+	}
+	addRegistryFlags(cmd, &opts.Registry, &opts.User)
+	return cmd
+}
+
+func newRegistryShareCmd(deps *Deps) *cobra.Command {
+	var opts tapper.RegistryShareOptions
+
+	cmd := &cobra.Command{
+		Use:   "share [USER/]KEG",
+		Short: "share a registry keg with other users",
+		Long: `Share a registry keg with another registry user. Readers can read the
+keg; writers can also change it. Sharing with a user again replaces their
+role, and --revoke removes their access.
+
+The access list is printed after every change; without --with the command
+only prints it.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.Keg = args[0]
+			if user, name, ok := strings.Cut(args[0], "/"); ok {
+				opts.User, opts.Keg = user, name
+			}
+			grants, err := deps.Tap.RegistryShare(cmd.Context(), opts)
+			if err != nil {
+				return err
+			}
+			if deps.Output != OutputHuman {
+				records := make([]registryGrantRecord, 0, len(grants))
+				for _, g := range grants {
+					records = append(records, registryGrantRecord{User: g.User, Role: g.Role})
+				}
+				return writeOutput(cmd.OutOrStdout(), deps.Output, records)
+			}
+
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "USER\tROLE")
+			for _, g := range grants {
+				fmt.Fprintf(w, "%s\t%s\n", g.User, g.Role)
+			}
+			return w.Flush()
+		},
+	}
+	addRegistryFlags(cmd, &opts.Registry, &opts.User)
+	cmd.Flags().StringVar(&opts.With, "with", "", "registry user to share the keg with")
+	cmd.Flags().StringVar(&opts.Role, "role", "", "read or write (default read)")
+	cmd.Flags().BoolVar(&opts.Revoke, "revoke", false, "remove the user's access instead")
+	_ = cmd.RegisterFlagCompletionFunc("role", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{registry.RoleRead, registry.RoleWrite}, cobra.ShellCompDirectiveNoFileComp
+	})
+	return cmd
+}
+
+func addRegistryFlags(cmd *cobra.Command, reg, user *string) {
+	cmd.Flags().StringVar(reg, "registry", "", "registry name (default defaultRegistry)")
+	cmd.Flags().StringVar(user, "user", "", "registry user (default current user)")
+}
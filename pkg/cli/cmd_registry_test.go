@@ -0,0 +1,232 @@
+package cli_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	testutils "github.com/jlrickert/cli-toolkit/sandbox"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry_ListsKegsAndSearches(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/users/joe/kegs":
+			_, _ = w.Write([]byte(`{"kegs":[{"user":"joe","name":"notes","title":"Notes","nodes":3}]}`))
+		case "/api/v1/kegs":
+			require.Equal(t, "golang", r.URL.Query().Get("q"))
+			_, _ = w.Write([]byte(`{"kegs":[{"user":"ann","name":"gonotes","description":"Go field notes"}]}`))
+		case "/api/v1/kegs/ann/gonotes":
+			_, _ = w.Write([]byte(`{"user":"ann","name":"gonotes","description":"Go field notes","visibility":"public","nodes":12}`))
+		case "/api/v1/kegs/joe/notes/search":
+			require.Equal(t, "go tips", r.URL.Query().Get("q"))
+			_, _ = w.Write([]byte(`{"hits":[{"id":"2","title":"Go tips"}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	sb := NewSandbox(t, testutils.WithFixture("joe", "~"))
+	withTestRegistry(t, sb, srv.URL)
+
+	res := NewProcess(t, false, "registry", "kegs", "--user", "joe").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err, string(res.Stderr))
+	require.Contains(t, string(res.Stdout), "joe/notes")
+	require.Contains(t, string(res.Stdout), "Notes")
+
+	res = NewProcess(t, false, "registry", "search", "--in", "joe/notes", "go tips").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err, string(res.Stderr))
+	require.Equal(t, "2  Go tips", strings.TrimSpace(string(res.Stdout)))
+
+	res = NewProcess(t, false, "registry", "search", "golang").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err, string(res.Stderr))
+	require.Contains(t, string(res.Stdout), "gonotes  ann")
+	require.Contains(t, string(res.Stdout), "Go field notes")
+
+	res = NewProcess(t, false, "registry", "show", "ann/gonotes", "--alias", "gonotes").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err, string(res.Stderr))
+	require.Contains(t, string(res.Stdout), "nodes:        12")
+	require.Contains(t, string(res.Stdout), "visibility:   public")
+	require.Contains(t, string(sb.MustReadFile("~/.config/tapper/config.yaml")), "gonotes")
+
+	res = NewProcess(t, false, "registry", "show", "ann/missing").Run(sb.Context(), sb.Runtime())
+	require.Error(t, res.Err)
+	require.Contains(t, string(res.Stderr), "registry keg not found: ann/missing")
+
+	res = NewProcess(t, false, "registry", "kegs", "--registry", "ghost").Run(sb.Context(), sb.Runtime())
+	require.Error(t, res.Err)
+	require.Contains(t, string(res.Stderr), "registry not found: ghost")
+}
+
+func TestRegistry_PublishUploadsLocalKeg(t *testing.T) {
+	t.Parallel()
+	var (
+		mu       sync.Mutex
+		requests []string
+		update   map[string]string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v1/users/joe/kegs":
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"user":"joe","name":"personal"}`))
+		case r.Method == http.MethodPatch && r.URL.Path == "/api/v1/kegs/joe/personal":
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&update))
+			_, _ = w.Write([]byte(`{"user":"joe","name":"personal"}`))
+		case r.Method == http.MethodPut:
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	sb := NewSandbox(t, testutils.WithFixture("joe", "~"))
+	withTestRegistry(t, sb, srv.URL)
+
+	res := NewProcess(t, false, "registry", "publish", "--keg", "personal", "--user", "joe", "--visibility", "public").
+		Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err, string(res.Stderr))
+	require.Equal(t, srv.URL+"/@joe/personal", strings.TrimSpace(string(res.Stdout)))
+	require.Contains(t, string(res.Stderr), "published 4 nodes")
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Equal(t, "public", update["visibility"])
+	require.Contains(t, requests, "PUT /api/v1/kegs/joe/personal/config")
+	require.Contains(t, requests, "PUT /api/v1/kegs/joe/personal/nodes/3")
+	require.Contains(t, requests, "PUT /api/v1/kegs/joe/personal/indexes/nodes.tsv")
+
+	res = NewProcess(t, false, "registry", "publish", "--keg", "personal", "--visibility", "secret").
+		Run(sb.Context(), sb.Runtime())
+	require.Error(t, res.Err)
+	require.Contains(t, string(res.Stderr), "visibility must be public or private")
+}
+
+func TestRegistry_PublishFillsImageAltText(t *testing.T) {
+	t.Parallel()
+	var (
+		mu      sync.Mutex
+		content string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case r.Method == http.MethodPost:
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"user":"testuser","name":"example"}`))
+		case r.Method == http.MethodPatch:
+			_, _ = w.Write([]byte(`{"user":"testuser","name":"example"}`))
+		case r.Method == http.MethodPut && r.URL.Path == "/api/v1/kegs/testuser/example/nodes/0":
+			var node struct {
+				Content string `json:"content"`
+			}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&node))
+			content = node.Content
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == http.MethodPut:
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	sb := imageFixture(t)
+	withTestRegistry(t, sb, srv.URL)
+	res := NewProcess(t, false, "image", "upload", "0", "~/test-images/default.png", "--alt", "Tapper logo").
+		Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	sb.MustWriteFile("~/kegs/example/0/README.md",
+		[]byte("# Zero\n\n![](images/default.png)\n![Custom](images/default.png)\n"), 0o644)
+
+	res = NewProcess(t, false, "registry", "publish", "--user", "testuser").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err, string(res.Stderr))
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Contains(t, content, "![Tapper logo](images/default.png)")
+	require.Contains(t, content, "![Custom](images/default.png)")
+}
+
+func TestRegistry_VisibilityAndSharing(t *testing.T) {
+	t.Parallel()
+	var (
+		mu     sync.Mutex
+		grants = map[string]string{}
+		update map[string]string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		member, isMember := strings.CutPrefix(r.URL.Path, "/api/v1/kegs/joe/team/acl/")
+		switch {
+		case r.Method == http.MethodPatch && r.URL.Path == "/api/v1/kegs/joe/team":
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&update))
+			_, _ = w.Write([]byte(`{"user":"joe","name":"team","visibility":"public"}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v1/kegs/joe/team/acl":
+			list := make([]map[string]string, 0, len(grants))
+			for user, role := range grants {
+				list = append(list, map[string]string{"user": user, "role": role})
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"grants": list})
+		case r.Method == http.MethodPut && isMember:
+			var body map[string]string
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			grants[member] = body["role"]
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == http.MethodDelete && isMember && grants[member] != "":
+			delete(grants, member)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	sb := NewSandbox(t, testutils.WithFixture("joe", "~"))
+	withTestRegistry(t, sb, srv.URL)
+
+	res := NewProcess(t, false, "registry", "visibility", "joe/team", "public").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err, string(res.Stderr))
+	require.Contains(t, string(res.Stderr), "joe/team is now public")
+	require.Equal(t, "public", update["visibility"])
+
+	res = NewProcess(t, false, "registry", "visibility", "joe/team", "hidden").Run(sb.Context(), sb.Runtime())
+	require.Error(t, res.Err)
+	require.Contains(t, string(res.Stderr), "visibility must be public or private")
+
+	res = NewProcess(t, false, "registry", "share", "joe/team", "--with", "@ann", "--role", "write").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err, string(res.Stderr))
+	require.Contains(t, string(res.Stdout), "ann   write")
+
+	res = NewProcess(t, false, "registry", "share", "joe/team", "--with", "bob", "--role", "admin").Run(sb.Context(), sb.Runtime())
+	require.Error(t, res.Err)
+	require.Contains(t, string(res.Stderr), "role must be read or write")
+
+	res = NewProcess(t, false, "registry", "share", "joe/team", "--with", "ann", "--revoke").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err, string(res.Stderr))
+	require.NotContains(t, string(res.Stdout), "ann")
+
+	res = NewProcess(t, false, "registry", "share", "joe/team", "--with", "ann", "--revoke").Run(sb.Context(), sb.Runtime())
+	require.Error(t, res.Err)
+	require.Contains(t, string(res.Stderr), "joe/team is not shared with ann")
+}
+
+// withTestRegistry makes the registry at url the default registry "test".
+func withTestRegistry(t *testing.T, sb *testutils.Sandbox, url string) {
+	t.Helper()
+	cfg := string(sb.MustReadFile("~/.config/tapper/config.yaml"))
+	cfg += "defaultRegistry: test\nregistries:\n  - name: test\n    url: " + url + "\n"
+	sb.MustWriteFile("~/.config/tapper/config.yaml", []byte(cfg), 0o644)
+}
@@ -0,0 +1,49 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/jlrickert/tapper/pkg/tapper"
+	"github.com/spf13/cobra"
+)
+
+// NewRelatedCmd returns the `related` cobra command.
+func NewRelatedCmd(deps *Deps) *cobra.Command {
+	var opts tapper.RelatedOptions
+
+	cmd := &cobra.Command{
+		Use:   "related NODE_ID",
+		Short: "suggest nodes related to a node",
+		Long: `Suggest nodes related to NODE_ID, best first.
+
+Nodes score one point for each tag they share with NODE_ID, each link target
+they both cite, and each node that links to both of them.
+
+Format placeholders: %i (node id), %d (date), %t (title), %s (score),
+%% (literal %). Default format: "%i\t%s\t%t". A format containing {{ is a
+Go template run per node with {{.Score}} holding the score.`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: nodeIDCompletionFunc(deps, 1),
+		Annotations:       nodeArgAnnotations(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.NodeID = args[0]
+			applyKegTargetProfile(deps, &opts.KegTargetOptions)
+
+			nodes, err := deps.Tap.Related(cmd.Context(), opts)
+			if err != nil {
+				return err
+			}
+			for _, node := range nodes {
+				fmt.Fprintln(cmd.OutOrStdout(), node)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().IntVarP(&opts.Limit, "limit", "n", 10, "maximum number of suggestions (0 for no limit)")
+	cmd.Flags().BoolVarP(&opts.IdOnly, "id-only", "", false, "show only ids")
+	cmd.Flags().StringVarP(&opts.Format, "format", "f", "", "output format")
+	cmd.Flags().BoolVar(&opts.Exact, "exact", false, "disable fuzzy title matching for node arguments")
+
+	return cmd
+}
@@ -0,0 +1,36 @@
+package cli_test
+
+import (
+	"strings"
+	"testing"
+
+	testutils "github.com/jlrickert/cli-toolkit/sandbox"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRelatedCommand_RanksBySharedTagsAndLinks(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("joe", "~"))
+
+	res := NewProcess(t, false, "related", "1").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	lines := strings.Split(strings.TrimSpace(string(res.Stdout)), "\n")
+	require.Equal(t, []string{
+		"3\t2\tMeeting Notes",
+		"0\t1\tSorry, planned but not yet available",
+		"2\t1\tProject Alpha",
+	}, lines)
+
+	limited := NewProcess(t, false, "related", "1", "--limit", "1", "--id-only").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, limited.Err)
+	require.Equal(t, "3", strings.TrimSpace(string(limited.Stdout)))
+}
+
+func TestRelatedCommand_MissingNode(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("joe", "~"))
+
+	res := NewProcess(t, false, "related", "99").Run(sb.Context(), sb.Runtime())
+	require.Error(t, res.Err)
+	require.Contains(t, res.Err.Error(), "node 99 not found")
+}
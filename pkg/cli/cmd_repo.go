@@ -9,15 +9,19 @@ import (
 
 func NewRepoCmd(deps *Deps) *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "repo",
-		Short: "manage keg repositories",
+		Use:     "repo",
+		Short:   "manage keg repositories",
+		Aliases: []string{"kegs"},
 	}
 
 	cmd.AddCommand(
+		NewRepoCloneCmd(deps),
 		NewRepoConfigCmd(deps),
 		NewRepoKegListCmd(deps),
 		NewInitCmd(deps),
 		NewRepoRmCmd(deps),
+		NewRepoStatusCmd(deps),
+		NewRepoTrustCmd(deps),
 	)
 
 	return cmd
@@ -33,6 +37,13 @@ func NewRepoKegListCmd(deps *Deps) *cobra.Command {
 			if err != nil {
 				return err
 			}
+			if deps.Output != OutputHuman {
+				records := make([]kegRecord, 0, len(kegs))
+				for _, alias := range kegs {
+					records = append(records, kegRecord{Alias: alias})
+				}
+				return writeOutput(cmd.OutOrStdout(), deps.Output, records)
+			}
 			if len(kegs) == 0 {
 				return fmt.Errorf("no kegs found")
 			}
@@ -0,0 +1,55 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jlrickert/tapper/pkg/tapper"
+	"github.com/spf13/cobra"
+)
+
+// NewRepoCloneCmd returns the `tap repo clone` cobra command.
+func NewRepoCloneCmd(deps *Deps) *cobra.Command {
+	var opts tapper.CloneRepoOptions
+
+	cmd := &cobra.Command{
+		Use:   "clone SOURCE ALIAS",
+		Short: "copy a keg into a new local keg",
+		Long: strings.TrimSpace(`
+Copy the config, nodes, archived nodes, assets and indexes of SOURCE into a
+new filesystem-backed keg and register it as ALIAS in the user config. Hooks
+and other settings that run commands are left out of the copied config.
+
+SOURCE is a configured keg alias or a keg target such as a path or file://
+URL. The clone is written under the first configured kegSearchPaths entry
+unless --path is given.
+`),
+		Example: strings.TrimSpace(`
+tap repo clone ~/shared/team-notes team
+tap repo clone work work-copy --path ./kegs/work
+`),
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.Source = args[0]
+			opts.Alias = args[1]
+			res, err := deps.Tap.CloneRepo(cmd.Context(), opts)
+			if err != nil {
+				return err
+			}
+			_, err = fmt.Fprintf(cmd.OutOrStdout(), "cloned %d nodes into keg %s at %s\n", res.Nodes, opts.Alias, res.Target.Path())
+			return err
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.Path, "path", "", "destination directory for the clone")
+
+	cmd.ValidArgsFunction = func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 || deps.Tap == nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		kegs, _ := deps.Tap.ListKegs(true)
+		return kegs, cobra.ShellCompDirectiveDefault
+	}
+
+	return cmd
+}
@@ -0,0 +1,106 @@
+package cli_test
+
+import (
+	"testing"
+
+	testutils "github.com/jlrickert/cli-toolkit/sandbox"
+	"github.com/jlrickert/tapper/pkg/keg"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepoClone_CopiesKegAndRegistersAlias(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("joe", "~"))
+
+	res := NewProcess(t, false, "repo", "clone", "personal", "copy", "--path", "~/kegs/copy").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err, string(res.Stderr))
+	require.Contains(t, string(res.Stdout), "cloned 4 nodes into keg copy")
+
+	require.Equal(t,
+		string(sb.MustReadFile("~/kegs/personal/2/README.md")),
+		string(sb.MustReadFile("~/kegs/copy/2/README.md")))
+	require.Equal(t,
+		string(sb.MustReadFile("~/kegs/personal/dex/nodes.tsv")),
+		string(sb.MustReadFile("~/kegs/copy/dex/nodes.tsv")))
+	require.Contains(t, string(sb.MustReadFile("~/.config/tapper/config.yaml")), "copy:")
+
+	res = NewProcess(t, false, "cat", "--keg", "copy", "2").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err, string(res.Stderr))
+	require.Contains(t, string(res.Stdout), "Project Alpha")
+}
+
+func TestRepoClone_RefusesTakenAlias(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("joe", "~"))
+
+	res := NewProcess(t, false, "kegs", "clone", "personal", "work").Run(sb.Context(), sb.Runtime())
+	require.Error(t, res.Err)
+	require.Contains(t, res.Err.Error(), "already configured")
+}
+
+func TestRepoClone_CopiesArchivedNodes(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("joe", "~"))
+
+	res := NewProcess(t, false, "archive", "--keg", "personal", "3").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err, string(res.Stderr))
+	res = NewProcess(t, false, "repo", "clone", "personal", "copy", "--path", "~/kegs/copy").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err, string(res.Stderr))
+
+	res = NewProcess(t, false, "cat", "--keg", "copy", "--archived", "--content-only", "3").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err, string(res.Stderr))
+	require.Contains(t, string(res.Stdout), "# Meeting Notes")
+}
+
+func TestRepoClone_CopiesItemStateAndStripsExecSettings(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("joe", "~"))
+	ctx := sb.Context()
+
+	repo := keg.NewFsRepo("~/kegs/personal", sb.Runtime())
+	k := keg.NewKeg(repo, sb.Runtime())
+	one, three := keg.NodeId{ID: 1}, keg.NodeId{ID: 3}
+	require.NoError(t, repo.WriteFile(ctx, one, "report.txt", []byte("report")))
+	require.NoError(t, repo.WriteItemMeta(ctx, one, keg.AssetKindItem, "report.txt", &keg.ItemMeta{Alt: "Quarterly report"}))
+	require.NoError(t, k.SetContent(ctx, one, []byte("# Rewritten\n\nNew text.\n")))
+	require.NoError(t, repo.WriteFile(ctx, three, "notes.txt", []byte("notes")))
+	sb.MustWriteFile("~/kegs/personal/keg", append(sb.MustReadFile("~/kegs/personal/keg"),
+		"hooks:\n    - event: post-create\n      run: echo created\n"...), 0o644)
+	res := NewProcess(t, false, "archive", "3", "--keg", "personal").Run(ctx, sb.Runtime())
+	require.NoError(t, res.Err, string(res.Stderr))
+
+	res = NewProcess(t, false, "repo", "clone", "personal", "copy", "--path", "~/kegs/copy").Run(ctx, sb.Runtime())
+	require.NoError(t, res.Err, string(res.Stderr))
+
+	for _, item := range []string{
+		"1/assets/report.txt",
+		"1/assets/.meta/report.txt.json",
+		"1/assets/.versions/index.tsv",
+		"archive/3/stats.json",
+		"archive/3/assets/notes.txt",
+	} {
+		require.Equal(t,
+			string(sb.MustReadFile("~/kegs/personal/"+item)),
+			string(sb.MustReadFile("~/kegs/copy/"+item)), item)
+	}
+	clone := keg.NewKeg(keg.NewFsRepo("~/kegs/copy", sb.Runtime()), sb.Runtime())
+	versions, err := clone.ListVersions(ctx, one)
+	require.NoError(t, err)
+	require.Len(t, versions, 1)
+
+	cfg := string(sb.MustReadFile("~/kegs/copy/keg"))
+	require.NotContains(t, cfg, "hooks:")
+	require.NotContains(t, cfg, "echo created")
+}
+
+func TestRepoClone_RefusesNonEmptyDestination(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("joe", "~"))
+	sb.MustWriteFile("~/kegs/copy/notes.txt", []byte("keep me\n"), 0o644)
+
+	res := NewProcess(t, false, "repo", "clone", "personal", "copy", "--path", "~/kegs/copy").Run(sb.Context(), sb.Runtime())
+	require.Error(t, res.Err)
+	require.Contains(t, res.Err.Error(), "not empty")
+	require.Equal(t, "keep me\n", string(sb.MustReadFile("~/kegs/copy/notes.txt")))
+	require.NotContains(t, string(sb.MustReadFile("~/.config/tapper/config.yaml")), "copy:")
+}
@@ -0,0 +1,112 @@
+package cli
+
+import (
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jlrickert/tapper/pkg/tapper"
+	"github.com/spf13/cobra"
+)
+
+// kegStatusRecord is the structured form of one `repo status` row.
+type kegStatusRecord struct {
+	Alias       string `json:"alias" yaml:"alias"`
+	Target      string `json:"target" yaml:"target"`
+	Reachable   bool   `json:"reachable" yaml:"reachable"`
+	Initialized bool   `json:"initialized" yaml:"initialized"`
+	Nodes       int    `json:"nodes" yaml:"nodes"`
+	Updated     string `json:"updated" yaml:"updated"`
+	IndexedAt   string `json:"indexed_at" yaml:"indexed_at"`
+	Fresh       bool   `json:"fresh" yaml:"fresh"`
+	Stale       int    `json:"stale" yaml:"stale"`
+	Drafts      int    `json:"drafts" yaml:"drafts"`
+	Error       string `json:"error" yaml:"error"`
+}
+
+func NewRepoStatusCmd(deps *Deps) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "show the health of every configured keg",
+		Long: `Report the health of every keg alias in the user config.
+
+For each keg the table shows whether its target is reachable and
+initialized, the node count, the last node update, whether the dex is fresh
+(stale nodes need ` + "`index`" + `), and uncommitted drafts.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			statuses, err := deps.Tap.KegsStatus(cmd.Context())
+			if err != nil {
+				return err
+			}
+			if deps.Output != OutputHuman {
+				records := make([]kegStatusRecord, 0, len(statuses))
+				for _, s := range statuses {
+					records = append(records, kegStatusRecord{
+						Alias:       s.Alias,
+						Target:      s.Target,
+						Reachable:   s.Reachable,
+						Initialized: s.Initialized,
+						Nodes:       s.Nodes,
+						Updated:     formatRecordTime(s.Updated),
+						IndexedAt:   formatRecordTime(s.IndexedAt),
+						Fresh:       s.Fresh(),
+						Stale:       s.Stale,
+						Drafts:      s.Drafts,
+						Error:       s.Error,
+					})
+				}
+				return writeOutput(cmd.OutOrStdout(), deps.Output, records)
+			}
+			if len(statuses) == 0 {
+				return fmt.Errorf("no kegs configured")
+			}
+
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "KEG\tSTATE\tNODES\tUPDATED\tINDEX\tDRAFTS")
+			for _, s := range statuses {
+				fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\t%d\n",
+					s.Alias, kegState(s), s.Nodes, formatStatusTime(s.Updated), indexState(s), s.Drafts)
+			}
+			if err := w.Flush(); err != nil {
+				return err
+			}
+			for _, s := range statuses {
+				if s.Error != "" {
+					fmt.Fprintf(cmd.ErrOrStderr(), "%s: %s\n", s.Alias, s.Error)
+				}
+			}
+			return nil
+		},
+	}
+	return cmd
+}
+
+func kegState(s tapper.KegStatus) string {
+	switch {
+	case !s.Reachable:
+		return "unreachable"
+	case !s.Initialized:
+		return "uninitialized"
+	case s.Error != "":
+		return "error"
+	}
+	return "ok"
+}
+
+func indexState(s tapper.KegStatus) string {
+	switch {
+	case !s.Initialized:
+		return "-"
+	case s.Fresh():
+		return "fresh"
+	}
+	return fmt.Sprintf("stale (%d)", s.Stale)
+}
+
+func formatStatusTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Format(time.DateTime)
+}
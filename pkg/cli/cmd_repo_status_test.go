@@ -0,0 +1,68 @@
+package cli_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	testutils "github.com/jlrickert/cli-toolkit/sandbox"
+	"github.com/stretchr/testify/require"
+)
+
+type kegStatusJSON struct {
+	Alias       string `json:"alias"`
+	Reachable   bool   `json:"reachable"`
+	Initialized bool   `json:"initialized"`
+	Nodes       int    `json:"nodes"`
+	Fresh       bool   `json:"fresh"`
+	Stale       int    `json:"stale"`
+	Error       string `json:"error"`
+}
+
+func TestRepoStatus_JSONReportsEveryAlias(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("joe", "~"))
+	cfg := string(sb.MustReadFile("~/.config/tapper/config.yaml"))
+	cfg = strings.Replace(cfg, "kegs:\n", "kegs:\n  ghost: ~/kegs/ghost\n", 1)
+	sb.MustWriteFile("~/.config/tapper/config.yaml", []byte(cfg), 0o644)
+
+	res := NewProcess(t, false, "repo", "status", "--output", "json").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err, string(res.Stderr))
+
+	var records []kegStatusJSON
+	require.NoError(t, json.Unmarshal(res.Stdout, &records))
+	byAlias := map[string]kegStatusJSON{}
+	for _, r := range records {
+		byAlias[r.Alias] = r
+	}
+	require.Len(t, byAlias, 4)
+
+	personal := byAlias["personal"]
+	require.True(t, personal.Reachable)
+	require.True(t, personal.Initialized)
+	require.Equal(t, 4, personal.Nodes)
+	require.True(t, personal.Fresh, "stale=%d", personal.Stale)
+
+	ghost := byAlias["ghost"]
+	require.False(t, ghost.Reachable)
+	require.NotEmpty(t, ghost.Error)
+}
+
+func TestRepoStatus_KegsAliasShowsStaleIndex(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("joe", "~"))
+	sb.MustWriteFile("~/kegs/personal/4/README.md", []byte("# Added by hand\n"), 0o644)
+
+	res := NewProcess(t, false, "kegs", "status").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err, string(res.Stderr))
+	out := string(res.Stdout)
+	require.Contains(t, out, "KEG")
+	var personal string
+	for _, line := range strings.Split(out, "\n") {
+		if strings.HasPrefix(line, "personal ") {
+			personal = line
+		}
+	}
+	require.Contains(t, personal, "ok")
+	require.Contains(t, personal, "stale (1)")
+}
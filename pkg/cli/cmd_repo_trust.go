@@ -0,0 +1,47 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/jlrickert/tapper/pkg/tapper"
+	"github.com/spf13/cobra"
+)
+
+func NewRepoTrustCmd(deps *Deps) *cobra.Command {
+	var opts tapper.TrustRepoOptions
+
+	cmd := &cobra.Command{
+		Use:   "trust",
+		Short: "allow a keg config to run hooks and external commands",
+		Long: `Trust the hooks, summarizer, embedder and image converter settings in the
+keg config. They run commands or send node content and API keys to other
+services, and a keg config is shared with everyone who clones or pulls the
+keg, so they are ignored until trusted.
+
+The settings are printed and recorded in the user config as they are now.
+When they change, for example after a sync, they are ignored again until
+the keg is trusted again. Registry kegs cannot be trusted. --revoke removes
+the trust.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			applyKegTargetProfile(deps, &opts.KegTargetOptions)
+			result, err := deps.Tap.TrustRepo(cmd.Context(), opts)
+			if err != nil {
+				return err
+			}
+			out := cmd.OutOrStdout()
+			switch {
+			case opts.Revoke:
+				_, err = fmt.Fprintf(out, "revoked trust in %s\n", result.Key)
+			case result.Settings == "":
+				_, err = fmt.Fprintf(out, "keg config of %s has nothing to trust\n", result.Key)
+			default:
+				_, err = fmt.Fprintf(out, "%strusted %s\n", result.Settings, result.Key)
+			}
+			return err
+		},
+	}
+
+	cmd.Flags().BoolVar(&opts.Revoke, "revoke", false, "remove the keg's trust")
+	return cmd
+}
@@ -0,0 +1,57 @@
+package cli_test
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+
+	testutils "github.com/jlrickert/cli-toolkit/sandbox"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepoTrust_GatesKegConfigHooks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell hooks run with sh -c")
+	}
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("joe", "~"))
+	cfg := sb.MustReadFile("~/kegs/personal/keg")
+	withHook := func(run string) {
+		sb.MustWriteFile("~/kegs/personal/keg", append(append([]byte(nil), cfg...),
+			"hooks:\n    - event: post-create\n      run: "+run+"\n"...), 0o644)
+	}
+	withHook(`echo "$TAP_NODE_ID" >> hooks.log`)
+	hookLog := func() string {
+		data, err := sb.Runtime().ReadFile("~/kegs/personal/hooks.log")
+		if err != nil {
+			return ""
+		}
+		return string(data)
+	}
+
+	res := NewProcess(t, false, "create", "--title", "Untrusted").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err, string(res.Stderr))
+	require.Empty(t, hookLog(), "hooks from an untrusted keg config must not run")
+
+	res = NewProcess(t, false, "repo", "trust").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err, string(res.Stderr))
+	require.Contains(t, string(res.Stdout), `run: echo "$TAP_NODE_ID" >> hooks.log`)
+	require.Contains(t, string(res.Stdout), "trusted ")
+	require.Contains(t, string(sb.MustReadFile("~/.config/tapper/config.yaml")), "trustedKegs:")
+
+	res = NewProcess(t, false, "create", "--title", "Trusted").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err, string(res.Stderr))
+	id := strings.TrimSpace(string(res.Stdout))
+	require.Equal(t, id+"\n", hookLog())
+
+	// Changed settings need to be trusted again.
+	withHook(`echo changed >> hooks.log`)
+	res = NewProcess(t, false, "create", "--title", "Changed").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err, string(res.Stderr))
+	require.Equal(t, id+"\n", hookLog())
+
+	res = NewProcess(t, false, "repo", "trust", "--revoke").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err, string(res.Stderr))
+	require.Contains(t, string(res.Stdout), "revoked trust in ")
+	require.NotContains(t, string(sb.MustReadFile("~/.config/tapper/config.yaml")), "sha256:")
+}
@@ -1,7 +1,9 @@
 package cli
 
 import (
+	"context"
 	"fmt"
+	"strings"
 
 	"github.com/jlrickert/tapper/pkg/tapper"
 	"github.com/spf13/cobra"
@@ -9,16 +11,23 @@ import (
 
 func NewRemoveCmd(deps *Deps) *cobra.Command {
 	var opts tapper.RemoveOptions
+	var dryRun bool
 
 	cmd := &cobra.Command{
 		Use:   "rm [NODE_ID...]",
 		Short: "remove nodes from the keg",
 		Long: `Remove one or more nodes and update the index.
 
-Nodes can be specified as positional arguments or selected via --query.
-Inbound links from other nodes are cleaned up.`,
+Nodes can be specified as positional arguments or selected via --query. A
+NODE_ID of "-" reads node IDs from stdin, one per line or as JSONL.
+Nodes that other nodes still link to are refused unless --force is given, in
+which case those links are pointed at node 0; --verbose reports each
+rewritten file on stderr. On a TTY the removal is
+confirmed first; --yes skips the prompt. With --dry-run the files that would
+be written or removed are listed and the keg is left untouched.`,
 		Aliases: []string{"remove"},
 		ValidArgsFunction: nodeIDCompletionFunc(deps, 0),
+		Annotations:       nodeArgAnnotations(0),
 		Args: func(cmd *cobra.Command, args []string) error {
 			if opts.Query != "" {
 				return nil
@@ -29,13 +38,41 @@ Inbound links from other nodes are cleaned up.`,
 			return nil
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			opts.NodeIDs = args
+			ids, err := nodeArgs(cmd, deps, args)
+			if err != nil {
+				return err
+			}
+			opts.NodeIDs = ids
 			applyKegTargetProfile(deps, &opts.KegTargetOptions)
-			return deps.Tap.Remove(cmd.Context(), opts)
+			if !dryRun {
+				if err := confirm(cmd, deps, "rm", false, removeQuestion(opts)); err != nil {
+					return err
+				}
+			}
+			return runWithDryRun(cmd, deps, dryRun, func(ctx context.Context) error {
+				report, err := deps.Tap.Remove(ctx, opts)
+				if perr := printLinkRepairs(cmd, deps, report); err == nil {
+					err = perr
+				}
+				return err
+			})
 		},
 	}
 
+	addDryRunFlag(cmd, &dryRun)
+	cmd.Flags().BoolVar(&opts.Force, "force", false, "remove nodes even when other nodes link to them")
 	cmd.Flags().StringVar(&opts.Query, "query", "", `boolean expression (see "tap docs query-expressions" for syntax)`)
 
 	return cmd
 }
+
+func removeQuestion(opts tapper.RemoveOptions) string {
+	var targets []string
+	if len(opts.NodeIDs) > 0 {
+		targets = append(targets, "node(s) "+strings.Join(opts.NodeIDs, ", "))
+	}
+	if opts.Query != "" {
+		targets = append(targets, fmt.Sprintf("nodes matching %q", opts.Query))
+	}
+	return fmt.Sprintf("Remove %s?", strings.Join(targets, " and "))
+}
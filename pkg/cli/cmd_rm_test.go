@@ -53,7 +53,7 @@ func TestRemoveCommand_RedirectsLinksToZero(t *testing.T) {
 	sb := NewSandbox(t, testutils.WithFixture("joe", "~"))
 
 	// Remove node 2 (Project Alpha).  Nodes 1 and 3 both link to it.
-	res := NewProcess(t, false, "rm", "2", "--keg", "personal").Run(sb.Context(), sb.Runtime())
+	res := NewProcess(t, false, "rm", "2", "--keg", "personal", "--force").Run(sb.Context(), sb.Runtime())
 	require.NoError(t, res.Err)
 
 	_, err := sb.Runtime().Stat("~/kegs/personal/2", false)
@@ -92,7 +92,7 @@ func TestRemoveCommand_RedirectsLinksCreatedViaStdin(t *testing.T) {
 	require.Equal(t, "5", strings.TrimSpace(string(res.Stdout)))
 
 	// Remove node 5.  Node 4's references to ../5 should become ../0.
-	res = NewProcess(t, false, "rm", "5", "--keg", "personal").Run(sb.Context(), sb.Runtime())
+	res = NewProcess(t, false, "rm", "5", "--keg", "personal", "--force").Run(sb.Context(), sb.Runtime())
 	require.NoError(t, res.Err)
 
 	_, err := sb.Runtime().Stat("~/kegs/personal/5", false)
@@ -110,7 +110,7 @@ func TestRemoveCommand_MultipleNodes(t *testing.T) {
 	sb := NewSandbox(t, testutils.WithFixture("joe", "~"))
 
 	// node 1 links to 2 and 3; remove both 2 and 3 in one command.
-	res := NewProcess(t, false, "rm", "2", "3", "--keg", "personal").Run(sb.Context(), sb.Runtime())
+	res := NewProcess(t, false, "rm", "2", "3", "--keg", "personal", "--force").Run(sb.Context(), sb.Runtime())
 	require.NoError(t, res.Err)
 
 	_, err := sb.Runtime().Stat("~/kegs/personal/2", false)
@@ -123,3 +123,75 @@ func TestRemoveCommand_MultipleNodes(t *testing.T) {
 	require.NotContains(t, content1, "../3")
 	require.Contains(t, content1, "../0")
 }
+
+func TestRemoveCommand_RefusesLinkedNodeWithoutForce(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("joe", "~"))
+
+	res := NewProcess(t, false, "rm", "2", "--keg", "personal").Run(sb.Context(), sb.Runtime())
+	require.Error(t, res.Err)
+	require.Contains(t, res.Err.Error(), "node 2 is linked from 1, 3; use --force")
+
+	_, err := sb.Runtime().Stat("~/kegs/personal/2", false)
+	require.NoError(t, err, "node 2 must be kept")
+	require.Contains(t, string(sb.MustReadFile("~/kegs/personal/1/README.md")), "../2")
+
+	// Removing every node that links in is not blocked by those links.
+	res = NewProcess(t, false, "rm", "1", "2", "3", "--keg", "personal").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+}
+
+func TestRemoveCommand_ConfirmsOnTTY(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("testuser", "~"))
+
+	res := NewProcess(t, false, "create", "--title", "Delete me").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+
+	res = NewProcess(t, true, "rm", "1").RunWithIO(sb.Context(), sb.Runtime(), strings.NewReader("n\n"))
+	require.Error(t, res.Err)
+	require.Contains(t, res.Err.Error(), "rm canceled")
+	require.Contains(t, string(res.Stderr), "Remove node(s) 1? [y/N]: ")
+	_, err := sb.Runtime().Stat("~/kegs/example/1", false)
+	require.NoError(t, err, "declined removal must keep the node")
+
+	res = NewProcess(t, true, "rm", "1").RunWithIO(sb.Context(), sb.Runtime(), strings.NewReader("y\n"))
+	require.NoError(t, res.Err)
+	_, err = sb.Runtime().Stat("~/kegs/example/1", false)
+	require.Error(t, err, "confirmed removal should delete the node")
+}
+
+func TestRemoveCommand_YesSkipsPrompt(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("testuser", "~"))
+
+	res := NewProcess(t, false, "create", "--title", "Delete me").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+
+	res = NewProcess(t, true, "rm", "1", "--yes").RunWithIO(sb.Context(), sb.Runtime(), strings.NewReader(""))
+	require.NoError(t, res.Err)
+	require.NotContains(t, string(res.Stderr), "[y/N]")
+	_, err := sb.Runtime().Stat("~/kegs/example/1", false)
+	require.Error(t, err)
+}
+
+func TestRemoveCommand_ReadsNodeIDsFromStdinWithoutPrompt(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("testuser", "~"))
+
+	for _, title := range []string{"One", "Two", "Three"} {
+		res := NewProcess(t, false, "create", "--title", title).Run(sb.Context(), sb.Runtime())
+		require.NoError(t, res.Err)
+	}
+
+	input := `[{"id": "1", "title": "One"}, {"id": 3, "title": "Three"}]`
+	res := NewProcess(t, true, "rm", "-").RunWithIO(sb.Context(), sb.Runtime(), strings.NewReader(input))
+	require.NoError(t, res.Err)
+	require.NotContains(t, string(res.Stderr), "[y/N]")
+	for _, id := range []string{"1", "3"} {
+		_, err := sb.Runtime().Stat("~/kegs/example/"+id, false)
+		require.Error(t, err, "node %s should be removed", id)
+	}
+	_, err := sb.Runtime().Stat("~/kegs/example/2", false)
+	require.NoError(t, err)
+}
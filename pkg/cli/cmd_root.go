@@ -8,8 +8,8 @@ import (
 	"os"
 	"strings"
 
-	"github.com/jlrickert/cli-toolkit/mylog"
 	"github.com/jlrickert/cli-toolkit/toolkit"
+	"github.com/jlrickert/tapper/pkg/keg"
 	"github.com/jlrickert/tapper/pkg/tapper"
 	"github.com/spf13/cobra"
 )
@@ -29,8 +29,37 @@ type Deps struct {
 	LogLevel   string
 	LogJSON    bool
 
+	// Verbose and Trace lower the log level to debug and trace.
+	Verbose bool
+	Trace   bool
+
+	// Output selects a machine-readable output format (see OutputJSON,
+	// OutputYAML and OutputTSV). Empty keeps human-readable output.
+	Output string
+
+	// ErrorFormat selects how failures are printed (see ErrorFormatText and
+	// ErrorFormatJSON). Empty prints JSON only with --output json.
+	ErrorFormat string
+
+	// Yes answers every confirmation prompt with yes.
+	Yes bool
+
+	// NoPager prints long output directly instead of through the pager.
+	NoPager bool
+
 	Tap *tapper.Tap
 	Err error
+
+	pager   *pager
+	logFile *os.File
+
+	// telemetry holds the command span and exporter shutdown set up by
+	// configureTelemetry.
+	telemetry *commandTelemetry
+
+	// stdinUsed records that node IDs were read from stdin, which leaves no
+	// input to answer a confirmation prompt.
+	stdinUsed bool
 }
 
 func NewRootCmd(deps *Deps) *cobra.Command {
@@ -54,6 +83,13 @@ func NewRootCmd(deps *Deps) *cobra.Command {
 				return fmt.Errorf("runtime is required")
 			}
 
+			if err := validateOutputFormat(deps.Output); err != nil {
+				return &usageError{err}
+			}
+			if err := validateErrorFormat(deps.ErrorFormat); err != nil {
+				return err
+			}
+
 			wd, err := rt.Getwd()
 			if err != nil {
 				return err
@@ -68,6 +104,12 @@ func NewRootCmd(deps *Deps) *cobra.Command {
 			}
 			deps.Tap = tap
 			deps.Root = wd
+			if rt.Stream().IsTTY && deps.Profile.withDefaults().AllowKegAliasFlags {
+				tap.KegService.PickKeg = kegPicker(cmd, deps)
+			}
+			if rt.Stream().IsTTY {
+				tap.KegService.PromptIdentity = identityPrompter(cmd, deps)
+			}
 			if deps.Profile.withDefaults().AllowKegAliasFlags {
 				_ = cmd.Root().RegisterFlagCompletionFunc("keg", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 					return listKegsFiltered(deps, cmd.Context(), toComplete), cobra.ShellCompDirectiveNoFileComp
@@ -79,29 +121,21 @@ func NewRootCmd(deps *Deps) *cobra.Command {
 				deps.Err = err
 			}
 
-			if deps.LogFile != "" || deps.LogJSON || deps.LogLevel != "" {
-				// create a logger out-> stderr or file
-				var out = os.Stderr
-				var f *os.File
-				if deps.LogFile != "" {
-					var err error
-					f, err = os.OpenFile(deps.LogFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
-					if err != nil {
-						return err
-					}
-					out = f
-				}
-				lg := mylog.NewLogger(mylog.LoggerConfig{
-					Out:     out,
-					Level:   mylog.ParseLevel(deps.LogLevel),
-					JSON:    deps.LogJSON,
-					Version: Version,
-				})
-				if err := deps.Runtime.SetLogger(lg); err != nil {
-					return err
-				}
+			if err := configureLogging(cmd, deps); err != nil {
+				return err
+			}
+			ctx, err = configureTelemetry(ctx, cmd, deps)
+			if err != nil {
+				return err
+			}
+			if deps.Yes {
+				ctx = keg.WithPolicyConfirmed(ctx)
+			}
+			if err := resolveArgAddresses(cmd, deps, args); err != nil {
+				return err
 			}
 
+			startPager(cmd, deps)
 			cmd.SetContext(ctx)
 			return nil
 		},
@@ -121,9 +155,24 @@ func NewRootCmd(deps *Deps) *cobra.Command {
 	}
 
 	cmd.PersistentFlags().StringVar(&deps.LogFile, "log-file", "", "write logs to file (default stderr)")
-	cmd.PersistentFlags().StringVar(&deps.LogLevel, "log-level", "info", "minimum log level")
+	cmd.PersistentFlags().StringVar(&deps.LogLevel, "log-level", "info", "minimum log level: trace, debug, info, warn or error")
 	cmd.PersistentFlags().BoolVar(&deps.LogJSON, "log-json", false, "output logs as JSON")
+	cmd.PersistentFlags().BoolVarP(&deps.Verbose, "verbose", "v", false, "log at debug level, including keg operation timings")
+	cmd.PersistentFlags().BoolVar(&deps.Trace, "trace", false, "log at trace level, including the start of every keg operation")
 	cmd.PersistentFlags().StringVarP(&deps.ConfigPath, "config", "c", "", "path to config file")
+	cmd.PersistentFlags().StringVar(&deps.Output, "output", "", "structured output for ls, cat, links, lint, stats, backup list, repo list and repo status: json, yaml or tsv")
+	cmd.PersistentFlags().BoolVar(&deps.NoPager, "no-pager", false, "do not pipe long output through the pager")
+	cmd.PersistentFlags().BoolVarP(&deps.Yes, "yes", "y", false, "skip confirmation prompts and confirm changes the keg policy protects")
+	cmd.PersistentFlags().StringVar(&deps.ErrorFormat, "error-format", "", "print failures as text or as a json object on stderr (default json with --output json)")
+	_ = cmd.RegisterFlagCompletionFunc("output", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return outputFormats, cobra.ShellCompDirectiveNoFileComp
+	})
+	_ = cmd.RegisterFlagCompletionFunc("error-format", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return errorFormats, cobra.ShellCompDirectiveNoFileComp
+	})
+	cmd.SetFlagErrorFunc(func(_ *cobra.Command, err error) error {
+		return &usageError{err}
+	})
 	if deps.Profile.withDefaults().AllowKegAliasFlags {
 		cmd.PersistentFlags().StringVarP(&deps.KegTargetOptions.Keg, "keg", "k", "", "alias of the keg to use")
 		cmd.PersistentFlags().BoolVar(&deps.KegTargetOptions.Project, "project", false, "resolve against the project-local keg")
@@ -132,47 +181,84 @@ func NewRootCmd(deps *Deps) *cobra.Command {
 	}
 
 	subcommands := []*cobra.Command{
-		NewBacklinksCmd(deps),
-		NewCatCmd(deps),
+		NewAttachCmd(deps),
+		paged(NewBacklinksCmd(deps)),
+		NewBackupCmd(deps),
+		paged(NewCatCmd(deps)),
+		NewCloneCmd(deps),
+		NewClustersCmd(deps),
+		NewCommitCmd(deps),
 		NewCreateCmd(deps),
+		NewCronCmd(deps),
 		NewDoctorCmd(deps),
-		NewDocsCmd(deps),
+		paged(NewDocsCmd(deps)),
 		NewEditCmd(deps),
 		NewArchiveCmd(deps),
 		NewFileCmd(deps),
+		NewFindCmd(deps),
+		NewFmtCmd(deps),
+		NewGCCmd(deps),
 		NewGraphCmd(deps),
-		NewGrepCmd(deps),
+		paged(NewGrepCmd(deps)),
 		NewImageCmd(deps),
 		NewImportCmd(deps),
 		NewIndexCmd(deps),
 		NewInfoCmd(deps),
-		NewLinksCmd(deps),
-		NewListCmd(deps),
+		paged(NewLinksCmd(deps)),
+		paged(NewListCmd(deps)),
+		NewLintCmd(deps),
+		NewLockCmd(deps),
 		NewMcpCmd(deps),
+		NewMergeCmd(deps),
 		NewMetaCmd(deps),
 		NewMoveCmd(deps),
+		paged(NewRelatedCmd(deps)),
+		paged(NewSearchCmd(deps)),
 		NewSnapshotCmd(deps),
+		NewPruneCmd(deps),
 		NewPwdCmd(deps),
 		NewRemoveCmd(deps),
-		NewStatsCmd(deps),
-		NewTagsCmd(deps),
+		NewRevertCmd(deps),
+		NewRunCmd(deps),
+		paged(NewStatsCmd(deps)),
+		paged(NewTagsCmd(deps)),
+		NewUnarchiveCmd(deps),
+		NewUnlockCmd(deps),
+		NewVersionsCmd(deps),
 	}
 	if deps.Profile.IncludeConfigCommand {
-		subcommands = append(subcommands, NewConfigCmd(deps))
+		subcommands = append(subcommands, NewConfigCmd(deps), NewAliasCmd(deps))
 	}
 	var repoCmd *cobra.Command
 	if deps.Profile.IncludeRepoCommand {
 		repoCmd = NewRepoCmd(deps)
-		subcommands = append(subcommands, repoCmd)
+		subcommands = append(subcommands, repoCmd, NewRegistryCmd(deps), NewAuthCmd(deps), NewSyncCmd(deps), NewWhoamiCmd(deps))
 	}
 	cmd.AddCommand(subcommands...)
 	if repoCmd != nil {
 		filterRepoTargetFlagsInHelp(repoCmd)
 	}
+	markArgsErrorsAsUsage(cmd)
 
 	return cmd
 }
 
+// markArgsErrorsAsUsage wraps the positional argument validators of cmd and
+// its subcommands so a wrong number of arguments exits as a usage error.
+func markArgsErrorsAsUsage(cmd *cobra.Command) {
+	if validate := cmd.Args; validate != nil {
+		cmd.Args = func(c *cobra.Command, args []string) error {
+			if err := validate(c, args); err != nil {
+				return &usageError{err}
+			}
+			return nil
+		}
+	}
+	for _, child := range cmd.Commands() {
+		markArgsErrorsAsUsage(child)
+	}
+}
+
 func filterRepoTargetFlagsInHelp(cmd *cobra.Command) {
 	original := cmd.HelpFunc()
 	cmd.SetHelpFunc(func(c *cobra.Command, args []string) {
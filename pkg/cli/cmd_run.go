@@ -0,0 +1,52 @@
+package cli
+
+import (
+	"context"
+
+	"github.com/jlrickert/tapper/pkg/tapper"
+	"github.com/spf13/cobra"
+)
+
+// NewRunCmd returns the `run` cobra command.
+//
+// Usage examples:
+//
+//	tap run digest.star
+//	tap run retag.star old new --dry-run
+func NewRunCmd(deps *Deps) *cobra.Command {
+	var opts tapper.RunScriptOptions
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "run SCRIPT [ARG...]",
+		Short: "run a Starlark automation script against a keg",
+		Long: `Run a Starlark script with access to the keg.
+
+Starlark is a small Python dialect. Besides its built-ins a script can call
+query(expr, where), cat(id), create(title, body, tags, attrs), tag(id, *tags),
+untag(id, *tags) and set_meta(id, key, value); extra arguments are in args.
+Scripts cannot read files or run commands. print() writes to stdout.
+
+For example, to move every node tagged "todo" to "task":
+
+	for n in query("todo"):
+	    untag(n.id, "todo")
+	    tag(n.id, "task")
+
+With --dry-run the script runs against an in-memory copy of the keg and the
+files it would write or remove are listed after its output.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.Path = args[0]
+			opts.Args = args[1:]
+			opts.Output = cmd.OutOrStdout()
+			applyKegTargetProfile(deps, &opts.KegTargetOptions)
+			return runWithDryRun(cmd, deps, dryRun, func(ctx context.Context) error {
+				return deps.Tap.RunScript(ctx, opts)
+			})
+		},
+	}
+
+	addDryRunFlag(cmd, &dryRun)
+	return cmd
+}
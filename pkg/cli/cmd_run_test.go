@@ -0,0 +1,95 @@
+package cli_test
+
+import (
+	"strings"
+	"testing"
+
+	testutils "github.com/jlrickert/cli-toolkit/sandbox"
+	"github.com/stretchr/testify/require"
+)
+
+const retagScript = `
+old, new = args
+moved = []
+for n in query(old):
+    untag(n.id, old)
+    tag(n.id, new)
+    moved.append(n.title)
+print("moved: " + ", ".join(moved))
+`
+
+func TestRun_ScriptRetagsNodes(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("joe", "~"))
+	sb.MustWriteFile("~/retag.star", []byte(retagScript), 0o644)
+
+	res := NewProcess(t, false, "run", "~/retag.star", "planned", "later", "--keg", "personal").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	require.Equal(t, "moved: Sorry, planned but not yet available, Personal Overview\n", string(res.Stdout))
+
+	meta := string(sb.MustReadFile("~/kegs/personal/1/meta.yaml"))
+	require.Contains(t, meta, "later")
+	require.NotContains(t, meta, "planned")
+	tags := NewProcess(t, false, "tags", "later", "--keg", "personal", "--id-only").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, tags.Err)
+	require.Equal(t, []string{"0", "1"}, strings.Fields(string(tags.Stdout)))
+}
+
+func TestRun_ScriptCreatesDigest(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("joe", "~"))
+	sb.MustWriteFile("~/digest.star", []byte(`
+lines = ["# Weekly digest", ""]
+for n in query():
+    if n.id != "0":
+        lines.append("- [%s](../%s)" % (n.title, n.id))
+id = create(body="\n".join(lines) + "\n", tags=["digest"], attrs={"kind": "digest"})
+set_meta(id, "reviewed", False)
+print(id)
+print(cat(2).splitlines()[0])
+`), 0o644)
+
+	res := NewProcess(t, false, "run", "~/digest.star", "--keg", "personal").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	require.Equal(t, "4\n# Project Alpha\n", string(res.Stdout))
+
+	readme := string(sb.MustReadFile("~/kegs/personal/4/README.md"))
+	require.Contains(t, readme, "- [Project Alpha](../2)")
+	meta := string(sb.MustReadFile("~/kegs/personal/4/meta.yaml"))
+	require.Contains(t, meta, "kind: digest")
+	require.Contains(t, meta, "reviewed: false")
+	require.Contains(t, meta, "- digest")
+	links := NewProcess(t, false, "backlinks", "2", "--keg", "personal").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, links.Err)
+	require.Contains(t, string(links.Stdout), "Weekly digest")
+}
+
+func TestRun_DryRunLeavesKegUntouched(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("joe", "~"))
+	sb.MustWriteFile("~/retag.star", []byte(retagScript), 0o644)
+	before := string(sb.MustReadFile("~/kegs/personal/1/meta.yaml"))
+
+	res := NewProcess(t, false, "run", "~/retag.star", "planned", "later", "--keg", "personal", "--dry-run").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	out := string(res.Stdout)
+	require.True(t, strings.HasPrefix(out, "moved: "), out)
+	require.Contains(t, out, "would write 1/meta.yaml")
+	require.Contains(t, out, "would write dex/tags")
+	require.Equal(t, before, string(sb.MustReadFile("~/kegs/personal/1/meta.yaml")))
+}
+
+func TestRun_ScriptErrors(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("joe", "~"))
+	sb.MustWriteFile("~/bad.star", []byte("def f():\n    cat(99)\n\nf()\n"), 0o644)
+	sb.MustWriteFile("~/escape.star", []byte(`load("os.star", "system")`+"\n"), 0o644)
+
+	res := NewProcess(t, false, "run", "~/bad.star", "--keg", "personal").Run(sb.Context(), sb.Runtime())
+	require.Error(t, res.Err)
+	require.Contains(t, res.Err.Error(), "bad.star:4")
+	require.Contains(t, res.Err.Error(), "in f")
+
+	res = NewProcess(t, false, "run", "~/escape.star", "--keg", "personal").Run(sb.Context(), sb.Runtime())
+	require.Error(t, res.Err, "scripts cannot load modules")
+}
@@ -0,0 +1,101 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/jlrickert/tapper/pkg/tapper"
+	"github.com/spf13/cobra"
+)
+
+const (
+	searchMatchColor = "\x1b[1;31m"
+	ansiReset        = "\x1b[0m"
+)
+
+// NewSearchCmd returns the `search` cobra command.
+func NewSearchCmd(deps *Deps) *cobra.Command {
+	var opts tapper.SearchOptions
+	var jsonOut bool
+
+	cmd := &cobra.Command{
+		Use:   "search QUERY...",
+		Short: "search nodes by text or meaning",
+		Long: `Search nodes for QUERY, best match first.
+
+By default QUERY is matched literally and case-insensitively against node
+content, and hits are ranked by relevance.
+
+With --semantic, QUERY is embedded with the keg's search.embedder backend and
+nodes are ranked by cosine similarity. Node vectors are cached in
+dex/vectors.jsonl and refreshed when content changes.
+
+With --regex, QUERY is a regular expression and every matching line is
+printed under its node, with matches highlighted when writing to a terminal
+(set NO_COLOR to disable). --json prints text search results as JSON with the
+byte span of each match for tooling.
+
+Format placeholders: %i (node id), %d (date), %t (title), %s (similarity
+score, semantic only), %% (literal %). A format containing {{ is a Go
+template run per node with {{.Score}} holding the similarity score.
+
+With --all, every configured keg is searched with a plain text query and
+node IDs are printed as alias:id, which every node command accepts.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.Query = strings.Join(args, " ")
+			applyKegTargetProfile(deps, &opts.KegTargetOptions)
+
+			if opts.Semantic && (opts.Regex || jsonOut) {
+				return fmt.Errorf("--semantic cannot be combined with --regex or --json")
+			}
+			if opts.AllKegs && (opts.Semantic || opts.Regex || jsonOut) {
+				return fmt.Errorf("--all cannot be combined with --semantic, --regex or --json")
+			}
+			if jsonOut {
+				results, err := deps.Tap.SearchMatches(cmd.Context(), opts)
+				if err != nil {
+					return err
+				}
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+				return enc.Encode(results)
+			}
+
+			var lines []string
+			if opts.Regex {
+				results, err := deps.Tap.SearchMatches(cmd.Context(), opts)
+				if err != nil {
+					return err
+				}
+				open, close := "", ""
+				if deps.Runtime.Stream().IsTTY && !deps.Runtime.Has("NO_COLOR") {
+					open, close = searchMatchColor, ansiReset
+				}
+				lines = tapper.RenderSearchResults(results, opts, open, close)
+			} else {
+				var err error
+				lines, err = deps.Tap.Search(cmd.Context(), opts)
+				if err != nil {
+					return err
+				}
+			}
+			for _, line := range lines {
+				fmt.Fprintln(cmd.OutOrStdout(), line)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&opts.Semantic, "semantic", false, "rank nodes by embedding similarity")
+	cmd.Flags().BoolVarP(&opts.Regex, "regex", "e", false, "treat QUERY as a regular expression and show matching lines")
+	cmd.Flags().BoolVarP(&opts.IgnoreCase, "ignore-case", "i", false, "case-insensitive regex matching")
+	cmd.Flags().BoolVar(&jsonOut, "json", false, "print matches with spans as JSON")
+	cmd.Flags().IntVarP(&opts.Limit, "limit", "n", 10, "maximum number of results (0 for no limit)")
+	cmd.Flags().BoolVarP(&opts.IdOnly, "id-only", "", false, "show only ids")
+	cmd.Flags().StringVarP(&opts.Format, "format", "f", "", "output format")
+	cmd.Flags().BoolVar(&opts.AllKegs, "all", false, "search every configured keg, printing ids as alias:id")
+
+	return cmd
+}
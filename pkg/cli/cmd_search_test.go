@@ -0,0 +1,143 @@
+package cli_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	testutils "github.com/jlrickert/cli-toolkit/sandbox"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchCommand_PlainMatchesLiterally(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("joe", "~"))
+
+	res := NewProcess(t, false, "search", "project alpha", "--id-only").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	require.Contains(t, strings.Fields(string(res.Stdout)), "2")
+
+	res = NewProcess(t, false, "search", "project alpha", "--format", `{{.ID}}|{{.Title}}`).Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	require.Contains(t, string(res.Stdout), "2|Project Alpha\n")
+
+	missing := NewProcess(t, false, "search", "(unbalanced").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, missing.Err)
+	require.Empty(t, strings.TrimSpace(string(missing.Stdout)))
+}
+
+func TestSearchCommand_SemanticRanksBySimilarity(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("testuser", "~"))
+
+	// Embed each text by how often it mentions a few keywords.
+	keywords := []string{"gopher", "crab", "bread"}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Input []string `json:"input"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		data := make([]map[string]any, 0, len(req.Input))
+		for i, text := range req.Input {
+			vec := make([]float32, len(keywords))
+			for j, kw := range keywords {
+				vec[j] = float32(strings.Count(strings.ToLower(text), kw))
+			}
+			data = append(data, map[string]any{"index": i, "embedding": vec})
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"data": data})
+	}))
+	defer srv.Close()
+
+	for _, title := range []string{"Gopher Patterns", "Crab Care", "Bread Baking"} {
+		res := NewProcess(t, false, "create", "--title", title).Run(sb.Context(), sb.Runtime())
+		require.NoError(t, res.Err)
+	}
+
+	cfg := "kegv: 2025-07\ntitle: Semantic\nsearch:\n  embedder:\n    kind: openai\n    url: " + srv.URL + "\n"
+	res := NewProcess(t, false, "config", "edit").RunWithIO(sb.Context(), sb.Runtime(), strings.NewReader(cfg))
+	require.NoError(t, res.Err)
+
+	res = NewProcess(t, false, "search", "--semantic", "a gopher").Run(sb.Context(), sb.Runtime())
+	require.Error(t, res.Err, "the embedder is not used before the keg is trusted")
+	require.Contains(t, res.Err.Error(), "tap repo trust")
+	res = NewProcess(t, false, "repo", "trust").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err, string(res.Stderr))
+
+	res = NewProcess(t, false, "search", "--semantic", "a gopher", "--limit", "1", "--format", "%t").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	require.Equal(t, "Gopher Patterns", strings.TrimSpace(string(res.Stdout)))
+
+	res = NewProcess(t, false, "search", "--semantic", "a gopher", "--limit", "1", "--format", `{{printf "%.1f" .Score}} {{.Title}}`).Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	require.Equal(t, "1.0 Gopher Patterns", strings.TrimSpace(string(res.Stdout)))
+
+	index := NewProcess(t, false, "index", "get", "vectors.jsonl").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, index.Err)
+	require.Contains(t, string(index.Stdout), `"vector"`)
+}
+
+func TestSearchCommand_SemanticRequiresEmbedder(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("joe", "~"))
+
+	res := NewProcess(t, false, "search", "--semantic", "anything").Run(sb.Context(), sb.Runtime())
+	require.Error(t, res.Err)
+	require.Contains(t, res.Err.Error(), "semantic search unavailable")
+}
+
+func TestSearchCommand_RegexPrintsMatchingLines(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("joe", "~"))
+
+	res := NewProcess(t, false, "search", "--regex", `Project \w+`).Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	require.Equal(t, strings.Join([]string{
+		"1 Personal Overview",
+		"7:- [Project Alpha](../2)",
+		"",
+		"2 Project Alpha",
+		"1:# Project Alpha",
+		"",
+		"3 Meeting Notes",
+		"3:Notes from various meetings. Part of [Project Alpha](../2).",
+	}, "\n"), strings.TrimSpace(string(res.Stdout)))
+
+	tty := NewProcess(t, true, "search", "--regex", "-i", "ALPHA", "--limit", "1").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, tty.Err)
+	require.Contains(t, string(tty.Stdout), "7:- [Project \x1b[1;31mAlpha\x1b[0m](../2)")
+}
+
+func TestSearchCommand_JSONIncludesSpans(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("joe", "~"))
+
+	res := NewProcess(t, false, "search", "--regex", "--json", `\.\./3`).Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+
+	var results []struct {
+		ID      string `json:"id"`
+		Matches []struct {
+			Line  int `json:"line"`
+			Spans []struct {
+				Start int `json:"start"`
+				End   int `json:"end"`
+			} `json:"spans"`
+		} `json:"matches"`
+	}
+	require.NoError(t, json.Unmarshal(res.Stdout, &results))
+	ids := make([]string, 0, len(results))
+	for _, r := range results {
+		ids = append(ids, r.ID)
+	}
+	require.Equal(t, []string{"1", "2"}, ids)
+	last := results[1].Matches[len(results[1].Matches)-1]
+	require.Equal(t, 6, last.Line)
+	require.Equal(t, 11, last.Spans[0].Start)
+	require.Equal(t, 15, last.Spans[0].End)
+
+	bad := NewProcess(t, false, "search", "--semantic", "--json", "x").Run(sb.Context(), sb.Runtime())
+	require.Error(t, bad.Err)
+}
@@ -1,10 +1,8 @@
 package cli
 
 import (
-	"bufio"
-	"errors"
+	"context"
 	"fmt"
-	"io"
 	"strings"
 	"text/tabwriter"
 
@@ -43,8 +41,9 @@ func NewSnapshotCreateCmd(deps *Deps) *cobra.Command {
 	var opts tapper.NodeSnapshotOptions
 
 	cmd := &cobra.Command{
-		Use:   "create NODE_ID",
-		Short: "create a snapshot for the current node state",
+		Use:         "create NODE_ID",
+		Annotations: nodeArgAnnotations(1),
+		Short:       "create a snapshot for the current node state",
 		Example: strings.TrimSpace(`
 tap snapshot create 12 --keg personal -m "before refactor"
 kegv2 snapshot create 12 -m "before refactor"
@@ -70,9 +69,10 @@ func NewSnapshotHistoryCmd(deps *Deps) *cobra.Command {
 	var opts tapper.NodeHistoryOptions
 
 	cmd := &cobra.Command{
-		Use:   "history NODE_ID",
-		Short: "list snapshots for a node",
-		Args:  cobra.ExactArgs(1),
+		Use:         "history NODE_ID",
+		Annotations: nodeArgAnnotations(1),
+		Short:       "list snapshots for a node",
+		Args:        cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			applyKegTargetProfile(deps, &opts.KegTargetOptions)
 			opts.NodeID = args[0]
@@ -99,59 +99,36 @@ func NewSnapshotHistoryCmd(deps *Deps) *cobra.Command {
 }
 
 func NewSnapshotRestoreCmd(deps *Deps) *cobra.Command {
-	var (
-		opts tapper.NodeRestoreOptions
-		yes  bool
-	)
+	var opts tapper.NodeRestoreOptions
+	var dryRun bool
 
 	cmd := &cobra.Command{
-		Use:   "restore NODE_ID REV",
-		Short: "restore a node to a prior snapshot",
-		Args:  cobra.ExactArgs(2),
+		Use:         "restore NODE_ID REV",
+		Annotations: nodeArgAnnotations(1),
+		Short:       "restore a node to a prior snapshot",
+		Args:        cobra.ExactArgs(2),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			applyKegTargetProfile(deps, &opts.KegTargetOptions)
 			opts.NodeID = args[0]
 			opts.Rev = args[1]
 
-			if !yes {
-				if !deps.Runtime.Stream().IsTTY {
-					return fmt.Errorf("restore requires confirmation; rerun with --yes")
-				}
-				ok, err := confirmSnapshotRestore(cmd, opts.NodeID, opts.Rev)
-				if err != nil {
+			if !dryRun {
+				question := fmt.Sprintf("Restore node %s to revision %s?", opts.NodeID, opts.Rev)
+				if err := confirm(cmd, deps, "restore", true, question); err != nil {
 					return err
 				}
-				if !ok {
-					return fmt.Errorf("restore canceled")
-				}
 			}
 
-			return deps.Tap.NodeRestore(cmd.Context(), opts)
+			return runWithDryRun(cmd, deps, dryRun, func(ctx context.Context) error {
+				return deps.Tap.NodeRestore(ctx, opts)
+			})
 		},
 	}
 
-	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "skip confirmation")
+	addDryRunFlag(cmd, &dryRun)
 	return cmd
 }
 
-func confirmSnapshotRestore(cmd *cobra.Command, nodeID string, rev string) (bool, error) {
-	_, err := fmt.Fprintf(cmd.ErrOrStderr(), "Restore node %s to revision %s? [y/N]: ", nodeID, rev)
-	if err != nil {
-		return false, err
-	}
-
-	line, err := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
-	if err != nil && !errors.Is(err, io.EOF) {
-		return false, err
-	}
-	switch strings.ToLower(strings.TrimSpace(line)) {
-	case "y", "yes":
-		return true, nil
-	default:
-		return false, nil
-	}
-}
-
 func shortHash(value string) string {
 	if len(value) <= 8 {
 		return value
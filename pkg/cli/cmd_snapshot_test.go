@@ -349,6 +349,96 @@ func TestArchiveImportCommand_FailsWhenHistoryIndexMissing(t *testing.T) {
 	require.Contains(t, string(res.Stderr), "missing snapshots/index.json")
 }
 
+func TestArchiveExportCommand_AppendsBacklinksFromKegConfig(t *testing.T) {
+	t.Parallel()
+
+	sb := NewSandbox(t,
+		testutils.WithFixture("joe", "~"),
+		testutils.WithWd("~/kegs/personal"),
+	)
+
+	res := NewProcess(t, false, "archive", "export", "--keg", "personal", "--nodes", "1", "--no-history", "-o", "~/plain.tar.gz").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	require.NotContains(t, readArchiveFile(t, sb.MustReadFile("~/plain.tar.gz"), "keg-archive/nodes/1/README.md"), "## Backlinks")
+
+	cfg := `kegv: 2025-07
+title: Personal
+export:
+  backlinks: true
+  backlinksHeading: Referenced by
+`
+	res = NewProcess(t, false, "config", "edit", "--keg", "personal").RunWithIO(sb.Context(), sb.Runtime(), strings.NewReader(cfg))
+	require.NoError(t, res.Err)
+
+	res = NewProcess(t, false, "archive", "export", "--keg", "personal", "--nodes", "1,2", "--no-history", "-o", "~/published.tar.gz").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	archive := sb.MustReadFile("~/published.tar.gz")
+
+	readme := readArchiveFile(t, archive, "keg-archive/nodes/1/README.md")
+	require.Contains(t, readme, "\n\n<!-- keg:backlinks -->\n## Referenced by\n\n- [Project Alpha](../2)")
+	require.True(t, strings.HasSuffix(readme, "\n"))
+
+	readme = readArchiveFile(t, archive, "keg-archive/nodes/2/README.md")
+	require.Contains(t, readme, "- [Personal Overview](../1)")
+	require.Contains(t, readme, "- [Meeting Notes](../3)")
+
+	res = NewProcess(t, false, "archive", "export", "--keg", "personal", "--nodes", "1", "--no-history", "--backlinks=false", "-o", "~/override.tar.gz").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	require.NotContains(t, readArchiveFile(t, sb.MustReadFile("~/override.tar.gz"), "keg-archive/nodes/1/README.md"), "Referenced by")
+}
+
+func TestArchiveImportCommand_StripsExportedBacklinks(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("testuser", "~"))
+
+	for _, args := range [][]string{
+		{"--title", "Target", "--body", "# Target\n\nLinked to.\n"},
+		{"--title", "Source", "--body", "# Source\n\nSee [target](../1).\n"},
+	} {
+		res := NewProcess(t, false, append([]string{"create"}, args...)...).Run(sb.Context(), sb.Runtime())
+		require.NoError(t, res.Err)
+	}
+
+	for range 2 {
+		res := NewProcess(t, false, "archive", "export", "--backlinks", "-o", "~/out.keg.tar.gz").Run(sb.Context(), sb.Runtime())
+		require.NoError(t, res.Err)
+		exported := readArchiveFile(t, sb.MustReadFile("~/out.keg.tar.gz"), "keg-archive/nodes/1/README.md")
+		require.Contains(t, exported, "## Backlinks\n\n- [Source](../2)")
+
+		res = NewProcess(t, false, "archive", "import", "~/out.keg.tar.gz").Run(sb.Context(), sb.Runtime())
+		require.NoError(t, res.Err)
+		require.Equal(t, "# Target\n\nLinked to.\n", string(sb.MustReadFile("~/kegs/example/1/README.md")))
+	}
+
+	res := NewProcess(t, false, "links", "1").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	require.Empty(t, string(res.Stdout), "the imported node gains no links from the section")
+}
+
+func readArchiveFile(t *testing.T, archive []byte, path string) string {
+	t.Helper()
+
+	gzr, err := gzip.NewReader(bytes.NewReader(archive))
+	require.NoError(t, err)
+	defer gzr.Close()
+
+	tr := tar.NewReader(gzr)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		if header.Name == path {
+			payload, err := io.ReadAll(tr)
+			require.NoError(t, err)
+			return string(payload)
+		}
+	}
+	t.Fatalf("archive entry %s not found", path)
+	return ""
+}
+
 func dropArchivePath(t *testing.T, archive []byte, dropPath string) []byte {
 	t.Helper()
 
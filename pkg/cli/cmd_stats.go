@@ -2,7 +2,9 @@ package cli
 
 import (
 	"fmt"
+	"text/tabwriter"
 
+	"github.com/jlrickert/tapper/pkg/keg"
 	"github.com/jlrickert/tapper/pkg/tapper"
 	"github.com/spf13/cobra"
 )
@@ -10,19 +12,60 @@ import (
 // NewStatsCmd returns the `stats` cobra command.
 func NewStatsCmd(deps *Deps) *cobra.Command {
 	var opts tapper.StatsOptions
+	var storage bool
+	var all bool
+	var allOpts tapper.AllStatsOptions
 
 	cmd := &cobra.Command{
-		Use:   "stats NODE_ID",
+		Use:   "stats [NODE_ID]",
 		Short: "display node stats",
 		Long: `Display programmatic stats (stats.json) for a node.
 
 Stats include title, lead, content hash, timestamps (created, updated,
-accessed), links, and access count.`,
-		Args:              cobra.ExactArgs(1),
+accessed), links, and access count.
+
+With --storage, report the bytes used by content, images and attachments for
+every node (largest first) or only NODE_ID, followed by keg totals and any
+quotas configured in the keg config.
+
+With --all, merge the stats of every configured keg into one report: node
+and tag counts, storage, and a sparkline of nodes updated per week over the
+last --weeks weeks, followed by totals across kegs.
+
+With --output json|yaml|tsv, stats are emitted as a record with the fields id,
+title, lead, hash, created, updated, accessed, access_count and links. With
+--storage each node is a record with id, content, images, attachments and
+total sizes in bytes; totals and quotas are omitted. With --all each keg is
+a record with alias, nodes, tags, storage (bytes), activity (weekly counts,
+oldest first) and error.`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if all {
+				return cobra.NoArgs(cmd, args)
+			}
+			if storage {
+				return cobra.MaximumNArgs(1)(cmd, args)
+			}
+			return cobra.ExactArgs(1)(cmd, args)
+		},
 		ValidArgsFunction: nodeIDCompletionFunc(deps, 1),
+		Annotations:       nodeArgAnnotations(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if all {
+				return runAllStats(cmd, deps, allOpts)
+			}
+			if storage {
+				return runStorageStats(cmd, deps, opts, args)
+			}
 			opts.NodeID = args[0]
 			applyKegTargetProfile(deps, &opts.KegTargetOptions)
+			if deps.Output != OutputHuman {
+				id, stats, err := deps.Tap.NodeStats(cmd.Context(), opts)
+				if err != nil {
+					return err
+				}
+				records := []statsRecord{{ID: id.Path(), statsFields: newStatsFields(stats)}}
+				return writeOutput(cmd.OutOrStdout(), deps.Output, records)
+			}
 
 			output, err := deps.Tap.Stats(cmd.Context(), opts)
 			if err != nil {
@@ -34,5 +77,131 @@ accessed), links, and access count.`,
 		},
 	}
 
+	cmd.Flags().BoolVar(&opts.Exact, "exact", false, "disable fuzzy title matching for node arguments")
+	cmd.Flags().BoolVar(&storage, "storage", false, "report storage used by content, images and attachments")
+	cmd.Flags().BoolVar(&all, "all", false, "merge stats of every configured keg into one report")
+	cmd.Flags().IntVar(&allOpts.Weeks, "weeks", tapper.DefaultActivityWeeks, "weeks of activity shown with --all")
+
 	return cmd
 }
+
+func runStorageStats(cmd *cobra.Command, deps *Deps, stats tapper.StatsOptions, args []string) error {
+	opts := tapper.StorageOptions{KegTargetOptions: stats.KegTargetOptions, Exact: stats.Exact}
+	if len(args) > 0 {
+		opts.NodeID = args[0]
+	}
+	applyKegTargetProfile(deps, &opts.KegTargetOptions)
+	res, err := deps.Tap.Storage(cmd.Context(), opts)
+	if err != nil {
+		return err
+	}
+	if deps.Output != OutputHuman {
+		records := make([]storageRecord, 0, len(res.Nodes))
+		for _, n := range res.Nodes {
+			records = append(records, storageRecord{
+				ID:          n.ID.Path(),
+				Content:     n.Content,
+				Images:      n.Images,
+				Attachments: n.Attachments,
+				Total:       n.Total(),
+			})
+		}
+		return writeOutput(cmd.OutOrStdout(), deps.Output, records)
+	}
+
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NODE\tCONTENT\tIMAGES\tATTACHMENTS\tTOTAL")
+	row := func(label string, s keg.NodeStorage) {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", label,
+			keg.FormatByteSize(s.Content), keg.FormatByteSize(s.Images),
+			keg.FormatByteSize(s.Attachments), keg.FormatByteSize(s.Total()))
+	}
+	for _, n := range res.Nodes {
+		row(n.ID.Path(), n)
+	}
+	if opts.NodeID == "" {
+		row("total", res.Totals)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if q := res.Quotas; q != nil && opts.NodeID == "" {
+		mode := "warn"
+		if q.Enforce {
+			mode = "enforce"
+		}
+		for _, quota := range []struct{ scope, limit string }{{"keg", q.Keg}, {"node", q.Node}, {"file", q.File}} {
+			if quota.limit != "" {
+				fmt.Fprintf(cmd.OutOrStdout(), "quota %s: %s (%s)\n", quota.scope, quota.limit, mode)
+			}
+		}
+	}
+	return nil
+}
+
+func runAllStats(cmd *cobra.Command, deps *Deps, opts tapper.AllStatsOptions) error {
+	res, err := deps.Tap.AllStats(cmd.Context(), opts)
+	if err != nil {
+		return err
+	}
+	if deps.Output != OutputHuman {
+		records := make([]kegAggregateRecord, 0, len(res.Kegs))
+		for _, k := range res.Kegs {
+			records = append(records, kegAggregateRecord{
+				Alias:    k.Alias,
+				Nodes:    k.Nodes,
+				Tags:     k.Tags,
+				Storage:  k.Storage.Total(),
+				Activity: k.Activity,
+				Error:    k.Error,
+			})
+		}
+		return writeOutput(cmd.OutOrStdout(), deps.Output, records)
+	}
+	if len(res.Kegs) == 0 {
+		return fmt.Errorf("no kegs configured")
+	}
+
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KEG\tNODES\tTAGS\tSTORAGE\tACTIVITY")
+	for _, k := range res.Kegs {
+		if k.Error != "" {
+			fmt.Fprintf(w, "%s\t-\t-\t-\t-\n", k.Alias)
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%d\t%d\t%s\t%s\n", k.Alias, k.Nodes, k.Tags,
+			keg.FormatByteSize(k.Storage.Total()), sparkline(k.Activity))
+	}
+	fmt.Fprintf(w, "total\t%d\t%d\t%s\t%s\n", res.Nodes, res.Tags,
+		keg.FormatByteSize(res.Storage.Total()), sparkline(res.Activity))
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	for _, k := range res.Kegs {
+		if k.Error != "" {
+			fmt.Fprintf(cmd.ErrOrStderr(), "%s: %s\n", k.Alias, k.Error)
+		}
+	}
+	return nil
+}
+
+var sparkTicks = []rune("▁▂▃▄▅▆▇█")
+
+// sparkline renders counts as a row of block characters scaled to the
+// largest count. Empty buckets show as the lowest tick.
+func sparkline(counts []int) string {
+	peak := 0
+	for _, n := range counts {
+		peak = max(peak, n)
+	}
+	out := make([]rune, len(counts))
+	for i, n := range counts {
+		level := 0
+		if peak > 0 {
+			level = n * (len(sparkTicks) - 1) / peak
+		}
+		out[i] = sparkTicks[level]
+	}
+	return string(out)
+}
@@ -1,9 +1,12 @@
 package cli_test
 
 import (
+	"encoding/json"
+	"strings"
 	"testing"
 
 	testutils "github.com/jlrickert/cli-toolkit/sandbox"
+	"github.com/jlrickert/tapper/pkg/keg"
 	"github.com/stretchr/testify/require"
 )
 
@@ -67,3 +70,88 @@ func TestStatsCommand_WithJoeFixture(t *testing.T) {
 	require.Contains(t, out, "hash:")
 	require.Contains(t, out, "updated:")
 }
+
+func TestStatsCommand_Storage(t *testing.T) {
+	t.Parallel()
+	sb := imageFixture(t)
+	png := sb.MustReadFile("~/test-images/default.png")
+
+	res := NewProcess(t, false, "create", "--title", "Gallery").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	id := strings.TrimSpace(string(res.Stdout))
+	res = NewProcess(t, false, "image", "upload", id, "~/test-images/default.png").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+
+	res = NewProcess(t, false, "stats", "--storage").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err, string(res.Stderr))
+	lines := strings.Split(strings.TrimSpace(string(res.Stdout)), "\n")
+	require.Equal(t, []string{"NODE", "CONTENT", "IMAGES", "ATTACHMENTS", "TOTAL"}, strings.Fields(lines[0]))
+	require.Len(t, lines, 4)
+	require.Equal(t, id, strings.Fields(lines[1])[0], "largest node listed first")
+	require.Equal(t, keg.FormatByteSize(int64(len(png))), strings.Fields(lines[1])[2])
+	require.Equal(t, "total", strings.Fields(lines[len(lines)-1])[0])
+
+	res = NewProcess(t, false, "stats", "--storage", "0").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	lines = strings.Split(strings.TrimSpace(string(res.Stdout)), "\n")
+	require.Len(t, lines, 2)
+	require.Equal(t, "0", strings.Fields(lines[1])[0])
+	require.Equal(t, "0B", strings.Fields(lines[1])[2])
+}
+
+func TestUploadQuota_WarnsOrFails(t *testing.T) {
+	t.Parallel()
+	sb := imageFixture(t)
+	cfg := sb.MustReadFile("~/kegs/example/keg")
+	sb.MustWriteFile("~/kegs/example/keg", append(cfg, "quotas:\n    file: 1KB\n"...), 0o644)
+
+	res := NewProcess(t, false, "image", "upload", "0", "~/test-images/default.png").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	require.Contains(t, string(res.Stderr), "warning: upload to node 0 exceeds the file quota of 1.0KB")
+
+	res = NewProcess(t, false, "stats", "--storage").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	require.Contains(t, string(res.Stdout), "quota file: 1KB (warn)")
+
+	sb.MustWriteFile("~/kegs/example/keg", append(cfg, "quotas:\n    file: 1KB\n    enforce: true\n"...), 0o644)
+	res = NewProcess(t, false, "image", "upload", "0", "~/test-images/default.png", "--name", "second.png").
+		Run(sb.Context(), sb.Runtime())
+	require.Error(t, res.Err)
+	require.Contains(t, res.Err.Error(), "storage quota exceeded")
+	_, err := sb.ReadFile("~/kegs/example/0/images/second.png")
+	require.Error(t, err)
+}
+
+func TestStatsAll_MergesEveryKeg(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("joe", "~"))
+
+	res := NewProcess(t, false, "stats", "--all", "--weeks", "4", "--output", "json").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err, string(res.Stderr))
+
+	var records []struct {
+		Alias    string `json:"alias"`
+		Nodes    int    `json:"nodes"`
+		Storage  int64  `json:"storage"`
+		Activity []int  `json:"activity"`
+		Error    string `json:"error"`
+	}
+	require.NoError(t, json.Unmarshal(res.Stdout, &records))
+	require.Len(t, records, 3)
+	for _, r := range records {
+		if r.Alias != "personal" {
+			continue
+		}
+		require.Empty(t, r.Error)
+		require.Equal(t, 4, r.Nodes)
+		require.Positive(t, r.Storage)
+		require.Len(t, r.Activity, 4)
+	}
+
+	res = NewProcess(t, false, "stats", "--all").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err, string(res.Stderr))
+	out := string(res.Stdout)
+	require.Contains(t, out, "ACTIVITY")
+	require.Contains(t, out, "personal")
+	require.Contains(t, out, "total")
+}
@@ -0,0 +1,97 @@
+package cli
+
+import (
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/jlrickert/tapper/pkg/keg"
+	"github.com/jlrickert/tapper/pkg/tapper"
+	"github.com/spf13/cobra"
+)
+
+// pendingWriteRecord is the structured form of one `sync --list` row.
+type pendingWriteRecord struct {
+	Op     string `json:"op" yaml:"op"`
+	Node   string `json:"node,omitempty" yaml:"node,omitempty"`
+	Index  string `json:"index,omitempty" yaml:"index,omitempty"`
+	Queued string `json:"queued" yaml:"queued"`
+}
+
+func NewSyncCmd(deps *Deps) *cobra.Command {
+	var (
+		opts tapper.SyncOptions
+		list bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "sync",
+		Short: "push changes made to a registry keg while offline",
+		Long: `Registry kegs keep a local cache. When the registry cannot be reached,
+reads are served from the cache and node, index and config changes are
+queued. Run sync once the registry is back to push the queued changes.
+
+A queued node change whose registry copy changed in the meantime is
+reported as a conflict and stays queued; --force pushes the local copy
+anyway. --merge runs the mergeTool from the user config on each conflict
+with $BASE, $LOCAL, $REMOTE and $MERGED naming temp files, and pushes the
+nodes it resolves. --list shows the queue without pushing.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			applyKegTargetProfile(deps, &opts.KegTargetOptions)
+			if list {
+				pending, err := deps.Tap.SyncPending(cmd.Context(), opts.KegTargetOptions)
+				if err != nil {
+					return err
+				}
+				if deps.Output != OutputHuman {
+					records := make([]pendingWriteRecord, 0, len(pending))
+					for _, p := range pending {
+						records = append(records, pendingWriteRecord{
+							Op:     p.Op,
+							Node:   p.Node,
+							Index:  p.Index,
+							Queued: formatRecordTime(p.Queued),
+						})
+					}
+					return writeOutput(cmd.OutOrStdout(), deps.Output, records)
+				}
+				w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+				fmt.Fprintln(w, "OP\tTARGET\tQUEUED")
+				for _, p := range pending {
+					target := p.Node
+					if target == "" {
+						target = p.Index
+					}
+					if target == "" {
+						target = "-"
+					}
+					fmt.Fprintf(w, "%s\t%s\t%s\n", p.Op, target, formatStatusTime(p.Queued))
+				}
+				return w.Flush()
+			}
+
+			result, err := deps.Tap.Sync(cmd.Context(), opts)
+			if result != nil {
+				for _, node := range result.Merged {
+					fmt.Fprintf(cmd.OutOrStdout(), "merged: node %s\n", node)
+				}
+				for _, c := range result.Conflicts {
+					fmt.Fprintf(cmd.OutOrStdout(), "conflict: node %s %s\n", c.Node, c.Reason)
+				}
+				fmt.Fprintf(cmd.ErrOrStderr(), "pushed %d changes, %d still queued\n", result.Pushed, result.Pending)
+			}
+			if err != nil {
+				return err
+			}
+			if n := len(result.Conflicts); n > 0 {
+				return fmt.Errorf("%d conflicting changes left queued; rerun with --merge to merge them or --force to overwrite the registry: %w", n, keg.ErrConflict)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&opts.Force, "force", false, "push local changes over registry changes")
+	cmd.Flags().BoolVar(&opts.Merge, "merge", false, "resolve conflicts with the configured mergeTool")
+	cmd.MarkFlagsMutuallyExclusive("force", "merge")
+	cmd.Flags().BoolVar(&list, "list", false, "list queued changes without pushing")
+	return cmd
+}
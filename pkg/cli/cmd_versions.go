@@ -0,0 +1,97 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/jlrickert/tapper/pkg/tapper"
+	"github.com/spf13/cobra"
+)
+
+// NewVersionsCmd returns the `versions` cobra command.
+//
+// Usage examples:
+//
+//	tap versions 12
+//	tap versions "project alpha" --keg personal
+func NewVersionsCmd(deps *Deps) *cobra.Command {
+	var opts tapper.VersionsOptions
+
+	cmd := &cobra.Command{
+		Use:   "versions NODE_ID",
+		Short: "list prior content versions of a node",
+		Long: `List prior content versions of a node, oldest first.
+
+A version is recorded whenever a node's content hash changes. Restore one
+with "tap revert NODE_ID VERSION".`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: nodeIDCompletionFunc(deps, 1),
+		Annotations:       nodeArgAnnotations(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			applyKegTargetProfile(deps, &opts.KegTargetOptions)
+			opts.NodeID = args[0]
+
+			versions, err := deps.Tap.Versions(cmd.Context(), opts)
+			if err != nil {
+				return err
+			}
+
+			tw := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+			fmt.Fprintln(tw, "VERSION\tCREATED\tHASH\tSIZE")
+			for _, v := range versions {
+				fmt.Fprintf(tw, "%d\t%s\t%s\t%d\n",
+					v.Number,
+					v.Created.Format("2006-01-02 15:04:05"),
+					shortHash(v.Hash),
+					v.Size,
+				)
+			}
+			return tw.Flush()
+		},
+	}
+
+	cmd.Flags().BoolVar(&opts.Exact, "exact", false, "disable fuzzy title matching for node arguments")
+	return cmd
+}
+
+// NewRevertCmd returns the `revert` cobra command.
+//
+// Usage examples:
+//
+//	tap revert 12 3
+//	tap revert 12 a1b2c3d4
+func NewRevertCmd(deps *Deps) *cobra.Command {
+	var opts tapper.RevertOptions
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "revert NODE_ID VERSION",
+		Short: "roll a node back to a prior content version",
+		Long: `Roll a node's content back to a version listed by "tap versions".
+
+VERSION is a version number or a unique prefix of its hash. The content being
+replaced is recorded as a new version, so a revert can itself be reverted.`,
+		Args:              cobra.ExactArgs(2),
+		ValidArgsFunction: nodeIDCompletionFunc(deps, 1),
+		Annotations:       nodeArgAnnotations(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			applyKegTargetProfile(deps, &opts.KegTargetOptions)
+			opts.NodeID = args[0]
+			opts.Version = args[1]
+
+			return runWithDryRun(cmd, deps, dryRun, func(ctx context.Context) error {
+				version, err := deps.Tap.Revert(ctx, opts)
+				if err != nil {
+					return err
+				}
+				_, err = fmt.Fprintf(cmd.OutOrStdout(), "reverted node %s to version %d (%s)\n", args[0], version.Number, shortHash(version.Hash))
+				return err
+			})
+		},
+	}
+
+	addDryRunFlag(cmd, &dryRun)
+	cmd.Flags().BoolVar(&opts.Exact, "exact", false, "disable fuzzy title matching for node arguments")
+	return cmd
+}
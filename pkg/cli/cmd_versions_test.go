@@ -0,0 +1,51 @@
+package cli_test
+
+import (
+	"strings"
+	"testing"
+
+	testutils "github.com/jlrickert/cli-toolkit/sandbox"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVersionsAndRevert_RollBackEditedContent(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("joe", "~"))
+	require.NoError(t, sb.Runtime().Set("EDITOR", "/bin/false"))
+	sb.Runtime().Unset("VISUAL")
+	original := string(sb.MustReadFile("~/kegs/personal/3/README.md"))
+
+	res := NewProcess(t, false, "versions", "3").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	require.Equal(t, []string{"VERSION", "CREATED", "HASH", "SIZE"}, strings.Fields(string(res.Stdout)))
+
+	res = NewProcess(t, false, "edit", "3").RunWithIO(sb.Context(), sb.Runtime(), strings.NewReader("# Rewritten Notes\n"))
+	require.NoError(t, res.Err)
+
+	res = NewProcess(t, false, "versions", "3").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	lines := strings.Split(strings.TrimSpace(string(res.Stdout)), "\n")
+	require.Len(t, lines, 2)
+	require.True(t, strings.HasPrefix(lines[1], "1 "))
+
+	files := NewProcess(t, false, "file", "ls", "3").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, files.Err)
+	require.NotContains(t, string(files.Stdout), ".versions")
+
+	res = NewProcess(t, false, "revert", "3", "1").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	require.Contains(t, string(res.Stdout), "reverted node 3 to version 1")
+	require.Equal(t, original, string(sb.MustReadFile("~/kegs/personal/3/README.md")))
+
+	res = NewProcess(t, false, "versions", "3").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	require.Len(t, strings.Split(strings.TrimSpace(string(res.Stdout)), "\n"), 3)
+
+	res = NewProcess(t, false, "cat", "3", "--content-only").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	require.Contains(t, string(res.Stdout), "# Meeting Notes")
+
+	res = NewProcess(t, false, "revert", "3", "9").Run(sb.Context(), sb.Runtime())
+	require.Error(t, res.Err)
+	require.Contains(t, res.Err.Error(), "version 9 not found")
+}
@@ -0,0 +1,106 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/jlrickert/tapper/pkg/keg"
+	"github.com/jlrickert/tapper/pkg/registry"
+	"github.com/jlrickert/tapper/pkg/tapper"
+	"github.com/spf13/cobra"
+)
+
+// whoamiRecord is the structured form of one registry in `whoami`.
+type whoamiRecord struct {
+	Registry   string          `json:"registry" yaml:"registry"`
+	Url        string          `json:"url" yaml:"url"`
+	User       string          `json:"user,omitempty" yaml:"user,omitempty"`
+	Name       string          `json:"name,omitempty" yaml:"name,omitempty"`
+	Namespaces []string        `json:"namespaces,omitempty" yaml:"namespaces,omitempty"`
+	Quota      *registry.Quota `json:"quota,omitempty" yaml:"quota,omitempty"`
+	Error      string          `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+func NewWhoamiCmd(deps *Deps) *cobra.Command {
+	var opts tapper.WhoamiOptions
+
+	cmd := &cobra.Command{
+		Use:   "whoami",
+		Short: "show the registry account tapper acts as",
+		Long: `Ask each configured registry which account its token belongs to, the
+namespaces that account can publish kegs under, and its quota and limits.
+Registries without a token are listed as not logged in.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ids, err := deps.Tap.Whoami(cmd.Context(), opts)
+			if err != nil {
+				return err
+			}
+			if deps.Output != OutputHuman {
+				records := make([]whoamiRecord, 0, len(ids))
+				for _, id := range ids {
+					rec := whoamiRecord{Registry: id.Registry, Url: id.Url}
+					if id.Identity != nil {
+						rec.User = id.Identity.User
+						rec.Name = id.Identity.Name
+						rec.Namespaces = id.Identity.Namespaces
+						rec.Quota = &id.Identity.Quota
+					}
+					if id.Err != nil {
+						rec.Error = id.Err.Error()
+					}
+					records = append(records, rec)
+				}
+				return writeOutput(cmd.OutOrStdout(), deps.Output, records)
+			}
+
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+			for i, id := range ids {
+				if i > 0 {
+					fmt.Fprintln(w)
+				}
+				fmt.Fprintf(w, "registry:\t%s (%s)\n", id.Registry, id.Url)
+				switch {
+				case id.Err != nil:
+					fmt.Fprintf(w, "error:\t%s\n", id.Err)
+					continue
+				case id.Identity == nil:
+					fmt.Fprintf(w, "user:\tnot logged in\n")
+					continue
+				}
+				me := id.Identity
+				user := me.User
+				if me.Name != "" {
+					user += " (" + me.Name + ")"
+				}
+				fmt.Fprintf(w, "user:\t%s\n", user)
+				if len(me.Namespaces) > 0 {
+					fmt.Fprintf(w, "namespaces:\t%s\n", strings.Join(me.Namespaces, ", "))
+				}
+				q := me.Quota
+				fmt.Fprintf(w, "kegs:\t%s\n", quotaUsage(strconv.Itoa(q.Kegs), q.MaxKegs > 0, strconv.Itoa(q.MaxKegs)))
+				fmt.Fprintf(w, "storage:\t%s\n", quotaUsage(keg.FormatByteSize(q.Storage), q.MaxStorage > 0, keg.FormatByteSize(q.MaxStorage)))
+				if q.MaxNodeSize > 0 {
+					fmt.Fprintf(w, "max node size:\t%s\n", keg.FormatByteSize(q.MaxNodeSize))
+				}
+				if q.RateLimit > 0 {
+					fmt.Fprintf(w, "rate limit:\t%d requests/min\n", q.RateLimit)
+				}
+			}
+			return w.Flush()
+		},
+	}
+	cmd.Flags().StringVar(&opts.Registry, "registry", "", "only ask this registry")
+	return cmd
+}
+
+// quotaUsage renders used against its limit, or used alone when there is
+// no limit.
+func quotaUsage(used string, limited bool, limit string) string {
+	if !limited {
+		return used
+	}
+	return used + " of " + limit
+}
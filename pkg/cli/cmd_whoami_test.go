@@ -0,0 +1,59 @@
+package cli_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	testutils "github.com/jlrickert/cli-toolkit/sandbox"
+	"github.com/jlrickert/tapper/pkg/tapper"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWhoami_ReportsRegistryIdentity(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/me" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer s3cret" {
+			http.Error(w, "bad token", http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"user":"joe","name":"Joe Example","namespaces":["joe","team"],
+			"quota":{"kegs":3,"maxKegs":10,"storage":1572864,"rateLimit":600}}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	sb := NewSandbox(t, testutils.WithFixture("joe", "~"))
+	withTestRegistry(t, sb, srv.URL)
+	require.NoError(t, sb.Runtime().Set(tapper.CredentialStoreEnvKey, "file"))
+
+	res := NewProcess(t, false, "whoami").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err, string(res.Stderr))
+	require.Regexp(t, `user:\s+not logged in`, string(res.Stdout))
+
+	res = NewProcess(t, false, "whoami", "--registry", "test").Run(sb.Context(), sb.Runtime())
+	require.Error(t, res.Err)
+	require.Contains(t, string(res.Stderr), "not logged in to test")
+
+	res = NewProcess(t, false, "auth", "login", "--with-token").RunWithIO(sb.Context(), sb.Runtime(), strings.NewReader("s3cret\n"))
+	require.NoError(t, res.Err, string(res.Stderr))
+
+	res = NewProcess(t, false, "whoami").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err, string(res.Stderr))
+	out := string(res.Stdout)
+	require.Regexp(t, `user:\s+joe \(Joe Example\)`, out)
+	require.Regexp(t, `namespaces:\s+joe, team`, out)
+	require.Regexp(t, `kegs:\s+3 of 10`, out)
+	require.Regexp(t, `storage:\s+1.5MB\n`, out)
+	require.Regexp(t, `rate limit:\s+600 requests/min`, out)
+
+	res = NewProcess(t, false, "auth", "login", "--with-token").RunWithIO(sb.Context(), sb.Runtime(), strings.NewReader("wrong\n"))
+	require.NoError(t, res.Err, string(res.Stderr))
+	res = NewProcess(t, false, "whoami").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err, string(res.Stderr))
+	require.Contains(t, string(res.Stdout), "token for test was rejected")
+}
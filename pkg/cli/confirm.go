@@ -0,0 +1,44 @@
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// confirm asks the user to approve a destructive action before it runs. The
+// question is written to stderr followed by " [y/N]: " and the answer is read
+// from stdin; anything but y or yes cancels with an error naming action.
+//
+// The global --yes flag skips the question. Without a TTY, or when stdin
+// already supplied node IDs, there is nobody to ask: when required is false the action proceeds so scripts keep working,
+// otherwise the command refuses and points at --yes.
+func confirm(cmd *cobra.Command, deps *Deps, action string, required bool, question string) error {
+	if deps.Yes {
+		return nil
+	}
+	if !deps.Runtime.Stream().IsTTY || deps.stdinUsed {
+		if required {
+			return fmt.Errorf("%s requires confirmation; rerun with --yes", action)
+		}
+		return nil
+	}
+
+	if _, err := fmt.Fprintf(cmd.ErrOrStderr(), "%s [y/N]: ", question); err != nil {
+		return err
+	}
+	line, err := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	switch strings.ToLower(strings.TrimSpace(line)) {
+	case "y", "yes":
+		return nil
+	default:
+		return fmt.Errorf("%s canceled", action)
+	}
+}
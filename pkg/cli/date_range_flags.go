@@ -0,0 +1,13 @@
+package cli
+
+import (
+	"github.com/jlrickert/tapper/pkg/tapper"
+	"github.com/spf13/cobra"
+)
+
+// addDateRangeFlags registers --since, --until, and --created-since on cmd.
+func addDateRangeFlags(cmd *cobra.Command, opts *tapper.DateRangeOptions) {
+	cmd.Flags().StringVar(&opts.Since, "since", "", `only nodes updated at or after this time (RFC3339, date, or duration like "7d", "2w")`)
+	cmd.Flags().StringVar(&opts.Until, "until", "", `only nodes updated at or before this time (RFC3339, date, or duration like "7d", "2w")`)
+	cmd.Flags().StringVar(&opts.CreatedSince, "created-since", "", `only nodes created at or after this time (RFC3339, date, or duration like "7d", "2w")`)
+}
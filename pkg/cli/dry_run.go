@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jlrickert/tapper/pkg/tapper"
+	"github.com/spf13/cobra"
+)
+
+// dryRunRecord is the structured form of one change reported by --dry-run.
+type dryRunRecord struct {
+	Keg  string `json:"keg" yaml:"keg"`
+	Op   string `json:"op" yaml:"op"`
+	Path string `json:"path" yaml:"path"`
+}
+
+func addDryRunFlag(cmd *cobra.Command, dryRun *bool) {
+	cmd.Flags().BoolVar(dryRun, "dry-run", false, "report the files that would change without writing anything")
+}
+
+// runWithDryRun runs op normally, or with dryRun set against in-memory copies
+// of the kegs it touches, then prints the files that would be written or
+// removed.
+func runWithDryRun(cmd *cobra.Command, deps *Deps, dryRun bool, op func(ctx context.Context) error) error {
+	if !dryRun {
+		return op(cmd.Context())
+	}
+	ctx, rec := tapper.WithDryRun(cmd.Context())
+	if err := op(ctx); err != nil {
+		return err
+	}
+	changes := rec.Changes()
+
+	if deps.Output != OutputHuman {
+		records := make([]dryRunRecord, 0, len(changes))
+		for _, c := range changes {
+			records = append(records, dryRunRecord{Keg: c.Keg, Op: string(c.Op), Path: c.Path})
+		}
+		return writeOutput(cmd.OutOrStdout(), deps.Output, records)
+	}
+
+	multiKeg := false
+	for _, c := range changes {
+		if c.Keg != changes[0].Keg {
+			multiKeg = true
+			break
+		}
+	}
+	out := cmd.OutOrStdout()
+	for _, c := range changes {
+		path := c.Path
+		if multiKeg {
+			path = c.Keg + ": " + path
+		}
+		if _, err := fmt.Fprintf(out, "would %s %s\n", c.Op, path); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(cmd.ErrOrStderr(), "dry run: %d file(s) would change; nothing was written\n", len(changes))
+	return err
+}
@@ -0,0 +1,114 @@
+package cli_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	testutils "github.com/jlrickert/cli-toolkit/sandbox"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDryRun_RemoveReportsChangesWithoutWriting(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("joe", "~"))
+	before := sb.MustReadFile("~/kegs/personal/1/README.md")
+
+	res := NewProcess(t, false, "rm", "3", "--force", "--dry-run").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err, string(res.Stderr))
+	out := string(res.Stdout)
+	require.Contains(t, out, "would remove 3/README.md\n")
+	require.Contains(t, out, "would remove 3/meta.yaml\n")
+	require.Contains(t, out, "would write 1/README.md\n", "inbound links are cleaned up")
+	require.Contains(t, out, "would write dex/nodes.tsv\n")
+	require.Contains(t, string(res.Stderr), "nothing was written")
+
+	require.Equal(t, before, sb.MustReadFile("~/kegs/personal/1/README.md"))
+	_, err := sb.ReadFile("~/kegs/personal/3/README.md")
+	require.NoError(t, err)
+}
+
+func TestDryRun_MoveAndMergeLeaveKegUntouched(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("joe", "~"))
+
+	res := NewProcess(t, false, "mv", "3", "9", "--dry-run").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err, string(res.Stderr))
+	require.Contains(t, string(res.Stdout), "would remove 3/README.md\n")
+	require.Contains(t, string(res.Stdout), "would write 9/README.md\n")
+	_, err := sb.ReadFile("~/kegs/personal/9/README.md")
+	require.Error(t, err)
+
+	res = NewProcess(t, false, "merge", "3", "2", "--dry-run", "--output", "json").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err, string(res.Stderr))
+	var changes []map[string]string
+	require.NoError(t, json.Unmarshal(res.Stdout, &changes))
+	require.Contains(t, changes, map[string]string{"keg": changes[0]["keg"], "op": "write", "path": "archive/3/README.md"})
+	require.Contains(t, changes, map[string]string{"keg": changes[0]["keg"], "op": "write", "path": "2/README.md"})
+
+	list := NewProcess(t, false, "list", "--id-only").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, list.Err)
+	require.Contains(t, strings.Fields(string(list.Stdout)), "3")
+	require.NotContains(t, string(sb.MustReadFile("~/kegs/personal/2/README.md")), "# Meeting Notes")
+}
+
+func TestDryRun_ImportPrefixesChangesWithKeg(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("joe", "~"))
+
+	res := NewProcess(t, false, "import", "--from", "personal", "1", "--keg", "work", "--leave-stubs", "--dry-run").
+		Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err, string(res.Stderr))
+	out := string(res.Stdout)
+	require.Contains(t, out, "1 -> 1")
+	require.Regexp(t, `would write \S*work\S*: 1/README.md`, out)
+	require.Regexp(t, `would write \S*personal\S*: 1/README.md`, out, "stubs are reported for the source keg")
+
+	_, err := sb.ReadFile("~/kegs/work/1/README.md")
+	require.Error(t, err)
+	require.NotContains(t, string(sb.MustReadFile("~/kegs/personal/1/README.md")), "Moved to")
+}
+
+func TestDryRun_RevertUnarchiveCommitAndRestoreLeaveKegUntouched(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("joe", "~"))
+	ctx := sb.Context()
+	require.NoError(t, sb.Runtime().Set("EDITOR", "/bin/false"))
+	sb.Runtime().Unset("VISUAL")
+	run := func(args ...string) *testutils.ProcessResult {
+		res := NewProcess(t, false, args...).Run(ctx, sb.Runtime())
+		require.NoError(t, res.Err, string(res.Stderr))
+		return res
+	}
+
+	run("snapshot", "create", "3", "-m", "baseline")
+	res := NewProcess(t, false, "edit", "3").RunWithIO(ctx, sb.Runtime(), strings.NewReader("# Rewritten Notes\n"))
+	require.NoError(t, res.Err, string(res.Stderr))
+	run("archive", "2")
+	draft := strings.TrimSpace(string(run("create", "--draft", "--title", "Rough Idea").Stdout))
+	snapshot := func() map[string]string {
+		files := map[string]string{}
+		for _, name := range []string{"3/README.md", "3/meta.yaml", "archive/2/README.md", draft + "/README.md", "dex/nodes.tsv"} {
+			files[name] = string(sb.MustReadFile("~/kegs/personal/" + name))
+		}
+		return files
+	}
+	before := snapshot()
+
+	res = run("revert", "3", "1", "--dry-run")
+	require.Contains(t, string(res.Stdout), "would write 3/README.md\n")
+	require.Contains(t, string(res.Stderr), "nothing was written")
+
+	res = run("snapshot", "restore", "3", "1", "--dry-run")
+	require.Contains(t, string(res.Stdout), "would write 3/README.md\n", "restore needs no --yes when nothing is written")
+
+	res = run("unarchive", "2", "--dry-run")
+	require.Contains(t, string(res.Stdout), "would remove archive/2/README.md\n")
+	require.Contains(t, string(res.Stdout), "would write 2/README.md\n")
+
+	res = run("commit", strings.SplitN(draft, "-", 2)[1], "--dry-run")
+	require.Contains(t, string(res.Stdout), draft+" -> 4\n")
+	require.Contains(t, string(res.Stdout), "would write 4/README.md\n")
+
+	require.Equal(t, before, snapshot())
+}
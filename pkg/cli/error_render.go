@@ -1,13 +1,219 @@
 package cli
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"log/slog"
 	"strings"
 
+	"github.com/jlrickert/tapper/pkg/keg"
 	"github.com/jlrickert/tapper/pkg/tapper"
+	"github.com/spf13/cobra"
 )
 
+// Error formats accepted by the global --error-format flag. An empty format
+// prints text unless --output json is set.
+const (
+	ErrorFormatText = "text"
+	ErrorFormatJSON = "json"
+)
+
+var errorFormats = []string{ErrorFormatText, ErrorFormatJSON}
+
+// Exit codes returned by Run for failed commands.
+const (
+	ExitError       = 1   // any failure without a more specific code
+	ExitUsage       = 2   // bad flags, arguments or input
+	ExitNotFound    = 3   // missing keg, node or other item
+	ExitConflict    = 4   // destination exists or conflicting change
+	ExitPermission  = 5   // permission denied or read-only keg
+	ExitLocked      = 6   // keg or node lock could not be acquired
+	ExitUnavailable = 7   // transient backend failure; retrying may succeed
+	ExitCanceled    = 130 // interrupted or timed out
+)
+
+// Error codes reported in the code field of a JSON error.
+const (
+	ErrorCodeError       = "error"
+	ErrorCodeUsage       = "usage"
+	ErrorCodeInvalid     = "invalid"
+	ErrorCodeNotFound    = "not_found"
+	ErrorCodeConflict    = "conflict"
+	ErrorCodePermission  = "permission"
+	ErrorCodeLocked      = "locked"
+	ErrorCodeUnavailable = "unavailable"
+	ErrorCodeCanceled    = "canceled"
+)
+
+// usageError marks a command line the command could not parse.
+type usageError struct{ err error }
+
+func (e *usageError) Error() string { return e.err.Error() }
+func (e *usageError) Unwrap() error { return e.err }
+
+func validateErrorFormat(format string) error {
+	switch format {
+	case "", ErrorFormatText, ErrorFormatJSON:
+		return nil
+	}
+	return &usageError{fmt.Errorf("unknown error format %q: expected one of %s", format, strings.Join(errorFormats, ", "))}
+}
+
+// errorRecord is the structured form of a failed command written to stderr
+// with --error-format json.
+type errorRecord struct {
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	Operation string `json:"operation,omitempty"`
+	Keg       string `json:"keg,omitempty"`
+	Node      string `json:"node,omitempty"`
+	Retryable bool   `json:"retryable"`
+	Hint      string `json:"hint,omitempty"`
+	ExitCode  int    `json:"exitCode"`
+}
+
+// classifyError maps err to its error code and exit code.
+func classifyError(err error) (string, int) {
+	var usage *usageError
+	var projectErr *tapper.ProjectKegNotFoundError
+	var pathErr *tapper.PathNotFoundError
+	switch {
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return ErrorCodeCanceled, ExitCanceled
+	case errors.As(err, &usage):
+		return ErrorCodeUsage, ExitUsage
+	case errors.Is(err, keg.ErrLockTimeout), errors.Is(err, keg.ErrLock):
+		return ErrorCodeLocked, ExitLocked
+	case errors.Is(err, keg.ErrNotExist), errors.As(err, &projectErr), errors.As(err, &pathErr):
+		return ErrorCodeNotFound, ExitNotFound
+	case errors.Is(err, keg.ErrDestinationExists), errors.Is(err, keg.ErrExist), errors.Is(err, keg.ErrConflict):
+		return ErrorCodeConflict, ExitConflict
+	case errors.Is(err, keg.ErrPermission):
+		return ErrorCodePermission, ExitPermission
+	case errors.Is(err, keg.ErrRateLimited), keg.IsRetryable(err), keg.IsTemporary(err):
+		return ErrorCodeUnavailable, ExitUnavailable
+	case errors.Is(err, keg.ErrInvalid):
+		return ErrorCodeInvalid, ExitUsage
+	}
+	return ErrorCodeError, ExitError
+}
+
+// exitCode returns the documented exit code for err.
+func exitCode(err error) int {
+	_, code := classifyError(err)
+	return code
+}
+
+// newErrorRecord describes err for --error-format json. cmd is the command
+// that ran, or nil when the command line failed before one was chosen.
+func newErrorRecord(err error, cmd *cobra.Command, deps *Deps) errorRecord {
+	code, exit := classifyError(err)
+	rec := errorRecord{
+		Code:      code,
+		Message:   renderUserError(err, deps),
+		Retryable: code == ErrorCodeUnavailable || code == ErrorCodeLocked,
+		ExitCode:  exit,
+		Keg:       deps.KegTargetOptions.Keg,
+	}
+	if cmd != nil {
+		rec.Operation = cmd.CommandPath()
+	}
+
+	var backendErr *keg.BackendError
+	if errors.As(err, &backendErr) && backendErr.Op != "" {
+		rec.Operation = backendErr.Op
+	}
+	var aliasErr *keg.AliasNotFoundError
+	if errors.As(err, &aliasErr) {
+		rec.Keg = aliasErr.Alias
+	}
+	var notFound *keg.NodeNotFoundError
+	var exists *keg.DestinationExistsError
+	var ambiguous *tapper.AmbiguousNodeError
+	var policyErr *keg.PolicyViolationError
+	switch {
+	case errors.As(err, &notFound):
+		rec.Node = notFound.ID.Path()
+	case errors.As(err, &exists):
+		rec.Node = exists.ID.Path()
+	case errors.As(err, &ambiguous):
+		rec.Node = ambiguous.Query
+	case errors.As(err, &policyErr):
+		rec.Node = policyErr.Node.Path()
+	}
+	rec.Hint = errorHint(err, rec, cmd, deps)
+	return rec
+}
+
+// errorHint suggests what to do about a failure, or returns "".
+func errorHint(err error, rec errorRecord, cmd *cobra.Command, deps *Deps) string {
+	profile := deps.Profile.withDefaults()
+	name := profile.Use
+	var ambiguous *tapper.AmbiguousNodeError
+	var projectErr *tapper.ProjectKegNotFoundError
+	var policyErr *keg.PolicyViolationError
+	var aliasErr *keg.AliasNotFoundError
+	switch {
+	case errors.As(err, &ambiguous):
+		return "use a node ID or a more specific title"
+	case errors.As(err, &aliasErr) && profile.IncludeRepoCommand:
+		return fmt.Sprintf("run `%s repo list` to list keg aliases", name)
+	case errors.As(err, &projectErr) && profile.IncludeRepoCommand:
+		return fmt.Sprintf("run `%s repo init` to create a keg here", name)
+	case errors.Is(err, keg.ErrReadOnly):
+		return "the keg is read-only; open a writable keg or drop readonly from its target"
+	case errors.As(err, &policyErr) && policyErr.NeedsConfirmation:
+		return "rerun with --yes to confirm the change"
+	case errors.As(err, &policyErr):
+		return fmt.Sprintf("the policy section of the keg config forbids this; run `%s config edit` to change it", name)
+	}
+	switch rec.Code {
+	case ErrorCodeUsage, ErrorCodeInvalid:
+		if cmd != nil {
+			return fmt.Sprintf("run `%s --help` for usage", cmd.CommandPath())
+		}
+		return fmt.Sprintf("run `%s --help` for usage", name)
+	case ErrorCodeNotFound:
+		if rec.Node != "" {
+			return fmt.Sprintf("run `%s ls` to list node IDs", name)
+		}
+	case ErrorCodeLocked:
+		return "another process holds the lock; try again"
+	case ErrorCodeUnavailable:
+		return "the storage backend failed temporarily; try again"
+	}
+	return ""
+}
+
+// useJSONErrors reports whether failures are written as JSON: with
+// --error-format json, or with --output json unless --error-format text.
+func useJSONErrors(deps *Deps) bool {
+	switch deps.ErrorFormat {
+	case ErrorFormatJSON:
+		return true
+	case ErrorFormatText:
+		return false
+	}
+	return deps.Output == OutputJSON
+}
+
+// writeUserError prints err to w in the selected error format.
+func writeUserError(w io.Writer, err error, cmd *cobra.Command, deps *Deps) {
+	if !useJSONErrors(deps) {
+		_, _ = fmt.Fprintf(w, "Error: %s\n", renderUserError(err, deps))
+		return
+	}
+	data, jsonErr := json.Marshal(newErrorRecord(err, cmd, deps))
+	if jsonErr != nil {
+		_, _ = fmt.Fprintf(w, "Error: %s\n", renderUserError(err, deps))
+		return
+	}
+	_, _ = fmt.Fprintf(w, "%s\n", data)
+}
+
 func renderUserError(err error, deps *Deps) string {
 	if err == nil {
 		return ""
@@ -18,6 +224,14 @@ func renderUserError(err error, deps *Deps) string {
 		return pathErr.Error()
 	}
 
+	var policyErr *keg.PolicyViolationError
+	if errors.As(err, &policyErr) {
+		if policyErr.NeedsConfirmation {
+			return policyErr.Error() + "; rerun with --yes to confirm"
+		}
+		return policyErr.Error()
+	}
+
 	var projectErr *tapper.ProjectKegNotFoundError
 	if errors.As(err, &projectErr) {
 		if isDebugLogLevel(deps) && len(projectErr.Tried) > 0 {
@@ -33,5 +247,5 @@ func isDebugLogLevel(deps *Deps) bool {
 	if deps == nil {
 		return false
 	}
-	return strings.EqualFold(strings.TrimSpace(deps.LogLevel), "debug")
+	return parseLogLevel(deps.LogLevel) <= slog.LevelDebug
 }
@@ -0,0 +1,115 @@
+package cli_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	testutils "github.com/jlrickert/cli-toolkit/sandbox"
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorFormat_JSON(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("testuser", "~"))
+
+	for _, args := range [][]string{
+		{"cat", "999", "--error-format", "json"},
+		{"cat", "999", "--output", "json"},
+	} {
+		res := NewProcess(t, false, args...).Run(sb.Context(), sb.Runtime())
+		require.Error(t, res.Err)
+		require.Equal(t, 3, res.ExitCode)
+		require.Empty(t, res.Stdout)
+
+		var got map[string]any
+		require.NoError(t, json.Unmarshal(res.Stderr, &got), string(res.Stderr))
+		require.Equal(t, "not_found", got["code"])
+		require.Equal(t, "tap cat", got["operation"])
+		require.Equal(t, "999", got["node"])
+		require.Equal(t, false, got["retryable"])
+		require.Equal(t, "run `tap ls` to list node IDs", got["hint"])
+		require.EqualValues(t, 3, got["exitCode"])
+		require.Contains(t, got["message"], "999")
+	}
+}
+
+func TestErrorFormat_TextAndExitCodes(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("testuser", "~"))
+
+	res := NewProcess(t, false, "cat", "999", "--output", "json", "--error-format", "text").Run(sb.Context(), sb.Runtime())
+	require.Equal(t, 3, res.ExitCode)
+	require.True(t, strings.HasPrefix(string(res.Stderr), "Error: "), string(res.Stderr))
+
+	res = NewProcess(t, false, "ls", "--error-format", "xml").Run(sb.Context(), sb.Runtime())
+	require.Equal(t, 2, res.ExitCode)
+	require.Contains(t, string(res.Stderr), `unknown error format "xml"`)
+
+	res = NewProcess(t, false, "ls", "--no-such-flag", "--error-format", "json").Run(sb.Context(), sb.Runtime())
+	require.Equal(t, 2, res.ExitCode)
+	require.Contains(t, string(res.Stderr), "unknown flag")
+}
+
+func TestErrorFormat_UsageAndMissingKeg(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("testuser", "~"))
+
+	cases := []struct {
+		name     string
+		args     []string
+		code     string
+		exitCode int
+		message  string
+	}{
+		{"wrong arg count", []string{"mv", "1", "--error-format", "json"}, "usage", 2, "accepts 2 arg(s), received 1"},
+		{"unknown command", []string{"nosuch", "--error-format", "json"}, "usage", 2, `unknown command "nosuch"`},
+		{"unknown command before flag", []string{"--error-format", "json", "nosuch"}, "usage", 2, `unknown command "nosuch"`},
+		{"unknown keg alias", []string{"cat", "foo:1", "--error-format", "json"}, "not_found", 3, "keg alias not found: foo"},
+		{"unknown keg flag", []string{"cat", "1", "--keg", "foo", "--error-format", "json"}, "not_found", 3, "keg alias not found: foo"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			res := NewProcess(t, false, tc.args...).Run(sb.Context(), sb.Runtime())
+			require.Error(t, res.Err)
+			require.Equal(t, tc.exitCode, res.ExitCode)
+
+			var got map[string]any
+			require.NoError(t, json.Unmarshal(res.Stderr, &got), string(res.Stderr))
+			require.Equal(t, tc.code, got["code"])
+			require.EqualValues(t, tc.exitCode, got["exitCode"])
+			require.Contains(t, got["message"], tc.message)
+		})
+	}
+
+	res := NewProcess(t, false, "nosuch").Run(sb.Context(), sb.Runtime())
+	require.Equal(t, 2, res.ExitCode)
+	require.True(t, strings.HasPrefix(string(res.Stderr), "Error: unknown command"), string(res.Stderr))
+}
+
+func TestErrorFormat_PolicyViolation(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("testuser", "~"))
+	cfg := sb.MustReadFile("~/kegs/example/keg")
+	sb.MustWriteFile("~/kegs/example/keg", append(cfg, "policy:\n    immutable: [\"2\"]\n    tags:\n        - match: published\n          confirm: true\n"...), 0o644)
+
+	res := NewProcess(t, false, "create", "--title", "Post", "--tags", "published").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	res = NewProcess(t, false, "create", "--title", "Frozen").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+
+	res = NewProcess(t, false, "rm", "1").Run(sb.Context(), sb.Runtime())
+	require.Equal(t, 5, res.ExitCode)
+	require.Contains(t, string(res.Stderr), `remove node 1 needs confirmation: keg policy protects nodes tagged "published"; rerun with --yes to confirm`)
+
+	res = NewProcess(t, false, "rm", "2", "--yes", "--error-format", "json").Run(sb.Context(), sb.Runtime())
+	require.Equal(t, 5, res.ExitCode)
+	var got map[string]any
+	require.NoError(t, json.Unmarshal(res.Stderr, &got), string(res.Stderr))
+	require.Equal(t, "permission", got["code"])
+	require.Equal(t, "2", got["node"])
+	require.Contains(t, got["hint"], "config edit")
+
+	res = NewProcess(t, false, "rm", "1", "--yes").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+}
@@ -0,0 +1,225 @@
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jlrickert/tapper/pkg/tapper"
+	"golang.org/x/term"
+)
+
+// errFindCanceled is returned when the user dismisses the finder.
+var errFindCanceled = errors.New("find canceled")
+
+const (
+	findPickerDefaultWidth  = 80
+	findPickerDefaultHeight = 24
+)
+
+// findPicker is a minimal fzf-style selector. It reads keystrokes from in,
+// redraws into out, and keeps the filtered candidate list in sync with the
+// query as it is typed. The picker does not depend on a real terminal so it
+// can be driven by scripted input in tests.
+type findPicker struct {
+	all     []tapper.FindCandidate
+	matches []tapper.FindCandidate
+	query   []rune
+	cursor  int
+
+	// preview returns the content shown beside the candidate list.
+	preview func(id string) string
+
+	width  int
+	height int
+}
+
+func newFindPicker(cands []tapper.FindCandidate, query string, preview func(string) string) *findPicker {
+	p := &findPicker{
+		all:     cands,
+		query:   []rune(query),
+		preview: preview,
+		width:   findPickerDefaultWidth,
+		height:  findPickerDefaultHeight,
+	}
+	p.refilter()
+	return p
+}
+
+func (p *findPicker) refilter() {
+	p.matches = tapper.FilterFindCandidates(p.all, string(p.query))
+	if p.cursor >= len(p.matches) {
+		p.cursor = len(p.matches) - 1
+	}
+	if p.cursor < 0 {
+		p.cursor = 0
+	}
+}
+
+// run processes keys until a candidate is chosen or the picker is canceled.
+func (p *findPicker) run(in io.Reader, out io.Writer) (tapper.FindCandidate, error) {
+	r := bufio.NewReader(in)
+	p.render(out)
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return tapper.FindCandidate{}, errFindCanceled
+			}
+			return tapper.FindCandidate{}, err
+		}
+
+		switch b {
+		case '\r', '\n':
+			if len(p.matches) == 0 {
+				continue
+			}
+			return p.matches[p.cursor], nil
+		case 3, 7: // ctrl-c, ctrl-g
+			return tapper.FindCandidate{}, errFindCanceled
+		case 127, 8: // backspace
+			if len(p.query) > 0 {
+				p.query = p.query[:len(p.query)-1]
+				p.refilter()
+			}
+		case 21: // ctrl-u
+			p.query = p.query[:0]
+			p.refilter()
+		case 14: // ctrl-n
+			p.move(1)
+		case 16: // ctrl-p
+			p.move(-1)
+		case 27: // escape sequence or bare escape
+			next, err := r.ReadByte()
+			if err != nil || next != '[' {
+				return tapper.FindCandidate{}, errFindCanceled
+			}
+			code, err := r.ReadByte()
+			if err != nil {
+				return tapper.FindCandidate{}, errFindCanceled
+			}
+			switch code {
+			case 'A':
+				p.move(-1)
+			case 'B':
+				p.move(1)
+			}
+		default:
+			if b < 32 {
+				continue
+			}
+			if err := r.UnreadByte(); err != nil {
+				return tapper.FindCandidate{}, err
+			}
+			ch, _, err := r.ReadRune()
+			if err != nil {
+				return tapper.FindCandidate{}, err
+			}
+			if ch != utf8.RuneError {
+				p.query = append(p.query, ch)
+				p.refilter()
+			}
+		}
+		p.render(out)
+	}
+}
+
+func (p *findPicker) move(delta int) {
+	if len(p.matches) == 0 {
+		return
+	}
+	p.cursor += delta
+	if p.cursor < 0 {
+		p.cursor = 0
+	}
+	if p.cursor >= len(p.matches) {
+		p.cursor = len(p.matches) - 1
+	}
+}
+
+// render draws the prompt, candidate list, and preview pane.
+func (p *findPicker) render(out io.Writer) {
+	listWidth := p.width / 2
+	previewWidth := p.width - listWidth - 3
+	rows := p.height - 2
+	if rows < 1 {
+		rows = 1
+	}
+
+	// Keep the cursor visible by scrolling the list window.
+	start := 0
+	if p.cursor >= rows {
+		start = p.cursor - rows + 1
+	}
+
+	var previewLines []string
+	if p.preview != nil && len(p.matches) > 0 {
+		previewLines = strings.Split(p.preview(p.matches[p.cursor].ID), "\n")
+	}
+
+	var b strings.Builder
+	b.WriteString("\x1b[H\x1b[2J")
+	fmt.Fprintf(&b, "> %s\r\n", string(p.query))
+	for row := 0; row < rows; row++ {
+		left := ""
+		if i := start + row; i < len(p.matches) {
+			marker := "  "
+			if i == p.cursor {
+				marker = "> "
+			}
+			left = marker + p.matches[i].ID + " " + p.matches[i].Title
+		}
+		right := ""
+		if row < len(previewLines) {
+			right = previewLines[row]
+		}
+		fmt.Fprintf(&b, "%s │ %s\r\n", padOrTruncate(left, listWidth), truncateRunes(right, previewWidth))
+	}
+	fmt.Fprintf(&b, "  %d/%d", len(p.matches), len(p.all))
+	_, _ = io.WriteString(out, b.String())
+}
+
+func padOrTruncate(s string, width int) string {
+	s = truncateRunes(s, width)
+	if n := utf8.RuneCountInString(s); n < width {
+		s += strings.Repeat(" ", width-n)
+	}
+	return s
+}
+
+func truncateRunes(s string, width int) string {
+	s = strings.ReplaceAll(s, "\t", " ")
+	if width <= 0 {
+		return ""
+	}
+	if utf8.RuneCountInString(s) <= width {
+		return s
+	}
+	return string([]rune(s)[:width])
+}
+
+// runFindPicker prepares the terminal (raw mode, alternate screen) when in and
+// out are real terminals, runs the picker, and restores the terminal.
+func runFindPicker(p *findPicker, in io.Reader, out io.Writer) (tapper.FindCandidate, error) {
+	inFile, inOK := in.(*os.File)
+	outFile, outOK := out.(*os.File)
+	if inOK && outOK && term.IsTerminal(int(inFile.Fd())) && term.IsTerminal(int(outFile.Fd())) {
+		if w, h, err := term.GetSize(int(outFile.Fd())); err == nil {
+			p.width, p.height = w, h
+		}
+		state, err := term.MakeRaw(int(inFile.Fd()))
+		if err != nil {
+			return tapper.FindCandidate{}, fmt.Errorf("unable to enter raw mode: %w", err)
+		}
+		_, _ = io.WriteString(out, "\x1b[?1049h")
+		defer func() {
+			_, _ = io.WriteString(out, "\x1b[?1049l")
+			_ = term.Restore(int(inFile.Fd()), state)
+		}()
+	}
+	return p.run(in, out)
+}
@@ -0,0 +1,34 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/jlrickert/tapper/pkg/tapper"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// identityPrompter returns a tapper.IdentityPrompter that asks for the age
+// identity on stderr and reads it from stdin without echo when stdin is a
+// terminal. It is only installed on a TTY.
+func identityPrompter(cmd *cobra.Command, deps *Deps) tapper.IdentityPrompter {
+	return func(ctx context.Context) (string, error) {
+		if deps.stdinUsed {
+			return "", fmt.Errorf("no age identity available")
+		}
+		fmt.Fprint(cmd.ErrOrStderr(), "Age identity: ")
+		if f, ok := cmd.InOrStdin().(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+			data, err := term.ReadPassword(int(f.Fd()))
+			fmt.Fprintln(cmd.ErrOrStderr())
+			if err != nil {
+				return "", err
+			}
+			return strings.TrimSpace(string(data)), nil
+		}
+		return readPickerLine(bufio.NewReader(cmd.InOrStdin()))
+	}
+}
@@ -0,0 +1,66 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/jlrickert/tapper/pkg/tapper"
+	"github.com/spf13/cobra"
+)
+
+// kegPicker returns a tapper.KegPicker that lists the aliases on stderr and
+// reads the choice, by number or name, from stdin. It then offers to save the
+// choice as the project's default keg. It is only installed on a TTY.
+func kegPicker(cmd *cobra.Command, deps *Deps) tapper.KegPicker {
+	return func(ctx context.Context, aliases []string) (string, bool, error) {
+		if deps.stdinUsed {
+			return "", false, fmt.Errorf("no keg configured")
+		}
+		out := cmd.ErrOrStderr()
+		in := bufio.NewReader(cmd.InOrStdin())
+
+		fmt.Fprintln(out, "No keg is configured for this directory. Choose one:")
+		for i, alias := range aliases {
+			fmt.Fprintf(out, "  %d) %s\n", i+1, alias)
+		}
+		fmt.Fprintf(out, "Keg [1-%d]: ", len(aliases))
+		answer, err := readPickerLine(in)
+		if err != nil {
+			return "", false, err
+		}
+		alias := ""
+		if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= len(aliases) {
+			alias = aliases[n-1]
+		} else if slices.Contains(aliases, answer) {
+			alias = answer
+		}
+		if alias == "" {
+			return "", false, fmt.Errorf("no keg selected")
+		}
+
+		fmt.Fprintf(out, "Use %s by default in this project? [y/N]: ", alias)
+		answer, err = readPickerLine(in)
+		if err != nil {
+			return "", false, err
+		}
+		switch strings.ToLower(answer) {
+		case "y", "yes":
+			return alias, true, nil
+		}
+		return alias, false, nil
+	}
+}
+
+func readPickerLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
@@ -0,0 +1,51 @@
+package cli_test
+
+import (
+	"strings"
+	"testing"
+
+	testutils "github.com/jlrickert/cli-toolkit/sandbox"
+	"github.com/stretchr/testify/require"
+)
+
+func withoutDefaultKeg(t *testing.T, sb *testutils.Sandbox) {
+	t.Helper()
+	cfg := string(sb.MustReadFile("~/.config/tapper/config.yaml"))
+	cfg = strings.Replace(cfg, "defaultKeg: personal\n", "", 1)
+	sb.MustWriteFile("~/.config/tapper/config.yaml", []byte(cfg), 0o644)
+}
+
+func TestKegPicker_ChoosesAndRemembersKeg(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("joe", "~"))
+	withoutDefaultKeg(t, sb)
+
+	res := NewProcess(t, false, "dir").Run(sb.Context(), sb.Runtime())
+	require.Error(t, res.Err, "without a TTY nothing is picked")
+
+	res = NewProcess(t, true, "dir").RunWithIO(sb.Context(), sb.Runtime(), strings.NewReader("personal\nn\n"))
+	require.NoError(t, res.Err, string(res.Stderr))
+	require.Contains(t, string(res.Stderr), "2) personal")
+	require.Contains(t, strings.TrimSpace(string(res.Stdout)), "kegs/personal")
+
+	res = NewProcess(t, false, "dir").Run(sb.Context(), sb.Runtime())
+	require.Error(t, res.Err, "a choice that is not remembered applies once")
+
+	res = NewProcess(t, true, "dir").RunWithIO(sb.Context(), sb.Runtime(), strings.NewReader("3\ny\n"))
+	require.NoError(t, res.Err, string(res.Stderr))
+	require.Contains(t, string(res.Stdout), "kegs/work")
+
+	res = NewProcess(t, false, "dir").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err, string(res.Stderr))
+	require.Contains(t, string(res.Stdout), "kegs/work")
+}
+
+func TestKegPicker_RejectsUnknownChoice(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("joe", "~"))
+	withoutDefaultKeg(t, sb)
+
+	res := NewProcess(t, true, "dir").RunWithIO(sb.Context(), sb.Runtime(), strings.NewReader("9\n"))
+	require.Error(t, res.Err)
+	require.Contains(t, res.Err.Error(), "no keg selected")
+}
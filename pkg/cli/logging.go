@@ -0,0 +1,156 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jlrickert/tapper/pkg/keg"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// logRotateSize is the size at which the log file is rotated before a
+	// command appends to it.
+	logRotateSize = 10 << 20
+
+	// logRotateKeep is the number of rotated log files kept next to the
+	// active one as file.1 ... file.N.
+	logRotateKeep = 3
+)
+
+// configureLogging installs the command logger. The level comes from --trace,
+// --verbose, an explicit --log-level, then logLevel in the user config. The
+// destination is --log-file, then logFile in the user config, then stderr.
+func configureLogging(cmd *cobra.Command, deps *Deps) error {
+	level := resolveLogLevel(cmd, deps)
+	path := strings.TrimSpace(deps.LogFile)
+	if path == "" && deps.Tap != nil {
+		path = strings.TrimSpace(deps.Tap.ConfigService.Config(true).LogFile())
+	}
+
+	rt := deps.Runtime
+	var out io.Writer = os.Stderr
+	if path != "" {
+		f, err := openLogFile(deps, path)
+		if err != nil {
+			return fmt.Errorf("unable to open log file: %w", err)
+		}
+		deps.logFile = f
+		out = f
+	}
+
+	opts := &slog.HandlerOptions{Level: level, ReplaceAttr: replaceLogLevel}
+	var handler slog.Handler = slog.NewTextHandler(out, opts)
+	if deps.LogJSON {
+		handler = slog.NewJSONHandler(out, opts)
+	}
+	hn, _ := os.Hostname()
+	lg := slog.New(handler).With(
+		slog.String("version", Version),
+		slog.String("host", hn),
+		slog.Int("pid", os.Getpid()),
+	)
+	return rt.SetLogger(lg)
+}
+
+// resolveLogLevel returns the effective log level and records its name in
+// deps.LogLevel.
+func resolveLogLevel(cmd *cobra.Command, deps *Deps) slog.Level {
+	switch {
+	case deps.Trace:
+		deps.LogLevel = "trace"
+	case deps.Verbose:
+		deps.LogLevel = "debug"
+	case cmd.Flags().Changed("log-level"):
+	case deps.Tap != nil && strings.TrimSpace(deps.Tap.ConfigService.Config(true).LogLevel()) != "":
+		deps.LogLevel = deps.Tap.ConfigService.Config(true).LogLevel()
+	}
+	return parseLogLevel(deps.LogLevel)
+}
+
+// parseLogLevel maps a level name to a slog level. It understands the names
+// accepted by mylog.ParseLevel plus "trace".
+func parseLogLevel(s string) slog.Level {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "trace":
+		return keg.LevelTrace
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// replaceLogLevel names the trace level TRACE instead of slog's DEBUG-4.
+func replaceLogLevel(groups []string, a slog.Attr) slog.Attr {
+	if a.Key != slog.LevelKey || len(groups) > 0 {
+		return a
+	}
+	if level, ok := a.Value.Any().(slog.Level); ok && level <= keg.LevelTrace {
+		a.Value = slog.StringValue("TRACE")
+	}
+	return a
+}
+
+// openLogFile rotates path when it has grown past logRotateSize and opens it
+// for appending. Rotation shifts file.N-1 to file.N, dropping the oldest, and
+// moves the active file to file.1.
+func openLogFile(deps *Deps, path string) (*os.File, error) {
+	rt := deps.Runtime
+	resolved, err := rt.ResolvePath(path, false)
+	if err != nil {
+		return nil, err
+	}
+	if err := rt.Mkdir(filepath.Dir(resolved), 0o755, true); err != nil {
+		return nil, err
+	}
+	if info, err := rt.Stat(resolved, false); err == nil && info.Size() >= logRotateSize {
+		for i := logRotateKeep - 1; i >= 1; i-- {
+			src := fmt.Sprintf("%s.%d", resolved, i)
+			if _, err := rt.Stat(src, false); err == nil {
+				if err := rt.Rename(src, fmt.Sprintf("%s.%d", resolved, i+1)); err != nil {
+					return nil, err
+				}
+			}
+		}
+		if err := rt.Rename(resolved, resolved+".1"); err != nil {
+			return nil, err
+		}
+	}
+
+	host := resolved
+	if jail := strings.TrimSpace(rt.GetJail()); jail != "" {
+		host = filepath.Join(jail, strings.TrimPrefix(resolved, string(filepath.Separator)))
+	}
+	return os.OpenFile(host, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+}
+
+// closeLogging closes the log file opened by configureLogging, if any.
+func closeLogging(deps *Deps) {
+	if deps.logFile != nil {
+		_ = deps.logFile.Close()
+		deps.logFile = nil
+	}
+}
+
+// logCommand records the command that ran, how long it took and how it ended.
+func logCommand(ctx context.Context, deps *Deps, cmd *cobra.Command, start time.Time, err error) {
+	if cmd == nil {
+		return
+	}
+	attrs := []any{"command", cmd.CommandPath(), "elapsed", time.Since(start)}
+	if err != nil {
+		attrs = append(attrs, "error", err.Error())
+	}
+	deps.Runtime.Logger().Log(ctx, slog.LevelDebug, "command finished", attrs...)
+}
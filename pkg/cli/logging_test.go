@@ -0,0 +1,69 @@
+package cli_test
+
+import (
+	"strings"
+	"testing"
+
+	testutils "github.com/jlrickert/cli-toolkit/sandbox"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogging_TraceWritesKegOperationsToLogFile(t *testing.T) {
+	t.Parallel()
+
+	sb := NewSandbox(t, testutils.WithFixture("joe", "~"))
+
+	res := NewProcess(t, false, "--trace", "--log-file", "~/tap.log", "create", "--title", "Logged").
+		Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	require.NotContains(t, string(res.Stderr), "keg operation")
+
+	log := string(sb.MustReadFile("~/tap.log"))
+	require.Contains(t, log, "level=TRACE")
+	require.Regexp(t, `msg="keg operation started" .*op=create`, log)
+	require.Regexp(t, `msg="keg operation finished" .*op=create elapsed=`, log)
+	require.Regexp(t, `msg="command finished" .*command="tap create"`, log)
+}
+
+func TestLogging_VerboseLogsAtDebug(t *testing.T) {
+	t.Parallel()
+
+	sb := NewSandbox(t, testutils.WithFixture("joe", "~"))
+
+	res := NewProcess(t, false, "-v", "--log-file", "~/tap.log", "cat", "1").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	log := string(sb.MustReadFile("~/tap.log"))
+	require.Contains(t, log, `msg="resolved keg"`)
+	require.Contains(t, log, `msg="command finished"`)
+	require.NotContains(t, log, "level=TRACE")
+}
+
+func TestLogging_UsesConfiguredLogFileAndLevel(t *testing.T) {
+	t.Parallel()
+
+	sb := NewSandbox(t, testutils.WithFixture("joe", "~"))
+	cfg := string(sb.MustReadFile("~/.config/tapper/config.yaml"))
+	sb.MustWriteFile("~/.config/tapper/config.yaml", []byte(cfg+"\nlogFile: ~/logs/tap.log\nlogLevel: debug\n"), 0o644)
+
+	res := NewProcess(t, false, "cat", "1").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	require.Empty(t, string(res.Stderr))
+	require.Contains(t, string(sb.MustReadFile("~/logs/tap.log")), `msg="command finished"`)
+}
+
+func TestLogging_RotatesOversizedLogFile(t *testing.T) {
+	t.Parallel()
+
+	sb := NewSandbox(t, testutils.WithFixture("joe", "~"))
+	old := strings.Repeat("x", 10<<20)
+	sb.MustWriteFile("~/tap.log", []byte(old), 0o644)
+	sb.MustWriteFile("~/tap.log.1", []byte("previous"), 0o644)
+
+	res := NewProcess(t, false, "--verbose", "--log-file", "~/tap.log", "cat", "1").
+		Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+
+	require.Equal(t, "previous", string(sb.MustReadFile("~/tap.log.2")))
+	require.Len(t, sb.MustReadFile("~/tap.log.1"), len(old))
+	require.Contains(t, string(sb.MustReadFile("~/tap.log")), `msg="command finished"`)
+}
@@ -0,0 +1,73 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/jlrickert/tapper/pkg/keg"
+	"github.com/spf13/cobra"
+)
+
+// nodeArgsAnnotation marks commands whose positional arguments are node IDs.
+// Its value is the number of leading node arguments, "0" meaning all.
+const nodeArgsAnnotation = "tapper/node-args"
+
+// nodeArgAnnotations returns the annotations for a command whose first n
+// positional arguments (every argument when n is 0) are node IDs. Such
+// arguments may be given as alias:id to address a node in another keg.
+func nodeArgAnnotations(n int) map[string]string {
+	return map[string]string{nodeArgsAnnotation: strconv.Itoa(n)}
+}
+
+// resolveArgAddresses rewrites alias:id node arguments of an annotated
+// command in place. Cobra hands the same args slice to RunE, so commands see
+// plain IDs with the keg target switched to the alias.
+func resolveArgAddresses(cmd *cobra.Command, deps *Deps, args []string) error {
+	raw, ok := cmd.Annotations[nodeArgsAnnotation]
+	if !ok {
+		return nil
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n <= 0 || n > len(args) {
+		n = len(args)
+	}
+	return resolveNodeAddresses(deps, args[:n])
+}
+
+// resolveNodeAddresses replaces every alias:id (or keg:alias/id) in ids with
+// the plain node ID and selects the alias as the keg target. All addressed
+// IDs must name the same keg, and it must agree with --keg when given. The
+// alias is looked up in the configured kegs.
+func resolveNodeAddresses(deps *Deps, ids []string) error {
+	selected := ""
+	for i, raw := range ids {
+		if raw == stdinArg {
+			continue
+		}
+		id, err := keg.ParseNode(raw)
+		if err != nil || id == nil || id.Alias == "" {
+			continue
+		}
+		alias := id.Alias
+		if !deps.Profile.withDefaults().AllowKegAliasFlags {
+			return fmt.Errorf("node %s: keg aliases are not supported here", raw)
+		}
+		if deps.Tap != nil {
+			if _, ok := deps.Tap.ConfigService.Config(true).Kegs()[alias]; !ok {
+				return keg.NewAliasNotFoundError(alias)
+			}
+		}
+		if selected != "" && selected != alias {
+			return fmt.Errorf("node arguments span kegs %s and %s; address one keg at a time", selected, alias)
+		}
+		if current := deps.KegTargetOptions.Keg; current != "" && current != alias && selected == "" {
+			return fmt.Errorf("node %s is in keg %s but --keg %s was given", raw, alias, current)
+		}
+		selected = alias
+		ids[i] = keg.NodeId{ID: id.ID, Code: id.Code}.Path()
+	}
+	if selected != "" {
+		deps.KegTargetOptions.Keg = selected
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package cli_test
+
+import (
+	"strings"
+	"testing"
+
+	testutils "github.com/jlrickert/cli-toolkit/sandbox"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNodeAddress_SelectsKegForNodeCommands(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("joe", "~"))
+
+	res := NewProcess(t, false, "cat", "personal:1").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err, string(res.Stderr))
+	require.Contains(t, string(res.Stdout), "Personal Overview")
+
+	res = NewProcess(t, false, "dir", "work:0").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err, string(res.Stderr))
+	require.Contains(t, string(res.Stdout), "kegs/work/0")
+}
+
+func TestNodeAddress_RejectsConflictsAndUnknownAliases(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("joe", "~"))
+
+	res := NewProcess(t, false, "cat", "ghost:1").Run(sb.Context(), sb.Runtime())
+	require.Error(t, res.Err)
+	require.Contains(t, string(res.Stderr), "keg alias not found: ghost")
+
+	res = NewProcess(t, false, "cat", "personal:1", "--keg", "work").Run(sb.Context(), sb.Runtime())
+	require.Error(t, res.Err)
+	require.Contains(t, string(res.Stderr), "--keg work was given")
+
+	res = NewProcess(t, false, "cat", "personal:1", "work:0").Run(sb.Context(), sb.Runtime())
+	require.Error(t, res.Err)
+	require.Contains(t, string(res.Stderr), "span kegs")
+}
+
+func TestListAll_EmitsAliasAddresses(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("joe", "~"))
+
+	res := NewProcess(t, false, "ls", "--all", "--id-only").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err, string(res.Stderr))
+	ids := strings.Fields(string(res.Stdout))
+	require.Contains(t, ids, "personal:1")
+	require.Contains(t, ids, "work:0")
+	require.Contains(t, ids, "example:0")
+
+	res = NewProcess(t, false, "search", "--all", "--id-only", "personal notes").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err, string(res.Stderr))
+	require.Contains(t, strings.Fields(string(res.Stdout)), "personal:1")
+}
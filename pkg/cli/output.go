@@ -0,0 +1,258 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jlrickert/tapper/pkg/keg"
+	"github.com/jlrickert/tapper/pkg/tapper"
+	"gopkg.in/yaml.v3"
+)
+
+// Structured output formats accepted by the global --output flag. An empty
+// format keeps the human-readable output of each command.
+const (
+	OutputHuman = ""
+	OutputJSON  = "json"
+	OutputYAML  = "yaml"
+	OutputTSV   = "tsv"
+)
+
+var outputFormats = []string{OutputJSON, OutputYAML, OutputTSV}
+
+func validateOutputFormat(format string) error {
+	switch format {
+	case OutputHuman, OutputJSON, OutputYAML, OutputTSV:
+		return nil
+	}
+	return fmt.Errorf("unknown output format %q: expected one of %s", format, strings.Join(outputFormats, ", "))
+}
+
+// writeOutput renders records, a slice of record structs, as a document in
+// the given machine-readable format. JSON and YAML emit a list of objects;
+// TSV emits a header row of field names followed by one row per record.
+func writeOutput(w io.Writer, format string, records any) error {
+	switch format {
+	case OutputJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(records)
+	case OutputYAML:
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(records); err != nil {
+			return err
+		}
+		return enc.Close()
+	case OutputTSV:
+		return writeTSV(w, records)
+	}
+	return validateOutputFormat(format)
+}
+
+// nodeEntryRecord is the structured form of a node listed by ls and links.
+type nodeEntryRecord struct {
+	ID       string `json:"id" yaml:"id"`
+	Title    string `json:"title" yaml:"title"`
+	Created  string `json:"created" yaml:"created"`
+	Updated  string `json:"updated" yaml:"updated"`
+	Accessed string `json:"accessed" yaml:"accessed"`
+}
+
+func nodeEntryRecords(entries []keg.NodeIndexEntry) []nodeEntryRecord {
+	records := make([]nodeEntryRecord, 0, len(entries))
+	for _, e := range entries {
+		records = append(records, nodeEntryRecord{
+			ID:       e.ID,
+			Title:    e.Title,
+			Created:  formatRecordTime(e.Created),
+			Updated:  formatRecordTime(e.Updated),
+			Accessed: formatRecordTime(e.Accessed),
+		})
+	}
+	return records
+}
+
+// statsFields holds the programmatic stats of a node.
+type statsFields struct {
+	Title       string   `json:"title" yaml:"title"`
+	Lead        string   `json:"lead" yaml:"lead"`
+	Hash        string   `json:"hash" yaml:"hash"`
+	Created     string   `json:"created" yaml:"created"`
+	Updated     string   `json:"updated" yaml:"updated"`
+	Accessed    string   `json:"accessed" yaml:"accessed"`
+	AccessCount int      `json:"access_count" yaml:"access_count"`
+	Links       []string `json:"links" yaml:"links"`
+}
+
+func newStatsFields(s *keg.NodeStats) statsFields {
+	links := make([]string, 0, len(s.Links()))
+	for _, link := range s.Links() {
+		links = append(links, link.Path())
+	}
+	return statsFields{
+		Title:       s.Title(),
+		Lead:        s.Lead(),
+		Hash:        s.Hash(),
+		Created:     formatRecordTime(s.Created()),
+		Updated:     formatRecordTime(s.Updated()),
+		Accessed:    formatRecordTime(s.Accessed()),
+		AccessCount: s.AccessCount(),
+		Links:       links,
+	}
+}
+
+// statsRecord is the structured form of `stats NODE_ID`.
+type statsRecord struct {
+	ID          string `json:"id" yaml:"id"`
+	statsFields `yaml:",inline"`
+}
+
+// storageRecord is the structured form of one `stats --storage` row. Sizes
+// are in bytes.
+type storageRecord struct {
+	ID          string `json:"id" yaml:"id"`
+	Content     int64  `json:"content" yaml:"content"`
+	Images      int64  `json:"images" yaml:"images"`
+	Attachments int64  `json:"attachments" yaml:"attachments"`
+	Total       int64  `json:"total" yaml:"total"`
+}
+
+// kegAggregateRecord is the structured form of one `stats --all` row.
+// Storage is in bytes and Activity holds weekly update counts, oldest first.
+type kegAggregateRecord struct {
+	Alias    string `json:"alias" yaml:"alias"`
+	Nodes    int    `json:"nodes" yaml:"nodes"`
+	Tags     int    `json:"tags" yaml:"tags"`
+	Storage  int64  `json:"storage" yaml:"storage"`
+	Activity []int  `json:"activity" yaml:"activity"`
+	Error    string `json:"error" yaml:"error"`
+}
+
+// documentRecord is the structured form of a node printed by cat.
+type documentRecord struct {
+	ID      string         `json:"id" yaml:"id"`
+	Meta    map[string]any `json:"meta" yaml:"meta"`
+	Stats   *statsFields   `json:"stats" yaml:"stats"`
+	Content string         `json:"content" yaml:"content"`
+}
+
+func documentRecords(docs []tapper.NodeDocument) []documentRecord {
+	records := make([]documentRecord, 0, len(docs))
+	for _, doc := range docs {
+		record := documentRecord{ID: doc.ID.Path(), Meta: doc.Meta, Content: doc.Content}
+		if doc.Stats != nil {
+			stats := newStatsFields(doc.Stats)
+			record.Stats = &stats
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+// kegRecord is the structured form of a keg listed by `repo list`.
+type kegRecord struct {
+	Alias string `json:"alias" yaml:"alias"`
+}
+
+func formatRecordTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+// writeTSV writes records as tab separated values. Column names come from
+// the json field tags. Tabs, newlines and backslashes in values are escaped
+// so every record stays on one line.
+func writeTSV(w io.Writer, records any) error {
+	rv := reflect.ValueOf(records)
+	if rv.Kind() != reflect.Slice {
+		return fmt.Errorf("tsv output needs a list of records, got %s", rv.Kind())
+	}
+	elem := rv.Type().Elem()
+	if elem.Kind() != reflect.Struct {
+		return fmt.Errorf("tsv output needs a list of records, got list of %s", elem.Kind())
+	}
+
+	var header []string
+	tsvColumns(elem, func(name string, _ []int) { header = append(header, name) })
+	var buf bytes.Buffer
+	buf.WriteString(strings.Join(header, "\t"))
+	buf.WriteByte('\n')
+	for i := 0; i < rv.Len(); i++ {
+		var cells []string
+		var cellErr error
+		row := rv.Index(i)
+		tsvColumns(elem, func(_ string, index []int) {
+			cell, err := tsvCell(row.FieldByIndex(index))
+			if err != nil && cellErr == nil {
+				cellErr = err
+			}
+			cells = append(cells, cell)
+		})
+		if cellErr != nil {
+			return cellErr
+		}
+		buf.WriteString(strings.Join(cells, "\t"))
+		buf.WriteByte('\n')
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// tsvColumns calls fn for every exported field of t in declaration order,
+// flattening embedded structs the way encoding/json does.
+func tsvColumns(t reflect.Type, fn func(name string, index []int)) {
+	for _, field := range reflect.VisibleFields(t) {
+		if field.Anonymous || !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fn(name, field.Index)
+	}
+}
+
+var tsvEscaper = strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\n", `\n`, "\r", `\r`)
+
+func tsvCell(v reflect.Value) (string, error) {
+	switch v.Kind() {
+	case reflect.String:
+		return tsvEscaper.Replace(v.String()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Pointer, reflect.Map, reflect.Interface:
+		if v.IsNil() {
+			return "", nil
+		}
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.String {
+			items := make([]string, v.Len())
+			for i := range items {
+				items[i] = tsvEscaper.Replace(v.Index(i).String())
+			}
+			return strings.Join(items, ","), nil
+		}
+	}
+	data, err := json.Marshal(v.Interface())
+	if err != nil {
+		return "", err
+	}
+	return tsvEscaper.Replace(string(data)), nil
+}
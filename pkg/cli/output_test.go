@@ -0,0 +1,104 @@
+package cli_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	testutils "github.com/jlrickert/cli-toolkit/sandbox"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestOutput_ListJSONYAMLAndTSV(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("testuser", "~"))
+	res := NewProcess(t, false, "create", "--title", "Tab\tand\nnewline").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+
+	res = NewProcess(t, false, "list", "--output", "json").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err, string(res.Stderr))
+	var nodes []map[string]any
+	require.NoError(t, json.Unmarshal(res.Stdout, &nodes))
+	require.Len(t, nodes, 2)
+	require.Equal(t, "1", nodes[1]["id"])
+	for _, key := range []string{"id", "title", "created", "updated", "accessed"} {
+		require.Contains(t, nodes[1], key)
+	}
+
+	res = NewProcess(t, false, "ls", "--output", "yaml", "--reverse").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	var fromYAML []map[string]any
+	require.NoError(t, yaml.Unmarshal(res.Stdout, &fromYAML))
+	require.Len(t, fromYAML, 2)
+	require.Equal(t, "1", fromYAML[0]["id"])
+
+	res = NewProcess(t, false, "ls", "--output", "tsv").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	lines := strings.Split(strings.TrimSuffix(string(res.Stdout), "\n"), "\n")
+	require.Len(t, lines, 3)
+	require.Equal(t, "id\ttitle\tcreated\tupdated\taccessed", lines[0])
+	row := strings.Split(lines[2], "\t")
+	require.Len(t, row, 5)
+	require.Equal(t, "1", row[0])
+}
+
+func TestOutput_StatsAndCatDocuments(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("joe", "~"))
+
+	res := NewProcess(t, false, "stats", "1", "--keg", "personal", "--output", "json").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err, string(res.Stderr))
+	var stats []map[string]any
+	require.NoError(t, json.Unmarshal(res.Stdout, &stats))
+	require.Len(t, stats, 1)
+	require.Equal(t, "1", stats[0]["id"])
+	for _, key := range []string{"title", "lead", "hash", "created", "updated", "accessed", "access_count", "links"} {
+		require.Contains(t, stats[0], key)
+	}
+
+	res = NewProcess(t, false, "cat", "0", "1", "--keg", "personal", "--output", "json").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err, string(res.Stderr))
+	var docs []struct {
+		ID      string         `json:"id"`
+		Meta    map[string]any `json:"meta"`
+		Stats   map[string]any `json:"stats"`
+		Content string         `json:"content"`
+	}
+	require.NoError(t, json.Unmarshal(res.Stdout, &docs))
+	require.Len(t, docs, 2)
+	require.Equal(t, "1", docs[1].ID)
+	require.Contains(t, docs[1].Content, "# Personal Overview")
+	require.NotNil(t, docs[1].Meta)
+	require.Contains(t, docs[1].Stats, "title")
+
+	res = NewProcess(t, false, "cat", "1", "--keg", "personal", "--output", "tsv").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	lines := strings.Split(strings.TrimSuffix(string(res.Stdout), "\n"), "\n")
+	require.Len(t, lines, 2, "content newlines must be escaped")
+	require.Equal(t, "id\tmeta\tstats\tcontent", lines[0])
+}
+
+func TestOutput_LinksAndKegs(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("joe", "~"))
+
+	res := NewProcess(t, false, "links", "0", "--keg", "personal", "--output", "json").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	require.Equal(t, "[]\n", string(res.Stdout))
+
+	res = NewProcess(t, false, "repo", "list", "--output", "yaml").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err, string(res.Stderr))
+	var kegs []map[string]string
+	require.NoError(t, yaml.Unmarshal(res.Stdout, &kegs))
+	require.Contains(t, kegs, map[string]string{"alias": "personal"})
+}
+
+func TestOutput_UnknownFormat(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("testuser", "~"))
+
+	res := NewProcess(t, false, "list", "--output", "xml").Run(sb.Context(), sb.Runtime())
+	require.Error(t, res.Err)
+	require.Contains(t, res.Err.Error(), `unknown output format "xml"`)
+}
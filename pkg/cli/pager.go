@@ -0,0 +1,114 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// pagerAnnotation marks commands whose stdout is paged when it is too long
+// for the terminal.
+const pagerAnnotation = "tap/pager"
+
+// defaultPager is used when neither the user config nor $PAGER names one.
+const defaultPager = "less -R"
+
+// paged marks cmd as producing output worth paging, like cat or ls.
+func paged(cmd *cobra.Command) *cobra.Command {
+	if cmd.Annotations == nil {
+		cmd.Annotations = map[string]string{}
+	}
+	cmd.Annotations[pagerAnnotation] = "true"
+	return cmd
+}
+
+// pager holds the stdout of a paged command until it finishes, then decides
+// whether the output fits on screen or has to go through the pager.
+type pager struct {
+	buf     bytes.Buffer
+	out     io.Writer
+	command string
+	height  int
+}
+
+// startPager redirects the stdout of cmd into a buffer when stdout is a TTY,
+// the command is marked paged, and paging is not disabled with --no-pager or
+// `pager: off` in the user config.
+func startPager(cmd *cobra.Command, deps *Deps) {
+	if deps.NoPager || cmd.Annotations[pagerAnnotation] == "" || !deps.Runtime.Stream().IsTTY {
+		return
+	}
+	command := ""
+	if deps.Tap != nil {
+		command = strings.TrimSpace(deps.Tap.ConfigService.Config(true).Pager())
+	}
+	if command == "" {
+		command = strings.TrimSpace(deps.Runtime.Get("PAGER"))
+	}
+	if command == "" {
+		command = defaultPager
+	}
+	if command == "off" || command == "cat" {
+		return
+	}
+
+	out := cmd.OutOrStdout()
+	height := terminalHeight(out, deps.Runtime.Get("LINES"))
+	if height <= 0 {
+		return
+	}
+	p := &pager{out: out, command: command, height: height}
+	cmd.SetOut(&p.buf)
+	deps.pager = p
+}
+
+// flushPager writes the buffered output of a paged command, through the
+// pager when it has more lines than the terminal. A pager that cannot be
+// started falls back to printing the output directly.
+func flushPager(ctx context.Context, deps *Deps) error {
+	p := deps.pager
+	if p == nil {
+		return nil
+	}
+	deps.pager = nil
+	if bytes.Count(p.buf.Bytes(), []byte("\n")) < p.height {
+		_, err := p.out.Write(p.buf.Bytes())
+		return err
+	}
+
+	parts := strings.Fields(p.command)
+	if _, err := exec.LookPath(parts[0]); err != nil {
+		_, err := p.out.Write(p.buf.Bytes())
+		return err
+	}
+	stream := deps.Runtime.Stream()
+	cmd := exec.CommandContext(ctx, parts[0], parts[1:]...)
+	cmd.Stdin = &p.buf
+	cmd.Stdout = p.out
+	cmd.Stderr = stream.Err
+	cmd.Env = deps.Runtime.Environ()
+	return cmd.Run()
+}
+
+// terminalHeight returns the number of rows of the terminal behind out, or
+// the value of $LINES when out is not a terminal file. It returns 0 when the
+// height is unknown.
+func terminalHeight(out io.Writer, lines string) int {
+	if f, ok := out.(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+		if _, h, err := term.GetSize(int(f.Fd())); err == nil {
+			return h
+		}
+	}
+	h, err := strconv.Atoi(strings.TrimSpace(lines))
+	if err != nil {
+		return 0
+	}
+	return h
+}
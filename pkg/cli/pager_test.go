@@ -0,0 +1,58 @@
+package cli_test
+
+import (
+	"strings"
+	"testing"
+
+	testutils "github.com/jlrickert/cli-toolkit/sandbox"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPager_PagesLongOutputOnTTY(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("joe", "~"))
+	require.NoError(t, sb.Runtime().Set("PAGER", "sed s/^/paged:/"))
+	require.NoError(t, sb.Runtime().Set("LINES", "2"))
+
+	res := NewProcess(t, true, "list", "--id-only").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	require.Equal(t, "paged:0\npaged:1\npaged:2\npaged:3\n", string(res.Stdout))
+
+	res = NewProcess(t, true, "list", "--id-only", "--no-pager").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	require.Equal(t, "0\n1\n2\n3\n", string(res.Stdout))
+
+	res = NewProcess(t, false, "list", "--id-only").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	require.Equal(t, "0\n1\n2\n3\n", string(res.Stdout), "output that is not a TTY is never paged")
+}
+
+func TestPager_ShortOutputIsPrintedDirectly(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("joe", "~"))
+	require.NoError(t, sb.Runtime().Set("PAGER", "sed s/^/paged:/"))
+	require.NoError(t, sb.Runtime().Set("LINES", "40"))
+
+	res := NewProcess(t, true, "list", "--id-only").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	require.Equal(t, "0\n1\n2\n3\n", string(res.Stdout))
+}
+
+func TestPager_ConfigOverridesAndDisables(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t, testutils.WithFixture("joe", "~"))
+	require.NoError(t, sb.Runtime().Set("PAGER", "sed s/^/env:/"))
+	require.NoError(t, sb.Runtime().Set("LINES", "2"))
+	cfgPath := "~/.config/tapper/config.yaml"
+	cfg := string(sb.MustReadFile(cfgPath))
+
+	sb.MustWriteFile(cfgPath, []byte(cfg+"pager: sed s/^/cfg:/\n"), 0o644)
+	res := NewProcess(t, true, "list", "--id-only").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	require.True(t, strings.HasPrefix(string(res.Stdout), "cfg:0\n"), string(res.Stdout))
+
+	sb.MustWriteFile(cfgPath, []byte(cfg+"pager: \"off\"\n"), 0o644)
+	res = NewProcess(t, true, "list", "--id-only").Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	require.Equal(t, "0\n1\n2\n3\n", string(res.Stdout))
+}
@@ -0,0 +1,118 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// stdinArg is the argument that makes a node-consuming command read node IDs
+// from stdin, as in `tap ls --format %i | tap rm -`.
+const stdinArg = "-"
+
+// nodeArgs returns args with every "-" replaced by the node IDs read from
+// stdin. Stdin is read once; further "-" arguments add nothing.
+//
+// Input is one node per line. A line is either plain text, whose first field
+// is the ID so `tap ls` and `tap grep` output can be piped as is, or a JSON
+// object with an "id" field (JSONL). A JSON array of such objects, as printed
+// by --output json, is accepted as well. Blank lines are ignored.
+func nodeArgs(cmd *cobra.Command, deps *Deps, args []string) ([]string, error) {
+	out := make([]string, 0, len(args))
+	read := false
+	for _, arg := range args {
+		if arg != stdinArg {
+			out = append(out, arg)
+			continue
+		}
+		if read {
+			continue
+		}
+		read = true
+		deps.stdinUsed = true
+		ids, err := readNodeIDs(cmd.InOrStdin())
+		if err != nil {
+			return nil, fmt.Errorf("unable to read node ids from stdin: %w", err)
+		}
+		if len(ids) == 0 && len(args) == 1 {
+			return nil, fmt.Errorf("no node ids read from stdin")
+		}
+		out = append(out, ids...)
+	}
+	if err := resolveNodeAddresses(deps, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// readNodeIDs parses node IDs from r as described by nodeArgs.
+func readNodeIDs(r io.Reader) ([]string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		var records []idRecord
+		if err := json.Unmarshal(trimmed, &records); err != nil {
+			return nil, err
+		}
+		ids := make([]string, 0, len(records))
+		for i, rec := range records {
+			id, err := rec.nodeID()
+			if err != nil {
+				return nil, fmt.Errorf("record %d: %w", i+1, err)
+			}
+			ids = append(ids, id)
+		}
+		return ids, nil
+	}
+
+	var ids []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "{") {
+			var rec idRecord
+			if err := json.Unmarshal([]byte(line), &rec); err != nil {
+				return nil, fmt.Errorf("line %d: %w", n, err)
+			}
+			id, err := rec.nodeID()
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", n, err)
+			}
+			ids = append(ids, id)
+			continue
+		}
+		ids = append(ids, strings.Fields(line)[0])
+	}
+	return ids, scanner.Err()
+}
+
+// idRecord is a JSON object carrying a node ID as a string or a number.
+type idRecord struct {
+	ID json.RawMessage `json:"id"`
+}
+
+func (r idRecord) nodeID() (string, error) {
+	if len(r.ID) == 0 {
+		return "", fmt.Errorf("missing id field")
+	}
+	var s string
+	if err := json.Unmarshal(r.ID, &s); err == nil {
+		return s, nil
+	}
+	var n json.Number
+	if err := json.Unmarshal(r.ID, &n); err != nil {
+		return "", fmt.Errorf("invalid id %s", r.ID)
+	}
+	return n.String(), nil
+}
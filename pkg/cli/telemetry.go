@@ -0,0 +1,73 @@
+package cli
+
+import (
+	"context"
+	"io"
+	"os"
+	"time"
+
+	"github.com/jlrickert/tapper/pkg/tapper"
+	"github.com/spf13/cobra"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// telemetryFlushTimeout bounds how long a command waits for its spans and
+// metrics to be exported before exiting.
+const telemetryFlushTimeout = 5 * time.Second
+
+// commandTelemetry is the span covering one command and the shutdown that
+// flushes its exporters.
+type commandTelemetry struct {
+	span     trace.Span
+	shutdown func(context.Context) error
+}
+
+// configureTelemetry starts OpenTelemetry export when the user config has a
+// telemetry section and opens a span for the command. It returns the
+// context keg operations should run under so their spans nest beneath the
+// command. The stdout exporter writes to the log destination.
+func configureTelemetry(ctx context.Context, cmd *cobra.Command, deps *Deps) (context.Context, error) {
+	if deps.Tap == nil {
+		return ctx, nil
+	}
+	tc := deps.Tap.ConfigService.Config(true).Telemetry()
+	if !tc.Enabled() {
+		return ctx, nil
+	}
+
+	var out io.Writer = os.Stderr
+	if deps.logFile != nil {
+		out = deps.logFile
+	}
+	shutdown, err := tapper.SetupTelemetry(ctx, tc, out)
+	if err != nil {
+		return ctx, err
+	}
+	ctx, span := otel.Tracer("github.com/jlrickert/tapper/pkg/cli").Start(ctx, cmd.CommandPath(),
+		trace.WithAttributes(attribute.String("tapper.version", Version)))
+	deps.telemetry = &commandTelemetry{span: span, shutdown: shutdown}
+	return ctx, nil
+}
+
+// closeTelemetry ends the command span with the command's outcome and
+// flushes the exporters. Export failures are logged, not returned.
+func closeTelemetry(ctx context.Context, deps *Deps, err error) {
+	t := deps.telemetry
+	if t == nil {
+		return
+	}
+	deps.telemetry = nil
+	if err != nil {
+		t.span.RecordError(err)
+		t.span.SetStatus(codes.Error, err.Error())
+	}
+	t.span.End()
+	flushCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), telemetryFlushTimeout)
+	defer cancel()
+	if err := t.shutdown(flushCtx); err != nil {
+		deps.Runtime.Logger().Warn("unable to flush telemetry", "error", err)
+	}
+}
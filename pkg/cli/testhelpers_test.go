@@ -1,55 +1,36 @@
 package cli_test
 
 import (
-	"context"
-	"embed"
 	"strings"
 	"testing"
 
 	tu "github.com/jlrickert/cli-toolkit/sandbox"
-	"github.com/jlrickert/cli-toolkit/toolkit"
-	"github.com/jlrickert/tapper/pkg/cli"
+	"github.com/jlrickert/tapper/pkg/clitest"
 )
 
 // NOTE: Production code should call streams.IsStdoutTTY() (method) instead of
 // performing raw terminal detection. Tests can override IsStdoutTTYFn to
 // simulate TTY or non-TTY environments.
 //
-// testdata is an optional embedded data FS for fixtures. Previously an embed
-// pattern attempted to include empty directories which caused an embed error.
-//
-//go:embed all:data/**
-var testdata embed.FS
+// The sandbox and process helpers live in pkg/clitest so tests outside this
+// repository can drive tap the same way.
 
 func NewSandbox(t *testing.T, opts ...tu.Option) *tu.Sandbox {
-	return tu.NewSandbox(t, &tu.Options{
-		Data: testdata,
-		Home: "/home/testuser",
-		User: "testuser",
-	}, opts...)
-}
-
-func NewCliRunner(t *testing.T) *tu.Process {
-	return nil
+	t.Helper()
+	return clitest.NewSandbox(t, opts...)
 }
 
 func NewProcess(t *testing.T, isTTY bool, args ...string) *tu.Process {
-	return tu.NewProcess(func(ctx context.Context, rt *toolkit.Runtime) (int, error) {
-		return cli.Run(ctx, rt, args)
-	}, isTTY)
+	return clitest.NewProcess(t, isTTY, args...)
 }
 
 func NewKegV2Process(t *testing.T, isTTY bool, args ...string) *tu.Process {
-	return tu.NewProcess(func(ctx context.Context, rt *toolkit.Runtime) (int, error) {
-		return cli.RunWithProfile(ctx, rt, args, cli.KegV2Profile())
-	}, isTTY)
+	return clitest.NewKegV2Process(t, isTTY, args...)
 }
 
 func NewCompletionProcess(t *testing.T, isTTY bool, pos int, words ...string) *tu.Process {
 	_ = pos
-	return tu.NewProcess(func(ctx context.Context, rt *toolkit.Runtime) (int, error) {
-		return cli.RunCompletion(ctx, rt, words)
-	}, isTTY)
+	return clitest.NewCompletionProcess(t, isTTY, words...)
 }
 
 // parseCompletionSuggestions parses the raw output of a cobra __complete
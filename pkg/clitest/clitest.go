@@ -0,0 +1,90 @@
+// Package clitest runs tap invocations in-process against fixture kegs, for
+// black-box tests of the CLI. It is the harness tapper's own pkg/cli tests
+// use, exported so plugin authors and packagers can test tap the same way:
+//
+//	sb := clitest.NewSandbox(t, sandbox.WithFixture("joe", "~"))
+//	res := clitest.NewProcess(t, false, "list", "--keg", "personal").
+//		Run(sb.Context(), sb.Runtime())
+//	require.NoError(t, res.Err)
+//
+// A sandbox jails the filesystem in a temp directory with the home
+// /home/testuser, a test clock fixed at 2025-10-15 12:30 UTC and a test
+// logger, so runs never touch the real home directory and output is
+// reproducible.
+package clitest
+
+import (
+	"context"
+	"embed"
+	"testing"
+
+	"github.com/jlrickert/cli-toolkit/sandbox"
+	"github.com/jlrickert/cli-toolkit/toolkit"
+	"github.com/jlrickert/tapper/pkg/cli"
+)
+
+// Fixtures holds the fixtures sandbox.WithFixture copies into a sandbox
+// from NewSandbox, under data/<name>:
+//
+//   - joe: a home with a tapper config and the kegs example, personal and
+//     work under ~/kegs
+//   - testuser: a home with a tapper config and the keg example
+//   - example: a single keg
+//   - empty: an empty directory
+//   - images: a sample image
+//
+//go:embed all:data/**
+var Fixtures embed.FS
+
+// Home is the home directory of the sandbox user.
+const Home = "/home/testuser"
+
+// NewSandbox returns a sandbox for the user testuser whose fixtures come
+// from Fixtures.
+func NewSandbox(t *testing.T, opts ...sandbox.Option) *sandbox.Sandbox {
+	t.Helper()
+	return NewSandboxWithData(t, Fixtures, opts...)
+}
+
+// NewSandboxWithData is NewSandbox with fixtures from data, which must keep
+// them under a data directory as Fixtures does. Use it to run tap against
+// fixture kegs of your own.
+func NewSandboxWithData(t *testing.T, data embed.FS, opts ...sandbox.Option) *sandbox.Sandbox {
+	t.Helper()
+	return sandbox.NewSandbox(t, &sandbox.Options{
+		Data: data,
+		Home: Home,
+		User: "testuser",
+	}, opts...)
+}
+
+// NewProcess returns a process running tap with args. isTTY makes the
+// process look interactive, which enables color and paging decisions.
+func NewProcess(t *testing.T, isTTY bool, args ...string) *sandbox.Process {
+	return sandbox.NewProcess(func(ctx context.Context, rt *toolkit.Runtime) (int, error) {
+		return cli.Run(ctx, rt, args)
+	}, isTTY)
+}
+
+// NewKegV2Process is NewProcess for the keg v2 command profile.
+func NewKegV2Process(t *testing.T, isTTY bool, args ...string) *sandbox.Process {
+	return sandbox.NewProcess(func(ctx context.Context, rt *toolkit.Runtime) (int, error) {
+		return cli.RunWithProfile(ctx, rt, args, cli.KegV2Profile())
+	}, isTTY)
+}
+
+// NewCompletionProcess returns a process asking tap for shell completions,
+// as the completion scripts do. words are the arguments after tap, ending
+// with the word being completed, which may be empty.
+func NewCompletionProcess(t *testing.T, isTTY bool, words ...string) *sandbox.Process {
+	return sandbox.NewProcess(func(ctx context.Context, rt *toolkit.Runtime) (int, error) {
+		return cli.RunCompletion(ctx, rt, words)
+	}, isTTY)
+}
+
+// Run runs tap non-interactively with args in sb and returns the result.
+func Run(sb *sandbox.Sandbox, args ...string) *sandbox.ProcessResult {
+	return sandbox.NewProcess(func(ctx context.Context, rt *toolkit.Runtime) (int, error) {
+		return cli.Run(ctx, rt, args)
+	}, false).Run(sb.Context(), sb.Runtime())
+}
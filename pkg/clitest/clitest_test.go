@@ -0,0 +1,36 @@
+package clitest_test
+
+import (
+	"testing"
+
+	"github.com/jlrickert/cli-toolkit/sandbox"
+	"github.com/jlrickert/tapper/pkg/clitest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun_AgainstFixtureKeg(t *testing.T) {
+	t.Parallel()
+	sb := clitest.NewSandbox(t, sandbox.WithFixture("joe", "~"))
+
+	res := clitest.Run(sb, "cat", "1", "--keg", "personal")
+	require.NoError(t, res.Err)
+	require.Contains(t, string(res.Stdout), "Personal Overview")
+
+	res = clitest.Run(sb, "create", "--keg", "personal", "--title", "From a plugin test")
+	require.NoError(t, res.Err, string(res.Stderr))
+	require.FileExists(t, sb.GetJail()+clitest.Home+"/kegs/personal/4/README.md")
+
+	res = clitest.Run(sb, "cat", "99", "--keg", "personal")
+	require.Error(t, res.Err)
+	require.NotZero(t, res.ExitCode)
+}
+
+func TestNewCompletionProcess(t *testing.T) {
+	t.Parallel()
+	sb := clitest.NewSandbox(t, sandbox.WithFixture("joe", "~"))
+
+	res := clitest.NewCompletionProcess(t, false, "--keg", "").
+		Run(sb.Context(), sb.Runtime())
+	require.NoError(t, res.Err)
+	require.Contains(t, string(res.Stdout), "personal")
+}
@@ -0,0 +1,88 @@
+package keg
+
+import (
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// maxAssetNameLen is the longest asset name accepted, matching the common
+// 255-byte filename limit of local filesystems.
+const maxAssetNameLen = 255
+
+// ValidateAssetName reports whether name is safe to use as an image or
+// attachment name. A valid name is a single path component: it is not empty,
+// "." or "..", contains no slash, backslash, drive prefix or control
+// character, and is at most 255 bytes of valid UTF-8. Violations are returned
+// as *InvalidNameError.
+func ValidateAssetName(name string) error {
+	reason := assetNameProblem(name)
+	if reason == "" {
+		return nil
+	}
+	return &InvalidNameError{Name: name, Reason: reason}
+}
+
+// SanitizeAssetName turns an arbitrary file name, such as the base name of an
+// uploaded local file, into a valid asset name. Directory components are
+// dropped, control characters and separators become "_" and surrounding
+// whitespace is trimmed. The result is in Unicode NFC. It returns "" when
+// nothing usable remains.
+func SanitizeAssetName(name string) string {
+	if i := strings.LastIndexAny(name, `/\`); i >= 0 {
+		name = name[i+1:]
+	}
+	name = norm.NFC.String(strings.ToValidUTF8(name, "_"))
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || r == ':' {
+			return '_'
+		}
+		return r
+	}, name)
+	name = strings.TrimSpace(name)
+	for len(name) > maxAssetNameLen {
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = name[:len(name)-size]
+	}
+	if name == "." || name == ".." {
+		return ""
+	}
+	return name
+}
+
+// checkAssetName validates the name a repository receives for an asset and
+// returns it in Unicode NFC, so "café.png" typed on macOS (NFD) and on Linux
+// (NFC) refer to the same asset. Besides plain asset names it accepts the
+// NodeVersionsDir/NAME paths content versions are stored under.
+func checkAssetName(name string) (string, error) {
+	name = norm.NFC.String(name)
+	if rest, ok := strings.CutPrefix(name, NodeVersionsDir+"/"); ok {
+		if assetNameProblem(rest) == "" {
+			return name, nil
+		}
+	}
+	return name, ValidateAssetName(name)
+}
+
+func assetNameProblem(name string) string {
+	switch {
+	case name == "":
+		return "name is empty"
+	case name == "." || name == "..":
+		return "name is a relative directory reference"
+	case len(name) > maxAssetNameLen:
+		return "name is longer than 255 bytes"
+	case !utf8.ValidString(name):
+		return "name is not valid UTF-8"
+	case strings.ContainsAny(name, `/\`):
+		return "name contains a path separator"
+	case filepath.VolumeName(name) != "" || (len(name) >= 2 && name[1] == ':'):
+		return "name has a drive prefix"
+	case strings.IndexFunc(name, unicode.IsControl) >= 0:
+		return "name contains a control character"
+	}
+	return ""
+}
@@ -0,0 +1,100 @@
+package keg_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jlrickert/tapper/pkg/keg"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateAssetName(t *testing.T) {
+	t.Parallel()
+
+	for _, name := range []string{"diagram.png", "notes v2.md", ".env", "résumé.pdf"} {
+		require.NoError(t, keg.ValidateAssetName(name), name)
+	}
+
+	for _, name := range []string{
+		"",
+		".",
+		"..",
+		"../../etc/passwd",
+		"sub/file.txt",
+		`..\windows.ini`,
+		"/etc/passwd",
+		"C:evil.txt",
+		"bad\x00name",
+		"tab\tname",
+		strings.Repeat("a", 256),
+		"\xff\xfe",
+	} {
+		err := keg.ValidateAssetName(name)
+		require.ErrorIs(t, err, keg.ErrInvalidName, "%q", name)
+		require.ErrorIs(t, err, keg.ErrInvalid, "%q", name)
+		var nameErr *keg.InvalidNameError
+		require.ErrorAs(t, err, &nameErr)
+		require.Equal(t, name, nameErr.Name)
+	}
+}
+
+func TestSanitizeAssetName(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]string{
+		"diagram.png":        "diagram.png",
+		"../../etc/passwd":   "passwd",
+		`C:\Users\me\a.txt`:  "a.txt",
+		" spaced.txt ":       "spaced.txt",
+		"line\nbreak.txt":    "line_break.txt",
+		"..":                 "",
+		"dir/":               "",
+		"c:drive-prefix.txt": "c_drive-prefix.txt",
+	}
+	for in, want := range cases {
+		got := keg.SanitizeAssetName(in)
+		require.Equal(t, want, got, "%q", in)
+		if got != "" {
+			require.NoError(t, keg.ValidateAssetName(got), "%q", in)
+		}
+	}
+}
+
+func TestMemoryRepo_RejectsUnsafeAssetNames(t *testing.T) {
+	t.Parallel()
+	fx := NewSandbox(t)
+	ctx := fx.Context()
+
+	r := keg.NewMemoryRepo(fx.Runtime())
+	id := keg.NodeId{ID: 1}
+	require.NoError(t, r.WriteContent(ctx, id, []byte("# one\n")))
+
+	require.ErrorIs(t, r.WriteFile(ctx, id, "../0/escape.txt", []byte("x")), keg.ErrInvalidName)
+	require.ErrorIs(t, r.WriteImage(ctx, id, "..", []byte("x")), keg.ErrInvalidName)
+	_, err := r.ReadFile(ctx, id, "../../keg")
+	require.ErrorIs(t, err, keg.ErrInvalidName)
+	require.ErrorIs(t, r.DeleteFile(ctx, id, "a/b"), keg.ErrInvalidName)
+}
+
+func TestMemoryRepo_AssetNamesAreNFC(t *testing.T) {
+	t.Parallel()
+	fx := NewSandbox(t)
+	ctx := fx.Context()
+
+	r := keg.NewMemoryRepo(fx.Runtime())
+	id := keg.NodeId{ID: 1}
+	require.NoError(t, r.WriteContent(ctx, id, []byte("# one\n")))
+
+	nfd := "cafe\u0301.txt"
+	nfc := "caf\u00e9.txt"
+	require.Equal(t, nfc, keg.SanitizeAssetName(nfd))
+
+	require.NoError(t, r.WriteFile(ctx, id, nfd, []byte("menu")))
+	got, err := r.ReadFile(ctx, id, nfc)
+	require.NoError(t, err)
+	require.Equal(t, "menu", string(got))
+
+	names, err := r.ListFiles(ctx, id)
+	require.NoError(t, err)
+	require.Equal(t, []string{nfc}, names)
+}
@@ -0,0 +1,107 @@
+package keg
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// DefaultBacklinksHeading is the heading used for generated backlinks
+// sections when ExportConfig.BacklinksHeading is empty.
+const DefaultBacklinksHeading = "Backlinks"
+
+// Generated backlinks sections are wrapped in these HTML comments so
+// StripBacklinksSection can remove them again.
+const (
+	backlinksSectionStart = "<!-- keg:backlinks -->\n"
+	backlinksSectionEnd   = "<!-- /keg:backlinks -->\n"
+)
+
+// BacklinksSection renders a Markdown section listing every node that links
+// to id, one bullet per source with its title and lead. Sources come from the
+// backlinks index in ID order. The section is wrapped in comment markers, and
+// it is nil when the node has no backlinks.
+func (k *Keg) BacklinksSection(ctx context.Context, id NodeId, heading string) ([]byte, error) {
+	dex, err := k.Dex(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sources, _ := dex.Backlinks(ctx, id)
+	if len(sources) == 0 {
+		return nil, nil
+	}
+	heading = strings.TrimSpace(heading)
+	if heading == "" {
+		heading = DefaultBacklinksHeading
+	}
+
+	var b bytes.Buffer
+	b.WriteString(backlinksSectionStart)
+	fmt.Fprintf(&b, "## %s\n\n", heading)
+	for _, src := range sources {
+		title, lead, err := k.titleAndLead(ctx, dex, src)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read backlink %s: %w", src.Path(), err)
+		}
+		fmt.Fprintf(&b, "- [%s](../%s)", title, src.Path())
+		if lead != "" {
+			fmt.Fprintf(&b, " — %s", lead)
+		}
+		b.WriteByte('\n')
+	}
+	b.WriteString(backlinksSectionEnd)
+	return b.Bytes(), nil
+}
+
+// AppendBacklinksSection returns content with section appended after a blank
+// line. Content is returned unchanged when section is empty.
+func AppendBacklinksSection(content, section []byte) []byte {
+	if len(section) == 0 {
+		return content
+	}
+	out := append([]byte(nil), content...)
+	if len(out) > 0 && !bytes.HasSuffix(out, []byte("\n")) {
+		out = append(out, '\n')
+	}
+	out = append(out, '\n')
+	return append(out, section...)
+}
+
+// StripBacklinksSection removes a section added by AppendBacklinksSection,
+// returning the content it was appended to. Content without a generated
+// section is returned unchanged.
+func StripBacklinksSection(content []byte) []byte {
+	start := bytes.LastIndex(content, []byte(backlinksSectionStart))
+	if start < 0 || !bytes.HasSuffix(content, []byte(backlinksSectionEnd)) {
+		return content
+	}
+	out := content[:start]
+	if bytes.HasSuffix(out, []byte("\n\n")) {
+		out = out[:len(out)-1]
+	}
+	return out
+}
+
+func (k *Keg) titleAndLead(ctx context.Context, dex *Dex, id NodeId) (string, string, error) {
+	title := ""
+	if ref := dex.GetRef(ctx, id); ref != nil {
+		title = ref.Title
+	}
+	raw, err := k.Repo.ReadContent(ctx, id)
+	if err != nil && !errors.Is(err, ErrNotExist) {
+		return "", "", err
+	}
+	content, err := ParseContent(k.Runtime, raw, k.ContentFormat(ctx))
+	if err != nil {
+		return "", "", err
+	}
+	if title == "" {
+		title = content.Title
+	}
+	if title == "" {
+		title = id.Path()
+	}
+	return title, strings.Join(strings.Fields(content.Lead), " "), nil
+}
@@ -0,0 +1,62 @@
+// Package bench measures repository and index operations on synthetic kegs
+// of 1k, 10k and 100k nodes, built by Fixture. Run the benchmarks with
+//
+//	go test ./pkg/keg/bench -run '^$' -bench . -benchmem
+//
+// Add -short to skip the 100k scale, and compare runs with benchstat.
+// Generated kegs are cached per backend and size for the whole run, so only
+// the first benchmark at a scale pays for building it.
+package bench
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"strings"
+
+	"github.com/jlrickert/tapper/pkg/kegtest"
+)
+
+// Sizes are the node counts the benchmarks run at.
+var Sizes = []int{1_000, 10_000, 100_000}
+
+var (
+	words = strings.Fields(`alpha branch cache delta engine field graph
+		handle index journal kernel ledger module network object parser query
+		record schema thread update vector window yield zone`)
+	tags = strings.Fields(`go rust testing design ops infra notes draft
+		reference howto idea meeting review release docs api cli storage
+		index search sync perf security ux`)
+)
+
+// Fixture returns a keg of n nodes numbered 1 to n. Titles, bodies, tags and
+// links to earlier nodes come from a generator seeded with n, so a size
+// always produces the same keg.
+func Fixture(n int) *kegtest.KegFixture {
+	r := rand.New(rand.NewPCG(uint64(n), 0x6b6567))
+	pick := func(from []string, count int) []string {
+		out := make([]string, count)
+		for i := range out {
+			out[i] = from[r.IntN(len(from))]
+		}
+		return out
+	}
+
+	f := kegtest.NewKegFixture().Title(fmt.Sprintf("Benchmark keg (%d nodes)", n))
+	for id := 1; id <= n; id++ {
+		var body strings.Builder
+		for range 2 + r.IntN(4) {
+			body.WriteString(strings.Join(pick(words, 8+r.IntN(8)), " "))
+			body.WriteString(".\n")
+		}
+		f.Node(id, fmt.Sprintf("Node %d %s", id, strings.Join(pick(words, 2), " ")), body.String())
+		f.Tag(pick(tags, 1+r.IntN(3))...)
+		if id > 1 {
+			links := make([]int, r.IntN(5))
+			for i := range links {
+				links[i] = 1 + r.IntN(id-1)
+			}
+			f.Link(links...)
+		}
+	}
+	return f
+}
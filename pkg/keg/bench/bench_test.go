@@ -0,0 +1,233 @@
+package bench
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jlrickert/cli-toolkit/clock"
+	"github.com/jlrickert/cli-toolkit/toolkit"
+	"github.com/jlrickert/tapper/pkg/keg"
+	"github.com/jlrickert/tapper/pkg/keg/search"
+)
+
+var (
+	// root holds the filesystem kegs for the run.
+	root string
+
+	mu   sync.Mutex
+	rt   *toolkit.Runtime
+	kegs = map[string]*keg.Keg{}
+)
+
+func TestMain(m *testing.M) {
+	var err error
+	if root, err = os.MkdirTemp("", "keg-bench-"); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	rt, err = toolkit.NewRuntime(toolkit.WithRuntimeClock(
+		clock.NewTestClock(time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC))))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	code := m.Run()
+	_ = os.RemoveAll(root)
+	os.Exit(code)
+}
+
+// backends are the repositories benchmarks that touch storage run against.
+var backends = []string{"memory", "fs"}
+
+// benchKeg returns the indexed keg of n nodes on backend, building it on
+// first use.
+func benchKeg(b *testing.B, backend string, n int) *keg.Keg {
+	b.Helper()
+	if n > 10_000 && testing.Short() {
+		b.Skip("skipping 100k nodes in short mode")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	key := backend + "-" + label(n)
+	if k, ok := kegs[key]; ok {
+		return k
+	}
+
+	var repo keg.Repository
+	switch backend {
+	case "memory":
+		repo = keg.NewMemoryRepo(rt)
+	case "fs":
+		repo = keg.NewFsRepo(filepath.Join(root, key), rt)
+	default:
+		b.Fatalf("unknown backend %q", backend)
+	}
+	start := time.Now()
+	k, err := Fixture(n).Build(context.Background(), repo, rt)
+	if err != nil {
+		b.Fatalf("unable to build %s keg: %v", key, err)
+	}
+	b.Logf("built %s keg in %s", key, time.Since(start).Round(time.Millisecond))
+	kegs[key] = k
+	return k
+}
+
+// run runs fn as a sub-benchmark for each backend and size.
+func run(b *testing.B, backends []string, fn func(b *testing.B, k *keg.Keg)) {
+	for _, backend := range backends {
+		for _, n := range Sizes {
+			b.Run(backend+"/"+label(n), func(b *testing.B) {
+				k := benchKeg(b, backend, n)
+				b.ReportAllocs()
+				fn(b, k)
+			})
+		}
+	}
+}
+
+func label(n int) string {
+	if n >= 1000 && n%1000 == 0 {
+		return fmt.Sprintf("%dk", n/1000)
+	}
+	return fmt.Sprint(n)
+}
+
+func BenchmarkListNodes(b *testing.B) {
+	run(b, backends, func(b *testing.B, k *keg.Keg) {
+		ctx := b.Context()
+		for b.Loop() {
+			if _, err := k.Repo.ListNodes(ctx); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkIndexRebuild(b *testing.B) {
+	run(b, backends, func(b *testing.B, k *keg.Keg) {
+		ctx := b.Context()
+		for b.Loop() {
+			if err := k.Index(ctx, keg.IndexOptions{Rebuild: true}); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// BenchmarkIndexIncremental measures an index run with nothing to update,
+// the floor an incremental index pays for scanning the keg.
+func BenchmarkIndexIncremental(b *testing.B) {
+	run(b, backends, func(b *testing.B, k *keg.Keg) {
+		ctx := b.Context()
+		for b.Loop() {
+			if err := k.Index(ctx, keg.IndexOptions{}); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkLoadDex(b *testing.B) {
+	run(b, backends, func(b *testing.B, k *keg.Keg) {
+		ctx := b.Context()
+		for b.Loop() {
+			if _, err := keg.NewDexFromRepo(ctx, k.Repo); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkParseNodeIndex(b *testing.B) {
+	benchParse(b, "nodes.tsv", func(ctx context.Context, data []byte) error {
+		_, err := keg.ParseNodeIndex(ctx, data)
+		return err
+	})
+}
+
+func BenchmarkParseChangesIndex(b *testing.B) {
+	benchParse(b, "changes.md", func(ctx context.Context, data []byte) error {
+		_, err := keg.ParseChangesIndex(ctx, data)
+		return err
+	})
+}
+
+func benchParse(b *testing.B, index string, parse func(context.Context, []byte) error) {
+	run(b, []string{"memory"}, func(b *testing.B, k *keg.Keg) {
+		ctx := b.Context()
+		data, err := k.Repo.GetIndex(ctx, index)
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.SetBytes(int64(len(data)))
+		for b.Loop() {
+			if err := parse(ctx, data); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// BenchmarkSearchRegex scans every node's content the way tap search does
+// for a plain-text query.
+func BenchmarkSearchRegex(b *testing.B) {
+	re := regexp.MustCompile("(?i)" + regexp.QuoteMeta("kernel ledger"))
+	run(b, backends, func(b *testing.B, k *keg.Keg) {
+		ctx := b.Context()
+		dex, err := k.Dex(ctx)
+		if err != nil {
+			b.Fatal(err)
+		}
+		for b.Loop() {
+			for _, entry := range dex.Nodes(ctx) {
+				id, err := keg.ParseNode(entry.ID)
+				if err != nil {
+					b.Fatal(err)
+				}
+				raw, err := k.Repo.ReadContent(ctx, *id)
+				if err != nil {
+					b.Fatal(err)
+				}
+				if _, err := search.ScanRegex(re, bytes.NewReader(raw)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+}
+
+func BenchmarkSearchTags(b *testing.B) {
+	expr, err := keg.ParseTagExpression("(go or rust) and not draft")
+	if err != nil {
+		b.Fatal(err)
+	}
+	run(b, []string{"memory"}, func(b *testing.B, k *keg.Keg) {
+		ctx := b.Context()
+		dex, err := k.Dex(ctx)
+		if err != nil {
+			b.Fatal(err)
+		}
+		universe := map[string]struct{}{}
+		for _, entry := range dex.Nodes(ctx) {
+			universe[entry.ID] = struct{}{}
+		}
+		resolve := func(tag string) map[string]struct{} {
+			ids, _ := dex.TagNodes(ctx, tag)
+			set := make(map[string]struct{}, len(ids))
+			for _, id := range ids {
+				set[id.Path()] = struct{}{}
+			}
+			return set
+		}
+		for b.Loop() {
+			keg.EvaluateTagExpression(expr, universe, resolve)
+		}
+	})
+}
@@ -0,0 +1,50 @@
+package keg
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var byteSizeUnits = []struct {
+	suffix string
+	size   int64
+}{
+	{"GB", 1 << 30},
+	{"MB", 1 << 20},
+	{"KB", 1 << 10},
+	{"G", 1 << 30},
+	{"M", 1 << 20},
+	{"K", 1 << 10},
+	{"B", 1},
+}
+
+// ParseByteSize parses a size such as "512", "200KB" or "1.5GB". Units are
+// binary (1KB = 1024 bytes) and case-insensitive.
+func ParseByteSize(raw string) (int64, error) {
+	s := strings.ToUpper(strings.TrimSpace(raw))
+	multiplier := int64(1)
+	for _, unit := range byteSizeUnits {
+		if rest, ok := strings.CutSuffix(s, unit.suffix); ok {
+			s = strings.TrimSpace(rest)
+			multiplier = unit.size
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q: %w", raw, ErrInvalid)
+	}
+	return int64(n * float64(multiplier)), nil
+}
+
+// FormatByteSize renders n bytes using the largest binary unit that keeps
+// the value at or above one, for example "1.5MB".
+func FormatByteSize(n int64) string {
+	for _, unit := range byteSizeUnits[:3] {
+		if n >= unit.size {
+			return strconv.FormatFloat(float64(n)/float64(unit.size), 'f', 1, 64) + unit.suffix
+		}
+	}
+	return strconv.FormatInt(n, 10) + "B"
+}
@@ -0,0 +1,30 @@
+package keg_test
+
+import (
+	"testing"
+
+	"github.com/jlrickert/tapper/pkg/keg"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseByteSize(t *testing.T) {
+	t.Parallel()
+	cases := map[string]int64{
+		"512":   512,
+		"10B":   10,
+		"200kb": 200 << 10,
+		"25MB":  25 << 20,
+		"1.5G":  3 << 29,
+		" 2 KB": 2 << 10,
+	}
+	for raw, want := range cases {
+		got, err := keg.ParseByteSize(raw)
+		require.NoError(t, err, raw)
+		require.Equal(t, want, got, raw)
+	}
+
+	for _, raw := range []string{"", "MB", "-1KB", "ten"} {
+		_, err := keg.ParseByteSize(raw)
+		require.ErrorIs(t, err, keg.ErrInvalid, raw)
+	}
+}
@@ -3,14 +3,16 @@ package keg
 import (
 	"bufio"
 	"bytes"
+	"net/url"
 	"regexp"
-	"sort"
+	"slices"
 	"strings"
 
 	"github.com/jlrickert/cli-toolkit/toolkit"
 	"github.com/yuin/goldmark"
 	gm_ast "github.com/yuin/goldmark/ast"
 	"github.com/yuin/goldmark/text"
+	"golang.org/x/text/unicode/norm"
 	"gopkg.in/yaml.v3"
 )
 
@@ -23,6 +25,7 @@ import (
 //   - Lead: first paragraph immediately following the title (used as a short
 //     summary).
 //   - Links: numeric outgoing node links discovered in the content (../N).
+//   - Media: images and file attachments referenced by the content.
 //   - Format: short hint of the detected format ("markdown", "rst", or "empty").
 //   - Frontmatter: parsed YAML frontmatter when present (Markdown only).
 //   - Body: the raw body bytes of the content file with frontmatter removed for
@@ -44,6 +47,11 @@ type NodeContent struct {
 	// content (for example "../42"). Entries are normalized NodeId values.
 	Links []NodeId
 
+	// Media lists the images and file attachments referenced by the content
+	// (for example "images/plot.png" or "../42/assets/report.pdf"), in order
+	// of first appearance. It is only populated for Markdown.
+	Media []MediaRef
+
 	// Format is a short hint of the detected format. Typical values are
 	// "markdown", "rst", or "empty".
 	Format string
@@ -59,10 +67,11 @@ type NodeContent struct {
 
 // ParseContent extracts a NodeContent value from raw file bytes.
 //
-// The format parameter is a filename hint (e.g., "README.md", "README.rst").
-// When format is ambiguous the function applies simple heuristics to choose
-// between Markdown and reStructuredText. The returned NodeContent contains a
-// deterministic, deduplicated, sorted list of discovered numeric links.
+// The format parameter is a filename hint (e.g., "README.md", "README.rst")
+// or the name of a registered format (see RegisterFormat). When format does
+// not name a format, the registered matchers choose one, falling back to
+// Markdown. The returned NodeContent contains a deterministic, deduplicated,
+// sorted list of discovered numeric links.
 //
 // ParseContent uses the provided runtime hasher to compute content Hash.
 // If the input is empty or only whitespace, a NodeContent with Format == "empty"
@@ -72,68 +81,21 @@ func ParseContent(rt *toolkit.Runtime, data []byte, format string) (*NodeContent
 		return &NodeContent{Format: "empty"}, nil
 	}
 
-	fmt := detectFormat(data, format)
-	hasher := rt.Hasher()
-
-	var title, lead string
-	var fm map[string]any
-	var contentData []byte
-
-	switch fmt {
-	case "rst":
-		// RST: no frontmatter handling for now
-		title, lead = extractRSTTitleAndLead(data)
-		contentData = data
-	default:
-		// default to markdown heuristics
-		// Support YAML frontmatter at the start of the document.
-		fm, contentData = extractMarkdownFrontmatter(data)
-		title, lead = extractMarkdownTitleAndLead(contentData)
-		fmt = "markdown"
+	f := lookupFormat(data, format)
+	content, err := f.parser(data)
+	if err != nil {
+		return nil, err
 	}
-
-	links := extractNumericLinks(contentData)
-
-	// sort & dedupe node ids (stable deterministic order)
-	links = dedupeAndSortNodeIDs(links)
-
-	return &NodeContent{
-		Hash:        hasher.Hash(data),
-		Title:       title,
-		Lead:        lead,
-		Links:       links,
-		Format:      fmt,
-		Frontmatter: fm,
-		Body:        string(contentData),
-	}, nil
-}
-
-// detectFormat returns "rst" or "markdown" using a filename hint and a small
-// content-based heuristic. If the provided format string ends with ".rst" or
-// ".rest" we prefer "rst". Otherwise we inspect the second line of the file:
-// an RST title is commonly followed by a line of === or --- that matches the
-// underline style.
-func detectFormat(data []byte, format string) string {
-	lower := strings.ToLower(format)
-	if strings.HasSuffix(lower, ".rst") || strings.HasSuffix(lower, ".rest") {
-		return "rst"
+	if content.Links == nil {
+		content.Links = extractNumericLinks([]byte(content.Body))
 	}
-	// simple heuristic: rst titles often use underline of === or --- on 2nd line
-	scanner := bufio.NewScanner(bytes.NewReader(data))
 
-	// We only need the second line for the underline heuristic; skip the first.
-	if !scanner.Scan() {
-		return "markdown"
-	}
-	var second string
-	if scanner.Scan() {
-		second = scanner.Text()
-	}
-	secondTrim := strings.TrimSpace(second)
-	if secondTrim != "" && (isAllRunes(secondTrim, '=') || isAllRunes(secondTrim, '-')) {
-		return "rst"
-	}
-	return "markdown"
+	content.Hash = rt.Hasher().Hash(data)
+	content.Title = norm.NFC.String(content.Title)
+	// sort & dedupe node ids (stable deterministic order)
+	content.Links = dedupeAndSortNodeIDs(content.Links)
+	content.Format = f.name
+	return content, nil
 }
 
 // isAllRunes reports whether s is non-empty and consists entirely of runeChar.
@@ -335,6 +297,75 @@ func extractNumericLinks(data []byte) []NodeId {
 	return out
 }
 
+// MediaRef is a reference from node content to an image or file attachment.
+type MediaRef struct {
+	// Node owns the referenced item. It is nil when the item belongs to the
+	// referencing node itself.
+	Node *NodeId
+	Kind AssetKind
+	Name string
+}
+
+var (
+	// mediaDestRE matches item paths relative to a node directory, optionally
+	// through a sibling node: images/x.png, ./assets/y.pdf, ../42/images/z.png.
+	mediaDestRE = regexp.MustCompile(`^\s*(?:\./)?(?:\.\./([0-9]+)/)?(` + NodeImagesDir + `|` + NodeAttachmentsDir + `)/([^?#\s]+)`)
+	// htmlMediaRE finds src and href attributes in raw HTML.
+	htmlMediaRE = regexp.MustCompile(`(?i)\b(?:src|href)\s*=\s*["']([^"']+)["']`)
+)
+
+// extractMediaRefs returns the images and attachments referenced by Markdown
+// links, images and raw HTML src/href attributes.
+func extractMediaRefs(data []byte) []MediaRef {
+	var dests []string
+	doc := goldmark.New().Parser().Parse(text.NewReader(data))
+	_ = gm_ast.Walk(doc, func(n gm_ast.Node, entering bool) (gm_ast.WalkStatus, error) {
+		if !entering {
+			return gm_ast.WalkContinue, nil
+		}
+		switch v := n.(type) {
+		case *gm_ast.Link:
+			dests = append(dests, string(v.Destination))
+		case *gm_ast.Image:
+			dests = append(dests, string(v.Destination))
+		}
+		return gm_ast.WalkContinue, nil
+	})
+	for _, m := range htmlMediaRE.FindAllSubmatch(data, -1) {
+		dests = append(dests, string(m[1]))
+	}
+
+	var out []MediaRef
+	seen := map[string]bool{}
+	for _, dest := range dests {
+		m := mediaDestRE.FindStringSubmatch(dest)
+		if m == nil {
+			continue
+		}
+		name, err := url.PathUnescape(m[3])
+		if err != nil {
+			name = m[3]
+		}
+		ref := MediaRef{Kind: AssetKindImage, Name: name}
+		if m[2] == NodeAttachmentsDir {
+			ref.Kind = AssetKindItem
+		}
+		if m[1] != "" {
+			id, err := ParseNode(m[1])
+			if err != nil || id == nil {
+				continue
+			}
+			ref.Node = id
+		}
+		key := m[1] + "/" + string(ref.Kind) + "/" + ref.Name
+		if !seen[key] {
+			seen[key] = true
+			out = append(out, ref)
+		}
+	}
+	return out
+}
+
 // dedupeAndSortNodeIDs removes duplicates from the input slice and returns a
 // new slice sorted in ascending numeric order. The operation is deterministic
 // and suitable for producing stable index outputs.
@@ -349,11 +380,8 @@ func dedupeAndSortNodeIDs(in []NodeId) []NodeId {
 		set[key] = struct{}{}
 		out = append(out, id)
 	}
-	sort.Slice(out, func(i, j int) bool {
-		a := out[i].Path()
-		b := out[j].Path()
-		return strings.Compare(a, b) < 0
-	})
+	// Order numerically; comparing paths would put ../10 before ../2.
+	slices.SortFunc(out, NodeId.Compare)
 	return out
 }
 
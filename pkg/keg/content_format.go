@@ -0,0 +1,146 @@
+package keg
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// FormatMatcher reports whether data is in a content format. hint is the
+// filename or format name given to ParseContent and may be empty.
+type FormatMatcher func(data []byte, hint string) bool
+
+// FormatParser extracts the title, lead, body, frontmatter and media of a
+// document. ParseContent fills in Hash and Format, normalizes the title and,
+// when the parser leaves Links nil, collects ../N links from Body.
+type FormatParser func(data []byte) (*NodeContent, error)
+
+type contentFormat struct {
+	name    string
+	matcher FormatMatcher
+	parser  FormatParser
+}
+
+// formats holds the registered content formats in registration order.
+var formats = struct {
+	mu   sync.RWMutex
+	list []contentFormat
+}{
+	list: []contentFormat{
+		{name: FormatMarkdown, parser: parseMarkdownContent},
+		{name: FormatRST, matcher: isRSTContent, parser: parseRSTContent},
+	},
+}
+
+// RegisterFormat makes a content format available to ParseContent, for
+// packages adding formats such as AsciiDoc or org. matcher may be nil, in
+// which case the format is only used when named by a ParseContent hint or a
+// keg's format setting. Registering an existing name replaces it;
+// registering markdown replaces the fallback used when nothing matches.
+// RegisterFormat panics if name is empty or parser is nil.
+func RegisterFormat(name string, matcher FormatMatcher, parser FormatParser) {
+	if name == "" || parser == nil {
+		panic("keg: RegisterFormat needs a name and a parser")
+	}
+	formats.mu.Lock()
+	defer formats.mu.Unlock()
+	formats.list = slices.DeleteFunc(formats.list, func(f contentFormat) bool { return f.name == name })
+	formats.list = append(formats.list, contentFormat{name: name, matcher: matcher, parser: parser})
+}
+
+// Formats returns the names of the registered content formats, sorted.
+func Formats() []string {
+	formats.mu.RLock()
+	defer formats.mu.RUnlock()
+	names := make([]string, 0, len(formats.list))
+	for _, f := range formats.list {
+		names = append(names, f.name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// lookupFormat picks the format for data. A hint naming a registered format
+// other than markdown selects it. Otherwise matchers run, most recently
+// registered first, and markdown is used when none match.
+func lookupFormat(data []byte, hint string) contentFormat {
+	formats.mu.RLock()
+	defer formats.mu.RUnlock()
+	name := strings.ToLower(strings.TrimSpace(hint))
+	var fallback contentFormat
+	for _, f := range formats.list {
+		if f.name == FormatMarkdown {
+			fallback = f
+		} else if f.name == name {
+			return f
+		}
+	}
+	for _, f := range slices.Backward(formats.list) {
+		if f.matcher != nil && f.matcher(data, hint) {
+			return f
+		}
+	}
+	return fallback
+}
+
+// ContentFormat returns the format hint for parsing node content: the keg
+// config's format when set, otherwise markdown.
+func (k *Keg) ContentFormat(ctx context.Context) string {
+	if cfg, err := k.Repo.ReadConfig(ctx); err == nil && cfg != nil && cfg.Format != "" {
+		return cfg.Format
+	}
+	return FormatMarkdown
+}
+
+// KeepFrontmatter reports whether the keg config asks Create to keep YAML
+// frontmatter in node content.
+func (k *Keg) KeepFrontmatter(ctx context.Context) bool {
+	cfg, err := k.Repo.ReadConfig(ctx)
+	return err == nil && cfg != nil && cfg.KeepFrontmatter
+}
+
+func parseMarkdownContent(data []byte) (*NodeContent, error) {
+	// Support YAML frontmatter at the start of the document.
+	fm, body := extractMarkdownFrontmatter(data)
+	title, lead := extractMarkdownTitleAndLead(body)
+	return &NodeContent{
+		Title:       title,
+		Lead:        lead,
+		Media:       extractMediaRefs(body),
+		Body:        string(body),
+		Frontmatter: fm,
+	}, nil
+}
+
+func parseRSTContent(data []byte) (*NodeContent, error) {
+	// RST: no frontmatter handling for now
+	title, lead := extractRSTTitleAndLead(data)
+	return &NodeContent{Title: title, Lead: lead, Body: string(data)}, nil
+}
+
+// isRSTContent matches reStructuredText using a filename hint and a small
+// content-based heuristic. A hint ending in ".rst" or ".rest" matches.
+// Otherwise we inspect the second line of the file: an RST title is commonly
+// followed by a line of === or --- that matches the underline style.
+func isRSTContent(data []byte, hint string) bool {
+	lower := strings.ToLower(hint)
+	if strings.HasSuffix(lower, ".rst") || strings.HasSuffix(lower, ".rest") {
+		return true
+	}
+	// simple heuristic: rst titles often use underline of === or --- on 2nd line
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+
+	// We only need the second line for the underline heuristic; skip the first.
+	if !scanner.Scan() {
+		return false
+	}
+	var second string
+	if scanner.Scan() {
+		second = scanner.Text()
+	}
+	secondTrim := strings.TrimSpace(second)
+	return secondTrim != "" && (isAllRunes(secondTrim, '=') || isAllRunes(secondTrim, '-'))
+}
@@ -0,0 +1,98 @@
+package keg_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jlrickert/tapper/pkg/keg"
+	"github.com/jlrickert/tapper/pkg/kegtest"
+	"github.com/stretchr/testify/require"
+)
+
+// parseOrg is a toy org-mode parser: the title comes from #+TITLE and the
+// lead is the first other non-empty line.
+func parseOrg(data []byte) (*keg.NodeContent, error) {
+	c := &keg.NodeContent{Body: string(data)}
+	for line := range strings.Lines(string(data)) {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "#+TITLE:"):
+			c.Title = strings.TrimSpace(strings.TrimPrefix(line, "#+TITLE:"))
+		case line != "" && c.Lead == "":
+			c.Lead = line
+		}
+	}
+	return c, nil
+}
+
+func init() {
+	// The matcher only looks at the hint so it cannot claim content from
+	// other tests.
+	keg.RegisterFormat("org", func(_ []byte, hint string) bool {
+		return strings.HasSuffix(hint, ".org")
+	}, parseOrg)
+}
+
+func TestParseContent_BuiltinFormats(t *testing.T) {
+	t.Parallel()
+	rt := testRuntime(t)
+
+	c, err := keg.ParseContent(rt, []byte("Title\n=====\n\nThe lead.\n"), keg.FormatMarkdown)
+	require.NoError(t, err)
+	require.Equal(t, keg.FormatRST, c.Format, "rst is detected from the title underline")
+	require.Equal(t, "Title", c.Title)
+
+	c, err = keg.ParseContent(rt, []byte("Title\n\nSee ../3.\n"), "README.rst")
+	require.NoError(t, err)
+	require.Equal(t, keg.FormatRST, c.Format)
+	require.Equal(t, []keg.NodeId{{ID: 3}}, c.Links)
+
+	require.Subset(t, keg.Formats(), []string{keg.FormatMarkdown, keg.FormatRST})
+}
+
+func TestParseContent_RegisteredFormat(t *testing.T) {
+	t.Parallel()
+	rt := testRuntime(t)
+	org := []byte("#+TITLE: Org notes\n\nSee ../4 and ../2.\n")
+
+	c, err := keg.ParseContent(rt, org, "README.org")
+	require.NoError(t, err)
+	require.Equal(t, "org", c.Format)
+	require.Equal(t, "Org notes", c.Title)
+	require.Equal(t, "See ../4 and ../2.", c.Lead)
+	require.Equal(t, []keg.NodeId{{ID: 2}, {ID: 4}}, c.Links, "links are collected from the body")
+	require.NotEmpty(t, c.Hash)
+
+	c, err = keg.ParseContent(rt, org, "org")
+	require.NoError(t, err)
+	require.Equal(t, "org", c.Format, "a hint naming the format selects it")
+
+	c, err = keg.ParseContent(rt, org, keg.FormatMarkdown)
+	require.NoError(t, err)
+	require.Equal(t, keg.FormatMarkdown, c.Format, "unmatched content falls back to markdown")
+}
+
+func TestKeg_ConfiguredContentFormat(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t)
+	ctx, rt := sb.Context(), sb.Runtime()
+	k := kegtest.NewKegFixture().MustBuild(t, ctx, keg.NewMemoryRepo(rt), rt)
+	require.Equal(t, keg.FormatMarkdown, k.ContentFormat(ctx))
+
+	require.NoError(t, k.UpdateConfig(ctx, func(cfg *keg.Config) { cfg.Format = "org" }))
+	require.Equal(t, "org", k.ContentFormat(ctx))
+
+	id, err := k.Create(ctx, &keg.CreateOptions{Body: []byte("#+TITLE: Planning\n\nQuarterly goals.\n")})
+	require.NoError(t, err)
+	dex, err := k.Dex(ctx)
+	require.NoError(t, err)
+	ref := dex.GetRef(ctx, id)
+	require.NotNil(t, ref)
+	require.Equal(t, "Planning", ref.Title)
+
+	require.NoError(t, k.SetContent(ctx, id, []byte("#+TITLE: Planning v2\n\nRevised goals.\n")))
+	stats, err := k.GetStats(ctx, id)
+	require.NoError(t, err)
+	require.Equal(t, "Planning v2", stats.Title())
+	require.Equal(t, "Revised goals.", stats.Lead())
+}
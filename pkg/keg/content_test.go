@@ -9,7 +9,7 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
-func testRuntime(t *testing.T) *toolkit.Runtime {
+func testRuntime(t testing.TB) *toolkit.Runtime {
 	t.Helper()
 	rt, err := toolkit.NewTestRuntime(t.TempDir(), "/home/testuser", "testuser")
 	require.NoError(t, err)
@@ -142,3 +142,35 @@ Lead paragraph following the fallback title.
 	// Body should not contain frontmatter markers
 	require.False(t, strings.HasPrefix(c.Body, "---"))
 }
+
+func TestParseContent_MarkdownMediaRefs(t *testing.T) {
+	t.Parallel()
+	rt := testRuntime(t)
+	raw := []byte(strings.Join([]string{
+		"# Media",
+		"",
+		"![plot](images/plot.png) and [report](./assets/My%20Report.pdf?dl=1).",
+		"![again](images/plot.png) ![remote](https://example.com/images/x.png)",
+		"[shared](../42/images/logo.svg) [node](../42)",
+		`<img src="images/inline.jpg" alt="inline">`,
+	}, "\n"))
+
+	c, err := keg.ParseContent(rt, raw, keg.MarkdownContentFilename)
+	require.NoError(t, err)
+	require.Equal(t, []keg.MediaRef{
+		{Kind: keg.AssetKindImage, Name: "plot.png"},
+		{Kind: keg.AssetKindItem, Name: "My Report.pdf"},
+		{Node: &keg.NodeId{ID: 42}, Kind: keg.AssetKindImage, Name: "logo.svg"},
+		{Kind: keg.AssetKindImage, Name: "inline.jpg"},
+	}, c.Media)
+}
+
+func TestParseContent_TitleIsNFC(t *testing.T) {
+	rt := testRuntime(t)
+	nfd, err := keg.ParseContent(rt, []byte("# Cafe\u0301 menu\n\nLead.\n"), "README.md")
+	require.NoError(t, err)
+	nfc, err := keg.ParseContent(rt, []byte("# Caf\u00e9 menu\n\nLead.\n"), "README.md")
+	require.NoError(t, err)
+	require.Equal(t, "Caf\u00e9 menu", nfd.Title)
+	require.Equal(t, nfc.Title, nfd.Title)
+}
@@ -32,9 +32,18 @@ type Dex struct {
 	// changes is the reverse-chronological list of all nodes.
 	changes ChangesIndex
 
+	// rank holds link-based importance scores, recomputed on every Write.
+	rank RankIndex
+
 	// custom holds config-driven tag-filtered index builders.
 	custom []IndexBuilder
 
+	// indexed is set when nodes.tsv was loaded from or written to the
+	// repository, as opposed to never having been built.
+	indexed bool
+	// changesIndexed is the same for changes.md, which older kegs lack.
+	changesIndexed bool
+
 	mu sync.RWMutex
 }
 
@@ -47,7 +56,9 @@ type DexOption func(*Dex) error
 //   - is not one of the core protected index names.
 //
 // The short file name used with repo.WriteIndex is derived by stripping any
-// leading "dex/" prefix from entry.File.
+// leading "dex/" prefix from entry.File. Each cfg.SavedSearches entry is
+// materialized as a SavedSearchIndex written to dex/search-NAME.md, and a
+// summarizer section adds a LeadIndex written to dex/leads.tsv.
 func WithConfig(cfg *Config) DexOption {
 	return func(d *Dex) error {
 		if cfg == nil {
@@ -68,12 +79,22 @@ func WithConfig(cfg *Config) DexOption {
 			}
 			d.custom = append(d.custom, idx)
 		}
+		for _, search := range cfg.SavedSearches {
+			idx, err := NewSavedSearchIndex(search)
+			if err != nil {
+				return fmt.Errorf("dex: saved search %q: %w", search.Name, err)
+			}
+			d.custom = append(d.custom, idx)
+		}
+		if cfg.Summarizer != nil {
+			d.custom = append(d.custom, NewLeadIndex())
+		}
 		return nil
 	}
 }
 
 // NewDexFromRepo loads available index artifacts ("nodes.tsv", "tags", "links",
-// "backlinks", "changes.md") from the provided repository and returns a Dex
+// "backlinks", "changes.md", "rank.tsv") from the provided repository and returns a Dex
 // populated with parsed indexes. Missing or empty index files are treated as
 // empty datasets and do not cause an error. Additional DexOptions (e.g.
 // WithConfig) can be supplied to configure optional behaviour such as
@@ -91,6 +112,7 @@ func NewDexFromRepo(ctx context.Context, repo Repository, opts ...DexOption) (*D
 			errs = append(errs, fmt.Errorf("unable to read `nodes.tsv` index: %w", err))
 		}
 	} else {
+		d.indexed = true
 		ni, err := ParseNodeIndex(ctx, data)
 		if err != nil {
 			errs = append(errs, fmt.Errorf("unable to parse `nodes.tsv` index: %w", err))
@@ -158,11 +180,14 @@ func NewDexFromRepo(ctx context.Context, repo Repository, opts ...DexOption) (*D
 	// changes.md
 	if data, err := repo.GetIndex(ctx, "changes.md"); err != nil {
 		if errors.Is(err, ErrNotExist) {
-			d.changes = ChangesIndex{}
+			// Kegs indexed before changes.md existed: derive it from nodes.tsv
+			// so the next Write does not drop nodes from it.
+			d.changes = changesFromNodes(d.nodes)
 		} else {
 			errs = append(errs, fmt.Errorf("unable to read `changes.md` index: %w", err))
 		}
 	} else {
+		d.changesIndexed = true
 		ci, err := ParseChangesIndex(ctx, data)
 		if err != nil {
 			errs = append(errs, fmt.Errorf("unable to parse `changes.md` index: %w", err))
@@ -172,12 +197,37 @@ func NewDexFromRepo(ctx context.Context, repo Repository, opts ...DexOption) (*D
 		}
 	}
 
+	// rank.tsv
+	if data, err := repo.GetIndex(ctx, RankIndexName); err != nil {
+		if !errors.Is(err, ErrNotExist) {
+			errs = append(errs, fmt.Errorf("unable to read `%s` index: %w", RankIndexName, err))
+		}
+	} else {
+		d.rank, _ = ParseRankIndex(ctx, data)
+	}
+
 	// Apply options (e.g. WithConfig to register custom tag-filtered indexes).
 	for _, opt := range opts {
 		if err := opt(d); err != nil {
 			errs = append(errs, err)
 		}
 	}
+	for _, c := range d.custom {
+		loader, ok := c.(IndexLoader)
+		if !ok {
+			continue
+		}
+		data, err := repo.GetIndex(ctx, c.Name())
+		if errors.Is(err, ErrNotExist) {
+			continue
+		}
+		if err == nil {
+			err = loader.Load(ctx, data)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("unable to read `%s` index: %w", c.Name(), err))
+		}
+	}
 
 	if len(errs) > 0 {
 		return d, errors.Join(errs...)
@@ -237,6 +287,7 @@ func (dex *Dex) Clear(ctx context.Context) {
 	dex.tags = TagIndex{}
 	dex.links = LinkIndex{}
 	dex.backlinks = BacklinkIndex{}
+	dex.rank = RankIndex{}
 	_ = dex.changes.Clear(ctx)
 	for _, c := range dex.custom {
 		_ = c.Clear(ctx)
@@ -319,7 +370,10 @@ func (dex *Dex) NextNode(ctx context.Context) NodeId {
 // Write serializes the in-memory indexes and writes them atomically to the
 // provided repository using WriteIndex. If any write operation fails the error
 // chain is returned (errors.Join is used to aggregate multiple errors).
-func (dex *Dex) Write(ctx context.Context, repo Repository) error {
+func (dex *Dex) Write(ctx context.Context, repo Repository) (err error) {
+	ctx, span := tracer().Start(ctx, "dex.write")
+	defer func() { endSpan(span, err) }()
+
 	dex.mu.Lock()
 	defer dex.mu.Unlock()
 
@@ -333,6 +387,18 @@ func (dex *Dex) Write(ctx context.Context, repo Repository) error {
 		errsMu.Unlock()
 	}
 
+	dex.rank = computePageRank(dex.nodes.List(ctx), dex.links.data)
+	wg.Go(func() {
+		data, err := dex.rank.Data(ctx)
+		name := RankIndexName
+		if err != nil {
+			appendErr(fmt.Errorf("unable to create `%s` index: %w", name, err))
+		}
+		if err := repo.WriteIndex(ctx, name, data); err != nil {
+			appendErr(fmt.Errorf("unable to write `%s` index: %w", name, err))
+		}
+	})
+
 	wg.Go(func() {
 		nodesData, err := dex.nodes.Data(ctx)
 		name := "nodes.tsv"
@@ -405,6 +471,11 @@ func (dex *Dex) Write(ctx context.Context, repo Repository) error {
 	wg.Wait()
 
 	if len(errs) == 0 {
+		// What is on disk now matches memory, so earlier parse damage is gone.
+		dex.indexed = true
+		dex.changesIndexed = true
+		dex.nodes.malformed = 0
+		dex.changes.malformed = 0
 		return nil
 	}
 
@@ -3,6 +3,7 @@ package keg
 import (
 	"context"
 	"fmt"
+	"slices"
 	"sort"
 	"strings"
 	"time"
@@ -24,6 +25,8 @@ const changesTimeFmt = "2006-01-02 15:04:05Z"
 // Callers that require concurrent access should guard an instance with a mutex.
 type ChangesIndex struct {
 	data []NodeIndexEntry // sorted by Updated descending (newest first)
+	// malformed counts lines ParseChangesIndex skipped.
+	malformed int
 }
 
 // ParseChangesIndex parses the serialized dex/changes.md bytes into a
@@ -41,8 +44,13 @@ func ParseChangesIndex(ctx context.Context, data []byte) (ChangesIndex, error) {
 		return idx, nil
 	}
 	for ln := range strings.SplitSeq(s, "\n") {
-		entry, ok := parseChangesLine(strings.TrimSpace(ln))
+		ln = strings.TrimSpace(ln)
+		if ln == "" {
+			continue
+		}
+		entry, ok := parseChangesLine(ln)
 		if !ok {
+			idx.malformed++
 			continue
 		}
 		idx.data = append(idx.data, entry)
@@ -131,6 +139,15 @@ func (idx *ChangesIndex) Add(ctx context.Context, data *NodeData) error {
 	return nil
 }
 
+// changesFromNodes builds a ChangesIndex holding every entry of nodes.
+func changesFromNodes(nodes NodeIndex) ChangesIndex {
+	idx := ChangesIndex{data: slices.Clone(nodes.data)}
+	sort.SliceStable(idx.data, func(a, b int) bool {
+		return idx.data[a].Updated.After(idx.data[b].Updated)
+	})
+	return idx
+}
+
 // Rm removes the node identified by node from the index. If the node is not
 // present the call is a no-op.
 func (idx *ChangesIndex) Rm(ctx context.Context, node NodeId) error {
@@ -155,6 +172,7 @@ func (idx *ChangesIndex) Clear(ctx context.Context) error {
 		return nil
 	}
 	idx.data = []NodeIndexEntry{}
+	idx.malformed = 0
 	return nil
 }
 
@@ -202,6 +220,8 @@ var coreIndexNames = map[string]bool{
 	"dex/links":      true,
 	"dex/backlinks":  true,
 	"dex/tags":       true,
+	"dex/rank.tsv":   true,
+	"dex/leads.tsv":  true,
 }
 
 // IsCoreIndex reports whether the given index file path (as used in a keg
@@ -0,0 +1,176 @@
+package keg
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ClustersIndexName is the dex artifact holding cluster assignments written
+// by `tap index rebuild --analyze`.
+const ClustersIndexName = "clusters.tsv"
+
+// labelPropagationMaxIterations bounds DetectClusters on graphs that keep
+// oscillating between equally good labelings.
+const labelPropagationMaxIterations = 50
+
+// NodeCluster is a group of densely linked nodes.
+type NodeCluster struct {
+	// ID numbers clusters from 1, largest first.
+	ID int
+
+	// Members are sorted by node ID.
+	Members []NodeId
+}
+
+// DetectClusters groups nodes by label propagation over the link graph,
+// treating links as undirected. Every node starts with its own label and
+// repeatedly adopts the label most common among its neighbors, visiting
+// nodes in ID order and breaking ties toward the lowest label, so results are
+// deterministic. Clusters are numbered by descending size, then by their
+// lowest member. Nodes without links form singleton clusters.
+func (dex *Dex) DetectClusters(ctx context.Context) []NodeCluster {
+	if dex == nil {
+		return nil
+	}
+	dex.mu.RLock()
+	entries := dex.nodes.List(ctx)
+	nodes := make([]NodeId, 0, len(entries))
+	adj := make(map[string][]NodeId, len(entries))
+	for _, e := range entries {
+		id, err := ParseNode(e.ID)
+		if err != nil || id == nil {
+			continue
+		}
+		nodes = append(nodes, *id)
+		adj[id.Path()] = dex.adjacentLocked(*id)
+	}
+	dex.mu.RUnlock()
+
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Compare(nodes[j]) < 0 })
+	label := make(map[string]NodeId, len(nodes))
+	for _, n := range nodes {
+		label[n.Path()] = n
+	}
+
+	for iter := 0; iter < labelPropagationMaxIterations; iter++ {
+		changed := false
+		for _, n := range nodes {
+			counts := map[string]int{}
+			var best NodeId
+			bestCount := 0
+			for _, nb := range adj[n.Path()] {
+				l, ok := label[nb.Path()]
+				if !ok {
+					// Link target is not an indexed node.
+					continue
+				}
+				counts[l.Path()]++
+				c := counts[l.Path()]
+				if c > bestCount || (c == bestCount && l.Compare(best) < 0) {
+					best, bestCount = l, c
+				}
+			}
+			if bestCount == 0 {
+				continue
+			}
+			// Keep the current label when it is tied for best.
+			if cur := label[n.Path()]; counts[cur.Path()] == bestCount {
+				continue
+			}
+			label[n.Path()] = best
+			changed = true
+		}
+		if !changed {
+			break
+		}
+	}
+
+	groups := map[string][]NodeId{}
+	for _, n := range nodes {
+		key := label[n.Path()].Path()
+		groups[key] = append(groups[key], n)
+	}
+	clusters := make([]NodeCluster, 0, len(groups))
+	for _, members := range groups {
+		clusters = append(clusters, NodeCluster{Members: members})
+	}
+	sortClusters(clusters)
+	return clusters
+}
+
+// ParseClusters parses a clusters.tsv artifact ("<node>\t<cluster>" lines).
+// Malformed lines are skipped.
+func ParseClusters(ctx context.Context, data []byte) ([]NodeCluster, error) {
+	_ = ctx
+	byID := map[int][]NodeId{}
+	for _, l := range bytes.Split(data, []byte{'\n'}) {
+		line := strings.TrimSpace(string(l))
+		if line == "" {
+			continue
+		}
+		parts := strings.SplitN(line, "\t", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		id, err := ParseNode(parts[0])
+		if err != nil || id == nil {
+			continue
+		}
+		cluster, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+		if err != nil {
+			continue
+		}
+		byID[cluster] = append(byID[cluster], *id)
+	}
+
+	clusters := make([]NodeCluster, 0, len(byID))
+	for _, members := range byID {
+		clusters = append(clusters, NodeCluster{Members: members})
+	}
+	sortClusters(clusters)
+	return clusters, nil
+}
+
+// ClustersData serializes clusters as a clusters.tsv artifact ordered by
+// node ID.
+func ClustersData(clusters []NodeCluster) []byte {
+	type row struct {
+		id      NodeId
+		cluster int
+	}
+	rows := make([]row, 0)
+	for _, c := range clusters {
+		for _, m := range c.Members {
+			rows = append(rows, row{id: m, cluster: c.ID})
+		}
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].id.Compare(rows[j].id) < 0 })
+
+	var buf bytes.Buffer
+	for _, r := range rows {
+		fmt.Fprintf(&buf, "%s\t%d\n", r.id.Path(), r.cluster)
+	}
+	return buf.Bytes()
+}
+
+// sortClusters orders members by ID and clusters by descending size then
+// lowest member, and renumbers them from 1.
+func sortClusters(clusters []NodeCluster) {
+	for i := range clusters {
+		m := clusters[i].Members
+		sort.Slice(m, func(a, b int) bool { return m[a].Compare(m[b]) < 0 })
+	}
+	sort.Slice(clusters, func(i, j int) bool {
+		if len(clusters[i].Members) != len(clusters[j].Members) {
+			return len(clusters[i].Members) > len(clusters[j].Members)
+		}
+		return clusters[i].Members[0].Compare(clusters[j].Members[0]) < 0
+	})
+	for i := range clusters {
+		clusters[i].ID = i + 1
+	}
+}
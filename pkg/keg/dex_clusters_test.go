@@ -0,0 +1,49 @@
+package keg
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jlrickert/cli-toolkit/toolkit"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDex_DetectClustersSeparatesComponents(t *testing.T) {
+	t.Parallel()
+
+	rt, err := toolkit.NewTestRuntime(t.TempDir(), "/home/testuser", "testuser")
+	require.NoError(t, err)
+	dex, err := NewDexFromRepo(t.Context(), NewMemoryRepo(rt))
+	require.NoError(t, err)
+
+	at := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	node := func(id int, links ...int) *NodeData {
+		n := makeNodeData(id, "Node", nil, at)
+		ids := make([]NodeId, 0, len(links))
+		for _, l := range links {
+			ids = append(ids, NodeId{ID: l})
+		}
+		n.Stats.SetLinks(ids)
+		return n
+	}
+
+	// Two triangles joined by nothing, plus an isolated node.
+	for _, n := range []*NodeData{
+		node(1, 2, 3), node(2, 3), node(3, 1),
+		node(4, 5, 6), node(5, 6), node(6, 4), node(7, 4),
+		node(8),
+	} {
+		require.NoError(t, dex.Add(t.Context(), n))
+	}
+
+	clusters := dex.DetectClusters(t.Context())
+	require.Len(t, clusters, 3)
+	require.Equal(t, 1, clusters[0].ID)
+	require.Equal(t, []int{4, 5, 6, 7}, nodeInts(clusters[0].Members))
+	require.Equal(t, []int{1, 2, 3}, nodeInts(clusters[1].Members))
+	require.Equal(t, []int{8}, nodeInts(clusters[2].Members))
+
+	parsed, err := ParseClusters(t.Context(), ClustersData(clusters))
+	require.NoError(t, err)
+	require.Equal(t, clusters, parsed)
+}
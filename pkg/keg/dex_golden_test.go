@@ -0,0 +1,35 @@
+package keg_test
+
+import (
+	"testing"
+
+	"github.com/jlrickert/tapper/pkg/keg"
+	"github.com/jlrickert/tapper/pkg/kegtest"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDex_GoldenArtifacts locks down the on-disk dex format. After an
+// intended format change, run go test ./pkg/keg -run Golden -update and
+// review the diff of testdata/dex.
+func TestDex_GoldenArtifacts(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t)
+	ctx, rt := sb.Context(), sb.Runtime()
+	repo := keg.NewMemoryRepo(rt)
+
+	k := kegtest.NewKegFixture().
+		Title("Golden").
+		Node(0, "Index", "Start here.").Link(1, 3).
+		Node(1, "Go tips", "Prefer small interfaces.").Tag("go").
+		Node(2, "Testing", "Table tests read well.").Tag("go", "testing").Link(1).
+		Node(3, "Release notes", "Untagged.").Link(2).
+		MustBuild(t, ctx, repo, rt)
+
+	require.NoError(t, k.UpdateConfig(ctx, func(cfg *keg.Config) {
+		cfg.Indexes = append(cfg.Indexes, keg.IndexEntry{File: "dex/go.md", Summary: "go notes", Tags: "go"})
+	}))
+	// Reopen so the dex picks up the tag-filtered index from the config.
+	require.NoError(t, keg.NewKeg(repo, rt).Index(ctx, keg.IndexOptions{Rebuild: true}))
+
+	kegtest.AssertDexGolden(t, ctx, repo, "testdata/dex")
+}
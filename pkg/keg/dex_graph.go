@@ -0,0 +1,134 @@
+package keg
+
+import (
+	"context"
+	"sort"
+)
+
+// Neighbors returns the nodes within depth hops of id, following links in
+// either direction (outgoing links and backlinks). The source node is
+// excluded and results are sorted by node ID. A depth below 1 is treated as 1.
+func (dex *Dex) Neighbors(ctx context.Context, id NodeId, depth int) []NodeId {
+	if dex == nil {
+		return nil
+	}
+	if depth < 1 {
+		depth = 1
+	}
+	dex.mu.RLock()
+	defer dex.mu.RUnlock()
+
+	self := id.Path()
+	seen := map[string]NodeId{self: id}
+	frontier := []NodeId{id}
+	for hop := 0; hop < depth && len(frontier) > 0; hop++ {
+		next := make([]NodeId, 0)
+		for _, n := range frontier {
+			for _, adj := range dex.adjacentLocked(n) {
+				if _, ok := seen[adj.Path()]; ok {
+					continue
+				}
+				seen[adj.Path()] = adj
+				next = append(next, adj)
+			}
+		}
+		frontier = next
+	}
+
+	delete(seen, self)
+	return sortedNodeIds(seen)
+}
+
+// ShortestPath returns the shortest chain of nodes connecting a to b,
+// inclusive of both ends, following links in either direction. When several
+// paths share the minimum length the one through the lowest node IDs is
+// chosen. The boolean is false when no path exists.
+func (dex *Dex) ShortestPath(ctx context.Context, a, b NodeId) ([]NodeId, bool) {
+	if dex == nil {
+		return nil, false
+	}
+	dex.mu.RLock()
+	defer dex.mu.RUnlock()
+
+	start, goal := a.Path(), b.Path()
+	if start == goal {
+		return []NodeId{a}, true
+	}
+
+	prev := map[string]NodeId{start: a}
+	queue := []NodeId{a}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for _, adj := range dex.adjacentLocked(cur) {
+			key := adj.Path()
+			if _, ok := prev[key]; ok {
+				continue
+			}
+			prev[key] = cur
+			if key == goal {
+				path := []NodeId{adj}
+				for step := cur; step.Path() != start; step = prev[step.Path()] {
+					path = append(path, step)
+				}
+				path = append(path, a)
+				for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+					path[i], path[j] = path[j], path[i]
+				}
+				return path, true
+			}
+			queue = append(queue, adj)
+		}
+	}
+	return nil, false
+}
+
+// Reachable returns every node reachable from id by following outgoing links
+// only, sorted by node ID. The source node is excluded unless a cycle leads
+// back to it.
+func (dex *Dex) Reachable(ctx context.Context, id NodeId) []NodeId {
+	if dex == nil {
+		return nil
+	}
+	dex.mu.RLock()
+	defer dex.mu.RUnlock()
+
+	seen := map[string]NodeId{}
+	stack := []NodeId{id}
+	for len(stack) > 0 {
+		cur := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for _, dst := range dex.links.data[cur.Path()] {
+			if _, ok := seen[dst.Path()]; ok {
+				continue
+			}
+			seen[dst.Path()] = dst
+			stack = append(stack, dst)
+		}
+	}
+	return sortedNodeIds(seen)
+}
+
+// adjacentLocked returns the nodes linked to or from id, deduplicated and
+// sorted so traversals are deterministic. Callers must hold dex.mu.
+func (dex *Dex) adjacentLocked(id NodeId) []NodeId {
+	key := id.Path()
+	set := map[string]NodeId{}
+	for _, n := range dex.links.data[key] {
+		set[n.Path()] = n
+	}
+	for _, n := range dex.backlinks.data[key] {
+		set[n.Path()] = n
+	}
+	delete(set, key)
+	return sortedNodeIds(set)
+}
+
+func sortedNodeIds(set map[string]NodeId) []NodeId {
+	out := make([]NodeId, 0, len(set))
+	for _, n := range set {
+		out = append(out, n)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Compare(out[j]) < 0 })
+	return out
+}
@@ -0,0 +1,78 @@
+package keg
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jlrickert/cli-toolkit/toolkit"
+	"github.com/stretchr/testify/require"
+)
+
+func newGraphTestDex(t *testing.T) *Dex {
+	t.Helper()
+	rt, err := toolkit.NewTestRuntime(t.TempDir(), "/home/testuser", "testuser")
+	require.NoError(t, err)
+	dex, err := NewDexFromRepo(t.Context(), NewMemoryRepo(rt))
+	require.NoError(t, err)
+
+	at := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	node := func(id int, links ...int) *NodeData {
+		n := makeNodeData(id, "Node", nil, at)
+		ids := make([]NodeId, 0, len(links))
+		for _, l := range links {
+			ids = append(ids, NodeId{ID: l})
+		}
+		n.Stats.SetLinks(ids)
+		return n
+	}
+
+	// 1 -> 2 -> 3 -> 4, 5 -> 3, 6 isolated, 7 -> 1 (back into the chain).
+	for _, n := range []*NodeData{
+		node(1, 2), node(2, 3), node(3, 4), node(4), node(5, 3), node(6), node(7, 1),
+	} {
+		require.NoError(t, dex.Add(t.Context(), n))
+	}
+	return dex
+}
+
+func nodeInts(ids []NodeId) []int {
+	out := make([]int, 0, len(ids))
+	for _, id := range ids {
+		out = append(out, id.ID)
+	}
+	return out
+}
+
+func TestDex_Neighbors(t *testing.T) {
+	t.Parallel()
+	dex := newGraphTestDex(t)
+
+	require.Equal(t, []int{1, 3}, nodeInts(dex.Neighbors(t.Context(), NodeId{ID: 2}, 1)))
+	require.Equal(t, []int{1, 3, 4, 5, 7}, nodeInts(dex.Neighbors(t.Context(), NodeId{ID: 2}, 2)))
+	require.Empty(t, dex.Neighbors(t.Context(), NodeId{ID: 6}, 3))
+}
+
+func TestDex_ShortestPath(t *testing.T) {
+	t.Parallel()
+	dex := newGraphTestDex(t)
+
+	path, ok := dex.ShortestPath(t.Context(), NodeId{ID: 7}, NodeId{ID: 5})
+	require.True(t, ok)
+	require.Equal(t, []int{7, 1, 2, 3, 5}, nodeInts(path), "links are followed in either direction")
+
+	path, ok = dex.ShortestPath(t.Context(), NodeId{ID: 4}, NodeId{ID: 4})
+	require.True(t, ok)
+	require.Equal(t, []int{4}, nodeInts(path))
+
+	_, ok = dex.ShortestPath(t.Context(), NodeId{ID: 1}, NodeId{ID: 6})
+	require.False(t, ok)
+}
+
+func TestDex_Reachable(t *testing.T) {
+	t.Parallel()
+	dex := newGraphTestDex(t)
+
+	require.Equal(t, []int{3, 4}, nodeInts(dex.Reachable(t.Context(), NodeId{ID: 5})))
+	require.Equal(t, []int{1, 2, 3, 4}, nodeInts(dex.Reachable(t.Context(), NodeId{ID: 7})))
+	require.Empty(t, dex.Reachable(t.Context(), NodeId{ID: 4}))
+}
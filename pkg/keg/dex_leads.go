@@ -0,0 +1,105 @@
+package keg
+
+import (
+	"bytes"
+	"context"
+	"slices"
+	"strings"
+)
+
+// LeadsIndexName is the dex artifact listing node leads. It is written when
+// the keg config has a summarizer section.
+const LeadsIndexName = "leads.tsv"
+
+// IndexLoader is implemented by IndexBuilders that can resume from their
+// serialized data. NewDexFromRepo loads them so a dex updated one node at a
+// time keeps the entries of the other nodes.
+type IndexLoader interface {
+	Load(ctx context.Context, data []byte) error
+}
+
+// LeadIndex maps a node path to its lead, as written by the keg's
+// summarizer.
+//
+// The on-disk format is one line per node with a lead, ordered by node ID:
+//
+//	"<id>\t<lead>\n"
+type LeadIndex struct {
+	data map[string]string
+}
+
+// NewLeadIndex returns an empty LeadIndex.
+func NewLeadIndex() *LeadIndex {
+	return &LeadIndex{data: map[string]string{}}
+}
+
+// Name returns the short index filename used with repo.WriteIndex.
+func (idx *LeadIndex) Name() string { return LeadsIndexName }
+
+// Load implements IndexLoader. Malformed lines are skipped.
+func (idx *LeadIndex) Load(ctx context.Context, data []byte) error {
+	_ = ctx
+	for l := range bytes.SplitSeq(data, []byte{'\n'}) {
+		id, lead, ok := strings.Cut(strings.TrimSpace(string(l)), "\t")
+		if !ok || id == "" {
+			continue
+		}
+		if lead = strings.TrimSpace(lead); lead != "" {
+			idx.data[id] = lead
+		}
+	}
+	return nil
+}
+
+// Add records the node's lead, or drops the node when it has none.
+func (idx *LeadIndex) Add(ctx context.Context, node *NodeData) error {
+	if node == nil {
+		return nil
+	}
+	lead := strings.Join(strings.Fields(node.Lead()), " ")
+	if lead == "" {
+		return idx.Remove(ctx, node.ID)
+	}
+	idx.data[node.ID.Path()] = lead
+	return nil
+}
+
+// Remove drops the node from the index.
+func (idx *LeadIndex) Remove(ctx context.Context, node NodeId) error {
+	_ = ctx
+	delete(idx.data, node.Path())
+	return nil
+}
+
+// Clear resets the index to an empty state.
+func (idx *LeadIndex) Clear(ctx context.Context) error {
+	_ = ctx
+	idx.data = map[string]string{}
+	return nil
+}
+
+// Lead returns the indexed lead of node.
+func (idx *LeadIndex) Lead(node NodeId) (string, bool) {
+	lead, ok := idx.data[node.Path()]
+	return lead, ok
+}
+
+// Data serializes the index in node ID order.
+func (idx *LeadIndex) Data(ctx context.Context) ([]byte, error) {
+	_ = ctx
+	ids := make([]NodeId, 0, len(idx.data))
+	for raw := range idx.data {
+		if id, err := ParseNode(raw); err == nil && id != nil {
+			ids = append(ids, *id)
+		}
+	}
+	slices.SortFunc(ids, func(a, b NodeId) int { return a.Compare(b) })
+	var b strings.Builder
+	for _, id := range ids {
+		b.WriteString(id.Path())
+		b.WriteByte('\t')
+		b.WriteString(idx.data[id.Path()])
+		b.WriteByte('\n')
+	}
+	return []byte(b.String()), nil
+}
@@ -39,6 +39,8 @@ func ParseLinkIndex(ctx context.Context, data []byte) (LinkIndex, error) {
 	s := string(data)
 	lines := strings.SplitSeq(s, "\n")
 	for line := range lines {
+		// Tolerate CRLF line endings from files edited on Windows.
+		line = strings.TrimRight(line, "\r")
 		if line == "" {
 			continue
 		}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"strings"
 	"time"
+
+	"golang.org/x/text/unicode/norm"
 )
 
 // NodeIndex is an in-memory index of node descriptors used to construct the
@@ -18,6 +20,8 @@ import (
 // Callers that require concurrent access should guard an instance with a mutex.
 type NodeIndex struct {
 	data []NodeIndexEntry
+	// malformed counts lines ParseNodeIndex skipped.
+	malformed int
 }
 
 // ParseNodeIndex parses the serialized nodes index bytes into a NodeIndex.
@@ -56,26 +60,35 @@ func ParseNodeIndex(ctx context.Context, data []byte) (NodeIndex, error) {
 	_ = ctx
 	idx := NodeIndex{data: []NodeIndexEntry{}}
 
-	s := strings.TrimSpace(string(data))
-	if s == "" {
+	// Trim only spaces and line breaks: an untitled node without timestamps
+	// serializes as "ID" followed by empty tab-separated columns.
+	s := strings.Trim(string(data), " \r\n")
+	if strings.TrimSpace(s) == "" {
 		return idx, nil
 	}
+	// Data always ends lines with a newline, so a missing one means the last
+	// write was cut short.
+	if !strings.HasSuffix(strings.TrimRight(string(data), " \t\r"), "\n") {
+		idx.malformed++
+	}
 
 	lines := strings.SplitSeq(s, "\n")
 	for ln := range lines {
-		ln = strings.TrimSpace(ln)
-		if ln == "" {
+		ln = strings.Trim(ln, " \r")
+		if strings.TrimSpace(ln) == "" {
 			continue
 		}
 
 		parts := strings.SplitN(ln, "\t", 6)
 		if len(parts) < 3 {
 			// malformed line; skip
+			idx.malformed++
 			continue
 		}
 
 		id := strings.TrimSpace(parts[0])
 		if id == "" {
+			idx.malformed++
 			continue
 		}
 
@@ -87,11 +100,11 @@ func ParseNodeIndex(ctx context.Context, data []byte) (NodeIndex, error) {
 			entry.Updated = parseTimestamp(parts[1])
 			entry.Created = parseTimestamp(parts[2])
 			entry.Accessed = parseTimestamp(parts[3])
-			entry.Title = strings.TrimSpace(parts[4])
+			entry.Title = norm.NFC.String(strings.TrimSpace(parts[4]))
 		} else {
 			// 3-column legacy format: id \t updated \t title
 			entry.Updated = parseTimestamp(parts[1])
-			entry.Title = strings.TrimSpace(parts[2])
+			entry.Title = norm.NFC.String(strings.TrimSpace(parts[2]))
 		}
 
 		idx.data = append(idx.data, entry)
@@ -0,0 +1,233 @@
+package keg
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"slices"
+	"testing"
+	"testing/quick"
+	"time"
+
+	"github.com/jlrickert/cli-toolkit/toolkit"
+)
+
+// Property tests for the ordering invariants the rest of tapper relies on:
+// nodes.tsv ascending and duplicate-free, changes.md newest first, and a dex
+// surviving a write and reload unchanged. Inputs come from testing/quick;
+// a failure prints the operations that broke the invariant.
+
+var propertyEpoch = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+var propertyTitles = []string{
+	"Go tips", "Testing", "", "café notes", "文档", "[draft] plan", "a (b) c", "x](../9",
+}
+
+// indexOp adds or removes one node. IDs are drawn from a small range so
+// sequences revisit the same nodes.
+type indexOp struct {
+	Rm      bool
+	ID      NodeId
+	Title   string
+	Updated time.Time
+}
+
+func (op indexOp) String() string {
+	if op.Rm {
+		return "rm " + op.ID.Path()
+	}
+	return fmt.Sprintf("add %s %q %s", op.ID.Path(), op.Title, op.Updated.Format(time.RFC3339))
+}
+
+type indexOps []indexOp
+
+func (indexOps) Generate(r *rand.Rand, size int) reflect.Value {
+	ops := make(indexOps, r.Intn(size*3+1))
+	for i := range ops {
+		id := NodeId{ID: r.Intn(size/2 + 2)}
+		if r.Intn(8) == 0 {
+			id.Code = fmt.Sprintf("%04d", r.Intn(3))
+		}
+		ops[i] = indexOp{
+			Rm:      r.Intn(4) == 0,
+			ID:      id,
+			Title:   propertyTitles[r.Intn(len(propertyTitles))],
+			Updated: propertyEpoch.Add(time.Duration(r.Intn(50)) * time.Hour),
+		}
+	}
+	return reflect.ValueOf(ops)
+}
+
+func (op indexOp) node() *NodeData {
+	stats := NewStats(op.Updated)
+	stats.SetTitle(op.Title)
+	stats.SetUpdated(op.Updated)
+	return &NodeData{ID: op.ID, Stats: stats}
+}
+
+// apply runs ops against add and rm and returns the ids expected to remain.
+func (ops indexOps) apply(t *testing.T, add func(*NodeData) error, rm func(NodeId) error) map[string]bool {
+	want := map[string]bool{}
+	for _, op := range ops {
+		var err error
+		if op.Rm {
+			err = rm(op.ID)
+			delete(want, op.ID.Path())
+		} else {
+			err = add(op.node())
+			want[op.ID.Path()] = true
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", op, err)
+		}
+	}
+	return want
+}
+
+func entryIDs(entries []NodeIndexEntry) map[string]bool {
+	ids := map[string]bool{}
+	for _, e := range entries {
+		ids[e.ID] = true
+	}
+	return ids
+}
+
+func TestProperty_NodeIndexSortedAndUnique(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	prop := func(ops indexOps) bool {
+		var idx NodeIndex
+		want := ops.apply(t,
+			func(d *NodeData) error { return idx.Add(ctx, d) },
+			func(id NodeId) error { return idx.Rm(ctx, id) })
+
+		entries := idx.List(ctx)
+		for i := 1; i < len(entries); i++ {
+			prev, err := ParseNode(entries[i-1].ID)
+			if err != nil {
+				return false
+			}
+			cur, err := ParseNode(entries[i].ID)
+			if err != nil || prev.Compare(*cur) >= 0 {
+				t.Logf("%s is not before %s", entries[i-1].ID, entries[i].ID)
+				return false
+			}
+		}
+		return reflect.DeepEqual(entryIDs(entries), want)
+	}
+	if err := quick.Check(prop, &quick.Config{MaxCount: 300}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestProperty_ChangesIndexNewestFirst(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	prop := func(ops indexOps) bool {
+		var idx ChangesIndex
+		want := ops.apply(t,
+			func(d *NodeData) error { return idx.Add(ctx, d) },
+			func(id NodeId) error { return idx.Rm(ctx, id) })
+
+		for i := 1; i < len(idx.data); i++ {
+			if idx.data[i].Updated.After(idx.data[i-1].Updated) {
+				t.Logf("%s updated after %s but listed below it", idx.data[i].ID, idx.data[i-1].ID)
+				return false
+			}
+		}
+		return len(entryIDs(idx.data)) == len(idx.data) && reflect.DeepEqual(entryIDs(idx.data), want)
+	}
+	if err := quick.Check(prop, &quick.Config{MaxCount: 300}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// dexNodes is a keg's worth of nodes with tags and links between them.
+type dexNodes []*NodeData
+
+func (dexNodes) Generate(r *rand.Rand, size int) reflect.Value {
+	ctx := context.Background()
+	tags := []string{"go", "testing", "notes", "zk"}
+	ids := r.Perm(size + 1)[:r.Intn(size+1)]
+	nodes := make(dexNodes, 0, len(ids))
+	for _, n := range ids {
+		created := propertyEpoch.Add(time.Duration(r.Intn(1000)) * time.Minute)
+		updated := created.Add(time.Duration(r.Intn(1000)) * time.Minute)
+		stats := NewStats(created)
+		stats.SetTitle(propertyTitles[r.Intn(len(propertyTitles))])
+		stats.SetUpdated(updated)
+		if r.Intn(2) == 0 {
+			stats.SetAccessed(updated.Add(time.Hour))
+		}
+		var links []NodeId
+		for _, l := range ids {
+			if l != n && r.Intn(4) == 0 {
+				links = append(links, NodeId{ID: l})
+			}
+		}
+		slices.SortFunc(links, NodeId.Compare)
+		stats.SetLinks(links)
+
+		meta := NewMeta(ctx, created)
+		var nodeTags []string
+		for _, tag := range tags {
+			if r.Intn(3) == 0 {
+				nodeTags = append(nodeTags, tag)
+			}
+		}
+		meta.SetTags(nodeTags)
+		nodes = append(nodes, &NodeData{ID: NodeId{ID: n}, Stats: stats, Meta: meta})
+	}
+	return reflect.ValueOf(nodes)
+}
+
+func TestProperty_DexWriteParseRoundTrip(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	rt, err := toolkit.NewTestRuntime(t.TempDir(), "/home/testuser", "testuser")
+	if err != nil {
+		t.Fatal(err)
+	}
+	prop := func(nodes dexNodes) bool {
+		dex := &Dex{}
+		for _, n := range nodes {
+			if err := dex.Add(ctx, n); err != nil {
+				t.Fatal(err)
+			}
+		}
+		first := NewMemoryRepo(rt)
+		if err := dex.Write(ctx, first); err != nil {
+			t.Fatal(err)
+		}
+
+		loaded, err := NewDexFromRepo(ctx, first)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want, got := dex.Nodes(ctx), loaded.Nodes(ctx); len(want)+len(got) > 0 && !reflect.DeepEqual(got, want) {
+			t.Logf("nodes differ after reload:\n%v\n%v", dex.Nodes(ctx), loaded.Nodes(ctx))
+			return false
+		}
+		second := NewMemoryRepo(rt)
+		if err := loaded.Write(ctx, second); err != nil {
+			t.Fatal(err)
+		}
+		names, err := first.ListIndexes(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, name := range names {
+			want, _ := first.GetIndex(ctx, name)
+			got, err := second.GetIndex(ctx, name)
+			if err != nil || string(got) != string(want) {
+				t.Logf("%s differs after reload:\n%s\n---\n%s", name, want, got)
+				return false
+			}
+		}
+		return true
+	}
+	if err := quick.Check(prop, &quick.Config{MaxCount: 100}); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,156 @@
+package keg
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// RankIndexName is the dex artifact holding per-node PageRank scores.
+const RankIndexName = "rank.tsv"
+
+const (
+	// pageRankDamping is the probability of following a link rather than
+	// jumping to a random node.
+	pageRankDamping = 0.85
+
+	pageRankMaxIterations = 100
+	pageRankTolerance     = 1e-10
+)
+
+// RankIndex maps a node path to its link-based importance score. Scores sum
+// to 1 across all indexed nodes.
+//
+// The on-disk format is one line per node, ordered by node ID:
+//
+//	"<id>\t<score>\n"
+type RankIndex struct {
+	data map[string]float64
+}
+
+// ParseRankIndex parses the raw bytes of a rank.tsv artifact. Malformed lines
+// are skipped.
+func ParseRankIndex(ctx context.Context, data []byte) (RankIndex, error) {
+	_ = ctx
+	idx := RankIndex{data: map[string]float64{}}
+	for _, l := range bytes.Split(data, []byte{'\n'}) {
+		line := strings.TrimSpace(string(l))
+		if line == "" {
+			continue
+		}
+		parts := strings.SplitN(line, "\t", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		score, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+		if err != nil {
+			continue
+		}
+		idx.data[parts[0]] = score
+	}
+	return idx, nil
+}
+
+// Data serializes the index in node ID order.
+func (idx RankIndex) Data(ctx context.Context) ([]byte, error) {
+	_ = ctx
+	ids := make([]NodeId, 0, len(idx.data))
+	for key := range idx.data {
+		id, err := ParseNode(key)
+		if err != nil || id == nil {
+			continue
+		}
+		ids = append(ids, *id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i].Compare(ids[j]) < 0 })
+
+	var buf bytes.Buffer
+	for _, id := range ids {
+		fmt.Fprintf(&buf, "%s\t%s\n", id.Path(), strconv.FormatFloat(idx.data[id.Path()], 'f', 6, 64))
+	}
+	return buf.Bytes(), nil
+}
+
+// computePageRank runs PageRank over the nodes in entries using the outgoing
+// links index. Links to nodes that are not indexed are ignored, and the rank
+// of nodes without outgoing links is spread evenly across every node.
+func computePageRank(entries []NodeIndexEntry, links map[string][]NodeId) RankIndex {
+	n := len(entries)
+	idx := RankIndex{data: make(map[string]float64, n)}
+	if n == 0 {
+		return idx
+	}
+
+	pos := make(map[string]int, n)
+	for i, e := range entries {
+		pos[e.ID] = i
+	}
+	out := make([][]int, n)
+	for i, e := range entries {
+		seen := map[int]struct{}{}
+		for _, dst := range links[e.ID] {
+			j, ok := pos[dst.Path()]
+			if !ok || j == i {
+				continue
+			}
+			if _, dup := seen[j]; dup {
+				continue
+			}
+			seen[j] = struct{}{}
+			out[i] = append(out[i], j)
+		}
+	}
+
+	rank := make([]float64, n)
+	for i := range rank {
+		rank[i] = 1 / float64(n)
+	}
+	next := make([]float64, n)
+	for iter := 0; iter < pageRankMaxIterations; iter++ {
+		dangling := 0.0
+		for i := range rank {
+			if len(out[i]) == 0 {
+				dangling += rank[i]
+			}
+		}
+		base := (1-pageRankDamping)/float64(n) + pageRankDamping*dangling/float64(n)
+		for i := range next {
+			next[i] = base
+		}
+		for i, targets := range out {
+			share := pageRankDamping * rank[i] / float64(len(targets))
+			for _, j := range targets {
+				next[j] += share
+			}
+		}
+		delta := 0.0
+		for i := range rank {
+			delta += math.Abs(next[i] - rank[i])
+		}
+		rank, next = next, rank
+		if delta < pageRankTolerance {
+			break
+		}
+	}
+
+	for i, e := range entries {
+		idx.data[e.ID] = rank[i]
+	}
+	return idx
+}
+
+// Rank returns the PageRank score recorded for id in the last written or
+// loaded rank.tsv artifact, and whether a score exists.
+func (dex *Dex) Rank(ctx context.Context, id NodeId) (float64, bool) {
+	if dex == nil {
+		return 0, false
+	}
+	dex.mu.RLock()
+	defer dex.mu.RUnlock()
+	score, ok := dex.rank.data[id.Path()]
+	return score, ok
+}
@@ -0,0 +1,66 @@
+package keg
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jlrickert/cli-toolkit/toolkit"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComputePageRank_FavorsHubs(t *testing.T) {
+	t.Parallel()
+
+	// 2, 3, and 4 all link to 1; 1 links back to 2; 5 is isolated.
+	entries := []NodeIndexEntry{{ID: "1"}, {ID: "2"}, {ID: "3"}, {ID: "4"}, {ID: "5"}}
+	links := map[string][]NodeId{
+		"1": {{ID: 2}},
+		"2": {{ID: 1}},
+		"3": {{ID: 1}, {ID: 99}}, // links to unindexed nodes are ignored
+		"4": {{ID: 1}, {ID: 1}},  // duplicates count once
+	}
+	idx := computePageRank(entries, links)
+
+	sum := 0.0
+	for _, score := range idx.data {
+		sum += score
+	}
+	require.InDelta(t, 1.0, sum, 1e-6)
+	require.Greater(t, idx.data["1"], idx.data["2"])
+	require.Greater(t, idx.data["2"], idx.data["3"])
+	require.InDelta(t, idx.data["3"], idx.data["5"], 1e-9, "nodes without backlinks share the baseline")
+	require.Empty(t, computePageRank(nil, nil).data)
+}
+
+func TestDex_WriteStoresRankIndex(t *testing.T) {
+	t.Parallel()
+	ctx := t.Context()
+
+	rt, err := toolkit.NewTestRuntime(t.TempDir(), "/home/testuser", "testuser")
+	require.NoError(t, err)
+	repo := NewMemoryRepo(rt)
+	dex, err := NewDexFromRepo(ctx, repo)
+	require.NoError(t, err)
+
+	at := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	hub := makeNodeData(1, "Hub", nil, at)
+	spoke := makeNodeData(2, "Spoke", nil, at)
+	spoke.Stats.SetLinks([]NodeId{{ID: 1}})
+	require.NoError(t, dex.Add(ctx, hub))
+	require.NoError(t, dex.Add(ctx, spoke))
+	require.NoError(t, dex.Write(ctx, repo))
+
+	raw, err := repo.GetIndex(ctx, RankIndexName)
+	require.NoError(t, err)
+	parsed, err := ParseRankIndex(ctx, raw)
+	require.NoError(t, err)
+	require.Len(t, parsed.data, 2)
+
+	reloaded, err := NewDexFromRepo(ctx, repo)
+	require.NoError(t, err)
+	hubScore, ok := reloaded.Rank(ctx, NodeId{ID: 1})
+	require.True(t, ok)
+	spokeScore, ok := reloaded.Rank(ctx, NodeId{ID: 2})
+	require.True(t, ok)
+	require.Greater(t, hubScore, spokeScore)
+}
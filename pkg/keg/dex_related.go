@@ -0,0 +1,141 @@
+package keg
+
+import (
+	"context"
+	"sort"
+)
+
+// RelatedOptions tunes Dex.Related scoring. Zero weights fall back to
+// DefaultRelatedOptions.
+type RelatedOptions struct {
+	// Limit caps the number of suggestions. Zero means no limit.
+	Limit int
+
+	// TagWeight scores each tag shared with the source node.
+	TagWeight float64
+
+	// LinkWeight scores each outgoing link target shared with the source
+	// node (both nodes cite the same target).
+	LinkWeight float64
+
+	// BacklinkWeight scores each node that links to both the source node and
+	// the candidate (the two are co-cited).
+	BacklinkWeight float64
+}
+
+// DefaultRelatedOptions weighs shared tags, shared link targets, and shared
+// backlinks equally.
+var DefaultRelatedOptions = RelatedOptions{
+	TagWeight:      1,
+	LinkWeight:     1,
+	BacklinkWeight: 1,
+}
+
+// RelatedNode is a single suggestion produced by Dex.Related.
+type RelatedNode struct {
+	ID    NodeId
+	Score float64
+
+	// SharedTags lists the tags carried by both nodes, sorted.
+	SharedTags []string
+
+	// SharedLinks counts link targets cited by both nodes.
+	SharedLinks int
+
+	// SharedBacklinks counts nodes linking to both nodes.
+	SharedBacklinks int
+}
+
+// Related scores every other node in the dex against id by shared tags,
+// shared outgoing link targets, and shared backlink sources, and returns the
+// suggestions ordered by descending score. Ties are broken by ascending node
+// ID. Nodes with no overlap are omitted.
+func (dex *Dex) Related(ctx context.Context, id NodeId, opts RelatedOptions) []RelatedNode {
+	if dex == nil {
+		return nil
+	}
+	if opts.TagWeight == 0 && opts.LinkWeight == 0 && opts.BacklinkWeight == 0 {
+		limit := opts.Limit
+		opts = DefaultRelatedOptions
+		opts.Limit = limit
+	}
+
+	dex.mu.RLock()
+	defer dex.mu.RUnlock()
+
+	self := id.Path()
+	byID := map[string]*RelatedNode{}
+	get := func(n NodeId) *RelatedNode {
+		key := n.Path()
+		if key == self {
+			return nil
+		}
+		r, ok := byID[key]
+		if !ok {
+			r = &RelatedNode{ID: n}
+			byID[key] = r
+		}
+		return r
+	}
+
+	// Shared tags: walk every tag that lists the source node.
+	for tag, nodes := range dex.tags.data {
+		if !containsNodePath(nodes, self) {
+			continue
+		}
+		for _, n := range nodes {
+			if r := get(n); r != nil {
+				r.SharedTags = append(r.SharedTags, tag)
+			}
+		}
+	}
+
+	// Shared link targets: other nodes that link to what the source links to.
+	for _, target := range dex.links.data[self] {
+		for _, src := range dex.backlinks.data[target.Path()] {
+			if r := get(src); r != nil {
+				r.SharedLinks++
+			}
+		}
+	}
+
+	// Shared backlinks: other nodes cited by the nodes that cite the source.
+	for _, src := range dex.backlinks.data[self] {
+		for _, dst := range dex.links.data[src.Path()] {
+			if r := get(dst); r != nil {
+				r.SharedBacklinks++
+			}
+		}
+	}
+
+	out := make([]RelatedNode, 0, len(byID))
+	for _, r := range byID {
+		sort.Strings(r.SharedTags)
+		r.Score = opts.TagWeight*float64(len(r.SharedTags)) +
+			opts.LinkWeight*float64(r.SharedLinks) +
+			opts.BacklinkWeight*float64(r.SharedBacklinks)
+		if r.Score <= 0 {
+			continue
+		}
+		out = append(out, *r)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Score != out[j].Score {
+			return out[i].Score > out[j].Score
+		}
+		return out[i].ID.Compare(out[j].ID) < 0
+	})
+	if opts.Limit > 0 && len(out) > opts.Limit {
+		out = out[:opts.Limit]
+	}
+	return out
+}
+
+func containsNodePath(nodes []NodeId, path string) bool {
+	for _, n := range nodes {
+		if n.Path() == path {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,60 @@
+package keg
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jlrickert/cli-toolkit/toolkit"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDex_RelatedScoresTagsLinksAndBacklinks(t *testing.T) {
+	t.Parallel()
+
+	rt, err := toolkit.NewTestRuntime(t.TempDir(), "/home/testuser", "testuser")
+	require.NoError(t, err)
+	dex, err := NewDexFromRepo(t.Context(), NewMemoryRepo(rt))
+	require.NoError(t, err)
+
+	at := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	withLinks := func(n *NodeData, links ...int) *NodeData {
+		ids := make([]NodeId, 0, len(links))
+		for _, l := range links {
+			ids = append(ids, NodeId{ID: l})
+		}
+		n.Stats.SetLinks(ids)
+		return n
+	}
+
+	// 1 is the source: tagged go+cli, links to 10.
+	// 2 shares both tags.
+	// 3 shares one tag and also links to 10.
+	// 4 is linked from 5 together with 1 (co-cited).
+	// 6 has nothing in common.
+	nodes := []*NodeData{
+		withLinks(makeNodeData(1, "Source", []string{"go", "cli"}, at), 10),
+		makeNodeData(2, "Both Tags", []string{"go", "cli"}, at),
+		withLinks(makeNodeData(3, "Tag And Link", []string{"go"}, at), 10),
+		makeNodeData(4, "Co-cited", nil, at),
+		withLinks(makeNodeData(5, "Hub", nil, at), 1, 4),
+		makeNodeData(6, "Unrelated", []string{"rust"}, at),
+		makeNodeData(10, "Target", nil, at),
+	}
+	for _, n := range nodes {
+		require.NoError(t, dex.Add(t.Context(), n))
+	}
+
+	got := dex.Related(t.Context(), NodeId{ID: 1}, RelatedOptions{})
+	ids := make([]int, 0, len(got))
+	for _, r := range got {
+		ids = append(ids, r.ID.ID)
+	}
+	require.Equal(t, []int{2, 3, 4}, ids)
+	require.Equal(t, []string{"cli", "go"}, got[0].SharedTags)
+	require.Equal(t, 1, got[1].SharedLinks)
+	require.Equal(t, 1, got[2].SharedBacklinks)
+
+	limited := dex.Related(t.Context(), NodeId{ID: 1}, RelatedOptions{Limit: 1, BacklinkWeight: 5})
+	require.Len(t, limited, 1)
+	require.Equal(t, 4, limited[0].ID.ID, "custom weights change the ranking")
+}
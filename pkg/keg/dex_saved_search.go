@@ -0,0 +1,176 @@
+package keg
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SavedSearchIndexName returns the short index filename used to materialize
+// the saved search called name, e.g. "search-recent-go.md".
+func SavedSearchIndexName(name string) string {
+	return "search-" + name + ".md"
+}
+
+// SavedSearchIndex is an in-memory index of nodes matching a saved search
+// declared in keg config. It is used to build dex/search-NAME.md artifacts.
+// A node matches when it satisfies both the tag expression and the where
+// filter; an empty component always matches.
+//
+// Concurrency note: SavedSearchIndex does not perform internal
+// synchronization. Callers should guard access with a mutex when needed.
+type SavedSearchIndex struct {
+	name  string
+	tags  *TagExpr
+	where *WhereExpr
+	data  []NodeIndexEntry
+}
+
+// NewSavedSearchIndex compiles search into an index builder. Returns an error
+// if the name is empty, is not a single path component (see
+// ValidateAssetName), or either expression fails to parse.
+func NewSavedSearchIndex(search SavedSearch) (*SavedSearchIndex, error) {
+	name := strings.TrimSpace(search.Name)
+	if name == "" {
+		return nil, fmt.Errorf("saved search name is required")
+	}
+	if err := ValidateAssetName(SavedSearchIndexName(name)); err != nil {
+		return nil, fmt.Errorf("invalid saved search name %q: %w", name, err)
+	}
+	idx := &SavedSearchIndex{
+		name: SavedSearchIndexName(name),
+		data: []NodeIndexEntry{},
+	}
+	if q := strings.TrimSpace(search.Tags); q != "" {
+		expr, err := ParseTagExpression(q)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tag expression for saved search %q: %w", name, err)
+		}
+		idx.tags = &expr
+	}
+	if w := strings.TrimSpace(search.Where); w != "" {
+		expr, err := ParseWhereExpression(w)
+		if err != nil {
+			return nil, fmt.Errorf("invalid where expression for saved search %q: %w", name, err)
+		}
+		idx.where = &expr
+	}
+	return idx, nil
+}
+
+// Name returns the short index filename used with repo.WriteIndex.
+func (idx *SavedSearchIndex) Name() string {
+	if idx == nil {
+		return ""
+	}
+	return idx.name
+}
+
+// Add evaluates the saved search against the node and, if it matches,
+// inserts or updates the node entry maintaining reverse-chronological order.
+func (idx *SavedSearchIndex) Add(ctx context.Context, data *NodeData) error {
+	if idx == nil || data == nil {
+		return nil
+	}
+	if !idx.matches(data) {
+		return idx.Remove(ctx, data.ID)
+	}
+
+	entry := data.Ref()
+	replaced := false
+	for i := range idx.data {
+		if idx.data[i].ID == entry.ID {
+			idx.data[i] = entry
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		idx.data = append(idx.data, entry)
+	}
+	sort.SliceStable(idx.data, func(a, b int) bool {
+		return idx.data[a].Updated.After(idx.data[b].Updated)
+	})
+	return nil
+}
+
+func (idx *SavedSearchIndex) matches(data *NodeData) bool {
+	if idx.tags != nil {
+		path := data.ID.Path()
+		tagSet := make(map[string]struct{})
+		for _, t := range data.Tags() {
+			tagSet[t] = struct{}{}
+		}
+		result := EvaluateTagExpression(*idx.tags, map[string]struct{}{path: {}}, func(tag string) map[string]struct{} {
+			if _, ok := tagSet[tag]; ok {
+				return map[string]struct{}{path: {}}
+			}
+			return map[string]struct{}{}
+		})
+		if len(result) == 0 {
+			return false
+		}
+	}
+	if idx.where != nil {
+		rec := WhereRecord{
+			Entry: data.Ref(),
+			Meta:  func() *NodeMeta { return data.Meta },
+		}
+		if !idx.where.Match(rec) {
+			return false
+		}
+	}
+	return true
+}
+
+// Remove removes the node identified by node from the index. If the node is
+// not present the call is a no-op.
+func (idx *SavedSearchIndex) Remove(ctx context.Context, node NodeId) error {
+	_ = ctx
+	if idx == nil || idx.data == nil {
+		return nil
+	}
+	target := node.Path()
+	for i := range idx.data {
+		if idx.data[i].ID == target {
+			idx.data = append(idx.data[:i], idx.data[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+// Clear resets the index to an empty state.
+func (idx *SavedSearchIndex) Clear(ctx context.Context) error {
+	_ = ctx
+	if idx == nil {
+		return nil
+	}
+	idx.data = []NodeIndexEntry{}
+	return nil
+}
+
+// Data serializes the SavedSearchIndex to the same markdown format as
+// ChangesIndex.Data. Entries are in reverse-chronological order.
+func (idx *SavedSearchIndex) Data(ctx context.Context) ([]byte, error) {
+	_ = ctx
+	if idx == nil || len(idx.data) == 0 {
+		return []byte{}, nil
+	}
+	var b strings.Builder
+	for _, e := range idx.data {
+		b.WriteString("* ")
+		if !e.Updated.IsZero() {
+			b.WriteString(e.Updated.UTC().Format(changesTimeFmt))
+		} else {
+			b.WriteString("0001-01-01 00:00:00Z")
+		}
+		b.WriteString(" [")
+		b.WriteString(e.Title)
+		b.WriteString("](../")
+		b.WriteString(e.ID)
+		b.WriteString(")\n")
+	}
+	return []byte(b.String()), nil
+}
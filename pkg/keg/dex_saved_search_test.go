@@ -0,0 +1,51 @@
+package keg
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSavedSearchIndex_CombinesTagsAndWhere(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	idx, err := NewSavedSearchIndex(SavedSearch{
+		Name:  "recent-go",
+		Tags:  "golang",
+		Where: "updated >= 2025-02-01",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "search-recent-go.md", idx.Name())
+
+	oldGo := makeNodeData(1, "Old Go", []string{"golang"}, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	newGo := makeNodeData(2, "New Go", []string{"golang"}, time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC))
+	newPy := makeNodeData(3, "New Python", []string{"python"}, time.Date(2025, 3, 2, 0, 0, 0, 0, time.UTC))
+	for _, n := range []*NodeData{oldGo, newGo, newPy} {
+		require.NoError(t, idx.Add(ctx, n))
+	}
+
+	data, err := idx.Data(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "* 2025-03-01 00:00:00Z [New Go](../2)\n", string(data))
+}
+
+func TestSavedSearchIndex_NewErrors(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewSavedSearchIndex(SavedSearch{Tags: "golang"})
+	require.Error(t, err, "name is required")
+
+	_, err = NewSavedSearchIndex(SavedSearch{Name: "bad", Tags: "a and (b"})
+	require.Error(t, err)
+
+	_, err = NewSavedSearchIndex(SavedSearch{Name: "bad", Where: "created >"})
+	require.Error(t, err)
+
+	for _, name := range []string{"../../../x", `a\b`, "nested/name"} {
+		_, err = NewSavedSearchIndex(SavedSearch{Name: name, Tags: "golang"})
+		require.ErrorIs(t, err, ErrInvalidName, name)
+	}
+}
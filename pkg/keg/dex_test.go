@@ -308,3 +308,39 @@ func TestDex_WithConfig_CoreIndexSkipped(t *testing.T) {
 	require.NoError(t, err)
 	require.Empty(t, dex.custom, "core index names should not produce custom indexes")
 }
+
+// TestParseIndexes_CRLF checks that index files rewritten with Windows line
+// endings parse the same as the LF originals.
+func TestParseIndexes_CRLF(t *testing.T) {
+	ctx := t.Context()
+	crlf := func(s string) []byte { return []byte(strings.ReplaceAll(s, "\n", "\r\n")) }
+
+	nodes := "1\t2025-01-02T00:00:00Z\t2025-01-01T00:00:00Z\t2025-01-02T00:00:00Z\tOne\n2\t2025-01-02T00:00:00Z\t2025-01-01T00:00:00Z\t2025-01-02T00:00:00Z\tTwo\n"
+	links := "1\t2\n2\n3\t1 2\n"
+	backlinks := "1\t3\n2\t1 3\n"
+	tags := "alpha\t1 2\nbeta\t3\n"
+
+	lfNodes, err := ParseNodeIndex(ctx, []byte(nodes))
+	require.NoError(t, err)
+	crNodes, err := ParseNodeIndex(ctx, crlf(nodes))
+	require.NoError(t, err)
+	require.Equal(t, lfNodes.List(ctx), crNodes.List(ctx))
+
+	lfLinks, err := ParseLinkIndex(ctx, []byte(links))
+	require.NoError(t, err)
+	crLinks, err := ParseLinkIndex(ctx, crlf(links))
+	require.NoError(t, err)
+	require.Equal(t, lfLinks.data, crLinks.data)
+
+	lfBack, err := ParseBacklinksIndex(ctx, []byte(backlinks))
+	require.NoError(t, err)
+	crBack, err := ParseBacklinksIndex(ctx, crlf(backlinks))
+	require.NoError(t, err)
+	require.Equal(t, lfBack.data, crBack.data)
+
+	lfTags, err := ParseTagIndex(ctx, []byte(tags))
+	require.NoError(t, err)
+	crTags, err := ParseTagIndex(ctx, crlf(tags))
+	require.NoError(t, err)
+	require.Equal(t, lfTags.data, crTags.data)
+}
@@ -0,0 +1,136 @@
+package keg
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// DexReport describes how a dex disagrees with the nodes in its repository.
+type DexReport struct {
+	// Indexed is false when nodes.tsv has never been written, in which case
+	// every node is reported missing without the dex being corrupt.
+	Indexed bool
+	// Nodes is the number of nodes in the repository.
+	Nodes int
+	// Missing lists repository nodes absent from nodes.tsv.
+	Missing []NodeId
+	// Stale lists nodes.tsv entries with no node in the repository.
+	Stale []string
+	// Unlisted lists nodes present in only one of nodes.tsv and changes.md.
+	// It is empty when the keg has no changes.md.
+	Unlisted []string
+	// MalformedLines counts lines of nodes.tsv and changes.md that could not
+	// be parsed, typically left by a truncated write.
+	MalformedLines int
+}
+
+// OK reports whether the dex matches the repository exactly.
+func (r *DexReport) OK() bool {
+	return r != nil && r.Indexed && len(r.Missing) == 0 && len(r.Stale) == 0 &&
+		len(r.Unlisted) == 0 && r.MalformedLines == 0
+}
+
+// corruptDriftMinNodes is the smallest keg in which missing or stale entries
+// alone mark a dex as corrupt. In smaller kegs a single new node is already
+// a large share.
+const corruptDriftMinNodes = 10
+
+// Corrupt reports whether the dex is damaged badly enough that serving it
+// would hide nodes: lines failed to parse or nodes.tsv was cut short,
+// nodes.tsv and changes.md disagree, or at least half of a keg of 10 or more
+// nodes is missing from or stale in the dex. A few unindexed nodes are normal
+// between index runs and are not corruption.
+func (r *DexReport) Corrupt() bool {
+	if r == nil || !r.Indexed {
+		return false
+	}
+	if r.MalformedLines > 0 || len(r.Unlisted) > 0 {
+		return true
+	}
+	drift := len(r.Missing) + len(r.Stale)
+	return r.Nodes >= corruptDriftMinNodes && drift*2 >= r.Nodes
+}
+
+// String summarizes the report in one line.
+func (r *DexReport) String() string {
+	if r == nil {
+		return "no report"
+	}
+	if !r.Indexed {
+		return "dex has not been built"
+	}
+	if r.OK() {
+		return fmt.Sprintf("dex matches %d nodes", r.Nodes)
+	}
+	var parts []string
+	if n := len(r.Missing); n > 0 {
+		parts = append(parts, fmt.Sprintf("%d missing", n))
+	}
+	if n := len(r.Stale); n > 0 {
+		parts = append(parts, fmt.Sprintf("%d stale", n))
+	}
+	if n := len(r.Unlisted); n > 0 {
+		parts = append(parts, fmt.Sprintf("%d not in both nodes.tsv and changes.md", n))
+	}
+	if r.MalformedLines > 0 {
+		parts = append(parts, fmt.Sprintf("%d malformed lines", r.MalformedLines))
+	}
+	return fmt.Sprintf("dex disagrees with %d nodes: %s", r.Nodes, strings.Join(parts, ", "))
+}
+
+// Verify compares the dex against the nodes listed by repo and reports any
+// disagreement. It does not modify the dex or the repository.
+func (dex *Dex) Verify(ctx context.Context, repo Repository) (*DexReport, error) {
+	ids, err := repo.ListNodes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list nodes: %w", err)
+	}
+
+	dex.mu.RLock()
+	defer dex.mu.RUnlock()
+
+	// Drafts (ids with a Code) are never indexed, so they are not missing.
+	ids = slices.DeleteFunc(ids, func(id NodeId) bool { return id.Code != "" })
+
+	report := &DexReport{
+		Indexed:        dex.indexed,
+		Nodes:          len(ids),
+		MalformedLines: dex.nodes.malformed + dex.changes.malformed,
+	}
+
+	indexed := make(map[string]struct{}, len(dex.nodes.data))
+	for _, entry := range dex.nodes.data {
+		indexed[entry.ID] = struct{}{}
+	}
+	present := make(map[string]struct{}, len(ids))
+	for _, id := range ids {
+		present[id.Path()] = struct{}{}
+		if _, ok := indexed[id.Path()]; !ok {
+			report.Missing = append(report.Missing, id)
+		}
+	}
+	for _, entry := range dex.nodes.data {
+		if _, ok := present[entry.ID]; !ok {
+			report.Stale = append(report.Stale, entry.ID)
+		}
+	}
+
+	if !dex.changesIndexed {
+		return report, nil
+	}
+	changed := make(map[string]struct{}, len(dex.changes.data))
+	for _, entry := range dex.changes.data {
+		changed[entry.ID] = struct{}{}
+		if _, ok := indexed[entry.ID]; !ok {
+			report.Unlisted = append(report.Unlisted, entry.ID)
+		}
+	}
+	for _, entry := range dex.nodes.data {
+		if _, ok := changed[entry.ID]; !ok {
+			report.Unlisted = append(report.Unlisted, entry.ID)
+		}
+	}
+	return report, nil
+}
@@ -0,0 +1,127 @@
+package keg_test
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+
+	"github.com/jlrickert/tapper/pkg/keg"
+	"github.com/stretchr/testify/require"
+)
+
+func newIndexedMemoryRepo(t *testing.T, nodes int) *keg.MemoryRepo {
+	t.Helper()
+	fx := NewSandbox(t)
+	ctx := fx.Context()
+	repo := keg.NewMemoryRepo(fx.Runtime())
+	k := keg.NewKeg(repo, fx.Runtime())
+	require.NoError(t, k.Init(ctx))
+	for i := 0; i < nodes; i++ {
+		_, err := k.Create(ctx, &keg.CreateOptions{Title: "Node"})
+		require.NoError(t, err)
+	}
+	return repo
+}
+
+func TestDexVerify_ReportsHealthyDex(t *testing.T) {
+	t.Parallel()
+	repo := newIndexedMemoryRepo(t, 3)
+	ctx := t.Context()
+
+	dex, err := keg.NewDexFromRepo(ctx, repo)
+	require.NoError(t, err)
+	report, err := dex.Verify(ctx, repo)
+	require.NoError(t, err)
+	require.True(t, report.OK(), report.String())
+	require.False(t, report.Corrupt())
+	require.Equal(t, 4, report.Nodes)
+}
+
+func TestDexVerify_DetectsTruncatedNodesIndex(t *testing.T) {
+	t.Parallel()
+	repo := newIndexedMemoryRepo(t, 3)
+	ctx := t.Context()
+
+	data, err := repo.GetIndex(ctx, "nodes.tsv")
+	require.NoError(t, err)
+	require.NoError(t, repo.WriteIndex(ctx, "nodes.tsv", data[:len(data)/2]))
+
+	dex, err := keg.NewDexFromRepo(ctx, repo)
+	require.NoError(t, err)
+	report, err := dex.Verify(ctx, repo)
+	require.NoError(t, err)
+	require.False(t, report.OK())
+	require.True(t, report.Corrupt(), report.String())
+	require.NotEmpty(t, report.Missing)
+}
+
+func TestDexVerify_FewUnindexedNodesAreNotCorrupt(t *testing.T) {
+	t.Parallel()
+	repo := newIndexedMemoryRepo(t, 2)
+	ctx := t.Context()
+
+	// A node written behind the dex's back is merely unindexed.
+	require.NoError(t, repo.WriteContent(ctx, keg.NodeId{ID: 10}, []byte("# Ten\n")))
+
+	dex, err := keg.NewDexFromRepo(ctx, repo)
+	require.NoError(t, err)
+	report, err := dex.Verify(ctx, repo)
+	require.NoError(t, err)
+	require.Equal(t, []keg.NodeId{{ID: 10}}, report.Missing)
+	require.False(t, report.Corrupt())
+}
+
+func TestDexVerify_IgnoresDrafts(t *testing.T) {
+	t.Parallel()
+	fx := NewSandbox(t)
+	ctx := fx.Context()
+	repo := keg.NewMemoryRepo(fx.Runtime())
+	k := keg.NewKeg(repo, fx.Runtime())
+	require.NoError(t, k.Init(ctx))
+	for i := 0; i < 2; i++ {
+		_, err := k.Create(ctx, &keg.CreateOptions{Title: "Draft", Draft: true})
+		require.NoError(t, err)
+	}
+
+	dex, err := keg.NewDexFromRepo(ctx, repo)
+	require.NoError(t, err)
+	report, err := dex.Verify(ctx, repo)
+	require.NoError(t, err)
+	require.True(t, report.OK(), report.String())
+	require.Equal(t, 1, report.Nodes)
+}
+
+func TestKegDex_RebuildsCorruptIndex(t *testing.T) {
+	t.Parallel()
+	fx := NewSandbox(t)
+	ctx := fx.Context()
+	rt := fx.Runtime()
+
+	repo := keg.NewMemoryRepo(rt)
+	k := keg.NewKeg(repo, rt)
+	require.NoError(t, k.Init(ctx))
+	for i := 0; i < 3; i++ {
+		_, err := k.Create(ctx, &keg.CreateOptions{Title: "Node"})
+		require.NoError(t, err)
+	}
+
+	data, err := repo.GetIndex(ctx, "nodes.tsv")
+	require.NoError(t, err)
+	require.NoError(t, repo.WriteIndex(ctx, "nodes.tsv", data[:len(data)-10]))
+
+	var logs bytes.Buffer
+	require.NoError(t, rt.SetLogger(slog.New(slog.NewTextHandler(&logs, nil))))
+
+	// A fresh Keg loads the damaged index and rebuilds it on first use.
+	reopened := keg.NewKeg(repo, rt)
+	dex, err := reopened.Dex(ctx)
+	require.NoError(t, err)
+	require.Len(t, dex.Nodes(ctx), 4)
+	require.Contains(t, logs.String(), "dex is corrupt")
+
+	healed, err := keg.NewDexFromRepo(ctx, repo)
+	require.NoError(t, err)
+	report, err := healed.Verify(ctx, repo)
+	require.NoError(t, err)
+	require.True(t, report.OK(), report.String())
+}
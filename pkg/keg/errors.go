@@ -8,6 +8,19 @@ import (
 )
 
 // Sentinel errors used for simple equality-style checks.
+//
+// Every Repository implementation reports failures with these sentinels so
+// callers can rely on errors.Is regardless of backend:
+//
+//   - ErrNotExist for a missing node, asset, index, snapshot or config, usually
+//     as a *NodeNotFoundError or *NotFoundError
+//   - ErrDestinationExists when a move target is taken, as a
+//     *DestinationExistsError
+//   - ErrInvalid for bad arguments such as an unknown asset kind, and
+//     ErrInvalidName for unsafe asset names
+//   - ErrPermission for permission failures, usually wrapped in a
+//     *BackendError
+//   - ErrLockTimeout and ErrLock for lock acquisition failures
 var (
 	ErrInvalid       = os.ErrInvalid    // invalid argument
 	ErrExist         = os.ErrExist      // file already exists
@@ -35,15 +48,41 @@ var (
 	// lock. Use errors.Is(err, ErrLock) to detect non-timeout lock acquisition
 	// failures.
 	ErrLock = errors.New("cannot acquire lock")
+
+	// ErrInvalidName indicates an asset or file name that is unsafe to use as
+	// a path component, such as "../../etc/passwd". It wraps ErrInvalid.
+	ErrInvalidName = fmt.Errorf("invalid name: %w", ErrInvalid)
+
+	// ErrReadOnly is returned by ReadOnlyRepo for any write to a keg opened
+	// read-only. It wraps ErrPermission.
+	ErrReadOnly = fmt.Errorf("keg is read-only: %w", ErrPermission)
+
+	// ErrPolicy is returned, as a *PolicyViolationError, for a change the
+	// keg policy does not allow. It wraps ErrPermission.
+	ErrPolicy = fmt.Errorf("keg policy violation: %w", ErrPermission)
+
+	// ErrEncrypted is returned when encrypted node content is read without
+	// an identity that can decrypt it. It wraps ErrPermission.
+	ErrEncrypted = fmt.Errorf("node content is encrypted: %w", ErrPermission)
 )
 
 // AliasNotFoundError is a typed error that carries the missing alias for callers
-// that need richer diagnostic information.
+// that need richer diagnostic information. It wraps ErrNotExist.
 type AliasNotFoundError struct {
 	Alias string
+
+	// Hint, when set, follows the message in parentheses.
+	Hint string
 }
 
-func (e *AliasNotFoundError) Error() string { return fmt.Sprintf("alias not found: %q", e.Alias) }
+func (e *AliasNotFoundError) Error() string {
+	if e.Hint != "" {
+		return fmt.Sprintf("keg alias not found: %s (%s)", e.Alias, e.Hint)
+	}
+	return fmt.Sprintf("keg alias not found: %s", e.Alias)
+}
+
+func (e *AliasNotFoundError) Unwrap() error { return ErrNotExist }
 
 // NewAliasNotFoundError constructs a typed AliasNotFoundError.
 func NewAliasNotFoundError(alias string) error {
@@ -78,6 +117,82 @@ func IsInvalidConfig(err error) bool {
 	return errors.Is(err, ErrInvalid)
 }
 
+// NodeNotFoundError reports a node missing from a repository. It unwraps to
+// ErrNotExist.
+type NodeNotFoundError struct {
+	ID NodeId
+}
+
+func (e *NodeNotFoundError) Error() string {
+	return fmt.Sprintf("node %s not found", e.ID.Path())
+}
+
+func (e *NodeNotFoundError) Unwrap() error { return ErrNotExist }
+
+// NewNodeNotFoundError constructs a *NodeNotFoundError for id.
+func NewNodeNotFoundError(id NodeId) error {
+	return &NodeNotFoundError{ID: id}
+}
+
+// NotFoundError reports a missing item other than a node, such as an image,
+// attachment, index or snapshot. It unwraps to ErrNotExist.
+type NotFoundError struct {
+	// Kind names what is missing, for example "image" or "index".
+	Kind string
+	// Name identifies the item within its kind.
+	Name string
+}
+
+func (e *NotFoundError) Error() string {
+	if e.Name == "" {
+		return e.Kind + " not found"
+	}
+	return fmt.Sprintf("%s %q not found", e.Kind, e.Name)
+}
+
+func (e *NotFoundError) Unwrap() error { return ErrNotExist }
+
+// NewNotFoundError constructs a *NotFoundError.
+func NewNotFoundError(kind, name string) error {
+	return &NotFoundError{Kind: kind, Name: name}
+}
+
+// DestinationExistsError reports a move whose destination node already
+// exists. It unwraps to ErrDestinationExists.
+type DestinationExistsError struct {
+	ID NodeId
+}
+
+func (e *DestinationExistsError) Error() string {
+	return fmt.Sprintf("destination node %s already exists", e.ID.Path())
+}
+
+func (e *DestinationExistsError) Unwrap() error { return ErrDestinationExists }
+
+// NewDestinationExistsError constructs a *DestinationExistsError for id.
+func NewDestinationExistsError(id NodeId) error {
+	return &DestinationExistsError{ID: id}
+}
+
+// NewUnknownAssetKindError reports an asset kind the repository does not
+// know. It wraps ErrInvalid.
+func NewUnknownAssetKindError(kind AssetKind) error {
+	return fmt.Errorf("unknown asset kind %q: %w", kind, ErrInvalid)
+}
+
+// InvalidNameError is returned when a caller-provided asset name fails
+// validation. It unwraps to ErrInvalidName.
+type InvalidNameError struct {
+	Name   string
+	Reason string
+}
+
+func (e *InvalidNameError) Error() string {
+	return fmt.Sprintf("invalid name %q: %s", e.Name, e.Reason)
+}
+
+func (e *InvalidNameError) Unwrap() error { return ErrInvalidName }
+
 // Behavior interfaces used when inspecting error chains via errors.As.
 // These are intentionally unexported; predicates expose the behavior to callers.
 type temporary interface{ Temporary() bool }
@@ -169,6 +284,11 @@ func NewTransientError(cause error) error {
 
 // Convenience predicates
 
+// IsNotExist reports whether err means a node or other item is missing.
+func IsNotExist(err error) bool {
+	return errors.Is(err, ErrNotExist)
+}
+
 // IsDestinationExists returns true if err represents a destination-exists condition.
 func IsDestinationExists(err error) bool {
 	return errors.Is(err, ErrDestinationExists)
@@ -252,3 +372,8 @@ func IsTemporary(err error) bool {
 	}
 	return false
 }
+
+// newSnapshotNotFoundError reports a missing snapshot revision of id.
+func newSnapshotNotFoundError(id NodeId, rev RevisionID) error {
+	return NewNotFoundError("snapshot", fmt.Sprintf("%s@%d", id.Path(), rev))
+}
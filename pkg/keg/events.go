@@ -0,0 +1,200 @@
+package keg
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// EventKind names the type of change an Event reports.
+type EventKind string
+
+// Event kinds published on an EventBus.
+const (
+	EventNodeCreated    EventKind = "node-created"
+	EventContentUpdated EventKind = "content-updated"
+	EventMetaUpdated    EventKind = "meta-updated"
+	EventNodeDeleted    EventKind = "node-deleted"
+	EventIndexRebuilt   EventKind = "index-rebuilt"
+)
+
+// Event is a change to a keg published on its EventBus once the change has
+// been written. Events are notifications: unlike hooks they cannot stop or
+// fail the operation that published them.
+type Event interface {
+	// Kind reports the type of the event.
+	Kind() EventKind
+	// Source reports the keg and time of the change.
+	Source() EventSource
+}
+
+// EventSource identifies the keg an event happened in and when.
+type EventSource struct {
+	Keg  *Keg
+	Time time.Time
+}
+
+// Source returns s. It lets event types satisfy Event by embedding
+// EventSource.
+func (s EventSource) Source() EventSource { return s }
+
+// NodeCreated reports a new node. Move, Commit and Unarchive publish it for
+// the node's new id.
+type NodeCreated struct {
+	EventSource
+	Node  NodeId
+	Title string
+	Tags  []string
+}
+
+// Kind returns EventNodeCreated.
+func (NodeCreated) Kind() EventKind { return EventNodeCreated }
+
+// ContentUpdated reports that a node's content changed. Writes that leave
+// the content hash unchanged do not publish it.
+type ContentUpdated struct {
+	EventSource
+	Node  NodeId
+	Title string
+	Hash  string
+}
+
+// Kind returns EventContentUpdated.
+func (ContentUpdated) Kind() EventKind { return EventContentUpdated }
+
+// MetaUpdated reports that a node's meta.yaml was written.
+type MetaUpdated struct {
+	EventSource
+	Node NodeId
+	Tags []string
+}
+
+// Kind returns EventMetaUpdated.
+func (MetaUpdated) Kind() EventKind { return EventMetaUpdated }
+
+// NodeDeleted reports that a node is gone from its id. Move, Commit, Merge
+// and Archive publish it for the id the node left.
+type NodeDeleted struct {
+	EventSource
+	Node NodeId
+}
+
+// Kind returns EventNodeDeleted.
+func (NodeDeleted) Kind() EventKind { return EventNodeDeleted }
+
+// IndexRebuilt reports a completed Index run.
+type IndexRebuilt struct {
+	EventSource
+	// Rebuild is true when every index artifact was rebuilt from scratch.
+	Rebuild bool
+}
+
+// Kind returns EventIndexRebuilt.
+func (IndexRebuilt) Kind() EventKind { return EventIndexRebuilt }
+
+// EventHandler receives events published on an EventBus.
+type EventHandler func(ctx context.Context, e Event)
+
+// EventBus delivers keg events to in-process subscribers. Handlers run
+// synchronously on the publishing goroutine in the order they subscribed,
+// after the keg has released its locks, so a handler may call back into the
+// keg but should hand slow work to its own goroutine. The zero value is
+// ready to use and safe for concurrent use.
+type EventBus struct {
+	mu       sync.RWMutex
+	next     int
+	handlers []subscription
+}
+
+type subscription struct {
+	id int
+	fn EventHandler
+}
+
+// NewEventBus returns an empty EventBus.
+func NewEventBus() *EventBus {
+	return &EventBus{}
+}
+
+// Subscribe registers fn for every event published on b and returns a
+// function that removes it again.
+func (b *EventBus) Subscribe(fn EventHandler) (unsubscribe func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.next++
+	id := b.next
+	b.handlers = append(b.handlers, subscription{id: id, fn: fn})
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		for i, s := range b.handlers {
+			if s.id == id {
+				b.handlers = append(b.handlers[:i:i], b.handlers[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// SubscribeTo registers fn for the events of type E published on b, such as
+// SubscribeTo(b, func(ctx context.Context, e NodeCreated) {...}).
+func SubscribeTo[E Event](b *EventBus, fn func(ctx context.Context, e E)) (unsubscribe func()) {
+	return b.Subscribe(func(ctx context.Context, e Event) {
+		if typed, ok := e.(E); ok {
+			fn(ctx, typed)
+		}
+	})
+}
+
+// Publish delivers e to every subscriber. Publishing on a nil bus does
+// nothing.
+func (b *EventBus) Publish(ctx context.Context, e Event) {
+	if b == nil {
+		return
+	}
+	b.mu.RLock()
+	handlers := make([]EventHandler, 0, len(b.handlers))
+	for _, s := range b.handlers {
+		handlers = append(handlers, s.fn)
+	}
+	b.mu.RUnlock()
+	for _, fn := range handlers {
+		fn(ctx, e)
+	}
+}
+
+// WithEventBus makes the keg publish its changes on bus.
+func WithEventBus(bus *EventBus) Option {
+	return func(k *Keg) {
+		k.Events = bus
+	}
+}
+
+// eventSource stamps an event published by k with the current time.
+func (k *Keg) eventSource() EventSource {
+	return EventSource{Keg: k, Time: k.Runtime.Clock().Now()}
+}
+
+// publish delivers events on the keg's bus when it has one.
+func (k *Keg) publish(ctx context.Context, events ...Event) {
+	if k.Events == nil {
+		return
+	}
+	for _, e := range events {
+		k.Events.Publish(ctx, e)
+	}
+}
+
+// publishRenamed reports a node that moved from src to dst as a NodeDeleted
+// for src followed by a NodeCreated for dst.
+func (k *Keg) publishRenamed(ctx context.Context, src, dst NodeId) {
+	if k.Events == nil {
+		return
+	}
+	created := NodeCreated{EventSource: k.eventSource(), Node: dst}
+	if data, err := k.getNode(ctx, dst); err == nil {
+		created.Title = data.Title()
+		created.Tags = data.Tags()
+	}
+	k.publish(ctx, NodeDeleted{EventSource: created.EventSource, Node: src}, created)
+}
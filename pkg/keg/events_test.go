@@ -0,0 +1,105 @@
+package keg_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/jlrickert/tapper/pkg/keg"
+	"github.com/jlrickert/tapper/pkg/kegtest"
+	"github.com/stretchr/testify/require"
+)
+
+// describeEvent renders an event as "kind node detail" for comparison.
+func describeEvent(e keg.Event) string {
+	var detail string
+	switch e := e.(type) {
+	case keg.NodeCreated:
+		detail = fmt.Sprintf("%s %s %s", e.Node.Path(), e.Title, strings.Join(e.Tags, ","))
+	case keg.ContentUpdated:
+		detail = fmt.Sprintf("%s %s", e.Node.Path(), e.Title)
+	case keg.MetaUpdated:
+		detail = fmt.Sprintf("%s %s", e.Node.Path(), strings.Join(e.Tags, ","))
+	case keg.NodeDeleted:
+		detail = e.Node.Path()
+	case keg.IndexRebuilt:
+		detail = fmt.Sprint(e.Rebuild)
+	}
+	return strings.TrimSpace(fmt.Sprintf("%s %s", e.Kind(), detail))
+}
+
+func TestKeg_PublishesEvents(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t)
+	ctx, rt := sb.Context(), sb.Runtime()
+	repo := keg.NewMemoryRepo(rt)
+	kegtest.NewKegFixture().Node(1, "One", "Body.").Tag("go").MustBuild(t, ctx, repo, rt)
+
+	bus := keg.NewEventBus()
+	k := keg.NewKeg(repo, rt, keg.WithEventBus(bus))
+	var log []string
+	bus.Subscribe(func(_ context.Context, e keg.Event) {
+		require.Same(t, k, e.Source().Keg)
+		log = append(log, describeEvent(e))
+	})
+
+	id, err := k.Create(ctx, &keg.CreateOptions{Title: "Two", Tags: []string{"zk"}})
+	require.NoError(t, err)
+	require.NoError(t, k.SetContent(ctx, id, []byte("# Second\n")))
+	require.NoError(t, k.SetContent(ctx, id, []byte("# Second\n")), "unchanged content")
+	require.NoError(t, k.UpdateMeta(ctx, id, func(m *keg.NodeMeta) { m.AddTag("done") }))
+	_, err = k.Move(ctx, id, keg.NodeId{ID: 5})
+	require.NoError(t, err)
+	_, err = k.Remove(ctx, keg.NodeId{ID: 1})
+	require.NoError(t, err)
+	require.NoError(t, k.Index(ctx, keg.IndexOptions{Rebuild: true}))
+
+	require.Equal(t, []string{
+		"node-created 2 Two zk",
+		"content-updated 2 Second",
+		"meta-updated 2 done,zk",
+		"node-deleted 2",
+		"node-created 5 Second done,zk",
+		"node-deleted 1",
+		"index-rebuilt true",
+	}, log)
+}
+
+func TestEventBus_SubscribeTo(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t)
+	ctx, rt := sb.Context(), sb.Runtime()
+	repo := keg.NewMemoryRepo(rt)
+	kegtest.NewKegFixture().MustBuild(t, ctx, repo, rt)
+
+	bus := keg.NewEventBus()
+	k := keg.NewKeg(repo, rt, keg.WithEventBus(bus))
+	var created []string
+	unsubscribe := keg.SubscribeTo(bus, func(_ context.Context, e keg.NodeCreated) {
+		created = append(created, e.Title)
+	})
+
+	id, err := k.Create(ctx, &keg.CreateOptions{Title: "First"})
+	require.NoError(t, err)
+	require.NoError(t, k.SetContent(ctx, id, []byte("# Renamed\n")))
+	unsubscribe()
+	_, err = k.Create(ctx, &keg.CreateOptions{Title: "Second"})
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"First"}, created)
+}
+
+func TestKeg_NoEventsWithoutBus(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t)
+	ctx, rt := sb.Context(), sb.Runtime()
+	repo := keg.NewMemoryRepo(rt)
+	kegtest.NewKegFixture().MustBuild(t, ctx, repo, rt)
+
+	k := keg.NewKeg(repo, rt)
+	_, err := k.Create(ctx, &keg.CreateOptions{Title: "Quiet"})
+	require.NoError(t, err)
+	var bus *keg.EventBus
+	bus.Publish(ctx, keg.NodeDeleted{})
+}
@@ -0,0 +1,138 @@
+package keg_test
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/jlrickert/tapper/pkg/keg"
+)
+
+// The fuzz targets below cover parsers that read files users edit by hand or
+// sync from elsewhere. Inputs that once broke a parser are kept under
+// testdata/fuzz/<Target> and replay as regular tests; add new crashers there
+// with the fix.
+
+func FuzzParseContent(f *testing.F) {
+	f.Add([]byte("# Title\n\nLead paragraph with ../1 and ../2.\n"), "README.md")
+	f.Add([]byte("---\ntags: [go]\n---\n# Front\n\n![img](images/a.png)\n"), "README.md")
+	f.Add([]byte("Title\n=====\n\nLead for ../3\n"), "README.rst")
+	f.Add([]byte("```\n# not a title\n```\n[link](../4)\n"), "")
+	rt := testRuntime(f)
+	f.Fuzz(func(t *testing.T, data []byte, format string) {
+		c, err := keg.ParseContent(rt, data, format)
+		if err != nil {
+			return
+		}
+		if !slices.IsSortedFunc(c.Links, func(a, b keg.NodeId) int { return a.Compare(b) }) {
+			t.Fatalf("links not sorted: %v", c.Links)
+		}
+		if len(slices.Compact(slices.Clone(c.Links))) != len(c.Links) {
+			t.Fatalf("links not deduplicated: %v", c.Links)
+		}
+	})
+}
+
+func FuzzParseMeta(f *testing.F) {
+	f.Add([]byte("tags: [go, testing]\nstatus: draft\n"))
+	f.Add([]byte("tags: go testing\ncreated: 2025-01-02T15:04:05Z\n"))
+	f.Add([]byte("links:\n  - ../1\nentity: [a, {b: c}]\n"))
+	f.Add([]byte("- not a mapping\n"))
+	f.Fuzz(func(t *testing.T, raw []byte) {
+		ctx := context.Background()
+		m, err := keg.ParseMeta(ctx, raw)
+		if err != nil {
+			return
+		}
+		again, err := keg.ParseMeta(ctx, []byte(m.ToYAML()))
+		if err != nil {
+			t.Fatalf("serialized meta does not parse: %v\n%s", err, m.ToYAML())
+		}
+		if !slices.Equal(m.Tags(), again.Tags()) {
+			t.Fatalf("tags changed on round trip: %v != %v", m.Tags(), again.Tags())
+		}
+	})
+}
+
+func FuzzParseNodeIndex(f *testing.F) {
+	f.Add([]byte("42\t2025-01-02T15:04:05Z\t2024-06-01T10:00:00Z\t2025-01-03T08:00:00Z\tMy Title\n"))
+	f.Add([]byte("1\t2025-01-02T15:04:05Z\tLegacy\n2\t\t\t\tNo times\n"))
+	f.Add([]byte("bad line\n3\t2025-01-02T15:04:05Z"))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		ctx := context.Background()
+		idx, err := keg.ParseNodeIndex(ctx, data)
+		if err != nil {
+			return
+		}
+		assertIndexStable(t, &idx, func(b []byte) (indexData, error) {
+			next, err := keg.ParseNodeIndex(ctx, b)
+			return &next, err
+		})
+	})
+}
+
+func FuzzParseChangesIndex(f *testing.F) {
+	f.Add([]byte("* 2025-10-03 20:52:37Z [Title](../1)\n* 2025-10-02 08:00:00Z [Other [x]](../2)\n"))
+	f.Add([]byte("* 2025-10-03 20:52:37Z [Broken](../x)\nnot a line\n"))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		ctx := context.Background()
+		idx, err := keg.ParseChangesIndex(ctx, data)
+		if err != nil {
+			return
+		}
+		assertIndexStable(t, &idx, func(b []byte) (indexData, error) {
+			next, err := keg.ParseChangesIndex(ctx, b)
+			return &next, err
+		})
+	})
+}
+
+func FuzzParseTagExpression(f *testing.F) {
+	for _, seed := range []string{"go", "go and not draft", "(a or b) and c", "a b", "not (", "a or or b", "'x y'"} {
+		f.Add(seed)
+	}
+	universe := map[string]struct{}{"1": {}, "2": {}, "3": {}}
+	tagged := map[string]map[string]struct{}{
+		"a": {"1": {}}, "b": {"2": {}}, "c": {"1": {}, "3": {}},
+	}
+	f.Fuzz(func(t *testing.T, raw string) {
+		expr, err := keg.ParseTagExpression(raw)
+		if err != nil {
+			return
+		}
+		got := keg.EvaluateTagExpression(expr, universe, func(tag string) map[string]struct{} {
+			return tagged[tag]
+		})
+		for id := range got {
+			if _, ok := universe[id]; !ok {
+				t.Fatalf("%q matched %q outside the universe", raw, id)
+			}
+		}
+	})
+}
+
+type indexData interface {
+	Data(ctx context.Context) ([]byte, error)
+}
+
+// assertIndexStable checks that serializing a parsed index and parsing it
+// again reproduces the same bytes.
+func assertIndexStable(t *testing.T, idx indexData, parse func([]byte) (indexData, error)) {
+	t.Helper()
+	ctx := context.Background()
+	first, err := idx.Data(ctx)
+	if err != nil {
+		t.Fatalf("unable to serialize parsed index: %v", err)
+	}
+	reparsed, err := parse(first)
+	if err != nil {
+		t.Fatalf("serialized index does not parse: %v\n%s", err, first)
+	}
+	second, err := reparsed.Data(ctx)
+	if err != nil {
+		t.Fatalf("unable to serialize reparsed index: %v", err)
+	}
+	if string(first) != string(second) {
+		t.Fatalf("index changed on round trip:\n%q\n%q", first, second)
+	}
+}
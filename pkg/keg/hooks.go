@@ -0,0 +1,217 @@
+package keg
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// HookEvent names the point in a keg operation where a hook runs.
+type HookEvent string
+
+// Hook events. Pre hooks run before the operation changes anything; post
+// hooks run once it has succeeded.
+const (
+	HookPreCreate  HookEvent = "pre-create"
+	HookPostCreate HookEvent = "post-create"
+	HookPreEdit    HookEvent = "pre-edit"
+	HookPostEdit   HookEvent = "post-edit"
+	HookPreDelete  HookEvent = "pre-delete"
+	HookPostDelete HookEvent = "post-delete"
+	HookPreIndex   HookEvent = "pre-index"
+	HookPostIndex  HookEvent = "post-index"
+)
+
+// HookPolicy decides what a failing hook does to the operation.
+type HookPolicy string
+
+const (
+	// HookAbort fails the operation. A failing pre hook stops it before
+	// anything is written; a failing post hook is returned as the
+	// operation's error after its changes are made.
+	HookAbort HookPolicy = "abort"
+	// HookWarn logs the failure and lets the operation succeed.
+	HookWarn HookPolicy = "warn"
+)
+
+// HookContext describes the node and keg a hook runs for. Node, Title and
+// Tags are empty for index hooks, and Node is empty for pre-create hooks
+// because the id is not allocated yet. Path and KegPath are only set for
+// file kegs.
+type HookContext struct {
+	Event   HookEvent `json:"event"`
+	Node    string    `json:"node,omitempty"`
+	Title   string    `json:"title,omitempty"`
+	Tags    []string  `json:"tags,omitempty"`
+	Path    string    `json:"path,omitempty"`
+	KegPath string    `json:"keg,omitempty"`
+}
+
+// HookFunc is a hook implemented in Go and registered with WithHooks.
+type HookFunc func(ctx context.Context, hc HookContext) error
+
+// Hook runs a shell command or a HookFunc on a lifecycle event.
+//
+// Run is executed with sh -c (cmd /C on Windows) from the keg directory of
+// file kegs. The hook context is passed in the TAP_HOOK_EVENT, TAP_NODE_ID,
+// TAP_NODE_TITLE, TAP_NODE_TAGS (space separated), TAP_NODE_PATH and
+// TAP_KEG_PATH environment variables and as JSON on stdin. A non-zero exit
+// status fails the hook.
+type Hook struct {
+	// Event is the event the hook runs on.
+	Event HookEvent `yaml:"event"`
+
+	// Run is the shell command to run. Ignored when Func is set.
+	Run string `yaml:"run,omitempty"`
+
+	// Func runs in-process instead of Run.
+	Func HookFunc `yaml:"-" json:"-"`
+
+	// OnFailure is abort or warn. Defaults to abort for pre hooks and warn
+	// for post hooks.
+	OnFailure HookPolicy `yaml:"onFailure,omitempty"`
+}
+
+// Policy returns the hook's failure policy with the default applied.
+func (h Hook) Policy() HookPolicy {
+	if h.OnFailure != "" {
+		return h.OnFailure
+	}
+	if strings.HasPrefix(string(h.Event), "pre-") {
+		return HookAbort
+	}
+	return HookWarn
+}
+
+// WithHooks registers hooks that run alongside the hooks in the keg config.
+// They run first, in the order given.
+func WithHooks(hooks ...Hook) Option {
+	return func(k *Keg) {
+		k.Hooks = append(k.Hooks, hooks...)
+	}
+}
+
+// hookSet is the hooks that apply to one operation.
+type hookSet []Hook
+
+// hooks returns the keg's registered hooks followed by those in its config.
+// Config hooks are left out until the user trusts them.
+func (k *Keg) hooks(ctx context.Context) hookSet {
+	hooks := hookSet(k.Hooks)
+	if cfg, err := k.Repo.ReadConfig(ctx); err == nil && cfg != nil && len(cfg.Hooks) > 0 && k.TrustsConfig(cfg) {
+		hooks = append(append(hookSet(nil), hooks...), cfg.Hooks...)
+	}
+	return hooks
+}
+
+// has reports whether any hook runs on one of events.
+func (s hookSet) has(events ...HookEvent) bool {
+	for _, h := range s {
+		for _, event := range events {
+			if h.Event == event {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// nodeHookContext describes an existing node for its hooks. Fields that
+// cannot be read are left empty.
+func (k *Keg) nodeHookContext(ctx context.Context, id NodeId) HookContext {
+	hc := HookContext{Node: id.Path()}
+	if stats, err := k.getStats(ctx, id); err == nil && stats != nil {
+		hc.Title = stats.Title()
+	}
+	if meta, err := k.getMeta(ctx, id); err == nil && meta != nil {
+		hc.Tags = meta.Tags()
+	}
+	return hc
+}
+
+// runHooks runs the hooks in hooks registered for event, in order. A hook
+// failing under HookAbort stops the remaining hooks and returns the error;
+// under HookWarn the failure is logged. Command hooks are skipped on a dry
+// run.
+func (k *Keg) runHooks(ctx context.Context, hooks hookSet, event HookEvent, hc HookContext) error {
+	if !hooks.has(event) {
+		return nil
+	}
+	hc.Event = event
+	if fsRepo, ok := UnwrapRepo(k.Repo).(*FsRepo); ok {
+		if root, err := fsRepo.hostPath(fsRepo.Root); err == nil {
+			hc.KegPath = root
+			if hc.Node != "" {
+				hc.Path = filepath.Join(root, hc.Node)
+			}
+		}
+	}
+
+	for _, h := range hooks {
+		if h.Event != event {
+			continue
+		}
+		// A dry run must not reach outside the keg copy.
+		if k.dryRun && h.Func == nil {
+			continue
+		}
+		err := k.runHook(ctx, h, hc)
+		if err == nil {
+			continue
+		}
+		if h.Policy() == HookWarn {
+			k.Runtime.Logger().Warn("hook failed", "event", event, "node", hc.Node, "error", err)
+			continue
+		}
+		return fmt.Errorf("%s hook failed: %w", event, err)
+	}
+	return nil
+}
+
+func (k *Keg) runHook(ctx context.Context, h Hook, hc HookContext) error {
+	if h.Func != nil {
+		return h.Func(ctx, hc)
+	}
+	if strings.TrimSpace(h.Run) == "" {
+		return fmt.Errorf("hook has no command: %w", ErrInvalid)
+	}
+
+	in, err := json.Marshal(hc)
+	if err != nil {
+		return err
+	}
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", h.Run)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", h.Run)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdin = bytes.NewReader(in)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	cmd.Dir = hc.KegPath
+	cmd.Env = append(k.Runtime.Environ(),
+		"TAP_HOOK_EVENT="+string(hc.Event),
+		"TAP_NODE_ID="+hc.Node,
+		"TAP_NODE_TITLE="+hc.Title,
+		"TAP_NODE_TAGS="+strings.Join(hc.Tags, " "),
+		"TAP_NODE_PATH="+hc.Path,
+		"TAP_KEG_PATH="+hc.KegPath,
+	)
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%s: %w: %s", h.Run, err, msg)
+		}
+		return fmt.Errorf("%s: %w", h.Run, err)
+	}
+	if out := strings.TrimSpace(stdout.String()); out != "" {
+		k.Runtime.Logger().Debug("hook output", "event", hc.Event, "run", h.Run, "output", out)
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+package keg_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/jlrickert/tapper/pkg/keg"
+	"github.com/jlrickert/tapper/pkg/kegtest"
+	"github.com/stretchr/testify/require"
+)
+
+// recordHooks returns hooks for every event that append "event node title
+// tags" to log.
+func recordHooks(log *[]string) []keg.Hook {
+	events := []keg.HookEvent{
+		keg.HookPreCreate, keg.HookPostCreate, keg.HookPreEdit, keg.HookPostEdit,
+		keg.HookPreDelete, keg.HookPostDelete, keg.HookPreIndex, keg.HookPostIndex,
+	}
+	hooks := make([]keg.Hook, 0, len(events))
+	for _, event := range events {
+		hooks = append(hooks, keg.Hook{Event: event, Func: func(_ context.Context, hc keg.HookContext) error {
+			*log = append(*log, strings.TrimSpace(fmt.Sprintf("%s %s %s %s", hc.Event, hc.Node, hc.Title, strings.Join(hc.Tags, ","))))
+			return nil
+		}})
+	}
+	return hooks
+}
+
+func TestKeg_HooksRunAroundOperations(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t)
+	ctx, rt := sb.Context(), sb.Runtime()
+	repo := keg.NewMemoryRepo(rt)
+	kegtest.NewKegFixture().Node(1, "One", "Body.").Tag("go").MustBuild(t, ctx, repo, rt)
+
+	var log []string
+	k := keg.NewKeg(repo, rt, keg.WithHooks(recordHooks(&log)...))
+	id, err := k.Create(ctx, &keg.CreateOptions{Title: "Two", Tags: []string{"zk"}})
+	require.NoError(t, err)
+	require.NoError(t, k.SetContent(ctx, id, []byte("# Second\n")))
+	_, err = k.Remove(ctx, keg.NodeId{ID: 1})
+	require.NoError(t, err)
+	require.NoError(t, k.Index(ctx, keg.IndexOptions{}))
+
+	require.Equal(t, []string{
+		"pre-create  Two zk",
+		"post-create 2 Two zk",
+		"pre-edit 2 Two zk",
+		"post-edit 2 Second zk",
+		"pre-delete 1 One go",
+		"post-delete 1 One go",
+		"pre-index",
+		"post-index",
+	}, log)
+}
+
+func TestKeg_HookFailurePolicies(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t)
+	ctx, rt := sb.Context(), sb.Runtime()
+	repo := keg.NewMemoryRepo(rt)
+	k := kegtest.NewKegFixture().Node(1, "One", "Body.").MustBuild(t, ctx, repo, rt)
+	errHook := errors.New("hook says no")
+	fail := func(context.Context, keg.HookContext) error { return errHook }
+
+	k.Hooks = []keg.Hook{{Event: keg.HookPreDelete, Func: fail}}
+	_, err := k.Remove(ctx, keg.NodeId{ID: 1})
+	require.ErrorIs(t, err, errHook)
+	require.ErrorContains(t, err, "pre-delete hook failed")
+	exists, err := repo.HasNode(ctx, keg.NodeId{ID: 1})
+	require.NoError(t, err)
+	require.True(t, exists, "a failing pre hook stops the operation")
+
+	k.Hooks = []keg.Hook{{Event: keg.HookPreCreate, Func: fail, OnFailure: keg.HookWarn}}
+	_, err = k.Create(ctx, &keg.CreateOptions{Title: "Warned"})
+	require.NoError(t, err)
+
+	k.Hooks = []keg.Hook{{Event: keg.HookPostEdit, Func: fail}}
+	require.NoError(t, k.SetContent(ctx, keg.NodeId{ID: 1}, []byte("# Edited\n")), "post hooks warn by default")
+
+	k.Hooks = []keg.Hook{{Event: keg.HookPostCreate, Func: fail, OnFailure: keg.HookAbort}}
+	id, err := k.Create(ctx, &keg.CreateOptions{Title: "Kept"})
+	require.ErrorIs(t, err, errHook)
+	exists, err = repo.HasNode(ctx, id)
+	require.NoError(t, err)
+	require.True(t, exists, "a failing post hook does not undo the operation")
+}
+
+func TestKeg_ShellHooksFromConfig(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell hooks run with sh -c")
+	}
+	t.Parallel()
+	sb := NewSandbox(t)
+	ctx, rt := sb.Context(), sb.Runtime()
+	repo := keg.NewFsRepo(t.TempDir(), rt)
+	k := kegtest.NewKegFixture().Node(1, "One", "Body.").MustBuild(t, ctx, repo, rt)
+
+	require.NoError(t, k.UpdateConfig(ctx, func(cfg *keg.Config) {
+		cfg.Hooks = []keg.Hook{
+			{Event: keg.HookPostCreate, Run: `printf '%s %s %s\n' "$TAP_HOOK_EVENT" "$TAP_NODE_ID" "$TAP_NODE_TITLE" >> hooks.log`},
+			{Event: keg.HookPostCreate, Run: `read -r line; printf '%s\n' "$line" > "$TAP_NODE_PATH/context.json"`},
+			{Event: keg.HookPreEdit, Run: `echo "$TAP_NODE_ID is frozen" >&2; exit 3`},
+		}
+	}))
+	var kegPath string
+	k.Hooks = []keg.Hook{{Event: keg.HookPostCreate, Func: func(_ context.Context, hc keg.HookContext) error {
+		kegPath = hc.KegPath
+		return nil
+	}}}
+
+	// Hooks in the keg config only run once the user trusts them.
+	_, err := k.Create(ctx, &keg.CreateOptions{Title: "Untrusted"})
+	require.NoError(t, err)
+	require.NotEmpty(t, kegPath)
+	_, err = os.Stat(filepath.Join(kegPath, "hooks.log"))
+	require.ErrorIs(t, err, os.ErrNotExist)
+	cfg, err := k.Config(ctx)
+	require.NoError(t, err)
+	k.TrustedConfig = cfg.TrustDigest()
+
+	id, err := k.Create(ctx, &keg.CreateOptions{Title: "Shell", Tags: []string{"go"}})
+	require.NoError(t, err)
+	log, err := os.ReadFile(filepath.Join(kegPath, "hooks.log"))
+	require.NoError(t, err)
+	require.Equal(t, "post-create 3 Shell\n", string(log))
+	hc, err := os.ReadFile(filepath.Join(kegPath, "3", "context.json"))
+	require.NoError(t, err)
+	require.JSONEq(t, fmt.Sprintf(`{"event":"post-create","node":"3","title":"Shell","tags":["go"],"path":%q,"keg":%q}`,
+		filepath.Join(kegPath, "3"), kegPath), string(hc))
+
+	err = k.SetContent(ctx, id, []byte("# Changed\n"))
+	require.ErrorContains(t, err, "3 is frozen")
+	content, err := k.GetContent(ctx, id)
+	require.NoError(t, err)
+	require.NotContains(t, string(content), "Changed")
+}
+
+func TestKeg_DryRunSkipsShellHooks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell hooks run with sh -c")
+	}
+	t.Parallel()
+	sb := NewSandbox(t)
+	ctx, rt := sb.Context(), sb.Runtime()
+	repo := keg.NewFsRepo(t.TempDir(), rt)
+	k := kegtest.NewKegFixture().Node(1, "One", "Body.").MustBuild(t, ctx, repo, rt)
+	marker := filepath.Join(t.TempDir(), "hook.log")
+	require.NoError(t, k.UpdateConfig(ctx, func(cfg *keg.Config) {
+		cfg.Hooks = []keg.Hook{{Event: keg.HookPostCreate, Run: "echo ran >> " + marker}}
+	}))
+	cfg, err := k.Config(ctx)
+	require.NoError(t, err)
+	k.TrustedConfig = cfg.TrustDigest()
+
+	dry, err := keg.NewDryRun(ctx, k)
+	require.NoError(t, err)
+	_, err = dry.Keg.Create(ctx, &keg.CreateOptions{Title: "Rehearsed"})
+	require.NoError(t, err)
+	_, err = os.Stat(marker)
+	require.ErrorIs(t, err, os.ErrNotExist, "a dry run does not run hook commands")
+
+	_, err = k.Create(ctx, &keg.CreateOptions{Title: "Real"})
+	require.NoError(t, err)
+	log, err := os.ReadFile(marker)
+	require.NoError(t, err)
+	require.Equal(t, "ran\n", string(log))
+}
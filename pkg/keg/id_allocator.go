@@ -0,0 +1,103 @@
+package keg
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// IDAllocator chooses the id of each node a Keg creates or commits from a
+// draft. Allocate must reserve the id it returns so a concurrent allocation
+// cannot hand it out again; ReserveNode does that for allocators computing
+// their own candidates. now comes from the keg runtime's clock, which makes
+// time-based schemes reproducible under a fixed test clock.
+type IDAllocator interface {
+	Allocate(ctx context.Context, repo Repository, now time.Time) (NodeId, error)
+}
+
+// IDAllocatorFunc adapts a function to IDAllocator.
+type IDAllocatorFunc func(ctx context.Context, repo Repository, now time.Time) (NodeId, error)
+
+// Allocate calls f.
+func (f IDAllocatorFunc) Allocate(ctx context.Context, repo Repository, now time.Time) (NodeId, error) {
+	return f(ctx, repo, now)
+}
+
+// SequentialIDs allocates one past the highest existing or archived node id
+// through Repository.Next. It is the default allocator.
+var SequentialIDs IDAllocator = IDAllocatorFunc(func(ctx context.Context, repo Repository, _ time.Time) (NodeId, error) {
+	return repo.Next(ctx)
+})
+
+// DatePrefixedIDs returns an allocator that numbers nodes by the day they
+// are created: the date as YYYYMMDD followed by a sequence of digits
+// counting from 1, so with digits 2 the first node of 2 January 2024 is
+// 2024010201. digits below 1 are treated as 1. The day is taken in now's
+// location. Allocation fails once a day's sequence is exhausted. With more
+// than 2 digits the ids exceed DefaultMaxNodeID, so MaxNodeID must be raised.
+func DatePrefixedIDs(digits int) IDAllocator {
+	digits = max(digits, 1)
+	return IDAllocatorFunc(func(ctx context.Context, repo Repository, now time.Time) (NodeId, error) {
+		day, err := strconv.Atoi(now.Format("20060102"))
+		if err != nil {
+			return NodeId{}, err
+		}
+		span := 1
+		for range digits {
+			span *= 10
+		}
+		for seq := 1; seq < span; seq++ {
+			id := NodeId{ID: day*span + seq}
+			ok, err := ReserveNode(ctx, repo, id)
+			if err != nil {
+				return NodeId{}, err
+			}
+			if ok {
+				return id, nil
+			}
+		}
+		return NodeId{}, fmt.Errorf("all %d node ids for %s are taken: %w",
+			span-1, now.Format(time.DateOnly), ErrExist)
+	})
+}
+
+// ReserveNode claims id for a new node by writing it an empty placeholder,
+// as Repository.Next does for the id it returns. It reports false when id
+// already exists or is archived. The check and the write run under the
+// repository's keg-wide lock when it has one.
+func ReserveNode(ctx context.Context, repo Repository, id NodeId) (bool, error) {
+	reserved := false
+	reserve := func(ctx context.Context) error {
+		exists, err := repo.HasNode(ctx, id)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return nil
+		}
+		if archive, ok := repoArchive(repo); ok {
+			archived, err := archive.ListArchived(ctx)
+			if err != nil {
+				return err
+			}
+			if slices.Contains(archived, id) {
+				return nil
+			}
+		}
+		if err := repo.WriteContent(ctx, id, nil); err != nil {
+			return fmt.Errorf("failed to reserve node %s: %w", id.Path(), err)
+		}
+		reserved = true
+		return nil
+	}
+
+	var err error
+	if locker, ok := repoKegLock(repo); ok {
+		err = locker.WithKegLock(ctx, reserve)
+	} else {
+		err = reserve(ctx)
+	}
+	return reserved, err
+}
@@ -0,0 +1,95 @@
+package keg_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jlrickert/tapper/pkg/keg"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDatePrefixedIDs_FollowRuntimeClock(t *testing.T) {
+	t.Parallel()
+	for name, newRepo := range map[string]func(*testing.T) keg.Repository{
+		"memory":     newMemoryRepo,
+		"filesystem": newFsRepo,
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			sb := NewSandbox(t)
+			ctx := sb.Context()
+			k := keg.NewKeg(newRepo(t), sb.Runtime(), keg.WithIDAllocator(keg.DatePrefixedIDs(2)))
+			require.NoError(t, k.Init(ctx))
+
+			var ids []int
+			for range 2 {
+				id, err := k.Create(ctx, &keg.CreateOptions{Title: "Entry"})
+				require.NoError(t, err)
+				ids = append(ids, id.ID)
+			}
+			sb.Advance(24 * time.Hour)
+			draft, err := k.Create(ctx, &keg.CreateOptions{Title: "Draft", Draft: true})
+			require.NoError(t, err)
+			committed, err := k.Commit(ctx, draft)
+			require.NoError(t, err)
+			ids = append(ids, committed.ID)
+			require.Equal(t, []int{2025101501, 2025101502, 2025101601}, ids)
+
+			stats, err := k.GetStats(ctx, committed)
+			require.NoError(t, err)
+			require.Equal(t, sb.Now(), stats.Created())
+		})
+	}
+}
+
+func TestDatePrefixedIDs_SkipsArchivedAndExhausts(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t)
+	ctx := sb.Context()
+	repo := keg.NewMemoryRepo(sb.Runtime())
+	k := keg.NewKeg(repo, sb.Runtime(), keg.WithIDAllocator(keg.DatePrefixedIDs(1)))
+	require.NoError(t, k.Init(ctx))
+
+	first, err := k.Create(ctx, &keg.CreateOptions{Title: "One"})
+	require.NoError(t, err)
+	require.NoError(t, repo.ArchiveNode(ctx, first))
+	for want := 202510152; want <= 202510159; want++ {
+		id, err := k.Create(ctx, &keg.CreateOptions{Title: "Entry"})
+		require.NoError(t, err)
+		require.Equal(t, want, id.ID)
+	}
+	_, err = k.Create(ctx, &keg.CreateOptions{Title: "Overflow"})
+	require.ErrorIs(t, err, keg.ErrExist)
+	require.ErrorContains(t, err, "all 9 node ids for 2025-10-15 are taken")
+}
+
+func TestIDAllocatorFunc_CustomScheme(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t)
+	ctx := sb.Context()
+	next := 100
+	ids := keg.IDAllocatorFunc(func(ctx context.Context, repo keg.Repository, _ time.Time) (keg.NodeId, error) {
+		for {
+			id := keg.NodeId{ID: next}
+			next += 100
+			if ok, err := keg.ReserveNode(ctx, repo, id); err != nil || ok {
+				return id, err
+			}
+		}
+	})
+	repo := keg.NewMemoryRepo(sb.Runtime())
+	k := keg.NewKeg(repo, sb.Runtime(), keg.WithIDAllocator(ids))
+	require.NoError(t, k.Init(ctx))
+	require.NoError(t, repo.WriteContent(ctx, keg.NodeId{ID: 200}, []byte("# Taken\n")))
+
+	a, err := k.Create(ctx, &keg.CreateOptions{Title: "A"})
+	require.NoError(t, err)
+	b, err := k.Create(ctx, &keg.CreateOptions{Title: "B"})
+	require.NoError(t, err)
+	require.Equal(t, []int{100, 300}, []int{a.ID, b.ID})
+
+	content, err := k.GetContent(ctx, keg.NodeId{ID: 200})
+	require.NoError(t, err)
+	require.Equal(t, "# Taken\n", string(content))
+}
@@ -0,0 +1,389 @@
+package keg
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+// StripImageMetadata removes EXIF (including GPS coordinates) and XMP
+// metadata from JPEG, PNG and HEIC images. Pixel data is left untouched.
+// Other formats are returned unchanged. The boolean reports whether any
+// metadata was removed.
+//
+// JPEG APP1/APP13 segments and PNG eXIf/XMP chunks are dropped outright.
+// HEIC metadata items are zeroed in place so the item offsets recorded in
+// the container stay valid.
+func StripImageMetadata(data []byte) ([]byte, bool, error) {
+	switch {
+	case bytes.HasPrefix(data, jpegSOI):
+		return stripJPEGMetadata(data)
+	case bytes.HasPrefix(data, pngSignature):
+		return stripPNGMetadata(data)
+	case isHEIF(data):
+		return stripHEIFMetadata(data)
+	default:
+		return data, false, nil
+	}
+}
+
+var (
+	jpegSOI      = []byte{0xFF, 0xD8}
+	pngSignature = []byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1A, '\n'}
+)
+
+// Image formats reported by DetectImageFormat.
+const (
+	ImageFormatJPEG = "jpeg"
+	ImageFormatPNG  = "png"
+	ImageFormatHEIF = "heif"
+	ImageFormatTIFF = "tiff"
+	ImageFormatGIF  = "gif"
+	ImageFormatWebP = "webp"
+)
+
+// DetectImageFormat identifies an image by its leading bytes. It returns an
+// empty string for unrecognized data.
+func DetectImageFormat(data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, jpegSOI):
+		return ImageFormatJPEG
+	case bytes.HasPrefix(data, pngSignature):
+		return ImageFormatPNG
+	case isHEIF(data):
+		return ImageFormatHEIF
+	case bytes.HasPrefix(data, []byte("II*\x00")) || bytes.HasPrefix(data, []byte("MM\x00*")):
+		return ImageFormatTIFF
+	case bytes.HasPrefix(data, []byte("GIF87a")) || bytes.HasPrefix(data, []byte("GIF89a")):
+		return ImageFormatGIF
+	case len(data) >= 12 && string(data[0:4]) == "RIFF" && string(data[8:12]) == "WEBP":
+		return ImageFormatWebP
+	default:
+		return ""
+	}
+}
+
+// stripJPEGMetadata drops APP1 (EXIF, XMP) and APP13 (Photoshop/IPTC)
+// segments before the start of scan.
+func stripJPEGMetadata(data []byte) ([]byte, bool, error) {
+	out := make([]byte, 0, len(data))
+	out = append(out, jpegSOI...)
+	stripped := false
+	pos := len(jpegSOI)
+	for pos < len(data) {
+		if data[pos] != 0xFF || pos+1 >= len(data) {
+			return nil, false, fmt.Errorf("malformed jpeg at offset %d: %w", pos, ErrParse)
+		}
+		marker := data[pos+1]
+		switch {
+		case marker == 0xFF:
+			// Fill byte before a marker.
+			pos++
+			continue
+		case marker == 0xD9 || (marker >= 0xD0 && marker <= 0xD7) || marker == 0x01:
+			// Markers without a length field.
+			out = append(out, data[pos:pos+2]...)
+			pos += 2
+			continue
+		}
+		if pos+4 > len(data) {
+			return nil, false, fmt.Errorf("truncated jpeg segment at offset %d: %w", pos, ErrParse)
+		}
+		end := pos + 2 + int(binary.BigEndian.Uint16(data[pos+2:pos+4]))
+		if end > len(data) {
+			return nil, false, fmt.Errorf("truncated jpeg segment at offset %d: %w", pos, ErrParse)
+		}
+		if marker == 0xDA {
+			// Start of scan: the rest is entropy-coded image data.
+			out = append(out, data[pos:]...)
+			return out, stripped, nil
+		}
+		if marker == 0xE1 || marker == 0xED {
+			stripped = true
+		} else {
+			out = append(out, data[pos:end]...)
+		}
+		pos = end
+	}
+	return out, stripped, nil
+}
+
+// pngMetadataKeywords are text chunk keywords that carry EXIF or XMP data.
+var pngMetadataKeywords = [][]byte{
+	[]byte("XML:com.adobe.xmp"),
+	[]byte("Raw profile type exif"),
+	[]byte("Raw profile type APP1"),
+	[]byte("Raw profile type xmp"),
+}
+
+// stripPNGMetadata drops eXIf chunks and text chunks holding EXIF or XMP.
+func stripPNGMetadata(data []byte) ([]byte, bool, error) {
+	out := make([]byte, 0, len(data))
+	out = append(out, pngSignature...)
+	stripped := false
+	pos := len(pngSignature)
+	for pos < len(data) {
+		if pos+8 > len(data) {
+			return nil, false, fmt.Errorf("truncated png chunk at offset %d: %w", pos, ErrParse)
+		}
+		length := int(binary.BigEndian.Uint32(data[pos : pos+4]))
+		end := pos + 12 + length
+		if length < 0 || end > len(data) {
+			return nil, false, fmt.Errorf("truncated png chunk at offset %d: %w", pos, ErrParse)
+		}
+		kind := string(data[pos+4 : pos+8])
+		body := data[pos+8 : pos+8+length]
+		if kind == "eXIf" || (isPNGTextChunk(kind) && hasPNGMetadataKeyword(body)) {
+			stripped = true
+		} else {
+			out = append(out, data[pos:end]...)
+		}
+		pos = end
+		if kind == "IEND" {
+			break
+		}
+	}
+	return out, stripped, nil
+}
+
+func isPNGTextChunk(kind string) bool {
+	return kind == "tEXt" || kind == "zTXt" || kind == "iTXt"
+}
+
+func hasPNGMetadataKeyword(body []byte) bool {
+	keyword, _, _ := bytes.Cut(body, []byte{0})
+	for _, k := range pngMetadataKeywords {
+		if bytes.Equal(keyword, k) {
+			return true
+		}
+	}
+	return false
+}
+
+// isHEIF reports whether data starts with an ISO BMFF ftyp box declaring a
+// HEIF brand.
+func isHEIF(data []byte) bool {
+	if len(data) < 12 || string(data[4:8]) != "ftyp" {
+		return false
+	}
+	switch string(data[8:12]) {
+	case "heic", "heix", "heim", "heis", "hevc", "hevx", "mif1", "msf1", "avif":
+		return true
+	}
+	return false
+}
+
+// stripHEIFMetadata zeroes the payload of Exif and XMP (mime) items listed
+// in the top-level meta box. Only items stored directly in the file
+// (construction method 0) are touched.
+func stripHEIFMetadata(data []byte) ([]byte, bool, error) {
+	meta, ok := findBox(data, "meta")
+	if !ok || len(meta) < 4 {
+		return data, false, nil
+	}
+	children := meta[4:] // skip full box version and flags
+
+	iinf, ok := findBox(children, "iinf")
+	if !ok {
+		return data, false, nil
+	}
+	targets, err := heifMetadataItems(iinf)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(targets) == 0 {
+		return data, false, nil
+	}
+	iloc, ok := findBox(children, "iloc")
+	if !ok {
+		return nil, false, fmt.Errorf("heif meta box has no iloc: %w", ErrParse)
+	}
+	extents, err := heifItemExtents(iloc, targets)
+	if err != nil {
+		return nil, false, err
+	}
+
+	out := bytes.Clone(data)
+	stripped := false
+	for _, ext := range extents {
+		if ext.offset < 0 || ext.length < 0 || ext.offset+ext.length > int64(len(out)) {
+			return nil, false, fmt.Errorf("heif item extent out of range: %w", ErrParse)
+		}
+		clear(out[ext.offset : ext.offset+ext.length])
+		stripped = true
+	}
+	return out, stripped, nil
+}
+
+// findBox returns the payload of the first box of the given type in a
+// sequence of ISO BMFF boxes.
+func findBox(data []byte, kind string) ([]byte, bool) {
+	pos := 0
+	for pos+8 <= len(data) {
+		size := int64(binary.BigEndian.Uint32(data[pos : pos+4]))
+		header := int64(8)
+		switch size {
+		case 0:
+			size = int64(len(data) - pos)
+		case 1:
+			if pos+16 > len(data) {
+				return nil, false
+			}
+			size = int64(binary.BigEndian.Uint64(data[pos+8 : pos+16]))
+			header = 16
+		}
+		if size < header || int64(pos)+size > int64(len(data)) {
+			return nil, false
+		}
+		if string(data[pos+4:pos+8]) == kind {
+			return data[int64(pos)+header : int64(pos)+size], true
+		}
+		pos += int(size)
+	}
+	return nil, false
+}
+
+// heifMetadataItems returns the IDs of Exif and mime (XMP) items declared in
+// an iinf box payload.
+func heifMetadataItems(iinf []byte) (map[uint32]bool, error) {
+	if len(iinf) < 4 {
+		return nil, fmt.Errorf("truncated heif iinf box: %w", ErrParse)
+	}
+	r := beReader{data: iinf}
+	version := r.u8()
+	r.skip(3)
+	if version == 0 {
+		r.u16()
+	} else {
+		r.u32()
+	}
+	if r.err {
+		return nil, fmt.Errorf("truncated heif iinf box: %w", ErrParse)
+	}
+
+	items := map[uint32]bool{}
+	rest := iinf[r.pos:]
+	for len(rest) >= 8 {
+		size := int(binary.BigEndian.Uint32(rest[0:4]))
+		if size < 8 || size > len(rest) {
+			return nil, fmt.Errorf("malformed heif infe box: %w", ErrParse)
+		}
+		if string(rest[4:8]) == "infe" {
+			e := beReader{data: rest[8:size]}
+			infeVersion := e.u8()
+			e.skip(3)
+			if infeVersion >= 2 {
+				var id uint32
+				if infeVersion == 2 {
+					id = uint32(e.u16())
+				} else {
+					id = e.u32()
+				}
+				e.u16() // protection index
+				itemType := string(e.bytes(4))
+				if !e.err && (itemType == "Exif" || itemType == "mime") {
+					items[id] = true
+				}
+			}
+		}
+		rest = rest[size:]
+	}
+	return items, nil
+}
+
+type heifExtent struct {
+	offset int64
+	length int64
+}
+
+// heifItemExtents returns the absolute file extents of the given items from
+// an iloc box payload.
+func heifItemExtents(iloc []byte, items map[uint32]bool) ([]heifExtent, error) {
+	r := beReader{data: iloc}
+	version := r.u8()
+	r.skip(3)
+	sizes := r.u16()
+	offsetSize := int(sizes >> 12)
+	lengthSize := int(sizes >> 8 & 0xF)
+	baseOffsetSize := int(sizes >> 4 & 0xF)
+	indexSize := 0
+	if version == 1 || version == 2 {
+		indexSize = int(sizes & 0xF)
+	}
+	var count uint32
+	if version < 2 {
+		count = uint32(r.u16())
+	} else {
+		count = r.u32()
+	}
+
+	var extents []heifExtent
+	for i := uint32(0); i < count && !r.err; i++ {
+		var id uint32
+		if version < 2 {
+			id = uint32(r.u16())
+		} else {
+			id = r.u32()
+		}
+		method := 0
+		if version == 1 || version == 2 {
+			method = int(r.u16() & 0xF)
+		}
+		r.u16() // data reference index
+		base := r.uintN(baseOffsetSize)
+		extentCount := int(r.u16())
+		for j := 0; j < extentCount && !r.err; j++ {
+			r.uintN(indexSize)
+			off := r.uintN(offsetSize)
+			length := r.uintN(lengthSize)
+			if items[id] && method == 0 {
+				extents = append(extents, heifExtent{offset: int64(base + off), length: int64(length)})
+			}
+		}
+	}
+	if r.err {
+		return nil, fmt.Errorf("truncated heif iloc box: %w", ErrParse)
+	}
+	return extents, nil
+}
+
+// beReader reads big-endian integers, recording rather than panicking on
+// short input.
+type beReader struct {
+	data []byte
+	pos  int
+	err  bool
+}
+
+func (r *beReader) bytes(n int) []byte {
+	if r.err || r.pos+n > len(r.data) {
+		r.err = true
+		return make([]byte, n)
+	}
+	b := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return b
+}
+
+func (r *beReader) skip(n int) { r.bytes(n) }
+func (r *beReader) u8() uint8  { return r.bytes(1)[0] }
+func (r *beReader) u16() uint16 {
+	return binary.BigEndian.Uint16(r.bytes(2))
+}
+func (r *beReader) u32() uint32 {
+	return binary.BigEndian.Uint32(r.bytes(4))
+}
+
+func (r *beReader) uintN(n int) uint64 {
+	switch n {
+	case 0:
+		return 0
+	case 4:
+		return uint64(r.u32())
+	case 8:
+		return binary.BigEndian.Uint64(r.bytes(8))
+	default:
+		r.err = true
+		return 0
+	}
+}
@@ -0,0 +1,155 @@
+package keg
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func jpegSegment(marker byte, payload string) []byte {
+	seg := []byte{0xFF, marker, 0, 0}
+	binary.BigEndian.PutUint16(seg[2:], uint16(len(payload)+2))
+	return append(seg, payload...)
+}
+
+func testJPEG() []byte {
+	var b bytes.Buffer
+	b.Write([]byte{0xFF, 0xD8})
+	b.Write(jpegSegment(0xE0, "JFIF\x00\x01\x01\x00\x00\x01\x00\x01\x00\x00"))
+	b.Write(jpegSegment(0xE1, "Exif\x00\x00GPSLatitude=47.6"))
+	b.Write(jpegSegment(0xE1, "http://ns.adobe.com/xap/1.0/\x00<xmp/>"))
+	b.Write(jpegSegment(0xDB, "\x00\x01"))
+	b.Write(jpegSegment(0xDA, "\x01\x01\x00\x00\x3F\x00"))
+	b.Write([]byte{0x12, 0xFF, 0x00, 0x34, 0xFF, 0xD9})
+	return b.Bytes()
+}
+
+func TestStripImageMetadata_JPEG(t *testing.T) {
+	t.Parallel()
+	in := testJPEG()
+
+	out, stripped, err := StripImageMetadata(in)
+	require.NoError(t, err)
+	require.True(t, stripped)
+	require.NotContains(t, string(out), "GPSLatitude")
+	require.NotContains(t, string(out), "xap/1.0")
+	require.Contains(t, string(out), "JFIF")
+	require.True(t, bytes.HasSuffix(out, []byte{0x12, 0xFF, 0x00, 0x34, 0xFF, 0xD9}))
+
+	again, stripped, err := StripImageMetadata(out)
+	require.NoError(t, err)
+	require.False(t, stripped)
+	require.Equal(t, out, again)
+}
+
+func pngChunk(kind, data string) []byte {
+	chunk := make([]byte, 8, 12+len(data))
+	binary.BigEndian.PutUint32(chunk[0:4], uint32(len(data)))
+	copy(chunk[4:8], kind)
+	chunk = append(chunk, data...)
+	return binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(chunk[4:]))
+}
+
+func TestStripImageMetadata_PNG(t *testing.T) {
+	t.Parallel()
+	var b bytes.Buffer
+	b.Write(pngSignature)
+	b.Write(pngChunk("IHDR", "\x00\x00\x00\x01\x00\x00\x00\x01\x08\x02\x00\x00\x00"))
+	b.Write(pngChunk("eXIf", "MM\x00*GPS"))
+	b.Write(pngChunk("iTXt", "XML:com.adobe.xmp\x00\x00\x00\x00\x00<xmp/>"))
+	b.Write(pngChunk("tEXt", "Comment\x00keep me"))
+	b.Write(pngChunk("IDAT", "pixels"))
+	b.Write(pngChunk("IEND", ""))
+
+	out, stripped, err := StripImageMetadata(b.Bytes())
+	require.NoError(t, err)
+	require.True(t, stripped)
+	require.NotContains(t, string(out), "eXIf")
+	require.NotContains(t, string(out), "adobe.xmp")
+	require.Contains(t, string(out), "keep me")
+	require.Contains(t, string(out), "pixels")
+	require.True(t, bytes.HasSuffix(out, pngChunk("IEND", "")))
+}
+
+func isoBox(kind string, payload ...[]byte) []byte {
+	body := bytes.Join(payload, nil)
+	box := binary.BigEndian.AppendUint32(nil, uint32(8+len(body)))
+	box = append(box, kind...)
+	return append(box, body...)
+}
+
+func testHEIC(pixels, exif string) []byte {
+	infe := func(id uint16, itemType string) []byte {
+		p := []byte{2, 0, 0, 0}
+		p = binary.BigEndian.AppendUint16(p, id)
+		p = binary.BigEndian.AppendUint16(p, 0)
+		p = append(p, itemType...)
+		return isoBox("infe", p, []byte("\x00"))
+	}
+	iinf := isoBox("iinf", []byte{0, 0, 0, 0, 0, 2}, infe(1, "hvc1"), infe(2, "Exif"))
+
+	iloc := func(pixelOff, exifOff uint32) []byte {
+		p := []byte{0, 0, 0, 0, 0x44, 0x00, 0, 2}
+		for _, it := range []struct {
+			id       uint16
+			off, len uint32
+		}{{1, pixelOff, uint32(len(pixels))}, {2, exifOff, uint32(len(exif))}} {
+			p = binary.BigEndian.AppendUint16(p, it.id)
+			p = binary.BigEndian.AppendUint16(p, 0)
+			p = binary.BigEndian.AppendUint16(p, 1)
+			p = binary.BigEndian.AppendUint32(p, it.off)
+			p = binary.BigEndian.AppendUint32(p, it.len)
+		}
+		return isoBox("iloc", p)
+	}
+
+	ftyp := isoBox("ftyp", []byte("heic\x00\x00\x00\x00mif1"))
+	meta := isoBox("meta", []byte{0, 0, 0, 0}, iinf, iloc(0, 0))
+	start := uint32(len(ftyp) + len(meta) + 8)
+	meta = isoBox("meta", []byte{0, 0, 0, 0}, iinf, iloc(start, start+uint32(len(pixels))))
+	return bytes.Join([][]byte{ftyp, meta, isoBox("mdat", []byte(pixels+exif))}, nil)
+}
+
+func TestStripImageMetadata_HEIC(t *testing.T) {
+	t.Parallel()
+	in := testHEIC("PIXELDATA", "Exif\x00\x00GPS=47.6")
+
+	out, stripped, err := StripImageMetadata(in)
+	require.NoError(t, err)
+	require.True(t, stripped)
+	require.Len(t, out, len(in))
+	require.NotContains(t, string(out), "GPS=47.6")
+	require.Contains(t, string(out), "PIXELDATA")
+	require.Contains(t, string(in), "GPS=47.6", "input must not be modified")
+}
+
+func TestStripImageMetadata_OtherFormatsUnchanged(t *testing.T) {
+	t.Parallel()
+	in := []byte("GIF89a not really")
+	out, stripped, err := StripImageMetadata(in)
+	require.NoError(t, err)
+	require.False(t, stripped)
+	require.Equal(t, in, out)
+
+	_, _, err = StripImageMetadata([]byte{0xFF, 0xD8, 0xFF, 0xE1, 0x00})
+	require.ErrorIs(t, err, ErrParse)
+}
+
+func TestDetectImageFormat(t *testing.T) {
+	t.Parallel()
+	cases := map[string][]byte{
+		ImageFormatJPEG: testJPEG(),
+		ImageFormatPNG:  append(bytes.Clone(pngSignature), pngChunk("IEND", "")...),
+		ImageFormatHEIF: testHEIC("PIXELS", "Exif"),
+		ImageFormatTIFF: []byte("II*\x00\x08\x00\x00\x00"),
+		ImageFormatGIF:  []byte("GIF89a\x01\x00"),
+		ImageFormatWebP: []byte("RIFF\x10\x00\x00\x00WEBPVP8 "),
+		"":              []byte("plain text"),
+	}
+	for want, data := range cases {
+		require.Equal(t, want, DetectImageFormat(data))
+	}
+}
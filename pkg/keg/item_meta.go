@@ -0,0 +1,84 @@
+package keg
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+)
+
+// NodeItemMetaDir is the directory, inside a node's images or assets
+// directory, that holds one <name>.json metadata sidecar per item.
+const NodeItemMetaDir = ".meta"
+
+// ItemMeta is structured metadata about a node image or file attachment.
+type ItemMeta struct {
+	// MimeType is the media type of the item, for example "image/png".
+	MimeType string `json:"mime_type,omitempty"`
+	// Size is the item size in bytes.
+	Size int64 `json:"size"`
+	// Alt is alternative text describing the item.
+	Alt string `json:"alt,omitempty"`
+	// SourceURL records where the item was fetched from, if anywhere.
+	SourceURL string `json:"source_url,omitempty"`
+	// Checksum is the content digest in "sha256:<hex>" form.
+	Checksum string `json:"checksum,omitempty"`
+}
+
+// NewItemMeta derives the MIME type, size and checksum of an item from its
+// name and content.
+func NewItemMeta(name string, data []byte) *ItemMeta {
+	sum := sha256.Sum256(data)
+	return &ItemMeta{
+		MimeType: DetectMimeType(name, data),
+		Size:     int64(len(data)),
+		Checksum: "sha256:" + hex.EncodeToString(sum[:]),
+	}
+}
+
+// DetectMimeType returns the media type for an item, preferring the file
+// extension and falling back to content sniffing.
+func DetectMimeType(name string, data []byte) string {
+	if byExt := mime.TypeByExtension(strings.ToLower(filepath.Ext(name))); byExt != "" {
+		mediaType, _, err := mime.ParseMediaType(byExt)
+		if err == nil {
+			return mediaType
+		}
+	}
+	mediaType, _, _ := mime.ParseMediaType(http.DetectContentType(data))
+	return mediaType
+}
+
+// Merge copies descriptive fields that other has and m lacks. Derived fields
+// (MimeType, Size, Checksum) are left alone.
+func (m *ItemMeta) Merge(other *ItemMeta) {
+	if m == nil || other == nil {
+		return
+	}
+	if m.Alt == "" {
+		m.Alt = other.Alt
+	}
+	if m.SourceURL == "" {
+		m.SourceURL = other.SourceURL
+	}
+}
+
+func parseItemMeta(data []byte) (*ItemMeta, error) {
+	var meta ItemMeta
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return nil, fmt.Errorf("invalid item metadata: %w", ErrParse)
+	}
+	return &meta, nil
+}
+
+func (m *ItemMeta) toJSON() ([]byte, error) {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
@@ -6,13 +6,14 @@ import (
 	"errors"
 	"fmt"
 	"path/filepath"
-	"regexp"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/jlrickert/cli-toolkit/toolkit"
 	kegurl "github.com/jlrickert/tapper/pkg/keg_url"
+	"github.com/jlrickert/tapper/pkg/registry"
 	"gopkg.in/yaml.v3"
 )
 
@@ -28,6 +29,30 @@ type Keg struct {
 	Repo Repository
 	// Runtime provides clock/hash/fs helpers used by high-level keg operations.
 	Runtime *toolkit.Runtime
+	// IDs allocates ids for new nodes; nil means SequentialIDs.
+	IDs IDAllocator
+	// Hooks run on lifecycle events before the hooks in the keg config.
+	Hooks []Hook
+	// Events, when set, receives the keg's changes once they are written.
+	Events *EventBus
+	// Identities decrypts the content of encrypted nodes; nil leaves it
+	// locked. See EncryptionConfig.
+	Identities IdentityFunc
+	// Summarizer writes node leads when content changes; nil uses the
+	// summarizer in the keg config. See SummarizerConfig.
+	Summarizer Summarizer
+	// TrustedConfig is the Config.TrustDigest the user approved. Hooks and
+	// external commands or APIs in the keg config are ignored unless it
+	// matches. See WithTrustedConfig.
+	TrustedConfig string
+
+	// untrustedWarned is set once the keg has logged that it ignores its
+	// untrusted config settings.
+	untrustedWarned atomic.Bool
+
+	// dryRun marks the in-memory copy made by NewDryRun. Hook commands from
+	// the keg config do not run on it.
+	dryRun bool
 
 	// dexMu guards lazy initialization of dex.
 	dexMu sync.Mutex
@@ -38,17 +63,26 @@ type Keg struct {
 // Option is a functional option for configuring Keg behavior
 type Option func(*Keg)
 
+// WithIDAllocator makes the keg allocate node ids with a instead of
+// SequentialIDs.
+func WithIDAllocator(a IDAllocator) Option {
+	return func(k *Keg) {
+		k.IDs = a
+	}
+}
+
 // NewKegFromTarget constructs a Keg from a kegurl.Target. It automatically
 // selects the appropriate repository implementation based on the target's scheme:
 // - memory:// targets use an in-memory repository
 // - file:// targets use a filesystem repository
+// A read-only target gets a ReadOnlyRepo middleware, and opts are applied
+// afterwards, so middlewares they add see writes the target rejects.
 // Returns an error if the target scheme is not supported.
-func NewKegFromTarget(ctx context.Context, target kegurl.Target, rt *toolkit.Runtime) (*Keg, error) {
+func NewKegFromTarget(ctx context.Context, target kegurl.Target, rt *toolkit.Runtime, opts ...Option) (*Keg, error) {
+	var k *Keg
 	switch target.Scheme() {
 	case kegurl.SchemeMemory:
-		repo := NewMemoryRepo(rt)
-		keg := Keg{Repo: repo, Runtime: rt}
-		return &keg, nil
+		k = &Keg{Repo: NewMemoryRepo(rt), Runtime: rt}
 	case kegurl.SchemeFile:
 		repo := FsRepo{
 			Root:            filepath.Clean(target.Path()),
@@ -57,10 +91,27 @@ func NewKegFromTarget(ctx context.Context, target kegurl.Target, rt *toolkit.Run
 			StatsFilename:   JSONStatsFilename,
 			runtime:         rt,
 		}
-		keg := Keg{Target: &target, Repo: &repo, Runtime: rt}
-		return &keg, nil
+		k = &Keg{Target: &target, Repo: &repo, Runtime: rt}
+	case kegurl.SchemeRegistry:
+		if target.Url == "" {
+			return nil, fmt.Errorf("registry %s has no url configured: %w", target.Repo, ErrInvalid)
+		}
+		token := target.Token
+		if token == "" && target.TokenEnv != "" {
+			token = rt.Get(target.TokenEnv)
+		}
+		client := registry.NewClient(target.Url, token)
+		k = &Keg{Target: &target, Repo: NewRegistryRepo(client, target.User, target.Keg, rt), Runtime: rt}
+	default:
+		return nil, fmt.Errorf("unsupported target scheme: %s", target.Scheme())
 	}
-	return nil, fmt.Errorf("unsupported target scheme: %s", target.Scheme())
+	if target.Readonly {
+		k.Repo = ReadOnlyRepo()(k.Repo)
+	}
+	for _, o := range opts {
+		o(k)
+	}
+	return k, nil
 }
 
 // NewKeg returns a Keg service backed by the provided repository.
@@ -156,14 +207,8 @@ func (k *Keg) Init(ctx context.Context) error {
 	id := NodeId{ID: 0}
 
 	if err := k.withNodeLock(ctx, id, func(lockCtx context.Context) error {
-		if err := k.Repo.WriteContent(lockCtx, id, []byte(rawContent)); err != nil {
-			return fmt.Errorf("InitKeg: write content to backend %s: %w", k.Repo.Name(), err)
-		}
-		if err := k.Repo.WriteMeta(lockCtx, id, []byte(m.ToYAML())); err != nil {
-			return fmt.Errorf("InitKeg: write meta to backend %s: %w", k.Repo.Name(), err)
-		}
-		if err := k.Repo.WriteStats(lockCtx, id, stats); err != nil {
-			return fmt.Errorf("InitKeg: write stats to backend %s: %w", k.Repo.Name(), err)
+		if err := k.Repo.WriteNode(lockCtx, id, []byte(rawContent), []byte(m.ToYAML()), stats); err != nil {
+			return fmt.Errorf("InitKeg: write node to backend %s: %w", k.Repo.Name(), err)
 		}
 		return nil
 	}); err != nil {
@@ -178,9 +223,13 @@ func (k *Keg) Init(ctx context.Context) error {
 	return nil
 }
 
-// Next reserves and returns the next available node ID from the repository.
+// Next reserves and returns the next node ID using the keg's IDAllocator.
 func (k *Keg) Next(ctx context.Context) (NodeId, error) {
-	return k.Repo.Next(ctx)
+	ids := k.IDs
+	if ids == nil {
+		ids = SequentialIDs
+	}
+	return ids.Allocate(ctx, k.Repo, k.Runtime.Clock().Now())
 }
 
 // CreateOptions specifies parameters for creating a new node
@@ -195,31 +244,112 @@ type CreateOptions struct {
 	Body []byte
 	// Attrs are arbitrary key-value attributes attached to the node
 	Attrs map[string]any
+	// Draft creates a temporary "N-CODE" node that stays out of the dex until
+	// it is promoted with Commit
+	Draft bool
+	// KeepFrontmatter writes Body with its YAML frontmatter instead of
+	// stripping it, as the keg config's keepFrontmatter does for every node
+	KeepFrontmatter bool
+	// Vars, when set, expands {{.Date}}, {{.Title}}, {{.KegAlias}},
+	// {{.User}} and snippets in Body. The default template is always
+	// expanded, with these values when given
+	Vars *TemplateVars
 }
 
 // Create creates a new node: allocates an ID, parses content, generates metadata,
 // and indexes the node in the dex. The node is immediately persisted to the repository.
 // If Body is empty, default markdown content is generated from Title and Lead.
-func (k *Keg) Create(ctx context.Context, opts *CreateOptions) (NodeId, error) {
+// The keg config's defaults section fills in the template, tags and attrs.
+func (k *Keg) Create(ctx context.Context, opts *CreateOptions) (_ NodeId, err error) {
+	ctx, done := k.logOp(ctx, "create")
+	defer done(&err)
 	if err := k.checkKegExists(ctx); err != nil {
 		return NodeId{}, fmt.Errorf("failed to create node: %w", err)
 	}
+	opts, err = k.applyCreateDefaults(ctx, k.createDefaults(ctx), opts)
+	if err != nil {
+		return NodeId{}, fmt.Errorf("failed to create node: %w", err)
+	}
+	now := k.Runtime.Clock().Now()
+	return k.createNode(ctx, opts, &now)
+}
+
+// CreateBatch creates one node per entry in batch-index mode: each node is
+// persisted as it is created, but the dex is written once after the last
+// node instead of after every node. The returned IDs line up with opts. When
+// an entry fails, the IDs created so far are returned and still indexed.
+func (k *Keg) CreateBatch(ctx context.Context, opts []*CreateOptions) (_ []NodeId, err error) {
+	ctx, done := k.logOp(ctx, "create batch", "count", len(opts))
+	defer done(&err)
+	if err := k.checkKegExists(ctx); err != nil {
+		return nil, fmt.Errorf("failed to create nodes: %w", err)
+	}
+
+	defaults := k.createDefaults(ctx)
+	ids := make([]NodeId, 0, len(opts))
+	var errs []error
+	for i, o := range opts {
+		o, err := k.applyCreateDefaults(ctx, defaults, o)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("entry %d: %w", i+1, err))
+			break
+		}
+		id, err := k.createNode(ctx, o, nil)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("entry %d: %w", i+1, err))
+			break
+		}
+		ids = append(ids, id)
+	}
+
+	if len(ids) > 0 {
+		if err := k.flushDex(ctx, k.Runtime.Clock().Now()); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return ids, errors.Join(errs...)
+}
+
+// createNode allocates, persists and indexes a single node. When now is nil
+// the dex is updated in memory only and the caller is responsible for
+// flushing it.
+func (k *Keg) createNode(ctx context.Context, opts *CreateOptions, now *time.Time) (NodeId, error) {
 
 	if opts == nil {
 		opts = &CreateOptions{}
 	}
+	hooks := k.hooks(ctx)
+	if err := k.runHooks(ctx, hooks, HookPreCreate, HookContext{Title: opts.Title, Tags: opts.Tags}); err != nil {
+		return NodeId{}, err
+	}
 
-	// Reserve next ID
-	id, err := k.Repo.Next(ctx)
-	if err != nil {
-		return NodeId{}, fmt.Errorf("failed to allocate node id: %w", err)
+	var id NodeId
+	if opts.Draft {
+		next, err := k.nextDraftBase(ctx)
+		if err != nil {
+			return NodeId{}, fmt.Errorf("failed to allocate draft id: %w", err)
+		}
+		id = NodeId{ID: next, Code: RandomCode(ctx)}
+	} else {
+		// Reserve next ID
+		next, err := k.Next(ctx)
+		if err != nil {
+			return NodeId{}, fmt.Errorf("failed to allocate node id: %w", err)
+		}
+		if err := k.checkMaxNodeID(ctx, next); err != nil {
+			_ = k.Repo.DeleteNode(ctx, next)
+			return NodeId{}, err
+		}
+		id = next
 	}
 
-	now := k.Runtime.Clock().Now()
+	created := k.Runtime.Clock().Now()
 
 	var rawContent []byte
+	format := MarkdownContentFilename
 	if len(opts.Body) > 0 {
 		rawContent = opts.Body
+		format = k.ContentFormat(ctx)
 	} else {
 		// Build default content/meta for a new node
 		b := strings.Builder{}
@@ -235,40 +365,68 @@ func (k *Keg) Create(ctx context.Context, opts *CreateOptions) (NodeId, error) {
 		rawContent = []byte(b.String())
 	}
 
-	content, err := ParseContent(k.Runtime, rawContent, MarkdownContentFilename)
+	content, err := ParseContent(k.Runtime, rawContent, format)
 	if err != nil {
 		return NodeId{}, fmt.Errorf("invalid content: %w", err)
 	}
-	m := NewMeta(ctx, now)
+	body := []byte(content.Body)
+	if opts.KeepFrontmatter || k.KeepFrontmatter(ctx) {
+		body = rawContent
+	}
+	m := NewMeta(ctx, created)
 	if len(opts.Attrs) > 0 {
 		m.SetAttrs(ctx, opts.Attrs)
 	}
 
-	stats := NewStats(now)
+	stats := NewStats(created)
 	if len(opts.Tags) > 0 {
 		m.SetTags(opts.Tags)
 	}
 	nodeData := &NodeData{ID: id, Content: content, Meta: m, Stats: stats}
-	_ = nodeData.UpdateMeta(ctx, &now)
-	nodeData.Stats.EnsureTimes(now)
+	_ = k.updateNodeMeta(ctx, nodeData, &created, true)
+	nodeData.Stats.EnsureTimes(created)
+	body, err = k.sealContent(ctx, m.Tags(), body)
+	if err != nil {
+		if !opts.Draft {
+			_ = k.Repo.DeleteNode(ctx, id)
+		}
+		return id, err
+	}
+	k.hashSealed(nodeData, body)
 
 	// Persist content and metadata atomically for this node.
 	if err := k.withNodeLock(ctx, id, func(lockCtx context.Context) error {
-		if err := k.Repo.WriteContent(lockCtx, id, []byte(content.Body)); err != nil {
-			return fmt.Errorf("create: write content to backend %s: %w", k.Repo.Name(), err)
-		}
-		if err := k.Repo.WriteMeta(lockCtx, id, []byte(m.ToYAML())); err != nil {
-			return fmt.Errorf("create: write meta to backend %s: %w", k.Repo.Name(), err)
-		}
-		if err := k.Repo.WriteStats(lockCtx, id, stats); err != nil {
-			return fmt.Errorf("create: write stats to backend %s: %w", k.Repo.Name(), err)
+		if err := k.Repo.WriteNode(lockCtx, id, body, []byte(m.ToYAML()), stats); err != nil {
+			return fmt.Errorf("create: write node to backend %s: %w", k.Repo.Name(), err)
 		}
 		return nil
 	}); err != nil {
+		if !opts.Draft {
+			// Release the id reserved by Next so the failed create leaves no
+			// empty node behind.
+			_ = k.Repo.DeleteNode(ctx, id)
+		}
 		return id, err
 	}
 
-	return id, k.addNodeToDex(ctx, nodeData, &now)
+	if err := k.addNodeToDex(ctx, nodeData, now); err != nil {
+		return id, err
+	}
+	k.publish(ctx, NodeCreated{EventSource: k.eventSource(), Node: id, Title: nodeData.Title(), Tags: m.Tags()})
+	return id, k.runHooks(ctx, hooks, HookPostCreate, HookContext{Node: id.Path(), Title: nodeData.Title(), Tags: m.Tags()})
+}
+
+// checkMaxNodeID rejects a newly allocated id above the keg's maxNodeId or,
+// when unset, above MaxNodeID.
+func (k *Keg) checkMaxNodeID(ctx context.Context, id NodeId) error {
+	limit := MaxNodeID
+	if cfg, err := k.Repo.ReadConfig(ctx); err == nil && cfg != nil && cfg.MaxNodeID > 0 {
+		limit = min(limit, cfg.MaxNodeID)
+	}
+	if id.ID > limit {
+		return fmt.Errorf("node id %d exceeds the maximum of %d: %w", id.ID, limit, ErrInvalid)
+	}
+	return nil
 }
 
 // Config returns the keg's configuration.
@@ -321,7 +479,8 @@ func (k *Keg) SetConfig(ctx context.Context, data []byte) error {
 	return nil
 }
 
-// GetContent retrieves the raw markdown content for a node.
+// GetContent retrieves the raw markdown content for a node. Encrypted
+// content is decrypted with the keg's Identities.
 func (k *Keg) GetContent(ctx context.Context, id NodeId) ([]byte, error) {
 	if err := k.checkKegExists(ctx); err != nil {
 		return nil, fmt.Errorf("failed to retrieve node content: %w", err)
@@ -331,37 +490,106 @@ func (k *Keg) GetContent(ctx context.Context, id NodeId) ([]byte, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to read content: %w", err)
 	}
-	return b, nil
+	return k.openContent(ctx, id, b)
 }
 
 // SetContent writes content for a node and updates its metadata by re-indexing.
 // This ensures the node's title, lead, and other metadata are kept in sync with content changes.
-func (k *Keg) SetContent(ctx context.Context, id NodeId, data []byte) error {
+// When the content hash changes, the previous content is kept as a version
+// (see ListVersions). With retitleLinks set in the keg config, bare node
+// links in data are given their target's title first (see RetitleLinks).
+// Nodes with the keg's encryption tag are stored encrypted.
+func (k *Keg) SetContent(ctx context.Context, id NodeId, data []byte) (err error) {
+	ctx, done := k.logOp(ctx, "set content", "node", id.Path(), "bytes", len(data))
+	defer done(&err)
 	if err := k.checkKegExists(ctx); err != nil {
 		return fmt.Errorf("failed to set node content: %w", err)
 	}
+	if err := k.checkPolicy(ctx, id, PolicyEdit); err != nil {
+		return err
+	}
+	if k.RetitleLinksOnSave(ctx) {
+		title, err := k.dexTitles(ctx)
+		if err != nil {
+			return err
+		}
+		data, _ = RetitleLinks(data, title)
+	}
+	hooks := k.hooks(ctx)
+	if hooks.has(HookPreEdit) {
+		if err := k.runHooks(ctx, hooks, HookPreEdit, k.nodeHookContext(ctx, id)); err != nil {
+			return err
+		}
+	}
 
 	var nodeData *NodeData
-	err := k.withNodeLock(ctx, id, func(lockCtx context.Context) error {
-		if err := k.Repo.WriteContent(lockCtx, id, data); err != nil {
-			return fmt.Errorf("unable to write content: %w", err)
+	err = k.withNodeLock(ctx, id, func(lockCtx context.Context) error {
+		prev, err := k.Repo.ReadContent(lockCtx, id)
+		if err != nil && !errors.Is(err, ErrNotExist) {
+			return fmt.Errorf("unable to read content: %w", err)
 		}
-		updated, changed, err := k.indexNodeLocked(lockCtx, id)
+		if IsEncryptedContent(prev) {
+			// Ciphertext differs on every write, so compare plaintext to
+			// avoid re-encrypting and versioning unchanged content.
+			if plain, err := k.openContent(lockCtx, id, prev); err == nil && bytes.Equal(plain, data) {
+				return nil
+			}
+		}
+		if err := k.recordVersionLocked(lockCtx, id, prev, data); err != nil {
+			return err
+		}
+		updated, err := k.contentUpdateLocked(lockCtx, id, data)
 		if err != nil {
 			return err
 		}
-		if changed {
+		var tags []string
+		if updated != nil {
+			tags = updated.Meta.Tags()
+		} else if meta, err := k.getMeta(lockCtx, id); err == nil {
+			tags = meta.Tags()
+		}
+		sealed, err := k.sealContent(lockCtx, tags, data)
+		if err != nil {
+			return err
+		}
+		if updated == nil && IsEncryptedContent(sealed) {
+			// The stats hash names the stored ciphertext, which changes
+			// with every write.
+			meta, stats, err := k.getMetaAndStats(lockCtx, id)
+			if err != nil {
+				return fmt.Errorf("failed to update node %s: %w", id, err)
+			}
+			updated = &NodeData{ID: id, Meta: meta, Stats: stats}
+		}
+		k.hashSealed(updated, sealed)
+		if updated == nil {
+			if err := k.Repo.WriteContent(lockCtx, id, sealed); err != nil {
+				return fmt.Errorf("unable to write content: %w", err)
+			}
+		} else {
+			if err := k.Repo.WriteNode(lockCtx, id, sealed, []byte(updated.Meta.ToYAML()), updated.Stats); err != nil {
+				return fmt.Errorf("unable to write content: %w", err)
+			}
 			nodeData = updated
 		}
+		if len(prev) > 0 && !IsEncryptedContent(prev) && IsEncryptedContent(sealed) {
+			return k.sealHistoryLocked(lockCtx, id, tags)
+		}
 		return nil
 	})
 	if err != nil {
 		return err
 	}
-	if nodeData == nil {
+	if nodeData != nil {
+		if err := k.writeNodeToDex(ctx, id, nodeData); err != nil {
+			return err
+		}
+		k.publish(ctx, ContentUpdated{EventSource: k.eventSource(), Node: id, Title: nodeData.Title(), Hash: nodeData.ContentHash()})
+	}
+	if !hooks.has(HookPostEdit) {
 		return nil
 	}
-	return k.writeNodeToDex(ctx, id, nodeData)
+	return k.runHooks(ctx, hooks, HookPostEdit, k.nodeHookContext(ctx, id))
 }
 
 // GetMeta retrieves the parsed metadata for a node.
@@ -381,13 +609,18 @@ func (k *Keg) GetStats(ctx context.Context, id NodeId) (*NodeStats, error) {
 }
 
 // SetMeta writes metadata for a node and updates the dex.
-func (k *Keg) SetMeta(ctx context.Context, id NodeId, meta *NodeMeta) error {
+func (k *Keg) SetMeta(ctx context.Context, id NodeId, meta *NodeMeta) (err error) {
+	ctx, done := k.logOp(ctx, "set meta", "node", id.Path())
+	defer done(&err)
 	if err := k.checkKegExists(ctx); err != nil {
 		return fmt.Errorf("failed to update node meta: %w", err)
 	}
+	if err := k.checkPolicy(ctx, id, PolicyEdit); err != nil {
+		return err
+	}
 
 	var nodeData *NodeData
-	err := k.withNodeLock(ctx, id, func(lockCtx context.Context) error {
+	err = k.withNodeLock(ctx, id, func(lockCtx context.Context) error {
 		stats, err := k.getStats(lockCtx, id)
 		if err != nil && !errors.Is(err, ErrNotExist) {
 			return fmt.Errorf("failed to read node stats: %w", err)
@@ -395,12 +628,15 @@ func (k *Keg) SetMeta(ctx context.Context, id NodeId, meta *NodeMeta) error {
 		if stats == nil {
 			stats = &NodeStats{}
 		}
+		if k.sealsTags(lockCtx, meta.Tags()) {
+			stats.SetLead("")
+		}
 
-		if err := k.Repo.WriteMeta(lockCtx, id, []byte(meta.ToYAML())); err != nil {
+		if err := k.Repo.WriteNode(lockCtx, id, nil, []byte(meta.ToYAML()), stats); err != nil {
 			return fmt.Errorf("UpdateMeta: write meta to backend %s: %w", k.Repo.Name(), err)
 		}
-		if err := k.Repo.WriteStats(lockCtx, id, stats); err != nil {
-			return fmt.Errorf("UpdateMeta: write stats to backend %s: %w", k.Repo.Name(), err)
+		if err := k.sealStoredContentLocked(lockCtx, id, meta.Tags()); err != nil {
+			return err
 		}
 
 		nodeData = &NodeData{ID: id, Meta: meta, Stats: stats}
@@ -411,7 +647,11 @@ func (k *Keg) SetMeta(ctx context.Context, id NodeId, meta *NodeMeta) error {
 	}
 
 	now := k.Runtime.Clock().Now()
-	return k.addNodeToDex(ctx, nodeData, &now)
+	if err := k.addNodeToDex(ctx, nodeData, &now); err != nil {
+		return err
+	}
+	k.publish(ctx, MetaUpdated{EventSource: k.eventSource(), Node: id, Tags: meta.Tags()})
+	return nil
 }
 
 // UpdateMeta reads the node's metadata, applies the provided mutation function,
@@ -420,10 +660,14 @@ func (k *Keg) UpdateMeta(ctx context.Context, id NodeId, f func(*NodeMeta)) erro
 	if err := k.checkKegExists(ctx); err != nil {
 		return fmt.Errorf("failed to update node meta: %w", err)
 	}
+	if err := k.checkPolicy(ctx, id, PolicyEdit); err != nil {
+		return err
+	}
 
 	now := k.Runtime.Clock().Now()
 
-	return k.withNodeLock(ctx, id, func(lockCtx context.Context) error {
+	var tags []string
+	err := k.withNodeLock(ctx, id, func(lockCtx context.Context) error {
 		m, stats, err := k.getMetaAndStats(lockCtx, id)
 		if errors.Is(err, ErrNotExist) {
 			m = NewMeta(lockCtx, now)
@@ -436,15 +680,21 @@ func (k *Keg) UpdateMeta(ctx context.Context, id NodeId, f func(*NodeMeta)) erro
 		}
 
 		f(m)
+		if k.sealsTags(lockCtx, m.Tags()) {
+			stats.SetLead("")
+		}
 
-		if err := k.Repo.WriteMeta(lockCtx, id, []byte(m.ToYAML())); err != nil {
+		if err := k.Repo.WriteNode(lockCtx, id, nil, []byte(m.ToYAML()), stats); err != nil {
 			return fmt.Errorf("UpdateMeta: write meta to backend %s: %w", k.Repo.Name(), err)
 		}
-		if err := k.Repo.WriteStats(lockCtx, id, stats); err != nil {
-			return fmt.Errorf("UpdateMeta: write stats to backend %s: %w", k.Repo.Name(), err)
-		}
-		return nil
+		tags = m.Tags()
+		return k.sealStoredContentLocked(lockCtx, id, tags)
 	})
+	if err != nil {
+		return err
+	}
+	k.publish(ctx, MetaUpdated{EventSource: k.eventSource(), Node: id, Tags: tags})
+	return nil
 }
 
 // Touch updates the access time of a node to the current time.
@@ -470,10 +720,7 @@ func (k *Keg) Touch(ctx context.Context, id NodeId) error {
 		stats.SetAccessed(now)
 		stats.IncrementAccessCount()
 		stats.EnsureTimes(now)
-		if err := k.Repo.WriteMeta(lockCtx, id, []byte(meta.ToYAML())); err != nil {
-			return err
-		}
-		return k.Repo.WriteStats(lockCtx, id, stats)
+		return k.Repo.WriteNode(lockCtx, id, nil, []byte(meta.ToYAML()), stats)
 	})
 }
 
@@ -526,17 +773,38 @@ func (k *Keg) IndexNode(ctx context.Context, id NodeId) error {
 type IndexOptions struct {
 	Rebuild  bool
 	NoUpdate bool
+
+	// Analyze runs graph analysis after indexing and writes the results, such
+	// as cluster assignments in dex/clusters.tsv.
+	Analyze bool
 }
 
 // Index updates the keg indices.
 // With Rebuild=true, all index artifacts are rebuilt from scratch.
 // With Rebuild=false, only nodes updated since config.updated (plus missing
 // metadata/stats files) are indexed.
-func (k *Keg) Index(ctx context.Context, opts IndexOptions) error {
+// Index holds the keg-wide lock so concurrent runs cannot interleave dex
+// writes.
+func (k *Keg) Index(ctx context.Context, opts IndexOptions) (err error) {
+	ctx, done := k.logOp(ctx, "index")
+	defer done(&err)
 	if err := k.checkKegExists(ctx); err != nil {
 		return fmt.Errorf("failed to re index keg: %w", err)
 	}
+	hooks := k.hooks(ctx)
+	if err := k.runHooks(ctx, hooks, HookPreIndex, HookContext{}); err != nil {
+		return err
+	}
+	if err := k.WithKegLock(ctx, func(lockCtx context.Context) error {
+		return k.index(lockCtx, opts)
+	}); err != nil {
+		return err
+	}
+	k.publish(ctx, IndexRebuilt{EventSource: k.eventSource(), Rebuild: opts.Rebuild})
+	return k.runHooks(ctx, hooks, HookPostIndex, HookContext{})
+}
 
+func (k *Keg) index(ctx context.Context, opts IndexOptions) error {
 	indexedAt, err := k.readIndexWatermark(ctx)
 	if err != nil {
 		return err
@@ -597,64 +865,74 @@ func (k *Keg) Index(ctx context.Context, opts IndexOptions) error {
 	now := k.Runtime.Clock().Now()
 
 	for _, id := range ids {
-		metaMissing, statsMissing, probeErr := k.nodeFilesMissing(ctx, id)
-		if probeErr != nil {
-			errs = append(errs, probeErr)
-			continue
-		}
-
-		data, nodeErrs := k.getNodeBestEffort(ctx, id)
-		if len(nodeErrs) > 0 {
-			errs = append(errs, nodeErrs...)
-		}
-
-		if data.Meta == nil {
-			data.Meta = NewMeta(ctx, time.Time{})
-		}
-		if data.Stats == nil {
-			data.Stats = &NodeStats{}
+		// Stop without saving the dex so a canceled run leaves the previous
+		// index in place.
+		if err := ctx.Err(); err != nil {
+			return errors.Join(append(errs, fmt.Errorf("index interrupted: %w", err))...)
 		}
+		// Read, refresh and persist under the node lock so a concurrent
+		// edit cannot land between the read and the write and be lost.
+		var data *NodeData
+		var needsRefresh, needsPersist, updatedSinceLastIndex bool
+		if err := k.withNodeLock(ctx, id, func(lockCtx context.Context) error {
+			metaMissing, statsMissing, probeErr := k.nodeFilesMissing(lockCtx, id)
+			if probeErr != nil {
+				return probeErr
+			}
 
-		changed := data.ContentChanged()
-		statsUpdated := data.Stats.Updated()
-		updatedSinceLastIndex := indexedAt.IsZero() ||
-			statsUpdated.IsZero() ||
-			statsUpdated.After(indexedAt)
-		hasRequiredStats := data.Stats.Title() != "" &&
-			data.Stats.Hash() != "" &&
-			!data.Stats.Created().IsZero() &&
-			!data.Stats.Updated().IsZero()
+			var nodeErrs []error
+			data, nodeErrs = k.getNodeBestEffort(lockCtx, id)
+			if len(nodeErrs) > 0 {
+				errs = append(errs, nodeErrs...)
+			}
 
-		needsRefresh := opts.Rebuild ||
-			metaMissing ||
-			statsMissing ||
-			(!opts.NoUpdate && (changed || updatedSinceLastIndex || !hasRequiredStats))
+			if data.Meta == nil {
+				data.Meta = NewMeta(lockCtx, time.Time{})
+			}
+			if data.Stats == nil {
+				data.Stats = &NodeStats{}
+			}
 
-		if needsRefresh {
-			err := data.UpdateMeta(ctx, &now)
-			if err != nil {
-				errs = append(errs, err)
-				continue
+			changed := data.ContentChanged()
+			statsUpdated := data.Stats.Updated()
+			updatedSinceLastIndex = indexedAt.IsZero() ||
+				statsUpdated.IsZero() ||
+				statsUpdated.After(indexedAt)
+			hasRequiredStats := data.Stats.Title() != "" &&
+				data.Stats.Hash() != "" &&
+				!data.Stats.Created().IsZero() &&
+				!data.Stats.Updated().IsZero()
+
+			needsRefresh = opts.Rebuild ||
+				metaMissing ||
+				statsMissing ||
+				(!opts.NoUpdate && (changed || updatedSinceLastIndex || !hasRequiredStats))
+
+			if needsRefresh {
+				if err := k.updateNodeMeta(lockCtx, data, &now, opts.Rebuild); err != nil {
+					return err
+				}
 			}
-		}
 
-		data.Stats.EnsureTimes(now)
+			data.Stats.EnsureTimes(now)
 
-		needsPersist := opts.Rebuild || metaMissing || statsMissing || needsRefresh
-		if needsPersist {
-			err := k.withNodeLock(ctx, id, func(lockCtx context.Context) error {
-				if err := k.Repo.WriteMeta(lockCtx, id, []byte(data.Meta.ToYAML())); err != nil {
+			redacted := k.redactLead(lockCtx, data)
+			needsPersist = opts.Rebuild || metaMissing || statsMissing || needsRefresh || redacted
+			if needsPersist {
+				if err := k.Repo.WriteNode(lockCtx, id, nil, []byte(data.Meta.ToYAML()), data.Stats); err != nil {
 					return fmt.Errorf("failed to write node meta %s: %w", id.Path(), err)
 				}
-				if err := k.Repo.WriteStats(lockCtx, id, data.Stats); err != nil {
-					return fmt.Errorf("failed to write node stats %s: %w", id.Path(), err)
-				}
-				return nil
-			})
-			if err != nil {
-				errs = append(errs, err)
-				continue
 			}
+			return nil
+		}); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		// Drafts (nodes with a Code) stay out of published indexes until
+		// they are committed to a numeric ID.
+		if id.Code != "" {
+			continue
 		}
 
 		// Always add to the dex when custom (tag-filtered) indexes are
@@ -673,6 +951,12 @@ func (k *Keg) Index(ctx context.Context, opts IndexOptions) error {
 	if err := k.dex.Write(ctx, k.Repo); err != nil {
 		errs = append(errs, fmt.Errorf("failed to save dex: %w", err))
 	}
+	if opts.Analyze {
+		clusters := k.dex.DetectClusters(ctx)
+		if err := k.Repo.WriteIndex(ctx, ClustersIndexName, ClustersData(clusters)); err != nil {
+			errs = append(errs, fmt.Errorf("unable to write `%s` index: %w", ClustersIndexName, err))
+		}
+	}
 	if err := k.touchConfigUpdated(ctx, now); err != nil {
 		errs = append(errs, fmt.Errorf("failed to update index timestamp: %w", err))
 	}
@@ -681,68 +965,56 @@ func (k *Keg) Index(ctx context.Context, opts IndexOptions) error {
 }
 
 // Move renames a node from src to dst and rewrites in-content links that
-// target src (../N) across the keg.
-func (k *Keg) Move(ctx context.Context, src NodeId, dst NodeId) error {
+// target src (../N) across the keg. The returned report lists the files whose
+// links were rewritten, including when an error is returned after the move.
+func (k *Keg) Move(ctx context.Context, src NodeId, dst NodeId) (_ *LinkRepairReport, err error) {
+	ctx, done := k.logOp(ctx, "move", "src", src.Path(), "dst", dst.Path())
+	defer done(&err)
 	if err := k.checkKegExists(ctx); err != nil {
-		return fmt.Errorf("failed to move node: %w", err)
+		return nil, fmt.Errorf("failed to move node: %w", err)
 	}
 
 	src = NodeId{ID: src.ID, Code: src.Code}
 	dst = NodeId{ID: dst.ID, Code: dst.Code}
 	if !src.Valid() || !dst.Valid() {
-		return fmt.Errorf("invalid node id: %w", ErrInvalid)
+		return nil, fmt.Errorf("invalid node id: %w", ErrInvalid)
 	}
 	if src.ID == 0 || dst.ID == 0 {
-		return fmt.Errorf("node 0 cannot be moved: %w", ErrInvalid)
+		return nil, fmt.Errorf("node 0 cannot be moved: %w", ErrInvalid)
 	}
 	if src.Equals(dst) {
-		return nil
+		return &LinkRepairReport{}, nil
 	}
 
 	srcExists, err := k.Repo.HasNode(ctx, src)
 	if err != nil {
-		return fmt.Errorf("failed to check source node: %w", err)
+		return nil, fmt.Errorf("failed to check source node: %w", err)
 	}
 	if !srcExists {
-		return fmt.Errorf("source node %s not found: %w", src.Path(), ErrNotExist)
+		return nil, fmt.Errorf("source node %s not found: %w", src.Path(), ErrNotExist)
+	}
+	if err := k.checkPolicy(ctx, src, PolicyMove); err != nil {
+		return nil, err
 	}
 
 	dstExists, err := k.Repo.HasNode(ctx, dst)
 	if err != nil {
-		return fmt.Errorf("failed to check destination node: %w", err)
+		return nil, fmt.Errorf("failed to check destination node: %w", err)
 	}
 	if dstExists {
-		return fmt.Errorf("destination node %s already exists: %w", dst.Path(), ErrDestinationExists)
+		return nil, fmt.Errorf("destination node %s already exists: %w", dst.Path(), ErrDestinationExists)
 	}
 
 	if err := k.Repo.MoveNode(ctx, src, dst); err != nil {
-		return fmt.Errorf("failed to move node %s to %s: %w", src.Path(), dst.Path(), err)
-	}
-
-	ids, err := k.Repo.ListNodes(ctx)
-	if err != nil {
-		return fmt.Errorf("failed to list nodes for link rewrite: %w", err)
+		return nil, fmt.Errorf("failed to move node %s to %s: %w", src.Path(), dst.Path(), err)
 	}
 
 	var errs []error
-	for _, id := range ids {
-		raw, readErr := k.Repo.ReadContent(ctx, id)
-		if readErr != nil {
-			if errors.Is(readErr, ErrNotExist) {
-				continue
-			}
-			errs = append(errs, fmt.Errorf("failed to read node content %s: %w", id.Path(), readErr))
-			continue
-		}
-
-		updated, changed := rewriteNodeLinks(raw, src, dst)
-		if !changed {
-			continue
-		}
-		if err := k.SetContent(ctx, id, updated); err != nil {
-			errs = append(errs, fmt.Errorf("failed to rewrite links for node %s: %w", id.Path(), err))
-		}
+	report, err := k.RepairLinks(ctx, LinkRepair{Renamed: map[NodeId]NodeId{src: dst}})
+	if err != nil {
+		errs = append(errs, err)
 	}
+	k.logRepair(report)
 
 	dex, err := k.Dex(ctx)
 	if err != nil {
@@ -751,11 +1023,13 @@ func (k *Keg) Move(ctx context.Context, src NodeId, dst NodeId) error {
 		if err := dex.Remove(ctx, src); err != nil {
 			errs = append(errs, fmt.Errorf("failed to remove stale dex entry for %s: %w", src.Path(), err))
 		}
-		movedData, err := k.getNode(ctx, dst)
-		if err != nil {
-			errs = append(errs, fmt.Errorf("failed to load moved node %s: %w", dst.Path(), err))
-		} else if err := dex.Add(ctx, movedData); err != nil {
-			errs = append(errs, fmt.Errorf("failed to add moved node %s to dex: %w", dst.Path(), err))
+		if dst.Code == "" {
+			movedData, err := k.getNode(ctx, dst)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("failed to load moved node %s: %w", dst.Path(), err))
+			} else if err := dex.Add(ctx, movedData); err != nil {
+				errs = append(errs, fmt.Errorf("failed to add moved node %s to dex: %w", dst.Path(), err))
+			}
 		}
 		if err := dex.Write(ctx, k.Repo); err != nil {
 			errs = append(errs, fmt.Errorf("failed to write dex after move: %w", err))
@@ -766,55 +1040,65 @@ func (k *Keg) Move(ctx context.Context, src NodeId, dst NodeId) error {
 	if err := k.touchConfigUpdated(ctx, now); err != nil {
 		errs = append(errs, fmt.Errorf("failed to update config after move: %w", err))
 	}
-
-	return errors.Join(errs...)
+	if len(errs) > 0 {
+		return report, errors.Join(errs...)
+	}
+	k.publishRenamed(ctx, src, dst)
+	return report, nil
 }
 
 // Remove deletes a node from the repository and updates dex/config artifacts.
-func (k *Keg) Remove(ctx context.Context, id NodeId) error {
+// The returned report lists the files whose links to the node were pointed at
+// node 0, including when an error is returned after the delete.
+func (k *Keg) Remove(ctx context.Context, id NodeId) (_ *LinkRepairReport, err error) {
+	ctx, done := k.logOp(ctx, "remove", "node", id.Path())
+	defer done(&err)
 	if err := k.checkKegExists(ctx); err != nil {
-		return fmt.Errorf("failed to remove node: %w", err)
+		return nil, fmt.Errorf("failed to remove node: %w", err)
 	}
 
 	id = NodeId{ID: id.ID, Code: id.Code}
 	if !id.Valid() {
-		return fmt.Errorf("invalid node id: %w", ErrInvalid)
+		return nil, fmt.Errorf("invalid node id: %w", ErrInvalid)
 	}
 	if id.ID == 0 {
-		return fmt.Errorf("node 0 cannot be removed: %w", ErrInvalid)
+		return nil, fmt.Errorf("node 0 cannot be removed: %w", ErrInvalid)
 	}
 
 	exists, err := k.Repo.HasNode(ctx, id)
 	if err != nil {
-		return fmt.Errorf("failed to check node existence: %w", err)
+		return nil, fmt.Errorf("failed to check node existence: %w", err)
 	}
 	if !exists {
-		return fmt.Errorf("node %s not found: %w", id.Path(), ErrNotExist)
+		return nil, fmt.Errorf("node %s not found: %w", id.Path(), ErrNotExist)
+	}
+	if err := k.checkPolicy(ctx, id, PolicyRemove); err != nil {
+		return nil, err
+	}
+
+	// Describe the node before it is gone so post-delete hooks see it too.
+	hooks := k.hooks(ctx)
+	hc := HookContext{Node: id.Path()}
+	if hooks.has(HookPreDelete, HookPostDelete) {
+		hc = k.nodeHookContext(ctx, id)
+	}
+	if err := k.runHooks(ctx, hooks, HookPreDelete, hc); err != nil {
+		return nil, err
 	}
 
 	if err := k.Repo.DeleteNode(ctx, id); err != nil {
-		return fmt.Errorf("failed to delete node %s: %w", id.Path(), err)
+		return nil, fmt.Errorf("failed to delete node %s: %w", id.Path(), err)
 	}
 
 	// Rewrite all links that pointed to the removed node so they point to
 	// the zero node (../0) instead of dangling.
-	zeroID := NodeId{ID: 0}
-	nodeIDs, listErr := k.Repo.ListNodes(ctx)
-	if listErr != nil {
-		return fmt.Errorf("failed to list nodes for link rewrite after remove: %w", listErr)
-	}
-	for _, otherID := range nodeIDs {
-		raw, readErr := k.Repo.ReadContent(ctx, otherID)
-		if readErr != nil {
-			continue
-		}
-		updated, changed := rewriteNodeLinks(raw, id, zeroID)
-		if changed {
-			_ = k.Repo.WriteContent(ctx, otherID, updated)
-		}
+	var errs []error
+	report, err := k.RepairLinks(ctx, LinkRepair{Deleted: []NodeId{id}})
+	if err != nil {
+		errs = append(errs, err)
 	}
+	k.logRepair(report)
 
-	var errs []error
 	dex, err := k.Dex(ctx)
 	if err != nil {
 		errs = append(errs, fmt.Errorf("failed to retrieve dex after remove: %w", err))
@@ -831,30 +1115,98 @@ func (k *Keg) Remove(ctx context.Context, id NodeId) error {
 	if err := k.touchConfigUpdated(ctx, now); err != nil {
 		errs = append(errs, fmt.Errorf("failed to update config after remove: %w", err))
 	}
-
-	return errors.Join(errs...)
+	if len(errs) > 0 {
+		return report, errors.Join(errs...)
+	}
+	k.publish(ctx, NodeDeleted{EventSource: k.eventSource(), Node: id})
+	return report, k.runHooks(ctx, hooks, HookPostDelete, hc)
 }
 
 // Commit finalizes a temporary node by allocating a permanent ID and moving it
 // from its temporary location (with Code suffix) to the canonical numeric ID.
-// For nodes without a Code (already permanent), Commit is a no-op.
-func (k *Keg) Commit(ctx context.Context, id NodeId) error {
+// Links to the draft are rewritten and the committed node is added to the dex.
+// For nodes without a Code (already permanent), Commit returns id unchanged.
+func (k *Keg) Commit(ctx context.Context, id NodeId) (_ NodeId, err error) {
+	ctx, done := k.logOp(ctx, "commit", "node", id.Path())
+	defer done(&err)
 	if err := k.checkKegExists(ctx); err != nil {
-		return fmt.Errorf("failed to commit node: %w", err)
+		return NodeId{}, fmt.Errorf("failed to commit node: %w", err)
 	}
 
 	// only commit when Code is present (temporary id)
 	if id.Code == "" {
-		return nil
+		return id, nil
 	}
-	dst, err := k.Repo.Next(ctx)
+	exists, err := k.Repo.HasNode(ctx, id)
 	if err != nil {
-		return err
+		return NodeId{}, fmt.Errorf("failed to check node existence: %w", err)
+	}
+	if !exists {
+		return NodeId{}, fmt.Errorf("draft %s not found: %w", id.Path(), ErrNotExist)
+	}
+
+	dst, err := k.Next(ctx)
+	if err != nil {
+		return NodeId{}, fmt.Errorf("failed to allocate node id: %w", err)
+	}
+	// Next reserves dst with an empty placeholder; release it so the draft
+	// can be moved into place.
+	if err := k.Repo.DeleteNode(ctx, dst); err != nil && !errors.Is(err, ErrNotExist) {
+		return NodeId{}, fmt.Errorf("failed to release reserved node %s: %w", dst.Path(), err)
 	}
 	if err := k.Repo.MoveNode(ctx, id, dst); err != nil {
-		return err
+		return NodeId{}, fmt.Errorf("failed to commit draft %s to %s: %w", id.Path(), dst.Path(), err)
 	}
-	return nil
+
+	var errs []error
+	report, err := k.RepairLinks(ctx, LinkRepair{Renamed: map[NodeId]NodeId{id: dst}})
+	if err != nil {
+		errs = append(errs, err)
+	}
+	k.logRepair(report)
+
+	data, err := k.getNode(ctx, dst)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("failed to load committed node %s: %w", dst.Path(), err))
+	} else if err := k.writeNodeToDex(ctx, dst, data); err != nil {
+		errs = append(errs, err)
+	}
+	if len(errs) > 0 {
+		return dst, errors.Join(errs...)
+	}
+	k.publishRenamed(ctx, id, dst)
+	return dst, nil
+}
+
+// ListDrafts returns the IDs of uncommitted draft nodes.
+func (k *Keg) ListDrafts(ctx context.Context) ([]NodeId, error) {
+	ids, err := k.Repo.ListNodes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	drafts := []NodeId{}
+	for _, id := range ids {
+		if id.Code != "" {
+			drafts = append(drafts, id)
+		}
+	}
+	return drafts, nil
+}
+
+// nextDraftBase returns the numeric ID a draft is provisionally filed under:
+// one past the highest committed node.
+func (k *Keg) nextDraftBase(ctx context.Context) (int, error) {
+	ids, err := k.Repo.ListNodes(ctx)
+	if err != nil {
+		return 0, err
+	}
+	next := 0
+	for _, id := range ids {
+		if id.Code == "" && id.ID >= next {
+			next = id.ID + 1
+		}
+	}
+	return next, nil
 }
 
 // Dex returns the keg's index, loading it from the repository on first access.
@@ -866,15 +1218,46 @@ func (k *Keg) Dex(ctx context.Context) (*Dex, error) {
 	}
 
 	k.dexMu.Lock()
-	defer k.dexMu.Unlock()
-
 	if k.dex != nil {
-		return k.dex, nil
+		dex := k.dex
+		k.dexMu.Unlock()
+		recordDexCache(ctx, true)
+		return dex, nil
 	}
+	recordDexCache(ctx, false)
 	opts, _ := k.dexOptions(ctx)
-	dex, err := NewDexFromRepo(ctx, k.Repo, opts...)
+	loadCtx, span := tracer().Start(ctx, "dex.load")
+	dex, err := NewDexFromRepo(loadCtx, k.Repo, opts...)
+	endSpan(span, err)
+	if err != nil {
+		// Leave the cache empty so the next call retries the load rather
+		// than serving a partial dex after a transient backend error.
+		k.dexMu.Unlock()
+		return dex, err
+	}
 	k.dex = dex
-	return dex, err
+	k.dexMu.Unlock()
+	return k.healDex(ctx, dex), nil
+}
+
+// healDex checks a freshly loaded dex against the repository and rebuilds it
+// when it is corrupt, for example after a truncated index write, so callers
+// never silently work from a partial index. Failures are logged and the
+// loaded dex is returned unchanged.
+func (k *Keg) healDex(ctx context.Context, dex *Dex) *Dex {
+	report, err := dex.Verify(ctx, k.Repo)
+	if err != nil || !report.Corrupt() {
+		return dex
+	}
+	log := k.Runtime.Logger()
+	log.Warn("dex is corrupt, rebuilding", "report", report.String())
+	if err := k.Index(ctx, IndexOptions{Rebuild: true}); err != nil {
+		log.Warn("dex rebuild failed", "error", err)
+		return dex
+	}
+	k.dexMu.Lock()
+	defer k.dexMu.Unlock()
+	return k.dex
 }
 
 // dexOptions reads the keg config and returns DexOptions to apply when
@@ -902,6 +1285,7 @@ func (k *Keg) withNodeLock(ctx context.Context, id NodeId, fn func(context.Conte
 
 func (k *Keg) indexNodeLocked(ctx context.Context, id NodeId) (*NodeData, bool, error) {
 	n := k.Node(id)
+	n.Format = k.ContentFormat(ctx)
 	changed, err := n.Changed(ctx)
 	if err != nil {
 		return nil, false, err
@@ -909,13 +1293,55 @@ func (k *Keg) indexNodeLocked(ctx context.Context, id NodeId) (*NodeData, bool,
 	if !changed {
 		return nil, false, nil
 	}
+	n.Summarizer = k.nodeSummarizer(ctx, n.data)
 	if err := n.Update(ctx); err != nil {
 		return nil, false, fmt.Errorf("failed to update node %s: %w", id, err)
 	}
 	return n.data, true, nil
 }
 
+// contentUpdateLocked returns the node data id will have once its content is
+// replaced by raw, with meta and stats refreshed from the new content. It
+// returns nil when raw does not change the content hash, so only the content
+// needs writing. The caller must hold the node lock.
+func (k *Keg) contentUpdateLocked(ctx context.Context, id NodeId, raw []byte) (*NodeData, error) {
+	content, err := ParseContent(k.Runtime, raw, k.ContentFormat(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to update node %s: %w", id, err)
+	}
+	meta, stats, err := k.getMetaAndStats(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update node %s: %w", id, err)
+	}
+	items, err := repoListFiles(ctx, k.Repo, id)
+	if err != nil {
+		return nil, err
+	}
+	images, err := repoListImages(ctx, k.Repo, id)
+	if err != nil {
+		return nil, err
+	}
+	data := &NodeData{ID: id, Content: content, Meta: meta, Stats: stats, Items: items, Images: images}
+	if !data.ContentChanged() {
+		return nil, nil
+	}
+
+	now := k.Runtime.Clock().Now()
+	if err := k.updateNodeMeta(ctx, data, &now, true); err != nil {
+		return nil, fmt.Errorf("failed to update node %s: %w", id, err)
+	}
+	if data.Stats == nil {
+		data.Stats = NewStats(now)
+	}
+	data.Stats.EnsureTimes(now)
+	return data, nil
+}
+
 func (k *Keg) writeNodeToDex(ctx context.Context, id NodeId, data *NodeData) error {
+	// Drafts stay out of the dex until they are committed.
+	if id.Code != "" {
+		return k.touchConfigUpdated(ctx, k.Runtime.Clock().Now())
+	}
 	dex, err := k.Dex(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to retrieve dex: %w", err)
@@ -929,25 +1355,15 @@ func (k *Keg) writeNodeToDex(ctx context.Context, id NodeId, data *NodeData) err
 	return k.touchConfigUpdated(ctx, k.Runtime.Clock().Now())
 }
 
-func rewriteNodeLinks(raw []byte, src NodeId, dst NodeId) ([]byte, bool) {
-	oldID := src.Path()
-	newID := dst.Path()
-	if oldID == "" || newID == "" || oldID == newID || len(raw) == 0 {
-		return raw, false
+// logRepair reports each file touched by a link repair through the runtime
+// logger at debug level; callers that want the report shown return it.
+func (k *Keg) logRepair(report *LinkRepairReport) {
+	if report == nil {
+		return
 	}
-
-	// Match canonical relative node links "../N" with optional spaces after "../".
-	// Keep trailing delimiter so only whole node ids are rewritten.
-	delimiters := `[[:space:]\)\]\}\>\.,;:!?'\"#]`
-	pattern := `\.\./\s*` + regexp.QuoteMeta(oldID) + `(` + delimiters + `|$)`
-	re := regexp.MustCompile(pattern)
-
-	original := string(raw)
-	rewritten := re.ReplaceAllString(original, "../"+newID+`$1`)
-	if rewritten == original {
-		return raw, false
+	for _, f := range report.Files {
+		k.Runtime.Logger().Debug("rewrote node links", "node", f.Node.Path(), "file", f.File, "rewrites", f.Rewrites)
 	}
-	return []byte(rewritten), true
 }
 
 func (k *Keg) touchConfigUpdated(ctx context.Context, at time.Time) error {
@@ -979,7 +1395,7 @@ func fsRepoTouchConfigUpdated(repo *FsRepo, updated string) error {
 	if bytes.Equal(raw, patched) {
 		return nil
 	}
-	if err := repo.runtime.AtomicWriteFile(configPath, patched, 0o644); err != nil {
+	if err := repo.atomicWrite(configPath, patched, 0o644); err != nil {
 		return NewBackendError(repo.Name(), "WriteConfig", 0, err, false)
 	}
 	return nil
@@ -1029,6 +1445,15 @@ func patchConfigUpdatedField(raw []byte, updated string) ([]byte, error) {
 	return yaml.Marshal(&doc)
 }
 
+// IndexedAt returns when the keg was last indexed, taken from the updated
+// field of the keg config. It is zero when the keg has never been indexed.
+func (k *Keg) IndexedAt(ctx context.Context) (time.Time, error) {
+	if err := k.checkKegExists(ctx); err != nil {
+		return time.Time{}, err
+	}
+	return k.readIndexWatermark(ctx)
+}
+
 func (k *Keg) readIndexWatermark(ctx context.Context) (time.Time, error) {
 	cfg, err := k.Repo.ReadConfig(ctx)
 	if err != nil {
@@ -1058,13 +1483,22 @@ func (k *Keg) nodeFilesMissing(ctx context.Context, id NodeId) (bool, bool, erro
 	return metaMissing, statsMissing, nil
 }
 
-// getContent retrieves and parses raw markdown content for a node.
+// getContent retrieves and parses raw content for a node.
 func (k *Keg) getContent(ctx context.Context, id NodeId) (*NodeContent, error) {
 	raw, err := k.Repo.ReadContent(ctx, id)
 	if err != nil {
 		return nil, err
 	}
-	return ParseContent(k.Runtime, raw, FormatMarkdown)
+	plain, err := k.openContent(ctx, id, raw)
+	if err != nil {
+		return nil, err
+	}
+	content, err := ParseContent(k.Runtime, plain, k.ContentFormat(ctx))
+	if err == nil && IsEncryptedContent(raw) {
+		// Encrypted content is hashed as stored (see hashSealed).
+		content.Hash = k.Runtime.Hasher().Hash(raw)
+	}
+	return content, err
 }
 
 // getMeta retrieves and parses YAML metadata for a node.
@@ -1111,9 +1545,13 @@ func (k *Keg) getNodeBestEffort(ctx context.Context, n NodeId) (*NodeData, []err
 	data := &NodeData{ID: n}
 
 	content, err := k.getContent(ctx, n)
-	if err != nil {
+	switch {
+	case errors.Is(err, ErrEncrypted):
+		// Locked content keeps the title, lead and links recorded in its
+		// stats when it was written.
+	case err != nil:
 		errs = append(errs, fmt.Errorf("node %s content: %w", n.Path(), err))
-	} else {
+	default:
 		data.Content = content
 	}
 
@@ -1181,20 +1619,30 @@ func (k *Keg) addNodeToDex(ctx context.Context, data *NodeData, now *time.Time)
 		return err
 	}
 
-	dex.Add(ctx, data)
+	// Drafts stay out of the dex until they are committed.
+	if data.ID.Code == "" {
+		dex.Add(ctx, data)
+	}
 
 	if now != nil {
-		if err := dex.Write(ctx, k.Repo); err != nil {
-			return err
-		}
-
-		if err := k.touchConfigUpdated(ctx, *now); err != nil {
-			return err
-		}
+		return k.flushDex(ctx, *now)
 	}
 	return nil
 }
 
+// flushDex writes the in-memory dex to the repository and bumps the keg's
+// updated timestamp.
+func (k *Keg) flushDex(ctx context.Context, now time.Time) error {
+	dex, err := k.Dex(ctx)
+	if err != nil {
+		return err
+	}
+	if err := dex.Write(ctx, k.Repo); err != nil {
+		return err
+	}
+	return k.touchConfigUpdated(ctx, now)
+}
+
 // checkKegExists verifies that a keg is properly initialized in the repository.
 // Returns an error if the keg is not found or if the repository is not configured.
 func (k *Keg) checkKegExists(ctx context.Context) error {
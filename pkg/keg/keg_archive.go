@@ -0,0 +1,141 @@
+package keg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Archive moves a node into the repository's archived namespace and drops it
+// from the dex, so it no longer appears in nodes.tsv or changes.md. Links to
+// the node are left untouched; its content stays readable through
+// ReadArchivedContent until Unarchive restores it.
+func (k *Keg) Archive(ctx context.Context, id NodeId) error {
+	if err := k.checkKegExists(ctx); err != nil {
+		return fmt.Errorf("failed to archive node: %w", err)
+	}
+	archive, ok := repoArchive(k.Repo)
+	if !ok {
+		return ErrNotSupported
+	}
+
+	id = NodeId{ID: id.ID, Code: id.Code}
+	if !id.Valid() {
+		return fmt.Errorf("invalid node id: %w", ErrInvalid)
+	}
+	if id.ID == 0 {
+		return fmt.Errorf("node 0 cannot be archived: %w", ErrInvalid)
+	}
+
+	exists, err := k.Repo.HasNode(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to check node existence: %w", err)
+	}
+	if !exists {
+		return fmt.Errorf("node %s not found: %w", id.Path(), ErrNotExist)
+	}
+	if err := k.checkPolicy(ctx, id, PolicyArchive); err != nil {
+		return err
+	}
+
+	// The filesystem lock lives inside the node directory, so the move is not
+	// wrapped in withNodeLock; Move and Remove follow the same rule.
+	if err := archive.ArchiveNode(ctx, id); err != nil {
+		return fmt.Errorf("failed to archive node %s: %w", id.Path(), err)
+	}
+
+	var errs []error
+	dex, err := k.Dex(ctx)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("failed to retrieve dex after archive: %w", err))
+	} else {
+		if err := dex.Remove(ctx, id); err != nil {
+			errs = append(errs, fmt.Errorf("failed to remove %s from dex: %w", id.Path(), err))
+		}
+		if err := dex.Write(ctx, k.Repo); err != nil {
+			errs = append(errs, fmt.Errorf("failed to write dex after archive: %w", err))
+		}
+	}
+	if err := k.touchConfigUpdated(ctx, k.Runtime.Clock().Now()); err != nil {
+		errs = append(errs, fmt.Errorf("failed to update config after archive: %w", err))
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	k.publish(ctx, NodeDeleted{EventSource: k.eventSource(), Node: id})
+	return nil
+}
+
+// Unarchive restores an archived node to the active namespace and indexes it
+// again.
+func (k *Keg) Unarchive(ctx context.Context, id NodeId) error {
+	if err := k.checkKegExists(ctx); err != nil {
+		return fmt.Errorf("failed to unarchive node: %w", err)
+	}
+	archive, ok := repoArchive(k.Repo)
+	if !ok {
+		return ErrNotSupported
+	}
+
+	id = NodeId{ID: id.ID, Code: id.Code}
+	if err := archive.UnarchiveNode(ctx, id); err != nil {
+		if errors.Is(err, ErrNotExist) {
+			return fmt.Errorf("archived node %s not found: %w", id.Path(), err)
+		}
+		return fmt.Errorf("failed to unarchive node %s: %w", id.Path(), err)
+	}
+
+	data, err := k.getNode(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := k.writeNodeToDex(ctx, id, data); err != nil {
+		return err
+	}
+	k.publish(ctx, NodeCreated{EventSource: k.eventSource(), Node: id, Title: data.Title(), Tags: data.Tags()})
+	return nil
+}
+
+// ListArchived returns the IDs of archived nodes in ascending order.
+func (k *Keg) ListArchived(ctx context.Context) ([]NodeId, error) {
+	if err := k.checkKegExists(ctx); err != nil {
+		return nil, fmt.Errorf("failed to list archived nodes: %w", err)
+	}
+	archive, ok := repoArchive(k.Repo)
+	if !ok {
+		return nil, ErrNotSupported
+	}
+	return archive.ListArchived(ctx)
+}
+
+// ReadArchivedContent returns the content of an archived node.
+func (k *Keg) ReadArchivedContent(ctx context.Context, id NodeId) ([]byte, error) {
+	if err := k.checkKegExists(ctx); err != nil {
+		return nil, fmt.Errorf("failed to read archived node: %w", err)
+	}
+	archive, ok := repoArchive(k.Repo)
+	if !ok {
+		return nil, ErrNotSupported
+	}
+	data, err := archive.ReadArchivedContent(ctx, NodeId{ID: id.ID, Code: id.Code})
+	if errors.Is(err, ErrNotExist) {
+		return nil, fmt.Errorf("archived node %s not found: %w", id.Path(), err)
+	}
+	return data, err
+}
+
+// ReadArchivedMeta returns the raw metadata of an archived node.
+func (k *Keg) ReadArchivedMeta(ctx context.Context, id NodeId) ([]byte, error) {
+	if err := k.checkKegExists(ctx); err != nil {
+		return nil, fmt.Errorf("failed to read archived node: %w", err)
+	}
+	archive, ok := repoArchive(k.Repo)
+	if !ok {
+		return nil, ErrNotSupported
+	}
+	data, err := archive.ReadArchivedMeta(ctx, NodeId{ID: id.ID, Code: id.Code})
+	if errors.Is(err, ErrNotExist) {
+		return nil, fmt.Errorf("archived node %s not found: %w", id.Path(), err)
+	}
+	return data, err
+}
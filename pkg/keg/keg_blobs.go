@@ -0,0 +1,19 @@
+package keg
+
+import (
+	"context"
+	"fmt"
+)
+
+// CollectBlobs removes attachment blobs no longer referenced by any node.
+// Repositories without a blob store return ErrNotSupported.
+func (k *Keg) CollectBlobs(ctx context.Context, dryRun bool) (*BlobGCReport, error) {
+	if err := k.checkKegExists(ctx); err != nil {
+		return nil, fmt.Errorf("failed to collect blobs: %w", err)
+	}
+	blobs, ok := repoBlobs(k.Repo)
+	if !ok {
+		return nil, fmt.Errorf("%s backend has no blob store: %w", k.Repo.Name(), ErrNotSupported)
+	}
+	return blobs.CollectBlobs(ctx, dryRun)
+}
@@ -0,0 +1,133 @@
+package keg
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Meta keys recording a checkout lock on a node. They live in meta.yaml so
+// the marker travels with the node when a keg is shared over git.
+const (
+	MetaLockedBy = "locked_by"
+	MetaLockedAt = "locked_at"
+)
+
+// Checkout describes who holds the checkout lock on a node.
+type Checkout struct {
+	Holder string
+	Since  time.Time
+}
+
+// Checkout returns the checkout lock on a node, or nil when it is not
+// locked.
+func (k *Keg) Checkout(ctx context.Context, id NodeId) (*Checkout, error) {
+	if err := k.checkKegExists(ctx); err != nil {
+		return nil, fmt.Errorf("failed to read node lock: %w", err)
+	}
+	meta, err := k.getMeta(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read node %s metadata: %w", id.Path(), err)
+	}
+	return checkoutFromMeta(meta), nil
+}
+
+// Lock records holder as the checkout lock on a node. Locking a node the
+// holder already owns refreshes the timestamp. A node locked by someone else
+// returns ErrConflict unless force is set. The marker is written while the
+// repository node lock is held, so concurrent lockers cannot both succeed.
+func (k *Keg) Lock(ctx context.Context, id NodeId, holder string, force bool) error {
+	if holder == "" {
+		return fmt.Errorf("lock holder is required: %w", ErrInvalid)
+	}
+	if err := k.checkNodeForCheckout(ctx, id); err != nil {
+		return err
+	}
+	now := k.Runtime.Clock().Now()
+	return k.withNodeLock(ctx, id, func(lockCtx context.Context) error {
+		return k.updateMetaLocked(lockCtx, id, func(m *NodeMeta) error {
+			if c := checkoutFromMeta(m); c != nil && c.Holder != holder && !force {
+				return checkoutConflict(id, c)
+			}
+			if err := m.Set(lockCtx, MetaLockedBy, holder); err != nil {
+				return err
+			}
+			return m.Set(lockCtx, MetaLockedAt, now.UTC().Format(time.RFC3339))
+		})
+	})
+}
+
+// Unlock removes the checkout lock from a node. Removing a lock held by
+// someone other than holder returns ErrConflict unless force is set.
+// Unlocking a node that is not locked is a no-op.
+func (k *Keg) Unlock(ctx context.Context, id NodeId, holder string, force bool) error {
+	if err := k.checkNodeForCheckout(ctx, id); err != nil {
+		return err
+	}
+	return k.withNodeLock(ctx, id, func(lockCtx context.Context) error {
+		return k.updateMetaLocked(lockCtx, id, func(m *NodeMeta) error {
+			c := checkoutFromMeta(m)
+			if c == nil {
+				return nil
+			}
+			if c.Holder != holder && !force {
+				return checkoutConflict(id, c)
+			}
+			if err := m.Set(lockCtx, MetaLockedBy, nil); err != nil {
+				return err
+			}
+			return m.Set(lockCtx, MetaLockedAt, nil)
+		})
+	})
+}
+
+// updateMetaLocked applies f to a node's metadata and writes it back. The
+// caller must already hold the node lock. When f returns an error nothing
+// is written.
+func (k *Keg) updateMetaLocked(ctx context.Context, id NodeId, f func(*NodeMeta) error) error {
+	m, err := k.getMeta(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to read node %s metadata: %w", id.Path(), err)
+	}
+	if err := f(m); err != nil {
+		return err
+	}
+	if err := k.Repo.WriteMeta(ctx, id, []byte(m.ToYAML())); err != nil {
+		return fmt.Errorf("failed to write node %s metadata: %w", id.Path(), err)
+	}
+	return nil
+}
+
+func (k *Keg) checkNodeForCheckout(ctx context.Context, id NodeId) error {
+	if err := k.checkKegExists(ctx); err != nil {
+		return fmt.Errorf("failed to lock node: %w", err)
+	}
+	exists, err := k.Repo.HasNode(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to check node existence: %w", err)
+	}
+	if !exists {
+		return fmt.Errorf("node %s not found: %w", id.Path(), ErrNotExist)
+	}
+	return nil
+}
+
+func checkoutFromMeta(m *NodeMeta) *Checkout {
+	holder, ok := m.Get(MetaLockedBy)
+	if !ok || holder == "" {
+		return nil
+	}
+	c := &Checkout{Holder: holder}
+	if raw, ok := m.Get(MetaLockedAt); ok {
+		c.Since, _ = time.Parse(time.RFC3339, raw)
+	}
+	return c
+}
+
+func checkoutConflict(id NodeId, c *Checkout) error {
+	msg := fmt.Sprintf("node %s is locked by %s", id.Path(), c.Holder)
+	if !c.Since.IsZero() {
+		msg += " since " + c.Since.Format(time.RFC3339)
+	}
+	return fmt.Errorf("%s: %w", msg, ErrConflict)
+}
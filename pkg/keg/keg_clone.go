@@ -0,0 +1,148 @@
+package keg
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// CloneOptions controls how Clone derives the new node from its source.
+type CloneOptions struct {
+	// Title replaces the cloned node's H1 title. An H1 is added when the
+	// source has none.
+	Title string
+	// TitleSuffix is appended to the cloned node's H1 title, separated by a
+	// space.
+	TitleSuffix string
+	// Tags are added to the tags copied from the source node.
+	Tags []string
+}
+
+// Clone copies a node's content, metadata, images and attachments into a
+// freshly allocated node and returns its ID. Programmatic fields such as the
+// hash and timestamps are not copied; the clone gets new stats of its own.
+func (k *Keg) Clone(ctx context.Context, src NodeId, opts CloneOptions) (NodeId, error) {
+	if err := k.checkKegExists(ctx); err != nil {
+		return NodeId{}, fmt.Errorf("failed to clone node: %w", err)
+	}
+
+	src = NodeId{ID: src.ID, Code: src.Code}
+	if !src.Valid() {
+		return NodeId{}, fmt.Errorf("invalid node id: %w", ErrInvalid)
+	}
+	exists, err := k.Repo.HasNode(ctx, src)
+	if err != nil {
+		return NodeId{}, fmt.Errorf("failed to check node existence: %w", err)
+	}
+	if !exists {
+		return NodeId{}, fmt.Errorf("node %s not found: %w", src.Path(), ErrNotExist)
+	}
+
+	content, err := k.Repo.ReadContent(ctx, src)
+	if err != nil {
+		return NodeId{}, fmt.Errorf("failed to read node %s: %w", src.Path(), err)
+	}
+	meta, err := k.getMeta(ctx, src)
+	if err != nil {
+		return NodeId{}, fmt.Errorf("failed to read node %s metadata: %w", src.Path(), err)
+	}
+	if len(opts.Tags) > 0 {
+		tags := append(meta.Tags(), opts.Tags...)
+		slices.Sort(tags)
+		meta.SetTags(slices.Compact(tags))
+	}
+	if opts.Title != "" {
+		content = replaceTitle(content, opts.Title)
+	}
+	if opts.TitleSuffix != "" {
+		content = appendTitleSuffix(content, opts.TitleSuffix)
+	}
+
+	dst, err := k.createNode(ctx, &CreateOptions{Body: content}, nil)
+	if err != nil {
+		return dst, fmt.Errorf("failed to create clone of %s: %w", src.Path(), err)
+	}
+
+	var errs []error
+	if err := k.withNodeLock(ctx, dst, func(lockCtx context.Context) error {
+		return k.Repo.WriteMeta(lockCtx, dst, []byte(meta.ToYAML()))
+	}); err != nil {
+		errs = append(errs, fmt.Errorf("failed to write clone metadata: %w", err))
+	}
+	if err := k.cloneAssets(ctx, src, dst); err != nil {
+		errs = append(errs, err)
+	}
+
+	data, err := k.getNode(ctx, dst)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("failed to load clone %s: %w", dst.Path(), err))
+	} else if err := k.writeNodeToDex(ctx, dst, data); err != nil {
+		errs = append(errs, err)
+	}
+	return dst, errors.Join(errs...)
+}
+
+// cloneAssets copies images and file attachments when the repository
+// supports them.
+func (k *Keg) cloneAssets(ctx context.Context, src, dst NodeId) error {
+	var errs []error
+	if images, ok := k.Repo.(RepositoryImages); ok {
+		names, err := images.ListImages(ctx, src)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to list images of %s: %w", src.Path(), err))
+		}
+		for _, name := range names {
+			data, err := images.ReadImage(ctx, src, name)
+			if err == nil {
+				err = images.WriteImage(ctx, dst, name, data)
+			}
+			if err != nil {
+				errs = append(errs, fmt.Errorf("failed to copy image %s: %w", name, err))
+			}
+		}
+	}
+	if files, ok := k.Repo.(RepositoryFiles); ok {
+		names, err := files.ListFiles(ctx, src)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to list attachments of %s: %w", src.Path(), err))
+		}
+		for _, name := range names {
+			data, err := files.ReadFile(ctx, src, name)
+			if err == nil {
+				err = files.WriteFile(ctx, dst, name, data)
+			}
+			if err != nil {
+				errs = append(errs, fmt.Errorf("failed to copy attachment %s: %w", name, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// appendTitleSuffix appends suffix to the first H1 line of a markdown body.
+// Content without an H1 is returned unchanged.
+func appendTitleSuffix(content []byte, suffix string) []byte {
+	lines := bytes.Split(content, []byte("\n"))
+	for i, line := range lines {
+		if bytes.HasPrefix(line, []byte("# ")) {
+			lines[i] = []byte(string(bytes.TrimRight(line, " \t\r")) + " " + suffix)
+			return bytes.Join(lines, []byte("\n"))
+		}
+	}
+	return content
+}
+
+// replaceTitle swaps the first H1 line of a markdown body for title, adding
+// one at the top when the body has no H1.
+func replaceTitle(content []byte, title string) []byte {
+	lines := bytes.Split(content, []byte("\n"))
+	for i, line := range lines {
+		if bytes.HasPrefix(line, []byte("# ")) {
+			lines[i] = []byte("# " + title)
+			return bytes.Join(lines, []byte("\n"))
+		}
+	}
+	return append([]byte("# "+title+"\n\n"), content...)
+}
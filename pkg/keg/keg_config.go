@@ -86,9 +86,356 @@ type ConfigV2 struct {
 
 	Tags map[string]string `yaml:"tags,omitempty"`
 
+	// Search tunes how search results are ranked for this keg.
+	Search *SearchConfig `yaml:"search,omitempty"`
+
+	// SavedSearches are named queries materialized into dex/search-NAME.md
+	// on index rebuild and replayable with `tap list --saved NAME`.
+	SavedSearches []SavedSearch `yaml:"savedSearches,omitempty"`
+
+	// Export controls how nodes are rendered when exported from the keg.
+	Export *ExportConfig `yaml:"export,omitempty"`
+
+	// Files controls how file attachments are stored.
+	Files *FilesConfig `yaml:"files,omitempty"`
+
+	// Images controls how uploaded images are stored.
+	Images *ImagesConfig `yaml:"images,omitempty"`
+
+	// Quotas sets soft storage limits checked when uploading images and
+	// file attachments.
+	Quotas *QuotasConfig `yaml:"quotas,omitempty"`
+
+	// Recurring are rules for nodes created on a schedule by `tap cron run`.
+	Recurring []RecurringNode `yaml:"recurring,omitempty"`
+
+	// MaxNodeID caps the node IDs new nodes may be given. Zero means
+	// MaxNodeID, the package-wide limit.
+	MaxNodeID int `yaml:"maxNodeId,omitempty"`
+
+	// Format names the content format node files are parsed as, one of
+	// Formats. Empty means markdown, with RST detected from content.
+	Format string `yaml:"format,omitempty"`
+
+	// Defaults are applied by Keg.Create to new nodes.
+	Defaults *CreateDefaults `yaml:"defaults,omitempty"`
+
+	// KeepFrontmatter makes Keg.Create leave YAML frontmatter in the
+	// content file instead of stripping it. The frontmatter stays the source
+	// of the node's tags and attrs, which are synced into meta.yaml whenever
+	// the content is written.
+	KeepFrontmatter bool `yaml:"keepFrontmatter,omitempty"`
+
+	// RetitleLinks makes Keg.SetContent give bare node links, such as
+	// [](../42) or [../42](../42), the current title of their target, as
+	// `tap links retitle` does.
+	RetitleLinks bool `yaml:"retitleLinks,omitempty"`
+
+	// Fmt controls how `tap fmt` normalizes node content.
+	Fmt *FmtConfig `yaml:"fmt,omitempty"`
+
+	// Lint configures the checks run by `tap lint`.
+	Lint *LintConfig `yaml:"lint,omitempty"`
+
+	// Encryption encrypts the content of tagged nodes at rest. See
+	// EncryptionConfig.
+	Encryption *EncryptionConfig `yaml:"encryption,omitempty"`
+
+	// Summarizer generates node leads instead of taking the first
+	// paragraph after the title. See SummarizerConfig.
+	Summarizer *SummarizerConfig `yaml:"summarizer,omitempty"`
+
+	// Unfurl caches previews of external links in dex/unfurl.jsonl. See
+	// UnfurlConfig.
+	Unfurl *UnfurlConfig `yaml:"unfurl,omitempty"`
+
+	// Hooks run shell commands before and after node create, edit and
+	// delete and keg index. See Hook.
+	Hooks []Hook `yaml:"hooks,omitempty"`
+
+	// Policy restricts which nodes may be changed and what may be attached
+	// to them. See PolicyConfig.
+	Policy *PolicyConfig `yaml:"policy,omitempty"`
+
 	path string
 }
 
+// CreateDefaults holds per-keg defaults for newly created nodes. Each field
+// only applies when the caller leaves the matching create option unset.
+type CreateDefaults struct {
+	// Template is a node ID whose content seeds nodes created without a
+	// body. The new node's title replaces the template's.
+	Template string `yaml:"template,omitempty"`
+
+	// Tags are added to every new node.
+	Tags []string `yaml:"tags,omitempty"`
+
+	// Attrs are set on every new node unless the caller sets the same key.
+	Attrs map[string]any `yaml:"attrs,omitempty"`
+}
+
+// ExportConfig holds per-keg export settings.
+type ExportConfig struct {
+	// Backlinks appends a generated section listing incoming links to each
+	// exported node.
+	Backlinks bool `yaml:"backlinks,omitempty"`
+
+	// BacklinksHeading overrides the heading of the generated section.
+	// Defaults to DefaultBacklinksHeading.
+	BacklinksHeading string `yaml:"backlinksHeading,omitempty"`
+}
+
+// FmtConfig holds per-keg settings for `tap fmt`.
+type FmtConfig struct {
+	// Width wraps paragraphs to this many columns. Zero keeps line breaks
+	// as written.
+	Width int `yaml:"width,omitempty"`
+}
+
+// EncryptionConfig holds the per-keg settings for encrypting node content.
+// The content of nodes with Tag is written as an armored age file for
+// Recipients; meta and stats stay in the clear so the node is still listed
+// and searchable by title and tags.
+type EncryptionConfig struct {
+	// Tag marks the nodes to encrypt. Defaults to DefaultEncryptionTag.
+	Tag string `yaml:"tag,omitempty"`
+
+	// Recipients are the age public keys ("age1...") content is encrypted
+	// to.
+	Recipients []string `yaml:"recipients,omitempty"`
+}
+
+// SummarizerConfig selects the summarizer that writes node leads when
+// content changes. Leads are stored in stats and listed in dex/leads.tsv.
+type SummarizerConfig struct {
+	// Kind is "sentences" (the default) to take the first Sentences
+	// sentences of the body, "command" for an external process or "openai"
+	// for an OpenAI-compatible chat completions endpoint.
+	Kind string `yaml:"kind,omitempty"`
+
+	// Sentences is how many sentences the "sentences" kind keeps. Zero
+	// means DefaultSummarySentences.
+	Sentences int `yaml:"sentences,omitempty"`
+
+	// Command is the argv of the "command" kind.
+	Command []string `yaml:"command,omitempty"`
+
+	// URL is the base URL of an OpenAI-compatible API, for example
+	// "https://api.openai.com/v1". Used by the "openai" kind.
+	URL string `yaml:"url,omitempty"`
+
+	// Model is the chat model name sent to the backend.
+	Model string `yaml:"model,omitempty"`
+
+	// APIKeyEnv names the environment variable holding the API key. The key
+	// itself is never stored in keg config.
+	APIKeyEnv string `yaml:"apiKeyEnv,omitempty"`
+}
+
+// UnfurlConfig enables `tap links unfurl`, which fetches the title,
+// description and favicon of external URLs in node content. Pages are only
+// fetched when robots.txt allows it.
+type UnfurlConfig struct {
+	// TTL is how long a cached preview is used before it is fetched again,
+	// as a Go duration such as "168h". Defaults to 720h.
+	TTL string `yaml:"ttl,omitempty"`
+}
+
+// LintConfig holds per-keg settings for `tap lint`.
+type LintConfig struct {
+	// Prose configures the prose rules run by `tap lint --prose`.
+	Prose *ProseLintConfig `yaml:"prose,omitempty"`
+}
+
+// ProseLintConfig configures the prose rules of `tap lint --prose`.
+type ProseLintConfig struct {
+	// MaxSentenceWords is the longest sentence SentenceLength allows. Zero
+	// means 30.
+	MaxSentenceWords int `yaml:"maxSentenceWords,omitempty"`
+
+	// Rules are Vale-style YAML rule files, or directories of them, to run
+	// along with the built-in rules. Relative paths are resolved against
+	// the keg directory.
+	Rules []string `yaml:"rules,omitempty"`
+
+	// Disable names rules to skip, such as "PassiveVoice" or a rule file's
+	// "Style.Name".
+	Disable []string `yaml:"disable,omitempty"`
+}
+
+// FilesConfig holds per-keg file attachment settings.
+type FilesConfig struct {
+	// BlobStore stores attachment contents once under blobs/ keyed by their
+	// SHA-256 hash, leaving small pointer files in each node's assets
+	// directory.
+	BlobStore bool `yaml:"blobStore,omitempty"`
+}
+
+// QuotasConfig holds per-keg storage limits. Sizes are strings such as
+// "500MB"; empty values are unlimited.
+type QuotasConfig struct {
+	// Keg limits the total size of the keg.
+	Keg string `yaml:"keg,omitempty"`
+	// Node limits the combined size of a node's content, images and
+	// attachments.
+	Node string `yaml:"node,omitempty"`
+	// File limits the size of a single uploaded image or attachment.
+	File string `yaml:"file,omitempty"`
+	// Enforce makes uploads that exceed a quota fail instead of warn.
+	Enforce bool `yaml:"enforce,omitempty"`
+}
+
+// ImagesConfig holds per-keg image upload settings.
+type ImagesConfig struct {
+	// StripMetadata removes EXIF (including GPS) and XMP metadata from
+	// uploaded images. Nil means enabled.
+	StripMetadata *bool `yaml:"stripMetadata,omitempty"`
+
+	// Convert rewrites uploads into more portable formats.
+	Convert *ImageConvertConfig `yaml:"convert,omitempty"`
+}
+
+// ImageConvertConfig controls image format conversion on upload. The
+// conversion runs an external command, ImageMagick by default.
+type ImageConvertConfig struct {
+	// ToJPEG converts HEIC/HEIF and TIFF uploads to JPEG.
+	ToJPEG bool `yaml:"toJpeg,omitempty"`
+	// PNGToWebP converts PNG uploads at least this large, for example
+	// "500KB", to WebP. Empty disables the conversion.
+	PNGToWebP string `yaml:"pngToWebp,omitempty"`
+	// Quality is the lossy output quality from 1 to 100. Zero means 85.
+	Quality int `yaml:"quality,omitempty"`
+	// KeepOriginal also stores the unconverted upload as a file attachment.
+	KeepOriginal bool `yaml:"keepOriginal,omitempty"`
+	// Command overrides the converter. It is run as
+	// `COMMAND INPUT -quality Q OUTPUT` and must pick the output format from
+	// the OUTPUT extension. Defaults to magick, then convert.
+	Command string `yaml:"command,omitempty"`
+}
+
+// SearchConfig holds per-keg search ranking settings. Weights left at zero
+// fall back to DefaultSearchRankWeights.
+type SearchConfig struct {
+	// RankThreshold ranks results automatically once a search returns at
+	// least this many hits. Zero disables automatic ranking.
+	RankThreshold int `yaml:"rankThreshold,omitempty"`
+
+	// TermWeight scales how often the query matches within a node.
+	TermWeight float64 `yaml:"termWeight,omitempty"`
+
+	// RecencyWeight scales how recently a node was updated.
+	RecencyWeight float64 `yaml:"recencyWeight,omitempty"`
+
+	// AccessWeight scales how often a node has been accessed.
+	AccessWeight float64 `yaml:"accessWeight,omitempty"`
+
+	// RecencyHalfLife is a Go duration after which the recency signal of an
+	// unchanged node is halved (for example "720h").
+	RecencyHalfLife string `yaml:"recencyHalfLife,omitempty"`
+
+	// Embedder configures the backend used by semantic search. Nil disables
+	// `tap search --semantic`.
+	Embedder *EmbedderConfig `yaml:"embedder,omitempty"`
+}
+
+// EmbedderConfig selects and configures a text embedding backend.
+type EmbedderConfig struct {
+	// Kind is the backend type: "openai" for an OpenAI-compatible HTTP
+	// endpoint or "command" for an external plugin process.
+	Kind string `yaml:"kind"`
+
+	// URL is the base URL of an OpenAI-compatible API, for example
+	// "https://api.openai.com/v1". Used by the "openai" kind.
+	URL string `yaml:"url,omitempty"`
+
+	// Model is the embedding model name sent to the backend.
+	Model string `yaml:"model,omitempty"`
+
+	// APIKeyEnv names the environment variable holding the API key. The key
+	// itself is never stored in keg config.
+	APIKeyEnv string `yaml:"apiKeyEnv,omitempty"`
+
+	// Command is the plugin argv used by the "command" kind.
+	Command []string `yaml:"command,omitempty"`
+}
+
+// SavedSearch is a named query combining a boolean tag expression with a
+// structured metadata filter (see ParseWhereExpression). Either may be empty.
+type SavedSearch struct {
+	Name    string `yaml:"name"`
+	Summary string `yaml:"summary,omitempty"`
+	Tags    string `yaml:"tags,omitempty"`
+	Where   string `yaml:"where,omitempty"`
+}
+
+// Recurrence periods accepted by RecurringNode.Every.
+const (
+	RecurDaily   = "daily"
+	RecurWeekly  = "weekly"
+	RecurMonthly = "monthly"
+)
+
+// RecurringNode describes a node created once per period by `tap cron run`.
+type RecurringNode struct {
+	// Name identifies the rule.
+	Name string `yaml:"name"`
+
+	// Every is the period: daily, weekly or monthly.
+	Every string `yaml:"every"`
+
+	// Day selects the occurrence within the period: a weekday name for
+	// weekly rules (default monday) or a day of the month for monthly rules
+	// (default 1). Days past the end of a short month fall on its last day.
+	Day string `yaml:"day,omitempty"`
+
+	// Template is an optional node ID cloned for each occurrence.
+	Template string `yaml:"template,omitempty"`
+
+	// Title is the node title. "{date}" is replaced with the occurrence date
+	// (YYYY-MM-DD); without it the date is appended. Defaults to the
+	// template's title.
+	Title string `yaml:"title,omitempty"`
+
+	// Tags are added to each created node.
+	Tags []string `yaml:"tags,omitempty"`
+}
+
+// SearchRankWeights are the resolved weights used to score search hits.
+type SearchRankWeights struct {
+	Term            float64
+	Recency         float64
+	Access          float64
+	RecencyHalfLife time.Duration
+}
+
+// DefaultSearchRankWeights favors term frequency while still surfacing
+// recently updated and frequently accessed nodes.
+var DefaultSearchRankWeights = SearchRankWeights{
+	Term:            1.0,
+	Recency:         0.5,
+	Access:          0.5,
+	RecencyHalfLife: 30 * 24 * time.Hour,
+}
+
+// RankWeights resolves the configured weights, falling back to
+// DefaultSearchRankWeights when no weight is set. A nil config yields the
+// defaults.
+func (sc *SearchConfig) RankWeights() SearchRankWeights {
+	w := DefaultSearchRankWeights
+	if sc == nil {
+		return w
+	}
+	if sc.TermWeight != 0 || sc.RecencyWeight != 0 || sc.AccessWeight != 0 {
+		w.Term = sc.TermWeight
+		w.Recency = sc.RecencyWeight
+		w.Access = sc.AccessWeight
+	}
+	if d, err := time.ParseDuration(strings.TrimSpace(sc.RecencyHalfLife)); err == nil && d > 0 {
+		w.RecencyHalfLife = d
+	}
+	return w
+}
+
 // LinkEntry represents a named link in the KEG configuration.
 type LinkEntry struct {
 	Alias string `json:"alias"` // Alias for the link
@@ -255,6 +602,51 @@ func (kc *Config) Touch(t time.Time) {
 	kc.Updated = t.Format(time.RFC3339)
 }
 
+// SavedSearch returns the saved search with the given name.
+func (kc *Config) SavedSearch(name string) (SavedSearch, bool) {
+	if kc == nil {
+		return SavedSearch{}, false
+	}
+	name = strings.TrimSpace(name)
+	for _, search := range kc.SavedSearches {
+		if search.Name == name {
+			return search, true
+		}
+	}
+	return SavedSearch{}, false
+}
+
+// StripImageMetadata reports whether uploaded images should have their
+// EXIF and XMP metadata removed. It defaults to true.
+func (kc *Config) StripImageMetadata() bool {
+	if kc == nil || kc.Images == nil || kc.Images.StripMetadata == nil {
+		return true
+	}
+	return *kc.Images.StripMetadata
+}
+
+// ImageConversion returns the image conversion settings, or nil when
+// uploads are stored in their original format.
+func (kc *Config) ImageConversion() *ImageConvertConfig {
+	if kc == nil || kc.Images == nil {
+		return nil
+	}
+	return kc.Images.Convert
+}
+
+// RecurringRule returns the recurring node rule with the given name.
+func (kc *Config) RecurringRule(name string) (RecurringNode, bool) {
+	if kc == nil {
+		return RecurringNode{}, false
+	}
+	for _, rule := range kc.Recurring {
+		if rule.Name == name {
+			return rule, true
+		}
+	}
+	return RecurringNode{}, false
+}
+
 // AddEntity adds or updates an entity entry by entity name.
 func (kc *Config) AddEntity(name string, id int, summary string) error {
 	if kc == nil {
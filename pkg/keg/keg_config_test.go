@@ -3,6 +3,7 @@ package keg_test
 import (
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/jlrickert/tapper/pkg/keg"
 	"github.com/stretchr/testify/require"
@@ -185,3 +186,28 @@ func TestConfigToYAML_PrependsSchemaModeline(t *testing.T) {
 	require.NoError(t, err)
 	require.True(t, strings.HasPrefix(string(out), "# yaml-language-server: $schema="+keg.KegConfigSchemaURL+"\n"))
 }
+
+func TestSearchConfig_RankWeights(t *testing.T) {
+	raw := `
+kegv: "2025-07"
+search:
+  rankThreshold: 20
+  termWeight: 2
+  recencyWeight: 0
+  accessWeight: 1.5
+  recencyHalfLife: 168h
+`
+	config, err := keg.ParseKegConfig([]byte(raw))
+	require.NoError(t, err)
+	require.NotNil(t, config.Search)
+	require.Equal(t, 20, config.Search.RankThreshold)
+
+	w := config.Search.RankWeights()
+	require.Equal(t, 2.0, w.Term)
+	require.Equal(t, 0.0, w.Recency)
+	require.Equal(t, 1.5, w.Access)
+	require.Equal(t, 168*time.Hour, w.RecencyHalfLife)
+
+	var nilSearch *keg.SearchConfig
+	require.Equal(t, keg.DefaultSearchRankWeights, nilSearch.RankWeights())
+}
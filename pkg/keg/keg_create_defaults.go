@@ -0,0 +1,77 @@
+package keg
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// createDefaults returns the defaults section of the keg config, or nil when
+// the config is unreadable or has none.
+func (k *Keg) createDefaults(ctx context.Context) *CreateDefaults {
+	cfg, err := k.Repo.ReadConfig(ctx)
+	if err != nil || cfg == nil {
+		return nil
+	}
+	return cfg.Defaults
+}
+
+// applyCreateDefaults returns a copy of opts with defaults filled in. Default
+// tags are merged with the caller's, default attrs yield to caller attrs with
+// the same key, and the template only seeds nodes created without a body.
+// Template variables are expanded in the template and, when opts has Vars,
+// in the caller's body.
+func (k *Keg) applyCreateDefaults(ctx context.Context, defaults *CreateDefaults, opts *CreateOptions) (*CreateOptions, error) {
+	if opts == nil {
+		opts = &CreateOptions{}
+	}
+	out := *opts
+	if opts.Vars != nil && len(opts.Body) > 0 {
+		body, err := ExpandTemplate(opts.Body, k.templateVars(opts))
+		if err != nil {
+			return nil, err
+		}
+		out.Body = body
+	}
+	if defaults == nil {
+		return &out, nil
+	}
+
+	if len(defaults.Tags) > 0 {
+		tags := append(slices.Clone(defaults.Tags), opts.Tags...)
+		slices.Sort(tags)
+		out.Tags = slices.Compact(tags)
+	}
+	if len(defaults.Attrs) > 0 {
+		attrs := maps.Clone(defaults.Attrs)
+		maps.Copy(attrs, opts.Attrs)
+		out.Attrs = attrs
+	}
+
+	raw := strings.TrimSpace(defaults.Template)
+	if len(opts.Body) == 0 && raw != "" {
+		node, err := ParseNode(raw)
+		if err != nil || node == nil {
+			return nil, fmt.Errorf("invalid default template node ID %q: %w", raw, ErrInvalid)
+		}
+		template := NodeId{ID: node.ID, Code: node.Code}
+		body, err := k.Repo.ReadContent(ctx, template)
+		if err == nil {
+			body, err = k.openContent(ctx, template, body)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read default template %s: %w", template.Path(), err)
+		}
+		body, err = ExpandTemplate(body, k.templateVars(opts))
+		if err != nil {
+			return nil, fmt.Errorf("failed to expand default template %s: %w", template.Path(), err)
+		}
+		if opts.Title != "" {
+			body = replaceTitle(body, opts.Title)
+		}
+		out.Body = body
+	}
+	return &out, nil
+}
@@ -0,0 +1,326 @@
+package keg
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+)
+
+// ChangeOp is the kind of repository change recorded by Keg.DryRun.
+type ChangeOp string
+
+const (
+	// ChangeWrite means a file would be created or overwritten.
+	ChangeWrite ChangeOp = "write"
+	// ChangeRemove means a file would be deleted.
+	ChangeRemove ChangeOp = "remove"
+)
+
+// Change is a single file-level change a dry run would make. Path is
+// relative to the keg root and uses the filesystem layout, for example
+// "3/README.md", "3/assets/spec.pdf", "archive/4/meta.yaml" or
+// "dex/nodes.tsv".
+type Change struct {
+	Op   ChangeOp
+	Path string
+}
+
+func (c Change) String() string {
+	return fmt.Sprintf("%s %s", c.Op, c.Path)
+}
+
+// DryRun is an in-memory copy of a keg that absorbs writes so an operation
+// can be rehearsed and its changes reported without touching the original.
+type DryRun struct {
+	// Keg is the copy to run operations against. It shares the Target of the
+	// original keg so identity checks keep working.
+	Keg *Keg
+
+	mem    *MemoryRepo
+	before map[string][]byte
+}
+
+// NewDryRun copies nodes, attachments, archived nodes, indexes and config of
+// k into memory. Snapshot history is not copied and changes to it are not
+// reported.
+func NewDryRun(ctx context.Context, k *Keg) (*DryRun, error) {
+	mem, err := k.copyToMemoryRepo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to prepare dry run: %w", err)
+	}
+	copied := NewKeg(mem, k.Runtime)
+	copied.Target = k.Target
+	copied.TrustedConfig = k.TrustedConfig
+	copied.dryRun = true
+	return &DryRun{Keg: copied, mem: mem, before: mem.files()}, nil
+}
+
+// Changes reports the files that operations on d.Keg have written or
+// removed so far, sorted by path.
+func (d *DryRun) Changes() []Change {
+	after := d.mem.files()
+	var changes []Change
+	for p, data := range after {
+		if old, ok := d.before[p]; !ok || !bytes.Equal(old, data) {
+			changes = append(changes, Change{Op: ChangeWrite, Path: p})
+		}
+	}
+	for p := range d.before {
+		if _, ok := after[p]; !ok {
+			changes = append(changes, Change{Op: ChangeRemove, Path: p})
+		}
+	}
+	slices.SortFunc(changes, func(a, b Change) int {
+		return cmp.Or(cmp.Compare(a.Path, b.Path), cmp.Compare(a.Op, b.Op))
+	})
+	return changes
+}
+
+// copyToMemoryRepo copies nodes with their items, versions and snapshots,
+// archived nodes, indexes and config of the keg into a fresh MemoryRepo.
+func (k *Keg) copyToMemoryRepo(ctx context.Context) (*MemoryRepo, error) {
+	repo := k.Repo
+	mem := NewMemoryRepo(k.Runtime)
+
+	ids, err := repo.ListNodes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		node, err := k.readMemoryNode(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("node %s: %w", id.Path(), err)
+		}
+		mem.nodes[id] = node
+		if mem.snapshots[id], err = readMemorySnapshots(ctx, repo, id); err != nil {
+			return nil, fmt.Errorf("node %s snapshots: %w", id.Path(), err)
+		}
+	}
+
+	if archive, ok := repoArchive(repo); ok {
+		archived, err := archive.ListArchived(ctx)
+		if err != nil {
+			return nil, err
+		}
+		state, hasState := repoArchivedState(repo)
+		for _, id := range archived {
+			content, err := archive.ReadArchivedContent(ctx, id)
+			if err != nil && !errors.Is(err, ErrNotExist) {
+				return nil, fmt.Errorf("archived node %s: %w", id.Path(), err)
+			}
+			meta, err := archive.ReadArchivedMeta(ctx, id)
+			if err != nil && !errors.Is(err, ErrNotExist) {
+				return nil, fmt.Errorf("archived node %s: %w", id.Path(), err)
+			}
+			node := &memoryNode{content: content, meta: meta}
+			if hasState {
+				if err := readArchivedMemoryState(ctx, state, id, node); err != nil {
+					return nil, fmt.Errorf("archived node %s: %w", id.Path(), err)
+				}
+			}
+			mem.archived[id] = node
+		}
+	}
+
+	names, err := repo.ListIndexes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		data, err := repo.GetIndex(ctx, name)
+		if err != nil {
+			return nil, fmt.Errorf("index %s: %w", name, err)
+		}
+		mem.indexes[name] = data
+	}
+
+	cfg, err := repo.ReadConfig(ctx)
+	if err != nil && !errors.Is(err, ErrNotExist) {
+		return nil, err
+	}
+	mem.config = cfg
+	return mem, nil
+}
+
+func (k *Keg) readMemoryNode(ctx context.Context, id NodeId) (*memoryNode, error) {
+	repo := k.Repo
+	node := &memoryNode{
+		items:    map[string][]byte{},
+		images:   map[string][]byte{},
+		itemMeta: map[string]ItemMeta{},
+	}
+	var err error
+	if node.content, err = repo.ReadContent(ctx, id); err != nil && !errors.Is(err, ErrNotExist) {
+		return nil, err
+	}
+	if node.meta, err = repo.ReadMeta(ctx, id); err != nil && !errors.Is(err, ErrNotExist) {
+		return nil, err
+	}
+	stats, err := repo.ReadStats(ctx, id)
+	if err != nil && !errors.Is(err, ErrNotExist) {
+		return nil, err
+	}
+	if stats != nil {
+		if node.stats, err = stats.ToJSON(); err != nil {
+			return nil, err
+		}
+	}
+
+	withMeta, hasMeta := repoItemMeta(repo)
+	for _, kind := range []AssetKind{AssetKindImage, AssetKindItem} {
+		list, into := repoListFiles, node.items
+		if kind == AssetKindImage {
+			list, into = repoListImages, node.images
+		}
+		names, err := list(ctx, repo, id)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range names {
+			if into[name], err = k.readItem(ctx, id, kind, name); err != nil {
+				return nil, err
+			}
+			if !hasMeta {
+				continue
+			}
+			meta, err := withMeta.ReadItemMeta(ctx, id, kind, name)
+			if err != nil && !errors.Is(err, ErrNotExist) {
+				return nil, err
+			}
+			if meta != nil {
+				node.itemMeta[itemMetaKey(kind, name)] = *meta
+			}
+		}
+	}
+	if files, ok := repo.(RepositoryFiles); ok {
+		versions := map[string][]byte{}
+		if err := readNodeVersions(ctx, files, id, versions); err != nil {
+			return nil, err
+		}
+		for name, data := range versions {
+			node.items[NodeVersionsDir+"/"+name] = data
+		}
+	}
+	return node, nil
+}
+
+// readArchivedMemoryState fills node with the stats and items of an
+// archived node.
+func readArchivedMemoryState(ctx context.Context, state RepositoryArchivedState, id NodeId, node *memoryNode) error {
+	stats, err := state.ReadArchivedStats(ctx, id)
+	if err != nil && !errors.Is(err, ErrNotExist) {
+		return err
+	}
+	if stats != nil {
+		if node.stats, err = stats.ToJSON(); err != nil {
+			return err
+		}
+	}
+	items, err := state.ReadArchivedItems(ctx, id)
+	if err != nil {
+		return err
+	}
+	node.items = map[string][]byte{}
+	node.images = map[string][]byte{}
+	node.itemMeta = map[string]ItemMeta{}
+	for name, data := range items.Files {
+		node.items[name] = data
+	}
+	for name, data := range items.Versions {
+		node.items[NodeVersionsDir+"/"+name] = data
+	}
+	for name, data := range items.Images {
+		node.images[name] = data
+	}
+	for name, meta := range items.FileMeta {
+		node.itemMeta[itemMetaKey(AssetKindItem, name)] = *meta
+	}
+	for name, meta := range items.ImageMeta {
+		node.itemMeta[itemMetaKey(AssetKindImage, name)] = *meta
+	}
+	return nil
+}
+
+// readMemorySnapshots reads the snapshot history of a node with each
+// revision's full content.
+func readMemorySnapshots(ctx context.Context, repo Repository, id NodeId) ([]memorySnapshotEntry, error) {
+	snapshots, ok := repoSnapshots(repo)
+	if !ok {
+		return nil, nil
+	}
+	history, err := snapshots.ListSnapshots(ctx, id)
+	if errors.Is(err, ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var entries []memorySnapshotEntry
+	for _, rev := range history {
+		snap, content, meta, stats, err := snapshots.GetSnapshot(ctx, id, rev.ID, SnapshotReadOptions{ResolveContent: true})
+		if err != nil {
+			return nil, fmt.Errorf("revision %d: %w", rev.ID, err)
+		}
+		entry := memorySnapshotEntry{snapshot: snap, content: content, meta: meta}
+		if stats != nil {
+			if entry.stats, err = stats.ToJSON(); err != nil {
+				return nil, err
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// files flattens the repository into keg-root-relative paths, mirroring the
+// FsRepo layout, mapped to their contents.
+func (r *MemoryRepo) files() map[string][]byte {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := map[string][]byte{}
+	addNode := func(dir string, node *memoryNode) {
+		if node.content != nil {
+			out[path.Join(dir, MarkdownContentFilename)] = slices.Clone(node.content)
+		}
+		if node.meta != nil {
+			out[path.Join(dir, YAMLMetaFilename)] = slices.Clone(node.meta)
+		}
+		if node.stats != nil {
+			out[path.Join(dir, JSONStatsFilename)] = slices.Clone(node.stats)
+		}
+		for name, data := range node.images {
+			out[path.Join(dir, NodeImagesDir, name)] = slices.Clone(data)
+		}
+		for name, data := range node.items {
+			out[path.Join(dir, NodeAttachmentsDir, name)] = slices.Clone(data)
+		}
+		for key, meta := range node.itemMeta {
+			kind, name, _ := strings.Cut(key, "/")
+			sub := NodeAttachmentsDir
+			if AssetKind(kind) == AssetKindImage {
+				sub = NodeImagesDir
+			}
+			data, _ := meta.toJSON()
+			out[path.Join(dir, sub, NodeItemMetaDir, name+".json")] = data
+		}
+	}
+	for id, node := range r.nodes {
+		addNode(id.Path(), node)
+	}
+	for id, node := range r.archived {
+		addNode(path.Join(ArchiveDirName, id.Path()), node)
+	}
+	for name, data := range r.indexes {
+		out[path.Join("dex", name)] = slices.Clone(data)
+	}
+	if r.config != nil {
+		data, _ := r.config.ToYAML()
+		out["keg"] = data
+	}
+	return out
+}
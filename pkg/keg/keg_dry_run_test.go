@@ -0,0 +1,39 @@
+package keg_test
+
+import (
+	"testing"
+
+	kegpkg "github.com/jlrickert/tapper/pkg/keg"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDryRun_RecordsChangesAndLeavesOriginal(t *testing.T) {
+	t.Parallel()
+	f := NewSandbox(t)
+	ctx := f.Context()
+
+	repo := kegpkg.NewMemoryRepo(f.Runtime())
+	k := kegpkg.NewKeg(repo, f.Runtime())
+	require.NoError(t, k.Init(ctx))
+	id, err := k.Create(ctx, &kegpkg.CreateOptions{Title: "Doomed"})
+	require.NoError(t, err)
+	require.NoError(t, repo.WriteFile(ctx, id, "notes.txt", []byte("hi")))
+
+	run, err := kegpkg.NewDryRun(ctx, k)
+	require.NoError(t, err)
+	require.Empty(t, run.Changes())
+
+	_, err = run.Keg.Remove(ctx, id)
+	require.NoError(t, err)
+	changes := run.Changes()
+	require.Contains(t, changes, kegpkg.Change{Op: kegpkg.ChangeRemove, Path: "1/README.md"})
+	require.Contains(t, changes, kegpkg.Change{Op: kegpkg.ChangeRemove, Path: "1/assets/notes.txt"})
+	require.Contains(t, changes, kegpkg.Change{Op: kegpkg.ChangeWrite, Path: "dex/nodes.tsv"})
+
+	exists, err := repo.HasNode(ctx, id)
+	require.NoError(t, err)
+	require.True(t, exists, "the original keg must not change")
+	data, err := repo.ReadFile(ctx, id, "notes.txt")
+	require.NoError(t, err)
+	require.Equal(t, "hi", string(data))
+}
@@ -0,0 +1,253 @@
+package keg
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+)
+
+// DefaultEncryptionTag marks the nodes whose content is encrypted when the
+// keg config's encryption section names no tag.
+const DefaultEncryptionTag = "private"
+
+// IdentityFunc returns the age identities that decrypt node content. It is
+// called the first time encrypted content is read and should return an
+// error wrapping ErrEncrypted when no identity is available.
+type IdentityFunc func(ctx context.Context) ([]age.Identity, error)
+
+// WithIdentities makes the keg decrypt encrypted node content with the
+// identities fn returns.
+func WithIdentities(fn IdentityFunc) Option {
+	return func(k *Keg) {
+		k.Identities = fn
+	}
+}
+
+// IsEncryptedContent reports whether data is an armored age file.
+func IsEncryptedContent(data []byte) bool {
+	return bytes.HasPrefix(bytes.TrimLeft(data, " \t\r\n"), []byte(armor.Header))
+}
+
+// EncryptionTag returns the tag that marks nodes for encryption, or "" when
+// the keg does not encrypt content.
+func (k *Keg) EncryptionTag(ctx context.Context) string {
+	cfg, err := k.Repo.ReadConfig(ctx)
+	if err != nil || cfg == nil || cfg.Encryption == nil {
+		return ""
+	}
+	if tag := strings.TrimSpace(cfg.Encryption.Tag); tag != "" {
+		return tag
+	}
+	return DefaultEncryptionTag
+}
+
+// sealsTags reports whether tags mark a node for encryption.
+func (k *Keg) sealsTags(ctx context.Context, tags []string) bool {
+	tag := k.EncryptionTag(ctx)
+	return tag != "" && slices.Contains(tags, tag)
+}
+
+// redactLead blanks the lead of a node marked for encryption so its first
+// paragraph is kept in plaintext neither in stats.json nor in the dex. It
+// reports whether a recorded lead was removed.
+func (k *Keg) redactLead(ctx context.Context, data *NodeData) bool {
+	if data == nil || !k.sealsTags(ctx, data.Tags()) {
+		return false
+	}
+	if data.Content != nil {
+		data.Content.Lead = ""
+	}
+	if data.Stats == nil || data.Stats.Lead() == "" {
+		return false
+	}
+	data.Stats.SetLead("")
+	return true
+}
+
+// IsEncrypted reports whether the stored content of id is encrypted.
+func (k *Keg) IsEncrypted(ctx context.Context, id NodeId) (bool, error) {
+	raw, err := k.Repo.ReadContent(ctx, id)
+	if err != nil {
+		return false, err
+	}
+	return IsEncryptedContent(raw), nil
+}
+
+// sealContent encrypts data for the keg's recipients when tags include the
+// encryption tag. Other content, and content that is already encrypted, is
+// returned unchanged.
+func (k *Keg) sealContent(ctx context.Context, tags []string, data []byte) ([]byte, error) {
+	tag := k.EncryptionTag(ctx)
+	if tag == "" || len(data) == 0 || IsEncryptedContent(data) || !slices.Contains(tags, tag) {
+		return data, nil
+	}
+	cfg, err := k.Repo.ReadConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read keg config: %w", err)
+	}
+	if len(cfg.Encryption.Recipients) == 0 {
+		return nil, fmt.Errorf("nodes tagged %q are encrypted but encryption.recipients is empty: %w", tag, ErrInvalid)
+	}
+	recipients, err := age.ParseRecipients(strings.NewReader(strings.Join(cfg.Encryption.Recipients, "\n")))
+	if err != nil {
+		return nil, fmt.Errorf("invalid encryption recipient: %v: %w", err, ErrInvalid)
+	}
+
+	var buf bytes.Buffer
+	aw := armor.NewWriter(&buf)
+	w, err := age.Encrypt(aw, recipients...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to encrypt content: %w", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return nil, fmt.Errorf("unable to encrypt content: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("unable to encrypt content: %w", err)
+	}
+	if err := aw.Close(); err != nil {
+		return nil, fmt.Errorf("unable to encrypt content: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// openContent decrypts encrypted content of id with the keg's identities.
+// Other content is returned unchanged.
+func (k *Keg) openContent(ctx context.Context, id NodeId, data []byte) ([]byte, error) {
+	if !IsEncryptedContent(data) {
+		return data, nil
+	}
+	if k.Identities == nil {
+		return nil, fmt.Errorf("node %s: %w", id.Path(), ErrEncrypted)
+	}
+	identities, err := k.Identities(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("node %s: %w", id.Path(), err)
+	}
+	if len(identities) == 0 {
+		return nil, fmt.Errorf("node %s: %w", id.Path(), ErrEncrypted)
+	}
+	r, err := age.Decrypt(armor.NewReader(bytes.NewReader(data)), identities...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decrypt node %s: %v: %w", id.Path(), err, ErrEncrypted)
+	}
+	plain, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decrypt node %s: %v: %w", id.Path(), err, ErrEncrypted)
+	}
+	return plain, nil
+}
+
+// sealStoredContentLocked encrypts the stored content of id once tags
+// include the encryption tag, along with the history kept for it (see
+// sealHistoryLocked). Content stays encrypted when the tag is removed until
+// it is next written. The caller must hold the node lock.
+func (k *Keg) sealStoredContentLocked(ctx context.Context, id NodeId, tags []string) error {
+	if !k.sealsTags(ctx, tags) {
+		return nil
+	}
+	raw, err := k.Repo.ReadContent(ctx, id)
+	if errors.Is(err, ErrNotExist) || (err == nil && (len(raw) == 0 || IsEncryptedContent(raw))) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("unable to read content: %w", err)
+	}
+	sealed, err := k.sealContent(ctx, tags, raw)
+	if err != nil {
+		return err
+	}
+	if err := k.Repo.WriteContent(ctx, id, sealed); err != nil {
+		return fmt.Errorf("unable to write content: %w", err)
+	}
+	stats, err := k.getStats(ctx, id)
+	if err != nil {
+		return fmt.Errorf("unable to read stats: %w", err)
+	}
+	k.hashSealed(&NodeData{ID: id, Stats: stats}, sealed)
+	if err := k.Repo.WriteStats(ctx, id, stats); err != nil {
+		return fmt.Errorf("unable to write stats: %w", err)
+	}
+	return k.sealHistoryLocked(ctx, id, tags)
+}
+
+// hashSealed records the hash of sealed, the content as stored, on data when
+// it is encrypted. Hashing the ciphertext keeps stats.json from carrying an
+// unsalted digest of the plaintext that would confirm a guess of the content.
+func (k *Keg) hashSealed(data *NodeData, sealed []byte) {
+	if data == nil || !IsEncryptedContent(sealed) {
+		return
+	}
+	hash := k.Runtime.Hasher().Hash(sealed)
+	if data.Content != nil {
+		data.Content.Hash = hash
+	}
+	data.Stats.SetHash(hash, nil)
+}
+
+// sealHistoryLocked protects the history of a node whose content has just
+// been encrypted. Plaintext content versions are encrypted and renamed after
+// the hash of their ciphertext, and snapshots, which cannot be rewritten, are
+// purged. The caller must hold the node lock.
+func (k *Keg) sealHistoryLocked(ctx context.Context, id NodeId, tags []string) error {
+	if files, ok := k.Repo.(RepositoryFiles); ok {
+		versions, err := readVersionIndex(ctx, files, id)
+		if err != nil {
+			return err
+		}
+		// Versions with the same content share a blob, so each blob is
+		// sealed once and every entry naming it is renamed.
+		renamed := map[string]string{}
+		for _, v := range versions {
+			if _, ok := renamed[v.Hash]; ok {
+				continue
+			}
+			name := NodeVersionsDir + "/" + v.Hash
+			data, err := files.ReadFile(ctx, id, name)
+			if errors.Is(err, ErrNotExist) {
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("unable to read content version %d: %w", v.Number, err)
+			}
+			sealed, err := k.sealContent(ctx, tags, data)
+			if err != nil {
+				return err
+			}
+			if bytes.Equal(sealed, data) {
+				continue
+			}
+			hash := k.Runtime.Hasher().Hash(sealed)
+			if err := files.WriteFile(ctx, id, NodeVersionsDir+"/"+hash, sealed); err != nil {
+				return fmt.Errorf("unable to write content version %d: %w", v.Number, err)
+			}
+			if err := files.DeleteFile(ctx, id, name); err != nil && !errors.Is(err, ErrNotExist) {
+				return fmt.Errorf("unable to remove plaintext version %d: %w", v.Number, err)
+			}
+			renamed[v.Hash] = hash
+		}
+		if len(renamed) > 0 {
+			for i, v := range versions {
+				if hash, ok := renamed[v.Hash]; ok {
+					versions[i].Hash = hash
+				}
+			}
+			if err := writeVersionIndex(ctx, files, id, versions); err != nil {
+				return err
+			}
+		}
+	}
+	if purge, ok := repoSnapshotPurge(k.Repo); ok {
+		if err := purge.PurgeSnapshots(ctx, id); err != nil && !errors.Is(err, ErrNotSupported) {
+			return fmt.Errorf("unable to purge snapshots: %w", err)
+		}
+	}
+	return nil
+}
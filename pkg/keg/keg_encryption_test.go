@@ -0,0 +1,156 @@
+package keg_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"filippo.io/age"
+	kegpkg "github.com/jlrickert/tapper/pkg/keg"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncryptedNodeContent(t *testing.T) {
+	t.Parallel()
+	f := NewSandbox(t)
+	ctx := f.Context()
+
+	identity, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	identities := func(context.Context) ([]age.Identity, error) {
+		return []age.Identity{identity}, nil
+	}
+
+	repo := kegpkg.NewMemoryRepo(f.Runtime())
+	k := kegpkg.NewKeg(repo, f.Runtime(), kegpkg.WithIdentities(identities))
+	require.NoError(t, k.Init(ctx))
+	require.NoError(t, k.UpdateConfig(ctx, func(cfg *kegpkg.Config) {
+		cfg.Encryption = &kegpkg.EncryptionConfig{Recipients: []string{identity.Recipient().String()}}
+	}))
+	require.Equal(t, kegpkg.DefaultEncryptionTag, k.EncryptionTag(ctx))
+
+	body := "# Diary\n\nA secret entry.\n"
+	id, err := k.Create(ctx, &kegpkg.CreateOptions{Body: []byte(body), Tags: []string{"private"}})
+	require.NoError(t, err)
+
+	raw, err := repo.ReadContent(ctx, id)
+	require.NoError(t, err)
+	require.True(t, kegpkg.IsEncryptedContent(raw))
+	require.NotContains(t, string(raw), "secret")
+
+	content, err := k.GetContent(ctx, id)
+	require.NoError(t, err)
+	require.Equal(t, body, string(content))
+
+	// Stats hash the stored ciphertext, never the plaintext.
+	stats, err := repo.ReadStats(ctx, id)
+	require.NoError(t, err)
+	require.Equal(t, f.Runtime().Hasher().Hash(raw), stats.Hash())
+	require.NotEqual(t, f.Runtime().Hasher().Hash([]byte(body)), stats.Hash())
+
+	// Meta and the dex are kept in the clear.
+	dex, err := k.Dex(ctx)
+	require.NoError(t, err)
+	entry := dex.GetRef(ctx, id)
+	require.NotNil(t, entry)
+	require.Equal(t, "Diary", entry.Title)
+
+	// Saving unchanged content keeps the stored ciphertext.
+	require.NoError(t, k.SetContent(ctx, id, content))
+	again, err := repo.ReadContent(ctx, id)
+	require.NoError(t, err)
+	require.Equal(t, raw, again)
+
+	locked := kegpkg.NewKeg(repo, f.Runtime())
+	_, err = locked.GetContent(ctx, id)
+	require.True(t, errors.Is(err, kegpkg.ErrEncrypted), "got %v", err)
+
+	// Tagging an existing node encrypts its content.
+	plain, err := k.Create(ctx, &kegpkg.CreateOptions{Body: []byte("# Plain\n\nOpen.\n")})
+	require.NoError(t, err)
+	encrypted, err := k.IsEncrypted(ctx, plain)
+	require.NoError(t, err)
+	require.False(t, encrypted)
+	require.NoError(t, k.UpdateMeta(ctx, plain, func(m *kegpkg.NodeMeta) { m.AddTag("private") }))
+	encrypted, err = k.IsEncrypted(ctx, plain)
+	require.NoError(t, err)
+	require.True(t, encrypted)
+	raw, err = repo.ReadContent(ctx, plain)
+	require.NoError(t, err)
+	stats, err = repo.ReadStats(ctx, plain)
+	require.NoError(t, err)
+	require.Equal(t, f.Runtime().Hasher().Hash(raw), stats.Hash())
+
+	// Indexing agrees with the recorded hash and leaves it alone.
+	require.NoError(t, k.Index(ctx, kegpkg.IndexOptions{}))
+	indexed, err := repo.ReadStats(ctx, plain)
+	require.NoError(t, err)
+	require.Equal(t, stats.Hash(), indexed.Hash())
+}
+
+func TestEncryptedNodeHistory(t *testing.T) {
+	t.Parallel()
+	f := NewSandbox(t)
+	ctx := f.Context()
+
+	identity, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	identities := func(context.Context) ([]age.Identity, error) {
+		return []age.Identity{identity}, nil
+	}
+
+	repo := kegpkg.NewMemoryRepo(f.Runtime())
+	k := kegpkg.NewKeg(repo, f.Runtime(), kegpkg.WithIdentities(identities))
+	require.NoError(t, k.Init(ctx))
+	require.NoError(t, k.UpdateConfig(ctx, func(cfg *kegpkg.Config) {
+		cfg.Encryption = &kegpkg.EncryptionConfig{Recipients: []string{identity.Recipient().String()}}
+	}))
+
+	first := "# Diary\n\nA secret entry.\n\n![map](images/map.png)\n"
+	id, err := k.Create(ctx, &kegpkg.CreateOptions{Body: []byte(first)})
+	require.NoError(t, err)
+	require.NoError(t, repo.WriteImage(ctx, id, "map.png", []byte("png")))
+	require.NoError(t, repo.WriteImage(ctx, id, "unused.png", []byte("png")))
+	require.NoError(t, k.SetContent(ctx, id, []byte(first+"\nMore secrets.\n")))
+	_, err = k.AppendSnapshot(ctx, id, "before tagging")
+	require.NoError(t, err)
+
+	require.NoError(t, k.UpdateMeta(ctx, id, func(m *kegpkg.NodeMeta) { m.AddTag("private") }))
+
+	// Versions recorded in plaintext are encrypted, snapshots are dropped
+	// and the lead is no longer kept in the clear.
+	versions, err := k.ListVersions(ctx, id)
+	require.NoError(t, err)
+	require.Len(t, versions, 1)
+	blob, err := repo.ReadFile(ctx, id, kegpkg.NodeVersionsDir+"/"+versions[0].Hash)
+	require.NoError(t, err)
+	require.True(t, kegpkg.IsEncryptedContent(blob))
+	require.Equal(t, f.Runtime().Hasher().Hash(blob), versions[0].Hash, "versions are named by their ciphertext")
+	_, err = repo.ReadFile(ctx, id, kegpkg.NodeVersionsDir+"/"+f.Runtime().Hasher().Hash([]byte(first)))
+	require.True(t, errors.Is(err, kegpkg.ErrNotExist), "plaintext-named blob is removed, got %v", err)
+	_, data, err := k.ReadVersion(ctx, id, "1")
+	require.NoError(t, err)
+	require.Equal(t, first, string(data))
+
+	snapshots, err := k.ListSnapshots(ctx, id)
+	require.NoError(t, err)
+	require.Empty(t, snapshots)
+
+	stats, err := repo.ReadStats(ctx, id)
+	require.NoError(t, err)
+	require.Empty(t, stats.Lead())
+	require.Equal(t, "Diary", stats.Title())
+
+	// Media references are read from the decrypted content, and the images
+	// of a node that cannot be decrypted are never reported unused.
+	unused, err := k.UnreferencedMedia(ctx)
+	require.NoError(t, err)
+	require.Len(t, unused, 1)
+	require.Equal(t, "unused.png", unused[0].Name)
+	locked := kegpkg.NewKeg(repo, f.Runtime())
+	unused, err = locked.UnreferencedMedia(ctx)
+	require.NoError(t, err)
+	require.Empty(t, unused)
+	_, _, err = locked.ReadVersion(ctx, id, "1")
+	require.True(t, errors.Is(err, kegpkg.ErrEncrypted), "got %v", err)
+}
@@ -0,0 +1,155 @@
+package keg_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jlrickert/tapper/pkg/keg"
+	"github.com/jlrickert/tapper/pkg/kegtest"
+	"github.com/stretchr/testify/require"
+)
+
+// faultyKeg builds an indexed keg of n nodes and returns a Keg over a
+// FaultyRepo wrapping it, along with the wrapped repository.
+func faultyKeg(t *testing.T, ctx context.Context, n int) (*keg.Keg, *kegtest.FaultyRepo, keg.Repository) {
+	t.Helper()
+	rt := testRuntime(t)
+	inner := keg.NewMemoryRepo(rt)
+	fx := kegtest.NewKegFixture().Title("Faults")
+	for i := 1; i <= n; i++ {
+		fx.Node(i, "Node", "Body.").Tag("demo")
+	}
+	fx.MustBuild(t, ctx, inner, rt)
+	repo := kegtest.NewFaultyRepo(inner)
+	return keg.NewKeg(repo, rt), repo, inner
+}
+
+func TestKeg_CreateFailedWriteLeavesNoNode(t *testing.T) {
+	t.Parallel()
+	ctx := NewSandbox(t).Context()
+	k, repo, inner := faultyKeg(t, ctx, 2)
+	repo.Inject(kegtest.Fault{Op: "WriteNode", Times: 1})
+
+	_, err := k.Create(ctx, &keg.CreateOptions{Title: "Lost"})
+	require.ErrorIs(t, err, kegtest.ErrInjected)
+	ids, err := inner.ListNodes(ctx)
+	require.NoError(t, err)
+	require.Len(t, ids, 3, "only the fixture nodes and the zero node exist")
+
+	id, err := k.Create(ctx, &keg.CreateOptions{Title: "Kept"})
+	require.NoError(t, err)
+	require.Equal(t, keg.NodeId{ID: 3}, id, "the failed create does not burn an id")
+}
+
+func TestKeg_TruncatedIndexWriteIsRebuilt(t *testing.T) {
+	t.Parallel()
+	ctx := NewSandbox(t).Context()
+	k, repo, inner := faultyKeg(t, ctx, 12)
+	repo.Inject(kegtest.Fault{Op: "WriteIndex", Name: "nodes.tsv", Truncate: 60, Times: 1})
+
+	err := k.Index(ctx, keg.IndexOptions{Rebuild: true})
+	require.ErrorIs(t, err, kegtest.ErrInjected)
+	data, err := inner.GetIndex(ctx, "nodes.tsv")
+	require.NoError(t, err)
+	require.Len(t, data, 60, "the fault left a cut nodes.tsv behind")
+
+	// A keg opening the damaged dex rebuilds it instead of serving it.
+	dex, err := keg.NewKeg(inner, testRuntime(t)).Dex(ctx)
+	require.NoError(t, err)
+	report, err := dex.Verify(ctx, inner)
+	require.NoError(t, err)
+	require.True(t, report.OK(), report.String())
+}
+
+func TestKeg_DexLoadRetriesAfterReadError(t *testing.T) {
+	t.Parallel()
+	ctx := NewSandbox(t).Context()
+	k, repo, _ := faultyKeg(t, ctx, 3)
+	repo.Inject(kegtest.Fault{Op: "GetIndex", Name: "nodes.tsv", Times: 1})
+
+	_, err := k.Dex(ctx)
+	require.ErrorIs(t, err, kegtest.ErrInjected)
+
+	dex, err := k.Dex(ctx)
+	require.NoError(t, err, "a failed load is not cached")
+	require.Len(t, dex.Nodes(ctx), 4)
+}
+
+func TestKeg_FailedConfigWriteKeepsConfig(t *testing.T) {
+	t.Parallel()
+	ctx := NewSandbox(t).Context()
+	k, repo, inner := faultyKeg(t, ctx, 1)
+	repo.Inject(kegtest.Fault{Op: "WriteConfig"})
+
+	err := k.UpdateConfig(ctx, func(cfg *keg.Config) { cfg.Summary = "changed" })
+	require.ErrorIs(t, err, kegtest.ErrInjected)
+	cfg, err := inner.ReadConfig(ctx)
+	require.NoError(t, err)
+	require.NotEqual(t, "changed", cfg.Summary)
+
+	// Index still writes the dex; only the timestamp update fails.
+	err = k.Index(ctx, keg.IndexOptions{Rebuild: true})
+	require.ErrorIs(t, err, kegtest.ErrInjected)
+	repo.Heal()
+	dex, err := keg.NewKeg(inner, testRuntime(t)).Dex(ctx)
+	require.NoError(t, err)
+	report, err := dex.Verify(ctx, inner)
+	require.NoError(t, err)
+	require.True(t, report.OK(), report.String())
+}
+
+func TestKeg_SlowBackendCancelsIndex(t *testing.T) {
+	t.Parallel()
+	ctx := NewSandbox(t).Context()
+	k, repo, inner := faultyKeg(t, ctx, 3)
+	before, err := inner.GetIndex(ctx, "nodes.tsv")
+	require.NoError(t, err)
+	_, err = k.Create(ctx, &keg.CreateOptions{Title: "New"})
+	require.NoError(t, err)
+
+	repo.Inject(kegtest.Fault{Op: "ReadContent", Latency: time.Second})
+	timeout, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	err = k.Index(timeout, keg.IndexOptions{Rebuild: true})
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	after, err := inner.GetIndex(ctx, "nodes.tsv")
+	require.NoError(t, err)
+	require.NotEqual(t, string(before), string(after), "create indexed the new node")
+	repo.Heal()
+	require.NoError(t, k.Index(ctx, keg.IndexOptions{Rebuild: true}))
+	dex, err := keg.NewKeg(inner, testRuntime(t)).Dex(ctx)
+	require.NoError(t, err)
+	require.Len(t, dex.Nodes(ctx), 5)
+}
+
+func TestKeg_FailedMergeRollsBack(t *testing.T) {
+	t.Parallel()
+	ctx := NewSandbox(t).Context()
+	k, repo, inner := faultyKeg(t, ctx, 3)
+	src, dst := keg.NodeId{ID: 2}, keg.NodeId{ID: 1}
+	require.NoError(t, k.SetContent(ctx, keg.NodeId{ID: 3}, []byte("# Node\n\nSee [two](../2).\n")))
+	before, err := inner.ReadContent(ctx, dst)
+	require.NoError(t, err)
+	repo.Inject(kegtest.Fault{Op: "DeleteNode", Times: 1})
+
+	err = k.Merge(ctx, src, dst, keg.MergeOptions{})
+	require.ErrorIs(t, err, kegtest.ErrInjected)
+
+	after, err := inner.ReadContent(ctx, dst)
+	require.NoError(t, err)
+	require.Equal(t, string(before), string(after), "the destination is restored")
+	linker, err := inner.ReadContent(ctx, keg.NodeId{ID: 3})
+	require.NoError(t, err)
+	require.Contains(t, string(linker), "[two](../2)", "rewritten links are restored")
+	exists, err := inner.HasNode(ctx, src)
+	require.NoError(t, err)
+	require.True(t, exists)
+
+	dex, err := k.Dex(ctx)
+	require.NoError(t, err)
+	backlinks, ok := dex.Backlinks(ctx, src)
+	require.True(t, ok)
+	require.Len(t, backlinks, 1)
+}
@@ -0,0 +1,118 @@
+package keg
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Attachment is a node image or file attachment with its metadata.
+type Attachment struct {
+	Kind AssetKind
+	Name string
+	Meta *ItemMeta
+}
+
+// ListAttachments returns the images and then the file attachments of a
+// node. With withMeta set each entry carries its stored metadata, or
+// metadata derived from the content when none has been recorded.
+func (k *Keg) ListAttachments(ctx context.Context, id NodeId, withMeta bool) ([]Attachment, error) {
+	if err := k.checkKegExists(ctx); err != nil {
+		return nil, fmt.Errorf("failed to list attachments: %w", err)
+	}
+	var out []Attachment
+	images, err := repoListImages(ctx, k.Repo, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list images of %s: %w", id.Path(), err)
+	}
+	for _, name := range images {
+		out = append(out, Attachment{Kind: AssetKindImage, Name: name})
+	}
+	files, err := repoListFiles(ctx, k.Repo, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list attachments of %s: %w", id.Path(), err)
+	}
+	for _, name := range files {
+		out = append(out, Attachment{Kind: AssetKindItem, Name: name})
+	}
+	if !withMeta {
+		return out, nil
+	}
+	for i := range out {
+		meta, err := k.ItemMeta(ctx, id, out[i].Kind, out[i].Name)
+		if err != nil {
+			return nil, err
+		}
+		out[i].Meta = meta
+	}
+	return out, nil
+}
+
+// ItemMeta returns the stored metadata of a node item, deriving it from the
+// item content when the repository has none recorded.
+func (k *Keg) ItemMeta(ctx context.Context, id NodeId, kind AssetKind, name string) (*ItemMeta, error) {
+	if withMeta, ok := repoItemMeta(k.Repo); ok {
+		meta, err := withMeta.ReadItemMeta(ctx, id, kind, name)
+		if err == nil {
+			return meta, nil
+		}
+		if !errors.Is(err, ErrNotExist) {
+			return nil, fmt.Errorf("failed to read metadata of %s: %w", name, err)
+		}
+	}
+	data, err := k.readItem(ctx, id, kind, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	return NewItemMeta(name, data), nil
+}
+
+// RecordItemMeta stores metadata derived from data for a node item. Alt text
+// and source URL come from desc, falling back to previously stored values.
+// Repositories without item metadata support ignore the call.
+func (k *Keg) RecordItemMeta(ctx context.Context, id NodeId, kind AssetKind, name string, data []byte, desc *ItemMeta) error {
+	withMeta, ok := repoItemMeta(k.Repo)
+	if !ok {
+		return nil
+	}
+	meta := NewItemMeta(name, data)
+	meta.Merge(desc)
+	if prev, err := withMeta.ReadItemMeta(ctx, id, kind, name); err == nil {
+		meta.Merge(prev)
+	}
+	if err := withMeta.WriteItemMeta(ctx, id, kind, name, meta); err != nil {
+		return fmt.Errorf("failed to write metadata of %s: %w", name, err)
+	}
+	return nil
+}
+
+// OpenItem opens a node image or file attachment for streaming reads.
+// Repositories without streaming support are read into memory.
+func (k *Keg) OpenItem(ctx context.Context, id NodeId, kind AssetKind, name string) (io.ReadCloser, error) {
+	if streams, ok := repoStreams(k.Repo); ok {
+		return streams.OpenItem(ctx, id, kind, name)
+	}
+	data, err := k.readItem(ctx, id, kind, name)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (k *Keg) readItem(ctx context.Context, id NodeId, kind AssetKind, name string) ([]byte, error) {
+	switch kind {
+	case AssetKindImage:
+		if images, ok := k.Repo.(RepositoryImages); ok {
+			return images.ReadImage(ctx, id, name)
+		}
+	case AssetKindItem:
+		if files, ok := k.Repo.(RepositoryFiles); ok {
+			return files.ReadFile(ctx, id, name)
+		}
+	default:
+		return nil, fmt.Errorf("unknown asset kind %q", kind)
+	}
+	return nil, fmt.Errorf("%s backend does not store %s assets: %w", k.Repo.Name(), kind, ErrNotSupported)
+}
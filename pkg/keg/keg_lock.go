@@ -0,0 +1,51 @@
+package keg
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// DefaultKegLockTimeout bounds how long WithKegLock waits for another process
+// holding the keg-wide lock when ctx carries no deadline of its own.
+const DefaultKegLockTimeout = 30 * time.Second
+
+// WithKegLock runs fn while holding the keg-wide lock, serializing index
+// rebuilds, imports and other whole-keg writes across processes. Calls nested
+// inside fn reuse the held lock. Repositories without keg lock support run fn
+// directly.
+func (k *Keg) WithKegLock(ctx context.Context, fn func(context.Context) error) error {
+	locker, ok := repoKegLock(k.Repo)
+	if !ok || contextHasKegLock(ctx) {
+		return fn(ctx)
+	}
+	waitCtx := ctx
+	if _, hasDeadline := ctx.Deadline(); !hasDeadline {
+		var cancel context.CancelFunc
+		waitCtx, cancel = context.WithTimeout(ctx, DefaultKegLockTimeout)
+		defer cancel()
+	}
+	// The wait timeout only bounds acquisition; fn runs under the caller's ctx.
+	return locker.WithKegLock(waitCtx, func(context.Context) error {
+		return fn(contextWithKegLock(ctx))
+	})
+}
+
+// KegLockOwner returns the holder of the keg-wide lock, or nil when the lock
+// is free or the repository has no keg lock.
+func (k *Keg) KegLockOwner(ctx context.Context) (*LockOwner, error) {
+	locker, ok := repoKegLock(k.Repo)
+	if !ok {
+		return nil, nil
+	}
+	return locker.KegLockOwner(ctx)
+}
+
+// BreakKegLock forcibly removes the keg-wide lock.
+func (k *Keg) BreakKegLock(ctx context.Context) error {
+	locker, ok := repoKegLock(k.Repo)
+	if !ok {
+		return fmt.Errorf("keg lock: %w", ErrNotSupported)
+	}
+	return locker.BreakKegLock(ctx)
+}
@@ -0,0 +1,35 @@
+package keg
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// LevelTrace is the slog level of per-operation trace logs, one step below
+// slog.LevelDebug.
+const LevelTrace = slog.LevelDebug - 4
+
+// logOp logs the start of a keg operation at trace level and starts its
+// telemetry span. It returns the span's context and a func that logs the
+// outcome and duration at debug level and ends the span:
+//
+//	ctx, done := k.logOp(ctx, "remove", "node", id.Path())
+//	defer done(&err)
+func (k *Keg) logOp(ctx context.Context, op string, args ...any) (context.Context, func(*error)) {
+	lg := k.Runtime.Logger()
+	start := time.Now()
+	lg.Log(ctx, LevelTrace, "keg operation started", append([]any{"op", op}, args...)...)
+	ctx, span := startOpSpan(ctx, op, args...)
+	return ctx, func(errp *error) {
+		elapsed := time.Since(start)
+		attrs := append([]any{"op", op, "elapsed", elapsed}, args...)
+		var err error
+		if errp != nil && *errp != nil {
+			err = *errp
+			attrs = append(attrs, "error", err.Error())
+		}
+		lg.Log(ctx, slog.LevelDebug, "keg operation finished", attrs...)
+		endOpSpan(ctx, span, op, elapsed, err)
+	}
+}
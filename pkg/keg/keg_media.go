@@ -0,0 +1,106 @@
+package keg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// MediaItem is an image or file attachment of a specific node.
+type MediaItem struct {
+	Node NodeId
+	Attachment
+}
+
+// UnreferencedMedia cross-references the media links in every node's
+// content against the images and attachments stored on disk, returning the
+// items no node links to. Content versions are never reported, and neither
+// are the items of nodes whose encrypted content cannot be decrypted.
+func (k *Keg) UnreferencedMedia(ctx context.Context) ([]MediaItem, error) {
+	if err := k.checkKegExists(ctx); err != nil {
+		return nil, fmt.Errorf("failed to scan media: %w", err)
+	}
+	ids, err := k.Repo.ListNodes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+
+	referenced := map[MediaItem]bool{}
+	locked := map[NodeId]bool{}
+	format := k.ContentFormat(ctx)
+	for _, id := range ids {
+		raw, err := k.Repo.ReadContent(ctx, id)
+		if errors.Is(err, ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read content of %s: %w", id.Path(), err)
+		}
+		raw, err = k.openContent(ctx, id, raw)
+		if errors.Is(err, ErrEncrypted) {
+			locked[id] = true
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		content, err := ParseContent(k.Runtime, raw, format)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse content of %s: %w", id.Path(), err)
+		}
+		for _, ref := range content.Media {
+			owner := id
+			if ref.Node != nil {
+				owner = *ref.Node
+			}
+			referenced[MediaItem{Node: owner, Attachment: Attachment{Kind: ref.Kind, Name: ref.Name}}] = true
+		}
+	}
+
+	var out []MediaItem
+	for _, id := range ids {
+		if locked[id] {
+			continue
+		}
+		items, err := k.ListAttachments(ctx, id, false)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range items {
+			m := MediaItem{Node: id, Attachment: item}
+			if !referenced[m] {
+				out = append(out, m)
+			}
+		}
+	}
+	return out, nil
+}
+
+// RemoveMedia deletes the given node items. Failures are collected and
+// returned together.
+func (k *Keg) RemoveMedia(ctx context.Context, items []MediaItem) error {
+	var errs []error
+	for _, item := range items {
+		var err error
+		switch item.Kind {
+		case AssetKindImage:
+			images, ok := k.Repo.(RepositoryImages)
+			if !ok {
+				return fmt.Errorf("%s backend does not store images: %w", k.Repo.Name(), ErrNotSupported)
+			}
+			err = images.DeleteImage(ctx, item.Node, item.Name)
+		case AssetKindItem:
+			files, ok := k.Repo.(RepositoryFiles)
+			if !ok {
+				return fmt.Errorf("%s backend does not store attachments: %w", k.Repo.Name(), ErrNotSupported)
+			}
+			err = files.DeleteFile(ctx, item.Node, item.Name)
+		default:
+			err = fmt.Errorf("unknown asset kind %q", item.Kind)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to remove %s from %s: %w", item.Name, item.Node.Path(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
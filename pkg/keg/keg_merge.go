@@ -0,0 +1,384 @@
+package keg
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// MergeOptions controls how Merge combines two nodes.
+type MergeOptions struct {
+	// Heading demotes the source node's title to a level-two heading instead
+	// of separating the merged content with a horizontal rule.
+	Heading bool
+}
+
+// Merge folds src into dst. The source content is appended to dst, the tag
+// sets are unioned, every link pointing at src is rewritten to dst, and src is
+// then archived (or deleted when the repository has no archive). Links carry
+// over with the appended content, and the images and attachments it
+// references are copied into dst, renamed when dst already holds a different
+// item of the same name.
+//
+// Merge holds the keg lock. All inputs are read and validated before
+// anything is written, and a failed write rolls back the nodes already
+// changed, so a failed merge leaves both nodes as they were.
+func (k *Keg) Merge(ctx context.Context, src, dst NodeId, opts MergeOptions) error {
+	if err := k.checkKegExists(ctx); err != nil {
+		return fmt.Errorf("failed to merge nodes: %w", err)
+	}
+
+	src = NodeId{ID: src.ID, Code: src.Code}
+	dst = NodeId{ID: dst.ID, Code: dst.Code}
+	if !src.Valid() || !dst.Valid() {
+		return fmt.Errorf("invalid node id: %w", ErrInvalid)
+	}
+	if src.ID == 0 || dst.ID == 0 {
+		return fmt.Errorf("node 0 cannot be merged: %w", ErrInvalid)
+	}
+	if src.Equals(dst) {
+		return fmt.Errorf("cannot merge node %s into itself: %w", src.Path(), ErrInvalid)
+	}
+	return k.WithKegLock(ctx, func(lockCtx context.Context) error {
+		return k.mergeLocked(lockCtx, src, dst, opts)
+	})
+}
+
+func (k *Keg) mergeLocked(ctx context.Context, src, dst NodeId, opts MergeOptions) error {
+	for _, id := range []NodeId{src, dst} {
+		exists, err := k.Repo.HasNode(ctx, id)
+		if err != nil {
+			return fmt.Errorf("failed to check node existence: %w", err)
+		}
+		if !exists {
+			return fmt.Errorf("node %s not found: %w", id.Path(), ErrNotExist)
+		}
+	}
+	if err := k.checkPolicy(ctx, src, PolicyMerge); err != nil {
+		return err
+	}
+	if err := k.checkPolicy(ctx, dst, PolicyEdit); err != nil {
+		return err
+	}
+
+	srcContent, err := k.GetContent(ctx, src)
+	if err != nil {
+		return fmt.Errorf("failed to read node %s: %w", src.Path(), err)
+	}
+	dstContent, err := k.GetContent(ctx, dst)
+	if err != nil {
+		return fmt.Errorf("failed to read node %s: %w", dst.Path(), err)
+	}
+	srcMeta, err := k.getMeta(ctx, src)
+	if err != nil {
+		return fmt.Errorf("failed to read node %s metadata: %w", src.Path(), err)
+	}
+	tags := srcMeta.Tags()
+	assets, srcContent, err := k.stageMergeAssets(ctx, src, dst, srcContent)
+	if err != nil {
+		return err
+	}
+	merged := mergeContent(dstContent, srcContent, opts.Heading)
+
+	tx := &mergeJournal{k: k, saved: map[NodeId]savedNode{}}
+	if err := tx.save(ctx, dst); err != nil {
+		return err
+	}
+	err = func() error {
+		for _, a := range assets {
+			if err := a.write(ctx, k.Repo, dst); err != nil {
+				return fmt.Errorf("failed to copy %s into %s: %w", a.Name, dst.Path(), err)
+			}
+			tx.assets = append(tx.assets, MediaItem{Node: dst, Attachment: Attachment{Kind: a.Kind, Name: a.As}})
+		}
+		if err := k.SetContent(ctx, dst, merged); err != nil {
+			return fmt.Errorf("failed to write merged content to %s: %w", dst.Path(), err)
+		}
+		if len(tags) > 0 {
+			if err := k.UpdateMeta(ctx, dst, func(m *NodeMeta) {
+				union := append(m.Tags(), tags...)
+				slices.Sort(union)
+				m.SetTags(slices.Compact(union))
+			}); err != nil {
+				return fmt.Errorf("failed to merge tags into %s: %w", dst.Path(), err)
+			}
+		}
+		report, err := k.RepairLinks(ctx, LinkRepair{Renamed: map[NodeId]NodeId{src: dst}, save: tx.save})
+		k.logRepair(report)
+		if err != nil {
+			return err
+		}
+		if archive, ok := repoArchive(k.Repo); ok {
+			err = archive.ArchiveNode(ctx, src)
+		} else {
+			err = k.Repo.DeleteNode(ctx, src)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to retire merged node %s: %w", src.Path(), err)
+		}
+		return nil
+	}()
+	if err != nil {
+		if rbErr := tx.rollback(ctx); rbErr != nil {
+			return errors.Join(err, fmt.Errorf("failed to roll back merge: %w", rbErr))
+		}
+		return err
+	}
+
+	var errs []error
+	dex, err := k.Dex(ctx)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("failed to retrieve dex after merge: %w", err))
+	} else {
+		if err := dex.Remove(ctx, src); err != nil {
+			errs = append(errs, fmt.Errorf("failed to remove %s from dex: %w", src.Path(), err))
+		}
+		if dst.Code == "" {
+			mergedData, err := k.getNode(ctx, dst)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("failed to load merged node %s: %w", dst.Path(), err))
+			} else if err := dex.Add(ctx, mergedData); err != nil {
+				errs = append(errs, fmt.Errorf("failed to add merged node %s to dex: %w", dst.Path(), err))
+			}
+		}
+		if err := dex.Write(ctx, k.Repo); err != nil {
+			errs = append(errs, fmt.Errorf("failed to write dex after merge: %w", err))
+		}
+	}
+
+	if err := k.touchConfigUpdated(ctx, k.Runtime.Clock().Now()); err != nil {
+		errs = append(errs, fmt.Errorf("failed to update config after merge: %w", err))
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	k.publish(ctx, NodeDeleted{EventSource: k.eventSource(), Node: src})
+	return nil
+}
+
+// mergeJournal records the stored files of every node a merge changes so a
+// failed merge can put them back.
+type mergeJournal struct {
+	k      *Keg
+	saved  map[NodeId]savedNode
+	order  []NodeId
+	assets []MediaItem
+}
+
+type savedNode struct {
+	content []byte
+	meta    []byte
+	stats   *NodeStats
+}
+
+// save records the current files of id unless they are already recorded.
+func (tx *mergeJournal) save(ctx context.Context, id NodeId) error {
+	if _, ok := tx.saved[id]; ok {
+		return nil
+	}
+	var s savedNode
+	var err error
+	if s.content, err = tx.k.Repo.ReadContent(ctx, id); err != nil && !errors.Is(err, ErrNotExist) {
+		return fmt.Errorf("failed to read node %s: %w", id.Path(), err)
+	}
+	if s.meta, err = tx.k.Repo.ReadMeta(ctx, id); err != nil && !errors.Is(err, ErrNotExist) {
+		return fmt.Errorf("failed to read node %s metadata: %w", id.Path(), err)
+	}
+	if s.stats, err = tx.k.Repo.ReadStats(ctx, id); err != nil && !errors.Is(err, ErrNotExist) {
+		return fmt.Errorf("failed to read node %s stats: %w", id.Path(), err)
+	}
+	tx.saved[id] = s
+	tx.order = append(tx.order, id)
+	return nil
+}
+
+// rollback restores every recorded node, removes the copied assets and
+// re-indexes the restored nodes.
+func (tx *mergeJournal) rollback(ctx context.Context) error {
+	k := tx.k
+	var errs []error
+	for _, id := range tx.order {
+		s := tx.saved[id]
+		if err := k.withNodeLock(ctx, id, func(lockCtx context.Context) error {
+			return k.Repo.WriteNode(lockCtx, id, s.content, s.meta, s.stats)
+		}); err != nil {
+			errs = append(errs, fmt.Errorf("failed to restore node %s: %w", id.Path(), err))
+		}
+	}
+	if err := k.RemoveMedia(ctx, tx.assets); err != nil {
+		errs = append(errs, err)
+	}
+
+	dex, err := k.Dex(ctx)
+	if err != nil {
+		return errors.Join(append(errs, err)...)
+	}
+	for _, id := range tx.order {
+		if id.Code != "" {
+			continue
+		}
+		if data, err := k.getNode(ctx, id); err == nil {
+			_ = dex.Add(ctx, data)
+		}
+	}
+	if err := dex.Write(ctx, k.Repo); err != nil {
+		errs = append(errs, fmt.Errorf("failed to write dex: %w", err))
+	}
+	return errors.Join(errs...)
+}
+
+// mergeAsset is an image or attachment of the merge source copied into the
+// destination under As.
+type mergeAsset struct {
+	Kind AssetKind
+	Name string
+	As   string
+	Data []byte
+}
+
+func (a mergeAsset) write(ctx context.Context, repo Repository, dst NodeId) error {
+	if a.Kind == AssetKindImage {
+		images, ok := repo.(RepositoryImages)
+		if !ok {
+			return ErrNotSupported
+		}
+		return images.WriteImage(ctx, dst, a.As, a.Data)
+	}
+	files, ok := repo.(RepositoryFiles)
+	if !ok {
+		return ErrNotSupported
+	}
+	return files.WriteFile(ctx, dst, a.As, a.Data)
+}
+
+// stageMergeAssets reads the images and attachments of src that content
+// references by relative path and decides the name each gets in dst. It
+// returns the items dst does not hold yet and content with references to
+// renamed items rewritten. Items dst already holds with the same bytes are
+// shared; missing items are left for the dangling reference to report.
+func (k *Keg) stageMergeAssets(ctx context.Context, src, dst NodeId, content []byte) ([]mergeAsset, []byte, error) {
+	parsed, err := ParseContent(k.Runtime, content, k.ContentFormat(ctx))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse node %s: %w", src.Path(), err)
+	}
+
+	taken := map[AssetKind]map[string]bool{}
+	var assets []mergeAsset
+	for _, ref := range parsed.Media {
+		if ref.Node != nil && !ref.Node.Equals(src) {
+			continue
+		}
+		data, err := readMergeAsset(ctx, k.Repo, src, ref.Kind, ref.Name)
+		if errors.Is(err, ErrNotExist) || errors.Is(err, ErrNotSupported) {
+			continue
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read %s of node %s: %w", ref.Name, src.Path(), err)
+		}
+		if taken[ref.Kind] == nil {
+			names, err := k.assetNames(ctx, dst, ref.Kind)
+			if err != nil {
+				return nil, nil, err
+			}
+			taken[ref.Kind] = names
+		}
+
+		as := ref.Name
+		if taken[ref.Kind][as] {
+			existing, err := readMergeAsset(ctx, k.Repo, dst, ref.Kind, as)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to read %s of node %s: %w", as, dst.Path(), err)
+			}
+			if bytes.Equal(existing, data) {
+				continue
+			}
+			as = freeAssetName(as, taken[ref.Kind])
+			content = renameMediaRefs(content, ref.Kind, ref.Name, as)
+		}
+		taken[ref.Kind][as] = true
+		assets = append(assets, mergeAsset{Kind: ref.Kind, Name: ref.Name, As: as, Data: data})
+	}
+	return assets, content, nil
+}
+
+func (k *Keg) assetNames(ctx context.Context, id NodeId, kind AssetKind) (map[string]bool, error) {
+	list := repoListFiles
+	if kind == AssetKindImage {
+		list = repoListImages
+	}
+	names, err := list(ctx, k.Repo, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list assets of %s: %w", id.Path(), err)
+	}
+	out := make(map[string]bool, len(names))
+	for _, name := range names {
+		out[name] = true
+	}
+	return out, nil
+}
+
+func readMergeAsset(ctx context.Context, repo Repository, id NodeId, kind AssetKind, name string) ([]byte, error) {
+	if kind == AssetKindImage {
+		images, ok := repo.(RepositoryImages)
+		if !ok {
+			return nil, ErrNotSupported
+		}
+		return images.ReadImage(ctx, id, name)
+	}
+	files, ok := repo.(RepositoryFiles)
+	if !ok {
+		return nil, ErrNotSupported
+	}
+	return files.ReadFile(ctx, id, name)
+}
+
+// freeAssetName returns name with the lowest numeric suffix ("scan-2.png")
+// that is not taken.
+func freeAssetName(name string, taken map[string]bool) string {
+	ext := path.Ext(name)
+	stem := strings.TrimSuffix(name, ext)
+	for i := 2; ; i++ {
+		candidate := fmt.Sprintf("%s-%d%s", stem, i, ext)
+		if !taken[candidate] {
+			return candidate
+		}
+	}
+}
+
+// renameMediaRefs points relative references to the item old of the given
+// kind at renamed instead. References through another node are untouched.
+func renameMediaRefs(content []byte, kind AssetKind, old, renamed string) []byte {
+	dir := NodeAttachmentsDir
+	if kind == AssetKindImage {
+		dir = NodeImagesDir
+	}
+	names := []string{regexp.QuoteMeta(old)}
+	if escaped := url.PathEscape(old); escaped != old {
+		names = append(names, regexp.QuoteMeta(escaped))
+	}
+	re := regexp.MustCompile(`(?m)(^|[^/])((?:\./)?` + dir + `/)(?:` + strings.Join(names, "|") + `)([?#\s)"'>]|$)`)
+	return re.ReplaceAll(content, []byte("${1}${2}"+strings.ReplaceAll(url.PathEscape(renamed), "$", "$$")+"${3}"))
+}
+
+func mergeContent(dst, src []byte, heading bool) []byte {
+	src = bytes.TrimSpace(src)
+	var b bytes.Buffer
+	b.Write(bytes.TrimRight(dst, "\n"))
+	if heading {
+		b.WriteString("\n\n")
+		if bytes.HasPrefix(src, []byte("# ")) {
+			b.WriteString("#")
+		}
+	} else {
+		b.WriteString("\n\n---\n\n")
+	}
+	b.Write(src)
+	b.WriteByte('\n')
+	return b.Bytes()
+}
@@ -0,0 +1,218 @@
+package keg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// PolicyConfig holds per-keg write rules. Keg checks them before it changes,
+// moves, merges, archives or removes a node, and CheckAttachment checks them
+// before an image or file is stored.
+type PolicyConfig struct {
+	// Immutable lists node IDs and ranges, such as "0" or "100-199", that
+	// cannot be changed, moved, merged, archived or removed.
+	Immutable []string `yaml:"immutable,omitempty"`
+
+	// Tags are rules for nodes whose tags match a tag expression.
+	Tags []TagPolicy `yaml:"tags,omitempty"`
+
+	// MaxAttachmentSize rejects images and file attachments larger than
+	// this, for example "25MB". Empty means no limit.
+	MaxAttachmentSize string `yaml:"maxAttachmentSize,omitempty"`
+}
+
+// TagPolicy protects the nodes whose tags match Match.
+type TagPolicy struct {
+	// Match is a tag expression such as "published" or "draft && !wip".
+	Match string `yaml:"match"`
+
+	// Immutable rejects changes to matching nodes.
+	Immutable bool `yaml:"immutable,omitempty"`
+
+	// Confirm allows changes to matching nodes only under a context from
+	// WithPolicyConfirmed.
+	Confirm bool `yaml:"confirm,omitempty"`
+}
+
+// Policy actions reported in a PolicyViolationError.
+const (
+	PolicyEdit    = "edit"
+	PolicyMove    = "move"
+	PolicyMerge   = "merge"
+	PolicyArchive = "archive"
+	PolicyRemove  = "remove"
+	PolicyAttach  = "attach to"
+)
+
+// PolicyViolationError reports a change the keg policy does not allow. It
+// unwraps to ErrPolicy.
+type PolicyViolationError struct {
+	Node NodeId
+	// Action is what was attempted, one of the Policy actions.
+	Action string
+	// Rule describes the rule that applies, for example
+	// `protects nodes tagged "published"`.
+	Rule string
+	// NeedsConfirmation is set when the change is allowed once confirmed
+	// with WithPolicyConfirmed.
+	NeedsConfirmation bool
+}
+
+func (e *PolicyViolationError) Error() string {
+	if e.NeedsConfirmation {
+		return fmt.Sprintf("%s node %s needs confirmation: keg policy %s", e.Action, e.Node.Path(), e.Rule)
+	}
+	return fmt.Sprintf("cannot %s node %s: keg policy %s", e.Action, e.Node.Path(), e.Rule)
+}
+
+func (e *PolicyViolationError) Unwrap() error { return ErrPolicy }
+
+type policyConfirmedKey struct{}
+
+// WithPolicyConfirmed returns a context under which changes to nodes that
+// a confirm tag rule protects are allowed. Immutable nodes stay immutable.
+func WithPolicyConfirmed(ctx context.Context) context.Context {
+	return context.WithValue(ctx, policyConfirmedKey{}, true)
+}
+
+func policyConfirmed(ctx context.Context) bool {
+	confirmed, _ := ctx.Value(policyConfirmedKey{}).(bool)
+	return confirmed
+}
+
+type policyExemptKey struct{}
+
+// policyExempt marks writes the keg makes to keep itself consistent, such as
+// link repair after a move, which the policy does not apply to.
+func policyExempt(ctx context.Context) context.Context {
+	return context.WithValue(ctx, policyExemptKey{}, true)
+}
+
+// policy returns the keg policy, or nil when the config sets none or cannot
+// be read.
+func (k *Keg) policy(ctx context.Context) *PolicyConfig {
+	cfg, err := k.Repo.ReadConfig(ctx)
+	if err != nil || cfg == nil {
+		return nil
+	}
+	return cfg.Policy
+}
+
+// checkPolicy fails with a *PolicyViolationError when the keg policy does
+// not allow action on id. Tag rules match the tags the node has before the
+// change.
+func (k *Keg) checkPolicy(ctx context.Context, id NodeId, action string) error {
+	if exempt, _ := ctx.Value(policyExemptKey{}).(bool); exempt {
+		return nil
+	}
+	p := k.policy(ctx)
+	if p == nil {
+		return nil
+	}
+	for _, raw := range p.Immutable {
+		ok, err := nodeInRange(raw, id)
+		if err != nil {
+			return err
+		}
+		if ok {
+			return &PolicyViolationError{Node: id, Action: action, Rule: fmt.Sprintf("marks %s immutable", strconv.Quote(raw))}
+		}
+	}
+	if len(p.Tags) == 0 {
+		return nil
+	}
+
+	var tags []string
+	meta, err := k.getMeta(ctx, id)
+	if err != nil && !errors.Is(err, ErrNotExist) {
+		return fmt.Errorf("failed to read node %s metadata: %w", id.Path(), err)
+	}
+	if meta != nil {
+		tags = meta.Tags()
+	}
+	for _, rule := range p.Tags {
+		if !rule.Immutable && !rule.Confirm {
+			continue
+		}
+		ok, err := tagsMatch(rule.Match, id, tags)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		if rule.Immutable {
+			return &PolicyViolationError{Node: id, Action: action, Rule: fmt.Sprintf("marks nodes tagged %q immutable", rule.Match)}
+		}
+		if !policyConfirmed(ctx) {
+			return &PolicyViolationError{Node: id, Action: action, Rule: fmt.Sprintf("protects nodes tagged %q", rule.Match), NeedsConfirmation: true}
+		}
+	}
+	return nil
+}
+
+// CheckAttachment fails with a *PolicyViolationError when the keg policy
+// does not allow storing an image or file attachment of size bytes on id.
+// Callers that write assets through the repository call it first.
+func (k *Keg) CheckAttachment(ctx context.Context, id NodeId, size int64) error {
+	if err := k.checkPolicy(ctx, id, PolicyAttach); err != nil {
+		return err
+	}
+	p := k.policy(ctx)
+	if p == nil || strings.TrimSpace(p.MaxAttachmentSize) == "" {
+		return nil
+	}
+	limit, err := ParseByteSize(p.MaxAttachmentSize)
+	if err != nil {
+		return fmt.Errorf("invalid policy.maxAttachmentSize in keg config: %w", err)
+	}
+	if size > limit {
+		return &PolicyViolationError{
+			Node:   id,
+			Action: PolicyAttach,
+			Rule:   fmt.Sprintf("limits attachments to %s (got %s)", FormatByteSize(limit), FormatByteSize(size)),
+		}
+	}
+	return nil
+}
+
+// nodeInRange reports whether id falls in raw, a node ID such as "0" or an
+// inclusive range such as "100-199".
+func nodeInRange(raw string, id NodeId) (bool, error) {
+	lo, hi, isRange := strings.Cut(strings.TrimSpace(raw), "-")
+	first, err := strconv.Atoi(strings.TrimSpace(lo))
+	if err != nil || first < 0 {
+		return false, fmt.Errorf("invalid policy.immutable entry %q in keg config: %w", raw, ErrInvalid)
+	}
+	last := first
+	if isRange {
+		last, err = strconv.Atoi(strings.TrimSpace(hi))
+		if err != nil || last < first {
+			return false, fmt.Errorf("invalid policy.immutable entry %q in keg config: %w", raw, ErrInvalid)
+		}
+	}
+	return id.ID >= first && id.ID <= last, nil
+}
+
+// tagsMatch reports whether a node with tags satisfies the tag expression
+// raw.
+func tagsMatch(raw string, id NodeId, tags []string) (bool, error) {
+	expr, err := ParseTagExpression(raw)
+	if err != nil {
+		return false, fmt.Errorf("invalid policy tag rule %q in keg config: %v: %w", raw, err, ErrInvalid)
+	}
+	path := id.Path()
+	result := EvaluateTagExpression(expr, map[string]struct{}{path: {}}, func(tag string) map[string]struct{} {
+		for _, t := range tags {
+			if t == tag {
+				return map[string]struct{}{path: {}}
+			}
+		}
+		return nil
+	})
+	_, ok := result[path]
+	return ok, nil
+}
@@ -0,0 +1,81 @@
+package keg_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jlrickert/tapper/pkg/keg"
+	"github.com/jlrickert/tapper/pkg/kegtest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeg_PolicyProtectsNodes(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t)
+	ctx, rt := sb.Context(), sb.Runtime()
+	repo := keg.NewMemoryRepo(rt)
+	k := kegtest.NewKegFixture().
+		Node(1, "Draft", "Body.").Tag("draft").
+		Node(2, "Post", "Body.").Tag("published").
+		Node(3, "Frozen", "See [draft](../1).").Tag("frozen").
+		MustBuild(t, ctx, repo, rt)
+	require.NoError(t, k.UpdateConfig(ctx, func(cfg *keg.Config) {
+		cfg.Policy = &keg.PolicyConfig{
+			Immutable: []string{"0"},
+			Tags: []keg.TagPolicy{
+				{Match: "published", Confirm: true},
+				{Match: "frozen", Immutable: true},
+			},
+		}
+	}))
+
+	var policyErr *keg.PolicyViolationError
+	err := k.SetContent(ctx, keg.NodeId{ID: 0}, []byte("# Changed\n"))
+	require.ErrorIs(t, err, keg.ErrPolicy)
+	require.ErrorIs(t, err, keg.ErrPermission)
+	require.True(t, errors.As(err, &policyErr))
+	require.False(t, policyErr.NeedsConfirmation)
+	require.Equal(t, `cannot edit node 0: keg policy marks "0" immutable`, err.Error())
+
+	err = k.SetContent(ctx, keg.NodeId{ID: 2}, []byte("# Changed\n"))
+	require.True(t, errors.As(err, &policyErr))
+	require.True(t, policyErr.NeedsConfirmation)
+	require.Equal(t, `edit node 2 needs confirmation: keg policy protects nodes tagged "published"`, err.Error())
+	_, err = k.Remove(ctx, keg.NodeId{ID: 2})
+	require.ErrorIs(t, err, keg.ErrPolicy)
+	require.NoError(t, k.SetContent(keg.WithPolicyConfirmed(ctx), keg.NodeId{ID: 2}, []byte("# Changed\n")))
+
+	require.ErrorIs(t, k.UpdateMeta(keg.WithPolicyConfirmed(ctx), keg.NodeId{ID: 3}, func(m *keg.NodeMeta) {}), keg.ErrPolicy)
+	_, err = k.Move(ctx, keg.NodeId{ID: 3}, keg.NodeId{ID: 30})
+	require.ErrorIs(t, err, keg.ErrPolicy)
+
+	// Link repair still rewrites immutable nodes that linked to a removed node.
+	require.NoError(t, k.SetContent(ctx, keg.NodeId{ID: 1}, []byte("# Changed\n")))
+	_, err = k.Remove(ctx, keg.NodeId{ID: 1})
+	require.NoError(t, err)
+	raw, err := repo.ReadContent(ctx, keg.NodeId{ID: 3})
+	require.NoError(t, err)
+	require.Contains(t, string(raw), "[draft](../0)")
+}
+
+func TestKeg_CheckAttachment(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t)
+	ctx, rt := sb.Context(), sb.Runtime()
+	repo := keg.NewMemoryRepo(rt)
+	k := kegtest.NewKegFixture().Node(1, "One", "Body.").MustBuild(t, ctx, repo, rt)
+
+	require.NoError(t, k.CheckAttachment(ctx, keg.NodeId{ID: 1}, 4<<20))
+	require.NoError(t, k.UpdateConfig(ctx, func(cfg *keg.Config) {
+		cfg.Policy = &keg.PolicyConfig{MaxAttachmentSize: "2MB"}
+	}))
+	require.NoError(t, k.CheckAttachment(ctx, keg.NodeId{ID: 1}, 2<<20))
+	err := k.CheckAttachment(ctx, keg.NodeId{ID: 1}, 4<<20)
+	require.ErrorIs(t, err, keg.ErrPolicy)
+	require.Equal(t, "cannot attach to node 1: keg policy limits attachments to 2.0MB (got 4.0MB)", err.Error())
+
+	require.NoError(t, k.UpdateConfig(ctx, func(cfg *keg.Config) {
+		cfg.Policy = &keg.PolicyConfig{Immutable: []string{"x-2"}}
+	}))
+	require.ErrorIs(t, k.CheckAttachment(ctx, keg.NodeId{ID: 1}, 1), keg.ErrInvalid)
+}
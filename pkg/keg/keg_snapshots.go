@@ -88,6 +88,9 @@ func (k *Keg) RestoreSnapshot(ctx context.Context, id NodeId, rev RevisionID) er
 	if !ok {
 		return ErrNotSupported
 	}
+	if err := k.checkPolicy(ctx, id, PolicyEdit); err != nil {
+		return err
+	}
 	if err := snapshots.RestoreSnapshot(ctx, id, rev, true); err != nil {
 		return err
 	}
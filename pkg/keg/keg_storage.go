@@ -0,0 +1,89 @@
+package keg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// NodeStorage is the storage used by a node, split by type. Content counts
+// the primary content and metadata files.
+type NodeStorage struct {
+	ID          NodeId
+	Content     int64
+	Images      int64
+	Attachments int64
+}
+
+// Total returns the combined size of all types.
+func (s NodeStorage) Total() int64 {
+	return s.Content + s.Images + s.Attachments
+}
+
+// StorageReport is the storage used by every node of a keg.
+type StorageReport struct {
+	Nodes []NodeStorage
+}
+
+// Totals sums the storage of all nodes. The ID of the result is zero.
+func (r *StorageReport) Totals() NodeStorage {
+	var sum NodeStorage
+	if r == nil {
+		return sum
+	}
+	for _, n := range r.Nodes {
+		sum.Content += n.Content
+		sum.Images += n.Images
+		sum.Attachments += n.Attachments
+	}
+	return sum
+}
+
+// Storage reports the storage used by each node of the keg in ascending
+// node order.
+func (k *Keg) Storage(ctx context.Context) (*StorageReport, error) {
+	if err := k.checkKegExists(ctx); err != nil {
+		return nil, fmt.Errorf("failed to measure storage: %w", err)
+	}
+	ids, err := k.Repo.ListNodes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+	report := &StorageReport{Nodes: make([]NodeStorage, 0, len(ids))}
+	for _, id := range ids {
+		usage, err := k.NodeStorage(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		report.Nodes = append(report.Nodes, usage)
+	}
+	return report, nil
+}
+
+// NodeStorage reports the storage used by a single node. Item sizes come
+// from recorded item metadata when available.
+func (k *Keg) NodeStorage(ctx context.Context, id NodeId) (NodeStorage, error) {
+	usage := NodeStorage{ID: id}
+	content, err := k.Repo.ReadContent(ctx, id)
+	if err != nil && !errors.Is(err, ErrNotExist) {
+		return usage, fmt.Errorf("failed to read content of %s: %w", id.Path(), err)
+	}
+	meta, err := k.Repo.ReadMeta(ctx, id)
+	if err != nil && !errors.Is(err, ErrNotExist) {
+		return usage, fmt.Errorf("failed to read meta of %s: %w", id.Path(), err)
+	}
+	usage.Content = int64(len(content) + len(meta))
+
+	items, err := k.ListAttachments(ctx, id, true)
+	if err != nil {
+		return usage, err
+	}
+	for _, item := range items {
+		if item.Kind == AssetKindImage {
+			usage.Images += item.Meta.Size
+		} else {
+			usage.Attachments += item.Meta.Size
+		}
+	}
+	return usage, nil
+}
@@ -0,0 +1,151 @@
+package keg
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// InstrumentationName is the OpenTelemetry instrumentation scope of the
+// spans and metrics emitted by this package.
+const InstrumentationName = "github.com/jlrickert/tapper/pkg/keg"
+
+// Metric names recorded through the global OpenTelemetry meter provider.
+const (
+	MetricOperations        = "tapper.keg.operations"
+	MetricOperationDuration = "tapper.keg.operation.duration"
+	MetricRepoCalls         = "tapper.repo.calls"
+	MetricRepoCallDuration  = "tapper.repo.call.duration"
+	MetricDexCache          = "tapper.dex.cache"
+)
+
+// telemetryInstruments holds the metric instruments. They are created on
+// first use from the global meter, which forwards to a provider installed
+// later with otel.SetMeterProvider.
+type telemetryInstruments struct {
+	operations   metric.Int64Counter
+	opDuration   metric.Float64Histogram
+	repoCalls    metric.Int64Counter
+	repoDuration metric.Float64Histogram
+	dexCache     metric.Int64Counter
+}
+
+var (
+	instrumentsOnce sync.Once
+	instruments     telemetryInstruments
+)
+
+// tracer returns the package tracer from the global tracer provider. The
+// default provider is a no-op, so spans cost little until telemetry is set
+// up.
+func tracer() trace.Tracer {
+	return otel.Tracer(InstrumentationName)
+}
+
+// meterInstruments returns the lazily created metric instruments. Creation
+// errors leave a no-op instrument in place; metrics are never worth failing
+// a keg operation over.
+func meterInstruments() *telemetryInstruments {
+	instrumentsOnce.Do(func() {
+		m := otel.Meter(InstrumentationName)
+		instruments.operations, _ = m.Int64Counter(MetricOperations,
+			metric.WithDescription("Keg operations by op and outcome."))
+		instruments.opDuration, _ = m.Float64Histogram(MetricOperationDuration,
+			metric.WithDescription("Duration of keg operations, including index rebuilds."),
+			metric.WithUnit("s"))
+		instruments.repoCalls, _ = m.Int64Counter(MetricRepoCalls,
+			metric.WithDescription("Repository calls by op, kind and outcome."))
+		instruments.repoDuration, _ = m.Float64Histogram(MetricRepoCallDuration,
+			metric.WithDescription("Duration of repository calls."),
+			metric.WithUnit("s"))
+		instruments.dexCache, _ = m.Int64Counter(MetricDexCache,
+			metric.WithDescription("Dex cache lookups by result."))
+	})
+	return &instruments
+}
+
+// startOpSpan starts the span of a keg operation. args are the same
+// key/value pairs logOp logs.
+func startOpSpan(ctx context.Context, op string, args ...any) (context.Context, trace.Span) {
+	attrs := make([]attribute.KeyValue, 0, len(args)/2+1)
+	attrs = append(attrs, attribute.String("keg.op", op))
+	for i := 0; i+1 < len(args); i += 2 {
+		key := fmt.Sprint(args[i])
+		switch v := args[i+1].(type) {
+		case int:
+			attrs = append(attrs, attribute.Int(key, v))
+		case bool:
+			attrs = append(attrs, attribute.Bool(key, v))
+		default:
+			attrs = append(attrs, attribute.String(key, fmt.Sprint(v)))
+		}
+	}
+	name := "keg." + strings.ReplaceAll(op, " ", "_")
+	return tracer().Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// endOpSpan records the outcome of a keg operation on its span and in the
+// operation metrics, then ends the span.
+func endOpSpan(ctx context.Context, span trace.Span, op string, elapsed time.Duration, err error) {
+	set := metric.WithAttributes(attribute.String("op", op), attribute.Bool("error", err != nil))
+	inst := meterInstruments()
+	inst.operations.Add(ctx, 1, set)
+	inst.opDuration.Record(ctx, elapsed.Seconds(), metric.WithAttributes(attribute.String("op", op)))
+	endSpan(span, err)
+}
+
+// endSpan marks span as failed when err is set and ends it.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// recordDexCache counts a dex cache lookup as a hit or a miss.
+func recordDexCache(ctx context.Context, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	meterInstruments().dexCache.Add(ctx, 1, metric.WithAttributes(attribute.String("result", result)))
+}
+
+// TelemetryRepo returns a middleware that wraps every repository call in an
+// OpenTelemetry span and counts it in the tapper.repo.calls and
+// tapper.repo.call.duration metrics, labelled by op and read/write/lock kind.
+// Spans and metrics go to the global providers.
+func TelemetryRepo() RepoMiddleware {
+	return InterceptRepo(func(ctx context.Context, call RepoCall, next func(context.Context) error) error {
+		attrs := []attribute.KeyValue{
+			attribute.String("repo.op", call.Op),
+			attribute.String("repo.kind", string(call.Kind)),
+		}
+		spanAttrs := attrs
+		if call.Node != "" {
+			spanAttrs = append(spanAttrs, attribute.String("node", call.Node))
+		}
+		if call.Name != "" {
+			spanAttrs = append(spanAttrs, attribute.String("name", call.Name))
+		}
+		ctx, span := tracer().Start(ctx, "repo."+call.Op, trace.WithAttributes(spanAttrs...))
+		start := time.Now()
+		err := next(ctx)
+		elapsed := time.Since(start)
+
+		inst := meterInstruments()
+		inst.repoCalls.Add(ctx, 1, metric.WithAttributes(append(attrs, attribute.Bool("error", err != nil))...))
+		inst.repoDuration.Record(ctx, elapsed.Seconds(), metric.WithAttributes(attrs...))
+		endSpan(span, err)
+		return err
+	})
+}
@@ -0,0 +1,94 @@
+package keg_test
+
+import (
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/jlrickert/tapper/pkg/keg"
+	"github.com/jlrickert/tapper/pkg/kegtest"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// sumPoint returns the value of the int64 sum named name whose attributes
+// include kv, or zero.
+func sumPoint(rm metricdata.ResourceMetrics, name string, kv attribute.KeyValue) int64 {
+	var total int64
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			sum, ok := m.Data.(metricdata.Sum[int64])
+			if m.Name != name || !ok {
+				continue
+			}
+			for _, dp := range sum.DataPoints {
+				if v, ok := dp.Attributes.Value(kv.Key); ok && v == kv.Value {
+					total += dp.Value
+				}
+			}
+		}
+	}
+	return total
+}
+
+// metricReader installs a manual reader as the global meter provider once.
+// Instruments bind to the first provider installed, so later tests share it.
+var metricReader = sync.OnceValue(func() *sdkmetric.ManualReader {
+	reader := sdkmetric.NewManualReader()
+	otel.SetMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+	return reader
+})
+
+// TestKeg_Telemetry swaps the global OpenTelemetry providers, so it must not
+// run in parallel.
+func TestKeg_Telemetry(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(noop.NewTracerProvider()) })
+	reader := metricReader()
+
+	sb := NewSandbox(t)
+	ctx, rt := sb.Context(), sb.Runtime()
+	repo := keg.NewMemoryRepo(rt)
+	kegtest.NewKegFixture().Node(1, "One", "Body.").MustBuild(t, ctx, repo, rt)
+
+	k := keg.NewKeg(repo, rt, keg.WithRepoMiddleware(keg.TelemetryRepo()))
+	id, err := k.Create(ctx, &keg.CreateOptions{Title: "Two"})
+	require.NoError(t, err)
+	_, err = k.Dex(ctx)
+	require.NoError(t, err)
+	require.NoError(t, k.Index(ctx, keg.IndexOptions{}))
+
+	spans := recorder.Ended()
+	var names []string
+	byID := map[string]sdktrace.ReadOnlySpan{}
+	for _, s := range spans {
+		names = append(names, s.Name())
+		byID[s.SpanContext().SpanID().String()] = s
+	}
+	for _, want := range []string{"keg.create", "keg.index", "dex.load", "dex.write", "repo.WriteNode"} {
+		require.Contains(t, names, want)
+	}
+	i := slices.IndexFunc(spans, func(s sdktrace.ReadOnlySpan) bool {
+		return s.Name() == "repo.WriteNode" && slices.Contains(s.Attributes(), attribute.String("node", id.Path()))
+	})
+	require.GreaterOrEqual(t, i, 0)
+	var ancestors []string
+	for s, ok := byID[spans[i].Parent().SpanID().String()]; ok; s, ok = byID[s.Parent().SpanID().String()] {
+		ancestors = append(ancestors, s.Name())
+	}
+	require.Equal(t, []string{"repo.WithNodeLock", "keg.create"}, ancestors)
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(ctx, &rm))
+	require.GreaterOrEqual(t, sumPoint(rm, keg.MetricOperations, attribute.String("op", "create")), int64(1))
+	require.GreaterOrEqual(t, sumPoint(rm, keg.MetricRepoCalls, attribute.String("repo.kind", "write")), int64(1))
+	require.GreaterOrEqual(t, sumPoint(rm, keg.MetricDexCache, attribute.String("result", "miss")), int64(1))
+	require.GreaterOrEqual(t, sumPoint(rm, keg.MetricDexCache, attribute.String("result", "hit")), int64(1))
+}
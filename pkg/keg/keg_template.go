@@ -0,0 +1,84 @@
+package keg
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// maxSnippetDepth bounds how deeply snippets may expand other snippets, so a
+// snippet that includes itself fails instead of recursing forever.
+const maxSnippetDepth = 8
+
+// TemplateVars are the values substituted into a node body when it is
+// created from a template or with CreateOptions.Vars. Bodies use Go
+// text/template syntax: {{.Date}}, {{.Title}}, {{.KegAlias}}, {{.User}} and
+// {{snippet "name"}}.
+type TemplateVars struct {
+	// Date is the creation date as YYYY-MM-DD. Empty uses today.
+	Date string
+	// Title is the new node's title. Empty uses CreateOptions.Title.
+	Title string
+	// KegAlias is the alias the keg was opened with.
+	KegAlias string
+	// User is the name of the user creating the node.
+	User string
+	// Snippets maps a snippet name to the text {{snippet "name"}} expands
+	// to. Snippets are expanded with the same variables and may use other
+	// snippets.
+	Snippets map[string]string
+}
+
+// ExpandTemplate substitutes vars into body. Bodies without "{{" are
+// returned unchanged.
+func ExpandTemplate(body []byte, vars TemplateVars) ([]byte, error) {
+	if !bytes.Contains(body, []byte("{{")) {
+		return body, nil
+	}
+	out, err := vars.expand("body", string(body), 0)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(out), nil
+}
+
+func (v TemplateVars) expand(name, src string, depth int) (string, error) {
+	if depth > maxSnippetDepth {
+		return "", fmt.Errorf("snippet %q nests more than %d levels: %w", name, maxSnippetDepth, ErrInvalid)
+	}
+	tmpl, err := template.New(name).Option("missingkey=error").Funcs(template.FuncMap{
+		"snippet": func(snippet string) (string, error) {
+			text, ok := v.Snippets[snippet]
+			if !ok {
+				return "", fmt.Errorf("unknown snippet %q: %w", snippet, ErrInvalid)
+			}
+			return v.expand(snippet, text, depth+1)
+		},
+	}).Parse(src)
+	if err != nil {
+		return "", fmt.Errorf("invalid template: %v: %w", err, ErrInvalid)
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, v); err != nil {
+		return "", fmt.Errorf("unable to expand template: %w", err)
+	}
+	return b.String(), nil
+}
+
+// templateVars returns the variables for a node created with opts, filling
+// in the date and title the caller left empty.
+func (k *Keg) templateVars(opts *CreateOptions) TemplateVars {
+	var vars TemplateVars
+	if opts.Vars != nil {
+		vars = *opts.Vars
+	}
+	if vars.Date == "" {
+		vars.Date = k.Runtime.Clock().Now().Format(time.DateOnly)
+	}
+	if vars.Title == "" {
+		vars.Title = opts.Title
+	}
+	return vars
+}
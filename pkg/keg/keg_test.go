@@ -1,7 +1,10 @@
 package keg_test
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"os"
 	"slices"
 	"testing"
 	"time"
@@ -184,6 +187,131 @@ func TestCreateWithBody(t *testing.T) {
 	require.Equal(t, "body paragraph", stats.Lead())
 }
 
+func TestCreateAppliesConfigDefaults(t *testing.T) {
+	t.Parallel()
+	f := NewSandbox(t)
+	ctx := f.Context()
+
+	repo := kegpkg.NewMemoryRepo(f.Runtime())
+	k := kegpkg.NewKeg(repo, f.Runtime())
+	require.NoError(t, k.Init(ctx))
+
+	tmpl, err := k.Create(ctx, &kegpkg.CreateOptions{Body: []byte("# Meeting\n\n## Attendees\n\n## Notes\n")})
+	require.NoError(t, err)
+	require.NoError(t, k.UpdateConfig(ctx, func(cfg *kegpkg.Config) {
+		cfg.Defaults = &kegpkg.CreateDefaults{
+			Template: tmpl.Path(),
+			Tags:     []string{"work"},
+			Attrs:    map[string]any{"team": "core", "status": "open"},
+		}
+	}))
+
+	id, err := k.Create(ctx, &kegpkg.CreateOptions{
+		Title: "Standup",
+		Tags:  []string{"daily"},
+		Attrs: map[string]any{"status": "done"},
+	})
+	require.NoError(t, err)
+
+	content, err := k.GetContent(ctx, id)
+	require.NoError(t, err)
+	require.Equal(t, "# Standup\n\n## Attendees\n\n## Notes\n", string(content))
+
+	m, err := k.GetMeta(ctx, id)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"daily", "work"}, m.Tags())
+	team, _ := m.Get("team")
+	require.Equal(t, "core", team)
+	status, _ := m.Get("status")
+	require.Equal(t, "done", status)
+
+	// A caller-supplied body wins over the template.
+	id, err = k.Create(ctx, &kegpkg.CreateOptions{Body: []byte("# Own\n")})
+	require.NoError(t, err)
+	content, err = k.GetContent(ctx, id)
+	require.NoError(t, err)
+	require.Equal(t, "# Own\n", string(content))
+}
+
+func TestCreateExpandsTemplateVars(t *testing.T) {
+	t.Parallel()
+	f := NewSandbox(t)
+	ctx := f.Context()
+	today := f.Runtime().Clock().Now().Format(time.DateOnly)
+
+	repo := kegpkg.NewMemoryRepo(f.Runtime())
+	k := kegpkg.NewKeg(repo, f.Runtime())
+	require.NoError(t, k.Init(ctx))
+
+	// Bodies created without Vars are kept verbatim, so templates can be
+	// written as nodes.
+	tmpl, err := k.Create(ctx, &kegpkg.CreateOptions{Body: []byte("# {{.Title}}\n\n{{.Date}} in {{.KegAlias}}\n")})
+	require.NoError(t, err)
+	content, err := k.GetContent(ctx, tmpl)
+	require.NoError(t, err)
+	require.Contains(t, string(content), "{{.Date}}")
+
+	require.NoError(t, k.UpdateConfig(ctx, func(cfg *kegpkg.Config) {
+		cfg.Defaults = &kegpkg.CreateDefaults{Template: tmpl.Path()}
+	}))
+	id, err := k.Create(ctx, &kegpkg.CreateOptions{
+		Title: "Standup",
+		Vars:  &kegpkg.TemplateVars{KegAlias: "work"},
+	})
+	require.NoError(t, err)
+	content, err = k.GetContent(ctx, id)
+	require.NoError(t, err)
+	require.Equal(t, "# Standup\n\n"+today+" in work\n", string(content))
+
+	vars := &kegpkg.TemplateVars{
+		User: "ana",
+		Snippets: map[string]string{
+			"sig":  "-- {{.User}}",
+			"foot": "{{snippet \"sig\"}} ({{.Date}})",
+			"loop": "{{snippet \"loop\"}}",
+		},
+	}
+	id, err = k.Create(ctx, &kegpkg.CreateOptions{
+		Title: "Note",
+		Body:  []byte("# {{.Title}}\n\n{{snippet \"foot\"}}\n"),
+		Vars:  vars,
+	})
+	require.NoError(t, err)
+	content, err = k.GetContent(ctx, id)
+	require.NoError(t, err)
+	require.Equal(t, "# Note\n\n-- ana ("+today+")\n", string(content))
+
+	for _, body := range []string{"{{snippet \"missing\"}}", "{{snippet \"loop\"}}", "{{.Nope}}", "{{"} {
+		_, err = k.Create(ctx, &kegpkg.CreateOptions{Body: []byte(body), Vars: vars})
+		require.Error(t, err, body)
+	}
+	_, err = k.Create(ctx, &kegpkg.CreateOptions{Body: []byte("{{snippet \"missing\"}}"), Vars: vars})
+	require.ErrorIs(t, err, kegpkg.ErrInvalid)
+}
+
+func TestCreateBatchIndexesAllNodes(t *testing.T) {
+	t.Parallel()
+	f := NewSandbox(t)
+
+	repo := kegpkg.NewMemoryRepo(f.Runtime())
+	k := kegpkg.NewKeg(repo, f.Runtime())
+	require.NoError(t, k.Init(f.Context()))
+
+	ids, err := k.CreateBatch(f.Context(), []*kegpkg.CreateOptions{
+		{Title: "One", Tags: []string{"a"}},
+		{Title: "Two", Tags: []string{"b"}},
+	})
+	require.NoError(t, err)
+	require.Len(t, ids, 2)
+	require.Equal(t, 1, ids[0].ID)
+	require.Equal(t, 2, ids[1].ID)
+
+	raw, err := repo.GetIndex(f.Context(), "nodes.tsv")
+	require.NoError(t, err)
+	require.Contains(t, string(raw), "One")
+	require.Contains(t, string(raw), "Two")
+}
+
 // New test: Body contains YAML frontmatter. Ensure content written equals the
 // provided bytes and parsed meta reflects the markdown heading and lead.
 func TestCreateWithBodyFrontmatter(t *testing.T) {
@@ -224,6 +352,51 @@ fm lead paragraph
 	require.Contains(t, m.ToYAML(), "foo: bar")
 }
 
+func TestCreateKeepsFrontmatter(t *testing.T) {
+	t.Parallel()
+	f := NewSandbox(t)
+
+	repo := kegpkg.NewMemoryRepo(f.Runtime())
+	k := kegpkg.NewKeg(repo, f.Runtime())
+	require.NoError(t, k.Init(f.Context()))
+
+	rawBody := []byte(`---
+tags: [fm]
+source: web
+---
+# Kept
+
+Lead paragraph.
+`)
+	id, err := k.Create(f.Context(), &kegpkg.CreateOptions{Body: rawBody, KeepFrontmatter: true})
+	require.NoError(t, err)
+	got, err := k.GetContent(f.Context(), id)
+	require.NoError(t, err)
+	require.Equal(t, string(rawBody), string(got))
+
+	stats, err := k.GetStats(f.Context(), id)
+	require.NoError(t, err)
+	require.Equal(t, "Kept", stats.Title())
+	require.Equal(t, "Lead paragraph.", stats.Lead())
+	m, err := k.GetMeta(f.Context(), id)
+	require.NoError(t, err)
+	require.Equal(t, []string{"fm"}, m.Tags())
+	require.Contains(t, m.ToYAML(), "source: web")
+
+	// Later content writes keep syncing the frontmatter into meta.
+	require.NoError(t, k.SetContent(f.Context(), id, []byte("---\ntags: [fm, synced]\n---\n# Heading\n")))
+	m, err = k.GetMeta(f.Context(), id)
+	require.NoError(t, err)
+	require.Equal(t, []string{"fm", "synced"}, m.Tags())
+
+	require.NoError(t, k.UpdateConfig(f.Context(), func(cfg *kegpkg.Config) { cfg.KeepFrontmatter = true }))
+	id, err = k.Create(f.Context(), &kegpkg.CreateOptions{Body: rawBody})
+	require.NoError(t, err)
+	got, err = k.GetContent(f.Context(), id)
+	require.NoError(t, err)
+	require.Equal(t, string(rawBody), string(got))
+}
+
 // TestSetContentAndUpdate ensures SetContent causes meta to be updated from
 // parsed content (for example lead paragraph changes).
 func TestSetContentAndUpdate(t *testing.T) {
@@ -488,7 +661,9 @@ func TestMove_RewritesLinksAndUpdatesDex(t *testing.T) {
 	// Add canonical and bare links to node 2.
 	require.NoError(t, k.SetContent(f.Context(), id1, []byte("# One\n\nSee [two](../2).\nAlso ../2.\n")))
 
-	require.NoError(t, k.Move(f.Context(), kegpkg.NodeId{ID: 2}, kegpkg.NodeId{ID: 3}))
+	report, err := k.Move(f.Context(), kegpkg.NodeId{ID: 2}, kegpkg.NodeId{ID: 3})
+	require.NoError(t, err)
+	require.Equal(t, []kegpkg.RepairedFile{{Node: id1, File: "content", Rewrites: 2}}, report.Files)
 
 	exists, err := k.Repo.HasNode(f.Context(), kegpkg.NodeId{ID: 2})
 	require.NoError(t, err)
@@ -533,7 +708,7 @@ func TestMove_DestinationExists(t *testing.T) {
 	_, err = k.Create(f.Context(), &kegpkg.CreateOptions{Title: "Three"})
 	require.NoError(t, err)
 
-	err = k.Move(f.Context(), kegpkg.NodeId{ID: 2}, kegpkg.NodeId{ID: 3})
+	_, err = k.Move(f.Context(), kegpkg.NodeId{ID: 2}, kegpkg.NodeId{ID: 3})
 	require.Error(t, err)
 	require.ErrorIs(t, err, kegpkg.ErrDestinationExists)
 }
@@ -553,7 +728,9 @@ func TestRemove_DeletesNodeAndUpdatesDex(t *testing.T) {
 
 	require.NoError(t, k.SetContent(f.Context(), id1, []byte("# One\n\nSee [two](../2).\n")))
 
-	require.NoError(t, k.Remove(f.Context(), id2))
+	report, err := k.Remove(f.Context(), id2)
+	require.NoError(t, err)
+	require.Equal(t, []kegpkg.RepairedFile{{Node: id1, File: "content", Rewrites: 1}}, report.Files)
 
 	exists, err := k.Repo.HasNode(f.Context(), id2)
 	require.NoError(t, err)
@@ -569,9 +746,8 @@ func TestRemove_DeletesNodeAndUpdatesDex(t *testing.T) {
 	require.False(t, ok, "deleted node should be absent from backlinks index")
 
 	node1Links, ok := dex.Links(f.Context(), id1)
-	if ok {
-		require.Len(t, node1Links, 0, "links to deleted node should be removed")
-	}
+	require.True(t, ok)
+	require.Equal(t, []kegpkg.NodeId{{ID: 0}}, node1Links, "links to deleted node should point at the zero node")
 }
 
 func TestRemove_NotFound(t *testing.T) {
@@ -582,7 +758,7 @@ func TestRemove_NotFound(t *testing.T) {
 	k := kegpkg.NewKeg(repo, f.Runtime())
 	require.NoError(t, k.Init(f.Context()))
 
-	err := k.Remove(f.Context(), kegpkg.NodeId{ID: 4242})
+	_, err := k.Remove(f.Context(), kegpkg.NodeId{ID: 4242})
 	require.Error(t, err)
 	require.ErrorIs(t, err, kegpkg.ErrNotExist)
 }
@@ -645,3 +821,164 @@ func TestIndex_MalformedMetaNodeGetsIndexed(t *testing.T) {
 	require.NotNil(t, ref, "node with malformed meta should still appear in index")
 	require.Equal(t, "Good Node", ref.Title)
 }
+
+func TestRepairLinks_RewritesContentAndMetaInOnePass(t *testing.T) {
+	t.Parallel()
+	f := NewSandbox(t)
+
+	repo := kegpkg.NewMemoryRepo(f.Runtime())
+	k := kegpkg.NewKeg(repo, f.Runtime())
+	require.NoError(t, k.Init(f.Context()))
+
+	for _, title := range []string{"One", "Two", "Three"} {
+		_, err := k.Create(f.Context(), &kegpkg.CreateOptions{Title: title})
+		require.NoError(t, err)
+	}
+	id1 := kegpkg.NodeId{ID: 1}
+	require.NoError(t, k.SetContent(f.Context(), id1, []byte("# One\n\n[two](../2) [three](../3) ../23\n")))
+	require.NoError(t, repo.WriteMeta(f.Context(), id1, []byte("related: ../3\n")))
+
+	// Swap 2 and 3 and delete nothing else; ../23 must stay untouched.
+	report, err := k.RepairLinks(f.Context(), kegpkg.LinkRepair{
+		Renamed: map[kegpkg.NodeId]kegpkg.NodeId{{ID: 2}: {ID: 3}, {ID: 3}: {ID: 2}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []kegpkg.RepairedFile{
+		{Node: id1, File: "content", Rewrites: 2},
+		{Node: id1, File: "meta", Rewrites: 1},
+	}, report.Files)
+
+	content, err := k.GetContent(f.Context(), id1)
+	require.NoError(t, err)
+	require.Equal(t, "# One\n\n[two](../3) [three](../2) ../23\n", string(content))
+	meta, err := repo.ReadMeta(f.Context(), id1)
+	require.NoError(t, err)
+	require.Contains(t, string(meta), "related: ../2")
+
+	report, err = k.RepairLinks(f.Context(), kegpkg.LinkRepair{Deleted: []kegpkg.NodeId{{ID: 3}}})
+	require.NoError(t, err)
+	require.Len(t, report.Files, 1)
+	content, err = k.GetContent(f.Context(), id1)
+	require.NoError(t, err)
+	require.Contains(t, string(content), "[two](../0)")
+}
+
+func TestArchiveRemovesNodeFromDexAndUnarchiveRestoresIt(t *testing.T) {
+	t.Parallel()
+	f := NewSandbox(t)
+	ctx := f.Context()
+
+	k := kegpkg.NewKeg(kegpkg.NewMemoryRepo(f.Runtime()), f.Runtime())
+	require.NoError(t, k.Init(ctx))
+	id, err := k.Create(ctx, &kegpkg.CreateOptions{Title: "Retired idea"})
+	require.NoError(t, err)
+
+	require.NoError(t, k.Archive(ctx, id))
+	dex, err := k.Dex(ctx)
+	require.NoError(t, err)
+	require.Nil(t, dex.GetRef(ctx, id))
+	content, err := k.ReadArchivedContent(ctx, id)
+	require.NoError(t, err)
+	require.Contains(t, string(content), "Retired idea")
+
+	err = k.Archive(ctx, kegpkg.NodeId{ID: 0})
+	require.ErrorIs(t, err, kegpkg.ErrInvalid)
+
+	require.NoError(t, k.Unarchive(ctx, id))
+	ref := dex.GetRef(ctx, id)
+	require.NotNil(t, ref)
+	require.Equal(t, "Retired idea", ref.Title)
+	archived, err := k.ListArchived(ctx)
+	require.NoError(t, err)
+	require.Empty(t, archived)
+}
+
+func TestIndex_WaitsForKegLock(t *testing.T) {
+	t.Parallel()
+	f := NewSandbox(t, sandbox.WithFixture("empty", "repofs_lock"))
+	ctx := f.Context()
+
+	k, err := kegpkg.NewKegFromTarget(ctx, kegurl.NewFile("repofs_lock"), f.Runtime())
+	require.NoError(t, err)
+	require.NoError(t, k.Init(ctx))
+
+	// A lock held by a live process blocks the index until ctx expires.
+	owner := fmt.Sprintf(`{"pid":%d,"hostname":"elsewhere"}`, os.Getpid())
+	require.NoError(t, f.Runtime().Mkdir("repofs_lock/.keg-lock", 0o700, true))
+	require.NoError(t, f.Runtime().WriteFile("repofs_lock/.keg-lock/owner.json", []byte(owner), 0o644))
+
+	lockCtx, cancel := context.WithTimeout(ctx, 150*time.Millisecond)
+	defer cancel()
+	err = k.Index(lockCtx, kegpkg.IndexOptions{})
+	require.ErrorIs(t, err, kegpkg.ErrLockTimeout)
+	require.Contains(t, err.Error(), "on elsewhere")
+
+	held, err := k.KegLockOwner(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, held)
+	require.False(t, held.Stale)
+
+	// A lock left by a dead process is cleared and released afterwards.
+	require.NoError(t, f.Runtime().WriteFile("repofs_lock/.keg-lock/owner.json", []byte(`{"pid":0}`), 0o644))
+	require.NoError(t, k.Index(ctx, kegpkg.IndexOptions{}))
+	held, err = k.KegLockOwner(ctx)
+	require.NoError(t, err)
+	require.Nil(t, held)
+}
+
+func TestIndex_StopsWhenContextCanceled(t *testing.T) {
+	t.Parallel()
+	f := NewSandbox(t, sandbox.WithFixture("empty", "repofs_cancel"))
+	ctx := f.Context()
+
+	k, err := kegpkg.NewKegFromTarget(ctx, kegurl.NewFile("repofs_cancel"), f.Runtime())
+	require.NoError(t, err)
+	require.NoError(t, k.Init(ctx))
+	require.NoError(t, f.Runtime().Mkdir("repofs_cancel/1", 0o755, true))
+	require.NoError(t, f.Runtime().WriteFile("repofs_cancel/1/README.md", []byte("# One\n"), 0o644))
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	err = k.Index(canceled, kegpkg.IndexOptions{Rebuild: true})
+	require.ErrorIs(t, err, context.Canceled)
+
+	_, err = k.Repo.ReadStats(ctx, kegpkg.NodeId{ID: 1})
+	require.ErrorIs(t, err, kegpkg.ErrNotExist, "canceled index should not have written node files")
+}
+
+func TestMerge_CopiesReferencedImages(t *testing.T) {
+	t.Parallel()
+	f := NewSandbox(t)
+	ctx := f.Context()
+
+	repo := kegpkg.NewMemoryRepo(f.Runtime())
+	k := kegpkg.NewKeg(repo, f.Runtime())
+	require.NoError(t, k.Init(ctx))
+
+	dst, err := k.Create(ctx, &kegpkg.CreateOptions{Title: "Keeper"})
+	require.NoError(t, err)
+	src, err := k.Create(ctx, &kegpkg.CreateOptions{Title: "Duplicate"})
+	require.NoError(t, err)
+	require.NoError(t, repo.WriteImage(ctx, dst, "chart.png", []byte("keeper chart")))
+	require.NoError(t, repo.WriteImage(ctx, src, "chart.png", []byte("duplicate chart")))
+	require.NoError(t, repo.WriteImage(ctx, src, "photo.png", []byte("photo")))
+	require.NoError(t, k.SetContent(ctx, src,
+		[]byte("# Duplicate\n\n![chart](images/chart.png)\n![photo](./images/photo.png)\n")))
+
+	require.NoError(t, k.Merge(ctx, src, dst, kegpkg.MergeOptions{}))
+
+	content, err := k.GetContent(ctx, dst)
+	require.NoError(t, err)
+	require.Contains(t, string(content), "![photo](./images/photo.png)")
+	require.NotContains(t, string(content), "(images/chart.png)", "the clashing image is renamed")
+
+	data, err := repo.ReadImage(ctx, dst, "chart.png")
+	require.NoError(t, err)
+	require.Equal(t, "keeper chart", string(data))
+	data, err = repo.ReadImage(ctx, dst, "photo.png")
+	require.NoError(t, err)
+	require.Equal(t, "photo", string(data))
+	images, err := repo.ListImages(ctx, dst)
+	require.NoError(t, err)
+	require.Len(t, images, 3)
+}
@@ -0,0 +1,128 @@
+package keg
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// execSettings are the keg config settings that run commands or send
+// requests on the user's behalf. A keg config travels with the keg through
+// git, registries and clones, so these are only used once the user trusts
+// them (see WithTrustedConfig).
+type execSettings struct {
+	Hooks          []Hook            `yaml:"hooks,omitempty"`
+	Summarizer     *SummarizerConfig `yaml:"summarizer,omitempty"`
+	Embedder       *EmbedderConfig   `yaml:"embedder,omitempty"`
+	ImageConverter string            `yaml:"imageConverter,omitempty"`
+}
+
+func (kc *Config) execSettings() execSettings {
+	var s execSettings
+	if kc == nil {
+		return s
+	}
+	s.Hooks = kc.Hooks
+	if kc.Summarizer != nil && !kc.Summarizer.builtin() {
+		s.Summarizer = kc.Summarizer
+	}
+	if kc.Search != nil {
+		s.Embedder = kc.Search.Embedder
+	}
+	if convert := kc.ImageConversion(); convert != nil {
+		s.ImageConverter = strings.TrimSpace(convert.Command)
+	}
+	return s
+}
+
+// builtin reports whether the summarizer runs in-process.
+func (c *SummarizerConfig) builtin() bool {
+	switch strings.ToLower(strings.TrimSpace(c.Kind)) {
+	case "", "sentences":
+		return true
+	}
+	return false
+}
+
+// ExecSettings returns the hooks, summarizer, embedder and image converter
+// settings of the config as YAML, or "" when it has none. These settings run
+// commands or send requests and are ignored until the user trusts them.
+func (kc *Config) ExecSettings() string {
+	s := kc.execSettings()
+	if len(s.Hooks) == 0 && s.Summarizer == nil && s.Embedder == nil && s.ImageConverter == "" {
+		return ""
+	}
+	data, err := yaml.Marshal(s)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// TrustDigest identifies the settings returned by ExecSettings. It is ""
+// when the config has none. Trust given to one digest does not carry over
+// to changed settings.
+func (kc *Config) TrustDigest() string {
+	settings := kc.ExecSettings()
+	if settings == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(settings))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// StripExecSettings removes the settings returned by ExecSettings, keeping
+// the built-in summarizer and the rest of the image conversion settings.
+func (kc *Config) StripExecSettings() {
+	if kc == nil {
+		return
+	}
+	kc.Hooks = nil
+	if kc.Summarizer != nil && !kc.Summarizer.builtin() {
+		kc.Summarizer = nil
+	}
+	if kc.Search != nil {
+		kc.Search.Embedder = nil
+	}
+	if kc.Images != nil && kc.Images.Convert != nil {
+		kc.Images.Convert.Command = ""
+	}
+}
+
+// WithTrustedConfig makes the keg use the hooks, summarizer, embedder and
+// image converter in its config while they match digest, a
+// Config.TrustDigest the user approved.
+func WithTrustedConfig(digest string) Option {
+	return func(k *Keg) {
+		k.TrustedConfig = digest
+	}
+}
+
+// TrustsConfig reports whether the settings of cfg that run commands or send
+// requests may be used. Configs without such settings are always trusted;
+// registry-backed kegs never are.
+func (k *Keg) TrustsConfig(cfg *Config) bool {
+	digest := cfg.TrustDigest()
+	if digest == "" {
+		return true
+	}
+	if _, ok := UnwrapRepo(k.Repo).(*RegistryRepo); ok {
+		k.warnUntrusted()
+		return false
+	}
+	if k.TrustedConfig != digest {
+		k.warnUntrusted()
+		return false
+	}
+	return true
+}
+
+// warnUntrusted logs once per keg that its config settings are ignored.
+func (k *Keg) warnUntrusted() {
+	if k.untrustedWarned.Swap(true) {
+		return
+	}
+	k.Runtime.Logger().Warn("ignoring hooks, summarizer, embedder and image converter commands in untrusted keg config; review them and run `tap repo trust`")
+}
@@ -0,0 +1,41 @@
+package keg_test
+
+import (
+	"testing"
+
+	"github.com/jlrickert/tapper/pkg/keg"
+	"github.com/jlrickert/tapper/pkg/registry"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeg_TrustsConfig(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t)
+	rt := sb.Runtime()
+
+	cfg := &keg.Config{
+		Summarizer: &keg.SummarizerConfig{Kind: "command", Command: []string{"summarize"}},
+		Hooks:      []keg.Hook{{Event: keg.HookPostCreate, Run: "make"}},
+	}
+	digest := cfg.TrustDigest()
+	require.NotEmpty(t, digest)
+	require.Contains(t, cfg.ExecSettings(), "run: make")
+
+	require.True(t, keg.NewKeg(keg.NewMemoryRepo(rt), rt).TrustsConfig(&keg.Config{}), "nothing to trust")
+	require.False(t, keg.NewKeg(keg.NewMemoryRepo(rt), rt).TrustsConfig(cfg))
+	trusted := keg.NewKeg(keg.NewMemoryRepo(rt), rt, keg.WithTrustedConfig(digest))
+	require.True(t, trusted.TrustsConfig(cfg))
+
+	cfg.Hooks[0].Run = "make install"
+	require.False(t, trusted.TrustsConfig(cfg), "changed settings are not trusted")
+
+	remote := keg.NewKeg(keg.NewRegistryRepo(registry.NewClient("http://registry.invalid", ""), "joe", "notes", rt), rt,
+		keg.WithTrustedConfig(cfg.TrustDigest()))
+	require.False(t, remote.TrustsConfig(cfg), "registry kegs are never trusted")
+
+	cfg.Summarizer = &keg.SummarizerConfig{Kind: "sentences", Sentences: 3}
+	cfg.StripExecSettings()
+	require.Empty(t, cfg.TrustDigest())
+	require.Empty(t, cfg.Hooks)
+	require.Equal(t, 3, cfg.Summarizer.Sentences, "the built-in summarizer is kept")
+}
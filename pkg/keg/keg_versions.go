@@ -0,0 +1,198 @@
+package keg
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// NodeVersionsDir is the item-area directory holding prior content versions.
+// Blobs are stored as NodeVersionsDir/HASH and listed in
+// NodeVersionsDir/index.tsv in the order they were recorded.
+const NodeVersionsDir = ".versions"
+
+const nodeVersionsIndexName = NodeVersionsDir + "/index.tsv"
+
+// ContentVersion describes a prior content revision of a node.
+type ContentVersion struct {
+	// Number is the 1-based position of the version in recording order.
+	Number int
+
+	// Hash is the hash of the stored blob, which it also names. Encrypted
+	// versions are hashed as stored, never as plaintext.
+	Hash string
+
+	// Created is when the content was replaced.
+	Created time.Time
+
+	// Size is the content length in bytes.
+	Size int
+}
+
+// ListVersions returns a node's recorded content versions, oldest first.
+// Repositories without file attachment support have no versions.
+func (k *Keg) ListVersions(ctx context.Context, id NodeId) ([]ContentVersion, error) {
+	if err := k.checkKegExists(ctx); err != nil {
+		return nil, fmt.Errorf("failed to list versions: %w", err)
+	}
+	files, ok := k.Repo.(RepositoryFiles)
+	if !ok {
+		return nil, ErrNotSupported
+	}
+	return readVersionIndex(ctx, files, id)
+}
+
+// ReadVersion resolves ref to a recorded version of a node and returns its
+// content, decrypted when the version was stored encrypted. Ref is either a
+// version number or a unique prefix of its hash.
+func (k *Keg) ReadVersion(ctx context.Context, id NodeId, ref string) (ContentVersion, []byte, error) {
+	versions, err := k.ListVersions(ctx, id)
+	if err != nil {
+		return ContentVersion{}, nil, err
+	}
+	version, err := resolveVersionRef(versions, ref)
+	if err != nil {
+		return ContentVersion{}, nil, err
+	}
+	data, err := k.Repo.(RepositoryFiles).ReadFile(ctx, id, NodeVersionsDir+"/"+version.Hash)
+	if err != nil {
+		return ContentVersion{}, nil, fmt.Errorf("unable to read version %d: %w", version.Number, err)
+	}
+	if data, err = k.openContent(ctx, id, data); err != nil {
+		return ContentVersion{}, nil, err
+	}
+	return version, data, nil
+}
+
+// RevertVersion restores a node's content to the version named by ref. The
+// content being replaced is itself recorded as a new version, so reverts can
+// be undone.
+func (k *Keg) RevertVersion(ctx context.Context, id NodeId, ref string) (ContentVersion, error) {
+	version, data, err := k.ReadVersion(ctx, id, ref)
+	if err != nil {
+		return ContentVersion{}, err
+	}
+	if err := k.SetContent(ctx, id, data); err != nil {
+		return ContentVersion{}, err
+	}
+	return version, nil
+}
+
+// recordVersionLocked stores prev as a version when it differs from next. It
+// must be called while holding the node lock and before next is written.
+func (k *Keg) recordVersionLocked(ctx context.Context, id NodeId, prev, next []byte) error {
+	files, ok := k.Repo.(RepositoryFiles)
+	if !ok || len(bytes.TrimSpace(prev)) == 0 {
+		return nil
+	}
+	hasher := k.Runtime.Hasher()
+	hash := hasher.Hash(prev)
+	if hash == hasher.Hash(next) {
+		return nil
+	}
+
+	versions, err := readVersionIndex(ctx, files, id)
+	if err != nil {
+		return err
+	}
+	stored := false
+	for _, v := range versions {
+		if v.Hash == hash {
+			stored = true
+			break
+		}
+	}
+	if !stored {
+		if err := files.WriteFile(ctx, id, NodeVersionsDir+"/"+hash, prev); err != nil {
+			return fmt.Errorf("unable to store content version: %w", err)
+		}
+	}
+
+	versions = append(versions, ContentVersion{
+		Number:  len(versions) + 1,
+		Hash:    hash,
+		Created: k.Runtime.Clock().Now().UTC(),
+		Size:    len(prev),
+	})
+	return writeVersionIndex(ctx, files, id, versions)
+}
+
+func writeVersionIndex(ctx context.Context, files RepositoryFiles, id NodeId, versions []ContentVersion) error {
+	var b strings.Builder
+	for _, v := range versions {
+		fmt.Fprintf(&b, "%s\t%s\t%d\n", v.Hash, v.Created.Format(time.RFC3339), v.Size)
+	}
+	if err := files.WriteFile(ctx, id, nodeVersionsIndexName, []byte(b.String())); err != nil {
+		return fmt.Errorf("unable to write version index: %w", err)
+	}
+	return nil
+}
+
+func readVersionIndex(ctx context.Context, files RepositoryFiles, id NodeId) ([]ContentVersion, error) {
+	data, err := files.ReadFile(ctx, id, nodeVersionsIndexName)
+	if errors.Is(err, ErrNotExist) {
+		return []ContentVersion{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read version index: %w", err)
+	}
+
+	versions := []ContentVersion{}
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		parts := strings.Split(line, "\t")
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("malformed version index line %d: %w", i+1, ErrInvalid)
+		}
+		created, err := time.Parse(time.RFC3339, parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("malformed version index line %d: %w", i+1, err)
+		}
+		size, err := strconv.Atoi(parts[2])
+		if err != nil {
+			return nil, fmt.Errorf("malformed version index line %d: %w", i+1, err)
+		}
+		versions = append(versions, ContentVersion{
+			Number:  len(versions) + 1,
+			Hash:    parts[0],
+			Created: created,
+			Size:    size,
+		})
+	}
+	return versions, nil
+}
+
+func resolveVersionRef(versions []ContentVersion, ref string) (ContentVersion, error) {
+	ref = strings.TrimSpace(ref)
+	if n, err := strconv.Atoi(ref); err == nil {
+		if n < 1 || n > len(versions) {
+			return ContentVersion{}, fmt.Errorf("version %d not found: %w", n, ErrNotExist)
+		}
+		return versions[n-1], nil
+	}
+	if ref == "" {
+		return ContentVersion{}, fmt.Errorf("version is required: %w", ErrInvalid)
+	}
+
+	var match *ContentVersion
+	for i := range versions {
+		if !strings.HasPrefix(versions[i].Hash, ref) {
+			continue
+		}
+		if match != nil && match.Hash != versions[i].Hash {
+			return ContentVersion{}, fmt.Errorf("version %q is ambiguous: %w", ref, ErrInvalid)
+		}
+		match = &versions[i]
+	}
+	if match == nil {
+		return ContentVersion{}, fmt.Errorf("version %q not found: %w", ref, ErrNotExist)
+	}
+	return *match, nil
+}
@@ -0,0 +1,56 @@
+package keg_test
+
+import (
+	"testing"
+
+	kegpkg "github.com/jlrickert/tapper/pkg/keg"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetContent_RecordsVersionsWhenHashChanges(t *testing.T) {
+	t.Parallel()
+	f := NewSandbox(t)
+	ctx := f.Context()
+
+	repo := kegpkg.NewMemoryRepo(f.Runtime())
+	k := kegpkg.NewKeg(repo, f.Runtime())
+	require.NoError(t, k.Init(ctx))
+	id, err := k.Create(ctx, &kegpkg.CreateOptions{Title: "Draft"})
+	require.NoError(t, err)
+	first, err := k.GetContent(ctx, id)
+	require.NoError(t, err)
+
+	require.NoError(t, k.SetContent(ctx, id, []byte("# Second\n")))
+	// Unchanged content does not add a version.
+	require.NoError(t, k.SetContent(ctx, id, []byte("# Second\n")))
+	require.NoError(t, k.SetContent(ctx, id, first))
+
+	versions, err := k.ListVersions(ctx, id)
+	require.NoError(t, err)
+	require.Len(t, versions, 2)
+	require.Equal(t, 1, versions[0].Number)
+	require.Equal(t, len(first), versions[0].Size)
+
+	_, data, err := k.ReadVersion(ctx, id, versions[1].Hash[:6])
+	require.NoError(t, err)
+	require.Equal(t, "# Second\n", string(data))
+
+	files, err := repo.ListFiles(ctx, id)
+	require.NoError(t, err)
+	require.Empty(t, files)
+
+	reverted, err := k.RevertVersion(ctx, id, "2")
+	require.NoError(t, err)
+	require.Equal(t, versions[1].Hash, reverted.Hash)
+	content, err := k.GetContent(ctx, id)
+	require.NoError(t, err)
+	require.Equal(t, "# Second\n", string(content))
+
+	versions, err = k.ListVersions(ctx, id)
+	require.NoError(t, err)
+	require.Len(t, versions, 3)
+	require.Equal(t, versions[0].Hash, versions[2].Hash)
+
+	_, _, err = k.ReadVersion(ctx, id, "7")
+	require.ErrorIs(t, err, kegpkg.ErrNotExist)
+}
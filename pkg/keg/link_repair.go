@@ -0,0 +1,169 @@
+package keg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// LinkRepair describes node ID changes whose inbound "../N" references must
+// be rewritten across a keg.
+type LinkRepair struct {
+	// Renamed maps old node IDs to their new IDs. All renames are applied in a
+	// single pass, so swaps and chains (1→2, 2→3) rewrite correctly.
+	Renamed map[NodeId]NodeId
+
+	// Deleted lists removed nodes. References to them are pointed at the zero
+	// node (../0) so they do not dangle.
+	Deleted []NodeId
+
+	// save, when set, is called before a node is rewritten. An error skips
+	// the node and is reported with the other failures.
+	save func(context.Context, NodeId) error
+}
+
+// RepairedFile records a single node file rewritten by RepairLinks.
+type RepairedFile struct {
+	Node NodeId
+
+	// File is "content" for the node body or "meta" for its metadata.
+	File string
+
+	// Rewrites counts the references changed in the file.
+	Rewrites int
+}
+
+// LinkRepairReport lists every file RepairLinks touched, ordered by node ID
+// then file.
+type LinkRepairReport struct {
+	Files []RepairedFile
+}
+
+// RepairLinks rewrites references to renamed or deleted nodes in every
+// node's content and metadata. Nodes whose content changed are re-indexed so
+// their stats and the dex links/backlinks indexes stay consistent. Failures on
+// individual nodes are collected and returned together with the partial
+// report.
+func (k *Keg) RepairLinks(ctx context.Context, repair LinkRepair) (*LinkRepairReport, error) {
+	report := &LinkRepairReport{}
+	mapping := make(map[string]string, len(repair.Renamed)+len(repair.Deleted))
+	for src, dst := range repair.Renamed {
+		if src.Path() != dst.Path() {
+			mapping[src.Path()] = dst.Path()
+		}
+	}
+	zero := NodeId{ID: 0}.Path()
+	for _, id := range repair.Deleted {
+		if id.Path() != zero {
+			mapping[id.Path()] = zero
+		}
+	}
+	if len(mapping) == 0 {
+		return report, nil
+	}
+	re := nodeLinkPattern(mapping)
+
+	ids, err := k.Repo.ListNodes(ctx)
+	if err != nil {
+		return report, fmt.Errorf("failed to list nodes for link repair: %w", err)
+	}
+
+	var errs []error
+	for _, id := range ids {
+		if err := ctx.Err(); err != nil {
+			return report, errors.Join(append(errs, err)...)
+		}
+
+		raw, readErr := k.Repo.ReadContent(ctx, id)
+		if readErr == nil {
+			raw, readErr = k.openContent(ctx, id, raw)
+		}
+		switch {
+		case errors.Is(readErr, ErrEncrypted):
+			k.Runtime.Logger().Warn("skipping link repair of locked node", "node", id.Path())
+		case readErr == nil:
+			if updated, n := rewriteMappedLinks(re, raw, mapping); n > 0 {
+				if err := repair.saveNode(ctx, id); err != nil {
+					errs = append(errs, err)
+				} else if err := k.SetContent(policyExempt(ctx), id, updated); err != nil {
+					errs = append(errs, fmt.Errorf("failed to rewrite links for node %s: %w", id.Path(), err))
+				} else {
+					report.Files = append(report.Files, RepairedFile{Node: id, File: "content", Rewrites: n})
+				}
+			}
+		case !errors.Is(readErr, ErrNotExist):
+			errs = append(errs, fmt.Errorf("failed to read node content %s: %w", id.Path(), readErr))
+		}
+
+		rawMeta, metaErr := k.Repo.ReadMeta(ctx, id)
+		switch {
+		case metaErr == nil:
+			if updated, n := rewriteMappedLinks(re, rawMeta, mapping); n > 0 {
+				err := repair.saveNode(ctx, id)
+				if err == nil {
+					err = k.withNodeLock(ctx, id, func(lockCtx context.Context) error {
+						return k.Repo.WriteMeta(lockCtx, id, updated)
+					})
+				}
+				if err != nil {
+					errs = append(errs, fmt.Errorf("failed to rewrite meta links for node %s: %w", id.Path(), err))
+				} else {
+					report.Files = append(report.Files, RepairedFile{Node: id, File: "meta", Rewrites: n})
+				}
+			}
+		case !errors.Is(metaErr, ErrNotExist):
+			errs = append(errs, fmt.Errorf("failed to read node meta %s: %w", id.Path(), metaErr))
+		}
+	}
+
+	sort.SliceStable(report.Files, func(i, j int) bool {
+		if c := report.Files[i].Node.Compare(report.Files[j].Node); c != 0 {
+			return c < 0
+		}
+		return report.Files[i].File < report.Files[j].File
+	})
+	return report, errors.Join(errs...)
+}
+
+func (r LinkRepair) saveNode(ctx context.Context, id NodeId) error {
+	if r.save == nil {
+		return nil
+	}
+	return r.save(ctx, id)
+}
+
+// nodeLinkPattern matches canonical relative node links "../N" for any N in
+// mapping, keeping the trailing delimiter so only whole node ids match.
+func nodeLinkPattern(mapping map[string]string) *regexp.Regexp {
+	olds := make([]string, 0, len(mapping))
+	for old := range mapping {
+		olds = append(olds, regexp.QuoteMeta(old))
+	}
+	// Longest first so "12" is preferred over "1" when both are mapped.
+	sort.Slice(olds, func(i, j int) bool { return len(olds[i]) > len(olds[j]) })
+	delimiters := `[[:space:]\)\]\}\>\.,;:!?'\"#]`
+	return regexp.MustCompile(`\.\./\s*(` + strings.Join(olds, "|") + `)(` + delimiters + `|$)`)
+}
+
+func rewriteMappedLinks(re *regexp.Regexp, raw []byte, mapping map[string]string) ([]byte, int) {
+	if len(raw) == 0 {
+		return raw, 0
+	}
+	count := 0
+	out := re.ReplaceAllStringFunc(string(raw), func(m string) string {
+		sub := re.FindStringSubmatch(m)
+		dst, ok := mapping[sub[1]]
+		if !ok {
+			return m
+		}
+		count++
+		return "../" + dst + sub[2]
+	})
+	if count == 0 {
+		return raw, 0
+	}
+	return []byte(out), count
+}
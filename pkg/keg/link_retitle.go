@@ -0,0 +1,100 @@
+package keg
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// bareLinkRE matches a markdown link to a node whose text is empty, the node
+// ID or the link destination itself, such as [](../42), [42](../42) or
+// [../42](../42). Image links are matched too so they can be skipped.
+var bareLinkRE = regexp.MustCompile(`(!?)\[\s*((?:\.\./\s*)?[0-9]*)\s*\]\(\s*\.\./\s*([0-9]+)\s*\)`)
+
+// RetitleLinks replaces the text of bare node links in raw with the title
+// returned for their target, and reports how many links it rewrote. Links
+// whose target has no title are left alone, as are links that already have
+// text of their own.
+func RetitleLinks(raw []byte, title func(id NodeId) string) ([]byte, int) {
+	if len(raw) == 0 {
+		return raw, 0
+	}
+	count := 0
+	out := bareLinkRE.ReplaceAllStringFunc(string(raw), func(m string) string {
+		sub := bareLinkRE.FindStringSubmatch(m)
+		if sub[1] != "" {
+			return m
+		}
+		target, err := strconv.Atoi(sub[3])
+		if err != nil {
+			return m
+		}
+		text := strings.TrimSpace(strings.TrimPrefix(sub[2], "../"))
+		if text != "" && text != sub[3] {
+			return m
+		}
+		t := strings.TrimSpace(title(NodeId{ID: target}))
+		if t == "" {
+			return m
+		}
+		count++
+		return "[" + escapeLinkText(t) + "](../" + sub[3] + ")"
+	})
+	if count == 0 {
+		return raw, 0
+	}
+	return []byte(out), count
+}
+
+// escapeLinkText escapes the brackets in s so it can be used as the text
+// of a markdown link.
+func escapeLinkText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "[", `\[`, "]", `\]`).Replace(s)
+}
+
+// RetitleLinks gives the bare node links in the content of id the current
+// title of their target from the dex, and reports how many links it
+// rewrote. Content without bare links is not written.
+func (k *Keg) RetitleLinks(ctx context.Context, id NodeId) (n int, err error) {
+	ctx, done := k.logOp(ctx, "retitle links", "node", id.Path())
+	defer done(&err)
+	raw, err := k.Repo.ReadContent(ctx, id)
+	if err != nil {
+		return 0, fmt.Errorf("unable to read node %s content: %w", id.Path(), err)
+	}
+	title, err := k.dexTitles(ctx)
+	if err != nil {
+		return 0, err
+	}
+	updated, n := RetitleLinks(raw, title)
+	if n == 0 {
+		return 0, nil
+	}
+	if err := k.SetContent(ctx, id, updated); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// RetitleLinksOnSave reports whether the keg config asks SetContent to
+// retitle bare node links.
+func (k *Keg) RetitleLinksOnSave(ctx context.Context) bool {
+	cfg, err := k.Repo.ReadConfig(ctx)
+	return err == nil && cfg != nil && cfg.RetitleLinks
+}
+
+// dexTitles returns a lookup of node titles from the dex.
+func (k *Keg) dexTitles(ctx context.Context) (func(NodeId) string, error) {
+	dex, err := k.Dex(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read dex: %w", err)
+	}
+	return func(id NodeId) string {
+		if ref := dex.GetRef(ctx, id); ref != nil {
+			return ref.Title
+		}
+		return ""
+	}, nil
+}
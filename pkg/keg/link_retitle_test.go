@@ -0,0 +1,71 @@
+package keg_test
+
+import (
+	"testing"
+
+	"github.com/jlrickert/tapper/pkg/keg"
+	"github.com/jlrickert/tapper/pkg/kegtest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetitleLinks(t *testing.T) {
+	t.Parallel()
+	titles := map[int]string{1: "Go [notes]", 2: "Rust"}
+	title := func(id keg.NodeId) string { return titles[id.ID] }
+
+	tests := []struct {
+		name  string
+		in    string
+		want  string
+		count int
+	}{
+		{name: "empty text", in: "See [](../2).", want: "See [Rust](../2).", count: 1},
+		{name: "destination as text", in: "See [../2](../2).", want: "See [Rust](../2).", count: 1},
+		{name: "id as text", in: "See [ 2 ]( ../2 ).", want: "See [Rust](../2).", count: 1},
+		{name: "escapes brackets", in: "[](../1)", want: `[Go \[notes\]](../1)`, count: 1},
+		{name: "keeps own text", in: "See [rust](../2).", want: "See [rust](../2).", count: 0},
+		{name: "text names other node", in: "See [1](../2).", want: "See [1](../2).", count: 0},
+		{name: "unknown target", in: "See [](../9).", want: "See [](../9).", count: 0},
+		{name: "skips images", in: "![](../2)", want: "![](../2)", count: 0},
+		{name: "several", in: "[](../1) and [../2](../2)", want: `[Go \[notes\]](../1) and [Rust](../2)`, count: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			out, n := keg.RetitleLinks([]byte(tt.in), title)
+			require.Equal(t, tt.want, string(out))
+			require.Equal(t, tt.count, n)
+		})
+	}
+}
+
+func TestKeg_RetitleLinks(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t)
+	ctx, rt := sb.Context(), sb.Runtime()
+	repo := keg.NewMemoryRepo(rt)
+	k := kegtest.NewKegFixture().
+		Node(1, "Target", "Body.").
+		Node(2, "Source", "See [](../1) and [../1](../1).").
+		MustBuild(t, ctx, repo, rt)
+
+	n, err := k.RetitleLinks(ctx, keg.NodeId{ID: 2})
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+	raw, err := repo.ReadContent(ctx, keg.NodeId{ID: 2})
+	require.NoError(t, err)
+	require.Contains(t, string(raw), "See [Target](../1) and [Target](../1).")
+
+	n, err = k.RetitleLinks(ctx, keg.NodeId{ID: 2})
+	require.NoError(t, err)
+	require.Zero(t, n)
+
+	// With retitleLinks set, SetContent retitles links on save.
+	require.NoError(t, k.UpdateConfig(ctx, func(cfg *keg.Config) {
+		cfg.RetitleLinks = true
+	}))
+	require.NoError(t, k.SetContent(ctx, keg.NodeId{ID: 2}, []byte("# Source\n\nAgain [](../1).\n")))
+	raw, err = repo.ReadContent(ctx, keg.NodeId{ID: 2})
+	require.NoError(t, err)
+	require.Equal(t, "# Source\n\nAgain [Target](../1).\n", string(raw))
+}
@@ -15,6 +15,12 @@ type Node struct {
 	ID      NodeId
 	Repo    Repository
 	Runtime *toolkit.Runtime
+	// Format is the format hint content is parsed with; empty means
+	// markdown.
+	Format string
+	// Summarizer, when set, writes the lead when Update sees changed
+	// content.
+	Summarizer Summarizer
 
 	data *NodeData
 }
@@ -60,13 +66,17 @@ func (n *Node) Init(ctx context.Context) error {
 	return nil
 }
 
-// getContent retrieves and parses raw markdown content for a node.
+// getContent retrieves and parses raw content for a node.
 func (n *Node) getContent(ctx context.Context, id NodeId) (*NodeContent, error) {
 	raw, err := n.Repo.ReadContent(ctx, id)
 	if err != nil {
 		return nil, err
 	}
-	return ParseContent(n.Runtime, raw, FormatMarkdown)
+	format := n.Format
+	if format == "" {
+		format = FormatMarkdown
+	}
+	return ParseContent(n.Runtime, raw, format)
 }
 
 // getMetaAndStats retrieves and parses YAML metadata plus programmatic stats
@@ -198,10 +208,15 @@ func (n *Node) Update(ctx context.Context) error {
 }
 
 func (n *Node) updateUnlocked(ctx context.Context, now time.Time) error {
+	changed := n.data.ContentChanged()
+	prevLead := n.data.Stats.Lead()
 	err1 := n.data.UpdateMeta(ctx, &now)
 	if n.data.Stats == nil {
 		n.data.Stats = NewStats(now)
 	}
+	if n.Summarizer != nil {
+		applySummary(ctx, n.Runtime.Logger(), n.Summarizer, n.data, prevLead, changed)
+	}
 	n.data.Stats.EnsureTimes(now)
 	err2 := n.saveUnlocked(ctx)
 	return errors.Join(err1, err2)
@@ -252,8 +267,5 @@ func (n *Node) Save(ctx context.Context) error {
 }
 
 func (n *Node) saveUnlocked(ctx context.Context) error {
-	if err := n.Repo.WriteMeta(ctx, n.ID, []byte(n.data.Meta.ToYAML())); err != nil {
-		return err
-	}
-	return n.Repo.WriteStats(ctx, n.ID, n.data.Stats)
+	return n.Repo.WriteNode(ctx, n.ID, nil, []byte(n.data.Meta.ToYAML()), n.data.Stats)
 }
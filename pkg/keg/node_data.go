@@ -3,6 +3,8 @@ package keg
 import (
 	"context"
 	"time"
+
+	"golang.org/x/text/unicode/norm"
 )
 
 // NodeData is a high-level representation of a KEG node. Implementations may
@@ -45,14 +47,15 @@ func (n *NodeData) ContentChanged() bool {
 }
 
 // Title returns the canonical title for the node. Prefer stats title and fall
-// back to parsed content title when available.
+// back to parsed content title when available. Stats titles written before
+// titles were normalized are converted to Unicode NFC.
 func (n *NodeData) Title() string {
 	if n == nil {
 		return ""
 	}
 	if n.Stats != nil {
 		if t := n.Stats.Title(); t != "" {
-			return t
+			return norm.NFC.String(t)
 		}
 	}
 	if n.Content != nil {
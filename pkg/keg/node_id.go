@@ -68,6 +68,40 @@ func NewTempNode(ctx context.Context, id string) *NodeId {
 	return &NodeId{ID: baseID, Code: code, Alias: alias}
 }
 
+// DefaultMaxNodeID is the largest node ID accepted by default. It keeps IDs
+// within 32 bits so they survive every platform and tool that reads a keg.
+const DefaultMaxNodeID = 1<<31 - 1
+
+// MaxNodeID is the largest node ID ParseNode accepts. Programs embedding the
+// package may lower it; a keg can set a tighter limit for new nodes with
+// maxNodeId in its config.
+var MaxNodeID = DefaultMaxNodeID
+
+// ReservedNodeDirNames are directory names used for keg structure at the keg
+// root or inside node directories. They can never name a node.
+var ReservedNodeDirNames = []string{
+	"dex",
+	ArchiveDirName,
+	BlobsDirName,
+	NodeImagesDir,
+	NodeAttachmentsDir,
+	NodeItemMetaDir,
+	NodeVersionsDir,
+	KegLockFile,
+	nodeWriteStagingDir,
+	nodeWriteCommitDir,
+}
+
+// IsReservedNodeDirName reports whether name is one of ReservedNodeDirNames.
+func IsReservedNodeDirName(name string) bool {
+	for _, reserved := range ReservedNodeDirNames {
+		if strings.EqualFold(name, reserved) {
+			return true
+		}
+	}
+	return false
+}
+
 // Path returns the path component for this NodeId suitable for use in file
 // names or URLs.
 //
@@ -91,6 +125,22 @@ func (id NodeId) Path() string {
 
 func (id NodeId) String() string { return id.Path() }
 
+// Address returns the short "<alias>:<id>" form used to tell nodes of
+// different kegs apart in multi-keg output. Without an alias it is Path.
+//
+// Examples:
+//
+//	NodeId{ID:42}                             -> "42"
+//	NodeId{ID:42, Alias:"work"}               -> "work:42"
+//	NodeId{ID:42, Alias:"work", Code:"0001"}  -> "work:42-0001"
+func (id NodeId) Address() string {
+	local := NodeId{ID: id.ID, Code: id.Code}.Path()
+	if id.Alias == "" {
+		return local
+	}
+	return id.Alias + ":" + local
+}
+
 // ParseNode converts a string into a *NodeId.
 //
 // Accepted forms:
@@ -103,18 +153,28 @@ func (id NodeId) String() string { return id.Path() }
 //
 //   - "keg:<alias>/<id>" or "keg:<alias>/<id>-<code>" to include an alias.
 //
+//   - "<alias>:<id>" or "<alias>:<id>-<code>", the short form of the above
+//     emitted by Address.
+//
 // Examples:
 //
 //	"42"               -> &NodeId{ID:42, Code:""}, nil
 //	"42-0001"          -> &NodeId{ID:42, Code:"0001"}, nil
 //	"keg:work/23"      -> &NodeId{ID:23, Keg:"work"}, nil
 //	"keg:work/23-0001" -> &NodeId{ID:23, Keg:"work", Code:"0001"}, nil
+//	"work:23"          -> &NodeId{ID:23, Keg:"work"}, nil
 //	"0023"             -> nil, error (leading zeros not allowed)
+//	"dex"              -> nil, error (reserved directory name)
 //	""                 -> nil, error
+//
+// IDs above MaxNodeID are rejected.
 func ParseNode(s string) (*NodeId, error) {
 	if s == "" {
 		return nil, fmt.Errorf("parse node id: empty")
 	}
+	if IsReservedNodeDirName(s) {
+		return nil, fmt.Errorf("parse node id %q: reserved directory name: %w", s, ErrInvalid)
+	}
 
 	// handle optional keg alias prefix "keg:<alias>/..."
 	alias := ""
@@ -130,6 +190,12 @@ func ParseNode(s string) (*NodeId, error) {
 			return nil, fmt.Errorf("parse node id %q: empty alias", s)
 		}
 		s = rest[slash+1:]
+	} else if colon := strings.IndexByte(s, ':'); colon >= 0 {
+		alias = s[:colon]
+		if alias == "" || strings.ContainsAny(alias, "/\\") {
+			return nil, fmt.Errorf("parse node id %q: invalid alias", s)
+		}
+		s = s[colon+1:]
 	}
 
 	// find hyphen if present
@@ -165,6 +231,9 @@ func ParseNode(s string) (*NodeId, error) {
 	if n < 0 {
 		return nil, fmt.Errorf("parse node id %q: negative id", s)
 	}
+	if n > MaxNodeID {
+		return nil, fmt.Errorf("parse node id %q: id exceeds maximum %d: %w", s, MaxNodeID, ErrInvalid)
+	}
 
 	// If there is a code part, validate it's exactly 4 digits.
 	if codePart != "" {
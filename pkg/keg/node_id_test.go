@@ -0,0 +1,105 @@
+package keg_test
+
+import (
+	"bytes"
+	"log/slog"
+	"strconv"
+	"testing"
+
+	"github.com/jlrickert/cli-toolkit/sandbox"
+	"github.com/jlrickert/tapper/pkg/keg"
+	kegurl "github.com/jlrickert/tapper/pkg/keg_url"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseNode_RejectsReservedNames(t *testing.T) {
+	t.Parallel()
+
+	for _, name := range []string{"dex", "archive", "blobs", "images", "assets", ".keg-lock", "DEX"} {
+		_, err := keg.ParseNode(name)
+		require.ErrorIs(t, err, keg.ErrInvalid, name)
+		require.Contains(t, err.Error(), "reserved directory name")
+	}
+}
+
+func TestParseNode_RejectsIDsAboveMaximum(t *testing.T) {
+	t.Parallel()
+
+	n, err := keg.ParseNode(strconv.Itoa(keg.DefaultMaxNodeID))
+	require.NoError(t, err)
+	require.Equal(t, keg.DefaultMaxNodeID, n.ID)
+
+	_, err = keg.ParseNode(strconv.Itoa(keg.DefaultMaxNodeID + 1))
+	require.ErrorIs(t, err, keg.ErrInvalid)
+	require.Contains(t, err.Error(), "exceeds maximum")
+}
+
+func TestParseNode_AliasAddress(t *testing.T) {
+	t.Parallel()
+
+	n, err := keg.ParseNode("work:23-0001")
+	require.NoError(t, err)
+	require.Equal(t, keg.NodeId{ID: 23, Code: "0001", Alias: "work"}, *n)
+	require.Equal(t, "work:23-0001", n.Address())
+
+	long, err := keg.ParseNode("keg:work/23")
+	require.NoError(t, err)
+	require.Equal(t, "work:23", long.Address())
+	require.Equal(t, "23", keg.NodeId{ID: 23}.Address())
+
+	for _, raw := range []string{":23", "work:", "work:abc", "a/b:1"} {
+		_, err := keg.ParseNode(raw)
+		require.Error(t, err, raw)
+	}
+}
+
+func TestFsRepo_ListNodesWarnsAboutMalformedIDs(t *testing.T) {
+	t.Parallel()
+	fx := NewSandbox(t, sandbox.WithFixture("empty", "~/empty"))
+	ctx := fx.Context()
+	rt := fx.Runtime()
+
+	var logs bytes.Buffer
+	require.NoError(t, rt.SetLogger(slog.New(slog.NewTextHandler(&logs, nil))))
+
+	r := keg.NewFsRepo("~/empty", rt)
+	require.NoError(t, r.WriteContent(ctx, keg.NodeId{ID: 5}, []byte("# five\n")))
+	for _, dir := range []string{"0023", "12-ab", "notes"} {
+		require.NoError(t, rt.Mkdir("~/empty/"+dir, 0o755, true))
+	}
+
+	ids, err := r.ListNodes(ctx)
+	require.NoError(t, err)
+	require.Contains(t, ids, keg.NodeId{ID: 5})
+	require.NotContains(t, ids, keg.NodeId{ID: 23})
+
+	out := logs.String()
+	require.Contains(t, out, "looks like a malformed node id")
+	require.Contains(t, out, "dir=0023")
+	require.Contains(t, out, "dir=12-ab")
+	require.NotContains(t, out, "dir=notes")
+	require.NotContains(t, out, "dir=dex")
+}
+
+func TestCreate_RespectsConfiguredMaxNodeID(t *testing.T) {
+	t.Parallel()
+	f := NewSandbox(t, sandbox.WithFixture("empty", "repofs_max"))
+	ctx := f.Context()
+
+	k, err := keg.NewKegFromTarget(ctx, kegurl.NewFile("repofs_max"), f.Runtime())
+	require.NoError(t, err)
+	require.NoError(t, k.Init(ctx))
+	require.NoError(t, k.UpdateConfig(ctx, func(cfg *keg.Config) { cfg.MaxNodeID = 1 }))
+
+	id, err := k.Create(ctx, &keg.CreateOptions{Title: "One"})
+	require.NoError(t, err)
+	require.Equal(t, 1, id.ID)
+
+	_, err = k.Create(ctx, &keg.CreateOptions{Title: "Two"})
+	require.ErrorIs(t, err, keg.ErrInvalid)
+	require.Contains(t, err.Error(), "exceeds the maximum of 1")
+
+	exists, err := k.Repo.HasNode(ctx, keg.NodeId{ID: 2})
+	require.NoError(t, err)
+	require.False(t, exists)
+}
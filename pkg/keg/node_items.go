@@ -0,0 +1,248 @@
+package keg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// NodeItems is the item area of a node: its file attachments and images, the
+// metadata sidecars of both, and its recorded content versions. Copies and
+// backups carry it along with the node's content, meta and stats.
+type NodeItems struct {
+	// Files and Images map item names to their content.
+	Files  map[string][]byte
+	Images map[string][]byte
+
+	// FileMeta and ImageMeta map item names to their metadata.
+	FileMeta  map[string]*ItemMeta
+	ImageMeta map[string]*ItemMeta
+
+	// Versions maps names inside NodeVersionsDir, including its index, to
+	// their content.
+	Versions map[string][]byte
+}
+
+func newNodeItems() *NodeItems {
+	return &NodeItems{
+		Files:     map[string][]byte{},
+		Images:    map[string][]byte{},
+		FileMeta:  map[string]*ItemMeta{},
+		ImageMeta: map[string]*ItemMeta{},
+		Versions:  map[string][]byte{},
+	}
+}
+
+// Empty reports whether the node has no items.
+func (n *NodeItems) Empty() bool {
+	return n == nil || len(n.Files)+len(n.Images)+len(n.FileMeta)+len(n.ImageMeta)+len(n.Versions) == 0
+}
+
+// Entries returns the items as files keyed by their slash-separated path
+// below the node directory, laid out as a filesystem keg stores them:
+// "assets/report.pdf", "assets/.meta/report.pdf.json",
+// "assets/.versions/index.tsv" and "images/cover.png".
+func (n *NodeItems) Entries() (map[string][]byte, error) {
+	entries := map[string][]byte{}
+	if n == nil {
+		return entries, nil
+	}
+	for name, data := range n.Files {
+		entries[path.Join(NodeAttachmentsDir, name)] = data
+	}
+	for name, data := range n.Images {
+		entries[path.Join(NodeImagesDir, name)] = data
+	}
+	for name, data := range n.Versions {
+		entries[path.Join(NodeAttachmentsDir, NodeVersionsDir, name)] = data
+	}
+	for dir, metas := range map[string]map[string]*ItemMeta{NodeAttachmentsDir: n.FileMeta, NodeImagesDir: n.ImageMeta} {
+		for name, meta := range metas {
+			data, err := meta.toJSON()
+			if err != nil {
+				return nil, fmt.Errorf("failed to encode metadata of %s: %w", name, err)
+			}
+			entries[path.Join(dir, NodeItemMetaDir, name+".json")] = data
+		}
+	}
+	return entries, nil
+}
+
+// ParseNodeItems reverses NodeItems.Entries. Paths outside the item areas
+// are ignored.
+func ParseNodeItems(entries map[string][]byte) (*NodeItems, error) {
+	items := newNodeItems()
+	for p, data := range entries {
+		dir, rest, ok := strings.Cut(p, "/")
+		if !ok || (dir != NodeAttachmentsDir && dir != NodeImagesDir) {
+			continue
+		}
+		var name string
+		var err error
+		switch {
+		case strings.HasPrefix(rest, NodeItemMetaDir+"/"):
+			name, err = checkAssetName(strings.TrimSuffix(strings.TrimPrefix(rest, NodeItemMetaDir+"/"), ".json"))
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse item %s: %w", p, err)
+			}
+			meta, err := parseItemMeta(data)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse item %s: %w", p, err)
+			}
+			if dir == NodeImagesDir {
+				items.ImageMeta[name] = meta
+			} else {
+				items.FileMeta[name] = meta
+			}
+		case dir == NodeAttachmentsDir && strings.HasPrefix(rest, NodeVersionsDir+"/"):
+			if name, err = checkAssetName(rest); err != nil {
+				return nil, fmt.Errorf("failed to parse item %s: %w", p, err)
+			}
+			items.Versions[strings.TrimPrefix(name, NodeVersionsDir+"/")] = data
+		default:
+			if name, err = checkAssetName(rest); err != nil {
+				return nil, fmt.Errorf("failed to parse item %s: %w", p, err)
+			}
+			if dir == NodeImagesDir {
+				items.Images[name] = data
+			} else {
+				items.Files[name] = data
+			}
+		}
+	}
+	return items, nil
+}
+
+// ReadNodeItems reads the item area of an active node from repo. Item areas
+// the repository does not support are left empty.
+func ReadNodeItems(ctx context.Context, repo Repository, id NodeId) (*NodeItems, error) {
+	items := newNodeItems()
+	itemMeta, hasItemMeta := repoItemMeta(repo)
+	readMeta := func(kind AssetKind, name string, into map[string]*ItemMeta) error {
+		if !hasItemMeta {
+			return nil
+		}
+		meta, err := itemMeta.ReadItemMeta(ctx, id, kind, name)
+		if errors.Is(err, ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read metadata of %s: %w", name, err)
+		}
+		into[name] = meta
+		return nil
+	}
+
+	if files, ok := repo.(RepositoryFiles); ok {
+		names, err := files.ListFiles(ctx, id)
+		if err != nil && !errors.Is(err, ErrNotExist) {
+			return nil, fmt.Errorf("failed to list files: %w", err)
+		}
+		for _, name := range names {
+			data, err := files.ReadFile(ctx, id, name)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read file %s: %w", name, err)
+			}
+			items.Files[name] = data
+			if err := readMeta(AssetKindItem, name, items.FileMeta); err != nil {
+				return nil, err
+			}
+		}
+		if err := readNodeVersions(ctx, files, id, items.Versions); err != nil {
+			return nil, err
+		}
+	}
+
+	if images, ok := repo.(RepositoryImages); ok {
+		names, err := images.ListImages(ctx, id)
+		if err != nil && !errors.Is(err, ErrNotExist) {
+			return nil, fmt.Errorf("failed to list images: %w", err)
+		}
+		for _, name := range names {
+			data, err := images.ReadImage(ctx, id, name)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read image %s: %w", name, err)
+			}
+			items.Images[name] = data
+			if err := readMeta(AssetKindImage, name, items.ImageMeta); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return items, nil
+}
+
+// readNodeVersions reads the version index of a node and the versions it
+// lists into versions, keyed by their name inside NodeVersionsDir.
+func readNodeVersions(ctx context.Context, files RepositoryFiles, id NodeId, versions map[string][]byte) error {
+	index, err := files.ReadFile(ctx, id, nodeVersionsIndexName)
+	if errors.Is(err, ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read version index: %w", err)
+	}
+	listed, err := readVersionIndex(ctx, files, id)
+	if err != nil {
+		return err
+	}
+	versions[path.Base(nodeVersionsIndexName)] = index
+	for _, v := range listed {
+		data, err := files.ReadFile(ctx, id, NodeVersionsDir+"/"+v.Hash)
+		if err != nil {
+			return fmt.Errorf("failed to read version %d: %w", v.Number, err)
+		}
+		versions[v.Hash] = data
+	}
+	return nil
+}
+
+// WriteNodeItems writes items into the item area of an active node in repo.
+// It returns ErrNotSupported when repo cannot hold an item kind items has.
+func WriteNodeItems(ctx context.Context, repo Repository, id NodeId, items *NodeItems) error {
+	if items.Empty() {
+		return nil
+	}
+	files, hasFiles := repo.(RepositoryFiles)
+	if !hasFiles && len(items.Files)+len(items.Versions) > 0 {
+		return fmt.Errorf("%s backend cannot store files: %w", repo.Name(), ErrNotSupported)
+	}
+	for name, data := range items.Files {
+		if err := files.WriteFile(ctx, id, name, data); err != nil {
+			return fmt.Errorf("failed to write file %s: %w", name, err)
+		}
+	}
+	for name, data := range items.Versions {
+		if err := files.WriteFile(ctx, id, NodeVersionsDir+"/"+name, data); err != nil {
+			return fmt.Errorf("failed to write version %s: %w", name, err)
+		}
+	}
+
+	images, hasImages := repo.(RepositoryImages)
+	if !hasImages && len(items.Images) > 0 {
+		return fmt.Errorf("%s backend cannot store images: %w", repo.Name(), ErrNotSupported)
+	}
+	for name, data := range items.Images {
+		if err := images.WriteImage(ctx, id, name, data); err != nil {
+			return fmt.Errorf("failed to write image %s: %w", name, err)
+		}
+	}
+
+	if len(items.FileMeta)+len(items.ImageMeta) == 0 {
+		return nil
+	}
+	itemMeta, ok := repoItemMeta(repo)
+	if !ok {
+		return fmt.Errorf("%s backend cannot store item metadata: %w", repo.Name(), ErrNotSupported)
+	}
+	for kind, metas := range map[AssetKind]map[string]*ItemMeta{AssetKindItem: items.FileMeta, AssetKindImage: items.ImageMeta} {
+		for name, meta := range metas {
+			if err := itemMeta.WriteItemMeta(ctx, id, kind, name, meta); err != nil {
+				return fmt.Errorf("failed to write metadata of %s: %w", name, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package keg_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jlrickert/tapper/pkg/keg"
+	"github.com/jlrickert/tapper/pkg/kegtest"
+	"github.com/stretchr/testify/require"
+)
+
+// assetRepo is the asset surface shared by the built-in backends beyond
+// keg.RepositoryFiles and keg.RepositoryImages.
+type assetRepo interface {
+	keg.Repository
+	ListAssets(ctx context.Context, id keg.NodeId, kind keg.AssetKind) ([]string, error)
+	WriteAsset(ctx context.Context, id keg.NodeId, kind keg.AssetKind, name string, data []byte) error
+}
+
+func newMemoryRepo(t *testing.T) keg.Repository {
+	return keg.NewMemoryRepo(NewSandbox(t).Runtime())
+}
+
+func newFsRepo(t *testing.T) keg.Repository {
+	return keg.NewFsRepo(t.TempDir(), NewSandbox(t).Runtime())
+}
+
+func TestRepository_Conformance(t *testing.T) {
+	t.Parallel()
+	t.Run("memory", func(t *testing.T) {
+		t.Parallel()
+		kegtest.RunRepositoryConformance(t, newMemoryRepo)
+	})
+	t.Run("filesystem", func(t *testing.T) {
+		t.Parallel()
+		kegtest.RunRepositoryConformance(t, newFsRepo)
+	})
+}
+
+func TestRepository_AssetErrorConformance(t *testing.T) {
+	t.Parallel()
+	for name, newRepo := range map[string]func(*testing.T) keg.Repository{
+		"memory":     newMemoryRepo,
+		"filesystem": newFsRepo,
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctx := t.Context()
+			repo := newRepo(t).(assetRepo)
+			missing, node := keg.NodeId{ID: 99}, keg.NodeId{ID: 1}
+
+			_, err := repo.ListAssets(ctx, missing, keg.AssetKindImage)
+			require.ErrorIs(t, err, keg.ErrNotExist)
+			var nf *keg.NodeNotFoundError
+			require.ErrorAs(t, err, &nf)
+			require.Equal(t, missing, nf.ID)
+
+			require.NoError(t, repo.WriteContent(ctx, node, []byte("# One\n")))
+			_, err = repo.ListAssets(ctx, node, keg.AssetKind("bogus"))
+			require.ErrorIs(t, err, keg.ErrInvalid)
+			err = repo.WriteAsset(ctx, node, keg.AssetKindItem, "../escape.txt", []byte("x"))
+			require.ErrorIs(t, err, keg.ErrInvalidName)
+		})
+	}
+}
+
+func TestRepository_NodeItemsConformance(t *testing.T) {
+	t.Parallel()
+	for name, newRepo := range map[string]func(*testing.T) keg.Repository{
+		"memory":     newMemoryRepo,
+		"filesystem": newFsRepo,
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctx := t.Context()
+			repo := newRepo(t)
+			node := keg.NodeId{ID: 1}
+			stats := &keg.NodeStats{}
+			stats.SetTitle("One")
+			require.NoError(t, repo.WriteNode(ctx, node, []byte("# One\n"), nil, stats))
+			want := &keg.NodeItems{
+				Files:     map[string][]byte{"report.txt": []byte("report")},
+				Images:    map[string][]byte{"cover.png": []byte("png")},
+				FileMeta:  map[string]*keg.ItemMeta{"report.txt": {Alt: "Quarterly report", Size: 6}},
+				ImageMeta: map[string]*keg.ItemMeta{"cover.png": {Alt: "Cover", Size: 3}},
+				Versions: map[string][]byte{
+					"index.tsv": []byte("abc\t2025-01-02T03:04:05Z\t7\n"),
+					"abc":       []byte("# Zero\n"),
+				},
+			}
+			require.NoError(t, keg.WriteNodeItems(ctx, repo, node, want))
+
+			got, err := keg.ReadNodeItems(ctx, repo, node)
+			require.NoError(t, err)
+			require.Equal(t, want, got)
+			entries, err := got.Entries()
+			require.NoError(t, err)
+			require.Contains(t, entries, "assets/.meta/report.txt.json")
+			require.Contains(t, entries, "assets/.versions/abc")
+			parsed, err := keg.ParseNodeItems(entries)
+			require.NoError(t, err)
+			require.Equal(t, want, parsed)
+
+			require.NoError(t, repo.(keg.RepositoryArchive).ArchiveNode(ctx, node))
+			state := repo.(keg.RepositoryArchivedState)
+			archived, err := state.ReadArchivedItems(ctx, node)
+			require.NoError(t, err)
+			require.Equal(t, want, archived)
+			archivedStats, err := state.ReadArchivedStats(ctx, node)
+			require.NoError(t, err)
+			require.Equal(t, "One", archivedStats.Title())
+		})
+	}
+}
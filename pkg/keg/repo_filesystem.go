@@ -8,8 +8,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"slices"
-	"syscall"
+	"strings"
 	"time"
 
 	appCtx "github.com/jlrickert/cli-toolkit/apppaths"
@@ -24,6 +23,11 @@ const (
 	KegLockFile             = ".keg-lock"
 	NodeImagesDir           = "images"
 	NodeAttachmentsDir      = "assets"
+
+	// nodeWriteStagingDir and nodeWriteCommitDir hold the files of an
+	// in-progress WriteNode before and after it is committed.
+	nodeWriteStagingDir = ".keg-write"
+	nodeWriteCommitDir  = ".keg-commit"
 )
 
 // FsRepo implements [Repository] using the local filesystem as storage. It
@@ -41,6 +45,9 @@ type FsRepo struct {
 	// SnapshotCheckpointInterval controls how many patch revisions may occur
 	// after a checkpoint before the next snapshot is stored as a full blob.
 	SnapshotCheckpointInterval int
+	// Durability selects whether writes are fsynced. The zero value behaves
+	// like DurabilityDefault.
+	Durability Durability
 
 	runtime *toolkit.Runtime
 }
@@ -107,7 +114,11 @@ func NewFsRepoFromEnvOrSearch(ctx context.Context, rt *toolkit.Runtime) (*FsRepo
 
 	// 3) if in a git project, find git root and search the project tree
 	if gitRoot := appCtx.FindGitRoot(ctx, rt, cwd); gitRoot != "" {
-		if kp := findKegRecursive(gitRoot, candidates); kp != "" {
+		kp, err := findKegRecursive(ctx, gitRoot, candidates)
+		if err != nil {
+			return nil, NewBackendError(f.Name(), "NewFsRepoFromEnvOrSearch", 0, err, false)
+		}
+		if kp != "" {
 			f := &FsRepo{
 				Root:            filepath.Dir(kp), // directory containing the keg file
 				ContentFilename: MarkdownContentFilename,
@@ -121,7 +132,11 @@ func NewFsRepoFromEnvOrSearch(ctx context.Context, rt *toolkit.Runtime) (*FsRepo
 
 	// 4) traverse current directory recursively (in case the keg is somewhere
 	// under cwd)
-	if kp := findKegRecursive(cwd, candidates); kp != "" {
+	kp, err := findKegRecursive(ctx, cwd, candidates)
+	if err != nil {
+		return nil, NewBackendError(f.Name(), "NewFsRepoFromEnvOrSearch", 0, err, false)
+	}
+	if kp != "" {
 		f := &FsRepo{
 			Root:            filepath.Dir(kp),
 			ContentFilename: MarkdownContentFilename,
@@ -180,7 +195,7 @@ func resolveKegFromEnv(ctx context.Context, rt *toolkit.Runtime, v string, candi
 	if err == nil && info.Mode().IsRegular() {
 		// env pointed to a file; verify its name is a candidate
 		base := filepath.Base(v)
-		if slices.Contains(candidates, base) {
+		if isKegFileName(candidates, base) {
 			return envResolveResult{rootDir: filepath.Dir(v), kegPath: v}, nil
 		}
 		return envResolveResult{}, NewBackendError("fs",
@@ -221,26 +236,46 @@ func findKegInDir(ctx context.Context, rt *toolkit.Runtime, dir string, candidat
 	return ""
 }
 
+// isKegFileName reports whether name is one of the candidate keg file names.
+// Matching ignores case on case-insensitive filesystems such as NTFS, so a
+// "KEG" file found while walking a tree is recognized there.
+func isKegFileName(candidates []string, name string) bool {
+	for _, c := range candidates {
+		if name == c || (caseInsensitiveFileNames && strings.EqualFold(name, c)) {
+			return true
+		}
+	}
+	return false
+}
+
 // findKegRecursive walks root and returns the first matched keg file path, or
-// "" if none.
-func findKegRecursive(root string, candidates []string) string {
+// "" if none. The walk stops with ctx's error when ctx is canceled.
+func findKegRecursive(ctx context.Context, root string, candidates []string) (string, error) {
 	// use WalkDir for efficiency; stop early on first found.
 	var found string
-	filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+	walkErr := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if ctx != nil {
+			if cerr := ctx.Err(); cerr != nil {
+				return cerr
+			}
+		}
 		if err != nil || found != "" {
 			// skip on error or already found
 			return nil
 		}
 		if d.Type().IsRegular() {
 			base := filepath.Base(path)
-			if slices.Contains(candidates, base) {
+			if isKegFileName(candidates, base) {
 				found = path
 				return nil
 			}
 		}
 		return nil
 	})
-	return found
+	if walkErr != nil {
+		return "", walkErr
+	}
+	return found, nil
 }
 
 // ------------------ Repository interface implementation ------------------
@@ -269,6 +304,12 @@ func (f *FsRepo) Runtime() *toolkit.Runtime {
 	return f.runtime
 }
 
+// rename moves src to dst through the runtime, retrying on platforms where a
+// destination held open by another process blocks the rename.
+func (f *FsRepo) rename(src, dst string) error {
+	return renameWithRetry(func() error { return f.runtime.Rename(src, dst) })
+}
+
 // lockInfo is the JSON structure written into lock files for process-aware
 // stale lock detection.
 type lockInfo struct {
@@ -294,15 +335,7 @@ func (f *FsRepo) isLockStale(lockPath string) bool {
 	if info.PID <= 0 {
 		return true
 	}
-	proc, err := os.FindProcess(info.PID)
-	if err != nil {
-		return true
-	}
-	// Signal 0 checks existence without killing.
-	if err := proc.Signal(syscall.Signal(0)); err != nil {
-		return true // process is dead
-	}
-	return false
+	return !processAlive(info.PID)
 }
 
 // writeLockMetadata writes process identity JSON into the lock directory.
@@ -377,7 +410,10 @@ func (f *FsRepo) WithNodeLock(ctx context.Context, id NodeId, fn func(context.Co
 	}
 
 	lockedCtx := contextWithNodeLock(ctx, id)
-	runErr := fn(lockedCtx)
+	runErr := f.recoverNodeWrite(nodeDir)
+	if runErr == nil {
+		runErr = fn(lockedCtx)
+	}
 
 	unlockErr := f.runtime.Remove(lockPath, true)
 	if unlockErr != nil && !os.IsNotExist(unlockErr) {
@@ -406,12 +442,23 @@ func (f *FsRepo) Next(ctx context.Context) (NodeId, error) {
 			if !e.IsDir() {
 				continue
 			}
-			if n, perr := ParseNode(e.Name()); perr == nil && n != nil {
+			// Drafts use their own "N-CODE" directories and do not reserve N.
+			if n, perr := ParseNode(e.Name()); perr == nil && n != nil && n.Code == "" {
 				if n.ID > maxID {
 					maxID = n.ID
 				}
 			}
 		}
+		// Archived IDs stay reserved so unarchiving cannot collide.
+		archived, err := f.ListArchived(ctx)
+		if err != nil {
+			return NodeId{}, err
+		}
+		for _, id := range archived {
+			if id.ID > maxID {
+				maxID = id.ID
+			}
+		}
 
 		candidate := maxID + 1
 		nodeDir := filepath.Join(f.Root, NodeId{ID: candidate}.Path())
@@ -435,7 +482,7 @@ func (f *FsRepo) ReadContent(ctx context.Context, id NodeId) ([]byte, error) {
 		return nil, err
 	}
 	if !exists {
-		return nil, ErrNotExist
+		return nil, NewNodeNotFoundError(id)
 	}
 	nodeDir := filepath.Join(f.Root, id.Path())
 	contentPath := filepath.Join(nodeDir, f.ContentFilename)
@@ -457,7 +504,7 @@ func (f *FsRepo) ReadMeta(ctx context.Context, id NodeId) ([]byte, error) {
 		return nil, err
 	}
 	if !exists {
-		return nil, ErrNotExist
+		return nil, NewNodeNotFoundError(id)
 	}
 	nodeDir := filepath.Join(f.Root, id.Path())
 	metaPath := filepath.Join(nodeDir, f.MetaFilename)
@@ -478,7 +525,7 @@ func (f *FsRepo) ReadStats(ctx context.Context, id NodeId) (*NodeStats, error) {
 		return nil, err
 	}
 	if !exists {
-		return nil, ErrNotExist
+		return nil, NewNodeNotFoundError(id)
 	}
 	nodeDir := filepath.Join(f.Root, id.Path())
 	statsPath := filepath.Join(nodeDir, f.StatsFilename)
@@ -488,11 +535,11 @@ func (f *FsRepo) ReadStats(ctx context.Context, id NodeId) (*NodeStats, error) {
 			// Compatibility path: parse stats from legacy meta.yaml content.
 			legacy, lerr := f.ReadMeta(ctx, id)
 			if lerr != nil || len(bytes.TrimSpace(legacy)) == 0 {
-				return nil, ErrNotExist
+				return nil, NewNotFoundError("stats", id.Path())
 			}
 			stats, perr := ParseStats(ctx, legacy)
 			if perr != nil {
-				return nil, ErrNotExist
+				return nil, NewNotFoundError("stats", id.Path())
 			}
 			return stats, nil
 		}
@@ -533,18 +580,30 @@ func (f *FsRepo) NodeFilesExist(ctx context.Context, id NodeId) (bool, bool, err
 }
 
 func (f *FsRepo) ListNodes(ctx context.Context) ([]NodeId, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, NewBackendError(f.Name(), "ListNodes", 0, err, false)
+	}
 	entries, err := f.runtime.ReadDir(f.Root)
 	if err != nil {
 		return nil, NewBackendError(f.Name(), "ListNodes", 0, err, false)
 	}
 	var ids []NodeId
 	for _, e := range entries {
+		if err := ctx.Err(); err != nil {
+			return nil, NewBackendError(f.Name(), "ListNodes", 0, err, false)
+		}
 		if !e.IsDir() {
 			continue
 		}
 		// Only include directory names that parse as valid NodeId identifiers.
-		if n, perr := ParseNode(e.Name()); perr == nil && n != nil && n.Valid() {
+		n, perr := ParseNode(e.Name())
+		if perr == nil && n != nil && n.Valid() {
 			ids = append(ids, *n)
+			continue
+		}
+		if looksLikeNodeID(e.Name()) {
+			f.runtime.Logger().Warn("ignoring directory that looks like a malformed node id",
+				"keg", f.Root, "dir", e.Name(), "error", perr)
 		}
 	}
 	// sort ascending using NodeId.Compare for deterministic ordering
@@ -562,6 +621,12 @@ func (f *FsRepo) ListNodes(ctx context.Context) ([]NodeId, error) {
 	return ids, nil
 }
 
+// looksLikeNodeID reports whether a directory name starts like a node ID, so
+// a failure to parse it is probably a mistake rather than unrelated content.
+func looksLikeNodeID(name string) bool {
+	return name != "" && name[0] >= '0' && name[0] <= '9'
+}
+
 // ListAssets implements Repository.
 func (f *FsRepo) ListAssets(ctx context.Context, id NodeId, kind AssetKind) ([]string, error) {
 	nodeDir := filepath.Join(f.Root, id.Path())
@@ -570,7 +635,7 @@ func (f *FsRepo) ListAssets(ctx context.Context, id NodeId, kind AssetKind) ([]s
 		return nil, err
 	}
 	if !exists {
-		return nil, fmt.Errorf("node %s does not exist: %w", nodeDir, ErrNotExist)
+		return nil, NewNodeNotFoundError(id)
 	}
 
 	var dir string
@@ -580,7 +645,7 @@ func (f *FsRepo) ListAssets(ctx context.Context, id NodeId, kind AssetKind) ([]s
 	case AssetKindItem:
 		dir = filepath.Join(nodeDir, NodeAttachmentsDir)
 	default:
-		return nil, fmt.Errorf("unknown asset kind %q", kind)
+		return nil, NewUnknownAssetKindError(kind)
 	}
 
 	entries, err := f.runtime.ReadDir(dir)
@@ -593,7 +658,10 @@ func (f *FsRepo) ListAssets(ctx context.Context, id NodeId, kind AssetKind) ([]s
 
 	var names []string
 	for _, e := range entries {
-		if kind == AssetKindImage && e.Name() == ".meta" {
+		if e.Name() == NodeItemMetaDir {
+			continue
+		}
+		if kind == AssetKindItem && e.Name() == NodeVersionsDir {
 			continue
 		}
 		names = append(names, e.Name())
@@ -613,7 +681,7 @@ func (f *FsRepo) WriteContent(ctx context.Context, id NodeId, data []byte) error
 		return NewBackendError(f.Name(), "WriteContent", 0, err, false)
 	}
 
-	err := f.runtime.AtomicWriteFile(contentPath, data, 0o644)
+	err := f.atomicWrite(contentPath, data, 0o644)
 	if err != nil {
 		return NewBackendError(f.Name(), "WriteContent", 0, err, false)
 	}
@@ -631,7 +699,7 @@ func (f *FsRepo) WriteMeta(ctx context.Context, id NodeId, data []byte) error {
 		return NewBackendError(f.Name(), "WriteMeta", 0, err, false)
 	}
 
-	err := f.runtime.AtomicWriteFile(metaPath, data, 0o644)
+	err := f.atomicWrite(metaPath, data, 0o644)
 	if err != nil {
 		return NewBackendError(f.Name(), "WriteMeta", 0, err, false)
 	}
@@ -657,21 +725,128 @@ func (f *FsRepo) WriteStats(ctx context.Context, id NodeId, stats *NodeStats) er
 	if err != nil {
 		return NewBackendError(f.Name(), "WriteStats", 0, err, false)
 	}
-	if err := f.runtime.AtomicWriteFile(statsPath, data, 0o644); err != nil {
+	if err := f.atomicWrite(statsPath, data, 0o644); err != nil {
 		return NewBackendError(f.Name(), "WriteStats", 0, err, false)
 	}
 	return nil
 }
 
+// WriteNode implements Repository. The files are staged in a temporary
+// directory inside the node, which is renamed to mark the update committed
+// before each file is renamed into place. An update interrupted before the
+// commit is discarded and one interrupted after it is finished the next time
+// the node is locked (see recoverNodeWrite).
+func (f *FsRepo) WriteNode(ctx context.Context, id NodeId, content, meta []byte, stats *NodeStats) error {
+	nodeDir := filepath.Join(f.Root, id.Path())
+	if err := f.runtime.Mkdir(nodeDir, 0o755, true); err != nil {
+		return NewBackendError(f.Name(), "WriteNode", 0, err, false)
+	}
+	if err := f.recoverNodeWrite(nodeDir); err != nil {
+		return err
+	}
+
+	files := map[string][]byte{}
+	if content != nil {
+		files[f.ContentFilename] = content
+	}
+	if meta != nil {
+		files[f.MetaFilename] = meta
+	}
+	if stats != nil {
+		data, err := stats.ToJSON()
+		if err != nil {
+			return NewBackendError(f.Name(), "WriteNode", 0, err, false)
+		}
+		files[f.StatsFilename] = data
+	}
+	if len(files) == 0 {
+		return nil
+	}
+
+	staging := filepath.Join(nodeDir, nodeWriteStagingDir)
+	if err := f.runtime.Mkdir(staging, 0o755, false); err != nil {
+		return NewBackendError(f.Name(), "WriteNode", 0, err, false)
+	}
+	for name, data := range files {
+		path := filepath.Join(staging, name)
+		if err := f.runtime.WriteFile(path, data, 0o644); err != nil {
+			_ = f.runtime.Remove(staging, true)
+			return NewBackendError(f.Name(), "WriteNode", 0, err, false)
+		}
+		if err := f.syncPath(path); err != nil {
+			_ = f.runtime.Remove(staging, true)
+			return NewBackendError(f.Name(), "WriteNode", 0, err, false)
+		}
+	}
+	if err := f.syncPath(staging); err != nil {
+		_ = f.runtime.Remove(staging, true)
+		return NewBackendError(f.Name(), "WriteNode", 0, err, false)
+	}
+	if err := f.rename(staging, filepath.Join(nodeDir, nodeWriteCommitDir)); err != nil {
+		_ = f.runtime.Remove(staging, true)
+		return NewBackendError(f.Name(), "WriteNode", 0, err, false)
+	}
+	// The commit directory is what recovery rolls forward, so it must reach
+	// the disk before any file is moved out of it.
+	if err := f.syncPath(nodeDir); err != nil {
+		return NewBackendError(f.Name(), "WriteNode", 0, err, false)
+	}
+	return f.applyNodeWrite(nodeDir)
+}
+
+// applyNodeWrite renames every file of a committed WriteNode into the node
+// directory and removes the commit directory.
+func (f *FsRepo) applyNodeWrite(nodeDir string) error {
+	commit := filepath.Join(nodeDir, nodeWriteCommitDir)
+	entries, err := f.runtime.ReadDir(commit)
+	if err != nil {
+		return NewBackendError(f.Name(), "WriteNode", 0, err, false)
+	}
+	for _, e := range entries {
+		if err := f.rename(filepath.Join(commit, e.Name()), filepath.Join(nodeDir, e.Name())); err != nil {
+			return NewBackendError(f.Name(), "WriteNode", 0, err, false)
+		}
+	}
+	if err := f.runtime.Remove(commit, true); err != nil {
+		return NewBackendError(f.Name(), "WriteNode", 0, err, false)
+	}
+	if err := f.syncPath(nodeDir); err != nil {
+		return NewBackendError(f.Name(), "WriteNode", 0, err, false)
+	}
+	return nil
+}
+
+// recoverNodeWrite cleans up after a WriteNode that was interrupted, for
+// example by a crash. A committed update is rolled forward; a staged but
+// uncommitted one is dropped. The caller must hold the node lock.
+func (f *FsRepo) recoverNodeWrite(nodeDir string) error {
+	if _, err := f.runtime.Stat(filepath.Join(nodeDir, nodeWriteCommitDir), false); err == nil {
+		if err := f.applyNodeWrite(nodeDir); err != nil {
+			return err
+		}
+	}
+	staging := filepath.Join(nodeDir, nodeWriteStagingDir)
+	if _, err := f.runtime.Stat(staging, false); err == nil {
+		if err := f.runtime.Remove(staging, true); err != nil {
+			return NewBackendError(f.Name(), "WriteNode", 0, err, false)
+		}
+	}
+	return nil
+}
+
 // WriteAsset implements Repository.
 func (f *FsRepo) WriteAsset(ctx context.Context, id NodeId, kind AssetKind, name string, data []byte) error {
+	name, err := checkAssetName(name)
+	if err != nil {
+		return err
+	}
 	nodeDir := filepath.Join(f.Root, id.Path())
 	exists, err := f.HasNode(ctx, id)
 	if err != nil {
 		return err
 	}
 	if !exists {
-		return ErrNotExist
+		return NewNodeNotFoundError(id)
 	}
 
 	var assetPath string
@@ -681,7 +856,7 @@ func (f *FsRepo) WriteAsset(ctx context.Context, id NodeId, kind AssetKind, name
 	case AssetKindItem:
 		assetPath = filepath.Join(nodeDir, NodeAttachmentsDir, name)
 	default:
-		return fmt.Errorf("unknown asset kind %q", kind)
+		return NewUnknownAssetKindError(kind)
 	}
 
 	// Create parent directory if it doesn't exist
@@ -690,7 +865,7 @@ func (f *FsRepo) WriteAsset(ctx context.Context, id NodeId, kind AssetKind, name
 		return NewBackendError(f.Name(), "WriteAsset", 0, err, false)
 	}
 
-	err = f.runtime.AtomicWriteFile(assetPath, data, 0o0644)
+	err = f.atomicWrite(assetPath, data, 0o0644)
 	if err != nil {
 		return NewBackendError(f.Name(), "WriteAsset", 0, err, false)
 	}
@@ -706,7 +881,7 @@ func (f *FsRepo) MoveNode(ctx context.Context, id NodeId, dst NodeId) error {
 		return err
 	}
 	if !srcExists {
-		return ErrNotExist
+		return NewNodeNotFoundError(id)
 	}
 
 	dstPath := filepath.Join(f.Root, dst.Path())
@@ -715,10 +890,10 @@ func (f *FsRepo) MoveNode(ctx context.Context, id NodeId, dst NodeId) error {
 		return err
 	}
 	if dstExists {
-		return ErrDestinationExists
+		return NewDestinationExistsError(dst)
 	}
 
-	if err := f.runtime.Rename(src, dstPath); err != nil {
+	if err := f.rename(src, dstPath); err != nil {
 		return NewBackendError(f.Name(), "MoveNode", 0, err, false)
 	}
 	return nil
@@ -730,7 +905,7 @@ func (f *FsRepo) GetIndex(ctx context.Context, name string) ([]byte, error) {
 	b, err := f.runtime.ReadFile(idxPath)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return nil, ErrNotExist
+			return nil, NewNotFoundError("index", name)
 		}
 		return nil, NewBackendError(f.Name(), "GetIndex", 0, err, false)
 	}
@@ -767,7 +942,7 @@ func (f *FsRepo) ClearIndexes(ctx context.Context) error {
 // WriteIndex implements Repository.
 func (f *FsRepo) WriteIndex(ctx context.Context, name string, data []byte) error {
 	idxPath := filepath.Join(f.Root, "dex", name)
-	err := f.runtime.AtomicWriteFile(idxPath, data, 0o0644)
+	err := f.atomicWrite(idxPath, data, 0o0644)
 	if err != nil {
 		return NewBackendError(f.Name(), "WriteIndex", 0, err, false)
 	}
@@ -802,7 +977,7 @@ func (f *FsRepo) DeleteNode(ctx context.Context, id NodeId) error {
 		return err
 	}
 	if !exists {
-		return ErrNotExist
+		return NewNodeNotFoundError(id)
 	}
 
 	if err := f.runtime.Remove(nodeDir, true); err != nil {
@@ -813,6 +988,10 @@ func (f *FsRepo) DeleteNode(ctx context.Context, id NodeId) error {
 
 // DeleteAsset implements Repository.
 func (f *FsRepo) DeleteAsset(ctx context.Context, id NodeId, kind AssetKind, name string) error {
+	name, err := checkAssetName(name)
+	if err != nil {
+		return err
+	}
 	nodeDir := filepath.Join(f.Root, id.Path())
 
 	// Ensure node exists
@@ -821,7 +1000,7 @@ func (f *FsRepo) DeleteAsset(ctx context.Context, id NodeId, kind AssetKind, nam
 		return err
 	}
 	if !exists {
-		return ErrNotExist
+		return NewNodeNotFoundError(id)
 	}
 
 	switch kind {
@@ -830,14 +1009,14 @@ func (f *FsRepo) DeleteAsset(ctx context.Context, id NodeId, kind AssetKind, nam
 		imagePath := filepath.Join(imagesDir, name)
 		if _, statErr := f.runtime.Stat(imagePath, false); statErr != nil {
 			if os.IsNotExist(statErr) {
-				return ErrNotExist
+				return NewNotFoundError("image", name)
 			}
 			return NewBackendError(f.Name(), "DeleteAsset", 0, statErr, false)
 		}
 		if err := f.runtime.Remove(imagePath, true); err != nil {
 			return NewBackendError(f.Name(), "DeleteAsset", 0, err, false)
 		}
-		metaPath := filepath.Join(imagesDir, ".meta", name+".json")
+		metaPath := filepath.Join(imagesDir, NodeItemMetaDir, name+".json")
 		_ = f.runtime.Remove(metaPath, false)
 		thumbPath := filepath.Join(imagesDir, "thumbs", name)
 		_ = f.runtime.Remove(thumbPath, false)
@@ -846,16 +1025,18 @@ func (f *FsRepo) DeleteAsset(ctx context.Context, id NodeId, kind AssetKind, nam
 		itemPath := filepath.Join(nodeDir, NodeAttachmentsDir, name)
 		if _, statErr := f.runtime.Stat(itemPath, false); statErr != nil {
 			if os.IsNotExist(statErr) {
-				return ErrNotExist
+				return NewNotFoundError("file", name)
 			}
 			return NewBackendError(f.Name(), "DeleteAsset", 0, statErr, false)
 		}
 		if err := f.runtime.Remove(itemPath, true); err != nil {
 			return NewBackendError(f.Name(), "DeleteAsset", 0, err, false)
 		}
+		metaPath := filepath.Join(nodeDir, NodeAttachmentsDir, NodeItemMetaDir, name+".json")
+		_ = f.runtime.Remove(metaPath, false)
 		return nil
 	default:
-		return fmt.Errorf("unknown asset kind %q", kind)
+		return NewUnknownAssetKindError(kind)
 	}
 }
 
@@ -868,17 +1049,21 @@ func (f *FsRepo) ListImages(ctx context.Context, id NodeId) ([]string, error) {
 }
 
 func (f *FsRepo) ReadFile(ctx context.Context, id NodeId, name string) ([]byte, error) {
+	name, err := checkAssetName(name)
+	if err != nil {
+		return nil, err
+	}
 	exists, err := f.HasNode(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 	if !exists {
-		return nil, ErrNotExist
+		return nil, NewNodeNotFoundError(id)
 	}
 	filePath := filepath.Join(f.Root, id.Path(), NodeAttachmentsDir, name)
 	if _, statErr := f.runtime.Stat(filePath, false); statErr != nil {
 		if os.IsNotExist(statErr) {
-			return nil, ErrNotExist
+			return nil, NewNotFoundError("file", name)
 		}
 		return nil, NewBackendError(f.Name(), "ReadFile", 0, statErr, false)
 	}
@@ -886,21 +1071,25 @@ func (f *FsRepo) ReadFile(ctx context.Context, id NodeId, name string) ([]byte,
 	if err != nil {
 		return nil, NewBackendError(f.Name(), "ReadFile", 0, err, false)
 	}
-	return b, nil
+	return f.resolveBlob(b)
 }
 
 func (f *FsRepo) ReadImage(ctx context.Context, id NodeId, name string) ([]byte, error) {
+	name, err := checkAssetName(name)
+	if err != nil {
+		return nil, err
+	}
 	exists, err := f.HasNode(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 	if !exists {
-		return nil, ErrNotExist
+		return nil, NewNodeNotFoundError(id)
 	}
 	imagePath := filepath.Join(f.Root, id.Path(), NodeImagesDir, name)
 	if _, statErr := f.runtime.Stat(imagePath, false); statErr != nil {
 		if os.IsNotExist(statErr) {
-			return nil, ErrNotExist
+			return nil, NewNotFoundError("image", name)
 		}
 		return nil, NewBackendError(f.Name(), "ReadImage", 0, statErr, false)
 	}
@@ -915,7 +1104,28 @@ func (f *FsRepo) WriteImage(ctx context.Context, id NodeId, name string, data []
 	return f.WriteAsset(ctx, id, AssetKindImage, name, data)
 }
 
+// WriteFile stores an attachment. When the keg enables the blob store, the
+// content goes to blobs/ and the node keeps a pointer to it. Content
+// versions manage their own hashed files and are always stored directly.
 func (f *FsRepo) WriteFile(ctx context.Context, id NodeId, name string, data []byte) error {
+	name, err := checkAssetName(name)
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(name, NodeVersionsDir+"/") && f.blobStoreEnabled(ctx) {
+		exists, err := f.HasNode(ctx, id)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return NewNodeNotFoundError(id)
+		}
+		pointer, err := f.writeBlob(data)
+		if err != nil {
+			return err
+		}
+		data = pointer
+	}
 	return f.WriteAsset(ctx, id, AssetKindItem, name, data)
 }
 
@@ -944,7 +1154,7 @@ func (f *FsRepo) ReadConfig(ctx context.Context) (*Config, error) {
 			return cfg, nil
 		}
 	}
-	return nil, ErrNotExist
+	return nil, NewNotFoundError("keg config", "")
 }
 
 // WriteConfig implements Repository.
@@ -961,7 +1171,7 @@ func (f *FsRepo) WriteConfig(ctx context.Context, config *Config) error {
 		return NewBackendError(f.Name(), "WriteConfig", 0, err, false)
 	}
 
-	err = f.runtime.AtomicWriteFile(target, out, 0o0644)
+	err = f.atomicWrite(target, out, 0o0644)
 	if err != nil {
 		return NewBackendError(f.Name(), "WriteConfig", 0, err, false)
 	}
@@ -0,0 +1,217 @@
+package keg
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// ArchiveDirName is the directory under the keg root holding archived nodes.
+const ArchiveDirName = "archive"
+
+func (f *FsRepo) archivedNodeDir(id NodeId) string {
+	return filepath.Join(f.Root, ArchiveDirName, id.Path())
+}
+
+func (f *FsRepo) hasArchived(id NodeId) (bool, error) {
+	info, err := f.runtime.Stat(f.archivedNodeDir(id), false)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, NewBackendError(f.Name(), "HasArchived", 0, err, false)
+	}
+	return info.IsDir(), nil
+}
+
+// ArchiveNode implements RepositoryArchive.
+func (f *FsRepo) ArchiveNode(ctx context.Context, id NodeId) error {
+	exists, err := f.HasNode(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return NewNodeNotFoundError(id)
+	}
+	archived, err := f.hasArchived(id)
+	if err != nil {
+		return err
+	}
+	if archived {
+		return NewDestinationExistsError(id)
+	}
+
+	if err := f.runtime.Mkdir(filepath.Join(f.Root, ArchiveDirName), 0o755, true); err != nil {
+		return NewBackendError(f.Name(), "ArchiveNode", 0, err, false)
+	}
+	if err := f.rename(filepath.Join(f.Root, id.Path()), f.archivedNodeDir(id)); err != nil {
+		return NewBackendError(f.Name(), "ArchiveNode", 0, err, false)
+	}
+	return nil
+}
+
+// UnarchiveNode implements RepositoryArchive.
+func (f *FsRepo) UnarchiveNode(ctx context.Context, id NodeId) error {
+	archived, err := f.hasArchived(id)
+	if err != nil {
+		return err
+	}
+	if !archived {
+		return NewNotFoundError("archived node", id.Path())
+	}
+	exists, err := f.HasNode(ctx, id)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return NewDestinationExistsError(id)
+	}
+
+	if err := f.rename(f.archivedNodeDir(id), filepath.Join(f.Root, id.Path())); err != nil {
+		return NewBackendError(f.Name(), "UnarchiveNode", 0, err, false)
+	}
+	return nil
+}
+
+// ListArchived implements RepositoryArchive.
+func (f *FsRepo) ListArchived(ctx context.Context) ([]NodeId, error) {
+	entries, err := f.runtime.ReadDir(filepath.Join(f.Root, ArchiveDirName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []NodeId{}, nil
+		}
+		return nil, NewBackendError(f.Name(), "ListArchived", 0, err, false)
+	}
+	ids := []NodeId{}
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		if n, perr := ParseNode(e.Name()); perr == nil && n != nil && n.Valid() {
+			ids = append(ids, *n)
+		}
+	}
+	slices.SortFunc(ids, func(a, b NodeId) int { return a.Compare(b) })
+	return ids, nil
+}
+
+// ReadArchivedContent implements RepositoryArchive.
+func (f *FsRepo) ReadArchivedContent(ctx context.Context, id NodeId) ([]byte, error) {
+	return f.readArchivedFile(id, f.ContentFilename, "ReadArchivedContent")
+}
+
+// ReadArchivedMeta implements RepositoryArchive.
+func (f *FsRepo) ReadArchivedMeta(ctx context.Context, id NodeId) ([]byte, error) {
+	return f.readArchivedFile(id, f.MetaFilename, "ReadArchivedMeta")
+}
+
+func (f *FsRepo) readArchivedFile(id NodeId, name, op string) ([]byte, error) {
+	archived, err := f.hasArchived(id)
+	if err != nil {
+		return nil, err
+	}
+	if !archived {
+		return nil, NewNotFoundError("archived node", id.Path())
+	}
+	b, err := f.runtime.ReadFile(filepath.Join(f.archivedNodeDir(id), name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []byte(nil), nil
+		}
+		return nil, NewBackendError(f.Name(), op, 0, err, false)
+	}
+	return b, nil
+}
+
+// ReadArchivedStats implements RepositoryArchivedState.
+func (f *FsRepo) ReadArchivedStats(ctx context.Context, id NodeId) (*NodeStats, error) {
+	raw, err := f.readArchivedFile(id, f.StatsFilename, "ReadArchivedStats")
+	if err != nil {
+		return nil, err
+	}
+	if raw == nil {
+		return nil, NewNotFoundError("stats", id.Path())
+	}
+	stats, err := ParseStats(ctx, raw)
+	if err != nil {
+		return nil, NewBackendError(f.Name(), "ReadArchivedStats", 0, err, false)
+	}
+	return stats, nil
+}
+
+// ReadArchivedItems implements RepositoryArchivedState. Blob pointers are
+// resolved like they are for active nodes.
+func (f *FsRepo) ReadArchivedItems(ctx context.Context, id NodeId) (*NodeItems, error) {
+	archived, err := f.hasArchived(id)
+	if err != nil {
+		return nil, err
+	}
+	if !archived {
+		return nil, NewNotFoundError("archived node", id.Path())
+	}
+	items := newNodeItems()
+	dir := f.archivedNodeDir(id)
+	if err := f.readArchivedItemDir(filepath.Join(dir, NodeAttachmentsDir), items.Files, items.FileMeta, items.Versions); err != nil {
+		return nil, err
+	}
+	if err := f.readArchivedItemDir(filepath.Join(dir, NodeImagesDir), items.Images, items.ImageMeta, nil); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// readArchivedItemDir reads the items, metadata sidecars and, when versions
+// is non-nil, the content versions kept in an archived item directory.
+func (f *FsRepo) readArchivedItemDir(dir string, data map[string][]byte, metas map[string]*ItemMeta, versions map[string][]byte) error {
+	readDir := func(dir string, fn func(name string, raw []byte) error) error {
+		entries, err := f.runtime.ReadDir(dir)
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return NewBackendError(f.Name(), "ReadArchivedItems", 0, err, false)
+		}
+		for _, e := range entries {
+			if e.IsDir() {
+				continue
+			}
+			raw, err := f.runtime.ReadFile(filepath.Join(dir, e.Name()))
+			if err != nil {
+				return NewBackendError(f.Name(), "ReadArchivedItems", 0, err, false)
+			}
+			if err := fn(e.Name(), raw); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	err := readDir(dir, func(name string, raw []byte) error {
+		resolved, err := f.resolveBlob(raw)
+		data[name] = resolved
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	err = readDir(filepath.Join(dir, NodeItemMetaDir), func(name string, raw []byte) error {
+		meta, err := parseItemMeta(raw)
+		if err != nil {
+			return err
+		}
+		metas[strings.TrimSuffix(name, ".json")] = meta
+		return nil
+	})
+	if err != nil || versions == nil {
+		return err
+	}
+	return readDir(filepath.Join(dir, NodeVersionsDir), func(name string, raw []byte) error {
+		resolved, err := f.resolveBlob(raw)
+		versions[name] = resolved
+		return err
+	})
+}
+
+var _ RepositoryArchivedState = (*FsRepo)(nil)
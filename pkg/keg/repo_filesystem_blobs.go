@@ -0,0 +1,158 @@
+package keg
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// BlobsDirName is the directory under the keg root holding
+// content-addressed attachment blobs.
+const BlobsDirName = "blobs"
+
+// blobPointerPrefix starts the pointer file left in a node's assets
+// directory when the blob store is enabled.
+const blobPointerPrefix = "keg-blob: sha256:"
+
+// blobPointerSize is the exact size of a pointer file: the prefix, a
+// hex-encoded SHA-256 digest and a trailing newline.
+const blobPointerSize = len(blobPointerPrefix) + sha256.Size*2 + 1
+
+func (f *FsRepo) blobPath(hash string) string {
+	return filepath.Join(f.Root, BlobsDirName, hash[:2], hash)
+}
+
+// blobStoreEnabled reports whether the keg config asks for attachments to be
+// stored in the blob store.
+func (f *FsRepo) blobStoreEnabled(ctx context.Context) bool {
+	cfg, err := f.ReadConfig(ctx)
+	return err == nil && cfg.Files != nil && cfg.Files.BlobStore
+}
+
+// writeBlob stores data in the blob store, unless an identical blob already
+// exists, and returns the pointer file content referencing it.
+func (f *FsRepo) writeBlob(data []byte) ([]byte, error) {
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+	path := f.blobPath(hash)
+	if _, err := f.runtime.Stat(path, false); err != nil {
+		if !os.IsNotExist(err) {
+			return nil, NewBackendError(f.Name(), "WriteBlob", 0, err, false)
+		}
+		if err := f.runtime.Mkdir(filepath.Dir(path), 0o755, true); err != nil {
+			return nil, NewBackendError(f.Name(), "WriteBlob", 0, err, false)
+		}
+		if err := f.atomicWrite(path, data, 0o644); err != nil {
+			return nil, NewBackendError(f.Name(), "WriteBlob", 0, err, false)
+		}
+	}
+	return []byte(blobPointerPrefix + hash + "\n"), nil
+}
+
+// parseBlobPointer returns the blob hash referenced by a pointer file.
+func parseBlobPointer(data []byte) (string, bool) {
+	if len(data) != blobPointerSize || !bytes.HasPrefix(data, []byte(blobPointerPrefix)) || data[len(data)-1] != '\n' {
+		return "", false
+	}
+	hash := string(data[len(blobPointerPrefix) : len(data)-1])
+	if _, err := hex.DecodeString(hash); err != nil {
+		return "", false
+	}
+	return hash, true
+}
+
+// resolveBlob follows a pointer file to its blob. Data that is not a pointer
+// is returned unchanged.
+func (f *FsRepo) resolveBlob(data []byte) ([]byte, error) {
+	hash, ok := parseBlobPointer(data)
+	if !ok {
+		return data, nil
+	}
+	b, err := f.runtime.ReadFile(f.blobPath(hash))
+	if err != nil {
+		return nil, NewBackendError(f.Name(), "ReadBlob", 0, err, false)
+	}
+	return b, nil
+}
+
+// CollectBlobs implements RepositoryBlobs. Pointers are gathered from every
+// file under the keg root, including archived nodes and content versions,
+// so no reachable blob is removed.
+func (f *FsRepo) CollectBlobs(ctx context.Context, dryRun bool) (*BlobGCReport, error) {
+	referenced := map[string]bool{}
+	if err := f.collectBlobPointers(f.Root, referenced); err != nil {
+		return nil, err
+	}
+
+	report := &BlobGCReport{}
+	blobsDir := filepath.Join(f.Root, BlobsDirName)
+	shards, err := f.runtime.ReadDir(blobsDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return report, nil
+		}
+		return nil, NewBackendError(f.Name(), "CollectBlobs", 0, err, false)
+	}
+	for _, shard := range shards {
+		if !shard.IsDir() {
+			continue
+		}
+		entries, err := f.runtime.ReadDir(filepath.Join(blobsDir, shard.Name()))
+		if err != nil {
+			return nil, NewBackendError(f.Name(), "CollectBlobs", 0, err, false)
+		}
+		for _, e := range entries {
+			if referenced[e.Name()] {
+				report.Kept++
+				continue
+			}
+			if info, err := e.Info(); err == nil {
+				report.Freed += info.Size()
+			}
+			report.Removed = append(report.Removed, e.Name())
+			if dryRun {
+				continue
+			}
+			if err := f.runtime.Remove(filepath.Join(blobsDir, shard.Name(), e.Name()), false); err != nil {
+				return report, NewBackendError(f.Name(), "CollectBlobs", 0, err, false)
+			}
+		}
+	}
+	sortStrings(report.Removed)
+	return report, nil
+}
+
+func (f *FsRepo) collectBlobPointers(dir string, referenced map[string]bool) error {
+	entries, err := f.runtime.ReadDir(dir)
+	if err != nil {
+		return NewBackendError(f.Name(), "CollectBlobs", 0, err, false)
+	}
+	for _, e := range entries {
+		path := filepath.Join(dir, e.Name())
+		if e.IsDir() {
+			if dir == f.Root && (e.Name() == BlobsDirName || strings.HasPrefix(e.Name(), ".")) {
+				continue
+			}
+			if err := f.collectBlobPointers(path, referenced); err != nil {
+				return err
+			}
+			continue
+		}
+		info, err := e.Info()
+		if err != nil || info.Size() != int64(blobPointerSize) {
+			continue
+		}
+		data, err := f.runtime.ReadFile(path)
+		if err != nil {
+			return NewBackendError(f.Name(), "CollectBlobs", 0, err, false)
+		}
+		if hash, ok := parseBlobPointer(data); ok {
+			referenced[hash] = true
+		}
+	}
+	return nil
+}
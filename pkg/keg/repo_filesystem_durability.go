@@ -0,0 +1,125 @@
+package keg
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Durability controls how hard FsRepo works to get writes onto stable storage.
+type Durability string
+
+const (
+	// DurabilityDefault relies on atomic renames and leaves flushing to the
+	// operating system. A power loss may drop the latest writes but never
+	// leaves a half-written file.
+	DurabilityDefault Durability = "default"
+
+	// DurabilityFsync fsyncs each file before it is renamed into place and
+	// the containing directory afterwards, so a completed write survives a
+	// power loss. Writes are noticeably slower, especially on network
+	// filesystems.
+	DurabilityFsync Durability = "fsync"
+)
+
+// ParseDurability parses a durability level. An empty string selects
+// DurabilityDefault.
+func ParseDurability(s string) (Durability, error) {
+	switch Durability(strings.ToLower(strings.TrimSpace(s))) {
+	case "", DurabilityDefault:
+		return DurabilityDefault, nil
+	case DurabilityFsync:
+		return DurabilityFsync, nil
+	}
+	return "", fmt.Errorf("unknown durability %q (want default or fsync): %w", s, ErrInvalid)
+}
+
+func (f *FsRepo) fsyncEnabled() bool {
+	return f.Durability == DurabilityFsync
+}
+
+// atomicWrite replaces path with data through a temp file and rename. With
+// DurabilityFsync the temp file is synced before the rename and the directory
+// after it.
+func (f *FsRepo) atomicWrite(path string, data []byte, perm os.FileMode) error {
+	if !f.fsyncEnabled() {
+		return f.runtime.AtomicWriteFile(path, data, perm)
+	}
+
+	host, err := f.hostPath(path)
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(host)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("atomic write: mkdirall %q: %w", dir, err)
+	}
+
+	tmp, err := os.CreateTemp(dir, ".tmp-"+filepath.Base(host)+".*")
+	if err != nil {
+		return fmt.Errorf("atomic write: create temp file: %w", err)
+	}
+	tmpName := tmp.Name()
+	defer os.Remove(tmpName)
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("atomic write: write temp file %q: %w", tmpName, err)
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("atomic write: fsync temp file %q: %w", tmpName, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("atomic write: close temp file %q: %w", tmpName, err)
+	}
+	_ = os.Chmod(tmpName, perm)
+
+	if err := renameWithRetry(func() error { return os.Rename(tmpName, host) }); err != nil {
+		return fmt.Errorf("atomic write: rename %q -> %q: %w", tmpName, host, err)
+	}
+	return fsyncHostPath(dir)
+}
+
+// syncPath fsyncs a file or directory when DurabilityFsync is enabled.
+func (f *FsRepo) syncPath(path string) error {
+	if !f.fsyncEnabled() {
+		return nil
+	}
+	host, err := f.hostPath(path)
+	if err != nil {
+		return err
+	}
+	return fsyncHostPath(host)
+}
+
+// hostPath maps a runtime path to the host filesystem, honoring the runtime
+// jail.
+func (f *FsRepo) hostPath(path string) (string, error) {
+	resolved, err := f.runtime.ResolvePath(path, false)
+	if err != nil {
+		return "", err
+	}
+	if jail := strings.TrimSpace(f.runtime.GetJail()); jail != "" {
+		resolved = filepath.Join(jail, strings.TrimPrefix(resolved, string(filepath.Separator)))
+	}
+	return resolved, nil
+}
+
+func fsyncHostPath(host string) error {
+	fh, err := os.Open(host)
+	if err != nil {
+		return fmt.Errorf("fsync %q: %w", host, err)
+	}
+	defer fh.Close()
+	if !dirSyncSupported {
+		if info, err := fh.Stat(); err == nil && info.IsDir() {
+			return nil
+		}
+	}
+	if err := fh.Sync(); err != nil {
+		return fmt.Errorf("fsync %q: %w", host, err)
+	}
+	return nil
+}
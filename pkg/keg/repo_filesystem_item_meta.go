@@ -0,0 +1,81 @@
+package keg
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+)
+
+func (f *FsRepo) itemDir(id NodeId, kind AssetKind) (string, error) {
+	nodeDir := filepath.Join(f.Root, id.Path())
+	switch kind {
+	case AssetKindImage:
+		return filepath.Join(nodeDir, NodeImagesDir), nil
+	case AssetKindItem:
+		return filepath.Join(nodeDir, NodeAttachmentsDir), nil
+	default:
+		return "", NewUnknownAssetKindError(kind)
+	}
+}
+
+func (f *FsRepo) itemMetaPath(id NodeId, kind AssetKind, name string) (string, error) {
+	dir, err := f.itemDir(id, kind)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, NodeItemMetaDir, name+".json"), nil
+}
+
+// ReadItemMeta implements RepositoryItemMeta. Metadata lives in
+// <node>/images/.meta/<name>.json or <node>/assets/.meta/<name>.json.
+func (f *FsRepo) ReadItemMeta(ctx context.Context, id NodeId, kind AssetKind, name string) (*ItemMeta, error) {
+	name, err := checkAssetName(name)
+	if err != nil {
+		return nil, err
+	}
+	path, err := f.itemMetaPath(id, kind, name)
+	if err != nil {
+		return nil, err
+	}
+	data, err := f.runtime.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, NewNotFoundError("item metadata", name)
+		}
+		return nil, NewBackendError(f.Name(), "ReadItemMeta", 0, err, false)
+	}
+	return parseItemMeta(data)
+}
+
+// WriteItemMeta implements RepositoryItemMeta.
+func (f *FsRepo) WriteItemMeta(ctx context.Context, id NodeId, kind AssetKind, name string, meta *ItemMeta) error {
+	name, err := checkAssetName(name)
+	if err != nil {
+		return err
+	}
+	if meta == nil {
+		meta = &ItemMeta{}
+	}
+	dir, err := f.itemDir(id, kind)
+	if err != nil {
+		return err
+	}
+	if _, err := f.runtime.Stat(filepath.Join(dir, name), false); err != nil {
+		if os.IsNotExist(err) {
+			return NewNotFoundError(string(kind), name)
+		}
+		return NewBackendError(f.Name(), "WriteItemMeta", 0, err, false)
+	}
+	data, err := meta.toJSON()
+	if err != nil {
+		return NewBackendError(f.Name(), "WriteItemMeta", 0, err, false)
+	}
+	metaDir := filepath.Join(dir, NodeItemMetaDir)
+	if err := f.runtime.Mkdir(metaDir, 0o755, true); err != nil {
+		return NewBackendError(f.Name(), "WriteItemMeta", 0, err, false)
+	}
+	if err := f.atomicWrite(filepath.Join(metaDir, name+".json"), data, 0o644); err != nil {
+		return NewBackendError(f.Name(), "WriteItemMeta", 0, err, false)
+	}
+	return nil
+}
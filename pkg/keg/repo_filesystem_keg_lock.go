@@ -0,0 +1,103 @@
+package keg
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// kegLockPath returns the keg-wide lock directory at the repository root.
+func (f *FsRepo) kegLockPath() string {
+	return filepath.Join(f.Root, KegLockFile)
+}
+
+// WithKegLock executes fn while holding the keg-wide lock. The lock is an
+// atomic mkdir of .keg-lock at the repository root carrying the same owner
+// metadata as node locks. A lock whose owning process is gone is removed and
+// acquisition is retried.
+func (f *FsRepo) WithKegLock(ctx context.Context, fn func(context.Context) error) error {
+	if fn == nil {
+		return fmt.Errorf("fn required")
+	}
+	if contextHasKegLock(ctx) {
+		return fn(ctx)
+	}
+
+	lockPath := f.kegLockPath()
+	for {
+		err := f.runtime.Mkdir(lockPath, 0o700, false)
+		if err == nil {
+			f.writeLockMetadata(lockPath)
+			break
+		}
+		if !os.IsExist(err) {
+			return errors.Join(ErrLock, NewBackendError(f.Name(), "WithKegLock", 0, err, false))
+		}
+		if f.isLockStale(lockMetadataPath(lockPath)) {
+			_ = f.runtime.Remove(lockPath, true)
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			held := "keg is locked"
+			if owner, ownerErr := f.KegLockOwner(ctx); ownerErr == nil && owner != nil {
+				held = fmt.Sprintf("keg is locked by %s", owner)
+			}
+			return fmt.Errorf("%w: %s: %w", ErrLockTimeout, held, ctx.Err())
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+
+	runErr := fn(contextWithKegLock(ctx))
+
+	unlockErr := f.runtime.Remove(lockPath, true)
+	if unlockErr != nil && !os.IsNotExist(unlockErr) {
+		unlockErr = errors.Join(ErrLock, NewBackendError(f.Name(), "WithKegLockUnlock", 0, unlockErr, false))
+	} else {
+		unlockErr = nil
+	}
+	return errors.Join(runErr, unlockErr)
+}
+
+// KegLockOwner returns the holder of the keg-wide lock, or nil when the lock
+// is free. A lock without readable owner metadata is reported with a zero
+// PID and marked stale.
+func (f *FsRepo) KegLockOwner(ctx context.Context) (*LockOwner, error) {
+	lockPath := f.kegLockPath()
+	if _, err := f.runtime.Stat(lockPath, false); err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, NewBackendError(f.Name(), "KegLockOwner", 0, err, false)
+	}
+
+	metaPath := lockMetadataPath(lockPath)
+	owner := &LockOwner{Stale: f.isLockStale(metaPath)}
+	data, err := f.runtime.ReadFile(metaPath)
+	if err != nil {
+		// The holder may still be writing its metadata.
+		return owner, nil
+	}
+	var info lockInfo
+	if json.Unmarshal(data, &info) == nil {
+		owner.PID = info.PID
+		owner.Hostname = info.Hostname
+		if ts, err := time.Parse(time.RFC3339Nano, info.StartedAt); err == nil {
+			owner.StartedAt = ts
+		}
+	}
+	return owner, nil
+}
+
+// BreakKegLock removes the keg-wide lock regardless of its holder.
+func (f *FsRepo) BreakKegLock(ctx context.Context) error {
+	err := f.runtime.Remove(f.kegLockPath(), true)
+	if err != nil && !os.IsNotExist(err) {
+		return NewBackendError(f.Name(), "BreakKegLock", 0, err, false)
+	}
+	return nil
+}
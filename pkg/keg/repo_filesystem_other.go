@@ -0,0 +1,31 @@
+//go:build !windows
+
+package keg
+
+import (
+	"os"
+	"syscall"
+)
+
+// caseInsensitiveFileNames reports whether keg file discovery should ignore
+// case when matching file names.
+const caseInsensitiveFileNames = false
+
+// dirSyncSupported reports whether a directory handle can be fsynced.
+const dirSyncSupported = true
+
+// processAlive reports whether a process with pid is still running.
+func processAlive(pid int) bool {
+	proc, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	// Signal 0 checks existence without killing.
+	return proc.Signal(syscall.Signal(0)) == nil
+}
+
+// renameWithRetry runs rename once. POSIX renames replace the destination
+// even while another process holds it open.
+func renameWithRetry(rename func() error) error {
+	return rename()
+}
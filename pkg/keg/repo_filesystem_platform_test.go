@@ -0,0 +1,49 @@
+package keg
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessAlive(t *testing.T) {
+	t.Parallel()
+	require.True(t, processAlive(os.Getpid()))
+	require.False(t, processAlive(1<<30), "a pid that cannot exist should not be alive")
+}
+
+func TestIsKegFileName(t *testing.T) {
+	t.Parallel()
+	candidates := []string{"keg", "keg.yaml", "keg.yml"}
+	require.True(t, isKegFileName(candidates, "keg"))
+	require.True(t, isKegFileName(candidates, "keg.yml"))
+	require.False(t, isKegFileName(candidates, "README.md"))
+	require.Equal(t, caseInsensitiveFileNames, isKegFileName(candidates, "KEG.YAML"))
+}
+
+func TestFindKegRecursive(t *testing.T) {
+	t.Parallel()
+	root := t.TempDir()
+	dir := filepath.Join(root, "docs", "notes")
+	require.NoError(t, os.MkdirAll(dir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "keg"), []byte("kegv: 2023-01\n"), 0o644))
+
+	found, err := findKegRecursive(context.Background(), root, []string{"keg"})
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(dir, "keg"), found)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = findKegRecursive(ctx, root, []string{"keg"})
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestFsyncHostPath_Directory(t *testing.T) {
+	t.Parallel()
+	// Directory syncs are skipped where the platform refuses them, so this
+	// must succeed everywhere.
+	require.NoError(t, fsyncHostPath(t.TempDir()))
+}
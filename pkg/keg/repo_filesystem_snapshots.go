@@ -3,6 +3,7 @@ package keg
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -40,7 +41,7 @@ func (f *FsRepo) appendSnapshotLocked(ctx context.Context, id NodeId, in Snapsho
 		return Snapshot{}, err
 	}
 	if !exists {
-		return Snapshot{}, ErrNotExist
+		return Snapshot{}, NewNodeNotFoundError(id)
 	}
 
 	index, err := f.readSnapshotIndex(ctx, id)
@@ -97,7 +98,7 @@ func (f *FsRepo) appendSnapshotLocked(ctx context.Context, id NodeId, in Snapsho
 	}
 
 	if storeFull {
-		if err := f.runtime.AtomicWriteFile(f.snapshotContentPath(id, snapshot.ID, SnapshotContentKindFull), content, 0o644); err != nil {
+		if err := f.atomicWrite(f.snapshotContentPath(id, snapshot.ID, SnapshotContentKindFull), content, 0o644); err != nil {
 			return Snapshot{}, NewBackendError(f.Name(), "AppendSnapshotWriteContent", 0, err, false)
 		}
 	} else {
@@ -109,15 +110,15 @@ func (f *FsRepo) appendSnapshotLocked(ctx context.Context, id NodeId, in Snapsho
 		if err != nil {
 			return Snapshot{}, err
 		}
-		if err := f.runtime.AtomicWriteFile(f.snapshotContentPath(id, snapshot.ID, SnapshotContentKindPatch), patchBytes, 0o644); err != nil {
+		if err := f.atomicWrite(f.snapshotContentPath(id, snapshot.ID, SnapshotContentKindPatch), patchBytes, 0o644); err != nil {
 			return Snapshot{}, NewBackendError(f.Name(), "AppendSnapshotWritePatch", 0, err, false)
 		}
 	}
 
-	if err := f.runtime.AtomicWriteFile(f.snapshotMetaPath(id, snapshot.ID), meta, 0o644); err != nil {
+	if err := f.atomicWrite(f.snapshotMetaPath(id, snapshot.ID), meta, 0o644); err != nil {
 		return Snapshot{}, NewBackendError(f.Name(), "AppendSnapshotWriteMeta", 0, err, false)
 	}
-	if err := f.runtime.AtomicWriteFile(f.snapshotStatsPath(id, snapshot.ID), statsBytes, 0o644); err != nil {
+	if err := f.atomicWrite(f.snapshotStatsPath(id, snapshot.ID), statsBytes, 0o644); err != nil {
 		return Snapshot{}, NewBackendError(f.Name(), "AppendSnapshotWriteStats", 0, err, false)
 	}
 
@@ -135,7 +136,7 @@ func (f *FsRepo) GetSnapshot(ctx context.Context, id NodeId, rev RevisionID, opt
 		return Snapshot{}, nil, nil, nil, err
 	}
 
-	snap, err := snapshotFromIndex(index, rev)
+	snap, err := snapshotFromIndex(id, index, rev)
 	if err != nil {
 		return Snapshot{}, nil, nil, nil, err
 	}
@@ -151,7 +152,7 @@ func (f *FsRepo) GetSnapshot(ctx context.Context, id NodeId, rev RevisionID, opt
 	meta, err := f.runtime.ReadFile(f.snapshotMetaPath(id, rev))
 	if err != nil {
 		if os.IsNotExist(err) {
-			return Snapshot{}, nil, nil, nil, ErrNotExist
+			return Snapshot{}, nil, nil, nil, newSnapshotNotFoundError(id, rev)
 		}
 		return Snapshot{}, nil, nil, nil, NewBackendError(f.Name(), "GetSnapshotMeta", 0, err, false)
 	}
@@ -159,7 +160,7 @@ func (f *FsRepo) GetSnapshot(ctx context.Context, id NodeId, rev RevisionID, opt
 	statsBytes, err := f.runtime.ReadFile(f.snapshotStatsPath(id, rev))
 	if err != nil {
 		if os.IsNotExist(err) {
-			return Snapshot{}, nil, nil, nil, ErrNotExist
+			return Snapshot{}, nil, nil, nil, newSnapshotNotFoundError(id, rev)
 		}
 		return Snapshot{}, nil, nil, nil, NewBackendError(f.Name(), "GetSnapshotStats", 0, err, false)
 	}
@@ -198,12 +199,20 @@ func (f *FsRepo) RestoreSnapshot(ctx context.Context, id NodeId, rev RevisionID,
 	})
 }
 
+// PurgeSnapshots implements RepositorySnapshotPurge.
+func (f *FsRepo) PurgeSnapshots(ctx context.Context, id NodeId) error {
+	if err := f.runtime.Remove(f.snapshotDir(id), true); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return NewBackendError(f.Name(), "PurgeSnapshots", 0, err, false)
+	}
+	return nil
+}
+
 func (f *FsRepo) restoreSnapshotLocked(ctx context.Context, id NodeId, rev RevisionID, createRestoreSnapshot bool) error {
 	index, err := f.readSnapshotIndex(ctx, id)
 	if err != nil {
 		return err
 	}
-	if _, err := snapshotFromIndex(index, rev); err != nil {
+	if _, err := snapshotFromIndex(id, index, rev); err != nil {
 		return err
 	}
 
@@ -214,14 +223,14 @@ func (f *FsRepo) restoreSnapshotLocked(ctx context.Context, id NodeId, rev Revis
 	meta, err := f.runtime.ReadFile(f.snapshotMetaPath(id, rev))
 	if err != nil {
 		if os.IsNotExist(err) {
-			return ErrNotExist
+			return newSnapshotNotFoundError(id, rev)
 		}
 		return NewBackendError(f.Name(), "RestoreSnapshotMeta", 0, err, false)
 	}
 	statsBytes, err := f.runtime.ReadFile(f.snapshotStatsPath(id, rev))
 	if err != nil {
 		if os.IsNotExist(err) {
-			return ErrNotExist
+			return newSnapshotNotFoundError(id, rev)
 		}
 		return NewBackendError(f.Name(), "RestoreSnapshotStats", 0, err, false)
 	}
@@ -267,7 +276,7 @@ func (f *FsRepo) readSnapshotIndex(ctx context.Context, id NodeId) ([]Snapshot,
 		return nil, err
 	}
 	if !exists {
-		return nil, ErrNotExist
+		return nil, NewNodeNotFoundError(id)
 	}
 
 	path := f.snapshotIndexPath(id)
@@ -294,14 +303,14 @@ func (f *FsRepo) writeSnapshotIndex(id NodeId, index []Snapshot) error {
 	if err != nil {
 		return NewBackendError(f.Name(), "WriteSnapshotIndex", 0, err, false)
 	}
-	if err := f.runtime.AtomicWriteFile(f.snapshotIndexPath(id), raw, 0o644); err != nil {
+	if err := f.atomicWrite(f.snapshotIndexPath(id), raw, 0o644); err != nil {
 		return NewBackendError(f.Name(), "WriteSnapshotIndex", 0, err, false)
 	}
 	return nil
 }
 
 func (f *FsRepo) readContentAtIndex(ctx context.Context, id NodeId, index []Snapshot, rev RevisionID) ([]byte, error) {
-	if _, err := snapshotFromIndex(index, rev); err != nil {
+	if _, err := snapshotFromIndex(id, index, rev); err != nil {
 		return nil, err
 	}
 
@@ -323,7 +332,7 @@ func (f *FsRepo) readContentAtIndex(ctx context.Context, id NodeId, index []Snap
 	content, err := f.runtime.ReadFile(fullPath)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return nil, ErrNotExist
+			return nil, newSnapshotNotFoundError(id, rev)
 		}
 		return nil, NewBackendError(f.Name(), "ReadSnapshotFull", 0, err, false)
 	}
@@ -337,7 +346,7 @@ func (f *FsRepo) readContentAtIndex(ctx context.Context, id NodeId, index []Snap
 		patchBytes, err := f.runtime.ReadFile(patchPath)
 		if err != nil {
 			if os.IsNotExist(err) {
-				return nil, ErrNotExist
+				return nil, newSnapshotNotFoundError(id, rev)
 			}
 			return nil, NewBackendError(f.Name(), "ReadSnapshotPatch", 0, err, false)
 		}
@@ -350,20 +359,20 @@ func (f *FsRepo) readContentAtIndex(ctx context.Context, id NodeId, index []Snap
 		}
 	}
 
-	if expected, err := snapshotFromIndex(index, rev); err == nil && expected.ContentHash != "" && expected.ContentHash != hashSnapshotBytes(f.runtime, content) {
+	if expected, err := snapshotFromIndex(id, index, rev); err == nil && expected.ContentHash != "" && expected.ContentHash != hashSnapshotBytes(f.runtime, content) {
 		return nil, fmt.Errorf("snapshot content hash mismatch for rev %d: %w", rev, ErrConflict)
 	}
 	_ = ctx
 	return content, nil
 }
 
-func snapshotFromIndex(index []Snapshot, rev RevisionID) (Snapshot, error) {
+func snapshotFromIndex(id NodeId, index []Snapshot, rev RevisionID) (Snapshot, error) {
 	for _, snap := range index {
 		if snap.ID == rev {
 			return snap, nil
 		}
 	}
-	return Snapshot{}, ErrNotExist
+	return Snapshot{}, newSnapshotNotFoundError(id, rev)
 }
 
 func (f *FsRepo) snapshotDir(id NodeId) string {
@@ -0,0 +1,62 @@
+package keg
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// OpenItem implements RepositoryStreams. Attachments stored in the blob
+// store are opened from blobs/.
+func (f *FsRepo) OpenItem(ctx context.Context, id NodeId, kind AssetKind, name string) (io.ReadCloser, error) {
+	name, err := checkAssetName(name)
+	if err != nil {
+		return nil, err
+	}
+	exists, err := f.HasNode(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, NewNodeNotFoundError(id)
+	}
+	dir, err := f.itemDir(id, kind)
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(dir, name)
+	info, err := f.runtime.Stat(path, true)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, NewNotFoundError(string(kind), name)
+		}
+		return nil, NewBackendError(f.Name(), "OpenItem", 0, err, false)
+	}
+	if kind == AssetKindItem && info.Size() == int64(blobPointerSize) {
+		data, err := f.runtime.ReadFile(path)
+		if err != nil {
+			return nil, NewBackendError(f.Name(), "OpenItem", 0, err, false)
+		}
+		if hash, ok := parseBlobPointer(data); ok {
+			path = f.blobPath(hash)
+		}
+	}
+
+	resolved, err := f.runtime.ResolvePath(path, true)
+	if err != nil {
+		return nil, NewBackendError(f.Name(), "OpenItem", 0, err, false)
+	}
+	if jail := strings.TrimSpace(f.runtime.GetJail()); jail != "" {
+		resolved = filepath.Join(jail, strings.TrimPrefix(resolved, string(filepath.Separator)))
+	}
+	file, err := os.Open(resolved)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, NewNotFoundError(string(kind), name)
+		}
+		return nil, NewBackendError(f.Name(), "OpenItem", 0, err, false)
+	}
+	return file, nil
+}
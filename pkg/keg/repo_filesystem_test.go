@@ -4,6 +4,7 @@ import (
 	"context"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -38,6 +39,20 @@ func TestFsRepo_WriteReadMetaAndContent(t *testing.T) {
 	require.Equal(t, string(meta), string(gotMeta))
 }
 
+func TestFsRepo_ListNodesHonorsCancellation(t *testing.T) {
+	t.Parallel()
+	fx := NewSandbox(t, sandbox.WithFixture("empty", "~/empty"))
+	r := keg.NewFsRepo("~/empty", fx.Runtime())
+	require.NoError(t, r.WriteContent(fx.Context(), keg.NodeId{ID: 1}, []byte("# one\n")))
+
+	ctx, cancel := context.WithCancel(fx.Context())
+	cancel()
+	_, err := r.ListNodes(ctx)
+	require.ErrorIs(t, err, context.Canceled)
+	var be *keg.BackendError
+	require.ErrorAs(t, err, &be)
+}
+
 func TestFsRepo_HasNode(t *testing.T) {
 	t.Parallel()
 	fx := NewSandbox(t, sandbox.WithFixture("empty", "~/empty"))
@@ -221,6 +236,70 @@ func TestFsRepo_WriteReadStats(t *testing.T) {
 	require.Contains(t, string(rawStats), "\"hash\":\"h1\"")
 }
 
+func TestFsRepo_WriteNodeWritesAllFilesAndKeepsOmittedParts(t *testing.T) {
+	t.Parallel()
+	fx := NewSandbox(t, sandbox.WithFixture("empty", "~/empty"))
+	ctx := fx.Context()
+	rt := fx.Runtime()
+
+	r := keg.NewFsRepo("~/empty", rt)
+	id := keg.NodeId{ID: 20}
+	now := time.Date(2026, 2, 14, 12, 0, 0, 0, time.UTC)
+	stats := keg.NewStats(now)
+	stats.SetHash("h1", &now)
+
+	require.NoError(t, r.WriteNode(ctx, id, []byte("# one\n"), []byte("tags: [a]\n"), stats))
+	require.NoError(t, r.WriteNode(ctx, id, nil, []byte("tags: [b]\n"), nil))
+
+	content, err := r.ReadContent(ctx, id)
+	require.NoError(t, err)
+	require.Equal(t, "# one\n", string(content))
+	meta, err := r.ReadMeta(ctx, id)
+	require.NoError(t, err)
+	require.Equal(t, "tags: [b]\n", string(meta))
+	got, err := r.ReadStats(ctx, id)
+	require.NoError(t, err)
+	require.Equal(t, "h1", got.Hash())
+
+	entries, err := rt.ReadDir("~/empty/20")
+	require.NoError(t, err)
+	for _, e := range entries {
+		require.NotContains(t, e.Name(), ".keg-", "staging directories must be cleaned up")
+	}
+}
+
+func TestFsRepo_WriteNodeRecoversInterruptedWrites(t *testing.T) {
+	t.Parallel()
+	fx := NewSandbox(t, sandbox.WithFixture("empty", "~/empty"))
+	ctx := fx.Context()
+	rt := fx.Runtime()
+
+	r := keg.NewFsRepo("~/empty", rt)
+	id := keg.NodeId{ID: 21}
+	require.NoError(t, r.WriteNode(ctx, id, []byte("# old\n"), []byte("tags: [old]\n"), nil))
+
+	// A write that crashed after committing is rolled forward.
+	require.NoError(t, rt.Mkdir("~/empty/21/.keg-commit", 0o755, true))
+	require.NoError(t, rt.WriteFile("~/empty/21/.keg-commit/README.md", []byte("# new\n"), 0o644))
+	require.NoError(t, rt.WriteFile("~/empty/21/.keg-commit/meta.yaml", []byte("tags: [new]\n"), 0o644))
+	// A write that crashed while staging is discarded.
+	require.NoError(t, rt.Mkdir("~/empty/21/.keg-write", 0o755, true))
+	require.NoError(t, rt.WriteFile("~/empty/21/.keg-write/README.md", []byte("# partial\n"), 0o644))
+
+	require.NoError(t, r.WithNodeLock(ctx, id, func(context.Context) error { return nil }))
+
+	content, err := r.ReadContent(ctx, id)
+	require.NoError(t, err)
+	require.Equal(t, "# new\n", string(content))
+	meta, err := r.ReadMeta(ctx, id)
+	require.NoError(t, err)
+	require.Equal(t, "tags: [new]\n", string(meta))
+	for _, dir := range []string{"~/empty/21/.keg-commit", "~/empty/21/.keg-write"} {
+		_, err := rt.Stat(dir, false)
+		require.True(t, os.IsNotExist(err), "%s should be removed", dir)
+	}
+}
+
 func TestFsRepo_WithNodeLockTimeout(t *testing.T) {
 	t.Parallel()
 	fx := NewSandbox(t)
@@ -285,3 +364,120 @@ func TestFsRepo_WithNodeLockReentrantAndCleanup(t *testing.T) {
 	require.Error(t, err)
 	require.True(t, os.IsNotExist(err))
 }
+
+func TestFsRepo_ArchiveAndUnarchiveNode(t *testing.T) {
+	t.Parallel()
+	fx := NewSandbox(t)
+	ctx := fx.Context()
+
+	r := keg.NewFsRepo(t.TempDir(), fx.Runtime())
+	id := keg.NodeId{ID: 4}
+	require.NoError(t, r.WriteContent(ctx, id, []byte("# Old\n")))
+	require.NoError(t, r.WriteMeta(ctx, id, []byte("tags: [old]\n")))
+
+	require.NoError(t, r.ArchiveNode(ctx, id))
+
+	exists, err := r.HasNode(ctx, id)
+	require.NoError(t, err)
+	require.False(t, exists)
+	ids, err := r.ListNodes(ctx)
+	require.NoError(t, err)
+	require.Empty(t, ids)
+
+	archived, err := r.ListArchived(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []keg.NodeId{id}, archived)
+	content, err := r.ReadArchivedContent(ctx, id)
+	require.NoError(t, err)
+	require.Equal(t, "# Old\n", string(content))
+
+	// Archived IDs stay reserved.
+	next, err := r.Next(ctx)
+	require.NoError(t, err)
+	require.Equal(t, keg.NodeId{ID: 5}, next)
+
+	require.NoError(t, r.UnarchiveNode(ctx, id))
+	exists, err = r.HasNode(ctx, id)
+	require.NoError(t, err)
+	require.True(t, exists)
+	_, err = r.ReadArchivedContent(ctx, id)
+	require.ErrorIs(t, err, keg.ErrNotExist)
+	require.ErrorIs(t, r.UnarchiveNode(ctx, id), keg.ErrNotExist)
+}
+
+func TestFsRepo_FsyncDurabilityWrites(t *testing.T) {
+	t.Parallel()
+	fx := NewSandbox(t, sandbox.WithFixture("empty", "~/empty"))
+	ctx := fx.Context()
+	rt := fx.Runtime()
+
+	r := keg.NewFsRepo("~/empty", rt)
+	r.Durability = keg.DurabilityFsync
+
+	id := keg.NodeId{ID: 31}
+	require.NoError(t, r.WriteContent(ctx, id, []byte("# synced\n")))
+	require.NoError(t, r.WriteNode(ctx, id, nil, []byte("tags: [synced]\n"), nil))
+	require.NoError(t, r.WriteIndex(ctx, "nodes.tsv", []byte("31\tsynced\n")))
+
+	content, err := r.ReadContent(ctx, id)
+	require.NoError(t, err)
+	require.Equal(t, "# synced\n", string(content))
+	meta, err := r.ReadMeta(ctx, id)
+	require.NoError(t, err)
+	require.Equal(t, "tags: [synced]\n", string(meta))
+	idx, err := r.GetIndex(ctx, "nodes.tsv")
+	require.NoError(t, err)
+	require.Equal(t, "31\tsynced\n", string(idx))
+
+	entries, err := rt.ReadDir("~/empty/31")
+	require.NoError(t, err)
+	for _, e := range entries {
+		require.False(t, strings.HasPrefix(e.Name(), ".tmp-"), "temp file %s left behind", e.Name())
+	}
+}
+
+func TestParseDurability(t *testing.T) {
+	t.Parallel()
+
+	d, err := keg.ParseDurability("")
+	require.NoError(t, err)
+	require.Equal(t, keg.DurabilityDefault, d)
+
+	d, err = keg.ParseDurability("FSYNC")
+	require.NoError(t, err)
+	require.Equal(t, keg.DurabilityFsync, d)
+
+	_, err = keg.ParseDurability("paranoid")
+	require.ErrorIs(t, err, keg.ErrInvalid)
+}
+
+func TestFsRepo_RejectsPathTraversalInAssetNames(t *testing.T) {
+	t.Parallel()
+	fx := NewSandbox(t, sandbox.WithFixture("empty", "~/kegs/empty"))
+	ctx := fx.Context()
+	rt := fx.Runtime()
+
+	r := keg.NewFsRepo("~/kegs/empty", rt)
+	id := keg.NodeId{ID: 41}
+	require.NoError(t, r.WriteContent(ctx, id, []byte("# traversal\n")))
+
+	err := r.WriteFile(ctx, id, "../../../escape.txt", []byte("owned"))
+	require.ErrorIs(t, err, keg.ErrInvalidName)
+	_, statErr := rt.Stat("~/escape.txt", false)
+	require.True(t, os.IsNotExist(statErr))
+
+	require.ErrorIs(t, r.WriteImage(ctx, id, "../README.md", []byte("x")), keg.ErrInvalidName)
+	require.ErrorIs(t, r.DeleteFile(ctx, id, "../README.md"), keg.ErrInvalidName)
+	_, err = r.ReadFile(ctx, id, "../../keg")
+	require.ErrorIs(t, err, keg.ErrInvalidName)
+	_, err = r.OpenItem(ctx, id, keg.AssetKindItem, "../meta.yaml")
+	require.ErrorIs(t, err, keg.ErrInvalidName)
+
+	content, err := r.ReadContent(ctx, id)
+	require.NoError(t, err)
+	require.Equal(t, "# traversal\n", string(content))
+
+	// Content versions keep using their reserved subdirectory.
+	require.NoError(t, r.WriteFile(ctx, id, keg.NodeVersionsDir+"/abc123", []byte("old")))
+	require.ErrorIs(t, r.WriteFile(ctx, id, keg.NodeVersionsDir+"/../x", []byte("old")), keg.ErrInvalidName)
+}
@@ -0,0 +1,47 @@
+//go:build windows
+
+package keg
+
+import (
+	"errors"
+	"os"
+	"time"
+)
+
+// caseInsensitiveFileNames reports whether keg file discovery should ignore
+// case when matching file names. NTFS treats "KEG" and "keg" as the same file.
+const caseInsensitiveFileNames = true
+
+// dirSyncSupported reports whether a directory handle can be fsynced. Windows
+// refuses FlushFileBuffers on directories; NTFS journals the rename itself.
+const dirSyncSupported = false
+
+// processAlive reports whether a process with pid is still running. Windows
+// has no signal 0, but FindProcess opens a handle and fails once the process
+// has exited.
+func processAlive(pid int) bool {
+	proc, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	_ = proc.Release()
+	return true
+}
+
+// renameRetries bounds how long renameWithRetry waits for another process
+// (an editor, indexer or virus scanner) to let go of the destination.
+const renameRetries = 10
+
+// renameWithRetry runs rename, retrying while Windows reports the file as in
+// use. Unlike POSIX, Windows cannot replace a file another process has open.
+func renameWithRetry(rename func() error) error {
+	var err error
+	for attempt := 0; attempt < renameRetries; attempt++ {
+		err = rename()
+		if err == nil || !errors.Is(err, os.ErrPermission) {
+			return err
+		}
+		time.Sleep(time.Duration(attempt+1) * 10 * time.Millisecond)
+	}
+	return err
+}
@@ -0,0 +1,38 @@
+//go:build windows
+
+package keg
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindKegRecursive_IgnoresCaseOnWindows(t *testing.T) {
+	t.Parallel()
+	root := t.TempDir()
+	dir := filepath.Join(root, "Notes")
+	require.NoError(t, os.MkdirAll(dir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "KEG"), []byte("kegv: 2023-01\n"), 0o644))
+
+	found, err := findKegRecursive(context.Background(), root, []string{"keg", "keg.yaml", "keg.yml"})
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(dir, "KEG"), found)
+}
+
+func TestRenameWithRetry_ReplacesExistingFile(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	dst := filepath.Join(dir, "dst")
+	require.NoError(t, os.WriteFile(src, []byte("new"), 0o644))
+	require.NoError(t, os.WriteFile(dst, []byte("old"), 0o644))
+
+	require.NoError(t, renameWithRetry(func() error { return os.Rename(src, dst) }))
+	got, err := os.ReadFile(dst)
+	require.NoError(t, err)
+	require.Equal(t, "new", string(got))
+}
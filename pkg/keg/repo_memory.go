@@ -1,11 +1,13 @@
 package keg
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
 	"slices"
 	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -29,15 +31,19 @@ import (
 //   - Index files are kept in-memory by name (for example "nodes.tsv") and are
 //     accessible via WriteIndex/GetIndex.
 //   - Methods return sentinel or typed errors defined in the package to match the
-//     Repository contract (for example NewNodeNotFoundError, ErrNotFound).
+//     Repository contract (for example NodeNotFoundError and NotFoundError, both matching ErrNotExist).
 type MemoryRepo struct {
 	mu sync.RWMutex
 	// nodes stores per-node data keyed by NodeID.
 	nodes map[NodeId]*memoryNode
 	// nodeLocks tracks active per-node lock ownership.
 	nodeLocks map[NodeId]struct{}
+	// kegLocked is set while the keg-wide lock is held.
+	kegLocked bool
 	// indexes stores raw index files by name (for example: "nodes.tsv").
 	indexes map[string][]byte
+	// archived stores nodes moved out of the active namespace.
+	archived map[NodeId]*memoryNode
 	// snapshots stores revision history per node.
 	snapshots map[NodeId][]memorySnapshotEntry
 	// config holds the in-memory Config if written.
@@ -52,6 +58,8 @@ type memoryNode struct {
 	stats   []byte
 	items   map[string][]byte
 	images  map[string][]byte
+	// itemMeta holds item metadata keyed by itemMetaKey.
+	itemMeta map[string]ItemMeta
 }
 
 type memorySnapshotEntry struct {
@@ -67,6 +75,7 @@ func NewMemoryRepo(rt *toolkit.Runtime) *MemoryRepo {
 		nodes:     make(map[NodeId]*memoryNode),
 		nodeLocks: make(map[NodeId]struct{}),
 		indexes:   make(map[string][]byte),
+		archived:  make(map[NodeId]*memoryNode),
 		snapshots: make(map[NodeId][]memorySnapshotEntry),
 		runtime:   rt,
 	}
@@ -114,6 +123,15 @@ func (r *MemoryRepo) Next(ctx context.Context) (NodeId, error) {
 	// Find the maximum existing NodeID.
 	max := -1
 	for id := range r.nodes {
+		if id.Code != "" {
+			continue
+		}
+		if int(id.ID) > max {
+			max = int(id.ID)
+		}
+	}
+	// Archived IDs stay reserved so unarchiving cannot collide.
+	for id := range r.archived {
 		if int(id.ID) > max {
 			max = int(id.ID)
 		}
@@ -131,7 +149,7 @@ func (r *MemoryRepo) Next(ctx context.Context) (NodeId, error) {
 
 // ReadContent returns the primary content for the given node id.
 //
-// - If the node does not exist, ErrNodeNotFound is returned.
+// - If the node does not exist, a *NodeNotFoundError is returned.
 // - If the node exists but has no content, (nil, nil) is returned.
 // - The returned slice is a copy to prevent caller-visible mutation.
 func (r *MemoryRepo) ReadContent(ctx context.Context, id NodeId) ([]byte, error) {
@@ -139,11 +157,11 @@ func (r *MemoryRepo) ReadContent(ctx context.Context, id NodeId) ([]byte, error)
 	n, ok := r.nodes[id]
 	r.mu.RUnlock()
 	if !ok {
-		return nil, ErrNotExist
+		return nil, NewNodeNotFoundError(id)
 	}
 
 	if n.content == nil {
-		// NodeContent may legitimately be absent; return nil rather than ErrNotFound.
+		// NodeContent may legitimately be absent; return nil rather than ErrNotExist.
 		return nil, nil
 	}
 	cp := make([]byte, len(n.content))
@@ -153,18 +171,19 @@ func (r *MemoryRepo) ReadContent(ctx context.Context, id NodeId) ([]byte, error)
 
 // ReadMeta returns the serialized node metadata (usually meta.yaml).
 //
-// - If the node does not exist, ErrNodeNotFound is returned.
-// - If meta is absent, ErrNotFound is returned.
-// - The returned bytes are a copy.
+//   - If the node does not exist, a *NodeNotFoundError is returned.
+//   - If the node exists but has no meta, (nil, nil) is returned, matching
+//     FsRepo.
+//   - The returned bytes are a copy.
 func (r *MemoryRepo) ReadMeta(ctx context.Context, id NodeId) ([]byte, error) {
 	r.mu.RLock()
 	n, ok := r.nodes[id]
 	r.mu.RUnlock()
 	if !ok {
-		return nil, ErrNotExist
+		return nil, NewNodeNotFoundError(id)
 	}
 	if n.meta == nil {
-		return nil, ErrNotExist
+		return nil, nil
 	}
 	cp := make([]byte, len(n.meta))
 	copy(cp, n.meta)
@@ -177,17 +196,17 @@ func (r *MemoryRepo) ReadStats(ctx context.Context, id NodeId) (*NodeStats, erro
 	n, ok := r.nodes[id]
 	r.mu.RUnlock()
 	if !ok {
-		return nil, ErrNotExist
+		return nil, NewNodeNotFoundError(id)
 	}
 	if n.stats == nil {
 		if n.meta == nil {
-			return nil, ErrNotExist
+			return nil, NewNotFoundError("stats", id.Path())
 		}
 		cp := make([]byte, len(n.meta))
 		copy(cp, n.meta)
 		stats, err := ParseStats(ctx, cp)
 		if err != nil {
-			return nil, ErrNotExist
+			return nil, NewNotFoundError("stats", id.Path())
 		}
 		return stats, nil
 	}
@@ -266,7 +285,7 @@ func (r *MemoryRepo) ListAssets(ctx context.Context, id NodeId, kind AssetKind)
 	_ = ctx
 	n, ok := r.getNode(id)
 	if !ok {
-		return nil, ErrNotExist
+		return nil, NewNodeNotFoundError(id)
 	}
 
 	r.mu.RLock()
@@ -279,11 +298,14 @@ func (r *MemoryRepo) ListAssets(ctx context.Context, id NodeId, kind AssetKind)
 	case AssetKindItem:
 		src = n.items
 	default:
-		return nil, fmt.Errorf("unknown asset kind %q", kind)
+		return nil, NewUnknownAssetKindError(kind)
 	}
 
 	names := make([]string, 0, len(src))
 	for k := range src {
+		if kind == AssetKindItem && strings.HasPrefix(k, NodeVersionsDir+"/") {
+			continue
+		}
 		names = append(names, k)
 	}
 	sort.Strings(names)
@@ -291,27 +313,22 @@ func (r *MemoryRepo) ListAssets(ctx context.Context, id NodeId, kind AssetKind)
 }
 
 // WriteContent writes the primary content for the given node id, creating the
-// node if necessary.
-//
-// Note: this implementation stores the provided slice reference in-memory.
-// Callers should avoid mutating the provided slice after calling this method.
+// node if necessary. A copy of data is kept.
 func (r *MemoryRepo) WriteContent(ctx context.Context, id NodeId, data []byte) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	n := r.ensureNode(id)
-	n.content = data
+	n.content = bytes.Clone(data)
 	return nil
 }
 
-// WriteMeta sets the node metadata (meta.yaml bytes), creating the node if needed.
-//
-// Note: the provided slice is stored as-is in-memory; do not modify it after
-// writing.
+// WriteMeta sets the node metadata (meta.yaml bytes), creating the node if
+// needed. A copy of data is kept.
 func (r *MemoryRepo) WriteMeta(ctx context.Context, id NodeId, data []byte) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	n := r.ensureNode(id)
-	n.meta = data
+	n.meta = bytes.Clone(data)
 	return nil
 }
 
@@ -335,27 +352,62 @@ func (r *MemoryRepo) WriteStats(ctx context.Context, id NodeId, stats *NodeStats
 	return nil
 }
 
-// WriteAsset stores a named asset blob for a node.
-func (r *MemoryRepo) WriteAsset(ctx context.Context, id NodeId, kind AssetKind, name string, data []byte) error {
+// WriteNode implements Repository. Content, meta and stats are swapped in
+// under one lock, so readers never observe a partial update.
+func (r *MemoryRepo) WriteNode(ctx context.Context, id NodeId, content, meta []byte, stats *NodeStats) error {
 	_ = ctx
+	var statsData []byte
+	if stats != nil {
+		data, err := stats.ToJSON()
+		if err != nil {
+			return err
+		}
+		statsData = data
+	}
+
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	n := r.ensureNode(id)
+	if content != nil {
+		n.content = bytes.Clone(content)
+	}
+	if meta != nil {
+		n.meta = bytes.Clone(meta)
+	}
+	if statsData != nil {
+		n.stats = statsData
+	}
+	return nil
+}
+
+// WriteAsset stores a named asset blob for an existing node.
+func (r *MemoryRepo) WriteAsset(ctx context.Context, id NodeId, kind AssetKind, name string, data []byte) error {
+	name, err := checkAssetName(name)
+	if err != nil {
+		return err
+	}
+	_ = ctx
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n, ok := r.nodes[id]
+	if !ok {
+		return NewNodeNotFoundError(id)
+	}
 
 	switch kind {
 	case AssetKindImage:
-		n.images[name] = data
+		n.images[name] = bytes.Clone(data)
 	case AssetKindItem:
-		n.items[name] = data
+		n.items[name] = bytes.Clone(data)
 	default:
-		return fmt.Errorf("unknown asset kind %q", kind)
+		return NewUnknownAssetKindError(kind)
 	}
 	return nil
 }
 
 // MoveNode renames or moves a node from id to dst.
 //
-// - If the source node does not exist, ErrNodeNotFound is returned.
+// - If the source node does not exist, a NodeNotFoundError is returned.
 // - If the destination already exists, a DestinationExistsError is returned.
 // The move is performed by transferring the in-memory node pointer.
 func (r *MemoryRepo) MoveNode(ctx context.Context, id NodeId, dst NodeId) error {
@@ -363,10 +415,10 @@ func (r *MemoryRepo) MoveNode(ctx context.Context, id NodeId, dst NodeId) error
 	defer r.mu.Unlock()
 	srcNode, ok := r.nodes[id]
 	if !ok {
-		return ErrNotExist
+		return NewNodeNotFoundError(id)
 	}
 	if _, exists := r.nodes[dst]; exists {
-		return ErrDestinationExists
+		return NewDestinationExistsError(dst)
 	}
 	// Move (transfer pointer)
 	r.nodes[dst] = srcNode
@@ -378,14 +430,14 @@ func (r *MemoryRepo) MoveNode(ctx context.Context, id NodeId, dst NodeId) error
 	return nil
 }
 
-// GetIndex reads a stored index by name. If not present, ErrNotFound is returned.
+// GetIndex reads a stored index by name. If not present, a NotFoundError matching ErrNotExist is returned.
 // The returned bytes are a copy.
 func (r *MemoryRepo) GetIndex(ctx context.Context, name string) ([]byte, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 	b, ok := r.indexes[name]
 	if !ok {
-		return nil, ErrNotExist
+		return nil, NewNotFoundError("index", name)
 	}
 	cp := make([]byte, len(b))
 	copy(cp, b)
@@ -396,7 +448,7 @@ func (r *MemoryRepo) GetIndex(ctx context.Context, name string) ([]byte, error)
 func (r *MemoryRepo) WriteIndex(ctx context.Context, name string, data []byte) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	r.indexes[name] = data
+	r.indexes[name] = bytes.Clone(data)
 	return nil
 }
 
@@ -414,7 +466,7 @@ func (r *MemoryRepo) DeleteNode(ctx context.Context, id NodeId) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	if _, ok := r.nodes[id]; !ok {
-		return ErrNotExist
+		return NewNodeNotFoundError(id)
 	}
 	delete(r.nodes, id)
 	delete(r.snapshots, id)
@@ -423,27 +475,33 @@ func (r *MemoryRepo) DeleteNode(ctx context.Context, id NodeId) error {
 
 // DeleteAsset removes an asset by name for a node.
 func (r *MemoryRepo) DeleteAsset(ctx context.Context, id NodeId, kind AssetKind, name string) error {
+	name, err := checkAssetName(name)
+	if err != nil {
+		return err
+	}
 	_ = ctx
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	n, ok := r.nodes[id]
 	if !ok {
-		return ErrNotExist
+		return NewNodeNotFoundError(id)
 	}
 
 	switch kind {
 	case AssetKindImage:
 		if _, ok := n.images[name]; !ok {
-			return ErrNotExist
+			return NewNotFoundError("image", name)
 		}
 		delete(n.images, name)
+		delete(n.itemMeta, itemMetaKey(kind, name))
 	case AssetKindItem:
 		if _, ok := n.items[name]; !ok {
-			return ErrNotExist
+			return NewNotFoundError("file", name)
 		}
 		delete(n.items, name)
+		delete(n.itemMeta, itemMetaKey(kind, name))
 	default:
-		return fmt.Errorf("unknown asset kind %q", kind)
+		return NewUnknownAssetKindError(kind)
 	}
 	return nil
 }
@@ -457,16 +515,20 @@ func (r *MemoryRepo) ListImages(ctx context.Context, id NodeId) ([]string, error
 }
 
 func (r *MemoryRepo) ReadFile(ctx context.Context, id NodeId, name string) ([]byte, error) {
+	name, err := checkAssetName(name)
+	if err != nil {
+		return nil, err
+	}
 	_ = ctx
 	n, ok := r.getNode(id)
 	if !ok {
-		return nil, ErrNotExist
+		return nil, NewNodeNotFoundError(id)
 	}
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 	data, exists := n.items[name]
 	if !exists {
-		return nil, ErrNotExist
+		return nil, NewNotFoundError("file", name)
 	}
 	cp := make([]byte, len(data))
 	copy(cp, data)
@@ -474,16 +536,20 @@ func (r *MemoryRepo) ReadFile(ctx context.Context, id NodeId, name string) ([]by
 }
 
 func (r *MemoryRepo) ReadImage(ctx context.Context, id NodeId, name string) ([]byte, error) {
+	name, err := checkAssetName(name)
+	if err != nil {
+		return nil, err
+	}
 	_ = ctx
 	n, ok := r.getNode(id)
 	if !ok {
-		return nil, ErrNotExist
+		return nil, NewNodeNotFoundError(id)
 	}
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 	data, exists := n.images[name]
 	if !exists {
-		return nil, ErrNotExist
+		return nil, NewNotFoundError("image", name)
 	}
 	cp := make([]byte, len(data))
 	copy(cp, data)
@@ -507,13 +573,13 @@ func (r *MemoryRepo) DeleteFile(ctx context.Context, id NodeId, name string) err
 }
 
 // ReadConfig returns the repository-level config previously written with
-// WriteConfig. If no config has been written, ErrNotFound is returned.
+// WriteConfig. If no config has been written, a NotFoundError matching ErrNotExist is returned.
 // A copy of the stored Config is returned to avoid external mutation.
 func (r *MemoryRepo) ReadConfig(ctx context.Context) (*Config, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 	if r.config == nil {
-		return nil, ErrNotExist
+		return nil, NewNotFoundError("keg config", "")
 	}
 	c := *r.config
 	return &c, nil
@@ -523,8 +589,8 @@ func (r *MemoryRepo) ReadConfig(ctx context.Context) (*Config, error) {
 func (r *MemoryRepo) WriteConfig(ctx context.Context, config *Config) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	c := config
-	r.config = c
+	c := *config
+	r.config = &c
 	return nil
 }
 
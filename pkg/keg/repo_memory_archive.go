@@ -0,0 +1,121 @@
+package keg
+
+import (
+	"context"
+	"slices"
+	"strings"
+)
+
+// ArchiveNode implements RepositoryArchive by moving the node into the
+// in-memory archived map. Snapshot history is kept with the node.
+func (r *MemoryRepo) ArchiveNode(ctx context.Context, id NodeId) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	node, ok := r.nodes[id]
+	if !ok {
+		return NewNodeNotFoundError(id)
+	}
+	if _, exists := r.archived[id]; exists {
+		return NewDestinationExistsError(id)
+	}
+	r.archived[id] = node
+	delete(r.nodes, id)
+	return nil
+}
+
+// UnarchiveNode implements RepositoryArchive.
+func (r *MemoryRepo) UnarchiveNode(ctx context.Context, id NodeId) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	node, ok := r.archived[id]
+	if !ok {
+		return NewNotFoundError("archived node", id.Path())
+	}
+	if _, exists := r.nodes[id]; exists {
+		return NewDestinationExistsError(id)
+	}
+	r.nodes[id] = node
+	delete(r.archived, id)
+	return nil
+}
+
+// ListArchived implements RepositoryArchive.
+func (r *MemoryRepo) ListArchived(ctx context.Context) ([]NodeId, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	ids := make([]NodeId, 0, len(r.archived))
+	for id := range r.archived {
+		ids = append(ids, id)
+	}
+	slices.SortFunc(ids, func(a, b NodeId) int { return a.Compare(b) })
+	return ids, nil
+}
+
+// ReadArchivedContent implements RepositoryArchive. The returned slice is a
+// copy.
+func (r *MemoryRepo) ReadArchivedContent(ctx context.Context, id NodeId) ([]byte, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	node, ok := r.archived[id]
+	if !ok {
+		return nil, NewNotFoundError("archived node", id.Path())
+	}
+	return slices.Clone(node.content), nil
+}
+
+// ReadArchivedMeta implements RepositoryArchive. The returned slice is a copy.
+func (r *MemoryRepo) ReadArchivedMeta(ctx context.Context, id NodeId) ([]byte, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	node, ok := r.archived[id]
+	if !ok {
+		return nil, NewNotFoundError("archived node", id.Path())
+	}
+	return slices.Clone(node.meta), nil
+}
+
+// ReadArchivedStats implements RepositoryArchivedState.
+func (r *MemoryRepo) ReadArchivedStats(ctx context.Context, id NodeId) (*NodeStats, error) {
+	r.mu.RLock()
+	node, ok := r.archived[id]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, NewNotFoundError("archived node", id.Path())
+	}
+	if node.stats == nil {
+		return nil, NewNotFoundError("stats", id.Path())
+	}
+	return ParseStats(ctx, slices.Clone(node.stats))
+}
+
+// ReadArchivedItems implements RepositoryArchivedState. The returned items
+// are copies.
+func (r *MemoryRepo) ReadArchivedItems(ctx context.Context, id NodeId) (*NodeItems, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	node, ok := r.archived[id]
+	if !ok {
+		return nil, NewNotFoundError("archived node", id.Path())
+	}
+	items := newNodeItems()
+	for name, data := range node.items {
+		if version, ok := strings.CutPrefix(name, NodeVersionsDir+"/"); ok {
+			items.Versions[version] = slices.Clone(data)
+			continue
+		}
+		items.Files[name] = slices.Clone(data)
+	}
+	for name, data := range node.images {
+		items.Images[name] = slices.Clone(data)
+	}
+	for key, meta := range node.itemMeta {
+		if name, ok := strings.CutPrefix(key, itemMetaKey(AssetKindImage, "")); ok {
+			items.ImageMeta[name] = &meta
+		} else if name, ok := strings.CutPrefix(key, itemMetaKey(AssetKindItem, "")); ok {
+			items.FileMeta[name] = &meta
+		}
+	}
+	return items, nil
+}
+
+var _ RepositoryArchivedState = (*MemoryRepo)(nil)
@@ -0,0 +1,64 @@
+package keg
+
+import (
+	"context"
+)
+
+func itemMetaKey(kind AssetKind, name string) string {
+	return string(kind) + "/" + name
+}
+
+// ReadItemMeta implements RepositoryItemMeta.
+func (r *MemoryRepo) ReadItemMeta(ctx context.Context, id NodeId, kind AssetKind, name string) (*ItemMeta, error) {
+	name, err := checkAssetName(name)
+	if err != nil {
+		return nil, err
+	}
+	_ = ctx
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	n, ok := r.nodes[id]
+	if !ok {
+		return nil, NewNodeNotFoundError(id)
+	}
+	meta, ok := n.itemMeta[itemMetaKey(kind, name)]
+	if !ok {
+		return nil, NewNotFoundError("item metadata", name)
+	}
+	return &meta, nil
+}
+
+// WriteItemMeta implements RepositoryItemMeta.
+func (r *MemoryRepo) WriteItemMeta(ctx context.Context, id NodeId, kind AssetKind, name string, meta *ItemMeta) error {
+	name, err := checkAssetName(name)
+	if err != nil {
+		return err
+	}
+	_ = ctx
+	if meta == nil {
+		meta = &ItemMeta{}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n, ok := r.nodes[id]
+	if !ok {
+		return NewNodeNotFoundError(id)
+	}
+	var exists bool
+	switch kind {
+	case AssetKindImage:
+		_, exists = n.images[name]
+	case AssetKindItem:
+		_, exists = n.items[name]
+	default:
+		return NewUnknownAssetKindError(kind)
+	}
+	if !exists {
+		return NewNotFoundError(string(kind), name)
+	}
+	if n.itemMeta == nil {
+		n.itemMeta = make(map[string]ItemMeta)
+	}
+	n.itemMeta[itemMetaKey(kind, name)] = *meta
+	return nil
+}
@@ -0,0 +1,70 @@
+package keg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// WithKegLock executes fn while holding the in-process keg-wide lock.
+func (r *MemoryRepo) WithKegLock(ctx context.Context, fn func(context.Context) error) error {
+	if fn == nil {
+		return fmt.Errorf("fn required")
+	}
+	if contextHasKegLock(ctx) {
+		return fn(ctx)
+	}
+
+	if !r.tryKegLock() {
+		ticker := time.NewTicker(100 * time.Millisecond)
+		defer ticker.Stop()
+		for acquired := false; !acquired; {
+			select {
+			case <-ctx.Done():
+				return fmt.Errorf("%w: keg is locked: %w", ErrLockTimeout, ctx.Err())
+			case <-ticker.C:
+				acquired = r.tryKegLock()
+			}
+		}
+	}
+
+	runErr := fn(contextWithKegLock(ctx))
+	return errors.Join(runErr, r.BreakKegLock(ctx))
+}
+
+func (r *MemoryRepo) tryKegLock() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.kegLocked {
+		return false
+	}
+	r.kegLocked = true
+	return true
+}
+
+// KegLockOwner reports the current process while the keg-wide lock is held.
+func (r *MemoryRepo) KegLockOwner(ctx context.Context) (*LockOwner, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if !r.kegLocked {
+		return nil, nil
+	}
+	owner := &LockOwner{}
+	if r.runtime != nil {
+		if pi := r.runtime.Process(); pi != nil {
+			owner.PID = pi.PID
+			owner.Hostname = pi.Hostname
+			owner.StartedAt = pi.StartedAt
+		}
+	}
+	return owner, nil
+}
+
+// BreakKegLock releases the keg-wide lock.
+func (r *MemoryRepo) BreakKegLock(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.kegLocked = false
+	return nil
+}
@@ -13,7 +13,7 @@ func (r *MemoryRepo) AppendSnapshot(ctx context.Context, id NodeId, in SnapshotW
 
 func (r *MemoryRepo) appendSnapshotLocked(ctx context.Context, id NodeId, in SnapshotWrite) (Snapshot, error) {
 	if _, ok := r.nodes[id]; !ok {
-		return Snapshot{}, ErrNotExist
+		return Snapshot{}, NewNodeNotFoundError(id)
 	}
 
 	entries := r.snapshots[id]
@@ -81,7 +81,7 @@ func (r *MemoryRepo) ListSnapshots(ctx context.Context, id NodeId) ([]Snapshot,
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 	if _, ok := r.nodes[id]; !ok {
-		return nil, ErrNotExist
+		return nil, NewNodeNotFoundError(id)
 	}
 
 	entries := r.snapshots[id]
@@ -118,7 +118,7 @@ func (r *MemoryRepo) RestoreSnapshot(ctx context.Context, id NodeId, rev Revisio
 	}
 	node, ok := r.nodes[id]
 	if !ok {
-		return ErrNotExist
+		return NewNodeNotFoundError(id)
 	}
 
 	node.content = cloneBytes(entry.content)
@@ -149,14 +149,22 @@ func (r *MemoryRepo) RestoreSnapshot(ctx context.Context, id NodeId, rev Revisio
 
 func (r *MemoryRepo) snapshotEntryLocked(id NodeId, rev RevisionID) (memorySnapshotEntry, error) {
 	if _, ok := r.nodes[id]; !ok {
-		return memorySnapshotEntry{}, ErrNotExist
+		return memorySnapshotEntry{}, NewNodeNotFoundError(id)
 	}
 	for _, entry := range r.snapshots[id] {
 		if entry.snapshot.ID == rev {
 			return entry, nil
 		}
 	}
-	return memorySnapshotEntry{}, ErrNotExist
+	return memorySnapshotEntry{}, newSnapshotNotFoundError(id, rev)
 }
 
 var _ RepositorySnapshots = (*MemoryRepo)(nil)
+
+// PurgeSnapshots implements RepositorySnapshotPurge.
+func (r *MemoryRepo) PurgeSnapshots(ctx context.Context, id NodeId) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.snapshots, id)
+	return nil
+}
@@ -0,0 +1,25 @@
+package keg
+
+import (
+	"bytes"
+	"context"
+	"io"
+)
+
+// OpenItem implements RepositoryStreams.
+func (r *MemoryRepo) OpenItem(ctx context.Context, id NodeId, kind AssetKind, name string) (io.ReadCloser, error) {
+	var data []byte
+	var err error
+	switch kind {
+	case AssetKindImage:
+		data, err = r.ReadImage(ctx, id, name)
+	case AssetKindItem:
+		data, err = r.ReadFile(ctx, id, name)
+	default:
+		return nil, NewUnknownAssetKindError(kind)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
@@ -129,6 +129,7 @@ func TestMemoryRepo_AssetsAPI(t *testing.T) {
 	r := keg.NewMemoryRepo(fx.Runtime())
 	ctx := fx.Context()
 	id := keg.NodeId{ID: 41}
+	require.NoError(t, r.WriteContent(ctx, id, []byte("# node\n")))
 
 	require.NoError(t, r.WriteAsset(ctx, id, keg.AssetKindImage, "a.png", []byte("png")))
 	require.NoError(t, r.WriteAsset(ctx, id, keg.AssetKindItem, "doc.txt", []byte("txt")))
@@ -147,6 +148,37 @@ func TestMemoryRepo_AssetsAPI(t *testing.T) {
 	require.Empty(t, items)
 }
 
+func TestMemoryRepo_ItemMeta(t *testing.T) {
+	t.Parallel()
+	fx := NewSandbox(t)
+
+	r := keg.NewMemoryRepo(fx.Runtime())
+	ctx := fx.Context()
+	id := keg.NodeId{ID: 7}
+	require.NoError(t, r.WriteContent(ctx, id, []byte("# node\n")))
+
+	meta := keg.NewItemMeta("logo.png", []byte("\x89PNG\r\n\x1a\n"))
+	meta.Alt = "logo"
+	err := r.WriteItemMeta(ctx, id, keg.AssetKindImage, "logo.png", meta)
+	require.ErrorIs(t, err, keg.ErrNotExist, "metadata requires the item to exist")
+
+	require.NoError(t, r.WriteImage(ctx, id, "logo.png", []byte("\x89PNG\r\n\x1a\n")))
+	require.NoError(t, r.WriteItemMeta(ctx, id, keg.AssetKindImage, "logo.png", meta))
+
+	got, err := r.ReadItemMeta(ctx, id, keg.AssetKindImage, "logo.png")
+	require.NoError(t, err)
+	require.Equal(t, meta, got)
+	require.Equal(t, "image/png", got.MimeType)
+	require.EqualValues(t, 8, got.Size)
+
+	_, err = r.ReadItemMeta(ctx, id, keg.AssetKindItem, "logo.png")
+	require.ErrorIs(t, err, keg.ErrNotExist)
+
+	require.NoError(t, r.DeleteImage(ctx, id, "logo.png"))
+	_, err = r.ReadItemMeta(ctx, id, keg.AssetKindImage, "logo.png")
+	require.ErrorIs(t, err, keg.ErrNotExist)
+}
+
 func TestMemoryRepo_MoveNodeAndDestinationExists(t *testing.T) {
 	t.Parallel()
 	fx := NewSandbox(t)
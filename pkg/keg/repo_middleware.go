@@ -0,0 +1,600 @@
+package keg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+)
+
+// RepoMiddleware wraps a Repository to add behavior around its calls, such
+// as logging or retries. Middlewares are composed with ChainRepo and
+// attached to a keg with WithRepoMiddleware.
+type RepoMiddleware func(Repository) Repository
+
+// ChainRepo wraps repo in mws. The first middleware is the outermost, so it
+// sees each call first and its result last.
+func ChainRepo(repo Repository, mws ...RepoMiddleware) Repository {
+	for i := len(mws) - 1; i >= 0; i-- {
+		repo = mws[i](repo)
+	}
+	return repo
+}
+
+// WithRepoMiddleware wraps the keg's repository in mws when the keg is
+// constructed.
+func WithRepoMiddleware(mws ...RepoMiddleware) Option {
+	return func(k *Keg) {
+		k.Repo = ChainRepo(k.Repo, mws...)
+	}
+}
+
+// UnwrapRepo returns the backend under any middlewares added with
+// InterceptRepo. Use it to reach backend-specific settings such as
+// FsRepo.Durability; calls on the result bypass the middlewares.
+func UnwrapRepo(repo Repository) Repository {
+	for {
+		u, ok := repo.(interface{ Unwrap() Repository })
+		if !ok {
+			return repo
+		}
+		repo = u.Unwrap()
+	}
+}
+
+// RepoCallKind classifies a repository call for middlewares.
+type RepoCallKind string
+
+const (
+	// RepoRead calls only read the backend and are safe to repeat.
+	RepoRead RepoCallKind = "read"
+	// RepoWrite calls change the backend, including Next, which reserves
+	// an id.
+	RepoWrite RepoCallKind = "write"
+	// RepoLock calls run a caller function under a node or keg lock.
+	RepoLock RepoCallKind = "lock"
+)
+
+// RepoCall describes one repository call. Op is the Repository method name,
+// such as "ReadContent". Node is the node path for calls on a node and Name
+// the item or index name.
+type RepoCall struct {
+	Op   string
+	Kind RepoCallKind
+	Node string
+	Name string
+}
+
+// RepoInterceptor runs around a repository call. It must call next to
+// perform the call, at most once for writes and locks, and return its
+// error, or return an error of its own without calling next.
+type RepoInterceptor func(ctx context.Context, call RepoCall, next func(context.Context) error) error
+
+// InterceptRepo returns a middleware that routes every call of the wrapped
+// repository through fn, except Name. The wrapped repository keeps the
+// optional files, images, item metadata, streams, keg lock, archive and
+// snapshot support of the file and memory backends; other backends keep
+// only Repository. Partial content reads fall back to ReadContent.
+func InterceptRepo(fn RepoInterceptor) RepoMiddleware {
+	return func(repo Repository) Repository {
+		core := &middlewareRepo{Repo: repo, fn: fn}
+		if _, ok := repo.(localRepository); ok {
+			return &localMiddlewareRepo{middlewareRepo: core}
+		}
+		return core
+	}
+}
+
+// ReadOnlyRepo rejects every write with ErrReadOnly. Reads and locks pass
+// through.
+func ReadOnlyRepo() RepoMiddleware {
+	return InterceptRepo(func(ctx context.Context, call RepoCall, next func(context.Context) error) error {
+		if call.Kind == RepoWrite {
+			return fmt.Errorf("%s: %w", call.Op, ErrReadOnly)
+		}
+		return next(ctx)
+	})
+}
+
+// LogRepo logs every repository call with its duration at trace level, and
+// failed calls at debug level.
+func LogRepo(lg *slog.Logger) RepoMiddleware {
+	return TimeRepo(func(ctx context.Context, call RepoCall, elapsed time.Duration, err error) {
+		attrs := []any{"op", call.Op, "elapsed", elapsed}
+		if call.Node != "" {
+			attrs = append(attrs, "node", call.Node)
+		}
+		if call.Name != "" {
+			attrs = append(attrs, "name", call.Name)
+		}
+		if err != nil {
+			lg.Log(ctx, slog.LevelDebug, "repository call failed", append(attrs, "error", err.Error())...)
+			return
+		}
+		lg.Log(ctx, LevelTrace, "repository call", attrs...)
+	})
+}
+
+// SlowRepoCalls logs a warning for every repository call that takes longer
+// than threshold. Lock calls are not reported since their time includes the
+// work done under the lock.
+func SlowRepoCalls(lg *slog.Logger, threshold time.Duration) RepoMiddleware {
+	return TimeRepo(func(ctx context.Context, call RepoCall, elapsed time.Duration, err error) {
+		if elapsed <= threshold || call.Kind == RepoLock {
+			return
+		}
+		lg.Warn("slow repository call", "op", call.Op, "node", call.Node, "elapsed", elapsed, "threshold", threshold)
+	})
+}
+
+// TimeRepo reports the duration and outcome of every repository call to
+// observe. Lock calls are timed including the work done under the lock.
+func TimeRepo(observe func(ctx context.Context, call RepoCall, elapsed time.Duration, err error)) RepoMiddleware {
+	return InterceptRepo(func(ctx context.Context, call RepoCall, next func(context.Context) error) error {
+		start := time.Now()
+		err := next(ctx)
+		observe(ctx, call, time.Since(start), err)
+		return err
+	})
+}
+
+// RetryRepo retries reads that fail with a retryable or temporary error
+// (see IsRetryable), up to attempts tries in total. The wait starts at
+// delay and doubles per retry; a *RateLimitError asking for longer is
+// honored. Writes and locks are never retried.
+func RetryRepo(attempts int, delay time.Duration) RepoMiddleware {
+	return InterceptRepo(func(ctx context.Context, call RepoCall, next func(context.Context) error) error {
+		err := next(ctx)
+		if call.Kind != RepoRead {
+			return err
+		}
+		wait := delay
+		for try := 1; try < attempts && (IsRetryable(err) || IsTemporary(err)); try++ {
+			pause := wait
+			var rl *RateLimitError
+			if errors.As(err, &rl) && rl.RetryAfter > pause {
+				pause = rl.RetryAfter
+			}
+			timer := time.NewTimer(pause)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return err
+			case <-timer.C:
+			}
+			wait *= 2
+			err = next(ctx)
+		}
+		return err
+	})
+}
+
+// localRepository is the set of optional capabilities shared by FsRepo and
+// MemoryRepo, which InterceptRepo keeps on the wrapped repository.
+type localRepository interface {
+	Repository
+	RepositoryFiles
+	RepositoryImages
+	RepositoryItemMeta
+	RepositoryStreams
+	RepositoryKegLock
+	RepositoryArchive
+	RepositorySnapshots
+}
+
+// middlewareRepo routes Repository calls through an interceptor.
+type middlewareRepo struct {
+	Repo Repository
+	fn   RepoInterceptor
+}
+
+var _ Repository = (*middlewareRepo)(nil)
+var _ RepositoryContentRange = (*middlewareRepo)(nil)
+var _ localRepository = (*localMiddlewareRepo)(nil)
+var _ RepositoryBlobs = (*localMiddlewareRepo)(nil)
+var _ RepositorySnapshotPurge = (*localMiddlewareRepo)(nil)
+var _ RepositoryArchivedState = (*localMiddlewareRepo)(nil)
+
+// Unwrap returns the wrapped repository.
+func (r *middlewareRepo) Unwrap() Repository {
+	return r.Repo
+}
+
+func (r *middlewareRepo) call(ctx context.Context, call RepoCall, next func(context.Context) error) error {
+	return r.fn(ctx, call, next)
+}
+
+func (r *middlewareRepo) Name() string {
+	return r.Repo.Name()
+}
+
+func (r *middlewareRepo) HasNode(ctx context.Context, id NodeId) (ok bool, err error) {
+	err = r.call(ctx, RepoCall{Op: "HasNode", Kind: RepoRead, Node: id.Path()}, func(ctx context.Context) (err error) {
+		ok, err = r.Repo.HasNode(ctx, id)
+		return err
+	})
+	return ok, err
+}
+
+func (r *middlewareRepo) Next(ctx context.Context) (id NodeId, err error) {
+	err = r.call(ctx, RepoCall{Op: "Next", Kind: RepoWrite}, func(ctx context.Context) (err error) {
+		id, err = r.Repo.Next(ctx)
+		return err
+	})
+	return id, err
+}
+
+func (r *middlewareRepo) ListNodes(ctx context.Context) (ids []NodeId, err error) {
+	err = r.call(ctx, RepoCall{Op: "ListNodes", Kind: RepoRead}, func(ctx context.Context) (err error) {
+		ids, err = r.Repo.ListNodes(ctx)
+		return err
+	})
+	return ids, err
+}
+
+func (r *middlewareRepo) MoveNode(ctx context.Context, id NodeId, dst NodeId) error {
+	return r.call(ctx, RepoCall{Op: "MoveNode", Kind: RepoWrite, Node: id.Path()}, func(ctx context.Context) error {
+		return r.Repo.MoveNode(ctx, id, dst)
+	})
+}
+
+func (r *middlewareRepo) DeleteNode(ctx context.Context, id NodeId) error {
+	return r.call(ctx, RepoCall{Op: "DeleteNode", Kind: RepoWrite, Node: id.Path()}, func(ctx context.Context) error {
+		return r.Repo.DeleteNode(ctx, id)
+	})
+}
+
+func (r *middlewareRepo) WithNodeLock(ctx context.Context, id NodeId, fn func(context.Context) error) error {
+	return r.call(ctx, RepoCall{Op: "WithNodeLock", Kind: RepoLock, Node: id.Path()}, func(ctx context.Context) error {
+		return r.Repo.WithNodeLock(ctx, id, fn)
+	})
+}
+
+func (r *middlewareRepo) ReadContent(ctx context.Context, id NodeId) (data []byte, err error) {
+	err = r.call(ctx, RepoCall{Op: "ReadContent", Kind: RepoRead, Node: id.Path()}, func(ctx context.Context) (err error) {
+		data, err = r.Repo.ReadContent(ctx, id)
+		return err
+	})
+	return data, err
+}
+
+// ReadContentRange forwards partial reads when the wrapped repository
+// supports them and cuts the range from ReadContent otherwise.
+func (r *middlewareRepo) ReadContentRange(ctx context.Context, id NodeId, offset, length int64) (data []byte, err error) {
+	err = r.call(ctx, RepoCall{Op: "ReadContentRange", Kind: RepoRead, Node: id.Path()}, func(ctx context.Context) (err error) {
+		if withRange, ok := r.Repo.(RepositoryContentRange); ok {
+			data, err = withRange.ReadContentRange(ctx, id, offset, length)
+			return err
+		}
+		content, err := r.Repo.ReadContent(ctx, id)
+		data = contentRange(content, offset, length)
+		return err
+	})
+	return data, err
+}
+
+func (r *middlewareRepo) WriteContent(ctx context.Context, id NodeId, data []byte) error {
+	return r.call(ctx, RepoCall{Op: "WriteContent", Kind: RepoWrite, Node: id.Path()}, func(ctx context.Context) error {
+		return r.Repo.WriteContent(ctx, id, data)
+	})
+}
+
+func (r *middlewareRepo) ReadMeta(ctx context.Context, id NodeId) (data []byte, err error) {
+	err = r.call(ctx, RepoCall{Op: "ReadMeta", Kind: RepoRead, Node: id.Path()}, func(ctx context.Context) (err error) {
+		data, err = r.Repo.ReadMeta(ctx, id)
+		return err
+	})
+	return data, err
+}
+
+func (r *middlewareRepo) WriteMeta(ctx context.Context, id NodeId, data []byte) error {
+	return r.call(ctx, RepoCall{Op: "WriteMeta", Kind: RepoWrite, Node: id.Path()}, func(ctx context.Context) error {
+		return r.Repo.WriteMeta(ctx, id, data)
+	})
+}
+
+func (r *middlewareRepo) ReadStats(ctx context.Context, id NodeId) (stats *NodeStats, err error) {
+	err = r.call(ctx, RepoCall{Op: "ReadStats", Kind: RepoRead, Node: id.Path()}, func(ctx context.Context) (err error) {
+		stats, err = r.Repo.ReadStats(ctx, id)
+		return err
+	})
+	return stats, err
+}
+
+func (r *middlewareRepo) WriteStats(ctx context.Context, id NodeId, stats *NodeStats) error {
+	return r.call(ctx, RepoCall{Op: "WriteStats", Kind: RepoWrite, Node: id.Path()}, func(ctx context.Context) error {
+		return r.Repo.WriteStats(ctx, id, stats)
+	})
+}
+
+func (r *middlewareRepo) WriteNode(ctx context.Context, id NodeId, content, meta []byte, stats *NodeStats) error {
+	return r.call(ctx, RepoCall{Op: "WriteNode", Kind: RepoWrite, Node: id.Path()}, func(ctx context.Context) error {
+		return r.Repo.WriteNode(ctx, id, content, meta, stats)
+	})
+}
+
+func (r *middlewareRepo) GetIndex(ctx context.Context, name string) (data []byte, err error) {
+	err = r.call(ctx, RepoCall{Op: "GetIndex", Kind: RepoRead, Name: name}, func(ctx context.Context) (err error) {
+		data, err = r.Repo.GetIndex(ctx, name)
+		return err
+	})
+	return data, err
+}
+
+func (r *middlewareRepo) WriteIndex(ctx context.Context, name string, data []byte) error {
+	return r.call(ctx, RepoCall{Op: "WriteIndex", Kind: RepoWrite, Name: name}, func(ctx context.Context) error {
+		return r.Repo.WriteIndex(ctx, name, data)
+	})
+}
+
+func (r *middlewareRepo) ListIndexes(ctx context.Context) (names []string, err error) {
+	err = r.call(ctx, RepoCall{Op: "ListIndexes", Kind: RepoRead}, func(ctx context.Context) (err error) {
+		names, err = r.Repo.ListIndexes(ctx)
+		return err
+	})
+	return names, err
+}
+
+func (r *middlewareRepo) ClearIndexes(ctx context.Context) error {
+	return r.call(ctx, RepoCall{Op: "ClearIndexes", Kind: RepoWrite}, func(ctx context.Context) error {
+		return r.Repo.ClearIndexes(ctx)
+	})
+}
+
+func (r *middlewareRepo) ReadConfig(ctx context.Context) (cfg *Config, err error) {
+	err = r.call(ctx, RepoCall{Op: "ReadConfig", Kind: RepoRead}, func(ctx context.Context) (err error) {
+		cfg, err = r.Repo.ReadConfig(ctx)
+		return err
+	})
+	return cfg, err
+}
+
+func (r *middlewareRepo) WriteConfig(ctx context.Context, config *Config) error {
+	return r.call(ctx, RepoCall{Op: "WriteConfig", Kind: RepoWrite}, func(ctx context.Context) error {
+		return r.Repo.WriteConfig(ctx, config)
+	})
+}
+
+// localMiddlewareRepo adds the optional capabilities of localRepository to
+// middlewareRepo.
+type localMiddlewareRepo struct {
+	*middlewareRepo
+}
+
+func (r *localMiddlewareRepo) local() localRepository {
+	return r.Repo.(localRepository)
+}
+
+func (r *localMiddlewareRepo) ListFiles(ctx context.Context, id NodeId) (names []string, err error) {
+	err = r.call(ctx, RepoCall{Op: "ListFiles", Kind: RepoRead, Node: id.Path()}, func(ctx context.Context) (err error) {
+		names, err = r.local().ListFiles(ctx, id)
+		return err
+	})
+	return names, err
+}
+
+func (r *localMiddlewareRepo) ReadFile(ctx context.Context, id NodeId, name string) (data []byte, err error) {
+	err = r.call(ctx, RepoCall{Op: "ReadFile", Kind: RepoRead, Node: id.Path(), Name: name}, func(ctx context.Context) (err error) {
+		data, err = r.local().ReadFile(ctx, id, name)
+		return err
+	})
+	return data, err
+}
+
+func (r *localMiddlewareRepo) WriteFile(ctx context.Context, id NodeId, name string, data []byte) error {
+	return r.call(ctx, RepoCall{Op: "WriteFile", Kind: RepoWrite, Node: id.Path(), Name: name}, func(ctx context.Context) error {
+		return r.local().WriteFile(ctx, id, name, data)
+	})
+}
+
+func (r *localMiddlewareRepo) DeleteFile(ctx context.Context, id NodeId, name string) error {
+	return r.call(ctx, RepoCall{Op: "DeleteFile", Kind: RepoWrite, Node: id.Path(), Name: name}, func(ctx context.Context) error {
+		return r.local().DeleteFile(ctx, id, name)
+	})
+}
+
+func (r *localMiddlewareRepo) ListImages(ctx context.Context, id NodeId) (names []string, err error) {
+	err = r.call(ctx, RepoCall{Op: "ListImages", Kind: RepoRead, Node: id.Path()}, func(ctx context.Context) (err error) {
+		names, err = r.local().ListImages(ctx, id)
+		return err
+	})
+	return names, err
+}
+
+func (r *localMiddlewareRepo) ReadImage(ctx context.Context, id NodeId, name string) (data []byte, err error) {
+	err = r.call(ctx, RepoCall{Op: "ReadImage", Kind: RepoRead, Node: id.Path(), Name: name}, func(ctx context.Context) (err error) {
+		data, err = r.local().ReadImage(ctx, id, name)
+		return err
+	})
+	return data, err
+}
+
+func (r *localMiddlewareRepo) WriteImage(ctx context.Context, id NodeId, name string, data []byte) error {
+	return r.call(ctx, RepoCall{Op: "WriteImage", Kind: RepoWrite, Node: id.Path(), Name: name}, func(ctx context.Context) error {
+		return r.local().WriteImage(ctx, id, name, data)
+	})
+}
+
+func (r *localMiddlewareRepo) DeleteImage(ctx context.Context, id NodeId, name string) error {
+	return r.call(ctx, RepoCall{Op: "DeleteImage", Kind: RepoWrite, Node: id.Path(), Name: name}, func(ctx context.Context) error {
+		return r.local().DeleteImage(ctx, id, name)
+	})
+}
+
+func (r *localMiddlewareRepo) ReadItemMeta(ctx context.Context, id NodeId, kind AssetKind, name string) (meta *ItemMeta, err error) {
+	err = r.call(ctx, RepoCall{Op: "ReadItemMeta", Kind: RepoRead, Node: id.Path(), Name: name}, func(ctx context.Context) (err error) {
+		meta, err = r.local().ReadItemMeta(ctx, id, kind, name)
+		return err
+	})
+	return meta, err
+}
+
+func (r *localMiddlewareRepo) WriteItemMeta(ctx context.Context, id NodeId, kind AssetKind, name string, meta *ItemMeta) error {
+	return r.call(ctx, RepoCall{Op: "WriteItemMeta", Kind: RepoWrite, Node: id.Path(), Name: name}, func(ctx context.Context) error {
+		return r.local().WriteItemMeta(ctx, id, kind, name, meta)
+	})
+}
+
+// OpenItem intercepts opening the item; reads from the returned reader are
+// not seen by the middleware.
+func (r *localMiddlewareRepo) OpenItem(ctx context.Context, id NodeId, kind AssetKind, name string) (rc io.ReadCloser, err error) {
+	err = r.call(ctx, RepoCall{Op: "OpenItem", Kind: RepoRead, Node: id.Path(), Name: name}, func(ctx context.Context) (err error) {
+		rc, err = r.local().OpenItem(ctx, id, kind, name)
+		return err
+	})
+	return rc, err
+}
+
+func (r *localMiddlewareRepo) WithKegLock(ctx context.Context, fn func(context.Context) error) error {
+	return r.call(ctx, RepoCall{Op: "WithKegLock", Kind: RepoLock}, func(ctx context.Context) error {
+		return r.local().WithKegLock(ctx, fn)
+	})
+}
+
+func (r *localMiddlewareRepo) KegLockOwner(ctx context.Context) (owner *LockOwner, err error) {
+	err = r.call(ctx, RepoCall{Op: "KegLockOwner", Kind: RepoRead}, func(ctx context.Context) (err error) {
+		owner, err = r.local().KegLockOwner(ctx)
+		return err
+	})
+	return owner, err
+}
+
+func (r *localMiddlewareRepo) BreakKegLock(ctx context.Context) error {
+	return r.call(ctx, RepoCall{Op: "BreakKegLock", Kind: RepoWrite}, func(ctx context.Context) error {
+		return r.local().BreakKegLock(ctx)
+	})
+}
+
+func (r *localMiddlewareRepo) ArchiveNode(ctx context.Context, id NodeId) error {
+	return r.call(ctx, RepoCall{Op: "ArchiveNode", Kind: RepoWrite, Node: id.Path()}, func(ctx context.Context) error {
+		return r.local().ArchiveNode(ctx, id)
+	})
+}
+
+func (r *localMiddlewareRepo) UnarchiveNode(ctx context.Context, id NodeId) error {
+	return r.call(ctx, RepoCall{Op: "UnarchiveNode", Kind: RepoWrite, Node: id.Path()}, func(ctx context.Context) error {
+		return r.local().UnarchiveNode(ctx, id)
+	})
+}
+
+func (r *localMiddlewareRepo) ListArchived(ctx context.Context) (ids []NodeId, err error) {
+	err = r.call(ctx, RepoCall{Op: "ListArchived", Kind: RepoRead}, func(ctx context.Context) (err error) {
+		ids, err = r.local().ListArchived(ctx)
+		return err
+	})
+	return ids, err
+}
+
+func (r *localMiddlewareRepo) ReadArchivedContent(ctx context.Context, id NodeId) (data []byte, err error) {
+	err = r.call(ctx, RepoCall{Op: "ReadArchivedContent", Kind: RepoRead, Node: id.Path()}, func(ctx context.Context) (err error) {
+		data, err = r.local().ReadArchivedContent(ctx, id)
+		return err
+	})
+	return data, err
+}
+
+func (r *localMiddlewareRepo) ReadArchivedMeta(ctx context.Context, id NodeId) (data []byte, err error) {
+	err = r.call(ctx, RepoCall{Op: "ReadArchivedMeta", Kind: RepoRead, Node: id.Path()}, func(ctx context.Context) (err error) {
+		data, err = r.local().ReadArchivedMeta(ctx, id)
+		return err
+	})
+	return data, err
+}
+
+func (r *localMiddlewareRepo) AppendSnapshot(ctx context.Context, id NodeId, in SnapshotWrite) (snap Snapshot, err error) {
+	err = r.call(ctx, RepoCall{Op: "AppendSnapshot", Kind: RepoWrite, Node: id.Path()}, func(ctx context.Context) (err error) {
+		snap, err = r.local().AppendSnapshot(ctx, id, in)
+		return err
+	})
+	return snap, err
+}
+
+func (r *localMiddlewareRepo) GetSnapshot(ctx context.Context, id NodeId, rev RevisionID, opts SnapshotReadOptions) (snap Snapshot, content []byte, meta []byte, stats *NodeStats, err error) {
+	err = r.call(ctx, RepoCall{Op: "GetSnapshot", Kind: RepoRead, Node: id.Path()}, func(ctx context.Context) (err error) {
+		snap, content, meta, stats, err = r.local().GetSnapshot(ctx, id, rev, opts)
+		return err
+	})
+	return snap, content, meta, stats, err
+}
+
+func (r *localMiddlewareRepo) ListSnapshots(ctx context.Context, id NodeId) (snaps []Snapshot, err error) {
+	err = r.call(ctx, RepoCall{Op: "ListSnapshots", Kind: RepoRead, Node: id.Path()}, func(ctx context.Context) (err error) {
+		snaps, err = r.local().ListSnapshots(ctx, id)
+		return err
+	})
+	return snaps, err
+}
+
+func (r *localMiddlewareRepo) ReadContentAt(ctx context.Context, id NodeId, rev RevisionID) (data []byte, err error) {
+	err = r.call(ctx, RepoCall{Op: "ReadContentAt", Kind: RepoRead, Node: id.Path()}, func(ctx context.Context) (err error) {
+		data, err = r.local().ReadContentAt(ctx, id, rev)
+		return err
+	})
+	return data, err
+}
+
+func (r *localMiddlewareRepo) RestoreSnapshot(ctx context.Context, id NodeId, rev RevisionID, createRestoreSnapshot bool) error {
+	return r.call(ctx, RepoCall{Op: "RestoreSnapshot", Kind: RepoWrite, Node: id.Path()}, func(ctx context.Context) error {
+		return r.local().RestoreSnapshot(ctx, id, rev, createRestoreSnapshot)
+	})
+}
+
+// PurgeSnapshots forwards to the wrapped backend and returns ErrNotSupported
+// when it cannot purge snapshots.
+func (r *localMiddlewareRepo) PurgeSnapshots(ctx context.Context, id NodeId) error {
+	purge, ok := repoSnapshotPurge(r.Repo)
+	if !ok {
+		return fmt.Errorf("%s backend cannot purge snapshots: %w", r.Repo.Name(), ErrNotSupported)
+	}
+	return r.call(ctx, RepoCall{Op: "PurgeSnapshots", Kind: RepoWrite, Node: id.Path()}, func(ctx context.Context) error {
+		return purge.PurgeSnapshots(ctx, id)
+	})
+}
+
+// ReadArchivedStats forwards to the wrapped backend and returns
+// ErrNotSupported when its archive does not keep stats.
+func (r *localMiddlewareRepo) ReadArchivedStats(ctx context.Context, id NodeId) (stats *NodeStats, err error) {
+	state, ok := repoArchivedState(r.Repo)
+	if !ok {
+		return nil, fmt.Errorf("%s backend does not keep archived node stats: %w", r.Repo.Name(), ErrNotSupported)
+	}
+	err = r.call(ctx, RepoCall{Op: "ReadArchivedStats", Kind: RepoRead, Node: id.Path()}, func(ctx context.Context) (err error) {
+		stats, err = state.ReadArchivedStats(ctx, id)
+		return err
+	})
+	return stats, err
+}
+
+// ReadArchivedItems forwards to the wrapped backend and returns
+// ErrNotSupported when its archive does not keep items.
+func (r *localMiddlewareRepo) ReadArchivedItems(ctx context.Context, id NodeId) (items *NodeItems, err error) {
+	state, ok := repoArchivedState(r.Repo)
+	if !ok {
+		return nil, fmt.Errorf("%s backend does not keep archived node items: %w", r.Repo.Name(), ErrNotSupported)
+	}
+	err = r.call(ctx, RepoCall{Op: "ReadArchivedItems", Kind: RepoRead, Node: id.Path()}, func(ctx context.Context) (err error) {
+		items, err = state.ReadArchivedItems(ctx, id)
+		return err
+	})
+	return items, err
+}
+
+// CollectBlobs forwards to the wrapped backend's blob store and returns
+// ErrNotSupported when it has none.
+func (r *localMiddlewareRepo) CollectBlobs(ctx context.Context, dryRun bool) (report *BlobGCReport, err error) {
+	blobs, ok := repoBlobs(r.Repo)
+	if !ok {
+		return nil, fmt.Errorf("%s backend has no blob store: %w", r.Repo.Name(), ErrNotSupported)
+	}
+	kind := RepoWrite
+	if dryRun {
+		kind = RepoRead
+	}
+	err = r.call(ctx, RepoCall{Op: "CollectBlobs", Kind: kind}, func(ctx context.Context) (err error) {
+		report, err = blobs.CollectBlobs(ctx, dryRun)
+		return err
+	})
+	return report, err
+}
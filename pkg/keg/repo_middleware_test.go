@@ -0,0 +1,117 @@
+package keg_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jlrickert/tapper/pkg/keg"
+	kegurl "github.com/jlrickert/tapper/pkg/keg_url"
+	"github.com/jlrickert/tapper/pkg/kegtest"
+	"github.com/stretchr/testify/require"
+)
+
+// passThrough is a middleware that forwards every call unchanged.
+var passThrough = keg.InterceptRepo(func(ctx context.Context, _ keg.RepoCall, next func(context.Context) error) error {
+	return next(ctx)
+})
+
+func TestRepoMiddleware_Conformance(t *testing.T) {
+	t.Parallel()
+	t.Run("memory", func(t *testing.T) {
+		t.Parallel()
+		kegtest.RunRepositoryConformance(t, func(t *testing.T) keg.Repository {
+			return keg.ChainRepo(newMemoryRepo(t), passThrough, keg.RetryRepo(2, time.Millisecond))
+		})
+	})
+	t.Run("filesystem", func(t *testing.T) {
+		t.Parallel()
+		kegtest.RunRepositoryConformance(t, func(t *testing.T) keg.Repository {
+			return keg.ChainRepo(newFsRepo(t), passThrough, keg.RetryRepo(2, time.Millisecond))
+		})
+	})
+}
+
+func TestChainRepo_FirstMiddlewareIsOutermost(t *testing.T) {
+	t.Parallel()
+	var log []string
+	trace := func(name string) keg.RepoMiddleware {
+		return keg.InterceptRepo(func(ctx context.Context, call keg.RepoCall, next func(context.Context) error) error {
+			log = append(log, name+" "+call.Op+" "+call.Node)
+			return next(ctx)
+		})
+	}
+	mem := newMemoryRepo(t)
+	repo := keg.ChainRepo(mem, trace("outer"), trace("inner"))
+
+	_, err := repo.HasNode(t.Context(), keg.NodeId{ID: 3})
+	require.NoError(t, err)
+	require.Equal(t, []string{"outer HasNode 3", "inner HasNode 3"}, log)
+	require.Same(t, mem, keg.UnwrapRepo(repo))
+	_, ok := repo.(keg.RepositoryArchive)
+	require.True(t, ok, "memory repo capabilities are kept")
+}
+
+func TestReadOnlyRepo_RejectsWrites(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t)
+	ctx, rt := sb.Context(), sb.Runtime()
+	repo := keg.NewMemoryRepo(rt)
+	kegtest.NewKegFixture().Node(1, "One", "Body.").MustBuild(t, ctx, repo, rt)
+
+	k := keg.NewKeg(repo, rt, keg.WithRepoMiddleware(keg.ReadOnlyRepo()))
+	content, err := k.GetContent(ctx, keg.NodeId{ID: 1})
+	require.NoError(t, err)
+	require.Contains(t, string(content), "# One")
+
+	_, err = k.Create(ctx, &keg.CreateOptions{Title: "Two"})
+	require.ErrorIs(t, err, keg.ErrReadOnly)
+	require.ErrorIs(t, err, keg.ErrPermission)
+	require.ErrorIs(t, k.SetContent(ctx, keg.NodeId{ID: 1}, []byte("# Changed\n")), keg.ErrReadOnly)
+	require.ErrorIs(t, k.Archive(ctx, keg.NodeId{ID: 1}), keg.ErrReadOnly)
+}
+
+func TestNewKegFromTarget_ReadonlyTarget(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t)
+	ctx := sb.Context()
+
+	var calls []string
+	k, err := keg.NewKegFromTarget(ctx, kegurl.NewFile("~/kegs/ro", kegurl.WithReadonly()), sb.Runtime(),
+		keg.WithRepoMiddleware(keg.TimeRepo(func(_ context.Context, call keg.RepoCall, _ time.Duration, err error) {
+			if err != nil {
+				calls = append(calls, call.Op)
+			}
+		})))
+	require.NoError(t, err)
+	require.IsType(t, &keg.FsRepo{}, keg.UnwrapRepo(k.Repo))
+	require.ErrorIs(t, k.Repo.WriteIndex(ctx, "nodes.tsv", nil), keg.ErrReadOnly)
+	require.Equal(t, []string{"WriteIndex"}, calls, "options wrap the read-only check")
+}
+
+func TestRetryRepo_RetriesTransientReads(t *testing.T) {
+	t.Parallel()
+	sb := NewSandbox(t)
+	ctx, rt := sb.Context(), sb.Runtime()
+	mem := keg.NewMemoryRepo(rt)
+	kegtest.NewKegFixture().Node(1, "One", "Body.").MustBuild(t, ctx, mem, rt)
+
+	flaky := keg.NewTransientError(errors.New("connection reset"))
+	faulty := kegtest.NewFaultyRepo(mem).
+		Inject(kegtest.Fault{Op: "ReadContent", Err: flaky, Times: 2}).
+		Inject(kegtest.Fault{Op: "WriteContent", Err: flaky, Times: 1})
+	repo := keg.ChainRepo(faulty, keg.RetryRepo(3, time.Millisecond))
+
+	content, err := repo.ReadContent(ctx, keg.NodeId{ID: 1})
+	require.NoError(t, err)
+	require.Contains(t, string(content), "# One")
+	require.Equal(t, 3, faulty.Calls("ReadContent"))
+
+	require.Error(t, repo.WriteContent(ctx, keg.NodeId{ID: 1}, []byte("# One\n")))
+	require.Equal(t, 1, faulty.Calls("WriteContent"), "writes are not retried")
+
+	_, err = repo.ReadContent(ctx, keg.NodeId{ID: 9})
+	require.ErrorIs(t, err, keg.ErrNotExist)
+	require.Equal(t, 4, faulty.Calls("ReadContent"), "missing nodes are not retried")
+}
@@ -0,0 +1,420 @@
+package keg
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/jlrickert/cli-toolkit/toolkit"
+)
+
+// RecordedCall is one line of a RecordingRepo log. Sizes count the bytes
+// read or written; Count is the number of items a List call returned.
+type RecordedCall struct {
+	Seq      int           `json:"seq"`
+	Time     time.Time     `json:"time"`
+	Op       string        `json:"op"`
+	Node     string        `json:"node,omitempty"`
+	Dst      string        `json:"dst,omitempty"`
+	Name     string        `json:"name,omitempty"`
+	Bytes    int           `json:"bytes,omitempty"`
+	Count    int           `json:"count,omitempty"`
+	Duration time.Duration `json:"duration"`
+	// ErrKind classifies a failed call as not-exist, exist, invalid or error.
+	ErrKind string `json:"errKind,omitempty"`
+	Err     string `json:"err,omitempty"`
+
+	// Payloads of writes, present when RecordingRepo.Payloads is set. Data
+	// holds node content, index, file and image bytes, Config the keg config
+	// as YAML.
+	Data   []byte          `json:"data,omitempty"`
+	Meta   []byte          `json:"meta,omitempty"`
+	Stats  json.RawMessage `json:"stats,omitempty"`
+	Config []byte          `json:"config,omitempty"`
+}
+
+// RecordingRepo wraps a Repository and appends a RecordedCall as a JSON line
+// for every call it forwards, so a session can be inspected or repeated
+// against another backend with Replay. Times and durations come from the
+// runtime clock.
+//
+// Besides Repository it forwards files, images and the keg-wide lock. Other
+// optional capabilities of the wrapped backend, such as snapshots and the
+// archive, are not exposed.
+type RecordingRepo struct {
+	// Repo is the wrapped backend.
+	Repo Repository
+	// Payloads records the bytes of each write, which Replay needs to repeat
+	// writes. Without it the log holds sizes only.
+	Payloads bool
+
+	runtime *toolkit.Runtime
+
+	mu  sync.Mutex
+	enc *json.Encoder
+	seq int
+	err error
+}
+
+var _ Repository = (*RecordingRepo)(nil)
+var _ RepositoryFiles = (*RecordingRepo)(nil)
+var _ RepositoryImages = (*RecordingRepo)(nil)
+var _ RepositoryKegLock = (*RecordingRepo)(nil)
+
+// NewRecordingRepo returns a RecordingRepo that forwards to repo and writes
+// its log to w.
+func NewRecordingRepo(repo Repository, w io.Writer, rt *toolkit.Runtime) *RecordingRepo {
+	return &RecordingRepo{Repo: repo, runtime: rt, enc: json.NewEncoder(w)}
+}
+
+// Err returns the first error writing the log. Recording stops after it.
+func (r *RecordingRepo) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// start returns the time a call begins.
+func (r *RecordingRepo) start() time.Time {
+	return r.runtime.Clock().Now()
+}
+
+// record completes call with its timing and outcome and appends it to the log.
+func (r *RecordingRepo) record(call RecordedCall, start time.Time, err error) {
+	call.Time = start
+	call.Duration = r.runtime.Clock().Now().Sub(start)
+	if err != nil {
+		call.ErrKind = errKind(err)
+		call.Err = err.Error()
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return
+	}
+	r.seq++
+	call.Seq = r.seq
+	if encErr := r.enc.Encode(call); encErr != nil {
+		r.err = fmt.Errorf("unable to write recording: %w", encErr)
+	}
+}
+
+// payload returns data when payloads are recorded.
+func (r *RecordingRepo) payload(data []byte) []byte {
+	if !r.Payloads {
+		return nil
+	}
+	return data
+}
+
+func errKind(err error) string {
+	switch {
+	case errors.Is(err, ErrNotExist):
+		return "not-exist"
+	case errors.Is(err, ErrDestinationExists), errors.Is(err, ErrExist):
+		return "exist"
+	case errors.Is(err, ErrInvalid):
+		return "invalid"
+	default:
+		return "error"
+	}
+}
+
+// Name returns the wrapped backend's name.
+func (r *RecordingRepo) Name() string {
+	return r.Repo.Name()
+}
+
+func (r *RecordingRepo) HasNode(ctx context.Context, id NodeId) (bool, error) {
+	start := r.start()
+	ok, err := r.Repo.HasNode(ctx, id)
+	call := RecordedCall{Op: "HasNode", Node: id.Path()}
+	if ok {
+		call.Count = 1
+	}
+	r.record(call, start, err)
+	return ok, err
+}
+
+func (r *RecordingRepo) Next(ctx context.Context) (NodeId, error) {
+	start := r.start()
+	id, err := r.Repo.Next(ctx)
+	call := RecordedCall{Op: "Next"}
+	if err == nil {
+		call.Node = id.Path()
+	}
+	r.record(call, start, err)
+	return id, err
+}
+
+func (r *RecordingRepo) ListNodes(ctx context.Context) ([]NodeId, error) {
+	start := r.start()
+	ids, err := r.Repo.ListNodes(ctx)
+	r.record(RecordedCall{Op: "ListNodes", Count: len(ids)}, start, err)
+	return ids, err
+}
+
+func (r *RecordingRepo) MoveNode(ctx context.Context, id NodeId, dst NodeId) error {
+	start := r.start()
+	err := r.Repo.MoveNode(ctx, id, dst)
+	r.record(RecordedCall{Op: "MoveNode", Node: id.Path(), Dst: dst.Path()}, start, err)
+	return err
+}
+
+func (r *RecordingRepo) DeleteNode(ctx context.Context, id NodeId) error {
+	start := r.start()
+	err := r.Repo.DeleteNode(ctx, id)
+	r.record(RecordedCall{Op: "DeleteNode", Node: id.Path()}, start, err)
+	return err
+}
+
+// WithNodeLock forwards to the wrapped backend and records the lock once fn
+// returns, so calls made under the lock appear before it in the log.
+func (r *RecordingRepo) WithNodeLock(ctx context.Context, id NodeId, fn func(context.Context) error) error {
+	start := r.start()
+	err := r.Repo.WithNodeLock(ctx, id, fn)
+	r.record(RecordedCall{Op: "WithNodeLock", Node: id.Path()}, start, err)
+	return err
+}
+
+func (r *RecordingRepo) ReadContent(ctx context.Context, id NodeId) ([]byte, error) {
+	start := r.start()
+	data, err := r.Repo.ReadContent(ctx, id)
+	r.record(RecordedCall{Op: "ReadContent", Node: id.Path(), Bytes: len(data)}, start, err)
+	return data, err
+}
+
+func (r *RecordingRepo) WriteContent(ctx context.Context, id NodeId, data []byte) error {
+	start := r.start()
+	err := r.Repo.WriteContent(ctx, id, data)
+	r.record(RecordedCall{Op: "WriteContent", Node: id.Path(), Bytes: len(data), Data: r.payload(data)}, start, err)
+	return err
+}
+
+func (r *RecordingRepo) ReadMeta(ctx context.Context, id NodeId) ([]byte, error) {
+	start := r.start()
+	data, err := r.Repo.ReadMeta(ctx, id)
+	r.record(RecordedCall{Op: "ReadMeta", Node: id.Path(), Bytes: len(data)}, start, err)
+	return data, err
+}
+
+func (r *RecordingRepo) WriteMeta(ctx context.Context, id NodeId, data []byte) error {
+	start := r.start()
+	err := r.Repo.WriteMeta(ctx, id, data)
+	r.record(RecordedCall{Op: "WriteMeta", Node: id.Path(), Bytes: len(data), Meta: r.payload(data)}, start, err)
+	return err
+}
+
+func (r *RecordingRepo) ReadStats(ctx context.Context, id NodeId) (*NodeStats, error) {
+	start := r.start()
+	stats, err := r.Repo.ReadStats(ctx, id)
+	r.record(RecordedCall{Op: "ReadStats", Node: id.Path()}, start, err)
+	return stats, err
+}
+
+func (r *RecordingRepo) WriteStats(ctx context.Context, id NodeId, stats *NodeStats) error {
+	start := r.start()
+	err := r.Repo.WriteStats(ctx, id, stats)
+	call := RecordedCall{Op: "WriteStats", Node: id.Path()}
+	call.Stats, call.Bytes = r.statsPayload(stats)
+	r.record(call, start, err)
+	return err
+}
+
+func (r *RecordingRepo) WriteNode(ctx context.Context, id NodeId, content, meta []byte, stats *NodeStats) error {
+	start := r.start()
+	err := r.Repo.WriteNode(ctx, id, content, meta, stats)
+	call := RecordedCall{Op: "WriteNode", Node: id.Path(), Data: r.payload(content), Meta: r.payload(meta)}
+	var statsBytes int
+	call.Stats, statsBytes = r.statsPayload(stats)
+	call.Bytes = len(content) + len(meta) + statsBytes
+	r.record(call, start, err)
+	return err
+}
+
+// statsPayload returns the JSON form of stats when payloads are recorded,
+// and its size.
+func (r *RecordingRepo) statsPayload(stats *NodeStats) (json.RawMessage, int) {
+	if stats == nil {
+		return nil, 0
+	}
+	data, err := stats.ToJSON()
+	if err != nil {
+		return nil, 0
+	}
+	return r.payload(data), len(data)
+}
+
+func (r *RecordingRepo) GetIndex(ctx context.Context, name string) ([]byte, error) {
+	start := r.start()
+	data, err := r.Repo.GetIndex(ctx, name)
+	r.record(RecordedCall{Op: "GetIndex", Name: name, Bytes: len(data)}, start, err)
+	return data, err
+}
+
+func (r *RecordingRepo) WriteIndex(ctx context.Context, name string, data []byte) error {
+	start := r.start()
+	err := r.Repo.WriteIndex(ctx, name, data)
+	r.record(RecordedCall{Op: "WriteIndex", Name: name, Bytes: len(data), Data: r.payload(data)}, start, err)
+	return err
+}
+
+func (r *RecordingRepo) ListIndexes(ctx context.Context) ([]string, error) {
+	start := r.start()
+	names, err := r.Repo.ListIndexes(ctx)
+	r.record(RecordedCall{Op: "ListIndexes", Count: len(names)}, start, err)
+	return names, err
+}
+
+func (r *RecordingRepo) ClearIndexes(ctx context.Context) error {
+	start := r.start()
+	err := r.Repo.ClearIndexes(ctx)
+	r.record(RecordedCall{Op: "ClearIndexes"}, start, err)
+	return err
+}
+
+func (r *RecordingRepo) ReadConfig(ctx context.Context) (*Config, error) {
+	start := r.start()
+	cfg, err := r.Repo.ReadConfig(ctx)
+	r.record(RecordedCall{Op: "ReadConfig"}, start, err)
+	return cfg, err
+}
+
+func (r *RecordingRepo) WriteConfig(ctx context.Context, config *Config) error {
+	start := r.start()
+	err := r.Repo.WriteConfig(ctx, config)
+	call := RecordedCall{Op: "WriteConfig"}
+	if config != nil {
+		if data, yamlErr := config.ToYAML(); yamlErr == nil {
+			call.Bytes = len(data)
+			call.Config = r.payload(data)
+		}
+	}
+	r.record(call, start, err)
+	return err
+}
+
+// WithKegLock forwards to the wrapped backend's keg lock, running fn
+// directly when it has none, and records the lock once fn returns.
+func (r *RecordingRepo) WithKegLock(ctx context.Context, fn func(context.Context) error) error {
+	start := r.start()
+	var err error
+	if locker, ok := repoKegLock(r.Repo); ok {
+		err = locker.WithKegLock(ctx, fn)
+	} else {
+		err = fn(ctx)
+	}
+	r.record(RecordedCall{Op: "WithKegLock"}, start, err)
+	return err
+}
+
+// KegLockOwner returns the wrapped backend's keg lock holder, or nil when it
+// has no keg lock.
+func (r *RecordingRepo) KegLockOwner(ctx context.Context) (*LockOwner, error) {
+	locker, ok := repoKegLock(r.Repo)
+	if !ok {
+		return nil, nil
+	}
+	return locker.KegLockOwner(ctx)
+}
+
+// BreakKegLock forwards to the wrapped backend's keg lock, if any.
+func (r *RecordingRepo) BreakKegLock(ctx context.Context) error {
+	locker, ok := repoKegLock(r.Repo)
+	if !ok {
+		return nil
+	}
+	return locker.BreakKegLock(ctx)
+}
+
+func (r *RecordingRepo) ListFiles(ctx context.Context, id NodeId) ([]string, error) {
+	start := r.start()
+	names, err := repoListFiles(ctx, r.Repo, id)
+	r.record(RecordedCall{Op: "ListFiles", Node: id.Path(), Count: len(names)}, start, err)
+	return names, err
+}
+
+func (r *RecordingRepo) ReadFile(ctx context.Context, id NodeId, name string) ([]byte, error) {
+	start := r.start()
+	var data []byte
+	err := r.files("ReadFile", func(files RepositoryFiles) (err error) {
+		data, err = files.ReadFile(ctx, id, name)
+		return err
+	})
+	r.record(RecordedCall{Op: "ReadFile", Node: id.Path(), Name: name, Bytes: len(data)}, start, err)
+	return data, err
+}
+
+func (r *RecordingRepo) WriteFile(ctx context.Context, id NodeId, name string, data []byte) error {
+	start := r.start()
+	err := r.files("WriteFile", func(files RepositoryFiles) error {
+		return files.WriteFile(ctx, id, name, data)
+	})
+	r.record(RecordedCall{Op: "WriteFile", Node: id.Path(), Name: name, Bytes: len(data), Data: r.payload(data)}, start, err)
+	return err
+}
+
+func (r *RecordingRepo) DeleteFile(ctx context.Context, id NodeId, name string) error {
+	start := r.start()
+	err := r.files("DeleteFile", func(files RepositoryFiles) error {
+		return files.DeleteFile(ctx, id, name)
+	})
+	r.record(RecordedCall{Op: "DeleteFile", Node: id.Path(), Name: name}, start, err)
+	return err
+}
+
+func (r *RecordingRepo) ListImages(ctx context.Context, id NodeId) ([]string, error) {
+	start := r.start()
+	names, err := repoListImages(ctx, r.Repo, id)
+	r.record(RecordedCall{Op: "ListImages", Node: id.Path(), Count: len(names)}, start, err)
+	return names, err
+}
+
+func (r *RecordingRepo) ReadImage(ctx context.Context, id NodeId, name string) ([]byte, error) {
+	start := r.start()
+	var data []byte
+	err := r.images("ReadImage", func(images RepositoryImages) (err error) {
+		data, err = images.ReadImage(ctx, id, name)
+		return err
+	})
+	r.record(RecordedCall{Op: "ReadImage", Node: id.Path(), Name: name, Bytes: len(data)}, start, err)
+	return data, err
+}
+
+func (r *RecordingRepo) WriteImage(ctx context.Context, id NodeId, name string, data []byte) error {
+	start := r.start()
+	err := r.images("WriteImage", func(images RepositoryImages) error {
+		return images.WriteImage(ctx, id, name, data)
+	})
+	r.record(RecordedCall{Op: "WriteImage", Node: id.Path(), Name: name, Bytes: len(data), Data: r.payload(data)}, start, err)
+	return err
+}
+
+func (r *RecordingRepo) DeleteImage(ctx context.Context, id NodeId, name string) error {
+	start := r.start()
+	err := r.images("DeleteImage", func(images RepositoryImages) error {
+		return images.DeleteImage(ctx, id, name)
+	})
+	r.record(RecordedCall{Op: "DeleteImage", Node: id.Path(), Name: name}, start, err)
+	return err
+}
+
+func (r *RecordingRepo) files(op string, fn func(RepositoryFiles) error) error {
+	files, ok := r.Repo.(RepositoryFiles)
+	if !ok {
+		return fmt.Errorf("%s: %s has no files: %w", op, r.Repo.Name(), ErrNotSupported)
+	}
+	return fn(files)
+}
+
+func (r *RecordingRepo) images(op string, fn func(RepositoryImages) error) error {
+	images, ok := r.Repo.(RepositoryImages)
+	if !ok {
+		return fmt.Errorf("%s: %s has no images: %w", op, r.Repo.Name(), ErrNotSupported)
+	}
+	return fn(images)
+}
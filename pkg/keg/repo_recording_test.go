@@ -0,0 +1,126 @@
+package keg_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/jlrickert/tapper/pkg/keg"
+	"github.com/stretchr/testify/require"
+)
+
+// recordSession runs a small keg session against a recorded MemoryRepo and
+// returns the log.
+func recordSession(t *testing.T, payloads bool) (*bytes.Buffer, keg.Repository) {
+	t.Helper()
+	sb := NewSandbox(t)
+	ctx, rt := sb.Context(), sb.Runtime()
+	var log bytes.Buffer
+	repo := keg.NewMemoryRepo(rt)
+	rec := keg.NewRecordingRepo(repo, &log, rt)
+	rec.Payloads = payloads
+
+	k := keg.NewKeg(rec, rt)
+	require.NoError(t, k.Init(ctx))
+	one, err := k.Create(ctx, &keg.CreateOptions{Title: "One", Tags: []string{"go"}})
+	require.NoError(t, err)
+	two, err := k.Create(ctx, &keg.CreateOptions{Title: "Two", Body: []byte("# Two\n\nSee ../1\n")})
+	require.NoError(t, err)
+	require.NoError(t, k.SetContent(ctx, one, []byte("# One\n\nUpdated.\n")))
+	require.NoError(t, rec.WriteImage(ctx, two, "diagram.png", []byte("png")))
+	_, err = k.GetContent(ctx, keg.NodeId{ID: 7})
+	require.ErrorIs(t, err, keg.ErrNotExist)
+	require.NoError(t, k.Index(ctx, keg.IndexOptions{Rebuild: true}))
+	require.NoError(t, rec.Err())
+	return &log, repo
+}
+
+func TestRecordingRepo_LogsCalls(t *testing.T) {
+	t.Parallel()
+	log, _ := recordSession(t, false)
+
+	var calls []keg.RecordedCall
+	for line := range strings.Lines(log.String()) {
+		var call keg.RecordedCall
+		require.NoError(t, json.Unmarshal([]byte(line), &call))
+		calls = append(calls, call)
+	}
+	require.NotEmpty(t, calls)
+	for i, call := range calls {
+		require.Equal(t, i+1, call.Seq)
+		require.Nil(t, call.Data, "payloads are off")
+	}
+
+	find := func(op, node string) keg.RecordedCall {
+		for _, call := range slices.Backward(calls) {
+			if call.Op == op && call.Node == node {
+				return call
+			}
+		}
+		t.Fatalf("no %s %s call recorded", op, node)
+		return keg.RecordedCall{}
+	}
+	require.Positive(t, find("WriteNode", "1").Bytes)
+	require.Equal(t, "not-exist", find("ReadContent", "7").ErrKind)
+	require.Equal(t, 3, find("WriteImage", "2").Bytes)
+	require.Equal(t, "1", find("Next", "1").Node)
+}
+
+func TestReplay_RepeatsSessionOnOtherBackends(t *testing.T) {
+	t.Parallel()
+	log, recorded := recordSession(t, true)
+	ctx := context.Background()
+
+	for name, newRepo := range map[string]func(*testing.T) keg.Repository{
+		"memory":     newMemoryRepo,
+		"filesystem": newFsRepo,
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			repo := newRepo(t)
+			mismatches, err := keg.Replay(ctx, bytes.NewReader(log.Bytes()), repo)
+			require.NoError(t, err)
+			require.Empty(t, mismatches)
+
+			for _, id := range []keg.NodeId{{ID: 0}, {ID: 1}, {ID: 2}} {
+				want, err := recorded.ReadContent(ctx, id)
+				require.NoError(t, err)
+				got, err := repo.ReadContent(ctx, id)
+				require.NoError(t, err)
+				require.Equal(t, string(want), string(got))
+			}
+			want, _ := recorded.GetIndex(ctx, "nodes.tsv")
+			got, err := repo.GetIndex(ctx, "nodes.tsv")
+			require.NoError(t, err)
+			require.Equal(t, string(want), string(got))
+		})
+	}
+}
+
+func TestReplay_ReportsMismatches(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	log, _ := recordSession(t, false)
+	mismatches, err := keg.Replay(ctx, bytes.NewReader(log.Bytes()), newMemoryRepo(t))
+	require.NoError(t, err)
+	require.NotEmpty(t, mismatches)
+	require.Equal(t, "payload not recorded", mismatches[0].Reason)
+
+	log, _ = recordSession(t, true)
+	repo := newMemoryRepo(t)
+	require.NoError(t, repo.WriteContent(ctx, keg.NodeId{ID: 7}, []byte("# Seven\n")))
+	mismatches, err = keg.Replay(ctx, bytes.NewReader(log.Bytes()), repo)
+	require.NoError(t, err)
+	var reasons []string
+	for _, m := range mismatches {
+		reasons = append(reasons, m.String())
+	}
+	require.Contains(t, strings.Join(reasons, "\n"), "ReadContent 7: error none, recorded not-exist")
+
+	_, err = keg.Replay(ctx, strings.NewReader("{not json\n"), repo)
+	require.ErrorIs(t, err, keg.ErrParse)
+}
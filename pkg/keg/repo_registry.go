@@ -0,0 +1,568 @@
+package keg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/jlrickert/cli-toolkit/toolkit"
+	"github.com/jlrickert/tapper/pkg/registry"
+)
+
+// RegistryRepo is a Repository backed by a keg registry. Node content, meta
+// and stats, index files and the keg config live on the server and are read
+// and written through a registry.Client.
+//
+// Node locks are held in process only; the registry itself applies writes
+// last-writer-wins. Files, images, snapshots and archives are not
+// supported.
+//
+// With a Cache the repository keeps working while the registry is
+// unreachable: reads are served from the cache and node, index and config
+// writes are queued until Sync replays them. Allocating node ids and moving
+// nodes still need the registry.
+type RegistryRepo struct {
+	Client *registry.Client
+
+	// User and Keg address the keg on the registry.
+	User string
+	Keg  string
+
+	// Cache, when set, keeps a local copy for offline use.
+	Cache *RegistryCache
+
+	mu        sync.Mutex
+	nodeLocks map[NodeId]*sync.Mutex
+
+	runtime *toolkit.Runtime
+}
+
+// NewRegistryRepo returns a repository for keg user/keg served by client.
+func NewRegistryRepo(client *registry.Client, user, keg string, rt *toolkit.Runtime) *RegistryRepo {
+	return &RegistryRepo{
+		Client:    client,
+		User:      user,
+		Keg:       keg,
+		nodeLocks: make(map[NodeId]*sync.Mutex),
+		runtime:   rt,
+	}
+}
+
+func (r *RegistryRepo) Name() string {
+	return "registry"
+}
+
+func (r *RegistryRepo) HasNode(ctx context.Context, id NodeId) (bool, error) {
+	_, err := r.readNode(ctx, "HasNode", id)
+	if err != nil {
+		if errors.Is(err, ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// Next asks the registry to reserve the next node id.
+func (r *RegistryRepo) Next(ctx context.Context) (NodeId, error) {
+	raw, err := r.Client.NextNode(ctx, r.User, r.Keg)
+	if err != nil {
+		return NodeId{}, registryBackendError("Next", err)
+	}
+	id, err := ParseNode(raw)
+	if err != nil {
+		return NodeId{}, fmt.Errorf("registry returned invalid node id %q: %w", raw, err)
+	}
+	return *id, nil
+}
+
+// ListNodes returns the node ids of the keg in ascending order. Ids the
+// registry reports that do not parse are skipped.
+func (r *RegistryRepo) ListNodes(ctx context.Context) ([]NodeId, error) {
+	raw, err := r.Client.ListNodes(ctx, r.User, r.Keg)
+	if err != nil {
+		if r.offline(err) {
+			if ids, cerr := r.Cache.Repo.ListNodes(ctx); cerr == nil {
+				return ids, nil
+			}
+		}
+		return nil, registryBackendError("ListNodes", err)
+	}
+	ids := make([]NodeId, 0, len(raw))
+	for _, s := range raw {
+		id, err := ParseNode(s)
+		if err != nil {
+			continue
+		}
+		ids = append(ids, *id)
+	}
+	slices.SortFunc(ids, func(a, b NodeId) int {
+		if a.ID != b.ID {
+			return a.ID - b.ID
+		}
+		return strings.Compare(a.Code, b.Code)
+	})
+	if r.Cache != nil {
+		r.Cache.prune(ctx, ids)
+		ids = slices.DeleteFunc(ids, func(id NodeId) bool {
+			return r.Cache.queued(id.Path(), PendingDelete)
+		})
+	}
+	return ids, nil
+}
+
+func (r *RegistryRepo) MoveNode(ctx context.Context, id NodeId, dst NodeId) error {
+	if r.Cache != nil && r.Cache.queued(id.Path(), "") {
+		return fmt.Errorf("node %s has unsynced changes; run tap sync first: %w", id.Path(), ErrConflict)
+	}
+	err := r.Client.MoveNode(ctx, r.User, r.Keg, registryNodeID(id), registryNodeID(dst))
+	switch {
+	case err == nil:
+		if r.Cache != nil {
+			_ = r.Cache.forget(ctx, id)
+		}
+		return nil
+	case errors.Is(err, registry.ErrNotFound):
+		return NewNodeNotFoundError(id)
+	case errors.Is(err, registry.ErrConflict):
+		return NewDestinationExistsError(dst)
+	}
+	return registryBackendError("MoveNode", err)
+}
+
+func (r *RegistryRepo) DeleteNode(ctx context.Context, id NodeId) error {
+	if r.Cache != nil && r.Cache.queued(id.Path(), "") {
+		return r.deleteOffline(ctx, id, nil)
+	}
+	err := r.Client.DeleteNode(ctx, r.User, r.Keg, registryNodeID(id))
+	switch {
+	case err == nil:
+		if r.Cache != nil {
+			return r.Cache.forget(ctx, id)
+		}
+		return nil
+	case r.offline(err):
+		return r.deleteOffline(ctx, id, err)
+	}
+	return registryNodeError("DeleteNode", id, err)
+}
+
+// deleteOffline removes a node from the cache and queues the delete. cause
+// is the registry error reported when the node is not cached.
+func (r *RegistryRepo) deleteOffline(ctx context.Context, id NodeId, cause error) error {
+	has, err := r.Cache.Repo.HasNode(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !has {
+		if cause != nil {
+			return registryBackendError("DeleteNode", cause)
+		}
+		return NewNodeNotFoundError(id)
+	}
+	if err := r.Cache.Repo.DeleteNode(ctx, id); err != nil {
+		return err
+	}
+	return r.Cache.enqueue(PendingWrite{Op: PendingDelete, Node: id.Path()})
+}
+
+// WithNodeLock executes fn while holding an in-process lock for node id.
+func (r *RegistryRepo) WithNodeLock(ctx context.Context, id NodeId, fn func(context.Context) error) error {
+	if fn == nil {
+		return fmt.Errorf("fn required")
+	}
+	if contextHasNodeLock(ctx, id) {
+		return fn(ctx)
+	}
+	r.mu.Lock()
+	lock, ok := r.nodeLocks[id]
+	if !ok {
+		lock = &sync.Mutex{}
+		r.nodeLocks[id] = lock
+	}
+	r.mu.Unlock()
+
+	lock.Lock()
+	defer lock.Unlock()
+	return fn(contextWithNodeLock(ctx, id))
+}
+
+// ReadContent returns the node content. A node without content returns
+// (nil, nil).
+func (r *RegistryRepo) ReadContent(ctx context.Context, id NodeId) ([]byte, error) {
+	n, err := r.readNode(ctx, "ReadContent", id)
+	if err != nil {
+		return nil, err
+	}
+	return n.Content, nil
+}
+
+// ReadMeta returns the node meta. A node without meta returns (nil, nil).
+func (r *RegistryRepo) ReadMeta(ctx context.Context, id NodeId) ([]byte, error) {
+	n, err := r.readInfo(ctx, "ReadMeta", id)
+	if err != nil {
+		return nil, err
+	}
+	return n.Meta, nil
+}
+
+func (r *RegistryRepo) ReadStats(ctx context.Context, id NodeId) (*NodeStats, error) {
+	n, err := r.readInfo(ctx, "ReadStats", id)
+	if err != nil {
+		return nil, err
+	}
+	if len(n.Stats) == 0 {
+		return nil, NewNotFoundError("stats", id.Path())
+	}
+	return ParseStats(ctx, n.Stats)
+}
+
+// ReadContentRange implements RepositoryContentRange. Cached nodes are cut
+// from the cached content; other nodes ask the registry for the range
+// alone.
+func (r *RegistryRepo) ReadContentRange(ctx context.Context, id NodeId, offset, length int64) ([]byte, error) {
+	if !r.cached(ctx, id) {
+		data, err := r.Client.ReadContentRange(ctx, r.User, r.Keg, registryNodeID(id), offset, length)
+		if err == nil {
+			return data, nil
+		}
+		if !errors.Is(err, registry.ErrNotFound) {
+			return nil, registryNodeError("ReadContentRange", id, err)
+		}
+		// Registries without the content endpoint answer 404 for every
+		// node; the full read below tells the two apart.
+	}
+	n, err := r.readNode(ctx, "ReadContentRange", id)
+	if err != nil {
+		return nil, err
+	}
+	return contentRange(n.Content, offset, length), nil
+}
+
+func (r *RegistryRepo) WriteContent(ctx context.Context, id NodeId, data []byte) error {
+	return r.WriteNode(ctx, id, data, nil, nil)
+}
+
+func (r *RegistryRepo) WriteMeta(ctx context.Context, id NodeId, data []byte) error {
+	return r.WriteNode(ctx, id, nil, data, nil)
+}
+
+func (r *RegistryRepo) WriteStats(ctx context.Context, id NodeId, stats *NodeStats) error {
+	if stats == nil {
+		stats = &NodeStats{}
+	}
+	return r.WriteNode(ctx, id, nil, nil, stats)
+}
+
+// WriteNode implements Repository. The registry applies content, meta and
+// stats in one request; nil values are left unchanged.
+func (r *RegistryRepo) WriteNode(ctx context.Context, id NodeId, content, meta []byte, stats *NodeStats) error {
+	update := registry.NodeUpdate{Content: content, Meta: meta}
+	if stats != nil {
+		data, err := stats.ToJSON()
+		if err != nil {
+			return err
+		}
+		update.Stats = data
+	}
+	if r.Cache != nil && r.Cache.queued(id.Path(), "") {
+		return r.writeOffline(ctx, id, content, meta, stats, nil)
+	}
+	err := r.Client.WriteNode(ctx, r.User, r.Keg, registryNodeID(id), update)
+	switch {
+	case err == nil:
+		if r.Cache != nil {
+			r.Cache.storeWrite(ctx, id, content, meta, stats)
+		}
+		return nil
+	case r.offline(err):
+		return r.writeOffline(ctx, id, content, meta, stats, err)
+	}
+	return registryBackendError("WriteNode", err)
+}
+
+// writeOffline applies a node write to the cache and queues it. A partial
+// write to a node that is not cached cannot be queued and fails with cause.
+func (r *RegistryRepo) writeOffline(ctx context.Context, id NodeId, content, meta []byte, stats *NodeStats, cause error) error {
+	has, err := r.Cache.Repo.HasNode(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !has && content == nil {
+		if cause == nil {
+			return NewNodeNotFoundError(id)
+		}
+		return registryBackendError("WriteNode", cause)
+	}
+	if has && !r.Cache.queued(id.Path(), PendingNode) {
+		// Keep the registry copy the first offline edit starts from so a
+		// conflict can be merged three ways.
+		if err := r.Cache.saveBase(ctx, id); err != nil {
+			return err
+		}
+	}
+	if err := r.Cache.Repo.WriteNode(ctx, id, content, meta, stats); err != nil {
+		return err
+	}
+	return r.Cache.enqueue(PendingWrite{Op: PendingNode, Node: id.Path()})
+}
+
+func (r *RegistryRepo) GetIndex(ctx context.Context, name string) ([]byte, error) {
+	if r.Cache != nil && r.Cache.queuedIndex(name) {
+		return r.Cache.Repo.GetIndex(ctx, name)
+	}
+	var v registry.Validator
+	if r.Cache != nil {
+		v = r.Cache.validator("index", name)
+	}
+	data, v, err := r.Client.ReadIndexIf(ctx, r.User, r.Keg, name, v)
+	switch {
+	case errors.Is(err, registry.ErrNotModified):
+		if cached, cerr := r.Cache.Repo.GetIndex(ctx, name); cerr == nil {
+			return cached, nil
+		}
+		_ = r.Cache.setValidator("index", name, registry.Validator{})
+		data, err = r.Client.ReadIndex(ctx, r.User, r.Keg, name)
+		if err != nil {
+			return nil, registryBackendError("GetIndex", err)
+		}
+		_ = r.Cache.Repo.WriteIndex(ctx, name, data)
+		return data, nil
+	case err == nil:
+		if r.Cache != nil && r.Cache.Repo.WriteIndex(ctx, name, data) == nil {
+			_ = r.Cache.setValidator("index", name, v)
+		}
+		return data, nil
+	case errors.Is(err, registry.ErrNotFound):
+		return nil, NewNotFoundError("index", name)
+	case r.offline(err):
+		if data, cerr := r.Cache.Repo.GetIndex(ctx, name); cerr == nil {
+			return data, nil
+		}
+	}
+	return nil, registryBackendError("GetIndex", err)
+}
+
+func (r *RegistryRepo) WriteIndex(ctx context.Context, name string, data []byte) error {
+	err := r.Client.WriteIndex(ctx, r.User, r.Keg, name, data)
+	switch {
+	case err == nil:
+		if r.Cache != nil {
+			_ = r.Cache.Repo.WriteIndex(ctx, name, data)
+			_ = r.Cache.setValidator("index", name, registry.Validator{})
+		}
+		return nil
+	case r.offline(err):
+		if err := r.Cache.Repo.WriteIndex(ctx, name, data); err != nil {
+			return err
+		}
+		return r.Cache.enqueue(PendingWrite{Op: PendingIndex, Index: name})
+	}
+	return registryBackendError("WriteIndex", err)
+}
+
+func (r *RegistryRepo) ListIndexes(ctx context.Context) ([]string, error) {
+	names, err := r.Client.ListIndexes(ctx, r.User, r.Keg)
+	if err != nil {
+		if r.offline(err) {
+			return r.Cache.Repo.ListIndexes(ctx)
+		}
+		return nil, registryBackendError("ListIndexes", err)
+	}
+	slices.Sort(names)
+	return names, nil
+}
+
+func (r *RegistryRepo) ClearIndexes(ctx context.Context) error {
+	err := r.Client.ClearIndexes(ctx, r.User, r.Keg)
+	switch {
+	case err == nil:
+		if r.Cache != nil {
+			_ = r.Cache.Repo.ClearIndexes(ctx)
+		}
+		return nil
+	case r.offline(err):
+		if err := r.Cache.Repo.ClearIndexes(ctx); err != nil {
+			return err
+		}
+		return r.Cache.enqueue(PendingWrite{Op: PendingClearIndexes})
+	}
+	return registryBackendError("ClearIndexes", err)
+}
+
+func (r *RegistryRepo) ReadConfig(ctx context.Context) (*Config, error) {
+	if r.Cache != nil && r.Cache.queued("", PendingConfig) {
+		return r.Cache.Repo.ReadConfig(ctx)
+	}
+	data, err := r.Client.ReadConfig(ctx, r.User, r.Keg)
+	switch {
+	case err == nil:
+		cfg, err := ParseKegConfig(data)
+		if err == nil && r.Cache != nil {
+			_ = r.Cache.Repo.WriteConfig(ctx, cfg)
+		}
+		return cfg, err
+	case errors.Is(err, registry.ErrNotFound):
+		return nil, NewNotFoundError("keg config", "")
+	case r.offline(err):
+		if cfg, cerr := r.Cache.Repo.ReadConfig(ctx); cerr == nil {
+			return cfg, nil
+		}
+	}
+	return nil, registryBackendError("ReadConfig", err)
+}
+
+func (r *RegistryRepo) WriteConfig(ctx context.Context, config *Config) error {
+	data, err := config.ToYAML()
+	if err != nil {
+		return err
+	}
+	err = r.Client.WriteConfig(ctx, r.User, r.Keg, data)
+	switch {
+	case err == nil:
+		if r.Cache != nil {
+			_ = r.Cache.Repo.WriteConfig(ctx, config)
+		}
+		return nil
+	case r.offline(err):
+		if err := r.Cache.Repo.WriteConfig(ctx, config); err != nil {
+			return err
+		}
+		return r.Cache.enqueue(PendingWrite{Op: PendingConfig})
+	}
+	return registryBackendError("WriteConfig", err)
+}
+
+// Search runs query on the registry and returns the matching node ids.
+func (r *RegistryRepo) Search(ctx context.Context, query string) ([]registry.SearchHit, error) {
+	hits, err := r.Client.Search(ctx, r.User, r.Keg, query)
+	if err != nil {
+		return nil, registryBackendError("Search", err)
+	}
+	return hits, nil
+}
+
+// readNode reads a node from the registry and refreshes the cache. Nodes
+// with queued changes, and every node while the registry is unreachable,
+// are read from the cache.
+func (r *RegistryRepo) readNode(ctx context.Context, op string, id NodeId) (*registry.Node, error) {
+	if r.Cache != nil && r.Cache.queued(id.Path(), "") {
+		n, err := r.Cache.node(ctx, id)
+		if errors.Is(err, ErrNotExist) {
+			return nil, NewNodeNotFoundError(id)
+		}
+		return n, err
+	}
+	if r.Cache == nil {
+		n, err := r.Client.ReadNode(ctx, r.User, r.Keg, registryNodeID(id))
+		if err != nil {
+			return nil, registryNodeError(op, id, err)
+		}
+		return n, nil
+	}
+
+	var v registry.Validator
+	if r.cached(ctx, id) {
+		v = r.Cache.validator("node", id.Path())
+	}
+	n, v, err := r.Client.ReadNodeIf(ctx, r.User, r.Keg, registryNodeID(id), v)
+	switch {
+	case errors.Is(err, registry.ErrNotModified):
+		if cached, cerr := r.Cache.node(ctx, id); cerr == nil {
+			return cached, nil
+		}
+		_ = r.Cache.setValidator("node", id.Path(), registry.Validator{})
+		n, err = r.Client.ReadNode(ctx, r.User, r.Keg, registryNodeID(id))
+		if err != nil {
+			return nil, registryNodeError(op, id, err)
+		}
+		_ = r.Cache.storeNode(ctx, id, n)
+		return n, nil
+	case err == nil:
+		if r.Cache.storeNode(ctx, id, n) == nil {
+			_ = r.Cache.setValidator("node", id.Path(), v)
+		}
+		return n, nil
+	case r.offline(err):
+		if cached, cerr := r.Cache.node(ctx, id); cerr == nil {
+			return cached, nil
+		}
+	}
+	return nil, registryNodeError(op, id, err)
+}
+
+// readInfo reads the meta and stats of a node without its content. Cached
+// nodes go through readNode, whose conditional request is cheaper still.
+func (r *RegistryRepo) readInfo(ctx context.Context, op string, id NodeId) (*registry.Node, error) {
+	if r.cached(ctx, id) {
+		return r.readNode(ctx, op, id)
+	}
+	n, err := r.Client.ReadNodeInfo(ctx, r.User, r.Keg, registryNodeID(id))
+	if err != nil {
+		return nil, registryNodeError(op, id, err)
+	}
+	return n, nil
+}
+
+// cached reports whether the cache holds a copy of node id or changes
+// queued for it.
+func (r *RegistryRepo) cached(ctx context.Context, id NodeId) bool {
+	if r.Cache == nil {
+		return false
+	}
+	if r.Cache.queued(id.Path(), "") {
+		return true
+	}
+	has, _ := r.Cache.Repo.HasNode(ctx, id)
+	return has
+}
+
+// offline reports whether the cache should stand in for the registry after
+// err.
+func (r *RegistryRepo) offline(err error) bool {
+	return r.Cache != nil && registry.IsTransient(err)
+}
+
+// registryNodeID spells id the way the registry expects, without an alias.
+func registryNodeID(id NodeId) string {
+	return NodeId{ID: id.ID, Code: id.Code}.Path()
+}
+
+// registryNodeError maps a missing node to *NodeNotFoundError and anything
+// else to a *BackendError.
+func registryNodeError(op string, id NodeId, err error) error {
+	if errors.Is(err, registry.ErrNotFound) {
+		return NewNodeNotFoundError(id)
+	}
+	return registryBackendError(op, err)
+}
+
+// registryBackendError wraps err in a *BackendError that also matches
+// ErrPermission or ErrConflict where the status calls for it. Rate limiting
+// surfaces as a *RateLimitError cause; Retryable reports whether the client
+// gave up on a failure that may pass later.
+func registryBackendError(op string, err error) error {
+	status := 0
+	var re *registry.Error
+	if errors.As(err, &re) {
+		status = re.StatusCode
+	}
+	cause := err
+	switch {
+	case errors.Is(err, registry.ErrUnauthorized):
+		cause = fmt.Errorf("%w: %w", ErrPermission, err)
+	case errors.Is(err, registry.ErrConflict):
+		cause = fmt.Errorf("%w: %w", ErrConflict, err)
+	case status == http.StatusTooManyRequests:
+		cause = NewRateLimitError(re.RetryAfter, re.Message, err)
+	}
+	return NewBackendError("registry", op, status, cause, registry.IsTransient(err))
+}
+
+// Ensure RegistryRepo implements Repository at compile time.
+var _ Repository = (*RegistryRepo)(nil)
+var _ RepositoryContentRange = (*RegistryRepo)(nil)
@@ -0,0 +1,508 @@
+package keg
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/jlrickert/cli-toolkit/toolkit"
+	"github.com/jlrickert/tapper/pkg/registry"
+)
+
+// Pending write operations recorded by RegistryCache.
+const (
+	PendingNode         = "node"
+	PendingDelete       = "delete"
+	PendingIndex        = "index"
+	PendingClearIndexes = "clearIndexes"
+	PendingConfig       = "config"
+)
+
+// PendingWrite is a change made while the registry was unreachable. The
+// data itself lives in the cache; the queue only records what to push.
+type PendingWrite struct {
+	Op    string `json:"op"`
+	Node  string `json:"node,omitempty"`
+	Index string `json:"index,omitempty"`
+
+	// Base is the digest of the registry node the change was made on top of,
+	// empty when the node was never seen on the registry.
+	Base string `json:"base,omitempty"`
+
+	Queued time.Time `json:"queued"`
+}
+
+// SyncConflict is a queued node change the registry has moved past.
+type SyncConflict struct {
+	Node   string
+	Reason string
+}
+
+// SyncResult summarizes RegistryRepo.Sync.
+type SyncResult struct {
+	// Pushed counts the queued writes applied to the registry.
+	Pushed int
+
+	// Conflicts lists node changes left queued because the registry copy
+	// changed since it was cached.
+	Conflicts []SyncConflict
+
+	// Pending is the number of writes still queued.
+	Pending int
+
+	// Merged lists the conflicting nodes resolved with a merge tool before
+	// the push. RegistryRepo.Sync leaves it empty; callers that merge, such
+	// as tap sync --merge, fill it in.
+	Merged []string
+}
+
+// RegistryCache is a local copy of a registry keg. Every successful read
+// refreshes it, reads fall back to it when the registry is unreachable, and
+// writes made offline are applied to it and queued until Sync replays them.
+type RegistryCache struct {
+	// Repo holds the cached nodes, indexes and config.
+	Repo *FsRepo
+
+	// Root is the cache directory. Repo lives in Root/keg next to the write
+	// queue and the digests of the registry nodes.
+	Root string
+
+	mu      sync.Mutex
+	runtime *toolkit.Runtime
+}
+
+type registryCacheState struct {
+	Queue   []PendingWrite    `json:"queue,omitempty"`
+	Digests map[string]string `json:"digests,omitempty"`
+
+	// Validators holds the ETag and Last-Modified of cached nodes and
+	// indexes, keyed by validatorKey, for conditional reads.
+	Validators map[string]registry.Validator `json:"validators,omitempty"`
+}
+
+// validatorKey names a cached resource in registryCacheState.Validators.
+func validatorKey(kind, name string) string {
+	return kind + "/" + name
+}
+
+// NewRegistryCache returns a cache stored under root.
+func NewRegistryCache(root string, rt *toolkit.Runtime) *RegistryCache {
+	return &RegistryCache{
+		Repo:    NewFsRepo(filepath.Join(root, "keg"), rt),
+		Root:    root,
+		runtime: rt,
+	}
+}
+
+// Pending returns the queued writes in the order they were made.
+func (c *RegistryCache) Pending() ([]PendingWrite, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	state, err := c.load()
+	if err != nil {
+		return nil, err
+	}
+	return state.Queue, nil
+}
+
+func (c *RegistryCache) statePath() string {
+	return filepath.Join(c.Root, "state.json")
+}
+
+func (c *RegistryCache) load() (*registryCacheState, error) {
+	state := &registryCacheState{}
+	data, err := c.runtime.ReadFile(c.statePath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return state, nil
+		}
+		return nil, fmt.Errorf("unable to read registry cache state: %w", err)
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("unable to parse registry cache state: %w", err)
+	}
+	return state, nil
+}
+
+func (c *RegistryCache) save(state *registryCacheState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := c.runtime.Mkdir(c.Root, 0o755, true); err != nil {
+		return fmt.Errorf("unable to create registry cache: %w", err)
+	}
+	if err := c.runtime.AtomicWriteFile(c.statePath(), data, 0o644); err != nil {
+		return fmt.Errorf("unable to write registry cache state: %w", err)
+	}
+	return nil
+}
+
+// update loads the state, applies fn and saves it.
+func (c *RegistryCache) update(fn func(*registryCacheState)) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	state, err := c.load()
+	if err != nil {
+		return err
+	}
+	fn(state)
+	return c.save(state)
+}
+
+// queued reports whether a write is queued for node, or for op when node is
+// empty. An empty op matches any node change.
+func (c *RegistryCache) queued(node, op string) bool {
+	return c.has(func(p PendingWrite) bool {
+		if node != "" {
+			return p.Node == node && (op == "" || p.Op == op)
+		}
+		return p.Op == op
+	})
+}
+
+// queuedIndex reports whether index name is only up to date in the cache.
+func (c *RegistryCache) queuedIndex(name string) bool {
+	return c.has(func(p PendingWrite) bool {
+		return p.Op == PendingClearIndexes || (p.Op == PendingIndex && p.Index == name)
+	})
+}
+
+func (c *RegistryCache) has(match func(PendingWrite) bool) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	state, err := c.load()
+	if err != nil {
+		return false
+	}
+	return slices.ContainsFunc(state.Queue, match)
+}
+
+// enqueue records a write. A node change replaces an earlier queued change
+// of the same node but keeps its base, so a conflict is judged against the
+// registry copy the first offline edit started from.
+func (c *RegistryCache) enqueue(p PendingWrite) error {
+	p.Queued = c.runtime.Clock().Now()
+	return c.update(func(state *registryCacheState) {
+		for i, q := range state.Queue {
+			same := q.Op == p.Op && q.Index == p.Index && q.Node == p.Node
+			if p.Node != "" {
+				same = q.Node == p.Node
+			}
+			if !same {
+				continue
+			}
+			if p.Node != "" {
+				p.Base = q.Base
+			}
+			state.Queue = slices.Delete(state.Queue, i, i+1)
+			break
+		}
+		if p.Node != "" && p.Base == "" {
+			p.Base = state.Digests[p.Node]
+		}
+		state.Queue = append(state.Queue, p)
+	})
+}
+
+// storeNode caches a node read from or written to the registry. A node
+// whose content and meta are already cached is left alone.
+func (c *RegistryCache) storeNode(ctx context.Context, id NodeId, n *registry.Node) error {
+	digest := nodeDigest(n.Content, n.Meta)
+	if c.digest(id) == digest {
+		if has, _ := c.Repo.HasNode(ctx, id); has {
+			return nil
+		}
+	}
+	var stats *NodeStats
+	if len(n.Stats) > 0 {
+		parsed, err := ParseStats(ctx, n.Stats)
+		if err == nil {
+			stats = parsed
+		}
+	}
+	content, meta := n.Content, n.Meta
+	if content == nil {
+		content = []byte{}
+	}
+	if meta == nil {
+		meta = []byte{}
+	}
+	if err := c.Repo.WriteNode(ctx, id, content, meta, stats); err != nil {
+		return err
+	}
+	return c.setDigest(id, digest)
+}
+
+// storeWrite applies a write the registry accepted to the cached copy of a
+// node. Nodes not cached yet are left to the next read, since a partial
+// write does not make a whole node.
+func (c *RegistryCache) storeWrite(ctx context.Context, id NodeId, content, meta []byte, stats *NodeStats) {
+	if has, _ := c.Repo.HasNode(ctx, id); !has {
+		return
+	}
+	if err := c.Repo.WriteNode(ctx, id, content, meta, stats); err != nil {
+		_ = c.forget(ctx, id)
+		return
+	}
+	n, err := c.node(ctx, id)
+	if err != nil {
+		return
+	}
+	_ = c.setDigest(id, nodeDigest(n.Content, n.Meta))
+	_ = c.setValidator("node", id.Path(), registry.Validator{})
+}
+
+func (c *RegistryCache) digest(id NodeId) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	state, err := c.load()
+	if err != nil {
+		return ""
+	}
+	return state.Digests[id.Path()]
+}
+
+// validator returns the validator of a cached resource, or the zero
+// Validator when there is none.
+func (c *RegistryCache) validator(kind, name string) registry.Validator {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	state, err := c.load()
+	if err != nil {
+		return registry.Validator{}
+	}
+	return state.Validators[validatorKey(kind, name)]
+}
+
+// setValidator records v for a cached resource; the zero Validator drops
+// it.
+func (c *RegistryCache) setValidator(kind, name string, v registry.Validator) error {
+	key := validatorKey(kind, name)
+	return c.update(func(state *registryCacheState) {
+		if v.IsZero() {
+			delete(state.Validators, key)
+			return
+		}
+		if state.Validators == nil {
+			state.Validators = map[string]registry.Validator{}
+		}
+		state.Validators[key] = v
+	})
+}
+
+func (c *RegistryCache) setDigest(id NodeId, digest string) error {
+	return c.update(func(state *registryCacheState) {
+		if state.Digests == nil {
+			state.Digests = map[string]string{}
+		}
+		state.Digests[id.Path()] = digest
+	})
+}
+
+// node returns the cached copy of a node.
+func (c *RegistryCache) node(ctx context.Context, id NodeId) (*registry.Node, error) {
+	content, err := c.Repo.ReadContent(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	meta, err := c.Repo.ReadMeta(ctx, id)
+	if err != nil && !errors.Is(err, ErrNotExist) {
+		return nil, err
+	}
+	n := &registry.Node{ID: id.Path(), Content: content, Meta: meta}
+	if stats, err := c.Repo.ReadStats(ctx, id); err == nil {
+		if n.Stats, err = stats.ToJSON(); err != nil {
+			return nil, err
+		}
+	}
+	return n, nil
+}
+
+// forget drops a node from the cache and its digest.
+func (c *RegistryCache) forget(ctx context.Context, id NodeId) error {
+	if err := c.Repo.DeleteNode(ctx, id); err != nil && !errors.Is(err, ErrNotExist) {
+		return err
+	}
+	c.dropBase(id)
+	return c.update(func(state *registryCacheState) {
+		delete(state.Digests, id.Path())
+		delete(state.Validators, validatorKey("node", id.Path()))
+	})
+}
+
+// prune removes cached nodes the registry no longer lists, keeping nodes
+// with queued changes.
+func (c *RegistryCache) prune(ctx context.Context, remote []NodeId) {
+	cached, err := c.Repo.ListNodes(ctx)
+	if err != nil {
+		return
+	}
+	for _, id := range cached {
+		if slices.Contains(remote, id) || c.queued(id.Path(), "") {
+			continue
+		}
+		_ = c.forget(ctx, id)
+	}
+}
+
+// nodeDigest identifies a registry node version by its content and meta.
+// Stats are left out since reading a node updates them.
+func nodeDigest(content, meta []byte) string {
+	h := sha256.New()
+	h.Write(content)
+	h.Write([]byte{0})
+	h.Write(meta)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Sync replays the writes queued while the registry was unreachable. A
+// queued node change whose registry copy changed since it was cached is
+// reported as a conflict and stays queued, unless force is set, in which
+// case the local copy wins. Sync stops at the first failure that leaves the
+// registry unreachable.
+func (r *RegistryRepo) Sync(ctx context.Context, force bool) (*SyncResult, error) {
+	if r.Cache == nil {
+		return nil, fmt.Errorf("registry keg has no local cache: %w", ErrNotSupported)
+	}
+	queue, err := r.Cache.Pending()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &SyncResult{}
+	var remaining []PendingWrite
+	for i, p := range queue {
+		conflict, err := r.replay(ctx, p, force)
+		if err != nil {
+			remaining = append(remaining, queue[i:]...)
+			if serr := r.Cache.update(func(state *registryCacheState) { state.Queue = remaining }); serr != nil {
+				return nil, serr
+			}
+			result.Pending = len(remaining)
+			return result, fmt.Errorf("unable to sync %s: %w", pendingLabel(p), err)
+		}
+		if conflict != "" {
+			result.Conflicts = append(result.Conflicts, SyncConflict{Node: p.Node, Reason: conflict})
+			remaining = append(remaining, p)
+			continue
+		}
+		if p.Node != "" {
+			if id, err := ParseNode(p.Node); err == nil {
+				r.Cache.dropBase(*id)
+			}
+		}
+		result.Pushed++
+	}
+	if err := r.Cache.update(func(state *registryCacheState) { state.Queue = remaining }); err != nil {
+		return nil, err
+	}
+	result.Pending = len(remaining)
+	return result, nil
+}
+
+// replay pushes one queued write. It returns a conflict reason instead of
+// pushing when the registry copy of the node changed.
+func (r *RegistryRepo) replay(ctx context.Context, p PendingWrite, force bool) (string, error) {
+	switch p.Op {
+	case PendingNode, PendingDelete:
+		id, err := ParseNode(p.Node)
+		if err != nil {
+			return "", fmt.Errorf("invalid queued node id %q: %w", p.Node, err)
+		}
+		remote, err := r.Client.ReadNode(ctx, r.User, r.Keg, registryNodeID(*id))
+		switch {
+		case errors.Is(err, registry.ErrNotFound):
+			remote = nil
+		case err != nil:
+			return "", registryBackendError("Sync", err)
+		}
+		if !force {
+			if reason := syncConflict(p, remote); reason != "" {
+				return reason, nil
+			}
+		}
+		if p.Op == PendingDelete {
+			if remote == nil {
+				return "", nil
+			}
+			if err := r.Client.DeleteNode(ctx, r.User, r.Keg, registryNodeID(*id)); err != nil && !errors.Is(err, registry.ErrNotFound) {
+				return "", registryBackendError("Sync", err)
+			}
+			return "", r.Cache.forget(ctx, *id)
+		}
+		local, err := r.Cache.node(ctx, *id)
+		if err != nil {
+			return "", err
+		}
+		update := registry.NodeUpdate{Content: local.Content, Meta: local.Meta, Stats: local.Stats}
+		if err := r.Client.WriteNode(ctx, r.User, r.Keg, registryNodeID(*id), update); err != nil {
+			return "", registryBackendError("Sync", err)
+		}
+		return "", r.Cache.storeNode(ctx, *id, local)
+	case PendingIndex:
+		data, err := r.Cache.Repo.GetIndex(ctx, p.Index)
+		if errors.Is(err, ErrNotExist) {
+			return "", nil
+		}
+		if err != nil {
+			return "", err
+		}
+		if err := r.Client.WriteIndex(ctx, r.User, r.Keg, p.Index, data); err != nil {
+			return "", registryBackendError("Sync", err)
+		}
+	case PendingClearIndexes:
+		if err := r.Client.ClearIndexes(ctx, r.User, r.Keg); err != nil {
+			return "", registryBackendError("Sync", err)
+		}
+	case PendingConfig:
+		cfg, err := r.Cache.Repo.ReadConfig(ctx)
+		if err != nil {
+			return "", fmt.Errorf("unable to read cached keg config: %w", err)
+		}
+		data, err := cfg.ToYAML()
+		if err != nil {
+			return "", err
+		}
+		if err := r.Client.WriteConfig(ctx, r.User, r.Keg, data); err != nil {
+			return "", registryBackendError("Sync", err)
+		}
+	default:
+		return "", fmt.Errorf("unknown queued write %q: %w", p.Op, ErrInvalid)
+	}
+	return "", nil
+}
+
+// syncConflict explains why a queued node change must not overwrite remote,
+// or returns "" when it may.
+func syncConflict(p PendingWrite, remote *registry.Node) string {
+	switch {
+	case remote == nil && p.Base != "" && p.Op == PendingNode:
+		return "deleted on the registry"
+	case remote == nil:
+		return ""
+	case p.Base == "":
+		return "created on the registry"
+	case nodeDigest(remote.Content, remote.Meta) != p.Base:
+		return "changed on the registry"
+	}
+	return ""
+}
+
+func pendingLabel(p PendingWrite) string {
+	switch {
+	case p.Node != "":
+		return "node " + p.Node
+	case p.Index != "":
+		return "index " + p.Index
+	}
+	return p.Op
+}
@@ -0,0 +1,318 @@
+package keg_test
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/jlrickert/tapper/pkg/keg"
+	"github.com/jlrickert/tapper/pkg/registry"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRegistry serves the node endpoints of keg joe/notes and answers 503
+// while offline is set, and to writes of node failPut. Node reads carry an ETag and count full downloads;
+// meta-only reads and content ranges do not count.
+type fakeRegistry struct {
+	mu        sync.Mutex
+	nodes     map[string]string
+	failPut   string
+	offline   atomic.Bool
+	downloads atomic.Int32
+}
+
+func (f *fakeRegistry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if f.offline.Load() {
+		http.Error(w, "down", http.StatusServiceUnavailable)
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	const prefix = "/api/v1/kegs/joe/notes/nodes"
+	id := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, prefix), "/")
+	switch {
+	case id == "" && r.Method == http.MethodGet:
+		ids := make([]string, 0, len(f.nodes))
+		for id := range f.nodes {
+			ids = append(ids, id)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"nodes": ids})
+	case r.Method == http.MethodGet && strings.HasSuffix(id, "/content"):
+		content, ok := f.nodes[strings.TrimSuffix(id, "/content")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		var start, end int
+		if _, err := fmt.Sscanf(r.Header.Get("Range"), "bytes=%d-%d", &start, &end); err != nil || start >= len(content) {
+			w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+			return
+		}
+		end = min(end, len(content)-1)
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, len(content)))
+		w.WriteHeader(http.StatusPartialContent)
+		_, _ = w.Write([]byte(content[start : end+1]))
+	case r.Method == http.MethodGet && r.URL.Query().Get("fields") != "":
+		if _, ok := f.nodes[id]; !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"id": id, "meta": "tags: [demo]\n"})
+	case r.Method == http.MethodGet:
+		content, ok := f.nodes[id]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		etag := fmt.Sprintf("%q", fmt.Sprintf("%x", sha256.Sum256([]byte(content))))
+		w.Header().Set("ETag", etag)
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		f.downloads.Add(1)
+		_ = json.NewEncoder(w).Encode(map[string]any{"id": id, "content": content})
+	case r.Method == http.MethodPut && id == f.failPut:
+		http.Error(w, "write failed", http.StatusServiceUnavailable)
+	case r.Method == http.MethodPut:
+		var body struct {
+			Content *string `json:"content"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if body.Content != nil {
+			f.nodes[id] = *body.Content
+		}
+	case r.Method == http.MethodDelete:
+		delete(f.nodes, id)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (f *fakeRegistry) node(id string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.nodes[id]
+}
+
+func (f *fakeRegistry) set(id, content string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.nodes[id] = content
+}
+
+func TestRegistryRepo_OfflineCacheAndSync(t *testing.T) {
+	t.Parallel()
+	fx := NewSandbox(t)
+	ctx := fx.Context()
+
+	fake := &fakeRegistry{nodes: map[string]string{"1": "# One\n", "2": "# Two\n"}}
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+
+	client := registry.NewClient(srv.URL, "")
+	client.Retry = &registry.RetryPolicy{MaxAttempts: 1}
+	repo := keg.NewRegistryRepo(client, "joe", "notes", fx.Runtime())
+	repo.Cache = keg.NewRegistryCache("~/cache", fx.Runtime())
+
+	one, two := keg.NodeId{ID: 1}, keg.NodeId{ID: 2}
+	for _, id := range []keg.NodeId{one, two} {
+		_, err := repo.ReadContent(ctx, id)
+		require.NoError(t, err)
+	}
+
+	fake.offline.Store(true)
+	content, err := repo.ReadContent(ctx, one)
+	require.NoError(t, err, "reads fall back to the cache")
+	require.Equal(t, "# One\n", string(content))
+	ids, err := repo.ListNodes(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []keg.NodeId{one, two}, ids)
+
+	require.NoError(t, repo.WriteContent(ctx, one, []byte("# One offline\n")))
+	require.NoError(t, repo.WriteContent(ctx, two, []byte("# Two offline\n")))
+	_, err = repo.ReadContent(ctx, keg.NodeId{ID: 3})
+	require.Error(t, err, "uncached nodes are unavailable offline")
+
+	_, err = repo.Sync(ctx, false)
+	require.Error(t, err, "sync needs the registry")
+	pending, err := repo.Cache.Pending()
+	require.NoError(t, err)
+	require.Len(t, pending, 2)
+
+	fake.offline.Store(false)
+	fake.set("2", "# Two edited elsewhere\n")
+	content, err = repo.ReadContent(ctx, two)
+	require.NoError(t, err)
+	require.Equal(t, "# Two offline\n", string(content), "queued nodes read from the cache")
+
+	result, err := repo.Sync(ctx, false)
+	require.NoError(t, err)
+	require.Equal(t, 1, result.Pushed)
+	require.Equal(t, []keg.SyncConflict{{Node: "2", Reason: "changed on the registry"}}, result.Conflicts)
+	require.Equal(t, 1, result.Pending)
+	require.Equal(t, "# One offline\n", fake.node("1"))
+	require.Equal(t, "# Two edited elsewhere\n", fake.node("2"))
+
+	result, err = repo.Sync(ctx, true)
+	require.NoError(t, err)
+	require.Equal(t, 1, result.Pushed)
+	require.Zero(t, result.Pending)
+	require.Equal(t, "# Two offline\n", fake.node("2"))
+}
+
+func TestRegistryRepo_ResolveConflict(t *testing.T) {
+	t.Parallel()
+	fx := NewSandbox(t)
+	ctx := fx.Context()
+
+	fake := &fakeRegistry{nodes: map[string]string{"2": "# Two\n"}}
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+
+	client := registry.NewClient(srv.URL, "")
+	client.Retry = &registry.RetryPolicy{MaxAttempts: 1}
+	repo := keg.NewRegistryRepo(client, "joe", "notes", fx.Runtime())
+	repo.Cache = keg.NewRegistryCache("~/cache", fx.Runtime())
+
+	two := keg.NodeId{ID: 2}
+	_, err := repo.ReadContent(ctx, two)
+	require.NoError(t, err)
+	fake.offline.Store(true)
+	require.NoError(t, repo.WriteContent(ctx, two, []byte("# Two offline\n")))
+	require.NoError(t, repo.WriteContent(ctx, two, []byte("# Two offline again\n")))
+	fake.offline.Store(false)
+	fake.set("2", "# Two elsewhere\n")
+
+	_, err = repo.Conflict(ctx, keg.NodeId{ID: 1})
+	require.ErrorIs(t, err, keg.ErrNotExist, "nothing queued")
+	v, err := repo.Conflict(ctx, two)
+	require.NoError(t, err)
+	require.Equal(t, "# Two\n", string(v.Base), "base is the copy the first offline edit started from")
+	require.Equal(t, "# Two offline again\n", string(v.Local))
+	require.Equal(t, "# Two elsewhere\n", string(v.Remote))
+
+	fake.set("2", "# Two changed again\n")
+	require.ErrorIs(t, repo.ResolveConflict(ctx, v), keg.ErrConflict, "stale remote")
+	v, err = repo.Conflict(ctx, two)
+	require.NoError(t, err)
+	require.NoError(t, repo.ResolveConflict(ctx, v))
+	require.NoError(t, repo.WriteContent(ctx, two, []byte("# Two merged\n")))
+
+	result, err := repo.Sync(ctx, false)
+	require.NoError(t, err)
+	require.Equal(t, 1, result.Pushed)
+	require.Empty(t, result.Conflicts)
+	require.Equal(t, "# Two merged\n", fake.node("2"))
+}
+
+func TestRegistryRepo_SyncResumesAfterFailedWrite(t *testing.T) {
+	t.Parallel()
+	fx := NewSandbox(t)
+	ctx := fx.Context()
+
+	fake := &fakeRegistry{nodes: map[string]string{"1": "# One\n", "2": "# Two\n"}}
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+
+	client := registry.NewClient(srv.URL, "")
+	client.Retry = &registry.RetryPolicy{MaxAttempts: 1}
+	repo := keg.NewRegistryRepo(client, "joe", "notes", fx.Runtime())
+	repo.Cache = keg.NewRegistryCache("~/cache", fx.Runtime())
+
+	one, two := keg.NodeId{ID: 1}, keg.NodeId{ID: 2}
+	for _, id := range []keg.NodeId{one, two} {
+		_, err := repo.ReadContent(ctx, id)
+		require.NoError(t, err)
+	}
+	fake.offline.Store(true)
+	require.NoError(t, repo.WriteContent(ctx, one, []byte("# One offline\n")))
+	require.NoError(t, repo.WriteContent(ctx, two, []byte("# Two offline\n")))
+	fake.offline.Store(false)
+
+	// The registry accepts the first queued write and fails the second.
+	fake.mu.Lock()
+	fake.failPut = "2"
+	fake.mu.Unlock()
+
+	result, err := repo.Sync(ctx, false)
+	require.Error(t, err)
+	require.Equal(t, 1, result.Pushed)
+	require.Equal(t, 1, result.Pending, "the failed write stays queued")
+	require.Equal(t, "# One offline\n", fake.node("1"))
+	require.Equal(t, "# Two\n", fake.node("2"))
+
+	fake.mu.Lock()
+	fake.failPut = ""
+	fake.mu.Unlock()
+	result, err = repo.Sync(ctx, false)
+	require.NoError(t, err)
+	require.Equal(t, 1, result.Pushed, "the pushed write is not repeated")
+	require.Zero(t, result.Pending)
+	require.Equal(t, "# Two offline\n", fake.node("2"))
+}
+
+func TestRegistryRepo_ConditionalReads(t *testing.T) {
+	t.Parallel()
+	fx := NewSandbox(t)
+	ctx := fx.Context()
+
+	fake := &fakeRegistry{nodes: map[string]string{"1": "# One\n"}}
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+
+	repo := keg.NewRegistryRepo(registry.NewClient(srv.URL, ""), "joe", "notes", fx.Runtime())
+	repo.Cache = keg.NewRegistryCache("~/cache", fx.Runtime())
+	one := keg.NodeId{ID: 1}
+
+	for range 3 {
+		content, err := repo.ReadContent(ctx, one)
+		require.NoError(t, err)
+		require.Equal(t, "# One\n", string(content))
+	}
+	require.EqualValues(t, 1, fake.downloads.Load(), "unchanged nodes are served from the cache")
+
+	fake.set("1", "# One changed\n")
+	content, err := repo.ReadContent(ctx, one)
+	require.NoError(t, err)
+	require.Equal(t, "# One changed\n", string(content))
+	require.EqualValues(t, 2, fake.downloads.Load())
+}
+
+func TestRegistryRepo_PartialReads(t *testing.T) {
+	t.Parallel()
+	fx := NewSandbox(t)
+	ctx := fx.Context()
+
+	fake := &fakeRegistry{nodes: map[string]string{"1": "# One\n\nA longer body.\n"}}
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+
+	repo := keg.NewRegistryRepo(registry.NewClient(srv.URL, ""), "joe", "notes", fx.Runtime())
+	repo.Cache = keg.NewRegistryCache("~/cache", fx.Runtime())
+	one := keg.NodeId{ID: 1}
+
+	meta, err := repo.ReadMeta(ctx, one)
+	require.NoError(t, err)
+	require.Equal(t, "tags: [demo]\n", string(meta))
+	prefix, err := keg.ReadContentPrefix(ctx, repo, one, 5)
+	require.NoError(t, err)
+	require.Equal(t, "# One", string(prefix))
+	require.Zero(t, fake.downloads.Load(), "meta and previews skip the content download")
+
+	_, err = keg.ReadContentPrefix(ctx, repo, keg.NodeId{ID: 9}, 5)
+	require.ErrorIs(t, err, keg.ErrNotExist)
+
+	_, err = repo.ReadContent(ctx, one)
+	require.NoError(t, err)
+	prefix, err = keg.ReadContentPrefix(ctx, repo, one, 5)
+	require.NoError(t, err)
+	require.Equal(t, "# One", string(prefix))
+	require.EqualValues(t, 1, fake.downloads.Load(), "cached nodes are previewed from the cache")
+}
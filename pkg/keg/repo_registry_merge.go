@@ -0,0 +1,139 @@
+package keg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/jlrickert/tapper/pkg/registry"
+)
+
+// ConflictVersions holds the three versions of node content a sync conflict
+// is merged from.
+type ConflictVersions struct {
+	Node NodeId
+
+	// Base is the content the queued change started from. It is empty when
+	// the node was created offline or its base copy was not kept.
+	Base []byte
+
+	// Local is the queued content in the cache.
+	Local []byte
+
+	// Remote is the current registry content.
+	Remote []byte
+
+	// remoteDigest identifies the registry copy Remote was read from.
+	remoteDigest string
+}
+
+// basePath is where the cache keeps the registry content a queued node
+// change started from.
+func (c *RegistryCache) basePath(id NodeId) string {
+	return filepath.Join(c.Root, "base", id.Path())
+}
+
+// saveBase keeps the cached content of id as the base of a queued change.
+// A node that is not cached has no base to keep.
+func (c *RegistryCache) saveBase(ctx context.Context, id NodeId) error {
+	content, err := c.Repo.ReadContent(ctx, id)
+	if errors.Is(err, ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return c.writeBase(id, content)
+}
+
+func (c *RegistryCache) writeBase(id NodeId, content []byte) error {
+	path := c.basePath(id)
+	if err := c.runtime.Mkdir(filepath.Dir(path), 0o755, true); err != nil {
+		return fmt.Errorf("unable to create registry cache: %w", err)
+	}
+	if err := c.runtime.AtomicWriteFile(path, content, 0o644); err != nil {
+		return fmt.Errorf("unable to write base of node %s: %w", id.Path(), err)
+	}
+	return nil
+}
+
+// base returns the kept base content of id, or nil when there is none.
+func (c *RegistryCache) base(id NodeId) []byte {
+	data, err := c.runtime.ReadFile(c.basePath(id))
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+// dropBase removes the kept base content of id.
+func (c *RegistryCache) dropBase(id NodeId) {
+	if err := c.runtime.Remove(c.basePath(id), false); err != nil && !os.IsNotExist(err) {
+		c.runtime.Logger().Debug("unable to remove base copy", "node", id.Path(), "error", err)
+	}
+}
+
+// Conflict returns the base, local and registry content of the queued
+// change of id for a three-way merge. It fails with ErrNotExist when no
+// change of id is queued and with ErrConflict when the node was deleted on
+// the registry, which leaves nothing to merge.
+func (r *RegistryRepo) Conflict(ctx context.Context, id NodeId) (*ConflictVersions, error) {
+	if r.Cache == nil {
+		return nil, fmt.Errorf("registry keg has no local cache: %w", ErrNotSupported)
+	}
+	if !r.Cache.queued(id.Path(), PendingNode) {
+		return nil, NewNotFoundError("queued change", id.Path())
+	}
+	local, err := r.Cache.Repo.ReadContent(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	remote, err := r.Client.ReadNode(ctx, r.User, r.Keg, registryNodeID(id))
+	switch {
+	case errors.Is(err, registry.ErrNotFound):
+		return nil, fmt.Errorf("node %s was deleted on the registry: %w", id.Path(), ErrConflict)
+	case err != nil:
+		return nil, registryBackendError("Conflict", err)
+	}
+	return &ConflictVersions{
+		Node:         id,
+		Base:         r.Cache.base(id),
+		Local:        local,
+		Remote:       remote.Content,
+		remoteDigest: nodeDigest(remote.Content, remote.Meta),
+	}, nil
+}
+
+// ResolveConflict marks the queued change of v.Node as made on top of the
+// registry copy v.Remote was read from, so the next Sync pushes the local
+// copy instead of reporting a conflict. Write the merged content, for
+// example with Keg.SetContent, after resolving. It fails with ErrConflict
+// when the registry copy changed again since Conflict read it.
+func (r *RegistryRepo) ResolveConflict(ctx context.Context, v *ConflictVersions) error {
+	if r.Cache == nil {
+		return fmt.Errorf("registry keg has no local cache: %w", ErrNotSupported)
+	}
+	remote, err := r.Client.ReadNode(ctx, r.User, r.Keg, registryNodeID(v.Node))
+	switch {
+	case errors.Is(err, registry.ErrNotFound):
+		return fmt.Errorf("node %s was deleted on the registry: %w", v.Node.Path(), ErrConflict)
+	case err != nil:
+		return registryBackendError("ResolveConflict", err)
+	}
+	if nodeDigest(remote.Content, remote.Meta) != v.remoteDigest {
+		return fmt.Errorf("node %s changed on the registry again: %w", v.Node.Path(), ErrConflict)
+	}
+	if err := r.Cache.writeBase(v.Node, v.Remote); err != nil {
+		return err
+	}
+	node := v.Node.Path()
+	return r.Cache.update(func(state *registryCacheState) {
+		for i, p := range state.Queue {
+			if p.Node == node && p.Op == PendingNode {
+				state.Queue[i].Base = v.remoteDigest
+			}
+		}
+	})
+}
@@ -0,0 +1,267 @@
+package keg
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// ReplayMismatch is a recorded call whose replay turned out differently.
+type ReplayMismatch struct {
+	Call RecordedCall
+	// Reason describes the difference, for example
+	// "error not-exist, recorded none".
+	Reason string
+}
+
+func (m ReplayMismatch) String() string {
+	target := m.Call.Node
+	if m.Call.Name != "" {
+		target = fmt.Sprintf("%s %s", target, m.Call.Name)
+	}
+	return fmt.Sprintf("#%d %s %s: %s", m.Call.Seq, m.Call.Op, target, m.Reason)
+}
+
+// Replay re-executes a RecordingRepo log read from r against repo, in
+// recorded order. repo should start out as the recorded backend did, for
+// example empty when the session began with Init. Replay reports every call
+// whose outcome differs: a different error kind, a different number of bytes
+// read or items listed, or a different id from Next. Lock records are
+// skipped since the calls made under a lock are recorded themselves. Writes
+// recorded without payloads cannot be repeated and are reported as
+// mismatches.
+//
+// The returned error is for an unreadable log or a canceled ctx; mismatches
+// are not errors.
+func Replay(ctx context.Context, r io.Reader, repo Repository) ([]ReplayMismatch, error) {
+	var mismatches []ReplayMismatch
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64<<10), 256<<20)
+	for line := 1; scanner.Scan(); line++ {
+		if err := ctx.Err(); err != nil {
+			return mismatches, err
+		}
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var call RecordedCall
+		if err := json.Unmarshal(scanner.Bytes(), &call); err != nil {
+			return mismatches, fmt.Errorf("recording line %d: %w: %w", line, ErrParse, err)
+		}
+		if reason := replayCall(ctx, repo, call); reason != "" {
+			mismatches = append(mismatches, ReplayMismatch{Call: call, Reason: reason})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return mismatches, fmt.Errorf("unable to read recording: %w", err)
+	}
+	return mismatches, nil
+}
+
+// replayCall repeats call against repo and returns why its outcome differs,
+// or "" when it matches.
+func replayCall(ctx context.Context, repo Repository, call RecordedCall) string {
+	node, dst, err := replayIDs(call)
+	if err != nil {
+		return err.Error()
+	}
+
+	got := RecordedCall{}
+	switch call.Op {
+	case "WithNodeLock", "WithKegLock":
+		return ""
+	case "HasNode":
+		var ok bool
+		ok, err = repo.HasNode(ctx, node)
+		if ok {
+			got.Count = 1
+		}
+	case "Next":
+		var id NodeId
+		id, err = repo.Next(ctx)
+		if err == nil {
+			got.Node = id.Path()
+		}
+	case "ListNodes":
+		var ids []NodeId
+		ids, err = repo.ListNodes(ctx)
+		got.Count = len(ids)
+	case "MoveNode":
+		err = repo.MoveNode(ctx, node, dst)
+	case "DeleteNode":
+		err = repo.DeleteNode(ctx, node)
+	case "ReadContent":
+		var data []byte
+		data, err = repo.ReadContent(ctx, node)
+		got.Bytes = len(data)
+	case "ReadMeta":
+		var data []byte
+		data, err = repo.ReadMeta(ctx, node)
+		got.Bytes = len(data)
+	case "ReadStats":
+		_, err = repo.ReadStats(ctx, node)
+	case "GetIndex":
+		var data []byte
+		data, err = repo.GetIndex(ctx, call.Name)
+		got.Bytes = len(data)
+	case "ListIndexes":
+		var names []string
+		names, err = repo.ListIndexes(ctx)
+		got.Count = len(names)
+	case "ClearIndexes":
+		err = repo.ClearIndexes(ctx)
+	case "ReadConfig":
+		_, err = repo.ReadConfig(ctx)
+	case "ListFiles":
+		var names []string
+		names, err = repoListFiles(ctx, repo, node)
+		got.Count = len(names)
+	case "ListImages":
+		var names []string
+		names, err = repoListImages(ctx, repo, node)
+		got.Count = len(names)
+	case "ReadFile", "DeleteFile", "ReadImage", "DeleteImage":
+		got.Bytes, err = replayAsset(ctx, repo, call, node)
+	default:
+		return replayWrite(ctx, repo, call, node)
+	}
+	return compareReplay(call, got, err)
+}
+
+// replayWrite repeats a recorded write from its payload.
+func replayWrite(ctx context.Context, repo Repository, call RecordedCall, node NodeId) string {
+	var err error
+	switch call.Op {
+	case "WriteContent":
+		if call.Data == nil && call.Bytes > 0 {
+			return "payload not recorded"
+		}
+		err = repo.WriteContent(ctx, node, call.Data)
+	case "WriteMeta":
+		if call.Meta == nil && call.Bytes > 0 {
+			return "payload not recorded"
+		}
+		err = repo.WriteMeta(ctx, node, call.Meta)
+	case "WriteStats", "WriteNode":
+		var stats *NodeStats
+		if call.Stats != nil {
+			if stats, err = ParseStats(ctx, call.Stats); err != nil {
+				return fmt.Sprintf("recorded stats do not parse: %v", err)
+			}
+		}
+		if call.Bytes > 0 && call.Data == nil && call.Meta == nil && stats == nil {
+			return "payload not recorded"
+		}
+		if call.Op == "WriteStats" {
+			err = repo.WriteStats(ctx, node, stats)
+		} else {
+			err = repo.WriteNode(ctx, node, call.Data, call.Meta, stats)
+		}
+	case "WriteIndex":
+		if call.Data == nil && call.Bytes > 0 {
+			return "payload not recorded"
+		}
+		err = repo.WriteIndex(ctx, call.Name, call.Data)
+	case "WriteConfig":
+		if call.Config == nil {
+			return "payload not recorded"
+		}
+		cfg, parseErr := ParseKegConfig(call.Config)
+		if parseErr != nil {
+			return fmt.Sprintf("recorded config does not parse: %v", parseErr)
+		}
+		err = repo.WriteConfig(ctx, cfg)
+	case "WriteFile", "WriteImage":
+		if call.Data == nil && call.Bytes > 0 {
+			return "payload not recorded"
+		}
+		_, err = replayAsset(ctx, repo, call, node)
+	default:
+		return "unknown operation"
+	}
+	return compareReplay(call, RecordedCall{}, err)
+}
+
+// replayAsset repeats a file or image call and returns the bytes it read.
+func replayAsset(ctx context.Context, repo Repository, call RecordedCall, node NodeId) (int, error) {
+	files, hasFiles := repo.(RepositoryFiles)
+	images, hasImages := repo.(RepositoryImages)
+	var data []byte
+	var err error
+	switch {
+	case call.Op == "ReadFile" && hasFiles:
+		data, err = files.ReadFile(ctx, node, call.Name)
+	case call.Op == "WriteFile" && hasFiles:
+		err = files.WriteFile(ctx, node, call.Name, call.Data)
+	case call.Op == "DeleteFile" && hasFiles:
+		err = files.DeleteFile(ctx, node, call.Name)
+	case call.Op == "ReadImage" && hasImages:
+		data, err = images.ReadImage(ctx, node, call.Name)
+	case call.Op == "WriteImage" && hasImages:
+		err = images.WriteImage(ctx, node, call.Name, call.Data)
+	case call.Op == "DeleteImage" && hasImages:
+		err = images.DeleteImage(ctx, node, call.Name)
+	default:
+		err = fmt.Errorf("%s: %s does not support it: %w", call.Op, repo.Name(), ErrNotSupported)
+	}
+	return len(data), err
+}
+
+func replayIDs(call RecordedCall) (node, dst NodeId, err error) {
+	if call.Node != "" && call.Op != "Next" {
+		id, parseErr := ParseNode(call.Node)
+		if parseErr != nil {
+			return node, dst, fmt.Errorf("recorded node %q does not parse", call.Node)
+		}
+		node = *id
+	}
+	if call.Dst != "" {
+		id, parseErr := ParseNode(call.Dst)
+		if parseErr != nil {
+			return node, dst, fmt.Errorf("recorded destination %q does not parse", call.Dst)
+		}
+		dst = *id
+	}
+	return node, dst, nil
+}
+
+// compareReplay compares the outcome of a replayed call with the recording.
+func compareReplay(want, got RecordedCall, err error) string {
+	kind := ""
+	if err != nil {
+		kind = errKind(err)
+	}
+	switch {
+	case kind != want.ErrKind:
+		return fmt.Sprintf("error %s, recorded %s", orNone(kind), orNone(want.ErrKind))
+	case err != nil:
+		return ""
+	case want.Op == "Next" && got.Node != want.Node:
+		return fmt.Sprintf("allocated %s, recorded %s", got.Node, want.Node)
+	case got.Bytes != want.Bytes && isReplayRead(want.Op):
+		return fmt.Sprintf("read %d bytes, recorded %d", got.Bytes, want.Bytes)
+	case got.Count != want.Count:
+		if want.Op == "HasNode" {
+			return fmt.Sprintf("node exists is %t, recorded %t", got.Count == 1, want.Count == 1)
+		}
+		return fmt.Sprintf("listed %d, recorded %d", got.Count, want.Count)
+	}
+	return ""
+}
+
+func isReplayRead(op string) bool {
+	switch op {
+	case "ReadContent", "ReadMeta", "GetIndex", "ReadFile", "ReadImage":
+		return true
+	}
+	return false
+}
+
+func orNone(kind string) string {
+	if kind == "" {
+		return "none"
+	}
+	return kind
+}
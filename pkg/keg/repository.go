@@ -2,6 +2,8 @@ package keg
 
 import (
 	"context"
+	"fmt"
+	"io"
 	"time"
 )
 
@@ -68,6 +70,11 @@ type Repository interface {
 	// Implementations should preserve manually edited metadata fields when stats
 	// and metadata share a storage representation.
 	WriteStats(ctx context.Context, id NodeId, stats *NodeStats) error
+	// WriteNode writes content, meta and stats for id as one update: after a
+	// crash either all of them or none of them are visible. A nil content,
+	// meta or stats leaves that part unchanged. Keg uses WriteNode for every
+	// update touching more than one of these files.
+	WriteNode(ctx context.Context, id NodeId, content, meta []byte, stats *NodeStats) error
 
 	// Indexes
 
@@ -118,6 +125,120 @@ type RepositoryImages interface {
 	DeleteImage(ctx context.Context, id NodeId, name string) error
 }
 
+// RepositoryItemMeta provides optional structured metadata for node images
+// and file attachments.
+type RepositoryItemMeta interface {
+	// ReadItemMeta reads metadata for a node item. Missing metadata should
+	// return a typed/sentinel not-exist error.
+	ReadItemMeta(ctx context.Context, id NodeId, kind AssetKind, name string) (*ItemMeta, error)
+	// WriteItemMeta stores metadata for an existing node item.
+	WriteItemMeta(ctx context.Context, id NodeId, kind AssetKind, name string, meta *ItemMeta) error
+}
+
+// RepositoryStreams provides optional streaming reads of node images and
+// file attachments, so large items need not be held in memory.
+type RepositoryStreams interface {
+	// OpenItem opens a node item for reading. Callers must close the
+	// returned reader. Missing items should return a typed/sentinel
+	// not-exist error.
+	OpenItem(ctx context.Context, id NodeId, kind AssetKind, name string) (io.ReadCloser, error)
+}
+
+// RepositoryContentRange provides optional partial content reads, so
+// previews over remote backends need not transfer whole nodes. Callers go
+// through ReadContentPrefix, which falls back to ReadContent.
+type RepositoryContentRange interface {
+	// ReadContentRange reads up to length bytes of node content starting at
+	// offset. A range past the end of the content returns no bytes. Missing
+	// nodes should return a typed/sentinel not-exist error.
+	ReadContentRange(ctx context.Context, id NodeId, offset, length int64) ([]byte, error)
+}
+
+// RepositoryKegLock provides an optional keg-wide lock that serializes
+// operations rewriting many nodes and the dex at once, such as index rebuilds
+// and imports. It is independent of the per-node locks.
+type RepositoryKegLock interface {
+	// WithKegLock executes fn while holding the keg-wide lock. A lock left
+	// behind by a dead process is removed. Implementations should return
+	// ErrLockTimeout when the lock stays held by someone else.
+	WithKegLock(ctx context.Context, fn func(context.Context) error) error
+	// KegLockOwner returns the holder of the keg-wide lock, or nil when the
+	// lock is free.
+	KegLockOwner(ctx context.Context) (*LockOwner, error)
+	// BreakKegLock removes the keg-wide lock whoever holds it. Removing a
+	// lock that is not held is not an error.
+	BreakKegLock(ctx context.Context) error
+}
+
+// LockOwner describes the process holding a lock.
+type LockOwner struct {
+	PID       int
+	Hostname  string
+	StartedAt time.Time
+
+	// Stale is set when the owning process is known to be gone.
+	Stale bool
+}
+
+func (o LockOwner) String() string {
+	s := fmt.Sprintf("pid %d", o.PID)
+	if o.Hostname != "" {
+		s += " on " + o.Hostname
+	}
+	if !o.StartedAt.IsZero() {
+		s += " since " + o.StartedAt.Format(time.RFC3339)
+	}
+	return s
+}
+
+// RepositoryArchive provides an optional archived namespace. Archived nodes
+// are not reported by HasNode or ListNodes, but their IDs stay reserved and
+// their content remains readable until they are unarchived.
+type RepositoryArchive interface {
+	// ArchiveNode moves an active node into the archived namespace.
+	// Missing nodes should return a typed/sentinel not-exist error.
+	ArchiveNode(ctx context.Context, id NodeId) error
+	// UnarchiveNode restores an archived node to the active namespace.
+	// Implementations should return ErrDestinationExists when an active node
+	// already uses id.
+	UnarchiveNode(ctx context.Context, id NodeId) error
+	// ListArchived returns archived node ids in ascending order.
+	ListArchived(ctx context.Context) ([]NodeId, error)
+	// ReadArchivedContent reads the primary content of an archived node.
+	ReadArchivedContent(ctx context.Context, id NodeId) ([]byte, error)
+	// ReadArchivedMeta reads raw metadata bytes of an archived node.
+	ReadArchivedMeta(ctx context.Context, id NodeId) ([]byte, error)
+}
+
+// RepositoryArchivedState is implemented by archives that keep the stats and
+// item areas of archived nodes, so copies and backups can carry them.
+type RepositoryArchivedState interface {
+	// ReadArchivedStats reads the stats of an archived node.
+	ReadArchivedStats(ctx context.Context, id NodeId) (*NodeStats, error)
+	// ReadArchivedItems reads the attachments, images, item metadata and
+	// content versions of an archived node.
+	ReadArchivedItems(ctx context.Context, id NodeId) (*NodeItems, error)
+}
+
+// RepositoryBlobs is implemented by repositories that can store file
+// attachments in a content-addressed blob store, leaving lightweight
+// pointers in the node directories.
+type RepositoryBlobs interface {
+	// CollectBlobs removes blobs no longer referenced by any attachment
+	// pointer. With dryRun set it only reports what would be removed.
+	CollectBlobs(ctx context.Context, dryRun bool) (*BlobGCReport, error)
+}
+
+// BlobGCReport summarizes a blob garbage collection pass.
+type BlobGCReport struct {
+	// Removed lists the hashes of unreferenced blobs.
+	Removed []string
+	// Freed is the total size in bytes of the removed blobs.
+	Freed int64
+	// Kept is the number of blobs still referenced.
+	Kept int
+}
+
 type RevisionID int64
 
 // SnapshotContentKind describes how snapshot content bytes are stored.
@@ -199,7 +320,27 @@ type RepositorySnapshots interface {
 	RestoreSnapshot(ctx context.Context, id NodeId, rev RevisionID, createRestoreSnapshot bool) error
 }
 
+// RepositorySnapshotPurge is implemented by snapshot stores that can drop
+// the whole history of a node, as is done when its content is first
+// encrypted.
+type RepositorySnapshotPurge interface {
+	// PurgeSnapshots removes every snapshot of id. A node without snapshots
+	// is not an error.
+	PurgeSnapshots(ctx context.Context, id NodeId) error
+}
+
 type nodeLockContextKey struct{}
+type kegLockContextKey struct{}
+
+func contextWithKegLock(ctx context.Context) context.Context {
+	return context.WithValue(ctx, kegLockContextKey{}, true)
+}
+
+func contextHasKegLock(ctx context.Context) bool {
+	held, _ := ctx.Value(kegLockContextKey{}).(bool)
+	return held
+}
+
 type nodeLockSet map[NodeId]struct{}
 
 func lockNodeKey(id NodeId) NodeId {
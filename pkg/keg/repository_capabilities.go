@@ -25,3 +25,81 @@ func repoSnapshots(repo Repository) (RepositorySnapshots, bool) {
 	}
 	return withSnapshots, true
 }
+
+func repoSnapshotPurge(repo Repository) (RepositorySnapshotPurge, bool) {
+	withPurge, ok := repo.(RepositorySnapshotPurge)
+	if !ok {
+		return nil, false
+	}
+	return withPurge, true
+}
+
+func repoArchive(repo Repository) (RepositoryArchive, bool) {
+	withArchive, ok := repo.(RepositoryArchive)
+	if !ok {
+		return nil, false
+	}
+	return withArchive, true
+}
+
+func repoArchivedState(repo Repository) (RepositoryArchivedState, bool) {
+	withState, ok := repo.(RepositoryArchivedState)
+	if !ok {
+		return nil, false
+	}
+	return withState, true
+}
+
+func repoBlobs(repo Repository) (RepositoryBlobs, bool) {
+	withBlobs, ok := repo.(RepositoryBlobs)
+	if !ok {
+		return nil, false
+	}
+	return withBlobs, true
+}
+
+func repoItemMeta(repo Repository) (RepositoryItemMeta, bool) {
+	withItemMeta, ok := repo.(RepositoryItemMeta)
+	if !ok {
+		return nil, false
+	}
+	return withItemMeta, true
+}
+
+func repoKegLock(repo Repository) (RepositoryKegLock, bool) {
+	withKegLock, ok := repo.(RepositoryKegLock)
+	if !ok {
+		return nil, false
+	}
+	return withKegLock, true
+}
+
+func repoStreams(repo Repository) (RepositoryStreams, bool) {
+	withStreams, ok := repo.(RepositoryStreams)
+	if !ok {
+		return nil, false
+	}
+	return withStreams, true
+}
+
+// ReadContentPrefix reads at most n bytes from the start of a node's
+// content, asking the repository for the range alone when it supports
+// partial reads. The prefix may end inside a multi-byte character.
+func ReadContentPrefix(ctx context.Context, repo Repository, id NodeId, n int64) ([]byte, error) {
+	if withRange, ok := repo.(RepositoryContentRange); ok {
+		return withRange.ReadContentRange(ctx, id, 0, n)
+	}
+	content, err := repo.ReadContent(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return contentRange(content, 0, n), nil
+}
+
+// contentRange cuts up to length bytes starting at offset from content.
+func contentRange(content []byte, offset, length int64) []byte {
+	if offset < 0 || length <= 0 || offset >= int64(len(content)) {
+		return nil
+	}
+	return content[offset:min(offset+length, int64(len(content)))]
+}
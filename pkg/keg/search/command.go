@@ -0,0 +1,74 @@
+package search
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// CommandEmbedder delegates embedding to an external process, which is how
+// local models (ONNX runtimes, sentence-transformers scripts, ...) plug in.
+// The process receives {"model": ..., "input": [...]} as JSON on stdin and
+// must print {"embeddings": [[...], ...]} on stdout.
+type CommandEmbedder struct {
+	Command   []string
+	ModelName string
+
+	// Timeout bounds each run. Zero means DefaultEmbedTimeout.
+	Timeout time.Duration
+}
+
+type commandResponse struct {
+	Embeddings [][]float32 `json:"embeddings"`
+}
+
+// Model implements Embedder. When no model is configured the command line
+// identifies the model so switching plugins invalidates cached vectors.
+func (e *CommandEmbedder) Model() string {
+	if e.ModelName != "" {
+		return e.ModelName
+	}
+	return strings.Join(e.Command, " ")
+}
+
+// Embed implements Embedder.
+func (e *CommandEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+	if len(e.Command) == 0 {
+		return nil, fmt.Errorf("embedder command is empty")
+	}
+	in, err := json.Marshal(openAIRequest{Model: e.ModelName, Input: texts})
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := embedContext(ctx, e.Timeout)
+	defer cancel()
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, e.Command[0], e.Command[1:]...)
+	cmd.Stdin = bytes.NewReader(in)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	cmd.WaitDelay = time.Second
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("embedder command failed: %w: %s", err, msg)
+		}
+		return nil, fmt.Errorf("embedder command failed: %w", err)
+	}
+
+	var out commandResponse
+	if err := json.Unmarshal(stdout.Bytes(), &out); err != nil {
+		return nil, fmt.Errorf("unable to decode embedder output: %w", err)
+	}
+	if len(out.Embeddings) != len(texts) {
+		return nil, fmt.Errorf("embedder returned %d vectors for %d inputs", len(out.Embeddings), len(texts))
+	}
+	return out.Embeddings, nil
+}
@@ -0,0 +1,101 @@
+package search
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jlrickert/tapper/pkg/keg"
+)
+
+// OpenAIEmbedder calls an OpenAI-compatible /embeddings endpoint. Any server
+// implementing the same request and response shape (Ollama, LM Studio,
+// vLLM, ...) works.
+type OpenAIEmbedder struct {
+	// URL is the API base, e.g. "https://api.openai.com/v1".
+	URL       string
+	ModelName string
+	APIKey    string
+
+	// Client is the HTTP client to use. Nil uses http.DefaultClient.
+	Client *http.Client
+
+	// Timeout bounds each request. Zero means DefaultEmbedTimeout.
+	Timeout time.Duration
+}
+
+type openAIRequest struct {
+	Model string   `json:"model,omitempty"`
+	Input []string `json:"input"`
+}
+
+type openAIResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+}
+
+// Model implements Embedder.
+func (e *OpenAIEmbedder) Model() string { return e.ModelName }
+
+// Embed implements Embedder.
+func (e *OpenAIEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+	body, err := json.Marshal(openAIRequest{Model: e.ModelName, Input: texts})
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := embedContext(ctx, e.Timeout)
+	defer cancel()
+	url := strings.TrimRight(e.URL, "/") + "/embeddings"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("unable to build embedding request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if e.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+e.APIKey)
+	}
+
+	client := e.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, keg.NewBackendError("openai", "Embed", 0, err, true)
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, keg.NewBackendError("openai", "Embed", resp.StatusCode, err, true)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		cause := fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(raw)))
+		return nil, keg.NewBackendError("openai", "Embed", resp.StatusCode, cause, resp.StatusCode >= 500)
+	}
+
+	var out openAIResponse
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return nil, fmt.Errorf("unable to decode embedding response: %w", err)
+	}
+	if len(out.Data) != len(texts) {
+		return nil, fmt.Errorf("embedding response has %d vectors for %d inputs", len(out.Data), len(texts))
+	}
+	sort.SliceStable(out.Data, func(i, j int) bool { return out.Data[i].Index < out.Data[j].Index })
+	vecs := make([][]float32, len(out.Data))
+	for i, d := range out.Data {
+		vecs[i] = d.Embedding
+	}
+	return vecs, nil
+}
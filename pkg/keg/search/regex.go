@@ -0,0 +1,77 @@
+package search
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// Span is a half-open byte range [Start, End) within a line.
+type Span struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// LineMatch is a single line containing one or more regex matches.
+type LineMatch struct {
+	// Line is the 1-based line number.
+	Line int `json:"line"`
+
+	// Text is the line without its trailing newline.
+	Text string `json:"text"`
+
+	// Spans locate each match within Text.
+	Spans []Span `json:"spans"`
+}
+
+// ScanRegex streams r line by line and returns every line matched by re.
+// Empty matches are ignored so patterns like `a*` do not flag every line.
+func ScanRegex(re *regexp.Regexp, r io.Reader) ([]LineMatch, error) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	var out []LineMatch
+	n := 0
+	for sc.Scan() {
+		n++
+		text := strings.TrimRight(sc.Text(), "\r")
+		var spans []Span
+		for _, loc := range re.FindAllStringIndex(text, -1) {
+			if loc[0] == loc[1] {
+				continue
+			}
+			spans = append(spans, Span{Start: loc[0], End: loc[1]})
+		}
+		if len(spans) > 0 {
+			out = append(out, LineMatch{Line: n, Text: text, Spans: spans})
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Highlight wraps each span of text in open and close, for example ANSI
+// escape sequences. Spans must be ordered and non-overlapping, as returned by
+// ScanRegex.
+func Highlight(text string, spans []Span, open, close string) string {
+	if len(spans) == 0 {
+		return text
+	}
+	var b strings.Builder
+	last := 0
+	for _, s := range spans {
+		if s.Start < last || s.End > len(text) {
+			continue
+		}
+		b.WriteString(text[last:s.Start])
+		b.WriteString(open)
+		b.WriteString(text[s.Start:s.End])
+		b.WriteString(close)
+		last = s.End
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
@@ -0,0 +1,65 @@
+// Package search provides semantic search over a keg. Node text is turned
+// into vectors by a pluggable Embedder, cached in a dex sidecar, and ranked
+// against a query vector by cosine similarity.
+package search
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jlrickert/tapper/pkg/keg"
+)
+
+// VectorsIndexName is the dex artifact holding cached node vectors.
+const VectorsIndexName = "vectors.jsonl"
+
+// DefaultEmbedTimeout bounds one embedder command or API request when the
+// embedder does not set its own timeout.
+const DefaultEmbedTimeout = 2 * time.Minute
+
+// embedContext bounds ctx by timeout, or DefaultEmbedTimeout when it is zero.
+func embedContext(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		timeout = DefaultEmbedTimeout
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// Embedder turns text into vectors. Implementations must return exactly one
+// vector per input, in input order.
+type Embedder interface {
+	// Model identifies the embedding model. Cached vectors produced by a
+	// different model are discarded.
+	Model() string
+
+	// Embed returns one vector for each text.
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// NewEmbedder builds the Embedder described by cfg. getenv resolves
+// cfg.APIKeyEnv so callers can supply a sandboxed environment.
+func NewEmbedder(cfg *keg.EmbedderConfig, getenv func(string) string) (Embedder, error) {
+	if cfg == nil {
+		return nil, fmt.Errorf("no embedder configured: %w", keg.ErrNotSupported)
+	}
+	switch strings.ToLower(strings.TrimSpace(cfg.Kind)) {
+	case "openai":
+		if strings.TrimSpace(cfg.URL) == "" {
+			return nil, keg.NewInvalidConfigError("search.embedder.url is required for the openai embedder")
+		}
+		key := ""
+		if cfg.APIKeyEnv != "" && getenv != nil {
+			key = getenv(cfg.APIKeyEnv)
+		}
+		return &OpenAIEmbedder{URL: cfg.URL, ModelName: cfg.Model, APIKey: key}, nil
+	case "command":
+		if len(cfg.Command) == 0 {
+			return nil, keg.NewInvalidConfigError("search.embedder.command is required for the command embedder")
+		}
+		return &CommandEmbedder{Command: cfg.Command, ModelName: cfg.Model}, nil
+	default:
+		return nil, keg.NewInvalidConfigError(fmt.Sprintf("unknown embedder kind %q", cfg.Kind))
+	}
+}
@@ -0,0 +1,214 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jlrickert/cli-toolkit/toolkit"
+	"github.com/jlrickert/tapper/pkg/keg"
+	"github.com/stretchr/testify/require"
+)
+
+// keywordEmbedder scores each text by how often it mentions each keyword.
+type keywordEmbedder struct {
+	keywords []string
+	calls    int
+	inputs   int
+}
+
+func (e *keywordEmbedder) Model() string { return "keywords" }
+
+func (e *keywordEmbedder) Embed(_ context.Context, texts []string) ([][]float32, error) {
+	e.calls++
+	e.inputs += len(texts)
+	out := make([][]float32, len(texts))
+	for i, text := range texts {
+		vec := make([]float32, len(e.keywords))
+		for j, kw := range e.keywords {
+			vec[j] = float32(strings.Count(strings.ToLower(text), kw))
+		}
+		out[i] = vec
+	}
+	return out, nil
+}
+
+func TestCosine(t *testing.T) {
+	t.Parallel()
+
+	require.InDelta(t, 1.0, Cosine([]float32{1, 2}, []float32{2, 4}), 1e-9)
+	require.InDelta(t, 0.0, Cosine([]float32{1, 0}, []float32{0, 1}), 1e-9)
+	require.InDelta(t, -1.0, Cosine([]float32{1, 0}, []float32{-1, 0}), 1e-9)
+	require.Zero(t, Cosine([]float32{1}, []float32{1, 2}))
+	require.Zero(t, Cosine([]float32{0, 0}, []float32{1, 2}))
+}
+
+func TestStore_SyncEmbedsOnlyChangedDocumentsAndRoundTrips(t *testing.T) {
+	t.Parallel()
+	ctx := t.Context()
+
+	rt, err := toolkit.NewTestRuntime(t.TempDir(), "/home/testuser", "testuser")
+	require.NoError(t, err)
+	repo := keg.NewMemoryRepo(rt)
+	emb := &keywordEmbedder{keywords: []string{"go", "rust"}}
+
+	store, err := LoadStore(ctx, repo)
+	require.NoError(t, err)
+	require.Zero(t, store.Len())
+
+	docs := []Document{
+		{ID: "1", Text: "go go go"},
+		{ID: "2", Text: "rust"},
+		{ID: "3", Text: "go and rust"},
+	}
+	changed, err := store.Sync(ctx, emb, docs)
+	require.NoError(t, err)
+	require.True(t, changed)
+	require.Equal(t, 3, emb.inputs)
+	require.NoError(t, store.Save(ctx, repo))
+
+	reloaded, err := LoadStore(ctx, repo)
+	require.NoError(t, err)
+	require.Equal(t, 3, reloaded.Len())
+
+	// Unchanged documents are served from the cache.
+	changed, err = reloaded.Sync(ctx, emb, docs)
+	require.NoError(t, err)
+	require.False(t, changed)
+	require.Equal(t, 3, emb.inputs)
+
+	// Edited documents are re-embedded and removed ones are dropped.
+	changed, err = reloaded.Sync(ctx, emb, []Document{
+		{ID: "1", Text: "go go go"},
+		{ID: "2", Text: "now about go"},
+	})
+	require.NoError(t, err)
+	require.True(t, changed)
+	require.Equal(t, 4, emb.inputs)
+	require.Equal(t, 2, reloaded.Len())
+	_, ok := reloaded.Get("3")
+	require.False(t, ok)
+
+	hits := reloaded.Search([]float32{1, 0}, 1)
+	require.Len(t, hits, 1)
+	require.Equal(t, "1", hits[0].ID)
+}
+
+func TestOpenAIEmbedder_Embed(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/v1/embeddings", r.URL.Path)
+		require.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		var req openAIRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		require.Equal(t, "tiny", req.Model)
+
+		// Respond out of order to exercise index sorting.
+		type item struct {
+			Index     int       `json:"index"`
+			Embedding []float32 `json:"embedding"`
+		}
+		data := make([]item, 0, len(req.Input))
+		for i := len(req.Input) - 1; i >= 0; i-- {
+			data = append(data, item{Index: i, Embedding: []float32{float32(len(req.Input[i]))}})
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"data": data})
+	}))
+	defer srv.Close()
+
+	emb, err := NewEmbedder(&keg.EmbedderConfig{
+		Kind:      "openai",
+		URL:       srv.URL + "/v1/",
+		Model:     "tiny",
+		APIKeyEnv: "TEST_KEY",
+	}, func(key string) string {
+		if key == "TEST_KEY" {
+			return "secret"
+		}
+		return ""
+	})
+	require.NoError(t, err)
+
+	vecs, err := emb.Embed(t.Context(), []string{"a", "abc"})
+	require.NoError(t, err)
+	require.Equal(t, [][]float32{{1}, {3}}, vecs)
+}
+
+func TestOpenAIEmbedder_ErrorStatus(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "bad key", http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	emb := &OpenAIEmbedder{URL: srv.URL}
+	_, err := emb.Embed(t.Context(), []string{"a"})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "bad key")
+}
+
+func TestOpenAIEmbedder_TimesOut(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer srv.Close()
+
+	emb := &OpenAIEmbedder{URL: srv.URL, Timeout: 50 * time.Millisecond}
+	start := time.Now()
+	_, err := emb.Embed(t.Context(), []string{"a"})
+	require.Error(t, err)
+	require.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestNewEmbedder_RejectsInvalidConfig(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewEmbedder(nil, nil)
+	require.ErrorIs(t, err, keg.ErrNotSupported)
+
+	_, err = NewEmbedder(&keg.EmbedderConfig{Kind: "onnx"}, nil)
+	require.ErrorIs(t, err, keg.ErrInvalid)
+
+	_, err = NewEmbedder(&keg.EmbedderConfig{Kind: "openai"}, nil)
+	require.ErrorIs(t, err, keg.ErrInvalid)
+
+	_, err = NewEmbedder(&keg.EmbedderConfig{Kind: "command"}, nil)
+	require.ErrorIs(t, err, keg.ErrInvalid)
+}
+
+func TestScanRegex_ReportsLinesAndSpans(t *testing.T) {
+	t.Parallel()
+
+	re := regexp.MustCompile(`go+`)
+	matches, err := ScanRegex(re, strings.NewReader("# Title\r\ngo and goo\nnothing\nx*\n"))
+	require.NoError(t, err)
+	require.Equal(t, []LineMatch{
+		{Line: 2, Text: "go and goo", Spans: []Span{{Start: 0, End: 2}, {Start: 7, End: 10}}},
+	}, matches)
+
+	empty, err := ScanRegex(regexp.MustCompile(`z*`), strings.NewReader("abc\n"))
+	require.NoError(t, err)
+	require.Empty(t, empty)
+}
+
+func TestHighlight(t *testing.T) {
+	t.Parallel()
+
+	got := Highlight("go and goo", []Span{{0, 2}, {7, 10}}, "[", "]")
+	require.Equal(t, "[go] and [goo]", got)
+	require.Equal(t, "plain", Highlight("plain", nil, "[", "]"))
+}
@@ -0,0 +1,202 @@
+package search
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/jlrickert/tapper/pkg/keg"
+)
+
+// embedBatchSize bounds how many texts are sent to the embedder at once.
+const embedBatchSize = 64
+
+// Document is a unit of text to embed, keyed by node ID.
+type Document struct {
+	ID   string
+	Text string
+}
+
+// Vector is a cached embedding for a single document.
+type Vector struct {
+	ID     string    `json:"id"`
+	Hash   string    `json:"hash"`
+	Model  string    `json:"model"`
+	Vector []float32 `json:"vector"`
+}
+
+// Hit is a document ranked by similarity to a query.
+type Hit struct {
+	ID    string
+	Score float64
+}
+
+// Store is the in-memory form of the dex/vectors.jsonl sidecar.
+//
+// Concurrency note: Store does not perform internal synchronization.
+type Store struct {
+	vectors map[string]Vector
+}
+
+// LoadStore reads the vector sidecar from repo. A missing sidecar yields an
+// empty store.
+func LoadStore(ctx context.Context, repo keg.Repository) (*Store, error) {
+	s := &Store{vectors: map[string]Vector{}}
+	raw, err := repo.GetIndex(ctx, VectorsIndexName)
+	if err != nil {
+		if errors.Is(err, keg.ErrNotExist) {
+			return s, nil
+		}
+		return nil, fmt.Errorf("unable to read %s: %w", VectorsIndexName, err)
+	}
+	sc := bufio.NewScanner(bytes.NewReader(raw))
+	sc.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for sc.Scan() {
+		line := bytes.TrimSpace(sc.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var v Vector
+		if err := json.Unmarshal(line, &v); err != nil {
+			// A corrupt line only costs a re-embed; skip it.
+			continue
+		}
+		s.vectors[v.ID] = v
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("unable to read %s: %w", VectorsIndexName, err)
+	}
+	return s, nil
+}
+
+// Save writes the store back to the sidecar, one vector per line ordered by
+// ID.
+func (s *Store) Save(ctx context.Context, repo keg.Repository) error {
+	ids := make([]string, 0, len(s.vectors))
+	for id := range s.vectors {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, id := range ids {
+		if err := enc.Encode(s.vectors[id]); err != nil {
+			return err
+		}
+	}
+	if err := repo.WriteIndex(ctx, VectorsIndexName, buf.Bytes()); err != nil {
+		return fmt.Errorf("unable to write %s: %w", VectorsIndexName, err)
+	}
+	return nil
+}
+
+// Len returns the number of cached vectors.
+func (s *Store) Len() int { return len(s.vectors) }
+
+// Get returns the cached vector for id.
+func (s *Store) Get(id string) (Vector, bool) {
+	v, ok := s.vectors[id]
+	return v, ok
+}
+
+// Sync brings the store in line with docs: documents whose text or model
+// changed are embedded, and vectors for documents no longer present are
+// dropped. It reports whether the store changed.
+func (s *Store) Sync(ctx context.Context, emb Embedder, docs []Document) (bool, error) {
+	model := emb.Model()
+	changed := false
+
+	keep := make(map[string]struct{}, len(docs))
+	pending := make([]Document, 0)
+	hashes := make(map[string]string, len(docs))
+	for _, doc := range docs {
+		keep[doc.ID] = struct{}{}
+		h := hashText(doc.Text)
+		hashes[doc.ID] = h
+		if v, ok := s.vectors[doc.ID]; ok && v.Hash == h && v.Model == model {
+			continue
+		}
+		pending = append(pending, doc)
+	}
+	for id := range s.vectors {
+		if _, ok := keep[id]; !ok {
+			delete(s.vectors, id)
+			changed = true
+		}
+	}
+
+	for start := 0; start < len(pending); start += embedBatchSize {
+		if err := ctx.Err(); err != nil {
+			return changed, err
+		}
+		end := min(start+embedBatchSize, len(pending))
+		batch := pending[start:end]
+		texts := make([]string, len(batch))
+		for i, doc := range batch {
+			texts[i] = doc.Text
+		}
+		vecs, err := emb.Embed(ctx, texts)
+		if err != nil {
+			return changed, fmt.Errorf("unable to embed nodes: %w", err)
+		}
+		if len(vecs) != len(batch) {
+			return changed, fmt.Errorf("embedder returned %d vectors for %d inputs", len(vecs), len(batch))
+		}
+		for i, doc := range batch {
+			s.vectors[doc.ID] = Vector{ID: doc.ID, Hash: hashes[doc.ID], Model: model, Vector: vecs[i]}
+			changed = true
+		}
+	}
+	return changed, nil
+}
+
+// Search ranks every cached vector against query by cosine similarity, best
+// first. Ties are ordered by ID. Limit <= 0 returns every hit.
+func (s *Store) Search(query []float32, limit int) []Hit {
+	hits := make([]Hit, 0, len(s.vectors))
+	for id, v := range s.vectors {
+		hits = append(hits, Hit{ID: id, Score: Cosine(query, v.Vector)})
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].ID < hits[j].ID
+	})
+	if limit > 0 && len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits
+}
+
+// Cosine returns the cosine similarity of a and b. Vectors of different
+// length or zero magnitude score 0.
+func Cosine(a, b []float32) float64 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		x, y := float64(a[i]), float64(b[i])
+		dot += x * y
+		na += x * x
+		nb += y * y
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
+
+func hashText(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])
+}
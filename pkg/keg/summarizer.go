@@ -0,0 +1,361 @@
+package keg
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os/exec"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// DefaultSummarySentences is how many sentences the built-in summarizer
+// keeps when the keg config does not say.
+const DefaultSummarySentences = 2
+
+// DefaultSummarizeTimeout bounds a summarizer command or API request when the
+// summarizer does not set its own timeout. Summarizers run inside node
+// writes, so a hung one must not hold the keg.
+const DefaultSummarizeTimeout = 30 * time.Second
+
+// summarizeContext bounds ctx by timeout, or DefaultSummarizeTimeout when it
+// is zero.
+func summarizeContext(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		timeout = DefaultSummarizeTimeout
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// Summarizer writes the lead of a node, the one-line summary stored in its
+// stats and listed in dex/leads.tsv. It is called when a node's content
+// changes.
+type Summarizer interface {
+	Summarize(ctx context.Context, content *NodeContent) (string, error)
+}
+
+// WithSummarizer makes the keg generate leads with s instead of the
+// summarizer configured in the keg config.
+func WithSummarizer(s Summarizer) Option {
+	return func(k *Keg) {
+		k.Summarizer = s
+	}
+}
+
+// NewSummarizer builds the Summarizer described by cfg. getenv resolves
+// cfg.APIKeyEnv so callers can supply a sandboxed environment. A nil cfg
+// returns nil: leads are then the first paragraph after the title.
+func NewSummarizer(cfg *SummarizerConfig, getenv func(string) string) (Summarizer, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+	switch strings.ToLower(strings.TrimSpace(cfg.Kind)) {
+	case "", "sentences":
+		if cfg.Sentences < 0 {
+			return nil, NewInvalidConfigError("summarizer.sentences must not be negative")
+		}
+		return &SentenceSummarizer{Sentences: cfg.Sentences}, nil
+	case "command":
+		if len(cfg.Command) == 0 {
+			return nil, NewInvalidConfigError("summarizer.command is required for the command summarizer")
+		}
+		return &CommandSummarizer{Command: cfg.Command}, nil
+	case "openai":
+		if strings.TrimSpace(cfg.URL) == "" {
+			return nil, NewInvalidConfigError("summarizer.url is required for the openai summarizer")
+		}
+		key := ""
+		if cfg.APIKeyEnv != "" && getenv != nil {
+			key = getenv(cfg.APIKeyEnv)
+		}
+		return &OpenAISummarizer{URL: cfg.URL, Model: cfg.Model, APIKey: key}, nil
+	default:
+		return nil, NewInvalidConfigError(fmt.Sprintf("unknown summarizer kind %q", cfg.Kind))
+	}
+}
+
+// SentenceSummarizer uses the first Sentences sentences of the text after
+// the title. Headings, code blocks, tables and HTML are skipped.
+type SentenceSummarizer struct {
+	// Sentences is how many sentences to keep. Zero means
+	// DefaultSummarySentences.
+	Sentences int
+}
+
+// Summarize implements Summarizer.
+func (s *SentenceSummarizer) Summarize(ctx context.Context, content *NodeContent) (string, error) {
+	_ = ctx
+	if content == nil {
+		return "", nil
+	}
+	n := s.Sentences
+	if n <= 0 {
+		n = DefaultSummarySentences
+	}
+	return firstSentences(summaryText(content.Body), n), nil
+}
+
+// summaryText returns the prose of a Markdown body as one line: headings,
+// fenced code, tables, HTML and list markers are dropped.
+func summaryText(body string) string {
+	var words []string
+	inFence := false
+	for _, line := range strings.Split(body, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+			continue
+		}
+		if inFence || trimmed == "" ||
+			strings.HasPrefix(trimmed, "#") ||
+			strings.HasPrefix(trimmed, "|") ||
+			strings.HasPrefix(trimmed, "<") ||
+			strings.HasPrefix(trimmed, "===") ||
+			strings.HasPrefix(trimmed, "---") {
+			continue
+		}
+		trimmed = strings.TrimLeft(trimmed, ">*-+ ")
+		words = append(words, strings.Fields(trimmed)...)
+	}
+	return strings.Join(words, " ")
+}
+
+// firstSentences returns the first n sentences of text. A sentence ends at
+// '.', '!' or '?' followed by a space or the end of the text.
+func firstSentences(text string, n int) string {
+	runes := []rune(text)
+	count := 0
+	for i, r := range runes {
+		if r != '.' && r != '!' && r != '?' {
+			continue
+		}
+		if i+1 < len(runes) && !unicode.IsSpace(runes[i+1]) {
+			continue
+		}
+		count++
+		if count == n {
+			return string(runes[:i+1])
+		}
+	}
+	return text
+}
+
+// CommandSummarizer delegates summarizing to an external process, which is
+// how local models and scripts plug in. The process receives
+// {"title": ..., "content": ...} as JSON on stdin and prints the lead on
+// stdout.
+type CommandSummarizer struct {
+	Command []string
+
+	// Timeout bounds each run. Zero means DefaultSummarizeTimeout.
+	Timeout time.Duration
+}
+
+type summaryRequest struct {
+	Title   string `json:"title"`
+	Content string `json:"content"`
+}
+
+// Summarize implements Summarizer.
+func (s *CommandSummarizer) Summarize(ctx context.Context, content *NodeContent) (string, error) {
+	if content == nil {
+		return "", nil
+	}
+	if len(s.Command) == 0 {
+		return "", fmt.Errorf("summarizer command is empty")
+	}
+	in, err := json.Marshal(summaryRequest{Title: content.Title, Content: content.Body})
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := summarizeContext(ctx, s.Timeout)
+	defer cancel()
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, s.Command[0], s.Command[1:]...)
+	cmd.Stdin = bytes.NewReader(in)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	// Children left holding stdout must not keep the write waiting either.
+	cmd.WaitDelay = time.Second
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("summarizer command failed: %w: %s", err, msg)
+		}
+		return "", fmt.Errorf("summarizer command failed: %w", err)
+	}
+	return stdout.String(), nil
+}
+
+// summaryPrompt is the system prompt sent to chat summarizers.
+const summaryPrompt = "Summarize the note in one or two plain sentences for use as its lead. " +
+	"Reply with the summary only."
+
+// OpenAISummarizer calls an OpenAI-compatible /chat/completions endpoint.
+// Any server implementing the same request and response shape (Ollama, LM
+// Studio, vLLM, ...) works.
+type OpenAISummarizer struct {
+	// URL is the API base, e.g. "https://api.openai.com/v1".
+	URL    string
+	Model  string
+	APIKey string
+
+	// Client is the HTTP client to use. Nil uses http.DefaultClient.
+	Client *http.Client
+
+	// Timeout bounds each request. Zero means DefaultSummarizeTimeout.
+	Timeout time.Duration
+}
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type chatRequest struct {
+	Model    string        `json:"model,omitempty"`
+	Messages []chatMessage `json:"messages"`
+}
+
+type chatResponse struct {
+	Choices []struct {
+		Message chatMessage `json:"message"`
+	} `json:"choices"`
+}
+
+// Summarize implements Summarizer.
+func (s *OpenAISummarizer) Summarize(ctx context.Context, content *NodeContent) (string, error) {
+	if content == nil {
+		return "", nil
+	}
+	body, err := json.Marshal(chatRequest{
+		Model: s.Model,
+		Messages: []chatMessage{
+			{Role: "system", Content: summaryPrompt},
+			{Role: "user", Content: content.Body},
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+	ctx, cancel := summarizeContext(ctx, s.Timeout)
+	defer cancel()
+	url := strings.TrimRight(s.URL, "/") + "/chat/completions"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("unable to build summary request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+s.APIKey)
+	}
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", NewBackendError("openai", "Summarize", 0, err, true)
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", NewBackendError("openai", "Summarize", resp.StatusCode, err, true)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		cause := fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(raw)))
+		return "", NewBackendError("openai", "Summarize", resp.StatusCode, cause, resp.StatusCode >= 500)
+	}
+
+	var out chatResponse
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return "", fmt.Errorf("unable to decode summary response: %w", err)
+	}
+	if len(out.Choices) == 0 {
+		return "", fmt.Errorf("summary response has no choices")
+	}
+	return out.Choices[0].Message.Content, nil
+}
+
+// summarizer returns the keg's Summarizer, or nil when leads are the first
+// paragraph after the title. An invalid summarizer config is logged and
+// ignored so writes do not fail over it, and a command or API summarizer is
+// ignored until the user trusts the keg config.
+func (k *Keg) summarizer(ctx context.Context) Summarizer {
+	if k.Summarizer != nil {
+		return k.Summarizer
+	}
+	cfg, err := k.Repo.ReadConfig(ctx)
+	if err != nil || cfg == nil || cfg.Summarizer == nil {
+		return nil
+	}
+	if !cfg.Summarizer.builtin() && !k.TrustsConfig(cfg) {
+		return nil
+	}
+	s, err := NewSummarizer(cfg.Summarizer, k.Runtime.Get)
+	if err != nil {
+		k.Runtime.Logger().Warn("ignoring summarizer config", "error", err)
+		return nil
+	}
+	return s
+}
+
+// nodeSummarizer returns the summarizer for data. Only the built-in
+// summarizer sees the content of nodes with the encryption tag, so it is
+// never sent to an external command or API.
+func (k *Keg) nodeSummarizer(ctx context.Context, data *NodeData) Summarizer {
+	s := k.summarizer(ctx)
+	if s == nil {
+		return nil
+	}
+	if _, local := s.(*SentenceSummarizer); !local {
+		if tag := k.EncryptionTag(ctx); tag != "" && slices.Contains(data.Tags(), tag) {
+			return nil
+		}
+	}
+	return s
+}
+
+// updateNodeMeta refreshes data from its content like NodeData.UpdateMeta and
+// then sets its lead with the keg's summarizer. The summarizer only runs
+// when the content changed or force is set; otherwise the lead recorded
+// before is kept. Nodes marked for encryption get no lead.
+func (k *Keg) updateNodeMeta(ctx context.Context, data *NodeData, now *time.Time, force bool) error {
+	changed := force || data.ContentChanged()
+	prevLead := data.Stats.Lead()
+	err := data.UpdateMeta(ctx, now)
+	applySummary(ctx, k.Runtime.Logger(), k.nodeSummarizer(ctx, data), data, prevLead, changed)
+	k.redactLead(ctx, data)
+	return err
+}
+
+// applySummary sets the lead of data with s. With unchanged content the
+// previous lead is restored instead of summarizing again. A failing
+// summarizer is logged and the first paragraph is kept.
+func applySummary(ctx context.Context, log *slog.Logger, s Summarizer, data *NodeData, prevLead string, changed bool) {
+	if s == nil || data == nil || data.Content == nil || data.Stats == nil {
+		return
+	}
+	if !changed {
+		if prevLead != "" {
+			data.Stats.SetLead(prevLead)
+		}
+		return
+	}
+	lead, err := s.Summarize(ctx, data.Content)
+	if err != nil {
+		log.Warn("unable to summarize node", "node", data.ID.Path(), "error", err)
+		return
+	}
+	if lead = strings.Join(strings.Fields(lead), " "); lead != "" {
+		data.Stats.SetLead(lead)
+	}
+}
@@ -0,0 +1,141 @@
+package keg_test
+
+import (
+	"context"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	kegpkg "github.com/jlrickert/tapper/pkg/keg"
+	"github.com/stretchr/testify/require"
+)
+
+type countingSummarizer struct {
+	calls int
+}
+
+func (s *countingSummarizer) Summarize(ctx context.Context, content *kegpkg.NodeContent) (string, error) {
+	s.calls++
+	return "Summary of " + content.Title + ".", nil
+}
+
+func TestSummarizerWritesLeads(t *testing.T) {
+	t.Parallel()
+	f := NewSandbox(t)
+	ctx := f.Context()
+
+	repo := kegpkg.NewMemoryRepo(f.Runtime())
+	require.NoError(t, kegpkg.NewKeg(repo, f.Runtime()).Init(ctx))
+	require.NoError(t, kegpkg.NewKeg(repo, f.Runtime()).UpdateConfig(ctx, func(cfg *kegpkg.Config) {
+		cfg.Summarizer = &kegpkg.SummarizerConfig{Sentences: 1}
+	}))
+	// Reopen so the dex registers the leads index from the config.
+	k := kegpkg.NewKeg(repo, f.Runtime())
+
+	id, err := k.Create(ctx, &kegpkg.CreateOptions{
+		Body: []byte("# Tips\n\nKeep interfaces small. Accept interfaces.\n\n```go\nfunc f() {}\n```\n"),
+	})
+	require.NoError(t, err)
+	stats, err := repo.ReadStats(ctx, id)
+	require.NoError(t, err)
+	require.Equal(t, "Keep interfaces small.", stats.Lead())
+
+	leads, err := repo.GetIndex(ctx, kegpkg.LeadsIndexName)
+	require.NoError(t, err)
+	require.Contains(t, string(leads), "1\tKeep interfaces small.\n")
+
+	other, err := k.Create(ctx, &kegpkg.CreateOptions{Body: []byte("# Notes\n\n- Lists count. As prose!\n")})
+	require.NoError(t, err)
+	leads, err = repo.GetIndex(ctx, kegpkg.LeadsIndexName)
+	require.NoError(t, err)
+	require.Contains(t, string(leads), "1\tKeep interfaces small.\n", "earlier leads survive an incremental update")
+	require.Contains(t, string(leads), other.Path()+"\tLists count.\n")
+}
+
+func TestSummarizerRunsOnContentChange(t *testing.T) {
+	t.Parallel()
+	f := NewSandbox(t)
+	ctx := f.Context()
+
+	s := &countingSummarizer{}
+	repo := kegpkg.NewMemoryRepo(f.Runtime())
+	k := kegpkg.NewKeg(repo, f.Runtime(), kegpkg.WithSummarizer(s))
+	require.NoError(t, k.Init(ctx))
+
+	id, err := k.Create(ctx, &kegpkg.CreateOptions{Body: []byte("# Draft\n\nFirst take.\n")})
+	require.NoError(t, err)
+	require.Equal(t, 1, s.calls)
+
+	require.NoError(t, k.SetContent(ctx, id, []byte("# Final\n\nSecond take.\n")))
+	require.Equal(t, 2, s.calls)
+	stats, err := repo.ReadStats(ctx, id)
+	require.NoError(t, err)
+	require.Equal(t, "Summary of Final.", stats.Lead())
+
+	// Unchanged content keeps the generated lead without summarizing again.
+	require.NoError(t, k.IndexNode(ctx, id))
+	require.NoError(t, k.Index(ctx, kegpkg.IndexOptions{}))
+	require.Equal(t, 2, s.calls)
+	stats, err = repo.ReadStats(ctx, id)
+	require.NoError(t, err)
+	require.Equal(t, "Summary of Final.", stats.Lead())
+
+	// Content edited behind the keg's back is summarized by IndexNode.
+	require.NoError(t, repo.WriteContent(ctx, id, []byte("# Edited\n\nThird take.\n")))
+	require.NoError(t, k.IndexNode(ctx, id))
+	require.Equal(t, 3, s.calls)
+	stats, err = repo.ReadStats(ctx, id)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(stats.Lead(), "Summary of Edited"))
+}
+
+func TestCommandSummarizerNeedsTrust(t *testing.T) {
+	t.Parallel()
+	if runtime.GOOS == "windows" {
+		t.Skip("summarizer command runs sh")
+	}
+	f := NewSandbox(t)
+	ctx := f.Context()
+
+	repo := kegpkg.NewMemoryRepo(f.Runtime())
+	require.NoError(t, kegpkg.NewKeg(repo, f.Runtime()).Init(ctx))
+	require.NoError(t, kegpkg.NewKeg(repo, f.Runtime()).UpdateConfig(ctx, func(cfg *kegpkg.Config) {
+		cfg.Summarizer = &kegpkg.SummarizerConfig{
+			Kind:    "command",
+			Command: []string{"sh", "-c", "echo Generated lead."},
+		}
+	}))
+	k := kegpkg.NewKeg(repo, f.Runtime())
+	body := []byte("# Tips\n\nKeep interfaces small.\n")
+
+	id, err := k.Create(ctx, &kegpkg.CreateOptions{Body: body})
+	require.NoError(t, err)
+	stats, err := repo.ReadStats(ctx, id)
+	require.NoError(t, err)
+	require.Equal(t, "Keep interfaces small.", stats.Lead(), "untrusted command is not run")
+
+	cfg, err := k.Config(ctx)
+	require.NoError(t, err)
+	k.TrustedConfig = cfg.TrustDigest()
+	id, err = k.Create(ctx, &kegpkg.CreateOptions{Body: body})
+	require.NoError(t, err)
+	stats, err = repo.ReadStats(ctx, id)
+	require.NoError(t, err)
+	require.Equal(t, "Generated lead.", stats.Lead())
+}
+
+func TestCommandSummarizerTimesOut(t *testing.T) {
+	t.Parallel()
+	if runtime.GOOS == "windows" {
+		t.Skip("summarizer command runs sh")
+	}
+	s := &kegpkg.CommandSummarizer{
+		Command: []string{"sh", "-c", "sleep 10"},
+		Timeout: 50 * time.Millisecond,
+	}
+	start := time.Now()
+	_, err := s.Summarize(context.Background(), &kegpkg.NodeContent{Title: "Slow", Body: "# Slow\n"})
+	require.Error(t, err)
+	require.Less(t, time.Since(start), 5*time.Second)
+}
@@ -4,11 +4,15 @@ import (
 	"sort"
 	"strings"
 	"unicode"
+
+	"golang.org/x/text/unicode/norm"
 )
 
 // NormalizeTag normalizeTag lowercases, trims, and tokenizes a tag string into a hyphen-separated token.
+// Input is first converted to Unicode NFC so a tag typed on macOS (NFD) and
+// on Linux (NFC) normalizes to the same token.
 func NormalizeTag(s string) string {
-	s = strings.TrimSpace(s)
+	s = strings.TrimSpace(norm.NFC.String(s))
 	if s == "" {
 		return ""
 	}
@@ -57,3 +57,10 @@ func TestParseTags(t *testing.T) {
 		})
 	}
 }
+
+func TestNormalizeTag_UnicodeForms(t *testing.T) {
+	t.Parallel()
+	// "é" typed on macOS arrives decomposed (NFD); Linux keeps it composed.
+	require.Equal(t, keg.NormalizeTag("caf\u00e9 notes"), keg.NormalizeTag("cafe\u0301 notes"))
+	require.Equal(t, keg.NormalizeTags([]string{"caf\u00e9"}), keg.NormalizeTags([]string{"cafe\u0301"}))
+}
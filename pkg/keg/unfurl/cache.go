@@ -0,0 +1,180 @@
+package unfurl
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jlrickert/tapper/pkg/keg"
+)
+
+// CacheIndexName is the dex artifact holding cached previews, one JSON
+// object per line ordered by URL.
+const CacheIndexName = "unfurl.jsonl"
+
+// Cache is the in-memory form of the dex/unfurl.jsonl sidecar.
+//
+// Concurrency note: Cache does not perform internal synchronization.
+type Cache struct {
+	previews map[string]Preview
+}
+
+// RefreshResult counts what Refresh did.
+type RefreshResult struct {
+	// Fetched is the number of URLs fetched because they were missing or
+	// stale.
+	Fetched int
+	// Failed and Blocked count the fetched URLs that errored or that
+	// robots.txt disallowed.
+	Failed  int
+	Blocked int
+}
+
+// LoadCache reads the preview sidecar from repo. A missing sidecar yields an
+// empty cache.
+func LoadCache(ctx context.Context, repo keg.Repository) (*Cache, error) {
+	c := &Cache{previews: map[string]Preview{}}
+	raw, err := repo.GetIndex(ctx, CacheIndexName)
+	if err != nil {
+		if errors.Is(err, keg.ErrNotExist) {
+			return c, nil
+		}
+		return nil, fmt.Errorf("unable to read %s: %w", CacheIndexName, err)
+	}
+	sc := bufio.NewScanner(bytes.NewReader(raw))
+	sc.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for sc.Scan() {
+		line := bytes.TrimSpace(sc.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var p Preview
+		if err := json.Unmarshal(line, &p); err != nil || p.URL == "" {
+			// A corrupt line only costs a refetch; skip it.
+			continue
+		}
+		c.previews[p.URL] = p
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("unable to read %s: %w", CacheIndexName, err)
+	}
+	return c, nil
+}
+
+// Save writes the cache back to the sidecar.
+func (c *Cache) Save(ctx context.Context, repo keg.Repository) error {
+	urls := make([]string, 0, len(c.previews))
+	for u := range c.previews {
+		urls = append(urls, u)
+	}
+	sort.Strings(urls)
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	for _, u := range urls {
+		if err := enc.Encode(c.previews[u]); err != nil {
+			return err
+		}
+	}
+	if err := repo.WriteIndex(ctx, CacheIndexName, buf.Bytes()); err != nil {
+		return fmt.Errorf("unable to write %s: %w", CacheIndexName, err)
+	}
+	return nil
+}
+
+// Len returns the number of cached previews.
+func (c *Cache) Len() int { return len(c.previews) }
+
+// Get returns the cached preview of rawURL.
+func (c *Cache) Get(rawURL string) (Preview, bool) {
+	p, ok := c.previews[rawURL]
+	return p, ok
+}
+
+// Refresh fetches the previews of urls that are missing from the cache or
+// older than ttl. With force every URL is fetched again. Transport failures
+// are counted but not cached, so the URL is tried again on the next
+// refresh.
+func (c *Cache) Refresh(ctx context.Context, f *Fetcher, urls []string, now time.Time, ttl time.Duration, force bool) (RefreshResult, error) {
+	var res RefreshResult
+	for _, u := range urls {
+		if err := ctx.Err(); err != nil {
+			return res, err
+		}
+		if p, ok := c.previews[u]; ok && !force && p.Fresh(now, ttl) {
+			continue
+		}
+		p := f.Fetch(ctx, u)
+		res.Fetched++
+		if p.transient {
+			res.Failed++
+			continue
+		}
+		p.Fetched = now
+		c.previews[u] = p
+		switch {
+		case p.Blocked:
+			res.Blocked++
+		case p.Error != "":
+			res.Failed++
+		}
+	}
+	return res, nil
+}
+
+// Prune drops previews of URLs not in keep and reports how many were
+// dropped.
+func (c *Cache) Prune(keep []string) int {
+	set := make(map[string]struct{}, len(keep))
+	for _, u := range keep {
+		set[u] = struct{}{}
+	}
+	n := 0
+	for u := range c.previews {
+		if _, ok := set[u]; !ok {
+			delete(c.previews, u)
+			n++
+		}
+	}
+	return n
+}
+
+var (
+	externalURLRe = regexp.MustCompile(`https?://[^\s<>"'` + "`" + `\[\]()]+(?:\([^\s<>"'()]*\)[^\s<>"'` + "`" + `\[\]()]*)*`)
+	fenceRe       = regexp.MustCompile("(?m)^[ \t]*(```|~~~)")
+)
+
+// ExternalURLs returns the distinct http(s) URLs in Markdown content, in the
+// order they first appear. URLs inside fenced code blocks are ignored and
+// trailing punctuation is trimmed.
+func ExternalURLs(content []byte) []string {
+	var urls []string
+	seen := map[string]struct{}{}
+	inFence := false
+	for line := range strings.Lines(string(content)) {
+		if fenceRe.MatchString(line) {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+		for _, u := range externalURLRe.FindAllString(line, -1) {
+			u = strings.TrimRight(u, ".,;:!?*_~")
+			if _, ok := seen[u]; ok || len(u) <= len("https://") {
+				continue
+			}
+			seen[u] = struct{}{}
+			urls = append(urls, u)
+		}
+	}
+	return urls
+}
@@ -0,0 +1,134 @@
+package unfurl
+
+import (
+	"strings"
+)
+
+// robotsRules are the Allow and Disallow lines of the robots.txt group that
+// applies to tapper.
+type robotsRules struct {
+	disallowAll bool
+	allow       []string
+	disallow    []string
+
+	// err is set when robots.txt could not be fetched.
+	err error
+}
+
+// parseRobots picks the group of a robots.txt naming agent, or the "*"
+// group when none does, and returns its rules.
+func parseRobots(data, agent string) *robotsRules {
+	agent = strings.ToLower(agent)
+	type group struct {
+		agents []string
+		rules  robotsRules
+	}
+	var groups []*group
+	var cur *group
+	inAgents := false
+	for line := range strings.Lines(data) {
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		key, val, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		val = strings.TrimSpace(val)
+		switch key {
+		case "user-agent":
+			if !inAgents {
+				cur = &group{}
+				groups = append(groups, cur)
+				inAgents = true
+			}
+			cur.agents = append(cur.agents, strings.ToLower(val))
+		case "allow", "disallow":
+			inAgents = false
+			if cur == nil || val == "" {
+				continue
+			}
+			if key == "allow" {
+				cur.rules.allow = append(cur.rules.allow, val)
+			} else {
+				cur.rules.disallow = append(cur.rules.disallow, val)
+			}
+		default:
+			inAgents = false
+		}
+	}
+
+	var wildcard *robotsRules
+	for _, g := range groups {
+		for _, a := range g.agents {
+			if a == agent {
+				return &g.rules
+			}
+			if a == "*" && wildcard == nil {
+				wildcard = &g.rules
+			}
+		}
+	}
+	if wildcard != nil {
+		return wildcard
+	}
+	return &robotsRules{}
+}
+
+// allows reports whether path may be fetched. The longest matching rule
+// wins and Allow wins ties.
+func (r *robotsRules) allows(path string) bool {
+	if r == nil {
+		return true
+	}
+	if r.disallowAll {
+		return false
+	}
+	best, allowed := -1, true
+	for _, p := range r.disallow {
+		if n := matchRobots(p, path); n > best {
+			best, allowed = n, false
+		}
+	}
+	for _, p := range r.allow {
+		if n := matchRobots(p, path); n >= best && n >= 0 {
+			best, allowed = n, true
+		}
+	}
+	return allowed
+}
+
+// matchRobots returns the length of pattern when it matches path, or -1.
+// Patterns may use '*' for any run of characters and a trailing '$' to
+// anchor the end.
+func matchRobots(pattern, path string) int {
+	body, anchored := strings.CutSuffix(pattern, "$")
+	parts := strings.Split(body, "*")
+	if anchored {
+		last := parts[len(parts)-1]
+		if !strings.HasSuffix(path, last) {
+			return -1
+		}
+		if len(parts) == 1 {
+			if path != last {
+				return -1
+			}
+			return len(pattern)
+		}
+		path = path[:len(path)-len(last)]
+		parts = parts[:len(parts)-1]
+	}
+	if !strings.HasPrefix(path, parts[0]) {
+		return -1
+	}
+	rest := path[len(parts[0]):]
+	for _, part := range parts[1:] {
+		j := strings.Index(rest, part)
+		if j < 0 {
+			return -1
+		}
+		rest = rest[j+len(part):]
+	}
+	return len(pattern)
+}
@@ -0,0 +1,284 @@
+// Package unfurl fetches link previews, the title, description and favicon
+// of external pages linked from node content, and caches them in a dex
+// sidecar so rich previews can be rendered without repeated network calls.
+package unfurl
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+// DefaultTTL is how long a cached preview is used before it is fetched
+// again when the keg config does not say.
+const DefaultTTL = 30 * 24 * time.Hour
+
+// UserAgent identifies tapper to the sites it fetches and is the agent
+// name matched against robots.txt groups.
+const UserAgent = "tapper"
+
+// defaultMaxSize bounds how much of a page is read looking for its head.
+const defaultMaxSize int64 = 1 << 20
+
+// defaultClient is used by Fetchers without a Client so a stalled host
+// cannot hang a refresh.
+var defaultClient = &http.Client{Timeout: 30 * time.Second}
+
+// Preview is the cached metadata of one external URL.
+type Preview struct {
+	URL         string    `json:"url"`
+	Title       string    `json:"title,omitempty"`
+	Description string    `json:"description,omitempty"`
+	Favicon     string    `json:"favicon,omitempty"`
+	Fetched     time.Time `json:"fetched"`
+
+	// Blocked is set when robots.txt disallows fetching the URL.
+	Blocked bool `json:"blocked,omitempty"`
+
+	// Error records why the last fetch failed. Failures are cached too so
+	// broken pages are not retried before the TTL expires, except transport
+	// failures, such as being offline, which are retried on the next
+	// refresh.
+	Error string `json:"error,omitempty"`
+
+	// transient marks an Error caused by a transport failure.
+	transient bool
+}
+
+// Fresh reports whether p was fetched less than ttl before now.
+func (p Preview) Fresh(now time.Time, ttl time.Duration) bool {
+	return !p.Fetched.IsZero() && now.Sub(p.Fetched) < ttl
+}
+
+// Fetcher downloads pages and extracts their previews, honoring robots.txt.
+// The robots.txt of each host is fetched once per Fetcher.
+type Fetcher struct {
+	// Client is the HTTP client to use. Nil uses a client that gives up
+	// after 30 seconds.
+	Client *http.Client
+
+	// MaxSize limits how many bytes of a page are read. Zero means 1 MiB.
+	MaxSize int64
+
+	mu     sync.Mutex
+	robots map[string]*robotsRules
+}
+
+// Fetch returns the preview of rawURL. Errors and robots.txt refusals are
+// recorded in the preview rather than returned; Fetched is left for the
+// caller to set.
+func (f *Fetcher) Fetch(ctx context.Context, rawURL string) Preview {
+	p := Preview{URL: rawURL}
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		p.Error = "not an absolute http(s) URL"
+		return p
+	}
+	allowed, err := f.allowed(ctx, u)
+	if err != nil {
+		p.Error = err.Error()
+		p.transient = true
+		return p
+	}
+	if !allowed {
+		p.Blocked = true
+		return p
+	}
+
+	resp, err := f.get(ctx, u.String())
+	if err != nil {
+		p.Error = err.Error()
+		p.transient = true
+		return p
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		p.Error = resp.Status
+		return p
+	}
+
+	base := resp.Request.URL
+	p.Favicon = base.ResolveReference(&url.URL{Path: "/favicon.ico"}).String()
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return p
+	}
+	maxSize := f.MaxSize
+	if maxSize <= 0 {
+		maxSize = defaultMaxSize
+	}
+	parseHead(io.LimitReader(resp.Body, maxSize), base, &p)
+	return p
+}
+
+func (f *Fetcher) get(ctx context.Context, rawURL string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", UserAgent)
+	client := f.Client
+	if client == nil {
+		client = defaultClient
+	}
+	return client.Do(req)
+}
+
+// allowed reports whether robots.txt of u's host lets UserAgent fetch u. It
+// returns an error when robots.txt could not be fetched at all.
+func (f *Fetcher) allowed(ctx context.Context, u *url.URL) (bool, error) {
+	origin := u.Scheme + "://" + u.Host
+	f.mu.Lock()
+	rules, ok := f.robots[origin]
+	f.mu.Unlock()
+	if !ok {
+		rules = f.fetchRobots(ctx, origin)
+		f.mu.Lock()
+		if f.robots == nil {
+			f.robots = map[string]*robotsRules{}
+		}
+		f.robots[origin] = rules
+		f.mu.Unlock()
+	}
+	if rules.err != nil {
+		return false, rules.err
+	}
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+	return rules.allows(path), nil
+}
+
+// fetchRobots reads robots.txt of origin. A missing file allows everything;
+// a server error disallows everything, as RFC 9309 asks. When the host
+// cannot be reached the rules carry the error so the URL is reported as
+// failed rather than blocked.
+func (f *Fetcher) fetchRobots(ctx context.Context, origin string) *robotsRules {
+	resp, err := f.get(ctx, origin+"/robots.txt")
+	if err != nil {
+		return &robotsRules{disallowAll: true, err: fmt.Errorf("unable to fetch robots.txt: %w", err)}
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode >= 500:
+		return &robotsRules{disallowAll: true}
+	case resp.StatusCode >= 400:
+		return &robotsRules{}
+	case resp.StatusCode >= 300:
+		return &robotsRules{disallowAll: true}
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 500<<10))
+	if err != nil {
+		return &robotsRules{disallowAll: true, err: fmt.Errorf("unable to read robots.txt: %w", err)}
+	}
+	return parseRobots(string(data), UserAgent)
+}
+
+// parseHead fills in p from the <head> of an HTML page: the <title> or
+// og:title, the description or og:description meta tag, and the first icon
+// link.
+func parseHead(r io.Reader, base *url.URL, p *Preview) {
+	var title, ogTitle, desc, ogDesc, icon string
+	z := html.NewTokenizer(r)
+	inTitle := false
+loop:
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			break loop
+		case html.TextToken:
+			if inTitle {
+				title += string(z.Text())
+			}
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			switch string(name) {
+			case "title":
+				inTitle = false
+			case "head":
+				break loop
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			attrs := map[string]string{}
+			for hasAttr {
+				var k, v []byte
+				k, v, hasAttr = z.TagAttr()
+				attrs[string(k)] = string(v)
+			}
+			switch string(name) {
+			case "title":
+				inTitle = true
+			case "body":
+				break loop
+			case "meta":
+				key := strings.ToLower(attrs["name"])
+				if key == "" {
+					key = strings.ToLower(attrs["property"])
+				}
+				switch key {
+				case "description":
+					desc = attrs["content"]
+				case "og:description":
+					ogDesc = attrs["content"]
+				case "og:title":
+					ogTitle = attrs["content"]
+				}
+			case "link":
+				if icon != "" || attrs["href"] == "" {
+					continue
+				}
+				for rel := range strings.FieldsSeq(strings.ToLower(attrs["rel"])) {
+					if rel == "icon" {
+						icon = attrs["href"]
+						break
+					}
+				}
+			}
+		}
+	}
+
+	p.Title = clean(firstNonEmpty(ogTitle, title))
+	p.Description = clean(firstNonEmpty(desc, ogDesc))
+	if icon != "" {
+		if ref, err := url.Parse(icon); err == nil {
+			p.Favicon = base.ResolveReference(ref).String()
+		}
+	}
+}
+
+func firstNonEmpty(vals ...string) string {
+	for _, v := range vals {
+		if strings.TrimSpace(v) != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func clean(s string) string {
+	return strings.Join(strings.Fields(html.UnescapeString(s)), " ")
+}
+
+// String renders p as "Title — Description", falling back to the URL.
+func (p Preview) String() string {
+	switch {
+	case p.Title != "" && p.Description != "":
+		return fmt.Sprintf("%s — %s", p.Title, p.Description)
+	case p.Title != "":
+		return p.Title
+	default:
+		return p.URL
+	}
+}
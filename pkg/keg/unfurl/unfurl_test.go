@@ -0,0 +1,160 @@
+package unfurl
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jlrickert/cli-toolkit/toolkit"
+	"github.com/jlrickert/tapper/pkg/keg"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExternalURLs(t *testing.T) {
+	t.Parallel()
+	content := []byte("# Links\n\n" +
+		"See [Go](https://go.dev/doc/) and https://example.com/a.\n" +
+		"Again https://go.dev/doc/ and (https://en.wikipedia.org/wiki/Tap_(valve)).\n" +
+		"```\ncurl https://skipped.example\n```\n" +
+		"Local [node](../3) only.\n")
+	require.Equal(t, []string{
+		"https://go.dev/doc/",
+		"https://example.com/a",
+		"https://en.wikipedia.org/wiki/Tap_(valve)",
+	}, ExternalURLs(content))
+}
+
+func TestParseRobots(t *testing.T) {
+	t.Parallel()
+	rules := parseRobots(`
+User-agent: *
+Disallow: /
+
+User-agent: Tapper
+User-agent: other
+Disallow: /private
+Allow: /private/ok
+Disallow: /*.pdf$
+`, UserAgent)
+	require.True(t, rules.allows("/notes"))
+	require.False(t, rules.allows("/private/x"))
+	require.True(t, rules.allows("/private/ok/y"))
+	require.False(t, rules.allows("/docs/a.pdf"))
+	require.True(t, rules.allows("/docs/a.pdf?x"))
+
+	require.False(t, parseRobots("User-agent: *\nDisallow: /\n", UserAgent).allows("/a"))
+	require.True(t, parseRobots("", UserAgent).allows("/a"))
+}
+
+func TestCache_RefreshFetchesPreviewsAndHonorsRobots(t *testing.T) {
+	t.Parallel()
+	ctx := t.Context()
+
+	var pageHits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			_, _ = w.Write([]byte("User-agent: tapper\nDisallow: /secret\n"))
+		case "/page":
+			pageHits.Add(1)
+			require.Equal(t, UserAgent, r.Header.Get("User-Agent"))
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			_, _ = w.Write([]byte(`<!doctype html><html><head>
+<title>  Page &amp; Title </title>
+<meta name="description" content="What the page is about.">
+<link rel="shortcut icon" href="/static/icon.png">
+</head><body><title>ignored</title></body></html>`))
+		case "/missing":
+			http.NotFound(w, r)
+		case "/secret":
+			t.Error("fetched a page disallowed by robots.txt")
+		}
+	}))
+	defer srv.Close()
+
+	rt, err := toolkit.NewTestRuntime(t.TempDir(), "/home/testuser", "testuser")
+	require.NoError(t, err)
+	repo := keg.NewMemoryRepo(rt)
+	cache, err := LoadCache(ctx, repo)
+	require.NoError(t, err)
+
+	urls := []string{srv.URL + "/page", srv.URL + "/missing", srv.URL + "/secret"}
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	f := &Fetcher{Client: srv.Client()}
+	res, err := cache.Refresh(ctx, f, urls, now, time.Hour, false)
+	require.NoError(t, err)
+	require.Equal(t, RefreshResult{Fetched: 3, Failed: 1, Blocked: 1}, res)
+	require.NoError(t, cache.Save(ctx, repo))
+
+	reloaded, err := LoadCache(ctx, repo)
+	require.NoError(t, err)
+	p, ok := reloaded.Get(srv.URL + "/page")
+	require.True(t, ok)
+	require.Equal(t, "Page & Title", p.Title)
+	require.Equal(t, "What the page is about.", p.Description)
+	require.Equal(t, srv.URL+"/static/icon.png", p.Favicon)
+	require.True(t, p.Fetched.Equal(now))
+	p, _ = reloaded.Get(srv.URL + "/secret")
+	require.True(t, p.Blocked)
+	p, _ = reloaded.Get(srv.URL + "/missing")
+	require.Contains(t, p.Error, "404")
+
+	// Fresh previews are served from the cache until the TTL runs out.
+	res, err = reloaded.Refresh(ctx, f, urls, now.Add(30*time.Minute), time.Hour, false)
+	require.NoError(t, err)
+	require.Zero(t, res.Fetched)
+	require.EqualValues(t, 1, pageHits.Load())
+
+	res, err = reloaded.Refresh(ctx, f, urls[:1], now.Add(2*time.Hour), time.Hour, false)
+	require.NoError(t, err)
+	require.Equal(t, 1, res.Fetched)
+	require.EqualValues(t, 2, pageHits.Load())
+
+	require.Equal(t, 2, reloaded.Prune(urls[:1]))
+	require.Equal(t, 1, reloaded.Len())
+}
+
+func TestCache_RefreshRetriesUnreachableHosts(t *testing.T) {
+	t.Parallel()
+	ctx := t.Context()
+
+	var up atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !up.Load() {
+			// Simulate an unreachable host by dropping the connection.
+			conn, _, err := w.(http.Hijacker).Hijack()
+			require.NoError(t, err)
+			_ = conn.Close()
+			return
+		}
+		if r.URL.Path == "/page" {
+			w.Header().Set("Content-Type", "text/html")
+			_, _ = w.Write([]byte("<html><head><title>Back</title></head></html>"))
+		}
+	}))
+	defer srv.Close()
+
+	rt, err := toolkit.NewTestRuntime(t.TempDir(), "/home/testuser", "testuser")
+	require.NoError(t, err)
+	cache, err := LoadCache(ctx, keg.NewMemoryRepo(rt))
+	require.NoError(t, err)
+
+	urls := []string{srv.URL + "/page"}
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	res, err := cache.Refresh(ctx, &Fetcher{Client: srv.Client()}, urls, now, time.Hour, false)
+	require.NoError(t, err)
+	require.Equal(t, RefreshResult{Fetched: 1, Failed: 1}, res)
+	_, ok := cache.Get(urls[0])
+	require.False(t, ok, "transport failures are not cached")
+
+	up.Store(true)
+	res, err = cache.Refresh(ctx, &Fetcher{Client: srv.Client()}, urls, now.Add(time.Minute), time.Hour, false)
+	require.NoError(t, err)
+	require.Equal(t, RefreshResult{Fetched: 1}, res)
+	p, ok := cache.Get(urls[0])
+	require.True(t, ok)
+	require.False(t, p.Blocked)
+	require.Equal(t, "Back", p.Title)
+}
@@ -0,0 +1,466 @@
+package keg
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// WhereExpr is an opaque compiled structured metadata filter such as
+// `created > 2025-01-01 and tags has go and attrs.status = active`. Callers
+// obtain one via ParseWhereExpression and test nodes with Match.
+//
+// Supported fields:
+//   - id: numeric node ID
+//   - title: node title from the dex
+//   - created, updated, accessed: node timestamps from the dex
+//   - tags (or tag): node tags from meta.yaml
+//   - attrs.<key> (or a bare <key>): a meta.yaml attribute
+//
+// Supported operators: =, !=, <, <=, >, >=, has, and contains. Predicates
+// combine with and/or/not (or &&, ||, !) and parentheses.
+type WhereExpr struct {
+	root whereNode
+}
+
+// WhereRecord is the view of a node that a WhereExpr is evaluated against.
+// Meta is loaded lazily so filters that only touch dex fields never read
+// meta.yaml; it may be nil or return nil when metadata is unavailable.
+type WhereRecord struct {
+	Entry NodeIndexEntry
+	Meta  func() *NodeMeta
+}
+
+// ParseWhereExpression compiles raw into a WhereExpr. Returns an error if raw
+// is empty or syntactically invalid.
+func ParseWhereExpression(raw string) (WhereExpr, error) {
+	tokens, err := tokenizeWhereExpression(raw)
+	if err != nil {
+		return WhereExpr{}, err
+	}
+	if len(tokens) <= 1 {
+		return WhereExpr{}, fmt.Errorf("expression is empty")
+	}
+
+	p := &whereExprParser{tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return WhereExpr{}, err
+	}
+	if tok := p.peek(); tok.typ != whereTokenEOF {
+		return WhereExpr{}, fmt.Errorf("unexpected token %q at position %d", tok.value, tok.pos+1)
+	}
+	return WhereExpr{root: root}, nil
+}
+
+// Match reports whether rec satisfies the expression. A zero WhereExpr
+// matches nothing.
+func (e WhereExpr) Match(rec WhereRecord) bool {
+	if e.root == nil {
+		return false
+	}
+	return e.root.match(&rec)
+}
+
+// --------------------------------------------------------------------------
+// Internal AST (unexported)
+// --------------------------------------------------------------------------
+
+type whereNode interface {
+	match(rec *WhereRecord) bool
+}
+
+type whereAndNode struct{ left, right whereNode }
+
+func (n *whereAndNode) match(rec *WhereRecord) bool {
+	return n.left.match(rec) && n.right.match(rec)
+}
+
+type whereOrNode struct{ left, right whereNode }
+
+func (n *whereOrNode) match(rec *WhereRecord) bool {
+	return n.left.match(rec) || n.right.match(rec)
+}
+
+type whereNotNode struct{ node whereNode }
+
+func (n *whereNotNode) match(rec *WhereRecord) bool {
+	return !n.node.match(rec)
+}
+
+type wherePredicateNode struct {
+	field string
+	op    string
+	value string
+}
+
+func (n *wherePredicateNode) match(rec *WhereRecord) bool {
+	switch strings.ToLower(n.field) {
+	case "id":
+		id, err := ParseNode(rec.Entry.ID)
+		if err != nil || id == nil {
+			return false
+		}
+		want, err := strconv.Atoi(n.value)
+		if err != nil {
+			return false
+		}
+		return compareOrdered(id.ID, want, n.op)
+	case "title":
+		return matchWhereString(rec.Entry.Title, n.op, norm.NFC.String(n.value))
+	case "created":
+		return matchWhereTime(rec.Entry.Created, n.op, n.value)
+	case "updated":
+		return matchWhereTime(rec.Entry.Updated, n.op, n.value)
+	case "accessed":
+		return matchWhereTime(rec.Entry.Accessed, n.op, n.value)
+	case "tags", "tag":
+		meta := rec.meta()
+		if meta == nil {
+			return n.op == "!="
+		}
+		want := NormalizeTag(n.value)
+		found := false
+		for _, tag := range meta.Tags() {
+			if tag == want {
+				found = true
+				break
+			}
+		}
+		switch n.op {
+		case "has", "contains", "=":
+			return found
+		case "!=":
+			return !found
+		}
+		return false
+	}
+
+	key := strings.TrimPrefix(n.field, "attrs.")
+	meta := rec.meta()
+	if meta == nil {
+		return n.op == "!="
+	}
+	got, ok := meta.Get(key)
+	if !ok {
+		return n.op == "!="
+	}
+	return matchWhereString(got, n.op, n.value)
+}
+
+func (rec *WhereRecord) meta() *NodeMeta {
+	if rec.Meta == nil {
+		return nil
+	}
+	return rec.Meta()
+}
+
+func matchWhereString(got, op, want string) bool {
+	switch op {
+	case "=":
+		return got == want
+	case "!=":
+		return got != want
+	case "has", "contains":
+		return strings.Contains(strings.ToLower(got), strings.ToLower(want))
+	}
+
+	// Ordering operators compare numerically, then chronologically, then
+	// lexically depending on what both sides parse as.
+	if a, errA := strconv.ParseFloat(got, 64); errA == nil {
+		if b, errB := strconv.ParseFloat(want, 64); errB == nil {
+			return compareOrdered(a, b, op)
+		}
+	}
+	if a, okA := parseWhereTime(got); okA {
+		if b, okB := parseWhereTime(want); okB {
+			return compareOrdered(a.Unix(), b.Unix(), op)
+		}
+	}
+	return compareOrdered(got, want, op)
+}
+
+func matchWhereTime(got time.Time, op, raw string) bool {
+	want, ok := parseWhereTime(raw)
+	if !ok || got.IsZero() {
+		return false
+	}
+	switch op {
+	case "=":
+		// A bare date matches any time on that day.
+		if len(strings.TrimSpace(raw)) == len(time.DateOnly) {
+			return got.UTC().Format(time.DateOnly) == want.Format(time.DateOnly)
+		}
+		return got.Equal(want)
+	case "!=":
+		return !matchWhereTime(got, "=", raw)
+	}
+	return compareOrdered(got.UnixNano(), want.UnixNano(), op)
+}
+
+func parseWhereTime(raw string) (time.Time, bool) {
+	raw = strings.TrimSpace(raw)
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02 15:04:05", time.DateOnly} {
+		if t, err := time.Parse(layout, raw); err == nil {
+			return t.UTC(), true
+		}
+	}
+	return time.Time{}, false
+}
+
+func compareOrdered[T int | int64 | float64 | string](a, b T, op string) bool {
+	switch op {
+	case "=":
+		return a == b
+	case "!=":
+		return a != b
+	case "<":
+		return a < b
+	case "<=":
+		return a <= b
+	case ">":
+		return a > b
+	case ">=":
+		return a >= b
+	}
+	return false
+}
+
+// --------------------------------------------------------------------------
+// Tokenizer and parser (unexported)
+// --------------------------------------------------------------------------
+
+type whereTokenType int
+
+const (
+	whereTokenEOF whereTokenType = iota
+	whereTokenWord
+	whereTokenString
+	whereTokenOp
+	whereTokenAnd
+	whereTokenOr
+	whereTokenNot
+	whereTokenLParen
+	whereTokenRParen
+)
+
+type whereToken struct {
+	typ   whereTokenType
+	value string
+	pos   int
+}
+
+func tokenizeWhereExpression(raw string) ([]whereToken, error) {
+	in := strings.TrimSpace(raw)
+	tokens := make([]whereToken, 0)
+	pos := 0
+	for pos < len(in) {
+		ch := in[pos]
+		if unicode.IsSpace(rune(ch)) {
+			pos++
+			continue
+		}
+
+		switch ch {
+		case '(':
+			tokens = append(tokens, whereToken{typ: whereTokenLParen, value: "(", pos: pos})
+			pos++
+			continue
+		case ')':
+			tokens = append(tokens, whereToken{typ: whereTokenRParen, value: ")", pos: pos})
+			pos++
+			continue
+		case '&', '|':
+			if pos+1 < len(in) && in[pos+1] == ch {
+				typ := whereTokenAnd
+				if ch == '|' {
+					typ = whereTokenOr
+				}
+				tokens = append(tokens, whereToken{typ: typ, value: in[pos : pos+2], pos: pos})
+				pos += 2
+				continue
+			}
+			return nil, fmt.Errorf("unexpected token %q at position %d", string(ch), pos+1)
+		case '!':
+			if pos+1 < len(in) && in[pos+1] == '=' {
+				tokens = append(tokens, whereToken{typ: whereTokenOp, value: "!=", pos: pos})
+				pos += 2
+				continue
+			}
+			tokens = append(tokens, whereToken{typ: whereTokenNot, value: "!", pos: pos})
+			pos++
+			continue
+		case '=':
+			tokens = append(tokens, whereToken{typ: whereTokenOp, value: "=", pos: pos})
+			pos++
+			if pos < len(in) && in[pos] == '=' {
+				pos++
+			}
+			continue
+		case '<', '>':
+			op := string(ch)
+			if pos+1 < len(in) && in[pos+1] == '=' {
+				op += "="
+			}
+			tokens = append(tokens, whereToken{typ: whereTokenOp, value: op, pos: pos})
+			pos += len(op)
+			continue
+		case '\'', '"':
+			quote := ch
+			start := pos
+			pos++
+			var b strings.Builder
+			closed := false
+			for pos < len(in) {
+				c := in[pos]
+				if c == '\\' && pos+1 < len(in) {
+					b.WriteByte(in[pos+1])
+					pos += 2
+					continue
+				}
+				pos++
+				if c == quote {
+					closed = true
+					break
+				}
+				b.WriteByte(c)
+			}
+			if !closed {
+				return nil, fmt.Errorf("unterminated quoted value at position %d", start+1)
+			}
+			tokens = append(tokens, whereToken{typ: whereTokenString, value: b.String(), pos: start})
+			continue
+		}
+
+		start := pos
+		for pos < len(in) && !unicode.IsSpace(rune(in[pos])) && !strings.ContainsRune("()&|!=<>'\"", rune(in[pos])) {
+			pos++
+		}
+		word := in[start:pos]
+		switch strings.ToLower(word) {
+		case "and":
+			tokens = append(tokens, whereToken{typ: whereTokenAnd, value: word, pos: start})
+		case "or":
+			tokens = append(tokens, whereToken{typ: whereTokenOr, value: word, pos: start})
+		case "not":
+			tokens = append(tokens, whereToken{typ: whereTokenNot, value: word, pos: start})
+		case "has", "contains":
+			tokens = append(tokens, whereToken{typ: whereTokenOp, value: strings.ToLower(word), pos: start})
+		default:
+			tokens = append(tokens, whereToken{typ: whereTokenWord, value: word, pos: start})
+		}
+	}
+	tokens = append(tokens, whereToken{typ: whereTokenEOF, pos: len(in)})
+	return tokens, nil
+}
+
+type whereExprParser struct {
+	tokens []whereToken
+	index  int
+}
+
+func (p *whereExprParser) peek() whereToken {
+	if p.index >= len(p.tokens) {
+		return p.tokens[len(p.tokens)-1]
+	}
+	return p.tokens[p.index]
+}
+
+func (p *whereExprParser) next() whereToken {
+	tok := p.peek()
+	if p.index < len(p.tokens) {
+		p.index++
+	}
+	return tok
+}
+
+func (p *whereExprParser) parseOr() (whereNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().typ == whereTokenOr {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &whereOrNode{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *whereExprParser) parseAnd() (whereNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().typ == whereTokenAnd {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &whereAndNode{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *whereExprParser) parseUnary() (whereNode, error) {
+	if p.peek().typ == whereTokenNot {
+		p.next()
+		node, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &whereNotNode{node: node}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *whereExprParser) parsePrimary() (whereNode, error) {
+	tok := p.next()
+	switch tok.typ {
+	case whereTokenLParen:
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		closing := p.next()
+		if closing.typ != whereTokenRParen {
+			if closing.typ == whereTokenEOF {
+				return nil, fmt.Errorf("expected ')' before end of expression")
+			}
+			return nil, fmt.Errorf("expected ')' before position %d", closing.pos+1)
+		}
+		return expr, nil
+	case whereTokenWord:
+		op := p.next()
+		if op.typ != whereTokenOp {
+			if op.typ == whereTokenEOF {
+				return nil, fmt.Errorf("expected operator after %q", tok.value)
+			}
+			return nil, fmt.Errorf("expected operator after %q at position %d", tok.value, op.pos+1)
+		}
+		val := p.next()
+		if val.typ != whereTokenWord && val.typ != whereTokenString {
+			if val.typ == whereTokenEOF {
+				return nil, fmt.Errorf("expected value after %q", op.value)
+			}
+			return nil, fmt.Errorf("expected value after %q at position %d", op.value, val.pos+1)
+		}
+		return &wherePredicateNode{
+			field: tok.value,
+			op:    op.value,
+			value: val.value,
+		}, nil
+	case whereTokenEOF:
+		return nil, fmt.Errorf("unexpected end of expression")
+	default:
+		return nil, fmt.Errorf("unexpected token %q at position %d", tok.value, tok.pos+1)
+	}
+}
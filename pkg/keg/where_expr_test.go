@@ -0,0 +1,68 @@
+package keg_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jlrickert/tapper/pkg/keg"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWhereExpression_Match(t *testing.T) {
+	t.Parallel()
+
+	meta, err := keg.ParseMeta(context.Background(), []byte("tags:\n  - go\n  - cli\nstatus: active\npriority: 3\n"))
+	require.NoError(t, err)
+
+	rec := keg.WhereRecord{
+		Entry: keg.NodeIndexEntry{
+			ID:      "12",
+			Title:   "Go CLI Patterns",
+			Created: time.Date(2025, 3, 4, 10, 0, 0, 0, time.UTC),
+			Updated: time.Date(2025, 6, 1, 8, 30, 0, 0, time.UTC),
+		},
+		Meta: func() *keg.NodeMeta { return meta },
+	}
+
+	cases := []struct {
+		expr string
+		want bool
+	}{
+		{"created > 2025-01-01 and tags has go and attrs.status = active", true},
+		{"created > 2025-04-01", false},
+		{"created = 2025-03-04", true},
+		{"updated >= 2025-06-01T08:30:00Z", true},
+		{"id = 12", true},
+		{"id < 10", false},
+		{"title contains 'cli patterns'", true},
+		{"tags has rust or priority > 2", true},
+		{"not tags has go", false},
+		{"attrs.status != active || missing = x", false},
+		{"missing != x", true},
+		{"(tags has rust or tags has cli) && priority <= 3", true},
+	}
+	for _, tc := range cases {
+		expr, err := keg.ParseWhereExpression(tc.expr)
+		require.NoError(t, err, tc.expr)
+		require.Equal(t, tc.want, expr.Match(rec), tc.expr)
+	}
+}
+
+func TestWhereExpression_ParseErrors(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]string{
+		"":                  "expression is empty",
+		"created >":         "expected value",
+		"created":           "expected operator",
+		"(tags has go":      "expected ')'",
+		"title = 'unclosed": "unterminated quoted value",
+		"a = b c = d":       "unexpected token",
+	}
+	for expr, want := range cases {
+		_, err := keg.ParseWhereExpression(expr)
+		require.Error(t, err, expr)
+		require.Contains(t, err.Error(), want, expr)
+	}
+}
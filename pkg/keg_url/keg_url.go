@@ -71,6 +71,14 @@ type Target struct {
 	// Readonly specifies in the target is readonly. Only api and file are
 	// writable
 	Readonly bool `yaml:"readonly,omitempty"`
+
+	// Deprecated marks an alias as retired. Resolving it still works but
+	// logs a warning.
+	Deprecated bool `yaml:"deprecated,omitempty"`
+
+	// Redirect names the alias or target that replaces this one. Resolution
+	// follows it instead of the target fields.
+	Redirect string `yaml:"redirect,omitempty"`
 }
 
 type TargetOption = func(t *Target)
@@ -164,8 +172,13 @@ func Parse(raw string) (*Target, error) {
 	detectedScheme := detectScheme(value)
 	switch detectedScheme {
 	case SchemeFile:
+		file := strings.TrimPrefix(value, "file://")
+		// file:///C:/kegs carries the drive after the empty authority.
+		if strings.HasPrefix(file, "/") && isWindowsPath(file[1:]) {
+			file = file[1:]
+		}
 		t := Target{
-			File: filepath.Clean(strings.TrimPrefix(value, "file://")),
+			File: filepath.Clean(file),
 		}
 		return &t, nil
 	case SchemeRegistry:
@@ -390,6 +403,11 @@ func detectScheme(raw string) string {
 	if raw == "" {
 		return SchemeFile
 	}
+	// Windows paths come first: "C:/kegs" would otherwise look like registry
+	// shorthand and "C:\kegs\my.keg" like a host name.
+	if isWindowsPath(raw) {
+		return SchemeFile
+	}
 	if m := scalarApiRE.FindStringSubmatch(raw); m != nil {
 		rest := strings.TrimSpace(m[2])
 		rest = strings.TrimPrefix(rest, "/")
@@ -428,16 +446,26 @@ func detectScheme(raw string) string {
 		return SchemeHTTPs
 	}
 
-	// Windows drive letter like "C:" should be treated as file.
-	if len(raw) >= 2 && raw[1] == ':' && ((raw[0] >= 'A' && raw[0] <= 'Z') ||
-		(raw[0] >= 'a' && raw[0] <= 'z')) {
-		return SchemeFile
-	}
-
 	// Fallback: treat as a local or repo file path.
 	return SchemeFile
 }
 
+// isWindowsPath reports whether raw is a Windows drive path ("C:", "C:\kegs",
+// "c:/kegs") or a UNC path ("\\server\share").
+func isWindowsPath(raw string) bool {
+	if strings.HasPrefix(raw, `\\`) {
+		return true
+	}
+	if len(raw) < 2 || raw[1] != ':' {
+		return false
+	}
+	c := raw[0]
+	if (c < 'A' || c > 'Z') && (c < 'a' || c > 'z') {
+		return false
+	}
+	return len(raw) == 2 || raw[2] == '\\' || raw[2] == '/'
+}
+
 func getHostLikePath(raw string) string {
 	// Look at the host-like part before the first slash.
 	firstSlash := strings.IndexRune(raw, '/')
@@ -55,6 +55,30 @@ func TestParse_File_TableDriven(t *testing.T) {
 			wantSchema: kegurl.SchemeFile,
 			wantFile:   "kegs/work",
 		},
+		{
+			name:       "windows drive with forward slashes",
+			raw:        "C:/Users/me/kegs",
+			wantSchema: kegurl.SchemeFile,
+			wantFile:   filepath.Clean("C:/Users/me/kegs"),
+		},
+		{
+			name:       "windows drive with dotted file name",
+			raw:        `C:\kegs\my.keg`,
+			wantSchema: kegurl.SchemeFile,
+			wantFile:   filepath.Clean(`C:\kegs\my.keg`),
+		},
+		{
+			name:       "windows file uri",
+			raw:        "file:///C:/kegs/work",
+			wantSchema: kegurl.SchemeFile,
+			wantFile:   filepath.Clean("C:/kegs/work"),
+		},
+		{
+			name:       "windows unc path",
+			raw:        `\\server\share\keg`,
+			wantSchema: kegurl.SchemeFile,
+			wantFile:   filepath.Clean(`\\server\share\keg`),
+		},
 	}
 
 	for _, tc := range cases {
@@ -255,3 +279,26 @@ func TestTargetExpand_ExpandsEnvironmentVariables(t *testing.T) {
 	require.Equal(t, "secret-token", kt.Token)
 	require.Equal(t, "TAPPER_TOKEN", kt.TokenEnv)
 }
+
+func FuzzParse(f *testing.F) {
+	for _, seed := range []string{
+		"~/kegs/notes", "file:///C:/kegs", "./rel/../keg", "registry:user/keg",
+		"registry:/@user/keg", "https://keg.example.com/@joe/notes?readonly=yes&token=abc",
+		"keg.example.com//a//b", "http://[::1]:8080/x", "C:\\kegs\\notes",
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, raw string) {
+		target, err := kegurl.Parse(raw)
+		if err != nil {
+			return
+		}
+		_ = target.Scheme()
+		_ = target.Host()
+		_ = target.Port()
+		_ = target.Path()
+		if s := target.String(); s == "" {
+			t.Fatalf("parsed %q to an empty target", raw)
+		}
+	})
+}
@@ -0,0 +1,337 @@
+package kegtest
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jlrickert/tapper/pkg/keg"
+	"github.com/stretchr/testify/require"
+)
+
+// RunRepositoryConformance checks that the repositories made by newRepo
+// honor the keg.Repository contract: not-found and conflict errors, copy
+// semantics of reads and writes, list ordering, partial node writes and node
+// locking. File and image checks run when the repository implements
+// keg.RepositoryFiles or keg.RepositoryImages.
+//
+// newRepo is called once per subtest and must return an empty repository;
+// subtests run in parallel.
+func RunRepositoryConformance(t *testing.T, newRepo func(t *testing.T) keg.Repository) {
+	t.Helper()
+	for _, c := range conformanceChecks {
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			c.run(t, t.Context(), newRepo(t))
+		})
+	}
+}
+
+var conformanceChecks = []struct {
+	name string
+	run  func(t *testing.T, ctx context.Context, repo keg.Repository)
+}{
+	{"missing node", checkMissingNode},
+	{"missing items", checkMissingItems},
+	{"node round trip", checkNodeRoundTrip},
+	{"copy semantics", checkCopySemantics},
+	{"ordering", checkOrdering},
+	{"next", checkNext},
+	{"move and delete", checkMoveAndDelete},
+	{"indexes", checkIndexes},
+	{"config", checkConfig},
+	{"node lock", checkNodeLock},
+	{"files", checkFiles},
+	{"images", checkImages},
+}
+
+func checkMissingNode(t *testing.T, ctx context.Context, repo keg.Repository) {
+	missing := keg.NodeId{ID: 99}
+
+	has, err := repo.HasNode(ctx, missing)
+	require.NoError(t, err)
+	require.False(t, has)
+
+	_, err = repo.ReadContent(ctx, missing)
+	requireNodeNotFound(t, err, missing)
+	_, err = repo.ReadMeta(ctx, missing)
+	requireNodeNotFound(t, err, missing)
+	_, err = repo.ReadStats(ctx, missing)
+	requireNodeNotFound(t, err, missing)
+	requireNodeNotFound(t, repo.DeleteNode(ctx, missing), missing)
+	requireNodeNotFound(t, repo.MoveNode(ctx, missing, keg.NodeId{ID: 100}), missing)
+}
+
+func checkMissingItems(t *testing.T, ctx context.Context, repo keg.Repository) {
+	_, err := repo.ReadConfig(ctx)
+	requireNotFound(t, err, "keg config")
+	_, err = repo.GetIndex(ctx, "nodes.tsv")
+	requireNotFound(t, err, "index")
+}
+
+func checkNodeRoundTrip(t *testing.T, ctx context.Context, repo keg.Repository) {
+	id := keg.NodeId{ID: 1}
+	stats := keg.NewStats(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC))
+	stats.SetTitle("One")
+	require.NoError(t, repo.WriteNode(ctx, id, []byte("# One\n"), []byte("tags: [a]\n"), stats))
+
+	has, err := repo.HasNode(ctx, id)
+	require.NoError(t, err)
+	require.True(t, has)
+	requireContent(t, ctx, repo, id, "# One\n")
+	requireMeta(t, ctx, repo, id, "tags: [a]\n")
+	got, err := repo.ReadStats(ctx, id)
+	require.NoError(t, err)
+	require.Equal(t, "One", got.Title())
+
+	// Nil parts of a node write leave the stored parts alone.
+	require.NoError(t, repo.WriteNode(ctx, id, []byte("# One again\n"), nil, nil))
+	requireContent(t, ctx, repo, id, "# One again\n")
+	requireMeta(t, ctx, repo, id, "tags: [a]\n")
+
+	require.NoError(t, repo.WriteMeta(ctx, id, []byte("tags: [b]\n")))
+	requireMeta(t, ctx, repo, id, "tags: [b]\n")
+	requireContent(t, ctx, repo, id, "# One again\n")
+
+	bare := keg.NodeId{ID: 2}
+	require.NoError(t, repo.WriteContent(ctx, bare, []byte("# Two\n")))
+	meta, err := repo.ReadMeta(ctx, bare)
+	require.NoError(t, err, "a node without meta reads as no meta")
+	require.Nil(t, meta)
+}
+
+func checkCopySemantics(t *testing.T, ctx context.Context, repo keg.Repository) {
+	id := keg.NodeId{ID: 1}
+	content := []byte("# One\n")
+	meta := []byte("tags: [a]\n")
+	require.NoError(t, repo.WriteContent(ctx, id, content))
+	require.NoError(t, repo.WriteMeta(ctx, id, meta))
+	content[2], meta[7] = 'X', 'X'
+	requireContent(t, ctx, repo, id, "# One\n")
+	requireMeta(t, ctx, repo, id, "tags: [a]\n")
+
+	read, err := repo.ReadContent(ctx, id)
+	require.NoError(t, err)
+	read[2] = 'X'
+	readMeta, err := repo.ReadMeta(ctx, id)
+	require.NoError(t, err)
+	readMeta[7] = 'X'
+	requireContent(t, ctx, repo, id, "# One\n")
+	requireMeta(t, ctx, repo, id, "tags: [a]\n")
+
+	index := []byte("1\tOne\n")
+	require.NoError(t, repo.WriteIndex(ctx, "nodes.tsv", index))
+	index[0] = 'X'
+	got, err := repo.GetIndex(ctx, "nodes.tsv")
+	require.NoError(t, err)
+	require.Equal(t, "1\tOne\n", string(got))
+}
+
+func checkOrdering(t *testing.T, ctx context.Context, repo keg.Repository) {
+	for _, n := range []int{10, 2, 7} {
+		require.NoError(t, repo.WriteContent(ctx, keg.NodeId{ID: n}, []byte("# Node\n")))
+	}
+	ids, err := repo.ListNodes(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []keg.NodeId{{ID: 2}, {ID: 7}, {ID: 10}}, ids, "nodes list in ascending order")
+
+	for _, name := range []string{"tags", "nodes.tsv", "links"} {
+		require.NoError(t, repo.WriteIndex(ctx, name, []byte(name)))
+	}
+	names, err := repo.ListIndexes(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []string{"links", "nodes.tsv", "tags"}, names, "indexes list in name order")
+}
+
+func checkNext(t *testing.T, ctx context.Context, repo keg.Repository) {
+	require.NoError(t, repo.WriteContent(ctx, keg.NodeId{ID: 0}, []byte("# Zero\n")))
+	require.NoError(t, repo.WriteContent(ctx, keg.NodeId{ID: 4}, []byte("# Four\n")))
+
+	first, err := repo.Next(ctx)
+	require.NoError(t, err)
+	require.Greater(t, first.ID, 4, "Next allocates past the highest node")
+	second, err := repo.Next(ctx)
+	require.NoError(t, err)
+	require.NotEqual(t, first, second, "Next reserves the ids it returns")
+}
+
+func checkMoveAndDelete(t *testing.T, ctx context.Context, repo keg.Repository) {
+	src, dst, other := keg.NodeId{ID: 1}, keg.NodeId{ID: 5}, keg.NodeId{ID: 2}
+	require.NoError(t, repo.WriteNode(ctx, src, []byte("# One\n"), []byte("tags: [a]\n"), nil))
+	require.NoError(t, repo.WriteContent(ctx, other, []byte("# Two\n")))
+
+	err := repo.MoveNode(ctx, src, other)
+	require.ErrorIs(t, err, keg.ErrDestinationExists)
+	var de *keg.DestinationExistsError
+	require.ErrorAs(t, err, &de)
+	require.Equal(t, other, de.ID)
+	requireContent(t, ctx, repo, other, "# Two\n")
+
+	require.NoError(t, repo.MoveNode(ctx, src, dst))
+	requireContent(t, ctx, repo, dst, "# One\n")
+	requireMeta(t, ctx, repo, dst, "tags: [a]\n")
+	has, err := repo.HasNode(ctx, src)
+	require.NoError(t, err)
+	require.False(t, has, "a moved node leaves its old id")
+
+	require.NoError(t, repo.DeleteNode(ctx, dst))
+	_, err = repo.ReadContent(ctx, dst)
+	requireNodeNotFound(t, err, dst)
+	ids, err := repo.ListNodes(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []keg.NodeId{other}, ids)
+}
+
+func checkIndexes(t *testing.T, ctx context.Context, repo keg.Repository) {
+	require.NoError(t, repo.WriteIndex(ctx, "nodes.tsv", []byte("1\tOne\n")))
+	require.NoError(t, repo.WriteIndex(ctx, "nodes.tsv", []byte("1\tUno\n")))
+	got, err := repo.GetIndex(ctx, "nodes.tsv")
+	require.NoError(t, err)
+	require.Equal(t, "1\tUno\n", string(got), "writing an index replaces it")
+
+	require.NoError(t, repo.ClearIndexes(ctx))
+	names, err := repo.ListIndexes(ctx)
+	require.NoError(t, err)
+	require.Empty(t, names)
+	_, err = repo.GetIndex(ctx, "nodes.tsv")
+	requireNotFound(t, err, "index")
+}
+
+func checkConfig(t *testing.T, ctx context.Context, repo keg.Repository) {
+	cfg := &keg.Config{Kegv: keg.ConfigV2VersionString, Title: "Notes"}
+	require.NoError(t, repo.WriteConfig(ctx, cfg))
+	cfg.Title = "Changed"
+
+	got, err := repo.ReadConfig(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "Notes", got.Title)
+	got.Title = "Changed"
+	again, err := repo.ReadConfig(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "Notes", again.Title, "configs read are copies")
+}
+
+func checkNodeLock(t *testing.T, ctx context.Context, repo keg.Repository) {
+	id := keg.NodeId{ID: 1}
+	require.NoError(t, repo.WriteContent(ctx, id, []byte("# One\n")))
+
+	const workers = 8
+	var (
+		wg      sync.WaitGroup
+		holders int
+		overlap bool
+		mu      sync.Mutex
+	)
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := repo.WithNodeLock(ctx, id, func(context.Context) error {
+				mu.Lock()
+				holders++
+				overlap = overlap || holders > 1
+				mu.Unlock()
+				time.Sleep(5 * time.Millisecond)
+				mu.Lock()
+				holders--
+				mu.Unlock()
+				return nil
+			})
+			require.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+	require.False(t, overlap, "node locks serialize holders")
+
+	lockCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	sentinel := errors.New("from fn")
+	err := repo.WithNodeLock(lockCtx, id, func(ctx context.Context) error {
+		return repo.WithNodeLock(ctx, id, func(context.Context) error { return sentinel })
+	})
+	require.ErrorIs(t, err, sentinel, "node locks are re-entrant through the lock context and pass fn's error through")
+}
+
+func checkFiles(t *testing.T, ctx context.Context, repo keg.Repository) {
+	files, ok := repo.(keg.RepositoryFiles)
+	if !ok {
+		t.Skip("repository does not implement keg.RepositoryFiles")
+	}
+	missing, id := keg.NodeId{ID: 99}, keg.NodeId{ID: 1}
+	requireNodeNotFound(t, files.WriteFile(ctx, missing, "doc.txt", []byte("x")), missing)
+
+	require.NoError(t, repo.WriteContent(ctx, id, []byte("# One\n")))
+	_, err := files.ReadFile(ctx, id, "missing.txt")
+	requireNotFound(t, err, "file")
+	err = files.WriteFile(ctx, id, "../escape.txt", []byte("x"))
+	require.ErrorIs(t, err, keg.ErrInvalidName)
+	require.ErrorIs(t, err, keg.ErrInvalid)
+
+	for _, name := range []string{"b.txt", "a.txt"} {
+		require.NoError(t, files.WriteFile(ctx, id, name, []byte(name)))
+	}
+	names, err := files.ListFiles(ctx, id)
+	require.NoError(t, err)
+	require.Equal(t, []string{"a.txt", "b.txt"}, names)
+	data, err := files.ReadFile(ctx, id, "a.txt")
+	require.NoError(t, err)
+	require.Equal(t, "a.txt", string(data))
+
+	require.NoError(t, files.DeleteFile(ctx, id, "a.txt"))
+	_, err = files.ReadFile(ctx, id, "a.txt")
+	requireNotFound(t, err, "file")
+}
+
+func checkImages(t *testing.T, ctx context.Context, repo keg.Repository) {
+	images, ok := repo.(keg.RepositoryImages)
+	if !ok {
+		t.Skip("repository does not implement keg.RepositoryImages")
+	}
+	id := keg.NodeId{ID: 1}
+	require.NoError(t, repo.WriteContent(ctx, id, []byte("# One\n")))
+	_, err := images.ReadImage(ctx, id, "missing.png")
+	requireNotFound(t, err, "image")
+
+	require.NoError(t, images.WriteImage(ctx, id, "pic.png", []byte("png")))
+	names, err := images.ListImages(ctx, id)
+	require.NoError(t, err)
+	require.Equal(t, []string{"pic.png"}, names)
+	require.NoError(t, images.DeleteImage(ctx, id, "pic.png"))
+	_, err = images.ReadImage(ctx, id, "pic.png")
+	requireNotFound(t, err, "image")
+}
+
+func requireContent(t *testing.T, ctx context.Context, repo keg.Repository, id keg.NodeId, want string) {
+	t.Helper()
+	got, err := repo.ReadContent(ctx, id)
+	require.NoError(t, err)
+	require.Equal(t, want, string(got))
+}
+
+func requireMeta(t *testing.T, ctx context.Context, repo keg.Repository, id keg.NodeId, want string) {
+	t.Helper()
+	got, err := repo.ReadMeta(ctx, id)
+	require.NoError(t, err)
+	require.Equal(t, want, string(got))
+}
+
+func requireNodeNotFound(t *testing.T, err error, id keg.NodeId) {
+	t.Helper()
+	require.Error(t, err)
+	require.ErrorIs(t, err, keg.ErrNotExist)
+	require.True(t, keg.IsNotExist(err))
+	var nf *keg.NodeNotFoundError
+	require.ErrorAs(t, err, &nf)
+	require.Equal(t, id, nf.ID)
+}
+
+func requireNotFound(t *testing.T, err error, kind string) {
+	t.Helper()
+	require.Error(t, err)
+	require.ErrorIs(t, err, keg.ErrNotExist)
+	var nf *keg.NotFoundError
+	require.ErrorAs(t, err, &nf)
+	require.Equal(t, kind, nf.Kind)
+}
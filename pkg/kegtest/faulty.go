@@ -0,0 +1,479 @@
+package kegtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jlrickert/tapper/pkg/keg"
+)
+
+// ErrInjected is returned by calls a FaultyRepo fails without a Fault.Err.
+var ErrInjected = errors.New("kegtest: injected fault")
+
+// Fault describes calls a FaultyRepo should fail or slow down. Empty match
+// fields match anything.
+type Fault struct {
+	// Op is the repository method to match, such as "WriteIndex".
+	Op string
+	// Node matches the node id path, such as "3".
+	Node string
+	// Name matches the index, file or image name.
+	Name string
+
+	// After lets this many matching calls through before the fault fires.
+	After int
+	// Times limits how often the fault fires; 0 means every matching call.
+	Times int
+
+	// Err is the error matching calls return. Defaults to ErrInjected.
+	Err error
+	// Truncate, when positive, makes a failing write store its first
+	// Truncate bytes before returning the error, as a crash part way through
+	// a non-atomic write would. It applies to content, meta, index, file and
+	// image writes; a truncated WriteNode stores only the cut content, or
+	// the cut meta when content is nil.
+	Truncate int
+	// Latency delays matching calls. A fault with Latency and neither Err
+	// nor Truncate only delays. The delay ends early when the context is
+	// canceled, failing the call with the context error.
+	Latency time.Duration
+}
+
+// delayOnly reports whether the fault slows calls without failing them.
+func (f Fault) delayOnly() bool {
+	return f.Latency > 0 && f.Err == nil && f.Truncate <= 0
+}
+
+type activeFault struct {
+	Fault
+	seen  int
+	fired int
+}
+
+// FaultyRepo wraps a keg.Repository and fails or delays the calls matched by
+// its faults, so tests can check that Keg, Dex and sync recover from backend
+// errors. Calls no fault matches are forwarded unchanged. Besides
+// Repository it forwards files, images and the keg-wide lock.
+//
+//	repo := kegtest.NewFaultyRepo(keg.NewMemoryRepo(rt)).
+//		Inject(kegtest.Fault{Op: "WriteIndex", Name: "nodes.tsv", Truncate: 40, Times: 1})
+type FaultyRepo struct {
+	// Repo is the wrapped backend.
+	Repo keg.Repository
+
+	mu     sync.Mutex
+	faults []*activeFault
+	calls  map[string]int
+}
+
+var _ keg.Repository = (*FaultyRepo)(nil)
+var _ keg.RepositoryFiles = (*FaultyRepo)(nil)
+var _ keg.RepositoryImages = (*FaultyRepo)(nil)
+var _ keg.RepositoryKegLock = (*FaultyRepo)(nil)
+
+// NewFaultyRepo returns a FaultyRepo forwarding to repo with no faults.
+func NewFaultyRepo(repo keg.Repository) *FaultyRepo {
+	return &FaultyRepo{Repo: repo, calls: map[string]int{}}
+}
+
+// Inject adds a fault and returns r so calls chain. Faults are checked in
+// the order they were added and the first one firing for a call applies.
+func (r *FaultyRepo) Inject(f Fault) *FaultyRepo {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.faults = append(r.faults, &activeFault{Fault: f})
+	return r
+}
+
+// Heal removes every fault, letting all calls through.
+func (r *FaultyRepo) Heal() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.faults = nil
+}
+
+// Calls returns how many times op was called, failed or not.
+func (r *FaultyRepo) Calls(op string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.calls[op]
+}
+
+// Fired returns how many calls the faults have failed or delayed.
+func (r *FaultyRepo) Fired() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := 0
+	for _, f := range r.faults {
+		n += f.fired
+	}
+	return n
+}
+
+// check counts the call and returns the fault firing for it, if any.
+func (r *FaultyRepo) check(op, node, name string) *Fault {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls[op]++
+	for _, f := range r.faults {
+		if (f.Op != "" && f.Op != op) || (f.Node != "" && f.Node != node) || (f.Name != "" && f.Name != name) {
+			continue
+		}
+		f.seen++
+		if f.seen <= f.After || (f.Times > 0 && f.fired >= f.Times) {
+			continue
+		}
+		f.fired++
+		fault := f.Fault
+		return &fault
+	}
+	return nil
+}
+
+// inject applies the fault firing for a call. It returns nil when the call
+// should be forwarded, after any latency.
+func (r *FaultyRepo) inject(ctx context.Context, op, node, name string) (*Fault, error) {
+	f := r.check(op, node, name)
+	if f == nil {
+		return nil, nil
+	}
+	if f.Latency > 0 {
+		timer := time.NewTimer(f.Latency)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+	if f.delayOnly() {
+		return nil, nil
+	}
+	return f, f.err(op, node, name)
+}
+
+func (f *Fault) err(op, node, name string) error {
+	err := f.Err
+	if err == nil {
+		err = ErrInjected
+	}
+	target := node
+	if name != "" {
+		target = fmt.Sprintf("%s %s", node, name)
+	}
+	if target == "" {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return fmt.Errorf("%s %s: %w", op, target, err)
+}
+
+// truncated returns the prefix of data a failing write stores, or nil when
+// it stores nothing.
+func (f *Fault) truncated(data []byte) []byte {
+	if f == nil || f.Truncate <= 0 || data == nil {
+		return nil
+	}
+	return data[:min(f.Truncate, len(data))]
+}
+
+// Name returns the wrapped backend's name.
+func (r *FaultyRepo) Name() string {
+	return r.Repo.Name()
+}
+
+func (r *FaultyRepo) HasNode(ctx context.Context, id keg.NodeId) (bool, error) {
+	if _, err := r.inject(ctx, "HasNode", id.Path(), ""); err != nil {
+		return false, err
+	}
+	return r.Repo.HasNode(ctx, id)
+}
+
+func (r *FaultyRepo) Next(ctx context.Context) (keg.NodeId, error) {
+	if _, err := r.inject(ctx, "Next", "", ""); err != nil {
+		return keg.NodeId{}, err
+	}
+	return r.Repo.Next(ctx)
+}
+
+func (r *FaultyRepo) ListNodes(ctx context.Context) ([]keg.NodeId, error) {
+	if _, err := r.inject(ctx, "ListNodes", "", ""); err != nil {
+		return nil, err
+	}
+	return r.Repo.ListNodes(ctx)
+}
+
+func (r *FaultyRepo) MoveNode(ctx context.Context, id keg.NodeId, dst keg.NodeId) error {
+	if _, err := r.inject(ctx, "MoveNode", id.Path(), ""); err != nil {
+		return err
+	}
+	return r.Repo.MoveNode(ctx, id, dst)
+}
+
+func (r *FaultyRepo) DeleteNode(ctx context.Context, id keg.NodeId) error {
+	if _, err := r.inject(ctx, "DeleteNode", id.Path(), ""); err != nil {
+		return err
+	}
+	return r.Repo.DeleteNode(ctx, id)
+}
+
+func (r *FaultyRepo) WithNodeLock(ctx context.Context, id keg.NodeId, fn func(context.Context) error) error {
+	if _, err := r.inject(ctx, "WithNodeLock", id.Path(), ""); err != nil {
+		return err
+	}
+	return r.Repo.WithNodeLock(ctx, id, fn)
+}
+
+func (r *FaultyRepo) ReadContent(ctx context.Context, id keg.NodeId) ([]byte, error) {
+	if _, err := r.inject(ctx, "ReadContent", id.Path(), ""); err != nil {
+		return nil, err
+	}
+	return r.Repo.ReadContent(ctx, id)
+}
+
+func (r *FaultyRepo) WriteContent(ctx context.Context, id keg.NodeId, data []byte) error {
+	f, err := r.inject(ctx, "WriteContent", id.Path(), "")
+	if err != nil {
+		if part := f.truncated(data); part != nil {
+			_ = r.Repo.WriteContent(ctx, id, part)
+		}
+		return err
+	}
+	return r.Repo.WriteContent(ctx, id, data)
+}
+
+func (r *FaultyRepo) ReadMeta(ctx context.Context, id keg.NodeId) ([]byte, error) {
+	if _, err := r.inject(ctx, "ReadMeta", id.Path(), ""); err != nil {
+		return nil, err
+	}
+	return r.Repo.ReadMeta(ctx, id)
+}
+
+func (r *FaultyRepo) WriteMeta(ctx context.Context, id keg.NodeId, data []byte) error {
+	f, err := r.inject(ctx, "WriteMeta", id.Path(), "")
+	if err != nil {
+		if part := f.truncated(data); part != nil {
+			_ = r.Repo.WriteMeta(ctx, id, part)
+		}
+		return err
+	}
+	return r.Repo.WriteMeta(ctx, id, data)
+}
+
+func (r *FaultyRepo) ReadStats(ctx context.Context, id keg.NodeId) (*keg.NodeStats, error) {
+	if _, err := r.inject(ctx, "ReadStats", id.Path(), ""); err != nil {
+		return nil, err
+	}
+	return r.Repo.ReadStats(ctx, id)
+}
+
+func (r *FaultyRepo) WriteStats(ctx context.Context, id keg.NodeId, stats *keg.NodeStats) error {
+	if _, err := r.inject(ctx, "WriteStats", id.Path(), ""); err != nil {
+		return err
+	}
+	return r.Repo.WriteStats(ctx, id, stats)
+}
+
+func (r *FaultyRepo) WriteNode(ctx context.Context, id keg.NodeId, content, meta []byte, stats *keg.NodeStats) error {
+	f, err := r.inject(ctx, "WriteNode", id.Path(), "")
+	if err != nil {
+		if part := f.truncated(content); part != nil {
+			_ = r.Repo.WriteContent(ctx, id, part)
+		} else if part := f.truncated(meta); part != nil {
+			_ = r.Repo.WriteMeta(ctx, id, part)
+		}
+		return err
+	}
+	return r.Repo.WriteNode(ctx, id, content, meta, stats)
+}
+
+func (r *FaultyRepo) GetIndex(ctx context.Context, name string) ([]byte, error) {
+	if _, err := r.inject(ctx, "GetIndex", "", name); err != nil {
+		return nil, err
+	}
+	return r.Repo.GetIndex(ctx, name)
+}
+
+func (r *FaultyRepo) WriteIndex(ctx context.Context, name string, data []byte) error {
+	f, err := r.inject(ctx, "WriteIndex", "", name)
+	if err != nil {
+		if part := f.truncated(data); part != nil {
+			_ = r.Repo.WriteIndex(ctx, name, part)
+		}
+		return err
+	}
+	return r.Repo.WriteIndex(ctx, name, data)
+}
+
+func (r *FaultyRepo) ListIndexes(ctx context.Context) ([]string, error) {
+	if _, err := r.inject(ctx, "ListIndexes", "", ""); err != nil {
+		return nil, err
+	}
+	return r.Repo.ListIndexes(ctx)
+}
+
+func (r *FaultyRepo) ClearIndexes(ctx context.Context) error {
+	if _, err := r.inject(ctx, "ClearIndexes", "", ""); err != nil {
+		return err
+	}
+	return r.Repo.ClearIndexes(ctx)
+}
+
+func (r *FaultyRepo) ReadConfig(ctx context.Context) (*keg.Config, error) {
+	if _, err := r.inject(ctx, "ReadConfig", "", ""); err != nil {
+		return nil, err
+	}
+	return r.Repo.ReadConfig(ctx)
+}
+
+func (r *FaultyRepo) WriteConfig(ctx context.Context, config *keg.Config) error {
+	if _, err := r.inject(ctx, "WriteConfig", "", ""); err != nil {
+		return err
+	}
+	return r.Repo.WriteConfig(ctx, config)
+}
+
+// WithKegLock forwards to the wrapped backend's keg lock, running fn
+// directly when it has none.
+func (r *FaultyRepo) WithKegLock(ctx context.Context, fn func(context.Context) error) error {
+	if _, err := r.inject(ctx, "WithKegLock", "", ""); err != nil {
+		return err
+	}
+	if locker, ok := r.Repo.(keg.RepositoryKegLock); ok {
+		return locker.WithKegLock(ctx, fn)
+	}
+	return fn(ctx)
+}
+
+// KegLockOwner returns the wrapped backend's keg lock holder, or nil when it
+// has no keg lock.
+func (r *FaultyRepo) KegLockOwner(ctx context.Context) (*keg.LockOwner, error) {
+	locker, ok := r.Repo.(keg.RepositoryKegLock)
+	if !ok {
+		return nil, nil
+	}
+	return locker.KegLockOwner(ctx)
+}
+
+// BreakKegLock forwards to the wrapped backend's keg lock, if any.
+func (r *FaultyRepo) BreakKegLock(ctx context.Context) error {
+	locker, ok := r.Repo.(keg.RepositoryKegLock)
+	if !ok {
+		return nil
+	}
+	return locker.BreakKegLock(ctx)
+}
+
+func (r *FaultyRepo) ListFiles(ctx context.Context, id keg.NodeId) ([]string, error) {
+	if _, err := r.inject(ctx, "ListFiles", id.Path(), ""); err != nil {
+		return nil, err
+	}
+	files, err := r.files("ListFiles")
+	if err != nil {
+		return nil, err
+	}
+	return files.ListFiles(ctx, id)
+}
+
+func (r *FaultyRepo) ReadFile(ctx context.Context, id keg.NodeId, name string) ([]byte, error) {
+	if _, err := r.inject(ctx, "ReadFile", id.Path(), name); err != nil {
+		return nil, err
+	}
+	files, err := r.files("ReadFile")
+	if err != nil {
+		return nil, err
+	}
+	return files.ReadFile(ctx, id, name)
+}
+
+func (r *FaultyRepo) WriteFile(ctx context.Context, id keg.NodeId, name string, data []byte) error {
+	files, err := r.files("WriteFile")
+	if err != nil {
+		return err
+	}
+	f, err := r.inject(ctx, "WriteFile", id.Path(), name)
+	if err != nil {
+		if part := f.truncated(data); part != nil {
+			_ = files.WriteFile(ctx, id, name, part)
+		}
+		return err
+	}
+	return files.WriteFile(ctx, id, name, data)
+}
+
+func (r *FaultyRepo) DeleteFile(ctx context.Context, id keg.NodeId, name string) error {
+	if _, err := r.inject(ctx, "DeleteFile", id.Path(), name); err != nil {
+		return err
+	}
+	files, err := r.files("DeleteFile")
+	if err != nil {
+		return err
+	}
+	return files.DeleteFile(ctx, id, name)
+}
+
+func (r *FaultyRepo) ListImages(ctx context.Context, id keg.NodeId) ([]string, error) {
+	if _, err := r.inject(ctx, "ListImages", id.Path(), ""); err != nil {
+		return nil, err
+	}
+	images, err := r.images("ListImages")
+	if err != nil {
+		return nil, err
+	}
+	return images.ListImages(ctx, id)
+}
+
+func (r *FaultyRepo) ReadImage(ctx context.Context, id keg.NodeId, name string) ([]byte, error) {
+	if _, err := r.inject(ctx, "ReadImage", id.Path(), name); err != nil {
+		return nil, err
+	}
+	images, err := r.images("ReadImage")
+	if err != nil {
+		return nil, err
+	}
+	return images.ReadImage(ctx, id, name)
+}
+
+func (r *FaultyRepo) WriteImage(ctx context.Context, id keg.NodeId, name string, data []byte) error {
+	images, err := r.images("WriteImage")
+	if err != nil {
+		return err
+	}
+	f, err := r.inject(ctx, "WriteImage", id.Path(), name)
+	if err != nil {
+		if part := f.truncated(data); part != nil {
+			_ = images.WriteImage(ctx, id, name, part)
+		}
+		return err
+	}
+	return images.WriteImage(ctx, id, name, data)
+}
+
+func (r *FaultyRepo) DeleteImage(ctx context.Context, id keg.NodeId, name string) error {
+	if _, err := r.inject(ctx, "DeleteImage", id.Path(), name); err != nil {
+		return err
+	}
+	images, err := r.images("DeleteImage")
+	if err != nil {
+		return err
+	}
+	return images.DeleteImage(ctx, id, name)
+}
+
+func (r *FaultyRepo) files(op string) (keg.RepositoryFiles, error) {
+	files, ok := r.Repo.(keg.RepositoryFiles)
+	if !ok {
+		return nil, fmt.Errorf("%s: %s has no file attachments: %w", op, r.Repo.Name(), keg.ErrNotSupported)
+	}
+	return files, nil
+}
+
+func (r *FaultyRepo) images(op string) (keg.RepositoryImages, error) {
+	images, ok := r.Repo.(keg.RepositoryImages)
+	if !ok {
+		return nil, fmt.Errorf("%s: %s has no images: %w", op, r.Repo.Name(), keg.ErrNotSupported)
+	}
+	return images, nil
+}
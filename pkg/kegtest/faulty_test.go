@@ -0,0 +1,51 @@
+package kegtest_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jlrickert/cli-toolkit/sandbox"
+	"github.com/jlrickert/tapper/pkg/keg"
+	"github.com/jlrickert/tapper/pkg/kegtest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFaultyRepo_Faults(t *testing.T) {
+	t.Parallel()
+	sb := sandbox.NewSandbox(t, &sandbox.Options{Home: "/home/testuser", User: "testuser"})
+	ctx, rt := sb.Context(), sb.Runtime()
+	inner := keg.NewMemoryRepo(rt)
+	repo := kegtest.NewFaultyRepo(inner)
+	one := keg.NodeId{ID: 1}
+
+	errDown := errors.New("down")
+	repo.Inject(kegtest.Fault{Op: "WriteContent", Node: "1", After: 1, Times: 1, Err: errDown})
+	require.NoError(t, repo.WriteContent(ctx, one, []byte("first")))
+	require.ErrorIs(t, repo.WriteContent(ctx, one, []byte("second")), errDown)
+	require.NoError(t, repo.WriteContent(ctx, one, []byte("third")))
+	require.Equal(t, 3, repo.Calls("WriteContent"))
+	require.Equal(t, 1, repo.Fired())
+
+	repo.Inject(kegtest.Fault{Op: "WriteIndex", Name: "nodes.tsv", Truncate: 4})
+	require.NoError(t, repo.WriteIndex(ctx, "tags", []byte("go 1\n")))
+	err := repo.WriteIndex(ctx, "nodes.tsv", []byte("1\tOne\n"))
+	require.ErrorIs(t, err, kegtest.ErrInjected)
+	data, err := inner.GetIndex(ctx, "nodes.tsv")
+	require.NoError(t, err)
+	require.Equal(t, "1\tOn", string(data))
+
+	repo.Heal()
+	repo.Inject(kegtest.Fault{Op: "ReadContent", Latency: time.Second})
+	timeout, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	_, err = repo.ReadContent(timeout, one)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	repo.Heal()
+	repo.Inject(kegtest.Fault{Op: "ReadContent", Latency: time.Millisecond})
+	content, err := repo.ReadContent(ctx, one)
+	require.NoError(t, err, "latency alone does not fail the call")
+	require.Equal(t, "third", string(content))
+}
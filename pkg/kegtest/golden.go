@@ -0,0 +1,117 @@
+package kegtest
+
+import (
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/jlrickert/tapper/pkg/keg"
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files with the output of the current code")
+
+// AssertGolden fails tb with a unified diff when got differs from the golden
+// file at path. With -update it writes got to path instead, creating parent
+// directories as needed.
+func AssertGolden(tb testing.TB, path string, got []byte) {
+	tb.Helper()
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			tb.Fatalf("kegtest: %v", err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			tb.Fatalf("kegtest: %v", err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		tb.Fatalf("kegtest: golden file %s is missing; run go test -update to create it", path)
+	}
+	if err != nil {
+		tb.Fatalf("kegtest: %v", err)
+	}
+	if string(want) != string(got) {
+		tb.Errorf("kegtest: output differs from %s (run go test -update to accept it):\n%s",
+			path, diff(path, want, got))
+	}
+}
+
+// AssertDexGolden compares every index artifact in repo, such as nodes.tsv,
+// changes.md, tags, links and backlinks, with the file of the same name in
+// dir. An index without a golden file, or a golden file without an index,
+// fails tb. With -update dir is rewritten to match repo.
+func AssertDexGolden(tb testing.TB, ctx context.Context, repo keg.Repository, dir string) {
+	tb.Helper()
+	names, err := repo.ListIndexes(ctx)
+	if err != nil {
+		tb.Fatalf("kegtest: unable to list indexes: %v", err)
+	}
+
+	goldens := map[string]bool{}
+	entries, err := os.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		tb.Fatalf("kegtest: %v", err)
+	}
+	for _, e := range entries {
+		if !e.IsDir() {
+			goldens[e.Name()] = true
+		}
+	}
+
+	for _, name := range names {
+		data, err := repo.GetIndex(ctx, name)
+		if err != nil {
+			tb.Fatalf("kegtest: unable to read index %s: %v", name, err)
+		}
+		if !*update && !goldens[name] {
+			tb.Errorf("kegtest: index %s has no golden file in %s; run go test -update to add it", name, dir)
+			continue
+		}
+		AssertGolden(tb, filepath.Join(dir, name), data)
+	}
+	for name := range goldens {
+		if slices.Contains(names, name) {
+			continue
+		}
+		path := filepath.Join(dir, name)
+		if *update {
+			if err := os.Remove(path); err != nil {
+				tb.Fatalf("kegtest: %v", err)
+			}
+			continue
+		}
+		tb.Errorf("kegtest: golden file %s has no matching index", path)
+	}
+}
+
+// diff renders a unified diff of want and got. Tabs are shown as "→" so
+// column changes in TSV indexes stay visible.
+func diff(path string, want, got []byte) string {
+	visible := func(b []byte) []string {
+		lines := strings.SplitAfter(string(b), "\n")
+		if lines[len(lines)-1] == "" {
+			lines = lines[:len(lines)-1]
+		}
+		for i, ln := range lines {
+			lines[i] = strings.ReplaceAll(ln, "\t", "→")
+		}
+		return lines
+	}
+	text, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        visible(want),
+		B:        visible(got),
+		FromFile: path,
+		ToFile:   "got",
+		Context:  2,
+	})
+	if err != nil {
+		return err.Error()
+	}
+	return text
+}
@@ -0,0 +1,21 @@
+package kegtest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiff_ShowsChangedColumns(t *testing.T) {
+	t.Parallel()
+	want := []byte("1\t2025-01-02T00:00:00Z\tOne\n2\t2025-01-02T00:00:00Z\tTwo\n")
+	got := []byte("1\t2025-01-02T00:00:00Z\tOne\n2\t2025-01-03T00:00:00Z\t\tTwo\n")
+
+	require.Equal(t, `--- testdata/nodes.tsv
++++ got
+@@ -1,2 +1,2 @@
+ 1→2025-01-02T00:00:00Z→One
+-2→2025-01-02T00:00:00Z→Two
++2→2025-01-03T00:00:00Z→→Two
+`, diff("testdata/nodes.tsv", want, got))
+}
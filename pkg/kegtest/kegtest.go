@@ -0,0 +1,184 @@
+// Package kegtest builds kegs for tests. A KegFixture describes nodes with
+// their content, tags, links and meta attributes; Build writes them into any
+// keg.Repository and indexes the result, so tests of tools embedding pkg/keg
+// can start from a realistic keg without copying fixture directories.
+//
+//	k := kegtest.NewKegFixture().
+//		Title("Notes").
+//		Node(1, "Go tips", "Prefer small interfaces.").Tag("go").
+//		Node(2, "Testing", "Table tests read well.").Tag("go", "testing").Link(1).
+//		MustBuild(t, ctx, keg.NewMemoryRepo(rt), rt)
+package kegtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/jlrickert/cli-toolkit/toolkit"
+	"github.com/jlrickert/tapper/pkg/keg"
+)
+
+// KegFixture describes a keg to build. Its methods return the fixture so
+// calls chain; Tag, Link and Attr apply to the node added last. Mistakes
+// such as a repeated node id are reported by Build.
+type KegFixture struct {
+	title string
+	nodes []*nodeFixture
+	byID  map[int]*nodeFixture
+	err   error
+}
+
+type nodeFixture struct {
+	id    int
+	title string
+	body  string
+	tags  []string
+	links []int
+	attrs []attr
+}
+
+type attr struct {
+	key   string
+	value any
+}
+
+// NewKegFixture returns an empty fixture. Built as is it holds only the
+// zero node keg.Keg.Init creates.
+func NewKegFixture() *KegFixture {
+	return &KegFixture{byID: map[int]*nodeFixture{}}
+}
+
+// Title sets the title in the keg config.
+func (f *KegFixture) Title(title string) *KegFixture {
+	f.title = title
+	return f
+}
+
+// Node adds node id with a markdown title heading followed by body. Node 0
+// replaces the content of the zero node.
+func (f *KegFixture) Node(id int, title, body string) *KegFixture {
+	switch {
+	case id < 0:
+		f.fail(fmt.Errorf("node %d: negative id", id))
+	case f.node(id) != nil:
+		f.fail(fmt.Errorf("node %d: added twice", id))
+	default:
+		n := &nodeFixture{id: id, title: title, body: body}
+		f.nodes = append(f.nodes, n)
+		f.byID[id] = n
+	}
+	return f
+}
+
+// Tag adds tags to the last node's meta.
+func (f *KegFixture) Tag(tags ...string) *KegFixture {
+	if n := f.last("Tag"); n != nil {
+		n.tags = append(n.tags, tags...)
+	}
+	return f
+}
+
+// Link appends a list of links to nodes ids to the last node's content. The
+// link text is the target's title when the fixture defines it.
+func (f *KegFixture) Link(ids ...int) *KegFixture {
+	if n := f.last("Link"); n != nil {
+		n.links = append(n.links, ids...)
+	}
+	return f
+}
+
+// Attr sets a meta attribute of the last node.
+func (f *KegFixture) Attr(key string, value any) *KegFixture {
+	if n := f.last("Attr"); n != nil {
+		n.attrs = append(n.attrs, attr{key: key, value: value})
+	}
+	return f
+}
+
+// Build initializes a keg in repo, which should be empty, writes the
+// fixture's nodes and rebuilds the indexes.
+func (f *KegFixture) Build(ctx context.Context, repo keg.Repository, rt *toolkit.Runtime) (*keg.Keg, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	k := keg.NewKeg(repo, rt)
+	if err := k.Init(ctx); err != nil {
+		return nil, fmt.Errorf("unable to init keg: %w", err)
+	}
+	if f.title != "" {
+		if err := k.UpdateConfig(ctx, func(cfg *keg.Config) { cfg.Title = f.title }); err != nil {
+			return nil, fmt.Errorf("unable to set keg title: %w", err)
+		}
+	}
+
+	now := rt.Clock().Now()
+	for _, n := range f.nodes {
+		meta := keg.NewMeta(ctx, now)
+		if len(n.tags) > 0 {
+			meta.SetTags(n.tags)
+		}
+		for _, a := range n.attrs {
+			if err := meta.Set(ctx, a.key, a.value); err != nil {
+				return nil, fmt.Errorf("node %d: unable to set %s: %w", n.id, a.key, err)
+			}
+		}
+		id := keg.NodeId{ID: n.id}
+		if err := repo.WriteNode(ctx, id, []byte(f.content(n)), []byte(meta.ToYAML()), nil); err != nil {
+			return nil, fmt.Errorf("node %d: %w", n.id, err)
+		}
+	}
+	if err := k.Index(ctx, keg.IndexOptions{Rebuild: true}); err != nil {
+		return nil, fmt.Errorf("unable to index keg: %w", err)
+	}
+	return k, nil
+}
+
+// MustBuild is Build that fails tb on error.
+func (f *KegFixture) MustBuild(tb testing.TB, ctx context.Context, repo keg.Repository, rt *toolkit.Runtime) *keg.Keg {
+	tb.Helper()
+	k, err := f.Build(ctx, repo, rt)
+	if err != nil {
+		tb.Fatalf("kegtest: %v", err)
+	}
+	return k
+}
+
+func (f *KegFixture) content(n *nodeFixture) string {
+	var b strings.Builder
+	b.WriteString("# " + n.title + "\n")
+	if body := strings.TrimSpace(n.body); body != "" {
+		b.WriteString("\n" + body + "\n")
+	}
+	if len(n.links) > 0 {
+		b.WriteString("\n")
+		for _, id := range n.links {
+			text := strconv.Itoa(id)
+			if target := f.node(id); target != nil && target.title != "" {
+				text = target.title
+			}
+			fmt.Fprintf(&b, "- [%s](../%d)\n", text, id)
+		}
+	}
+	return b.String()
+}
+
+func (f *KegFixture) node(id int) *nodeFixture {
+	return f.byID[id]
+}
+
+// last returns the node added last, recording an error when there is none.
+func (f *KegFixture) last(method string) *nodeFixture {
+	if len(f.nodes) == 0 {
+		f.fail(fmt.Errorf("%s called before Node", method))
+		return nil
+	}
+	return f.nodes[len(f.nodes)-1]
+}
+
+func (f *KegFixture) fail(err error) {
+	f.err = errors.Join(f.err, err)
+}
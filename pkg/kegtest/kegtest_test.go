@@ -0,0 +1,70 @@
+package kegtest_test
+
+import (
+	"testing"
+
+	"github.com/jlrickert/cli-toolkit/sandbox"
+	"github.com/jlrickert/tapper/pkg/keg"
+	"github.com/jlrickert/tapper/pkg/kegtest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKegFixture_Build(t *testing.T) {
+	t.Parallel()
+	sb := sandbox.NewSandbox(t, &sandbox.Options{Home: "/home/testuser", User: "testuser"})
+	ctx, rt := sb.Context(), sb.Runtime()
+
+	fixture := kegtest.NewKegFixture().
+		Title("Notes").
+		Node(0, "Index", "Start here.").Link(1, 2).
+		Node(1, "Go tips", "Prefer small interfaces.").Tag("go").Attr("status", "draft").
+		Node(2, "Testing", "Table tests read well.").Tag("go", "testing").Link(1)
+
+	for name, repo := range map[string]keg.Repository{
+		"memory": keg.NewMemoryRepo(rt),
+		"fs":     keg.NewFsRepo("~/notes", rt),
+	} {
+		t.Run(name, func(t *testing.T) {
+			k := fixture.MustBuild(t, ctx, repo, rt)
+
+			cfg, err := k.Config(ctx)
+			require.NoError(t, err)
+			require.Equal(t, "Notes", cfg.Title)
+
+			dex, err := k.Dex(ctx)
+			require.NoError(t, err)
+			titles := map[string]string{}
+			for _, entry := range dex.Nodes(ctx) {
+				titles[entry.ID] = entry.Title
+			}
+			require.Equal(t, map[string]string{"0": "Index", "1": "Go tips", "2": "Testing"}, titles)
+
+			tagged, _ := dex.TagNodes(ctx, "go")
+			require.Equal(t, []keg.NodeId{{ID: 1}, {ID: 2}}, tagged)
+			backlinks, _ := dex.Backlinks(ctx, keg.NodeId{ID: 1})
+			require.Equal(t, []keg.NodeId{{ID: 0}, {ID: 2}}, backlinks)
+
+			content, err := k.GetContent(ctx, keg.NodeId{ID: 2})
+			require.NoError(t, err)
+			require.Equal(t, "# Testing\n\nTable tests read well.\n\n- [Go tips](../1)\n", string(content))
+
+			meta, err := k.GetMeta(ctx, keg.NodeId{ID: 1})
+			require.NoError(t, err)
+			status, _ := meta.Get("status")
+			require.Equal(t, "draft", status)
+		})
+	}
+}
+
+func TestKegFixture_BuildReportsMistakes(t *testing.T) {
+	t.Parallel()
+	sb := sandbox.NewSandbox(t, &sandbox.Options{Home: "/home/testuser", User: "testuser"})
+
+	_, err := kegtest.NewKegFixture().
+		Tag("orphan").
+		Node(1, "One", "").
+		Node(1, "Again", "").
+		Build(sb.Context(), keg.NewMemoryRepo(sb.Runtime()), sb.Runtime())
+	require.ErrorContains(t, err, "Tag called before Node")
+	require.ErrorContains(t, err, "node 1: added twice")
+}
@@ -85,6 +85,7 @@ func TestMCP_ToolsList(t *testing.T) {
 	require.Contains(t, names, "tags")
 	require.Contains(t, names, "backlinks")
 	require.Contains(t, names, "links")
+	require.Contains(t, names, "related")
 	require.Contains(t, names, "list_kegs")
 	require.Contains(t, names, "info")
 	require.Contains(t, names, "keg_info")
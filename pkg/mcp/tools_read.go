@@ -15,6 +15,7 @@ func registerReadTools(srv *sdkmcp.Server, tap *tapper.Tap, defaults KegDefaults
 	registerTags(srv, tap, defaults)
 	registerBacklinks(srv, tap, defaults)
 	registerLinks(srv, tap, defaults)
+	registerRelated(srv, tap, defaults)
 	registerListKegs(srv, tap)
 	registerInfo(srv, tap, defaults)
 	registerKegInfo(srv, tap, defaults)
@@ -31,6 +32,7 @@ type catInput struct {
 	MetaOnly    bool     `json:"meta_only,omitempty" jsonschema:"return metadata only"`
 	StatsOnly   bool     `json:"stats_only,omitempty" jsonschema:"return stats only"`
 	Tag         string   `json:"tag,omitempty" jsonschema:"tag expression to select nodes (alternative to node_ids)"`
+	Exact       bool     `json:"exact,omitempty" jsonschema:"disable fuzzy title matching for non-numeric node IDs"`
 }
 
 func registerCat(srv *sdkmcp.Server, tap *tapper.Tap, defaults KegDefaults) {
@@ -45,6 +47,7 @@ func registerCat(srv *sdkmcp.Server, tap *tapper.Tap, defaults KegDefaults) {
 			ContentOnly:      in.ContentOnly,
 			MetaOnly:         in.MetaOnly,
 			StatsOnly:        in.StatsOnly,
+			Exact:            in.Exact,
 		}
 		result, err := tap.Cat(ctx, opts)
 		if err != nil {
@@ -58,6 +61,10 @@ func registerCat(srv *sdkmcp.Server, tap *tapper.Tap, defaults KegDefaults) {
 
 type listInput struct {
 	Query   string `json:"query,omitempty" jsonschema:"boolean query expression to filter nodes (e.g. 'golang and entity=concept')"`
+	Where   string `json:"where,omitempty" jsonschema:"metadata filter (e.g. 'created > 2025-01-01 and tags has go and attrs.status = active')"`
+	Saved   string `json:"saved,omitempty" jsonschema:"name of a saved search from the keg config"`
+	Since   string `json:"since,omitempty" jsonschema:"only nodes updated at or after this time (RFC3339, date, or duration like 7d)"`
+	Until   string `json:"until,omitempty" jsonschema:"only nodes updated at or before this time (RFC3339, date, or duration like 7d)"`
 	Keg     string `json:"keg,omitempty" jsonschema:"keg alias (uses default if empty)"`
 	Format  string `json:"format,omitempty" jsonschema:"output format (%i=id %d=date %t=title)"`
 	IdOnly  bool   `json:"id_only,omitempty" jsonschema:"return node IDs only"`
@@ -73,6 +80,9 @@ func registerList(srv *sdkmcp.Server, tap *tapper.Tap, defaults KegDefaults) {
 		opts := tapper.ListOptions{
 			KegTargetOptions: resolveKegTarget(in.Keg, defaults),
 			Query:            in.Query,
+			Where:            in.Where,
+			Saved:            in.Saved,
+			DateRangeOptions: tapper.DateRangeOptions{Since: in.Since, Until: in.Until},
 			Format:           in.Format,
 			IdOnly:           in.IdOnly,
 			Reverse:          in.Reverse,
@@ -95,6 +105,7 @@ type grepInput struct {
 	IdOnly     bool   `json:"id_only,omitempty" jsonschema:"return node IDs only"`
 	Reverse    bool   `json:"reverse,omitempty" jsonschema:"reverse output order"`
 	IgnoreCase bool   `json:"ignore_case,omitempty" jsonschema:"case-insensitive matching"`
+	Rank       bool   `json:"rank,omitempty" jsonschema:"order results by relevance (term frequency, recency, access count)"`
 }
 
 func registerGrep(srv *sdkmcp.Server, tap *tapper.Tap, defaults KegDefaults) {
@@ -109,6 +120,7 @@ func registerGrep(srv *sdkmcp.Server, tap *tapper.Tap, defaults KegDefaults) {
 			IdOnly:           in.IdOnly,
 			Reverse:          in.Reverse,
 			IgnoreCase:       in.IgnoreCase,
+			Rank:             in.Rank,
 		}
 		lines, err := tap.Grep(ctx, opts)
 		if err != nil {
@@ -158,6 +170,7 @@ type backlinksInput struct {
 	Format  string `json:"format,omitempty" jsonschema:"output format (%i=id %d=date %t=title)"`
 	IdOnly  bool   `json:"id_only,omitempty" jsonschema:"return node IDs only"`
 	Reverse bool   `json:"reverse,omitempty" jsonschema:"reverse output order"`
+	Exact   bool   `json:"exact,omitempty" jsonschema:"disable fuzzy title matching for a non-numeric node ID"`
 }
 
 func registerBacklinks(srv *sdkmcp.Server, tap *tapper.Tap, defaults KegDefaults) {
@@ -171,6 +184,7 @@ func registerBacklinks(srv *sdkmcp.Server, tap *tapper.Tap, defaults KegDefaults
 			Format:           in.Format,
 			IdOnly:           in.IdOnly,
 			Reverse:          in.Reverse,
+			Exact:            in.Exact,
 		}
 		lines, err := tap.Backlinks(ctx, opts)
 		if err != nil {
@@ -180,6 +194,38 @@ func registerBacklinks(srv *sdkmcp.Server, tap *tapper.Tap, defaults KegDefaults
 	})
 }
 
+// --- related ---
+
+type relatedInput struct {
+	NodeID string `json:"node_id" jsonschema:"node ID to find related nodes for"`
+	Keg    string `json:"keg,omitempty" jsonschema:"keg alias (uses default if empty)"`
+	Limit  int    `json:"limit,omitempty" jsonschema:"maximum number of suggestions (0=unlimited)"`
+	Format string `json:"format,omitempty" jsonschema:"output format (%i=id %d=date %t=title %s=score)"`
+	IdOnly bool   `json:"id_only,omitempty" jsonschema:"return node IDs only"`
+	Exact  bool   `json:"exact,omitempty" jsonschema:"disable fuzzy title matching for a non-numeric node ID"`
+}
+
+func registerRelated(srv *sdkmcp.Server, tap *tapper.Tap, defaults KegDefaults) {
+	sdkmcp.AddTool(srv, &sdkmcp.Tool{
+		Name:        "related",
+		Description: "Suggest nodes related to a given node by shared tags, links, and backlinks",
+	}, func(ctx context.Context, req *sdkmcp.CallToolRequest, in relatedInput) (*sdkmcp.CallToolResult, any, error) {
+		opts := tapper.RelatedOptions{
+			KegTargetOptions: resolveKegTarget(in.Keg, defaults),
+			NodeID:           in.NodeID,
+			Limit:            in.Limit,
+			Format:           in.Format,
+			IdOnly:           in.IdOnly,
+			Exact:            in.Exact,
+		}
+		lines, err := tap.Related(ctx, opts)
+		if err != nil {
+			return errorResult(err), nil, nil
+		}
+		return linesResult(lines), nil, nil
+	})
+}
+
 // --- links ---
 
 type linksInput struct {
@@ -188,6 +234,7 @@ type linksInput struct {
 	Format  string `json:"format,omitempty" jsonschema:"output format (%i=id %d=date %t=title)"`
 	IdOnly  bool   `json:"id_only,omitempty" jsonschema:"return node IDs only"`
 	Reverse bool   `json:"reverse,omitempty" jsonschema:"reverse output order"`
+	Exact   bool   `json:"exact,omitempty" jsonschema:"disable fuzzy title matching for a non-numeric node ID"`
 }
 
 func registerLinks(srv *sdkmcp.Server, tap *tapper.Tap, defaults KegDefaults) {
@@ -201,6 +248,7 @@ func registerLinks(srv *sdkmcp.Server, tap *tapper.Tap, defaults KegDefaults) {
 			Format:           in.Format,
 			IdOnly:           in.IdOnly,
 			Reverse:          in.Reverse,
+			Exact:            in.Exact,
 		}
 		lines, err := tap.Links(ctx, opts)
 		if err != nil {
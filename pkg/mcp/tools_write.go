@@ -127,6 +127,7 @@ func registerMeta(srv *sdkmcp.Server, tap *tapper.Tap, defaults KegDefaults) {
 
 type removeInput struct {
 	NodeIDs []string `json:"node_ids" jsonschema:"node IDs to remove"`
+	Force   bool     `json:"force,omitempty" jsonschema:"remove nodes even when other nodes link to them"`
 	Keg     string   `json:"keg,omitempty" jsonschema:"keg alias (uses default if empty)"`
 }
 
@@ -138,9 +139,10 @@ func registerRemove(srv *sdkmcp.Server, tap *tapper.Tap, defaults KegDefaults) {
 		opts := tapper.RemoveOptions{
 			KegTargetOptions: resolveKegTarget(in.Keg, defaults),
 			NodeIDs:          in.NodeIDs,
+			Force:            in.Force,
 		}
 
-		if err := tap.Remove(ctx, opts); err != nil {
+		if _, err := tap.Remove(ctx, opts); err != nil {
 			return errorResult(err), nil, nil
 		}
 		return textResult(fmt.Sprintf("removed %d node(s)", len(in.NodeIDs))), nil, nil
@@ -166,7 +168,7 @@ func registerMove(srv *sdkmcp.Server, tap *tapper.Tap, defaults KegDefaults) {
 			DestID:           in.DestID,
 		}
 
-		if err := tap.Move(ctx, opts); err != nil {
+		if _, err := tap.Move(ctx, opts); err != nil {
 			return errorResult(err), nil, nil
 		}
 		return textResult(fmt.Sprintf("moved node %s to %s", in.SourceID, in.DestID)), nil, nil
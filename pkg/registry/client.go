@@ -0,0 +1,600 @@
+// Package registry is a client for the keg registry HTTP API ("knut"). It
+// lists and creates kegs for a user, reads and writes nodes, and searches a
+// keg. The package knows nothing about keg types; pkg/keg adapts it into a
+// Repository for registry targets.
+package registry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// APIPrefix is the path prefix of every registry endpoint.
+const APIPrefix = "/api/v1"
+
+// Sentinel errors matched by *Error through errors.Is.
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrConflict     = errors.New("conflict")
+)
+
+// Error reports a non-2xx response from the registry.
+type Error struct {
+	// Op names the client method, for example "ReadNode".
+	Op         string
+	StatusCode int
+	// Message is the response body, trimmed.
+	Message string
+
+	// RetryAfter is the wait the registry asked for with Retry-After, zero
+	// when it sent none.
+	RetryAfter time.Duration
+
+	// Attempts is the number of tries made before giving up.
+	Attempts int
+}
+
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("registry %s: %s", e.Op, http.StatusText(e.StatusCode))
+	}
+	return fmt.Sprintf("registry %s: %s: %s", e.Op, http.StatusText(e.StatusCode), e.Message)
+}
+
+// Is maps the status code to ErrNotFound, ErrUnauthorized or ErrConflict.
+func (e *Error) Is(target error) bool {
+	switch e.StatusCode {
+	case http.StatusNotFound:
+		return target == ErrNotFound
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return target == ErrUnauthorized
+	case http.StatusConflict:
+		return target == ErrConflict
+	}
+	return false
+}
+
+// Transient reports whether retrying the request may succeed.
+func (e *Error) Transient() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+// Client talks to one registry.
+type Client struct {
+	// URL is the registry base, e.g. "https://keg.jlrickert.me".
+	URL string
+
+	// Token is sent as a bearer token when set.
+	Token string
+
+	// Client is the HTTP client to use. Nil uses http.DefaultClient.
+	Client *http.Client
+
+	// Retry is the retry policy. Nil uses DefaultRetryPolicy.
+	Retry *RetryPolicy
+
+	// Budgets caps the tries per operation name, for example "NextNode",
+	// below the policy's MaxAttempts. Nil uses DefaultBudgets.
+	Budgets map[string]int
+}
+
+// NewClient returns a client for the registry at rawURL. A URL without a
+// scheme is assumed to be https.
+func NewClient(rawURL, token string) *Client {
+	u := strings.TrimRight(strings.TrimSpace(rawURL), "/")
+	if u != "" && !strings.Contains(u, "://") {
+		u = "https://" + u
+	}
+	return &Client{URL: u, Token: token}
+}
+
+// Keg visibilities understood by the registry.
+const (
+	VisibilityPublic  = "public"
+	VisibilityPrivate = "private"
+)
+
+// Roles a keg can be shared with. Writers can also read.
+const (
+	RoleRead  = "read"
+	RoleWrite = "write"
+)
+
+// Grant is one entry of a keg's access list: a user the keg is shared with
+// and the role they hold. The owner is not listed.
+type Grant struct {
+	User string `json:"user"`
+	Role string `json:"role"`
+}
+
+// Identity is the account a token authenticates as.
+type Identity struct {
+	User string `json:"user"`
+	Name string `json:"name,omitempty"`
+
+	// Namespaces are the users and organizations the account can publish
+	// kegs under, its own user included.
+	Namespaces []string `json:"namespaces,omitempty"`
+
+	Quota Quota `json:"quota"`
+}
+
+// Quota is the usage and limits of an account. A zero limit means the
+// registry sets none.
+type Quota struct {
+	Kegs       int   `json:"kegs"`
+	MaxKegs    int   `json:"maxKegs,omitempty"`
+	Storage    int64 `json:"storage"`
+	MaxStorage int64 `json:"maxStorage,omitempty"`
+
+	// MaxNodeSize caps the content of a single node, in bytes.
+	MaxNodeSize int64 `json:"maxNodeSize,omitempty"`
+
+	// RateLimit is the number of requests allowed per minute.
+	RateLimit int `json:"rateLimit,omitempty"`
+}
+
+// KegInfo describes a keg hosted by the registry.
+type KegInfo struct {
+	User        string    `json:"user"`
+	Name        string    `json:"name"`
+	Title       string    `json:"title,omitempty"`
+	Description string    `json:"description,omitempty"`
+	Visibility  string    `json:"visibility,omitempty"`
+	Nodes       int       `json:"nodes,omitempty"`
+	Updated     time.Time `json:"updated,omitzero"`
+}
+
+// KegUpdate changes the settings of a keg. Empty fields are left as they
+// are.
+type KegUpdate struct {
+	Title      string `json:"title,omitempty"`
+	Visibility string `json:"visibility,omitempty"`
+}
+
+// Node is one node as stored by the registry. Meta is the YAML of meta.yaml
+// and Stats the JSON of the node stats; either may be empty.
+type Node struct {
+	ID      string
+	Content []byte
+	Meta    []byte
+	Stats   []byte
+}
+
+// NodeUpdate changes a node. Nil fields are left as they are, so callers
+// can update content without resending meta and stats.
+type NodeUpdate struct {
+	Content []byte
+	Meta    []byte
+	Stats   []byte
+}
+
+// SearchHit is one result of Search.
+type SearchHit struct {
+	ID      string `json:"id"`
+	Title   string `json:"title"`
+	Snippet string `json:"snippet,omitempty"`
+}
+
+type nodeWire struct {
+	ID      string          `json:"id,omitempty"`
+	Content *string         `json:"content,omitempty"`
+	Meta    *string         `json:"meta,omitempty"`
+	Stats   json.RawMessage `json:"stats,omitempty"`
+}
+
+// Me returns the account the client's token authenticates as.
+func (c *Client) Me(ctx context.Context) (*Identity, error) {
+	var out Identity
+	if err := c.doJSON(ctx, "Me", http.MethodGet, joinPath([]string{"me"}), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListKegs returns the kegs owned by user.
+func (c *Client) ListKegs(ctx context.Context, user string) ([]KegInfo, error) {
+	var out struct {
+		Kegs []KegInfo `json:"kegs"`
+	}
+	err := c.doJSON(ctx, "ListKegs", http.MethodGet, userPath(user, "kegs"), nil, &out)
+	return out.Kegs, err
+}
+
+// GetKeg returns the details of keg user/keg.
+func (c *Client) GetKeg(ctx context.Context, user, keg string) (*KegInfo, error) {
+	var out KegInfo
+	if err := c.doJSON(ctx, "GetKeg", http.MethodGet, kegPath(user, keg), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// SearchKegs finds the kegs visible to the caller whose name, owner, title
+// or description match query.
+func (c *Client) SearchKegs(ctx context.Context, query string) ([]KegInfo, error) {
+	var out struct {
+		Kegs []KegInfo `json:"kegs"`
+	}
+	path := joinPath([]string{"kegs"}) + "?q=" + url.QueryEscape(query)
+	err := c.doJSON(ctx, "SearchKegs", http.MethodGet, path, nil, &out)
+	return out.Kegs, err
+}
+
+// CreateKeg creates an empty keg named name for user.
+func (c *Client) CreateKeg(ctx context.Context, user, name, title string) (*KegInfo, error) {
+	body := map[string]string{"name": name, "title": title}
+	var out KegInfo
+	if err := c.doJSON(ctx, "CreateKeg", http.MethodPost, userPath(user, "kegs"), body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateKeg applies update to the settings of keg user/keg.
+func (c *Client) UpdateKeg(ctx context.Context, user, keg string, update KegUpdate) (*KegInfo, error) {
+	var out KegInfo
+	if err := c.doJSON(ctx, "UpdateKeg", http.MethodPatch, kegPath(user, keg), update, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListGrants returns the access list of keg user/keg.
+func (c *Client) ListGrants(ctx context.Context, user, keg string) ([]Grant, error) {
+	var out struct {
+		Grants []Grant `json:"grants"`
+	}
+	err := c.doJSON(ctx, "ListGrants", http.MethodGet, kegPath(user, keg, "acl"), nil, &out)
+	return out.Grants, err
+}
+
+// Share gives member role on keg user/keg, replacing any role they held.
+func (c *Client) Share(ctx context.Context, user, keg, member, role string) error {
+	body := map[string]string{"role": role}
+	return c.doJSON(ctx, "Share", http.MethodPut, kegPath(user, keg, "acl", strings.TrimPrefix(member, "@")), body, nil)
+}
+
+// Unshare removes member from the access list of keg user/keg.
+func (c *Client) Unshare(ctx context.Context, user, keg, member string) error {
+	_, err := c.do(ctx, "Unshare", http.MethodDelete, kegPath(user, keg, "acl", strings.TrimPrefix(member, "@")), nil, "")
+	return err
+}
+
+// KegURL returns the web address of keg user/keg on this registry.
+func (c *Client) KegURL(user, keg string) string {
+	return c.URL + "/@" + strings.TrimPrefix(user, "@") + "/" + url.PathEscape(keg)
+}
+
+// ListNodes returns the node ids of a keg as the registry spells them.
+func (c *Client) ListNodes(ctx context.Context, user, keg string) ([]string, error) {
+	var out struct {
+		Nodes []string `json:"nodes"`
+	}
+	err := c.doJSON(ctx, "ListNodes", http.MethodGet, kegPath(user, keg, "nodes"), nil, &out)
+	return out.Nodes, err
+}
+
+// NextNode reserves and returns the next free node id of a keg.
+func (c *Client) NextNode(ctx context.Context, user, keg string) (string, error) {
+	var out struct {
+		ID string `json:"id"`
+	}
+	err := c.doJSON(ctx, "NextNode", http.MethodPost, kegPath(user, keg, "nodes"), nil, &out)
+	return out.ID, err
+}
+
+// ReadNode returns the content, meta and stats of node id.
+func (c *Client) ReadNode(ctx context.Context, user, keg, id string) (*Node, error) {
+	n, _, err := c.ReadNodeIf(ctx, user, keg, id, Validator{})
+	return n, err
+}
+
+// ReadNodeIf reads node id unless it still matches v, in which case it
+// returns ErrNotModified. The returned Validator identifies the version
+// read.
+func (c *Client) ReadNodeIf(ctx context.Context, user, keg, id string, v Validator) (*Node, Validator, error) {
+	raw, header, err := c.exchange(ctx, "ReadNode", http.MethodGet, kegPath(user, keg, "nodes", id), nil, "", v.header())
+	if err != nil {
+		return nil, Validator{}, err
+	}
+	var w nodeWire
+	if err := json.Unmarshal(raw, &w); err != nil {
+		return nil, Validator{}, fmt.Errorf("registry ReadNode: unable to decode response: %w", err)
+	}
+	n := &Node{ID: w.ID, Stats: []byte(w.Stats)}
+	if n.ID == "" {
+		n.ID = id
+	}
+	if w.Content != nil {
+		n.Content = []byte(*w.Content)
+	}
+	if w.Meta != nil {
+		n.Meta = []byte(*w.Meta)
+	}
+	return n, validatorFrom(header), nil
+}
+
+// ReadNodeInfo returns the meta and stats of node id without its content.
+// Registries that ignore the fields parameter still send the content; it is
+// dropped.
+func (c *Client) ReadNodeInfo(ctx context.Context, user, keg, id string) (*Node, error) {
+	var w nodeWire
+	path := kegPath(user, keg, "nodes", id) + "?fields=meta,stats"
+	if err := c.doJSON(ctx, "ReadNodeInfo", http.MethodGet, path, nil, &w); err != nil {
+		return nil, err
+	}
+	n := &Node{ID: w.ID, Stats: []byte(w.Stats)}
+	if n.ID == "" {
+		n.ID = id
+	}
+	if w.Meta != nil {
+		n.Meta = []byte(*w.Meta)
+	}
+	return n, nil
+}
+
+// ReadContentRange returns up to length bytes of the content of node id,
+// starting at offset. A range past the end of the content returns no bytes.
+// When the registry answers with the whole content the range is cut from it.
+func (c *Client) ReadContentRange(ctx context.Context, user, keg, id string, offset, length int64) ([]byte, error) {
+	if offset < 0 || length <= 0 {
+		return nil, fmt.Errorf("registry ReadContentRange: invalid range %d+%d", offset, length)
+	}
+	header := http.Header{
+		"Accept": {"text/markdown"},
+		"Range":  {fmt.Sprintf("bytes=%d-%d", offset, offset+length-1)},
+	}
+	raw, resp, err := c.exchange(ctx, "ReadContentRange", http.MethodGet, kegPath(user, keg, "nodes", id, "content"), nil, "", header)
+	var re *Error
+	if errors.As(err, &re) && re.StatusCode == http.StatusRequestedRangeNotSatisfiable {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if resp.Get("Content-Range") == "" {
+		if offset >= int64(len(raw)) {
+			return nil, nil
+		}
+		raw = raw[offset:min(offset+length, int64(len(raw)))]
+	}
+	return raw, nil
+}
+
+// WriteNode creates node id or applies update to it.
+func (c *Client) WriteNode(ctx context.Context, user, keg, id string, update NodeUpdate) error {
+	w := nodeWire{Stats: json.RawMessage(update.Stats)}
+	if update.Content != nil {
+		s := string(update.Content)
+		w.Content = &s
+	}
+	if update.Meta != nil {
+		s := string(update.Meta)
+		w.Meta = &s
+	}
+	return c.doJSON(ctx, "WriteNode", http.MethodPut, kegPath(user, keg, "nodes", id), w, nil)
+}
+
+// DeleteNode removes node id.
+func (c *Client) DeleteNode(ctx context.Context, user, keg, id string) error {
+	return c.doJSON(ctx, "DeleteNode", http.MethodDelete, kegPath(user, keg, "nodes", id), nil, nil)
+}
+
+// MoveNode renames node id to dst. The registry answers 409 when dst exists.
+func (c *Client) MoveNode(ctx context.Context, user, keg, id, dst string) error {
+	body := map[string]string{"to": dst}
+	return c.doJSON(ctx, "MoveNode", http.MethodPost, kegPath(user, keg, "nodes", id, "move"), body, nil)
+}
+
+// WriteAsset stores an attachment of node id. kind is "files" or "images".
+func (c *Client) WriteAsset(ctx context.Context, user, keg, id, kind, name string, data []byte) error {
+	_, err := c.do(ctx, "WriteAsset", http.MethodPut, kegPath(user, keg, "nodes", id, kind, name), data, "application/octet-stream")
+	return err
+}
+
+// Search runs query against a keg on the server.
+func (c *Client) Search(ctx context.Context, user, keg, query string) ([]SearchHit, error) {
+	var out struct {
+		Hits []SearchHit `json:"hits"`
+	}
+	path := kegPath(user, keg, "search") + "?q=" + url.QueryEscape(query)
+	err := c.doJSON(ctx, "Search", http.MethodGet, path, nil, &out)
+	return out.Hits, err
+}
+
+// ReadConfig returns the keg config as YAML.
+func (c *Client) ReadConfig(ctx context.Context, user, keg string) ([]byte, error) {
+	return c.do(ctx, "ReadConfig", http.MethodGet, kegPath(user, keg, "config"), nil, "")
+}
+
+// WriteConfig replaces the keg config with data, which is YAML.
+func (c *Client) WriteConfig(ctx context.Context, user, keg string, data []byte) error {
+	_, err := c.do(ctx, "WriteConfig", http.MethodPut, kegPath(user, keg, "config"), data, "application/yaml")
+	return err
+}
+
+// ListIndexes returns the names of the index files stored for a keg.
+func (c *Client) ListIndexes(ctx context.Context, user, keg string) ([]string, error) {
+	var out struct {
+		Indexes []string `json:"indexes"`
+	}
+	err := c.doJSON(ctx, "ListIndexes", http.MethodGet, kegPath(user, keg, "indexes"), nil, &out)
+	return out.Indexes, err
+}
+
+// ReadIndex returns the raw index file name.
+func (c *Client) ReadIndex(ctx context.Context, user, keg, name string) ([]byte, error) {
+	data, _, err := c.ReadIndexIf(ctx, user, keg, name, Validator{})
+	return data, err
+}
+
+// ReadIndexIf reads the index file name unless it still matches v, in which
+// case it returns ErrNotModified.
+func (c *Client) ReadIndexIf(ctx context.Context, user, keg, name string, v Validator) ([]byte, Validator, error) {
+	raw, header, err := c.exchange(ctx, "ReadIndex", http.MethodGet, kegPath(user, keg, "indexes", name), nil, "", v.header())
+	if err != nil {
+		return nil, Validator{}, err
+	}
+	return raw, validatorFrom(header), nil
+}
+
+// WriteIndex replaces the index file name with data.
+func (c *Client) WriteIndex(ctx context.Context, user, keg, name string, data []byte) error {
+	_, err := c.do(ctx, "WriteIndex", http.MethodPut, kegPath(user, keg, "indexes", name), data, "application/octet-stream")
+	return err
+}
+
+// ClearIndexes removes every index file of a keg.
+func (c *Client) ClearIndexes(ctx context.Context, user, keg string) error {
+	_, err := c.do(ctx, "ClearIndexes", http.MethodDelete, kegPath(user, keg, "indexes"), nil, "")
+	return err
+}
+
+// doJSON sends in as JSON, when not nil, and decodes the response into out,
+// when not nil.
+func (c *Client) doJSON(ctx context.Context, op, method, path string, in, out any) error {
+	var body []byte
+	contentType := ""
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("registry %s: unable to encode request: %w", op, err)
+		}
+		body = data
+		contentType = "application/json"
+	}
+	raw, err := c.do(ctx, op, method, path, body, contentType)
+	if err != nil || out == nil || len(raw) == 0 {
+		return err
+	}
+	if err := json.Unmarshal(raw, out); err != nil {
+		return fmt.Errorf("registry %s: unable to decode response: %w", op, err)
+	}
+	return nil
+}
+
+// do sends one API request, authenticated when a token is set.
+func (c *Client) do(ctx context.Context, op, method, path string, body []byte, contentType string) ([]byte, error) {
+	raw, _, err := c.exchange(ctx, op, method, path, body, contentType, nil)
+	return raw, err
+}
+
+// exchange is do with extra request headers that also returns the response
+// headers.
+func (c *Client) exchange(ctx context.Context, op, method, path string, body []byte, contentType string, header http.Header) ([]byte, http.Header, error) {
+	if c.URL == "" {
+		return nil, nil, fmt.Errorf("registry %s: no registry url", op)
+	}
+	return c.send(ctx, op, method, func(ctx context.Context) (*http.Request, error) {
+		var r io.Reader
+		if body != nil {
+			r = bytes.NewReader(body)
+		}
+		req, err := http.NewRequestWithContext(ctx, method, c.URL+APIPrefix+path, r)
+		if err != nil {
+			return nil, err
+		}
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		req.Header.Set("Accept", "application/json")
+		if c.Token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.Token)
+		}
+		for k, vs := range header {
+			req.Header[k] = vs
+		}
+		return req, nil
+	})
+}
+
+// send performs the request built by newReq and returns the response body
+// and headers, retrying per the client's retry policy. A 304 returns
+// ErrNotModified; other non-2xx responses become *Error.
+func (c *Client) send(ctx context.Context, op, method string, newReq func(context.Context) (*http.Request, error)) ([]byte, http.Header, error) {
+	policy := c.retryPolicy()
+	budget := c.budget(op, policy)
+	for attempt := 1; ; attempt++ {
+		raw, header, err := c.try(ctx, op, policy, newReq)
+		if err == nil {
+			return raw, header, nil
+		}
+		var re *Error
+		if errors.As(err, &re) {
+			re.Attempts = attempt
+		}
+		wait, ok := shouldRetry(method, err, attempt, budget, policy)
+		if !ok || ctx.Err() != nil {
+			return nil, header, err
+		}
+		if err := sleep(ctx, wait); err != nil {
+			return nil, nil, fmt.Errorf("registry %s: %w", op, err)
+		}
+	}
+}
+
+// try makes a single request, bounded by the policy timeout.
+func (c *Client) try(ctx context.Context, op string, policy RetryPolicy, newReq func(context.Context) (*http.Request, error)) ([]byte, http.Header, error) {
+	if policy.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, policy.Timeout)
+		defer cancel()
+	}
+	req, err := newReq(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("registry %s: unable to build request: %w", op, err)
+	}
+	client := c.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("registry %s: %w", op, err)
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("registry %s: unable to read response: %w", op, err)
+	}
+	if resp.StatusCode == http.StatusNotModified {
+		return nil, resp.Header, fmt.Errorf("registry %s: %w", op, ErrNotModified)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, resp.Header, &Error{
+			Op:         op,
+			StatusCode: resp.StatusCode,
+			Message:    strings.TrimSpace(string(raw)),
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+		}
+	}
+	return raw, resp.Header, nil
+}
+
+func userPath(user string, parts ...string) string {
+	return joinPath(append([]string{"users", strings.TrimPrefix(user, "@")}, parts...))
+}
+
+func kegPath(user, keg string, parts ...string) string {
+	return joinPath(append([]string{"kegs", strings.TrimPrefix(user, "@"), keg}, parts...))
+}
+
+func joinPath(parts []string) string {
+	var b strings.Builder
+	for _, p := range parts {
+		b.WriteByte('/')
+		b.WriteString(url.PathEscape(p))
+	}
+	return b.String()
+}
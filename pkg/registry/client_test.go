@@ -0,0 +1,168 @@
+package registry_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jlrickert/tapper/pkg/registry"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_NodeRoundTripAndErrors(t *testing.T) {
+	t.Parallel()
+
+	nodes := map[string]map[string]any{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			http.Error(w, "bad token", http.StatusUnauthorized)
+			return
+		}
+		const prefix = "/api/v1/kegs/joe/notes/nodes/"
+		id := r.URL.Path[len(prefix):]
+		switch r.Method {
+		case http.MethodPut:
+			var body map[string]any
+			raw, _ := io.ReadAll(r.Body)
+			require.NoError(t, json.Unmarshal(raw, &body))
+			nodes[id] = body
+		case http.MethodGet:
+			n, ok := nodes[id]
+			if !ok {
+				http.Error(w, "no such node", http.StatusNotFound)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(n)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	ctx := t.Context()
+
+	c := registry.NewClient(srv.URL, "secret")
+	require.NoError(t, c.WriteNode(ctx, "@joe", "notes", "1", registry.NodeUpdate{Content: []byte("# One\n")}))
+	require.NotContains(t, nodes["1"], "meta", "nil fields are left out of the update")
+
+	n, err := c.ReadNode(ctx, "joe", "notes", "1")
+	require.NoError(t, err)
+	require.Equal(t, "1", n.ID)
+	require.Equal(t, "# One\n", string(n.Content))
+	require.Nil(t, n.Meta)
+
+	_, err = c.ReadNode(ctx, "joe", "notes", "2")
+	require.ErrorIs(t, err, registry.ErrNotFound)
+	require.Contains(t, err.Error(), "no such node")
+
+	_, err = registry.NewClient(srv.URL, "wrong").ReadNode(ctx, "joe", "notes", "1")
+	require.ErrorIs(t, err, registry.ErrUnauthorized)
+}
+
+func TestClient_RetriesTransientFailures(t *testing.T) {
+	t.Parallel()
+
+	var reads, nexts atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/kegs/joe/notes/config":
+			if reads.Add(1) < 3 {
+				http.Error(w, "try later", http.StatusServiceUnavailable)
+				return
+			}
+			_, _ = w.Write([]byte("title: Notes\n"))
+		case "/api/v1/kegs/joe/notes/nodes":
+			switch nexts.Add(1) {
+			case 1:
+				w.Header().Set("Retry-After", "0")
+				http.Error(w, "slow down", http.StatusTooManyRequests)
+			case 2:
+				_, _ = w.Write([]byte(`{"id":"7"}`))
+			default:
+				http.Error(w, "boom", http.StatusInternalServerError)
+			}
+		case "/api/v1/kegs/joe/notes/indexes":
+			w.Header().Set("Retry-After", "120")
+			http.Error(w, "slow down", http.StatusTooManyRequests)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	ctx := t.Context()
+
+	c := registry.NewClient(srv.URL, "")
+	c.Retry = &registry.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Second}
+
+	data, err := c.ReadConfig(ctx, "joe", "notes")
+	require.NoError(t, err)
+	require.Equal(t, "title: Notes\n", string(data))
+	require.EqualValues(t, 3, reads.Load())
+
+	id, err := c.NextNode(ctx, "joe", "notes")
+	require.NoError(t, err, "a rate-limited POST is retried")
+	require.Equal(t, "7", id)
+
+	_, err = c.NextNode(ctx, "joe", "notes")
+	require.Error(t, err)
+	require.EqualValues(t, 3, nexts.Load(), "a failed NextNode is not retried")
+	require.True(t, registry.IsTransient(err))
+
+	_, err = c.ListIndexes(ctx, "joe", "notes")
+	var re *registry.Error
+	require.ErrorAs(t, err, &re)
+	require.Equal(t, 2*time.Minute, re.RetryAfter)
+	require.Equal(t, 1, re.Attempts, "a Retry-After beyond MaxDelay is not waited for")
+}
+
+func TestClient_ConditionalReads(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Last-Modified", "Mon, 02 Jan 2006 15:04:05 GMT")
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		_, _ = w.Write([]byte("1\tOne\n"))
+	}))
+	t.Cleanup(srv.Close)
+	ctx := t.Context()
+	c := registry.NewClient(srv.URL, "")
+
+	data, v, err := c.ReadIndexIf(ctx, "joe", "notes", "nodes.tsv", registry.Validator{})
+	require.NoError(t, err)
+	require.Equal(t, "1\tOne\n", string(data))
+	require.Equal(t, registry.Validator{ETag: `"v1"`, LastModified: "Mon, 02 Jan 2006 15:04:05 GMT"}, v)
+
+	_, _, err = c.ReadIndexIf(ctx, "joe", "notes", "nodes.tsv", v)
+	require.ErrorIs(t, err, registry.ErrNotModified)
+}
+
+func TestClient_ReadContentRangeWithoutRangeSupport(t *testing.T) {
+	t.Parallel()
+	var gotRange string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/api/v1/kegs/joe/notes/nodes/1/content", r.URL.Path)
+		gotRange = r.Header.Get("Range")
+		_, _ = w.Write([]byte("# One\n\nBody\n"))
+	}))
+	t.Cleanup(srv.Close)
+	ctx := t.Context()
+	c := registry.NewClient(srv.URL, "")
+
+	data, err := c.ReadContentRange(ctx, "joe", "notes", "1", 2, 3)
+	require.NoError(t, err)
+	require.Equal(t, "bytes=2-4", gotRange)
+	require.Equal(t, "One", string(data), "a whole-content answer is cut to the range")
+
+	data, err = c.ReadContentRange(ctx, "joe", "notes", "1", 100, 3)
+	require.NoError(t, err)
+	require.Empty(t, data)
+}
+
+func TestNewClient_DefaultsToHTTPS(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, "https://keg.jlrickert.me", registry.NewClient("keg.jlrickert.me/", "").URL)
+	require.Equal(t, "http://localhost:8080", registry.NewClient("http://localhost:8080", "").URL)
+}
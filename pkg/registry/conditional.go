@@ -0,0 +1,41 @@
+package registry
+
+import (
+	"errors"
+	"net/http"
+)
+
+// ErrNotModified is returned by conditional reads when the resource still
+// matches the validator sent.
+var ErrNotModified = errors.New("not modified")
+
+// Validator identifies a version of a resource by the ETag and Last-Modified
+// headers the registry sent with it. The zero Validator makes reads
+// unconditional.
+type Validator struct {
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+}
+
+// IsZero reports whether v carries no validator.
+func (v Validator) IsZero() bool {
+	return v.ETag == "" && v.LastModified == ""
+}
+
+// header returns the conditional request headers for v. The ETag wins when
+// both are known, as RFC 9110 has servers ignore If-Modified-Since next to
+// If-None-Match.
+func (v Validator) header() http.Header {
+	h := http.Header{}
+	switch {
+	case v.ETag != "":
+		h.Set("If-None-Match", v.ETag)
+	case v.LastModified != "":
+		h.Set("If-Modified-Since", v.LastModified)
+	}
+	return h
+}
+
+func validatorFrom(h http.Header) Validator {
+	return Validator{ETag: h.Get("ETag"), LastModified: h.Get("Last-Modified")}
+}
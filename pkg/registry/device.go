@@ -0,0 +1,137 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ClientID identifies tapper to the registry's OAuth endpoints.
+const ClientID = "tapper"
+
+// Errors returned by PollDeviceToken when the user does not finish the
+// device-code flow.
+var (
+	ErrAccessDenied = errors.New("authorization denied")
+	ErrExpiredCode  = errors.New("device code expired")
+)
+
+// DeviceAuth is the registry's answer to a device authorization request
+// (RFC 8628). The user visits VerificationURI and enters UserCode.
+type DeviceAuth struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete,omitempty"`
+	ExpiresIn               int    `json:"expires_in"`
+	Interval                int    `json:"interval,omitempty"`
+}
+
+// Token is an access token issued by the registry. Expires is zero when the
+// token does not expire.
+type Token struct {
+	AccessToken string
+	Expires     time.Time
+}
+
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int    `json:"expires_in,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+// StartDeviceAuth begins the device-code flow.
+func (c *Client) StartDeviceAuth(ctx context.Context) (*DeviceAuth, error) {
+	form := url.Values{"client_id": {ClientID}}
+	var out DeviceAuth
+	if err := c.postForm(ctx, "StartDeviceAuth", "/oauth/device/code", form, &out); err != nil {
+		return nil, err
+	}
+	if out.DeviceCode == "" || out.UserCode == "" {
+		return nil, fmt.Errorf("registry StartDeviceAuth: response has no device code")
+	}
+	return &out, nil
+}
+
+// PollDeviceToken polls the token endpoint until the user approves or
+// denies auth, the code expires, or ctx is done. now stamps the expiry of
+// the returned token.
+func (c *Client) PollDeviceToken(ctx context.Context, auth *DeviceAuth, now func() time.Time) (*Token, error) {
+	interval := time.Duration(auth.Interval) * time.Second
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	form := url.Values{
+		"client_id":   {ClientID},
+		"device_code": {auth.DeviceCode},
+		"grant_type":  {"urn:ietf:params:oauth:grant-type:device_code"},
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(interval):
+		}
+
+		var out tokenResponse
+		err := c.postForm(ctx, "PollDeviceToken", "/oauth/token", form, &out)
+		var re *Error
+		if errors.As(err, &re) && re.StatusCode == http.StatusBadRequest {
+			// OAuth reports pending and failed polls as 400 with an error code.
+			_ = json.Unmarshal([]byte(re.Message), &out)
+			err = nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch out.Error {
+		case "":
+			if out.AccessToken == "" {
+				return nil, fmt.Errorf("registry PollDeviceToken: response has no access token")
+			}
+			tok := &Token{AccessToken: out.AccessToken}
+			if out.ExpiresIn > 0 {
+				tok.Expires = now().Add(time.Duration(out.ExpiresIn) * time.Second)
+			}
+			return tok, nil
+		case "authorization_pending":
+		case "slow_down":
+			interval += 5 * time.Second
+		case "access_denied":
+			return nil, ErrAccessDenied
+		case "expired_token":
+			return nil, ErrExpiredCode
+		default:
+			return nil, fmt.Errorf("registry PollDeviceToken: %s", out.Error)
+		}
+	}
+}
+
+// postForm posts form to an OAuth endpoint, which lives outside APIPrefix,
+// and decodes the JSON answer into out.
+func (c *Client) postForm(ctx context.Context, op, path string, form url.Values, out any) error {
+	if c.URL == "" {
+		return fmt.Errorf("registry %s: no registry url", op)
+	}
+	raw, _, err := c.send(ctx, op, http.MethodPost, func(ctx context.Context) (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.URL+path, strings.NewReader(form.Encode()))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("Accept", "application/json")
+		return req, nil
+	})
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(raw, out); err != nil {
+		return fmt.Errorf("registry %s: unable to decode response: %w", op, err)
+	}
+	return nil
+}
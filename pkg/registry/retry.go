@@ -0,0 +1,160 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RetryPolicy controls how a Client retries failed requests. Rate-limited
+// (429) requests are retried for every method since the registry did not act
+// on them; server errors, timeouts and network failures are retried only for
+// idempotent methods.
+type RetryPolicy struct {
+	// MaxAttempts is the number of tries per request, the first included.
+	// Values below 1 mean a single try.
+	MaxAttempts int
+
+	// BaseDelay is the backoff before the second try. It doubles per retry,
+	// with jitter, up to MaxDelay.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+
+	// Timeout bounds each try. Zero leaves the request to the context.
+	Timeout time.Duration
+}
+
+// DefaultRetryPolicy is used by clients without a policy of their own.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 4,
+	BaseDelay:   250 * time.Millisecond,
+	MaxDelay:    10 * time.Second,
+	Timeout:     30 * time.Second,
+}
+
+// DefaultBudgets caps the tries of operations that allocate on the server,
+// where an unanswered request may still have been applied. A 429 is still
+// retried up to the policy's MaxAttempts.
+var DefaultBudgets = map[string]int{
+	"CreateKeg": 1,
+	"NextNode":  1,
+	"MoveNode":  1,
+}
+
+// IsTransient reports whether err, as returned by a Client method, may
+// succeed when tried again later: rate limiting, server errors, timeouts and
+// network failures.
+func IsTransient(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var re *Error
+	if errors.As(err, &re) {
+		return re.Transient()
+	}
+	var ne net.Error
+	return errors.As(err, &ne) ||
+		errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+func (c *Client) retryPolicy() RetryPolicy {
+	if c.Retry != nil {
+		return *c.Retry
+	}
+	return DefaultRetryPolicy
+}
+
+// budget returns the number of tries op may make for non-rate-limit
+// failures.
+func (c *Client) budget(op string, policy RetryPolicy) int {
+	n := max(policy.MaxAttempts, 1)
+	budgets := c.Budgets
+	if budgets == nil {
+		budgets = DefaultBudgets
+	}
+	if b, ok := budgets[op]; ok && b >= 1 && b < n {
+		return b
+	}
+	return n
+}
+
+// shouldRetry reports whether a failed try may be repeated and how long to
+// wait first. A Retry-After the policy cannot honor ends the retries.
+func shouldRetry(method string, err error, attempt, budget int, policy RetryPolicy) (time.Duration, bool) {
+	if attempt >= max(policy.MaxAttempts, 1) {
+		return 0, false
+	}
+	var re *Error
+	if errors.As(err, &re) && re.StatusCode == http.StatusTooManyRequests {
+		if re.RetryAfter > 0 {
+			return re.RetryAfter, policy.MaxDelay == 0 || re.RetryAfter <= policy.MaxDelay
+		}
+		return backoff(attempt, policy), true
+	}
+	if attempt >= budget || !idempotent(method) || !IsTransient(err) {
+		return 0, false
+	}
+	if re != nil && re.RetryAfter > 0 {
+		return re.RetryAfter, policy.MaxDelay == 0 || re.RetryAfter <= policy.MaxDelay
+	}
+	return backoff(attempt, policy), true
+}
+
+// backoff returns the delay after try attempt: BaseDelay doubled per retry,
+// capped at MaxDelay, with up to half of it replaced by jitter.
+func backoff(attempt int, policy RetryPolicy) time.Duration {
+	if policy.BaseDelay <= 0 {
+		return 0
+	}
+	d := policy.BaseDelay << min(attempt-1, 20)
+	if policy.MaxDelay > 0 && (d > policy.MaxDelay || d <= 0) {
+		d = policy.MaxDelay
+	}
+	return d/2 + rand.N(d/2+1)
+}
+
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds or as an HTTP
+// date.
+func parseRetryAfter(v string, now time.Time) time.Duration {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		return max(time.Duration(secs)*time.Second, 0)
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return max(t.Sub(now), 0)
+	}
+	return 0
+}
+
+// sleep waits d or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
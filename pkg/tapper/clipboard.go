@@ -0,0 +1,96 @@
+package tapper
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/jlrickert/cli-toolkit/toolkit"
+)
+
+// ClipboardCmdEnvKey names the environment variable that overrides the
+// command used to read an image from the system clipboard. The command must
+// write the raw image bytes to stdout.
+const ClipboardCmdEnvKey = "TAP_CLIPBOARD_CMD"
+
+// ErrClipboardEmpty is returned when the clipboard holds no image.
+var ErrClipboardEmpty = errors.New("clipboard does not contain an image")
+
+// clipboardImageCommands returns candidate commands that print the clipboard
+// image as PNG, in the order they should be tried.
+func clipboardImageCommands(rt *toolkit.Runtime) [][]string {
+	if override := strings.Fields(rt.Get(ClipboardCmdEnvKey)); len(override) > 0 {
+		return [][]string{override}
+	}
+	switch runtime.GOOS {
+	case "darwin":
+		return [][]string{{"pngpaste", "-"}}
+	case "windows":
+		return [][]string{{"powershell", "-NoProfile", "-NonInteractive", "-Command",
+			"Add-Type -AssemblyName System.Windows.Forms; " +
+				"$img = [Windows.Forms.Clipboard]::GetImage(); " +
+				"if ($img) { $ms = New-Object IO.MemoryStream; " +
+				"$img.Save($ms, [Drawing.Imaging.ImageFormat]::Png); " +
+				"$out = [Console]::OpenStandardOutput(); $out.Write($ms.ToArray(), 0, $ms.Length) }"}}
+	default:
+		cmds := [][]string{{"xclip", "-selection", "clipboard", "-t", "image/png", "-o"}}
+		if rt.Get("WAYLAND_DISPLAY") != "" {
+			cmds = append([][]string{{"wl-paste", "--no-newline", "--type", "image/png"}}, cmds...)
+		}
+		return cmds
+	}
+}
+
+// readClipboardImage returns the image currently on the system clipboard.
+func readClipboardImage(ctx context.Context, rt *toolkit.Runtime) ([]byte, error) {
+	var errs []error
+	for _, parts := range clipboardImageCommands(rt) {
+		if _, err := exec.LookPath(parts[0]); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", parts[0], err))
+			continue
+		}
+		var stdout, stderr bytes.Buffer
+		cmd := exec.CommandContext(ctx, parts[0], parts[1:]...)
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+		cmd.Env = rt.Environ()
+		if err := cmd.Run(); err != nil {
+			msg := strings.TrimSpace(stderr.String())
+			if msg == "" {
+				msg = err.Error()
+			}
+			errs = append(errs, fmt.Errorf("%s: %s", parts[0], msg))
+			continue
+		}
+		if stdout.Len() == 0 {
+			return nil, ErrClipboardEmpty
+		}
+		return stdout.Bytes(), nil
+	}
+	return nil, fmt.Errorf("unable to read clipboard (set %s to override): %w",
+		ClipboardCmdEnvKey, errors.Join(errs...))
+}
+
+// imageExtension returns a file extension matching the image content, or
+// an empty string when the data is not a recognized image.
+func imageExtension(data []byte) string {
+	switch http.DetectContentType(data) {
+	case "image/png":
+		return ".png"
+	case "image/jpeg":
+		return ".jpg"
+	case "image/gif":
+		return ".gif"
+	case "image/webp":
+		return ".webp"
+	case "image/bmp":
+		return ".bmp"
+	default:
+		return ""
+	}
+}
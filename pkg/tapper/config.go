@@ -5,10 +5,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"maps"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"sort"
 	"strings"
 	"time"
@@ -35,6 +37,47 @@ type configDTO struct {
 	LogFile  string `yaml:"logFile,omitempty"`
 	LogLevel string `yaml:"logLevel,omitempty"`
 
+	// durability is "fsync" to sync file keg writes to stable storage
+	// before reporting success; empty or "default" leaves flushing to the OS.
+	Durability string `yaml:"durability,omitempty"`
+
+	// repository adds logging, slow call warnings, retries or read-only
+	// enforcement around the storage backend of every keg.
+	Repository *RepositoryConfig `yaml:"repository,omitempty"`
+
+	// telemetry exports OpenTelemetry traces and metrics for keg, dex and
+	// repository operations.
+	Telemetry *TelemetryConfig `yaml:"telemetry,omitempty"`
+
+	// pager is the command long output is piped through on a TTY. "off"
+	// disables paging; empty falls back to $PAGER and then "less -R".
+	Pager string `yaml:"pager,omitempty"`
+
+	// mergeTool is the shell command `tap sync --merge` runs for each
+	// conflicting node, with $BASE, $LOCAL, $REMOTE and $MERGED naming temp
+	// files, like git's mergetool.<tool>.cmd.
+	MergeTool string `yaml:"mergeTool,omitempty"`
+
+	// backup configures `tap backup run`.
+	Backup *BackupConfig `yaml:"backup,omitempty"`
+
+	// hooks run on node create, edit and delete and keg index in every keg,
+	// before the hooks in the keg's own config.
+	Hooks []keg.Hook `yaml:"hooks,omitempty"`
+
+	// trustedKegs maps a keg location to the digest of the keg config
+	// commands approved with `tap repo trust`. It is only read from the
+	// user's own config, never from a project config.
+	TrustedKegs map[string]string `yaml:"trustedKegs,omitempty"`
+
+	// aliases maps a short command name to the argument list it expands to,
+	// for example `wls: ls --keg work --sort updated`.
+	Aliases map[string]string `yaml:"aliases,omitempty"`
+
+	// snippets maps a name to text that node templates and `tap create
+	// --body` include with {{snippet "name"}}.
+	Snippets map[string]string `yaml:"snippets,omitempty"`
+
 	// updated is a timestamp.
 	Updated time.Time `yaml:"updated,omitempty"`
 
@@ -83,6 +126,83 @@ type KegRegistry struct {
 	Url      string `yaml:"url,omitempty"`
 	Token    string `yaml:"token,omitempty"`
 	TokenEnv string `yaml:"tokenEnv,omitempty"`
+
+	// Timeout bounds each request to the registry, as a Go duration such as
+	// "20s". Empty keeps the client default.
+	Timeout string `yaml:"timeout,omitempty"`
+
+	// Retries is the number of times a failed request is retried. Nil keeps
+	// the client default; zero disables retries.
+	Retries *int `yaml:"retries,omitempty"`
+}
+
+// RepositoryConfig selects the repository middlewares applied to every keg
+// the user resolves.
+type RepositoryConfig struct {
+	// Log logs every repository call at trace level and failed calls at
+	// debug level.
+	Log bool `yaml:"log,omitempty"`
+
+	// SlowCall logs a warning for repository calls slower than this Go
+	// duration, such as "500ms". Empty disables the warning.
+	SlowCall string `yaml:"slowCall,omitempty"`
+
+	// Retries is the number of times a read failing with a transient
+	// backend error is retried. Zero disables retries.
+	Retries int `yaml:"retries,omitempty"`
+
+	// Readonly rejects every write to every keg, as if each target set
+	// readonly.
+	Readonly bool `yaml:"readonly,omitempty"`
+}
+
+// TelemetryConfig selects where OpenTelemetry traces and metrics are
+// exported.
+type TelemetryConfig struct {
+	// Exporter is "otlp" to send to an OTLP/HTTP collector, "stdout" to
+	// write spans and metrics to the log destination, or empty or "none"
+	// to disable telemetry.
+	Exporter string `yaml:"exporter,omitempty"`
+
+	// Endpoint is the OTLP/HTTP collector URL, such as
+	// "http://localhost:4318". Empty falls back to the standard
+	// OTEL_EXPORTER_OTLP_* environment variables.
+	Endpoint string `yaml:"endpoint,omitempty"`
+
+	// ServiceName is reported as the service.name resource attribute.
+	// Empty uses "tapper".
+	ServiceName string `yaml:"serviceName,omitempty"`
+}
+
+// BackupConfig describes where `tap backup run` writes archives and how many
+// it keeps.
+type BackupConfig struct {
+	// Destination is the directory backups are written to.
+	Destination string `yaml:"destination,omitempty"`
+
+	// Schedule is a hint for how often a backup is due: hourly, daily,
+	// weekly or monthly. Runs inside the interval are skipped.
+	Schedule string `yaml:"schedule,omitempty"`
+
+	// Keep is the number of newest backups retained per keg. Zero keeps all.
+	Keep int `yaml:"keep,omitempty"`
+}
+
+// Interval returns the minimum time between scheduled backups, or zero when
+// no schedule is set.
+func (b *BackupConfig) Interval() time.Duration {
+	switch strings.ToLower(strings.TrimSpace(b.Schedule)) {
+	case "hourly":
+		return time.Hour
+	case "daily":
+		return 24 * time.Hour
+	case "weekly":
+		return 7 * 24 * time.Hour
+	case "monthly":
+		return 30 * 24 * time.Hour
+	default:
+		return 0
+	}
 }
 
 // stringList supports YAML scalar-or-sequence forms for search path config.
@@ -223,6 +343,20 @@ func (cfg *Config) Registries() []KegRegistry {
 	return cfg.data.Registries
 }
 
+// Registry returns the registry named name, or the default registry when name
+// is empty.
+func (cfg *Config) Registry(name string) (KegRegistry, bool) {
+	if name == "" {
+		name = cfg.DefaultRegistry()
+	}
+	for _, reg := range cfg.Registries() {
+		if reg.Name == name {
+			return reg, true
+		}
+	}
+	return KegRegistry{}, false
+}
+
 // LogFile returns the log file path.
 func (cfg *Config) LogFile() string {
 	if cfg.data == nil {
@@ -239,6 +373,112 @@ func (cfg *Config) LogLevel() string {
 	return cfg.data.LogLevel
 }
 
+// Durability returns the configured write durability level.
+func (cfg *Config) Durability() string {
+	if cfg.data == nil {
+		cfg.data = &configDTO{}
+	}
+	return cfg.data.Durability
+}
+
+// Hooks returns the lifecycle hooks applied to every keg.
+func (cfg *Config) Hooks() []keg.Hook {
+	if cfg.data == nil {
+		cfg.data = &configDTO{}
+	}
+	return cfg.data.Hooks
+}
+
+// TrustedKeg returns the trust digest recorded for the keg at key, or ""
+// when the keg is not trusted.
+func (cfg *Config) TrustedKeg(key string) string {
+	if cfg == nil || cfg.data == nil {
+		return ""
+	}
+	return cfg.data.TrustedKegs[key]
+}
+
+// SetTrustedKeg records digest as trusted for the keg at key. An empty
+// digest removes the entry.
+func (cfg *Config) SetTrustedKeg(key, digest string) {
+	if cfg.data == nil {
+		cfg.data = &configDTO{}
+	}
+	if digest == "" {
+		delete(cfg.data.TrustedKegs, key)
+		return
+	}
+	if cfg.data.TrustedKegs == nil {
+		cfg.data.TrustedKegs = map[string]string{}
+	}
+	cfg.data.TrustedKegs[key] = digest
+}
+
+// Pager returns the configured pager command, "off" when paging is disabled,
+// or an empty string when unset.
+func (cfg *Config) Pager() string {
+	if cfg.data == nil {
+		cfg.data = &configDTO{}
+	}
+	return cfg.data.Pager
+}
+
+// Repository returns the repository middleware settings, or nil when none
+// are configured.
+func (cfg *Config) Repository() *RepositoryConfig {
+	if cfg.data == nil {
+		cfg.data = &configDTO{}
+	}
+	return cfg.data.Repository
+}
+
+// MergeTool returns the command run to merge sync conflicts, or "" when
+// none is configured.
+func (cfg *Config) MergeTool() string {
+	if cfg.data == nil {
+		cfg.data = &configDTO{}
+	}
+	return cfg.data.MergeTool
+}
+
+// Telemetry returns the telemetry settings, or nil when none are
+// configured.
+func (cfg *Config) Telemetry() *TelemetryConfig {
+	if cfg.data == nil {
+		cfg.data = &configDTO{}
+	}
+	return cfg.data.Telemetry
+}
+
+// Backup returns the backup settings, or nil when none are configured.
+func (cfg *Config) Backup() *BackupConfig {
+	if cfg.data == nil {
+		cfg.data = &configDTO{}
+	}
+	return cfg.data.Backup
+}
+
+// Aliases returns a copy of the user-defined command aliases, keyed by name.
+func (cfg *Config) Aliases() map[string]string {
+	if cfg.data == nil {
+		cfg.data = &configDTO{}
+	}
+	out := make(map[string]string, len(cfg.data.Aliases))
+	for name, expansion := range cfg.data.Aliases {
+		out[name] = expansion
+	}
+	return out
+}
+
+// Snippets returns a copy of the user-defined create snippets, keyed by
+// name.
+func (cfg *Config) Snippets() map[string]string {
+	if cfg.data == nil {
+		cfg.data = &configDTO{}
+	}
+	return maps.Clone(cfg.data.Snippets)
+}
+
 // Updated returns the last update timestamp.
 func (cfg *Config) Updated() time.Time {
 	if cfg.data == nil {
@@ -303,6 +543,41 @@ func (cfg *Config) SetLogLevel(level string) error {
 	return nil
 }
 
+// SetAlias adds or replaces the command alias name.
+func (cfg *Config) SetAlias(name, expansion string) error {
+	if cfg == nil {
+		return fmt.Errorf("config is nil")
+	}
+	if name == "" {
+		return fmt.Errorf("alias name is required")
+	}
+	if cfg.data == nil {
+		cfg.data = &configDTO{}
+	}
+	if cfg.data.Aliases == nil {
+		cfg.data.Aliases = make(map[string]string)
+	}
+	cfg.data.Aliases[name] = expansion
+	return nil
+}
+
+// RemoveAlias removes the command alias name.
+//
+// Returns an error when the alias is not defined.
+func (cfg *Config) RemoveAlias(name string) error {
+	if cfg == nil {
+		return fmt.Errorf("config is nil")
+	}
+	if cfg.data == nil || cfg.data.Aliases == nil {
+		return fmt.Errorf("command alias not found: %s", name)
+	}
+	if _, ok := cfg.data.Aliases[name]; !ok {
+		return fmt.Errorf("command alias not found: %s", name)
+	}
+	delete(cfg.data.Aliases, name)
+	return nil
+}
+
 // Clone produces a deep copy of the Config.
 func (cfg *Config) Clone() *Config {
 	if cfg == nil {
@@ -329,11 +604,69 @@ func (cfg *Config) ResolveAlias(alias string) (*kegurl.Target, error) {
 	if cfg.data.Kegs == nil {
 		cfg.data.Kegs = map[string]kegurl.Target{}
 	}
-	u, ok := cfg.data.Kegs[alias]
-	if !ok {
-		return nil, fmt.Errorf("keg alias not found: %s", alias)
+	t, _, err := cfg.followRedirects(alias)
+	return t, err
+}
+
+// DeprecatedAliases returns the deprecated aliases passed through while
+// resolving alias, keyed to the alias or target each one redirects to. It is
+// empty when alias is current or unknown.
+func (cfg *Config) DeprecatedAliases(alias string) []AliasRedirect {
+	_, hops, _ := cfg.followRedirects(alias)
+	return hops
+}
+
+// AliasRedirect records a deprecated alias and where it now points.
+type AliasRedirect struct {
+	Alias    string
+	Redirect string
+}
+
+// followRedirects walks the redirect chain that starts at alias. A redirect
+// naming another configured alias is followed; anything else is parsed as a
+// target. A chain that revisits an alias is an error.
+func (cfg *Config) followRedirects(alias string) (*kegurl.Target, []AliasRedirect, error) {
+	if cfg.data == nil || cfg.data.Kegs == nil {
+		return nil, nil, keg.NewAliasNotFoundError(alias)
+	}
+	var hops []AliasRedirect
+	chain := []string{alias}
+	current := alias
+	for {
+		u, ok := cfg.data.Kegs[current]
+		if !ok {
+			return nil, hops, keg.NewAliasNotFoundError(current)
+		}
+		if u.Deprecated {
+			hops = append(hops, AliasRedirect{Alias: current, Redirect: u.Redirect})
+		}
+		if u.Redirect == "" {
+			t, err := kegurl.Parse(u.String())
+			return t, hops, err
+		}
+		if _, ok := cfg.data.Kegs[u.Redirect]; !ok {
+			t, err := kegurl.Parse(u.Redirect)
+			return t, hops, err
+		}
+		if slices.Contains(chain, u.Redirect) {
+			return nil, hops, fmt.Errorf("keg alias redirect cycle: %s -> %s: %w",
+				strings.Join(chain, " -> "), u.Redirect, keg.ErrInvalid)
+		}
+		chain = append(chain, u.Redirect)
+		current = u.Redirect
+	}
+}
+
+// warnDeprecatedAlias logs a warning for each deprecated alias passed
+// through while resolving alias.
+func warnDeprecatedAlias(rt *toolkit.Runtime, cfg *Config, alias string) {
+	for _, hop := range cfg.DeprecatedAliases(alias) {
+		if hop.Redirect == "" {
+			rt.Logger().Warn("keg alias is deprecated", "alias", hop.Alias)
+			continue
+		}
+		rt.Logger().Warn("keg alias is deprecated", "alias", hop.Alias, "redirect", hop.Redirect)
 	}
-	return kegurl.Parse(u.String())
 }
 
 // LookupAlias returns the keg alias matching the given project root path.
@@ -406,6 +739,7 @@ func (cfg *Config) LookupAlias(rt *toolkit.Runtime, projectRoot string) string {
 // prefixes and patterns may contain ~ or $VAR values.
 func (cfg *Config) ResolveKegMap(rt *toolkit.Runtime, projectRoot string) (*kegurl.Target, error) {
 	alias := cfg.LookupAlias(rt, projectRoot)
+	warnDeprecatedAlias(rt, cfg, alias)
 	return cfg.ResolveAlias(alias)
 }
 
@@ -416,6 +750,7 @@ func (cfg *Config) ResolveDefault(rt *toolkit.Runtime) (*kegurl.Target, error) {
 		return nil, nil
 	}
 	alias := toolkit.ExpandEnv(rt, cfg.DefaultKeg())
+	warnDeprecatedAlias(rt, cfg, alias)
 	return cfg.ResolveAlias(alias)
 
 }
@@ -537,6 +872,7 @@ func (cfg *Config) Write(rt *toolkit.Runtime, path string) error {
 // Merge semantics:
 //   - Later configs override earlier values for scalar keys.
 //   - kegSearchPaths are appended in order with deduplication.
+//   - Command aliases are merged by name; later configs win.
 //   - KegMap entries are appended in order, but entries with the same alias
 //     are replaced by later entries.
 //   - The returned Config will have a Kegs map and a KegMap slice.
@@ -574,6 +910,39 @@ func MergeConfig(cfgs ...*Config) *Config {
 		if c.data.LogLevel != "" {
 			out.data.LogLevel = c.data.LogLevel
 		}
+		if c.data.Durability != "" {
+			out.data.Durability = c.data.Durability
+		}
+		if c.data.Pager != "" {
+			out.data.Pager = c.data.Pager
+		}
+		if c.data.MergeTool != "" {
+			out.data.MergeTool = c.data.MergeTool
+		}
+		if c.data.Backup != nil {
+			backup := *c.data.Backup
+			out.data.Backup = &backup
+		}
+		if c.data.Repository != nil {
+			repo := *c.data.Repository
+			out.data.Repository = &repo
+		}
+		if c.data.Telemetry != nil {
+			telemetry := *c.data.Telemetry
+			out.data.Telemetry = &telemetry
+		}
+		if len(c.data.Hooks) > 0 {
+			out.data.Hooks = append(out.data.Hooks, c.data.Hooks...)
+		}
+		for name, expansion := range c.data.Aliases {
+			out.SetAlias(name, expansion)
+		}
+		if len(c.data.Snippets) > 0 {
+			if out.data.Snippets == nil {
+				out.data.Snippets = make(map[string]string, len(c.data.Snippets))
+			}
+			maps.Copy(out.data.Snippets, c.data.Snippets)
+		}
 		if !c.data.Updated.IsZero() {
 			out.data.Updated = c.data.Updated
 		}
@@ -635,10 +1004,10 @@ func (cfg *Config) RemoveKeg(alias string) error {
 		return fmt.Errorf("alias is required")
 	}
 	if cfg.data == nil || cfg.data.Kegs == nil {
-		return fmt.Errorf("keg alias not found: %s", alias)
+		return keg.NewAliasNotFoundError(alias)
 	}
 	if _, ok := cfg.data.Kegs[alias]; !ok {
-		return fmt.Errorf("keg alias not found: %s", alias)
+		return keg.NewAliasNotFoundError(alias)
 	}
 	delete(cfg.data.Kegs, alias)
 	return nil
@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/jlrickert/cli-toolkit/toolkit"
+	"github.com/jlrickert/tapper/pkg/keg"
 	kegurl "github.com/jlrickert/tapper/pkg/keg_url"
 )
 
@@ -74,6 +75,25 @@ func (s *ConfigService) ProjectConfig(cache bool) (*Config, error) {
 	return cfg, nil
 }
 
+// PersonalConfig returns the configuration only the user controls: the file
+// named by ConfigPath, or else the user config. Unlike Config it leaves out
+// the project config, which is shared through the project's repository, so
+// it is where hooks and keg trust are read from.
+func (s *ConfigService) PersonalConfig(cache bool) (*Config, error) {
+	if s.ConfigPath != "" {
+		return s.Config(cache), nil
+	}
+	return s.UserConfig(cache)
+}
+
+// personalConfigPath is the file PersonalConfig reads.
+func (s *ConfigService) personalConfigPath() string {
+	if s.ConfigPath != "" {
+		return s.ConfigPath
+	}
+	return s.PathService.UserConfig()
+}
+
 // Config returns the merged user and project configuration with optional caching.
 // If cache is true and a merged config exists, it returns the cached version.
 // Otherwise, it retrieves both configs, merges them, caches the result, and returns it.
@@ -132,6 +152,7 @@ func (s *ConfigService) ResolveTarget(alias string, cache bool) (*kegurl.Target,
 	// Check for explicit keg in configuration first.
 	t, err := cfg.ResolveAlias(requestedAlias)
 	if err == nil && t != nil {
+		warnDeprecatedAlias(s.Runtime, cfg, requestedAlias)
 		return t, nil
 	}
 
@@ -145,7 +166,10 @@ func (s *ConfigService) ResolveTarget(alias string, cache bool) (*kegurl.Target,
 		return &t, nil
 	}
 
-	return nil, fmt.Errorf("keg alias not found: %s (add alias under kegs:, add discovery paths in kegSearchPaths, or create ./kegs/%s)", requestedAlias, requestedAlias)
+	return nil, &keg.AliasNotFoundError{
+		Alias: requestedAlias,
+		Hint:  fmt.Sprintf("add alias under kegs:, add discovery paths in kegSearchPaths, or create ./kegs/%s", requestedAlias),
+	}
 }
 
 // localRepoKegTargets scans kegSearchPaths and returns alias-to-path mappings.
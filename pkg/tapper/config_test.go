@@ -6,6 +6,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/jlrickert/tapper/pkg/keg"
 	kegurl "github.com/jlrickert/tapper/pkg/keg_url"
 	"github.com/jlrickert/tapper/pkg/tapper"
 	"github.com/stretchr/testify/require"
@@ -147,6 +148,45 @@ func TestResolveAlias_Behavior(t *testing.T) {
 	require.Error(t, err, "expected ResolveAlias to error for unknown alias")
 }
 
+func TestResolveAlias_FollowsDeprecatedRedirects(t *testing.T) {
+	t.Parallel()
+
+	raw := `kegs:
+  old:
+    deprecated: true
+    redirect: team
+  team:
+    deprecated: true
+    redirect: "https://example.com/team"
+  moved:
+    redirect: "https://example.com/moved"
+  loop-a:
+    deprecated: true
+    redirect: loop-b
+  loop-b:
+    redirect: loop-a
+`
+	uc, err := tapper.ParseConfig([]byte(raw))
+	require.NoError(t, err)
+
+	kt, err := uc.ResolveAlias("old")
+	require.NoError(t, err)
+	require.Equal(t, "https://example.com/team", kt.String())
+	require.Equal(t, []tapper.AliasRedirect{
+		{Alias: "old", Redirect: "team"},
+		{Alias: "team", Redirect: "https://example.com/team"},
+	}, uc.DeprecatedAliases("old"))
+
+	kt, err = uc.ResolveAlias("moved")
+	require.NoError(t, err)
+	require.Equal(t, "https://example.com/moved", kt.String())
+	require.Empty(t, uc.DeprecatedAliases("moved"))
+
+	_, err = uc.ResolveAlias("loop-a")
+	require.ErrorIs(t, err, keg.ErrInvalid)
+	require.Contains(t, err.Error(), "loop-a -> loop-b -> loop-a")
+}
+
 func TestResolveProjectKeg_PrefixAndRegexPrecedence(t *testing.T) {
 	t.Parallel()
 	fx := NewSandbox(t)
@@ -505,3 +545,29 @@ func TestConfigToYAML_PrependsSchemaModeline(t *testing.T) {
 	require.NoError(t, err)
 	require.True(t, strings.HasPrefix(string(out), "# yaml-language-server: $schema="+tapper.TapConfigSchemaURL+"\n"))
 }
+
+func TestMergeConfig_AliasesMergeByName(t *testing.T) {
+	t.Parallel()
+
+	userCfg, err := tapper.ParseConfig([]byte(`
+aliases:
+  wls: ls --keg work
+  tc: cat --meta-only
+`))
+	require.NoError(t, err)
+	projectCfg, err := tapper.ParseConfig([]byte(`
+aliases:
+  wls: ls --keg project --sort updated
+`))
+	require.NoError(t, err)
+
+	merged := tapper.MergeConfig(userCfg, projectCfg)
+	require.Equal(t, map[string]string{
+		"wls": "ls --keg project --sort updated",
+		"tc":  "cat --meta-only",
+	}, merged.Aliases())
+
+	require.NoError(t, merged.RemoveAlias("tc"))
+	require.Error(t, merged.RemoveAlias("tc"))
+	require.Len(t, userCfg.Aliases(), 2, "merging must not share the alias map")
+}
@@ -0,0 +1,249 @@
+package tapper
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/jlrickert/cli-toolkit/toolkit"
+	"github.com/jlrickert/tapper/pkg/keg"
+	"gopkg.in/yaml.v3"
+)
+
+// CredentialStoreEnvKey names the environment variable that selects where
+// registry credentials are kept: "keyring" or "file". Unset uses the system
+// keyring when its command line tool is installed and the file otherwise.
+const CredentialStoreEnvKey = "TAP_CREDENTIAL_STORE"
+
+// credentialService is the keyring service name credentials are filed
+// under.
+const credentialService = "tapper"
+
+// Credential is a registry token saved by `tap auth login`.
+type Credential struct {
+	Registry string    `json:"registry" yaml:"registry"`
+	Token    string    `json:"token" yaml:"token"`
+	Expires  time.Time `json:"expires,omitzero" yaml:"expires,omitempty"`
+}
+
+// Expired reports whether the credential has an expiry before now.
+func (c Credential) Expired(now time.Time) bool {
+	return !c.Expires.IsZero() && !now.Before(c.Expires)
+}
+
+// CredentialStore keeps registry credentials. Get returns an error matching
+// keg.ErrNotExist when no credential is stored for the registry.
+type CredentialStore interface {
+	Name() string
+	Get(ctx context.Context, registry string) (*Credential, error)
+	Set(ctx context.Context, cred Credential) error
+	Delete(ctx context.Context, registry string) error
+}
+
+// NewCredentialStore returns the credential store selected by
+// CredentialStoreEnvKey, preferring the system keyring.
+func NewCredentialStore(rt *toolkit.Runtime, paths *PathService) CredentialStore {
+	file := &fileCredentialStore{rt: rt, path: filepath.Join(paths.StateRoot, "credentials.yaml")}
+	switch strings.ToLower(strings.TrimSpace(rt.Get(CredentialStoreEnvKey))) {
+	case "file":
+		return file
+	case "keyring":
+		return &keyringCredentialStore{rt: rt}
+	}
+	if tool := keyringTool(); tool != "" {
+		if _, err := exec.LookPath(tool); err == nil {
+			return &keyringCredentialStore{rt: rt}
+		}
+	}
+	return file
+}
+
+// keyringTool returns the command used to reach the system keyring, or ""
+// on platforms without one.
+func keyringTool() string {
+	switch runtime.GOOS {
+	case "darwin":
+		return "security"
+	case "linux", "freebsd", "openbsd":
+		return "secret-tool"
+	}
+	return ""
+}
+
+// keyringCredentialStore keeps each credential as a JSON secret in the
+// macOS keychain or the freedesktop secret service.
+type keyringCredentialStore struct {
+	rt *toolkit.Runtime
+}
+
+func (s *keyringCredentialStore) Name() string { return "keyring" }
+
+func (s *keyringCredentialStore) Get(ctx context.Context, registry string) (*Credential, error) {
+	var args []string
+	switch keyringTool() {
+	case "security":
+		args = []string{"security", "find-generic-password", "-s", credentialService, "-a", registry, "-w"}
+	case "secret-tool":
+		args = []string{"secret-tool", "lookup", "service", credentialService, "registry", registry}
+	default:
+		return nil, fmt.Errorf("system keyring: %w", keg.ErrNotSupported)
+	}
+	out, err := s.run(ctx, nil, args)
+	if err != nil || len(bytes.TrimSpace(out)) == 0 {
+		// Both tools exit non-zero when nothing is stored.
+		return nil, fmt.Errorf("no credential for registry %s: %w", registry, keg.ErrNotExist)
+	}
+	var cred Credential
+	if err := json.Unmarshal(bytes.TrimSpace(out), &cred); err != nil {
+		return nil, fmt.Errorf("unable to parse keyring credential for %s: %w", registry, err)
+	}
+	return &cred, nil
+}
+
+func (s *keyringCredentialStore) Set(ctx context.Context, cred Credential) error {
+	secret, err := json.Marshal(cred)
+	if err != nil {
+		return err
+	}
+	switch keyringTool() {
+	case "security":
+		// security only takes the secret as an argument.
+		_, err = s.run(ctx, nil, []string{"security", "add-generic-password", "-U",
+			"-s", credentialService, "-a", cred.Registry, "-w", string(secret)})
+	case "secret-tool":
+		_, err = s.run(ctx, secret, []string{"secret-tool", "store",
+			"--label=tapper registry " + cred.Registry,
+			"service", credentialService, "registry", cred.Registry})
+	default:
+		return fmt.Errorf("system keyring: %w", keg.ErrNotSupported)
+	}
+	if err != nil {
+		return fmt.Errorf("unable to store credential in keyring: %w", err)
+	}
+	return nil
+}
+
+func (s *keyringCredentialStore) Delete(ctx context.Context, registry string) error {
+	if _, err := s.Get(ctx, registry); err != nil {
+		return err
+	}
+	var err error
+	switch keyringTool() {
+	case "security":
+		_, err = s.run(ctx, nil, []string{"security", "delete-generic-password", "-s", credentialService, "-a", registry})
+	case "secret-tool":
+		_, err = s.run(ctx, nil, []string{"secret-tool", "clear", "service", credentialService, "registry", registry})
+	}
+	if err != nil {
+		return fmt.Errorf("unable to remove credential from keyring: %w", err)
+	}
+	return nil
+}
+
+func (s *keyringCredentialStore) run(ctx context.Context, stdin []byte, args []string) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	cmd.Env = s.rt.Environ()
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%s: %s", args[0], msg)
+		}
+		return nil, fmt.Errorf("%s: %w", args[0], err)
+	}
+	return stdout.Bytes(), nil
+}
+
+// fileCredentialStore keeps credentials in a YAML file readable only by the
+// user. It is the fallback where no keyring is available.
+type fileCredentialStore struct {
+	rt   *toolkit.Runtime
+	path string
+}
+
+func (s *fileCredentialStore) Name() string { return "file" }
+
+func (s *fileCredentialStore) Get(ctx context.Context, registry string) (*Credential, error) {
+	creds, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range creds {
+		if c.Registry == registry {
+			return &c, nil
+		}
+	}
+	return nil, fmt.Errorf("no credential for registry %s: %w", registry, keg.ErrNotExist)
+}
+
+func (s *fileCredentialStore) Set(ctx context.Context, cred Credential) error {
+	creds, err := s.read()
+	if err != nil {
+		return err
+	}
+	replaced := false
+	for i, c := range creds {
+		if c.Registry == cred.Registry {
+			creds[i] = cred
+			replaced = true
+		}
+	}
+	if !replaced {
+		creds = append(creds, cred)
+	}
+	return s.write(creds)
+}
+
+func (s *fileCredentialStore) Delete(ctx context.Context, registry string) error {
+	creds, err := s.read()
+	if err != nil {
+		return err
+	}
+	kept := creds[:0]
+	for _, c := range creds {
+		if c.Registry != registry {
+			kept = append(kept, c)
+		}
+	}
+	if len(kept) == len(creds) {
+		return fmt.Errorf("no credential for registry %s: %w", registry, keg.ErrNotExist)
+	}
+	return s.write(kept)
+}
+
+func (s *fileCredentialStore) read() ([]Credential, error) {
+	data, err := s.rt.ReadFile(s.path)
+	if err != nil {
+		if errors.Is(err, keg.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("unable to read credentials: %w", err)
+	}
+	var creds []Credential
+	if err := yaml.Unmarshal(data, &creds); err != nil {
+		return nil, fmt.Errorf("unable to parse %s: %w", s.path, err)
+	}
+	return creds, nil
+}
+
+func (s *fileCredentialStore) write(creds []Credential) error {
+	data, err := yaml.Marshal(creds)
+	if err != nil {
+		return err
+	}
+	if err := s.rt.Mkdir(filepath.Dir(s.path), 0o700, true); err != nil {
+		return fmt.Errorf("unable to create credentials directory: %w", err)
+	}
+	return s.rt.AtomicWriteFile(s.path, data, 0o600)
+}
@@ -0,0 +1,127 @@
+package tapper
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jlrickert/tapper/pkg/keg"
+)
+
+// DateRangeOptions restricts results to nodes whose timestamps fall inside a
+// window. Each bound accepts an RFC3339 timestamp, a date (2025-01-31), or a
+// relative duration counted back from now such as "90m", "36h", "7d", "2w",
+// "3mo", or "1y". Empty bounds are ignored.
+type DateRangeOptions struct {
+	// Since keeps nodes updated at or after this time.
+	Since string
+
+	// Until keeps nodes updated at or before this time.
+	Until string
+
+	// CreatedSince keeps nodes created at or after this time.
+	CreatedSince string
+}
+
+// IsZero reports whether no bound is set.
+func (o DateRangeOptions) IsZero() bool {
+	return strings.TrimSpace(o.Since) == "" &&
+		strings.TrimSpace(o.Until) == "" &&
+		strings.TrimSpace(o.CreatedSince) == ""
+}
+
+// ParseTimeBound parses a date range bound relative to now. See
+// DateRangeOptions for the accepted forms.
+func ParseTimeBound(raw string, now time.Time) (time.Time, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return time.Time{}, fmt.Errorf("empty time bound: %w", keg.ErrInvalid)
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05", time.DateOnly} {
+		if t, err := time.Parse(layout, raw); err == nil {
+			return t, nil
+		}
+	}
+
+	// Relative durations: a positive integer followed by a unit.
+	i := 0
+	for i < len(raw) && raw[i] >= '0' && raw[i] <= '9' {
+		i++
+	}
+	if i == 0 || i == len(raw) {
+		return time.Time{}, fmt.Errorf("invalid time bound %q: %w", raw, keg.ErrInvalid)
+	}
+	n, err := strconv.Atoi(raw[:i])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time bound %q: %w", raw, keg.ErrInvalid)
+	}
+	switch strings.ToLower(raw[i:]) {
+	case "s":
+		return now.Add(-time.Duration(n) * time.Second), nil
+	case "m":
+		return now.Add(-time.Duration(n) * time.Minute), nil
+	case "h":
+		return now.Add(-time.Duration(n) * time.Hour), nil
+	case "d":
+		return now.AddDate(0, 0, -n), nil
+	case "w":
+		return now.AddDate(0, 0, -7*n), nil
+	case "mo":
+		return now.AddDate(0, -n, 0), nil
+	case "y":
+		return now.AddDate(-n, 0, 0), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time bound %q: unknown unit: %w", raw, keg.ErrInvalid)
+}
+
+// filterDateRange returns the entries that fall inside the window described by
+// opts, preserving order.
+func filterDateRange(entries []keg.NodeIndexEntry, opts DateRangeOptions, now time.Time) ([]keg.NodeIndexEntry, error) {
+	if opts.IsZero() {
+		return entries, nil
+	}
+
+	type bound struct {
+		at  time.Time
+		set bool
+	}
+	parse := func(flag, raw string) (bound, error) {
+		if strings.TrimSpace(raw) == "" {
+			return bound{}, nil
+		}
+		at, err := ParseTimeBound(raw, now)
+		if err != nil {
+			return bound{}, fmt.Errorf("invalid %s: %w", flag, err)
+		}
+		return bound{at: at, set: true}, nil
+	}
+
+	since, err := parse("since", opts.Since)
+	if err != nil {
+		return nil, err
+	}
+	until, err := parse("until", opts.Until)
+	if err != nil {
+		return nil, err
+	}
+	createdSince, err := parse("created-since", opts.CreatedSince)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]keg.NodeIndexEntry, 0, len(entries))
+	for _, entry := range entries {
+		if since.set && entry.Updated.Before(since.at) {
+			continue
+		}
+		if until.set && entry.Updated.After(until.at) {
+			continue
+		}
+		if createdSince.set && entry.Created.Before(createdSince.at) {
+			continue
+		}
+		out = append(out, entry)
+	}
+	return out, nil
+}
@@ -0,0 +1,34 @@
+package tapper
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTimeBound(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+
+	cases := map[string]time.Time{
+		"2025-01-31":           time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC),
+		"2025-01-31T08:00:00Z": time.Date(2025, 1, 31, 8, 0, 0, 0, time.UTC),
+		"90m":                  now.Add(-90 * time.Minute),
+		"36h":                  now.Add(-36 * time.Hour),
+		"7d":                   time.Date(2025, 6, 8, 12, 0, 0, 0, time.UTC),
+		"2w":                   time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC),
+		"3mo":                  time.Date(2025, 3, 15, 12, 0, 0, 0, time.UTC),
+		"1y":                   time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC),
+	}
+	for raw, want := range cases {
+		got, err := ParseTimeBound(raw, now)
+		require.NoError(t, err, raw)
+		require.True(t, want.Equal(got), "%s: want %s got %s", raw, want, got)
+	}
+
+	for _, raw := range []string{"", "d", "7", "7x", "yesterday"} {
+		_, err := ParseTimeBound(raw, now)
+		require.Error(t, err, raw)
+	}
+}
@@ -27,15 +27,10 @@ func editWithLiveSaves(ctx context.Context, rt *toolkit.Runtime, path string, on
 		return fmt.Errorf("save callback is required")
 	}
 
-	resolvedPath, err := rt.ResolvePath(path, true)
+	editorPath, err := hostPath(rt, path, true)
 	if err != nil {
 		return fmt.Errorf("resolve edit path: %w", err)
 	}
-	editorPath := resolvedPath
-	if jail := strings.TrimSpace(rt.GetJail()); jail != "" {
-		trimmed := strings.TrimPrefix(resolvedPath, string(filepath.Separator))
-		editorPath = filepath.Join(jail, trimmed)
-	}
 
 	editor := strings.TrimSpace(rt.Get("VISUAL"))
 	if editor == "" {
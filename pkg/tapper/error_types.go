@@ -3,6 +3,8 @@ package tapper
 import (
 	"fmt"
 	"strings"
+
+	"github.com/jlrickert/tapper/pkg/keg"
 )
 
 // ProjectKegNotFoundError indicates project-local keg discovery failed.
@@ -45,3 +47,30 @@ type PathNotFoundError struct {
 func (e *PathNotFoundError) Error() string {
 	return fmt.Sprintf("keg not found at path %q: directory does not exist", e.Path)
 }
+
+// AmbiguousNodeError indicates a fuzzy node argument matched more than one
+// node title equally well. Candidates holds the best matches (possibly
+// truncated) and Total the full number of matching nodes.
+type AmbiguousNodeError struct {
+	Query      string
+	Candidates []keg.NodeIndexEntry
+	Total      int
+}
+
+func (e *AmbiguousNodeError) Error() string {
+	if e == nil {
+		return "ambiguous node"
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "node %q is ambiguous; %d nodes match:", e.Query, e.Total)
+	for _, c := range e.Candidates {
+		fmt.Fprintf(&b, "\n  %s\t%s", c.ID, c.Title)
+	}
+	if e.Total > len(e.Candidates) {
+		fmt.Fprintf(&b, "\n  ... and %d more", e.Total-len(e.Candidates))
+	}
+	b.WriteString("\nuse a node ID or a more specific title")
+	return b.String()
+}
+
+func (e *AmbiguousNodeError) Unwrap() error { return keg.ErrInvalid }
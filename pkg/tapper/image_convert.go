@@ -0,0 +1,112 @@
+package tapper
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/jlrickert/tapper/pkg/keg"
+)
+
+// defaultConvertQuality is the lossy output quality used when the keg
+// config does not set one.
+const defaultConvertQuality = 85
+
+// defaultImageConverters are tried in order when the keg config does not
+// name a conversion command.
+var defaultImageConverters = []string{"magick", "convert"}
+
+// imageConversionTarget returns the extension an upload should be
+// converted to under cfg, or an empty string to keep it as is.
+func imageConversionTarget(cfg *keg.ImageConvertConfig, data []byte) (string, error) {
+	if cfg == nil {
+		return "", nil
+	}
+	switch keg.DetectImageFormat(data) {
+	case keg.ImageFormatHEIF, keg.ImageFormatTIFF:
+		if cfg.ToJPEG {
+			return ".jpg", nil
+		}
+	case keg.ImageFormatPNG:
+		if cfg.PNGToWebP == "" {
+			return "", nil
+		}
+		threshold, err := keg.ParseByteSize(cfg.PNGToWebP)
+		if err != nil {
+			return "", fmt.Errorf("invalid images.convert.pngToWebp in keg config: %w", err)
+		}
+		if int64(len(data)) >= threshold {
+			return ".webp", nil
+		}
+	}
+	return "", nil
+}
+
+// convertImage runs the configured converter to turn data into the format
+// named by ext.
+func (t *Tap) convertImage(ctx context.Context, cfg *keg.ImageConvertConfig, name, ext string, data []byte) ([]byte, error) {
+	converter, err := imageConverterCommand(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := os.MkdirTemp("", "tap-convert-")
+	if err != nil {
+		return nil, fmt.Errorf("unable to create conversion directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+	in := filepath.Join(dir, "input"+strings.ToLower(filepath.Ext(name)))
+	out := filepath.Join(dir, "output"+ext)
+	if err := os.WriteFile(in, data, 0o600); err != nil {
+		return nil, fmt.Errorf("unable to stage %s for conversion: %w", name, err)
+	}
+
+	quality := cfg.Quality
+	if quality <= 0 || quality > 100 {
+		quality = defaultConvertQuality
+	}
+	var stderr bytes.Buffer
+	args := slices.Concat(converter[1:], []string{in, "-quality", strconv.Itoa(quality), out})
+	cmd := exec.CommandContext(ctx, converter[0], args...)
+	cmd.Stderr = &stderr
+	cmd.Env = t.Runtime.Environ()
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return nil, fmt.Errorf("unable to convert %s to %s: %s", name, strings.TrimPrefix(ext, "."), msg)
+	}
+	converted, err := os.ReadFile(out)
+	if err != nil || len(converted) == 0 {
+		return nil, fmt.Errorf("unable to convert %s to %s: converter produced no output", name, strings.TrimPrefix(ext, "."))
+	}
+	return converted, nil
+}
+
+func imageConverterCommand(cfg *keg.ImageConvertConfig) ([]string, error) {
+	if parts := strings.Fields(cfg.Command); len(parts) > 0 {
+		return parts, nil
+	}
+	var errs []error
+	for _, name := range defaultImageConverters {
+		if _, err := exec.LookPath(name); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		return []string{name}, nil
+	}
+	return nil, fmt.Errorf("image conversion needs ImageMagick or images.convert.command: %w", errors.Join(errs...))
+}
+
+// replaceExt swaps the extension of name for ext.
+func replaceExt(name, ext string) string {
+	return strings.TrimSuffix(name, filepath.Ext(name)) + ext
+}
@@ -0,0 +1,137 @@
+package tapper
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	"filippo.io/age"
+	"github.com/jlrickert/cli-toolkit/toolkit"
+	"github.com/jlrickert/tapper/pkg/keg"
+)
+
+// IdentityEnvKey names the environment variable holding the path of an age
+// identity file used to decrypt encrypted nodes. It takes precedence over
+// the identity saved with `tap auth key`.
+const IdentityEnvKey = "TAP_AGE_IDENTITY"
+
+// identityCredential is the credential store entry `tap auth key` saves
+// the age identity under.
+const identityCredential = "age-identity"
+
+// IdentityPrompter asks the user for an age identity ("AGE-SECRET-KEY-1...")
+// when none is configured.
+type IdentityPrompter func(ctx context.Context) (string, error)
+
+// identities returns the age identities that decrypt encrypted nodes: the
+// file named by IdentityEnvKey, the identity saved by `tap auth key`, or
+// one from PromptIdentity. The result, or the failure, is kept so the user
+// is asked at most once.
+func (s *KegService) identities(ctx context.Context) ([]age.Identity, error) {
+	s.identityMu.Lock()
+	defer s.identityMu.Unlock()
+	if s.identityCache != nil || s.identityErr != nil {
+		return s.identityCache, s.identityErr
+	}
+	s.identityCache, s.identityErr = s.loadIdentities(ctx)
+	return s.identityCache, s.identityErr
+}
+
+func (s *KegService) loadIdentities(ctx context.Context) ([]age.Identity, error) {
+
+	var (
+		raw    []byte
+		source string
+	)
+	if path := strings.TrimSpace(s.Runtime.Get(IdentityEnvKey)); path != "" {
+		expanded, err := toolkit.ExpandPath(s.Runtime, path)
+		if err != nil {
+			return nil, err
+		}
+		if raw, err = s.Runtime.ReadFile(expanded); err != nil {
+			return nil, fmt.Errorf("unable to read %s: %w", IdentityEnvKey, err)
+		}
+		source = IdentityEnvKey
+	} else if cred := s.savedIdentity(ctx); cred != "" {
+		raw, source = []byte(cred), "the saved identity"
+	} else if s.PromptIdentity != nil {
+		answer, err := s.PromptIdentity(ctx)
+		if err != nil {
+			return nil, err
+		}
+		raw, source = []byte(answer), "the entered identity"
+	} else {
+		return nil, fmt.Errorf("no age identity; set %s or run `tap auth key`: %w", IdentityEnvKey, keg.ErrEncrypted)
+	}
+
+	ids, err := age.ParseIdentities(bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("invalid age identity in %s: %v: %w", source, err, keg.ErrEncrypted)
+	}
+	return ids, nil
+}
+
+// savedIdentity returns the identity saved by `tap auth key`, or "".
+func (s *KegService) savedIdentity(ctx context.Context) string {
+	if s.Credentials == nil {
+		return ""
+	}
+	cred, err := s.Credentials.Get(ctx, identityCredential)
+	if err != nil || cred == nil {
+		return ""
+	}
+	return cred.Token
+}
+
+// AuthKeyOptions describes a `tap auth key` run.
+type AuthKeyOptions struct {
+	// Identity is the age identity to save. Empty requires Generate.
+	Identity string
+
+	// Generate creates a new X25519 identity instead.
+	Generate bool
+}
+
+// AuthKey saves the age identity that decrypts encrypted nodes in the
+// credential store and returns its recipient, the public key to list under
+// encryption.recipients in the keg config.
+func (t *Tap) AuthKey(ctx context.Context, opts AuthKeyOptions) (string, error) {
+	if t.Credentials == nil {
+		return "", fmt.Errorf("no credential store: %w", keg.ErrNotSupported)
+	}
+	var identity *age.X25519Identity
+	var err error
+	switch {
+	case opts.Generate:
+		identity, err = age.GenerateX25519Identity()
+	case strings.TrimSpace(opts.Identity) != "":
+		identity, err = parseX25519Identity(opts.Identity)
+	default:
+		return "", fmt.Errorf("identity required: %w", keg.ErrInvalid)
+	}
+	if err != nil {
+		return "", err
+	}
+	if err := t.Credentials.Set(ctx, Credential{Registry: identityCredential, Token: identity.String()}); err != nil {
+		return "", err
+	}
+	return identity.Recipient().String(), nil
+}
+
+// parseX25519Identity parses the first identity in an age identity file,
+// skipping comments.
+func parseX25519Identity(raw string) (*age.X25519Identity, error) {
+	for _, line := range strings.Split(raw, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		identity, err := age.ParseX25519Identity(line)
+		if err != nil {
+			return nil, fmt.Errorf("invalid age identity: %v: %w", err, keg.ErrInvalid)
+		}
+		return identity, nil
+	}
+	return nil, fmt.Errorf("no age identity found: %w", keg.ErrInvalid)
+}
@@ -0,0 +1,81 @@
+package tapper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/jlrickert/tapper/pkg/keg"
+)
+
+// KegPicker asks the user to choose one of aliases when no keg resolves for
+// the working directory. When remember is true the choice is saved as the
+// defaultKeg of the project config so later commands resolve it directly.
+type KegPicker func(ctx context.Context, aliases []string) (alias string, remember bool, err error)
+
+// pickKeg falls back to s.PickKeg when path based resolution found no alias.
+// Without a picker, or with no aliases to offer, it reports that no keg is
+// configured.
+func (s *KegService) pickKeg(ctx context.Context) (string, error) {
+	if s.PickKeg == nil {
+		return "", fmt.Errorf("no keg configured")
+	}
+	aliases := s.pickableAliases()
+	if len(aliases) == 0 {
+		return "", fmt.Errorf("no keg configured")
+	}
+	alias, remember, err := s.PickKeg(ctx, aliases)
+	if err != nil {
+		return "", err
+	}
+	if remember {
+		if err := s.rememberKeg(alias); err != nil {
+			return "", err
+		}
+	}
+	return alias, nil
+}
+
+// pickableAliases returns configured and discovered keg aliases, sorted.
+func (s *KegService) pickableAliases() []string {
+	seen := map[string]struct{}{}
+	for _, alias := range s.ConfigService.Config(true).ListKegs() {
+		seen[alias] = struct{}{}
+	}
+	if discovered, err := s.ConfigService.DiscoveredKegAliases(true); err == nil {
+		for _, alias := range discovered {
+			seen[alias] = struct{}{}
+		}
+	}
+	out := make([]string, 0, len(seen))
+	for alias := range seen {
+		out = append(out, alias)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// rememberKeg records alias as the defaultKeg of the project config,
+// creating the file when the project has none yet.
+func (s *KegService) rememberKeg(alias string) error {
+	cfg, err := s.ConfigService.ProjectConfig(false)
+	if err != nil {
+		if !errors.Is(err, keg.ErrNotExist) {
+			return fmt.Errorf("unable to read project config: %w", err)
+		}
+		cfg = &Config{}
+	}
+	if err := cfg.SetDefaultKeg(alias); err != nil {
+		return err
+	}
+	paths := s.ConfigService.PathService
+	if err := s.Runtime.Mkdir(paths.Project(), 0o755, true); err != nil {
+		return fmt.Errorf("unable to create project config directory: %w", err)
+	}
+	if err := cfg.Write(s.Runtime, paths.ProjectConfig()); err != nil {
+		return err
+	}
+	s.ConfigService.ResetCache()
+	return nil
+}
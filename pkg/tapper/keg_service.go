@@ -6,7 +6,9 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
+	"time"
 
+	"filippo.io/age"
 	appCtx "github.com/jlrickert/cli-toolkit/apppaths"
 	"github.com/jlrickert/cli-toolkit/toolkit"
 	"github.com/jlrickert/tapper/pkg/keg"
@@ -21,10 +23,33 @@ type KegService struct {
 	// ConfigService resolves configured keg aliases and targets.
 	ConfigService *ConfigService
 
+	// Credentials holds registry tokens saved by `tap auth login`. Nil
+	// leaves registry kegs with only configured tokens.
+	Credentials CredentialStore
+
+	// PickKeg, when set, is asked to choose a keg when nothing resolves for
+	// the working directory instead of failing.
+	PickKeg KegPicker
+
+	// PromptIdentity, when set, is asked for the age identity that decrypts
+	// encrypted nodes when none is configured.
+	PromptIdentity IdentityPrompter
+
+	// Events receives the changes of every keg the service resolves. Nil
+	// leaves kegs without an event bus.
+	Events *keg.EventBus
+
 	// cacheMu guards kegCache for concurrent access.
 	cacheMu sync.Mutex
 	// kegCache memoizes resolved kegs by alias or file-derived cache key.
 	kegCache map[string]*keg.Keg
+
+	// identityMu guards identityCache and identityErr.
+	identityMu sync.Mutex
+	// identityCache memoizes the age identities once resolved.
+	identityCache []age.Identity
+	// identityErr memoizes a failure to resolve the age identities.
+	identityErr error
 }
 
 // ResolveKegOptions controls how KegService resolves a keg target.
@@ -50,6 +75,125 @@ func (s *KegService) ensureCache() {
 	}
 }
 
+// newKeg constructs a keg for target, wraps its repository in the configured
+// middlewares, applies the configured write durability to file-backed
+// repositories, registers the user's hooks and keg trust and attaches the
+// service's event bus.
+func (s *KegService) newKeg(ctx context.Context, target kegurl.Target) (*keg.Keg, error) {
+	if s.ConfigService == nil {
+		return keg.NewKegFromTarget(ctx, target, s.Runtime, keg.WithEventBus(s.Events), keg.WithIdentities(s.identities))
+	}
+	cfg := s.ConfigService.Config(true)
+	if target.Scheme() == kegurl.SchemeRegistry {
+		target = withRegistry(ctx, s.Runtime, s.Credentials, cfg, target)
+	}
+	mws, err := repoMiddleware(cfg, s.Runtime)
+	if err != nil {
+		return nil, err
+	}
+	k, err := keg.NewKegFromTarget(ctx, target, s.Runtime, keg.WithEventBus(s.Events), keg.WithRepoMiddleware(mws...),
+		keg.WithIdentities(s.identities))
+	if err != nil {
+		return nil, err
+	}
+	if err := applyDurability(k, cfg); err != nil {
+		return nil, err
+	}
+	applyRegistry(k, cfg, s.ConfigService.PathService)
+	if own, err := s.ConfigService.PersonalConfig(true); err == nil && own != nil {
+		k.Hooks = append(k.Hooks, own.Hooks()...)
+		k.TrustedConfig = own.TrustedKeg(trustKey(s.Runtime, &target))
+	}
+	return k, nil
+}
+
+// trustKey names a keg in the trustedKegs section of the user config: the
+// absolute path of a file keg, or the target URL of any other.
+func trustKey(rt *toolkit.Runtime, target *kegurl.Target) string {
+	if target == nil {
+		return ""
+	}
+	if target.Scheme() != kegurl.SchemeFile {
+		return target.String()
+	}
+	path, err := toolkit.ExpandPath(rt, target.Path())
+	if err != nil {
+		path = target.Path()
+	}
+	if !filepath.IsAbs(path) {
+		if wd, err := rt.Getwd(); err == nil {
+			path = filepath.Join(wd, path)
+		}
+	}
+	return filepath.Clean(path)
+}
+
+// applyRegistry sets the retry policy of the target's registry on a
+// registry-backed keg and gives it an offline cache under the data root.
+func applyRegistry(k *keg.Keg, cfg *Config, paths *PathService) {
+	repo, ok := keg.UnwrapRepo(k.Repo).(*keg.RegistryRepo)
+	if !ok || cfg == nil || k.Target == nil {
+		return
+	}
+	if reg, ok := cfg.Registry(k.Target.Repo); ok {
+		policy := reg.RetryPolicy()
+		repo.Client.Retry = &policy
+	}
+	if paths != nil {
+		repo.Cache = keg.NewRegistryCache(paths.RegistryCache(k.Target.Repo, repo.User, repo.Keg), k.Runtime)
+	}
+}
+
+// repoMiddleware returns the repository middlewares selected by the
+// repository and telemetry sections of cfg, outermost first.
+func repoMiddleware(cfg *Config, rt *toolkit.Runtime) ([]keg.RepoMiddleware, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+	var mws []keg.RepoMiddleware
+	if cfg.Telemetry().Enabled() {
+		mws = append(mws, keg.TelemetryRepo())
+	}
+	rc := cfg.Repository()
+	if rc == nil {
+		return mws, nil
+	}
+	if rc.Log {
+		mws = append(mws, keg.LogRepo(rt.Logger()))
+	}
+	if rc.SlowCall != "" {
+		threshold, err := time.ParseDuration(rc.SlowCall)
+		if err != nil {
+			return nil, fmt.Errorf("invalid repository.slowCall in config: %w", err)
+		}
+		mws = append(mws, keg.SlowRepoCalls(rt.Logger(), threshold))
+	}
+	if rc.Readonly {
+		mws = append(mws, keg.ReadOnlyRepo())
+	}
+	if rc.Retries > 0 {
+		mws = append(mws, keg.RetryRepo(rc.Retries+1, repoRetryDelay))
+	}
+	return mws, nil
+}
+
+// repoRetryDelay is the wait before the first retry of a failed read.
+const repoRetryDelay = 100 * time.Millisecond
+
+// applyDurability sets the durability level from cfg on a file-backed keg.
+func applyDurability(k *keg.Keg, cfg *Config) error {
+	fsRepo, ok := keg.UnwrapRepo(k.Repo).(*keg.FsRepo)
+	if !ok || cfg == nil {
+		return nil
+	}
+	durability, err := keg.ParseDurability(cfg.Durability())
+	if err != nil {
+		return fmt.Errorf("invalid durability in config: %w", err)
+	}
+	fsRepo.Durability = durability
+	return nil
+}
+
 // Resolve returns a keg using explicit path, project, alias, or configured fallback resolution.
 func (s *KegService) Resolve(ctx context.Context, opts ResolveKegOptions) (*keg.Keg, error) {
 	s.cacheMu.Lock()
@@ -161,7 +305,7 @@ func (s *KegService) resolveFileKeg(ctx context.Context, root string, cache bool
 	}
 
 	target := kegurl.NewFile(root)
-	k, err := keg.NewKegFromTarget(ctx, target, s.Runtime)
+	k, err := s.newKeg(ctx, target)
 	if err != nil {
 		return nil, err
 	}
@@ -174,7 +318,8 @@ func (s *KegService) resolveFileKeg(ctx context.Context, root string, cache bool
 
 // resolvePath resolves the effective keg alias from config for the given path and returns its keg.
 //
-// Precedence: kegMap (path-specific) → defaultKeg (general) → fallbackKeg (last resort).
+// Precedence: kegMap (path-specific) → defaultKeg (general) → fallbackKeg
+// (last resort) → PickKeg when set.
 func (s *KegService) resolvePath(ctx context.Context, path string, cache bool) (*keg.Keg, error) {
 	s.ensureCache()
 	cfg := s.ConfigService.Config(true)
@@ -186,7 +331,11 @@ func (s *KegService) resolvePath(ctx context.Context, path string, cache bool) (
 		kegAlias = cfg.FallbackKeg()
 	}
 	if kegAlias == "" {
-		return nil, fmt.Errorf("no keg configured")
+		picked, err := s.pickKeg(ctx)
+		if err != nil {
+			return nil, err
+		}
+		kegAlias = picked
 	}
 	return s.resolveKegAlias(ctx, kegAlias, path, cache)
 }
@@ -206,7 +355,7 @@ func (s *KegService) resolveKegAlias(ctx context.Context, kegAlias string, proje
 
 	target, err := s.ConfigService.ResolveTarget(kegAlias, cache)
 	if err == nil && target != nil {
-		k, err := keg.NewKegFromTarget(ctx, *target, s.Runtime)
+		k, err := s.newKeg(ctx, *target)
 		if err != nil {
 			return k, err
 		}
@@ -233,7 +382,7 @@ func (s *KegService) resolveKegAlias(ctx context.Context, kegAlias string, proje
 		return nil, err
 	}
 
-	k, err := keg.NewKegFromTarget(ctx, *target, s.Runtime)
+	k, err := s.newKeg(ctx, *target)
 	if err != nil {
 		return k, err
 	}
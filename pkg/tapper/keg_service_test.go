@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	"github.com/jlrickert/cli-toolkit/sandbox"
+	"github.com/jlrickert/tapper/pkg/keg"
 	"github.com/jlrickert/tapper/pkg/tapper"
 	"github.com/stretchr/testify/require"
 )
@@ -324,3 +325,118 @@ defaultRegistry: ""
 	require.NotNil(t, target)
 	require.True(t, strings.HasSuffix(filepath.Clean(target.Path()), filepath.Clean("/home/testuser/Documents/kegs-b/pub")))
 }
+
+func TestResolve_AppliesConfiguredDurability(t *testing.T) {
+	t.Parallel()
+
+	fx := NewSandbox(t, sandbox.WithFixture("example", "/home/testuser"))
+	root := "/home/testuser"
+	require.NoError(t, fx.Setwd(root))
+
+	tap, err := tapper.NewTap(tapper.TapOptions{
+		Root:    root,
+		Runtime: fx.Runtime(),
+	})
+	require.NoError(t, err)
+
+	userCfg := []byte(`defaultKeg: pub
+durability: fsync
+kegs: {}
+kegSearchPaths:
+  - ~/Documents/kegs
+`)
+	require.NoError(t, fx.Runtime().Mkdir(filepath.Dir(tap.PathService.UserConfig()), 0o755, true))
+	require.NoError(t, fx.Runtime().AtomicWriteFile(tap.PathService.UserConfig(), userCfg, 0o644))
+	require.NoError(t, fx.Runtime().Mkdir("/home/testuser/Documents/kegs/pub", 0o755, true))
+	require.NoError(t, fx.Runtime().AtomicWriteFile("/home/testuser/Documents/kegs/pub/keg", []byte(""), 0o644))
+
+	k, err := tap.KegService.Resolve(context.Background(), tapper.ResolveKegOptions{Root: root})
+	require.NoError(t, err)
+	repo, ok := k.Repo.(*keg.FsRepo)
+	require.True(t, ok)
+	require.Equal(t, keg.DurabilityFsync, repo.Durability)
+}
+
+func TestResolve_AppliesConfiguredHooks(t *testing.T) {
+	t.Parallel()
+
+	fx := NewSandbox(t, sandbox.WithFixture("example", "/home/testuser"))
+	root := "/home/testuser"
+	require.NoError(t, fx.Setwd(root))
+
+	tap, err := tapper.NewTap(tapper.TapOptions{
+		Root:    root,
+		Runtime: fx.Runtime(),
+	})
+	require.NoError(t, err)
+
+	userCfg := []byte(`defaultKeg: pub
+hooks:
+  - event: post-create
+    run: git add -A && git commit -qm "$TAP_NODE_TITLE"
+kegs: {}
+kegSearchPaths:
+  - ~/Documents/kegs
+`)
+	require.NoError(t, fx.Runtime().Mkdir(filepath.Dir(tap.PathService.UserConfig()), 0o755, true))
+	require.NoError(t, fx.Runtime().AtomicWriteFile(tap.PathService.UserConfig(), userCfg, 0o644))
+	require.NoError(t, fx.Runtime().Mkdir("/home/testuser/Documents/kegs/pub", 0o755, true))
+	require.NoError(t, fx.Runtime().AtomicWriteFile("/home/testuser/Documents/kegs/pub/keg", []byte(""), 0o644))
+
+	k, err := tap.KegService.Resolve(context.Background(), tapper.ResolveKegOptions{Root: root})
+	require.NoError(t, err)
+	require.Equal(t, []keg.Hook{{
+		Event: keg.HookPostCreate,
+		Run:   `git add -A && git commit -qm "$TAP_NODE_TITLE"`,
+	}}, k.Hooks)
+}
+
+func TestResolve_SharesEventBus(t *testing.T) {
+	t.Parallel()
+	fx := NewSandbox(t)
+	tap := setupTapWithKeg(t, fx)
+
+	var created []string
+	keg.SubscribeTo(tap.KegService.Events, func(_ context.Context, e keg.NodeCreated) {
+		created = append(created, e.Title)
+	})
+	_, err := tap.Create(fx.Context(), tapper.CreateOptions{Title: "Published"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"Published"}, created)
+}
+
+func TestResolve_AppliesRepositoryConfig(t *testing.T) {
+	t.Parallel()
+
+	fx := NewSandbox(t, sandbox.WithFixture("example", "/home/testuser"))
+	root := "/home/testuser"
+	require.NoError(t, fx.Setwd(root))
+
+	tap, err := tapper.NewTap(tapper.TapOptions{
+		Root:    root,
+		Runtime: fx.Runtime(),
+	})
+	require.NoError(t, err)
+
+	userCfg := []byte(`defaultKeg: pub
+durability: fsync
+repository:
+  log: true
+  slowCall: 2s
+  readonly: true
+kegs: {}
+kegSearchPaths:
+  - ~/Documents/kegs
+`)
+	require.NoError(t, fx.Runtime().Mkdir(filepath.Dir(tap.PathService.UserConfig()), 0o755, true))
+	require.NoError(t, fx.Runtime().AtomicWriteFile(tap.PathService.UserConfig(), userCfg, 0o644))
+	require.NoError(t, fx.Runtime().Mkdir("/home/testuser/Documents/kegs/pub", 0o755, true))
+	require.NoError(t, fx.Runtime().AtomicWriteFile("/home/testuser/Documents/kegs/pub/keg", []byte(""), 0o644))
+
+	k, err := tap.KegService.Resolve(context.Background(), tapper.ResolveKegOptions{Root: root})
+	require.NoError(t, err)
+	fsRepo, ok := keg.UnwrapRepo(k.Repo).(*keg.FsRepo)
+	require.True(t, ok)
+	require.Equal(t, keg.DurabilityFsync, fsRepo.Durability, "backend settings reach the wrapped repo")
+	require.ErrorIs(t, k.Repo.WriteIndex(context.Background(), "nodes.tsv", nil), keg.ErrReadOnly)
+}
@@ -0,0 +1,408 @@
+package tapper
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/yuin/goldmark"
+	gm_ast "github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+)
+
+var (
+	fmtNodeLinkRE    = regexp.MustCompile(`\]\(\s*\.\./\s*([0-9]+)/?\s*\)`)
+	fmtTableDelimRE  = regexp.MustCompile(`^\|?\s*:?-+:?\s*(\|\s*:?-+:?\s*)*\|?$`)
+	fmtOrderedMarkRE = regexp.MustCompile(`^[0-9]{1,9}[.)]$`)
+	fmtHeadingMarkRE = regexp.MustCompile(`^#{1,6}$`)
+	fmtBreakRE       = regexp.MustCompile(`^[-=*_]+$`)
+)
+
+// minWrapWidth keeps deeply nested paragraphs from being wrapped to a
+// word per line.
+const minWrapWidth = 20
+
+// markdownFormatter writes Markdown back out in a canonical form. Block
+// structure comes from the goldmark AST; inline content, code and HTML are
+// copied from the source so nothing the formatter does not understand is
+// lost.
+type markdownFormatter struct {
+	src []byte
+
+	// width wraps paragraphs to this many columns. Zero keeps line breaks.
+	width int
+
+	// indent is the width of the list and blockquote prefixes around the
+	// block being written.
+	indent int
+
+	// level is the level of the last heading written.
+	level int
+}
+
+// formatMarkdown normalizes Markdown: ATX headings that go at most one level
+// deeper than the heading before them, "-" bullets and sequential numbers,
+// fenced code, one blank line between blocks, no trailing whitespace,
+// backslash hard breaks, canonical ../N node links and, with a positive
+// width, paragraphs wrapped to width columns. Frontmatter, code and HTML
+// are kept as written and link reference definitions move to the end.
+func formatMarkdown(src []byte, width int) []byte {
+	front, body := splitFrontmatter(src)
+	if len(bytes.TrimSpace(body)) == 0 {
+		return src
+	}
+	f := &markdownFormatter{src: body, width: width}
+	pc := parser.NewContext()
+	doc := goldmark.New().Parser().Parse(text.NewReader(body), parser.WithContext(pc))
+
+	parts := []string{strings.TrimRight(f.blocks(doc, true), "\n")}
+	if refs := f.references(pc.References()); refs != "" {
+		parts = append(parts, refs)
+	}
+	out := strings.TrimLeft(strings.Join(parts, "\n\n"), "\n")
+	return append(front, []byte(out+"\n")...)
+}
+
+// splitFrontmatter splits a leading YAML frontmatter block, including its
+// closing line, from src.
+func splitFrontmatter(src []byte) ([]byte, []byte) {
+	if !bytes.HasPrefix(src, []byte("---\n")) {
+		return nil, src
+	}
+	rest := src[len("---\n"):]
+	for off := 0; off < len(rest); {
+		end := bytes.IndexByte(rest[off:], '\n')
+		line := rest[off:]
+		next := len(rest)
+		if end >= 0 {
+			line = rest[off : off+end]
+			next = off + end + 1
+		}
+		if s := string(bytes.TrimRight(line, " \t\r")); s == "---" || s == "..." {
+			split := len("---\n") + next
+			return bytes.Clone(src[:split]), src[split:]
+		}
+		off = next
+	}
+	return nil, src
+}
+
+// blocks writes the block children of n. Loose containers separate
+// children with a blank line.
+func (f *markdownFormatter) blocks(n gm_ast.Node, loose bool) string {
+	var b strings.Builder
+	for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+		if c.PreviousSibling() != nil {
+			// A thematic break right under a line of text would turn it
+			// into a setext heading.
+			if loose || c.Kind() == gm_ast.KindThematicBreak {
+				b.WriteString("\n\n")
+			} else {
+				b.WriteString("\n")
+			}
+		}
+		b.WriteString(strings.TrimRight(f.block(c), "\n"))
+	}
+	return b.String()
+}
+
+func (f *markdownFormatter) block(n gm_ast.Node) string {
+	switch v := n.(type) {
+	case *gm_ast.Heading:
+		level := min(v.Level, f.level+1)
+		f.level = level
+		marker := strings.Repeat("#", level)
+		title := canonicalNodeLinks(strings.Join(f.lineTexts(v), " "))
+		if title == "" {
+			return marker
+		}
+		return marker + " " + title
+	case *gm_ast.Paragraph, *gm_ast.TextBlock:
+		return f.paragraph(v)
+	case *gm_ast.ThematicBreak:
+		return "---"
+	case *gm_ast.FencedCodeBlock:
+		info := ""
+		if v.Info != nil {
+			info = strings.TrimSpace(string(v.Info.Segment.Value(f.src)))
+		}
+		return f.fence(f.rawLines(v), info)
+	case *gm_ast.CodeBlock:
+		return f.fence(strings.TrimRight(f.rawLines(v), "\n")+"\n", "")
+	case *gm_ast.HTMLBlock:
+		raw := f.rawLines(v)
+		if v.HasClosure() {
+			raw += string(v.ClosureLine.Value(f.src))
+		}
+		return trimLinesRight(raw)
+	case *gm_ast.Blockquote:
+		f.indent += 2
+		body := f.blocks(v, true)
+		f.indent -= 2
+		return prefixLines(body, "> ", "> ")
+	case *gm_ast.List:
+		return f.list(v)
+	}
+	return f.blocks(n, true)
+}
+
+func (f *markdownFormatter) list(v *gm_ast.List) string {
+	// Neighbouring lists of the same kind would merge once their markers
+	// match, so every other one gets the alternate marker.
+	alt := false
+	for p := v.PreviousSibling(); p != nil; p = p.PreviousSibling() {
+		prev, ok := p.(*gm_ast.List)
+		if !ok || prev.IsOrdered() != v.IsOrdered() {
+			break
+		}
+		alt = !alt
+	}
+
+	var items []string
+	num := v.Start
+	for c := v.FirstChild(); c != nil; c = c.NextSibling() {
+		marker := "- "
+		switch {
+		case v.IsOrdered() && alt:
+			marker = fmt.Sprintf("%d) ", num)
+		case v.IsOrdered():
+			marker = fmt.Sprintf("%d. ", num)
+		case alt:
+			marker = "* "
+		}
+		num++
+		f.indent += len(marker)
+		body := f.blocks(c, !v.IsTight)
+		f.indent -= len(marker)
+		if body == "" {
+			items = append(items, strings.TrimRight(marker, " "))
+			continue
+		}
+		items = append(items, prefixLines(body, marker, strings.Repeat(" ", len(marker))))
+	}
+	if v.IsTight {
+		return strings.Join(items, "\n")
+	}
+	return strings.Join(items, "\n\n")
+}
+
+// fence writes code as a fenced block, with a fence longer than any
+// backtick fence inside it.
+func (f *markdownFormatter) fence(code, info string) string {
+	fence := "```"
+	for _, line := range strings.Split(code, "\n") {
+		trimmed := strings.TrimLeft(line, " ")
+		for strings.HasPrefix(trimmed, fence) {
+			fence += "`"
+		}
+	}
+	return fence + info + "\n" + code + fence
+}
+
+// paragraph writes the source lines of a paragraph, wrapped to the
+// formatter width when one is set. Hard breaks are written as a trailing
+// backslash.
+func (f *markdownFormatter) paragraph(n gm_ast.Node) string {
+	lines := n.Lines()
+	var chunks [][]string
+	var cur []string
+	for i := 0; i < lines.Len(); i++ {
+		seg := lines.At(i)
+		raw := strings.TrimRight(string(seg.Value(f.src)), "\r\n")
+		line := strings.TrimSpace(raw)
+		last := i == lines.Len()-1
+		switch {
+		case !last && strings.HasSuffix(raw, "  "):
+			chunks, cur = append(chunks, append(cur, line)), nil
+		case !last && strings.HasSuffix(line, `\`):
+			chunks, cur = append(chunks, append(cur, strings.TrimSuffix(line, `\`))), nil
+		default:
+			cur = append(cur, line)
+		}
+	}
+	chunks = append(chunks, cur)
+
+	var out []string
+	for i, chunk := range chunks {
+		wrapped := f.wrap(chunk)
+		if i < len(chunks)-1 {
+			wrapped[len(wrapped)-1] = strings.TrimRight(wrapped[len(wrapped)-1], " ") + `\`
+		}
+		out = append(out, wrapped...)
+	}
+	return strings.Join(out, "\n")
+}
+
+// wrap joins lines of paragraph text and, with a width set, refills them.
+// A word that would start a block, such as "#" or "1.", is never moved to
+// the start of a line.
+func (f *markdownFormatter) wrap(lines []string) []string {
+	if f.width <= 0 || isTableText(lines) {
+		var out []string
+		for _, line := range strings.Split(canonicalNodeLinks(strings.Join(lines, "\n")), "\n") {
+			if len(out) > 0 && startsBlock(firstWord(line)) && !isTableText(lines) {
+				out[len(out)-1] += " " + line
+				continue
+			}
+			out = append(out, line)
+		}
+		return out
+	}
+
+	width := max(f.width-f.indent, minWrapWidth)
+	var out []string
+	cur := ""
+	for _, word := range strings.Fields(canonicalNodeLinks(strings.Join(lines, " "))) {
+		switch {
+		case cur == "":
+			cur = word
+		case utf8.RuneCountInString(cur)+1+utf8.RuneCountInString(word) > width && !startsBlock(word):
+			out = append(out, cur)
+			cur = word
+		default:
+			cur += " " + word
+		}
+	}
+	return append(out, cur)
+}
+
+// references writes link reference definitions in the order they appear
+// in the source.
+func (f *markdownFormatter) references(refs []parser.Reference) string {
+	if len(refs) == 0 {
+		return ""
+	}
+	pos := func(r parser.Reference) int {
+		if i := bytes.Index(f.src, append(append([]byte("["), r.Label()...), ']', ':')); i >= 0 {
+			return i
+		}
+		return len(f.src)
+	}
+	sort.SliceStable(refs, func(i, j int) bool {
+		if pi, pj := pos(refs[i]), pos(refs[j]); pi != pj {
+			return pi < pj
+		}
+		return string(refs[i].Label()) < string(refs[j].Label())
+	})
+
+	var lines []string
+	for _, r := range refs {
+		dest := string(r.Destination())
+		if dest == "" || strings.ContainsAny(dest, " \t") {
+			dest = "<" + dest + ">"
+		}
+		line := fmt.Sprintf("[%s]: %s", r.Label(), dest)
+		if title := string(r.Title()); title != "" {
+			switch {
+			case !strings.Contains(title, `"`):
+				line += ` "` + title + `"`
+			case !strings.Contains(title, "'"):
+				line += " '" + title + "'"
+			default:
+				line += " (" + title + ")"
+			}
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// lineTexts returns the trimmed source lines of a block.
+func (f *markdownFormatter) lineTexts(n gm_ast.Node) []string {
+	var out []string
+	lines := n.Lines()
+	for i := 0; i < lines.Len(); i++ {
+		seg := lines.At(i)
+		if s := strings.TrimSpace(string(seg.Value(f.src))); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// rawLines returns the source lines of a block, keeping the indentation
+// goldmark records as padding.
+func (f *markdownFormatter) rawLines(n gm_ast.Node) string {
+	var b strings.Builder
+	lines := n.Lines()
+	for i := 0; i < lines.Len(); i++ {
+		seg := lines.At(i)
+		b.WriteString(strings.Repeat(" ", seg.Padding))
+		b.Write(seg.Value(f.src))
+	}
+	return b.String()
+}
+
+// canonicalNodeLinks rewrites node link destinations such as "( ../ 42/ )"
+// to "(../42)", leaving code spans alone.
+func canonicalNodeLinks(s string) string {
+	var b strings.Builder
+	for s != "" {
+		start := strings.IndexByte(s, '`')
+		if start < 0 {
+			b.WriteString(fmtNodeLinkRE.ReplaceAllString(s, "](../$1)"))
+			break
+		}
+		b.WriteString(fmtNodeLinkRE.ReplaceAllString(s[:start], "](../$1)"))
+		run := len(s[start:]) - len(strings.TrimLeft(s[start:], "`"))
+		fence := s[start : start+run]
+		end := strings.Index(s[start+run:], fence)
+		if end < 0 {
+			b.WriteString(s[start:])
+			break
+		}
+		stop := start + run + end + run
+		b.WriteString(s[start:stop])
+		s = s[stop:]
+	}
+	return b.String()
+}
+
+// startsBlock reports whether a line starting with word could begin a new
+// block instead of continuing a paragraph.
+func startsBlock(word string) bool {
+	switch {
+	case word == "":
+		return false
+	case fmtHeadingMarkRE.MatchString(word), fmtOrderedMarkRE.MatchString(word), fmtBreakRE.MatchString(word):
+		return true
+	case word == "+":
+		return true
+	}
+	for _, prefix := range []string{">", "<", "```", "~~~"} {
+		if strings.HasPrefix(word, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func firstWord(line string) string {
+	if fields := strings.Fields(line); len(fields) > 0 {
+		return fields[0]
+	}
+	return ""
+}
+
+// isTableText reports whether paragraph lines look like a pipe table,
+// which is kept line by line.
+func isTableText(lines []string) bool {
+	for _, line := range lines {
+		if strings.Contains(line, "-") && fmtTableDelimRE.MatchString(line) && strings.Contains(strings.Join(lines, ""), "|") {
+			return true
+		}
+	}
+	return false
+}
+
+func trimLinesRight(s string) string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t\r")
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,68 @@
+package tapper
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatMarkdown(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name  string
+		in    string
+		width int
+		want  string
+	}{
+		{
+			name: "headings",
+			in:   "Title\n=====\n\n#### Deep ###\n\nSub\n---\n",
+			want: "# Title\n\n## Deep\n\n## Sub\n",
+		},
+		{
+			name: "lists",
+			in:   "# T\n\n* one\n+ two\n\n3. a\n7. b\n   * nested   \n",
+			want: "# T\n\n- one\n\n* two\n\n3. a\n4. b\n   - nested\n",
+		},
+		{
+			name: "trailing whitespace and hard breaks",
+			in:   "# T   \n\nline one  \nline two\\\nline three   \n\n\n\n---\n",
+			want: "# T\n\nline one\\\nline two\\\nline three\n\n---\n",
+		},
+		{
+			name: "code is kept",
+			in:   "# T\n\n    indented  \n\n~~~go\nx := 1  \n```\n~~~\n",
+			want: "# T\n\n```\nindented  \n```\n\n````go\nx := 1  \n```\n````\n",
+		},
+		{
+			name: "node links",
+			in:   "# T\n\nSee [a]( ../ 12/ ) and `[b]( ../3 )`.\n",
+			want: "# T\n\nSee [a](../12) and `[b]( ../3 )`.\n",
+		},
+		{
+			name:  "wraps paragraphs",
+			in:    "# T\n\nalpha beta gamma delta epsilon zeta eta theta iota kappa lambda mu - nu\n\n> quoted text that is long enough to wrap here\n",
+			width: 24,
+			want:  "# T\n\nalpha beta gamma delta\nepsilon zeta eta theta\niota kappa lambda mu -\nnu\n\n> quoted text that is\n> long enough to wrap\n> here\n",
+		},
+		{
+			name:  "keeps tables",
+			in:    "# T\n\n| a | b |\n|---|---|\n| 1 | 2 |\n",
+			width: 10,
+			want:  "# T\n\n| a | b |\n|---|---|\n| 1 | 2 |\n",
+		},
+		{
+			name: "frontmatter and references",
+			in:   "---\ntags: [a]\n---\n# T\n\n[x]: https://example.com  \"X\"\nSee [x].\n",
+			want: "---\ntags: [a]\n---\n# T\n\nSee [x].\n\n[x]: https://example.com \"X\"\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got := string(formatMarkdown([]byte(tt.in), tt.width))
+			require.Equal(t, tt.want, got)
+			require.Equal(t, got, string(formatMarkdown([]byte(got), tt.width)), "formatting is not idempotent")
+		})
+	}
+}
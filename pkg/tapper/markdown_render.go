@@ -0,0 +1,351 @@
+package tapper
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	gm_ast "github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/text"
+)
+
+// ANSI styles used by the terminal Markdown renderer.
+const (
+	ansiReset     = "\x1b[0m"
+	ansiBold      = "\x1b[1m"
+	ansiDim       = "\x1b[2m"
+	ansiItalic    = "\x1b[3m"
+	ansiUnderline = "\x1b[4m"
+	ansiRed       = "\x1b[31m"
+	ansiGreen     = "\x1b[32m"
+	ansiYellow    = "\x1b[33m"
+	ansiBlue      = "\x1b[34m"
+	ansiMagenta   = "\x1b[35m"
+	ansiCyan      = "\x1b[36m"
+)
+
+var headingStyles = []string{
+	ansiBold + ansiMagenta,
+	ansiBold + ansiCyan,
+	ansiBold + ansiBlue,
+	ansiBold + ansiGreen,
+	ansiBold + ansiYellow,
+	ansiBold,
+}
+
+var (
+	nodeLinkDestRE = regexp.MustCompile(`^\s*\.\./\s*([0-9]+)/?\s*$`)
+	bareNodeLinkRE = regexp.MustCompile(`\.\./([0-9]+)\b`)
+)
+
+// markdownRenderer renders Markdown for a terminal: headings, emphasis and
+// code are styled with ANSI escapes, fenced code is keyword highlighted and
+// links to other nodes are followed by the title of the node they point at.
+type markdownRenderer struct {
+	src []byte
+
+	// color enables ANSI styles. Without it only the layout is rendered.
+	color bool
+
+	// nodeTitle returns the title of node id, or false when it is unknown.
+	nodeTitle func(id string) (string, bool)
+}
+
+// renderMarkdown renders src for display in a terminal.
+func renderMarkdown(src []byte, color bool, nodeTitle func(id string) (string, bool)) string {
+	r := &markdownRenderer{src: src, color: color, nodeTitle: nodeTitle}
+	doc := goldmark.New().Parser().Parse(text.NewReader(src))
+	return strings.TrimRight(r.blocks(doc, true), "\n") + "\n"
+}
+
+func (r *markdownRenderer) style(s string, codes ...string) string {
+	if !r.color || s == "" {
+		return s
+	}
+	return strings.Join(codes, "") + s + ansiReset
+}
+
+// blocks renders the block children of n. Loose containers separate
+// children with a blank line.
+func (r *markdownRenderer) blocks(n gm_ast.Node, loose bool) string {
+	var parts []string
+	for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+		parts = append(parts, strings.TrimRight(r.block(c), "\n"))
+	}
+	sep := "\n"
+	if loose {
+		sep = "\n\n"
+	}
+	return strings.Join(parts, sep) + "\n"
+}
+
+func (r *markdownRenderer) block(n gm_ast.Node) string {
+	switch v := n.(type) {
+	case *gm_ast.Heading:
+		style := headingStyles[min(v.Level, len(headingStyles))-1]
+		return r.style(strings.Repeat("#", v.Level)+" "+r.inlinesPlain(v), style)
+	case *gm_ast.Paragraph, *gm_ast.TextBlock:
+		return r.inlines(v)
+	case *gm_ast.ThematicBreak:
+		return r.style(strings.Repeat("─", 40), ansiDim)
+	case *gm_ast.FencedCodeBlock:
+		lang := string(v.Language(r.src))
+		code := r.highlight(r.lines(v), lang)
+		if lang != "" {
+			code = r.style(lang, ansiDim) + "\n" + code
+		}
+		return code
+	case *gm_ast.CodeBlock:
+		return r.highlight(r.lines(v), "")
+	case *gm_ast.HTMLBlock:
+		return r.style(strings.TrimRight(r.lines(v), "\n"), ansiDim)
+	case *gm_ast.Blockquote:
+		bar := r.style("│ ", ansiDim)
+		return prefixLines(r.blocks(v, true), bar, bar)
+	case *gm_ast.List:
+		var items []string
+		num := v.Start
+		for c := v.FirstChild(); c != nil; c = c.NextSibling() {
+			marker := "• "
+			if v.IsOrdered() {
+				marker = fmt.Sprintf("%d. ", num)
+				num++
+			}
+			body := r.blocks(c, !v.IsTight)
+			items = append(items, prefixLines(body, r.style(marker, ansiCyan), strings.Repeat(" ", len([]rune(marker)))))
+		}
+		sep := "\n"
+		if !v.IsTight {
+			sep = "\n\n"
+		}
+		return strings.Join(items, sep)
+	}
+	return r.blocks(n, true)
+}
+
+// lines returns the raw source lines of a block.
+func (r *markdownRenderer) lines(n gm_ast.Node) string {
+	var b strings.Builder
+	lines := n.Lines()
+	for i := 0; i < lines.Len(); i++ {
+		seg := lines.At(i)
+		b.Write(seg.Value(r.src))
+	}
+	return b.String()
+}
+
+// inlinesPlain renders inline children without styles, for headings.
+func (r *markdownRenderer) inlinesPlain(n gm_ast.Node) string {
+	color := r.color
+	r.color = false
+	defer func() { r.color = color }()
+	return r.inlines(n)
+}
+
+func (r *markdownRenderer) inlines(n gm_ast.Node) string {
+	var b strings.Builder
+	for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+		b.WriteString(r.inline(c))
+	}
+	return b.String()
+}
+
+func (r *markdownRenderer) inline(n gm_ast.Node) string {
+	switch v := n.(type) {
+	case *gm_ast.Text:
+		s := r.annotateBareLinks(string(v.Segment.Value(r.src)))
+		if v.SoftLineBreak() || v.HardLineBreak() {
+			s += "\n"
+		}
+		return s
+	case *gm_ast.String:
+		return string(v.Value)
+	case *gm_ast.CodeSpan:
+		var b strings.Builder
+		for c := v.FirstChild(); c != nil; c = c.NextSibling() {
+			if t, ok := c.(*gm_ast.Text); ok {
+				b.Write(t.Segment.Value(r.src))
+			}
+		}
+		return r.style(b.String(), ansiYellow)
+	case *gm_ast.Emphasis:
+		if v.Level >= 2 {
+			return r.style(r.inlines(v), ansiBold)
+		}
+		return r.style(r.inlines(v), ansiItalic)
+	case *gm_ast.Link:
+		label := r.inlines(v)
+		return r.style(label, ansiUnderline, ansiBlue) + r.linkNote(label, string(v.Destination))
+	case *gm_ast.AutoLink:
+		url := string(v.URL(r.src))
+		return r.style(url, ansiUnderline, ansiBlue)
+	case *gm_ast.Image:
+		label := "image"
+		if alt := r.inlines(v); alt != "" {
+			label += ": " + alt
+		}
+		return r.style("["+label+"]", ansiDim) + r.style(" "+string(v.Destination), ansiDim)
+	case *gm_ast.RawHTML:
+		var b strings.Builder
+		for i := 0; i < v.Segments.Len(); i++ {
+			seg := v.Segments.At(i)
+			b.Write(seg.Value(r.src))
+		}
+		return r.style(b.String(), ansiDim)
+	}
+	return r.inlines(n)
+}
+
+// linkNote describes where a link points: the title of a linked node, or the
+// URL when it differs from the label.
+func (r *markdownRenderer) linkNote(label, dest string) string {
+	if m := nodeLinkDestRE.FindStringSubmatch(dest); m != nil {
+		if title, ok := r.nodeTitle(m[1]); ok && title != label {
+			return r.style(" → "+m[1]+" "+title, ansiDim)
+		}
+		return r.style(" → "+m[1], ansiDim)
+	}
+	if dest == "" || dest == label {
+		return ""
+	}
+	return r.style(" <"+dest+">", ansiDim)
+}
+
+// annotateBareLinks appends the node title to bare ../N references in text.
+func (r *markdownRenderer) annotateBareLinks(s string) string {
+	return bareNodeLinkRE.ReplaceAllStringFunc(s, func(ref string) string {
+		id := strings.TrimPrefix(ref, "../")
+		title, ok := r.nodeTitle(id)
+		if !ok {
+			return ref
+		}
+		return r.style(ref, ansiUnderline, ansiBlue) + r.style(" ("+title+")", ansiDim)
+	})
+}
+
+var (
+	codeStringRE  = regexp.MustCompile("\"(?:[^\"\\\\]|\\\\.)*\"|'(?:[^'\\\\]|\\\\.)*'|`[^`]*`")
+	codeTokenRE   = regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_]*|\b[0-9]+(?:\.[0-9]+)?\b`)
+	codeKeywords  = map[string]bool{}
+	commentTokens = map[string]string{
+		"":           "//",
+		"go":         "//",
+		"c":          "//",
+		"cpp":        "//",
+		"java":       "//",
+		"javascript": "//",
+		"js":         "//",
+		"typescript": "//",
+		"ts":         "//",
+		"rust":       "//",
+		"python":     "#",
+		"py":         "#",
+		"sh":         "#",
+		"bash":       "#",
+		"shell":      "#",
+		"yaml":       "#",
+		"yml":        "#",
+		"ruby":       "#",
+		"toml":       "#",
+		"sql":        "--",
+		"lua":        "--",
+	}
+)
+
+func init() {
+	for _, kw := range strings.Fields(`break case chan const continue default defer
+		else fallthrough for func go goto if import interface map package range
+		return select struct switch type var class def elif except finally from
+		in is lambda not or and pass raise try while with yield async await
+		function let new this throw catch typeof instanceof export extends fn
+		impl mut pub use match loop enum trait where then fi do done esac echo
+		local true false nil null None True False self SELECT FROM WHERE INSERT
+		UPDATE DELETE JOIN ON AS`) {
+		codeKeywords[kw] = true
+	}
+}
+
+// highlight applies light, language-agnostic syntax highlighting to code:
+// comments, strings, numbers and common keywords. Each line is indented by
+// two spaces.
+func (r *markdownRenderer) highlight(code string, lang string) string {
+	comment, ok := commentTokens[strings.ToLower(lang)]
+	if !ok {
+		comment = commentTokens[""]
+	}
+	lines := strings.Split(strings.TrimRight(code, "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = "  " + r.highlightLine(line, comment)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (r *markdownRenderer) highlightLine(line, comment string) string {
+	if !r.color {
+		return line
+	}
+	// Split off a trailing comment that does not start inside a string.
+	tail := ""
+	quoted := codeStringRE.FindAllStringIndex(line, -1)
+	for from := 0; ; {
+		idx := strings.Index(line[from:], comment)
+		if idx < 0 {
+			break
+		}
+		idx += from
+		inString := false
+		for _, s := range quoted {
+			if idx >= s[0] && idx < s[1] {
+				inString = true
+				from = s[1]
+				break
+			}
+		}
+		if !inString {
+			tail = r.style(line[idx:], ansiDim)
+			line = line[:idx]
+			break
+		}
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, s := range codeStringRE.FindAllStringIndex(line, -1) {
+		b.WriteString(r.highlightWords(line[last:s[0]]))
+		b.WriteString(r.style(line[s[0]:s[1]], ansiGreen))
+		last = s[1]
+	}
+	b.WriteString(r.highlightWords(line[last:]))
+	return b.String() + tail
+}
+
+func (r *markdownRenderer) highlightWords(s string) string {
+	return codeTokenRE.ReplaceAllStringFunc(s, func(tok string) string {
+		switch {
+		case codeKeywords[tok]:
+			return r.style(tok, ansiBold, ansiBlue)
+		case tok[0] >= '0' && tok[0] <= '9':
+			return r.style(tok, ansiRed)
+		}
+		return tok
+	})
+}
+
+// prefixLines prefixes the first line of s with first and every following
+// line with rest.
+func prefixLines(s, first, rest string) string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	for i, line := range lines {
+		p := rest
+		if i == 0 {
+			p = first
+		}
+		if line == "" {
+			lines[i] = strings.TrimRight(p, " ")
+			continue
+		}
+		lines[i] = p + line
+	}
+	return strings.Join(lines, "\n")
+}
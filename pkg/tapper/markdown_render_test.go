@@ -0,0 +1,37 @@
+package tapper
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderMarkdown_Layout(t *testing.T) {
+	t.Parallel()
+	src := "# Title\n\nSee [Alpha](../2), ../3 and [site](https://example.com).\n\n" +
+		"- one\n- two\n  - nested\n\n> quoted\n\n```go\nreturn 42 // done\n```\n"
+	titles := map[string]string{"2": "Project Alpha", "3": "Meeting Notes"}
+	nodeTitle := func(id string) (string, bool) {
+		title, ok := titles[id]
+		return title, ok
+	}
+
+	got := renderMarkdown([]byte(src), false, nodeTitle)
+	require.Equal(t, "# Title\n\n"+
+		"See Alpha → 2 Project Alpha, ../3 (Meeting Notes) and site <https://example.com>.\n\n"+
+		"• one\n• two\n  • nested\n\n"+
+		"│ quoted\n\n"+
+		"go\n  return 42 // done\n", got)
+}
+
+func TestRenderMarkdown_Colors(t *testing.T) {
+	t.Parallel()
+	nodeTitle := func(string) (string, bool) { return "", false }
+
+	got := renderMarkdown([]byte("## Head\n\n**bold** `code`\n\n```\nif x == \"a\" {}\n```\n"), true, nodeTitle)
+	require.Contains(t, got, ansiBold+ansiCyan+"## Head"+ansiReset)
+	require.Contains(t, got, ansiBold+"bold"+ansiReset)
+	require.Contains(t, got, ansiYellow+"code"+ansiReset)
+	require.Contains(t, got, ansiBold+ansiBlue+"if"+ansiReset)
+	require.Contains(t, got, ansiGreen+`"a"`+ansiReset)
+}
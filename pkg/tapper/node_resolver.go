@@ -0,0 +1,179 @@
+package tapper
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/jlrickert/tapper/pkg/keg"
+)
+
+// maxNodeMatchCandidates caps the number of candidates reported when a fuzzy
+// node argument is ambiguous.
+const maxNodeMatchCandidates = 10
+
+// NodeMatch is a candidate produced by fuzzy title matching.
+type NodeMatch struct {
+	Entry keg.NodeIndexEntry
+	Score int
+}
+
+// resolveNode turns a user supplied node argument into a NodeId. Numeric
+// arguments (and "<id>-<code>" forms) are parsed directly. Any other argument
+// is matched against node titles in the dex unless exact is set, in which case
+// only canonical node IDs are accepted.
+func (t *Tap) resolveNode(ctx context.Context, k *keg.Keg, arg string, exact bool) (keg.NodeId, error) {
+	node, err := keg.ParseNode(arg)
+	if err == nil && node != nil {
+		return keg.NodeId{ID: node.ID, Code: node.Code}, nil
+	}
+	if exact || strings.TrimSpace(arg) == "" {
+		if err == nil {
+			err = keg.ErrInvalid
+		}
+		return keg.NodeId{}, fmt.Errorf("invalid node ID %q: %w", arg, err)
+	}
+
+	dex, dexErr := k.Dex(ctx)
+	if dexErr != nil {
+		return keg.NodeId{}, fmt.Errorf("unable to read dex: %w", dexErr)
+	}
+
+	matches := MatchNodeTitles(dex.Nodes(ctx), arg)
+	if len(matches) == 0 {
+		return keg.NodeId{}, fmt.Errorf("invalid node ID %q: no node title matches: %w", arg, keg.ErrInvalid)
+	}
+
+	// A single candidate, or a candidate that is strictly better than every
+	// other one, resolves without prompting the user.
+	if len(matches) == 1 || matches[0].Score > matches[1].Score {
+		id, parseErr := keg.ParseNode(matches[0].Entry.ID)
+		if parseErr != nil || id == nil {
+			return keg.NodeId{}, fmt.Errorf("invalid node ID %q in index: %w", matches[0].Entry.ID, keg.ErrInvalid)
+		}
+		return keg.NodeId{ID: id.ID, Code: id.Code}, nil
+	}
+
+	candidates := make([]keg.NodeIndexEntry, 0, maxNodeMatchCandidates)
+	for _, m := range matches {
+		if len(candidates) >= maxNodeMatchCandidates {
+			break
+		}
+		candidates = append(candidates, m.Entry)
+	}
+	return keg.NodeId{}, &AmbiguousNodeError{Query: arg, Candidates: candidates, Total: len(matches)}
+}
+
+// MatchNodeTitles scores entries against query and returns the matching
+// entries ordered from best to worst. Ties are broken by ascending node ID.
+//
+// Scoring favors, in order: an exact case-insensitive title match, a title
+// prefix match, a substring match, every query word appearing in the title,
+// and finally an in-order subsequence of the query characters.
+func MatchNodeTitles(entries []keg.NodeIndexEntry, query string) []NodeMatch {
+	q := normalizeMatchText(query)
+	if q == "" {
+		return nil
+	}
+
+	out := make([]NodeMatch, 0)
+	for _, entry := range entries {
+		score := scoreTitleMatch(normalizeMatchText(entry.Title), q)
+		if score <= 0 {
+			continue
+		}
+		out = append(out, NodeMatch{Entry: entry, Score: score})
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Score != out[j].Score {
+			return out[i].Score > out[j].Score
+		}
+		return compareNodeEntryID(out[i].Entry.ID, out[j].Entry.ID) < 0
+	})
+	return out
+}
+
+func scoreTitleMatch(title string, query string) int {
+	if title == "" {
+		return 0
+	}
+	switch {
+	case title == query:
+		return 1000
+	case strings.HasPrefix(title, query):
+		return 800
+	case strings.Contains(title, query):
+		return 600
+	}
+
+	words := strings.Fields(query)
+	if len(words) > 1 {
+		all := true
+		for _, w := range words {
+			if !strings.Contains(title, w) {
+				all = false
+				break
+			}
+		}
+		if all {
+			return 400
+		}
+	}
+
+	if gaps, ok := subsequenceGaps(title, query); ok {
+		// Tighter subsequences rank higher but always below word matches.
+		score := 300 - gaps
+		if score < 1 {
+			score = 1
+		}
+		return score
+	}
+	return 0
+}
+
+// subsequenceGaps reports whether every rune of query appears in title in
+// order, and how many title runes were skipped between matched runes.
+func subsequenceGaps(title string, query string) (int, bool) {
+	qr := []rune(strings.ReplaceAll(query, " ", ""))
+	if len(qr) == 0 {
+		return 0, false
+	}
+	gaps := 0
+	started := false
+	qi := 0
+	for _, r := range title {
+		if qi >= len(qr) {
+			break
+		}
+		if r == qr[qi] {
+			started = true
+			qi++
+			continue
+		}
+		if started {
+			gaps++
+		}
+	}
+	return gaps, qi == len(qr)
+}
+
+func normalizeMatchText(s string) string {
+	s = strings.ToLower(strings.TrimSpace(s))
+	var b strings.Builder
+	lastSpace := false
+	for _, r := range s {
+		if unicode.IsSpace(r) {
+			if !lastSpace {
+				b.WriteRune(' ')
+			}
+			lastSpace = true
+			continue
+		}
+		lastSpace = false
+		b.WriteRune(r)
+	}
+	return b.String()
+}
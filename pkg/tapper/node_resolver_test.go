@@ -0,0 +1,38 @@
+package tapper
+
+import (
+	"testing"
+
+	"github.com/jlrickert/tapper/pkg/keg"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchNodeTitles_RanksExactPrefixSubstringAndSubsequence(t *testing.T) {
+	t.Parallel()
+	entries := []keg.NodeIndexEntry{
+		{ID: "1", Title: "Go Concurrency Patterns"},
+		{ID: "2", Title: "Concurrency"},
+		{ID: "3", Title: "Patterns for concurrency in Go"},
+		{ID: "4", Title: "Cooking notes"},
+	}
+
+	got := MatchNodeTitles(entries, "concurrency")
+	require.Len(t, got, 3)
+	require.Equal(t, "2", got[0].Entry.ID, "exact title match ranks first")
+	require.Equal(t, "1", got[1].Entry.ID)
+	require.Equal(t, "3", got[2].Entry.ID)
+
+	got = MatchNodeTitles(entries, "go patterns")
+	require.Len(t, got, 2)
+	require.Equal(t, []string{"1", "3"}, []string{got[0].Entry.ID, got[1].Entry.ID})
+
+	got = MatchNodeTitles(entries, "cknts")
+	require.Len(t, got, 1)
+	require.Equal(t, "4", got[0].Entry.ID)
+}
+
+func TestMatchNodeTitles_EmptyQueryMatchesNothing(t *testing.T) {
+	t.Parallel()
+	entries := []keg.NodeIndexEntry{{ID: "1", Title: "Anything"}}
+	require.Empty(t, MatchNodeTitles(entries, "   "))
+}
@@ -0,0 +1,194 @@
+package tapper
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/jlrickert/tapper/pkg/keg"
+)
+
+// NodeTemplateData is the value a Go template given to --format is executed
+// against, once per listed node. The embedded index entry provides ID, Title,
+// Created, Updated and Accessed. Tags, attributes and stats are methods that
+// read the node the first time a template uses them, so templates that only
+// touch index fields stay as cheap as the %-placeholder formats.
+type NodeTemplateData struct {
+	keg.NodeIndexEntry
+
+	// Score is the relevance score of search and related results. It is zero
+	// for plain listings.
+	Score float64
+
+	ctx   context.Context
+	k     *keg.Keg
+	meta  *keg.NodeMeta
+	stats *keg.NodeStats
+}
+
+// TemplateList is a list of strings that prints comma separated, so
+// {{.Tags}} reads naturally while {{range .Tags}} still works.
+type TemplateList []string
+
+func (l TemplateList) String() string {
+	return strings.Join(l, ", ")
+}
+
+// Tags returns the node's tags from meta.yaml.
+func (d *NodeTemplateData) Tags() (TemplateList, error) {
+	meta, err := d.loadMeta()
+	if err != nil || meta == nil {
+		return TemplateList{}, err
+	}
+	return TemplateList(meta.Tags()), nil
+}
+
+// Attr returns a meta.yaml attribute rendered as a string, or "" when the
+// node does not set it.
+func (d *NodeTemplateData) Attr(key string) (string, error) {
+	meta, err := d.loadMeta()
+	if err != nil || meta == nil {
+		return "", err
+	}
+	value, _ := meta.Get(key)
+	return value, nil
+}
+
+// Lead returns the first paragraph of the node content.
+func (d *NodeTemplateData) Lead() (string, error) {
+	stats, err := d.loadStats()
+	if err != nil || stats == nil {
+		return "", err
+	}
+	return stats.Lead(), nil
+}
+
+// Hash returns the content hash recorded in the node stats.
+func (d *NodeTemplateData) Hash() (string, error) {
+	stats, err := d.loadStats()
+	if err != nil || stats == nil {
+		return "", err
+	}
+	return stats.Hash(), nil
+}
+
+// AccessCount returns how often the node has been read.
+func (d *NodeTemplateData) AccessCount() (int, error) {
+	stats, err := d.loadStats()
+	if err != nil || stats == nil {
+		return 0, err
+	}
+	return stats.AccessCount(), nil
+}
+
+// Links returns the IDs of the nodes this node links to.
+func (d *NodeTemplateData) Links() (TemplateList, error) {
+	stats, err := d.loadStats()
+	if err != nil || stats == nil {
+		return TemplateList{}, err
+	}
+	links := make(TemplateList, 0, len(stats.Links()))
+	for _, link := range stats.Links() {
+		links = append(links, link.Path())
+	}
+	return links, nil
+}
+
+func (d *NodeTemplateData) nodeID() (keg.NodeId, bool) {
+	id, err := keg.ParseNode(d.ID)
+	if err != nil || id == nil {
+		return keg.NodeId{}, false
+	}
+	// Multi-keg listings address nodes as alias:id; d.k is already that keg.
+	return keg.NodeId{ID: id.ID, Code: id.Code}, true
+}
+
+func (d *NodeTemplateData) loadMeta() (*keg.NodeMeta, error) {
+	if d.meta != nil || d.k == nil {
+		return d.meta, nil
+	}
+	id, ok := d.nodeID()
+	if !ok {
+		return nil, nil
+	}
+	meta, err := d.k.GetMeta(d.ctx, id)
+	if err != nil && !errors.Is(err, keg.ErrNotExist) {
+		return nil, err
+	}
+	d.meta = meta
+	return meta, nil
+}
+
+func (d *NodeTemplateData) loadStats() (*keg.NodeStats, error) {
+	if d.stats != nil || d.k == nil {
+		return d.stats, nil
+	}
+	id, ok := d.nodeID()
+	if !ok {
+		return nil, nil
+	}
+	stats, err := d.k.GetStats(d.ctx, id)
+	if err != nil && !errors.Is(err, keg.ErrNotExist) {
+		return nil, err
+	}
+	d.stats = stats
+	return stats, nil
+}
+
+// isNodeTemplate reports whether a --format value is a Go template rather
+// than a %-placeholder format.
+func isNodeTemplate(format string) bool {
+	return strings.Contains(format, "{{")
+}
+
+var nodeTemplateEscapes = strings.NewReplacer(`\t`, "\t", `\n`, "\n")
+
+// parseNodeTemplate compiles a --format Go template. The escapes \t and \n
+// are turned into tab and newline first so shell-quoted formats such as
+// '{{.ID}}\t{{.Title}}' work as expected.
+func parseNodeTemplate(format string) (*template.Template, error) {
+	tmpl, err := template.New("format").
+		Funcs(template.FuncMap{"join": strings.Join}).
+		Parse(nodeTemplateEscapes.Replace(format))
+	if err != nil {
+		return nil, fmt.Errorf("invalid format template: %w", err)
+	}
+	return tmpl, nil
+}
+
+func executeNodeTemplate(tmpl *template.Template, data *NodeTemplateData) (string, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("unable to format node %s: %w", data.ID, err)
+	}
+	return buf.String(), nil
+}
+
+// formatNodeEntries renders entries with format, which is either a Go
+// template or a %-placeholder format handled by renderNodeEntries.
+func formatNodeEntries(ctx context.Context, k *keg.Keg, entries []keg.NodeIndexEntry, format string, idOnly bool, reverse bool) ([]string, error) {
+	if idOnly || !isNodeTemplate(format) {
+		return renderNodeEntries(entries, format, idOnly, reverse), nil
+	}
+	tmpl, err := parseNodeTemplate(format)
+	if err != nil {
+		return []string{}, err
+	}
+
+	lines := make([]string, 0, len(entries))
+	for i := range entries {
+		entry := entries[i]
+		if reverse {
+			entry = entries[len(entries)-1-i]
+		}
+		line, err := executeNodeTemplate(tmpl, &NodeTemplateData{NodeIndexEntry: entry, ctx: ctx, k: k})
+		if err != nil {
+			return []string{}, err
+		}
+		lines = append(lines, line)
+	}
+	return lines, nil
+}
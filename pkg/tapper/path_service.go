@@ -2,6 +2,7 @@ package tapper
 
 import (
 	"path/filepath"
+	"strings"
 
 	appctx "github.com/jlrickert/cli-toolkit/apppaths"
 	"github.com/jlrickert/cli-toolkit/toolkit"
@@ -32,3 +33,9 @@ func (s *PathService) ProjectConfig() string {
 func (s *PathService) UserConfig() string {
 	return filepath.Join(s.ConfigRoot, "config.yaml")
 }
+
+// RegistryCache returns the offline cache directory of registry keg
+// user/keg on the named registry.
+func (s *PathService) RegistryCache(registry, user, keg string) string {
+	return filepath.Join(s.DataRoot, "registry", registry, strings.TrimPrefix(user, "@"), keg)
+}
@@ -0,0 +1,358 @@
+package tapper
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/jlrickert/tapper/pkg/keg"
+	"github.com/yuin/goldmark"
+	gm_ast "github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/text"
+	"gopkg.in/yaml.v3"
+)
+
+// Prose lint levels, from least to most severe.
+const (
+	LintSuggestion = "suggestion"
+	LintWarning    = "warning"
+	LintError      = "error"
+)
+
+// Built-in prose rule names.
+const (
+	RulePassiveVoice   = "PassiveVoice"
+	RuleSentenceLength = "SentenceLength"
+)
+
+// defaultMaxSentenceWords is used when the keg config sets no
+// lint.prose.maxSentenceWords.
+const defaultMaxSentenceWords = 30
+
+var (
+	passiveVoiceRE = regexp.MustCompile(`(?i)\b(?:am|is|are|was|were|be|been|being)\s+(?:[a-z]+ed|` + strings.Join(irregularParticiples, "|") + `)\b`)
+	sentenceEndRE  = regexp.MustCompile(`[.!?]+["')\]]*$`)
+	proseWordRE    = regexp.MustCompile(`\S+`)
+)
+
+var irregularParticiples = strings.Fields(`arisen awoken born borne beaten become begun bent
+	bound bitten blown broken brought built burnt bought caught chosen come cut dealt
+	done drawn driven eaten fallen felt fought found fled flown forbidden forgotten
+	forgiven frozen gotten given gone ground grown hung heard hidden hit held hurt
+	kept knelt known laid led left lent let lain lit lost made meant met paid put
+	quit read ridden rung risen run said seen sought sold sent set shaken shed shot
+	shown shut sung sunk slain slept slid spoken spent spun split spread stood stolen
+	stuck stung struck sworn swept swum swung taken taught torn told thought thrown
+	understood woken worn woven won wound written`)
+
+// proseRule is a prose check. Built-in rules set check; rules loaded from
+// Vale-style files set pattern.
+type proseRule struct {
+	name    string
+	level   string
+	message string
+
+	// pattern matches the text the rule reports. Substitution rules also
+	// have a swap for each of their patterns.
+	pattern *regexp.Regexp
+	swaps   []proseSwap
+
+	check func(block string) []proseMatch
+}
+
+// proseSwap is a substitution: text matching re should be replaced with to.
+type proseSwap struct {
+	re *regexp.Regexp
+	to string
+}
+
+// proseMatch is a rule hit at a byte offset of the linted text.
+type proseMatch struct {
+	offset  int
+	text    string
+	message string
+}
+
+// valeRule is the subset of a Vale rule file that tapper understands.
+type valeRule struct {
+	Extends    string            `yaml:"extends"`
+	Message    string            `yaml:"message"`
+	Level      string            `yaml:"level"`
+	IgnoreCase bool              `yaml:"ignorecase"`
+	Nonword    bool              `yaml:"nonword"`
+	Tokens     []string          `yaml:"tokens"`
+	Swap       map[string]string `yaml:"swap"`
+}
+
+// builtinProseRules returns the rules tapper runs without rule files.
+func builtinProseRules(maxWords int) []proseRule {
+	if maxWords <= 0 {
+		maxWords = defaultMaxSentenceWords
+	}
+	return []proseRule{
+		{
+			name:  RulePassiveVoice,
+			level: LintWarning,
+			check: func(block string) []proseMatch {
+				var out []proseMatch
+				for _, loc := range passiveVoiceRE.FindAllStringIndex(block, -1) {
+					match := strings.Join(strings.Fields(block[loc[0]:loc[1]]), " ")
+					out = append(out, proseMatch{offset: loc[0], text: match, message: fmt.Sprintf("%q may be passive voice", match)})
+				}
+				return out
+			},
+		},
+		{
+			name:  RuleSentenceLength,
+			level: LintWarning,
+			check: func(block string) []proseMatch {
+				var out []proseMatch
+				for _, s := range splitSentences(block) {
+					if s.words > maxWords {
+						out = append(out, proseMatch{offset: s.offset, message: fmt.Sprintf("sentence has %d words, more than %d", s.words, maxWords)})
+					}
+				}
+				return out
+			},
+		},
+	}
+}
+
+// parseValeRule builds a rule from a Vale-style rule file. The existence
+// and substitution rule types are supported.
+func parseValeRule(name string, data []byte) (proseRule, error) {
+	var v valeRule
+	if err := yaml.Unmarshal(data, &v); err != nil {
+		return proseRule{}, fmt.Errorf("invalid rule %s: %v: %w", name, err, keg.ErrInvalid)
+	}
+	rule := proseRule{name: name, level: v.Level, message: v.Message}
+	switch rule.level {
+	case "":
+		rule.level = LintSuggestion
+	case LintSuggestion, LintWarning, LintError:
+	default:
+		return proseRule{}, fmt.Errorf("invalid rule %s: unknown level %q: %w", name, v.Level, keg.ErrInvalid)
+	}
+
+	flags := ""
+	if v.IgnoreCase {
+		flags = "(?i)"
+	}
+	var tokens []string
+	switch v.Extends {
+	case "existence":
+		tokens = v.Tokens
+	case "substitution":
+		for from := range v.Swap {
+			tokens = append(tokens, from)
+		}
+		// Longest first so the most specific phrase wins.
+		sort.Slice(tokens, func(i, j int) bool {
+			if len(tokens[i]) != len(tokens[j]) {
+				return len(tokens[i]) > len(tokens[j])
+			}
+			return tokens[i] < tokens[j]
+		})
+		for _, from := range tokens {
+			re, err := regexp.Compile(flags + "^(?:" + from + ")$")
+			if err != nil {
+				return proseRule{}, fmt.Errorf("invalid rule %s: %v: %w", name, err, keg.ErrInvalid)
+			}
+			rule.swaps = append(rule.swaps, proseSwap{re: re, to: v.Swap[from]})
+		}
+	default:
+		return proseRule{}, fmt.Errorf("rule %s extends %q; only existence and substitution rules are supported: %w", name, v.Extends, keg.ErrNotSupported)
+	}
+	if len(tokens) == 0 {
+		return proseRule{}, fmt.Errorf("invalid rule %s: no tokens: %w", name, keg.ErrInvalid)
+	}
+
+	expr := "(?:" + strings.Join(tokens, "|") + ")"
+	if !v.Nonword {
+		expr = `\b` + expr + `\b`
+	}
+	re, err := regexp.Compile(flags + expr)
+	if err != nil {
+		return proseRule{}, fmt.Errorf("invalid rule %s: %v: %w", name, err, keg.ErrInvalid)
+	}
+	rule.pattern = re
+	return rule, nil
+}
+
+// ruleName names a rule file the way Vale does: the directory it is in, a
+// dot and the file name without its extension.
+func ruleName(path string) string {
+	base := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	return filepath.Base(filepath.Dir(path)) + "." + base
+}
+
+// matches runs the rule over a block of prose.
+func (r proseRule) matches(block string) []proseMatch {
+	if r.check != nil {
+		return r.check(block)
+	}
+	var out []proseMatch
+	for _, loc := range r.pattern.FindAllStringIndex(block, -1) {
+		match := block[loc[0]:loc[1]]
+		msg := fillMessage(r.message, match)
+		if r.swaps != nil {
+			to := ""
+			for _, s := range r.swaps {
+				if s.re.MatchString(match) {
+					to = s.to
+					break
+				}
+			}
+			msg = fillMessage(r.message, to, match)
+		}
+		if msg == "" {
+			msg = fmt.Sprintf("%q matches rule %s", match, r.name)
+		}
+		out = append(out, proseMatch{offset: loc[0], text: match, message: msg})
+	}
+	return out
+}
+
+// fillMessage substitutes args for the %s verbs of a Vale rule message in
+// order.
+func fillMessage(msg string, args ...string) string {
+	for _, arg := range args {
+		if !strings.Contains(msg, "%s") {
+			break
+		}
+		msg = strings.Replace(msg, "%s", arg, 1)
+	}
+	return msg
+}
+
+type sentence struct {
+	offset int
+	words  int
+}
+
+// splitSentences splits a block of prose into sentences at words ending
+// in ".", "!" or "?".
+func splitSentences(block string) []sentence {
+	var out []sentence
+	cur := sentence{offset: -1}
+	for _, loc := range proseWordRE.FindAllStringIndex(block, -1) {
+		word := block[loc[0]:loc[1]]
+		if !strings.ContainsFunc(word, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) {
+			continue
+		}
+		if cur.offset < 0 {
+			cur.offset = loc[0]
+		}
+		cur.words++
+		if sentenceEndRE.MatchString(word) {
+			out = append(out, cur)
+			cur = sentence{offset: -1}
+		}
+	}
+	if cur.offset >= 0 {
+		out = append(out, cur)
+	}
+	return out
+}
+
+// proseBlock is the prose of one paragraph, heading or list item, with
+// everything that is not prose, such as markup, code and link targets,
+// blanked out so offsets match the source.
+type proseBlock struct {
+	start int
+	text  string
+}
+
+// proseBlocks extracts the prose blocks of Markdown src.
+func proseBlocks(src []byte) []proseBlock {
+	doc := goldmark.New().Parser().Parse(text.NewReader(src))
+	var out []proseBlock
+	_ = gm_ast.Walk(doc, func(n gm_ast.Node, entering bool) (gm_ast.WalkStatus, error) {
+		if !entering {
+			return gm_ast.WalkContinue, nil
+		}
+		switch n.Kind() {
+		case gm_ast.KindParagraph, gm_ast.KindTextBlock, gm_ast.KindHeading:
+		default:
+			return gm_ast.WalkContinue, nil
+		}
+
+		var segs []text.Segment
+		_ = gm_ast.Walk(n, func(c gm_ast.Node, entering bool) (gm_ast.WalkStatus, error) {
+			if !entering {
+				return gm_ast.WalkContinue, nil
+			}
+			switch v := c.(type) {
+			case *gm_ast.CodeSpan, *gm_ast.RawHTML, *gm_ast.AutoLink:
+				return gm_ast.WalkSkipChildren, nil
+			case *gm_ast.Text:
+				segs = append(segs, v.Segment)
+			}
+			return gm_ast.WalkContinue, nil
+		})
+		if len(segs) == 0 {
+			return gm_ast.WalkSkipChildren, nil
+		}
+		start, stop := segs[0].Start, segs[len(segs)-1].Stop
+		buf := make([]byte, stop-start)
+		for i := range buf {
+			buf[i] = ' '
+			if src[start+i] == '\n' {
+				buf[i] = '\n'
+			}
+		}
+		for _, s := range segs {
+			copy(buf[s.Start-start:], src[s.Start:s.Stop])
+		}
+		out = append(out, proseBlock{start: start, text: string(buf)})
+		return gm_ast.WalkSkipChildren, nil
+	})
+	return out
+}
+
+// lintProse runs rules over the prose of Markdown content and returns the
+// findings ordered by position. Frontmatter is skipped but counted in line
+// numbers.
+func lintProse(content []byte, rules []proseRule) []LintFinding {
+	front, body := splitFrontmatter(content)
+	lineOffset := strings.Count(string(front), "\n")
+	lineStarts := []int{0}
+	for i, b := range body {
+		if b == '\n' {
+			lineStarts = append(lineStarts, i+1)
+		}
+	}
+	position := func(offset int) (int, int) {
+		line := sort.Search(len(lineStarts), func(i int) bool { return lineStarts[i] > offset }) - 1
+		col := len([]rune(string(body[lineStarts[line]:offset]))) + 1
+		return line + 1 + lineOffset, col
+	}
+
+	var out []LintFinding
+	for _, block := range proseBlocks(body) {
+		for _, rule := range rules {
+			for _, m := range rule.matches(block.text) {
+				line, col := position(block.start + m.offset)
+				out = append(out, LintFinding{
+					Line:    line,
+					Column:  col,
+					Rule:    rule.name,
+					Level:   rule.level,
+					Message: m.message,
+					Match:   m.text,
+				})
+			}
+		}
+	}
+	slices.SortStableFunc(out, func(a, b LintFinding) int {
+		if a.Line != b.Line {
+			return a.Line - b.Line
+		}
+		return a.Column - b.Column
+	})
+	return out
+}
@@ -0,0 +1,46 @@
+package tapper
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLintProse(t *testing.T) {
+	t.Parallel()
+	weasel, err := parseValeRule("keg.Weasel", []byte("extends: existence\nmessage: \"Avoid '%s'.\"\nlevel: error\nignorecase: true\ntokens:\n  - very\n  - really\n"))
+	require.NoError(t, err)
+	terms, err := parseValeRule("keg.Terms", []byte("extends: substitution\nmessage: \"Use '%s' instead of '%s'.\"\nswap:\n  utilize: use\n  in order to: to\n"))
+	require.NoError(t, err)
+	rules := append(builtinProseRules(8), weasel, terms)
+
+	src := "---\ntags: [a]\n---\n# Title\n\n" +
+		"The report was written by Ana. It is Very good.\n\n" +
+		"We utilize `very` code in order to ship [really](https://very.example.com) fast.\n\n" +
+		"```\nthe test was skipped very often\n```\n\n" +
+		"One two three four five six seven eight nine ten.\n"
+	var got []string
+	for _, f := range lintProse([]byte(src), rules) {
+		got = append(got, fmt.Sprintf("%d:%d:%s:%s:%s", f.Line, f.Column, f.Level, f.Rule, f.Message))
+	}
+	require.Equal(t, []string{
+		`6:12:warning:PassiveVoice:"was written" may be passive voice`,
+		`6:38:error:keg.Weasel:Avoid 'Very'.`,
+		`8:1:warning:SentenceLength:sentence has 9 words, more than 8`,
+		`8:4:suggestion:keg.Terms:Use 'use' instead of 'utilize'.`,
+		`8:24:suggestion:keg.Terms:Use 'to' instead of 'in order to'.`,
+		`8:42:error:keg.Weasel:Avoid 'really'.`,
+		`14:1:warning:SentenceLength:sentence has 10 words, more than 8`,
+	}, got)
+}
+
+func TestParseValeRule_Errors(t *testing.T) {
+	t.Parallel()
+	_, err := parseValeRule("keg.Cap", []byte("extends: capitalization\n"))
+	require.ErrorContains(t, err, "only existence and substitution")
+	_, err = parseValeRule("keg.Empty", []byte("extends: existence\n"))
+	require.ErrorContains(t, err, "no tokens")
+	_, err = parseValeRule("keg.Level", []byte("extends: existence\nlevel: fatal\ntokens: [x]\n"))
+	require.ErrorContains(t, err, "unknown level")
+}
@@ -0,0 +1,150 @@
+package tapper
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jlrickert/tapper/pkg/keg"
+	"gopkg.in/yaml.v3"
+)
+
+// queryBlockLang is the info string of fenced blocks expanded into lists of
+// matching nodes.
+const queryBlockLang = "keg-query"
+
+var fenceRE = regexp.MustCompile("^ {0,3}(`{3,}|~{3,})\\s*([^`\\s]*)")
+
+// queryBlock is the YAML body of a keg-query block.
+type queryBlock struct {
+	// Tags is a boolean query expression, as for `tap ls --query`.
+	Tags string `yaml:"tags"`
+
+	// Where is an attribute expression, as for `tap ls --where`.
+	Where string `yaml:"where"`
+
+	// Sort is id, title, updated, created or accessed. Time sorts list the
+	// newest node first.
+	Sort string `yaml:"sort"`
+
+	// Limit keeps the first Limit nodes. Zero lists every match.
+	Limit int `yaml:"limit"`
+}
+
+// expandQueryBlocks replaces each keg-query fenced block in content with a
+// Markdown list linking to the nodes it matches. self, the node the content
+// belongs to, is never listed. Content without query blocks is returned
+// unchanged.
+func (t *Tap) expandQueryBlocks(ctx context.Context, k *keg.Keg, dex *keg.Dex, self keg.NodeId, content []byte) ([]byte, error) {
+	if !bytes.Contains(content, []byte(queryBlockLang)) {
+		return content, nil
+	}
+
+	lines := strings.SplitAfter(string(content), "\n")
+	var out strings.Builder
+	for i := 0; i < len(lines); i++ {
+		m := fenceRE.FindStringSubmatch(lines[i])
+		if m == nil {
+			out.WriteString(lines[i])
+			continue
+		}
+		fence := m[1]
+		end := len(lines)
+		for j := i + 1; j < len(lines); j++ {
+			if c := fenceRE.FindStringSubmatch(lines[j]); c != nil && c[2] == "" &&
+				c[1][0] == fence[0] && len(c[1]) >= len(fence) {
+				end = j
+				break
+			}
+		}
+		if m[2] != queryBlockLang {
+			// Copy other fenced blocks untouched so query blocks quoted in
+			// them are left alone.
+			for j := i; j <= end && j < len(lines); j++ {
+				out.WriteString(lines[j])
+			}
+			i = end
+			continue
+		}
+
+		body := strings.Join(lines[i+1:min(end, len(lines))], "")
+		list, err := t.queryBlockList(ctx, k, dex, self, body)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s block on line %d: %w", queryBlockLang, i+1, err)
+		}
+		out.WriteString(list)
+		i = end
+	}
+	return []byte(out.String()), nil
+}
+
+// queryBlockList renders the nodes matching a keg-query block body as a
+// Markdown list.
+func (t *Tap) queryBlockList(ctx context.Context, k *keg.Keg, dex *keg.Dex, self keg.NodeId, body string) (string, error) {
+	var q queryBlock
+	dec := yaml.NewDecoder(strings.NewReader(body))
+	dec.KnownFields(true)
+	if err := dec.Decode(&q); err != nil && strings.TrimSpace(body) != "" {
+		return "", fmt.Errorf("%v: %w", err, keg.ErrInvalid)
+	}
+	if q.Limit < 0 {
+		return "", fmt.Errorf("limit must not be negative: %w", keg.ErrInvalid)
+	}
+
+	entries := dex.Nodes(ctx)
+	var err error
+	if tags := strings.TrimSpace(q.Tags); tags != "" {
+		if entries, err = filterQueryExpr(ctx, k, dex, entries, tags); err != nil {
+			return "", fmt.Errorf("invalid query expression: %w", err)
+		}
+	}
+	if where := strings.TrimSpace(q.Where); where != "" {
+		if entries, err = filterWhereExpr(ctx, k, entries, where); err != nil {
+			return "", fmt.Errorf("invalid where expression: %w", err)
+		}
+	}
+	entries = slices.DeleteFunc(entries, func(e keg.NodeIndexEntry) bool {
+		id, err := keg.ParseNode(e.ID)
+		return err == nil && id != nil && id.Equals(self)
+	})
+
+	switch ListSortType(strings.TrimSpace(q.Sort)) {
+	case SortByDefault, SortByID:
+		sortNodeIndexEntries(entries)
+	case "title":
+		slices.SortStableFunc(entries, func(a, b keg.NodeIndexEntry) int {
+			return strings.Compare(strings.ToLower(a.Title), strings.ToLower(b.Title))
+		})
+	case SortByUpdated:
+		sortNodeIndexEntriesByTime(entries, func(e keg.NodeIndexEntry) time.Time { return e.Updated })
+		slices.Reverse(entries)
+	case SortByCreated:
+		sortNodeIndexEntriesByTime(entries, func(e keg.NodeIndexEntry) time.Time { return e.Created })
+		slices.Reverse(entries)
+	case SortByAccessed:
+		sortNodeIndexEntriesByTime(entries, func(e keg.NodeIndexEntry) time.Time { return e.Accessed })
+		slices.Reverse(entries)
+	default:
+		return "", fmt.Errorf("unknown sort type %q: %w", q.Sort, keg.ErrInvalid)
+	}
+	if q.Limit > 0 && len(entries) > q.Limit {
+		entries = entries[:q.Limit]
+	}
+
+	if len(entries) == 0 {
+		return "*No matching nodes.*\n", nil
+	}
+	var b strings.Builder
+	for _, e := range entries {
+		title := e.Title
+		if title == "" {
+			title = e.ID
+		}
+		fmt.Fprintf(&b, "- [%s](../%s)\n", title, e.ID)
+	}
+	return b.String(), nil
+}
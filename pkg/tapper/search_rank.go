@@ -0,0 +1,79 @@
+package tapper
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/jlrickert/tapper/pkg/keg"
+)
+
+// searchRankSignals are the raw per-node inputs blended into a ranking score.
+type searchRankSignals struct {
+	hits     int
+	updated  time.Time
+	accesses int
+}
+
+// searchRankScore blends term frequency, update recency, and access count
+// into a single score. Term frequency and access count are saturating so a
+// handful of very noisy nodes cannot drown out everything else; maxAccesses
+// normalizes access counts across the result set.
+func searchRankScore(w keg.SearchRankWeights, s searchRankSignals, maxAccesses int, now time.Time) float64 {
+	term := 0.0
+	if s.hits > 0 {
+		term = float64(s.hits) / float64(s.hits+2)
+	}
+
+	recency := 0.0
+	if !s.updated.IsZero() && w.RecencyHalfLife > 0 {
+		age := now.Sub(s.updated)
+		if age < 0 {
+			age = 0
+		}
+		recency = math.Pow(0.5, float64(age)/float64(w.RecencyHalfLife))
+	}
+
+	access := 0.0
+	if maxAccesses > 0 && s.accesses > 0 {
+		access = math.Log1p(float64(s.accesses)) / math.Log1p(float64(maxAccesses))
+	}
+
+	return w.Term*term + w.Recency*recency + w.Access*access
+}
+
+// rankGrepMatches orders matches from most to least relevant using the
+// keg's search weights. Access counts are read from node stats; nodes whose
+// stats cannot be read simply contribute no access signal. Ties keep node ID
+// order.
+func rankGrepMatches(ctx context.Context, k *keg.Keg, matches []grepMatch, w keg.SearchRankWeights, now time.Time) {
+	signals := make([]searchRankSignals, len(matches))
+	maxAccesses := 0
+	for i, match := range matches {
+		signals[i] = searchRankSignals{hits: match.hits, updated: match.entry.Updated}
+		if w.Access == 0 {
+			continue
+		}
+		id, err := keg.ParseNode(match.entry.ID)
+		if err != nil || id == nil {
+			continue
+		}
+		stats, err := k.GetStats(ctx, *id)
+		if err != nil || stats == nil {
+			continue
+		}
+		signals[i].accesses = stats.AccessCount()
+		if signals[i].accesses > maxAccesses {
+			maxAccesses = signals[i].accesses
+		}
+	}
+
+	scores := make(map[string]float64, len(matches))
+	for i, match := range matches {
+		scores[match.entry.ID] = searchRankScore(w, signals[i], maxAccesses, now)
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return scores[matches[i].entry.ID] > scores[matches[j].entry.ID]
+	})
+}
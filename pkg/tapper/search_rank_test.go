@@ -0,0 +1,31 @@
+package tapper
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jlrickert/tapper/pkg/keg"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchRankScore_BlendsSignals(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	w := keg.DefaultSearchRankWeights
+
+	stale := searchRankSignals{hits: 3, updated: now.Add(-365 * 24 * time.Hour)}
+	fresh := searchRankSignals{hits: 3, updated: now.Add(-time.Hour)}
+	require.Greater(t, searchRankScore(w, fresh, 0, now), searchRankScore(w, stale, 0, now),
+		"recently updated nodes rank higher")
+
+	popular := searchRankSignals{hits: 1, updated: stale.updated, accesses: 50}
+	quiet := searchRankSignals{hits: 1, updated: stale.updated, accesses: 1}
+	require.Greater(t, searchRankScore(w, popular, 50, now), searchRankScore(w, quiet, 50, now),
+		"frequently accessed nodes rank higher")
+
+	termOnly := keg.SearchRankWeights{Term: 1}
+	require.Greater(t,
+		searchRankScore(termOnly, searchRankSignals{hits: 10}, 0, now),
+		searchRankScore(termOnly, searchRankSignals{hits: 1, accesses: 100}, 100, now),
+		"disabled signals do not contribute")
+}
@@ -116,3 +116,87 @@ func evalQueryExpr(
 	})
 	return matched, nil
 }
+
+// filterQueryExpr evaluates a --query expression and returns the matching
+// entries sorted by node ID.
+func filterQueryExpr(
+	ctx context.Context,
+	k *keg.Keg,
+	d *keg.Dex,
+	entries []keg.NodeIndexEntry,
+	expr string,
+) ([]keg.NodeIndexEntry, error) {
+	matchedIDs, err := evalQueryExpr(ctx, k, d, entries, expr)
+	if err != nil {
+		return nil, err
+	}
+	entryByID := make(map[string]keg.NodeIndexEntry, len(entries)*2)
+	for _, e := range entries {
+		entryByID[e.ID] = e
+		id, parseErr := keg.ParseNode(e.ID)
+		if parseErr == nil && id != nil {
+			entryByID[id.Path()] = e
+		}
+	}
+	filtered := make([]keg.NodeIndexEntry, 0, len(matchedIDs))
+	seen := make(map[string]struct{})
+	for nodeID := range matchedIDs {
+		if e, ok := entryByID[nodeID]; ok {
+			if _, dup := seen[e.ID]; !dup {
+				seen[e.ID] = struct{}{}
+				filtered = append(filtered, e)
+			}
+		}
+	}
+	sortNodeIndexEntries(filtered)
+	return filtered, nil
+}
+
+// filterWhereExpr parses expr as a structured metadata filter and returns the
+// entries that satisfy it, preserving their order. Node meta.yaml files are
+// only read when the expression references tags or attributes.
+func filterWhereExpr(
+	ctx context.Context,
+	k *keg.Keg,
+	entries []keg.NodeIndexEntry,
+	expr string,
+) ([]keg.NodeIndexEntry, error) {
+	parsed, err := keg.ParseWhereExpression(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]keg.NodeIndexEntry, 0, len(entries))
+	for _, entry := range entries {
+		rec := keg.WhereRecord{Entry: entry, Meta: lazyNodeMeta(ctx, k, entry.ID)}
+		if parsed.Match(rec) {
+			out = append(out, entry)
+		}
+	}
+	return out, nil
+}
+
+// lazyNodeMeta returns a loader that reads and parses a node's meta.yaml on
+// first use. The loader returns nil when the metadata cannot be read.
+func lazyNodeMeta(ctx context.Context, k *keg.Keg, nodeID string) func() *keg.NodeMeta {
+	var (
+		loaded bool
+		meta   *keg.NodeMeta
+	)
+	return func() *keg.NodeMeta {
+		if loaded {
+			return meta
+		}
+		loaded = true
+		id, err := keg.ParseNode(nodeID)
+		if err != nil || id == nil {
+			return nil
+		}
+		raw, err := k.Repo.ReadMeta(ctx, *id)
+		if err != nil {
+			return nil
+		}
+		meta, _ = keg.ParseMeta(ctx, raw)
+		return meta
+	}
+}
@@ -20,6 +20,9 @@ type Tap struct {
 	PathService   *PathService
 	ConfigService *ConfigService
 	KegService    *KegService
+
+	// Credentials holds registry tokens saved by `tap auth login`.
+	Credentials CredentialStore
 }
 
 type TapOptions struct {
@@ -57,9 +60,12 @@ func NewTap(opts TapOptions) (*Tap, error) {
 		PathService: pathService,
 		ConfigPath:  opts.ConfigPath,
 	}
+	credentials := NewCredentialStore(rt, pathService)
 	kegService := &KegService{
 		Runtime:       rt,
 		ConfigService: configService,
+		Credentials:   credentials,
+		Events:        keg.NewEventBus(),
 	}
 	return &Tap{
 		Runtime:       rt,
@@ -67,6 +73,7 @@ func NewTap(opts TapOptions) (*Tap, error) {
 		PathService:   pathService,
 		ConfigService: configService,
 		KegService:    kegService,
+		Credentials:   credentials,
 	}, nil
 }
 
@@ -115,7 +122,8 @@ func firstDir(path string) string {
 }
 
 func (t *Tap) resolveKeg(ctx context.Context, opts KegTargetOptions) (*keg.Keg, error) {
-	return t.KegService.Resolve(ctx, ResolveKegOptions{
+	start := time.Now()
+	k, err := t.KegService.Resolve(ctx, ResolveKegOptions{
 		Root:    t.Root,
 		Keg:     opts.Keg,
 		Project: opts.Project,
@@ -123,6 +131,19 @@ func (t *Tap) resolveKeg(ctx context.Context, opts KegTargetOptions) (*keg.Keg,
 		Path:    opts.Path,
 		NoCache: false,
 	})
+	if err != nil {
+		t.Runtime.Logger().Debug("unable to resolve keg", "keg", opts.Keg, "elapsed", time.Since(start), "error", err.Error())
+		return nil, err
+	}
+	target := ""
+	if k.Target != nil {
+		target = k.Target.String()
+	}
+	t.Runtime.Logger().Debug("resolved keg", "keg", opts.Keg, "target", target, "elapsed", time.Since(start))
+	if rec := dryRunRecorderFrom(ctx); rec != nil {
+		return rec.copyOf(ctx, k)
+	}
+	return k, nil
 }
 
 func newEditorTempFilePath(rt *toolkit.Runtime, prefix string, suffix string) (string, error) {
@@ -0,0 +1,106 @@
+package tapper
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// CommandAlias is a user-defined command alias: Name expands to the argument
+// list in Command before the CLI parses its arguments.
+type CommandAlias struct {
+	Name    string
+	Command string
+}
+
+// AddAliasOptions configures a new command alias.
+type AddAliasOptions struct {
+	// Name is the word typed in place of the command, such as "wls".
+	Name string
+
+	// Command is the argument list Name expands to, such as
+	// "ls --keg work --sort updated".
+	Command string
+
+	// Force replaces an existing alias with the same name.
+	Force bool
+}
+
+// ListAliases returns the command aliases from the merged configuration
+// sorted by name.
+func (t *Tap) ListAliases() []CommandAlias {
+	aliases := t.ConfigService.Config(true).Aliases()
+	out := make([]CommandAlias, 0, len(aliases))
+	for name, command := range aliases {
+		out = append(out, CommandAlias{Name: name, Command: command})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// AddAlias records a command alias in the user configuration.
+func (t *Tap) AddAlias(ctx context.Context, opts AddAliasOptions) error {
+	if err := ValidateAliasName(opts.Name); err != nil {
+		return err
+	}
+	if strings.TrimSpace(opts.Command) == "" {
+		return fmt.Errorf("alias %q needs a command to expand to", opts.Name)
+	}
+
+	userCfg, err := t.ConfigService.UserConfig(false)
+	if err != nil {
+		return fmt.Errorf("unable to load user config: %w", err)
+	}
+	if existing, ok := userCfg.Aliases()[opts.Name]; ok && !opts.Force {
+		return fmt.Errorf("alias %q already expands to %q; use --force to replace it", opts.Name, existing)
+	}
+	if err := userCfg.SetAlias(opts.Name, strings.TrimSpace(opts.Command)); err != nil {
+		return err
+	}
+	if err := userCfg.Write(t.Runtime, t.PathService.UserConfig()); err != nil {
+		return fmt.Errorf("unable to save user config: %w", err)
+	}
+
+	t.ConfigService.ResetCache()
+	return nil
+}
+
+// RemoveAlias deletes a command alias from the user configuration.
+func (t *Tap) RemoveAlias(ctx context.Context, name string) error {
+	if name == "" {
+		return fmt.Errorf("alias name is required")
+	}
+	userCfg, err := t.ConfigService.UserConfig(false)
+	if err != nil {
+		return fmt.Errorf("unable to load user config: %w", err)
+	}
+	if err := userCfg.RemoveAlias(name); err != nil {
+		return err
+	}
+	if err := userCfg.Write(t.Runtime, t.PathService.UserConfig()); err != nil {
+		return fmt.Errorf("unable to save user config: %w", err)
+	}
+
+	t.ConfigService.ResetCache()
+	return nil
+}
+
+// ValidateAliasName reports whether name can be used as a command alias. An
+// alias is a single word that does not look like a flag or a node ID.
+func ValidateAliasName(name string) error {
+	if name == "" {
+		return fmt.Errorf("alias name is required")
+	}
+	if strings.HasPrefix(name, "-") {
+		return fmt.Errorf("invalid alias name %q: must not start with '-'", name)
+	}
+	if strings.IndexFunc(name, unicode.IsSpace) >= 0 {
+		return fmt.Errorf("invalid alias name %q: must not contain whitespace", name)
+	}
+	if strings.IndexFunc(name, func(r rune) bool { return !unicode.IsDigit(r) }) < 0 {
+		return fmt.Errorf("invalid alias name %q: numeric names are node IDs", name)
+	}
+	return nil
+}
@@ -28,6 +28,20 @@ type ExportOptions struct {
 	NodeIDs     []string
 	WithHistory bool
 	OutputPath  string
+
+	// Backlinks overrides the keg's export.backlinks setting when non-nil.
+	// When enabled, each exported README.md gets a generated backlinks
+	// section appended, which Import strips again.
+	Backlinks *bool
+
+	// Unlock exports nodes with encrypted content as plaintext. Without it
+	// their content is archived encrypted, as stored.
+	Unlock bool
+
+	// Full also archives the keg config, the archived nodes and the items of
+	// every node: attachments, images, their metadata and content versions.
+	// Backups are written this way; Import restores all of it.
+	Full bool
 }
 
 type ImportOptions struct {
@@ -41,13 +55,26 @@ type archiveManifest struct {
 	ExportedAt  time.Time             `json:"exported_at"`
 	WithHistory bool                  `json:"with_history,omitempty"`
 	Nodes       []archiveManifestNode `json:"nodes"`
+
+	// Config reports that the keg config is archived as keg-archive/keg.
+	Config bool `json:"config,omitempty"`
+
+	// Archived lists the archived nodes, stored under keg-archive/archive.
+	Archived []archiveManifestNode `json:"archived,omitempty"`
 }
 
 type archiveManifestNode struct {
 	SourceID      string `json:"source_id"`
 	RevisionCount int    `json:"revision_count,omitempty"`
+
+	// Items lists the archived item files of the node by their path below
+	// the node, e.g. "assets/report.pdf" or "images/.meta/cover.png.json".
+	Items []string `json:"items,omitempty"`
 }
 
+// archiveConfigEntry holds the keg config in full archives.
+const archiveConfigEntry = "keg-archive/keg"
+
 func (t *Tap) Export(ctx context.Context, opts ExportOptions) (string, error) {
 	k, err := t.resolveKeg(ctx, opts.KegTargetOptions)
 	if err != nil {
@@ -68,6 +95,11 @@ func (t *Tap) Export(ctx context.Context, opts ExportOptions) (string, error) {
 		manifest.Source = k.Target.String()
 	}
 
+	backlinks, heading, err := exportBacklinksSettings(ctx, k, opts.Backlinks)
+	if err != nil {
+		return "", err
+	}
+
 	var snapshotRepo keg.RepositorySnapshots
 	if opts.WithHistory {
 		var ok bool
@@ -86,6 +118,20 @@ func (t *Tap) Export(ctx context.Context, opts ExportOptions) (string, error) {
 		if err != nil {
 			return "", fmt.Errorf("unable to read node %s content: %w", id.Path(), err)
 		}
+		sealed := keg.IsEncryptedContent(content)
+		if sealed && opts.Unlock {
+			if content, err = k.GetContent(ctx, id); err != nil {
+				return "", fmt.Errorf("unable to decrypt node %s: %w", id.Path(), err)
+			}
+			sealed = false
+		}
+		if backlinks && !sealed {
+			section, err := k.BacklinksSection(ctx, id, heading)
+			if err != nil {
+				return "", fmt.Errorf("unable to render backlinks for node %s: %w", id.Path(), err)
+			}
+			content = keg.AppendBacklinksSection(content, section)
+		}
 		meta, err := readOptionalNodeMeta(ctx, k.Repo, id)
 		if err != nil {
 			return "", fmt.Errorf("unable to read node %s metadata: %w", id.Path(), err)
@@ -107,6 +153,15 @@ func (t *Tap) Export(ctx context.Context, opts ExportOptions) (string, error) {
 		}
 
 		entry := archiveManifestNode{SourceID: id.Path()}
+		if opts.Full {
+			items, err := keg.ReadNodeItems(ctx, k.Repo, id)
+			if err != nil {
+				return "", fmt.Errorf("unable to read node %s items: %w", id.Path(), err)
+			}
+			if entry.Items, err = writeArchiveItems(tw, base, items); err != nil {
+				return "", err
+			}
+		}
 		if opts.WithHistory {
 			history, err := snapshotRepo.ListSnapshots(ctx, id)
 			if err != nil {
@@ -151,6 +206,24 @@ func (t *Tap) Export(ctx context.Context, opts ExportOptions) (string, error) {
 		manifest.Nodes = append(manifest.Nodes, entry)
 	}
 
+	if opts.Full {
+		if manifest.Archived, err = exportArchivedNodes(ctx, k.Repo, tw); err != nil {
+			return "", err
+		}
+		cfg, err := k.Repo.ReadConfig(ctx)
+		if err != nil {
+			return "", fmt.Errorf("unable to read keg config: %w", err)
+		}
+		rawConfig, err := cfg.ToYAML()
+		if err != nil {
+			return "", fmt.Errorf("unable to encode keg config: %w", err)
+		}
+		if err := writeTarFile(tw, archiveConfigEntry, rawConfig); err != nil {
+			return "", err
+		}
+		manifest.Config = true
+	}
+
 	rawManifest, err := json.MarshalIndent(manifest, "", "  ")
 	if err != nil {
 		return "", fmt.Errorf("unable to encode archive manifest: %w", err)
@@ -184,6 +257,17 @@ func (t *Tap) Import(ctx context.Context, opts ImportOptions) ([]keg.NodeId, err
 		return nil, fmt.Errorf("unable to open keg: %w", err)
 	}
 
+	var ids []keg.NodeId
+	err = k.WithKegLock(ctx, func(lockCtx context.Context) error {
+		var err error
+		ids, err = t.importArchive(lockCtx, k, opts)
+		return err
+	})
+	return ids, err
+}
+
+// importArchive restores an archive into k while the keg lock is held.
+func (t *Tap) importArchive(ctx context.Context, k *keg.Keg, opts ImportOptions) ([]keg.NodeId, error) {
 	archiveBytes, err := readArchiveInput(ctx, t.Runtime, opts.Input)
 	if err != nil {
 		return nil, err
@@ -222,6 +306,9 @@ func (t *Tap) Import(ctx context.Context, opts ImportOptions) ([]keg.NodeId, err
 
 	preservedAssets := make(map[string]importedNodeAssets, len(ordered))
 	for _, sourceID := range ordered {
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("import interrupted before node %s: %w", sourceID, err)
+		}
 		newID := mapping[sourceID]
 		exists, err := k.Repo.HasNode(ctx, newID)
 		if err != nil {
@@ -243,6 +330,9 @@ func (t *Tap) Import(ctx context.Context, opts ImportOptions) ([]keg.NodeId, err
 	}
 
 	for _, sourceID := range ordered {
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("import interrupted before node %s: %w", sourceID, err)
+		}
 		newID := mapping[sourceID]
 		nodeManifest := manifestNodes[sourceID]
 		base := filepath.ToSlash(filepath.Join("keg-archive", "nodes", sourceID))
@@ -260,7 +350,7 @@ func (t *Tap) Import(ctx context.Context, opts ImportOptions) ([]keg.NodeId, err
 			return nil, fmt.Errorf("archive node %s missing stats.json: %w", sourceID, err)
 		}
 
-		content = rewriteImportedLinks(content, mapping)
+		content = rewriteImportedLinks(keg.StripBacklinksSection(content), mapping)
 		stats, err := keg.ParseStats(ctx, statsBytes)
 		if err != nil {
 			return nil, fmt.Errorf("unable to parse imported stats for node %s: %w", sourceID, err)
@@ -337,6 +427,32 @@ func (t *Tap) Import(ctx context.Context, opts ImportOptions) ([]keg.NodeId, err
 				return nil, fmt.Errorf("unable to restore existing assets for node %s: %w", sourceID, err)
 			}
 		}
+		if len(nodeManifest.Items) > 0 {
+			items, err := archiveNodeItems(entries, base, nodeManifest.Items)
+			if err != nil {
+				return nil, err
+			}
+			if err := keg.WriteNodeItems(ctx, k.Repo, newID, items); err != nil {
+				return nil, fmt.Errorf("unable to write imported items for node %s: %w", sourceID, err)
+			}
+		}
+	}
+
+	if err := importArchivedNodes(ctx, k, manifest, entries); err != nil {
+		return nil, err
+	}
+	if manifest.Config {
+		rawConfig, err := readRequiredArchiveEntry(entries, archiveConfigEntry)
+		if err != nil {
+			return nil, fmt.Errorf("archive keg config missing: %w", err)
+		}
+		cfg, err := keg.ParseKegConfig(rawConfig)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse archived keg config: %w", err)
+		}
+		if err := k.Repo.WriteConfig(ctx, cfg); err != nil {
+			return nil, fmt.Errorf("unable to restore keg config: %w", err)
+		}
 	}
 
 	if err := rebuildDexFromRepo(ctx, k); err != nil {
@@ -355,6 +471,160 @@ func (t *Tap) Import(ctx context.Context, opts ImportOptions) ([]keg.NodeId, err
 	return imported, nil
 }
 
+// writeArchiveItems writes items below base and returns their paths relative
+// to base, sorted.
+func writeArchiveItems(tw *tar.Writer, base string, items *keg.NodeItems) ([]string, error) {
+	entries, err := items.Entries()
+	if err != nil {
+		return nil, err
+	}
+	paths := make([]string, 0, len(entries))
+	for path := range entries {
+		paths = append(paths, path)
+	}
+	slices.Sort(paths)
+	for _, path := range paths {
+		if err := writeTarFile(tw, base+"/"+path, entries[path]); err != nil {
+			return nil, err
+		}
+	}
+	return paths, nil
+}
+
+// archiveNodeItems collects the item files of the node archived at base.
+func archiveNodeItems(entries map[string][]byte, base string, paths []string) (*keg.NodeItems, error) {
+	nodeEntries := make(map[string][]byte, len(paths))
+	for _, path := range paths {
+		data, ok := entries[base+"/"+path]
+		if !ok {
+			return nil, fmt.Errorf("archive is missing %s/%s: %w", base, path, keg.ErrInvalid)
+		}
+		nodeEntries[path] = data
+	}
+	return keg.ParseNodeItems(nodeEntries)
+}
+
+// exportArchivedNodes writes every archived node of repo, with its stats and
+// items when the archive keeps them, under keg-archive/archive.
+func exportArchivedNodes(ctx context.Context, repo keg.Repository, tw *tar.Writer) ([]archiveManifestNode, error) {
+	archive, ok := repo.(keg.RepositoryArchive)
+	if !ok {
+		return nil, nil
+	}
+	ids, err := archive.ListArchived(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list archived nodes: %w", err)
+	}
+	state, hasState := repo.(keg.RepositoryArchivedState)
+	var nodes []archiveManifestNode
+	for _, id := range ids {
+		content, err := archive.ReadArchivedContent(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read archived node %s: %w", id.Path(), err)
+		}
+		meta, err := archive.ReadArchivedMeta(ctx, id)
+		if err != nil && !errors.Is(err, keg.ErrNotExist) {
+			return nil, fmt.Errorf("unable to read archived node %s metadata: %w", id.Path(), err)
+		}
+		stats := &keg.NodeStats{}
+		items := &keg.NodeItems{}
+		if hasState {
+			if stats, err = state.ReadArchivedStats(ctx, id); errors.Is(err, keg.ErrNotExist) {
+				stats = &keg.NodeStats{}
+			} else if err != nil {
+				return nil, fmt.Errorf("unable to read archived node %s stats: %w", id.Path(), err)
+			}
+			if items, err = state.ReadArchivedItems(ctx, id); err != nil {
+				return nil, fmt.Errorf("unable to read archived node %s items: %w", id.Path(), err)
+			}
+		}
+		rawStats, err := stats.ToJSON()
+		if err != nil {
+			return nil, fmt.Errorf("unable to encode archived node %s stats: %w", id.Path(), err)
+		}
+
+		base := "keg-archive/archive/" + id.Path()
+		if err := writeTarFile(tw, base+"/README.md", content); err != nil {
+			return nil, err
+		}
+		if err := writeTarFile(tw, base+"/meta.yaml", meta); err != nil {
+			return nil, err
+		}
+		if err := writeTarFile(tw, base+"/stats.json", rawStats); err != nil {
+			return nil, err
+		}
+		entry := archiveManifestNode{SourceID: id.Path()}
+		if entry.Items, err = writeArchiveItems(tw, base, items); err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, entry)
+	}
+	return nodes, nil
+}
+
+// importArchivedNodes writes the archived nodes of a full archive into k and
+// archives them there. Nodes already archived in k are left alone.
+func importArchivedNodes(ctx context.Context, k *keg.Keg, manifest archiveManifest, entries map[string][]byte) error {
+	if len(manifest.Archived) == 0 {
+		return nil
+	}
+	archive, ok := k.Repo.(keg.RepositoryArchive)
+	if !ok {
+		return fmt.Errorf("keg cannot hold archived nodes: %w", keg.ErrNotSupported)
+	}
+	existing, err := archive.ListArchived(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to list archived nodes: %w", err)
+	}
+	for _, node := range manifest.Archived {
+		id, err := parseNodeID(node.SourceID)
+		if err != nil {
+			return fmt.Errorf("invalid archived node %q: %w", node.SourceID, err)
+		}
+		if slices.Contains(existing, id) {
+			continue
+		}
+		if exists, err := k.Repo.HasNode(ctx, id); err != nil {
+			return err
+		} else if exists {
+			return fmt.Errorf("unable to restore archived node %s: %w", id.Path(), keg.NewDestinationExistsError(id))
+		}
+
+		base := "keg-archive/archive/" + node.SourceID
+		content, err := readRequiredArchiveEntry(entries, base+"/README.md")
+		if err != nil {
+			return fmt.Errorf("archived node %s missing README.md: %w", node.SourceID, err)
+		}
+		meta, err := readRequiredArchiveEntry(entries, base+"/meta.yaml")
+		if err != nil {
+			return fmt.Errorf("archived node %s missing meta.yaml: %w", node.SourceID, err)
+		}
+		rawStats, err := readRequiredArchiveEntry(entries, base+"/stats.json")
+		if err != nil {
+			return fmt.Errorf("archived node %s missing stats.json: %w", node.SourceID, err)
+		}
+		stats, err := keg.ParseStats(ctx, rawStats)
+		if err != nil {
+			return fmt.Errorf("unable to parse archived node %s stats: %w", node.SourceID, err)
+		}
+		items, err := archiveNodeItems(entries, base, node.Items)
+		if err != nil {
+			return err
+		}
+
+		if err := k.Repo.WriteNode(ctx, id, content, meta, stats); err != nil {
+			return fmt.Errorf("unable to write archived node %s: %w", node.SourceID, err)
+		}
+		if err := keg.WriteNodeItems(ctx, k.Repo, id, items); err != nil {
+			return fmt.Errorf("unable to write archived node %s items: %w", node.SourceID, err)
+		}
+		if err := archive.ArchiveNode(ctx, id); err != nil {
+			return fmt.Errorf("unable to archive node %s: %w", node.SourceID, err)
+		}
+	}
+	return nil
+}
+
 func exportNodeIDs(ctx context.Context, k *keg.Keg, raw []string) ([]keg.NodeId, error) {
 	if len(raw) == 0 {
 		return k.Repo.ListNodes(ctx)
@@ -373,6 +643,23 @@ func exportNodeIDs(ctx context.Context, k *keg.Keg, raw []string) ([]keg.NodeId,
 	return out, nil
 }
 
+func exportBacklinksSettings(ctx context.Context, k *keg.Keg, override *bool) (bool, string, error) {
+	cfg, err := k.Config(ctx)
+	if err != nil && !errors.Is(err, keg.ErrNotExist) {
+		return false, "", fmt.Errorf("unable to read keg config: %w", err)
+	}
+	enabled := false
+	heading := ""
+	if cfg != nil && cfg.Export != nil {
+		enabled = cfg.Export.Backlinks
+		heading = cfg.Export.BacklinksHeading
+	}
+	if override != nil {
+		enabled = *override
+	}
+	return enabled, heading, nil
+}
+
 func readOptionalNodeMeta(ctx context.Context, repo keg.Repository, id keg.NodeId) ([]byte, error) {
 	_ = ctx
 	data, err := repo.ReadMeta(ctx, id)
@@ -638,6 +925,12 @@ func rebuildDexFromRepo(ctx context.Context, k *keg.Keg) error {
 	}
 
 	for _, id := range ids {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("dex rebuild interrupted: %w", err)
+		}
+		if id.Code != "" {
+			continue
+		}
 		nodeData, err := loadNodeDataForDex(ctx, k, id)
 		if err != nil {
 			return fmt.Errorf("unable to read node %s for dex rebuild: %w", id.Path(), err)
@@ -654,13 +947,16 @@ func rebuildDexFromRepo(ctx context.Context, k *keg.Keg) error {
 }
 
 func loadNodeDataForDex(ctx context.Context, k *keg.Keg, id keg.NodeId) (*keg.NodeData, error) {
-	contentBytes, err := k.Repo.ReadContent(ctx, id)
-	if err != nil {
+	// Locked content keeps the title, lead and links recorded in its stats.
+	var content *keg.NodeContent
+	contentBytes, err := k.GetContent(ctx, id)
+	if err != nil && !errors.Is(err, keg.ErrEncrypted) {
 		return nil, err
 	}
-	content, err := keg.ParseContent(k.Runtime, contentBytes, keg.FormatMarkdown)
-	if err != nil {
-		return nil, err
+	if err == nil {
+		if content, err = keg.ParseContent(k.Runtime, contentBytes, k.ContentFormat(ctx)); err != nil {
+			return nil, err
+		}
 	}
 
 	metaBytes, err := k.Repo.ReadMeta(ctx, id)
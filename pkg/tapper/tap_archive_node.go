@@ -0,0 +1,97 @@
+package tapper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jlrickert/tapper/pkg/keg"
+)
+
+type ArchiveNodeOptions struct {
+	KegTargetOptions
+
+	// NodeIDs lists the nodes to archive or unarchive.
+	NodeIDs []string
+}
+
+// ArchiveNodes moves nodes into the keg's archived namespace. Archived nodes
+// drop out of the index but stay readable with `tap cat --archived`.
+func (t *Tap) ArchiveNodes(ctx context.Context, opts ArchiveNodeOptions) error {
+	k, err := t.resolveKeg(ctx, opts.KegTargetOptions)
+	if err != nil {
+		return fmt.Errorf("unable to open keg: %w", err)
+	}
+	if len(opts.NodeIDs) == 0 {
+		return fmt.Errorf("at least one node ID is required")
+	}
+
+	for _, raw := range opts.NodeIDs {
+		id, err := parseNodeID(raw)
+		if err != nil {
+			return err
+		}
+		if err := k.Archive(ctx, id); err != nil {
+			if errors.Is(err, keg.ErrNotExist) {
+				return keg.NewNodeNotFoundError(id)
+			}
+			return fmt.Errorf("unable to archive node %s: %w", id.Path(), err)
+		}
+	}
+	return nil
+}
+
+// UnarchiveNodes restores archived nodes to the active keg.
+func (t *Tap) UnarchiveNodes(ctx context.Context, opts ArchiveNodeOptions) error {
+	k, err := t.resolveKeg(ctx, opts.KegTargetOptions)
+	if err != nil {
+		return fmt.Errorf("unable to open keg: %w", err)
+	}
+	if len(opts.NodeIDs) == 0 {
+		return fmt.Errorf("at least one node ID is required")
+	}
+
+	for _, raw := range opts.NodeIDs {
+		id, err := parseNodeID(raw)
+		if err != nil {
+			return err
+		}
+		if err := k.Unarchive(ctx, id); err != nil {
+			if errors.Is(err, keg.ErrNotExist) {
+				return fmt.Errorf("archived node %s not found", id.Path())
+			}
+			if errors.Is(err, keg.ErrDestinationExists) {
+				return fmt.Errorf("node %s already exists", id.Path())
+			}
+			return fmt.Errorf("unable to unarchive node %s: %w", id.Path(), err)
+		}
+	}
+	return nil
+}
+
+// ListArchived returns "id\ttitle" lines for every archived node.
+func (t *Tap) ListArchived(ctx context.Context, opts KegTargetOptions) ([]string, error) {
+	k, err := t.resolveKeg(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open keg: %w", err)
+	}
+	ids, err := k.ListArchived(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list archived nodes: %w", err)
+	}
+
+	lines := make([]string, 0, len(ids))
+	format := k.ContentFormat(ctx)
+	for _, id := range ids {
+		raw, err := k.ReadArchivedContent(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		content, err := keg.ParseContent(k.Runtime, raw, format)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse archived node %s: %w", id.Path(), err)
+		}
+		lines = append(lines, id.Path()+"\t"+content.Title)
+	}
+	return lines, nil
+}
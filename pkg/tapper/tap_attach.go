@@ -0,0 +1,102 @@
+package tapper
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jlrickert/tapper/pkg/keg"
+)
+
+// PasteImageOptions configures behavior for Tap.PasteImage.
+type PasteImageOptions struct {
+	KegTargetOptions
+	NodeID string
+
+	// Name overrides the generated paste-YYYYMMDD-HHMMSS filename.
+	Name string
+
+	// Alt is alternative text for the image reference and metadata.
+	Alt string
+
+	// KeepMetadata stores the image as-is even when the keg strips image
+	// metadata on upload.
+	KeepMetadata bool
+}
+
+// PasteImageResult describes an image pasted from the clipboard.
+type PasteImageResult struct {
+	Name string
+
+	// Markdown is an image reference suitable for the node's README.md.
+	Markdown string
+}
+
+// PasteImage reads an image from the system clipboard and stores it on a
+// node through UploadImage.
+func (t *Tap) PasteImage(ctx context.Context, opts PasteImageOptions) (*PasteImageResult, error) {
+	data, err := readClipboardImage(ctx, t.Runtime)
+	if err != nil {
+		return nil, err
+	}
+	ext := imageExtension(data)
+	if ext == "" {
+		return nil, ErrClipboardEmpty
+	}
+	name := opts.Name
+	if name == "" {
+		name = "paste-" + t.Runtime.Clock().Now().Format("20060102-150405") + ext
+	}
+	name, err = t.UploadImage(ctx, UploadImageOptions{
+		KegTargetOptions: opts.KegTargetOptions,
+		NodeID:           opts.NodeID,
+		Name:             name,
+		Alt:              opts.Alt,
+		Data:             data,
+		KeepMetadata:     opts.KeepMetadata,
+	})
+	if err != nil {
+		return nil, err
+	}
+	alt := opts.Alt
+	if alt == "" {
+		alt = name
+	}
+	return &PasteImageResult{
+		Name:     name,
+		Markdown: fmt.Sprintf("![%s](%s/%s)", alt, keg.NodeImagesDir, name),
+	}, nil
+}
+
+// ListAttachmentsOptions configures behavior for Tap.ListAttachments.
+type ListAttachmentsOptions struct {
+	KegTargetOptions
+	NodeID string
+
+	// Long includes item metadata in the result.
+	Long bool
+}
+
+// ListAttachments returns the images and file attachments of a node.
+func (t *Tap) ListAttachments(ctx context.Context, opts ListAttachmentsOptions) ([]keg.Attachment, error) {
+	k, err := t.resolveKeg(ctx, opts.KegTargetOptions)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open keg: %w", err)
+	}
+	node, err := keg.ParseNode(opts.NodeID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid node ID %q: %w", opts.NodeID, err)
+	}
+	if node == nil {
+		return nil, fmt.Errorf("invalid node ID %q: %w", opts.NodeID, keg.ErrInvalid)
+	}
+	return k.ListAttachments(ctx, keg.NodeId{ID: node.ID, Code: node.Code}, opts.Long)
+}
+
+// AttachmentPath returns the path of an attachment relative to its node
+// directory, as used in Markdown links.
+func AttachmentPath(a keg.Attachment) string {
+	if a.Kind == keg.AssetKindImage {
+		return keg.NodeImagesDir + "/" + a.Name
+	}
+	return keg.NodeAttachmentsDir + "/" + a.Name
+}
@@ -0,0 +1,103 @@
+package tapper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jlrickert/cli-toolkit/toolkit"
+	"github.com/jlrickert/tapper/pkg/keg"
+)
+
+// CatAttachmentOptions configures behavior for Tap.CatAttachment.
+type CatAttachmentOptions struct {
+	KegTargetOptions
+	NodeID string
+
+	// Name is the item name. A leading "images/" or "assets/" selects the
+	// kind; bare names are looked up among file attachments, then images.
+	Name string
+
+	// Out writes the item to this local path instead of the given writer.
+	Out string
+}
+
+// CatAttachment streams a node image or file attachment to w, or to
+// opts.Out when set. It returns the number of bytes copied.
+func (t *Tap) CatAttachment(ctx context.Context, opts CatAttachmentOptions, w io.Writer) (int64, error) {
+	k, err := t.resolveKeg(ctx, opts.KegTargetOptions)
+	if err != nil {
+		return 0, fmt.Errorf("unable to open keg: %w", err)
+	}
+	node, err := keg.ParseNode(opts.NodeID)
+	if err != nil {
+		return 0, fmt.Errorf("invalid node ID %q: %w", opts.NodeID, err)
+	}
+	if node == nil {
+		return 0, fmt.Errorf("invalid node ID %q: %w", opts.NodeID, keg.ErrInvalid)
+	}
+	id := keg.NodeId{ID: node.ID, Code: node.Code}
+
+	r, err := openAttachment(ctx, k, id, opts.Name)
+	if err != nil {
+		return 0, fmt.Errorf("unable to open %q on node %s: %w", opts.Name, id.Path(), err)
+	}
+	defer r.Close()
+
+	if opts.Out != "" {
+		path, err := hostPath(t.Runtime, opts.Out, false)
+		if err != nil {
+			return 0, fmt.Errorf("unable to resolve %q: %w", opts.Out, err)
+		}
+		f, err := os.Create(path)
+		if err != nil {
+			return 0, fmt.Errorf("unable to create %q: %w", opts.Out, err)
+		}
+		n, err := io.Copy(f, r)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return n, fmt.Errorf("unable to write %q: %w", opts.Out, err)
+		}
+		return n, nil
+	}
+	n, err := io.Copy(w, r)
+	if err != nil {
+		return n, fmt.Errorf("unable to stream %q: %w", opts.Name, err)
+	}
+	return n, nil
+}
+
+func openAttachment(ctx context.Context, k *keg.Keg, id keg.NodeId, name string) (io.ReadCloser, error) {
+	if rest, ok := strings.CutPrefix(name, keg.NodeImagesDir+"/"); ok {
+		return k.OpenItem(ctx, id, keg.AssetKindImage, rest)
+	}
+	if rest, ok := strings.CutPrefix(name, keg.NodeAttachmentsDir+"/"); ok {
+		return k.OpenItem(ctx, id, keg.AssetKindItem, rest)
+	}
+	r, err := k.OpenItem(ctx, id, keg.AssetKindItem, name)
+	if errors.Is(err, keg.ErrNotExist) {
+		return k.OpenItem(ctx, id, keg.AssetKindImage, name)
+	}
+	return r, err
+}
+
+// hostPath resolves path against the runtime and maps it into the jail, if
+// any, so it can be handed to the operating system directly. Symlinks are
+// only resolved when follow is set, which requires the path to exist.
+func hostPath(rt *toolkit.Runtime, path string, follow bool) (string, error) {
+	resolved, err := rt.ResolvePath(path, follow)
+	if err != nil {
+		return "", err
+	}
+	if jail := strings.TrimSpace(rt.GetJail()); jail != "" {
+		trimmed := strings.TrimPrefix(resolved, string(filepath.Separator))
+		resolved = filepath.Join(jail, trimmed)
+	}
+	return resolved, nil
+}
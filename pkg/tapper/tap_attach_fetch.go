@@ -0,0 +1,240 @@
+package tapper
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/jlrickert/tapper/pkg/keg"
+)
+
+// DefaultFetchMaxSize is the download limit used by Tap.FetchAttachment when
+// no limit is given.
+const DefaultFetchMaxSize int64 = 25 << 20
+
+// fetchTimeout bounds a whole attachment download, including reading the
+// body.
+const fetchTimeout = 2 * time.Minute
+
+// fetchClient downloads attachments. Unlike http.DefaultClient it gives up
+// on servers that stall.
+var fetchClient = &http.Client{Timeout: fetchTimeout}
+
+// Attachment kinds accepted by FetchAttachmentOptions.As.
+const (
+	FetchAsAuto  = ""
+	FetchAsImage = "image"
+	FetchAsFile  = "file"
+)
+
+// FetchAttachmentOptions configures behavior for Tap.FetchAttachment.
+type FetchAttachmentOptions struct {
+	KegTargetOptions
+	NodeID string
+	URL    string
+
+	// Name overrides the stored filename derived from the URL.
+	Name string
+
+	// As forces storage as an image or file attachment. By default images
+	// are detected from the response content type.
+	As string
+
+	// Alt is alternative text recorded for fetched images.
+	Alt string
+
+	// MaxSize limits the download size in bytes. Zero uses
+	// DefaultFetchMaxSize.
+	MaxSize int64
+
+	// Accept lists allowed media types. Entries may end in "/*" to match a
+	// whole type. When empty, anything but HTML pages is accepted.
+	Accept []string
+
+	// Rewrite replaces occurrences of URL in the node content with the
+	// local path of the stored attachment.
+	Rewrite bool
+}
+
+// FetchAttachmentResult describes a downloaded attachment.
+type FetchAttachmentResult struct {
+	Attachment keg.Attachment
+	// Path is the attachment path relative to the node directory.
+	Path string
+	// Rewritten reports how many URL occurrences were replaced.
+	Rewritten int
+}
+
+// FetchAttachment downloads a remote file and stores it on a node as an
+// image or file attachment, recording the source URL in its metadata.
+func (t *Tap) FetchAttachment(ctx context.Context, opts FetchAttachmentOptions) (*FetchAttachmentResult, error) {
+	switch opts.As {
+	case FetchAsAuto, FetchAsImage, FetchAsFile:
+	default:
+		return nil, fmt.Errorf("unknown attachment kind %q: %w", opts.As, keg.ErrInvalid)
+	}
+	src, err := url.Parse(opts.URL)
+	if err != nil || (src.Scheme != "http" && src.Scheme != "https") || src.Host == "" {
+		return nil, fmt.Errorf("invalid URL %q: must be an absolute http(s) URL: %w", opts.URL, keg.ErrInvalid)
+	}
+
+	// Resolve the node before downloading so a bad keg or node ID fails fast.
+	k, err := t.resolveKeg(ctx, opts.KegTargetOptions)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open keg: %w", err)
+	}
+	node, err := keg.ParseNode(opts.NodeID)
+	if err != nil || node == nil {
+		return nil, fmt.Errorf("invalid node ID %q: %w", opts.NodeID, keg.ErrInvalid)
+	}
+	id := keg.NodeId{ID: node.ID, Code: node.Code}
+	exists, err := k.Repo.HasNode(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("unable to inspect node: %w", err)
+	}
+	if !exists {
+		return nil, keg.NewNodeNotFoundError(id)
+	}
+
+	data, mediaType, err := fetchRemote(ctx, opts.URL, opts.MaxSize)
+	if err != nil {
+		return nil, err
+	}
+	if !acceptMediaType(mediaType, opts.Accept) {
+		return nil, fmt.Errorf("unable to fetch %s: content type %q is not accepted: %w", opts.URL, mediaType, keg.ErrInvalid)
+	}
+
+	kind := keg.AssetKindItem
+	if opts.As == FetchAsImage || (opts.As == FetchAsAuto && strings.HasPrefix(mediaType, "image/")) {
+		kind = keg.AssetKindImage
+	}
+	if kind == keg.AssetKindImage && !strings.HasPrefix(mediaType, "image/") {
+		return nil, fmt.Errorf("unable to fetch %s as image: content type is %q: %w", opts.URL, mediaType, keg.ErrInvalid)
+	}
+
+	name := opts.Name
+	if name == "" {
+		name = fetchName(src, mediaType)
+	}
+
+	if kind == keg.AssetKindImage {
+		name, err = t.UploadImage(ctx, UploadImageOptions{
+			KegTargetOptions: opts.KegTargetOptions,
+			NodeID:           opts.NodeID,
+			Name:             name,
+			Alt:              opts.Alt,
+			SourceURL:        opts.URL,
+			Data:             data,
+		})
+	} else {
+		name, err = t.UploadFile(ctx, UploadFileOptions{
+			KegTargetOptions: opts.KegTargetOptions,
+			NodeID:           opts.NodeID,
+			Name:             name,
+			SourceURL:        opts.URL,
+			Data:             data,
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	res := &FetchAttachmentResult{Attachment: keg.Attachment{Kind: kind, Name: name}}
+	res.Path = AttachmentPath(res.Attachment)
+	if opts.Rewrite {
+		res.Rewritten, err = rewriteNodeURL(ctx, k, id, opts.URL, res.Path)
+		if err != nil {
+			return res, err
+		}
+	}
+	return res, nil
+}
+
+// fetchRemote downloads rawURL, failing when the body exceeds maxSize.
+func fetchRemote(ctx context.Context, rawURL string, maxSize int64) ([]byte, string, error) {
+	if maxSize <= 0 {
+		maxSize = DefaultFetchMaxSize
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to create request: %w", err)
+	}
+	resp, err := fetchClient.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to fetch %s: %w", rawURL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, "", fmt.Errorf("unable to fetch %s: status %d", rawURL, resp.StatusCode)
+	}
+	if resp.ContentLength > maxSize {
+		return nil, "", fmt.Errorf("unable to fetch %s: size %d exceeds limit of %d bytes", rawURL, resp.ContentLength, maxSize)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxSize+1))
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to read %s: %w", rawURL, err)
+	}
+	if int64(len(data)) > maxSize {
+		return nil, "", fmt.Errorf("unable to fetch %s: download exceeds limit of %d bytes", rawURL, maxSize)
+	}
+
+	mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil || mediaType == "application/octet-stream" {
+		mediaType, _, _ = mime.ParseMediaType(http.DetectContentType(data))
+	}
+	return data, mediaType, nil
+}
+
+func acceptMediaType(mediaType string, accept []string) bool {
+	if len(accept) == 0 {
+		return mediaType != "text/html"
+	}
+	for _, pattern := range accept {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		if pattern == "*/*" || pattern == mediaType {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(pattern, "/*"); ok && strings.HasPrefix(mediaType, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// fetchName derives a filename from the URL path, adding an extension that
+// matches the media type when the path has none.
+func fetchName(src *url.URL, mediaType string) string {
+	name := path.Base(src.Path)
+	if name == "." || name == "/" {
+		name = "download"
+	}
+	if path.Ext(name) == "" {
+		if exts, _ := mime.ExtensionsByType(mediaType); len(exts) > 0 {
+			name += exts[0]
+		}
+	}
+	return name
+}
+
+func rewriteNodeURL(ctx context.Context, k *keg.Keg, id keg.NodeId, from, to string) (int, error) {
+	content, err := k.GetContent(ctx, id)
+	if err != nil {
+		return 0, fmt.Errorf("unable to read node content: %w", err)
+	}
+	count := bytes.Count(content, []byte(from))
+	if count == 0 {
+		return 0, nil
+	}
+	content = bytes.ReplaceAll(content, []byte(from), []byte(to))
+	if err := k.SetContent(ctx, id, content); err != nil {
+		return 0, fmt.Errorf("unable to update node content: %w", err)
+	}
+	return count, nil
+}
@@ -0,0 +1,138 @@
+package tapper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jlrickert/tapper/pkg/keg"
+	"github.com/jlrickert/tapper/pkg/registry"
+)
+
+// AuthLoginOptions describes a `tap auth login` run.
+type AuthLoginOptions struct {
+	// Registry is the registry name. Empty uses defaultRegistry.
+	Registry string
+
+	// Token, when set, is saved as is instead of running the device-code
+	// flow.
+	Token string
+
+	// Prompt is shown the device code the user has to enter. It is required
+	// for the device-code flow.
+	Prompt func(auth *registry.DeviceAuth) error
+}
+
+// AuthStatus reports how tapper authenticates against one registry.
+type AuthStatus struct {
+	Registry string
+	Url      string
+
+	// Source is where the token comes from: "config", "env", the credential
+	// store name ("keyring" or "file"), or empty when there is none.
+	Source string
+
+	// Expires is the expiry of a saved credential, zero when unknown or
+	// never.
+	Expires time.Time
+
+	// Expired is set for a saved credential past its expiry.
+	Expired bool
+}
+
+// Authenticated reports whether a usable token is available.
+func (s AuthStatus) Authenticated() bool {
+	return s.Source != "" && !s.Expired
+}
+
+// AuthLogin obtains a token for a registry, by paste or device code, and
+// saves it in the credential store.
+func (t *Tap) AuthLogin(ctx context.Context, opts AuthLoginOptions) (*Credential, error) {
+	reg, err := t.lookupRegistry(opts.Registry)
+	if err != nil {
+		return nil, err
+	}
+	if t.Credentials == nil {
+		return nil, fmt.Errorf("no credential store: %w", keg.ErrNotSupported)
+	}
+
+	cred := Credential{Registry: reg.Name, Token: strings.TrimSpace(opts.Token)}
+	if cred.Token == "" {
+		if opts.Prompt == nil {
+			return nil, fmt.Errorf("token required: %w", keg.ErrInvalid)
+		}
+		client := registry.NewClient(reg.Url, "")
+		auth, err := client.StartDeviceAuth(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if err := opts.Prompt(auth); err != nil {
+			return nil, err
+		}
+		pollCtx := ctx
+		if auth.ExpiresIn > 0 {
+			var cancel context.CancelFunc
+			pollCtx, cancel = context.WithTimeout(ctx, time.Duration(auth.ExpiresIn)*time.Second)
+			defer cancel()
+		}
+		tok, err := client.PollDeviceToken(pollCtx, auth, t.Runtime.Clock().Now)
+		if errors.Is(err, context.DeadlineExceeded) {
+			err = registry.ErrExpiredCode
+		}
+		if err != nil {
+			return nil, fmt.Errorf("login to %s failed: %w", reg.Name, err)
+		}
+		cred.Token = tok.AccessToken
+		cred.Expires = tok.Expires
+	}
+
+	if err := t.Credentials.Set(ctx, cred); err != nil {
+		return nil, err
+	}
+	return &cred, nil
+}
+
+// AuthLogout removes the saved credential of a registry.
+func (t *Tap) AuthLogout(ctx context.Context, name string) error {
+	reg, err := t.lookupRegistry(name)
+	if err != nil {
+		return err
+	}
+	if t.Credentials == nil {
+		return fmt.Errorf("not logged in to %s: %w", reg.Name, keg.ErrNotExist)
+	}
+	if err := t.Credentials.Delete(ctx, reg.Name); err != nil {
+		if errors.Is(err, keg.ErrNotExist) {
+			return fmt.Errorf("not logged in to %s: %w", reg.Name, keg.ErrNotExist)
+		}
+		return err
+	}
+	return nil
+}
+
+// AuthStatus reports the token source of every configured registry. It
+// does not contact the registries.
+func (t *Tap) AuthStatus(ctx context.Context) []AuthStatus {
+	now := t.Runtime.Clock().Now()
+	regs := t.ConfigService.Config(true).Registries()
+	out := make([]AuthStatus, 0, len(regs))
+	for _, reg := range regs {
+		status := AuthStatus{Registry: reg.Name, Url: reg.Url}
+		switch {
+		case reg.Token != "":
+			status.Source = "config"
+		case reg.TokenEnv != "" && t.Runtime.Get(reg.TokenEnv) != "":
+			status.Source = "env"
+		case t.Credentials != nil:
+			if cred, err := t.Credentials.Get(ctx, reg.Name); err == nil {
+				status.Source = t.Credentials.Name()
+				status.Expires = cred.Expires
+				status.Expired = cred.Expired(now)
+			}
+		}
+		out = append(out, status)
+	}
+	return out
+}
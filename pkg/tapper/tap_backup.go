@@ -0,0 +1,323 @@
+package tapper
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jlrickert/cli-toolkit/toolkit"
+	"github.com/jlrickert/tapper/pkg/keg"
+	kegurl "github.com/jlrickert/tapper/pkg/keg_url"
+)
+
+// backupTimeLayout stamps backup archive names so lexical order is time order.
+const backupTimeLayout = "20060102T150405Z"
+
+// BackupRunOptions configures Tap.BackupRun.
+type BackupRunOptions struct {
+	KegTargetOptions
+
+	// Force writes a backup even when the schedule says none is due.
+	Force bool
+}
+
+// BackupRunResult describes the outcome of a backup run.
+type BackupRunResult struct {
+	// Backup is the archive written by this run. It is nil when Skipped.
+	Backup *Backup
+
+	// Skipped reports that the latest backup is newer than the schedule
+	// interval, so no archive was written.
+	Skipped bool
+
+	// Pruned lists archives removed by the keep policy.
+	Pruned []string
+}
+
+// BackupVerifyOptions configures Tap.BackupVerify.
+type BackupVerifyOptions struct {
+	KegTargetOptions
+
+	// Path is the archive to verify. Defaults to the latest backup of the keg.
+	Path string
+}
+
+// Backup is one backup archive in the configured destination.
+type Backup struct {
+	Path    string
+	Name    string
+	Created time.Time
+	Size    int64
+
+	// Nodes is the number of nodes recorded in the archive manifest. It is
+	// only set by BackupRun and BackupVerify.
+	Nodes int
+}
+
+// BackupRun exports the whole resolved keg, with node content as stored,
+// items, archived nodes and config, into the configured backup destination, verifies the new archive
+// against its manifest, and prunes the oldest archives beyond backup.keep.
+func (t *Tap) BackupRun(ctx context.Context, opts BackupRunOptions) (*BackupRunResult, error) {
+	cfg, dest, err := t.backupDestination()
+	if err != nil {
+		return nil, err
+	}
+	k, err := t.resolveKeg(ctx, opts.KegTargetOptions)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open keg: %w", err)
+	}
+	name := t.backupName(k)
+	now := t.Runtime.Clock().Now().UTC()
+
+	existing, err := t.listBackups(dest, name)
+	if err != nil {
+		return nil, err
+	}
+	if !opts.Force && len(existing) > 0 {
+		interval := cfg.Interval()
+		latest := existing[len(existing)-1]
+		if interval > 0 && now.Sub(latest.Created) < interval {
+			return &BackupRunResult{Skipped: true}, nil
+		}
+	}
+
+	path := filepath.Join(dest, name+"-"+now.Format(backupTimeLayout)+".tar.gz")
+	_, withHistory := k.Repo.(keg.RepositorySnapshots)
+	// Backups hold node content exactly as stored: no generated backlinks,
+	// and encrypted content stays encrypted.
+	backlinks := false
+	exportOpts := ExportOptions{
+		KegTargetOptions: opts.KegTargetOptions,
+		WithHistory:      withHistory,
+		OutputPath:       path,
+		Backlinks:        &backlinks,
+		Full:             true,
+	}
+	if _, err := t.Export(ctx, exportOpts); err != nil {
+		return nil, fmt.Errorf("unable to write backup: %w", err)
+	}
+	nodes, err := t.verifyBackupFile(ctx, path)
+	if err != nil {
+		_ = t.Runtime.Remove(path, false)
+		return nil, fmt.Errorf("backup %s failed verification: %w", path, err)
+	}
+
+	backup := Backup{Path: path, Name: filepath.Base(path), Created: now, Nodes: nodes}
+	if info, err := t.Runtime.Stat(path, false); err == nil {
+		backup.Size = info.Size()
+	}
+	result := &BackupRunResult{Backup: &backup}
+
+	// A forced run within the same second overwrites the previous archive.
+	all := make([]Backup, 0, len(existing)+1)
+	for _, old := range existing {
+		if old.Path != path {
+			all = append(all, old)
+		}
+	}
+	all = append(all, backup)
+	if keep := cfg.Keep; keep > 0 && len(all) > keep {
+		for _, old := range all[:len(all)-keep] {
+			if err := t.Runtime.Remove(old.Path, false); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return result, fmt.Errorf("unable to prune backup %s: %w", old.Path, err)
+			}
+			result.Pruned = append(result.Pruned, old.Path)
+		}
+	}
+	return result, nil
+}
+
+// BackupList returns the backups of the resolved keg, oldest first.
+func (t *Tap) BackupList(ctx context.Context, opts KegTargetOptions) ([]Backup, error) {
+	_, dest, err := t.backupDestination()
+	if err != nil {
+		return nil, err
+	}
+	k, err := t.resolveKeg(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open keg: %w", err)
+	}
+	return t.listBackups(dest, t.backupName(k))
+}
+
+// BackupVerify checks that an archive is readable and holds every file its
+// manifest lists. Without a path it verifies the latest backup of the keg.
+func (t *Tap) BackupVerify(ctx context.Context, opts BackupVerifyOptions) (*Backup, error) {
+	path := strings.TrimSpace(opts.Path)
+	if path == "" {
+		backups, err := t.BackupList(ctx, opts.KegTargetOptions)
+		if err != nil {
+			return nil, err
+		}
+		if len(backups) == 0 {
+			return nil, fmt.Errorf("no backups found: %w", keg.ErrNotExist)
+		}
+		path = backups[len(backups)-1].Path
+	}
+	path, err := expandArchivePath(t.Runtime, path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := t.Runtime.Stat(path, false)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read backup %s: %w", path, err)
+	}
+	nodes, err := t.verifyBackupFile(ctx, path)
+	if err != nil {
+		return nil, fmt.Errorf("backup %s failed verification: %w", path, err)
+	}
+	backup := &Backup{Path: path, Name: filepath.Base(path), Size: info.Size(), Nodes: nodes}
+	if _, created, ok := parseBackupName(backup.Name); ok {
+		backup.Created = created
+	}
+	return backup, nil
+}
+
+// backupDestination returns the backup config and its local destination
+// directory. Only local directories are supported as destinations.
+func (t *Tap) backupDestination() (*BackupConfig, string, error) {
+	cfg := t.ConfigService.Config(true).Backup()
+	if cfg == nil || strings.TrimSpace(cfg.Destination) == "" {
+		return nil, "", fmt.Errorf("backup.destination not defined in user config: %w", keg.ErrNotExist)
+	}
+	if strings.HasPrefix(cfg.Destination, "s3://") {
+		return nil, "", fmt.Errorf("backup destination %s: %w", cfg.Destination, keg.ErrNotSupported)
+	}
+	dest, err := expandArchivePath(t.Runtime, cfg.Destination)
+	if err != nil {
+		return nil, "", err
+	}
+	return cfg, dest, nil
+}
+
+// backupName is the archive name prefix for k: its configured alias, or the
+// base name of its target when it has none.
+func (t *Tap) backupName(k *keg.Keg) string {
+	if k.Target == nil {
+		return "keg"
+	}
+	cfg := t.ConfigService.Config(true)
+	if alias := cfg.LookupAliasForTarget(t.Runtime, k.Target.String()); alias != "" {
+		return alias
+	}
+	path := k.Target.Path()
+	if k.Target.Scheme() == kegurl.SchemeFile {
+		path = toolkit.ExpandEnv(t.Runtime, path)
+	}
+	if base := filepath.Base(filepath.Clean(path)); base != "." && base != string(filepath.Separator) {
+		return base
+	}
+	return "keg"
+}
+
+// listBackups returns the archives in dest named for name, oldest first. A
+// missing destination holds no backups.
+func (t *Tap) listBackups(dest, name string) ([]Backup, error) {
+	entries, err := t.Runtime.ReadDir(dest)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("unable to read backup destination %s: %w", dest, err)
+	}
+	var backups []Backup
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		prefix, created, ok := parseBackupName(e.Name())
+		if !ok || prefix != name {
+			continue
+		}
+		backup := Backup{Path: filepath.Join(dest, e.Name()), Name: e.Name(), Created: created}
+		if info, err := e.Info(); err == nil {
+			backup.Size = info.Size()
+		}
+		backups = append(backups, backup)
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].Created.Before(backups[j].Created)
+	})
+	return backups, nil
+}
+
+// parseBackupName splits "<name>-<timestamp>.tar.gz" into its parts.
+func parseBackupName(file string) (string, time.Time, bool) {
+	base, ok := strings.CutSuffix(file, ".tar.gz")
+	if !ok {
+		return "", time.Time{}, false
+	}
+	idx := strings.LastIndex(base, "-")
+	if idx <= 0 {
+		return "", time.Time{}, false
+	}
+	created, err := time.Parse(backupTimeLayout, base[idx+1:])
+	if err != nil {
+		return "", time.Time{}, false
+	}
+	return base[:idx], created, true
+}
+
+// verifyBackupFile reads the archive at path and checks it against its
+// manifest. It returns the number of nodes in the manifest.
+func (t *Tap) verifyBackupFile(ctx context.Context, path string) (int, error) {
+	data, err := readArchiveInput(ctx, t.Runtime, path)
+	if err != nil {
+		return 0, err
+	}
+	entries, err := readArchiveEntries(data)
+	if err != nil {
+		return 0, err
+	}
+	rawManifest, ok := entries["keg-archive/manifest.json"]
+	if !ok {
+		return 0, fmt.Errorf("archive manifest missing: %w", keg.ErrInvalid)
+	}
+	var manifest archiveManifest
+	if err := json.Unmarshal(rawManifest, &manifest); err != nil {
+		return 0, fmt.Errorf("unable to parse archive manifest: %w", err)
+	}
+	if manifest.Format != kegArchiveFormat {
+		return 0, fmt.Errorf("unsupported archive format %q: %w", manifest.Format, keg.ErrInvalid)
+	}
+	if manifest.Config {
+		if _, ok := entries[archiveConfigEntry]; !ok {
+			return 0, fmt.Errorf("keg config is missing: %w", keg.ErrInvalid)
+		}
+	}
+	for _, node := range manifest.Nodes {
+		base := "keg-archive/nodes/" + node.SourceID
+		if err := verifyBackupNode(entries, base, node); err != nil {
+			return 0, err
+		}
+		if node.RevisionCount > 0 {
+			if _, ok := entries[base+"/snapshots/index.json"]; !ok {
+				return 0, fmt.Errorf("node %s is missing its snapshot index: %w", node.SourceID, keg.ErrInvalid)
+			}
+		}
+	}
+	for _, node := range manifest.Archived {
+		if err := verifyBackupNode(entries, "keg-archive/archive/"+node.SourceID, node); err != nil {
+			return 0, fmt.Errorf("archived %w", err)
+		}
+	}
+	return len(manifest.Nodes), nil
+}
+
+// verifyBackupNode checks that the node archived at base has its content,
+// meta, stats and every item its manifest entry lists.
+func verifyBackupNode(entries map[string][]byte, base string, node archiveManifestNode) error {
+	files := append([]string{"README.md", "meta.yaml", "stats.json"}, node.Items...)
+	for _, file := range files {
+		if _, ok := entries[base+"/"+file]; !ok {
+			return fmt.Errorf("node %s is missing %s: %w", node.SourceID, file, keg.ErrInvalid)
+		}
+	}
+	return nil
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/jlrickert/cli-toolkit/toolkit"
 	"github.com/jlrickert/tapper/pkg/keg"
+	"gopkg.in/yaml.v3"
 )
 
 type CatOptions struct {
@@ -34,6 +35,17 @@ type CatOptions struct {
 	// MetaOnly displays metadata only.
 	MetaOnly bool
 
+	// Render displays content as styled terminal Markdown instead of raw
+	// text. Links to other nodes are followed by their titles.
+	Render bool
+
+	// Exact disables fuzzy title matching for non-numeric node arguments.
+	Exact bool
+
+	// Archived reads nodes from the archived namespace instead of the active
+	// keg. Node arguments must be numeric IDs.
+	Archived bool
+
 	// Stream carries stdin piping information when editing.
 	Stream *toolkit.Stream
 }
@@ -52,31 +64,29 @@ func (t *Tap) Cat(ctx context.Context, opts CatOptions) (string, error) {
 	if opts.MetaOnly {
 		outputModes++
 	}
+	if opts.Render {
+		outputModes++
+	}
 	if outputModes > 1 {
-		return "", fmt.Errorf("only one output mode may be selected: --edit, --content-only, --stats-only, --meta-only")
+		return "", fmt.Errorf("only one output mode may be selected: --edit, --content-only, --stats-only, --meta-only, --render")
 	}
 
-	// Resolve node IDs from tag expression or direct args.
-	nodeIDs := opts.NodeIDs
-	if opts.Tag != "" {
-		if len(nodeIDs) > 0 {
-			return "", fmt.Errorf("cannot specify both node IDs and --tag")
-		}
-		tagIDs, err := t.Tags(ctx, TagsOptions{
-			KegTargetOptions: opts.KegTargetOptions,
-			Tag:              opts.Tag,
-			IdOnly:           true,
-		})
-		if err != nil {
-			return "", fmt.Errorf("unable to query by tag: %w", err)
-		}
-		nodeIDs = tagIDs
+	nodeIDs, err := t.catNodeIDs(ctx, opts)
+	if err != nil {
+		return "", err
 	}
-
 	if len(nodeIDs) == 0 {
 		return "", nil
 	}
 
+	if opts.Render {
+		return t.catRendered(ctx, nodeIDs, opts)
+	}
+
+	if opts.Archived {
+		return t.catArchived(ctx, nodeIDs, opts)
+	}
+
 	if opts.Edit {
 		if len(nodeIDs) > 1 {
 			return "", fmt.Errorf("--edit can only be used with a single node")
@@ -84,6 +94,7 @@ func (t *Tap) Cat(ctx context.Context, opts CatOptions) (string, error) {
 		return "", t.Edit(ctx, EditOptions{
 			NodeID:           nodeIDs[0],
 			KegTargetOptions: opts.KegTargetOptions,
+			Exact:            opts.Exact,
 			Stream:           opts.Stream,
 		})
 	}
@@ -127,30 +138,121 @@ func (t *Tap) Cat(ctx context.Context, opts CatOptions) (string, error) {
 	return buf.String(), nil
 }
 
+// NodeDocument is the structured form of a node as printed by Tap.Cat.
+type NodeDocument struct {
+	ID      keg.NodeId
+	Meta    map[string]any
+	Stats   *keg.NodeStats
+	Content string
+}
+
+// CatDocuments reads the nodes selected by opts as structured documents.
+// Output mode options are ignored; every document carries metadata, stats
+// and content. Archived nodes have no stats.
+func (t *Tap) CatDocuments(ctx context.Context, opts CatOptions) ([]NodeDocument, error) {
+	if opts.Edit {
+		return nil, fmt.Errorf("--edit cannot be combined with structured output")
+	}
+	nodeIDs, err := t.catNodeIDs(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	k, err := t.resolveKeg(ctx, opts.KegTargetOptions)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open keg: %w", err)
+	}
+
+	docs := make([]NodeDocument, 0, len(nodeIDs))
+	for _, nodeID := range nodeIDs {
+		var (
+			node          keg.NodeId
+			content, meta []byte
+			stats         *keg.NodeStats
+		)
+		if opts.Archived {
+			if node, err = parseNodeID(nodeID); err != nil {
+				return nil, err
+			}
+			if content, err = k.ReadArchivedContent(ctx, node); err != nil {
+				return nil, err
+			}
+			if meta, err = k.ReadArchivedMeta(ctx, node); err != nil {
+				return nil, err
+			}
+		} else {
+			if node, err = t.resolveNode(ctx, k, nodeID, opts.Exact); err != nil {
+				return nil, err
+			}
+			if content, err = k.GetContent(ctx, node); err != nil {
+				if errors.Is(err, keg.ErrNotExist) {
+					return nil, keg.NewNodeNotFoundError(node)
+				}
+				return nil, fmt.Errorf("unable to read node content: %w", err)
+			}
+			if meta, err = k.Repo.ReadMeta(ctx, node); err != nil && !errors.Is(err, keg.ErrNotExist) {
+				return nil, fmt.Errorf("unable to read node metadata: %w", err)
+			}
+			if err := k.Touch(ctx, node); err != nil {
+				return nil, fmt.Errorf("unable to update node access: %w", err)
+			}
+			if stats, err = k.Repo.ReadStats(ctx, node); err != nil {
+				if !errors.Is(err, keg.ErrNotExist) {
+					return nil, fmt.Errorf("unable to read node stats: %w", err)
+				}
+				stats = &keg.NodeStats{}
+			}
+		}
+
+		fields := map[string]any{}
+		if err := yaml.Unmarshal(meta, &fields); err != nil {
+			return nil, fmt.Errorf("unable to parse metadata of node %s: %w", node.Path(), err)
+		}
+		docs = append(docs, NodeDocument{ID: node, Meta: fields, Stats: stats, Content: string(content)})
+	}
+	return docs, nil
+}
+
+// catNodeIDs returns the node arguments selected by opts, resolving the tag
+// expression when one is given.
+func (t *Tap) catNodeIDs(ctx context.Context, opts CatOptions) ([]string, error) {
+	if opts.Tag == "" {
+		return opts.NodeIDs, nil
+	}
+	if len(opts.NodeIDs) > 0 {
+		return nil, fmt.Errorf("cannot specify both node IDs and --tag")
+	}
+	tagIDs, err := t.Tags(ctx, TagsOptions{
+		KegTargetOptions: opts.KegTargetOptions,
+		Tag:              opts.Tag,
+		IdOnly:           true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to query by tag: %w", err)
+	}
+	return tagIDs, nil
+}
+
 // catSingleNode reads and formats a single node's content according to opts.
 func (t *Tap) catSingleNode(ctx context.Context, k *keg.Keg, nodeID string, opts CatOptions) (string, error) {
-	node, err := keg.ParseNode(nodeID)
+	node, err := t.resolveNode(ctx, k, nodeID, opts.Exact)
 	if err != nil {
-		return "", fmt.Errorf("invalid node ID %q: %w", nodeID, err)
-	}
-	if node == nil {
-		return "", fmt.Errorf("invalid node ID %q: %w", nodeID, keg.ErrInvalid)
+		return "", err
 	}
 
-	content, err := k.Repo.ReadContent(ctx, *node)
+	content, err := k.GetContent(ctx, node)
 	if err != nil {
 		if errors.Is(err, keg.ErrNotExist) {
-			return "", fmt.Errorf("node %s not found", node.Path())
+			return "", keg.NewNodeNotFoundError(node)
 		}
 		return "", fmt.Errorf("unable to read node content: %w", err)
 	}
 
-	meta, err := k.Repo.ReadMeta(ctx, *node)
+	meta, err := k.Repo.ReadMeta(ctx, node)
 	if err != nil && !errors.Is(err, keg.ErrNotExist) {
 		return "", fmt.Errorf("unable to read node metadata: %w", err)
 	}
 
-	if err := k.Touch(ctx, *node); err != nil {
+	if err := k.Touch(ctx, node); err != nil {
 		return "", fmt.Errorf("unable to update node access: %w", err)
 	}
 
@@ -159,7 +261,7 @@ func (t *Tap) catSingleNode(ctx context.Context, k *keg.Keg, nodeID string, opts
 	}
 
 	if opts.StatsOnly {
-		stats, err := k.Repo.ReadStats(ctx, *node)
+		stats, err := k.Repo.ReadStats(ctx, node)
 		if err != nil {
 			if errors.Is(err, keg.ErrNotExist) {
 				stats = &keg.NodeStats{}
@@ -177,6 +279,121 @@ func (t *Tap) catSingleNode(ctx context.Context, k *keg.Keg, nodeID string, opts
 	return formatFrontmatter(meta, content), nil
 }
 
+// catArchived prints archived nodes. Archived nodes are not touched and have
+// no stats, so --stats-only and --edit are rejected.
+// catRendered renders the content of each node as terminal Markdown with
+// keg-query blocks expanded. Nodes are separated by a rule. Styles are
+// dropped when NO_COLOR is set.
+func (t *Tap) catRendered(ctx context.Context, nodeIDs []string, opts CatOptions) (string, error) {
+	k, err := t.resolveKeg(ctx, opts.KegTargetOptions)
+	if err != nil {
+		return "", fmt.Errorf("unable to open keg: %w", err)
+	}
+	dex, err := k.Dex(ctx)
+	if err != nil {
+		return "", fmt.Errorf("unable to read dex: %w", err)
+	}
+	nodeTitle := func(id string) (string, bool) {
+		node, err := keg.ParseNode(id)
+		if err != nil || node == nil {
+			return "", false
+		}
+		ref := dex.GetRef(ctx, *node)
+		if ref == nil || ref.Title == "" {
+			return "", false
+		}
+		return ref.Title, true
+	}
+	color := t.Runtime.Get("NO_COLOR") == ""
+
+	var buf strings.Builder
+	for i, raw := range nodeIDs {
+		var (
+			node    keg.NodeId
+			content []byte
+		)
+		if opts.Archived {
+			if node, err = parseNodeID(raw); err != nil {
+				return "", err
+			}
+			if content, err = k.ReadArchivedContent(ctx, node); err != nil {
+				return "", err
+			}
+		} else {
+			if node, err = t.resolveNode(ctx, k, raw, opts.Exact); err != nil {
+				return "", err
+			}
+			if content, err = k.GetContent(ctx, node); err != nil {
+				if errors.Is(err, keg.ErrNotExist) {
+					return "", keg.NewNodeNotFoundError(node)
+				}
+				return "", fmt.Errorf("unable to read node content: %w", err)
+			}
+			if err := k.Touch(ctx, node); err != nil {
+				return "", fmt.Errorf("unable to update node access: %w", err)
+			}
+		}
+		if content, err = t.expandQueryBlocks(ctx, k, dex, node, content); err != nil {
+			return "", fmt.Errorf("unable to render node %s: %w", node.Path(), err)
+		}
+		if i > 0 {
+			buf.WriteString("\n")
+			buf.WriteString(strings.Repeat("═", 40))
+			buf.WriteString("\n\n")
+		}
+		buf.WriteString(renderMarkdown(content, color, nodeTitle))
+	}
+	return buf.String(), nil
+}
+
+func (t *Tap) catArchived(ctx context.Context, nodeIDs []string, opts CatOptions) (string, error) {
+	if opts.Edit || opts.StatsOnly {
+		return "", fmt.Errorf("--archived cannot be combined with --edit or --stats-only")
+	}
+	k, err := t.resolveKeg(ctx, opts.KegTargetOptions)
+	if err != nil {
+		return "", fmt.Errorf("unable to open keg: %w", err)
+	}
+
+	var buf strings.Builder
+	for i, raw := range nodeIDs {
+		node, err := parseNodeID(raw)
+		if err != nil {
+			return "", err
+		}
+		content, err := k.ReadArchivedContent(ctx, node)
+		if err != nil {
+			return "", err
+		}
+		meta, err := k.ReadArchivedMeta(ctx, node)
+		if err != nil {
+			return "", err
+		}
+
+		var out string
+		switch {
+		case len(nodeIDs) == 1 && opts.ContentOnly:
+			out = string(content)
+		case len(nodeIDs) == 1 && opts.MetaOnly:
+			out = string(meta)
+		case len(nodeIDs) == 1:
+			out = formatFrontmatter(meta, content)
+		case opts.ContentOnly:
+			out = formatContentWithID(node.Path(), content)
+		case opts.MetaOnly:
+			out = formatMetaWithID(node.Path(), meta)
+		default:
+			out = formatFrontmatterWithID(node.Path(), meta, content)
+		}
+		if i > 0 {
+			buf.WriteString("\n")
+		}
+		buf.WriteString(strings.TrimRight(out, "\n"))
+		buf.WriteString("\n")
+	}
+	return buf.String(), nil
+}
+
 func formatFrontmatter(meta []byte, content []byte) string {
 	metaText := strings.TrimRight(string(meta), "\n")
 	return fmt.Sprintf("---\n%s\n---\n%s", metaText, string(content))
@@ -212,28 +429,25 @@ func formatContentWithID(id string, content []byte) string {
 // stream output. It injects the node ID into every output mode so each
 // document is self-identifying.
 func (t *Tap) catSingleNodeForStream(ctx context.Context, k *keg.Keg, nodeID string, opts CatOptions) (string, error) {
-	node, err := keg.ParseNode(nodeID)
+	node, err := t.resolveNode(ctx, k, nodeID, opts.Exact)
 	if err != nil {
-		return "", fmt.Errorf("invalid node ID %q: %w", nodeID, err)
-	}
-	if node == nil {
-		return "", fmt.Errorf("invalid node ID %q: %w", nodeID, keg.ErrInvalid)
+		return "", err
 	}
 
-	content, err := k.Repo.ReadContent(ctx, *node)
+	content, err := k.GetContent(ctx, node)
 	if err != nil {
 		if errors.Is(err, keg.ErrNotExist) {
-			return "", fmt.Errorf("node %s not found", node.Path())
+			return "", keg.NewNodeNotFoundError(node)
 		}
 		return "", fmt.Errorf("unable to read node content: %w", err)
 	}
 
-	meta, err := k.Repo.ReadMeta(ctx, *node)
+	meta, err := k.Repo.ReadMeta(ctx, node)
 	if err != nil && !errors.Is(err, keg.ErrNotExist) {
 		return "", fmt.Errorf("unable to read node metadata: %w", err)
 	}
 
-	if err := k.Touch(ctx, *node); err != nil {
+	if err := k.Touch(ctx, node); err != nil {
 		return "", fmt.Errorf("unable to update node access: %w", err)
 	}
 
@@ -244,7 +458,7 @@ func (t *Tap) catSingleNodeForStream(ctx context.Context, k *keg.Keg, nodeID str
 	}
 
 	if opts.StatsOnly {
-		stats, readErr := k.Repo.ReadStats(ctx, *node)
+		stats, readErr := k.Repo.ReadStats(ctx, node)
 		if readErr != nil {
 			if errors.Is(readErr, keg.ErrNotExist) {
 				stats = &keg.NodeStats{}
@@ -0,0 +1,46 @@
+package tapper
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jlrickert/tapper/pkg/keg"
+)
+
+type CloneOptions struct {
+	KegTargetOptions
+
+	NodeID string
+
+	// TitleSuffix is appended to the clone's title.
+	TitleSuffix string
+
+	// Tags are added to the tags copied from the source node.
+	Tags []string
+}
+
+// Clone duplicates a node into a freshly allocated node and returns its ID.
+func (t *Tap) Clone(ctx context.Context, opts CloneOptions) (keg.NodeId, error) {
+	k, err := t.resolveKeg(ctx, opts.KegTargetOptions)
+	if err != nil {
+		return keg.NodeId{}, fmt.Errorf("unable to open keg: %w", err)
+	}
+
+	node, err := keg.ParseNode(opts.NodeID)
+	if err != nil {
+		return keg.NodeId{}, fmt.Errorf("invalid node ID %q: %w", opts.NodeID, err)
+	}
+	if node == nil {
+		return keg.NodeId{}, fmt.Errorf("invalid node ID %q: %w", opts.NodeID, keg.ErrInvalid)
+	}
+
+	src := keg.NodeId{ID: node.ID, Code: node.Code}
+	id, err := k.Clone(ctx, src, keg.CloneOptions{
+		TitleSuffix: opts.TitleSuffix,
+		Tags:        opts.Tags,
+	})
+	if err != nil {
+		return id, fmt.Errorf("unable to clone node %s: %w", src.Path(), err)
+	}
+	return id, nil
+}
@@ -0,0 +1,82 @@
+package tapper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/jlrickert/tapper/pkg/keg"
+)
+
+// ClustersOptions configures Tap.Clusters.
+type ClustersOptions struct {
+	KegTargetOptions
+
+	// Top caps how many nodes are listed per cluster. 0 lists every member.
+	Top int
+
+	// MinSize hides clusters with fewer members.
+	MinSize int
+}
+
+// Clusters lists the topic groupings recorded in dex/clusters.tsv by
+// `tap index rebuild --analyze`. Each cluster is printed as a header
+// followed by its most central members (by PageRank, then ID).
+func (t *Tap) Clusters(ctx context.Context, opts ClustersOptions) ([]string, error) {
+	k, err := t.resolveKeg(ctx, opts.KegTargetOptions)
+	if err != nil {
+		return []string{}, fmt.Errorf("unable to open keg: %w", err)
+	}
+	dex, err := k.Dex(ctx)
+	if err != nil {
+		return []string{}, fmt.Errorf("unable to read dex: %w", err)
+	}
+
+	raw, err := k.Repo.GetIndex(ctx, keg.ClustersIndexName)
+	if err != nil {
+		if errors.Is(err, keg.ErrNotExist) {
+			return []string{}, fmt.Errorf("no cluster index found; run `tap index rebuild --analyze` first: %w", err)
+		}
+		return []string{}, fmt.Errorf("unable to read %s: %w", keg.ClustersIndexName, err)
+	}
+	clusters, err := keg.ParseClusters(ctx, raw)
+	if err != nil {
+		return []string{}, fmt.Errorf("unable to parse %s: %w", keg.ClustersIndexName, err)
+	}
+
+	lines := make([]string, 0)
+	for _, c := range clusters {
+		if len(c.Members) < opts.MinSize {
+			continue
+		}
+		members := append([]keg.NodeId(nil), c.Members...)
+		score := make(map[string]float64, len(members))
+		for _, m := range members {
+			score[m.Path()], _ = dex.Rank(ctx, m)
+		}
+		sort.SliceStable(members, func(i, j int) bool {
+			return score[members[i].Path()] > score[members[j].Path()]
+		})
+		if opts.Top > 0 && len(members) > opts.Top {
+			members = members[:opts.Top]
+		}
+
+		if len(lines) > 0 {
+			lines = append(lines, "")
+		}
+		noun := "nodes"
+		if len(c.Members) == 1 {
+			noun = "node"
+		}
+		lines = append(lines, fmt.Sprintf("cluster %d (%d %s)", c.ID, len(c.Members), noun))
+		for _, m := range members {
+			title := ""
+			if ref := dex.GetRef(ctx, m); ref != nil {
+				title = ref.Title
+			}
+			lines = append(lines, fmt.Sprintf("  %s\t%s", m.Path(), title))
+		}
+	}
+	return lines, nil
+}
@@ -0,0 +1,87 @@
+package tapper
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jlrickert/tapper/pkg/keg"
+)
+
+type CommitOptions struct {
+	KegTargetOptions
+
+	// Drafts are draft codes ("4821") or full draft IDs ("12-4821").
+	Drafts []string
+}
+
+// CommitResult maps a promoted draft to its permanent node ID.
+type CommitResult struct {
+	Draft keg.NodeId
+	Node  keg.NodeId
+}
+
+// Commit promotes draft nodes to the next available numeric IDs.
+func (t *Tap) Commit(ctx context.Context, opts CommitOptions) ([]CommitResult, error) {
+	k, err := t.resolveKeg(ctx, opts.KegTargetOptions)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open keg: %w", err)
+	}
+	if len(opts.Drafts) == 0 {
+		return nil, fmt.Errorf("at least one draft code is required")
+	}
+	drafts, err := k.ListDrafts(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list drafts: %w", err)
+	}
+
+	results := make([]CommitResult, 0, len(opts.Drafts))
+	for _, raw := range opts.Drafts {
+		draft, err := matchDraft(drafts, raw)
+		if err != nil {
+			return results, err
+		}
+		node, err := k.Commit(ctx, draft)
+		if err != nil {
+			return results, fmt.Errorf("unable to commit draft %s: %w", draft.Path(), err)
+		}
+		results = append(results, CommitResult{Draft: draft, Node: node})
+	}
+	return results, nil
+}
+
+// Drafts returns "id\ttitle" lines for every uncommitted draft.
+func (t *Tap) Drafts(ctx context.Context, opts KegTargetOptions) ([]string, error) {
+	k, err := t.resolveKeg(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open keg: %w", err)
+	}
+	drafts, err := k.ListDrafts(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list drafts: %w", err)
+	}
+	lines := make([]string, 0, len(drafts))
+	format := k.ContentFormat(ctx)
+	for _, id := range drafts {
+		raw, err := k.Repo.ReadContent(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read draft %s: %w", id.Path(), err)
+		}
+		content, err := keg.ParseContent(k.Runtime, raw, format)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse draft %s: %w", id.Path(), err)
+		}
+		lines = append(lines, id.Path()+"\t"+content.Title)
+	}
+	return lines, nil
+}
+
+func matchDraft(drafts []keg.NodeId, raw string) (keg.NodeId, error) {
+	raw = strings.TrimSpace(raw)
+	for _, id := range drafts {
+		if id.Code == raw || id.Path() == raw {
+			return id, nil
+		}
+	}
+	return keg.NodeId{}, fmt.Errorf("draft %q not found: %w", raw, keg.ErrNotExist)
+}
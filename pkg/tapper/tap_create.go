@@ -18,6 +18,20 @@ type CreateOptions struct {
 	Tags   []string
 	Attrs  map[string]string
 	Stream *toolkit.Stream
+
+	// Body is the node content. {{.Date}}, {{.Title}}, {{.KegAlias}},
+	// {{.User}} and {{snippet "name"}} in it are expanded. A body is used
+	// instead of stdin.
+	Body string
+
+	// Draft creates a temporary node excluded from indexes until it is
+	// promoted with `tap commit`.
+	Draft bool
+
+	// KeepFrontmatter keeps YAML frontmatter from piped content in README.md,
+	// as the keg config's keepFrontmatter does, instead of moving it into
+	// meta.yaml.
+	KeepFrontmatter bool
 }
 
 func (t *Tap) Create(ctx context.Context, opts CreateOptions) (keg.NodeId, error) {
@@ -26,8 +40,41 @@ func (t *Tap) Create(ctx context.Context, opts CreateOptions) (keg.NodeId, error
 		return keg.NodeId{}, fmt.Errorf("unable to determine default keg: %w", err)
 	}
 
+	if opts.Body != "" {
+		node, err := k.Create(ctx, &keg.CreateOptions{
+			Title: opts.Title,
+			Lead:  opts.Lead,
+			Tags:  opts.Tags,
+			Attrs: createAttrsFromStrings(opts.Attrs),
+			Draft: opts.Draft,
+			Body:  []byte(opts.Body),
+			Vars:  t.createTemplateVars(k, opts),
+		})
+		if err != nil {
+			return keg.NodeId{}, fmt.Errorf("unable to create node: %w", err)
+		}
+		return node, nil
+	}
+
 	if opts.Stream != nil && opts.Stream.IsPiped {
 		b, _ := io.ReadAll(opts.Stream.In)
+		if opts.KeepFrontmatter || k.KeepFrontmatter(ctx) {
+			// Kept frontmatter is written with the content; Create syncs
+			// its tags and attrs into meta.yaml.
+			node, err := k.Create(ctx, &keg.CreateOptions{
+				Title:           opts.Title,
+				Lead:            opts.Lead,
+				Tags:            opts.Tags,
+				Attrs:           createAttrsFromStrings(opts.Attrs),
+				Draft:           opts.Draft,
+				Body:            b,
+				KeepFrontmatter: true,
+			})
+			if err != nil {
+				return keg.NodeId{}, fmt.Errorf("unable to create node: %w", err)
+			}
+			return node, nil
+		}
 		node, createErr := t.createNodeFromRaw(ctx, k, b, opts)
 		if createErr != nil {
 			return keg.NodeId{}, createErr
@@ -91,6 +138,8 @@ func (t *Tap) Create(ctx context.Context, opts CreateOptions) (keg.NodeId, error
 		Lead:  opts.Lead,
 		Tags:  opts.Tags,
 		Attrs: attrs,
+		Draft: opts.Draft,
+		Vars:  t.createTemplateVars(k, opts),
 	})
 	if err != nil {
 		return keg.NodeId{}, fmt.Errorf("unable to create node: %w", err)
@@ -98,6 +147,22 @@ func (t *Tap) Create(ctx context.Context, opts CreateOptions) (keg.NodeId, error
 	return node, nil
 }
 
+// createTemplateVars returns the template variables for a node created in
+// k: the keg's alias, the current user and the snippets from the tap config.
+func (t *Tap) createTemplateVars(k *keg.Keg, opts CreateOptions) *keg.TemplateVars {
+	cfg := t.ConfigService.Config(true)
+	alias := opts.Keg
+	if alias == "" && k.Target != nil {
+		alias = cfg.LookupAliasForTarget(t.Runtime, k.Target.String())
+	}
+	return &keg.TemplateVars{
+		Title:    opts.Title,
+		KegAlias: alias,
+		User:     t.lockHolder(""),
+		Snippets: cfg.Snippets(),
+	}
+}
+
 func createAttrsFromStrings(attrs map[string]string) map[string]any {
 	out := make(map[string]any, len(attrs))
 	for k, v := range attrs {
@@ -152,6 +217,7 @@ func (t *Tap) createNodeFromRaw(ctx context.Context, k *keg.Keg, raw []byte, def
 		Lead:  defaults.Lead,
 		Tags:  defaults.Tags,
 		Attrs: createAttrsFromStrings(defaults.Attrs),
+		Draft: defaults.Draft,
 	}
 
 	hasFrontmatter := false
@@ -0,0 +1,235 @@
+package tapper
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/jlrickert/cli-toolkit/toolkit"
+	"github.com/jlrickert/tapper/pkg/keg"
+)
+
+// Batch input formats accepted by CreateBatch.
+const (
+	BatchFormatJSONL = "jsonl"
+	BatchFormatCSV   = "csv"
+)
+
+type CreateBatchOptions struct {
+	KegTargetOptions
+
+	// File is the batch file to read. "-" reads from Stream.
+	File string
+
+	// Format is "jsonl" or "csv". When empty it is inferred from the file
+	// extension, defaulting to jsonl.
+	Format string
+
+	Stream *toolkit.Stream
+}
+
+// BatchRecord is a single node description in a batch file.
+type BatchRecord struct {
+	Title string         `json:"title"`
+	Lead  string         `json:"lead"`
+	Tags  []string       `json:"tags"`
+	Body  string         `json:"body"`
+	Attrs map[string]any `json:"attrs"`
+}
+
+// BatchResult maps a 1-based input row to the node created from it.
+type BatchResult struct {
+	Row  int
+	Node keg.NodeId
+}
+
+// CreateBatch creates one node per record in a JSONL or CSV file. Every
+// record is parsed before any node is created, and the dex is written once
+// at the end.
+func (t *Tap) CreateBatch(ctx context.Context, opts CreateBatchOptions) ([]BatchResult, error) {
+	k, err := t.resolveKeg(ctx, opts.KegTargetOptions)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open keg: %w", err)
+	}
+
+	raw, err := t.readBatchInput(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	format := opts.Format
+	if format == "" {
+		format = BatchFormatJSONL
+		if strings.EqualFold(filepath.Ext(opts.File), ".csv") {
+			format = BatchFormatCSV
+		}
+	}
+
+	var records []BatchRecord
+	switch strings.ToLower(format) {
+	case BatchFormatJSONL:
+		records, err = parseBatchJSONL(raw)
+	case BatchFormatCSV:
+		records, err = parseBatchCSV(raw)
+	default:
+		return nil, fmt.Errorf("unknown batch format %q (want jsonl or csv)", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("batch input contains no records")
+	}
+
+	createOpts := make([]*keg.CreateOptions, len(records))
+	for i, rec := range records {
+		createOpts[i] = rec.createOptions()
+	}
+
+	ids, err := k.CreateBatch(ctx, createOpts)
+	results := make([]BatchResult, len(ids))
+	for i, id := range ids {
+		results[i] = BatchResult{Row: i + 1, Node: id}
+	}
+	if err != nil {
+		return results, fmt.Errorf("unable to create nodes: %w", err)
+	}
+	return results, nil
+}
+
+func (t *Tap) readBatchInput(opts CreateBatchOptions) ([]byte, error) {
+	if opts.File == "" {
+		return nil, fmt.Errorf("batch file path is required")
+	}
+	if opts.File == "-" {
+		if opts.Stream == nil || opts.Stream.In == nil {
+			return nil, fmt.Errorf("no stdin available for batch input")
+		}
+		raw, err := io.ReadAll(opts.Stream.In)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read batch input: %w", err)
+		}
+		return raw, nil
+	}
+	raw, err := t.Runtime.ReadFile(opts.File)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read batch file %s: %w", opts.File, err)
+	}
+	return raw, nil
+}
+
+func (r BatchRecord) createOptions() *keg.CreateOptions {
+	opts := &keg.CreateOptions{
+		Title: strings.TrimSpace(r.Title),
+		Lead:  strings.TrimSpace(r.Lead),
+		Tags:  r.Tags,
+		Attrs: r.Attrs,
+	}
+	body := strings.TrimSpace(r.Body)
+	if body == "" {
+		return opts
+	}
+
+	// keg.Create ignores Title and Lead once a body is supplied, so fold
+	// them into the markdown unless the body already carries its own H1.
+	var b strings.Builder
+	if opts.Title != "" && !strings.HasPrefix(body, "# ") {
+		b.WriteString("# " + opts.Title + "\n\n")
+		if opts.Lead != "" {
+			b.WriteString(opts.Lead + "\n\n")
+		}
+	}
+	b.WriteString(body)
+	b.WriteString("\n")
+	opts.Body = []byte(b.String())
+	return opts
+}
+
+func parseBatchJSONL(raw []byte) ([]BatchRecord, error) {
+	var records []BatchRecord
+	scanner := bufio.NewScanner(bytes.NewReader(raw))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var rec BatchRecord
+		if err := json.Unmarshal([]byte(text), &rec); err != nil {
+			return nil, fmt.Errorf("invalid batch record on line %d: %w", line, err)
+		}
+		records = append(records, rec)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("unable to read batch input: %w", err)
+	}
+	return records, nil
+}
+
+// parseBatchCSV reads a CSV file whose header names the columns. The title,
+// lead, tags and body columns are recognized; tags are split on commas,
+// semicolons or whitespace, and every other non-empty column becomes an
+// attribute.
+func parseBatchCSV(raw []byte) ([]BatchRecord, error) {
+	reader := csv.NewReader(bytes.NewReader(raw))
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid batch csv header: %w", err)
+	}
+	for i := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(header[i]))
+	}
+
+	var records []BatchRecord
+	for {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid batch csv: %w", err)
+		}
+		var rec BatchRecord
+		for i, value := range row {
+			if i >= len(header) || header[i] == "" {
+				continue
+			}
+			switch header[i] {
+			case "title":
+				rec.Title = value
+			case "lead":
+				rec.Lead = value
+			case "body":
+				rec.Body = value
+			case "tags":
+				rec.Tags = strings.FieldsFunc(value, func(r rune) bool {
+					return r == ',' || r == ';' || unicode.IsSpace(r)
+				})
+			default:
+				if strings.TrimSpace(value) == "" {
+					continue
+				}
+				if rec.Attrs == nil {
+					rec.Attrs = map[string]any{}
+				}
+				rec.Attrs[header[i]] = value
+			}
+		}
+		records = append(records, rec)
+	}
+	return records, nil
+}
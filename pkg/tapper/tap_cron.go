@@ -0,0 +1,212 @@
+package tapper
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jlrickert/tapper/pkg/keg"
+)
+
+type CronRunOptions struct {
+	KegTargetOptions
+
+	// Date evaluates the rules as of this day (YYYY-MM-DD) instead of today.
+	Date string
+
+	// Rules limits the run to the named rules. Empty runs every rule.
+	Rules []string
+
+	// DryRun reports what would be created without writing anything.
+	DryRun bool
+}
+
+// CronResult reports the occurrence a recurring rule is due for.
+type CronResult struct {
+	Rule  string
+	Date  time.Time
+	Title string
+
+	// Node is the created or already existing node. It is zero when a dry
+	// run skipped creation.
+	Node keg.NodeId
+
+	// Created is set when this run created the node; Exists when the
+	// occurrence was already present.
+	Created bool
+	Exists  bool
+}
+
+// CronRun creates the current occurrence of each recurring node rule in the
+// keg config. Runs are idempotent: an occurrence whose date-stamped title
+// already exists in the dex is reported but not created again, so the
+// command is safe to call from cron or a systemd timer as often as needed.
+func (t *Tap) CronRun(ctx context.Context, opts CronRunOptions) ([]CronResult, error) {
+	k, err := t.resolveKeg(ctx, opts.KegTargetOptions)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open keg: %w", err)
+	}
+	cfg, err := k.Config(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read keg config: %w", err)
+	}
+
+	day := t.Runtime.Clock().Now()
+	if raw := strings.TrimSpace(opts.Date); raw != "" {
+		day, err = time.Parse(time.DateOnly, raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid date %q (want YYYY-MM-DD): %w", raw, keg.ErrInvalid)
+		}
+	}
+
+	rules := cfg.Recurring
+	if len(opts.Rules) > 0 {
+		rules = make([]keg.RecurringNode, 0, len(opts.Rules))
+		for _, name := range opts.Rules {
+			rule, ok := cfg.RecurringRule(name)
+			if !ok {
+				return nil, fmt.Errorf("recurring rule %q not found: %w", name, keg.ErrNotExist)
+			}
+			rules = append(rules, rule)
+		}
+	}
+
+	dex, err := k.Dex(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read dex: %w", err)
+	}
+	existing := map[string]keg.NodeId{}
+	for _, entry := range dex.Nodes(ctx) {
+		if id, err := keg.ParseNode(entry.ID); err == nil && id != nil {
+			existing[entry.Title] = keg.NodeId{ID: id.ID, Code: id.Code}
+		}
+	}
+
+	results := make([]CronResult, 0, len(rules))
+	for _, rule := range rules {
+		res, err := t.runRecurringRule(ctx, k, rule, day, existing, opts.DryRun)
+		if err != nil {
+			return results, fmt.Errorf("recurring rule %q: %w", rule.Name, err)
+		}
+		if res.Created {
+			existing[res.Title] = res.Node
+		}
+		results = append(results, res)
+	}
+	return results, nil
+}
+
+func (t *Tap) runRecurringRule(ctx context.Context, k *keg.Keg, rule keg.RecurringNode, day time.Time, existing map[string]keg.NodeId, dryRun bool) (CronResult, error) {
+	due, err := recurrenceDate(rule, day)
+	if err != nil {
+		return CronResult{}, err
+	}
+
+	var template *keg.NodeId
+	base := strings.TrimSpace(rule.Title)
+	if raw := strings.TrimSpace(rule.Template); raw != "" {
+		node, err := keg.ParseNode(raw)
+		if err != nil || node == nil {
+			return CronResult{}, fmt.Errorf("invalid template node ID %q: %w", raw, keg.ErrInvalid)
+		}
+		template = &keg.NodeId{ID: node.ID, Code: node.Code}
+		if base == "" {
+			stats, err := k.GetStats(ctx, *template)
+			if err != nil {
+				return CronResult{}, fmt.Errorf("unable to read template %s: %w", template.Path(), err)
+			}
+			base = stats.Title()
+		}
+	}
+	if base == "" {
+		return CronResult{}, fmt.Errorf("a title or template is required: %w", keg.ErrInvalid)
+	}
+
+	res := CronResult{Rule: rule.Name, Date: due, Title: recurrenceTitle(base, due)}
+	if id, ok := existing[res.Title]; ok {
+		res.Node = id
+		res.Exists = true
+		return res, nil
+	}
+	if dryRun {
+		return res, nil
+	}
+
+	if template != nil {
+		res.Node, err = k.Clone(ctx, *template, keg.CloneOptions{Title: res.Title, Tags: rule.Tags})
+	} else {
+		res.Node, err = k.Create(ctx, &keg.CreateOptions{Title: res.Title, Tags: rule.Tags})
+	}
+	if err != nil {
+		return res, fmt.Errorf("unable to create node: %w", err)
+	}
+	res.Created = true
+	return res, nil
+}
+
+// recurrenceTitle stamps title with the occurrence date, replacing "{date}"
+// or appending the date when the placeholder is absent.
+func recurrenceTitle(title string, due time.Time) string {
+	date := due.Format(time.DateOnly)
+	if strings.Contains(title, "{date}") {
+		return strings.ReplaceAll(title, "{date}", date)
+	}
+	return title + " " + date
+}
+
+// recurrenceDate returns the most recent occurrence of rule on or before day.
+func recurrenceDate(rule keg.RecurringNode, day time.Time) (time.Time, error) {
+	day = time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC)
+	dayArg := strings.ToLower(strings.TrimSpace(rule.Day))
+
+	switch strings.ToLower(strings.TrimSpace(rule.Every)) {
+	case keg.RecurDaily:
+		return day, nil
+	case keg.RecurWeekly:
+		want := time.Monday
+		if dayArg != "" {
+			idx := slices.IndexFunc(weekdays, func(w time.Weekday) bool {
+				name := strings.ToLower(w.String())
+				return name == dayArg || name[:3] == dayArg
+			})
+			if idx < 0 {
+				return time.Time{}, fmt.Errorf("invalid weekday %q: %w", rule.Day, keg.ErrInvalid)
+			}
+			want = weekdays[idx]
+		}
+		back := (int(day.Weekday()) - int(want) + 7) % 7
+		return day.AddDate(0, 0, -back), nil
+	case keg.RecurMonthly:
+		dom := 1
+		if dayArg != "" {
+			n, err := strconv.Atoi(dayArg)
+			if err != nil || n < 1 || n > 31 {
+				return time.Time{}, fmt.Errorf("invalid day of month %q: %w", rule.Day, keg.ErrInvalid)
+			}
+			dom = n
+		}
+		due := monthDay(day.Year(), day.Month(), dom)
+		if due.After(day) {
+			due = monthDay(day.Year(), day.Month()-1, dom)
+		}
+		return due, nil
+	default:
+		return time.Time{}, fmt.Errorf("invalid period %q (want daily, weekly or monthly): %w", rule.Every, keg.ErrInvalid)
+	}
+}
+
+var weekdays = []time.Weekday{
+	time.Sunday, time.Monday, time.Tuesday, time.Wednesday,
+	time.Thursday, time.Friday, time.Saturday,
+}
+
+// monthDay returns day dom of the given month, clamped to the month's last
+// day.
+func monthDay(year int, month time.Month, dom int) time.Time {
+	first := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+	last := first.AddDate(0, 1, -1).Day()
+	return first.AddDate(0, 0, min(dom, last)-1)
+}
@@ -0,0 +1,48 @@
+package tapper
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jlrickert/tapper/pkg/keg"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecurrenceDate(t *testing.T) {
+	t.Parallel()
+
+	// 2026-10-15 is a Thursday.
+	day := time.Date(2026, 10, 15, 18, 30, 0, 0, time.UTC)
+	cases := []struct {
+		name string
+		rule keg.RecurringNode
+		want string
+	}{
+		{"daily", keg.RecurringNode{Every: "daily"}, "2026-10-15"},
+		{"weekly default monday", keg.RecurringNode{Every: "weekly"}, "2026-10-12"},
+		{"weekly same day", keg.RecurringNode{Every: "weekly", Day: "Thursday"}, "2026-10-15"},
+		{"weekly short name", keg.RecurringNode{Every: "weekly", Day: "fri"}, "2026-10-09"},
+		{"monthly default first", keg.RecurringNode{Every: "monthly"}, "2026-10-01"},
+		{"monthly previous month", keg.RecurringNode{Every: "monthly", Day: "20"}, "2026-09-20"},
+		{"monthly clamps short month", keg.RecurringNode{Every: "monthly", Day: "31"}, "2026-09-30"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := recurrenceDate(tc.rule, day)
+			require.NoError(t, err)
+			require.Equal(t, tc.want, got.Format(time.DateOnly))
+		})
+	}
+
+	_, err := recurrenceDate(keg.RecurringNode{Every: "hourly"}, day)
+	require.ErrorIs(t, err, keg.ErrInvalid)
+	_, err = recurrenceDate(keg.RecurringNode{Every: "weekly", Day: "someday"}, day)
+	require.ErrorIs(t, err, keg.ErrInvalid)
+}
+
+func TestRecurrenceTitle(t *testing.T) {
+	t.Parallel()
+	due := time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC)
+	require.Equal(t, "Review 2026-10-12 notes", recurrenceTitle("Review {date} notes", due))
+	require.Equal(t, "Review 2026-10-12", recurrenceTitle("Review", due))
+}
@@ -52,7 +52,7 @@ func (t *Tap) Dir(ctx context.Context, opts DirOptions) (string, error) {
 			return "", fmt.Errorf("unable to check node existence: %w", err)
 		}
 		if !exists {
-			return "", fmt.Errorf("node %s not found", id.Path())
+			return "", keg.NewNodeNotFoundError(id)
 		}
 
 		return filepath.Join(kegDir, id.Path()), nil
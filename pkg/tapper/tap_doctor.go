@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
+	"strings"
 
 	"github.com/jlrickert/tapper/pkg/keg"
 )
@@ -40,6 +42,9 @@ func (t *Tap) Doctor(ctx context.Context, opts DoctorOptions) ([]Issue, error) {
 	} else if cfg.Kegv != keg.ConfigV1VersionString && cfg.Kegv != keg.ConfigV2VersionString {
 		issues = append(issues, Issue{Level: "warning", Kind: "config", Message: fmt.Sprintf("unrecognized kegv version %q", cfg.Kegv)})
 	}
+	if cfg.Format != "" && !slices.Contains(keg.Formats(), strings.ToLower(cfg.Format)) {
+		issues = append(issues, Issue{Level: "warning", Kind: "config", Message: fmt.Sprintf("unknown content format %q; nodes are parsed as markdown", cfg.Format)})
+	}
 
 	// 2. List all nodes and build existence set
 	nodeIDs, err := k.Repo.ListNodes(ctx)
@@ -83,6 +88,7 @@ func (t *Tap) Doctor(ctx context.Context, opts DoctorOptions) ([]Issue, error) {
 	}
 
 	// 4. Per-node checks
+	format := k.ContentFormat(ctx)
 	for _, id := range nodeIDs {
 		nodePath := id.Path()
 
@@ -96,8 +102,10 @@ func (t *Tap) Doctor(ctx context.Context, opts DoctorOptions) ([]Issue, error) {
 			}
 		} else if len(rawContent) == 0 {
 			issues = append(issues, Issue{Level: "warning", Kind: "content", NodeID: nodePath, Message: "content is empty"})
+		} else if keg.IsEncryptedContent(rawContent) {
+			// Encrypted content cannot be checked without the identity.
 		} else {
-			content, parseErr := keg.ParseContent(k.Runtime, rawContent, keg.MarkdownContentFilename)
+			content, parseErr := keg.ParseContent(k.Runtime, rawContent, format)
 			if parseErr != nil {
 				issues = append(issues, Issue{Level: "error", Kind: "content", NodeID: nodePath, Message: fmt.Sprintf("unable to parse content: %v", parseErr)})
 			} else {
@@ -155,5 +163,31 @@ func (t *Tap) Doctor(ctx context.Context, opts DoctorOptions) ([]Issue, error) {
 		}
 	}
 
+	// 5. Dex consistency. Load the dex straight from the repository so a
+	// corrupt index is reported rather than silently rebuilt.
+	if dex, dexErr := keg.NewDexFromRepo(ctx, k.Repo); dexErr != nil {
+		issues = append(issues, Issue{Level: "error", Kind: "dex", Message: fmt.Sprintf("unable to read dex: %v (rebuild with tap index rebuild)", dexErr)})
+	} else if report, verifyErr := dex.Verify(ctx, k.Repo); verifyErr != nil {
+		issues = append(issues, Issue{Level: "error", Kind: "dex", Message: fmt.Sprintf("unable to verify dex: %v", verifyErr)})
+	} else if report.Corrupt() {
+		issues = append(issues, Issue{Level: "error", Kind: "dex", Message: report.String() + " (rebuild with tap index rebuild)"})
+	} else if report.Indexed && !report.OK() {
+		issues = append(issues, Issue{Level: "warning", Kind: "dex", Message: report.String() + " (update with tap index)"})
+	}
+
+	// 6. Media referenced by no node content
+	unreferenced, err := k.UnreferencedMedia(ctx)
+	if err != nil {
+		issues = append(issues, Issue{Level: "error", Kind: "media", Message: fmt.Sprintf("unable to scan media: %v", err)})
+	}
+	for _, item := range unreferenced {
+		issues = append(issues, Issue{
+			Level:   "warning",
+			Kind:    "unreferenced-media",
+			NodeID:  item.Node.Path(),
+			Message: fmt.Sprintf("%s is not referenced by any node (remove with tap gc media --apply)", AttachmentPath(item.Attachment)),
+		})
+	}
+
 	return issues, nil
 }
@@ -0,0 +1,79 @@
+package tapper
+
+import (
+	"context"
+	"sync"
+
+	"github.com/jlrickert/tapper/pkg/keg"
+)
+
+type dryRunKey struct{}
+
+// DryRunRecorder rehearses operations against in-memory copies of the kegs
+// they resolve, so nothing is written. Attach one to a context with
+// WithDryRun and read the recorded changes once the operation returns.
+type DryRunRecorder struct {
+	mu   sync.Mutex
+	runs []dryRunKeg
+}
+
+type dryRunKeg struct {
+	original *keg.Keg
+	run      *keg.DryRun
+}
+
+// DryRunChange is a file change that a rehearsed operation would make.
+type DryRunChange struct {
+	// Keg identifies the keg by its target, for example its file URL.
+	Keg string
+	keg.Change
+}
+
+// WithDryRun returns a context under which every keg resolved by Tap
+// operations is replaced with an in-memory copy, together with the recorder
+// that collects their changes.
+func WithDryRun(ctx context.Context) (context.Context, *DryRunRecorder) {
+	rec := &DryRunRecorder{}
+	return context.WithValue(ctx, dryRunKey{}, rec), rec
+}
+
+func dryRunRecorderFrom(ctx context.Context) *DryRunRecorder {
+	rec, _ := ctx.Value(dryRunKey{}).(*DryRunRecorder)
+	return rec
+}
+
+// copyOf returns the dry-run copy of k, creating it on first use so repeated
+// resolutions within one operation share the same copy.
+func (r *DryRunRecorder) copyOf(ctx context.Context, k *keg.Keg) (*keg.Keg, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, run := range r.runs {
+		if run.original == k || kegsAreSame(run.original, k) {
+			return run.run.Keg, nil
+		}
+	}
+	run, err := keg.NewDryRun(ctx, k)
+	if err != nil {
+		return nil, err
+	}
+	r.runs = append(r.runs, dryRunKeg{original: k, run: run})
+	return run.Keg, nil
+}
+
+// Changes returns the recorded changes grouped by keg in the order the kegs
+// were first resolved.
+func (r *DryRunRecorder) Changes() []DryRunChange {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []DryRunChange
+	for _, run := range r.runs {
+		label := "memory"
+		if run.original.Target != nil {
+			label = run.original.Target.String()
+		}
+		for _, change := range run.run.Changes() {
+			out = append(out, DryRunChange{Keg: label, Change: change})
+		}
+	}
+	return out
+}
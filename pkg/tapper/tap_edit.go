@@ -14,22 +14,30 @@ import (
 )
 
 type EditOptions struct {
-	// NodeID is the node identifier to edit (e.g., "0", "42")
+	// NodeID is the node identifier to edit (e.g., "0", "42"). Non-numeric
+	// values are matched against node titles unless Exact is set.
 	NodeID string
 
 	KegTargetOptions
 
+	// Exact disables fuzzy title matching for non-numeric node arguments.
+	Exact bool
+
 	// Stream carries stdin piping information.
 	Stream *toolkit.Stream
 }
 
 // MetaOptions configures behavior for Tap.Meta.
 type MetaOptions struct {
-	// NodeID is the node identifier to inspect (e.g., "0", "42")
+	// NodeID is the node identifier to inspect (e.g., "0", "42"). Non-numeric
+	// values are matched against node titles unless Exact is set.
 	NodeID string
 
 	KegTargetOptions
 
+	// Exact disables fuzzy title matching for non-numeric node arguments.
+	Exact bool
+
 	// Edit opens metadata in the editor.
 	Edit bool
 
@@ -46,21 +54,16 @@ func (t *Tap) Meta(ctx context.Context, opts MetaOptions) (string, error) {
 		return "", fmt.Errorf("unable to open keg: %w", err)
 	}
 
-	node, err := keg.ParseNode(opts.NodeID)
+	id, err := t.resolveNode(ctx, k, opts.NodeID, opts.Exact)
 	if err != nil {
-		return "", fmt.Errorf("invalid node ID %q: %w", opts.NodeID, err)
+		return "", err
 	}
-	if node == nil {
-		return "", fmt.Errorf("invalid node ID %q: %w", opts.NodeID, keg.ErrInvalid)
-	}
-
-	id := keg.NodeId{ID: node.ID, Code: node.Code}
 	exists, err := k.Repo.HasNode(ctx, id)
 	if err != nil {
 		return "", fmt.Errorf("unable to inspect node: %w", err)
 	}
 	if !exists {
-		return "", fmt.Errorf("node %s not found", id.Path())
+		return "", keg.NewNodeNotFoundError(id)
 	}
 
 	if opts.Edit {
@@ -115,24 +118,20 @@ func (t *Tap) Edit(ctx context.Context, opts EditOptions) error {
 		return fmt.Errorf("unable to open keg: %w", err)
 	}
 
-	node, err := keg.ParseNode(opts.NodeID)
+	id, err := t.resolveNode(ctx, k, opts.NodeID, opts.Exact)
 	if err != nil {
-		return fmt.Errorf("invalid node ID %q: %w", opts.NodeID, err)
-	}
-	if node == nil {
-		return fmt.Errorf("invalid node ID %q: %w", opts.NodeID, keg.ErrInvalid)
+		return err
 	}
-
-	id := keg.NodeId{ID: node.ID, Code: node.Code}
 	exists, err := k.Repo.HasNode(ctx, id)
 	if err != nil {
 		return fmt.Errorf("unable to inspect node: %w", err)
 	}
 	if !exists {
-		return fmt.Errorf("node %s not found", id.Path())
+		return keg.NewNodeNotFoundError(id)
 	}
+	t.warnIfLocked(ctx, k, id)
 
-	content, err := k.Repo.ReadContent(ctx, id)
+	content, err := k.GetContent(ctx, id)
 	if err != nil {
 		return fmt.Errorf("unable to read node content: %w", err)
 	}
@@ -19,6 +19,13 @@ type UploadFileOptions struct {
 	NodeID   string
 	FilePath string
 	Name     string
+
+	// Data is the file content. When set, FilePath is not read and Name
+	// is required.
+	Data []byte
+
+	// SourceURL is recorded in the attachment metadata.
+	SourceURL string
 }
 
 // DownloadFileOptions configures behavior for Tap.DownloadFile.
@@ -36,6 +43,12 @@ type DeleteFileOptions struct {
 	Name   string
 }
 
+// FileGCOptions configures behavior for Tap.FileGC.
+type FileGCOptions struct {
+	KegTargetOptions
+	DryRun bool
+}
+
 // ListImagesOptions configures behavior for Tap.ListImages.
 type ListImagesOptions struct {
 	KegTargetOptions
@@ -48,6 +61,24 @@ type UploadImageOptions struct {
 	NodeID   string
 	FilePath string
 	Name     string
+
+	// Alt is alternative text recorded in the image metadata.
+	Alt string
+
+	// SourceURL is recorded in the image metadata.
+	SourceURL string
+
+	// Data is the image content. When set, FilePath is not read and Name
+	// is required.
+	Data []byte
+
+	// KeepMetadata stores the image as-is even when the keg strips image
+	// metadata on upload.
+	KeepMetadata bool
+
+	// NoConvert skips the format conversion configured under
+	// images.convert.
+	NoConvert bool
 }
 
 // DownloadImageOptions configures behavior for Tap.DownloadImage.
@@ -110,19 +141,34 @@ func (t *Tap) UploadFile(ctx context.Context, opts UploadFileOptions) (string, e
 		return "", fmt.Errorf("unable to inspect node: %w", err)
 	}
 	if !exists {
-		return "", fmt.Errorf("node %s not found", id.Path())
-	}
-	data, err := t.Runtime.ReadFile(opts.FilePath)
-	if err != nil {
-		return "", fmt.Errorf("unable to read local file %q: %w", opts.FilePath, err)
+		return "", keg.NewNodeNotFoundError(id)
 	}
+	data := opts.Data
 	name := opts.Name
+	if data == nil {
+		data, err = t.Runtime.ReadFile(opts.FilePath)
+		if err != nil {
+			return "", fmt.Errorf("unable to read local file %q: %w", opts.FilePath, err)
+		}
+		if name == "" {
+			name = keg.SanitizeAssetName(filepath.Base(opts.FilePath))
+		}
+	}
 	if name == "" {
-		name = filepath.Base(opts.FilePath)
+		return "", fmt.Errorf("file name is required: %w", keg.ErrInvalid)
+	}
+	if err := k.CheckAttachment(ctx, id, int64(len(data))); err != nil {
+		return "", err
+	}
+	if err := t.checkQuota(ctx, k, id, int64(len(data))); err != nil {
+		return "", err
 	}
 	if err := repoFiles.WriteFile(ctx, id, name, data); err != nil {
 		return "", fmt.Errorf("unable to upload file: %w", err)
 	}
+	if err := k.RecordItemMeta(ctx, id, keg.AssetKindItem, name, data, &keg.ItemMeta{SourceURL: opts.SourceURL}); err != nil {
+		return "", err
+	}
 	return name, nil
 }
 
@@ -187,6 +233,19 @@ func (t *Tap) DeleteFile(ctx context.Context, opts DeleteFileOptions) error {
 	return nil
 }
 
+// FileGC removes attachment blobs that no node references any more.
+func (t *Tap) FileGC(ctx context.Context, opts FileGCOptions) (*keg.BlobGCReport, error) {
+	k, err := t.resolveKeg(ctx, opts.KegTargetOptions)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open keg: %w", err)
+	}
+	report, err := k.CollectBlobs(ctx, opts.DryRun)
+	if err != nil {
+		return nil, fmt.Errorf("unable to collect blobs: %w", err)
+	}
+	return report, nil
+}
+
 // ListImages returns the names of images for a node.
 func (t *Tap) ListImages(ctx context.Context, opts ListImagesOptions) ([]string, error) {
 	k, err := t.resolveKeg(ctx, opts.KegTargetOptions)
@@ -232,19 +291,92 @@ func (t *Tap) UploadImage(ctx context.Context, opts UploadImageOptions) (string,
 		return "", fmt.Errorf("unable to inspect node: %w", err)
 	}
 	if !exists {
-		return "", fmt.Errorf("node %s not found", id.Path())
-	}
-	data, err := t.Runtime.ReadFile(opts.FilePath)
-	if err != nil {
-		return "", fmt.Errorf("unable to read local file %q: %w", opts.FilePath, err)
+		return "", keg.NewNodeNotFoundError(id)
 	}
+	data := opts.Data
 	name := opts.Name
+	if data == nil {
+		data, err = t.Runtime.ReadFile(opts.FilePath)
+		if err != nil {
+			return "", fmt.Errorf("unable to read local file %q: %w", opts.FilePath, err)
+		}
+		if name == "" {
+			name = keg.SanitizeAssetName(filepath.Base(opts.FilePath))
+		}
+	}
 	if name == "" {
-		name = filepath.Base(opts.FilePath)
+		return "", fmt.Errorf("image name is required: %w", keg.ErrInvalid)
+	}
+	cfg, err := k.Config(ctx)
+	if err != nil {
+		return "", fmt.Errorf("unable to read keg config: %w", err)
+	}
+	strip := func(data []byte) ([]byte, error) {
+		if opts.KeepMetadata || !cfg.StripImageMetadata() {
+			return data, nil
+		}
+		stripped, _, err := keg.StripImageMetadata(data)
+		if err != nil {
+			return nil, fmt.Errorf("unable to strip metadata from %q: %w", name, err)
+		}
+		return stripped, nil
+	}
+	if data, err = strip(data); err != nil {
+		return "", err
+	}
+
+	var original []byte
+	originalName := name
+	if convert := cfg.ImageConversion(); convert != nil && !opts.NoConvert {
+		if convert.Command != "" && !k.TrustsConfig(cfg) {
+			untrusted := *convert
+			untrusted.Command = ""
+			convert = &untrusted
+		}
+		ext, err := imageConversionTarget(convert, data)
+		if err != nil {
+			return "", err
+		}
+		if ext != "" {
+			converted, err := t.convertImage(ctx, convert, name, ext, data)
+			if err != nil {
+				return "", err
+			}
+			if convert.KeepOriginal {
+				original = data
+			}
+			name = replaceExt(name, ext)
+			if data, err = strip(converted); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	if err := k.CheckAttachment(ctx, id, int64(max(len(data), len(original)))); err != nil {
+		return "", err
+	}
+	if err := t.checkQuota(ctx, k, id, int64(len(data)+len(original))); err != nil {
+		return "", err
 	}
 	if err := repoImages.WriteImage(ctx, id, name, data); err != nil {
 		return "", fmt.Errorf("unable to upload image: %w", err)
 	}
+	if err := k.RecordItemMeta(ctx, id, keg.AssetKindImage, name, data, &keg.ItemMeta{Alt: opts.Alt, SourceURL: opts.SourceURL}); err != nil {
+		return "", err
+	}
+	if original != nil {
+		repoFiles, ok := k.Repo.(keg.RepositoryFiles)
+		if !ok {
+			t.warn(fmt.Sprintf("warning: keg backend does not support file attachments; original %s not kept", originalName))
+			return name, nil
+		}
+		if err := repoFiles.WriteFile(ctx, id, originalName, original); err != nil {
+			return "", fmt.Errorf("unable to keep original image: %w", err)
+		}
+		if err := k.RecordItemMeta(ctx, id, keg.AssetKindItem, originalName, original, &keg.ItemMeta{SourceURL: opts.SourceURL}); err != nil {
+			return "", err
+		}
+	}
 	return name, nil
 }
 
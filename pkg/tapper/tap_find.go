@@ -0,0 +1,127 @@
+package tapper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jlrickert/tapper/pkg/keg"
+)
+
+// FindOptions configures Tap.FindCandidates and Tap.FindPreview.
+type FindOptions struct {
+	KegTargetOptions
+}
+
+// FindCandidate is a node offered by the interactive finder.
+type FindCandidate struct {
+	ID    string
+	Title string
+	Lead  string
+	Tags  []string
+
+	// Score is the match score assigned by FilterFindCandidates. It is zero
+	// for unfiltered candidates.
+	Score int
+}
+
+// FindCandidates loads every indexed node together with its tags and lead so
+// the finder can filter without further I/O. Nodes whose metadata or stats
+// cannot be read are still returned with the fields available in the dex.
+func (t *Tap) FindCandidates(ctx context.Context, opts FindOptions) ([]FindCandidate, error) {
+	k, err := t.resolveKeg(ctx, opts.KegTargetOptions)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open keg: %w", err)
+	}
+	dex, err := k.Dex(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read dex: %w", err)
+	}
+
+	entries := dex.Nodes(ctx)
+	out := make([]FindCandidate, 0, len(entries))
+	for _, entry := range entries {
+		cand := FindCandidate{ID: entry.ID, Title: entry.Title}
+		if id, parseErr := keg.ParseNode(entry.ID); parseErr == nil && id != nil {
+			if meta, metaErr := k.GetMeta(ctx, *id); metaErr == nil && meta != nil {
+				cand.Tags = meta.Tags()
+			}
+			if stats, statsErr := k.GetStats(ctx, *id); statsErr == nil && stats != nil {
+				cand.Lead = stats.Lead()
+			}
+		}
+		out = append(out, cand)
+	}
+	return out, nil
+}
+
+// findPreviewBytes caps the content FindPreview reads, which is more than a
+// preview pane can show.
+const findPreviewBytes = 32 << 10
+
+// FindPreview returns the start of a node's raw markdown content for display
+// in the finder preview pane. Only the first findPreviewBytes are read, so
+// remote kegs need not send whole nodes. Unlike Cat it does not record an
+// access.
+func (t *Tap) FindPreview(ctx context.Context, opts FindOptions, nodeID string) (string, error) {
+	k, err := t.resolveKeg(ctx, opts.KegTargetOptions)
+	if err != nil {
+		return "", fmt.Errorf("unable to open keg: %w", err)
+	}
+	id, err := keg.ParseNode(nodeID)
+	if err != nil || id == nil {
+		if err == nil {
+			err = keg.ErrInvalid
+		}
+		return "", fmt.Errorf("invalid node ID %q: %w", nodeID, err)
+	}
+	raw, err := keg.ReadContentPrefix(ctx, k.Repo, *id, findPreviewBytes)
+	if err != nil {
+		if errors.Is(err, keg.ErrNotExist) {
+			return "", keg.NewNodeNotFoundError(*id)
+		}
+		return "", fmt.Errorf("unable to read node content: %w", err)
+	}
+	// Drop a character cut in half at the end of the prefix.
+	return strings.ToValidUTF8(string(raw), ""), nil
+}
+
+// FilterFindCandidates returns the candidates matching query ordered from
+// best to worst. Titles are weighted highest, then tags, then the lead. An
+// empty query returns every candidate in its original order.
+func FilterFindCandidates(cands []FindCandidate, query string) []FindCandidate {
+	q := normalizeMatchText(query)
+	if q == "" {
+		out := make([]FindCandidate, len(cands))
+		copy(out, cands)
+		return out
+	}
+
+	out := make([]FindCandidate, 0)
+	for _, cand := range cands {
+		score := scoreTitleMatch(normalizeMatchText(cand.Title), q)
+		for _, tag := range cand.Tags {
+			if s := scoreTitleMatch(normalizeMatchText(tag), q) / 2; s > score {
+				score = s
+			}
+		}
+		if score < 50 && strings.Contains(normalizeMatchText(cand.Lead), q) {
+			score = 50
+		}
+		if score <= 0 {
+			continue
+		}
+		cand.Score = score
+		out = append(out, cand)
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Score != out[j].Score {
+			return out[i].Score > out[j].Score
+		}
+		return compareNodeEntryID(out[i].ID, out[j].ID) < 0
+	})
+	return out
+}
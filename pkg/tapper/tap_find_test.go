@@ -0,0 +1,27 @@
+package tapper
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilterFindCandidates_MatchesTitleTagsAndLead(t *testing.T) {
+	t.Parallel()
+	cands := []FindCandidate{
+		{ID: "1", Title: "Weekly Review", Tags: []string{"golang"}},
+		{ID: "2", Title: "Golang Tips"},
+		{ID: "3", Title: "Shopping", Lead: "remember to buy golang stickers"},
+		{ID: "4", Title: "Unrelated"},
+	}
+
+	got := FilterFindCandidates(cands, "golang")
+	ids := make([]string, 0, len(got))
+	for _, c := range got {
+		ids = append(ids, c.ID)
+	}
+	require.Equal(t, []string{"2", "1", "3"}, ids, "title beats tag beats lead")
+
+	require.Len(t, FilterFindCandidates(cands, ""), len(cands))
+	require.Empty(t, FilterFindCandidates(cands, "zzz"))
+}
@@ -0,0 +1,88 @@
+package tapper
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jlrickert/tapper/pkg/keg"
+)
+
+// FmtOptions describes a `tap fmt` run.
+type FmtOptions struct {
+	KegTargetOptions
+
+	// NodeIDs are the nodes to format. Empty formats every node.
+	NodeIDs []string
+
+	// Exact disables fuzzy title matching for non-numeric node arguments.
+	Exact bool
+
+	// Check reports the nodes that are not formatted without changing them.
+	Check bool
+
+	// Width overrides the keg's fmt.width setting when non-nil.
+	Width *int
+}
+
+// Fmt normalizes the Markdown content of nodes (see formatMarkdown) and
+// returns the nodes it changed, or with Check the nodes it would change.
+// Nodes in another content format, such as RST, are skipped.
+func (t *Tap) Fmt(ctx context.Context, opts FmtOptions) ([]keg.NodeId, error) {
+	k, err := t.resolveKeg(ctx, opts.KegTargetOptions)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open keg: %w", err)
+	}
+
+	var ids []keg.NodeId
+	if len(opts.NodeIDs) == 0 {
+		ids, err = k.Repo.ListNodes(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("unable to list nodes: %w", err)
+		}
+	}
+	for _, arg := range opts.NodeIDs {
+		id, err := t.resolveNode(ctx, k, arg, opts.Exact)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	width := 0
+	if cfg, err := k.Config(ctx); err == nil && cfg != nil && cfg.Fmt != nil {
+		width = cfg.Fmt.Width
+	}
+	if opts.Width != nil {
+		width = *opts.Width
+	}
+	format := k.ContentFormat(ctx)
+
+	var changed []keg.NodeId
+	for _, id := range ids {
+		raw, err := k.GetContent(ctx, id)
+		if errors.Is(err, keg.ErrEncrypted) {
+			continue
+		}
+		if err != nil {
+			return changed, err
+		}
+		content, err := keg.ParseContent(t.Runtime, raw, format)
+		if err != nil || content.Format != keg.FormatMarkdown {
+			continue
+		}
+		formatted := formatMarkdown(raw, width)
+		if bytes.Equal(formatted, raw) {
+			continue
+		}
+		changed = append(changed, id)
+		if opts.Check {
+			continue
+		}
+		if err := k.SetContent(ctx, id, formatted); err != nil {
+			return changed, fmt.Errorf("unable to format node %s: %w", id.Path(), err)
+		}
+	}
+	return changed, nil
+}
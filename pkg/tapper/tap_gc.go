@@ -0,0 +1,36 @@
+package tapper
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jlrickert/tapper/pkg/keg"
+)
+
+// MediaGCOptions configures behavior for Tap.MediaGC.
+type MediaGCOptions struct {
+	KegTargetOptions
+
+	// Apply deletes the unreferenced media. Without it they are only
+	// reported.
+	Apply bool
+}
+
+// MediaGC finds images and file attachments that no node content links to
+// and, with Apply set, deletes them. It returns the unreferenced items.
+func (t *Tap) MediaGC(ctx context.Context, opts MediaGCOptions) ([]keg.MediaItem, error) {
+	k, err := t.resolveKeg(ctx, opts.KegTargetOptions)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open keg: %w", err)
+	}
+	items, err := k.UnreferencedMedia(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Apply && len(items) > 0 {
+		if err := k.RemoveMedia(ctx, items); err != nil {
+			return items, err
+		}
+	}
+	return items, nil
+}
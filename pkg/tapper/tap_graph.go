@@ -18,6 +18,30 @@ type GraphOptions struct {
 
 	// BundleJS is the compiled browser renderer injected into the generated page.
 	BundleJS []byte
+
+	// Format selects the output: "html" (default) renders the interactive
+	// page, "json" emits a nodes/edges document for external tooling.
+	Format string
+}
+
+// graphExport is the document produced by `tap graph --format json`.
+type graphExport struct {
+	Nodes []graphExportNode `json:"nodes"`
+	Edges []graphExportEdge `json:"edges"`
+}
+
+type graphExportNode struct {
+	ID    string   `json:"id"`
+	Title string   `json:"title"`
+	Tags  []string `json:"tags"`
+
+	// Degree counts distinct links into and out of the node.
+	Degree int `json:"degree"`
+}
+
+type graphExportEdge struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
 }
 
 type graphPayload struct {
@@ -56,6 +80,14 @@ func (t *Tap) Graph(ctx context.Context, opts GraphOptions) (string, error) {
 		return "", fmt.Errorf("unable to read dex: %w", err)
 	}
 
+	switch strings.ToLower(strings.TrimSpace(opts.Format)) {
+	case "", "html":
+	case "json":
+		return renderGraphJSON(ctx, dex)
+	default:
+		return "", fmt.Errorf("unknown graph format %q: %w", opts.Format, keg.ErrInvalid)
+	}
+
 	payload := buildGraphPayload(ctx, t.Runtime, k, dex)
 	bundle := opts.BundleJS
 	if len(strings.TrimSpace(string(bundle))) == 0 {
@@ -147,6 +179,69 @@ func buildGraphPayload(ctx context.Context, rt *toolkit.Runtime, k *keg.Keg, dex
 	return payload
 }
 
+// renderGraphJSON exports the link graph as an indented JSON document. Only
+// forward links between indexed nodes are emitted as edges; backlinks are the
+// same edges reversed.
+func renderGraphJSON(ctx context.Context, dex *keg.Dex) (string, error) {
+	doc := graphExport{Nodes: []graphExportNode{}, Edges: []graphExportEdge{}}
+	tagsByNode := graphTagsByNode(ctx, dex)
+	degree := map[string]int{}
+	nodeByID := map[string]graphExportNode{}
+	for _, entry := range dex.Nodes(ctx) {
+		id := strings.TrimSpace(entry.ID)
+		if id == "" {
+			continue
+		}
+		tags := tagsByNode[id]
+		if tags == nil {
+			tags = []string{}
+		}
+		nodeByID[id] = graphExportNode{ID: id, Title: strings.TrimSpace(entry.Title), Tags: tags}
+	}
+
+	seen := map[string]struct{}{}
+	for _, node := range nodeByID {
+		src, err := keg.ParseNode(node.ID)
+		if err != nil || src == nil {
+			continue
+		}
+		links, _ := dex.Links(ctx, *src)
+		for _, dst := range links {
+			edge := graphExportEdge{Source: src.Path(), Target: dst.Path()}
+			key := edge.Source + "\x00" + edge.Target
+			if _, ok := seen[key]; ok || edge.Source == edge.Target {
+				continue
+			}
+			if _, ok := nodeByID[edge.Target]; !ok {
+				// Skip dangling links so every edge resolves to a node.
+				continue
+			}
+			seen[key] = struct{}{}
+			doc.Edges = append(doc.Edges, edge)
+			degree[edge.Source]++
+			degree[edge.Target]++
+		}
+	}
+
+	for id, node := range nodeByID {
+		node.Degree = degree[id]
+		doc.Nodes = append(doc.Nodes, node)
+	}
+	sort.Slice(doc.Nodes, func(i, j int) bool { return compareNodePath(doc.Nodes[i].ID, doc.Nodes[j].ID) < 0 })
+	sort.Slice(doc.Edges, func(i, j int) bool {
+		if c := compareNodePath(doc.Edges[i].Source, doc.Edges[j].Source); c != 0 {
+			return c < 0
+		}
+		return compareNodePath(doc.Edges[i].Target, doc.Edges[j].Target) < 0
+	})
+
+	out, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("unable to marshal graph: %w", err)
+	}
+	return string(out) + "\n", nil
+}
+
 func addEdgeAndNode(payload *graphPayload, seen map[string]struct{}, nodeByID map[string]graphNode, edge graphEdge) {
 	if payload == nil {
 		return
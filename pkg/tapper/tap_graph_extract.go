@@ -0,0 +1,157 @@
+package tapper
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/jlrickert/tapper/pkg/keg"
+	kegurl "github.com/jlrickert/tapper/pkg/keg_url"
+)
+
+// GraphExtractOptions configures Tap.GraphExtract.
+type GraphExtractOptions struct {
+	// KegTargetOptions selects the source keg.
+	KegTargetOptions
+
+	// Target is the new keg receiving the extracted subgraph. An alias that
+	// is not configured yet is created as a user keg and registered; a Path
+	// without a keg is initialized there. A target that already exists must
+	// hold no nodes besides node 0.
+	Target KegTargetOptions
+
+	// Seed selects the seed node. Non-numeric values are matched against node
+	// titles unless Exact is set.
+	Seed string
+
+	// Depth is how many link hops (in either direction) around Seed to
+	// include. Zero extracts only the seed.
+	Depth int
+
+	// Filter is a boolean query expression (same syntax as `tap tags EXPR`)
+	// selecting nodes to extract. Combined with Seed as a union.
+	Filter string
+
+	// Exact disables fuzzy title matching for the seed argument.
+	Exact bool
+}
+
+// GraphExtract copies a subgraph of the source keg into a new Target keg. The subgraph
+// is the seed's neighborhood up to Depth hops, the nodes matching Filter, or
+// both. Copied nodes receive fresh IDs and their links are rewritten the same
+// way as ImportFromKeg: links inside the subgraph stay relative and links
+// leaving it become keg:SOURCE/N references. Node 0 is never extracted.
+func (t *Tap) GraphExtract(ctx context.Context, opts GraphExtractOptions) ([]ImportedNode, error) {
+	seed := strings.TrimSpace(opts.Seed)
+	filter := strings.TrimSpace(opts.Filter)
+	if seed == "" && filter == "" {
+		return nil, fmt.Errorf("a seed node or filter expression is required: %w", keg.ErrInvalid)
+	}
+	if opts.Depth < 0 {
+		return nil, fmt.Errorf("depth must not be negative: %w", keg.ErrInvalid)
+	}
+
+	k, err := t.resolveKeg(ctx, opts.KegTargetOptions)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open keg: %w", err)
+	}
+	dex, err := k.Dex(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read dex: %w", err)
+	}
+
+	var selected []keg.NodeId
+	if seed != "" {
+		id, err := t.resolveNode(ctx, k, seed, opts.Exact)
+		if err != nil {
+			return nil, err
+		}
+		exists, err := k.Repo.HasNode(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("unable to inspect node: %w", err)
+		}
+		if !exists {
+			return nil, keg.NewNodeNotFoundError(id)
+		}
+		selected = append(selected, id)
+		if opts.Depth > 0 {
+			selected = append(selected, dex.Neighbors(ctx, id, opts.Depth)...)
+		}
+	}
+	if filter != "" {
+		matched, err := collectImportNodesByTag(ctx, k, filter)
+		if err != nil {
+			return nil, err
+		}
+		selected = unionImportNodeIDs(selected, matched)
+	}
+	selected = filterZeroImportNode(selected)
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("no nodes matched for extraction: %w", keg.ErrNotExist)
+	}
+	slices.SortFunc(selected, func(a, b keg.NodeId) int { return a.Compare(b) })
+
+	if err := t.prepareExtractTarget(ctx, opts.Target); err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(selected))
+	for _, id := range selected {
+		ids = append(ids, id.Path())
+	}
+	return t.ImportFromKeg(ctx, ImportFromKegOptions{
+		Source:       opts.KegTargetOptions,
+		Target:       opts.Target,
+		NodeIDs:      ids,
+		SkipZeroNode: true,
+	})
+}
+
+// prepareExtractTarget creates the keg named by target when it does not
+// exist yet and refuses a target that already holds nodes.
+func (t *Tap) prepareExtractTarget(ctx context.Context, target KegTargetOptions) error {
+	alias := strings.TrimSpace(target.Keg)
+	path := strings.TrimSpace(target.Path)
+	switch {
+	case path != "" && alias != "":
+		return fmt.Errorf("an extract target takes an alias or a path, not both: %w", keg.ErrInvalid)
+	case path != "":
+		resolved, err := t.Runtime.ResolvePath(path, false)
+		if err != nil {
+			return fmt.Errorf("unable to resolve target path %q: %w", path, err)
+		}
+		dst, err := t.KegService.newKeg(ctx, kegurl.NewFile(resolved))
+		if err != nil {
+			return fmt.Errorf("unable to open target keg: %w", err)
+		}
+		exists, err := keg.RepoContainsKeg(ctx, dst.Repo)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			_, err := t.initProjectKeg(ctx, initLocalOptions{Path: resolved})
+			return err
+		}
+	case alias != "":
+		if _, ok := t.ConfigService.Config(true).Kegs()[alias]; !ok {
+			_, err := t.initUserKeg(ctx, InitOptions{Keg: alias, User: true})
+			return err
+		}
+	default:
+		return fmt.Errorf("a target keg is required: %w", keg.ErrInvalid)
+	}
+
+	dst, err := t.resolveKeg(ctx, target)
+	if err != nil {
+		return fmt.Errorf("unable to open target keg: %w", err)
+	}
+	ids, err := dst.Repo.ListNodes(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to list target nodes: %w", err)
+	}
+	if len(filterZeroImportNode(ids)) > 0 {
+		return fmt.Errorf("target keg already holds nodes; extract into a new keg: %w", keg.ErrExist)
+	}
+	return nil
+}
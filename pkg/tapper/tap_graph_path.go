@@ -0,0 +1,77 @@
+package tapper
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jlrickert/tapper/pkg/keg"
+)
+
+// GraphPathOptions configures Tap.GraphPath.
+type GraphPathOptions struct {
+	KegTargetOptions
+
+	// From and To are the endpoints. Non-numeric values are matched against
+	// node titles unless Exact is set.
+	From string
+	To   string
+
+	// Exact disables fuzzy title matching for non-numeric node arguments.
+	Exact bool
+
+	// Format to use. %i is node id
+	// %d is date
+	// %t is node title
+	// %% for literal %
+	Format string
+
+	IdOnly bool
+}
+
+// GraphPath returns the shortest chain of nodes connecting From to To,
+// following links in either direction, one rendered node per line.
+func (t *Tap) GraphPath(ctx context.Context, opts GraphPathOptions) ([]string, error) {
+	k, err := t.resolveKeg(ctx, opts.KegTargetOptions)
+	if err != nil {
+		return []string{}, fmt.Errorf("unable to open keg: %w", err)
+	}
+	dex, err := k.Dex(ctx)
+	if err != nil {
+		return []string{}, fmt.Errorf("unable to read dex: %w", err)
+	}
+
+	ends := make([]keg.NodeId, 0, 2)
+	for _, arg := range []string{opts.From, opts.To} {
+		id, err := t.resolveNode(ctx, k, arg, opts.Exact)
+		if err != nil {
+			return []string{}, err
+		}
+		exists, err := k.Repo.HasNode(ctx, id)
+		if err != nil {
+			return []string{}, fmt.Errorf("unable to inspect node: %w", err)
+		}
+		if !exists {
+			return []string{}, keg.NewNodeNotFoundError(id)
+		}
+		ends = append(ends, id)
+	}
+
+	path, ok := dex.ShortestPath(ctx, ends[0], ends[1])
+	if !ok {
+		return []string{}, fmt.Errorf("no path between %s and %s: %w", ends[0].Path(), ends[1].Path(), keg.ErrNotExist)
+	}
+
+	entries := make([]keg.NodeIndexEntry, 0, len(path))
+	for _, id := range path {
+		entry := keg.NodeIndexEntry{ID: id.Path()}
+		if ref := dex.GetRef(ctx, id); ref != nil {
+			entry = *ref
+		}
+		entries = append(entries, entry)
+	}
+	format := opts.Format
+	if format == "" {
+		format = "%i\t%t"
+	}
+	return formatNodeEntries(ctx, k, entries, format, opts.IdOnly, false)
+}
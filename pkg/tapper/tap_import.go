@@ -46,7 +46,7 @@ var kegLinkInTextRE = regexp.MustCompile(`keg:([a-zA-Z0-9][a-zA-Z0-9_-]*)/([0-9]
 var relImportLinkRE = regexp.MustCompile(`\.\./\s*([0-9]+)([[:space:]\)\]\}\>\.,;:!?'"#]|$)`)
 
 // ImportFromKeg copies nodes from a source keg into the target keg. Each node
-// is assigned a fresh ID via targetRepo.Next() and all links in the copied
+// is assigned a fresh ID via the target keg's Next and all links in the copied
 // content are rewritten according to the six rules described in the plan.
 func (t *Tap) ImportFromKeg(ctx context.Context, opts ImportFromKegOptions) ([]ImportedNode, error) {
 	// Extract the source alias from any keg:ALIAS/N positional args and
@@ -100,22 +100,38 @@ func (t *Tap) ImportFromKeg(ctx context.Context, opts ImportFromKegOptions) ([]I
 	}
 	slices.SortFunc(srcIDs, func(a, b keg.NodeId) int { return a.Compare(b) })
 
+	// Hold the target keg lock so a concurrent index or import cannot
+	// allocate the same IDs or interleave dex writes.
+	var result []ImportedNode
+	err = tgtKeg.WithKegLock(ctx, func(lockCtx context.Context) error {
+		var err error
+		result, err = t.importNodes(lockCtx, srcKeg, tgtKeg, tgtAlias, srcIDs, opts)
+		return err
+	})
+	return result, err
+}
+
+// importNodes copies srcIDs into tgtKeg while the target keg lock is held.
+func (t *Tap) importNodes(ctx context.Context, srcKeg, tgtKeg *keg.Keg, tgtAlias string, srcIDs []keg.NodeId, opts ImportFromKegOptions) ([]ImportedNode, error) {
+	srcAlias := opts.Source.Keg
+
 	// Pass 1: allocate target IDs. Build the full mapping before writing anything.
-	// Next() scans existing nodes without reserving, so call it once and
-	// compute subsequent IDs by incrementing from the base.
+	// Each id is reserved through the target keg's allocator so imports follow
+	// the same numbering scheme as created nodes.
 	mapping := make(map[string]keg.NodeId, len(srcIDs)) // srcID numeric string → newID
-	if len(srcIDs) > 0 {
-		baseID, err := tgtKeg.Repo.Next(ctx)
+	for _, srcID := range srcIDs {
+		newID, err := tgtKeg.Next(ctx)
 		if err != nil {
 			return nil, fmt.Errorf("unable to allocate node ID for import: %w", err)
 		}
-		for i, srcID := range srcIDs {
-			mapping[srcID.Path()] = keg.NodeId{ID: baseID.ID + i}
-		}
+		mapping[srcID.Path()] = newID
 	}
 
 	// Pass 2: rewrite links and write each node to the target.
 	for _, srcID := range srcIDs {
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("import interrupted before node %s: %w", srcID.Path(), err)
+		}
 		newID := mapping[srcID.Path()]
 
 		content, err := srcKeg.Repo.ReadContent(ctx, srcID)
@@ -16,6 +16,10 @@ type IndexOptions struct {
 
 	// NoUpdate skips updating node meta information
 	NoUpdate bool
+
+	// Analyze also runs graph analysis (cluster detection into
+	// dex/clusters.tsv).
+	Analyze bool
 }
 
 type IndexCatOptions struct {
@@ -62,6 +66,7 @@ func (t *Tap) Index(ctx context.Context, opts IndexOptions) (string, error) {
 	err = k.Index(ctx, keg.IndexOptions{
 		Rebuild:  opts.Rebuild,
 		NoUpdate: opts.NoUpdate,
+		Analyze:  opts.Analyze,
 	})
 	if err != nil {
 		return "", fmt.Errorf("unable to rebuild indices: %w", err)
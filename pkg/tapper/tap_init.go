@@ -131,7 +131,7 @@ type initLocalOptions struct {
 // creator/title metadata is applied to the generated keg config.
 func (t *Tap) initProjectKeg(ctx context.Context, opts initLocalOptions) (*kegurl.Target, error) {
 	target := kegurl.NewFile(opts.Path)
-	k, err := keg.NewKegFromTarget(ctx, target, t.Runtime)
+	k, err := t.KegService.newKeg(ctx, target)
 	if err != nil {
 		return nil, fmt.Errorf("unable to init keg: %w", err)
 	}
@@ -156,7 +156,7 @@ func (t *Tap) initUserKeg(ctx context.Context, opts InitOptions) (*kegurl.Target
 	kegPath := filepath.Join(repoPath, opts.Keg)
 
 	target := kegurl.NewFile(kegPath)
-	k, err := keg.NewKegFromTarget(ctx, target, t.Runtime)
+	k, err := t.KegService.newKeg(ctx, target)
 	if err != nil {
 		return nil, fmt.Errorf("unable to init keg: %w", err)
 	}
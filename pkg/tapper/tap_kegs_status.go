@@ -0,0 +1,146 @@
+package tapper
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jlrickert/tapper/pkg/keg"
+)
+
+// KegStatus is the health summary of one configured keg.
+type KegStatus struct {
+	Alias  string
+	Target string
+
+	// Reachable reports whether the keg target could be opened.
+	Reachable bool
+
+	// Initialized reports whether the target holds a keg config and zero node.
+	Initialized bool
+
+	Nodes int
+
+	// Updated is the latest node update time.
+	Updated time.Time
+
+	// IndexedAt is when the dex was last written. Stale counts nodes changed
+	// since then, missing from the dex, or still in the dex after removal.
+	IndexedAt time.Time
+	Stale     int
+
+	// Drafts counts uncommitted draft nodes waiting for `commit`.
+	Drafts int
+
+	// Error describes why the keg is unreachable or could not be inspected.
+	Error string
+}
+
+// Fresh reports whether the dex reflects every node in the keg.
+func (s KegStatus) Fresh() bool {
+	return s.Initialized && s.Stale == 0
+}
+
+// KegsStatus reports the health of every keg alias in the user config, in
+// alias order. A keg that cannot be opened or read is reported with Error
+// set instead of failing the whole overview.
+func (t *Tap) KegsStatus(ctx context.Context) ([]KegStatus, error) {
+	cfg := t.ConfigService.Config(true)
+	aliases := cfg.ListKegs()
+	targets := cfg.Kegs()
+
+	statuses := make([]KegStatus, 0, len(aliases))
+	for _, alias := range aliases {
+		if err := ctx.Err(); err != nil {
+			return statuses, err
+		}
+		status := KegStatus{Alias: alias}
+		if target, ok := targets[alias]; ok {
+			status.Target = target.String()
+			if target.Redirect != "" {
+				status.Target = "-> " + target.Redirect
+			}
+		}
+		k, err := t.KegService.Resolve(ctx, ResolveKegOptions{Root: t.Root, Keg: alias})
+		if err != nil {
+			status.Error = err.Error()
+			statuses = append(statuses, status)
+			continue
+		}
+		if fsRepo, ok := keg.UnwrapRepo(k.Repo).(*keg.FsRepo); ok {
+			if _, err := t.Runtime.Stat(fsRepo.Root, false); err != nil {
+				status.Error = fmt.Sprintf("keg directory %s is not reachable: %v", fsRepo.Root, err)
+				statuses = append(statuses, status)
+				continue
+			}
+		}
+		if err := kegStatus(ctx, k, &status); err != nil {
+			status.Error = err.Error()
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// kegStatus fills status from k. It reads the dex from the repository as is
+// so a damaged index shows up as stale rather than being rebuilt.
+func kegStatus(ctx context.Context, k *keg.Keg, status *KegStatus) error {
+	initialized, err := keg.RepoContainsKeg(ctx, k.Repo)
+	if err != nil {
+		return err
+	}
+	status.Reachable = true
+	status.Initialized = initialized
+	if !initialized {
+		return nil
+	}
+
+	ids, err := k.Repo.ListNodes(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to list nodes: %w", err)
+	}
+	status.Nodes = len(ids)
+
+	indexedAt, err := k.IndexedAt(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to read index time: %w", err)
+	}
+	status.IndexedAt = indexedAt
+
+	dex, err := keg.NewDexFromRepo(ctx, k.Repo)
+	if err != nil {
+		return fmt.Errorf("unable to read dex: %w", err)
+	}
+	indexed := make(map[string]struct{})
+	for _, entry := range dex.Nodes(ctx) {
+		indexed[entry.ID] = struct{}{}
+	}
+
+	for _, id := range ids {
+		if id.Code != "" {
+			status.Drafts++
+		}
+		_, inDex := indexed[id.Path()]
+		delete(indexed, id.Path())
+
+		updated := nodeUpdated(ctx, k, id)
+		if updated.After(status.Updated) {
+			status.Updated = updated
+		}
+		if !inDex || updated.IsZero() || indexedAt.IsZero() || updated.After(indexedAt) {
+			status.Stale++
+		}
+	}
+	status.Stale += len(indexed)
+	return nil
+}
+
+// nodeUpdated returns the update time recorded in the stats of id, or zero
+// when the stats are missing or unreadable so the node counts as stale.
+func nodeUpdated(ctx context.Context, k *keg.Keg, id keg.NodeId) time.Time {
+	stats, err := k.Repo.ReadStats(ctx, id)
+	if err != nil {
+		return time.Time{}
+	}
+	return stats.Updated()
+}
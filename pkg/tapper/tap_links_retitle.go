@@ -0,0 +1,69 @@
+package tapper
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jlrickert/tapper/pkg/keg"
+)
+
+// RetitleLinksOptions describes a `tap links retitle` run.
+type RetitleLinksOptions struct {
+	KegTargetOptions
+
+	// NodeIDs are the nodes whose links are retitled. Ignored with All.
+	NodeIDs []string
+
+	// All retitles links in every node of the keg.
+	All bool
+
+	// Exact disables fuzzy title matching for non-numeric node arguments.
+	Exact bool
+}
+
+// RetitledNode reports the bare links retitled in one node.
+type RetitledNode struct {
+	Node     keg.NodeId
+	Rewrites int
+}
+
+// RetitleLinks gives bare node links, such as [](../42) or [../42](../42),
+// the current title of their target from the dex. Only nodes with links
+// rewritten are reported.
+func (t *Tap) RetitleLinks(ctx context.Context, opts RetitleLinksOptions) ([]RetitledNode, error) {
+	k, err := t.resolveKeg(ctx, opts.KegTargetOptions)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open keg: %w", err)
+	}
+
+	var ids []keg.NodeId
+	switch {
+	case opts.All:
+		ids, err = k.Repo.ListNodes(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("unable to list nodes: %w", err)
+		}
+	case len(opts.NodeIDs) == 0:
+		return nil, fmt.Errorf("a node id or --all is required: %w", keg.ErrInvalid)
+	default:
+		for _, arg := range opts.NodeIDs {
+			id, err := t.resolveNode(ctx, k, arg, opts.Exact)
+			if err != nil {
+				return nil, err
+			}
+			ids = append(ids, id)
+		}
+	}
+
+	var out []RetitledNode
+	for _, id := range ids {
+		n, err := k.RetitleLinks(ctx, id)
+		if err != nil {
+			return out, fmt.Errorf("unable to retitle links in node %s: %w", id.Path(), err)
+		}
+		if n > 0 {
+			out = append(out, RetitledNode{Node: id, Rewrites: n})
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,170 @@
+package tapper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jlrickert/tapper/pkg/keg"
+	"github.com/jlrickert/tapper/pkg/keg/unfurl"
+)
+
+// UnfurlOptions describes a `tap links unfurl` run.
+type UnfurlOptions struct {
+	KegTargetOptions
+
+	// NodeIDs limits the run to these nodes. Empty means every node, in
+	// which case previews of URLs no longer linked are dropped.
+	NodeIDs []string
+
+	// Force fetches every URL again, ignoring the TTL.
+	Force bool
+
+	// Exact disables fuzzy title matching for non-numeric node arguments.
+	Exact bool
+}
+
+// UnfurlResult reports a `tap links unfurl` run.
+type UnfurlResult struct {
+	// URLs is the number of distinct external URLs found.
+	URLs int
+	unfurl.RefreshResult
+	// Pruned is the number of cached previews dropped.
+	Pruned int
+}
+
+// LinkPreviewsOptions selects the node whose link previews are listed.
+type LinkPreviewsOptions struct {
+	KegTargetOptions
+	NodeID string
+	Exact  bool
+}
+
+// Unfurl fetches previews of the external URLs in node content into the
+// keg's dex/unfurl.jsonl cache. Previews younger than the configured TTL are
+// kept. Encrypted nodes are skipped so their links never leave the machine.
+func (t *Tap) Unfurl(ctx context.Context, opts UnfurlOptions) (*UnfurlResult, error) {
+	k, err := t.resolveKeg(ctx, opts.KegTargetOptions)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open keg: %w", err)
+	}
+	ttl, err := unfurlTTL(ctx, k)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []keg.NodeId
+	if len(opts.NodeIDs) == 0 {
+		ids, err = k.Repo.ListNodes(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("unable to list nodes: %w", err)
+		}
+	}
+	for _, arg := range opts.NodeIDs {
+		id, err := t.resolveNode(ctx, k, arg, opts.Exact)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	urls, err := t.externalURLs(ctx, k, ids)
+	if err != nil {
+		return nil, err
+	}
+	cache, err := unfurl.LoadCache(ctx, k.Repo)
+	if err != nil {
+		return nil, err
+	}
+	res, err := cache.Refresh(ctx, &unfurl.Fetcher{}, urls, t.Runtime.Clock().Now().UTC(), ttl, opts.Force)
+	out := &UnfurlResult{URLs: len(urls), RefreshResult: res}
+	if err == nil && len(opts.NodeIDs) == 0 {
+		out.Pruned = cache.Prune(urls)
+	}
+	// Save what was fetched even when interrupted.
+	if saveErr := cache.Save(ctx, k.Repo); saveErr != nil {
+		return out, saveErr
+	}
+	return out, err
+}
+
+// LinkPreviews returns the cached previews of the external URLs in a node,
+// in the order they appear. URLs not unfurled yet have only their URL set.
+// No network requests are made.
+func (t *Tap) LinkPreviews(ctx context.Context, opts LinkPreviewsOptions) ([]unfurl.Preview, error) {
+	k, err := t.resolveKeg(ctx, opts.KegTargetOptions)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open keg: %w", err)
+	}
+	id, err := t.resolveNode(ctx, k, opts.NodeID, opts.Exact)
+	if err != nil {
+		return nil, err
+	}
+	content, err := k.GetContent(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read node %s content: %w", id.Path(), err)
+	}
+	cache, err := unfurl.LoadCache(ctx, k.Repo)
+	if err != nil {
+		return nil, err
+	}
+	urls := unfurl.ExternalURLs(content)
+	out := make([]unfurl.Preview, 0, len(urls))
+	for _, u := range urls {
+		p, ok := cache.Get(u)
+		if !ok {
+			p = unfurl.Preview{URL: u}
+		}
+		out = append(out, p)
+	}
+	return out, nil
+}
+
+// unfurlTTL returns the preview TTL from the keg config, or ErrNotSupported
+// when the keg has no unfurl section.
+func unfurlTTL(ctx context.Context, k *keg.Keg) (time.Duration, error) {
+	cfg, err := k.Config(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("unable to read keg config: %w", err)
+	}
+	if cfg == nil || cfg.Unfurl == nil {
+		return 0, fmt.Errorf("link unfurling is not enabled; add an unfurl section to the keg config: %w", keg.ErrNotSupported)
+	}
+	raw := strings.TrimSpace(cfg.Unfurl.TTL)
+	if raw == "" {
+		return unfurl.DefaultTTL, nil
+	}
+	ttl, err := time.ParseDuration(raw)
+	if err != nil || ttl <= 0 {
+		return 0, keg.NewInvalidConfigError(fmt.Sprintf("unfurl.ttl %q is not a positive duration", raw))
+	}
+	return ttl, nil
+}
+
+// externalURLs collects the distinct external URLs linked from ids.
+// Encrypted content is skipped.
+func (t *Tap) externalURLs(ctx context.Context, k *keg.Keg, ids []keg.NodeId) ([]string, error) {
+	var urls []string
+	seen := map[string]struct{}{}
+	for _, id := range ids {
+		content, err := k.Repo.ReadContent(ctx, id)
+		if err != nil {
+			if errors.Is(err, keg.ErrNotExist) {
+				continue
+			}
+			return nil, fmt.Errorf("unable to read node %s content: %w", id.Path(), err)
+		}
+		if keg.IsEncryptedContent(content) {
+			continue
+		}
+		for _, u := range unfurl.ExternalURLs(content) {
+			if _, ok := seen[u]; !ok {
+				seen[u] = struct{}{}
+				urls = append(urls, u)
+			}
+		}
+	}
+	return urls, nil
+}
@@ -0,0 +1,211 @@
+package tapper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/jlrickert/tapper/pkg/keg"
+)
+
+// LintOptions describes a `tap lint` run.
+type LintOptions struct {
+	KegTargetOptions
+
+	// NodeIDs are the nodes to lint. Empty lints every node.
+	NodeIDs []string
+
+	// Query limits the linted nodes to those matching a boolean tag
+	// expression.
+	Query string
+
+	// Exact disables fuzzy title matching for non-numeric node arguments.
+	Exact bool
+
+	// Prose runs the prose rules: the built-in PassiveVoice and
+	// SentenceLength rules and the rule files from the keg config.
+	Prose bool
+
+	// Rules are more Vale-style rule files or directories to run. Relative
+	// paths are resolved against the working directory.
+	Rules []string
+}
+
+// LintFinding is a problem found by `tap lint`.
+type LintFinding struct {
+	Node keg.NodeId
+
+	// Line and Column are 1-based positions in the node content.
+	Line   int
+	Column int
+
+	Rule    string
+	Level   string
+	Message string
+
+	// Match is the text the rule matched, if any.
+	Match string
+}
+
+// Lint checks node content and returns its findings ordered by node and
+// position. Nodes that are not Markdown are skipped.
+func (t *Tap) Lint(ctx context.Context, opts LintOptions) ([]LintFinding, error) {
+	if !opts.Prose {
+		return nil, fmt.Errorf("nothing to lint; choose a check such as --prose: %w", keg.ErrInvalid)
+	}
+	k, err := t.resolveKeg(ctx, opts.KegTargetOptions)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open keg: %w", err)
+	}
+	ids, err := t.lintNodes(ctx, k, opts)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := t.proseRules(ctx, k, opts.Rules)
+	if err != nil {
+		return nil, err
+	}
+
+	format := k.ContentFormat(ctx)
+	var findings []LintFinding
+	for _, id := range ids {
+		raw, err := k.GetContent(ctx, id)
+		if errors.Is(err, keg.ErrEncrypted) {
+			continue
+		}
+		if err != nil {
+			return findings, err
+		}
+		content, err := keg.ParseContent(t.Runtime, raw, format)
+		if err != nil || content.Format != keg.FormatMarkdown {
+			continue
+		}
+		for _, f := range lintProse(raw, rules) {
+			f.Node = id
+			findings = append(findings, f)
+		}
+	}
+	return findings, nil
+}
+
+// lintNodes resolves the nodes a lint run covers.
+func (t *Tap) lintNodes(ctx context.Context, k *keg.Keg, opts LintOptions) ([]keg.NodeId, error) {
+	var ids []keg.NodeId
+	for _, arg := range opts.NodeIDs {
+		id, err := t.resolveNode(ctx, k, arg, opts.Exact)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	q := strings.TrimSpace(opts.Query)
+	if q == "" {
+		if len(ids) > 0 {
+			return ids, nil
+		}
+		all, err := k.Repo.ListNodes(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("unable to list nodes: %w", err)
+		}
+		return all, nil
+	}
+
+	dex, err := k.Dex(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read dex: %w", err)
+	}
+	entries, err := filterQueryExpr(ctx, k, dex, dex.Nodes(ctx), q)
+	if err != nil {
+		return nil, fmt.Errorf("invalid query expression: %w", err)
+	}
+	var matched []keg.NodeId
+	for _, e := range entries {
+		id, err := keg.ParseNode(e.ID)
+		if err != nil || id == nil {
+			continue
+		}
+		if len(ids) == 0 || slices.ContainsFunc(ids, id.Equals) {
+			matched = append(matched, *id)
+		}
+	}
+	return matched, nil
+}
+
+// proseRules returns the built-in prose rules and those loaded from the
+// keg config and extra, less the rules the keg config disables.
+func (t *Tap) proseRules(ctx context.Context, k *keg.Keg, extra []string) ([]proseRule, error) {
+	var cfg keg.ProseLintConfig
+	if kc, err := k.Config(ctx); err == nil && kc != nil && kc.Lint != nil && kc.Lint.Prose != nil {
+		cfg = *kc.Lint.Prose
+	}
+
+	var paths []string
+	for _, raw := range cfg.Rules {
+		path, err := expandArchivePath(t.Runtime, raw)
+		if err != nil {
+			return nil, err
+		}
+		if fsRepo, ok := keg.UnwrapRepo(k.Repo).(*keg.FsRepo); ok && !filepath.IsAbs(path) {
+			path = filepath.Join(fsRepo.Root, path)
+		}
+		paths = append(paths, path)
+	}
+	for _, raw := range extra {
+		path, err := expandArchivePath(t.Runtime, raw)
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+
+	rules := builtinProseRules(cfg.MaxSentenceWords)
+	for _, path := range paths {
+		files, err := t.ruleFiles(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			data, err := t.Runtime.ReadFile(file)
+			if err != nil {
+				return nil, fmt.Errorf("unable to read rule file %s: %w", file, err)
+			}
+			rule, err := parseValeRule(ruleName(file), data)
+			if err != nil {
+				return nil, err
+			}
+			rules = append(rules, rule)
+		}
+	}
+	return slices.DeleteFunc(rules, func(r proseRule) bool {
+		return slices.Contains(cfg.Disable, r.name)
+	}), nil
+}
+
+// ruleFiles lists the YAML rule files at path, which is a rule file or a
+// directory of them.
+func (t *Tap) ruleFiles(path string) ([]string, error) {
+	info, err := t.Runtime.Stat(path, false)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read rules %s: %w", path, err)
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+	entries, err := t.Runtime.ReadDir(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read rules %s: %w", path, err)
+	}
+	var files []string
+	for _, e := range entries {
+		ext := filepath.Ext(e.Name())
+		if !e.IsDir() && (ext == ".yml" || ext == ".yaml") {
+			files = append(files, filepath.Join(path, e.Name()))
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
@@ -5,6 +5,8 @@ import (
 	"errors"
 	"fmt"
 	"regexp"
+	"slices"
+	"sort"
 	"strings"
 	"time"
 
@@ -20,21 +22,33 @@ const (
 	SortByUpdated  ListSortType = "updated"  // ascending by last-updated timestamp
 	SortByCreated  ListSortType = "created"  // ascending by creation timestamp
 	SortByAccessed ListSortType = "accessed" // ascending by last-accessed timestamp
+	SortByRank     ListSortType = "rank"     // ascending by link-based PageRank score
 )
 
 type ListOptions struct {
 	KegTargetOptions
+	DateRangeOptions
 
 	// Query is an optional boolean expression that filters nodes. Supports both
 	// plain tag names ("golang") and key=value attribute predicates
 	// ("entity=plan"). When empty, all nodes are listed.
 	Query string
 
+	// Where is an optional structured metadata filter such as
+	// `created > 2025-01-01 and tags has go and attrs.status = active`. It is
+	// applied after Query.
+	Where string
+
+	// Saved names a saved search from the keg config whose tag expression
+	// and where filter are applied before Query and Where.
+	Saved string
+
 	// Format to use. %i is node id, %d
 	// %i is node id
 	// %d is date
 	// %t is node title
 	// %% for literal %
+	// A format containing {{ is a Go template over NodeTemplateData.
 	Format string
 
 	IdOnly bool
@@ -46,6 +60,10 @@ type ListOptions struct {
 
 	// Limit caps the number of results returned. 0 means no limit.
 	Limit int
+
+	// AllKegs lists every configured keg instead of the resolved one. Node
+	// IDs are emitted as alias:id.
+	AllKegs bool
 }
 
 type BacklinksOptions struct {
@@ -54,6 +72,9 @@ type BacklinksOptions struct {
 	// NodeID is the target node to inspect incoming links for.
 	NodeID string
 
+	// Exact disables fuzzy title matching for non-numeric node arguments.
+	Exact bool
+
 	// Format to use. %i is node id
 	// %d is date
 	// %t is node title
@@ -71,6 +92,9 @@ type LinksOptions struct {
 	// NodeID is the source node to inspect outgoing links for.
 	NodeID string
 
+	// Exact disables fuzzy title matching for non-numeric node arguments.
+	Exact bool
+
 	// Format to use. %i is node id
 	// %d is date
 	// %t is node title
@@ -84,6 +108,7 @@ type LinksOptions struct {
 
 type GrepOptions struct {
 	KegTargetOptions
+	DateRangeOptions
 
 	// Query is the regex pattern used to search nodes.
 	Query string
@@ -100,11 +125,20 @@ type GrepOptions struct {
 
 	// IgnoreCase enables case-insensitive regex matching.
 	IgnoreCase bool
+
+	// Rank orders results by relevance instead of node ID. Relevance blends
+	// term frequency, update recency, and access count using the keg's
+	// search weights. Ranking also applies automatically once the number of
+	// hits reaches the keg's search.rankThreshold.
+	Rank bool
 }
 
 type TagsOptions struct {
 	KegTargetOptions
 
+	// DateRangeOptions applies when listing nodes for an expression.
+	DateRangeOptions
+
 	// Query is an optional boolean expression that filters nodes. Supports both
 	// plain tag names ("golang") and key=value attribute predicates
 	// ("entity=plan"). When non-empty it takes precedence over Tag.
@@ -128,16 +162,39 @@ type TagsOptions struct {
 type grepMatch struct {
 	entry keg.NodeIndexEntry
 	lines []string
+	hits  int
 }
 
 func (t *Tap) List(ctx context.Context, opts ListOptions) ([]string, error) {
+	if opts.AllKegs {
+		return t.listAllKegs(ctx, opts)
+	}
+	entries, err := t.ListEntries(ctx, opts)
+	if err != nil {
+		return []string{}, err
+	}
+	var k *keg.Keg
+	if isNodeTemplate(opts.Format) {
+		if k, err = t.resolveKeg(ctx, opts.KegTargetOptions); err != nil {
+			return []string{}, fmt.Errorf("unable to open keg: %w", err)
+		}
+	}
+	return formatNodeEntries(ctx, k, entries, opts.Format, opts.IdOnly, false)
+}
+
+// ListEntries returns the index entries selected by opts in display order.
+// Format and IdOnly are ignored.
+func (t *Tap) ListEntries(ctx context.Context, opts ListOptions) ([]keg.NodeIndexEntry, error) {
+	if opts.AllKegs {
+		return t.listEntriesAllKegs(ctx, opts)
+	}
 	k, err := t.resolveKeg(ctx, opts.KegTargetOptions)
 	if err != nil {
-		return []string{}, fmt.Errorf("unable to open keg: %w", err)
+		return nil, fmt.Errorf("unable to open keg: %w", err)
 	}
 	dex, err := k.Dex(ctx)
 	if err != nil {
-		return []string{}, fmt.Errorf("unable to read dex: %w", err)
+		return nil, fmt.Errorf("unable to read dex: %w", err)
 	}
 
 	entries := dex.Nodes(ctx)
@@ -161,31 +218,46 @@ func (t *Tap) List(ctx context.Context, opts ListOptions) ([]string, error) {
 		}
 	}
 
-	if q := strings.TrimSpace(opts.Query); q != "" {
-		matchedIDs, evalErr := evalQueryExpr(ctx, k, dex, entries, q)
-		if evalErr != nil {
-			return []string{}, fmt.Errorf("invalid query expression: %w", evalErr)
+	if name := strings.TrimSpace(opts.Saved); name != "" {
+		cfg, cfgErr := k.Config(ctx)
+		if cfgErr != nil {
+			return nil, fmt.Errorf("unable to read keg config: %w", cfgErr)
+		}
+		search, ok := cfg.SavedSearch(name)
+		if !ok {
+			return nil, fmt.Errorf("saved search %q not found: %w", name, keg.ErrNotExist)
 		}
-		filtered := make([]keg.NodeIndexEntry, 0, len(matchedIDs))
-		entryByID := make(map[string]keg.NodeIndexEntry, len(entries)*2)
-		for _, e := range entries {
-			entryByID[e.ID] = e
-			id, parseErr := keg.ParseNode(e.ID)
-			if parseErr == nil && id != nil {
-				entryByID[id.Path()] = e
+		if q := strings.TrimSpace(search.Tags); q != "" {
+			entries, err = filterQueryExpr(ctx, k, dex, entries, q)
+			if err != nil {
+				return nil, fmt.Errorf("invalid query expression in saved search %q: %w", name, err)
 			}
 		}
-		seen := make(map[string]struct{})
-		for nodeID := range matchedIDs {
-			if e, ok := entryByID[nodeID]; ok {
-				if _, dup := seen[e.ID]; !dup {
-					seen[e.ID] = struct{}{}
-					filtered = append(filtered, e)
-				}
+		if w := strings.TrimSpace(search.Where); w != "" {
+			entries, err = filterWhereExpr(ctx, k, entries, w)
+			if err != nil {
+				return nil, fmt.Errorf("invalid where expression in saved search %q: %w", name, err)
 			}
 		}
-		sortNodeIndexEntries(filtered)
-		entries = filtered
+	}
+
+	if q := strings.TrimSpace(opts.Query); q != "" {
+		entries, err = filterQueryExpr(ctx, k, dex, entries, q)
+		if err != nil {
+			return nil, fmt.Errorf("invalid query expression: %w", err)
+		}
+	}
+
+	if w := strings.TrimSpace(opts.Where); w != "" {
+		entries, err = filterWhereExpr(ctx, k, entries, w)
+		if err != nil {
+			return nil, fmt.Errorf("invalid where expression: %w", err)
+		}
+	}
+
+	entries, err = filterDateRange(entries, opts.DateRangeOptions, t.Runtime.Clock().Now())
+	if err != nil {
+		return nil, err
 	}
 
 	switch opts.Sort {
@@ -197,15 +269,20 @@ func (t *Tap) List(ctx context.Context, opts ListOptions) ([]string, error) {
 		sortNodeIndexEntriesByTime(entries, func(e keg.NodeIndexEntry) time.Time { return e.Created })
 	case SortByAccessed:
 		sortNodeIndexEntriesByTime(entries, func(e keg.NodeIndexEntry) time.Time { return e.Accessed })
+	case SortByRank:
+		sortNodeIndexEntriesByRank(ctx, dex, entries)
 	default:
-		return []string{}, fmt.Errorf("unknown sort type: %q", opts.Sort)
+		return nil, fmt.Errorf("unknown sort type: %q", opts.Sort)
 	}
 
 	if opts.Limit > 0 && len(entries) > opts.Limit {
 		entries = entries[len(entries)-opts.Limit:]
 	}
 
-	return renderNodeEntries(entries, opts.Format, opts.IdOnly, opts.Reverse), nil
+	if opts.Reverse {
+		slices.Reverse(entries)
+	}
+	return entries, nil
 }
 
 func (t *Tap) Backlinks(ctx context.Context, opts BacklinksOptions) ([]string, error) {
@@ -218,21 +295,17 @@ func (t *Tap) Backlinks(ctx context.Context, opts BacklinksOptions) ([]string, e
 		return []string{}, fmt.Errorf("unable to read dex: %w", err)
 	}
 
-	node, err := keg.ParseNode(opts.NodeID)
+	id, err := t.resolveNode(ctx, k, opts.NodeID, opts.Exact)
 	if err != nil {
-		return []string{}, fmt.Errorf("invalid node ID %q: %w", opts.NodeID, err)
-	}
-	if node == nil {
-		return []string{}, fmt.Errorf("invalid node ID %q: %w", opts.NodeID, keg.ErrInvalid)
+		return []string{}, err
 	}
-	id := keg.NodeId{ID: node.ID, Code: node.Code}
 
 	exists, err := k.Repo.HasNode(ctx, id)
 	if err != nil {
 		return []string{}, fmt.Errorf("unable to inspect node: %w", err)
 	}
 	if !exists {
-		return []string{}, fmt.Errorf("node %s not found", id.Path())
+		return []string{}, keg.NewNodeNotFoundError(id)
 	}
 
 	backlinks, ok := dex.Backlinks(ctx, id)
@@ -250,39 +323,51 @@ func (t *Tap) Backlinks(ctx context.Context, opts BacklinksOptions) ([]string, e
 		entries = append(entries, keg.NodeIndexEntry{ID: source.Path()})
 	}
 	sortNodeIndexEntries(entries)
-	return renderNodeEntries(entries, opts.Format, opts.IdOnly, opts.Reverse), nil
+	return formatNodeEntries(ctx, k, entries, opts.Format, opts.IdOnly, opts.Reverse)
 }
 
 func (t *Tap) Links(ctx context.Context, opts LinksOptions) ([]string, error) {
+	entries, err := t.LinkEntries(ctx, opts)
+	if err != nil {
+		return []string{}, err
+	}
+	var k *keg.Keg
+	if isNodeTemplate(opts.Format) {
+		if k, err = t.resolveKeg(ctx, opts.KegTargetOptions); err != nil {
+			return []string{}, fmt.Errorf("unable to open keg: %w", err)
+		}
+	}
+	return formatNodeEntries(ctx, k, entries, opts.Format, opts.IdOnly, false)
+}
+
+// LinkEntries returns index entries for the nodes opts.NodeID links to, in
+// display order. Format and IdOnly are ignored.
+func (t *Tap) LinkEntries(ctx context.Context, opts LinksOptions) ([]keg.NodeIndexEntry, error) {
 	k, err := t.resolveKeg(ctx, opts.KegTargetOptions)
 	if err != nil {
-		return []string{}, fmt.Errorf("unable to open keg: %w", err)
+		return nil, fmt.Errorf("unable to open keg: %w", err)
 	}
 	dex, err := k.Dex(ctx)
 	if err != nil {
-		return []string{}, fmt.Errorf("unable to read dex: %w", err)
+		return nil, fmt.Errorf("unable to read dex: %w", err)
 	}
 
-	node, err := keg.ParseNode(opts.NodeID)
+	id, err := t.resolveNode(ctx, k, opts.NodeID, opts.Exact)
 	if err != nil {
-		return []string{}, fmt.Errorf("invalid node ID %q: %w", opts.NodeID, err)
-	}
-	if node == nil {
-		return []string{}, fmt.Errorf("invalid node ID %q: %w", opts.NodeID, keg.ErrInvalid)
+		return nil, err
 	}
-	id := keg.NodeId{ID: node.ID, Code: node.Code}
 
 	exists, err := k.Repo.HasNode(ctx, id)
 	if err != nil {
-		return []string{}, fmt.Errorf("unable to inspect node: %w", err)
+		return nil, fmt.Errorf("unable to inspect node: %w", err)
 	}
 	if !exists {
-		return []string{}, fmt.Errorf("node %s not found", id.Path())
+		return nil, keg.NewNodeNotFoundError(id)
 	}
 
 	links, ok := dex.Links(ctx, id)
 	if !ok || len(links) == 0 {
-		return []string{}, nil
+		return []keg.NodeIndexEntry{}, nil
 	}
 
 	entries := make([]keg.NodeIndexEntry, 0, len(links))
@@ -295,7 +380,10 @@ func (t *Tap) Links(ctx context.Context, opts LinksOptions) ([]string, error) {
 		entries = append(entries, keg.NodeIndexEntry{ID: target.Path()})
 	}
 	sortNodeIndexEntries(entries)
-	return renderNodeEntries(entries, opts.Format, opts.IdOnly, opts.Reverse), nil
+	if opts.Reverse {
+		slices.Reverse(entries)
+	}
+	return entries, nil
 }
 
 func (t *Tap) Grep(ctx context.Context, opts GrepOptions) ([]string, error) {
@@ -303,9 +391,27 @@ func (t *Tap) Grep(ctx context.Context, opts GrepOptions) ([]string, error) {
 	if err != nil {
 		return []string{}, fmt.Errorf("unable to open keg: %w", err)
 	}
+	matches, err := t.grepMatches(ctx, k, opts)
+	if err != nil {
+		return []string{}, err
+	}
+
+	matchedEntries := make([]keg.NodeIndexEntry, 0, len(matches))
+	for _, match := range matches {
+		matchedEntries = append(matchedEntries, match.entry)
+	}
+	if opts.IdOnly || opts.Format != "" {
+		return formatNodeEntries(ctx, k, matchedEntries, opts.Format, opts.IdOnly, opts.Reverse)
+	}
+	return renderGrepMatches(matches, opts.Reverse), nil
+}
+
+// grepMatches returns the nodes of k whose content matches opts.Query, ranked
+// when requested or when the keg's rank threshold is reached.
+func (t *Tap) grepMatches(ctx context.Context, k *keg.Keg, opts GrepOptions) ([]grepMatch, error) {
 	dex, err := k.Dex(ctx)
 	if err != nil {
-		return []string{}, fmt.Errorf("unable to read dex: %w", err)
+		return nil, fmt.Errorf("unable to read dex: %w", err)
 	}
 
 	pattern := opts.Query
@@ -314,10 +420,13 @@ func (t *Tap) Grep(ctx context.Context, opts GrepOptions) ([]string, error) {
 	}
 	re, err := regexp.Compile(pattern)
 	if err != nil {
-		return []string{}, fmt.Errorf("invalid query regex %q: %w", opts.Query, err)
+		return nil, fmt.Errorf("invalid query regex %q: %w", opts.Query, err)
 	}
 
-	entries := dex.Nodes(ctx)
+	entries, err := filterDateRange(dex.Nodes(ctx), opts.DateRangeOptions, t.Runtime.Clock().Now())
+	if err != nil {
+		return nil, err
+	}
 	matches := make([]grepMatch, 0)
 	for _, entry := range entries {
 		id, parseErr := keg.ParseNode(entry.ID)
@@ -330,25 +439,29 @@ func (t *Tap) Grep(ctx context.Context, opts GrepOptions) ([]string, error) {
 			if errors.Is(contentErr, keg.ErrNotExist) {
 				continue
 			}
-			return []string{}, fmt.Errorf("unable to read node content: %w", contentErr)
+			return nil, fmt.Errorf("unable to read node content: %w", contentErr)
 		}
 		lineMatches := grepContentLineMatches(re, contentRaw)
 		if len(lineMatches) > 0 {
 			matches = append(matches, grepMatch{
 				entry: entry,
 				lines: lineMatches,
+				hits:  len(re.FindAllIndex(contentRaw, -1)),
 			})
 		}
 	}
 
-	matchedEntries := make([]keg.NodeIndexEntry, 0, len(matches))
-	for _, match := range matches {
-		matchedEntries = append(matchedEntries, match.entry)
-	}
-	if opts.IdOnly || opts.Format != "" {
-		return renderNodeEntries(matchedEntries, opts.Format, opts.IdOnly, opts.Reverse), nil
+	if len(matches) > 1 {
+		var search *keg.SearchConfig
+		if cfg, cfgErr := k.Config(ctx); cfgErr == nil && cfg != nil {
+			search = cfg.Search
+		}
+		autoRank := search != nil && search.RankThreshold > 0 && len(matches) >= search.RankThreshold
+		if opts.Rank || autoRank {
+			rankGrepMatches(ctx, k, matches, search.RankWeights(), t.Runtime.Clock().Now())
+		}
 	}
-	return renderGrepMatches(matches, opts.Reverse), nil
+	return matches, nil
 }
 
 func (t *Tap) Tags(ctx context.Context, opts TagsOptions) ([]string, error) {
@@ -421,8 +534,12 @@ func (t *Tap) Tags(ctx context.Context, opts TagsOptions) ([]string, error) {
 			entries = append(entries, keg.NodeIndexEntry{ID: nodeID})
 		}
 	}
+	entries, err = filterDateRange(entries, opts.DateRangeOptions, t.Runtime.Clock().Now())
+	if err != nil {
+		return []string{}, err
+	}
 	sortNodeIndexEntries(entries)
-	return renderNodeEntries(entries, opts.Format, opts.IdOnly, opts.Reverse), nil
+	return formatNodeEntries(ctx, k, entries, opts.Format, opts.IdOnly, opts.Reverse)
 }
 
 func grepContentLineMatches(re *regexp.Regexp, raw []byte) []string {
@@ -518,6 +635,21 @@ func sortNodeIndexEntriesByTime(entries []keg.NodeIndexEntry, timeFunc func(keg.
 	}
 }
 
+// sortNodeIndexEntriesByRank orders entries by ascending PageRank so the
+// most central nodes come last, matching the time-based sorts. Nodes without
+// a recorded score sort first.
+func sortNodeIndexEntriesByRank(ctx context.Context, dex *keg.Dex, entries []keg.NodeIndexEntry) {
+	score := make(map[string]float64, len(entries))
+	for _, e := range entries {
+		if id, err := keg.ParseNode(e.ID); err == nil && id != nil {
+			score[e.ID], _ = dex.Rank(ctx, *id)
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return score[entries[i].ID] < score[entries[j].ID]
+	})
+}
+
 func sortNodeIndexEntries(entries []keg.NodeIndexEntry) {
 	for i := 1; i < len(entries); i++ {
 		for j := i; j > 0; j-- {
@@ -0,0 +1,150 @@
+package tapper
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jlrickert/tapper/pkg/keg"
+)
+
+type LockOptions struct {
+	KegTargetOptions
+
+	NodeIDs []string
+
+	// Holder names who holds the lock. Defaults to $USER.
+	Holder string
+
+	// Force takes over or removes a lock held by someone else.
+	Force bool
+}
+
+// Lock records a visible checkout lock on each node so collaborators on a
+// shared keg can see who is editing it.
+func (t *Tap) Lock(ctx context.Context, opts LockOptions) error {
+	return t.eachLockNode(ctx, opts, func(k *keg.Keg, id keg.NodeId, holder string) error {
+		if err := k.Lock(ctx, id, holder, opts.Force); err != nil {
+			return fmt.Errorf("unable to lock node %s: %w", id.Path(), err)
+		}
+		return nil
+	})
+}
+
+// Unlock removes the checkout lock from each node.
+func (t *Tap) Unlock(ctx context.Context, opts LockOptions) error {
+	return t.eachLockNode(ctx, opts, func(k *keg.Keg, id keg.NodeId, holder string) error {
+		if err := k.Unlock(ctx, id, holder, opts.Force); err != nil {
+			return fmt.Errorf("unable to unlock node %s: %w", id.Path(), err)
+		}
+		return nil
+	})
+}
+
+// UnlockKeg removes the keg-wide lock taken by index and import runs. It
+// returns the previous holder, or nil when the keg was not locked. A lock
+// whose owning process is still alive is only removed with Force.
+func (t *Tap) UnlockKeg(ctx context.Context, opts LockOptions) (*keg.LockOwner, error) {
+	k, err := t.resolveKeg(ctx, opts.KegTargetOptions)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open keg: %w", err)
+	}
+	owner, err := k.KegLockOwner(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read keg lock: %w", err)
+	}
+	if owner == nil {
+		return nil, nil
+	}
+	if !owner.Stale && !opts.Force {
+		return nil, fmt.Errorf("keg is locked by %s, which is still running; use --force to remove the lock: %w", owner, keg.ErrLock)
+	}
+	if err := k.BreakKegLock(ctx); err != nil {
+		return nil, fmt.Errorf("unable to remove keg lock: %w", err)
+	}
+	return owner, nil
+}
+
+// ListLocks returns "id\tholder\tsince\ttitle" lines for every locked node.
+func (t *Tap) ListLocks(ctx context.Context, opts KegTargetOptions) ([]string, error) {
+	k, err := t.resolveKeg(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open keg: %w", err)
+	}
+	dex, err := k.Dex(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read dex: %w", err)
+	}
+
+	var lines []string
+	for _, entry := range dex.Nodes(ctx) {
+		node, err := keg.ParseNode(entry.ID)
+		if err != nil || node == nil {
+			continue
+		}
+		c, err := k.Checkout(ctx, keg.NodeId{ID: node.ID, Code: node.Code})
+		if err != nil {
+			return nil, err
+		}
+		if c == nil {
+			continue
+		}
+		since := "-"
+		if !c.Since.IsZero() {
+			since = c.Since.Format(time.RFC3339)
+		}
+		lines = append(lines, strings.Join([]string{entry.ID, c.Holder, since, entry.Title}, "\t"))
+	}
+	return lines, nil
+}
+
+func (t *Tap) eachLockNode(ctx context.Context, opts LockOptions, fn func(*keg.Keg, keg.NodeId, string) error) error {
+	k, err := t.resolveKeg(ctx, opts.KegTargetOptions)
+	if err != nil {
+		return fmt.Errorf("unable to open keg: %w", err)
+	}
+	if len(opts.NodeIDs) == 0 {
+		return fmt.Errorf("at least one node ID is required")
+	}
+	holder := t.lockHolder(opts.Holder)
+	if holder == "" {
+		return fmt.Errorf("unable to determine lock holder: set --holder or $USER: %w", keg.ErrInvalid)
+	}
+	for _, raw := range opts.NodeIDs {
+		id, err := t.resolveNode(ctx, k, raw, true)
+		if err != nil {
+			return err
+		}
+		if err := fn(k, id, holder); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// lockHolder returns the explicit holder or the current user name.
+func (t *Tap) lockHolder(holder string) string {
+	if h := strings.TrimSpace(holder); h != "" {
+		return h
+	}
+	for _, key := range []string{"USER", "LOGNAME", "USERNAME"} {
+		if v := strings.TrimSpace(t.Runtime.Get(key)); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// warnIfLocked tells the user when a node is checked out by someone else.
+func (t *Tap) warnIfLocked(ctx context.Context, k *keg.Keg, id keg.NodeId) {
+	c, err := k.Checkout(ctx, id)
+	if err != nil || c == nil || c.Holder == t.lockHolder("") {
+		return
+	}
+	msg := fmt.Sprintf("warning: node %s is locked by %s", id.Path(), c.Holder)
+	if !c.Since.IsZero() {
+		msg += " since " + c.Since.Format(time.RFC3339)
+	}
+	t.warn(msg)
+}
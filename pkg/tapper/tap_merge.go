@@ -0,0 +1,53 @@
+package tapper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jlrickert/tapper/pkg/keg"
+)
+
+type MergeOptions struct {
+	KegTargetOptions
+
+	SourceID string
+	DestID   string
+
+	// Heading appends the source under its own title demoted to a level-two
+	// heading instead of after a horizontal rule.
+	Heading bool
+}
+
+func (t *Tap) Merge(ctx context.Context, opts MergeOptions) error {
+	k, err := t.resolveKeg(ctx, opts.KegTargetOptions)
+	if err != nil {
+		return fmt.Errorf("unable to open keg: %w", err)
+	}
+
+	src, err := keg.ParseNode(opts.SourceID)
+	if err != nil {
+		return fmt.Errorf("invalid source node ID %q: %w", opts.SourceID, err)
+	}
+	if src == nil {
+		return fmt.Errorf("invalid source node ID %q: %w", opts.SourceID, keg.ErrInvalid)
+	}
+
+	dst, err := keg.ParseNode(opts.DestID)
+	if err != nil {
+		return fmt.Errorf("invalid destination node ID %q: %w", opts.DestID, err)
+	}
+	if dst == nil {
+		return fmt.Errorf("invalid destination node ID %q: %w", opts.DestID, keg.ErrInvalid)
+	}
+
+	srcID := keg.NodeId{ID: src.ID, Code: src.Code}
+	dstID := keg.NodeId{ID: dst.ID, Code: dst.Code}
+	if err := k.Merge(ctx, srcID, dstID, keg.MergeOptions{Heading: opts.Heading}); err != nil {
+		if errors.Is(err, keg.ErrNotExist) {
+			return fmt.Errorf("unable to merge: %w", err)
+		}
+		return fmt.Errorf("unable to merge node %s into %s: %w", srcID.Path(), dstID.Path(), err)
+	}
+	return nil
+}
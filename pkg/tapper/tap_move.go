@@ -15,39 +15,42 @@ type MoveOptions struct {
 	DestID   string
 }
 
-func (t *Tap) Move(ctx context.Context, opts MoveOptions) error {
+// Move renames a node and returns the files whose links to it were
+// rewritten.
+func (t *Tap) Move(ctx context.Context, opts MoveOptions) (*keg.LinkRepairReport, error) {
 	k, err := t.resolveKeg(ctx, opts.KegTargetOptions)
 	if err != nil {
-		return fmt.Errorf("unable to open keg: %w", err)
+		return nil, fmt.Errorf("unable to open keg: %w", err)
 	}
 
 	src, err := keg.ParseNode(opts.SourceID)
 	if err != nil {
-		return fmt.Errorf("invalid source node ID %q: %w", opts.SourceID, err)
+		return nil, fmt.Errorf("invalid source node ID %q: %w", opts.SourceID, err)
 	}
 	if src == nil {
-		return fmt.Errorf("invalid source node ID %q: %w", opts.SourceID, keg.ErrInvalid)
+		return nil, fmt.Errorf("invalid source node ID %q: %w", opts.SourceID, keg.ErrInvalid)
 	}
 
 	dst, err := keg.ParseNode(opts.DestID)
 	if err != nil {
-		return fmt.Errorf("invalid destination node ID %q: %w", opts.DestID, err)
+		return nil, fmt.Errorf("invalid destination node ID %q: %w", opts.DestID, err)
 	}
 	if dst == nil {
-		return fmt.Errorf("invalid destination node ID %q: %w", opts.DestID, keg.ErrInvalid)
+		return nil, fmt.Errorf("invalid destination node ID %q: %w", opts.DestID, keg.ErrInvalid)
 	}
 
 	srcID := keg.NodeId{ID: src.ID, Code: src.Code}
 	dstID := keg.NodeId{ID: dst.ID, Code: dst.Code}
-	if err := k.Move(ctx, srcID, dstID); err != nil {
+	report, err := k.Move(ctx, srcID, dstID)
+	if err != nil {
 		if errors.Is(err, keg.ErrNotExist) {
-			return fmt.Errorf("node %s not found", srcID.Path())
+			return nil, keg.NewNodeNotFoundError(srcID)
 		}
 		if errors.Is(err, keg.ErrDestinationExists) {
-			return fmt.Errorf("destination node %s already exists", dstID.Path())
+			return nil, fmt.Errorf("destination node %s already exists", dstID.Path())
 		}
-		return fmt.Errorf("unable to move node: %w", err)
+		return report, fmt.Errorf("unable to move node: %w", err)
 	}
 
-	return nil
+	return report, nil
 }
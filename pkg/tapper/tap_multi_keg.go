@@ -0,0 +1,141 @@
+package tapper
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"github.com/jlrickert/tapper/pkg/keg"
+)
+
+// forEachKeg calls fn with every keg alias in the user config, in alias
+// order, and a copy of opts targeting that alias. A keg fn fails on is
+// logged and skipped so one broken alias does not hide the others.
+func (t *Tap) forEachKeg(ctx context.Context, opts KegTargetOptions, fn func(alias string, opts KegTargetOptions) error) error {
+	aliases := t.ConfigService.Config(true).ListKegs()
+	if len(aliases) == 0 {
+		return fmt.Errorf("no kegs configured: %w", keg.ErrNotExist)
+	}
+	for _, alias := range aliases {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		kegOpts := opts
+		kegOpts.Keg = alias
+		kegOpts.Project = false
+		kegOpts.Cwd = false
+		kegOpts.Path = ""
+		if err := fn(alias, kegOpts); err != nil {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return ctxErr
+			}
+			t.Runtime.Logger().Warn("skipping keg", "keg", alias, "error", err.Error())
+		}
+	}
+	return nil
+}
+
+// addressEntries rewrites entry IDs to the alias:id form so nodes of
+// different kegs stay distinguishable once merged.
+func addressEntries(alias string, entries []keg.NodeIndexEntry) []keg.NodeIndexEntry {
+	for i, entry := range entries {
+		id, err := keg.ParseNode(entry.ID)
+		if err != nil || id == nil {
+			continue
+		}
+		id.Alias = alias
+		entries[i].ID = id.Address()
+	}
+	return entries
+}
+
+// listAllKegs lists the nodes of every configured keg. Filters, sorting and
+// the limit apply per keg.
+func (t *Tap) listAllKegs(ctx context.Context, opts ListOptions) ([]string, error) {
+	lines := []string{}
+	err := t.forEachKeg(ctx, opts.KegTargetOptions, func(alias string, kegOpts KegTargetOptions) error {
+		kopts := opts
+		kopts.KegTargetOptions = kegOpts
+		kopts.AllKegs = false
+		entries, err := t.ListEntries(ctx, kopts)
+		if err != nil {
+			return err
+		}
+		var k *keg.Keg
+		if isNodeTemplate(opts.Format) {
+			if k, err = t.resolveKeg(ctx, kegOpts); err != nil {
+				return fmt.Errorf("unable to open keg: %w", err)
+			}
+		}
+		kegLines, err := formatNodeEntries(ctx, k, addressEntries(alias, entries), opts.Format, opts.IdOnly, false)
+		if err != nil {
+			return err
+		}
+		lines = append(lines, kegLines...)
+		return nil
+	})
+	return lines, err
+}
+
+// listEntriesAllKegs is ListEntries across every configured keg with IDs in
+// the alias:id form.
+func (t *Tap) listEntriesAllKegs(ctx context.Context, opts ListOptions) ([]keg.NodeIndexEntry, error) {
+	var out []keg.NodeIndexEntry
+	err := t.forEachKeg(ctx, opts.KegTargetOptions, func(alias string, kegOpts KegTargetOptions) error {
+		kopts := opts
+		kopts.KegTargetOptions = kegOpts
+		kopts.AllKegs = false
+		entries, err := t.ListEntries(ctx, kopts)
+		if err != nil {
+			return err
+		}
+		out = append(out, addressEntries(alias, entries)...)
+		return nil
+	})
+	return out, err
+}
+
+// searchAllKegs runs a plain search against every configured keg. Hits are
+// ranked within each keg and grouped by keg in alias order.
+func (t *Tap) searchAllKegs(ctx context.Context, opts SearchOptions, query string) ([]string, error) {
+	if opts.Semantic || opts.Regex {
+		return []string{}, fmt.Errorf("searching all kegs supports plain text queries only: %w", keg.ErrNotSupported)
+	}
+	format := opts.Format
+	if format == "" {
+		format = "%i\t%t"
+	}
+	lines := []string{}
+	err := t.forEachKeg(ctx, opts.KegTargetOptions, func(alias string, kegOpts KegTargetOptions) error {
+		k, err := t.resolveKeg(ctx, kegOpts)
+		if err != nil {
+			return fmt.Errorf("unable to open keg: %w", err)
+		}
+		matches, err := t.grepMatches(ctx, k, GrepOptions{
+			KegTargetOptions: kegOpts,
+			Query:            regexp.QuoteMeta(query),
+			IgnoreCase:       true,
+			Rank:             true,
+		})
+		if err != nil {
+			return err
+		}
+		entries := make([]keg.NodeIndexEntry, 0, len(matches))
+		for _, match := range matches {
+			entries = append(entries, match.entry)
+		}
+		kegLines, err := formatNodeEntries(ctx, k, addressEntries(alias, entries), format, opts.IdOnly, false)
+		if err != nil {
+			return err
+		}
+		lines = append(lines, kegLines...)
+		return nil
+	})
+	if err != nil {
+		return []string{}, err
+	}
+	if opts.Limit > 0 && len(lines) > opts.Limit {
+		lines = lines[:opts.Limit]
+	}
+	return lines, nil
+}
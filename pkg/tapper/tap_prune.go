@@ -0,0 +1,163 @@
+package tapper
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jlrickert/tapper/pkg/keg"
+)
+
+// Prune candidate reasons.
+const (
+	PruneReasonEmpty       = "empty"
+	PruneReasonStaleDraft  = "stale-draft"
+	PruneReasonStaleOrphan = "stale-orphan"
+)
+
+// DefaultPruneOlderThan is how long a node must go untouched before it is
+// considered stale.
+const DefaultPruneOlderThan = "180d"
+
+type PruneOptions struct {
+	KegTargetOptions
+
+	// OlderThan marks nodes not updated or accessed since this bound as
+	// stale. Accepts the same forms as DateRangeOptions. Defaults to
+	// DefaultPruneOlderThan.
+	OlderThan string
+}
+
+// PruneCandidate is a node that looks safe to archive or delete.
+type PruneCandidate struct {
+	ID      keg.NodeId
+	Title   string
+	Reasons []string
+}
+
+// PruneCandidates reports nodes with no content beyond their title, drafts
+// that have gone stale, and stale nodes with no links in or out. Node 0 is
+// never a candidate.
+func (t *Tap) PruneCandidates(ctx context.Context, opts PruneOptions) ([]PruneCandidate, error) {
+	k, err := t.resolveKeg(ctx, opts.KegTargetOptions)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open keg: %w", err)
+	}
+
+	olderThan := strings.TrimSpace(opts.OlderThan)
+	if olderThan == "" {
+		olderThan = DefaultPruneOlderThan
+	}
+	cutoff, err := ParseTimeBound(olderThan, t.Runtime.Clock().Now())
+	if err != nil {
+		return nil, err
+	}
+
+	dex, err := k.Dex(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read dex: %w", err)
+	}
+	ids, err := k.Repo.ListNodes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list nodes: %w", err)
+	}
+	slices.SortFunc(ids, func(a, b keg.NodeId) int { return a.Compare(b) })
+
+	var candidates []PruneCandidate
+	for _, id := range ids {
+		if id.ID == 0 && id.Code == "" {
+			continue
+		}
+		raw, err := k.Repo.ReadContent(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read node %s: %w", id.Path(), err)
+		}
+		stats, err := k.GetStats(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read node %s stats: %w", id.Path(), err)
+		}
+
+		var reasons []string
+		if isTitleOnly(raw) {
+			reasons = append(reasons, PruneReasonEmpty)
+		}
+		if lastTouched(stats).Before(cutoff) {
+			backlinks, _ := dex.Backlinks(ctx, id)
+			switch {
+			case id.Code != "":
+				reasons = append(reasons, PruneReasonStaleDraft)
+			case len(stats.Links()) == 0 && len(backlinks) == 0:
+				reasons = append(reasons, PruneReasonStaleOrphan)
+			}
+		}
+		if len(reasons) == 0 {
+			continue
+		}
+		candidates = append(candidates, PruneCandidate{ID: id, Title: stats.Title(), Reasons: reasons})
+	}
+	return candidates, nil
+}
+
+type PruneApplyOptions struct {
+	KegTargetOptions
+
+	Nodes []keg.NodeId
+
+	// Delete removes the nodes instead of archiving them.
+	Delete bool
+
+	// Force deletes nodes that other nodes still link to, as Remove does.
+	// Without it a delete is refused and the keg is left untouched.
+	Force bool
+}
+
+// PruneApply archives (or deletes) the given prune candidates.
+func (t *Tap) PruneApply(ctx context.Context, opts PruneApplyOptions) error {
+	k, err := t.resolveKeg(ctx, opts.KegTargetOptions)
+	if err != nil {
+		return fmt.Errorf("unable to open keg: %w", err)
+	}
+	if opts.Delete && !opts.Force {
+		if err := checkRemoveBacklinks(ctx, k, opts.Nodes); err != nil {
+			return err
+		}
+	}
+	for _, id := range opts.Nodes {
+		if opts.Delete {
+			_, err = k.Remove(ctx, id)
+		} else {
+			err = k.Archive(ctx, id)
+		}
+		if err != nil {
+			return fmt.Errorf("unable to prune node %s: %w", id.Path(), err)
+		}
+	}
+	return nil
+}
+
+// isTitleOnly reports whether markdown content holds nothing beyond an H1.
+func isTitleOnly(raw []byte) bool {
+	body := bytes.TrimSpace(raw)
+	if bytes.HasPrefix(body, []byte("# ")) {
+		if i := bytes.IndexByte(body, '\n'); i >= 0 {
+			body = bytes.TrimSpace(body[i+1:])
+		} else {
+			body = nil
+		}
+	}
+	return len(body) == 0
+}
+
+func lastTouched(stats *keg.NodeStats) time.Time {
+	touched := stats.Updated()
+	if stats.Accessed().After(touched) {
+		touched = stats.Accessed()
+	}
+	if touched.IsZero() {
+		touched = stats.Created()
+	}
+	return touched
+}
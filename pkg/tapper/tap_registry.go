@@ -0,0 +1,293 @@
+package tapper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jlrickert/cli-toolkit/toolkit"
+	"github.com/jlrickert/tapper/pkg/keg"
+	kegurl "github.com/jlrickert/tapper/pkg/keg_url"
+	"github.com/jlrickert/tapper/pkg/registry"
+)
+
+// RegistryInfo describes a configured registry for `tap registry list`.
+type RegistryInfo struct {
+	Name    string
+	Url     string
+	Default bool
+	// HasToken reports whether a token is configured, present in the
+	// environment or saved by `tap auth login`.
+	HasToken bool
+}
+
+// RegistryKegsOptions selects whose kegs RegistryKegs lists.
+type RegistryKegsOptions struct {
+	// Registry is the registry name. Empty uses defaultRegistry.
+	Registry string
+
+	// User is the registry user. Empty uses the current OS user.
+	User string
+}
+
+// RegistryCreateOptions describes a keg to create on a registry.
+type RegistryCreateOptions struct {
+	Registry string
+	User     string
+
+	// Keg is the name of the new keg.
+	Keg string
+
+	Title string
+
+	// Alias, when set, adds the new keg to the user config under that alias.
+	Alias string
+}
+
+// RegistryDiscoverOptions describes a search for kegs on a registry.
+type RegistryDiscoverOptions struct {
+	Registry string
+	Query    string
+}
+
+// RegistryShowOptions selects a registry keg to describe.
+type RegistryShowOptions struct {
+	Registry string
+	User     string
+	Keg      string
+
+	// Alias, when set, adds the keg to the user config under that alias.
+	Alias string
+}
+
+// RegistrySearchOptions describes a server-side search of a registry keg.
+type RegistrySearchOptions struct {
+	Registry string
+	User     string
+	Keg      string
+	Query    string
+}
+
+// Registries lists the configured registries in config order.
+func (t *Tap) Registries(ctx context.Context) []RegistryInfo {
+	cfg := t.ConfigService.Config(true)
+	regs := cfg.Registries()
+	out := make([]RegistryInfo, 0, len(regs))
+	for _, reg := range regs {
+		out = append(out, RegistryInfo{
+			Name:     reg.Name,
+			Url:      reg.Url,
+			Default:  reg.Name == cfg.DefaultRegistry(),
+			HasToken: t.registryToken(ctx, reg) != "",
+		})
+	}
+	return out
+}
+
+// RegistryKegs lists the kegs a user owns on a registry.
+func (t *Tap) RegistryKegs(ctx context.Context, opts RegistryKegsOptions) ([]registry.KegInfo, error) {
+	client, err := t.registryClient(ctx, opts.Registry)
+	if err != nil {
+		return nil, err
+	}
+	return client.ListKegs(ctx, t.registryUser(opts.User))
+}
+
+// RegistryCreate creates a keg on a registry and optionally records it in
+// the user config.
+func (t *Tap) RegistryCreate(ctx context.Context, opts RegistryCreateOptions) (*registry.KegInfo, error) {
+	if strings.TrimSpace(opts.Keg) == "" {
+		return nil, fmt.Errorf("keg name required: %w", keg.ErrInvalid)
+	}
+	client, err := t.registryClient(ctx, opts.Registry)
+	if err != nil {
+		return nil, err
+	}
+	user := t.registryUser(opts.User)
+	info, err := client.CreateKeg(ctx, user, opts.Keg, opts.Title)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Alias != "" {
+		if err := t.addRegistryAlias(opts.Alias, opts.Registry, user, opts.Keg); err != nil {
+			return nil, err
+		}
+	}
+	return info, nil
+}
+
+// RegistryDiscover finds kegs on a registry matching a query.
+func (t *Tap) RegistryDiscover(ctx context.Context, opts RegistryDiscoverOptions) ([]registry.KegInfo, error) {
+	if strings.TrimSpace(opts.Query) == "" {
+		return nil, fmt.Errorf("search query required: %w", keg.ErrInvalid)
+	}
+	client, err := t.registryClient(ctx, opts.Registry)
+	if err != nil {
+		return nil, err
+	}
+	return client.SearchKegs(ctx, opts.Query)
+}
+
+// RegistryShow returns the details of a registry keg and optionally records
+// it in the user config.
+func (t *Tap) RegistryShow(ctx context.Context, opts RegistryShowOptions) (*registry.KegInfo, error) {
+	if opts.Keg == "" {
+		return nil, fmt.Errorf("keg name required: %w", keg.ErrInvalid)
+	}
+	client, err := t.registryClient(ctx, opts.Registry)
+	if err != nil {
+		return nil, err
+	}
+	user := t.registryUser(opts.User)
+	info, err := client.GetKeg(ctx, user, opts.Keg)
+	if err != nil {
+		return nil, registryKegError(user, opts.Keg, err)
+	}
+	if opts.Alias != "" {
+		if err := t.addRegistryAlias(opts.Alias, opts.Registry, user, opts.Keg); err != nil {
+			return nil, err
+		}
+	}
+	return info, nil
+}
+
+// registryKegError reports a missing registry keg as keg.ErrNotExist and
+// returns other errors as they are.
+func registryKegError(user, name string, err error) error {
+	if errors.Is(err, registry.ErrNotFound) {
+		return fmt.Errorf("registry keg not found: %s/%s: %w", user, name, keg.ErrNotExist)
+	}
+	return err
+}
+
+// addRegistryAlias adds registry keg user/name to the user config under
+// alias. An empty registry means the default registry.
+func (t *Tap) addRegistryAlias(alias, reg, user, name string) error {
+	if reg == "" {
+		reg = t.ConfigService.Config(true).DefaultRegistry()
+	}
+	userCfg, err := t.ConfigService.UserConfig(false)
+	if err != nil {
+		return err
+	}
+	if err := userCfg.AddKeg(alias, kegurl.NewApi(reg, user, name)); err != nil {
+		return err
+	}
+	if err := userCfg.Write(t.Runtime, t.PathService.UserConfig()); err != nil {
+		return err
+	}
+	t.ConfigService.ResetCache()
+	return nil
+}
+
+// RegistrySearch runs a search on the registry against one of its kegs.
+func (t *Tap) RegistrySearch(ctx context.Context, opts RegistrySearchOptions) ([]registry.SearchHit, error) {
+	if opts.Keg == "" {
+		return nil, fmt.Errorf("keg name required: %w", keg.ErrInvalid)
+	}
+	client, err := t.registryClient(ctx, opts.Registry)
+	if err != nil {
+		return nil, err
+	}
+	return client.Search(ctx, t.registryUser(opts.User), opts.Keg, opts.Query)
+}
+
+// registryClient returns a client for the named registry, or the default
+// registry when name is empty.
+func (t *Tap) registryClient(ctx context.Context, name string) (*registry.Client, error) {
+	reg, err := t.lookupRegistry(name)
+	if err != nil {
+		return nil, err
+	}
+	if reg.Url == "" {
+		return nil, fmt.Errorf("registry %s has no url configured: %w", reg.Name, keg.ErrInvalid)
+	}
+	client := registry.NewClient(reg.Url, t.registryToken(ctx, reg))
+	policy := reg.RetryPolicy()
+	client.Retry = &policy
+	return client, nil
+}
+
+// RetryPolicy returns the client retry policy with the timeout and retries
+// of reg applied. Invalid or unset values keep the defaults.
+func (reg KegRegistry) RetryPolicy() registry.RetryPolicy {
+	policy := registry.DefaultRetryPolicy
+	if d, err := time.ParseDuration(strings.TrimSpace(reg.Timeout)); err == nil && d > 0 {
+		policy.Timeout = d
+	}
+	if reg.Retries != nil && *reg.Retries >= 0 {
+		policy.MaxAttempts = *reg.Retries + 1
+	}
+	return policy
+}
+
+// lookupRegistry returns the configured registry named name, or the default
+// registry when name is empty.
+func (t *Tap) lookupRegistry(name string) (KegRegistry, error) {
+	reg, ok := t.ConfigService.Config(true).Registry(name)
+	if !ok {
+		if name == "" {
+			return KegRegistry{}, fmt.Errorf("no default registry configured (set defaultRegistry and registries)")
+		}
+		return KegRegistry{}, fmt.Errorf("registry not found: %s", name)
+	}
+	return reg, nil
+}
+
+// registryToken returns the token used for reg: its inline token, the
+// value of its tokenEnv variable, or a saved, unexpired `tap auth login`
+// credential, in that order.
+func (t *Tap) registryToken(ctx context.Context, reg KegRegistry) string {
+	return registryToken(ctx, t.Runtime, t.Credentials, reg)
+}
+
+func registryToken(ctx context.Context, rt *toolkit.Runtime, creds CredentialStore, reg KegRegistry) string {
+	if reg.Token != "" {
+		return reg.Token
+	}
+	if reg.TokenEnv != "" {
+		if token := rt.Get(reg.TokenEnv); token != "" {
+			return token
+		}
+	}
+	if creds == nil {
+		return ""
+	}
+	cred, err := creds.Get(ctx, reg.Name)
+	if err != nil || cred.Expired(rt.Clock().Now()) {
+		return ""
+	}
+	return cred.Token
+}
+
+// registryUser falls back to the current OS user, as `repo init --registry`
+// does.
+func (t *Tap) registryUser(user string) string {
+	if user != "" {
+		return user
+	}
+	if u, _ := t.Runtime.GetUser(); u != "" {
+		return u
+	}
+	return "user"
+}
+
+// withRegistry fills the url and token of a registry target from the
+// registries section of cfg and saved credentials. Values already set on the
+// target win.
+func withRegistry(ctx context.Context, rt *toolkit.Runtime, creds CredentialStore, cfg *Config, target kegurl.Target) kegurl.Target {
+	reg, ok := cfg.Registry(target.Repo)
+	if !ok {
+		return target
+	}
+	if target.Url == "" {
+		target.Url = reg.Url
+	}
+	if target.Token != "" || (target.TokenEnv != "" && rt.Get(target.TokenEnv) != "") {
+		return target
+	}
+	target.Token = registryToken(ctx, rt, creds, reg)
+	return target
+}
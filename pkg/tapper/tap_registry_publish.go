@@ -0,0 +1,273 @@
+package tapper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/jlrickert/tapper/pkg/keg"
+	"github.com/jlrickert/tapper/pkg/registry"
+)
+
+// RegistryPublishOptions describes a `tap registry publish` run.
+type RegistryPublishOptions struct {
+	KegTargetOptions
+
+	Registry string
+	User     string
+
+	// Name is the keg name on the registry. Empty uses the local alias or
+	// directory name.
+	Name string
+
+	Title string
+
+	// Visibility is registry.VisibilityPublic or registry.VisibilityPrivate.
+	// Empty keeps the current visibility, private for a new keg.
+	Visibility string
+
+	// Unlock publishes nodes with encrypted content as plaintext. Without it
+	// they are skipped.
+	Unlock bool
+}
+
+// RegistryPublishResult summarizes a publish.
+type RegistryPublishResult struct {
+	// URL is the web address of the published keg.
+	URL string
+
+	User    string
+	Name    string
+	Created bool
+
+	Nodes   int
+	Assets  int
+	Indexes int
+
+	// Removed counts registry nodes deleted because they no longer exist
+	// locally.
+	Removed int
+
+	// Skipped counts nodes left out because their content is encrypted.
+	Skipped int
+}
+
+// RegistryPublish uploads a local file keg to the user's namespace on a
+// registry, creating the registry keg on first publish. Nodes, their files
+// and images, the keg config and the index files are uploaded; registry
+// nodes missing locally are removed so the registry mirrors the local keg.
+// Nodes with encrypted content are skipped, and removed from the registry,
+// unless opts.Unlock is set. Image references without alt text get the alt
+// text recorded in the image metadata.
+func (t *Tap) RegistryPublish(ctx context.Context, opts RegistryPublishOptions) (*RegistryPublishResult, error) {
+	if opts.Visibility != "" {
+		if err := checkVisibility(opts.Visibility); err != nil {
+			return nil, err
+		}
+	}
+	k, err := t.resolveKeg(ctx, opts.KegTargetOptions)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open keg: %w", err)
+	}
+	if _, ok := keg.UnwrapRepo(k.Repo).(*keg.FsRepo); !ok {
+		return nil, fmt.Errorf("only local file kegs can be published: %w", keg.ErrNotSupported)
+	}
+	client, err := t.registryClient(ctx, opts.Registry)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &RegistryPublishResult{User: t.registryUser(opts.User), Name: opts.Name}
+	if result.Name == "" {
+		result.Name = t.backupName(k)
+	}
+	if err := t.publishKeg(ctx, client, result, opts); err != nil {
+		return nil, err
+	}
+
+	cfg, err := k.Repo.ReadConfig(ctx)
+	if err != nil && !errors.Is(err, keg.ErrNotExist) {
+		return nil, err
+	}
+	if cfg != nil {
+		data, err := cfg.ToYAML()
+		if err != nil {
+			return nil, err
+		}
+		if err := client.WriteConfig(ctx, result.User, result.Name, data); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := publishNodes(ctx, client, k, opts.Unlock, result); err != nil {
+		return nil, err
+	}
+	if err := publishIndexes(ctx, client, k.Repo, result); err != nil {
+		return nil, err
+	}
+	result.URL = client.KegURL(result.User, result.Name)
+	return result, nil
+}
+
+// publishKeg creates the registry keg, or reuses it when it already exists,
+// and applies title and visibility.
+func (t *Tap) publishKeg(ctx context.Context, client *registry.Client, result *RegistryPublishResult, opts RegistryPublishOptions) error {
+	_, err := client.CreateKeg(ctx, result.User, result.Name, opts.Title)
+	switch {
+	case err == nil:
+		result.Created = true
+	case errors.Is(err, registry.ErrConflict):
+	default:
+		return err
+	}
+	update := registry.KegUpdate{Visibility: opts.Visibility}
+	if !result.Created {
+		update.Title = opts.Title
+	}
+	if result.Created && update.Visibility == "" {
+		update.Visibility = registry.VisibilityPrivate
+	}
+	if update == (registry.KegUpdate{}) {
+		return nil
+	}
+	_, err = client.UpdateKeg(ctx, result.User, result.Name, update)
+	return err
+}
+
+func publishNodes(ctx context.Context, client *registry.Client, k *keg.Keg, unlock bool, result *RegistryPublishResult) error {
+	repo := k.Repo
+	ids, err := repo.ListNodes(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to list nodes: %w", err)
+	}
+	local := make(map[string]struct{}, len(ids))
+	for _, id := range ids {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		remoteID := id.Path()
+
+		content, err := repo.ReadContent(ctx, id)
+		if err != nil {
+			return fmt.Errorf("unable to read node %s: %w", id.Path(), err)
+		}
+		if keg.IsEncryptedContent(content) {
+			if !unlock {
+				result.Skipped++
+				continue
+			}
+			if content, err = k.GetContent(ctx, id); err != nil {
+				return fmt.Errorf("unable to decrypt node %s: %w", id.Path(), err)
+			}
+		}
+		local[remoteID] = struct{}{}
+		if content, err = publishImageAlt(ctx, k, id, content); err != nil {
+			return err
+		}
+		meta, err := repo.ReadMeta(ctx, id)
+		if err != nil {
+			return fmt.Errorf("unable to read meta of node %s: %w", id.Path(), err)
+		}
+		update := registry.NodeUpdate{Content: content, Meta: meta}
+		if stats, err := repo.ReadStats(ctx, id); err == nil {
+			if update.Stats, err = stats.ToJSON(); err != nil {
+				return err
+			}
+		} else if !errors.Is(err, keg.ErrNotExist) {
+			return fmt.Errorf("unable to read stats of node %s: %w", id.Path(), err)
+		}
+		if err := client.WriteNode(ctx, result.User, result.Name, remoteID, update); err != nil {
+			return err
+		}
+		result.Nodes++
+
+		assets, err := readImportedNodeAssets(ctx, repo, id)
+		if err != nil {
+			return fmt.Errorf("unable to read attachments of node %s: %w", id.Path(), err)
+		}
+		for kind, files := range map[string]map[string][]byte{"files": assets.files, "images": assets.images} {
+			for name, data := range files {
+				if err := client.WriteAsset(ctx, result.User, result.Name, remoteID, kind, name, data); err != nil {
+					return err
+				}
+				result.Assets++
+			}
+		}
+	}
+
+	if result.Created {
+		return nil
+	}
+	remote, err := client.ListNodes(ctx, result.User, result.Name)
+	if err != nil {
+		return err
+	}
+	for _, id := range remote {
+		if _, ok := local[id]; ok {
+			continue
+		}
+		if err := client.DeleteNode(ctx, result.User, result.Name, id); err != nil && !errors.Is(err, registry.ErrNotFound) {
+			return err
+		}
+		result.Removed++
+	}
+	return nil
+}
+
+// imageRefPattern matches markdown references to a node's own images,
+// capturing the alt text, the path prefix and the image name.
+var imageRefPattern = regexp.MustCompile(`!\[([^\]]*)\]\(((?:\./)?` + keg.NodeImagesDir + `/)([^)\s?#]+)`)
+
+// publishImageAlt fills in image references whose alt text is empty, or just
+// the image name, with the alt text recorded in the image metadata.
+func publishImageAlt(ctx context.Context, k *keg.Keg, id keg.NodeId, content []byte) ([]byte, error) {
+	var errs []error
+	out := imageRefPattern.ReplaceAllFunc(content, func(ref []byte) []byte {
+		m := imageRefPattern.FindSubmatch(ref)
+		name, err := url.PathUnescape(string(m[3]))
+		if err != nil {
+			return ref
+		}
+		if alt := string(m[1]); alt != "" && alt != name {
+			return ref
+		}
+		meta, err := k.ItemMeta(ctx, id, keg.AssetKindImage, name)
+		if errors.Is(err, keg.ErrNotExist) {
+			return ref
+		}
+		if err != nil {
+			errs = append(errs, err)
+			return ref
+		}
+		if meta.Alt == "" {
+			return ref
+		}
+		alt := strings.NewReplacer(`\`, `\\`, "[", `\[`, "]", `\]`).Replace(meta.Alt)
+		return []byte("![" + alt + "](" + string(m[2]) + string(m[3]))
+	})
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("unable to read image metadata of node %s: %w", id.Path(), errors.Join(errs...))
+	}
+	return out, nil
+}
+
+func publishIndexes(ctx context.Context, client *registry.Client, repo keg.Repository, result *RegistryPublishResult) error {
+	names, err := repo.ListIndexes(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to list indexes: %w", err)
+	}
+	for _, name := range names {
+		data, err := repo.GetIndex(ctx, name)
+		if err != nil {
+			return fmt.Errorf("unable to read index %s: %w", name, err)
+		}
+		if err := client.WriteIndex(ctx, result.User, result.Name, name, data); err != nil {
+			return err
+		}
+		result.Indexes++
+	}
+	return nil
+}
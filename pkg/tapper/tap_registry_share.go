@@ -0,0 +1,114 @@
+package tapper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jlrickert/tapper/pkg/keg"
+	"github.com/jlrickert/tapper/pkg/registry"
+)
+
+// RegistryVisibilityOptions changes who can see a registry keg.
+type RegistryVisibilityOptions struct {
+	Registry string
+	User     string
+	Keg      string
+
+	// Visibility is registry.VisibilityPublic or registry.VisibilityPrivate.
+	Visibility string
+}
+
+// RegistryShareOptions changes or lists the access list of a registry keg.
+type RegistryShareOptions struct {
+	Registry string
+	User     string
+	Keg      string
+
+	// With is the registry user to share the keg with. Empty only lists the
+	// access list.
+	With string
+
+	// Role is registry.RoleRead or registry.RoleWrite. Empty means read.
+	Role string
+
+	// Revoke removes With from the access list instead of sharing.
+	Revoke bool
+}
+
+// RegistryVisibility makes a registry keg public or private.
+func (t *Tap) RegistryVisibility(ctx context.Context, opts RegistryVisibilityOptions) (*registry.KegInfo, error) {
+	if opts.Keg == "" {
+		return nil, fmt.Errorf("keg name required: %w", keg.ErrInvalid)
+	}
+	if err := checkVisibility(opts.Visibility); err != nil {
+		return nil, err
+	}
+	client, err := t.registryClient(ctx, opts.Registry)
+	if err != nil {
+		return nil, err
+	}
+	user := t.registryUser(opts.User)
+	info, err := client.UpdateKeg(ctx, user, opts.Keg, registry.KegUpdate{Visibility: opts.Visibility})
+	if err != nil {
+		return nil, registryKegError(user, opts.Keg, err)
+	}
+	return info, nil
+}
+
+// RegistryShare shares a registry keg with another user, or revokes their
+// access, and returns the resulting access list. Without a user it only
+// returns the access list.
+func (t *Tap) RegistryShare(ctx context.Context, opts RegistryShareOptions) ([]registry.Grant, error) {
+	if opts.Keg == "" {
+		return nil, fmt.Errorf("keg name required: %w", keg.ErrInvalid)
+	}
+	with := strings.TrimPrefix(strings.TrimSpace(opts.With), "@")
+	role := opts.Role
+	if role == "" {
+		role = registry.RoleRead
+	}
+	switch {
+	case with == "" && (opts.Revoke || opts.Role != ""):
+		return nil, fmt.Errorf("user to share with required: %w", keg.ErrInvalid)
+	case role != registry.RoleRead && role != registry.RoleWrite:
+		return nil, fmt.Errorf("role must be read or write, got %q: %w", role, keg.ErrInvalid)
+	}
+	client, err := t.registryClient(ctx, opts.Registry)
+	if err != nil {
+		return nil, err
+	}
+	user := t.registryUser(opts.User)
+	switch {
+	case with == "":
+	case opts.Revoke:
+		err = client.Unshare(ctx, user, opts.Keg, with)
+		// A 404 also answers for users without access; only a missing keg
+		// fails to list its grants as well.
+		if errors.Is(err, registry.ErrNotFound) {
+			if _, listErr := client.ListGrants(ctx, user, opts.Keg); listErr == nil {
+				return nil, fmt.Errorf("%s/%s is not shared with %s: %w", user, opts.Keg, with, keg.ErrNotExist)
+			}
+		}
+	default:
+		err = client.Share(ctx, user, opts.Keg, with, role)
+	}
+	if err != nil {
+		return nil, registryKegError(user, opts.Keg, err)
+	}
+	grants, err := client.ListGrants(ctx, user, opts.Keg)
+	if err != nil {
+		return nil, registryKegError(user, opts.Keg, err)
+	}
+	return grants, nil
+}
+
+// checkVisibility rejects anything but public or private.
+func checkVisibility(v string) error {
+	switch v {
+	case registry.VisibilityPublic, registry.VisibilityPrivate:
+		return nil
+	}
+	return fmt.Errorf("visibility must be public or private, got %q: %w", v, keg.ErrInvalid)
+}
@@ -0,0 +1,103 @@
+package tapper
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/jlrickert/tapper/pkg/keg"
+)
+
+// RelatedOptions configures Tap.Related.
+type RelatedOptions struct {
+	KegTargetOptions
+
+	// NodeID is the node to find suggestions for. Non-numeric values are
+	// matched against node titles unless Exact is set.
+	NodeID string
+
+	// Exact disables fuzzy title matching for non-numeric node arguments.
+	Exact bool
+
+	// Limit caps the number of suggestions. 0 means no limit.
+	Limit int
+
+	// Format to use. %i is node id
+	// %d is date
+	// %t is node title
+	// %s is relatedness score
+	// %% for literal %
+	// A format containing {{ is a Go template over NodeTemplateData.
+	Format string
+
+	IdOnly bool
+}
+
+// Related suggests nodes related to NodeID by shared tags, shared link
+// targets, and shared backlinks, best first.
+func (t *Tap) Related(ctx context.Context, opts RelatedOptions) ([]string, error) {
+	k, err := t.resolveKeg(ctx, opts.KegTargetOptions)
+	if err != nil {
+		return []string{}, fmt.Errorf("unable to open keg: %w", err)
+	}
+	dex, err := k.Dex(ctx)
+	if err != nil {
+		return []string{}, fmt.Errorf("unable to read dex: %w", err)
+	}
+
+	id, err := t.resolveNode(ctx, k, opts.NodeID, opts.Exact)
+	if err != nil {
+		return []string{}, err
+	}
+	exists, err := k.Repo.HasNode(ctx, id)
+	if err != nil {
+		return []string{}, fmt.Errorf("unable to inspect node: %w", err)
+	}
+	if !exists {
+		return []string{}, keg.NewNodeNotFoundError(id)
+	}
+
+	var tmpl *template.Template
+	if !opts.IdOnly && isNodeTemplate(opts.Format) {
+		if tmpl, err = parseNodeTemplate(opts.Format); err != nil {
+			return []string{}, err
+		}
+	}
+
+	related := dex.Related(ctx, id, keg.RelatedOptions{Limit: opts.Limit})
+
+	lines := make([]string, 0, len(related))
+	for _, r := range related {
+		entry := keg.NodeIndexEntry{ID: r.ID.Path()}
+		if ref := dex.GetRef(ctx, r.ID); ref != nil {
+			entry = *ref
+		}
+		if opts.IdOnly {
+			lines = append(lines, entry.ID)
+			continue
+		}
+		if tmpl != nil {
+			line, err := executeNodeTemplate(tmpl, &NodeTemplateData{NodeIndexEntry: entry, Score: r.Score, ctx: ctx, k: k})
+			if err != nil {
+				return []string{}, err
+			}
+			lines = append(lines, line)
+			continue
+		}
+
+		format := opts.Format
+		if format == "" {
+			format = "%i\t%s\t%t"
+		}
+		line := strings.ReplaceAll(format, "%%", "\x00")
+		line = strings.ReplaceAll(line, "%i", entry.ID)
+		line = strings.ReplaceAll(line, "%d", entry.Updated.Format(time.RFC3339))
+		line = strings.ReplaceAll(line, "%s", strconv.FormatFloat(r.Score, 'f', -1, 64))
+		line = strings.ReplaceAll(line, "%t", entry.Title)
+		lines = append(lines, strings.ReplaceAll(line, "\x00", "%"))
+	}
+	return lines, nil
+}
@@ -18,12 +18,18 @@ type RemoveOptions struct {
 	// Query is an optional boolean expression (tags and/or key=value attr
 	// predicates) that selects additional nodes to remove.
 	Query string
+
+	// Force removes nodes that other nodes still link to. Without it Remove
+	// refuses and leaves the keg untouched.
+	Force bool
 }
 
-func (t *Tap) Remove(ctx context.Context, opts RemoveOptions) error {
+// Remove deletes nodes and returns the files whose links to them were
+// pointed at node 0.
+func (t *Tap) Remove(ctx context.Context, opts RemoveOptions) (*keg.LinkRepairReport, error) {
 	k, err := t.resolveKeg(ctx, opts.KegTargetOptions)
 	if err != nil {
-		return fmt.Errorf("unable to open keg: %w", err)
+		return nil, fmt.Errorf("unable to open keg: %w", err)
 	}
 
 	nodeIDs := opts.NodeIDs
@@ -31,12 +37,12 @@ func (t *Tap) Remove(ctx context.Context, opts RemoveOptions) error {
 	if q := strings.TrimSpace(opts.Query); q != "" {
 		dex, dexErr := k.Dex(ctx)
 		if dexErr != nil {
-			return fmt.Errorf("unable to read dex: %w", dexErr)
+			return nil, fmt.Errorf("unable to read dex: %w", dexErr)
 		}
 		entries := dex.Nodes(ctx)
 		matchedPaths, evalErr := evalQueryExpr(ctx, k, dex, entries, q)
 		if evalErr != nil {
-			return fmt.Errorf("invalid query expression: %w", evalErr)
+			return nil, fmt.Errorf("invalid query expression: %w", evalErr)
 		}
 		seen := make(map[string]struct{})
 		for path := range matchedPaths {
@@ -53,26 +59,67 @@ func (t *Tap) Remove(ctx context.Context, opts RemoveOptions) error {
 	}
 
 	if len(nodeIDs) == 0 {
-		return fmt.Errorf("at least one node ID is required")
+		return nil, fmt.Errorf("at least one node ID is required")
 	}
 
+	ids := make([]keg.NodeId, 0, len(nodeIDs))
 	for _, nodeID := range nodeIDs {
 		node, err := keg.ParseNode(nodeID)
 		if err != nil {
-			return fmt.Errorf("invalid node ID %q: %w", nodeID, err)
+			return nil, fmt.Errorf("invalid node ID %q: %w", nodeID, err)
 		}
 		if node == nil {
-			return fmt.Errorf("invalid node ID %q: %w", nodeID, keg.ErrInvalid)
+			return nil, fmt.Errorf("invalid node ID %q: %w", nodeID, keg.ErrInvalid)
+		}
+		ids = append(ids, keg.NodeId{ID: node.ID, Code: node.Code})
+	}
+
+	if !opts.Force {
+		if err := checkRemoveBacklinks(ctx, k, ids); err != nil {
+			return nil, err
 		}
+	}
 
-		id := keg.NodeId{ID: node.ID, Code: node.Code}
-		if err := k.Remove(ctx, id); err != nil {
+	report := &keg.LinkRepairReport{}
+	for _, id := range ids {
+		removed, err := k.Remove(ctx, id)
+		if removed != nil {
+			report.Files = append(report.Files, removed.Files...)
+		}
+		if err != nil {
 			if errors.Is(err, keg.ErrNotExist) {
-				return fmt.Errorf("node %s not found", id.Path())
+				return report, keg.NewNodeNotFoundError(id)
 			}
-			return fmt.Errorf("unable to remove node %s: %w", id.Path(), err)
+			return report, fmt.Errorf("unable to remove node %s: %w", id.Path(), err)
 		}
 	}
 
+	return report, nil
+}
+
+// checkRemoveBacklinks refuses the removal when a node in ids is linked from
+// a node that is not itself being removed.
+func checkRemoveBacklinks(ctx context.Context, k *keg.Keg, ids []keg.NodeId) error {
+	dex, err := k.Dex(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to read dex: %w", err)
+	}
+	removing := make(map[string]struct{}, len(ids))
+	for _, id := range ids {
+		removing[id.Path()] = struct{}{}
+	}
+	for _, id := range ids {
+		backlinks, _ := dex.Backlinks(ctx, id)
+		var from []string
+		for _, src := range backlinks {
+			if _, ok := removing[src.Path()]; !ok {
+				from = append(from, src.Path())
+			}
+		}
+		if len(from) > 0 {
+			return fmt.Errorf("node %s is linked from %s; use --force to remove it and point those links at node 0",
+				id.Path(), strings.Join(from, ", "))
+		}
+	}
 	return nil
 }
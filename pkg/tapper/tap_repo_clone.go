@@ -0,0 +1,285 @@
+package tapper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jlrickert/tapper/pkg/keg"
+	kegurl "github.com/jlrickert/tapper/pkg/keg_url"
+)
+
+// CloneRepoOptions configures Tap.CloneRepo.
+type CloneRepoOptions struct {
+	// Source is a configured keg alias or a keg target (path or URL).
+	Source string
+
+	// Alias is registered in the user config for the clone.
+	Alias string
+
+	// Path is the destination directory. Defaults to <kegSearchPath>/<alias>.
+	Path string
+}
+
+// CloneRepoResult describes a cloned keg.
+type CloneRepoResult struct {
+	Target *kegurl.Target
+	Nodes  int
+}
+
+// CloneRepo copies the config, nodes, archived nodes, items and indexes of
+// the source keg into a new filesystem keg and registers it under opts.Alias
+// in the user config. Hooks and other exec settings are left out of the
+// cloned config. The destination must be missing or empty; a failed
+// clone leaves it untouched. Sources are opened like any other keg target, so schemes without a
+// repository backend are rejected.
+func (t *Tap) CloneRepo(ctx context.Context, opts CloneRepoOptions) (*CloneRepoResult, error) {
+	alias := strings.TrimSpace(opts.Alias)
+	source := strings.TrimSpace(opts.Source)
+	if alias == "" || source == "" {
+		return nil, fmt.Errorf("source and alias are required: %w", keg.ErrInvalid)
+	}
+
+	userCfg, err := t.ConfigService.UserConfig(false)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load user config: %w", err)
+	}
+	if _, ok := userCfg.Kegs()[alias]; ok {
+		return nil, fmt.Errorf("keg alias %q is already configured: %w", alias, keg.ErrExist)
+	}
+
+	srcTarget, err := t.cloneSourceTarget(source)
+	if err != nil {
+		return nil, err
+	}
+	src, err := t.KegService.newKeg(ctx, *srcTarget)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open source keg %s: %w", srcTarget.String(), err)
+	}
+	initialized, err := keg.RepoContainsKeg(ctx, src.Repo)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open source keg %s: %w", srcTarget.String(), err)
+	}
+	if !initialized {
+		return nil, fmt.Errorf("source %s is not an initialized keg: %w", srcTarget.String(), keg.ErrNotExist)
+	}
+
+	dstPath := strings.TrimSpace(opts.Path)
+	if dstPath == "" {
+		base := t.ConfigService.Config(true).PrimaryKegSearchPath()
+		if base == "" {
+			return nil, fmt.Errorf("kegSearchPaths not defined in user config; pass a destination path: %w", keg.ErrNotExist)
+		}
+		dstPath = filepath.Join(base, alias)
+	}
+	dstPath, err = t.Runtime.ResolvePath(dstPath, false)
+	if err != nil {
+		return nil, fmt.Errorf("unable to resolve destination %q: %w", opts.Path, err)
+	}
+	dstTarget := kegurl.NewFile(dstPath)
+	dst, err := t.KegService.newKeg(ctx, dstTarget)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open destination keg: %w", err)
+	}
+	exists, err := keg.RepoContainsKeg(ctx, dst.Repo)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, fmt.Errorf("destination %s already holds a keg: %w", dstPath, keg.ErrExist)
+	}
+	entries, err := t.Runtime.ReadDir(dstPath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("unable to read destination %s: %w", dstPath, err)
+	}
+	if len(entries) > 0 {
+		return nil, fmt.Errorf("destination %s is not empty: %w", dstPath, keg.ErrExist)
+	}
+
+	// Clone into a sibling staging directory and move it into place once
+	// complete, so a failed clone never leaves a partial keg behind.
+	staging := filepath.Join(filepath.Dir(dstPath), "."+filepath.Base(dstPath)+".clone")
+	if err := t.Runtime.Remove(staging, true); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("unable to clear staging directory %s: %w", staging, err)
+	}
+	nodes, err := t.cloneIntoStaging(ctx, src.Repo, staging)
+	if err != nil {
+		_ = t.Runtime.Remove(staging, true)
+		return nil, fmt.Errorf("unable to clone %s: %w", srcTarget.String(), err)
+	}
+	if entries != nil {
+		if err := t.Runtime.Remove(dstPath, false); err != nil {
+			_ = t.Runtime.Remove(staging, true)
+			return nil, fmt.Errorf("unable to replace destination %s: %w", dstPath, err)
+		}
+	}
+	if err := t.Runtime.Rename(staging, dstPath); err != nil {
+		_ = t.Runtime.Remove(staging, true)
+		return nil, fmt.Errorf("unable to move clone into %s: %w", dstPath, err)
+	}
+
+	if err := userCfg.AddKeg(alias, dstTarget); err != nil {
+		return nil, err
+	}
+	if err := userCfg.Write(t.Runtime, t.PathService.UserConfig()); err != nil {
+		return nil, fmt.Errorf("unable to save user config: %w", err)
+	}
+	t.ConfigService.ResetCache()
+	return &CloneRepoResult{Target: dst.Target, Nodes: nodes}, nil
+}
+
+// cloneSourceTarget resolves source as a configured alias first and as a
+// target string otherwise.
+func (t *Tap) cloneSourceTarget(source string) (*kegurl.Target, error) {
+	if _, ok := t.ConfigService.Config(true).Kegs()[source]; ok {
+		return t.ConfigService.ResolveTarget(source, true)
+	}
+	target, err := kegurl.Parse(source)
+	if err != nil {
+		return nil, fmt.Errorf("invalid keg target %q: %w", source, err)
+	}
+	if target.Scheme() == kegurl.SchemeFile {
+		path, err := t.Runtime.ResolvePath(target.Path(), false)
+		if err != nil {
+			return nil, fmt.Errorf("unable to resolve source %q: %w", source, err)
+		}
+		file := kegurl.NewFile(path)
+		return &file, nil
+	}
+	return target, nil
+}
+
+// cloneIntoStaging copies src into a new file keg at path.
+func (t *Tap) cloneIntoStaging(ctx context.Context, src keg.Repository, path string) (int, error) {
+	stage, err := t.KegService.newKeg(ctx, kegurl.NewFile(path))
+	if err != nil {
+		return 0, fmt.Errorf("unable to open staging keg: %w", err)
+	}
+	return copyKegRepo(ctx, src, stage.Repo)
+}
+
+// copyKegRepo writes every node with its items, the archived nodes, every
+// index and finally the config of src into dst, so dst only reads as a keg
+// once the copy is complete. Archived nodes keep their stats and items when
+// src can read them. The config is written without its exec settings. It
+// returns the number of active nodes copied.
+func copyKegRepo(ctx context.Context, src, dst keg.Repository) (int, error) {
+	cfg, err := src.ReadConfig(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("unable to read keg config: %w", err)
+	}
+
+	ids, err := src.ListNodes(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("unable to list nodes: %w", err)
+	}
+	for _, id := range ids {
+		if err := ctx.Err(); err != nil {
+			return 0, fmt.Errorf("clone interrupted: %w", err)
+		}
+		content, err := src.ReadContent(ctx, id)
+		if err != nil {
+			return 0, fmt.Errorf("unable to read node %s: %w", id.Path(), err)
+		}
+		meta, err := readOptionalNodeMeta(ctx, src, id)
+		if err != nil {
+			return 0, fmt.Errorf("unable to read node %s metadata: %w", id.Path(), err)
+		}
+		stats, err := src.ReadStats(ctx, id)
+		if err != nil && !errors.Is(err, keg.ErrNotExist) {
+			return 0, fmt.Errorf("unable to read node %s stats: %w", id.Path(), err)
+		}
+		if err := dst.WriteNode(ctx, id, content, meta, stats); err != nil {
+			return 0, fmt.Errorf("unable to write node %s: %w", id.Path(), err)
+		}
+		items, err := keg.ReadNodeItems(ctx, src, id)
+		if err != nil {
+			return 0, fmt.Errorf("unable to read node %s items: %w", id.Path(), err)
+		}
+		if err := keg.WriteNodeItems(ctx, dst, id, items); err != nil {
+			return 0, fmt.Errorf("unable to write node %s items: %w", id.Path(), err)
+		}
+	}
+
+	if err := copyArchivedNodes(ctx, src, dst); err != nil {
+		return 0, err
+	}
+
+	names, err := src.ListIndexes(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("unable to list indexes: %w", err)
+	}
+	for _, name := range names {
+		data, err := src.GetIndex(ctx, name)
+		if err != nil {
+			return 0, fmt.Errorf("unable to read index %s: %w", name, err)
+		}
+		if err := dst.WriteIndex(ctx, name, data); err != nil {
+			return 0, fmt.Errorf("unable to write index %s: %w", name, err)
+		}
+	}
+	// Hooks and other command settings are not trusted on the new machine,
+	// so the clone starts without them.
+	cfg.StripExecSettings()
+	if err := dst.WriteConfig(ctx, cfg); err != nil {
+		return 0, fmt.Errorf("unable to write keg config: %w", err)
+	}
+	return len(ids), nil
+}
+
+// copyArchivedNodes writes each archived node of src into dst and archives
+// it there. Repositories without an archive are skipped.
+func copyArchivedNodes(ctx context.Context, src, dst keg.Repository) error {
+	srcArchive, ok := src.(keg.RepositoryArchive)
+	if !ok {
+		return nil
+	}
+	ids, err := srcArchive.ListArchived(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to list archived nodes: %w", err)
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	dstArchive, ok := dst.(keg.RepositoryArchive)
+	if !ok {
+		return fmt.Errorf("destination cannot hold archived nodes: %w", keg.ErrNotSupported)
+	}
+	state, hasState := src.(keg.RepositoryArchivedState)
+	for _, id := range ids {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("clone interrupted: %w", err)
+		}
+		content, err := srcArchive.ReadArchivedContent(ctx, id)
+		if err != nil {
+			return fmt.Errorf("unable to read archived node %s: %w", id.Path(), err)
+		}
+		meta, err := srcArchive.ReadArchivedMeta(ctx, id)
+		if err != nil && !errors.Is(err, keg.ErrNotExist) {
+			return fmt.Errorf("unable to read archived node %s metadata: %w", id.Path(), err)
+		}
+		var stats *keg.NodeStats
+		var items *keg.NodeItems
+		if hasState {
+			if stats, err = state.ReadArchivedStats(ctx, id); err != nil && !errors.Is(err, keg.ErrNotExist) {
+				return fmt.Errorf("unable to read archived node %s stats: %w", id.Path(), err)
+			}
+			if items, err = state.ReadArchivedItems(ctx, id); err != nil {
+				return fmt.Errorf("unable to read archived node %s items: %w", id.Path(), err)
+			}
+		}
+		if err := dst.WriteNode(ctx, id, content, meta, stats); err != nil {
+			return fmt.Errorf("unable to write archived node %s: %w", id.Path(), err)
+		}
+		if err := keg.WriteNodeItems(ctx, dst, id, items); err != nil {
+			return fmt.Errorf("unable to write archived node %s items: %w", id.Path(), err)
+		}
+		if err := dstArchive.ArchiveNode(ctx, id); err != nil {
+			return fmt.Errorf("unable to archive node %s: %w", id.Path(), err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,332 @@
+package tapper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/jlrickert/tapper/pkg/keg"
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+	"go.starlark.net/syntax"
+)
+
+// RunScriptOptions configures a Starlark script run.
+type RunScriptOptions struct {
+	KegTargetOptions
+
+	// Path is the script file. It names the script in error messages when
+	// Source is set.
+	Path string
+
+	// Source is the script text. When nil the script is read from Path.
+	Source []byte
+
+	// Args are passed to the script as the list args.
+	Args []string
+
+	// Output receives the script's print output. Nil discards it.
+	Output io.Writer
+}
+
+// RunScript runs a Starlark script against a keg. Besides the Starlark
+// built-ins the script sees:
+//
+//	args                                list of extra command-line arguments
+//	query(expr="", where="")            nodes matching a query expression, as
+//	                                    structs with id, title, created and
+//	                                    updated
+//	cat(id)                             node content
+//	create(title="", body="", tags=[], attrs={})
+//	                                    create a node and return its id
+//	tag(id, *tags)                      add tags to a node
+//	untag(id, *tags)                    remove tags from a node
+//	set_meta(id, key, value)            set a meta.yaml key; None removes it
+//
+// Scripts cannot read files, run commands or load modules. Node ids may be
+// ints or strings; strings that are not ids are matched against titles.
+// Run under WithDryRun to rehearse a script without writing to the keg.
+func (t *Tap) RunScript(ctx context.Context, opts RunScriptOptions) error {
+	src := opts.Source
+	if src == nil {
+		data, err := t.Runtime.ReadFile(opts.Path)
+		if err != nil {
+			return fmt.Errorf("unable to read script: %w", err)
+		}
+		src = data
+	}
+	k, err := t.resolveKeg(ctx, opts.KegTargetOptions)
+	if err != nil {
+		return fmt.Errorf("unable to open keg: %w", err)
+	}
+
+	out := opts.Output
+	if out == nil {
+		out = io.Discard
+	}
+	thread := &starlark.Thread{
+		Name: "tap run",
+		Print: func(_ *starlark.Thread, msg string) {
+			fmt.Fprintln(out, msg)
+		},
+	}
+	thread.SetLocal("ctx", ctx)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			thread.Cancel(ctx.Err().Error())
+		case <-done:
+		}
+	}()
+
+	api := &scriptAPI{tap: t, keg: k, target: opts.KegTargetOptions}
+	args := make([]starlark.Value, 0, len(opts.Args))
+	for _, arg := range opts.Args {
+		args = append(args, starlark.String(arg))
+	}
+	predeclared := starlark.StringDict{
+		"args":     starlark.NewList(args),
+		"query":    starlark.NewBuiltin("query", api.query),
+		"cat":      starlark.NewBuiltin("cat", api.cat),
+		"create":   starlark.NewBuiltin("create", api.create),
+		"tag":      starlark.NewBuiltin("tag", api.tag),
+		"untag":    starlark.NewBuiltin("untag", api.untag),
+		"set_meta": starlark.NewBuiltin("set_meta", api.setMeta),
+	}
+
+	name := opts.Path
+	if name == "" {
+		name = "<script>"
+	}
+	fileOpts := &syntax.FileOptions{Set: true, While: true, TopLevelControl: true, GlobalReassign: true}
+	_, err = starlark.ExecFileOptions(fileOpts, thread, name, src, predeclared)
+	var evalErr *starlark.EvalError
+	if errors.As(err, &evalErr) {
+		return fmt.Errorf("script failed: %s", evalErr.Backtrace())
+	}
+	if err != nil {
+		return fmt.Errorf("script failed: %w", err)
+	}
+	return nil
+}
+
+// scriptAPI implements the keg built-ins of RunScript.
+type scriptAPI struct {
+	tap    *Tap
+	keg    *keg.Keg
+	target KegTargetOptions
+}
+
+func (a *scriptAPI) ctx(thread *starlark.Thread) context.Context {
+	if ctx, ok := thread.Local("ctx").(context.Context); ok {
+		return ctx
+	}
+	return context.Background()
+}
+
+func (a *scriptAPI) node(thread *starlark.Thread, v starlark.Value) (keg.NodeId, error) {
+	var arg string
+	switch v := v.(type) {
+	case starlark.Int:
+		arg = v.String()
+	case starlark.String:
+		arg = string(v)
+	default:
+		return keg.NodeId{}, fmt.Errorf("node id must be an int or string, not %s", v.Type())
+	}
+	return a.tap.resolveNode(a.ctx(thread), a.keg, arg, false)
+}
+
+func (a *scriptAPI) query(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var expr, where string
+	if err := starlark.UnpackArgs(b.Name(), args, kwargs, "expr?", &expr, "where?", &where); err != nil {
+		return nil, err
+	}
+	entries, err := a.tap.ListEntries(a.ctx(thread), ListOptions{KegTargetOptions: a.target, Query: expr, Where: where})
+	if err != nil {
+		return nil, err
+	}
+	nodes := make([]starlark.Value, 0, len(entries))
+	for _, e := range entries {
+		nodes = append(nodes, starlarkstruct.FromStringDict(starlarkstruct.Default, starlark.StringDict{
+			"id":      starlark.String(e.ID),
+			"title":   starlark.String(e.Title),
+			"created": starlark.String(e.Created.Format(time.RFC3339)),
+			"updated": starlark.String(e.Updated.Format(time.RFC3339)),
+		}))
+	}
+	return starlark.NewList(nodes), nil
+}
+
+func (a *scriptAPI) cat(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var idArg starlark.Value
+	if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 1, &idArg); err != nil {
+		return nil, err
+	}
+	id, err := a.node(thread, idArg)
+	if err != nil {
+		return nil, err
+	}
+	content, err := a.keg.GetContent(a.ctx(thread), id)
+	if err != nil {
+		return nil, err
+	}
+	return starlark.String(content), nil
+}
+
+func (a *scriptAPI) create(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var title, body string
+	var tags *starlark.List
+	var attrs *starlark.Dict
+	if err := starlark.UnpackArgs(b.Name(), args, kwargs, "title?", &title, "body?", &body, "tags?", &tags, "attrs?", &attrs); err != nil {
+		return nil, err
+	}
+	opts := &keg.CreateOptions{Title: title, Body: []byte(body)}
+	if tags != nil {
+		strs, err := starlarkStrings(b.Name(), tags)
+		if err != nil {
+			return nil, err
+		}
+		opts.Tags = strs
+	}
+	if attrs != nil {
+		v, err := fromStarlark(attrs)
+		if err != nil {
+			return nil, fmt.Errorf("%s: attrs: %w", b.Name(), err)
+		}
+		opts.Attrs = v.(map[string]any)
+	}
+	id, err := a.keg.Create(a.ctx(thread), opts)
+	if err != nil {
+		return nil, err
+	}
+	return starlark.String(id.Path()), nil
+}
+
+func (a *scriptAPI) tag(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	return a.retag(thread, b, args, kwargs, (*keg.NodeMeta).AddTag)
+}
+
+func (a *scriptAPI) untag(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	return a.retag(thread, b, args, kwargs, (*keg.NodeMeta).RmTag)
+}
+
+func (a *scriptAPI) retag(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple, apply func(*keg.NodeMeta, string)) (starlark.Value, error) {
+	if len(kwargs) > 0 {
+		return nil, fmt.Errorf("%s: unexpected keyword arguments", b.Name())
+	}
+	if len(args) < 1 {
+		return nil, fmt.Errorf("%s: missing node id", b.Name())
+	}
+	tags, err := starlarkStrings(b.Name(), args[1:])
+	if err != nil {
+		return nil, err
+	}
+	return starlark.None, a.updateMeta(thread, args[0], func(meta *keg.NodeMeta) error {
+		for _, tag := range tags {
+			apply(meta, tag)
+		}
+		return nil
+	})
+}
+
+func (a *scriptAPI) setMeta(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var idArg, value starlark.Value
+	var key string
+	if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 3, &idArg, &key, &value); err != nil {
+		return nil, err
+	}
+	v, err := fromStarlark(value)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", b.Name(), err)
+	}
+	return starlark.None, a.updateMeta(thread, idArg, func(meta *keg.NodeMeta) error {
+		return meta.Set(a.ctx(thread), key, v)
+	})
+}
+
+func (a *scriptAPI) updateMeta(thread *starlark.Thread, idArg starlark.Value, update func(*keg.NodeMeta) error) error {
+	ctx := a.ctx(thread)
+	id, err := a.node(thread, idArg)
+	if err != nil {
+		return err
+	}
+	meta, err := a.keg.GetMeta(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := update(meta); err != nil {
+		return err
+	}
+	return a.keg.SetMeta(ctx, id, meta)
+}
+
+// starlarkStrings converts an iterable of Starlark strings.
+func starlarkStrings(fn string, it starlark.Iterable) ([]string, error) {
+	var out []string
+	iter := it.Iterate()
+	defer iter.Done()
+	var v starlark.Value
+	for iter.Next(&v) {
+		s, ok := starlark.AsString(v)
+		if !ok {
+			return nil, fmt.Errorf("%s: want string, got %s", fn, v.Type())
+		}
+		out = append(out, s)
+	}
+	return out, nil
+}
+
+// fromStarlark converts a Starlark value to the plain Go value stored in
+// meta.yaml.
+func fromStarlark(v starlark.Value) (any, error) {
+	switch v := v.(type) {
+	case starlark.NoneType:
+		return nil, nil
+	case starlark.Bool:
+		return bool(v), nil
+	case starlark.String:
+		return string(v), nil
+	case starlark.Int:
+		i, ok := v.Int64()
+		if !ok {
+			return nil, fmt.Errorf("int %s out of range", v)
+		}
+		return i, nil
+	case starlark.Float:
+		return float64(v), nil
+	case *starlark.List, starlark.Tuple:
+		var out []any
+		iter := v.(starlark.Iterable).Iterate()
+		defer iter.Done()
+		var elem starlark.Value
+		for iter.Next(&elem) {
+			e, err := fromStarlark(elem)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, e)
+		}
+		return out, nil
+	case *starlark.Dict:
+		out := make(map[string]any, v.Len())
+		for _, item := range v.Items() {
+			key, ok := starlark.AsString(item[0])
+			if !ok {
+				return nil, fmt.Errorf("dict key must be a string, not %s", item[0].Type())
+			}
+			e, err := fromStarlark(item[1])
+			if err != nil {
+				return nil, err
+			}
+			out[key] = e
+		}
+		return out, nil
+	default:
+		return nil, fmt.Errorf("unsupported value of type %s", v.Type())
+	}
+}
@@ -0,0 +1,41 @@
+package tapper_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jlrickert/tapper/pkg/tapper"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunScript_Source(t *testing.T) {
+	t.Parallel()
+	fx := NewSandbox(t)
+	tap := setupTapWithKeg(t, fx)
+
+	var out bytes.Buffer
+	err := tap.RunScript(fx.Context(), tapper.RunScriptOptions{
+		Source: []byte(`
+id = create(title="From a script", tags=["auto"])
+set_meta(id, "status", "draft")
+set_meta(id, "status", None)
+print(id, [n.title for n in query("auto")])
+`),
+		Output: &out,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "1 [\"From a script\"]\n", out.String())
+}
+
+func TestRunScript_CancelStopsScript(t *testing.T) {
+	t.Parallel()
+	fx := NewSandbox(t)
+	tap := setupTapWithKeg(t, fx)
+
+	ctx, cancel := context.WithTimeout(fx.Context(), 50*time.Millisecond)
+	defer cancel()
+	err := tap.RunScript(ctx, tapper.RunScriptOptions{Source: []byte("while True:\n    pass\n")})
+	require.ErrorContains(t, err, "context deadline exceeded")
+}
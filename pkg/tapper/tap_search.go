@@ -0,0 +1,299 @@
+package tapper
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/jlrickert/tapper/pkg/keg"
+	"github.com/jlrickert/tapper/pkg/keg/search"
+)
+
+// SearchOptions configures Tap.Search.
+type SearchOptions struct {
+	KegTargetOptions
+
+	// Query is the text to search for.
+	Query string
+
+	// Semantic ranks nodes by embedding similarity to Query using the keg's
+	// search.embedder backend instead of matching text literally.
+	Semantic bool
+
+	// Regex treats Query as a regular expression and reports every matching
+	// line instead of a ranked node list.
+	Regex bool
+
+	// IgnoreCase makes regex matching case-insensitive. Plain searches always
+	// ignore case.
+	IgnoreCase bool
+
+	// Limit caps the number of results. 0 means no limit.
+	Limit int
+
+	// Format to use. %i is node id
+	// %d is date
+	// %t is node title
+	// %s is similarity score (semantic search only)
+	// %% for literal %
+	// A format containing {{ is a Go template over NodeTemplateData.
+	Format string
+
+	IdOnly bool
+
+	// AllKegs searches every configured keg instead of the resolved one.
+	// Only plain searches are supported; node IDs are emitted as alias:id.
+	AllKegs bool
+}
+
+// Search finds nodes matching Query. Plain searches match the query text
+// case-insensitively and rank hits by relevance, like `grep --rank`.
+// Semantic searches embed the query and rank every node by cosine similarity,
+// refreshing the dex/vectors.jsonl cache for new or changed nodes first.
+func (t *Tap) Search(ctx context.Context, opts SearchOptions) ([]string, error) {
+	query := strings.TrimSpace(opts.Query)
+	if query == "" {
+		return []string{}, fmt.Errorf("search query is required: %w", keg.ErrInvalid)
+	}
+	if opts.AllKegs {
+		return t.searchAllKegs(ctx, opts, query)
+	}
+	if opts.Regex && !opts.Semantic {
+		results, err := t.SearchMatches(ctx, opts)
+		if err != nil {
+			return []string{}, err
+		}
+		return RenderSearchResults(results, opts, "", ""), nil
+	}
+	if !opts.Semantic {
+		format := opts.Format
+		if format == "" {
+			format = "%i\t%t"
+		}
+		lines, err := t.Grep(ctx, GrepOptions{
+			KegTargetOptions: opts.KegTargetOptions,
+			Query:            regexp.QuoteMeta(query),
+			IgnoreCase:       true,
+			Rank:             true,
+			Format:           format,
+			IdOnly:           opts.IdOnly,
+		})
+		if err != nil {
+			return []string{}, err
+		}
+		if opts.Limit > 0 && len(lines) > opts.Limit {
+			lines = lines[:opts.Limit]
+		}
+		return lines, nil
+	}
+
+	k, err := t.resolveKeg(ctx, opts.KegTargetOptions)
+	if err != nil {
+		return []string{}, fmt.Errorf("unable to open keg: %w", err)
+	}
+	dex, err := k.Dex(ctx)
+	if err != nil {
+		return []string{}, fmt.Errorf("unable to read dex: %w", err)
+	}
+	cfg, err := k.Config(ctx)
+	if err != nil {
+		return []string{}, fmt.Errorf("unable to read keg config: %w", err)
+	}
+	var embCfg *keg.EmbedderConfig
+	if cfg != nil && cfg.Search != nil {
+		embCfg = cfg.Search.Embedder
+	}
+	if embCfg != nil && !k.TrustsConfig(cfg) {
+		return []string{}, fmt.Errorf("semantic search unavailable: search.embedder is not trusted; review it and run `tap repo trust`: %w", keg.ErrNotSupported)
+	}
+	emb, err := search.NewEmbedder(embCfg, t.Runtime.Get)
+	if err != nil {
+		return []string{}, fmt.Errorf("semantic search unavailable: %w", err)
+	}
+
+	entries := dex.Nodes(ctx)
+	docs := make([]search.Document, 0, len(entries))
+	for _, entry := range entries {
+		id, parseErr := keg.ParseNode(entry.ID)
+		if parseErr != nil || id == nil {
+			continue
+		}
+		raw, readErr := k.Repo.ReadContent(ctx, *id)
+		if readErr != nil {
+			if errors.Is(readErr, keg.ErrNotExist) {
+				continue
+			}
+			return []string{}, fmt.Errorf("unable to read node content: %w", readErr)
+		}
+		docs = append(docs, search.Document{ID: entry.ID, Text: entry.Title + "\n\n" + string(raw)})
+	}
+
+	store, err := search.LoadStore(ctx, k.Repo)
+	if err != nil {
+		return []string{}, err
+	}
+	changed, err := store.Sync(ctx, emb, docs)
+	if changed {
+		// Persist whatever was embedded before a failure so it is not redone.
+		if saveErr := store.Save(ctx, k.Repo); saveErr != nil && err == nil {
+			err = saveErr
+		}
+	}
+	if err != nil {
+		return []string{}, err
+	}
+
+	qv, err := emb.Embed(ctx, []string{query})
+	if err != nil {
+		return []string{}, fmt.Errorf("unable to embed query: %w", err)
+	}
+	if len(qv) != 1 {
+		return []string{}, fmt.Errorf("embedder returned %d vectors for the query", len(qv))
+	}
+
+	var tmpl *template.Template
+	if !opts.IdOnly && isNodeTemplate(opts.Format) {
+		if tmpl, err = parseNodeTemplate(opts.Format); err != nil {
+			return []string{}, err
+		}
+	}
+
+	hits := store.Search(qv[0], opts.Limit)
+	lines := make([]string, 0, len(hits))
+	for _, hit := range hits {
+		entry := keg.NodeIndexEntry{ID: hit.ID}
+		if id, parseErr := keg.ParseNode(hit.ID); parseErr == nil && id != nil {
+			if ref := dex.GetRef(ctx, *id); ref != nil {
+				entry = *ref
+			}
+		}
+		if opts.IdOnly {
+			lines = append(lines, entry.ID)
+			continue
+		}
+		if tmpl != nil {
+			line, err := executeNodeTemplate(tmpl, &NodeTemplateData{NodeIndexEntry: entry, Score: hit.Score, ctx: ctx, k: k})
+			if err != nil {
+				return []string{}, err
+			}
+			lines = append(lines, line)
+			continue
+		}
+
+		format := opts.Format
+		if format == "" {
+			format = "%i\t%s\t%t"
+		}
+		line := strings.ReplaceAll(format, "%%", "\x00")
+		line = strings.ReplaceAll(line, "%i", entry.ID)
+		line = strings.ReplaceAll(line, "%d", entry.Updated.Format(time.RFC3339))
+		line = strings.ReplaceAll(line, "%s", strconv.FormatFloat(hit.Score, 'f', 4, 64))
+		line = strings.ReplaceAll(line, "%t", entry.Title)
+		lines = append(lines, strings.ReplaceAll(line, "\x00", "%"))
+	}
+	return lines, nil
+}
+
+// SearchResult is a node with the lines matched by a text search.
+type SearchResult struct {
+	ID      string             `json:"id"`
+	Title   string             `json:"title"`
+	Matches []search.LineMatch `json:"matches"`
+}
+
+// SearchMatches runs a text search and returns each matching node with the
+// matched lines and byte spans, ordered by node ID. Query is a regular
+// expression when Regex is set and literal, case-insensitive text otherwise.
+// Node content is scanned one node at a time. Semantic searches have no spans
+// and are rejected.
+func (t *Tap) SearchMatches(ctx context.Context, opts SearchOptions) ([]SearchResult, error) {
+	if opts.Semantic {
+		return nil, fmt.Errorf("semantic search does not report match spans: %w", keg.ErrInvalid)
+	}
+	if strings.TrimSpace(opts.Query) == "" {
+		return nil, fmt.Errorf("search query is required: %w", keg.ErrInvalid)
+	}
+	pattern := opts.Query
+	if !opts.Regex {
+		pattern = "(?i)" + regexp.QuoteMeta(strings.TrimSpace(opts.Query))
+	} else if opts.IgnoreCase {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid query regex %q: %w", opts.Query, err)
+	}
+
+	k, err := t.resolveKeg(ctx, opts.KegTargetOptions)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open keg: %w", err)
+	}
+	dex, err := k.Dex(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read dex: %w", err)
+	}
+
+	entries := dex.Nodes(ctx)
+	sortNodeIndexEntries(entries)
+	results := make([]SearchResult, 0)
+	for _, entry := range entries {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		id, parseErr := keg.ParseNode(entry.ID)
+		if parseErr != nil || id == nil {
+			continue
+		}
+		raw, readErr := k.Repo.ReadContent(ctx, *id)
+		if readErr != nil {
+			if errors.Is(readErr, keg.ErrNotExist) {
+				continue
+			}
+			return nil, fmt.Errorf("unable to read node content: %w", readErr)
+		}
+		lines, scanErr := search.ScanRegex(re, bytes.NewReader(raw))
+		if scanErr != nil {
+			return nil, fmt.Errorf("unable to scan node %s: %w", entry.ID, scanErr)
+		}
+		if len(lines) == 0 {
+			continue
+		}
+		results = append(results, SearchResult{ID: entry.ID, Title: entry.Title, Matches: lines})
+		if opts.Limit > 0 && len(results) >= opts.Limit {
+			break
+		}
+	}
+	return results, nil
+}
+
+// RenderSearchResults formats results grep-style: a header per node followed
+// by numbered matching lines. Each match is wrapped in open and close, for
+// example ANSI color codes; pass empty strings for plain output.
+func RenderSearchResults(results []SearchResult, opts SearchOptions, open, close string) []string {
+	lines := make([]string, 0)
+	for i, res := range results {
+		if opts.IdOnly {
+			lines = append(lines, res.ID)
+			continue
+		}
+		if i > 0 {
+			lines = append(lines, "")
+		}
+		header := strings.TrimSpace(res.Title)
+		if header == "" {
+			lines = append(lines, res.ID)
+		} else {
+			lines = append(lines, res.ID+" "+header)
+		}
+		for _, m := range res.Matches {
+			lines = append(lines, fmt.Sprintf("%d:%s", m.Line, search.Highlight(m.Text, m.Spans, open, close)))
+		}
+	}
+	return lines
+}
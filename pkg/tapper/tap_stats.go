@@ -13,38 +13,47 @@ type StatsOptions struct {
 	NodeID string
 
 	KegTargetOptions
+
+	// Exact disables fuzzy title matching for non-numeric node arguments.
+	Exact bool
 }
 
 func (t *Tap) Stats(ctx context.Context, opts StatsOptions) (string, error) {
-	k, err := t.resolveKeg(ctx, opts.KegTargetOptions)
+	_, stats, err := t.NodeStats(ctx, opts)
 	if err != nil {
-		return "", fmt.Errorf("unable to open keg: %w", err)
+		return "", err
 	}
+	return formatStatsOnlyYAML(ctx, stats), nil
+}
 
-	node, err := keg.ParseNode(opts.NodeID)
+// NodeStats resolves opts.NodeID and returns its programmatic stats. Nodes
+// without a stats file yield empty stats.
+func (t *Tap) NodeStats(ctx context.Context, opts StatsOptions) (keg.NodeId, *keg.NodeStats, error) {
+	k, err := t.resolveKeg(ctx, opts.KegTargetOptions)
 	if err != nil {
-		return "", fmt.Errorf("invalid node ID %q: %w", opts.NodeID, err)
+		return keg.NodeId{}, nil, fmt.Errorf("unable to open keg: %w", err)
 	}
-	if node == nil {
-		return "", fmt.Errorf("invalid node ID %q: %w", opts.NodeID, keg.ErrInvalid)
+
+	node, err := t.resolveNode(ctx, k, opts.NodeID, opts.Exact)
+	if err != nil {
+		return keg.NodeId{}, nil, err
 	}
 
-	exists, err := k.Repo.HasNode(ctx, *node)
+	exists, err := k.Repo.HasNode(ctx, node)
 	if err != nil {
-		return "", fmt.Errorf("unable to inspect node: %w", err)
+		return keg.NodeId{}, nil, fmt.Errorf("unable to inspect node: %w", err)
 	}
 	if !exists {
-		return "", fmt.Errorf("node %s not found", node.Path())
+		return keg.NodeId{}, nil, keg.NewNodeNotFoundError(node)
 	}
 
-	stats, err := k.Repo.ReadStats(ctx, *node)
+	stats, err := k.Repo.ReadStats(ctx, node)
 	if err != nil {
 		if errors.Is(err, keg.ErrNotExist) {
 			stats = &keg.NodeStats{}
 		} else {
-			return "", fmt.Errorf("unable to read node stats: %w", err)
+			return keg.NodeId{}, nil, fmt.Errorf("unable to read node stats: %w", err)
 		}
 	}
-
-	return formatStatsOnlyYAML(ctx, stats), nil
+	return node, stats, nil
 }
@@ -0,0 +1,125 @@
+package tapper
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jlrickert/tapper/pkg/keg"
+)
+
+// DefaultActivityWeeks is the number of weekly activity buckets reported by
+// AllStats when no window is given.
+const DefaultActivityWeeks = 12
+
+// AllStatsOptions configures Tap.AllStats.
+type AllStatsOptions struct {
+	// Weeks is the number of weekly activity buckets. Defaults to
+	// DefaultActivityWeeks.
+	Weeks int
+}
+
+// KegAggregate is the summary of one configured keg in AllStats.
+type KegAggregate struct {
+	Alias string
+	Nodes int
+
+	// Tags is the number of distinct tags in the keg.
+	Tags int
+
+	// Activity counts nodes last updated in each week of the window, oldest
+	// first. The last bucket ends now.
+	Activity []int
+
+	Storage keg.NodeStorage
+
+	// Error describes why the keg could not be read. Other fields are zero.
+	Error string
+}
+
+// AllStatsResult merges the stats of every configured keg.
+type AllStatsResult struct {
+	Kegs []KegAggregate
+
+	// Nodes, Activity and Storage sum the readable kegs. Tags counts distinct
+	// tags across all of them.
+	Nodes    int
+	Tags     int
+	Activity []int
+	Storage  keg.NodeStorage
+}
+
+// AllStats gathers node, tag, activity and storage stats for every keg alias
+// in the user config, in alias order. A keg that cannot be read is reported
+// with Error set and left out of the totals.
+func (t *Tap) AllStats(ctx context.Context, opts AllStatsOptions) (*AllStatsResult, error) {
+	weeks := opts.Weeks
+	if weeks <= 0 {
+		weeks = DefaultActivityWeeks
+	}
+	now := t.Runtime.Clock().Now()
+	result := &AllStatsResult{Activity: make([]int, weeks)}
+	tags := make(map[string]struct{})
+
+	for _, alias := range t.ConfigService.Config(true).ListKegs() {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		agg := KegAggregate{Alias: alias}
+		kegTags, err := t.kegAggregate(ctx, alias, now, weeks, &agg)
+		if err != nil {
+			agg = KegAggregate{Alias: alias, Error: err.Error()}
+			result.Kegs = append(result.Kegs, agg)
+			continue
+		}
+		result.Kegs = append(result.Kegs, agg)
+
+		result.Nodes += agg.Nodes
+		for i, n := range agg.Activity {
+			result.Activity[i] += n
+		}
+		result.Storage.Content += agg.Storage.Content
+		result.Storage.Images += agg.Storage.Images
+		result.Storage.Attachments += agg.Storage.Attachments
+		for _, tag := range kegTags {
+			tags[tag] = struct{}{}
+		}
+	}
+	result.Tags = len(tags)
+	return result, nil
+}
+
+// kegAggregate fills agg for the keg behind alias and returns its tags.
+func (t *Tap) kegAggregate(ctx context.Context, alias string, now time.Time, weeks int, agg *KegAggregate) ([]string, error) {
+	k, err := t.KegService.Resolve(ctx, ResolveKegOptions{Root: t.Root, Keg: alias})
+	if err != nil {
+		return nil, err
+	}
+	dex, err := k.Dex(ctx)
+	if err != nil {
+		return nil, err
+	}
+	entries := dex.Nodes(ctx)
+	agg.Nodes = len(entries)
+	tags := dex.TagList(ctx)
+	agg.Tags = len(tags)
+
+	agg.Activity = make([]int, weeks)
+	week := 7 * 24 * time.Hour
+	for _, entry := range entries {
+		if entry.Updated.IsZero() || entry.Updated.After(now) {
+			continue
+		}
+		ago := int(now.Sub(entry.Updated) / week)
+		if ago < weeks {
+			agg.Activity[weeks-1-ago]++
+		}
+	}
+
+	report, err := k.Storage(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to measure storage: %w", err)
+	}
+	agg.Storage = report.Totals()
+	return tags, nil
+}
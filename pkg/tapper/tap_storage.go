@@ -0,0 +1,144 @@
+package tapper
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/jlrickert/tapper/pkg/keg"
+)
+
+// ErrQuotaExceeded is returned when an upload would exceed an enforced keg
+// storage quota.
+var ErrQuotaExceeded = errors.New("storage quota exceeded")
+
+// StorageOptions configures behavior for Tap.Storage.
+type StorageOptions struct {
+	KegTargetOptions
+
+	// NodeID limits the report to one node.
+	NodeID string
+
+	// Exact disables fuzzy title matching for non-numeric node arguments.
+	Exact bool
+}
+
+// StorageResult is a keg storage report together with the configured
+// quotas.
+type StorageResult struct {
+	// Nodes are ordered from largest to smallest.
+	Nodes  []keg.NodeStorage
+	Totals keg.NodeStorage
+	Quotas *keg.QuotasConfig
+}
+
+// Storage reports storage used by the nodes of a keg, split by content,
+// images and attachments.
+func (t *Tap) Storage(ctx context.Context, opts StorageOptions) (*StorageResult, error) {
+	k, err := t.resolveKeg(ctx, opts.KegTargetOptions)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open keg: %w", err)
+	}
+	var report *keg.StorageReport
+	if opts.NodeID != "" {
+		id, err := t.resolveNode(ctx, k, opts.NodeID, opts.Exact)
+		if err != nil {
+			return nil, err
+		}
+		usage, err := k.NodeStorage(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		report = &keg.StorageReport{Nodes: []keg.NodeStorage{usage}}
+	} else {
+		report, err = k.Storage(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+	cfg, err := k.Config(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read keg config: %w", err)
+	}
+
+	nodes := slices.Clone(report.Nodes)
+	slices.SortFunc(nodes, func(a, b keg.NodeStorage) int {
+		if c := cmp.Compare(b.Total(), a.Total()); c != 0 {
+			return c
+		}
+		return a.ID.Compare(b.ID)
+	})
+	return &StorageResult{Nodes: nodes, Totals: report.Totals(), Quotas: cfg.Quotas}, nil
+}
+
+// checkQuota compares an upload of size bytes to node id against the keg
+// quotas. Exceeded quotas produce a warning, or an ErrQuotaExceeded error
+// when the keg enforces them.
+func (t *Tap) checkQuota(ctx context.Context, k *keg.Keg, id keg.NodeId, size int64) error {
+	cfg, err := k.Config(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to read keg config: %w", err)
+	}
+	q := cfg.Quotas
+	if q == nil {
+		return nil
+	}
+
+	var exceeded []string
+	check := func(scope, raw string, used func() (int64, error)) error {
+		if raw == "" {
+			return nil
+		}
+		limit, err := keg.ParseByteSize(raw)
+		if err != nil {
+			return fmt.Errorf("invalid %s quota in keg config: %w", scope, err)
+		}
+		n, err := used()
+		if err != nil {
+			return err
+		}
+		if n+size > limit {
+			exceeded = append(exceeded, fmt.Sprintf("%s quota of %s (would use %s)",
+				scope, keg.FormatByteSize(limit), keg.FormatByteSize(n+size)))
+		}
+		return nil
+	}
+	errs := []error{
+		check("file", q.File, func() (int64, error) { return 0, nil }),
+		check("node", q.Node, func() (int64, error) {
+			usage, err := k.NodeStorage(ctx, id)
+			return usage.Total(), err
+		}),
+		check("keg", q.Keg, func() (int64, error) {
+			report, err := k.Storage(ctx)
+			if err != nil {
+				return 0, err
+			}
+			totals := report.Totals()
+			return totals.Total(), nil
+		}),
+	}
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+
+	for _, msg := range exceeded {
+		if q.Enforce {
+			return fmt.Errorf("upload to node %s exceeds the %s: %w", id.Path(), msg, ErrQuotaExceeded)
+		}
+		t.warn(fmt.Sprintf("warning: upload to node %s exceeds the %s", id.Path(), msg))
+	}
+	return nil
+}
+
+// warn writes msg to the error stream, falling back to the logger.
+func (t *Tap) warn(msg string) {
+	stream := t.Runtime.Stream()
+	if stream != nil && stream.Err != nil {
+		fmt.Fprintln(stream.Err, msg)
+		return
+	}
+	t.Runtime.Logger().Warn(msg)
+}
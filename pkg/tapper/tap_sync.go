@@ -0,0 +1,188 @@
+package tapper
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/jlrickert/tapper/pkg/keg"
+)
+
+// SyncOptions describes a `tap sync` run.
+type SyncOptions struct {
+	KegTargetOptions
+
+	// Force pushes local changes over registry nodes that changed since
+	// they were cached, instead of reporting them as conflicts.
+	Force bool
+
+	// Merge runs the mergeTool from the user config on each conflicting
+	// node and pushes the nodes it resolves.
+	Merge bool
+}
+
+// Sync replays the writes made to a registry keg while the registry was
+// unreachable.
+func (t *Tap) Sync(ctx context.Context, opts SyncOptions) (*keg.SyncResult, error) {
+	k, repo, err := t.registryKeg(ctx, opts.KegTargetOptions)
+	if err != nil {
+		return nil, err
+	}
+	result, err := repo.Sync(ctx, opts.Force)
+	if err != nil || !opts.Merge || len(result.Conflicts) == 0 {
+		return result, err
+	}
+
+	tool := strings.TrimSpace(t.ConfigService.Config(true).MergeTool())
+	if tool == "" {
+		return result, fmt.Errorf("no mergeTool set in the user config: %w", keg.ErrInvalid)
+	}
+	var merged []string
+	for _, c := range result.Conflicts {
+		id, err := keg.ParseNode(c.Node)
+		if err != nil {
+			return result, fmt.Errorf("invalid conflicting node id %q: %w", c.Node, err)
+		}
+		ok, err := t.mergeConflict(ctx, k, repo, tool, *id)
+		if err != nil {
+			return result, fmt.Errorf("unable to merge node %s: %w", c.Node, err)
+		}
+		if ok {
+			merged = append(merged, c.Node)
+		}
+	}
+	if len(merged) == 0 {
+		return result, nil
+	}
+
+	// Only the conflicting changes are still queued; push the merged ones.
+	again, err := repo.Sync(ctx, false)
+	if again != nil {
+		again.Pushed += result.Pushed
+		again.Merged = merged
+	}
+	return again, err
+}
+
+// mergeConflict runs tool on the base, local and registry content of id.
+// The tool resolves the conflict by exiting zero and leaving the merged
+// content, without conflict markers, in $MERGED; the result is then written
+// back with SetContent on top of the registry copy. It reports whether the
+// conflict was resolved.
+func (t *Tap) mergeConflict(ctx context.Context, k *keg.Keg, repo *keg.RegistryRepo, tool string, id keg.NodeId) (bool, error) {
+	v, err := repo.Conflict(ctx, id)
+	if err != nil {
+		return false, err
+	}
+
+	rt := t.Runtime
+	dir := filepath.Join(repo.Cache.Root, "merge", id.Path())
+	if err := rt.Mkdir(dir, 0o755, true); err != nil {
+		return false, fmt.Errorf("unable to create merge directory: %w", err)
+	}
+	defer func() {
+		_ = rt.Remove(dir, true)
+	}()
+
+	env := rt.Environ()
+	files := []struct {
+		name string
+		data []byte
+	}{
+		{"BASE", v.Base},
+		{"LOCAL", v.Local},
+		{"REMOTE", v.Remote},
+		{"MERGED", v.Local},
+	}
+	mergedPath := filepath.Join(dir, "MERGED.md")
+	for _, f := range files {
+		path := filepath.Join(dir, f.name+".md")
+		if err := rt.AtomicWriteFile(path, f.data, 0o644); err != nil {
+			return false, fmt.Errorf("unable to write %s version: %w", strings.ToLower(f.name), err)
+		}
+		host, err := hostPath(rt, path, false)
+		if err != nil {
+			return false, err
+		}
+		env = append(env, f.name+"="+host)
+	}
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", tool)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", tool)
+	}
+	stream := rt.Stream()
+	cmd.Stdin = stream.In
+	cmd.Stdout = stream.Out
+	cmd.Stderr = stream.Err
+	cmd.Env = env
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			rt.Logger().Info("merge tool did not resolve conflict", "node", id.Path(), "exit", exitErr.ExitCode())
+			return false, nil
+		}
+		return false, fmt.Errorf("unable to run merge tool: %w", err)
+	}
+
+	data, err := rt.ReadFile(mergedPath)
+	if err != nil {
+		return false, fmt.Errorf("unable to read merged content: %w", err)
+	}
+	if hasConflictMarkers(data) {
+		rt.Logger().Info("merged content still has conflict markers", "node", id.Path())
+		return false, nil
+	}
+	if err := repo.ResolveConflict(ctx, v); err != nil {
+		return false, err
+	}
+	if err := k.SetContent(ctx, id, data); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// hasConflictMarkers reports whether data has a line starting a conflict
+// block the way git writes them.
+func hasConflictMarkers(data []byte) bool {
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(make([]byte, 0, 64*1024), len(data)+1)
+	for sc.Scan() {
+		line := sc.Bytes()
+		if bytes.HasPrefix(line, []byte("<<<<<<< ")) || bytes.HasPrefix(line, []byte(">>>>>>> ")) {
+			return true
+		}
+	}
+	return false
+}
+
+// SyncPending lists the writes queued for a registry keg.
+func (t *Tap) SyncPending(ctx context.Context, opts KegTargetOptions) ([]keg.PendingWrite, error) {
+	_, repo, err := t.registryKeg(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return repo.Cache.Pending()
+}
+
+// registryKeg resolves a registry keg with a local cache and its
+// repository.
+func (t *Tap) registryKeg(ctx context.Context, opts KegTargetOptions) (*keg.Keg, *keg.RegistryRepo, error) {
+	k, err := t.resolveKeg(ctx, opts)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to open keg: %w", err)
+	}
+	repo, ok := keg.UnwrapRepo(k.Repo).(*keg.RegistryRepo)
+	if !ok || repo.Cache == nil {
+		return nil, nil, fmt.Errorf("only registry kegs can be synced: %w", keg.ErrNotSupported)
+	}
+	return k, repo, nil
+}
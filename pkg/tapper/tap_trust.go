@@ -0,0 +1,68 @@
+package tapper
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jlrickert/tapper/pkg/keg"
+)
+
+// TrustRepoOptions configures Tap.TrustRepo.
+type TrustRepoOptions struct {
+	KegTargetOptions
+
+	// Revoke removes the keg's trust instead of granting it.
+	Revoke bool
+}
+
+// TrustRepoResult describes the keg config settings trusted by TrustRepo.
+type TrustRepoResult struct {
+	// Key names the keg in the trustedKegs section of the user config.
+	Key string
+
+	// Settings is the YAML of the hooks, summarizer, embedder and image
+	// converter settings now trusted. It is empty when the keg config has
+	// none or trust was revoked.
+	Settings string
+}
+
+// TrustRepo records the commands and API settings in the resolved keg's
+// config as trusted in the user's own config, so hooks run and the
+// configured summarizer, embedder and image converter are used. Trust covers
+// the settings as they are now; once they change the keg must be trusted
+// again. Registry-backed kegs cannot be trusted.
+func (t *Tap) TrustRepo(ctx context.Context, opts TrustRepoOptions) (*TrustRepoResult, error) {
+	k, err := t.resolveKeg(ctx, opts.KegTargetOptions)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open keg: %w", err)
+	}
+	if _, ok := keg.UnwrapRepo(k.Repo).(*keg.RegistryRepo); ok {
+		return nil, fmt.Errorf("registry kegs cannot be trusted: %w", keg.ErrNotSupported)
+	}
+	key := trustKey(t.Runtime, k.Target)
+	if key == "" {
+		return nil, fmt.Errorf("keg has no location to trust: %w", keg.ErrNotSupported)
+	}
+	cfg, err := k.Config(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read keg config: %w", err)
+	}
+
+	own, err := t.ConfigService.PersonalConfig(false)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load user config: %w", err)
+	}
+	result := &TrustRepoResult{Key: key}
+	digest := ""
+	if !opts.Revoke {
+		digest = cfg.TrustDigest()
+		result.Settings = cfg.ExecSettings()
+	}
+	own.SetTrustedKeg(key, digest)
+	if err := own.Write(t.Runtime, t.ConfigService.personalConfigPath()); err != nil {
+		return nil, fmt.Errorf("unable to save user config: %w", err)
+	}
+	t.ConfigService.ResetCache()
+	k.TrustedConfig = digest
+	return result, nil
+}
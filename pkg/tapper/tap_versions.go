@@ -0,0 +1,73 @@
+package tapper
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jlrickert/tapper/pkg/keg"
+)
+
+type VersionsOptions struct {
+	KegTargetOptions
+	NodeID string
+
+	// Exact disables fuzzy title matching for non-numeric node arguments.
+	Exact bool
+}
+
+type RevertOptions struct {
+	KegTargetOptions
+	NodeID string
+
+	// Version is a version number or a unique prefix of its content hash.
+	Version string
+
+	// Exact disables fuzzy title matching for non-numeric node arguments.
+	Exact bool
+}
+
+// Versions lists prior content versions recorded for a node, oldest first.
+func (t *Tap) Versions(ctx context.Context, opts VersionsOptions) ([]keg.ContentVersion, error) {
+	k, id, err := t.resolveVersionsNode(ctx, opts.KegTargetOptions, opts.NodeID, opts.Exact)
+	if err != nil {
+		return nil, err
+	}
+	versions, err := k.ListVersions(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list versions: %w", err)
+	}
+	return versions, nil
+}
+
+// Revert rolls a node's content back to a recorded version. The replaced
+// content is recorded as a new version.
+func (t *Tap) Revert(ctx context.Context, opts RevertOptions) (keg.ContentVersion, error) {
+	k, id, err := t.resolveVersionsNode(ctx, opts.KegTargetOptions, opts.NodeID, opts.Exact)
+	if err != nil {
+		return keg.ContentVersion{}, err
+	}
+	version, err := k.RevertVersion(ctx, id, opts.Version)
+	if err != nil {
+		return keg.ContentVersion{}, fmt.Errorf("unable to revert node %s: %w", id.Path(), err)
+	}
+	return version, nil
+}
+
+func (t *Tap) resolveVersionsNode(ctx context.Context, targetOpts KegTargetOptions, nodeID string, exact bool) (*keg.Keg, keg.NodeId, error) {
+	k, err := t.resolveKeg(ctx, targetOpts)
+	if err != nil {
+		return nil, keg.NodeId{}, fmt.Errorf("unable to open keg: %w", err)
+	}
+	id, err := t.resolveNode(ctx, k, nodeID, exact)
+	if err != nil {
+		return nil, keg.NodeId{}, err
+	}
+	exists, err := k.Repo.HasNode(ctx, id)
+	if err != nil {
+		return nil, keg.NodeId{}, err
+	}
+	if !exists {
+		return nil, keg.NodeId{}, keg.NewNodeNotFoundError(id)
+	}
+	return k, id, nil
+}
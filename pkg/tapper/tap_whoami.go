@@ -0,0 +1,74 @@
+package tapper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jlrickert/tapper/pkg/registry"
+)
+
+// WhoamiOptions selects the registries Whoami asks.
+type WhoamiOptions struct {
+	// Registry is the registry name. Empty asks every configured registry.
+	Registry string
+}
+
+// RegistryIdentity is the account tapper acts as on one registry.
+type RegistryIdentity struct {
+	Registry string
+	Url      string
+
+	// Identity is nil when there is no token for the registry or the
+	// registry could not tell who it belongs to.
+	Identity *registry.Identity
+
+	// Err is why the registry could not be asked.
+	Err error
+}
+
+// Whoami asks registries which account their token authenticates as. With
+// a registry name a failure is returned as the error; otherwise each
+// registry reports its own.
+func (t *Tap) Whoami(ctx context.Context, opts WhoamiOptions) ([]RegistryIdentity, error) {
+	var regs []KegRegistry
+	if opts.Registry != "" {
+		reg, err := t.lookupRegistry(opts.Registry)
+		if err != nil {
+			return nil, err
+		}
+		regs = append(regs, reg)
+	} else {
+		regs = t.ConfigService.Config(true).Registries()
+	}
+
+	out := make([]RegistryIdentity, 0, len(regs))
+	for _, reg := range regs {
+		id := RegistryIdentity{Registry: reg.Name, Url: reg.Url}
+		if t.registryToken(ctx, reg) != "" {
+			id.Identity, id.Err = t.registryIdentity(ctx, reg.Name)
+		}
+		out = append(out, id)
+	}
+	if opts.Registry != "" {
+		switch id := out[0]; {
+		case id.Err != nil:
+			return nil, id.Err
+		case id.Identity == nil:
+			return nil, fmt.Errorf("not logged in to %s", id.Registry)
+		}
+	}
+	return out, nil
+}
+
+func (t *Tap) registryIdentity(ctx context.Context, name string) (*registry.Identity, error) {
+	client, err := t.registryClient(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	me, err := client.Me(ctx)
+	if errors.Is(err, registry.ErrUnauthorized) {
+		return nil, fmt.Errorf("token for %s was rejected; run tap auth login %s: %w", name, name, err)
+	}
+	return me, err
+}
@@ -0,0 +1,105 @@
+package tapper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdoutmetric"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// Telemetry exporters accepted in the telemetry section of the user config.
+const (
+	TelemetryExporterOTLP   = "otlp"
+	TelemetryExporterStdout = "stdout"
+	TelemetryExporterNone   = "none"
+)
+
+// defaultTelemetryServiceName is the service.name reported when the config
+// does not set one.
+const defaultTelemetryServiceName = "tapper"
+
+// Enabled reports whether tc selects an exporter. A nil config is disabled.
+func (tc *TelemetryConfig) Enabled() bool {
+	if tc == nil {
+		return false
+	}
+	exporter := strings.ToLower(strings.TrimSpace(tc.Exporter))
+	return exporter != "" && exporter != TelemetryExporterNone
+}
+
+// SetupTelemetry installs global OpenTelemetry tracer and meter providers
+// that export to the exporter selected by tc. The stdout exporter writes to
+// w, which callers point at the log destination so command output stays
+// clean. The returned shutdown flushes and stops both providers; it is a
+// no-op when telemetry is disabled.
+func SetupTelemetry(ctx context.Context, tc *TelemetryConfig, w io.Writer) (shutdown func(context.Context) error, err error) {
+	noop := func(context.Context) error { return nil }
+	if !tc.Enabled() {
+		return noop, nil
+	}
+
+	var (
+		spans   sdktrace.SpanExporter
+		metrics sdkmetric.Exporter
+	)
+	switch strings.ToLower(strings.TrimSpace(tc.Exporter)) {
+	case TelemetryExporterStdout:
+		spans, err = stdouttrace.New(stdouttrace.WithWriter(w))
+		if err != nil {
+			return noop, fmt.Errorf("unable to create stdout span exporter: %w", err)
+		}
+		metrics, err = stdoutmetric.New(stdoutmetric.WithWriter(w))
+		if err != nil {
+			return noop, fmt.Errorf("unable to create stdout metric exporter: %w", err)
+		}
+	case TelemetryExporterOTLP:
+		var traceOpts []otlptracehttp.Option
+		var metricOpts []otlpmetrichttp.Option
+		if endpoint := strings.TrimSpace(tc.Endpoint); endpoint != "" {
+			traceOpts = append(traceOpts, otlptracehttp.WithEndpointURL(endpoint))
+			metricOpts = append(metricOpts, otlpmetrichttp.WithEndpointURL(endpoint))
+		}
+		spans, err = otlptracehttp.New(ctx, traceOpts...)
+		if err != nil {
+			return noop, fmt.Errorf("unable to create OTLP span exporter: %w", err)
+		}
+		metrics, err = otlpmetrichttp.New(ctx, metricOpts...)
+		if err != nil {
+			return noop, fmt.Errorf("unable to create OTLP metric exporter: %w", err)
+		}
+	default:
+		return noop, fmt.Errorf("invalid telemetry.exporter %q in config: want otlp, stdout or none", tc.Exporter)
+	}
+
+	name := strings.TrimSpace(tc.ServiceName)
+	if name == "" {
+		name = defaultTelemetryServiceName
+	}
+	res := resource.NewSchemaless(attribute.String("service.name", name))
+
+	tracerProvider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(spans),
+		sdktrace.WithResource(res),
+	)
+	meterProvider := sdkmetric.NewMeterProvider(
+		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(metrics)),
+		sdkmetric.WithResource(res),
+	)
+	otel.SetTracerProvider(tracerProvider)
+	otel.SetMeterProvider(meterProvider)
+
+	return func(ctx context.Context) error {
+		return errors.Join(tracerProvider.Shutdown(ctx), meterProvider.Shutdown(ctx))
+	}, nil
+}
@@ -0,0 +1,58 @@
+package tapper_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/jlrickert/tapper/pkg/keg"
+	"github.com/jlrickert/tapper/pkg/tapper"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// TestSetupTelemetry_Stdout installs global OpenTelemetry providers, so it
+// must not run in parallel.
+func TestSetupTelemetry_Stdout(t *testing.T) {
+	fx := NewSandbox(t)
+	ctx := fx.Context()
+	tap := setupTapWithKeg(t, fx)
+	userCfg := `fallbackKeg: test
+telemetry:
+  exporter: stdout
+  serviceName: tap-test
+kegs: {}
+kegSearchPaths:
+  - /home/testuser/kegs
+`
+	require.NoError(t, fx.Runtime().AtomicWriteFile(tap.PathService.UserConfig(), []byte(userCfg), 0o644))
+
+	var out bytes.Buffer
+	tc := tap.ConfigService.Config(true).Telemetry()
+	require.True(t, tc.Enabled())
+	shutdown, err := tapper.SetupTelemetry(ctx, tc, &out)
+	require.NoError(t, err)
+	t.Cleanup(func() { otel.SetTracerProvider(noop.NewTracerProvider()) })
+
+	_, err = tap.Create(ctx, tapper.CreateOptions{Title: "Traced"})
+	require.NoError(t, err)
+	require.NoError(t, shutdown(ctx))
+
+	got := out.String()
+	require.Contains(t, got, `"Name":"keg.create"`)
+	require.Contains(t, got, `"Name":"repo.WriteNode"`)
+	require.Contains(t, got, "tap-test")
+	require.Contains(t, got, keg.MetricRepoCalls)
+}
+
+func TestSetupTelemetry_Disabled(t *testing.T) {
+	t.Parallel()
+	fx := NewSandbox(t)
+
+	shutdown, err := tapper.SetupTelemetry(fx.Context(), &tapper.TelemetryConfig{Exporter: "none"}, nil)
+	require.NoError(t, err)
+	require.NoError(t, shutdown(fx.Context()))
+
+	_, err = tapper.SetupTelemetry(fx.Context(), &tapper.TelemetryConfig{Exporter: "jaeger"}, nil)
+	require.ErrorContains(t, err, "invalid telemetry.exporter")
+}